@@ -73,6 +73,7 @@ const (
 	agentLogLevelEnvKey    = "ARIESD_LOG_LEVEL"
 	agentLogLevelFlagUsage = "Log Level." +
 		" Possible values [INFO] [DEBUG] [ERROR] [WARNING] [CRITICAL] . Defaults to INFO if not set." +
+		" Module levels can be set as well, e.g aries-framework/http=DEBUG:aries-framework/dispatcher=ERROR:INFO ." +
 		" Alternatively, this can be set with the following environment variable (in CSV format): " + agentLogLevelEnvKey
 
 	// http resolver url flag
@@ -373,8 +374,7 @@ func getResolverOpts(httpResolvers []string) ([]aries.Option, error) {
 				return nil, fmt.Errorf("invalid http resolver options found")
 			}
 
-			httpVDRI, err := httpbinding.New(r[1],
-				httpbinding.WithAccept(func(method string) bool { return method == r[0] }))
+			httpVDRI, err := httpbinding.New(r[1], httpbinding.WithMethods(r[0]))
 
 			if err != nil {
 				return nil, fmt.Errorf("failed to setup http resolver :  %w", err)
@@ -461,13 +461,10 @@ func getInboundSchemeToURLMap(schemeHostStr []string) (map[string]string, error)
 
 func setLogLevel(logLevel string) error {
 	if logLevel != "" {
-		level, err := log.ParseLevel(logLevel)
-		if err != nil {
+		if err := log.SetSpec(logLevel); err != nil {
 			return fmt.Errorf("failed to parse log level '%s' : %w", logLevel, err)
 		}
 
-		log.SetLevel("", level)
-
 		logger.Infof("logger level set to %s", logLevel)
 	}
 
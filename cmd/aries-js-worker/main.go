@@ -396,8 +396,7 @@ func getResolverOpts(httpResolvers []string) ([]aries.Option, error) {
 				return nil, fmt.Errorf("invalid http resolver options found")
 			}
 
-			httpVDRI, err := httpbinding.New(r[1],
-				httpbinding.WithAccept(func(method string) bool { return method == r[0] }))
+			httpVDRI, err := httpbinding.New(r[1], httpbinding.WithMethods(r[0]))
 
 			if err != nil {
 				return nil, fmt.Errorf("failed to setup http resolver :  %w", err)
@@ -412,12 +411,10 @@ func getResolverOpts(httpResolvers []string) ([]aries.Option, error) {
 
 func setLogLevel(logLevel string) error {
 	if logLevel != "" {
-		level, err := log.ParseLevel(logLevel)
-		if err != nil {
+		if err := log.SetSpec(logLevel); err != nil {
 			return err
 		}
 
-		log.SetLevel("", level)
 		logger.Infof("log level set to `%s`", logLevel)
 	}
 
@@ -529,6 +529,282 @@ const Aries = function (opts) {
             createKeySet: async function () {
                 return invoke(aw, pending, this.pkgname, "CreateKeySet", {}, "timeout while creating key set")
             },
+        },
+
+        /**
+         * Present Proof methods - the actions and the state changes of the exchanges are notified on the
+         * "present-proof_actions" and "present-proof_states" topics (see startNotifier), the actions are accepted
+         * or declined by their PIID.
+         */
+        presentproof: {
+            pkgname: "presentproof",
+
+            /**
+             * Returns the pending actions that have yet to be accepted or declined.
+             *
+             * @returns {Promise<Object>}
+             */
+            actions: async function () {
+                return invoke(aw, pending, this.pkgname, "Actions", {}, "timeout while getting the present proof actions")
+            },
+
+            /**
+             * Sends a request presentation (Verifier).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            sendRequestPresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "SendRequestPresentation", req, "timeout while sending request presentation")
+            },
+
+            /**
+             * Sends a propose presentation (Prover).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            sendProposePresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "SendProposePresentation", req, "timeout while sending propose presentation")
+            },
+
+            /**
+             * Accepts a request presentation by sending the presentation (Prover).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            acceptRequestPresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "AcceptRequestPresentation", req, "timeout while accepting request presentation")
+            },
+
+            /**
+             * Declines a request presentation (Prover).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            declineRequestPresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeclineRequestPresentation", req, "timeout while declining request presentation")
+            },
+
+            /**
+             * Accepts a propose presentation by sending a request presentation (Verifier).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            acceptProposePresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "AcceptProposePresentation", req, "timeout while accepting propose presentation")
+            },
+
+            /**
+             * Declines a propose presentation (Verifier).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            declineProposePresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeclineProposePresentation", req, "timeout while declining propose presentation")
+            },
+
+            /**
+             * Accepts a presentation (Verifier).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            acceptPresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "AcceptPresentation", req, "timeout while accepting presentation")
+            },
+
+            /**
+             * Declines a presentation (Verifier).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            declinePresentation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeclinePresentation", req, "timeout while declining presentation")
+            },
+
+            /**
+             * Pauses an action, the returned token resumes it later.
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            pauseAction: async function (req) {
+                return invoke(aw, pending, this.pkgname, "PauseAction", req, "timeout while pausing action")
+            },
+
+            /**
+             * Resumes an action paused with the token.
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            resumeAction: async function (req) {
+                return invoke(aw, pending, this.pkgname, "ResumeAction", req, "timeout while resuming action")
+            }
+        },
+
+        /**
+         * Issue Credential methods - the actions are notified on the "issue-credential_actions" topic
+         * (see startNotifier) with a preview of the offered and issued credentials, they are accepted
+         * or declined by their PIID.
+         */
+        issuecredential: {
+            pkgname: "issuecredential",
+
+            /**
+             * Returns the pending actions that have yet to be accepted or declined.
+             *
+             * @returns {Promise<Object>}
+             */
+            actions: async function () {
+                return invoke(aw, pending, this.pkgname, "Actions", {}, "timeout while getting the issue credential actions")
+            },
+
+            /**
+             * Sends an offer (Issuer).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            sendOffer: async function (req) {
+                return invoke(aw, pending, this.pkgname, "SendOffer", req, "timeout while sending offer")
+            },
+
+            /**
+             * Sends a proposal (Holder).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            sendProposal: async function (req) {
+                return invoke(aw, pending, this.pkgname, "SendProposal", req, "timeout while sending proposal")
+            },
+
+            /**
+             * Sends a request (Holder).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            sendRequest: async function (req) {
+                return invoke(aw, pending, this.pkgname, "SendRequest", req, "timeout while sending request")
+            },
+
+            /**
+             * Accepts a proposal by sending an offer (Issuer).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            acceptProposal: async function (req) {
+                return invoke(aw, pending, this.pkgname, "AcceptProposal", req, "timeout while accepting proposal")
+            },
+
+            /**
+             * Declines a proposal (Issuer).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            declineProposal: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeclineProposal", req, "timeout while declining proposal")
+            },
+
+            /**
+             * Accepts an offer (Holder).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            acceptOffer: async function (req) {
+                return invoke(aw, pending, this.pkgname, "AcceptOffer", req, "timeout while accepting offer")
+            },
+
+            /**
+             * Declines an offer (Holder).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            declineOffer: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeclineOffer", req, "timeout while declining offer")
+            },
+
+            /**
+             * Answers an offer with a new proposal (Holder).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            negotiateProposal: async function (req) {
+                return invoke(aw, pending, this.pkgname, "NegotiateProposal", req, "timeout while negotiating proposal")
+            },
+
+            /**
+             * Accepts a request by issuing the credentials (Issuer).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            acceptRequest: async function (req) {
+                return invoke(aw, pending, this.pkgname, "AcceptRequest", req, "timeout while accepting request")
+            },
+
+            /**
+             * Declines a request (Issuer).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            declineRequest: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeclineRequest", req, "timeout while declining request")
+            },
+
+            /**
+             * Accepts and stores the issued credentials (Holder).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            acceptCredential: async function (req) {
+                return invoke(aw, pending, this.pkgname, "AcceptCredential", req, "timeout while accepting credential")
+            },
+
+            /**
+             * Declines the issued credentials (Holder).
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            declineCredential: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeclineCredential", req, "timeout while declining credential")
+            },
+
+            /**
+             * Pauses an action, the returned token resumes it later.
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            pauseAction: async function (req) {
+                return invoke(aw, pending, this.pkgname, "PauseAction", req, "timeout while pausing action")
+            },
+
+            /**
+             * Resumes an action paused with the token.
+             *
+             * @param req - json document
+             * @returns {Promise<Object>}
+             */
+            resumeAction: async function (req) {
+                return invoke(aw, pending, this.pkgname, "ResumeAction", req, "timeout while resuming action")
+            }
         }
     }
 
@@ -0,0 +1,88 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package connectionupgrade
+
+import (
+	"errors"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/connectionupgrade"
+)
+
+// ProfileDIDCommV2 is the media type profile of the upgraded connections (see connection.Record.MediaTypeProfile).
+const ProfileDIDCommV2 = connectionupgrade.ProfileDIDCommV2
+
+var (
+	// ErrNotSupported is returned when the agent does not pack the DIDComm V2 envelope.
+	ErrNotSupported = connectionupgrade.ErrNotSupported
+	// ErrPeerNotSupported is returned when the other agent of the connection does not support the upgrade.
+	ErrPeerNotSupported = connectionupgrade.ErrPeerNotSupported
+	// ErrRejected is returned when the other agent of the connection rejected the upgrade.
+	ErrRejected = connectionupgrade.ErrRejected
+	// ErrTimeout is returned when the other agent of the connection did not reply in time.
+	ErrTimeout = connectionupgrade.ErrTimeout
+)
+
+// UpgradedProperties are the properties of the message event triggered once a connection was upgraded.
+type UpgradedProperties interface {
+	ConnectionID() string
+	Profile() string
+}
+
+// Provider contains dependencies for the protocol and is typically created by using aries.Context()
+type Provider interface {
+	Service(id string) (interface{}, error)
+}
+
+// ProtocolService defines the connection upgrade service.
+type ProtocolService interface {
+	service.Handler
+	RegisterMsgEvent(ch chan<- service.StateMsg) error
+	UnregisterMsgEvent(ch chan<- service.StateMsg) error
+	Upgrade(connectionID string) error
+}
+
+// Client enable access to the connection upgrade API. The connections established with the DIDComm V1 envelope
+// are upgraded to the DIDComm V2 media type profile when the other agent supports it.
+//
+// The message events report the connections upgraded by the other agents (see UpgradedProperties).
+type Client struct {
+	service ProtocolService
+}
+
+// New returns new instance of the connection upgrade client
+func New(ctx Provider) (*Client, error) {
+	raw, err := ctx.Service(connectionupgrade.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, ok := raw.(ProtocolService)
+	if !ok {
+		return nil, errors.New("cast service to connection upgrade service failed")
+	}
+
+	return &Client{service: svc}, nil
+}
+
+// RegisterMsgEvent registers a channel for the message events (see UpgradedProperties).
+func (c *Client) RegisterMsgEvent(ch chan<- service.StateMsg) error {
+	return c.service.RegisterMsgEvent(ch)
+}
+
+// UnregisterMsgEvent unregisters the channel of the message events.
+func (c *Client) UnregisterMsgEvent(ch chan<- service.StateMsg) error {
+	return c.service.UnregisterMsgEvent(ch)
+}
+
+// Upgrade upgrades the connection to the DIDComm V2 media type profile: the documents of the DIDs of both agents
+// are updated in place to accept the profile and the messages of the connection are then packed with the V2
+// envelope. It blocks until the other agent accepted the upgrade, the connection keeps the V1 envelope
+// if an error is returned (e.g ErrPeerNotSupported).
+func (c *Client) Upgrade(connectionID string) error {
+	return c.service.Upgrade(connectionID)
+}
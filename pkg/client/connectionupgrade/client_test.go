@@ -0,0 +1,61 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package connectionupgrade
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+)
+
+type protocolService struct {
+	service.Handler
+	service.Message
+	upgraded []string
+}
+
+func (s *protocolService) Upgrade(connectionID string) error {
+	if connectionID == "v1-only" {
+		return ErrPeerNotSupported
+	}
+
+	s.upgraded = append(s.upgraded, connectionID)
+
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	t.Run("get service error", func(t *testing.T) {
+		_, err := New(&provider.Provider{ServiceErr: errors.New("test err")})
+		require.EqualError(t, err, "test err")
+	})
+
+	t.Run("cast service error", func(t *testing.T) {
+		_, err := New(&provider.Provider{})
+		require.EqualError(t, err, "cast service to connection upgrade service failed")
+	})
+}
+
+func TestClient_Upgrade(t *testing.T) {
+	svc := &protocolService{}
+
+	client, err := New(&provider.Provider{ServiceValue: svc})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Upgrade("conn"))
+	require.Equal(t, []string{"conn"}, svc.upgraded)
+
+	require.True(t, errors.Is(client.Upgrade("v1-only"), ErrPeerNotSupported))
+
+	events := make(chan service.StateMsg)
+	require.NoError(t, client.RegisterMsgEvent(events))
+	require.NoError(t, client.UnregisterMsgEvent(events))
+}
@@ -0,0 +1,186 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package diagnostics exports the state of the agent for the observability dashboards: a JSON snapshot of
+// the connections, of the protocol instances in progress, of the queues, of the mediation and of the stores.
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/introduce"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	didstore "github.com/hyperledger/aries-framework-go/pkg/store/did"
+	vcstore "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
+)
+
+// provider contains dependencies for the diagnostics and is typically created by using aries.Context()
+type provider interface {
+	Service(id string) (interface{}, error)
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+}
+
+// instancesCounter is implemented by the protocol services counting their instances in progress by state.
+type instancesCounter interface {
+	InstancesByState() (map[string]int, error)
+}
+
+// queuesReporter is implemented by the services having internal queues.
+type queuesReporter interface {
+	QueueDepths() map[string]int
+}
+
+// router is implemented by the route coordination service.
+type router interface {
+	GetConnection() (string, error)
+	RoutedKeys() (int, error)
+}
+
+// Snapshot is the state of the agent at a point in time.
+type Snapshot struct {
+	// Time is the time of the snapshot.
+	Time time.Time `json:"time"`
+	// Connections are the numbers of connections by state.
+	Connections map[string]int `json:"connections"`
+	// ProtocolInstances are the numbers of protocol instances in progress by protocol and by state.
+	ProtocolInstances map[string]map[string]int `json:"protocolInstances"`
+	// QueueDepths are the numbers of items waiting in the queues, by protocol/queue.
+	QueueDepths map[string]int `json:"queueDepths"`
+	// Mediator is the mediation state of the agent.
+	Mediator Mediator `json:"mediator"`
+	// Stores are the numbers of records of the stores: the saved credentials and DIDs.
+	Stores map[string]int `json:"stores"`
+}
+
+// Mediator is the mediation state of the agent: its registration with a router and the keys it routes
+// as a router.
+type Mediator struct {
+	// RouterConnectionID is the connection with the router the agent is registered with, if any.
+	RouterConnectionID string `json:"routerConnectionID,omitempty"`
+	// RoutedKeys is the number of recipient keys routed by the agent for the agents registered with it.
+	RoutedKeys int `json:"routedKeys"`
+}
+
+// Client exports the state of the agent.
+type Client struct {
+	connections *connection.Lookup
+	credentials *vcstore.Store
+	dids        *didstore.Store
+	// services are the services reporting their state by name, the services not loaded in the
+	// framework are not reported
+	services map[string]interface{}
+}
+
+// New returns a new diagnostics client.
+func New(ctx provider) (*Client, error) {
+	connections, err := connection.NewLookup(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("diagnostics connection lookup: %w", err)
+	}
+
+	credentials, err := vcstore.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("diagnostics credential store: %w", err)
+	}
+
+	dids, err := didstore.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("diagnostics did store: %w", err)
+	}
+
+	services := map[string]interface{}{}
+
+	for _, name := range []string{issuecredential.Name, presentproof.Name, introduce.Introduce, route.Coordination} {
+		svc, err := ctx.Service(name)
+		if err != nil || svc == nil {
+			continue
+		}
+
+		services[name] = svc
+	}
+
+	return &Client{
+		connections: connections,
+		credentials: credentials,
+		dids:        dids,
+		services:    services,
+	}, nil
+}
+
+// Snapshot returns the current state of the agent.
+func (c *Client) Snapshot() (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Time:              time.Now().UTC(),
+		Connections:       map[string]int{},
+		ProtocolInstances: map[string]map[string]int{},
+		QueueDepths:       map[string]int{},
+		Stores:            map[string]int{},
+	}
+
+	records, err := c.connections.QueryConnectionRecords()
+	if err != nil {
+		return nil, fmt.Errorf("snapshot connections: %w", err)
+	}
+
+	for _, record := range records {
+		snapshot.Connections[record.State]++
+	}
+
+	for name, svc := range c.services {
+		if counter, ok := svc.(instancesCounter); ok {
+			instances, err := counter.InstancesByState()
+			if err != nil {
+				return nil, fmt.Errorf("snapshot %s instances: %w", name, err)
+			}
+
+			snapshot.ProtocolInstances[name] = instances
+		}
+
+		if reporter, ok := svc.(queuesReporter); ok {
+			for queue, depth := range reporter.QueueDepths() {
+				snapshot.QueueDepths[name+"/"+queue] = depth
+			}
+		}
+	}
+
+	if err := c.mediator(&snapshot.Mediator); err != nil {
+		return nil, err
+	}
+
+	snapshot.Stores["credentials"] = len(c.credentials.GetCredentials())
+	snapshot.Stores["dids"] = len(c.dids.GetDIDRecords())
+
+	return snapshot, nil
+}
+
+func (c *Client) mediator(mediator *Mediator) error {
+	r, ok := c.services[route.Coordination].(router)
+	if !ok {
+		return nil
+	}
+
+	connectionID, err := r.GetConnection()
+	if err != nil && !errors.Is(err, route.ErrRouterNotRegistered) {
+		return fmt.Errorf("snapshot router connection: %w", err)
+	}
+
+	routedKeys, err := r.RoutedKeys()
+	if err != nil {
+		return fmt.Errorf("snapshot routed keys: %w", err)
+	}
+
+	mediator.RouterConnectionID = connectionID
+	mediator.RoutedKeys = routedKeys
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/introduce"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	didstore "github.com/hyperledger/aries-framework-go/pkg/store/did"
+)
+
+type protocolService struct {
+	instances    map[string]int
+	instancesErr error
+	queues       map[string]int
+}
+
+func (s *protocolService) InstancesByState() (map[string]int, error) {
+	return s.instances, s.instancesErr
+}
+
+func (s *protocolService) QueueDepths() map[string]int {
+	return s.queues
+}
+
+type routeService struct {
+	connectionID  string
+	connectionErr error
+	routedKeys    int
+	routedKeysErr error
+}
+
+func (s *routeService) GetConnection() (string, error) {
+	return s.connectionID, s.connectionErr
+}
+
+func (s *routeService) RoutedKeys() (int, error) {
+	return s.routedKeys, s.routedKeysErr
+}
+
+func newProvider(services map[string]interface{}) *mockprovider.Provider {
+	return &mockprovider.Provider{
+		ServiceMap:                    services,
+		StorageProviderValue:          mem.NewProvider(),
+		TransientStorageProviderValue: mem.NewProvider(),
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("services not loaded in the framework are not reported", func(t *testing.T) {
+		c, err := New(newProvider(map[string]interface{}{presentproof.Name: &protocolService{}}))
+		require.NoError(t, err)
+		require.Len(t, c.services, 1)
+
+		c, err = New(&mockprovider.Provider{
+			ServiceErr:                    errors.New("service not found"),
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+		require.Empty(t, c.services)
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		_, err := New(&mockprovider.Provider{
+			StorageProviderValue:          &mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+			TransientStorageProviderValue: mem.NewProvider(),
+		})
+		require.EqualError(t, err, "diagnostics connection lookup: failed to open permanent store to create "+
+			"new connection recorder: open error")
+	})
+}
+
+func TestClient_Snapshot(t *testing.T) {
+	t.Run("agent state", func(t *testing.T) {
+		provider := newProvider(map[string]interface{}{
+			issuecredential.Name: &protocolService{instances: map[string]int{"offer-sent": 2}},
+			presentproof.Name: &protocolService{
+				instances: map[string]int{"request-sent": 1},
+				queues:    map[string]int{"verification": 3},
+			},
+			introduce.Introduce: &protocolService{instances: map[string]int{}},
+			route.Coordination:  &routeService{connectionID: "router-connection", routedKeys: 4},
+		})
+
+		recorder, err := connection.NewRecorder(provider)
+		require.NoError(t, err)
+
+		for id, state := range map[string]string{"conn-1": "completed", "conn-2": "completed", "conn-3": "requested"} {
+			require.NoError(t, recorder.SaveConnectionRecord(&connection.Record{ConnectionID: id, State: state}))
+		}
+
+		dids, err := didstore.New(provider)
+		require.NoError(t, err)
+		require.NoError(t, dids.SaveDID("my-did", &did.Doc{ID: "did:example:123"}))
+
+		c, err := New(provider)
+		require.NoError(t, err)
+
+		snapshot, err := c.Snapshot()
+		require.NoError(t, err)
+		require.False(t, snapshot.Time.IsZero())
+		require.Equal(t, map[string]int{"completed": 2, "requested": 1}, snapshot.Connections)
+		require.Equal(t, map[string]map[string]int{
+			issuecredential.Name: {"offer-sent": 2},
+			presentproof.Name:    {"request-sent": 1},
+			introduce.Introduce:  {},
+		}, snapshot.ProtocolInstances)
+		require.Equal(t, map[string]int{"present-proof/verification": 3}, snapshot.QueueDepths)
+		require.Equal(t, Mediator{RouterConnectionID: "router-connection", RoutedKeys: 4}, snapshot.Mediator)
+		require.Equal(t, map[string]int{"credentials": 0, "dids": 1}, snapshot.Stores)
+
+		snapshotJSON, err := json.Marshal(snapshot)
+		require.NoError(t, err)
+		require.Contains(t, string(snapshotJSON), `"mediator":{"routerConnectionID":"router-connection","routedKeys":4}`)
+	})
+
+	t.Run("agent not registered with a router", func(t *testing.T) {
+		c, err := New(newProvider(map[string]interface{}{
+			route.Coordination: &routeService{connectionErr: route.ErrRouterNotRegistered},
+		}))
+		require.NoError(t, err)
+
+		snapshot, err := c.Snapshot()
+		require.NoError(t, err)
+		require.Equal(t, Mediator{}, snapshot.Mediator)
+		require.Empty(t, snapshot.Connections)
+		require.Empty(t, snapshot.ProtocolInstances)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		c, err := New(newProvider(map[string]interface{}{
+			presentproof.Name: &protocolService{instancesErr: errors.New("iterator error")},
+		}))
+		require.NoError(t, err)
+
+		_, err = c.Snapshot()
+		require.EqualError(t, err, "snapshot present-proof instances: iterator error")
+
+		c, err = New(newProvider(map[string]interface{}{
+			route.Coordination: &routeService{connectionErr: errors.New("store error")},
+		}))
+		require.NoError(t, err)
+
+		_, err = c.Snapshot()
+		require.EqualError(t, err, "snapshot router connection: store error")
+
+		c, err = New(newProvider(map[string]interface{}{
+			route.Coordination: &routeService{routedKeysErr: errors.New("iterator error")},
+		}))
+		require.NoError(t, err)
+
+		_, err = c.Snapshot()
+		require.EqualError(t, err, "snapshot routed keys: iterator error")
+	})
+}
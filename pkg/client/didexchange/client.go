@@ -11,8 +11,7 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
@@ -31,6 +30,25 @@ const (
 // ErrConnectionNotFound is returned when connection not found
 var ErrConnectionNotFound = errors.New("connection not found")
 
+var errFunnelNotSupported = errors.New("the DID exchange service does not record the connection funnel")
+
+type (
+	// FunnelStage is a stage of the establishment of the connections created from the invitations of the agent.
+	FunnelStage = didexchange.FunnelStage
+	// FunnelEvent reports that a connection, or an invitation, reached a stage of the funnel.
+	FunnelEvent = didexchange.FunnelEvent
+	// FunnelMetrics is the instrumentation hook of the connection establishment funnel.
+	FunnelMetrics = didexchange.FunnelMetrics
+)
+
+// funnelService is implemented by the services recording the connection establishment funnel
+// (see didexchange.Service).
+type funnelService interface {
+	InvitationCreated(invitationID string)
+	SetFunnelMetrics(metrics didexchange.FunnelMetrics)
+	FunnelCounters() map[didexchange.FunnelStage]uint64
+}
+
 // provider contains dependencies for the DID exchange protocol and is typically created by using aries.Context()
 type provider interface {
 	Service(id string) (interface{}, error)
@@ -48,6 +66,7 @@ type Client struct {
 	legacyKMS       legacykms.KeyManager
 	serviceEndpoint string
 	connectionStore *connection.Recorder
+	idGenerator     idgen.Generator
 }
 
 // protocolService defines DID Exchange service.
@@ -64,6 +83,9 @@ type protocolService interface {
 	// CreateImplicitInvitation creates implicit invitation. Inviter DID is required, invitee DID is optional.
 	// If invitee DID is not provided new peer DID will be created for implicit invitation exchange request.
 	CreateImplicitInvitation(inviterLabel, inviterDID, inviteeLabel, inviteeDID string) (string, error)
+
+	// Hangup terminates the connection and notifies the other party
+	Hangup(connectionID, reason string) error
 }
 
 // New return new instance of didexchange client
@@ -100,6 +122,7 @@ func New(ctx provider) (*Client, error) {
 		legacyKMS:       ctx.LegacyKMS(),
 		serviceEndpoint: ctx.ServiceEndpoint(),
 		connectionStore: connectionStore,
+		idGenerator:     idgen.FromProvider(ctx),
 	}, nil
 }
 
@@ -121,7 +144,7 @@ func (c *Client) CreateInvitation(label string) (*Invitation, error) {
 	}
 
 	invitation := &didexchange.Invitation{
-		ID:              uuid.New().String(),
+		ID:              c.idGenerator.NewID(),
 		Label:           label,
 		RecipientKeys:   []string{sigPubKey},
 		ServiceEndpoint: serviceEndpoint,
@@ -138,6 +161,8 @@ func (c *Client) CreateInvitation(label string) (*Invitation, error) {
 		return nil, fmt.Errorf("failed to save invitation: %w", err)
 	}
 
+	c.invitationCreated(invitation.ID)
+
 	return &Invitation{invitation}, nil
 }
 
@@ -145,7 +170,7 @@ func (c *Client) CreateInvitation(label string) (*Invitation, error) {
 // so client can cross reference this invitation during did exchange protocol
 func (c *Client) CreateInvitationWithDID(label, did string) (*Invitation, error) {
 	invitation := &didexchange.Invitation{
-		ID:    uuid.New().String(),
+		ID:    c.idGenerator.NewID(),
 		Label: label,
 		DID:   did,
 		Type:  didexchange.InvitationMsgType,
@@ -156,12 +181,48 @@ func (c *Client) CreateInvitationWithDID(label, did string) (*Invitation, error)
 		return nil, fmt.Errorf("failed to save invitation with DID: %w", err)
 	}
 
+	c.invitationCreated(invitation.ID)
+
 	return &Invitation{invitation}, nil
 }
 
+// SetFunnelMetrics sets the instrumentation hook of the connection establishment funnel: the stages reached by
+// the connections created from the invitations of the agent (invitation created, request received, response sent
+// and completed) are reported with their timing. An error is returned if the service does not record the funnel.
+func (c *Client) SetFunnelMetrics(metrics FunnelMetrics) error {
+	f, ok := c.didexchangeSvc.(funnelService)
+	if !ok {
+		return errFunnelNotSupported
+	}
+
+	f.SetFunnelMetrics(metrics)
+
+	return nil
+}
+
+// FunnelCounters returns the number of invitations and connections which reached each stage of the connection
+// establishment funnel since the agent started, nil if the service does not record the funnel.
+func (c *Client) FunnelCounters() map[FunnelStage]uint64 {
+	if f, ok := c.didexchangeSvc.(funnelService); ok {
+		return f.FunnelCounters()
+	}
+
+	return nil
+}
+
+func (c *Client) invitationCreated(invitationID string) {
+	if f, ok := c.didexchangeSvc.(funnelService); ok {
+		f.InvitationCreated(invitationID)
+	}
+}
+
 // HandleInvitation handle incoming invitation and returns the connectionID that can be used to query the state
 // of did exchange protocol. Upon successful completion of did exchange protocol connection details will be used
 // for securing communication between agents.
+// When the invitation comes from an agent (identified by the public DID of the invitation) with which a connection
+// is already completed, a post state event with the "reuse-available" state ID and the existing connection ID is
+// triggered; the existing connection ID is returned if the framework reuses the connections
+// (see aries.WithConnectionReuse).
 func (c *Client) HandleInvitation(invitation *Invitation) (string, error) {
 	payload, err := json.Marshal(invitation)
 	if err != nil {
@@ -271,6 +332,17 @@ func (c *Client) GetConnectionAtState(connectionID, stateID string) (*Connection
 	}, nil
 }
 
+// Hangup terminates the completed connection for given id. The other party is notified with a hangup
+// message before the connection record is removed; reason is an optional explanation sent along with it.
+func (c *Client) Hangup(connectionID, reason string) error {
+	err := c.didexchangeSvc.Hangup(connectionID, reason)
+	if err != nil {
+		return fmt.Errorf("did exchange client - hangup: %w", err)
+	}
+
+	return nil
+}
+
 // RemoveConnection removes connection record for given id
 func (c *Client) RemoveConnection(connectionID string) error {
 	err := c.connectionStore.RemoveConnection(connectionID)
@@ -581,6 +581,70 @@ func TestClient_RemoveConnection(t *testing.T) {
 	})
 }
 
+func TestClient_Hangup(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		c, err := New(&mockprovider.Provider{
+			TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+			StorageProviderValue:          mockstore.NewMockStoreProvider(),
+			ServiceMap: map[string]interface{}{
+				didexchange.DIDExchange: &mocksvc.MockDIDExchangeSvc{},
+				route.Coordination:      &mockroute.MockRouteSvc{},
+			}})
+		require.NoError(t, err)
+
+		require.NoError(t, c.Hangup("connection-id", "bye"))
+	})
+
+	t.Run("test error from service", func(t *testing.T) {
+		c, err := New(&mockprovider.Provider{
+			TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+			StorageProviderValue:          mockstore.NewMockStoreProvider(),
+			ServiceMap: map[string]interface{}{
+				didexchange.DIDExchange: &mocksvc.MockDIDExchangeSvc{HangupErr: errors.New("hangup error")},
+				route.Coordination:      &mockroute.MockRouteSvc{},
+			}})
+		require.NoError(t, err)
+
+		err = c.Hangup("connection-id", "")
+		require.EqualError(t, err, "did exchange client - hangup: hangup error")
+	})
+}
+
+func TestClient_SetFunnelMetrics(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		svc, err := didexchange.New(&mockprotocol.MockProvider{
+			ServiceMap: map[string]interface{}{
+				route.Coordination: &mockroute.MockRouteSvc{},
+			},
+		})
+		require.NoError(t, err)
+
+		c, err := New(&mockprovider.Provider{
+			TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+			StorageProviderValue:          mockstore.NewMockStoreProvider(),
+			ServiceMap: map[string]interface{}{
+				didexchange.DIDExchange: svc,
+				route.Coordination:      &mockroute.MockRouteSvc{},
+			}})
+		require.NoError(t, err)
+
+		require.NoError(t, c.SetFunnelMetrics(nil))
+	})
+
+	t.Run("test funnel not supported", func(t *testing.T) {
+		c, err := New(&mockprovider.Provider{
+			TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+			StorageProviderValue:          mockstore.NewMockStoreProvider(),
+			ServiceMap: map[string]interface{}{
+				didexchange.DIDExchange: &mocksvc.MockDIDExchangeSvc{},
+				route.Coordination:      &mockroute.MockRouteSvc{},
+			}})
+		require.NoError(t, err)
+
+		require.EqualError(t, c.SetFunnelMetrics(nil), errFunnelNotSupported.Error())
+	})
+}
+
 func TestClient_HandleInvitation(t *testing.T) {
 	t.Run("test success", func(t *testing.T) {
 		c, err := New(&mockprovider.Provider{
@@ -6,6 +6,12 @@ SPDX-License-Identifier: Apache-2.0
 
 package didexchange
 
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
 // Event properties related api. This can be used to cast Generic event properties to DID Exchange specific props.
 type Event interface {
 	// connection ID
@@ -14,3 +20,18 @@ type Event interface {
 	// invitation ID
 	InvitationID() string
 }
+
+// EventV2 is the version 2 of the event properties (see aries.WithEventPayloadVersion).
+type EventV2 interface {
+	Event
+
+	// version of the event properties (service.EventPayloadV2)
+	PayloadVersion() service.EventPayloadVersion
+
+	// connection record at the state of the event
+	ConnectionRecord() *connection.Record
+
+	// resolved DID document of the other agent, the DID is not known before the request (inviter)
+	// or the response (invitee) is received
+	TheirDIDDoc() (*did.Doc, error)
+}
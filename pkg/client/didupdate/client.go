@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didupdate
+
+import (
+	"errors"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didupdate"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+)
+
+// UpdatedProperties are the properties of the message event triggered once the updated document
+// of the other agent of a connection is stored.
+type UpdatedProperties interface {
+	DID() string
+	Doc() *did.Doc
+}
+
+// Provider contains dependencies for the protocol and is typically created by using aries.Context()
+type Provider interface {
+	Service(id string) (interface{}, error)
+}
+
+// ProtocolService defines the didupdate service.
+type ProtocolService interface {
+	service.Handler
+	RegisterMsgEvent(ch chan<- service.StateMsg) error
+	UnregisterMsgEvent(ch chan<- service.StateMsg) error
+	UpdateDID(doc *did.Doc) error
+}
+
+// Client enable access to the DID update API. The updated documents of the peer DIDs of the agent are sent
+// to the other agents of their connections, signed with a key of the prior documents.
+//
+// The message events report the updated documents received from the other agents (see UpdatedProperties).
+type Client struct {
+	service ProtocolService
+}
+
+// New returns new instance of the didupdate client
+func New(ctx Provider) (*Client, error) {
+	raw, err := ctx.Service(didupdate.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, ok := raw.(ProtocolService)
+	if !ok {
+		return nil, errors.New("cast service to didupdate service failed")
+	}
+
+	return &Client{service: svc}, nil
+}
+
+// RegisterMsgEvent registers a channel for the message events (see UpdatedProperties).
+func (c *Client) RegisterMsgEvent(ch chan<- service.StateMsg) error {
+	return c.service.RegisterMsgEvent(ch)
+}
+
+// UnregisterMsgEvent unregisters the channel of the message events.
+func (c *Client) UnregisterMsgEvent(ch chan<- service.StateMsg) error {
+	return c.service.UnregisterMsgEvent(ch)
+}
+
+// UpdateDID stores the updated document of a peer DID of the agent (e.g after the agent was re-keyed)
+// and sends it to the other agent of each connection of the DID.
+func (c *Client) UpdateDID(doc *did.Doc) error {
+	return c.service.UpdateDID(doc)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didupdate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didupdate"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/legacykms"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+type protocolProvider struct {
+	*provider.Provider
+}
+
+func (p *protocolProvider) Messenger() service.Messenger {
+	return nil
+}
+
+func (p *protocolProvider) Signer() legacykms.Signer {
+	return &mockkms.CloseableKMS{}
+}
+
+func newClient(t *testing.T) *Client {
+	t.Helper()
+
+	svc, err := didupdate.New(&protocolProvider{
+		Provider: &provider.Provider{
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+			VDRIRegistryValue:             &mockvdri.MockVDRIRegistry{},
+		},
+	})
+	require.NoError(t, err)
+
+	client, err := New(&provider.Provider{ServiceValue: svc})
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestNew(t *testing.T) {
+	t.Run("get service error", func(t *testing.T) {
+		_, err := New(&provider.Provider{ServiceErr: errors.New("test err")})
+		require.EqualError(t, err, "test err")
+	})
+
+	t.Run("cast service error", func(t *testing.T) {
+		_, err := New(&provider.Provider{})
+		require.EqualError(t, err, "cast service to didupdate service failed")
+	})
+}
+
+func TestClient_UpdateDID(t *testing.T) {
+	client := newClient(t)
+
+	err := client.UpdateDID(&did.Doc{ID: "did:example:123"})
+	require.EqualError(t, err, "the document of a peer DID (numalgo 1) is mandatory")
+}
+
+func TestClient_RegisterMsgEvent(t *testing.T) {
+	client := newClient(t)
+
+	events := make(chan service.StateMsg)
+	require.NoError(t, client.RegisterMsgEvent(events))
+	require.NoError(t, client.UnregisterMsgEvent(events))
+}
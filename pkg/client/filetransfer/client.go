@@ -0,0 +1,109 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filetransfer
+
+import (
+	"errors"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/filetransfer"
+)
+
+// Transfer is the record of a file transfer.
+type Transfer = filetransfer.Transfer
+
+// ProgressProperties are the properties of the action event (an offered file) and of the message events,
+// which report the progress of the transfers.
+// Transfer returns the transfer updated by the event: its state and the number of transferred chunks.
+type ProgressProperties interface {
+	Transfer() Transfer
+}
+
+// Provider contains dependencies for the protocol and is typically created by using aries.Context()
+type Provider interface {
+	Service(id string) (interface{}, error)
+}
+
+// ProtocolService defines the filetransfer service.
+type ProtocolService interface {
+	service.DIDComm
+	service.CallbackEvent
+	SendFile(connectionID, name, mimeType string, content []byte) (string, error)
+	AcceptFile(transferID string) error
+	DeclineFile(transferID string) error
+	Resume(transferID string) error
+	Content(transferID string) ([]byte, error)
+	Transfer(transferID string) (*Transfer, error)
+	Transfers() ([]*Transfer, error)
+}
+
+// Client enable access to the file transfer API. The files are transferred over the connections, in chunks
+// encrypted for the other agent of the connection.
+//
+// An offered file is reported by an action event: continuing it accepts the file, stopping it declines the file.
+// The message events report the progress of the transfers (see ProgressProperties).
+type Client struct {
+	service.Event
+	// CallbackEvent allows consuming the events with callbacks rather than channels (e.g on the JS/WASM target)
+	service.CallbackEvent
+	service ProtocolService
+}
+
+// New returns new instance of the filetransfer client
+func New(ctx Provider) (*Client, error) {
+	raw, err := ctx.Service(filetransfer.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, ok := raw.(ProtocolService)
+	if !ok {
+		return nil, errors.New("cast service to filetransfer service failed")
+	}
+
+	return &Client{
+		Event:         svc,
+		CallbackEvent: svc,
+		service:       svc,
+	}, nil
+}
+
+// SendFile offers the file to the other agent of the connection and returns the ID of the transfer.
+// The chunks of the file are sent once the other agent accepts it.
+func (c *Client) SendFile(connectionID, name, mimeType string, content []byte) (string, error) {
+	return c.service.SendFile(connectionID, name, mimeType, content)
+}
+
+// AcceptFile accepts an offered file, e.g if the action event of the offer was not handled.
+func (c *Client) AcceptFile(transferID string) error {
+	return c.service.AcceptFile(transferID)
+}
+
+// DeclineFile declines an offered file.
+func (c *Client) DeclineFile(transferID string) error {
+	return c.service.DeclineFile(transferID)
+}
+
+// Resume resumes an interrupted transfer of a received file, only the missing chunks are requested.
+func (c *Client) Resume(transferID string) error {
+	return c.service.Resume(transferID)
+}
+
+// Content returns the content of a received file once its transfer is completed, i.e once its integrity is verified.
+func (c *Client) Content(transferID string) ([]byte, error) {
+	return c.service.Content(transferID)
+}
+
+// Transfer returns the transfer with the given ID.
+func (c *Client) Transfer(transferID string) (*Transfer, error) {
+	return c.service.Transfer(transferID)
+}
+
+// Transfers returns the transfers.
+func (c *Client) Transfers() ([]*Transfer, error) {
+	return c.service.Transfers()
+}
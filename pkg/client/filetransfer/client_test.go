@@ -0,0 +1,117 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filetransfer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/filetransfer"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	Alice = "Alice"
+	Bob   = "Bob"
+
+	connID = "connection-id"
+)
+
+type protocolProvider struct {
+	*provider.Provider
+	messenger service.Messenger
+}
+
+func (p *protocolProvider) Messenger() service.Messenger {
+	return p.messenger
+}
+
+func newClient(t *testing.T, messenger service.Messenger) *Client {
+	t.Helper()
+
+	p := &protocolProvider{
+		Provider: &provider.Provider{
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+		},
+		messenger: messenger,
+	}
+
+	recorder, err := connection.NewRecorder(p)
+	require.NoError(t, err)
+	require.NoError(t, recorder.SaveConnectionRecord(&connection.Record{
+		ConnectionID: connID, State: "completed", MyDID: Alice, TheirDID: Bob,
+	}))
+
+	svc, err := filetransfer.New(p)
+	require.NoError(t, err)
+
+	client, err := New(&provider.Provider{ServiceValue: svc})
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestNew(t *testing.T) {
+	t.Run("get service error", func(t *testing.T) {
+		_, err := New(&provider.Provider{ServiceErr: errors.New("test err")})
+		require.EqualError(t, err, "test err")
+	})
+
+	t.Run("cast service error", func(t *testing.T) {
+		_, err := New(&provider.Provider{})
+		require.EqualError(t, err, "cast service to filetransfer service failed")
+	})
+}
+
+func TestClient_SendFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messenger := serviceMocks.NewMockMessenger(ctrl)
+	messenger.EXPECT().Send(gomock.Any(), Alice, Bob).
+		Do(func(msg service.DIDCommMsgMap, _, _ string) {
+			require.Equal(t, filetransfer.OfferMsgType, msg.Type())
+		})
+
+	client := newClient(t, messenger)
+
+	events := make(chan service.StateMsg, 1)
+	require.NoError(t, client.RegisterMsgEvent(events))
+
+	transferID, err := client.SendFile(connID, "file.txt", "text/plain", []byte("content"))
+	require.NoError(t, err)
+
+	props, ok := (<-events).Properties.(ProgressProperties)
+	require.True(t, ok)
+	require.Equal(t, transferID, props.Transfer().ID)
+	require.Equal(t, filetransfer.StateOfferSent, props.Transfer().State)
+
+	transfer, err := client.Transfer(transferID)
+	require.NoError(t, err)
+	require.Equal(t, connID, transfer.ConnectionID)
+	require.Equal(t, int64(len("content")), transfer.File.Size)
+
+	transfers, err := client.Transfers()
+	require.NoError(t, err)
+	require.Equal(t, []*Transfer{transfer}, transfers)
+
+	// a sent file cannot be accepted, declined or resumed
+	require.Error(t, client.AcceptFile(transferID))
+	require.Error(t, client.DeclineFile(transferID))
+	require.Error(t, client.Resume(transferID))
+
+	_, err = client.Content(transferID)
+	require.Error(t, err)
+}
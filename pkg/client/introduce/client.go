@@ -11,8 +11,10 @@ import (
 	"fmt"
 
 	"github.com/hyperledger/aries-framework-go/pkg/client/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/client/presentproof"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/introduce"
+	protocol "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
 )
 
 // Provider contains dependencies for the introduce protocol and is typically created by using aries.Context()
@@ -52,9 +54,11 @@ func New(ctx Provider) (*Client, error) {
 }
 
 // SendProposal sends a proposal to the introducees (the client does not have a public Invitation).
+// The request-presentation of a recipient (the verifier) is attached to its proposal, the verifier sends it
+// to the other introducee (the prover) as soon as they are connected.
 func (c *Client) SendProposal(recipient1, recipient2 *introduce.Recipient) error {
-	proposal1 := introduce.CreateProposal(recipient1.To)
-	proposal2 := introduce.CreateProposal(recipient2.To)
+	proposal1 := introduce.CreateRecipientProposal(recipient1)
+	proposal2 := introduce.CreateRecipientProposal(recipient2)
 
 	introduce.WrapWithMetadataPIID(proposal1, proposal2)
 
@@ -70,7 +74,7 @@ func (c *Client) SendProposal(recipient1, recipient2 *introduce.Recipient) error
 
 // SendProposalWithInvitation sends a proposal to the introducee (the client has a public Invitation).
 func (c *Client) SendProposalWithInvitation(inv *didexchange.Invitation, recipient *introduce.Recipient) error {
-	proposal := introduce.CreateProposal(recipient.To)
+	proposal := introduce.CreateRecipientProposal(recipient)
 
 	introduce.WrapWithMetadataPublicInvitation(proposal, inv.Invitation)
 
@@ -109,6 +113,16 @@ func (c *Client) AcceptRequestWithRecipients(piID string, to *introduce.To, reci
 	return c.service.Continue(piID, WithRecipients(to, recipient))
 }
 
+// SendProposalWithPresentationRequest introduces the prover to the verifier, the verifier sends the request to
+// the prover as soon as they are connected so the proof exchange starts right after the introduction.
+func (c *Client) SendProposalWithPresentationRequest(prover, verifier *introduce.Recipient,
+	request *presentproof.RequestPresentation) error {
+	verifierWithRequest := *verifier
+	verifierWithRequest.RequestPresentation = (*protocol.RequestPresentation)(request)
+
+	return c.SendProposal(prover, &verifierWithRequest)
+}
+
 // Actions returns unfinished actions for the async usage
 func (c *Client) Actions() ([]introduce.Action, error) {
 	return c.service.Actions()
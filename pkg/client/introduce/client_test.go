@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hyperledger/aries-framework-go/pkg/client/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/client/presentproof"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	protocolDidexchange "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/introduce"
@@ -101,6 +102,51 @@ func TestClient_SendProposal(t *testing.T) {
 	})
 }
 
+func TestClient_SendProposalWithPresentationRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := introduceMocks.NewMockProvider(ctrl)
+
+	svc := introduceMocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().
+		HandleOutbound(gomock.Any(), "proverMyDID", "proverTheirDID").
+		DoAndReturn(func(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
+			proposal := &introduce.Proposal{}
+			require.NoError(t, msg.Decode(proposal))
+			require.Empty(t, proposal.Attachments)
+
+			return "", nil
+		})
+	svc.EXPECT().
+		HandleOutbound(gomock.Any(), "verifierMyDID", "verifierTheirDID").
+		DoAndReturn(func(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
+			proposal := &introduce.Proposal{}
+			require.NoError(t, msg.Decode(proposal))
+			require.Len(t, proposal.Attachments, 1)
+			require.Equal(t, introduce.RequestPresentationAttachID, proposal.Attachments[0].ID)
+
+			return "", nil
+		})
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	verifier := &introduce.Recipient{
+		MyDID:    "verifierMyDID",
+		TheirDID: "verifierTheirDID",
+	}
+
+	require.NoError(t, client.SendProposalWithPresentationRequest(&introduce.Recipient{
+		MyDID:    "proverMyDID",
+		TheirDID: "proverTheirDID",
+	}, verifier, &presentproof.RequestPresentation{Comment: "proof of age"}))
+
+	// the recipient of the caller is not modified
+	require.Nil(t, verifier.RequestPresentation)
+}
+
 func TestClient_SendProposalWithInvitation(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -82,7 +82,8 @@ func mockContext(agent string, tr map[string]chan payload, done chan struct{}) P
 	mProvider.EXPECT().OutboundDispatcher().Return(outbound)
 
 	provider := introduceServiceMocks.NewMockProvider(ctrl)
-	provider.EXPECT().StorageProvider().Return(storageProvider)
+	provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+	provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider())
 	provider.EXPECT().Service(gomock.Any()).Return(didSvc, nil)
 
 	msgSvc, err := messenger.NewMessenger(mProvider)
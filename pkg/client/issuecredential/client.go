@@ -11,6 +11,8 @@ import (
 
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	docverifiable "github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
 )
 
 var (
@@ -38,8 +40,49 @@ type (
 	IssueCredential issuecredential.IssueCredential
 	// Action contains helpful information about action
 	Action issuecredential.Action
+	// DuplicateDecision describes how the Holder handles received credentials that have near-duplicates
+	// (same issuer, types and subject ID) already stored.
+	DuplicateDecision = issuecredential.DuplicateDecision
+	// ValidityPeriod is the validity window of the credential, requested by the proposal and negotiated by the offer.
+	ValidityPeriod = issuecredential.ValidityPeriod
 )
 
+const (
+	// DuplicateReplace removes the stored near-duplicates before saving the received credential
+	DuplicateReplace = issuecredential.DuplicateReplace
+	// DuplicateKeepBoth saves the received credential and keeps the stored near-duplicates
+	DuplicateKeepBoth = issuecredential.DuplicateKeepBoth
+	// DuplicateReject rejects the received credential
+	DuplicateReject = issuecredential.DuplicateReject
+)
+
+// ConnectionProperties are the properties of all the action events.
+// ConnectionID returns the ID of the connection the exchange is associated with, empty if the DIDs
+// of the exchange are not those of a connection.
+type ConnectionProperties interface {
+	ConnectionID() string
+}
+
+// DuplicatesProperties are the properties of the IssueCredential action event.
+// Duplicates returns the stored credentials which are near-duplicates of the received credentials.
+type DuplicatesProperties interface {
+	Duplicates() []*verifiable.CredentialRecord
+}
+
+// CredentialsProperties are the properties of the IssueCredential action event added by the version 2
+// of the event properties (see aries.WithEventPayloadVersion).
+// Credentials returns the parsed credentials of the received IssueCredential message.
+type CredentialsProperties interface {
+	Credentials() []*docverifiable.Credential
+}
+
+// ValidityPeriodProperties are the properties of the ProposeCredential, OfferCredential and RequestCredential
+// action events. ValidityPeriod returns the validity window requested by the proposal, offered by the offer or,
+// for the request, negotiated by the offer: the Issuer builds the credentials within it (see ValidityPeriod.Apply).
+type ValidityPeriodProperties interface {
+	ValidityPeriod() *ValidityPeriod
+}
+
 // Provider contains dependencies for the issuecredential protocol and is typically created by using aries.Context()
 type Provider interface {
 	Service(id string) (interface{}, error)
@@ -48,14 +91,19 @@ type Provider interface {
 // ProtocolService defines the issuecredential service.
 type ProtocolService interface {
 	service.DIDComm
+	service.CallbackEvent
 	Actions() ([]issuecredential.Action, error)
 	ActionContinue(piID string, opt issuecredential.Opt) error
 	ActionStop(piID string, err error) error
+	ActionPause(piID string) (string, error)
+	ActionResume(token string) (*issuecredential.Action, error)
 }
 
 // Client enable access to issuecredential API
 type Client struct {
 	service.Event
+	// CallbackEvent allows consuming the events with callbacks rather than channels (e.g on the JS/WASM target)
+	service.CallbackEvent
 	service ProtocolService
 }
 
@@ -72,8 +120,9 @@ func New(ctx Provider) (*Client, error) {
 	}
 
 	return &Client{
-		Event:   svc,
-		service: svc,
+		Event:         svc,
+		CallbackEvent: svc,
+		service:       svc,
 	}, nil
 }
 
@@ -92,6 +141,24 @@ func (c *Client) Actions() ([]Action, error) {
 	return result, nil
 }
 
+// PauseAction parks the action by the piID (e.g the user has to find a document before accepting an offer)
+// and returns the token the action is resumed with later, even after a restart of the agent.
+// A paused action can not be accepted or declined until it is resumed (see ResumeAction).
+func (c *Client) PauseAction(piID string) (string, error) {
+	return c.service.ActionPause(piID)
+}
+
+// ResumeAction resumes the action paused with the token and returns it, the action is then accepted or declined
+// by its PIID. The token can only be used once.
+func (c *Client) ResumeAction(token string) (*Action, error) {
+	action, err := c.service.ActionResume(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*Action)(action), nil
+}
+
 // SendOffer is used by the Issuer to send an offer.
 func (c *Client) SendOffer(offer *OfferCredential, myDID, theirDID string) error {
 	if offer == nil {
@@ -126,6 +193,7 @@ func (c *Client) SendRequest(request *RequestCredential, myDID, theirDID string)
 }
 
 // AcceptProposal is used when the Issuer is willing to accept the proposal.
+// The ValidityPeriod of the offer confirms or adjusts the validity window requested by the proposal.
 // NOTE: For async usage.
 func (c *Client) AcceptProposal(piID string, msg *OfferCredential) error {
 	return c.service.ActionContinue(piID, WithOfferCredential(msg))
@@ -155,6 +223,7 @@ func (c *Client) NegotiateProposal(piID string, msg *ProposeCredential) error {
 }
 
 // AcceptRequest is used when the Issuer is willing to accept the request.
+// The credentials must be valid within the validity window negotiated by the offer (if any).
 // NOTE: For async usage.
 func (c *Client) AcceptRequest(piID string, msg *IssueCredential) error {
 	return c.service.ActionContinue(piID, WithIssueCredential(msg))
@@ -172,6 +241,13 @@ func (c *Client) AcceptCredential(piID string, names ...string) error {
 	return c.service.ActionContinue(piID, WithFriendlyNames(names...))
 }
 
+// ResolveDuplicateCredential is used when the Holder is willing to accept the IssueCredential that has
+// near-duplicates in the store (see DuplicatesProperties and Action.Duplicates).
+// NOTE: For async usage.
+func (c *Client) ResolveDuplicateCredential(piID string, decision DuplicateDecision, names ...string) error {
+	return c.service.ActionContinue(piID, WithDuplicateDecision(decision, names...))
+}
+
 // DeclineCredential is used when the Holder does not want to accept the IssueCredential.
 // NOTE: For async usage.
 func (c *Client) DeclineCredential(piID, reason string) error {
@@ -204,3 +280,10 @@ func WithIssueCredential(msg *IssueCredential) issuecredential.Opt {
 func WithFriendlyNames(names ...string) issuecredential.Opt {
 	return issuecredential.WithFriendlyNames(names...)
 }
+
+// WithDuplicateDecision allows providing a decision about the received credentials that have near-duplicates
+// along with the names for the credentials.
+// USAGE: This function should be used when the Holder receives IssueCredential message with duplicates
+func WithDuplicateDecision(decision DuplicateDecision, names ...string) issuecredential.Opt {
+	return issuecredential.WithDuplicateDecision(decision, names...)
+}
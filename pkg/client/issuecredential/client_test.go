@@ -271,6 +271,22 @@ func TestClient_AcceptCredential(t *testing.T) {
 	require.NoError(t, client.AcceptCredential("PIID"))
 }
 
+func TestClient_ResolveDuplicateCredential(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().ActionContinue("PIID", gomock.Any()).Return(nil)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	require.NoError(t, client.ResolveDuplicateCredential("PIID", DuplicateReplace, "name"))
+}
+
 func TestClient_DeclineCredential(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -286,3 +302,66 @@ func TestClient_DeclineCredential(t *testing.T) {
 
 	require.NoError(t, client.DeclineCredential("PIID", "the reason"))
 }
+
+func TestClient_PauseAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().ActionPause("PIID").Return("token", nil)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	token, err := client.PauseAction("PIID")
+	require.NoError(t, err)
+	require.Equal(t, "token", token)
+}
+
+func TestClient_ResumeAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().ActionResume("token").Return(&issuecredential.Action{PIID: "PIID"}, nil)
+	svc.EXPECT().ActionResume("unknown").Return(nil, issuecredential.ErrUnknownResumeToken)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	action, err := client.ResumeAction("token")
+	require.NoError(t, err)
+	require.Equal(t, "PIID", action.PIID)
+
+	_, err = client.ResumeAction("unknown")
+	require.True(t, errors.Is(err, issuecredential.ErrUnknownResumeToken))
+}
+
+func TestClient_Callbacks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().RegisterActionCallback(gomock.Any()).Return(nil)
+	svc.EXPECT().UnregisterActionCallback()
+	svc.EXPECT().RegisterMsgCallback(gomock.Any()).Return(func() {}, nil)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	require.NoError(t, client.RegisterActionCallback(func(service.DIDCommAction) {}))
+	client.UnregisterActionCallback()
+
+	unregister, err := client.RegisterMsgCallback(func(service.StateMsg) {})
+	require.NoError(t, err)
+	require.NotNil(t, unregister)
+}
@@ -0,0 +1,1224 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package migration rotates the keys of the DIDs of a long-lived agent without downtime: a new key is created
+// for each key of the agent's DIDs, registered with the mediator and published in the updated DID document.
+// The documents of the peer DIDs of the agent's connections are sent to the other agents of the connections
+// (see the DID update protocol), the documents of the public DIDs are published again with the VDRI registry.
+// The prior keys are retired once the other agents switched to the new keys.
+//
+// The keys are created by the KMS which created the prior keys: the legacy KMS, or the KMS of the framework for
+// the keys indexed with their KMS key ID (see keyid.Store). The key of the DIDComm service of the peer DIDs
+// (numalgo 1) used by the completed connections of the agent is migrated, as well as all the indexed keys of the
+// other DIDs of the agent (e.g created with the VDRI registry). The DIDs whose method does not support updates
+// (see vdriapi.MethodCapabilities) can't be published again and are not migrated.
+//
+// The other agents which don't implement the DID update protocol (see discoverfeatures) never receive the updated
+// documents, their connections are rotated to a new peer DID with the new key instead: the messages sent over
+// these connections carry a from_prior JWT signed with the prior key until the other agents use the new DID
+// (see decorator.FromPrior). The connections are rotated if the discover features service is loaded.
+package migration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didupdate"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/discoverfeatures"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/hyperledger/aries-framework-go/pkg/store/keyid"
+	"github.com/hyperledger/aries-framework-go/pkg/vdri/peer"
+)
+
+const (
+	// Namespace is the namespace of the store of the migration jobs.
+	Namespace = "keymigration"
+
+	jobKeyPrefix = "job_"
+
+	// JobRunning is the state of a job in progress, or interrupted before it completed (e.g the agent stopped).
+	JobRunning = "running"
+	// JobCompleted is the state of a job having migrated all the DIDs.
+	JobCompleted = "completed"
+	// JobFailed is the state of a job stopped by an error, it may be resumed.
+	JobFailed = "failed"
+
+	// StepPending is the step of a DID not migrated yet.
+	StepPending = "pending"
+	// StepKeyCreated is the step of a DID once its new key is created by the KMS.
+	StepKeyCreated = "key-created"
+	// StepRouted is the step of a DID once its new key is registered with the mediator (if any).
+	StepRouted = "routed"
+	// StepRotating is the step of a peer DID once the connections to rotate to a new DID are known, i.e the
+	// connections whose other agents don't implement the DID update protocol (see DIDMigration.Rotated).
+	StepRotating = "rotating"
+	// StepRotated is the step of a peer DID once these connections are rotated to the new DID.
+	StepRotated = "rotated"
+	// StepStored is the step of a DID whose updated document is stored but not sent to some connections yet
+	// (see DIDMigration.Pending), it is sent to them when the job is resumed.
+	StepStored = "stored"
+	// StepPublished is the step of a migrated DID: its updated document is stored and sent to the connections.
+	StepPublished = "published"
+	// StepRetired is the step of a migrated DID once its prior key is retired (see Client.Retire).
+	StepRetired = "retired"
+
+	stateNameCompleted = "completed"
+	peerMethod         = "peer"
+
+	// the signature algorithm of the from_prior JWTs, the keys of the peer DIDs are Ed25519 keys
+	fromPriorAlgorithm = "EdDSA"
+)
+
+// keyTypes are the types of the KMS keys of the public keys of the DID documents, by type of public key.
+// nolint:gochecknoglobals
+var keyTypes = map[string]kms.KeyType{
+	"Ed25519VerificationKey2018":        kms.ED25519Type,
+	"EcdsaSecp256k1VerificationKey2019": kms.ECDSASecp256k1Type,
+}
+
+var logger = log.New("aries-framework/client/migration")
+
+var (
+	// ErrJobNotFound is returned when resuming an unknown job.
+	ErrJobNotFound = errors.New("migration job not found")
+	// ErrJobInProgress is returned when a job is started while another one is running.
+	ErrJobInProgress = errors.New("a migration job is in progress")
+	// ErrJobCompleted is returned when resuming a completed job.
+	ErrJobCompleted = errors.New("migration job is completed")
+	// ErrJobNotCompleted is returned when retiring the prior keys of a job which is not completed.
+	ErrJobNotCompleted = errors.New("migration job is not completed")
+	// ErrUpdatesPending is returned when the updated documents could not be sent to some connections,
+	// the job is failed and the updates are sent again when it is resumed.
+	ErrUpdatesPending = errors.New("updated documents not sent to all the connections")
+)
+
+// provider contains dependencies for the migration and is typically created by using aries.Context()
+type provider interface {
+	Service(id string) (interface{}, error)
+	LegacyKMS() legacykms.KeyManager
+	KMS() kms.KeyManager
+	VDRIRegistry() vdriapi.Registry
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+	Messenger() service.Messenger
+}
+
+// didUpdater is implemented by the DID update service.
+type didUpdater interface {
+	UpdateDID(doc *did.Doc) error
+	SendUpdate(update *didupdate.Update, connectionIDs ...string) error
+}
+
+// pubKeyExporter is implemented by the KMSs exporting the public keys (e.g the local and the remote KMS).
+type pubKeyExporter interface {
+	ExportPubKeyBytes(keyID string) ([]byte, error)
+}
+
+// keyDeleter is implemented by the KMSs deleting the keys (e.g the local KMS).
+type keyDeleter interface {
+	Delete(keyID string) error
+}
+
+// featureQuerier is implemented by the discover features service.
+type featureQuerier interface {
+	Query(query, myDID, theirDID string) ([]*discoverfeatures.Protocol, error)
+}
+
+// fromPriorSetter is implemented by the messenger adding the from_prior JWTs to the messages of the rotated DIDs.
+type fromPriorSetter interface {
+	SetFromPrior(myDID, theirDID, fromPrior string) error
+}
+
+// router is implemented by the route coordination service.
+type router interface {
+	GetConnection() (string, error)
+	AddKey(recKey string) error
+	RemoveKey(recKey string) error
+}
+
+// Job is a key migration job, it is persisted after each step so that it can be resumed (see Client.Resume).
+type Job struct {
+	ID      string          `json:"id"`
+	State   string          `json:"state"`
+	DIDs    []*DIDMigration `json:"dids"`
+	Error   string          `json:"error,omitempty"`
+	Created time.Time       `json:"created"`
+	Updated time.Time       `json:"updated"`
+}
+
+// DIDMigration is the migration of a key of a DID of the agent.
+type DIDMigration struct {
+	DID string `json:"did"`
+	// Public is true for the DIDs other than peer DIDs, their updated documents are published with the VDRI
+	// registry instead of being sent to the connections.
+	Public bool `json:"public,omitempty"`
+	// PriorKey is the base58 encoded key of the DID before the migration.
+	PriorKey string `json:"priorKey"`
+	// PriorKeyID is the KMS key ID of the prior key, empty for the keys of the legacy KMS (identified by their value).
+	PriorKeyID string `json:"priorKeyID,omitempty"`
+	// KeyType is the type of the KMS keys, empty for the keys of the legacy KMS.
+	KeyType kms.KeyType `json:"keyType,omitempty"`
+	// NewKey is the base58 encoded key the DID is migrated to, once created.
+	NewKey string `json:"newKey,omitempty"`
+	// NewKeyID is the KMS key ID of the new key, empty for the keys of the legacy KMS.
+	NewKeyID string `json:"newKeyID,omitempty"`
+	Step     string `json:"step"`
+	// Update is the signed update of the document, kept until it is sent to all the connections.
+	Update *didupdate.Update `json:"update,omitempty"`
+	// Pending are the IDs of the connections the update is still to be sent to, their other agents keep using
+	// the prior key which remains valid.
+	Pending []string `json:"pending,omitempty"`
+	// RotatedDID is the new peer DID with the new key the connections whose other agents don't implement the
+	// DID update protocol are rotated to, Rotated are the IDs of these connections.
+	RotatedDID string   `json:"rotatedDID,omitempty"`
+	Rotated    []string `json:"rotated,omitempty"`
+}
+
+// Progress is the event sent after each step of a job and when the job completes or fails.
+type Progress struct {
+	JobID string
+	// State is the state of the job.
+	State string
+	// DID and Step are the DID migrated by the step and its new step, empty for the job events.
+	DID  string
+	Step string
+	// Migrated is the number of DIDs migrated so far, out of Total.
+	Migrated int
+	Total    int
+	Error    string
+}
+
+// Client runs the key migration jobs. The prior keys are kept by the KMS and remain registered with the mediator,
+// so that the messages packed with them are still delivered while the other agents switch to the new keys,
+// until they are retired (see Retire). A single job runs at a time.
+type Client struct {
+	legacyKMS    legacykms.KeyManager
+	kms          kms.KeyManager
+	vdriRegistry vdriapi.Registry
+	updater      didUpdater
+	// router is nil if the route coordination service is not loaded
+	router router
+	// features is nil if the discover features service is not loaded, the connections are not rotated then
+	features    featureQuerier
+	fromPrior   fromPriorSetter
+	connections *connection.Recorder
+	keys        *keyid.Store
+	store       storage.Store
+	mu          sync.Mutex
+	running     bool
+	eventsMu    sync.RWMutex
+	events      []chan<- Progress
+	idGenerator idgen.Generator
+}
+
+// New returns a new key migration client.
+func New(ctx provider) (*Client, error) {
+	raw, err := ctx.Service(didupdate.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	updater, ok := raw.(didUpdater)
+	if !ok {
+		return nil, errors.New("cast service to didupdate service failed")
+	}
+
+	connections, err := connection.NewRecorder(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migration connection recorder: %w", err)
+	}
+
+	keys, err := keyid.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migration key ID store: %w", err)
+	}
+
+	store, err := ctx.StorageProvider().OpenStore(Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("open migration store: %w", err)
+	}
+
+	client := &Client{
+		legacyKMS:    ctx.LegacyKMS(),
+		kms:          ctx.KMS(),
+		vdriRegistry: ctx.VDRIRegistry(),
+		updater:      updater,
+		connections:  connections,
+		keys:         keys,
+		store:        store,
+		idGenerator:  idgen.FromProvider(ctx),
+	}
+
+	if raw, err := ctx.Service(route.Coordination); err == nil {
+		if r, ok := raw.(router); ok {
+			client.router = r
+		}
+	}
+
+	if setter, ok := ctx.Messenger().(fromPriorSetter); ok {
+		if raw, err := ctx.Service(discoverfeatures.Name); err == nil {
+			if f, ok := raw.(featureQuerier); ok {
+				client.features, client.fromPrior = f, setter
+			}
+		}
+	}
+
+	return client, nil
+}
+
+// RegisterProgressEvent registers a channel for the progress events of the jobs.
+// The events are sent synchronously, the channel must be consumed for the jobs to make progress.
+func (c *Client) RegisterProgressEvent(ch chan<- Progress) error {
+	if ch == nil {
+		return errors.New("channel is mandatory")
+	}
+
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	c.events = append(c.events, ch)
+
+	return nil
+}
+
+// UnregisterProgressEvent unregisters a channel of the progress events.
+func (c *Client) UnregisterProgressEvent(ch chan<- Progress) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	for i := range c.events {
+		if c.events[i] == ch {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// Migrate starts a job migrating the keys of the DIDs of the agent (see the package doc) and returns it once done.
+// If the job fails, the job (with its ID) is returned along with the error and may be resumed: ErrUpdatesPending
+// is returned once all the DIDs are migrated if the updated documents could not be sent to some connections.
+func (c *Client) Migrate() (*Job, error) {
+	if err := c.start(); err != nil {
+		return nil, err
+	}
+	defer c.stop()
+
+	migrations, err := c.plan()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	job := &Job{ID: c.idGenerator.NewID(), State: JobRunning, DIDs: migrations, Created: now, Updated: now}
+
+	if err := c.saveJob(job); err != nil {
+		return nil, err
+	}
+
+	return job, c.run(job)
+}
+
+// Resume resumes a failed or interrupted job from the last step completed for each DID, the updated documents
+// are sent again to the connections they could not be sent to.
+func (c *Client) Resume(jobID string) (*Job, error) {
+	if err := c.start(); err != nil {
+		return nil, err
+	}
+	defer c.stop()
+
+	job, err := c.Job(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.State == JobCompleted {
+		return job, ErrJobCompleted
+	}
+
+	job.State = JobRunning
+	job.Error = ""
+
+	return job, c.run(job)
+}
+
+// Job returns the job with the given ID.
+func (c *Client) Job(jobID string) (*Job, error) {
+	src, err := c.store.Get(jobKeyPrefix + jobID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, ErrJobNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get migration job: %w", err)
+	}
+
+	job := &Job{}
+	if err := json.Unmarshal(src, job); err != nil {
+		return nil, fmt.Errorf("unmarshal migration job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (c *Client) start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return ErrJobInProgress
+	}
+
+	c.running = true
+
+	return nil
+}
+
+func (c *Client) stop() {
+	c.mu.Lock()
+	c.running = false
+	c.mu.Unlock()
+}
+
+// dids returns the peer DIDs (numalgo 1) of the agent used by its completed connections.
+func (c *Client) dids() ([]string, error) {
+	records, err := c.connections.QueryConnectionRecords()
+	if err != nil {
+		return nil, fmt.Errorf("query connection records: %w", err)
+	}
+
+	seen := map[string]bool{}
+
+	var dids []string
+
+	for _, record := range records {
+		if record.State != stateNameCompleted || !peer.IsUpdatable(record.MyDID) || seen[record.MyDID] {
+			continue
+		}
+
+		seen[record.MyDID] = true
+		dids = append(dids, record.MyDID)
+	}
+
+	sort.Strings(dids)
+
+	return dids, nil
+}
+
+// plan returns the migrations of the keys of the peer DIDs of the agent's connections, then of its public DIDs.
+func (c *Client) plan() ([]*DIDMigration, error) {
+	dids, err := c.dids()
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []*DIDMigration
+
+	for _, id := range dids {
+		doc, err := c.vdriRegistry.Resolve(id)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", id, err)
+		}
+
+		dest, err := service.CreateDestination(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document of %s: %w", id, err)
+		}
+
+		d := &DIDMigration{DID: id, PriorKey: dest.RecipientKeys[0], Step: StepPending}
+
+		for i := range doc.PublicKey {
+			if !bytes.Equal(doc.PublicKey[i].Value, base58.Decode(d.PriorKey)) {
+				continue
+			}
+
+			// the key is a key of the legacy KMS if it is not indexed
+			if err := c.kmsKey(doc, &doc.PublicKey[i], d); err != nil && !errors.Is(err, keyid.ErrNotFound) {
+				return nil, err
+			}
+
+			break
+		}
+
+		migrations = append(migrations, d)
+	}
+
+	public, err := c.publicDIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range public {
+		doc, err := c.vdriRegistry.Resolve(id)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", id, err)
+		}
+
+		seen := map[string]bool{}
+
+		for i := range doc.PublicKey {
+			d := &DIDMigration{DID: id, Public: true, PriorKey: base58.Encode(doc.PublicKey[i].Value), Step: StepPending}
+			if seen[d.PriorKey] {
+				continue
+			}
+
+			err := c.kmsKey(doc, &doc.PublicKey[i], d)
+			if errors.Is(err, keyid.ErrNotFound) {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			seen[d.PriorKey] = true
+			migrations = append(migrations, d)
+		}
+	}
+
+	return migrations, nil
+}
+
+// kmsKey sets the KMS key ID and type of the prior key of the migration from the key ID indexed with the DID URL
+// of the public key (see keyid.Store). The keys of the legacy KMS are indexed with their value, the prior key is
+// a key of the legacy KMS if it is not indexed. keyid.ErrNotFound is returned if the key is not indexed.
+func (c *Client) kmsKey(doc *did.Doc, pk *did.PublicKey, d *DIDMigration) error {
+	keyID, err := c.keys.KeyID(keyid.DIDURL(doc.ID, pk.ID))
+	if errors.Is(err, keyid.ErrNotFound) {
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("key ID of %s: %w", d.DID, err)
+	}
+
+	if keyID == d.PriorKey {
+		return nil
+	}
+
+	keyType, ok := keyTypes[pk.Type]
+	if !ok {
+		return fmt.Errorf("key %s of %s: unsupported key type %s", pk.ID, d.DID, pk.Type)
+	}
+
+	d.PriorKeyID, d.KeyType = keyID, keyType
+
+	return nil
+}
+
+// publicDIDs returns the DIDs of the agent other than peer DIDs whose keys are indexed (see keyid.Store), the
+// DIDs whose method does not support updates are not returned: their documents can't be published again.
+func (c *Client) publicDIDs() ([]string, error) {
+	keyIDs, err := c.keys.KeyIDs()
+	if err != nil {
+		return nil, fmt.Errorf("key IDs: %w", err)
+	}
+
+	updatable := map[string]bool{}
+
+	for _, method := range c.vdriRegistry.SupportedMethods() {
+		updatable[method.Method] = method.Update
+	}
+
+	seen := map[string]bool{}
+
+	var dids []string
+
+	for didURL := range keyIDs {
+		id := strings.SplitN(didURL, "#", 2)[0]
+
+		parts := strings.SplitN(id, ":", 3)
+		if len(parts) != 3 || parts[1] == peerMethod || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+
+		if !updatable[parts[1]] {
+			logger.Warnf("migration: %s is not migrated, the %s method does not support updates", id, parts[1])
+
+			continue
+		}
+
+		dids = append(dids, id)
+	}
+
+	sort.Strings(dids)
+
+	return dids, nil
+}
+
+// run migrates the DIDs of the job step by step, the job is saved after each step. The job fails once all the
+// DIDs are migrated if the updated documents of some DIDs are still to be sent to some connections.
+func (c *Client) run(job *Job) error {
+	pending := 0
+
+	for _, d := range job.DIDs {
+		if err := c.migrate(job, d); err != nil {
+			return c.fail(job, err)
+		}
+
+		if d.Step == StepStored {
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		return c.fail(job, fmt.Errorf("%w: %d DIDs", ErrUpdatesPending, pending))
+	}
+
+	job.State = JobCompleted
+
+	if err := c.saveJob(job); err != nil {
+		return err
+	}
+
+	logger.Infof("migration job %s completed: %d DIDs migrated", job.ID, len(job.DIDs))
+
+	c.sendProgress(job, nil)
+
+	return nil
+}
+
+// migrate executes the steps of the migration of the DID until it is published, or until its updated document
+// is stored if it could not be sent to some connections.
+func (c *Client) migrate(job *Job, d *DIDMigration) error {
+	for d.Step != StepPublished && d.Step != StepRetired {
+		if err := c.step(d); err != nil {
+			return fmt.Errorf("migrate %s: %w", d.DID, err)
+		}
+
+		if err := c.saveJob(job); err != nil {
+			return err
+		}
+
+		c.sendProgress(job, d)
+
+		if d.Step == StepStored {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// step executes the next step of the migration of the DID.
+func (c *Client) step(d *DIDMigration) error {
+	switch d.Step {
+	case StepPending:
+		if err := c.createKey(d); err != nil {
+			return err
+		}
+
+		d.Step = StepKeyCreated
+	case StepKeyCreated:
+		if err := c.routeKey(d.NewKey); err != nil {
+			return err
+		}
+
+		d.Step = StepRouted
+	case StepRouted:
+		if !d.Public {
+			if err := c.planRotation(d); err != nil {
+				return err
+			}
+		}
+
+		// the DIDs without connections to rotate are published right away
+		if len(d.Rotated) > 0 {
+			d.Step = StepRotating
+
+			return nil
+		}
+
+		return c.publish(d)
+	case StepRotating:
+		if err := c.rotateConnections(d); err != nil {
+			return err
+		}
+
+		d.Step = StepRotated
+	case StepRotated:
+		if err := c.publish(d); err != nil {
+			return err
+		}
+	case StepStored:
+		c.sendUpdate(d, c.updater.SendUpdate(d.Update, d.Pending...))
+	default:
+		return fmt.Errorf("unknown step %s", d.Step)
+	}
+
+	return nil
+}
+
+// createKey creates the new key with the KMS of the prior key.
+func (c *Client) createKey(d *DIDMigration) error {
+	if d.PriorKeyID == "" {
+		_, verKey, err := c.legacyKMS.CreateKeySet()
+		if err != nil {
+			return fmt.Errorf("create key: %w", err)
+		}
+
+		d.NewKey = verKey
+
+		return nil
+	}
+
+	exporter, ok := c.kms.(pubKeyExporter)
+	if !ok {
+		return errors.New("create key: the KMS does not export the public keys")
+	}
+
+	keyID, _, err := c.kms.Create(d.KeyType)
+	if err != nil {
+		return fmt.Errorf("create key: %w", err)
+	}
+
+	pubKey, err := exporter.ExportPubKeyBytes(keyID)
+	if err != nil {
+		return fmt.Errorf("export public key: %w", err)
+	}
+
+	d.NewKey, d.NewKeyID = base58.Encode(pubKey), keyID
+
+	return nil
+}
+
+// routeKey registers the new key with the mediator the agent is registered with, if any, before the other agents
+// are told to use it.
+func (c *Client) routeKey(key string) error {
+	mediated, err := c.mediated()
+	if err != nil || !mediated {
+		return err
+	}
+
+	if err := c.router.AddKey(key); err != nil {
+		return fmt.Errorf("add key to router: %w", err)
+	}
+
+	return nil
+}
+
+// mediated returns true if the agent is registered with a mediator.
+func (c *Client) mediated() (bool, error) {
+	if c.router == nil {
+		return false, nil
+	}
+
+	_, err := c.router.GetConnection()
+	if errors.Is(err, route.ErrRouterNotRegistered) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("router connection: %w", err)
+	}
+
+	return true, nil
+}
+
+// publish stores the document of the DID with the new key and sends it to the other agents of the connections,
+// or publishes it with the VDRI registry for the public DIDs. The document is stored even if it could not be sent
+// to some agents, the DID is then kept at StepStored.
+func (c *Client) publish(d *DIDMigration) error {
+	prior, err := c.vdriRegistry.Resolve(d.DID)
+	if err != nil {
+		return fmt.Errorf("resolve prior document: %w", err)
+	}
+
+	// the document was stored by an interrupted run, it is not signed again with the new key
+	if hasKey(prior, d.NewKey) {
+		if err := c.index(prior, d); err != nil {
+			return err
+		}
+
+		d.Step = StepPublished
+
+		return nil
+	}
+
+	doc, err := rotate(prior, d.PriorKey, d.NewKey)
+	if err != nil {
+		return err
+	}
+
+	if d.Public {
+		return c.publishPublic(doc, d)
+	}
+
+	err = c.updater.UpdateDID(doc)
+
+	sendErr := &didupdate.SendError{}
+	if err != nil && !errors.As(err, &sendErr) {
+		return fmt.Errorf("update DID: %w", err)
+	}
+
+	// the document is stored
+	if err := c.index(doc, d); err != nil {
+		return err
+	}
+
+	c.sendUpdate(d, err)
+
+	return nil
+}
+
+// publishPublic publishes the document of the public DID with the VDRI registry.
+func (c *Client) publishPublic(doc *did.Doc, d *DIDMigration) error {
+	updated := time.Now().UTC()
+	doc.Updated = &updated
+
+	if err := c.vdriRegistry.Store(doc); err != nil {
+		return fmt.Errorf("store DID document: %w", err)
+	}
+
+	if err := c.index(doc, d); err != nil {
+		return err
+	}
+
+	d.Step = StepPublished
+
+	return nil
+}
+
+// planRotation lists the connections of the peer DID whose other agents don't implement the DID update protocol,
+// the new DID they are rotated to is created and stored with the new key.
+func (c *Client) planRotation(d *DIDMigration) error {
+	if c.features == nil {
+		return nil
+	}
+
+	records, err := c.connections.QueryConnectionRecords()
+	if err != nil {
+		return fmt.Errorf("query connection records: %w", err)
+	}
+
+	var rotated []string
+
+	for _, record := range records {
+		if record.MyDID == d.DID && record.State == stateNameCompleted && !c.implementsDIDUpdate(record) {
+			rotated = append(rotated, record.ConnectionID)
+		}
+	}
+
+	if len(rotated) == 0 {
+		return nil
+	}
+
+	sort.Strings(rotated)
+
+	// the from_prior JWTs are signed with the prior key, as the updates of the documents
+	if d.PriorKeyID != "" {
+		logger.Warnf("migration: the connections of %s are not rotated, the prior key is not a key of the legacy KMS",
+			d.DID)
+
+		return nil
+	}
+
+	prior, err := c.vdriRegistry.Resolve(d.DID)
+	if err != nil {
+		return fmt.Errorf("resolve prior document: %w", err)
+	}
+
+	doc, err := rotatedDoc(prior, d.PriorKey, d.NewKey)
+	if err != nil {
+		return err
+	}
+
+	if err := c.vdriRegistry.Store(doc); err != nil {
+		return fmt.Errorf("store rotated DID document: %w", err)
+	}
+
+	if err := c.index(doc, d); err != nil {
+		return err
+	}
+
+	d.RotatedDID, d.Rotated = doc.ID, rotated
+
+	return nil
+}
+
+// implementsDIDUpdate queries the protocols of the other agent of the connection. The connection of an agent which
+// can't be queried is not rotated, the update is sent again to the connection when the job is resumed.
+func (c *Client) implementsDIDUpdate(record *connection.Record) bool {
+	protocols, err := c.features.Query(discoverfeatures.PID(didupdate.Spec), record.MyDID, record.TheirDID)
+	if err != nil && !errors.Is(err, discoverfeatures.ErrQueryTimeout) {
+		logger.Warnf("migration: query the protocols of the connection %s: %s", record.ConnectionID, err)
+
+		return true
+	}
+
+	return len(protocols) > 0
+}
+
+// rotateConnections rotates the planned connections to the new DID, the messages sent over them carry a from_prior
+// JWT until the other agents use the new DID. The connections remain mapped to the prior DID until it is retired.
+func (c *Client) rotateConnections(d *DIDMigration) error {
+	if len(d.Rotated) == 0 {
+		return nil
+	}
+
+	prior, err := c.vdriRegistry.Resolve(d.DID)
+	if err != nil {
+		return fmt.Errorf("resolve prior document: %w", err)
+	}
+
+	fromPrior, err := c.signFromPrior(prior, d)
+	if err != nil {
+		return err
+	}
+
+	for _, connectionID := range d.Rotated {
+		record, err := c.connections.GetConnectionRecord(connectionID)
+		if err != nil {
+			return fmt.Errorf("get connection record: %w", err)
+		}
+
+		record.MyDID = d.RotatedDID
+
+		if err := c.connections.SaveConnectionRecord(record); err != nil {
+			return fmt.Errorf("save connection record: %w", err)
+		}
+
+		if err := c.fromPrior.SetFromPrior(d.RotatedDID, record.TheirDID, fromPrior); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// signFromPrior returns the from_prior JWT declaring the rotation of the DID, signed with the prior key.
+func (c *Client) signFromPrior(prior *did.Doc, d *DIDMigration) (string, error) {
+	kmsSigner, ok := c.legacyKMS.(legacykms.Signer)
+	if !ok {
+		return "", errors.New("sign from_prior: the legacy KMS does not sign the messages")
+	}
+
+	signer := &fromPriorSigner{signer: kmsSigner, verKey: d.PriorKey}
+
+	for _, pk := range prior.PublicKey {
+		if bytes.Equal(pk.Value, base58.Decode(d.PriorKey)) {
+			signer.keyID = pk.ID
+
+			break
+		}
+	}
+
+	claims := &decorator.FromPrior{ISS: d.DID, SUB: d.RotatedDID, IAT: time.Now().UTC().Unix()}
+
+	token, err := jwt.NewSigned(claims, nil, signer)
+	if err != nil {
+		return "", fmt.Errorf("sign from_prior: %w", err)
+	}
+
+	fromPrior, err := token.Serialize(false)
+	if err != nil {
+		return "", fmt.Errorf("serialize from_prior: %w", err)
+	}
+
+	return fromPrior, nil
+}
+
+// fromPriorSigner signs the from_prior JWTs with the prior key of the legacy KMS.
+type fromPriorSigner struct {
+	signer legacykms.Signer
+	verKey string
+	keyID  string
+}
+
+func (s *fromPriorSigner) Sign(data []byte) ([]byte, error) {
+	return s.signer.SignMessage(data, s.verKey)
+}
+
+func (s *fromPriorSigner) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: fromPriorAlgorithm, jose.HeaderKeyID: s.keyID}
+}
+
+// sendUpdate records the outcome of sending the update of the document, the DID is published once the update
+// is sent to all the connections.
+func (c *Client) sendUpdate(d *DIDMigration, err error) {
+	sendErr := &didupdate.SendError{}
+	if errors.As(err, &sendErr) {
+		logger.Warnf("migrate %s: %s", d.DID, err)
+
+		d.Step = StepStored
+		d.Update = sendErr.Update
+		d.Pending = sendErr.ConnectionIDs()
+
+		return
+	}
+
+	d.Step = StepPublished
+	d.Update = nil
+	d.Pending = nil
+}
+
+// index indexes the new key with the DID URLs of the document publishing it (see keyid.Store), the DID URLs
+// are no longer indexed with the prior key. The keys of the legacy KMS are indexed with their value.
+func (c *Client) index(doc *did.Doc, d *DIDMigration) error {
+	keyID := d.NewKeyID
+	if keyID == "" {
+		keyID = d.NewKey
+	}
+
+	if err := c.keys.SaveKey(doc, keyID, base58.Decode(d.NewKey)); err != nil {
+		return fmt.Errorf("index key ID: %w", err)
+	}
+
+	return nil
+}
+
+// Retire retires the prior keys of a completed job, once the other agents switched to the new keys: the prior
+// keys are removed from the mediator and deleted from the KMS (if it deletes the keys, see LocalKMS.Delete), the
+// messages packed with them are not delivered afterwards. Retire may be run again if it fails.
+func (c *Client) Retire(jobID string) (*Job, error) {
+	if err := c.start(); err != nil {
+		return nil, err
+	}
+	defer c.stop()
+
+	job, err := c.Job(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.State != JobCompleted {
+		return job, ErrJobNotCompleted
+	}
+
+	for _, d := range job.DIDs {
+		if d.Step != StepPublished {
+			continue
+		}
+
+		if err := c.retire(d); err != nil {
+			return job, fmt.Errorf("retire prior key of %s: %w", d.DID, err)
+		}
+
+		d.Step = StepRetired
+
+		if err := c.saveJob(job); err != nil {
+			return job, err
+		}
+
+		c.sendProgress(job, d)
+	}
+
+	return job, nil
+}
+
+// retire removes the prior key from the mediator (if any) and deletes it from the KMS, the rotated connections are
+// no longer mapped to the prior DID.
+func (c *Client) retire(d *DIDMigration) error {
+	mediated, err := c.mediated()
+	if err != nil {
+		return err
+	}
+
+	for _, connectionID := range d.Rotated {
+		record, err := c.connections.GetConnectionRecord(connectionID)
+		if err != nil {
+			return fmt.Errorf("get connection record: %w", err)
+		}
+
+		if err := c.connections.RemoveDIDMapping(d.DID, record.TheirDID); err != nil {
+			return fmt.Errorf("remove prior DID mapping: %w", err)
+		}
+	}
+
+	if mediated {
+		if err := c.router.RemoveKey(d.PriorKey); err != nil {
+			return fmt.Errorf("remove key from router: %w", err)
+		}
+	}
+
+	if d.PriorKeyID != "" {
+		deleter, ok := c.kms.(keyDeleter)
+		if !ok {
+			logger.Warnf("migration: the KMS does not delete the keys, the prior key %s of %s is kept",
+				d.PriorKeyID, d.DID)
+
+			return nil
+		}
+
+		if err := deleter.Delete(d.PriorKeyID); err != nil {
+			return fmt.Errorf("delete key: %w", err)
+		}
+
+		return nil
+	}
+
+	// the key is already deleted if retiring it was interrupted
+	if err := c.legacyKMS.DeleteKeySet(d.PriorKey); err != nil && !errors.Is(err, cryptoutil.ErrKeyNotFound) {
+		return fmt.Errorf("delete key: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) fail(job *Job, err error) error {
+	job.State = JobFailed
+	job.Error = err.Error()
+
+	if saveErr := c.saveJob(job); saveErr != nil {
+		logger.Errorf("migration job %s: %s", job.ID, saveErr)
+	}
+
+	c.sendProgress(job, nil)
+
+	return err
+}
+
+func (c *Client) saveJob(job *Job) error {
+	job.Updated = time.Now().UTC()
+
+	src, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("marshal migration job: %w", err)
+	}
+
+	if err := c.store.Put(jobKeyPrefix+job.ID, src); err != nil {
+		return fmt.Errorf("save migration job: %w", err)
+	}
+
+	return nil
+}
+
+// sendProgress sends the progress event of the step of the DID, or of the job if d is nil.
+func (c *Client) sendProgress(job *Job, d *DIDMigration) {
+	progress := Progress{JobID: job.ID, State: job.State, Total: len(job.DIDs), Error: job.Error}
+
+	if d != nil {
+		progress.DID = d.DID
+		progress.Step = d.Step
+	}
+
+	for _, m := range job.DIDs {
+		if m.Step == StepPublished || m.Step == StepRetired {
+			progress.Migrated++
+		}
+	}
+
+	c.eventsMu.RLock()
+	events := append(c.events[:0:0], c.events...)
+	c.eventsMu.RUnlock()
+
+	for _, ch := range events {
+		ch <- progress
+	}
+}
+
+// rotate returns a copy of the document with the new key in place of the prior key, in the public keys,
+// the verification relationships and the recipient keys of the services. The IDs of the keys are kept.
+func rotate(prior *did.Doc, priorKey, newKey string) (*did.Doc, error) {
+	src, err := prior.JSONBytes()
+	if err != nil {
+		return nil, fmt.Errorf("marshal prior document: %w", err)
+	}
+
+	doc, err := did.ParseDocument(src)
+	if err != nil {
+		return nil, fmt.Errorf("parse prior document: %w", err)
+	}
+
+	priorValue, newValue := base58.Decode(priorKey), base58.Decode(newKey)
+
+	for i := range doc.PublicKey {
+		if bytes.Equal(doc.PublicKey[i].Value, priorValue) {
+			doc.PublicKey[i].Value = newValue
+		}
+	}
+
+	for _, methods := range [][]did.VerificationMethod{doc.Authentication, doc.AssertionMethod,
+		doc.CapabilityDelegation, doc.CapabilityInvocation, doc.KeyAgreement} {
+		for i := range methods {
+			if bytes.Equal(methods[i].PublicKey.Value, priorValue) {
+				methods[i].PublicKey.Value = newValue
+			}
+		}
+	}
+
+	for i := range doc.Service {
+		for j := range doc.Service[i].RecipientKeys {
+			if doc.Service[i].RecipientKeys[j] == priorKey {
+				doc.Service[i].RecipientKeys[j] = newKey
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+// rotatedDoc returns the genesis document of the new peer DID with the new key in place of the prior key, the IDs
+// of the keys and of the services are relative to the new DID.
+func rotatedDoc(prior *did.Doc, priorKey, newKey string) (*did.Doc, error) {
+	doc, err := rotate(prior, priorKey, newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	relative := func(id string) string {
+		return strings.TrimPrefix(id, prior.ID)
+	}
+
+	for i := range doc.PublicKey {
+		doc.PublicKey[i].ID = relative(doc.PublicKey[i].ID)
+		doc.PublicKey[i].Controller = relative(doc.PublicKey[i].Controller)
+	}
+
+	for i := range doc.Authentication {
+		doc.Authentication[i].PublicKey.ID = relative(doc.Authentication[i].PublicKey.ID)
+		doc.Authentication[i].PublicKey.Controller = relative(doc.Authentication[i].PublicKey.Controller)
+	}
+
+	for i := range doc.Service {
+		doc.Service[i].ID = relative(doc.Service[i].ID)
+	}
+
+	created := time.Now().UTC()
+
+	rotated, err := peer.NewDoc(doc.PublicKey, doc.Authentication, did.WithService(doc.Service),
+		did.WithCreatedTime(created), did.WithUpdatedTime(created))
+	if err != nil {
+		return nil, fmt.Errorf("new peer DID: %w", err)
+	}
+
+	return rotated, nil
+}
+
+func hasKey(doc *did.Doc, key string) bool {
+	value := base58.Decode(key)
+
+	for _, pk := range doc.PublicKey {
+		if bytes.Equal(pk.Value, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,1082 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package migration
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didupdate"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/discoverfeatures"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/hyperledger/aries-framework-go/pkg/store/keyid"
+)
+
+const (
+	aliceDID = "did:peer:1zQmalicealicealicealicealicealicealicealicealice"
+	carlDID  = "did:peer:1zQmcarlcarlcarlcarlcarlcarlcarlcarlcarlcarlcarl"
+	bobDID   = "did:peer:1zQmbobbobbobbobbobbobbobbobbobbobbobbobbobbobbob"
+
+	publicDID = "did:example:public"
+)
+
+// keyManager creates ed25519 keys and signs with them
+type keyManager struct {
+	legacykms.KeyManager
+	privKeys  map[string]ed25519.PrivateKey
+	created   int
+	deleted   []string
+	err       error
+	deleteErr error
+}
+
+func (k *keyManager) CreateKeySet() (string, string, error) {
+	if k.err != nil {
+		return "", "", k.err
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	k.created++
+	k.privKeys[base58.Encode(pub)] = priv
+
+	return "", base58.Encode(pub), nil
+}
+
+func (k *keyManager) SignMessage(message []byte, fromVerKey string) ([]byte, error) {
+	priv, ok := k.privKeys[fromVerKey]
+	if !ok {
+		return nil, cryptoutil.ErrKeyNotFound
+	}
+
+	return ed25519.Sign(priv, message), nil
+}
+
+func (k *keyManager) DeleteKeySet(verKey string) error {
+	if k.deleteErr != nil {
+		return k.deleteErr
+	}
+
+	k.deleted = append(k.deleted, verKey)
+
+	return nil
+}
+
+// localKeyManager creates ed25519 keys identified by their key ID, as the local KMS does
+type localKeyManager struct {
+	kms.KeyManager
+	keys      map[string][]byte
+	deleted   []string
+	err       error
+	deleteErr error
+}
+
+func (k *localKeyManager) Create(kt kms.KeyType) (string, interface{}, error) {
+	if k.err != nil {
+		return "", nil, k.err
+	}
+
+	if kt != kms.ED25519Type {
+		return "", nil, fmt.Errorf("unexpected key type %s", kt)
+	}
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", nil, err
+	}
+
+	keyID := fmt.Sprintf("kms-%d", len(k.keys))
+	k.keys[keyID] = pub
+
+	return keyID, nil, nil
+}
+
+func (k *localKeyManager) ExportPubKeyBytes(keyID string) ([]byte, error) {
+	pub, ok := k.keys[keyID]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	return pub, nil
+}
+
+func (k *localKeyManager) Delete(keyID string) error {
+	if k.deleteErr != nil {
+		return k.deleteErr
+	}
+
+	k.deleted = append(k.deleted, keyID)
+
+	return nil
+}
+
+// updater stores the updated documents and sends them to the completed connections of the DIDs, as the DID
+// update service does
+type updater struct {
+	registry    *mockvdri.MockVDRIRegistry
+	connections *connection.Recorder
+	// unreachable are the connections the updates cannot be sent to
+	unreachable map[string]bool
+	// sent are the connections the updates were sent to
+	sent []string
+	err  error
+}
+
+func (u *updater) UpdateDID(doc *did.Doc) error {
+	if u.err != nil {
+		return u.err
+	}
+
+	u.registry.MemStore[doc.ID] = doc
+
+	records, err := u.connections.QueryConnectionRecords()
+	if err != nil {
+		return err
+	}
+
+	var connectionIDs []string
+
+	for _, record := range records {
+		if record.MyDID == doc.ID && record.State == "completed" {
+			connectionIDs = append(connectionIDs, record.ConnectionID)
+		}
+	}
+
+	sort.Strings(connectionIDs)
+
+	return u.SendUpdate(&didupdate.Update{ID: doc.ID, DID: doc.ID}, connectionIDs...)
+}
+
+func (u *updater) SendUpdate(update *didupdate.Update, connectionIDs ...string) error {
+	failures := map[string]error{}
+
+	for _, id := range connectionIDs {
+		if u.unreachable[id] {
+			failures[id] = errors.New("unreachable")
+
+			continue
+		}
+
+		u.sent = append(u.sent, id)
+	}
+
+	if len(failures) > 0 {
+		return &didupdate.SendError{Update: update, Failures: failures}
+	}
+
+	return nil
+}
+
+type mockRouter struct {
+	connErr   error
+	addErr    error
+	removeErr error
+	keys      []string
+}
+
+func (r *mockRouter) GetConnection() (string, error) {
+	return "router", r.connErr
+}
+
+func (r *mockRouter) AddKey(recKey string) error {
+	if r.addErr != nil {
+		return r.addErr
+	}
+
+	r.keys = append(r.keys, recKey)
+
+	return nil
+}
+
+func (r *mockRouter) RemoveKey(recKey string) error {
+	if r.removeErr != nil {
+		return r.removeErr
+	}
+
+	for i, key := range r.keys {
+		if key == recKey {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// features discloses the DID update protocol for the agents implementing it, as the discover features service does
+type features struct {
+	didUpdate map[string]bool
+	err       error
+}
+
+func (f *features) Query(query, _, theirDID string) ([]*discoverfeatures.Protocol, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if !f.didUpdate[theirDID] {
+		return nil, nil
+	}
+
+	return []*discoverfeatures.Protocol{{PID: query}}, nil
+}
+
+// messenger records the from_prior JWTs of the rotated DIDs
+type messenger struct {
+	service.Messenger
+	fromPrior map[string]string
+	err       error
+}
+
+func (m *messenger) SetFromPrior(myDID, theirDID, fromPrior string) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.fromPrior[myDID+" "+theirDID] = fromPrior
+
+	return nil
+}
+
+type fixture struct {
+	provider *mockprovider.Provider
+	registry *mockvdri.MockVDRIRegistry
+	kms      *keyManager
+	localKMS *localKeyManager
+	updater  *updater
+	router   *mockRouter
+	keys     map[string]string
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	registry := &mockvdri.MockVDRIRegistry{MemStore: map[string]*did.Doc{}}
+	registry.ResolveFunc = func(didID string, _ ...vdriapi.ResolveOpts) (*did.Doc, error) {
+		doc, ok := registry.MemStore[didID]
+		if !ok {
+			return nil, vdriapi.ErrNotFound
+		}
+
+		return doc, nil
+	}
+
+	f := &fixture{
+		registry: registry,
+		kms:      &keyManager{privKeys: map[string]ed25519.PrivateKey{}},
+		localKMS: &localKeyManager{keys: map[string][]byte{}},
+		updater:  &updater{registry: registry, unreachable: map[string]bool{}},
+		router:   &mockRouter{},
+		keys:     map[string]string{},
+	}
+
+	f.provider = &mockprovider.Provider{
+		ServiceMap:                    map[string]interface{}{didupdate.Name: f.updater, route.Coordination: f.router},
+		KMSValue:                      f.kms,
+		LocalKMSValue:                 f.localKMS,
+		VDRIRegistryValue:             registry,
+		StorageProviderValue:          mem.NewProvider(),
+		TransientStorageProviderValue: mem.NewProvider(),
+	}
+
+	recorder, err := connection.NewRecorder(f.provider)
+	require.NoError(t, err)
+
+	f.updater.connections = recorder
+
+	for i, record := range []*connection.Record{
+		{MyDID: aliceDID, TheirDID: bobDID, State: "completed"},
+		{MyDID: aliceDID, TheirDID: "did:example:dave", State: "completed"},
+		{MyDID: carlDID, TheirDID: bobDID, State: "completed"},
+		{MyDID: "did:peer:1zQmpending", TheirDID: bobDID, State: "requested"},
+		{MyDID: "did:example:erin", TheirDID: bobDID, State: "completed"},
+	} {
+		record.ConnectionID = string(rune('a' + i))
+		require.NoError(t, recorder.SaveConnectionRecord(record))
+	}
+
+	for _, id := range []string{aliceDID, carlDID} {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		f.keys[id] = base58.Encode(pub)
+		f.kms.privKeys[f.keys[id]] = priv
+
+		pk := did.PublicKey{ID: id + "#key-1", Type: "Ed25519VerificationKey2018", Controller: id, Value: pub}
+
+		registry.MemStore[id] = &did.Doc{
+			Context:        []string{did.Context},
+			ID:             id,
+			PublicKey:      []did.PublicKey{pk},
+			Authentication: []did.VerificationMethod{{PublicKey: pk}},
+			Service: []did.Service{{
+				ID:              "#agent",
+				Type:            vdriapi.DIDCommServiceType,
+				ServiceEndpoint: "http://agent.example.com",
+				RecipientKeys:   []string{f.keys[id]},
+				RoutingKeys:     []string{"routing-key"},
+			}},
+		}
+	}
+
+	return f
+}
+
+func (f *fixture) client(t *testing.T) *Client {
+	t.Helper()
+
+	client, err := New(f.provider)
+	require.NoError(t, err)
+
+	return client
+}
+
+// requireMigrated checks that the document of the DID has the new key of the migration only
+func (f *fixture) requireMigrated(t *testing.T, d *DIDMigration) {
+	t.Helper()
+
+	require.Equal(t, StepPublished, d.Step)
+	require.Equal(t, f.keys[d.DID], d.PriorKey)
+
+	doc := f.registry.MemStore[d.DID]
+	require.True(t, hasKey(doc, d.NewKey))
+	require.False(t, hasKey(doc, d.PriorKey))
+	require.Equal(t, d.DID+"#key-1", doc.PublicKey[0].ID)
+	require.Equal(t, base58.Decode(d.NewKey), doc.Authentication[0].PublicKey.Value)
+	require.Equal(t, []string{d.NewKey}, doc.Service[0].RecipientKeys)
+	require.Equal(t, []string{"routing-key"}, doc.Service[0].RoutingKeys)
+	require.Empty(t, d.Pending)
+	require.Nil(t, d.Update)
+
+	keys, err := keyid.New(f.provider)
+	require.NoError(t, err)
+
+	// the keys of the legacy KMS are indexed with their value
+	newKeyID := d.NewKeyID
+	if newKeyID == "" {
+		newKeyID = d.NewKey
+	}
+
+	keyID, err := keys.KeyID(d.DID + "#key-1")
+	require.NoError(t, err)
+	require.Equal(t, newKeyID, keyID)
+}
+
+func TestNew(t *testing.T) {
+	t.Run("get service error", func(t *testing.T) {
+		_, err := New(&mockprovider.Provider{ServiceErr: errors.New("test err")})
+		require.EqualError(t, err, "test err")
+	})
+
+	t.Run("cast service error", func(t *testing.T) {
+		_, err := New(&mockprovider.Provider{})
+		require.EqualError(t, err, "cast service to didupdate service failed")
+	})
+
+	t.Run("open store error", func(t *testing.T) {
+		f := newFixture(t)
+		f.provider.StorageProviderValue = &mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")}
+
+		_, err := New(f.provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "open error")
+	})
+
+	t.Run("without route service", func(t *testing.T) {
+		f := newFixture(t)
+		delete(f.provider.ServiceMap, route.Coordination)
+
+		require.Nil(t, f.client(t).router)
+	})
+}
+
+func TestClient_Migrate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newFixture(t)
+		client := f.client(t)
+
+		events := make(chan Progress, 10)
+		require.NoError(t, client.RegisterProgressEvent(events))
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+		require.Equal(t, JobCompleted, job.State)
+		require.Len(t, job.DIDs, 2)
+		require.Equal(t, aliceDID, job.DIDs[0].DID)
+		require.Equal(t, carlDID, job.DIDs[1].DID)
+
+		for _, d := range job.DIDs {
+			f.requireMigrated(t, d)
+		}
+
+		require.Equal(t, []string{"a", "b", "c"}, f.updater.sent)
+		require.Equal(t, []string{job.DIDs[0].NewKey, job.DIDs[1].NewKey}, f.router.keys)
+
+		stored, err := client.Job(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, job.DIDs, stored.DIDs)
+		require.Equal(t, JobCompleted, stored.State)
+
+		require.Len(t, events, 7)
+
+		for _, step := range []string{StepKeyCreated, StepRouted, StepPublished} {
+			progress := <-events
+			require.Equal(t, aliceDID, progress.DID)
+			require.Equal(t, step, progress.Step)
+			require.Equal(t, JobRunning, progress.State)
+			require.Equal(t, 2, progress.Total)
+		}
+
+		for i := 0; i < 3; i++ {
+			<-events
+		}
+
+		progress := <-events
+		require.Equal(t, Progress{JobID: job.ID, State: JobCompleted, Migrated: 2, Total: 2}, progress)
+
+		client.UnregisterProgressEvent(events)
+
+		_, err = client.Migrate()
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+
+	t.Run("mediator not registered", func(t *testing.T) {
+		f := newFixture(t)
+		f.router.connErr = route.ErrRouterNotRegistered
+
+		job, err := f.client(t).Migrate()
+		require.NoError(t, err)
+		require.Equal(t, JobCompleted, job.State)
+		require.Empty(t, f.router.keys)
+	})
+
+	t.Run("index the keys published by the prior documents", func(t *testing.T) {
+		f := newFixture(t)
+
+		keys, err := keyid.New(f.provider)
+		require.NoError(t, err)
+		require.NoError(t, keys.Save(f.keys[aliceDID], aliceDID+"#key-1"))
+
+		job, err := f.client(t).Migrate()
+		require.NoError(t, err)
+
+		didURLs, err := keys.DIDURLs(f.keys[aliceDID])
+		require.NoError(t, err)
+		require.Empty(t, didURLs)
+
+		didURLs, err = keys.DIDURLs(job.DIDs[0].NewKey)
+		require.NoError(t, err)
+		require.Equal(t, []string{aliceDID + "#key-1"}, didURLs)
+	})
+
+	t.Run("update error", func(t *testing.T) {
+		f := newFixture(t)
+		f.updater.err = errors.New("store error")
+
+		job, err := f.client(t).Migrate()
+		require.EqualError(t, err, "migrate "+aliceDID+": update DID: store error")
+		require.Equal(t, JobFailed, job.State)
+		require.Equal(t, StepRouted, job.DIDs[0].Step)
+		require.Equal(t, StepPending, job.DIDs[1].Step)
+	})
+
+	t.Run("key creation error", func(t *testing.T) {
+		f := newFixture(t)
+		f.kms.err = errors.New("kms error")
+
+		_, err := f.client(t).Migrate()
+		require.EqualError(t, err, "migrate "+aliceDID+": create key: kms error")
+	})
+
+	t.Run("resolve error", func(t *testing.T) {
+		f := newFixture(t)
+		delete(f.registry.MemStore, carlDID)
+
+		_, err := f.client(t).Migrate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "resolve "+carlDID)
+	})
+
+	t.Run("job in progress", func(t *testing.T) {
+		client := newFixture(t).client(t)
+		client.running = true
+
+		_, err := client.Migrate()
+		require.True(t, errors.Is(err, ErrJobInProgress))
+
+		_, err = client.Resume("id")
+		require.True(t, errors.Is(err, ErrJobInProgress))
+	})
+}
+
+// addPublicDID adds the document of a public DID of the agent, its first key is a key of the local KMS and its
+// second key a key of the legacy KMS, the DIDs of the other method are not updatable
+func (f *fixture) addPublicDID(t *testing.T, id string) *did.Doc {
+	t.Helper()
+
+	f.registry.Methods = []vdriapi.MethodCapabilities{{Method: "example", Create: true, Update: true}, {Method: "other"}}
+
+	keys, err := keyid.New(f.provider)
+	require.NoError(t, err)
+
+	doc := &did.Doc{Context: []string{did.Context}, ID: id}
+
+	for i, keyID := range []string{"public-kms", ""} {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		if keyID == "" {
+			keyID = base58.Encode(pub)
+		}
+
+		pk := did.PublicKey{ID: fmt.Sprintf("%s#key-%d", id, i+1), Type: "Ed25519VerificationKey2018",
+			Controller: id, Value: pub}
+		doc.PublicKey = append(doc.PublicKey, pk)
+		doc.Authentication = append(doc.Authentication, did.VerificationMethod{PublicKey: pk})
+
+		require.NoError(t, keys.Save(keyID, pk.ID))
+	}
+
+	f.registry.MemStore[id] = doc
+
+	return doc
+}
+
+func TestClient_Migrate_PublicDIDs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newFixture(t)
+		prior := f.addPublicDID(t, publicDID)
+		f.addPublicDID(t, "did:other:public")
+
+		keys, err := keyid.New(f.provider)
+		require.NoError(t, err)
+
+		// the key of carl is a key of the local KMS
+		require.NoError(t, keys.Save("carl-kms", carlDID+"#key-1"))
+
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+		require.Equal(t, JobCompleted, job.State)
+		require.Len(t, job.DIDs, 4)
+
+		carl := job.DIDs[1]
+		require.Equal(t, "carl-kms", carl.PriorKeyID)
+		require.Equal(t, kms.ED25519Type, carl.KeyType)
+		require.Equal(t, base58.Encode(f.localKMS.keys[carl.NewKeyID]), carl.NewKey)
+
+		for _, d := range job.DIDs[:2] {
+			require.False(t, d.Public)
+			f.requireMigrated(t, d)
+		}
+
+		// the keys of the public DID are migrated, its document is published with the VDRI registry
+		local, legacy := job.DIDs[2], job.DIDs[3]
+		require.Equal(t, publicDID, local.DID)
+		require.True(t, local.Public)
+		require.Equal(t, "public-kms", local.PriorKeyID)
+		require.Equal(t, base58.Encode(prior.PublicKey[0].Value), local.PriorKey)
+		require.Equal(t, base58.Encode(f.localKMS.keys[local.NewKeyID]), local.NewKey)
+		require.Equal(t, publicDID, legacy.DID)
+		require.Empty(t, legacy.PriorKeyID)
+		require.Empty(t, legacy.NewKeyID)
+		require.Equal(t, base58.Encode(prior.PublicKey[1].Value), legacy.PriorKey)
+		require.Equal(t, 2, f.kms.created)
+		require.Len(t, f.localKMS.keys, 2)
+
+		doc := f.registry.MemStore[publicDID]
+		require.NotNil(t, doc.Updated)
+
+		for i, d := range []*DIDMigration{local, legacy} {
+			require.Equal(t, StepPublished, d.Step)
+			require.Equal(t, base58.Decode(d.NewKey), doc.PublicKey[i].Value)
+			require.Equal(t, base58.Decode(d.NewKey), doc.Authentication[i].PublicKey.Value)
+			require.False(t, hasKey(doc, d.PriorKey))
+		}
+
+		for didURL, keyID := range map[string]string{
+			carlDID + "#key-1": carl.NewKeyID, publicDID + "#key-1": local.NewKeyID, publicDID + "#key-2": legacy.NewKey,
+		} {
+			indexed, err := keys.KeyID(didURL)
+			require.NoError(t, err)
+			require.Equal(t, keyID, indexed)
+		}
+
+		// the documents of the public DIDs are not sent to the connections
+		require.Equal(t, []string{"a", "b", "c"}, f.updater.sent)
+		require.Equal(t, []string{job.DIDs[0].NewKey, carl.NewKey, local.NewKey, legacy.NewKey}, f.router.keys)
+
+		job, err = client.Retire(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"carl-kms", "public-kms"}, f.localKMS.deleted)
+		require.Equal(t, []string{f.keys[aliceDID], legacy.PriorKey}, f.kms.deleted)
+
+		for _, d := range job.DIDs {
+			require.Equal(t, StepRetired, d.Step)
+		}
+	})
+
+	t.Run("resume after a publication error", func(t *testing.T) {
+		f := newFixture(t)
+		f.addPublicDID(t, publicDID)
+		f.registry.PutErr = errors.New("store error")
+
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.EqualError(t, err, "migrate "+publicDID+": store DID document: store error")
+		require.Equal(t, StepRouted, job.DIDs[2].Step)
+
+		// the document was stored before the error
+		f.registry.PutErr = nil
+
+		job, err = client.Resume(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, JobCompleted, job.State)
+
+		keys, err := keyid.New(f.provider)
+		require.NoError(t, err)
+
+		keyID, err := keys.KeyID(publicDID + "#key-1")
+		require.NoError(t, err)
+		require.Equal(t, job.DIDs[2].NewKeyID, keyID)
+	})
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		f := newFixture(t)
+		f.addPublicDID(t, publicDID).PublicKey[0].Type = "RsaVerificationKey2018"
+
+		_, err := f.client(t).Migrate()
+		require.EqualError(t, err, "key "+publicDID+"#key-1 of "+publicDID+": unsupported key type RsaVerificationKey2018")
+	})
+
+	t.Run("KMS errors", func(t *testing.T) {
+		f := newFixture(t)
+		f.addPublicDID(t, publicDID)
+		f.localKMS.err = errors.New("kms error")
+
+		_, err := f.client(t).Migrate()
+		require.EqualError(t, err, "migrate "+publicDID+": create key: kms error")
+
+		f.provider.LocalKMSValue = &mockkms.KeyManager{}
+
+		_, err = f.client(t).Migrate()
+		require.EqualError(t, err, "migrate "+publicDID+": create key: the KMS does not export the public keys")
+
+		f.localKMS.err = nil
+		f.provider.LocalKMSValue = f.localKMS
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+
+		f.localKMS.deleteErr = errors.New("kms error")
+
+		_, err = client.Retire(job.ID)
+		require.EqualError(t, err, "retire prior key of "+publicDID+": delete key: kms error")
+
+		// the prior keys are kept by the KMSs not deleting the keys
+		client.kms = &mockkms.KeyManager{}
+
+		job, err = client.Retire(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, StepRetired, job.DIDs[2].Step)
+	})
+
+	t.Run("key ID errors", func(t *testing.T) {
+		f := newFixture(t)
+		f.addPublicDID(t, publicDID)
+
+		client := f.client(t)
+		client.keys, _ = keyid.New(&mockprovider.Provider{ //nolint:errcheck
+			StorageProviderValue: mockstore.NewCustomMockStoreProvider(&mockstore.MockStore{
+				Store: map[string][]byte{}, ErrItr: errors.New("iterator error"),
+			}),
+		})
+
+		_, err := client.Migrate()
+		require.EqualError(t, err, "key IDs: iterate key IDs: iterator error")
+	})
+}
+
+func TestClient_Resume(t *testing.T) {
+	t.Run("resume failed job", func(t *testing.T) {
+		f := newFixture(t)
+		f.router.addErr = errors.New("keylist update timeout")
+
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "add key to router: keylist update timeout")
+		require.Equal(t, JobFailed, job.State)
+		require.Equal(t, StepKeyCreated, job.DIDs[0].Step)
+
+		newKey, failure := job.DIDs[0].NewKey, err.Error()
+
+		// the agent restarted
+		f.router.addErr = nil
+		client = f.client(t)
+
+		stored, err := client.Job(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, JobFailed, stored.State)
+		require.Equal(t, failure, stored.Error)
+
+		job, err = client.Resume(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, JobCompleted, job.State)
+		require.Empty(t, job.Error)
+		require.Equal(t, newKey, job.DIDs[0].NewKey)
+		require.Equal(t, 2, f.kms.created)
+
+		for _, d := range job.DIDs {
+			f.requireMigrated(t, d)
+		}
+
+		_, err = client.Resume(job.ID)
+		require.True(t, errors.Is(err, ErrJobCompleted))
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		_, err := newFixture(t).client(t).Resume("unknown")
+		require.True(t, errors.Is(err, ErrJobNotFound))
+	})
+}
+
+func TestClient_Resume_PendingUpdates(t *testing.T) {
+	f := newFixture(t)
+	f.updater.unreachable["b"] = true
+
+	client := f.client(t)
+
+	job, err := client.Migrate()
+	require.True(t, errors.Is(err, ErrUpdatesPending))
+	require.Equal(t, JobFailed, job.State)
+
+	// the document of alice is stored, the update is kept for the connection it was not sent to
+	alice := job.DIDs[0]
+	require.Equal(t, StepStored, alice.Step)
+	require.Equal(t, []string{"b"}, alice.Pending)
+	require.Equal(t, aliceDID, alice.Update.DID)
+	require.True(t, hasKey(f.registry.MemStore[aliceDID], alice.NewKey))
+	f.requireMigrated(t, job.DIDs[1])
+	require.Equal(t, []string{"a", "c"}, f.updater.sent)
+
+	job, err = client.Resume(job.ID)
+	require.True(t, errors.Is(err, ErrUpdatesPending))
+	require.Equal(t, []string{"b"}, job.DIDs[0].Pending)
+	require.Equal(t, []string{"a", "c"}, f.updater.sent)
+
+	// the agent restarted
+	f.updater.unreachable["b"] = false
+	client = f.client(t)
+
+	job, err = client.Resume(job.ID)
+	require.NoError(t, err)
+	require.Equal(t, JobCompleted, job.State)
+	require.Equal(t, []string{"a", "c", "b"}, f.updater.sent)
+
+	for _, d := range job.DIDs {
+		f.requireMigrated(t, d)
+	}
+}
+
+func TestClient_Migrate_RotateConnections(t *testing.T) {
+	newRotationFixture := func(t *testing.T) (*fixture, *messenger) {
+		t.Helper()
+
+		f := newFixture(t)
+		m := &messenger{fromPrior: map[string]string{}}
+
+		// dave doesn't implement the DID update protocol
+		f.provider.ServiceMap[discoverfeatures.Name] = &features{didUpdate: map[string]bool{bobDID: true}}
+		f.provider.MessengerValue = m
+
+		return f, m
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f, m := newRotationFixture(t)
+		client := f.client(t)
+
+		events := make(chan Progress, 10)
+		require.NoError(t, client.RegisterProgressEvent(events))
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+		require.Equal(t, JobCompleted, job.State)
+
+		alice := job.DIDs[0]
+		f.requireMigrated(t, alice)
+		f.requireMigrated(t, job.DIDs[1])
+		require.Equal(t, []string{"b"}, alice.Rotated)
+		require.Empty(t, job.DIDs[1].Rotated)
+
+		// the update is sent to the connections of the agents implementing the DID update protocol only
+		require.Equal(t, []string{"a", "c"}, f.updater.sent)
+
+		for _, step := range []string{StepKeyCreated, StepRouted, StepRotating, StepRotated, StepPublished} {
+			require.Equal(t, step, (<-events).Step)
+		}
+
+		// the new peer DID has the new key
+		rotated, ok := f.registry.MemStore[alice.RotatedDID]
+		require.True(t, ok)
+		require.True(t, strings.HasPrefix(rotated.ID, "did:peer:"))
+		require.True(t, hasKey(rotated, alice.NewKey))
+		require.False(t, hasKey(rotated, alice.PriorKey))
+		require.Equal(t, []string{alice.NewKey}, rotated.Service[0].RecipientKeys)
+
+		keys, err := keyid.New(f.provider)
+		require.NoError(t, err)
+
+		keyID, err := keys.KeyID(keyid.DIDURL(rotated.ID, rotated.PublicKey[0].ID))
+		require.NoError(t, err)
+		require.Equal(t, alice.NewKey, keyID)
+
+		recorder, err := connection.NewRecorder(f.provider)
+		require.NoError(t, err)
+
+		record, err := recorder.GetConnectionRecord("b")
+		require.NoError(t, err)
+		require.Equal(t, alice.RotatedDID, record.MyDID)
+
+		record, err = recorder.GetConnectionRecord("a")
+		require.NoError(t, err)
+		require.Equal(t, aliceDID, record.MyDID)
+
+		// the from_prior JWT is signed with the prior key
+		fromPrior, ok := m.fromPrior[alice.RotatedDID+" did:example:dave"]
+		require.True(t, ok)
+		require.Len(t, m.fromPrior, 1)
+
+		token, err := jwt.Parse(fromPrior, jwt.WithSignatureVerifier(jwt.NewVerifier(jwt.KeyResolverFunc(
+			func(issuer, keyID string) (*verifier.PublicKey, error) {
+				require.Equal(t, aliceDID, issuer)
+				require.Equal(t, aliceDID+"#key-1", keyID)
+
+				return &verifier.PublicKey{Type: kms.ED25519, Value: base58.Decode(alice.PriorKey)}, nil
+			}))))
+		require.NoError(t, err)
+
+		claims := &decorator.FromPrior{}
+		require.NoError(t, token.DecodeClaims(claims))
+		require.Equal(t, aliceDID, claims.ISS)
+		require.Equal(t, alice.RotatedDID, claims.SUB)
+		require.NotZero(t, claims.IAT)
+
+		// the rotated connection is no longer mapped to the prior DID once it is retired
+		_, err = recorder.GetConnectionIDByDIDs(aliceDID, "did:example:dave")
+		require.NoError(t, err)
+
+		_, err = client.Retire(job.ID)
+		require.NoError(t, err)
+
+		_, err = recorder.GetConnectionIDByDIDs(aliceDID, "did:example:dave")
+		require.Error(t, err)
+
+		connectionID, err := recorder.GetConnectionIDByDIDs(alice.RotatedDID, "did:example:dave")
+		require.NoError(t, err)
+		require.Equal(t, "b", connectionID)
+	})
+
+	t.Run("the agents which can't be queried are not rotated", func(t *testing.T) {
+		f, m := newRotationFixture(t)
+		f.provider.ServiceMap[discoverfeatures.Name] = &features{err: errors.New("send error")}
+
+		job, err := f.client(t).Migrate()
+		require.NoError(t, err)
+		require.Empty(t, job.DIDs[0].Rotated)
+		require.Empty(t, m.fromPrior)
+		require.Equal(t, []string{"a", "b", "c"}, f.updater.sent)
+	})
+
+	t.Run("the agents which don't answer the query are rotated", func(t *testing.T) {
+		f, _ := newRotationFixture(t)
+		f.provider.ServiceMap[discoverfeatures.Name] = &features{err: discoverfeatures.ErrQueryTimeout}
+
+		job, err := f.client(t).Migrate()
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, job.DIDs[0].Rotated)
+		require.Equal(t, []string{"c"}, job.DIDs[1].Rotated)
+		require.Empty(t, f.updater.sent)
+	})
+
+	t.Run("resume after a rotation error", func(t *testing.T) {
+		f, m := newRotationFixture(t)
+		m.err = errors.New("store error")
+
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.EqualError(t, err, "migrate "+aliceDID+": store error")
+		require.Equal(t, JobFailed, job.State)
+		require.Equal(t, StepRotating, job.DIDs[0].Step)
+
+		m.err = nil
+
+		job, err = client.Resume(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, JobCompleted, job.State)
+		require.Len(t, m.fromPrior, 1)
+		require.Contains(t, m.fromPrior, job.DIDs[0].RotatedDID+" did:example:dave")
+	})
+
+	t.Run("without messenger", func(t *testing.T) {
+		f, _ := newRotationFixture(t)
+		f.provider.MessengerValue = nil
+
+		client := f.client(t)
+		require.Nil(t, client.features)
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+		require.Empty(t, job.DIDs[0].Rotated)
+	})
+
+	t.Run("the legacy KMS does not sign", func(t *testing.T) {
+		f, _ := newRotationFixture(t)
+		f.provider.KMSValue = &struct{ legacykms.KeyManager }{f.kms}
+
+		job, err := f.client(t).Migrate()
+		require.EqualError(t, err,
+			"migrate "+aliceDID+": sign from_prior: the legacy KMS does not sign the messages")
+		require.Equal(t, StepRotating, job.DIDs[0].Step)
+	})
+}
+
+func TestClient_Retire(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newFixture(t)
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+
+		f.router.keys = append(f.router.keys, f.keys[aliceDID], f.keys[carlDID])
+
+		job, err = client.Retire(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{f.keys[aliceDID], f.keys[carlDID]}, f.kms.deleted)
+		require.Equal(t, []string{job.DIDs[0].NewKey, job.DIDs[1].NewKey}, f.router.keys)
+
+		for _, d := range job.DIDs {
+			require.Equal(t, StepRetired, d.Step)
+		}
+
+		stored, err := client.Job(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, job.DIDs, stored.DIDs)
+
+		// the retired keys are not retired again
+		_, err = client.Retire(job.ID)
+		require.NoError(t, err)
+		require.Len(t, f.kms.deleted, 2)
+	})
+
+	t.Run("retire again after a failure", func(t *testing.T) {
+		f := newFixture(t)
+		f.router.connErr = route.ErrRouterNotRegistered
+
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+
+		f.kms.deleteErr = errors.New("kms error")
+
+		job, err = client.Retire(job.ID)
+		require.EqualError(t, err, "retire prior key of "+aliceDID+": delete key: kms error")
+		require.Equal(t, StepPublished, job.DIDs[0].Step)
+
+		// the key was deleted before the failure
+		f.kms.deleteErr = cryptoutil.ErrKeyNotFound
+
+		job, err = client.Retire(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, StepRetired, job.DIDs[0].Step)
+		require.Equal(t, StepRetired, job.DIDs[1].Step)
+	})
+
+	t.Run("router errors", func(t *testing.T) {
+		f := newFixture(t)
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.NoError(t, err)
+
+		f.router.removeErr = errors.New("keylist update timeout")
+
+		_, err = client.Retire(job.ID)
+		require.EqualError(t, err, "retire prior key of "+aliceDID+": remove key from router: keylist update timeout")
+
+		f.router.connErr = errors.New("store error")
+
+		_, err = client.Retire(job.ID)
+		require.EqualError(t, err, "retire prior key of "+aliceDID+": router connection: store error")
+		require.Empty(t, f.kms.deleted)
+	})
+
+	t.Run("job not completed", func(t *testing.T) {
+		f := newFixture(t)
+		f.kms.err = errors.New("kms error")
+
+		client := f.client(t)
+
+		job, err := client.Migrate()
+		require.Error(t, err)
+
+		_, err = client.Retire(job.ID)
+		require.True(t, errors.Is(err, ErrJobNotCompleted))
+
+		_, err = client.Retire("unknown")
+		require.True(t, errors.Is(err, ErrJobNotFound))
+
+		client.running = true
+
+		_, err = client.Retire(job.ID)
+		require.True(t, errors.Is(err, ErrJobInProgress))
+	})
+}
+
+func TestClient_RegisterProgressEvent(t *testing.T) {
+	client := newFixture(t).client(t)
+
+	require.EqualError(t, client.RegisterProgressEvent(nil), "channel is mandatory")
+
+	client.UnregisterProgressEvent(make(chan Progress))
+}
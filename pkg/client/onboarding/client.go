@@ -0,0 +1,509 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package onboarding onboards simple agents from out-of-band invitations: given an invitation with a known goal,
+// it establishes the connection, launches the protocol fulfilling the goal, answers its actions with the
+// registered providers and returns a single completion, rather than having the agent integrate the
+// out-of-band, DID exchange and protocol clients and their events.
+package onboarding
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/client/outofband"
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	oobsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofband"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+var logger = log.New("aries-framework/client/onboarding")
+
+const (
+	// GoalIssueVC is the goal code of the invitations of an Issuer willing to issue a credential to the invitee.
+	GoalIssueVC = "issue-vc"
+	// GoalRequestProof is the goal code of the invitations of a Verifier requesting a proof from the invitee.
+	GoalRequestProof = "request-proof"
+
+	// StateDone is the terminal state of the protocol instances completed successfully.
+	StateDone = "done"
+	// StateAbandoning is the terminal state of the protocol instances abandoned by either party.
+	StateAbandoning = "abandoning"
+
+	defaultTimeout = 2 * time.Minute
+
+	connectionInvited   = "invited"
+	connectionCompleted = "completed"
+	connectionAbandoned = "abandoned"
+)
+
+var (
+	// ErrUnsupportedGoal is returned for the invitations without a goal code or with an unknown one.
+	ErrUnsupportedGoal = errors.New("unsupported goal code")
+	// ErrAbandoned is returned when the connection or the protocol instance is abandoned.
+	ErrAbandoned = errors.New("onboarding abandoned")
+
+	errNoCredentialProvider = errors.New("no credential provider registered")
+)
+
+// Provider contains dependencies for the onboarding and is typically created by using aries.Context()
+type Provider interface {
+	Service(id string) (interface{}, error)
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+}
+
+// AttributeProvider provides the attributes of the credential proposed to the Issuer for an issue-vc invitation.
+type AttributeProvider func(req *outofband.Request) ([]issuecredential.Attribute, error)
+
+// CredentialProvider provides the presentation answering the request of the Verifier for a request-proof
+// invitation, typically built from the credentials held by the agent.
+type CredentialProvider func(req *presentproof.RequestPresentation, myDID, theirDID string) (
+	*presentproof.Presentation, error)
+
+// Completion is the outcome of an onboarding.
+type Completion struct {
+	// ConnectionID is the ID of the connection established with the inviter.
+	ConnectionID string `json:"connectionID"`
+	// GoalCode is the goal code of the invitation.
+	GoalCode string `json:"goalCode"`
+	// PIID is the ID of the protocol instance launched for the goal.
+	PIID string `json:"piid,omitempty"`
+	// State is the terminal state of the protocol instance, StateDone on success.
+	State string `json:"state,omitempty"`
+}
+
+type oobService interface {
+	AcceptRequest(request *oobsvc.Request) (string, error)
+}
+
+type didexchangeService interface {
+	RegisterMsgCallback(cb service.StateMsgCallback) (func(), error)
+	AcceptInvitation(connectionID, publicDID, label string) error
+}
+
+// protocolService is implemented by the issue credential and present proof services.
+type protocolService interface {
+	service.Event
+	RegisterThreadActionCallback(thID string, cb service.ActionCallback) (func(), error)
+}
+
+type issueCredentialService interface {
+	protocolService
+	HandleOutbound(msg service.DIDCommMsg, myDID, theirDID string) error
+}
+
+type presentProofService interface {
+	protocolService
+	HandleInbound(msg service.DIDCommMsg, myDID, theirDID string) (string, error)
+}
+
+// connectionProperties are the properties of the DID exchange events.
+type connectionProperties interface {
+	ConnectionID() string
+}
+
+// Client onboards the agent from out-of-band invitations.
+type Client struct {
+	oob             oobService
+	didexchange     didexchangeService
+	issueCredential issueCredentialService
+	presentProof    presentProofService
+	connections     *connection.Lookup
+
+	mu          sync.RWMutex
+	attributes  AttributeProvider
+	credentials CredentialProvider
+	timeout     time.Duration
+	idGenerator idgen.Generator
+}
+
+// New returns a new onboarding client.
+func New(ctx Provider) (*Client, error) {
+	c := &Client{timeout: defaultTimeout, idGenerator: idgen.FromProvider(ctx)}
+
+	var ok bool
+
+	raw, err := lookup(ctx, oobsvc.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.oob, ok = raw.(oobService); !ok {
+		return nil, fmt.Errorf("cast service %s as a dependency", oobsvc.Name)
+	}
+
+	if raw, err = lookup(ctx, didexchange.DIDExchange); err != nil {
+		return nil, err
+	}
+
+	if c.didexchange, ok = raw.(didexchangeService); !ok {
+		return nil, fmt.Errorf("cast service %s as a dependency", didexchange.DIDExchange)
+	}
+
+	if raw, err = lookup(ctx, issuecredential.Name); err != nil {
+		return nil, err
+	}
+
+	if c.issueCredential, ok = raw.(issueCredentialService); !ok {
+		return nil, fmt.Errorf("cast service %s as a dependency", issuecredential.Name)
+	}
+
+	if raw, err = lookup(ctx, presentproof.Name); err != nil {
+		return nil, err
+	}
+
+	if c.presentProof, ok = raw.(presentProofService); !ok {
+		return nil, fmt.Errorf("cast service %s as a dependency", presentproof.Name)
+	}
+
+	if c.connections, err = connection.NewLookup(ctx); err != nil {
+		return nil, fmt.Errorf("create connection lookup: %w", err)
+	}
+
+	return c, nil
+}
+
+func lookup(ctx Provider, name string) (interface{}, error) {
+	raw, err := ctx.Service(name)
+	if err != nil {
+		return nil, fmt.Errorf("look up service %s: %w", name, err)
+	}
+
+	return raw, nil
+}
+
+// RegisterAttributeProvider registers the provider of the attributes proposed for the issue-vc invitations,
+// the proposals don't preview any attribute otherwise.
+func (c *Client) RegisterAttributeProvider(provider AttributeProvider) {
+	c.mu.Lock()
+	c.attributes = provider
+	c.mu.Unlock()
+}
+
+// RegisterCredentialProvider registers the provider of the presentations answering the request-proof
+// invitations, the requests of the Verifiers are declined otherwise.
+func (c *Client) RegisterCredentialProvider(provider CredentialProvider) {
+	c.mu.Lock()
+	c.credentials = provider
+	c.mu.Unlock()
+}
+
+// SetTimeout sets the time the onboarding may take, from the acceptance of the invitation to the completion
+// of the protocol (defaults to 2 minutes).
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	c.timeout = timeout
+	c.mu.Unlock()
+}
+
+// Onboard accepts the out-of-band invitation, waits for the connection with the inviter and runs the protocol
+// fulfilling the goal of the invitation: for GoalIssueVC the agent proposes a credential to the Issuer, accepts
+// its offer and saves the issued credential; for GoalRequestProof the agent proposes a presentation to the
+// Verifier and answers its request with the registered CredentialProvider.
+//
+// The onboarding only handles the action events of the protocol instance it launched, the events of the other
+// instances still reach the channels or callbacks registered with the protocol clients, and several onboardings
+// may run concurrently.
+// The completion is returned along with ErrAbandoned if the protocol instance is abandoned.
+func (c *Client) Onboard(req *outofband.Request) (*Completion, error) {
+	if req == nil || req.Request == nil {
+		return nil, errors.New("empty out-of-band request")
+	}
+
+	if req.GoalCode != GoalIssueVC && req.GoalCode != GoalRequestProof {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedGoal, req.GoalCode)
+	}
+
+	c.mu.RLock()
+	deadline := time.Now().Add(c.timeout)
+	c.mu.RUnlock()
+
+	connID, err := c.connect(req, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := c.connections.GetConnectionRecord(connID)
+	if err != nil {
+		return nil, fmt.Errorf("get connection record: %w", err)
+	}
+
+	completion := &Completion{
+		ConnectionID: connID,
+		GoalCode:     req.GoalCode,
+		PIID:         c.idGenerator.NewID(),
+	}
+
+	x, err := c.exchange(req, record, completion.PIID)
+	if err != nil {
+		return nil, err
+	}
+
+	completion.State, err = x.run(deadline)
+	if err != nil {
+		return nil, fmt.Errorf("%s exchange %s: %w", req.GoalCode, completion.PIID, err)
+	}
+
+	if completion.State == StateAbandoning {
+		return completion, fmt.Errorf("%w: %s exchange %s", ErrAbandoned, req.GoalCode, completion.PIID)
+	}
+
+	return completion, nil
+}
+
+// connect accepts the invitation and waits for the connection to be completed, the invitation is approved
+// unless the DID exchange is approved by the agent meanwhile.
+func (c *Client) connect(req *outofband.Request, deadline time.Time) (string, error) {
+	w := &connectionWatcher{
+		states:  make(map[string]string),
+		updates: make(chan struct{}, 1),
+	}
+
+	unregister, err := c.didexchange.RegisterMsgCallback(w.notify)
+	if err != nil {
+		return "", fmt.Errorf("register didexchange callback: %w", err)
+	}
+
+	defer unregister()
+
+	connID, err := c.oob.AcceptRequest(req.Request)
+	if err != nil {
+		return "", fmt.Errorf("accept out-of-band request: %w", err)
+	}
+
+	// the connection with the inviter is reused
+	if record, err := c.connections.GetConnectionRecord(connID); err == nil && record.State == connectionCompleted {
+		return connID, nil
+	}
+
+	if err := w.wait(connID, deadline, c.didexchange.AcceptInvitation); err != nil {
+		return "", fmt.Errorf("connection %s: %w", connID, err)
+	}
+
+	return connID, nil
+}
+
+// exchange returns the protocol exchange fulfilling the goal of the invitation.
+func (c *Client) exchange(req *outofband.Request, record *connection.Record, piID string) (*exchange, error) {
+	c.mu.RLock()
+	attributes, credentials := c.attributes, c.credentials
+	c.mu.RUnlock()
+
+	if req.GoalCode == GoalRequestProof {
+		// the present proof messages are sent by the service handling them as inbound messages
+		msg := service.NewDIDCommMsgMap(&presentproof.ProposePresentation{
+			Type: presentproof.ProposePresentationMsgType,
+		})
+
+		return &exchange{
+			svc:    c.presentProof,
+			piID:   piID,
+			answer: answerRequestPresentation(credentials, record),
+			send: func() error {
+				if err := msg.SetID(piID); err != nil {
+					return err
+				}
+
+				_, err := c.presentProof.HandleInbound(msg, record.MyDID, record.TheirDID)
+
+				return err
+			},
+		}, nil
+	}
+
+	proposal := &issuecredential.ProposeCredential{Type: issuecredential.ProposeCredentialMsgType}
+
+	if attributes != nil {
+		attrs, err := attributes(req)
+		if err != nil {
+			return nil, fmt.Errorf("provide attributes: %w", err)
+		}
+
+		if len(attrs) > 0 {
+			proposal.CredentialProposal = issuecredential.PreviewCredential{
+				Type:       issuecredential.CredentialPreviewMsgType,
+				Attributes: attrs,
+			}
+		}
+	}
+
+	msg := service.NewDIDCommMsgMap(proposal)
+
+	return &exchange{
+		svc:    c.issueCredential,
+		piID:   piID,
+		answer: answerOffer,
+		send: func() error {
+			if err := msg.SetID(piID); err != nil {
+				return err
+			}
+
+			return c.issueCredential.HandleOutbound(msg, record.MyDID, record.TheirDID)
+		},
+	}, nil
+}
+
+// answerOffer accepts the offer and the credentials of the Issuer, the credentials are saved under their ID.
+func answerOffer(action service.DIDCommAction) {
+	switch action.Message.Type() {
+	case issuecredential.OfferCredentialMsgType, issuecredential.IssueCredentialMsgType:
+		action.Continue(nil)
+	default:
+		action.Stop(fmt.Errorf("unexpected message %s", action.Message.Type()))
+	}
+}
+
+// answerRequestPresentation answers the request of the Verifier with the presentation of the provider.
+func answerRequestPresentation(provider CredentialProvider, record *connection.Record) func(service.DIDCommAction) {
+	return func(action service.DIDCommAction) {
+		if action.Message.Type() != presentproof.RequestPresentationMsgType {
+			action.Stop(fmt.Errorf("unexpected message %s", action.Message.Type()))
+
+			return
+		}
+
+		if provider == nil {
+			action.Stop(errNoCredentialProvider)
+
+			return
+		}
+
+		req := &presentproof.RequestPresentation{}
+		if err := action.Message.Decode(req); err != nil {
+			action.Stop(fmt.Errorf("decode request presentation: %w", err))
+
+			return
+		}
+
+		presentation, err := provider(req, record.MyDID, record.TheirDID)
+		if err != nil {
+			action.Stop(fmt.Errorf("provide presentation: %w", err))
+
+			return
+		}
+
+		action.Continue(presentproof.WithPresentation(presentation))
+	}
+}
+
+// exchange is the protocol instance launched by the onboarding.
+type exchange struct {
+	svc    protocolService
+	piID   string
+	send   func() error
+	answer func(action service.DIDCommAction)
+}
+
+// run sends the first message of the exchange, answers its actions until it reaches a terminal state
+// and returns that state.
+func (x *exchange) run(deadline time.Time) (string, error) {
+	// the callback must not block the protocol service
+	unregister, err := x.svc.RegisterThreadActionCallback(x.piID, func(action service.DIDCommAction) {
+		go x.answer(action)
+	})
+	if err != nil {
+		return "", fmt.Errorf("register action callback: %w", err)
+	}
+
+	defer unregister()
+
+	sub, err := service.SubscribeThread(x.svc, x.piID, service.WithTerminalStates(StateDone, StateAbandoning))
+	if err != nil {
+		return "", fmt.Errorf("subscribe thread: %w", err)
+	}
+
+	defer func() {
+		if err := sub.Close(); err != nil {
+			logger.Warnf("close subscription of thread %s: %v", x.piID, err)
+		}
+	}()
+
+	if err := x.send(); err != nil {
+		return "", fmt.Errorf("send first message: %w", err)
+	}
+
+	msg, err := sub.WaitForTerminalState(time.Until(deadline))
+	if err != nil {
+		return "", err
+	}
+
+	return msg.StateID, nil
+}
+
+// connectionWatcher keeps the latest state of the connections from the DID exchange events.
+type connectionWatcher struct {
+	mu      sync.Mutex
+	states  map[string]string
+	updates chan struct{}
+}
+
+func (w *connectionWatcher) notify(msg service.StateMsg) {
+	props, ok := msg.Properties.(connectionProperties)
+	if !ok || msg.Type != service.PostState {
+		return
+	}
+
+	w.mu.Lock()
+	w.states[props.ConnectionID()] = msg.StateID
+	w.mu.Unlock()
+
+	// the waiter re-reads the states, one pending update is enough
+	select {
+	case w.updates <- struct{}{}:
+	default:
+	}
+}
+
+func (w *connectionWatcher) state(connID string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.states[connID]
+}
+
+// wait waits for the connection to be completed, the invitation is accepted once the connection is invited.
+func (w *connectionWatcher) wait(connID string, deadline time.Time,
+	accept func(connectionID, publicDID, label string) error) error {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	accepted := false
+
+	for {
+		switch w.state(connID) {
+		case connectionCompleted:
+			return nil
+		case connectionAbandoned:
+			return ErrAbandoned
+		case connectionInvited:
+			if accepted {
+				break
+			}
+
+			accepted = true
+
+			// the agent may have approved the invitation meanwhile
+			if err := accept(connID, "", ""); err != nil && w.state(connID) == connectionInvited {
+				return fmt.Errorf("accept invitation: %w", err)
+			}
+		}
+
+		select {
+		case <-w.updates:
+		case <-timer.C:
+			return service.ErrWaitTimeout
+		}
+	}
+}
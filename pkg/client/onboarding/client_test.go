@@ -0,0 +1,432 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package onboarding
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/client/outofband"
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	oobsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofband"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	connID   = "conn-id"
+	myDID    = "did:example:holder"
+	theirDID = "did:example:issuer"
+)
+
+type connectionProps string
+
+func (p connectionProps) ConnectionID() string {
+	return string(p)
+}
+
+// inviter plays the DID exchange and out-of-band services of the invitee, as driven by the inviter.
+type inviter struct {
+	service.Message
+	recorder  *connection.Recorder
+	reuse     bool
+	abandon   bool
+	acceptErr error
+	accepted  int
+	mu        sync.Mutex
+}
+
+func (i *inviter) AcceptRequest(*oobsvc.Request) (string, error) {
+	if i.reuse {
+		return connID, i.complete()
+	}
+
+	go i.trigger("invited")
+
+	return connID, nil
+}
+
+func (i *inviter) AcceptInvitation(connectionID, _, _ string) error {
+	i.mu.Lock()
+	i.accepted++
+	i.mu.Unlock()
+
+	if connectionID != connID || i.acceptErr != nil {
+		return i.acceptErr
+	}
+
+	go func() {
+		if i.abandon {
+			i.trigger("abandoned")
+
+			return
+		}
+
+		if err := i.complete(); err == nil {
+			i.trigger("completed")
+		}
+	}()
+
+	return nil
+}
+
+func (i *inviter) complete() error {
+	return i.recorder.SaveConnectionRecord(&connection.Record{
+		ConnectionID: connID,
+		State:        "completed",
+		MyDID:        myDID,
+		TheirDID:     theirDID,
+	})
+}
+
+func (i *inviter) trigger(state string) {
+	i.TriggerMsgEvents(service.StateMsg{
+		ProtocolName: didexchange.DIDExchange,
+		Type:         service.PostState,
+		StateID:      state,
+		Properties:   connectionProps(connID),
+	})
+}
+
+// counterparty plays the issue credential or present proof service, as driven by the Issuer or the Verifier.
+type counterparty struct {
+	service.Action
+	service.Message
+	t       *testing.T
+	script  []string
+	sendErr error
+	decline bool
+	opts    []interface{}
+	mu      sync.Mutex
+}
+
+func (c *counterparty) HandleOutbound(msg service.DIDCommMsg, me, them string) error {
+	_, err := c.HandleInbound(msg, me, them)
+
+	return err
+}
+
+func (c *counterparty) HandleInbound(msg service.DIDCommMsg, me, them string) (string, error) {
+	require.Equal(c.t, myDID, me)
+	require.Equal(c.t, theirDID, them)
+
+	if c.sendErr != nil {
+		return "", c.sendErr
+	}
+
+	piID := msg.ID()
+	require.NotEmpty(c.t, piID)
+
+	// the action of another exchange is left to the handlers of the agent
+	c.TriggerActionEvent(service.DIDCommAction{
+		Message: reply("other", "other-thread"),
+		Continue: func(interface{}) {
+			c.t.Error("unexpected continue")
+		},
+		Stop: func(error) {
+			c.t.Error("unexpected stop")
+		},
+	})
+
+	go c.next(piID, c.script)
+
+	return piID, nil
+}
+
+// next triggers the action of the next message of the script, the exchange is done once the script is played.
+func (c *counterparty) next(piID string, script []string) {
+	if len(script) == 0 {
+		c.finish(piID, StateDone)
+
+		return
+	}
+
+	c.TriggerActionEvent(service.DIDCommAction{
+		Message: reply(script[0], piID),
+		Continue: func(opt interface{}) {
+			c.mu.Lock()
+			c.opts = append(c.opts, opt)
+			c.mu.Unlock()
+
+			if c.decline {
+				c.finish(piID, StateAbandoning)
+
+				return
+			}
+
+			c.next(piID, script[1:])
+		},
+		Stop: func(error) {
+			c.finish(piID, StateAbandoning)
+		},
+	})
+}
+
+func (c *counterparty) finish(piID, state string) {
+	c.TriggerMsgEvents(service.StateMsg{
+		Type:    service.PostState,
+		Msg:     reply("ack", piID),
+		StateID: state,
+	})
+}
+
+func reply(msgType, thID string) service.DIDCommMsgMap {
+	return service.DIDCommMsgMap{
+		"@id":     "reply-" + msgType,
+		"@type":   msgType,
+		"~thread": map[string]interface{}{"thid": thID},
+	}
+}
+
+func newProvider(i *inviter, issuer, verifier *counterparty) *mockprovider.Provider {
+	return &mockprovider.Provider{
+		ServiceMap: map[string]interface{}{
+			oobsvc.Name:             i,
+			didexchange.DIDExchange: i,
+			issuecredential.Name:    issuer,
+			presentproof.Name:       verifier,
+		},
+		StorageProviderValue:          mem.NewProvider(),
+		TransientStorageProviderValue: mem.NewProvider(),
+	}
+}
+
+func newClient(t *testing.T, i *inviter, issuer, verifier *counterparty) *Client {
+	t.Helper()
+
+	p := newProvider(i, issuer, verifier)
+
+	recorder, err := connection.NewRecorder(p)
+	require.NoError(t, err)
+
+	i.recorder = recorder
+
+	c, err := New(p)
+	require.NoError(t, err)
+
+	c.SetTimeout(time.Second)
+
+	return c
+}
+
+func invitation(goalCode string) *outofband.Request {
+	return &outofband.Request{Request: &oobsvc.Request{ID: "invitation", GoalCode: goalCode}}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c, err := New(newProvider(&inviter{}, &counterparty{}, &counterparty{}))
+		require.NoError(t, err)
+		require.NotNil(t, c)
+	})
+
+	t.Run("missing or invalid services", func(t *testing.T) {
+		for _, name := range []string{oobsvc.Name, didexchange.DIDExchange, issuecredential.Name, presentproof.Name} {
+			p := newProvider(&inviter{}, &counterparty{}, &counterparty{})
+			p.ServiceMap[name] = struct{}{}
+
+			_, err := New(p)
+			require.EqualError(t, err, "cast service "+name+" as a dependency")
+		}
+
+		_, err := New(&mockprovider.Provider{ServiceErr: errors.New("no service")})
+		require.EqualError(t, err, "look up service out-of-band: no service")
+	})
+
+	t.Run("connection lookup error", func(t *testing.T) {
+		p := newProvider(&inviter{}, &counterparty{}, &counterparty{})
+		p.StorageProviderValue = &mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")}
+
+		_, err := New(p)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create connection lookup")
+	})
+}
+
+func TestClient_Onboard(t *testing.T) {
+	t.Run("issue-vc", func(t *testing.T) {
+		i := &inviter{}
+		issuer := &counterparty{
+			t:      t,
+			script: []string{issuecredential.OfferCredentialMsgType, issuecredential.IssueCredentialMsgType},
+		}
+		c := newClient(t, i, issuer, &counterparty{})
+
+		c.RegisterAttributeProvider(func(req *outofband.Request) ([]issuecredential.Attribute, error) {
+			require.Equal(t, "invitation", req.ID)
+
+			return []issuecredential.Attribute{{Name: "name", Value: "Alice"}}, nil
+		})
+
+		completion, err := c.Onboard(invitation(GoalIssueVC))
+		require.NoError(t, err)
+		require.Equal(t, connID, completion.ConnectionID)
+		require.Equal(t, GoalIssueVC, completion.GoalCode)
+		require.Equal(t, StateDone, completion.State)
+		require.NotEmpty(t, completion.PIID)
+		require.Equal(t, 1, i.accepted)
+		require.Len(t, issuer.opts, 2)
+	})
+
+	t.Run("request-proof", func(t *testing.T) {
+		verifier := &counterparty{t: t, script: []string{presentproof.RequestPresentationMsgType}}
+		c := newClient(t, &inviter{reuse: true}, &counterparty{}, verifier)
+
+		presentation := &presentproof.Presentation{Comment: "presentation"}
+
+		c.RegisterCredentialProvider(func(_ *presentproof.RequestPresentation, me, them string) (
+			*presentproof.Presentation, error) {
+			require.Equal(t, myDID, me)
+			require.Equal(t, theirDID, them)
+
+			return presentation, nil
+		})
+
+		completion, err := c.Onboard(invitation(GoalRequestProof))
+		require.NoError(t, err)
+		require.Equal(t, StateDone, completion.State)
+		require.Len(t, verifier.opts, 1)
+		require.IsType(t, presentproof.Opt(nil), verifier.opts[0])
+	})
+
+	t.Run("request-proof without credential provider", func(t *testing.T) {
+		verifier := &counterparty{t: t, script: []string{presentproof.RequestPresentationMsgType}}
+		c := newClient(t, &inviter{}, &counterparty{}, verifier)
+
+		completion, err := c.Onboard(invitation(GoalRequestProof))
+		require.True(t, errors.Is(err, ErrAbandoned))
+		require.Equal(t, StateAbandoning, completion.State)
+	})
+
+	t.Run("unexpected message", func(t *testing.T) {
+		issuer := &counterparty{t: t, script: []string{issuecredential.RequestCredentialMsgType}}
+		c := newClient(t, &inviter{}, issuer, &counterparty{})
+
+		_, err := c.Onboard(invitation(GoalIssueVC))
+		require.True(t, errors.Is(err, ErrAbandoned))
+	})
+
+	t.Run("offer declined by the issuer", func(t *testing.T) {
+		issuer := &counterparty{t: t, script: []string{issuecredential.OfferCredentialMsgType}, decline: true}
+		c := newClient(t, &inviter{}, issuer, &counterparty{})
+
+		completion, err := c.Onboard(invitation(GoalIssueVC))
+		require.EqualError(t, err, "onboarding abandoned: issue-vc exchange "+completion.PIID)
+		require.Equal(t, StateAbandoning, completion.State)
+	})
+
+	t.Run("unsupported goal", func(t *testing.T) {
+		c := newClient(t, &inviter{}, &counterparty{}, &counterparty{})
+
+		_, err := c.Onboard(invitation("buy-coffee"))
+		require.True(t, errors.Is(err, ErrUnsupportedGoal))
+
+		_, err = c.Onboard(nil)
+		require.EqualError(t, err, "empty out-of-band request")
+	})
+
+	t.Run("connection abandoned", func(t *testing.T) {
+		c := newClient(t, &inviter{abandon: true}, &counterparty{}, &counterparty{})
+
+		_, err := c.Onboard(invitation(GoalIssueVC))
+		require.EqualError(t, err, "connection conn-id: onboarding abandoned")
+	})
+
+	t.Run("accept invitation error", func(t *testing.T) {
+		c := newClient(t, &inviter{acceptErr: errors.New("accept error")}, &counterparty{}, &counterparty{})
+
+		_, err := c.Onboard(invitation(GoalIssueVC))
+		require.EqualError(t, err, "connection conn-id: accept invitation: accept error")
+	})
+
+	t.Run("connection timeout", func(t *testing.T) {
+		i := &inviter{}
+		c := newClient(t, i, &counterparty{}, &counterparty{})
+		c.didexchange = &lateInviter{inviter: i}
+		c.SetTimeout(10 * time.Millisecond)
+
+		_, err := c.Onboard(invitation(GoalIssueVC))
+		require.True(t, errors.Is(err, service.ErrWaitTimeout))
+	})
+
+	t.Run("attribute provider error", func(t *testing.T) {
+		c := newClient(t, &inviter{reuse: true}, &counterparty{}, &counterparty{})
+		c.RegisterAttributeProvider(func(*outofband.Request) ([]issuecredential.Attribute, error) {
+			return nil, errors.New("no attributes")
+		})
+
+		_, err := c.Onboard(invitation(GoalIssueVC))
+		require.EqualError(t, err, "provide attributes: no attributes")
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		issuer := &counterparty{t: t, sendErr: errors.New("send error")}
+		c := newClient(t, &inviter{reuse: true}, issuer, &counterparty{})
+
+		completion, err := c.Onboard(invitation(GoalIssueVC))
+		require.Nil(t, completion)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "send first message: send error")
+	})
+
+	t.Run("actions of the other threads", func(t *testing.T) {
+		issuer := &counterparty{
+			t:      t,
+			script: []string{issuecredential.OfferCredentialMsgType, issuecredential.IssueCredentialMsgType},
+		}
+
+		// the actions of the other exchanges reach the callback of the agent
+		others := make(chan string, 1)
+		require.NoError(t, issuer.RegisterActionCallback(func(action service.DIDCommAction) {
+			thID, err := action.Message.ThreadID()
+			require.NoError(t, err)
+
+			others <- thID
+		}))
+
+		c := newClient(t, &inviter{reuse: true}, issuer, &counterparty{})
+
+		completion, err := c.Onboard(invitation(GoalIssueVC))
+		require.NoError(t, err)
+		require.Equal(t, StateDone, completion.State)
+		require.Equal(t, "other-thread", <-others)
+	})
+
+	t.Run("thread callback already registered", func(t *testing.T) {
+		issuer := &counterparty{t: t}
+		c := newClient(t, &inviter{reuse: true}, issuer, &counterparty{})
+
+		// the ID of the protocol instance is generated by the onboarding
+		c.idGenerator = idgen.GeneratorFunc(func() string { return "thread" })
+
+		_, err := issuer.RegisterThreadActionCallback("thread", func(service.DIDCommAction) {})
+		require.NoError(t, err)
+
+		_, err = c.Onboard(invitation(GoalIssueVC))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "register action callback")
+	})
+}
+
+// lateInviter never accepts the invitation.
+type lateInviter struct {
+	*inviter
+}
+
+func (l *lateInviter) AcceptInvitation(string, string, string) error {
+	return nil
+}
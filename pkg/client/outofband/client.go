@@ -10,8 +10,7 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofband"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
@@ -22,6 +21,12 @@ import (
 const (
 	// RequestMsgType is the request message's '@type'.
 	RequestMsgType = outofband.RequestMsgType
+	// HandshakeDIDExchange10 identifies the DID exchange 1.0 handshake protocol.
+	HandshakeDIDExchange10 = outofband.HandshakeDIDExchange10
+	// HandshakeDIDExchange11 identifies the DID exchange 1.1 handshake protocol.
+	HandshakeDIDExchange11 = outofband.HandshakeDIDExchange11
+	// HandshakeConnections10 identifies the connections 1.0 handshake protocol.
+	HandshakeConnections10 = outofband.HandshakeConnections10
 )
 
 // RequestOptions allow you to customize the way request messages are built.
@@ -44,6 +49,7 @@ type Provider interface {
 type Client struct {
 	didDocSvcFunc func() (*did.Service, error)
 	oobService    oobService
+	idGenerator   idgen.Generator
 }
 
 // New returns a new Client for the Out-Of-Band protocol.
@@ -61,6 +67,7 @@ func New(p Provider) (*Client, error) {
 	return &Client{
 		didDocSvcFunc: didServiceBlockFunc(p),
 		oobService:    oobSvc,
+		idGenerator:   idgen.FromProvider(p),
 	}, nil
 }
 
@@ -90,7 +97,11 @@ func (c *Client) CreateRequest(opts ...RequestOptions) (*Request, error) {
 		req.Service = []interface{}{svc}
 	}
 
-	req.ID = uuid.New().String()
+	if len(req.HandshakeProtocols) == 0 {
+		req.HandshakeProtocols = HandshakeProtocols()
+	}
+
+	req.ID = c.idGenerator.NewID()
 	req.Type = RequestMsgType
 
 	err := c.oobService.SaveRequest(req.Request)
@@ -104,13 +115,14 @@ func (c *Client) CreateRequest(opts ...RequestOptions) (*Request, error) {
 // AcceptRequest from another agent and return the ID of a new connection record.
 func (c *Client) AcceptRequest(r *Request) (string, error) {
 	connID, err := c.oobService.AcceptRequest(&outofband.Request{
-		ID:       r.ID,
-		Type:     r.Type,
-		Label:    r.Label,
-		Goal:     r.Goal,
-		GoalCode: r.GoalCode,
-		Requests: r.Requests,
-		Service:  r.Service,
+		ID:                 r.ID,
+		Type:               r.Type,
+		Label:              r.Label,
+		Goal:               r.Goal,
+		GoalCode:           r.GoalCode,
+		Requests:           r.Requests,
+		Service:            r.Service,
+		HandshakeProtocols: r.HandshakeProtocols,
 	})
 	if err != nil {
 		return "", fmt.Errorf("out-of-band service failed to accept request : %w", err)
@@ -145,6 +157,26 @@ func WithGoal(goal, goalCode string) RequestOptions {
 	}
 }
 
+// HandshakeProtocols returns the handshake protocols supported by this agent, in preference order.
+func HandshakeProtocols() []string {
+	return outofband.HandshakeProtocols()
+}
+
+// WithHandshakeProtocols allows you to specify the protocols the receiver can use to establish the connection,
+// in preference order. The receiver selects the first one it supports. The handshake protocols supported by this
+// agent are offered if none are specified (see HandshakeProtocols).
+func WithHandshakeProtocols(protocols ...string) RequestOptions {
+	return func(r *Request) error {
+		if len(protocols) == 0 {
+			return errors.New("must provide at least one handshake protocol")
+		}
+
+		r.HandshakeProtocols = protocols
+
+		return nil
+	}
+}
+
 // WithServices allows you to specify service entries to include in the request message.
 // Each entry must be either a valid DID (string) or a `service` object.
 func WithServices(svcs ...interface{}) RequestOptions {
@@ -202,7 +234,7 @@ func didServiceBlockFunc(p Provider) func() (*did.Service, error) {
 		}
 
 		svc := &did.Service{
-			ID:              uuid.New().String(),
+			ID:              idgen.FromProvider(p).NewID(),
 			Type:            "did-communication",
 			Priority:        0,
 			RecipientKeys:   []string{verKey},
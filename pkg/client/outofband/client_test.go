@@ -177,6 +177,30 @@ func TestCreateRequest(t *testing.T) {
 			WithServices(unsupported))
 		require.Error(t, err)
 	})
+	t.Run("includes the handshake protocols", func(t *testing.T) {
+		c, err := New(withTestProvider())
+		require.NoError(t, err)
+		req, err := c.CreateRequest(
+			WithAttachments(dummyAttachment(t)),
+			WithHandshakeProtocols(HandshakeDIDExchange11, HandshakeDIDExchange10),
+		)
+		require.NoError(t, err)
+		require.Equal(t, []string{HandshakeDIDExchange11, HandshakeDIDExchange10}, req.HandshakeProtocols)
+	})
+	t.Run("offers the supported handshake protocols by default", func(t *testing.T) {
+		c, err := New(withTestProvider())
+		require.NoError(t, err)
+		req, err := c.CreateRequest(WithAttachments(dummyAttachment(t)))
+		require.NoError(t, err)
+		require.Equal(t, HandshakeProtocols(), req.HandshakeProtocols)
+		require.Contains(t, req.HandshakeProtocols, HandshakeDIDExchange10)
+	})
+	t.Run("fails when no handshake protocol is provided", func(t *testing.T) {
+		c, err := New(withTestProvider())
+		require.NoError(t, err)
+		_, err = c.CreateRequest(WithAttachments(dummyAttachment(t)), WithHandshakeProtocols())
+		require.Error(t, err)
+	})
 	t.Run("wraps did service block creation error when KMS fails", func(t *testing.T) {
 		expected := errors.New("test")
 		provider := withTestProvider()
@@ -238,6 +262,23 @@ func TestAcceptRequest(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, expected, result)
 	})
+	t.Run("passes the handshake protocols", func(t *testing.T) {
+		var offered []string
+		provider := withTestProvider()
+		provider.ServiceMap = map[string]interface{}{
+			outofband.Name: &stubOOBService{
+				acceptReqFunc: func(r *outofband.Request) (string, error) {
+					offered = r.HandshakeProtocols
+					return "", nil
+				},
+			},
+		}
+		c, err := New(provider)
+		require.NoError(t, err)
+		_, err = c.AcceptRequest(&Request{&outofband.Request{HandshakeProtocols: []string{HandshakeDIDExchange10}}})
+		require.NoError(t, err)
+		require.Equal(t, []string{HandshakeDIDExchange10}, offered)
+	})
 	t.Run("wraps error from outofband service", func(t *testing.T) {
 		expected := errors.New("test")
 		provider := withTestProvider()
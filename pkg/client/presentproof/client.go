@@ -8,9 +8,16 @@ package presentproof
 
 import (
 	"errors"
+	"fmt"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
 
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/vdri/peer"
 )
 
 type (
@@ -22,31 +29,154 @@ type (
 	// presentation process, or in response to a request-presentation message when the Prover wants to
 	// propose using a different presentation format.
 	ProposePresentation presentproof.ProposePresentation
+	// VerificationResult is the result of the verification of one of the presentations of a Presentation message.
+	VerificationResult = presentproof.VerificationResult
+	// VerificationMetrics is the instrumentation hook of the verification of the received presentations.
+	VerificationMetrics = presentproof.VerificationMetrics
+	// PresentationPreview is the preview of the presentation proposed by a ProposePresentation message:
+	// the attributes and predicates the Prover is willing to present.
+	PresentationPreview = presentproof.PresentationPreview
+	// Attribute is an attribute of a PresentationPreview.
+	Attribute = presentproof.Attribute
+	// Predicate is a predicate of a PresentationPreview.
+	Predicate = presentproof.Predicate
+	// AuditLog records the exchanges reaching the done state, e.g an audit.Store.
+	AuditLog = presentproof.AuditLog
+	// RerequestReason is the content of the reason attachment (ReasonAttachmentID) of the RequestPresentation
+	// message requesting the presentations again.
+	RerequestReason = presentproof.RerequestReason
+	// ProblemReportError is the error of an exchange abandoned by the other agent, see ErrorFromProblemCode.
+	ProblemReportError = presentproof.ProblemReportError
+)
+
+// Codes of the problem-report message of an abandoned exchange, telling the cause of abandoning it.
+const (
+	// CodeNoMatchingCredential is reported by the Prover holding no credential matching the request.
+	CodeNoMatchingCredential = presentproof.CodeNoMatchingCredential
+	// CodeVerificationFailed is reported by the Verifier when a received presentation is not verified.
+	CodeVerificationFailed = presentproof.CodeVerificationFailed
+	// CodePolicyRejected is reported when the action is declined by an action policy.
+	CodePolicyRejected = presentproof.CodePolicyRejected
+	// CodeExpiredRequest is reported when the expires_time of the request-presentation message is reached.
+	CodeExpiredRequest = presentproof.CodeExpiredRequest
 )
 
+// Errors of the causes of abandoning an exchange, wrapped by the error returned by ErrorFromProblemCode.
+// Declining a request-presentation with an error wrapping ErrNoMatchingCredential (e.g returned by the
+// PresentationSupplier of AutoAcceptRequestPresentation) reports the no-matching-credential code.
+var (
+	ErrNoMatchingCredential = presentproof.ErrNoMatchingCredential
+	ErrVerificationFailed   = presentproof.ErrVerificationFailed
+	ErrPolicyRejected       = presentproof.ErrPolicyRejected
+	ErrExpiredRequest       = presentproof.ErrExpiredRequest
+)
+
+// ReasonAttachmentID is the ID of the reason attachment of the RequestPresentation message requesting
+// the presentations again.
+const ReasonAttachmentID = presentproof.ReasonAttachmentID
+
+// RequestAllAvailable is the RequestedCount of the requests asking the Prover to present all the matching
+// credentials it holds.
+const RequestAllAvailable = presentproof.RequestAllAvailable
+
+const peerMethod = "peer"
+
 var (
 	errEmptyRequestPresentation = errors.New("request presentation message is empty")
 	errEmptyProposePresentation = errors.New("propose presentation message is empty")
+	errPeerDIDNotSupported      = errors.New("peer DIDs are not supported: the provider has no VDRI registry")
 )
 
+// ConnectionProperties are the properties of all the action events.
+// ConnectionID returns the ID of the connection the exchange is associated with, empty if the DIDs
+// of the exchange are not those of a connection.
+type ConnectionProperties interface {
+	ConnectionID() string
+}
+
+// VerificationResultsProperties are the properties of the Presentation action event.
+// VerificationResults returns the verification result of each presentation, as well as the requested
+// presentations which were not provided (matched by the IDs of the request attachments).
+type VerificationResultsProperties interface {
+	VerificationResults() []VerificationResult
+}
+
+// PresentationsProperties are the properties of the Presentation action event added by the version 2
+// of the event properties (see aries.WithEventPayloadVersion).
+// Presentations returns the verified presentations of the received Presentation message.
+type PresentationsProperties interface {
+	Presentations() []*verifiable.Presentation
+}
+
+// PresentationPreviewProperties are the properties of the ProposePresentation action event.
+// PresentationPreview returns the presentation preview of the received proposal, the Verifier may build the
+// request answering the proposal from it (see RequestPresentationFromPreview).
+type PresentationPreviewProperties interface {
+	PresentationPreview() *PresentationPreview
+}
+
+// MultipleAvailableProperties are the properties of the ProposePresentation action event.
+// MultipleAvailable returns the number of matching credentials the Prover holds (ProposePresentation
+// MultipleAvailable), the Verifier may then request several or all of them (RequestPresentation RequestedCount).
+type MultipleAvailableProperties interface {
+	MultipleAvailable() int
+}
+
+// RequestedCountProperties are the properties of the RequestPresentation action event.
+// RequestedCount returns the number of matching credentials the Prover is requested to present,
+// RequestAllAvailable for all of them and 0 if the Verifier did not state it (a single credential).
+type RequestedCountProperties interface {
+	RequestedCount() int
+}
+
+// ProblemReportProperties are the properties of the state-change events of the abandoning state.
+// ProblemCode returns the code of the problem-report sent or received when the exchange was abandoned,
+// the error of a code received from the other agent is returned by ErrorFromProblemCode.
+type ProblemReportProperties interface {
+	ProblemCode() string
+}
+
 // Provider contains dependencies for the protocol and is typically created by using aries.Context()
+// The peer DIDs of the exchanges and the presentations created by the client require the provider to
+// also give access to the VDRI registry, the storage provider and the signer of the agent (as aries.Context() does).
 type Provider interface {
 	Service(id string) (interface{}, error)
 }
 
+// vdriRegistryProvider is implemented by the providers giving access to the VDRI registry (e.g aries.Context()),
+// the exchanges are created from peer DIDs only if the provider implements it.
+type vdriRegistryProvider interface {
+	VDRIRegistry() vdriapi.Registry
+}
+
 // ProtocolService defines the presentproof service.
 type ProtocolService interface {
 	service.DIDComm
+	service.CallbackEvent
 	Actions() ([]presentproof.Action, error)
 	ActionContinue(piID string, opt presentproof.Opt) error
 	ActionStop(piID string, err error) error
+	ActionPause(piID string) (string, error)
+	ActionResume(token string) (*presentproof.Action, error)
+	AddActionPolicies(policies ...presentproof.ActionPolicy)
+	SetExchangeTimeout(timeout time.Duration)
+	SetVerificationWorkers(workers int)
+	SetVerificationRetries(retries int, backoff time.Duration)
+	SetVerificationMetrics(metrics presentproof.VerificationMetrics)
+	SetAuditLog(log presentproof.AuditLog)
 }
 
 // Client enable access to presentproof API
 // https://github.com/hyperledger/aries-rfcs/tree/master/features/0037-present-proof
 type Client struct {
 	service.Event
-	service ProtocolService
+	// CallbackEvent allows consuming the events with callbacks rather than channels (e.g on the JS/WASM target)
+	service.CallbackEvent
+	service      ProtocolService
+	vdriRegistry vdriapi.Registry
+	// presentationProvider and documentLoader create the presentations, see CreatePresentationForRequest
+	presentationProvider presentationProvider
+	documentLoader       ld.DocumentLoader
 }
 
 // New returns new instance of the presentproof client
@@ -61,10 +191,31 @@ func New(ctx Provider) (*Client, error) {
 		return nil, errors.New("cast service to presentproof service failed")
 	}
 
-	return &Client{
-		Event:   svc,
-		service: svc,
-	}, nil
+	client := &Client{
+		Event:         svc,
+		CallbackEvent: svc,
+		service:       svc,
+	}
+
+	if vp, ok := ctx.(vdriRegistryProvider); ok {
+		client.vdriRegistry = vp.VDRIRegistry()
+	}
+
+	if pp, ok := ctx.(presentationProvider); ok {
+		client.presentationProvider = pp
+	}
+
+	if dp, ok := ctx.(documentLoaderProvider); ok {
+		client.documentLoader = dp.JSONLDDocumentLoader()
+	}
+
+	return client, nil
+}
+
+// ErrorFromProblemCode returns the error of an exchange abandoned by the other agent with the given problem code
+// (see ProblemReportProperties), errors.Is tells the cause of abandoning the exchange, e.g ErrVerificationFailed.
+func ErrorFromProblemCode(code string) error {
+	return presentproof.ErrorFromProblemCode(code)
 }
 
 // Actions returns pending actions that have yet to be executed or cancelled.
@@ -72,7 +223,23 @@ func (c *Client) Actions() ([]presentproof.Action, error) {
 	return c.service.Actions()
 }
 
+// PauseAction parks the action by the piID (e.g the user has to find a document before accepting a request)
+// and returns the token the action is resumed with later, even after a restart of the agent.
+// A paused action can not be accepted or declined until it is resumed (see ResumeAction).
+func (c *Client) PauseAction(piID string) (string, error) {
+	return c.service.ActionPause(piID)
+}
+
+// ResumeAction resumes the action paused with the token and returns it, the action is then accepted or declined
+// by its PIID. The token can only be used once.
+func (c *Client) ResumeAction(token string) (*presentproof.Action, error) {
+	return c.service.ActionResume(token)
+}
+
 // SendRequestPresentation is used by the Verifier to send a request presentation.
+// Several presentations may be requested in a single exchange by attachments with unique IDs,
+// the verification result of each presentation is reported by the Presentation action event
+// (see VerificationResultsProperties and Action.VerificationResults).
 func (c *Client) SendRequestPresentation(msg *RequestPresentation, myDID, theirDID string) error {
 	if msg == nil {
 		return errEmptyRequestPresentation
@@ -85,13 +252,34 @@ func (c *Client) SendRequestPresentation(msg *RequestPresentation, myDID, theirD
 	return err
 }
 
+// SendRequestPresentationWithPeerDID is used by the Verifier to send a request presentation from a freshly
+// generated peer DID (numalgo 2) rather than a pre-established one, e.g for a connection-less exchange.
+// It returns the generated DID.
+func (c *Client) SendRequestPresentationWithPeerDID(msg *RequestPresentation, theirDID string) (string, error) {
+	if msg == nil {
+		return "", errEmptyRequestPresentation
+	}
+
+	myDID, err := c.newPeerDID()
+	if err != nil {
+		return "", err
+	}
+
+	return myDID, c.SendRequestPresentation(msg, myDID, theirDID)
+}
+
 // AcceptRequestPresentation is used by the Prover is to accept a presentation request.
+// The IDs of the presentation attachments must match the IDs of the requested presentations.
 func (c *Client) AcceptRequestPresentation(piID string, msg *Presentation) error {
 	return c.service.ActionContinue(piID, WithPresentation(msg))
 }
 
 // NegotiateRequestPresentation is used by the Prover to counter a presentation request they received with a proposal.
 func (c *Client) NegotiateRequestPresentation(piID string, msg *ProposePresentation) error {
+	if msg != nil {
+		setPreviewType(&msg.PresentationProposal)
+	}
+
 	return c.service.ActionContinue(piID, WithProposePresentation(msg))
 }
 
@@ -107,17 +295,48 @@ func (c *Client) SendProposePresentation(msg *ProposePresentation, myDID, theirD
 	}
 
 	msg.Type = presentproof.ProposePresentationMsgType
+	setPreviewType(&msg.PresentationProposal)
 
 	_, err := c.service.HandleInbound(service.NewDIDCommMsgMap(msg), myDID, theirDID)
 
 	return err
 }
 
+// SendProposePresentationWithPeerDID is used by the Prover to send a propose presentation from a freshly
+// generated peer DID (numalgo 2) rather than a pre-established one, e.g for a connection-less exchange.
+// It returns the generated DID.
+func (c *Client) SendProposePresentationWithPeerDID(msg *ProposePresentation, theirDID string) (string, error) {
+	if msg == nil {
+		return "", errEmptyProposePresentation
+	}
+
+	myDID, err := c.newPeerDID()
+	if err != nil {
+		return "", err
+	}
+
+	return myDID, c.SendProposePresentation(msg, myDID, theirDID)
+}
+
 // AcceptProposePresentation is used when the Verifier is willing to accept the propose presentation.
 func (c *Client) AcceptProposePresentation(piID string, msg *RequestPresentation) error {
 	return c.service.ActionContinue(piID, WithRequestPresentation(msg))
 }
 
+// RequestPresentationFromPreview builds the request presentation answering a proposal from its presentation
+// preview: the attributes and predicates of the preview are requested from the credentials of the preview
+// by a presentation definition, the Prover answers it with CreatePresentationForRequest and the presentation
+// is checked against it. The returned request is accepted with AcceptProposePresentation, the name is the name
+// of the presentation definition.
+func RequestPresentationFromPreview(preview *PresentationPreview, name string) (*RequestPresentation, error) {
+	request, err := presentproof.RequestPresentationFromPreview(preview, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*RequestPresentation)(request), nil
+}
+
 // DeclineProposePresentation is used when the Verifier does not want to accept the propose presentation.
 func (c *Client) DeclineProposePresentation(piID, reason string) error {
 	return c.service.ActionStop(piID, errors.New(reason))
@@ -133,6 +352,85 @@ func (c *Client) DeclinePresentation(piID, reason string) error {
 	return c.service.ActionStop(piID, errors.New(reason))
 }
 
+// RerequestPresentation is used by the Verifier to request the presentations again in the same thread,
+// instead of declining a presentation which failed the verification. The reason and the verification results
+// are attached to the request, the Prover receives it as a new RequestPresentation action event.
+// NOTE: For async usage. This function can be used only after receiving Presentation
+func (c *Client) RerequestPresentation(piID string, msg *RequestPresentation, reason string) error {
+	if msg == nil {
+		return errors.New("rerequest presentation: the request presentation is required")
+	}
+
+	return c.service.ActionContinue(piID, WithRerequestPresentation(msg, reason))
+}
+
+// AddActionPolicies registers policies executing the actions automatically, without an action event to consume.
+// Policies are evaluated in the registration order and the first one deciding on an action wins.
+func (c *Client) AddActionPolicies(policies ...presentproof.ActionPolicy) {
+	c.service.AddActionPolicies(policies...)
+}
+
+// SetExchangeTimeout sets the duration after which an exchange without response is abandoned (disabled by default).
+// The Verifier may also set the expiration of a single exchange with the ~timing decorator of the request
+// presentation (expires_time).
+func (c *Client) SetExchangeTimeout(timeout time.Duration) {
+	c.service.SetExchangeTimeout(timeout)
+}
+
+// SetVerificationWorkers sets the maximum number of received presentations verified concurrently (8 by default),
+// the presentations of all the exchanges are verified by the same workers.
+func (c *Client) SetVerificationWorkers(workers int) {
+	c.service.SetVerificationWorkers(workers)
+}
+
+// SetVerificationRetries sets how many times the verification of a received presentation is retried when the DID
+// resolution fails for a transient reason, e.g a network timeout (2 by default, 5 at most), and the delay before
+// the first retry, doubled at each retry (500ms by default). The retries stop once the delays would exceed 30s,
+// the exchange is abandoned if the verification still fails.
+func (c *Client) SetVerificationRetries(retries int, backoff time.Duration) {
+	c.service.SetVerificationRetries(retries, backoff)
+}
+
+// SetVerificationMetrics sets the instrumentation hook reporting the verification latency and the number of
+// presentations waiting for a verification worker.
+func (c *Client) SetVerificationMetrics(metrics VerificationMetrics) {
+	c.service.SetVerificationMetrics(metrics)
+}
+
+// SetAuditLog sets the audit log recording a tamper-evident record of each exchange reaching the done state,
+// e.g an audit.Store exporting the records as JSON lines. A nil log disables the audit (the default).
+func (c *Client) SetAuditLog(log AuditLog) {
+	c.service.SetAuditLog(log)
+}
+
+// PresentationSupplier supplies the presentation answering a request presentation.
+type PresentationSupplier func(req *RequestPresentation, myDID, theirDID string) (*Presentation, error)
+
+// AutoAcceptRequestPresentation is a policy used by the Prover to answer the request presentations
+// with the presentation provided by the supplier. The request is declined if the supplier returns an error.
+func AutoAcceptRequestPresentation(supplier PresentationSupplier) presentproof.ActionPolicy {
+	return presentproof.AutoAcceptRequestPresentation(
+		func(req *presentproof.RequestPresentation, myDID, theirDID string) (*presentproof.Presentation, error) {
+			msg, err := supplier((*RequestPresentation)(req), myDID, theirDID)
+			if err != nil {
+				return nil, err
+			}
+
+			return (*presentproof.Presentation)(msg), nil
+		},
+	)
+}
+
+// AutoAcceptPresentation is a policy used by the Verifier to accept the presentations.
+func AutoAcceptPresentation() presentproof.ActionPolicy {
+	return presentproof.AutoAcceptPresentation()
+}
+
+// AutoDeclineUnknownDIDs is a policy declining the actions received from the DIDs for which isKnown returns false.
+func AutoDeclineUnknownDIDs(isKnown func(theirDID string) bool) presentproof.ActionPolicy {
+	return presentproof.AutoDeclineUnknownDIDs(isKnown)
+}
+
 // WithPresentation allows providing Presentation message
 // Use this option to respond to RequestPresentation
 func WithPresentation(msg *Presentation) presentproof.Opt {
@@ -153,3 +451,35 @@ func WithRequestPresentation(msg *RequestPresentation) presentproof.Opt {
 	origin := presentproof.RequestPresentation(*msg)
 	return presentproof.WithRequestPresentation(&origin)
 }
+
+// WithRerequestPresentation allows providing RequestPresentation message requesting the presentations again
+// Use this option to respond to Presentation
+func WithRerequestPresentation(msg *RequestPresentation, reason string) presentproof.Opt {
+	if msg == nil {
+		// the exchange is abandoned
+		return presentproof.WithRerequestPresentation(nil, reason)
+	}
+
+	origin := presentproof.RequestPresentation(*msg)
+	return presentproof.WithRerequestPresentation(&origin, reason)
+}
+
+// newPeerDID creates an ephemeral peer DID for an exchange, its doc is resolved from the DID itself.
+func (c *Client) newPeerDID() (string, error) {
+	if c.vdriRegistry == nil {
+		return "", errPeerDIDNotSupported
+	}
+
+	doc, err := c.vdriRegistry.Create(peerMethod, vdriapi.WithNumAlgo(peer.NumAlgo2))
+	if err != nil {
+		return "", fmt.Errorf("create peer DID: %w", err)
+	}
+
+	return doc.ID, nil
+}
+
+func setPreviewType(preview *PresentationPreview) {
+	if len(preview.Attributes)+len(preview.Predicates) > 0 {
+		preview.Type = presentproof.PresentationPreviewMsgType
+	}
+}
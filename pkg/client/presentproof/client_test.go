@@ -9,13 +9,20 @@ package presentproof
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	mocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/client/presentproof"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/vdri/peer"
 )
 
 const (
@@ -99,6 +106,29 @@ func TestClient_SendProposePresentation(t *testing.T) {
 		require.NoError(t, client.SendProposePresentation(&ProposePresentation{}, Alice, Bob))
 	})
 
+	t.Run("Presentation preview", func(t *testing.T) {
+		provider := mocks.NewMockProvider(ctrl)
+
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().HandleInbound(gomock.Any(), Alice, Bob).
+			DoAndReturn(func(msg service.DIDCommMsg, _, _ string) (string, error) {
+				var proposal ProposePresentation
+				require.NoError(t, msg.Decode(&proposal))
+				require.Equal(t, presentproof.PresentationPreviewMsgType, proposal.PresentationProposal.Type)
+				require.Equal(t, "name", proposal.PresentationProposal.Attributes[0].Name)
+
+				return "", nil
+			})
+
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+		client, err := New(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, client.SendProposePresentation(&ProposePresentation{
+			PresentationProposal: PresentationPreview{Attributes: []Attribute{{Name: "name", Value: "Alice"}}},
+		}, Alice, Bob))
+	})
+
 	t.Run("Empty Request Presentation", func(t *testing.T) {
 		provider := mocks.NewMockProvider(ctrl)
 
@@ -110,6 +140,98 @@ func TestClient_SendProposePresentation(t *testing.T) {
 	})
 }
 
+// registryProvider gives access to the VDRI registry
+type registryProvider struct {
+	*mocks.MockProvider
+	registry vdriapi.Registry
+}
+
+func (p *registryProvider) VDRIRegistry() vdriapi.Registry {
+	return p.registry
+}
+
+func TestClient_SendWithPeerDID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const peerDID = "did:peer:2.Vz6MkqRYqQiSgvZQdnBytw86Qbs2ZWUkGv22od935YF4s8M7V"
+
+	registry := &mockvdri.MockVDRIRegistry{
+		CreateFunc: func(method string, opts ...vdriapi.DocOpts) (*did.Doc, error) {
+			docOpts := &vdriapi.CreateDIDOpts{}
+			for _, opt := range opts {
+				opt(docOpts)
+			}
+
+			require.Equal(t, "peer", method)
+			require.Equal(t, peer.NumAlgo2, docOpts.NumAlgo)
+
+			return &did.Doc{ID: peerDID}, nil
+		},
+	}
+
+	t.Run("request presentation", func(t *testing.T) {
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().HandleInbound(gomock.Any(), peerDID, Bob).Return("", nil)
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+		client, err := New(&registryProvider{MockProvider: provider, registry: registry})
+		require.NoError(t, err)
+
+		myDID, err := client.SendRequestPresentationWithPeerDID(&RequestPresentation{}, Bob)
+		require.NoError(t, err)
+		require.Equal(t, peerDID, myDID)
+
+		_, err = client.SendRequestPresentationWithPeerDID(nil, Bob)
+		require.EqualError(t, err, errEmptyRequestPresentation.Error())
+	})
+
+	t.Run("propose presentation", func(t *testing.T) {
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().HandleInbound(gomock.Any(), peerDID, Bob).Return("", nil)
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+		client, err := New(&registryProvider{MockProvider: provider, registry: registry})
+		require.NoError(t, err)
+
+		myDID, err := client.SendProposePresentationWithPeerDID(&ProposePresentation{}, Bob)
+		require.NoError(t, err)
+		require.Equal(t, peerDID, myDID)
+
+		_, err = client.SendProposePresentationWithPeerDID(nil, Bob)
+		require.EqualError(t, err, errEmptyProposePresentation.Error())
+	})
+
+	t.Run("create error", func(t *testing.T) {
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(mocks.NewMockProtocolService(ctrl), nil)
+
+		client, err := New(&registryProvider{
+			MockProvider: provider,
+			registry:     &mockvdri.MockVDRIRegistry{CreateErr: errors.New("test")},
+		})
+		require.NoError(t, err)
+
+		_, err = client.SendRequestPresentationWithPeerDID(&RequestPresentation{}, Bob)
+		require.EqualError(t, err, "create peer DID: test")
+	})
+
+	t.Run("no registry", func(t *testing.T) {
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(mocks.NewMockProtocolService(ctrl), nil)
+
+		client, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = client.SendProposePresentationWithPeerDID(&ProposePresentation{}, Bob)
+		require.EqualError(t, err, errPeerDIDNotSupported.Error())
+	})
+}
+
 func TestClient_AcceptRequestPresentation(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -206,6 +328,25 @@ func TestClient_DeclinePresentation(t *testing.T) {
 	require.NoError(t, client.DeclinePresentation("PIID", "declined"))
 }
 
+func TestClient_RerequestPresentation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().ActionContinue("PIID", gomock.Any()).Return(nil)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	require.NoError(t, client.RerequestPresentation("PIID", &RequestPresentation{}, "expired credential"))
+	require.EqualError(t, client.RerequestPresentation("PIID", nil, "expired credential"),
+		"rerequest presentation: the request presentation is required")
+	require.NotNil(t, WithRerequestPresentation(nil, "expired credential"))
+}
+
 func TestClient_NegotiateProposePresentation(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -221,3 +362,200 @@ func TestClient_NegotiateProposePresentation(t *testing.T) {
 
 	require.NoError(t, client.NegotiateRequestPresentation("PIID", &ProposePresentation{}))
 }
+
+func TestRequestPresentationFromPreview(t *testing.T) {
+	request, err := RequestPresentationFromPreview(&PresentationPreview{
+		Attributes: []Attribute{{Name: "name", CredDefID: "cred-def"}},
+		Predicates: []Predicate{{Name: "age", Predicate: ">=", Threshold: "18"}},
+	}, "proof")
+	require.NoError(t, err)
+	require.Len(t, request.RequestPresentations, 1)
+
+	require.Equal(t, []decorator.AttachmentFormat{{
+		AttachID: presentproof.PresentationDefinitionAttachmentID,
+		Format:   attachformat.DIFPresentationDefinitions,
+	}}, request.Formats)
+
+	definitionRequest, ok := request.RequestPresentations[0].Data.JSON.(*presentproof.PresentationDefinitionRequest)
+	require.True(t, ok)
+	require.Equal(t, "proof", definitionRequest.PresentationDefinition.Name)
+	require.Len(t, definitionRequest.PresentationDefinition.InputDescriptors, 2)
+
+	_, err = RequestPresentationFromPreview(&PresentationPreview{}, "proof")
+	require.EqualError(t, err, "presentation preview is empty")
+}
+
+func TestClient_AddActionPolicies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().AddActionPolicies(gomock.Any(), gomock.Any()).Times(1)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	client.AddActionPolicies(AutoDeclineUnknownDIDs(func(string) bool { return true }), AutoAcceptPresentation())
+}
+
+func TestClient_SetExchangeTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().SetExchangeTimeout(time.Hour).Times(1)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	client.SetExchangeTimeout(time.Hour)
+}
+
+func TestClient_SetVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().SetVerificationWorkers(4).Times(1)
+	svc.EXPECT().SetVerificationRetries(3, time.Second).Times(1)
+	svc.EXPECT().SetVerificationMetrics(nil).Times(1)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	client.SetVerificationWorkers(4)
+	client.SetVerificationRetries(3, time.Second)
+	client.SetVerificationMetrics(nil)
+}
+
+func TestClient_SetAuditLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().SetAuditLog(nil).Times(1)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	client.SetAuditLog(nil)
+}
+
+func TestAutoAcceptRequestPresentation(t *testing.T) {
+	request := service.NewDIDCommMsgMap(presentproof.RequestPresentation{
+		Type:    presentproof.RequestPresentationMsgType,
+		Comment: "request",
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		supplier := func(req *RequestPresentation, myDID, theirDID string) (*Presentation, error) {
+			require.Equal(t, "request", req.Comment)
+			require.Equal(t, Alice, myDID)
+			require.Equal(t, Bob, theirDID)
+
+			return &Presentation{}, nil
+		}
+
+		policy := AutoAcceptRequestPresentation(supplier)
+
+		require.NotNil(t, policy(request, Alice, Bob))
+	})
+
+	t.Run("Supplier error", func(t *testing.T) {
+		policy := AutoAcceptRequestPresentation(func(*RequestPresentation, string, string) (*Presentation, error) {
+			return nil, errors.New("no credentials")
+		})
+
+		require.Equal(t, presentproof.StopWith(errors.New("no credentials")), policy(request, Alice, Bob))
+	})
+}
+
+func TestErrorFromProblemCode(t *testing.T) {
+	require.True(t, errors.Is(ErrorFromProblemCode(CodeNoMatchingCredential), ErrNoMatchingCredential))
+	require.True(t, errors.Is(ErrorFromProblemCode(CodeVerificationFailed), ErrVerificationFailed))
+	require.True(t, errors.Is(ErrorFromProblemCode(CodePolicyRejected), ErrPolicyRejected))
+	require.True(t, errors.Is(ErrorFromProblemCode(CodeExpiredRequest), ErrExpiredRequest))
+
+	err := ErrorFromProblemCode("rejected")
+	require.False(t, errors.Is(err, ErrPolicyRejected))
+
+	var reportErr *ProblemReportError
+
+	require.True(t, errors.As(err, &reportErr))
+	require.Equal(t, "rejected", reportErr.Code)
+}
+
+func TestClient_PauseAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().ActionPause("PIID").Return("token", nil)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	token, err := client.PauseAction("PIID")
+	require.NoError(t, err)
+	require.Equal(t, "token", token)
+}
+
+func TestClient_ResumeAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().ActionResume("token").Return(&presentproof.Action{PIID: "PIID"}, nil)
+	svc.EXPECT().ActionResume("unknown").Return(nil, presentproof.ErrUnknownResumeToken)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	action, err := client.ResumeAction("token")
+	require.NoError(t, err)
+	require.Equal(t, "PIID", action.PIID)
+
+	_, err = client.ResumeAction("unknown")
+	require.True(t, errors.Is(err, presentproof.ErrUnknownResumeToken))
+}
+
+func TestClient_Callbacks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := mocks.NewMockProvider(ctrl)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().RegisterActionCallback(gomock.Any()).Return(nil)
+	svc.EXPECT().UnregisterActionCallback()
+	svc.EXPECT().RegisterMsgCallback(gomock.Any()).Return(func() {}, nil)
+
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+	client, err := New(provider)
+	require.NoError(t, err)
+
+	require.NoError(t, client.RegisterActionCallback(func(service.DIDCommAction) {}))
+	client.UnregisterActionCallback()
+
+	unregister, err := client.RegisterMsgCallback(func(service.StateMsg) {})
+	require.NoError(t, err)
+	require.NotNil(t, unregister)
+}
@@ -0,0 +1,300 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/keyid"
+	verifiablestore "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
+)
+
+const (
+	ed25519VerificationKey2018 = "Ed25519VerificationKey2018"
+	ed25519Signature2018       = "Ed25519Signature2018"
+	authenticationPurpose      = "authentication"
+	presentationMimeType       = "application/ld+json"
+)
+
+var errPresentationNotSupported = errors.New(
+	"presentations are not supported: the provider has no storage provider, signer or VDRI registry")
+
+// presentationProvider is implemented by the providers giving access to the stored credentials and to the KMS
+// of the agent (e.g aries.Context()), the presentations are created only if the provider implements it.
+type presentationProvider interface {
+	StorageProvider() storage.Provider
+	Signer() legacykms.Signer
+}
+
+// documentLoaderProvider is implemented by the providers sharing a JSON-LD document loader (e.g aries.Context()),
+// otherwise the client creates its own loader caching the contexts in its storage provider.
+type documentLoaderProvider interface {
+	JSONLDDocumentLoader() ld.DocumentLoader
+}
+
+// CreatePresentationForRequest returns the Presentation message answering the request with the stored credentials
+// (see verifiable.Store) of the given IDs, it is sent by AcceptRequestPresentation.
+//
+// A verifiable presentation of the credentials is created for each requested presentation, with the ID of its
+// attachment. The presentations are held by the signer DID and signed by the KMS with the Ed25519 key of the
+// authentication methods of its document indexed with a KMS key (see keyid.Store), or with the first Ed25519 key
+// of the authentication methods if none is indexed (Ed25519Signature2018), the challenge and the domain of the
+// requested presentation (its "challenge" and "domain" fields or its "options") are set to the proof.
+// The request may ask for several of the matching credentials (RequestedCount), as many credentials must be given.
+func (c *Client) CreatePresentationForRequest(request *RequestPresentation, credentialIDs []string,
+	signerDID string) (*Presentation, error) {
+	if request == nil {
+		return nil, errEmptyRequestPresentation
+	}
+
+	if c.presentationProvider == nil || c.vdriRegistry == nil {
+		return nil, errPresentationNotSupported
+	}
+
+	if len(credentialIDs) < request.RequestedCount {
+		return nil, fmt.Errorf("%d credentials requested, %d given", request.RequestedCount, len(credentialIDs))
+	}
+
+	requested, formats, err := presentproof.RequestedPresentations((*presentproof.RequestPresentation)(request))
+	if err != nil {
+		return nil, fmt.Errorf("requested presentations: %w", err)
+	}
+
+	credentials, err := c.credentials(credentialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := c.vdriRegistry.Resolve(signerDID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve signer DID: %w", err)
+	}
+
+	keys, err := keyid.New(c.presentationProvider)
+	if err != nil {
+		return nil, fmt.Errorf("open key ID store: %w", err)
+	}
+
+	key, keyID, err := signingKey(doc, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requested) == 0 {
+		// a single presentation is expected, e.g when the request is described by its comment
+		requested = []decorator.Attachment{{}}
+	}
+
+	presentation := &Presentation{}
+
+	for i := range requested {
+		challenge, domain := proofOptions(&requested[i])
+
+		vp, err := c.signedPresentation(credentials, doc.ID, key, keyID, challenge, domain)
+		if err != nil {
+			return nil, fmt.Errorf("presentation %s: %w", requested[i].ID, err)
+		}
+
+		presentation.Presentations = append(presentation.Presentations, decorator.Attachment{
+			ID:       requested[i].ID,
+			MimeType: presentationMimeType,
+			Data:     decorator.AttachmentData{Base64: base64.StdEncoding.EncodeToString(vp)},
+		})
+
+		// the presentations answering a request declaring its formats declare theirs
+		if formats != nil {
+			presentation.Formats = append(presentation.Formats, decorator.AttachmentFormat{
+				AttachID: requested[i].ID,
+				Format:   attachformat.DIFPresentationSubmission,
+			})
+		}
+	}
+
+	return presentation, nil
+}
+
+func (c *Client) credentials(ids []string) ([]interface{}, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("no credential to present")
+	}
+
+	store, err := verifiablestore.New(c.presentationProvider)
+	if err != nil {
+		return nil, fmt.Errorf("open credential store: %w", err)
+	}
+
+	credentials := make([]interface{}, len(ids))
+
+	for i, id := range ids {
+		vc, err := store.GetCredential(id)
+		if err != nil {
+			return nil, fmt.Errorf("get credential %s: %w", id, err)
+		}
+
+		credentials[i] = vc
+	}
+
+	return credentials, nil
+}
+
+func (c *Client) signedPresentation(credentials []interface{}, holder string, key *did.PublicKey,
+	keyID, challenge, domain string) ([]byte, error) {
+	vp := &verifiable.Presentation{
+		Context: []string{jsonld.CredentialsContextURL},
+		Type:    []string{"VerifiablePresentation"},
+		Holder:  holder,
+	}
+
+	if err := vp.SetCredentials(credentials...); err != nil {
+		return nil, fmt.Errorf("set credentials: %w", err)
+	}
+
+	loader, err := c.jsonldDocumentLoader()
+	if err != nil {
+		return nil, err
+	}
+
+	created := time.Now().UTC()
+
+	err = vp.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+		SignatureType: ed25519Signature2018,
+		Suite: ed25519signature2018.New(
+			suite.WithSigner(&kmsSigner{signer: c.presentationProvider.Signer(), verKey: keyID}),
+			suite.WithDocumentLoader(loader)),
+		SignatureRepresentation: verifiable.SignatureJWS,
+		Created:                 &created,
+		VerificationMethod:      key.ID,
+		Challenge:               challenge,
+		Domain:                  domain,
+		Purpose:                 authenticationPurpose,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sign presentation: %w", err)
+	}
+
+	return vp.MarshalJSON()
+}
+
+// signingKey returns the first Ed25519 key of the authentication methods of the document indexed with a KMS key,
+// or of its public keys if it has no authentication method, and the KMS key ID. The first Ed25519 key is returned
+// if none is indexed (e.g the DIDs created before the keys were indexed), its KMS key ID is then its base58 value.
+// The ID of the key is absolute so the verifier resolves it.
+func signingKey(doc *did.Doc, keys *keyid.Store) (*did.PublicKey, string, error) {
+	candidates := make([]did.PublicKey, 0, len(doc.Authentication)+len(doc.PublicKey))
+
+	for _, auth := range doc.Authentication {
+		candidates = append(candidates, auth.PublicKey)
+	}
+
+	if len(candidates) == 0 {
+		candidates = doc.PublicKey
+	}
+
+	var first *did.PublicKey
+
+	for i := range candidates {
+		if candidates[i].Type != ed25519VerificationKey2018 {
+			continue
+		}
+
+		key := candidates[i]
+		key.ID = keyid.DIDURL(doc.ID, key.ID)
+
+		keyID, err := keys.KeyID(key.ID)
+		if err == nil {
+			return &key, keyID, nil
+		}
+
+		if !errors.Is(err, keyid.ErrNotFound) {
+			return nil, "", fmt.Errorf("key ID of %s: %w", key.ID, err)
+		}
+
+		if first == nil {
+			first = &key
+		}
+	}
+
+	if first == nil {
+		return nil, "", fmt.Errorf("no %s key to sign with in the document of %s", ed25519VerificationKey2018, doc.ID)
+	}
+
+	return first, base58.Encode(first.Value), nil
+}
+
+// proofOptions returns the challenge and the domain of the requested presentation, e.g the options
+// of a presentation definition.
+func proofOptions(attachment *decorator.Attachment) (string, string) {
+	if attachment.Data.JSON == nil {
+		return "", ""
+	}
+
+	src, err := json.Marshal(attachment.Data.JSON)
+	if err != nil {
+		return "", ""
+	}
+
+	var options struct {
+		Challenge string `json:"challenge"`
+		Domain    string `json:"domain"`
+		Options   *struct {
+			Challenge string `json:"challenge"`
+			Domain    string `json:"domain"`
+		} `json:"options"`
+	}
+
+	if err := json.Unmarshal(src, &options); err != nil {
+		return "", ""
+	}
+
+	if options.Options != nil {
+		return options.Options.Challenge, options.Options.Domain
+	}
+
+	return options.Challenge, options.Domain
+}
+
+// kmsSigner signs the presentations with a key of the KMS.
+type kmsSigner struct {
+	signer legacykms.Signer
+	verKey string
+}
+
+func (s *kmsSigner) Sign(data []byte) ([]byte, error) {
+	return s.signer.SignMessage(data, s.verKey)
+}
+
+// jsonldDocumentLoader returns the document loader of the provider, or a loader caching the contexts
+// in the storage provider.
+func (c *Client) jsonldDocumentLoader() (ld.DocumentLoader, error) {
+	if c.documentLoader != nil {
+		return c.documentLoader, nil
+	}
+
+	loader, err := jsonld.NewDocumentLoader(c.presentationProvider.StorageProvider())
+	if err != nil {
+		return nil, fmt.Errorf("document loader: %w", err)
+	}
+
+	return loader, nil
+}
@@ -0,0 +1,351 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/golang/mock/gomock"
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	mocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/client/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/keyid"
+	verifiablestore "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
+)
+
+const (
+	holderDID    = "did:example:holder"
+	credentialID = "http://example.edu/credentials/1872"
+)
+
+// walletProvider gives access to the VDRI registry, the stored credentials and the KMS
+type walletProvider struct {
+	*registryProvider
+	storageProvider storage.Provider
+	signer          legacykms.Signer
+}
+
+func (p *walletProvider) StorageProvider() storage.Provider {
+	return p.storageProvider
+}
+
+func (p *walletProvider) Signer() legacykms.Signer {
+	return p.signer
+}
+
+// loaderProvider shares a JSON-LD document loader
+type loaderProvider struct {
+	*walletProvider
+	loader ld.DocumentLoader
+}
+
+func (p *loaderProvider) JSONLDDocumentLoader() ld.DocumentLoader {
+	return p.loader
+}
+
+// signer signs with the ed25519 key of the holder
+type signer struct {
+	verKey string
+	key    ed25519.PrivateKey
+}
+
+func (s *signer) SignMessage(message []byte, fromVerKey string) ([]byte, error) {
+	if fromVerKey != s.verKey {
+		return nil, errors.New("key not found")
+	}
+
+	return ed25519.Sign(s.key, message), nil
+}
+
+func newWalletProvider(t *testing.T, ctrl *gomock.Controller) (*walletProvider, ed25519.PublicKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pk := did.PublicKey{ID: "#key-1", Type: ed25519VerificationKey2018, Controller: holderDID, Value: pub}
+
+	registry := &mockvdri.MockVDRIRegistry{ResolveValue: &did.Doc{
+		ID:             holderDID,
+		PublicKey:      []did.PublicKey{{ID: "#key-0", Type: "X25519KeyAgreementKey2019"}, pk},
+		Authentication: []did.VerificationMethod{{PublicKey: pk}},
+	}}
+
+	provider := mocks.NewMockProvider(ctrl)
+	provider.EXPECT().Service(gomock.Any()).Return(mocks.NewMockProtocolService(ctrl), nil).AnyTimes()
+
+	p := &walletProvider{
+		registryProvider: &registryProvider{MockProvider: provider, registry: registry},
+		storageProvider:  mem.NewProvider(),
+		signer:           &signer{verKey: base58.Encode(pub), key: priv},
+	}
+
+	store, err := verifiablestore.New(p)
+	require.NoError(t, err)
+
+	vc, _, err := verifiable.NewCredential([]byte(`{
+		"@context": ["https://www.w3.org/2018/credentials/v1"],
+		"id": "` + credentialID + `",
+		"type": ["VerifiableCredential", "UniversityDegreeCredential"],
+		"issuer": "did:example:issuer",
+		"issuanceDate": "2010-01-01T19:23:24Z",
+		"credentialSubject": {"id": "` + holderDID + `"}
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, store.SaveCredential("degree", vc))
+
+	return p, pub
+}
+
+func TestClient_CreatePresentationForRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	loader, err := jsonld.NewDocumentLoader(mem.NewProvider(), jsonld.WithRemoteDocumentLoader(nil))
+	require.NoError(t, err)
+
+	verify := func(t *testing.T, attachment decorator.Attachment, pub ed25519.PublicKey) verifiable.Proof {
+		t.Helper()
+
+		raw, err := base64.StdEncoding.DecodeString(attachment.Data.Base64)
+		require.NoError(t, err)
+
+		vp, err := verifiable.NewPresentation(raw,
+			verifiable.WithPresPublicKeyFetcher(verifiable.SingleKey(pub, kms.ED25519)),
+			verifiable.WithPresEmbeddedSignatureSuites(ed25519signature2018.New(
+				suite.WithVerifier(ed25519signature2018.NewPublicKeyVerifier()),
+				suite.WithDocumentLoader(loader))))
+		require.NoError(t, err)
+		require.Equal(t, holderDID, vp.Holder)
+		require.Len(t, vp.Proofs, 1)
+
+		credentials, err := vp.MarshalledCredentials()
+		require.NoError(t, err)
+		require.Len(t, credentials, 1)
+
+		return vp.Proofs[0]
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		p, pub := newWalletProvider(t, ctrl)
+
+		client, err := New(&loaderProvider{walletProvider: p, loader: loader})
+		require.NoError(t, err)
+
+		presentation, err := client.CreatePresentationForRequest(&RequestPresentation{
+			RequestPresentations: []decorator.Attachment{{
+				ID: "degree",
+				Data: decorator.AttachmentData{JSON: map[string]interface{}{
+					"options": map[string]interface{}{"challenge": "c0ae1c8e", "domain": "example.com"},
+				}},
+			}, {
+				ID:   "membership",
+				Data: decorator.AttachmentData{JSON: map[string]interface{}{"challenge": "3d1f9b2a"}},
+			}},
+		}, []string{credentialID}, holderDID)
+		require.NoError(t, err)
+		require.Len(t, presentation.Presentations, 2)
+
+		require.Equal(t, "degree", presentation.Presentations[0].ID)
+		proof := verify(t, presentation.Presentations[0], pub)
+		require.Equal(t, "c0ae1c8e", proof["challenge"])
+		require.Equal(t, "example.com", proof["domain"])
+		require.Equal(t, holderDID+"#key-1", proof["verificationMethod"])
+		require.Equal(t, "authentication", proof["proofPurpose"])
+
+		require.Equal(t, "membership", presentation.Presentations[1].ID)
+		proof = verify(t, presentation.Presentations[1], pub)
+		require.Equal(t, "3d1f9b2a", proof["challenge"])
+		require.Nil(t, proof["domain"])
+	})
+
+	t.Run("Single presentation with the document loader of the client", func(t *testing.T) {
+		p, pub := newWalletProvider(t, ctrl)
+
+		client, err := New(p)
+		require.NoError(t, err)
+
+		presentation, err := client.CreatePresentationForRequest(&RequestPresentation{}, []string{credentialID},
+			holderDID)
+		require.NoError(t, err)
+		require.Len(t, presentation.Presentations, 1)
+		require.Empty(t, presentation.Presentations[0].ID)
+		require.Equal(t, "application/ld+json", presentation.Presentations[0].MimeType)
+
+		proof := verify(t, presentation.Presentations[0], pub)
+		require.Nil(t, proof["challenge"])
+	})
+
+	t.Run("Presentation answering the request built from a preview", func(t *testing.T) {
+		p, pub := newWalletProvider(t, ctrl)
+
+		client, err := New(p)
+		require.NoError(t, err)
+
+		request, err := RequestPresentationFromPreview(&PresentationPreview{
+			Attributes: []Attribute{{Name: "degree"}},
+		}, "proof of education")
+		require.NoError(t, err)
+
+		presentation, err := client.CreatePresentationForRequest(request, []string{credentialID}, holderDID)
+		require.NoError(t, err)
+		require.Len(t, presentation.Presentations, 1)
+		require.Equal(t, []decorator.AttachmentFormat{{
+			AttachID: presentproof.PresentationDefinitionAttachmentID,
+			Format:   attachformat.DIFPresentationSubmission,
+		}}, presentation.Formats)
+
+		definitionRequest, ok := request.RequestPresentations[0].Data.JSON.(*presentproof.PresentationDefinitionRequest)
+		require.True(t, ok)
+
+		proof := verify(t, presentation.Presentations[0], pub)
+		require.Equal(t, definitionRequest.Options.Challenge, proof["challenge"])
+	})
+
+	t.Run("Presentations of the supported formats", func(t *testing.T) {
+		p, pub := newWalletProvider(t, ctrl)
+
+		client, err := New(p)
+		require.NoError(t, err)
+
+		presentation, err := client.CreatePresentationForRequest(&RequestPresentation{
+			Formats: []decorator.AttachmentFormat{
+				{AttachID: "indy", Format: attachformat.HLIndyProofRequest},
+				{AttachID: "degree", Format: attachformat.DIFPresentationDefinitions},
+			},
+			RequestPresentations: []decorator.Attachment{{ID: "indy"}, {ID: "degree"}},
+		}, []string{credentialID}, holderDID)
+		require.NoError(t, err)
+		require.Len(t, presentation.Presentations, 1)
+		require.Equal(t, "degree", presentation.Presentations[0].ID)
+		require.Equal(t, []decorator.AttachmentFormat{
+			{AttachID: "degree", Format: attachformat.DIFPresentationSubmission},
+		}, presentation.Formats)
+
+		verify(t, presentation.Presentations[0], pub)
+	})
+
+	t.Run("Key indexed with the KMS key", func(t *testing.T) {
+		p, pub := newWalletProvider(t, ctrl)
+
+		pk := did.PublicKey{ID: "#key-1", Type: ed25519VerificationKey2018, Controller: holderDID, Value: pub}
+		other := did.PublicKey{ID: "#key-2", Type: ed25519VerificationKey2018, Controller: holderDID,
+			Value: []byte("other")}
+
+		p.registry = &mockvdri.MockVDRIRegistry{ResolveValue: &did.Doc{
+			ID:             holderDID,
+			PublicKey:      []did.PublicKey{other, pk},
+			Authentication: []did.VerificationMethod{{PublicKey: other}, {PublicKey: pk}},
+		}}
+
+		keys, err := keyid.New(p)
+		require.NoError(t, err)
+		require.NoError(t, keys.Save(base58.Encode(pub), holderDID+"#key-1"))
+
+		client, err := New(p)
+		require.NoError(t, err)
+
+		presentation, err := client.CreatePresentationForRequest(&RequestPresentation{}, []string{credentialID},
+			holderDID)
+		require.NoError(t, err)
+
+		proof := verify(t, presentation.Presentations[0], pub)
+		require.Equal(t, holderDID+"#key-1", proof["verificationMethod"])
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		p, _ := newWalletProvider(t, ctrl)
+
+		client, err := New(p)
+		require.NoError(t, err)
+
+		_, err = client.CreatePresentationForRequest(nil, []string{credentialID}, holderDID)
+		require.EqualError(t, err, errEmptyRequestPresentation.Error())
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{}, nil, holderDID)
+		require.EqualError(t, err, "no credential to present")
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{RequestedCount: 2}, []string{credentialID},
+			holderDID)
+		require.EqualError(t, err, "2 credentials requested, 1 given")
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{
+			Formats:              []decorator.AttachmentFormat{{AttachID: "indy", Format: attachformat.HLIndyProofRequest}},
+			RequestPresentations: []decorator.Attachment{{ID: "indy"}},
+		}, []string{credentialID}, holderDID)
+		require.True(t, errors.Is(err, attachformat.ErrNoCommonFormat))
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{}, []string{"unknown"}, holderDID)
+		require.Contains(t, err.Error(), "get credential unknown")
+
+		p.registry = &mockvdri.MockVDRIRegistry{ResolveErr: vdriapi.ErrNotFound}
+
+		client, err = New(p)
+		require.NoError(t, err)
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{}, []string{credentialID}, holderDID)
+		require.Contains(t, err.Error(), "resolve signer DID")
+
+		p.registry = &mockvdri.MockVDRIRegistry{ResolveValue: &did.Doc{ID: holderDID}}
+
+		client, err = New(p)
+		require.NoError(t, err)
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{}, []string{credentialID}, holderDID)
+		require.EqualError(t, err, "no Ed25519VerificationKey2018 key to sign with in the document of "+holderDID)
+
+		p.registry = &mockvdri.MockVDRIRegistry{ResolveValue: &did.Doc{ID: holderDID, PublicKey: []did.PublicKey{
+			{ID: holderDID + "#key-1", Type: ed25519VerificationKey2018, Value: []byte("unknown")},
+		}}}
+
+		client, err = New(p)
+		require.NoError(t, err)
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{
+			RequestPresentations: []decorator.Attachment{{ID: "degree"}},
+		}, []string{credentialID}, holderDID)
+		require.Contains(t, err.Error(), "presentation degree: sign presentation")
+		require.Contains(t, err.Error(), "key not found")
+
+		p.storageProvider = &mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open store error")}
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{}, []string{credentialID}, holderDID)
+		require.Contains(t, err.Error(), "open credential store")
+	})
+
+	t.Run("Not supported", func(t *testing.T) {
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(mocks.NewMockProtocolService(ctrl), nil)
+
+		client, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = client.CreatePresentationForRequest(&RequestPresentation{}, []string{credentialID}, holderDID)
+		require.EqualError(t, err, errPresentationNotSupported.Error())
+	})
+}
@@ -0,0 +1,134 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package idgen provides the pluggable generator of the identifiers created by the framework,
+// such as message IDs, connection IDs and protocol instance IDs.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Generator generates unique identifiers.
+type Generator interface {
+	NewID() string
+}
+
+// GeneratorFunc is a function implementing Generator.
+type GeneratorFunc func() string
+
+// NewID returns a new identifier.
+func (f GeneratorFunc) NewID() string {
+	return f()
+}
+
+// Provider provides the generator of the identifiers configured for a framework instance (see
+// context.Provider IDGenerator).
+type Provider interface {
+	IDGenerator() Generator
+}
+
+// FromProvider returns the generator of the provider p if it provides one (see Provider), the default random
+// (version 4) UUID generator otherwise.
+func FromProvider(p interface{}) Generator {
+	if gp, ok := p.(Provider); ok && gp.IDGenerator() != nil {
+		return gp.IDGenerator()
+	}
+
+	return UUIDv4()
+}
+
+// NewID returns a new identifier created by the default random (version 4) UUID generator, for the
+// identifiers created without a provider.
+func NewID() string {
+	return UUIDv4().NewID()
+}
+
+// UUIDv4 returns a generator of random (version 4) UUIDs, the default generator of the framework.
+func UUIDv4() Generator {
+	return GeneratorFunc(func() string {
+		return uuid.New().String()
+	})
+}
+
+// UUIDv7 returns a generator of time-ordered (version 7) UUIDs: the identifiers start with the
+// creation time in milliseconds and a sequence number, and thus sort in their creation order.
+func UUIDv7() Generator {
+	return &uuidV7Generator{random: rand.Reader, now: time.Now}
+}
+
+// WithPrefix returns a generator prepending the prefix (e.g a tenant name) to the identifiers created by g.
+func WithPrefix(prefix string, g Generator) Generator {
+	return GeneratorFunc(func() string {
+		return prefix + g.NewID()
+	})
+}
+
+const (
+	// the sequence number is stored in the 12 bits following the version
+	maxSequence = 0x0fff
+	msPerSecond = int64(time.Second / time.Millisecond)
+	nsPerMs     = int64(time.Millisecond)
+)
+
+type uuidV7Generator struct {
+	mu       sync.Mutex
+	random   io.Reader
+	now      func() time.Time
+	lastMs   int64
+	sequence uint16
+}
+
+func (g *uuidV7Generator) NewID() string {
+	ms, seq := g.next()
+
+	var id uuid.UUID
+
+	// the generator is unusable if the system random source fails, as for uuid.New()
+	if _, err := io.ReadFull(g.random, id[8:]); err != nil {
+		panic(err)
+	}
+
+	var timestamp [8]byte
+
+	binary.BigEndian.PutUint64(timestamp[:], uint64(ms))
+	copy(id[:6], timestamp[2:])
+
+	binary.BigEndian.PutUint16(id[6:8], seq)
+
+	id[6] = (id[6] & 0x0f) | 0x70 // version 7
+	id[8] = (id[8] & 0x3f) | 0x80 // variant RFC 4122
+
+	return id.String()
+}
+
+// next returns the timestamp and sequence number of the next identifier, the sequence number is
+// incremented for the identifiers created in the same millisecond to keep them ordered.
+func (g *uuidV7Generator) next() (int64, uint16) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	ms := now.Unix()*msPerSecond + int64(now.Nanosecond())/nsPerMs
+
+	switch {
+	case ms > g.lastMs:
+		g.lastMs, g.sequence = ms, 0
+	case g.sequence < maxSequence:
+		g.sequence++
+	default:
+		// the sequence is exhausted, borrow the next millisecond
+		g.lastMs, g.sequence = g.lastMs+1, 0
+	}
+
+	return g.lastMs, g.sequence
+}
@@ -0,0 +1,112 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package idgen
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("random error")
+}
+
+type generatorProvider struct {
+	generator Generator
+}
+
+func (p *generatorProvider) IDGenerator() Generator {
+	return p.generator
+}
+
+func TestFromProvider(t *testing.T) {
+	custom := GeneratorFunc(func() string { return "custom" })
+	require.Equal(t, "custom", FromProvider(&generatorProvider{generator: custom}).NewID())
+
+	for _, p := range []interface{}{nil, struct{}{}, &generatorProvider{}} {
+		id, err := uuid.Parse(FromProvider(p).NewID())
+		require.NoError(t, err)
+		require.Equal(t, uuid.Version(4), id.Version())
+	}
+}
+
+func TestNewID(t *testing.T) {
+	id, err := uuid.Parse(NewID())
+	require.NoError(t, err)
+	require.Equal(t, uuid.Version(4), id.Version())
+}
+
+func TestUUIDv7(t *testing.T) {
+	t.Run("valid version 7 UUIDs", func(t *testing.T) {
+		id, err := uuid.Parse(UUIDv7().NewID())
+		require.NoError(t, err)
+		require.Equal(t, uuid.Version(7), id.Version())
+		require.Equal(t, uuid.RFC4122, id.Variant())
+	})
+
+	t.Run("time ordered", func(t *testing.T) {
+		g := UUIDv7()
+
+		ids := make([]string, 5000)
+		for i := range ids {
+			ids[i] = g.NewID()
+		}
+
+		require.True(t, sort.StringsAreSorted(ids))
+	})
+
+	t.Run("timestamp", func(t *testing.T) {
+		now := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+		g := &uuidV7Generator{random: strings.NewReader(strings.Repeat("r", 16)), now: func() time.Time { return now }}
+
+		id := uuid.MustParse(g.NewID())
+
+		var ms int64
+		for _, b := range id[:6] {
+			ms = ms<<8 | int64(b)
+		}
+
+		require.Equal(t, now.UnixNano()/int64(time.Millisecond), ms)
+	})
+
+	t.Run("sequence overflow", func(t *testing.T) {
+		now := time.Now()
+		g := &uuidV7Generator{random: strings.NewReader(""), now: func() time.Time { return now }}
+
+		ms, _ := g.next()
+
+		for i := 0; i < maxSequence; i++ {
+			g.next()
+		}
+
+		next, seq := g.next()
+		require.Equal(t, ms+1, next)
+		require.Zero(t, seq)
+	})
+
+	t.Run("random source error", func(t *testing.T) {
+		g := &uuidV7Generator{random: failingReader{}, now: time.Now}
+
+		require.Panics(t, func() { g.NewID() })
+	})
+}
+
+func TestWithPrefix(t *testing.T) {
+	id := WithPrefix("tenant-a:", UUIDv4()).NewID()
+	require.True(t, strings.HasPrefix(id, "tenant-a:"))
+
+	_, err := uuid.Parse(strings.TrimPrefix(id, "tenant-a:"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Keys of the contextual fields logged by the framework.
+const (
+	FieldProtocol   = "protocol"
+	FieldThread     = "thread"
+	FieldConnection = "connection"
+	FieldState      = "state"
+)
+
+// Fields are the contextual key-value pairs of a structured log line, e.g the protocol and thread of a message.
+type Fields map[string]interface{}
+
+// FieldLogger is implemented by the custom loggers supporting structured logging: the contextual fields are
+// passed to WithFields instead of being appended to the messages.
+type FieldLogger interface {
+	Logger
+
+	// WithFields returns a logger logging the given contextual fields with every message
+	WithFields(fields Fields) Logger
+}
+
+// String returns the fields sorted by key, formatted as [key1=value1 key2=value2].
+func (f Fields) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, f[k])
+	}
+
+	return "[" + strings.Join(pairs, " ") + "]"
+}
+
+// WithFields returns a logger of the same module logging the given contextual fields, in addition to the fields
+// of this logger, with every message. Deriving the logger is cheap: the fields are only merged and the underlying
+// logger instance (shared with this logger) only wrapped once a message of an enabled level is logged, the callers
+// don't need to check the level first.
+//  Usage:
+//  logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: thID}).Errorf("handle: %s", err)
+func (l *Log) WithFields(fields Fields) *Log {
+	return &Log{module: l.module, fields: fields, sampler: l.sampler, parent: l}
+}
+
+// withFields adds the fields to the logger, natively if it supports structured logging
+func withFields(logger Logger, fields Fields) Logger {
+	if fl, ok := logger.(FieldLogger); ok {
+		return fl.WithFields(fields)
+	}
+
+	// the fields are appended to the format, their % signs must not be interpreted as verbs
+	return &fieldsLogger{logger: logger, suffix: " " + strings.ReplaceAll(fields.String(), "%", "%%")}
+}
+
+// fieldsLogger appends the contextual fields to the messages of loggers not supporting structured logging
+type fieldsLogger struct {
+	logger Logger
+	suffix string
+}
+
+func (f *fieldsLogger) Fatalf(msg string, args ...interface{}) {
+	f.logger.Fatalf(msg+f.suffix, args...)
+}
+
+func (f *fieldsLogger) Panicf(msg string, args ...interface{}) {
+	f.logger.Panicf(msg+f.suffix, args...)
+}
+
+func (f *fieldsLogger) Debugf(msg string, args ...interface{}) {
+	f.logger.Debugf(msg+f.suffix, args...)
+}
+
+func (f *fieldsLogger) Infof(msg string, args ...interface{}) {
+	f.logger.Infof(msg+f.suffix, args...)
+}
+
+func (f *fieldsLogger) Warnf(msg string, args ...interface{}) {
+	f.logger.Warnf(msg+f.suffix, args...)
+}
+
+func (f *fieldsLogger) Errorf(msg string, args ...interface{}) {
+	f.logger.Errorf(msg+f.suffix, args...)
+}
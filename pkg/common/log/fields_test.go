@@ -0,0 +1,233 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package log
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger records the formatted messages
+type recordingLogger struct {
+	lines *[]string
+}
+
+func (r *recordingLogger) record(level, msg string, args ...interface{}) {
+	*r.lines = append(*r.lines, level+" "+fmt.Sprintf(msg, args...))
+}
+
+func (r *recordingLogger) Fatalf(msg string, args ...interface{}) { r.record("FATAL", msg, args...) }
+func (r *recordingLogger) Panicf(msg string, args ...interface{}) { r.record("PANIC", msg, args...) }
+func (r *recordingLogger) Debugf(msg string, args ...interface{}) { r.record("DEBUG", msg, args...) }
+func (r *recordingLogger) Infof(msg string, args ...interface{})  { r.record("INFO", msg, args...) }
+func (r *recordingLogger) Warnf(msg string, args ...interface{})  { r.record("WARN", msg, args...) }
+func (r *recordingLogger) Errorf(msg string, args ...interface{}) { r.record("ERROR", msg, args...) }
+
+// structuredLogger records the fields it is given instead of having them appended to the messages
+type structuredLogger struct {
+	recordingLogger
+	fields *Fields
+}
+
+func (s *structuredLogger) WithFields(fields Fields) Logger {
+	*s.fields = fields
+
+	return &s.recordingLogger
+}
+
+type recordingProvider struct {
+	logger Logger
+}
+
+func (p *recordingProvider) GetLogger(string) Logger {
+	return p.logger
+}
+
+func initializeRecording(t *testing.T, logger Logger) {
+	t.Helper()
+
+	loggerProviderOnce = sync.Once{}
+
+	Initialize(&recordingProvider{logger: logger})
+}
+
+func TestLog_WithFields(t *testing.T) {
+	defer func() { loggerProviderOnce = sync.Once{} }()
+
+	const module = "sample-module-fields"
+
+	SetLevel(module, DEBUG)
+
+	t.Run("fields appended to the messages", func(t *testing.T) {
+		var lines []string
+
+		initializeRecording(t, &recordingLogger{lines: &lines})
+
+		logger := New(module).WithFields(Fields{FieldProtocol: "present-proof", FieldThread: "th%1"})
+		logger.WithFields(Fields{FieldState: "done"}).Infof("state %s", "executed")
+		logger.Errorf("failed: %s", "error")
+		logger.Debugf("debug")
+		logger.Warnf("warn")
+		logger.Panicf("panic")
+		logger.Fatalf("fatal")
+
+		require.Equal(t, []string{
+			"INFO state executed [protocol=present-proof state=done thread=th%1]",
+			"ERROR failed: error [protocol=present-proof thread=th%1]",
+			"DEBUG debug [protocol=present-proof thread=th%1]",
+			"WARN warn [protocol=present-proof thread=th%1]",
+			"PANIC panic [protocol=present-proof thread=th%1]",
+			"FATAL fatal [protocol=present-proof thread=th%1]",
+		}, lines)
+	})
+
+	t.Run("fields passed to structured loggers", func(t *testing.T) {
+		var (
+			lines  []string
+			fields Fields
+		)
+
+		initializeRecording(t, &structuredLogger{recordingLogger: recordingLogger{lines: &lines}, fields: &fields})
+
+		New(module).WithFields(Fields{FieldConnection: "conn1"}).Infof("message")
+
+		require.Equal(t, []string{"INFO message"}, lines)
+		require.Equal(t, Fields{FieldConnection: "conn1"}, fields)
+	})
+
+	t.Run("underlying logger shared with the derived loggers", func(t *testing.T) {
+		var lines []string
+
+		provider := &countingProvider{recordingProvider: recordingProvider{logger: &recordingLogger{lines: &lines}}}
+
+		loggerProviderOnce = sync.Once{}
+		Initialize(provider)
+
+		logger := New(module)
+
+		for i := 0; i < 3; i++ {
+			logger.WithFields(Fields{FieldThread: i}).WithFields(Fields{FieldState: "done"}).Infof("message")
+		}
+
+		logger.WithSampling(NewSampler(1, 0, time.Minute)).Infof("message")
+
+		require.Len(t, lines, 4)
+		// the first call is the one of the initialization of the provider
+		require.Equal(t, 2, provider.calls)
+	})
+}
+
+func TestLog_WithFieldsDisabledLevel(t *testing.T) {
+	defer func() { loggerProviderOnce = sync.Once{} }()
+
+	const module = "sample-module-fields-level"
+
+	var lines []string
+
+	initializeRecording(t, &recordingLogger{lines: &lines})
+
+	SetLevel(module, WARNING)
+
+	logger := New(module).WithFields(Fields{FieldProtocol: "p"})
+	derived := logger.WithFields(Fields{FieldThread: "th1"})
+
+	derived.Debugf("debug")
+	derived.Infof("info")
+
+	// the fields are neither merged nor the underlying logger wrapped for the disabled levels
+	require.Empty(t, lines)
+	require.Nil(t, derived.instance)
+	require.Nil(t, logger.instance)
+
+	derived.Warnf("warn")
+
+	require.Equal(t, []string{"WARN warn [protocol=p thread=th1]"}, lines)
+	require.Nil(t, logger.instance)
+}
+
+// countingProvider counts the loggers it creates
+type countingProvider struct {
+	recordingProvider
+	calls int
+}
+
+func (p *countingProvider) GetLogger(module string) Logger {
+	p.calls++
+
+	return p.recordingProvider.GetLogger(module)
+}
+
+func TestSampler(t *testing.T) {
+	now := time.Now()
+
+	s := NewSampler(2, 3, time.Second)
+	s.now = func() time.Time { return now }
+
+	var sampled []bool
+	for i := 0; i < 8; i++ {
+		sampled = append(sampled, s.Sample("dispatch %s"))
+	}
+
+	require.Equal(t, []bool{true, true, false, false, true, false, false, true}, sampled)
+
+	// the formats are sampled separately
+	require.True(t, s.Sample("other %s"))
+
+	// the count is reset every period
+	now = now.Add(time.Second)
+	require.True(t, s.Sample("dispatch %s"))
+
+	// nothing is logged after the first messages if thereafter is zero
+	s = NewSampler(1, 0, time.Second)
+	require.True(t, s.Sample("msg"))
+	require.False(t, s.Sample("msg"))
+}
+
+func TestLog_WithSampling(t *testing.T) {
+	defer func() { loggerProviderOnce = sync.Once{} }()
+
+	const module = "sample-module-sampling"
+
+	var lines []string
+
+	initializeRecording(t, &recordingLogger{lines: &lines})
+
+	SetLevel(module, INFO)
+
+	logger := New(module).WithFields(Fields{FieldProtocol: "p"}).WithSampling(NewSampler(1, 0, time.Minute))
+
+	// messages of disabled levels don't count
+	logger.Debugf("message")
+
+	for i := 0; i < 3; i++ {
+		logger.Infof("message")
+		logger.Warnf("warning")
+		logger.Errorf("error")
+	}
+
+	require.Equal(t, []string{"INFO message [protocol=p]", "WARN warning [protocol=p]", "ERROR error [protocol=p]"}, lines)
+}
+
+func TestSetSpec(t *testing.T) {
+	defer SetLevel("", INFO)
+
+	require.NoError(t, SetSpec("module-spec-1=DEBUG:aries/module-spec-2=error:WARNING"))
+	require.Equal(t, DEBUG, GetLevel("module-spec-1"))
+	require.Equal(t, ERROR, GetLevel("aries/module-spec-2"))
+	require.Equal(t, WARNING, GetLevel("module-spec-unknown"))
+
+	err := SetSpec("module-spec-1=INFO:module-spec-3=LOUD")
+	require.EqualError(t, err, "invalid log level spec entry [module-spec-3=LOUD]: logger: invalid log level")
+	require.Equal(t, DEBUG, GetLevel("module-spec-1"))
+
+	err = SetSpec("=INFO")
+	require.EqualError(t, err, "invalid log level spec entry [=INFO]: module is empty")
+}
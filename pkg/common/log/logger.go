@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package log
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/hyperledger/aries-framework-go/pkg/internal/common/logging/metadata"
@@ -24,7 +26,14 @@ const (
 type Log struct {
 	instance Logger
 	module   string
+	fields   Fields
+	sampler  *Sampler
 	once     sync.Once
+	// parent is the logger this logger derives from (see WithFields and WithSampling), its fields are merged
+	// and its underlying logger instance is shared rather than created again for every derived logger
+	parent   *Log
+	base     Logger
+	baseOnce sync.Once
 }
 
 // New creates and returns a Logger implementation based on given module name.
@@ -47,34 +56,88 @@ func (l *Log) Panicf(msg string, args ...interface{}) {
 	l.logger().Panicf(msg, args...)
 }
 
-// Debugf calls Debugf function of underlying logger
+// Debugf calls Debugf function of underlying logger if the debug level of the module is enabled
 func (l *Log) Debugf(msg string, args ...interface{}) {
+	if !IsEnabledFor(l.module, DEBUG) || !l.sampled(msg) {
+		return
+	}
+
 	l.logger().Debugf(msg, args...)
 }
 
-// Infof calls Infof function of underlying logger
+// Infof calls Infof function of underlying logger if the info level of the module is enabled
 func (l *Log) Infof(msg string, args ...interface{}) {
+	if !IsEnabledFor(l.module, INFO) || !l.sampled(msg) {
+		return
+	}
+
 	l.logger().Infof(msg, args...)
 }
 
-// Warnf calls Warnf function of underlying logger
+// Warnf calls Warnf function of underlying logger if the warning level of the module is enabled
 func (l *Log) Warnf(msg string, args ...interface{}) {
+	if !IsEnabledFor(l.module, WARNING) || !l.sampled(msg) {
+		return
+	}
+
 	l.logger().Warnf(msg, args...)
 }
 
-// Errorf calls Errorf function of underlying logger
+// Errorf calls Errorf function of underlying logger if the error level of the module is enabled
 func (l *Log) Errorf(msg string, args ...interface{}) {
+	if !IsEnabledFor(l.module, ERROR) || !l.sampled(msg) {
+		return
+	}
+
 	l.logger().Errorf(msg, args...)
 }
 
 func (l *Log) logger() Logger {
 	l.once.Do(func() {
-		l.instance = loggerProvider().GetLogger(l.module)
+		l.instance = moduleLogger(l.baseLogger(), l.module, l.allFields())
 	})
 
 	return l.instance
 }
 
+// baseLogger returns the underlying logger instance of the module, it is created once by the root logger
+// and shared by the loggers deriving from it.
+func (l *Log) baseLogger() Logger {
+	if l.parent != nil {
+		return l.parent.baseLogger()
+	}
+
+	l.baseOnce.Do(func() {
+		l.base = loggerProvider().newLogger(l.module)
+	})
+
+	return l.base
+}
+
+// allFields returns the fields of this logger merged with the fields of the loggers it derives from.
+func (l *Log) allFields() Fields {
+	if l.parent == nil {
+		return l.fields
+	}
+
+	parent := l.parent.allFields()
+	if len(l.fields) == 0 {
+		return parent
+	}
+
+	merged := make(Fields, len(parent)+len(l.fields))
+
+	for k, v := range parent {
+		merged[k] = v
+	}
+
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+
+	return merged
+}
+
 // SetLevel - setting log level for given module
 //  Parameters:
 //  module is module name
@@ -85,6 +148,40 @@ func SetLevel(module string, level Level) {
 	metadata.SetLevel(module, metadata.Level(level))
 }
 
+// SetSpec - setting log levels from a specification
+//  Parameters:
+//  spec is a list of module=level pairs separated by colons, optionally ending with the default level
+//  e.g "aries-framework/http=DEBUG:aries-framework/ws=ERROR:WARNING"
+//
+// No level is changed if the specification is invalid
+func SetSpec(spec string) error {
+	levels := map[string]Level{}
+
+	for _, entry := range strings.Split(spec, ":") {
+		module, levelName := "", entry
+
+		if i := strings.LastIndex(entry, "="); i >= 0 {
+			module, levelName = entry[:i], entry[i+1:]
+			if module == "" {
+				return fmt.Errorf("invalid log level spec entry [%s]: module is empty", entry)
+			}
+		}
+
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return fmt.Errorf("invalid log level spec entry [%s]: %w", entry, err)
+		}
+
+		levels[module] = level
+	}
+
+	for module, level := range levels {
+		SetLevel(module, level)
+	}
+
+	return nil
+}
+
 // GetLevel - getting log level for given module
 //  Parameters:
 //  module is module name
@@ -15,7 +15,7 @@ import (
 // loggerProviderInstance is logger factory singleton - access only via loggerProvider()
 //nolint:gochecknoglobals
 var (
-	loggerProviderInstance LoggerProvider
+	loggerProviderInstance *modlogProvider
 	loggerProviderOnce     sync.Once
 )
 
@@ -29,7 +29,7 @@ func Initialize(l LoggerProvider) {
 	})
 }
 
-func loggerProvider() LoggerProvider {
+func loggerProvider() *modlogProvider {
 	loggerProviderOnce.Do(func() {
 		// A custom logger must be initialized prior to the first log output
 		// Otherwise the built-in logger is used
@@ -49,11 +49,22 @@ type modlogProvider struct {
 
 // GetLogger returns moduled logger implementation.
 func (p *modlogProvider) GetLogger(module string) Logger {
-	var logger Logger
+	return moduleLogger(p.newLogger(module), module, nil)
+}
+
+// newLogger returns the logger of the custom logging provider, or the default logger.
+func (p *modlogProvider) newLogger(module string) Logger {
 	if p.custom != nil {
-		logger = p.custom.GetLogger(module)
-	} else {
-		logger = modlog.NewDefLog(module)
+		return p.custom.GetLogger(module)
+	}
+
+	return modlog.NewDefLog(module)
+}
+
+// moduleLogger returns moduled logger implementation logging the given contextual fields.
+func moduleLogger(logger Logger, module string, fields Fields) Logger {
+	if len(fields) > 0 {
+		logger = withFields(logger, fields)
 	}
 
 	return modlog.NewModLog(logger, module)
@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampler limits the volume of logs of high-volume paths (e.g the dispatching of every message): in each period,
+// the first messages of a given format are logged, then only one message out of 'thereafter'.
+// Fatal and panic messages are never sampled.
+type Sampler struct {
+	first      int
+	thereafter int
+	period     time.Duration
+	now        func() time.Time
+
+	mu     sync.Mutex
+	counts map[string]*sampleCount
+}
+
+type sampleCount struct {
+	start time.Time
+	n     int
+}
+
+// NewSampler returns a sampler logging, for each message format, the first messages of each period then
+// one message out of thereafter (none if thereafter is zero).
+func NewSampler(first, thereafter int, period time.Duration) *Sampler {
+	return &Sampler{
+		first:      first,
+		thereafter: thereafter,
+		period:     period,
+		now:        time.Now,
+		counts:     map[string]*sampleCount{},
+	}
+}
+
+// Sample returns true if the message with the given format must be logged.
+func (s *Sampler) Sample(format string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	c, ok := s.counts[format]
+	if !ok || now.Sub(c.start) >= s.period {
+		c = &sampleCount{start: now}
+		s.counts[format] = c
+	}
+
+	c.n++
+
+	if c.n <= s.first {
+		return true
+	}
+
+	return s.thereafter > 0 && (c.n-s.first)%s.thereafter == 0
+}
+
+// WithSampling returns a logger of the same module and fields whose messages are sampled by the given sampler.
+func (l *Log) WithSampling(s *Sampler) *Log {
+	return &Log{module: l.module, sampler: s, parent: l}
+}
+
+// sampled returns true if the message must be logged, the callers only sample the messages of enabled levels.
+func (l *Log) sampled(msg string) bool {
+	return l.sampler == nil || l.sampler.Sample(msg)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package scheduler runs the periodic maintenance tasks of the framework and of the applications.
+//
+// The framework schedules the abandonment of the expired present proof exchanges, the delivery retries and
+// the cleanup of the forward messages queued by the mediator, the polling of the revocation lists of the
+// stored credentials and the compaction of the stores of the storage providers reclaiming the space of the
+// deleted records (see storage.Compactor). The applications register their own tasks, see
+// aries.WithScheduledTask.
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+)
+
+var logger = log.New("aries-framework/scheduler")
+
+var (
+	// ErrTaskRegistered is returned when a task is already registered with the name.
+	ErrTaskRegistered = errors.New("task already registered")
+	// ErrTaskNotFound is returned when no task is registered with the name.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrStopped is returned when a task is registered after the scheduler was stopped.
+	ErrStopped = errors.New("scheduler stopped")
+)
+
+// Task is a periodic maintenance task, e.g abandoning the expired exchanges. The time is the time of the run.
+// An error is logged, the task still runs at its next period.
+type Task func(now time.Time) error
+
+type entry struct {
+	interval time.Duration
+	task     Task
+	// serializes the scheduled runs and the runs on demand of the task
+	runMu sync.Mutex
+	done  chan struct{}
+}
+
+// Scheduler runs the periodic maintenance tasks of the framework and of the applications (cron-like).
+// Each task runs at its own interval, the runs of a task do not overlap. The tasks stop when the scheduler
+// is stopped, i.e when the framework is closed.
+type Scheduler struct {
+	mu      sync.Mutex
+	tasks   map[string]*entry
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New returns a new scheduler.
+func New() *Scheduler {
+	return &Scheduler{tasks: map[string]*entry{}}
+}
+
+// Register schedules the task to run at every interval, the first run is one interval after the registration.
+func (s *Scheduler) Register(name string, interval time.Duration, task Task) error {
+	if name == "" || task == nil {
+		return errors.New("task name and function are mandatory")
+	}
+
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval of task %s: %s", name, interval)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return ErrStopped
+	}
+
+	if _, ok := s.tasks[name]; ok {
+		return fmt.Errorf("%s: %w", name, ErrTaskRegistered)
+	}
+
+	e := &entry{interval: interval, task: task, done: make(chan struct{})}
+	s.tasks[name] = e
+
+	s.wg.Add(1)
+
+	go s.schedule(name, e)
+
+	return nil
+}
+
+// Unregister stops running the task.
+func (s *Scheduler) Unregister(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.tasks[name]
+	if !ok {
+		return fmt.Errorf("%s: %w", name, ErrTaskNotFound)
+	}
+
+	delete(s.tasks, name)
+	close(e.done)
+
+	return nil
+}
+
+// Run runs the task now, out of its schedule, and returns its error.
+func (s *Scheduler) Run(name string) error {
+	s.mu.Lock()
+	e, ok := s.tasks[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%s: %w", name, ErrTaskNotFound)
+	}
+
+	return e.run(time.Now())
+}
+
+// Tasks returns the names of the registered tasks.
+func (s *Scheduler) Tasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Stop stops all the tasks and waits for the running tasks to complete.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+
+	if !s.stopped {
+		s.stopped = true
+
+		for name, e := range s.tasks {
+			delete(s.tasks, name)
+			close(e.done)
+		}
+	}
+
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) schedule(name string, e *entry) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case now := <-ticker.C:
+			if err := e.run(now); err != nil {
+				logger.Errorf("task %s: %s", name, err)
+			}
+		}
+	}
+}
+
+func (e *entry) run(now time.Time) error {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	return e.task(now)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	interval = 10 * time.Millisecond
+	timeout  = time.Second
+)
+
+func TestScheduler_Register(t *testing.T) {
+	s := New()
+	defer s.Stop()
+
+	runs := make(chan time.Time, 10)
+
+	require.NoError(t, s.Register("cleanup", interval, func(now time.Time) error {
+		runs <- now
+		return errors.New("cleanup error")
+	}))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case now := <-runs:
+			require.False(t, now.IsZero())
+		case <-time.After(timeout):
+			t.Fatal("task not run")
+		}
+	}
+
+	err := s.Register("cleanup", interval, func(time.Time) error { return nil })
+	require.True(t, errors.Is(err, ErrTaskRegistered))
+
+	require.EqualError(t, s.Register("", interval, func(time.Time) error { return nil }),
+		"task name and function are mandatory")
+	require.EqualError(t, s.Register("task", interval, nil), "task name and function are mandatory")
+	require.EqualError(t, s.Register("task", 0, func(time.Time) error { return nil }),
+		"invalid interval of task task: 0s")
+
+	require.Equal(t, []string{"cleanup"}, s.Tasks())
+}
+
+func TestScheduler_Unregister(t *testing.T) {
+	s := New()
+	defer s.Stop()
+
+	require.NoError(t, s.Register("polling", time.Hour, func(time.Time) error { return nil }))
+	require.NoError(t, s.Register("compaction", time.Hour, func(time.Time) error { return nil }))
+	require.Equal(t, []string{"compaction", "polling"}, s.Tasks())
+
+	require.NoError(t, s.Unregister("polling"))
+	require.Equal(t, []string{"compaction"}, s.Tasks())
+
+	require.True(t, errors.Is(s.Unregister("polling"), ErrTaskNotFound))
+
+	// a task can be registered again with the name
+	require.NoError(t, s.Register("polling", time.Hour, func(time.Time) error { return nil }))
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := New()
+	defer s.Stop()
+
+	require.NoError(t, s.Register("expiry", time.Hour, func(time.Time) error { return errors.New("expiry error") }))
+
+	require.EqualError(t, s.Run("expiry"), "expiry error")
+	require.True(t, errors.Is(s.Run("unknown"), ErrTaskNotFound))
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	s := New()
+
+	started := make(chan struct{})
+	completed := make(chan struct{})
+
+	var once sync.Once
+
+	require.NoError(t, s.Register("long", interval, func(time.Time) error {
+		once.Do(func() {
+			close(started)
+			time.Sleep(5 * interval)
+			close(completed)
+		})
+
+		return nil
+	}))
+
+	select {
+	case <-started:
+	case <-time.After(timeout):
+		t.Fatal("task not run")
+	}
+
+	s.Stop()
+
+	// the running task completed
+	select {
+	case <-completed:
+	default:
+		t.Fatal("task not completed")
+	}
+
+	require.Empty(t, s.Tasks())
+	require.True(t, errors.Is(s.Register("long", interval, func(time.Time) error { return nil }), ErrStopped))
+
+	s.Stop()
+}
@@ -0,0 +1,85 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperledger/aries-framework-go/pkg/client/diagnostics"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/cmdutil"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/logutil"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+var logger = log.New("aries-framework/command/diagnostics")
+
+// Error codes
+const (
+	// SnapshotErrorCode for snapshot error
+	SnapshotErrorCode = command.Code(iota + command.Diagnostics)
+)
+
+const (
+	// command name
+	commandName = "diagnostics"
+
+	// command methods
+	snapshotCommandMethod = "Snapshot"
+
+	// log constants
+	successString = "success"
+)
+
+// provider contains dependencies for the diagnostics and is typically created by using aries.Context().
+type provider interface {
+	Service(id string) (interface{}, error)
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+}
+
+// Command contains command operations provided by diagnostics controller.
+type Command struct {
+	client *diagnostics.Client
+}
+
+// New returns new diagnostics controller command instance.
+func New(ctx provider) (*Command, error) {
+	client, err := diagnostics.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create diagnostics client : %w", err)
+	}
+
+	return &Command{
+		client: client,
+	}, nil
+}
+
+// GetHandlers returns list of all commands supported by this controller command
+func (o *Command) GetHandlers() []command.Handler {
+	return []command.Handler{
+		cmdutil.NewCommandHandler(commandName, snapshotCommandMethod, o.Snapshot),
+	}
+}
+
+// Snapshot returns the current state of the agent: the connections by state, the protocol instances in
+// progress, the queue depths, the mediation state and the store sizes.
+func (o *Command) Snapshot(rw io.Writer, req io.Reader) command.Error {
+	snapshot, err := o.client.Snapshot()
+	if err != nil {
+		logutil.LogError(logger, commandName, snapshotCommandMethod, err.Error())
+		return command.NewExecuteError(SnapshotErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, snapshot, logger)
+
+	logutil.LogDebug(logger, commandName, snapshotCommandMethod, successString)
+
+	return nil
+}
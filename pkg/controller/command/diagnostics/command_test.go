@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diagnostics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/client/diagnostics"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	mockroute "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol/route"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+type routeService struct {
+	mockroute.MockRouteSvc
+	routedKeysErr error
+}
+
+func (s *routeService) RoutedKeys() (int, error) {
+	return 2, s.routedKeysErr
+}
+
+func TestNew(t *testing.T) {
+	t.Run("test new command", func(t *testing.T) {
+		cmd, err := New(&mockprovider.Provider{
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		handlers := cmd.GetHandlers()
+		require.Equal(t, 1, len(handlers))
+	})
+
+	t.Run("test new command - client creation fail", func(t *testing.T) {
+		cmd, err := New(&mockprovider.Provider{
+			StorageProviderValue: &mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create diagnostics client")
+		require.Nil(t, cmd)
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Run("test snapshot - success", func(t *testing.T) {
+		cmd, err := New(&mockprovider.Provider{
+			ServiceMap: map[string]interface{}{
+				route.Coordination: &routeService{MockRouteSvc: mockroute.MockRouteSvc{ConnectionID: "conn-abc"}},
+			},
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := cmd.Snapshot(&b, nil)
+		require.NoError(t, cmdErr)
+
+		snapshot := &diagnostics.Snapshot{}
+		require.NoError(t, json.Unmarshal(b.Bytes(), snapshot))
+		require.Equal(t, diagnostics.Mediator{RouterConnectionID: "conn-abc", RoutedKeys: 2}, snapshot.Mediator)
+		require.Equal(t, map[string]int{"credentials": 0, "dids": 0}, snapshot.Stores)
+	})
+
+	t.Run("test snapshot - error", func(t *testing.T) {
+		cmd, err := New(&mockprovider.Provider{
+			ServiceMap: map[string]interface{}{
+				route.Coordination: &routeService{routedKeysErr: errors.New("iterator error")},
+			},
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := cmd.Snapshot(&b, nil)
+		require.Error(t, cmdErr)
+		require.Equal(t, SnapshotErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "snapshot routed keys: iterator error")
+	})
+}
@@ -55,6 +55,15 @@ const (
 
 	// IssueCredential error group for issue credential command errors
 	IssueCredential = 8000
+
+	// PresentProof error group for present proof command errors
+	PresentProof Group = 9000
+
+	// Diagnostics error group for diagnostics command errors
+	Diagnostics Group = 10000
+
+	// Webhook error group for webhook subscription command errors
+	Webhook Group = 11000
 )
 
 // Error is the  interface for representing an command error condition, with the nil value representing no error.
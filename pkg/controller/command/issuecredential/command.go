@@ -17,10 +17,13 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/cmdutil"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/internal/logutil"
 )
 
-var logger = log.New("aries-framework/controller/issuecredential")
+const logModule = "aries-framework/controller/issuecredential"
+
+var logger = log.New(logModule)
 
 const (
 	// InvalidRequestErrorCode is typically a code for validation errors
@@ -52,6 +55,10 @@ const (
 	SendRequestErrorCode
 	// ActionsErrorCode failures in actions command
 	ActionsErrorCode
+	// PauseActionErrorCode failures in pause action command
+	PauseActionErrorCode
+	// ResumeActionErrorCode failures in resume action command
+	ResumeActionErrorCode
 )
 
 const (
@@ -71,6 +78,11 @@ const (
 	declineRequest    = "DeclineRequest"
 	acceptCredential  = "AcceptCredential"
 	declineCredential = "DeclineCredential"
+	pauseAction       = "PauseAction"
+	resumeAction      = "ResumeAction"
+
+	// webhook notifier topic
+	actionsWebhookTopic = "issue-credential_actions"
 )
 
 const (
@@ -82,37 +94,122 @@ const (
 	errEmptyIssueCredential   = "empty IssueCredential"
 	errEmptyProposeCredential = "empty ProposeCredential"
 	errEmptyRequestCredential = "empty RequestCredential"
+	errEmptyResumeToken       = "empty ResumeToken"
 	// log constants
 	successString = "success"
 )
 
 // Command is controller command for issue credential
 type Command struct {
-	client *issuecredential.Client
+	client   *issuecredential.Client
+	notifier command.Notifier
+}
+
+// Opt configures the issue credential controller command.
+type Opt func(c *Command)
+
+// WithNotifier sends the action events to the notifier with a preview of the offered and issued credentials
+// (e.g to build approval UIs), the action events are not notified otherwise.
+func WithNotifier(notifier command.Notifier) Opt {
+	return func(c *Command) {
+		c.notifier = notifier
+	}
 }
 
 // New returns new issue credential controller command instance
-func New(ctx issuecredential.Provider) (*Command, error) {
+func New(ctx issuecredential.Provider, opts ...Opt) (*Command, error) {
 	client, err := issuecredential.New(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create a client: %w", err)
 	}
 
+	cmd := &Command{client: client}
+
+	for _, opt := range opts {
+		opt(cmd)
+	}
+
 	// creates action channel
 	actions := make(chan service.DIDCommAction)
 	// registers action channel to listen for events
-	if err := client.RegisterActionEvent(actions); err != nil {
+	err = client.RegisterActionEvent(actions)
+	if errors.Is(err, service.ErrChannelRegistered) {
+		// the action events are already handled by another client of the agent,
+		// the pending actions are still listed by Actions
+		logger.Warnf("issue credential action events are not notified: %s", err)
+
+		return cmd, nil
+	}
+
+	if err != nil {
 		return nil, fmt.Errorf("register action event: %w", err)
 	}
 
-	// this code listens for the action events and does nothing
-	// this trick is used to avoid error such as `no clients are registered to handle the message`
-	go func() {
-		for range actions {
+	// the actions are continued or stopped by their PIID (see Actions), listening for the action events
+	// also avoids errors such as `no clients are registered to handle the message`
+	go cmd.startClientEventListener(actions)
+
+	return cmd, nil
+}
+
+// startClientEventListener sends the action events to the notifier, if any.
+func (c *Command) startClientEventListener(actions <-chan service.DIDCommAction) {
+	for action := range actions {
+		if c.notifier == nil {
+			continue
+		}
+
+		msg := &ActionMsg{
+			PIID:    piID(action.Message),
+			Message: toMsgMap(action.Message),
 		}
-	}()
 
-	return &Command{client: client}, nil
+		previews, err := previews(action.Message)
+		if err != nil {
+			// the action is still notified, the credentials are validated once accepted
+			logger.Warnf("credential preview: %s", err)
+		}
+
+		msg.Previews = previews
+
+		c.notify(actionsWebhookTopic, msg)
+	}
+}
+
+func (c *Command) notify(topic string, msg interface{}) {
+	src, err := json.Marshal(msg)
+	if err != nil {
+		logger.Errorf("%s notification json marshal : %s", topic, err)
+
+		return
+	}
+
+	logger.Debugf("Sending notification on topic '%s', message body : %s", topic,
+		verifiable.RedactedMessage(json.RawMessage(src)))
+
+	if err := c.notifier.Notify(topic, src); err != nil {
+		logger.Errorf("%s notification webhook : %s", topic, err)
+	}
+}
+
+// piID returns the protocol state machine identifier of the message.
+func piID(msg service.DIDCommMsg) string {
+	if pthID := msg.ParentThreadID(); pthID != "" {
+		return pthID
+	}
+
+	// the ID of the exchange is set to the first message when the exchange starts
+	thID, _ := msg.ThreadID() // nolint: errcheck
+
+	return thID
+}
+
+func toMsgMap(msg service.DIDCommMsg) service.DIDCommMsgMap {
+	if msgMap, ok := msg.(service.DIDCommMsgMap); ok {
+		return msgMap
+	}
+
+	return nil
 }
 
 // GetHandlers returns list of all commands supported by this controller command
@@ -131,6 +228,8 @@ func (c *Command) GetHandlers() []command.Handler {
 		cmdutil.NewCommandHandler(commandName, declineRequest, c.DeclineRequest),
 		cmdutil.NewCommandHandler(commandName, acceptCredential, c.AcceptCredential),
 		cmdutil.NewCommandHandler(commandName, declineCredential, c.DeclineCredential),
+		cmdutil.NewCommandHandler(commandName, pauseAction, c.PauseAction),
+		cmdutil.NewCommandHandler(commandName, resumeAction, c.ResumeAction),
 	}
 }
 
@@ -507,3 +606,59 @@ func (c *Command) DeclineCredential(rw io.Writer, req io.Reader) command.Error {
 
 	return nil
 }
+
+// PauseAction parks the action until it is resumed with the returned token (see ResumeAction),
+// e.g when the user input the action requires may take days.
+func (c *Command) PauseAction(rw io.Writer, req io.Reader) command.Error {
+	var args PauseActionArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, pauseAction, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, pauseAction, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	token, err := c.client.PauseAction(args.PIID)
+	if err != nil {
+		logutil.LogError(logger, commandName, pauseAction, err.Error())
+		return command.NewExecuteError(PauseActionErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &PauseActionResponse{ResumeToken: token}, logger)
+
+	logutil.LogDebug(logger, commandName, pauseAction, successString)
+
+	return nil
+}
+
+// ResumeAction resumes the action paused with the token, the returned action is then accepted or declined
+// by its PIID.
+func (c *Command) ResumeAction(rw io.Writer, req io.Reader) command.Error {
+	var args ResumeActionArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, resumeAction, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.ResumeToken == "" {
+		logutil.LogDebug(logger, commandName, resumeAction, errEmptyResumeToken)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyResumeToken))
+	}
+
+	action, err := c.client.ResumeAction(args.ResumeToken)
+	if err != nil {
+		logutil.LogError(logger, commandName, resumeAction, err.Error())
+		return command.NewExecuteError(ResumeActionErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &ResumeActionResponse{Action: action}, logger)
+
+	logutil.LogDebug(logger, commandName, resumeAction, successString)
+
+	return nil
+}
@@ -10,19 +10,35 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/hyperledger/aries-framework-go/pkg/client/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/mocks/webhook"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	protocol "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
 	mocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/client/issuecredential"
+	mocklog "github.com/hyperledger/aries-framework-go/pkg/internal/mock/log"
 )
 
 const jsonPayload = `{"piid":"id"}`
 
+// logRecorder records the lines logged by the tests of the package
+var logRecorder = &mocklog.Recorder{} // nolint:gochecknoglobals
+
+func TestMain(m *testing.M) {
+	log.Initialize(logRecorder)
+
+	os.Exit(m.Run())
+}
+
 func TestNew(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -48,6 +64,18 @@ func TestNew(t *testing.T) {
 		require.Nil(t, cmd)
 	})
 
+	t.Run("Action events already handled", func(t *testing.T) {
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().RegisterActionEvent(gomock.Any()).Return(service.ErrChannelRegistered)
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+	})
+
 	t.Run("Register action event (error)", func(t *testing.T) {
 		service := mocks.NewMockProtocolService(ctrl)
 		service.EXPECT().RegisterActionEvent(gomock.Any()).Return(errors.New("error"))
@@ -59,6 +87,207 @@ func TestNew(t *testing.T) {
 		require.EqualError(t, err, "register action event: error")
 		require.Nil(t, cmd)
 	})
+
+	t.Run("action events are not notified without notifier", func(t *testing.T) {
+		actionCh := make(chan chan<- service.DIDCommAction, 1)
+
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().RegisterActionEvent(gomock.Any()).DoAndReturn(func(ch chan<- service.DIDCommAction) error {
+			actionCh <- ch
+			return nil
+		})
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		actions := <-actionCh
+
+		// the action events are still consumed
+		for i := 0; i < 2; i++ {
+			select {
+			case actions <- service.DIDCommAction{Message: service.NewDIDCommMsgMap(protocol.OfferCredential{})}:
+			case <-time.After(time.Second):
+				t.Fatal("action event not consumed")
+			}
+		}
+	})
+}
+
+func TestCommand_Notifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	actionCh := make(chan chan<- service.DIDCommAction, 1)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().RegisterActionEvent(gomock.Any()).DoAndReturn(func(ch chan<- service.DIDCommAction) error {
+		actionCh <- ch
+		return nil
+	})
+
+	provider := mocks.NewMockProvider(ctrl)
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+	notifications := make(chan []byte)
+
+	notifier := webhook.NewMockWebhookNotifier()
+	notifier.NotifyFunc = func(topic string, msg []byte) error {
+		require.Equal(t, actionsWebhookTopic, topic)
+
+		notifications <- msg
+
+		return errors.New("ignored")
+	}
+
+	cmd, err := New(provider, WithNotifier(notifier))
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+
+	actions := <-actionCh
+
+	receive := func() ActionMsg {
+		select {
+		case src := <-notifications:
+			action := ActionMsg{}
+			require.NoError(t, json.Unmarshal(src, &action))
+
+			return action
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		return ActionMsg{}
+	}
+
+	t.Run("offer received", func(t *testing.T) {
+		msg := service.NewDIDCommMsgMap(protocol.OfferCredential{
+			Type: protocol.OfferCredentialMsgType,
+			CredentialPreview: protocol.PreviewCredential{Attributes: []protocol.Attribute{
+				{Name: "degree", Value: "Bachelor of Science"},
+			}},
+			OffersAttach: []decorator.Attachment{{Data: decorator.AttachmentData{JSON: credential()}}},
+		})
+		msg.SetID("thID")
+
+		actions <- service.DIDCommAction{Message: msg}
+
+		action := receive()
+		require.Equal(t, "thID", action.PIID)
+		require.Equal(t, protocol.OfferCredentialMsgType, action.Message.Type())
+		require.Len(t, action.Previews, 2)
+		require.Equal(t, []string{"VerifiableCredential", "UniversityDegreeCredential"}, action.Previews[0].Types)
+		require.Equal(t, []PreviewAttribute{{Name: "degree", Value: "Bachelor of Science"}},
+			action.Previews[1].Attributes)
+	})
+
+	t.Run("credential received", func(t *testing.T) {
+		msg := service.NewDIDCommMsgMap(protocol.IssueCredential{
+			Type:              protocol.IssueCredentialMsgType,
+			CredentialsAttach: []decorator.Attachment{{Data: decorator.AttachmentData{JSON: credential()}}},
+		})
+		msg.SetID("msgID")
+		msg["~thread"] = map[string]interface{}{"thid": "thID"}
+
+		actions <- service.DIDCommAction{Message: msg}
+
+		action := receive()
+		require.Equal(t, "thID", action.PIID)
+		require.Equal(t, protocol.IssueCredentialMsgType, action.Message.Type())
+		require.Equal(t, []*CredentialPreview{{
+			ID:             "http://example.edu/credentials/1872",
+			Types:          []string{"VerifiableCredential", "UniversityDegreeCredential"},
+			Issuer:         "did:example:76e12ec712ebc6f1c221ebfeb1f",
+			IssuerName:     "Example University",
+			IssuanceDate:   "2010-01-01T19:23:24Z",
+			ExpirationDate: "2020-01-01T19:23:24Z",
+			Subject:        "did:example:ebfeb1f712ebc6f1c276e12ec21",
+			Attributes: []PreviewAttribute{
+				{Name: "degree", Value: map[string]interface{}{"type": "BachelorDegree"}},
+				{Name: "name", Value: "Jayden Doe"},
+			},
+		}}, action.Previews)
+	})
+
+	t.Run("invalid credential still notified", func(t *testing.T) {
+		msg := service.NewDIDCommMsgMap(protocol.IssueCredential{
+			Type:              protocol.IssueCredentialMsgType,
+			CredentialsAttach: []decorator.Attachment{{Data: decorator.AttachmentData{Base64: "!"}}},
+		})
+		msg.SetID("thID")
+
+		actions <- service.DIDCommAction{Message: msg}
+
+		action := receive()
+		require.Equal(t, protocol.IssueCredentialMsgType, action.Message.Type())
+		require.Empty(t, action.Previews)
+	})
+
+	t.Run("request received", func(t *testing.T) {
+		msg := service.NewDIDCommMsgMap(protocol.RequestCredential{Type: protocol.RequestCredentialMsgType})
+		msg.SetID("thID")
+
+		actions <- service.DIDCommAction{Message: msg}
+
+		action := receive()
+		require.Equal(t, protocol.RequestCredentialMsgType, action.Message.Type())
+		require.Empty(t, action.Previews)
+	})
+}
+
+func credential() map[string]interface{} {
+	return map[string]interface{}{
+		"@context": []interface{}{"https://www.w3.org/2018/credentials/v1"},
+		"id":       "http://example.edu/credentials/1872",
+		"type":     []interface{}{"VerifiableCredential", "UniversityDegreeCredential"},
+		"issuer": map[string]interface{}{
+			"id":   "did:example:76e12ec712ebc6f1c221ebfeb1f",
+			"name": "Example University",
+		},
+		"issuanceDate":   "2010-01-01T19:23:24Z",
+		"expirationDate": "2020-01-01T19:23:24Z",
+		"credentialSubject": map[string]interface{}{
+			"id":     "did:example:ebfeb1f712ebc6f1c276e12ec21",
+			"name":   "Jayden Doe",
+			"degree": map[string]interface{}{"type": "BachelorDegree"},
+		},
+	}
+}
+
+func TestCommand_NotificationsRedactedLogs(t *testing.T) {
+	log.SetLevel(logModule, log.DEBUG)
+	defer log.SetLevel(logModule, log.INFO)
+
+	logRecorder.Reset()
+
+	notifier := webhook.NewMockWebhookNotifier()
+
+	msg := service.NewDIDCommMsgMap(protocol.IssueCredential{
+		Type: protocol.IssueCredentialMsgType,
+		CredentialsAttach: []decorator.Attachment{{Data: decorator.AttachmentData{JSON: map[string]interface{}{
+			"type":              "VerifiableCredential",
+			"credentialSubject": map[string]interface{}{"id": "did:example:holder", "name": "Alice Smith"},
+		}}}},
+	})
+
+	(&Command{notifier: notifier}).notify(actionsWebhookTopic, &ActionMsg{
+		PIID:    "piid",
+		Message: msg,
+		Previews: []*CredentialPreview{{
+			Types:      []string{"VerifiableCredential"},
+			Attributes: []PreviewAttribute{{Name: "name", Value: "Alice Smith"}},
+		}},
+	})
+
+	lines := logRecorder.Lines(logModule)
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"credentialSubject":{"id":"***","name":"***"}`)
+	require.Contains(t, lines[0], `"attributes":[{"name":"name","value":"***"}]`)
+	require.NotContains(t, lines[0], "Alice Smith")
+	require.NotContains(t, lines[0], "did:example:holder")
 }
 
 func TestCommand_Actions(t *testing.T) {
@@ -1154,6 +1383,166 @@ func TestCommand_DeclineCredential(t *testing.T) {
 	})
 }
 
+func TestCommand_PauseAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := mocks.NewMockProtocolService(ctrl)
+	service.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil).AnyTimes()
+
+	provider := mocks.NewMockProvider(ctrl)
+	provider.EXPECT().Service(gomock.Any()).Return(service, nil).AnyTimes()
+
+	t.Run("Decode error", func(t *testing.T) {
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		cmdErr := cmd.PauseAction(&b, bytes.NewBufferString("}"))
+
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+
+	t.Run("Empty PIID", func(t *testing.T) {
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		cmdErr := cmd.PauseAction(&b, bytes.NewBufferString("{}"))
+
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), errEmptyPIID)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+
+	t.Run("PauseAction (error)", func(t *testing.T) {
+		service := mocks.NewMockProtocolService(ctrl)
+		service.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil)
+		service.EXPECT().ActionPause("id").Return("", errors.New("some error message"))
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(service, nil)
+
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		cmdErr := cmd.PauseAction(&b, bytes.NewBufferString(jsonPayload))
+
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "some error message")
+		require.Equal(t, PauseActionErrorCode, cmdErr.Code())
+		require.Equal(t, command.ExecuteError, cmdErr.Type())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		service := mocks.NewMockProtocolService(ctrl)
+		service.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil)
+		service.EXPECT().ActionPause("id").Return("token", nil)
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(service, nil)
+
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		require.NoError(t, cmd.PauseAction(&b, bytes.NewBufferString(jsonPayload)))
+
+		response := PauseActionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(&response))
+		require.Equal(t, "token", response.ResumeToken)
+	})
+}
+
+func TestCommand_ResumeAction(t *testing.T) {
+	const tokenPayload = `{"resume_token":"token"}`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := mocks.NewMockProtocolService(ctrl)
+	service.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil).AnyTimes()
+
+	provider := mocks.NewMockProvider(ctrl)
+	provider.EXPECT().Service(gomock.Any()).Return(service, nil).AnyTimes()
+
+	t.Run("Decode error", func(t *testing.T) {
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		cmdErr := cmd.ResumeAction(&b, bytes.NewBufferString("}"))
+
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+
+	t.Run("Empty ResumeToken", func(t *testing.T) {
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		cmdErr := cmd.ResumeAction(&b, bytes.NewBufferString("{}"))
+
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), errEmptyResumeToken)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+
+	t.Run("ResumeAction (error)", func(t *testing.T) {
+		service := mocks.NewMockProtocolService(ctrl)
+		service.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil)
+		service.EXPECT().ActionResume("token").Return(nil, protocol.ErrUnknownResumeToken)
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(service, nil)
+
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		cmdErr := cmd.ResumeAction(&b, bytes.NewBufferString(tokenPayload))
+
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), protocol.ErrUnknownResumeToken.Error())
+		require.Equal(t, ResumeActionErrorCode, cmdErr.Code())
+		require.Equal(t, command.ExecuteError, cmdErr.Type())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		service := mocks.NewMockProtocolService(ctrl)
+		service.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil)
+		service.EXPECT().ActionResume("token").Return(&protocol.Action{PIID: "id"}, nil)
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(service, nil)
+
+		cmd, err := New(provider)
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		var b bytes.Buffer
+		require.NoError(t, cmd.ResumeAction(&b, bytes.NewBufferString(tokenPayload)))
+
+		response := ResumeActionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(&response))
+		require.Equal(t, "id", response.Action.PIID)
+	})
+}
+
 func toProtocolActions(actions []issuecredential.Action) []protocol.Action {
 	res := make([]protocol.Action, len(actions))
 	for i, action := range actions {
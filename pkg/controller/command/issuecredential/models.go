@@ -6,7 +6,10 @@ SPDX-License-Identifier: Apache-2.0
 
 package issuecredential
 
-import "github.com/hyperledger/aries-framework-go/pkg/client/issuecredential"
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/client/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+)
 
 // AcceptProposalArgs model
 //
@@ -227,3 +230,89 @@ type SendRequestResponse struct{}
 type ActionsResponse struct {
 	Actions []issuecredential.Action `json:"actions"`
 }
+
+// PauseActionArgs model
+//
+// This is used for pausing an action
+//
+type PauseActionArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+}
+
+// PauseActionResponse model
+//
+// Represents a PauseAction response message
+//
+type PauseActionResponse struct {
+	// ResumeToken is the token the action is resumed with
+	ResumeToken string `json:"resume_token"`
+}
+
+// ResumeActionArgs model
+//
+// This is used for resuming a paused action
+//
+type ResumeActionArgs struct {
+	// ResumeToken is the token returned when the action was paused
+	ResumeToken string `json:"resume_token"`
+}
+
+// ResumeActionResponse model
+//
+// Represents a ResumeAction response message
+//
+type ResumeActionResponse struct {
+	Action *issuecredential.Action `json:"action"`
+}
+
+// ActionMsg model
+//
+// Represents the notification of an action event, the action is continued or stopped by its PIID
+//
+type ActionMsg struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// Message is the received message the action is about
+	Message service.DIDCommMsgMap `json:"message"`
+	// Previews of the credentials offered (offer-credential) or issued (issue-credential) by the message,
+	// to approve them without parsing the attachments
+	Previews []*CredentialPreview `json:"previews,omitempty"`
+}
+
+// CredentialPreview model
+//
+// Represents a human-friendly preview of an offered or issued credential. The preview of the attributes
+// of an offer (credential_preview) has no other field.
+//
+type CredentialPreview struct {
+	// ID of the credential
+	ID string `json:"id,omitempty"`
+	// Types of the credential
+	Types []string `json:"types,omitempty"`
+	// Issuer is the ID of the issuer
+	Issuer string `json:"issuer,omitempty"`
+	// IssuerName is the name of the issuer
+	IssuerName string `json:"issuer_name,omitempty"`
+	// IssuanceDate of the credential
+	IssuanceDate string `json:"issuance_date,omitempty"`
+	// ExpirationDate of the credential
+	ExpirationDate string `json:"expiration_date,omitempty"`
+	// Subject is the ID of the credential subject
+	Subject string `json:"subject,omitempty"`
+	// Attributes are the claims about the subject
+	Attributes []PreviewAttribute `json:"attributes,omitempty"`
+}
+
+// PreviewAttribute model
+//
+// Represents a claim of a credential preview
+//
+type PreviewAttribute struct {
+	// Name of the attribute
+	Name string `json:"name"`
+	// MimeType of the value, if any
+	MimeType string `json:"mime_type,omitempty"`
+	// Value of the attribute, a nested object for structured claims
+	Value interface{} `json:"value"`
+}
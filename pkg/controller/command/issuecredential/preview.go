@@ -0,0 +1,198 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	protocol "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+)
+
+// previews returns the previews of the credentials offered or issued by the message, the other messages
+// have no preview.
+func previews(msg service.DIDCommMsg) ([]*CredentialPreview, error) {
+	msgMap, ok := msg.(service.DIDCommMsgMap)
+	if !ok {
+		return nil, nil
+	}
+
+	switch msg.Type() {
+	case protocol.OfferCredentialMsgType:
+		offer := protocol.OfferCredential{}
+		if err := msgMap.Decode(&offer); err != nil {
+			return nil, fmt.Errorf("decode offer: %w", err)
+		}
+
+		result, err := attachmentPreviews(offer.OffersAttach)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(offer.CredentialPreview.Attributes) == 0 {
+			return result, nil
+		}
+
+		// the attributes the issuer is willing to issue, when the offer does not attach the credential itself
+		preview := &CredentialPreview{}
+
+		for _, attr := range offer.CredentialPreview.Attributes {
+			preview.Attributes = append(preview.Attributes, PreviewAttribute{
+				Name:     attr.Name,
+				MimeType: attr.MimeType,
+				Value:    attr.Value,
+			})
+		}
+
+		return append(result, preview), nil
+	case protocol.IssueCredentialMsgType:
+		credential := protocol.IssueCredential{}
+		if err := msgMap.Decode(&credential); err != nil {
+			return nil, fmt.Errorf("decode credential: %w", err)
+		}
+
+		return attachmentPreviews(credential.CredentialsAttach)
+	}
+
+	return nil, nil
+}
+
+func attachmentPreviews(attachments []decorator.Attachment) ([]*CredentialPreview, error) {
+	var result []*CredentialPreview
+
+	for i := range attachments {
+		raw, err := attachmentJSON(&attachments[i])
+		if err != nil {
+			return nil, fmt.Errorf("attachment %d: %w", i, err)
+		}
+
+		// only the attached credentials are previewed, not the links to them
+		if raw == nil {
+			continue
+		}
+
+		preview, err := credentialPreview(raw)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %d: %w", i, err)
+		}
+
+		result = append(result, preview)
+	}
+
+	return result, nil
+}
+
+func attachmentJSON(attachment *decorator.Attachment) (map[string]interface{}, error) {
+	var src []byte
+
+	switch {
+	case attachment.Data.JSON != nil:
+		raw, err := json.Marshal(attachment.Data.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("marshal: %w", err)
+		}
+
+		src = raw
+	case attachment.Data.Base64 != "":
+		raw, err := base64.StdEncoding.DecodeString(attachment.Data.Base64)
+		if err != nil {
+			return nil, fmt.Errorf("decode base64: %w", err)
+		}
+
+		src = raw
+	default:
+		return nil, nil
+	}
+
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(src, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshal credential: %w", err)
+	}
+
+	return raw, nil
+}
+
+// credentialPreview reads the credential fields a human needs to approve it, the credential is neither
+// validated nor verified: it is done by the protocol once the credential is accepted.
+func credentialPreview(raw map[string]interface{}) (*CredentialPreview, error) {
+	preview := &CredentialPreview{
+		ID:             stringValue(raw["id"]),
+		Types:          stringValues(raw["type"]),
+		IssuanceDate:   stringValue(raw["issuanceDate"]),
+		ExpirationDate: stringValue(raw["expirationDate"]),
+	}
+
+	switch issuer := raw["issuer"].(type) {
+	case string:
+		preview.Issuer = issuer
+	case map[string]interface{}:
+		preview.Issuer = stringValue(issuer["id"])
+		preview.IssuerName = stringValue(issuer["name"])
+	}
+
+	var subject map[string]interface{}
+
+	switch s := raw["credentialSubject"].(type) {
+	case map[string]interface{}:
+		subject = s
+	case []interface{}:
+		// the attributes of the first subject, usually the holder
+		if len(s) > 0 {
+			subject, _ = s[0].(map[string]interface{}) // nolint: errcheck
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("unsupported credential subject: %v", s)
+	}
+
+	preview.Subject = stringValue(subject["id"])
+
+	names := make([]string, 0, len(subject))
+
+	for name := range subject {
+		if name != "id" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		preview.Attributes = append(preview.Attributes, PreviewAttribute{Name: name, Value: subject[name]})
+	}
+
+	return preview, nil
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string) // nolint: errcheck
+
+	return s
+}
+
+func stringValues(v interface{}) []string {
+	switch values := v.(type) {
+	case string:
+		return []string{values}
+	case []interface{}:
+		result := make([]string, 0, len(values))
+
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				result = append(result, s)
+			}
+		}
+
+		return result
+	}
+
+	return nil
+}
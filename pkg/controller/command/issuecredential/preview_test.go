@@ -0,0 +1,83 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	protocol "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+)
+
+func TestPreviews(t *testing.T) {
+	t.Run("base64 credential", func(t *testing.T) {
+		raw, err := json.Marshal(map[string]interface{}{
+			"type":              "VerifiableCredential",
+			"issuer":            "did:example:issuer",
+			"credentialSubject": []interface{}{map[string]interface{}{"id": "did:example:holder", "age": 42}},
+		})
+		require.NoError(t, err)
+
+		result, err := previews(service.NewDIDCommMsgMap(protocol.IssueCredential{
+			Type: protocol.IssueCredentialMsgType,
+			CredentialsAttach: []decorator.Attachment{
+				{Data: decorator.AttachmentData{Base64: base64.StdEncoding.EncodeToString(raw)}},
+				{Data: decorator.AttachmentData{Links: []string{"https://example.com/credential"}}},
+			},
+		}))
+		require.NoError(t, err)
+		require.Equal(t, []*CredentialPreview{{
+			Types:      []string{"VerifiableCredential"},
+			Issuer:     "did:example:issuer",
+			Subject:    "did:example:holder",
+			Attributes: []PreviewAttribute{{Name: "age", Value: float64(42)}},
+		}}, result)
+	})
+
+	t.Run("offer without attributes", func(t *testing.T) {
+		result, err := previews(service.NewDIDCommMsgMap(protocol.OfferCredential{
+			Type: protocol.OfferCredentialMsgType,
+		}))
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("not a credential", func(t *testing.T) {
+		_, err := previews(service.NewDIDCommMsgMap(protocol.OfferCredential{
+			Type:         protocol.OfferCredentialMsgType,
+			OffersAttach: []decorator.Attachment{{Data: decorator.AttachmentData{JSON: []string{"credential"}}}},
+		}))
+		require.Contains(t, err.Error(), "attachment 0: unmarshal credential")
+
+		_, err = previews(service.NewDIDCommMsgMap(protocol.IssueCredential{
+			Type: protocol.IssueCredentialMsgType,
+			CredentialsAttach: []decorator.Attachment{{Data: decorator.AttachmentData{
+				JSON: map[string]interface{}{"credentialSubject": "did:example:holder"},
+			}}},
+		}))
+		require.EqualError(t, err, "attachment 0: unsupported credential subject: did:example:holder")
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		_, err := previews(service.DIDCommMsgMap{
+			"@type":              protocol.IssueCredentialMsgType,
+			"credentials~attach": "invalid",
+		})
+		require.Contains(t, err.Error(), "decode credential")
+
+		_, err = previews(service.DIDCommMsgMap{
+			"@type":         protocol.OfferCredentialMsgType,
+			"offers~attach": "invalid",
+		})
+		require.Contains(t, err.Error(), "decode offer")
+	})
+}
@@ -0,0 +1,520 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hyperledger/aries-framework-go/pkg/client/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/cmdutil"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/logutil"
+)
+
+const logModule = "aries-framework/controller/presentproof"
+
+var logger = log.New(logModule)
+
+const (
+	// InvalidRequestErrorCode is typically a code for validation errors
+	// for invalid present proof controller requests
+	InvalidRequestErrorCode = command.Code(iota + command.PresentProof)
+	// ActionsErrorCode failures in actions command
+	ActionsErrorCode
+	// SendRequestPresentationErrorCode failures in send request presentation command
+	SendRequestPresentationErrorCode
+	// SendProposePresentationErrorCode failures in send propose presentation command
+	SendProposePresentationErrorCode
+	// AcceptRequestPresentationErrorCode failures in accept request presentation command
+	AcceptRequestPresentationErrorCode
+	// DeclineRequestPresentationErrorCode failures in decline request presentation command
+	DeclineRequestPresentationErrorCode
+	// AcceptProposePresentationErrorCode failures in accept propose presentation command
+	AcceptProposePresentationErrorCode
+	// DeclineProposePresentationErrorCode failures in decline propose presentation command
+	DeclineProposePresentationErrorCode
+	// AcceptPresentationErrorCode failures in accept presentation command
+	AcceptPresentationErrorCode
+	// DeclinePresentationErrorCode failures in decline presentation command
+	DeclinePresentationErrorCode
+	// PauseActionErrorCode failures in pause action command
+	PauseActionErrorCode
+	// ResumeActionErrorCode failures in resume action command
+	ResumeActionErrorCode
+)
+
+const (
+	// command name
+	commandName = "presentproof"
+
+	actions                    = "Actions"
+	sendRequestPresentation    = "SendRequestPresentation"
+	sendProposePresentation    = "SendProposePresentation"
+	acceptRequestPresentation  = "AcceptRequestPresentation"
+	declineRequestPresentation = "DeclineRequestPresentation"
+	acceptProposePresentation  = "AcceptProposePresentation"
+	declineProposePresentation = "DeclineProposePresentation"
+	acceptPresentation         = "AcceptPresentation"
+	declinePresentation        = "DeclinePresentation"
+	pauseAction                = "PauseAction"
+	resumeAction               = "ResumeAction"
+
+	// webhook notifier topics
+	actionsWebhookTopic = "present-proof_actions"
+	statesWebhookTopic  = "present-proof_states"
+)
+
+const (
+	// error messages
+	errEmptyPIID                = "empty PIID"
+	errEmptyMyDID               = "empty MyDID"
+	errEmptyTheirDID            = "empty TheirDID"
+	errEmptyPresentation        = "empty Presentation"
+	errEmptyProposePresentation = "empty ProposePresentation"
+	errEmptyRequestPresentation = "empty RequestPresentation"
+	errEmptyResumeToken         = "empty ResumeToken"
+	// log constants
+	successString = "success"
+)
+
+// Command is controller command for present proof
+type Command struct {
+	client   *presentproof.Client
+	notifier command.Notifier
+}
+
+// New returns new present proof controller command instance, the action events and the state changes
+// of the exchanges are sent to the notifier (e.g to drive the protocol from JavaScript).
+func New(ctx presentproof.Provider, notifier command.Notifier) (*Command, error) {
+	client, err := presentproof.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create a client: %w", err)
+	}
+
+	cmd := &Command{client: client, notifier: notifier}
+
+	// the actions are continued or stopped by their PIID (see Actions)
+	actionCh := make(chan service.DIDCommAction)
+
+	err = client.RegisterActionEvent(actionCh)
+	if errors.Is(err, service.ErrChannelRegistered) {
+		// the action events are already handled by another client of the agent,
+		// the pending actions are still listed by Actions
+		logger.Warnf("present proof action events are not notified: %s", err)
+
+		actionCh = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("register action event: %w", err)
+	}
+
+	msgCh := make(chan service.StateMsg)
+	if err := client.RegisterMsgEvent(msgCh); err != nil {
+		return nil, fmt.Errorf("register msg event: %w", err)
+	}
+
+	go cmd.startClientEventListener(actionCh, msgCh)
+
+	return cmd, nil
+}
+
+// startClientEventListener sends the action events and the state changes to the notifier.
+func (c *Command) startClientEventListener(actionCh <-chan service.DIDCommAction, msgCh <-chan service.StateMsg) {
+	for {
+		select {
+		case action := <-actionCh:
+			c.notify(actionsWebhookTopic, &ActionMsg{
+				PIID:    piID(action.Message),
+				Message: toMsgMap(action.Message),
+			})
+		case msg := <-msgCh:
+			if msg.Type != service.PostState {
+				continue
+			}
+
+			c.notify(statesWebhookTopic, &StateMsg{
+				PIID:    piID(msg.Msg),
+				StateID: msg.StateID,
+				Message: toMsgMap(msg.Msg),
+			})
+		}
+	}
+}
+
+func (c *Command) notify(topic string, msg interface{}) {
+	src, err := json.Marshal(msg)
+	if err != nil {
+		logger.Errorf("%s notification json marshal : %s", topic, err)
+
+		return
+	}
+
+	logger.Debugf("Sending notification on topic '%s', message body : %s", topic,
+		verifiable.RedactedMessage(json.RawMessage(src)))
+
+	if err := c.notifier.Notify(topic, src); err != nil {
+		logger.Errorf("%s notification webhook : %s", topic, err)
+	}
+}
+
+// piID returns the protocol state machine identifier of the message.
+func piID(msg service.DIDCommMsg) string {
+	if pthID := msg.ParentThreadID(); pthID != "" {
+		return pthID
+	}
+
+	// the ID of the exchange is set to the first message when the exchange starts
+	thID, _ := msg.ThreadID() // nolint: errcheck
+
+	return thID
+}
+
+func toMsgMap(msg service.DIDCommMsg) service.DIDCommMsgMap {
+	if msgMap, ok := msg.(service.DIDCommMsgMap); ok {
+		return msgMap
+	}
+
+	return nil
+}
+
+// GetHandlers returns list of all commands supported by this controller command
+func (c *Command) GetHandlers() []command.Handler {
+	return []command.Handler{
+		cmdutil.NewCommandHandler(commandName, actions, c.Actions),
+		cmdutil.NewCommandHandler(commandName, sendRequestPresentation, c.SendRequestPresentation),
+		cmdutil.NewCommandHandler(commandName, sendProposePresentation, c.SendProposePresentation),
+		cmdutil.NewCommandHandler(commandName, acceptRequestPresentation, c.AcceptRequestPresentation),
+		cmdutil.NewCommandHandler(commandName, declineRequestPresentation, c.DeclineRequestPresentation),
+		cmdutil.NewCommandHandler(commandName, acceptProposePresentation, c.AcceptProposePresentation),
+		cmdutil.NewCommandHandler(commandName, declineProposePresentation, c.DeclineProposePresentation),
+		cmdutil.NewCommandHandler(commandName, acceptPresentation, c.AcceptPresentation),
+		cmdutil.NewCommandHandler(commandName, declinePresentation, c.DeclinePresentation),
+		cmdutil.NewCommandHandler(commandName, pauseAction, c.PauseAction),
+		cmdutil.NewCommandHandler(commandName, resumeAction, c.ResumeAction),
+	}
+}
+
+// Actions returns pending actions that have yet to be executed or cancelled.
+func (c *Command) Actions(rw io.Writer, _ io.Reader) command.Error {
+	result, err := c.client.Actions()
+	if err != nil {
+		logutil.LogError(logger, commandName, actions, err.Error())
+		return command.NewExecuteError(ActionsErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &ActionsResponse{
+		Actions: result,
+	}, logger)
+
+	return nil
+}
+
+// SendRequestPresentation is used by the Verifier to send a request presentation.
+// nolint: dupl
+func (c *Command) SendRequestPresentation(rw io.Writer, req io.Reader) command.Error {
+	var args SendRequestPresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, sendRequestPresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.MyDID == "" {
+		logutil.LogDebug(logger, commandName, sendRequestPresentation, errEmptyMyDID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyMyDID))
+	}
+
+	if args.TheirDID == "" {
+		logutil.LogDebug(logger, commandName, sendRequestPresentation, errEmptyTheirDID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyTheirDID))
+	}
+
+	if args.RequestPresentation == nil {
+		logutil.LogDebug(logger, commandName, sendRequestPresentation, errEmptyRequestPresentation)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyRequestPresentation))
+	}
+
+	if err := c.client.SendRequestPresentation(args.RequestPresentation, args.MyDID, args.TheirDID); err != nil {
+		logutil.LogError(logger, commandName, sendRequestPresentation, err.Error())
+		return command.NewExecuteError(SendRequestPresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &SendRequestPresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, sendRequestPresentation, successString)
+
+	return nil
+}
+
+// SendProposePresentation is used by the Prover to send a propose presentation.
+// nolint: dupl
+func (c *Command) SendProposePresentation(rw io.Writer, req io.Reader) command.Error {
+	var args SendProposePresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, sendProposePresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.MyDID == "" {
+		logutil.LogDebug(logger, commandName, sendProposePresentation, errEmptyMyDID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyMyDID))
+	}
+
+	if args.TheirDID == "" {
+		logutil.LogDebug(logger, commandName, sendProposePresentation, errEmptyTheirDID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyTheirDID))
+	}
+
+	if args.ProposePresentation == nil {
+		logutil.LogDebug(logger, commandName, sendProposePresentation, errEmptyProposePresentation)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyProposePresentation))
+	}
+
+	if err := c.client.SendProposePresentation(args.ProposePresentation, args.MyDID, args.TheirDID); err != nil {
+		logutil.LogError(logger, commandName, sendProposePresentation, err.Error())
+		return command.NewExecuteError(SendProposePresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &SendProposePresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, sendProposePresentation, successString)
+
+	return nil
+}
+
+// AcceptRequestPresentation is used by the Prover to send a presentation in response to the request.
+// nolint: dupl
+func (c *Command) AcceptRequestPresentation(rw io.Writer, req io.Reader) command.Error {
+	var args AcceptRequestPresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, acceptRequestPresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, acceptRequestPresentation, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	if args.Presentation == nil {
+		logutil.LogDebug(logger, commandName, acceptRequestPresentation, errEmptyPresentation)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPresentation))
+	}
+
+	if err := c.client.AcceptRequestPresentation(args.PIID, args.Presentation); err != nil {
+		logutil.LogError(logger, commandName, acceptRequestPresentation, err.Error())
+		return command.NewExecuteError(AcceptRequestPresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &AcceptRequestPresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, acceptRequestPresentation, successString)
+
+	return nil
+}
+
+// DeclineRequestPresentation is used when the Prover does not want to accept the request presentation.
+// nolint: dupl
+func (c *Command) DeclineRequestPresentation(rw io.Writer, req io.Reader) command.Error {
+	var args DeclineRequestPresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, declineRequestPresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, declineRequestPresentation, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	if err := c.client.DeclineRequestPresentation(args.PIID, args.Reason); err != nil {
+		logutil.LogError(logger, commandName, declineRequestPresentation, err.Error())
+		return command.NewExecuteError(DeclineRequestPresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &DeclineRequestPresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, declineRequestPresentation, successString)
+
+	return nil
+}
+
+// AcceptProposePresentation is used when the Verifier is willing to accept the propose presentation.
+// nolint: dupl
+func (c *Command) AcceptProposePresentation(rw io.Writer, req io.Reader) command.Error {
+	var args AcceptProposePresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, acceptProposePresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, acceptProposePresentation, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	if args.RequestPresentation == nil {
+		logutil.LogDebug(logger, commandName, acceptProposePresentation, errEmptyRequestPresentation)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyRequestPresentation))
+	}
+
+	if err := c.client.AcceptProposePresentation(args.PIID, args.RequestPresentation); err != nil {
+		logutil.LogError(logger, commandName, acceptProposePresentation, err.Error())
+		return command.NewExecuteError(AcceptProposePresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &AcceptProposePresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, acceptProposePresentation, successString)
+
+	return nil
+}
+
+// DeclineProposePresentation is used when the Verifier does not want to accept the propose presentation.
+// nolint: dupl
+func (c *Command) DeclineProposePresentation(rw io.Writer, req io.Reader) command.Error {
+	var args DeclineProposePresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, declineProposePresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, declineProposePresentation, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	if err := c.client.DeclineProposePresentation(args.PIID, args.Reason); err != nil {
+		logutil.LogError(logger, commandName, declineProposePresentation, err.Error())
+		return command.NewExecuteError(DeclineProposePresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &DeclineProposePresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, declineProposePresentation, successString)
+
+	return nil
+}
+
+// AcceptPresentation is used by the Verifier to accept a presentation.
+func (c *Command) AcceptPresentation(rw io.Writer, req io.Reader) command.Error {
+	var args AcceptPresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, acceptPresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, acceptPresentation, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	if err := c.client.AcceptPresentation(args.PIID); err != nil {
+		logutil.LogError(logger, commandName, acceptPresentation, err.Error())
+		return command.NewExecuteError(AcceptPresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &AcceptPresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, acceptPresentation, successString)
+
+	return nil
+}
+
+// DeclinePresentation is used by the Verifier to decline a presentation.
+// nolint: dupl
+func (c *Command) DeclinePresentation(rw io.Writer, req io.Reader) command.Error {
+	var args DeclinePresentationArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, declinePresentation, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, declinePresentation, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	if err := c.client.DeclinePresentation(args.PIID, args.Reason); err != nil {
+		logutil.LogError(logger, commandName, declinePresentation, err.Error())
+		return command.NewExecuteError(DeclinePresentationErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &DeclinePresentationResponse{}, logger)
+
+	logutil.LogDebug(logger, commandName, declinePresentation, successString)
+
+	return nil
+}
+
+// PauseAction parks the action until it is resumed with the returned token (see ResumeAction),
+// e.g when the user input the action requires may take days.
+func (c *Command) PauseAction(rw io.Writer, req io.Reader) command.Error {
+	var args PauseActionArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, pauseAction, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.PIID == "" {
+		logutil.LogDebug(logger, commandName, pauseAction, errEmptyPIID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyPIID))
+	}
+
+	token, err := c.client.PauseAction(args.PIID)
+	if err != nil {
+		logutil.LogError(logger, commandName, pauseAction, err.Error())
+		return command.NewExecuteError(PauseActionErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &PauseActionResponse{ResumeToken: token}, logger)
+
+	logutil.LogDebug(logger, commandName, pauseAction, successString)
+
+	return nil
+}
+
+// ResumeAction resumes the action paused with the token, the returned action is then accepted or declined
+// by its PIID.
+func (c *Command) ResumeAction(rw io.Writer, req io.Reader) command.Error {
+	var args ResumeActionArgs
+
+	if err := json.NewDecoder(req).Decode(&args); err != nil {
+		logutil.LogInfo(logger, commandName, resumeAction, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if args.ResumeToken == "" {
+		logutil.LogDebug(logger, commandName, resumeAction, errEmptyResumeToken)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyResumeToken))
+	}
+
+	action, err := c.client.ResumeAction(args.ResumeToken)
+	if err != nil {
+		logutil.LogError(logger, commandName, resumeAction, err.Error())
+		return command.NewExecuteError(ResumeActionErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &ResumeActionResponse{Action: action}, logger)
+
+	logutil.LogDebug(logger, commandName, resumeAction, successString)
+
+	return nil
+}
@@ -0,0 +1,497 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/mocks/webhook"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	protocol "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	mocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/client/presentproof"
+	mocklog "github.com/hyperledger/aries-framework-go/pkg/internal/mock/log"
+)
+
+const (
+	jsonPIID    = `{"piid":"id"}`
+	jsonPayload = `{"my_did":"id1","their_did":"id2","request_presentation":{},"propose_presentation":{}}`
+)
+
+func newProvider(ctrl *gomock.Controller) (*mocks.MockProvider, *mocks.MockProtocolService) {
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil).AnyTimes()
+	svc.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil).AnyTimes()
+
+	provider := mocks.NewMockProvider(ctrl)
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil).AnyTimes()
+
+	return provider, svc
+}
+
+// logRecorder records the lines logged by the tests of the package
+var logRecorder = &mocklog.Recorder{} // nolint:gochecknoglobals
+
+func TestMain(m *testing.M) {
+	log.Initialize(logRecorder)
+
+	os.Exit(m.Run())
+}
+
+func TestNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("Success", func(t *testing.T) {
+		provider, _ := newProvider(ctrl)
+
+		cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+		require.Len(t, cmd.GetHandlers(), 11)
+	})
+
+	t.Run("Create client (error)", func(t *testing.T) {
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(nil, nil)
+
+		cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+		require.EqualError(t, err, "cannot create a client: cast service to presentproof service failed")
+		require.Nil(t, cmd)
+	})
+
+	t.Run("Action event already registered", func(t *testing.T) {
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().RegisterActionEvent(gomock.Any()).Return(service.ErrChannelRegistered)
+		svc.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil)
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+		cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+	})
+
+	t.Run("Register action event (error)", func(t *testing.T) {
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().RegisterActionEvent(gomock.Any()).Return(errors.New("error"))
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+		cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+		require.EqualError(t, err, "register action event: error")
+		require.Nil(t, cmd)
+	})
+
+	t.Run("Register msg event (error)", func(t *testing.T) {
+		svc := mocks.NewMockProtocolService(ctrl)
+		svc.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil)
+		svc.EXPECT().RegisterMsgEvent(gomock.Any()).Return(errors.New("error"))
+
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+		cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+		require.EqualError(t, err, "register msg event: error")
+		require.Nil(t, cmd)
+	})
+}
+
+func TestCommand_Notifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	actionCh := make(chan chan<- service.DIDCommAction, 1)
+	msgCh := make(chan chan<- service.StateMsg, 1)
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().RegisterActionEvent(gomock.Any()).DoAndReturn(func(ch chan<- service.DIDCommAction) error {
+		actionCh <- ch
+		return nil
+	})
+	svc.EXPECT().RegisterMsgEvent(gomock.Any()).DoAndReturn(func(ch chan<- service.StateMsg) error {
+		msgCh <- ch
+		return nil
+	})
+
+	provider := mocks.NewMockProvider(ctrl)
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+	type notification struct {
+		topic string
+		msg   []byte
+	}
+
+	notifications := make(chan notification)
+
+	notifier := webhook.NewMockWebhookNotifier()
+	notifier.NotifyFunc = func(topic string, msg []byte) error {
+		notifications <- notification{topic: topic, msg: msg}
+
+		return errors.New("ignored")
+	}
+
+	cmd, err := New(provider, notifier)
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+
+	msg := service.NewDIDCommMsgMap(protocol.RequestPresentation{
+		Type: protocol.RequestPresentationMsgType,
+	})
+	msg.SetID("thID")
+
+	(<-actionCh) <- service.DIDCommAction{Message: msg}
+
+	select {
+	case n := <-notifications:
+		require.Equal(t, actionsWebhookTopic, n.topic)
+
+		action := ActionMsg{}
+		require.NoError(t, json.Unmarshal(n.msg, &action))
+		require.Equal(t, "thID", action.PIID)
+		require.Equal(t, protocol.RequestPresentationMsgType, action.Message.Type())
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	states := <-msgCh
+
+	// the state changes are only notified once the transition is done
+	states <- service.StateMsg{Type: service.PreState, Msg: msg, StateID: "request-received"}
+
+	msg = service.NewDIDCommMsgMap(protocol.Presentation{Type: protocol.PresentationMsgType})
+	msg["~thread"] = map[string]interface{}{"thid": "thID", "pthid": "pthID"}
+	states <- service.StateMsg{Type: service.PostState, Msg: msg, StateID: "presentation-sent"}
+
+	select {
+	case n := <-notifications:
+		require.Equal(t, statesWebhookTopic, n.topic)
+
+		state := StateMsg{}
+		require.NoError(t, json.Unmarshal(n.msg, &state))
+		require.Equal(t, "pthID", state.PIID)
+		require.Equal(t, "presentation-sent", state.StateID)
+		require.Equal(t, protocol.PresentationMsgType, state.Message.Type())
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestCommand_NotificationsRedactedLogs(t *testing.T) {
+	log.SetLevel(logModule, log.DEBUG)
+	defer log.SetLevel(logModule, log.INFO)
+
+	logRecorder.Reset()
+
+	notifier := webhook.NewMockWebhookNotifier()
+
+	msg := service.NewDIDCommMsgMap(protocol.Presentation{
+		Type: protocol.PresentationMsgType,
+		Presentations: []decorator.Attachment{{Data: decorator.AttachmentData{JSON: map[string]interface{}{
+			"type": "VerifiablePresentation",
+			"verifiableCredential": []interface{}{map[string]interface{}{
+				"credentialSubject": map[string]interface{}{"id": "did:example:holder", "name": "Alice Smith"},
+			}},
+		}}}},
+	})
+
+	(&Command{notifier: notifier}).notify(statesWebhookTopic, &StateMsg{PIID: "piid", Message: msg})
+
+	lines := logRecorder.Lines(logModule)
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"credentialSubject":{"id":"***","name":"***"}`)
+	require.NotContains(t, lines[0], "Alice Smith")
+	require.NotContains(t, lines[0], "did:example:holder")
+}
+
+func TestCommand_Actions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, svc := newProvider(ctrl)
+
+	cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		expected := ActionsResponse{Actions: []protocol.Action{{PIID: "ID1"}, {PIID: "ID2"}}}
+
+		svc.EXPECT().Actions().Return(expected.Actions, nil)
+
+		var b bytes.Buffer
+		require.NoError(t, cmd.Actions(&b, nil))
+
+		response := ActionsResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(&response))
+		require.Equal(t, expected, response)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		svc.EXPECT().Actions().Return(nil, errors.New("some error message"))
+
+		cmdErr := cmd.Actions(nil, nil)
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "some error message")
+		require.Equal(t, ActionsErrorCode, cmdErr.Code())
+		require.Equal(t, command.ExecuteError, cmdErr.Type())
+	})
+}
+
+func TestCommand_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, svc := newProvider(ctrl)
+
+	cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		fn      func(io.Writer, io.Reader) command.Error
+		code    command.Code
+		errMsgs []string
+	}{{
+		name:    sendRequestPresentation,
+		fn:      cmd.SendRequestPresentation,
+		code:    SendRequestPresentationErrorCode,
+		errMsgs: []string{errEmptyMyDID, errEmptyTheirDID, errEmptyRequestPresentation},
+	}, {
+		name:    sendProposePresentation,
+		fn:      cmd.SendProposePresentation,
+		code:    SendProposePresentationErrorCode,
+		errMsgs: []string{errEmptyMyDID, errEmptyTheirDID, errEmptyProposePresentation},
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			cmdErr := tc.fn(&bytes.Buffer{}, bytes.NewBufferString("}"))
+			require.Error(t, cmdErr)
+			require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+			require.Equal(t, command.ValidationError, cmdErr.Type())
+
+			payloads := []string{`{}`, `{"my_did":"id1"}`, `{"my_did":"id1","their_did":"id2"}`}
+			for i, payload := range payloads {
+				cmdErr = tc.fn(&bytes.Buffer{}, bytes.NewBufferString(payload))
+				require.EqualError(t, cmdErr, tc.errMsgs[i])
+				require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+			}
+
+			svc.EXPECT().HandleInbound(gomock.Any(), "id1", "id2").Return("", errors.New("some error message"))
+
+			cmdErr = tc.fn(&bytes.Buffer{}, bytes.NewBufferString(jsonPayload))
+			require.EqualError(t, cmdErr, "some error message")
+			require.Equal(t, tc.code, cmdErr.Code())
+			require.Equal(t, command.ExecuteError, cmdErr.Type())
+
+			svc.EXPECT().HandleInbound(gomock.Any(), "id1", "id2").Return("piid", nil)
+
+			var b bytes.Buffer
+			require.NoError(t, tc.fn(&b, bytes.NewBufferString(jsonPayload)))
+			require.Equal(t, "{}\n", b.String())
+		})
+	}
+}
+
+func TestCommand_AcceptAndDecline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, svc := newProvider(ctrl)
+
+	cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		fn      func(io.Writer, io.Reader) command.Error
+		code    command.Code
+		payload string
+		// errMsg is the validation error of the payload without the message
+		errMsg string
+		stop   bool
+	}{{
+		name:    acceptRequestPresentation,
+		fn:      cmd.AcceptRequestPresentation,
+		code:    AcceptRequestPresentationErrorCode,
+		payload: `{"piid":"id","presentation":{}}`,
+		errMsg:  errEmptyPresentation,
+	}, {
+		name:    acceptProposePresentation,
+		fn:      cmd.AcceptProposePresentation,
+		code:    AcceptProposePresentationErrorCode,
+		payload: `{"piid":"id","request_presentation":{}}`,
+		errMsg:  errEmptyRequestPresentation,
+	}, {
+		name:    acceptPresentation,
+		fn:      cmd.AcceptPresentation,
+		code:    AcceptPresentationErrorCode,
+		payload: jsonPIID,
+	}, {
+		name:    declineRequestPresentation,
+		fn:      cmd.DeclineRequestPresentation,
+		code:    DeclineRequestPresentationErrorCode,
+		payload: jsonPIID,
+		stop:    true,
+	}, {
+		name:    declineProposePresentation,
+		fn:      cmd.DeclineProposePresentation,
+		code:    DeclineProposePresentationErrorCode,
+		payload: jsonPIID,
+		stop:    true,
+	}, {
+		name:    declinePresentation,
+		fn:      cmd.DeclinePresentation,
+		code:    DeclinePresentationErrorCode,
+		payload: jsonPIID,
+		stop:    true,
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			cmdErr := tc.fn(&bytes.Buffer{}, bytes.NewBufferString("}"))
+			require.Error(t, cmdErr)
+			require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+			require.Equal(t, command.ValidationError, cmdErr.Type())
+
+			cmdErr = tc.fn(&bytes.Buffer{}, bytes.NewBufferString("{}"))
+			require.EqualError(t, cmdErr, errEmptyPIID)
+			require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+			if tc.errMsg != "" {
+				cmdErr = tc.fn(&bytes.Buffer{}, bytes.NewBufferString(jsonPIID))
+				require.EqualError(t, cmdErr, tc.errMsg)
+				require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+			}
+
+			expect := func(err error) {
+				if tc.stop {
+					svc.EXPECT().ActionStop("id", gomock.Any()).Return(err)
+					return
+				}
+
+				svc.EXPECT().ActionContinue("id", gomock.Any()).Return(err)
+			}
+
+			expect(errors.New("some error message"))
+
+			cmdErr = tc.fn(&bytes.Buffer{}, bytes.NewBufferString(tc.payload))
+			require.EqualError(t, cmdErr, "some error message")
+			require.Equal(t, tc.code, cmdErr.Code())
+			require.Equal(t, command.ExecuteError, cmdErr.Type())
+
+			expect(nil)
+
+			var b bytes.Buffer
+			require.NoError(t, tc.fn(&b, bytes.NewBufferString(tc.payload)))
+			require.Equal(t, "{}\n", b.String())
+		})
+	}
+}
+
+func TestCommand_PauseAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, svc := newProvider(ctrl)
+
+	cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+	require.NoError(t, err)
+
+	t.Run("Decode error", func(t *testing.T) {
+		cmdErr := cmd.PauseAction(&bytes.Buffer{}, bytes.NewBufferString("}"))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+
+	t.Run("Empty PIID", func(t *testing.T) {
+		cmdErr := cmd.PauseAction(&bytes.Buffer{}, bytes.NewBufferString("{}"))
+		require.EqualError(t, cmdErr, errEmptyPIID)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Pause error", func(t *testing.T) {
+		svc.EXPECT().ActionPause("id").Return("", errors.New("some error message"))
+
+		cmdErr := cmd.PauseAction(&bytes.Buffer{}, bytes.NewBufferString(jsonPIID))
+		require.EqualError(t, cmdErr, "some error message")
+		require.Equal(t, PauseActionErrorCode, cmdErr.Code())
+		require.Equal(t, command.ExecuteError, cmdErr.Type())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		svc.EXPECT().ActionPause("id").Return("token", nil)
+
+		var b bytes.Buffer
+		require.NoError(t, cmd.PauseAction(&b, bytes.NewBufferString(jsonPIID)))
+
+		response := PauseActionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(&response))
+		require.Equal(t, "token", response.ResumeToken)
+	})
+}
+
+func TestCommand_ResumeAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider, svc := newProvider(ctrl)
+
+	cmd, err := New(provider, webhook.NewMockWebhookNotifier())
+	require.NoError(t, err)
+
+	t.Run("Decode error", func(t *testing.T) {
+		cmdErr := cmd.ResumeAction(&bytes.Buffer{}, bytes.NewBufferString("}"))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+
+	t.Run("Empty ResumeToken", func(t *testing.T) {
+		cmdErr := cmd.ResumeAction(&bytes.Buffer{}, bytes.NewBufferString("{}"))
+		require.EqualError(t, cmdErr, errEmptyResumeToken)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Resume error", func(t *testing.T) {
+		svc.EXPECT().ActionResume("token").Return(nil, protocol.ErrUnknownResumeToken)
+
+		cmdErr := cmd.ResumeAction(&bytes.Buffer{}, bytes.NewBufferString(`{"resume_token":"token"}`))
+		require.EqualError(t, cmdErr, protocol.ErrUnknownResumeToken.Error())
+		require.Equal(t, ResumeActionErrorCode, cmdErr.Code())
+		require.Equal(t, command.ExecuteError, cmdErr.Type())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		svc.EXPECT().ActionResume("token").Return(&protocol.Action{PIID: "id"}, nil)
+
+		var b bytes.Buffer
+		require.NoError(t, cmd.ResumeAction(&b, bytes.NewBufferString(`{"resume_token":"token"}`)))
+
+		response := ResumeActionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(&response))
+		require.Equal(t, "id", response.Action.PIID)
+	})
+}
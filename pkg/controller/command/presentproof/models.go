@@ -0,0 +1,218 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/client/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	protocol "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+)
+
+// ActionsResponse model
+//
+// Represents a Actions response message
+//
+type ActionsResponse struct {
+	Actions []protocol.Action `json:"actions"`
+}
+
+// SendRequestPresentationArgs model
+//
+// This is used for sending a request presentation
+//
+type SendRequestPresentationArgs struct {
+	// MyDID sender's did
+	MyDID string `json:"my_did"`
+	// TheirDID receiver's did
+	TheirDID string `json:"their_did"`
+	// RequestPresentation describes values that need to be revealed and predicates that need to be fulfilled.
+	RequestPresentation *presentproof.RequestPresentation `json:"request_presentation"`
+}
+
+// SendRequestPresentationResponse model
+//
+// Represents a SendRequestPresentation response message
+//
+type SendRequestPresentationResponse struct{}
+
+// SendProposePresentationArgs model
+//
+// This is used for sending a propose presentation
+//
+type SendProposePresentationArgs struct {
+	// MyDID sender's did
+	MyDID string `json:"my_did"`
+	// TheirDID receiver's did
+	TheirDID string `json:"their_did"`
+	// ProposePresentation is a message sent by the Prover to the verifier to initiate a proof presentation process.
+	ProposePresentation *presentproof.ProposePresentation `json:"propose_presentation"`
+}
+
+// SendProposePresentationResponse model
+//
+// Represents a SendProposePresentation response message
+//
+type SendProposePresentationResponse struct{}
+
+// AcceptRequestPresentationArgs model
+//
+// This is used for accepting a request presentation
+//
+type AcceptRequestPresentationArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// Presentation is a message that contains signed presentations.
+	Presentation *presentproof.Presentation `json:"presentation"`
+}
+
+// AcceptRequestPresentationResponse model
+//
+// Represents a AcceptRequestPresentation response message
+//
+type AcceptRequestPresentationResponse struct{}
+
+// DeclineRequestPresentationArgs model
+//
+// This is used when the request presentation needs to be rejected
+//
+type DeclineRequestPresentationArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// Reason why request presentation is declined
+	Reason string `json:"reason"`
+}
+
+// DeclineRequestPresentationResponse model
+//
+// Represents a DeclineRequestPresentation response message
+//
+type DeclineRequestPresentationResponse struct{}
+
+// AcceptProposePresentationArgs model
+//
+// This is used for accepting a propose presentation
+//
+type AcceptProposePresentationArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// RequestPresentation describes values that need to be revealed and predicates that need to be fulfilled.
+	RequestPresentation *presentproof.RequestPresentation `json:"request_presentation"`
+}
+
+// AcceptProposePresentationResponse model
+//
+// Represents a AcceptProposePresentation response message
+//
+type AcceptProposePresentationResponse struct{}
+
+// DeclineProposePresentationArgs model
+//
+// This is used when the propose presentation needs to be rejected
+//
+type DeclineProposePresentationArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// Reason why propose presentation is declined
+	Reason string `json:"reason"`
+}
+
+// DeclineProposePresentationResponse model
+//
+// Represents a DeclineProposePresentation response message
+//
+type DeclineProposePresentationResponse struct{}
+
+// AcceptPresentationArgs model
+//
+// This is used for accepting a presentation
+//
+type AcceptPresentationArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+}
+
+// AcceptPresentationResponse model
+//
+// Represents a AcceptPresentation response message
+//
+type AcceptPresentationResponse struct{}
+
+// DeclinePresentationArgs model
+//
+// This is used when the presentation needs to be rejected
+//
+type DeclinePresentationArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// Reason why presentation is declined
+	Reason string `json:"reason"`
+}
+
+// DeclinePresentationResponse model
+//
+// Represents a DeclinePresentation response message
+//
+type DeclinePresentationResponse struct{}
+
+// PauseActionArgs model
+//
+// This is used for pausing an action
+//
+type PauseActionArgs struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+}
+
+// PauseActionResponse model
+//
+// Represents a PauseAction response message
+//
+type PauseActionResponse struct {
+	// ResumeToken is the token the action is resumed with
+	ResumeToken string `json:"resume_token"`
+}
+
+// ResumeActionArgs model
+//
+// This is used for resuming a paused action
+//
+type ResumeActionArgs struct {
+	// ResumeToken is the token returned when the action was paused
+	ResumeToken string `json:"resume_token"`
+}
+
+// ResumeActionResponse model
+//
+// Represents a ResumeAction response message
+//
+type ResumeActionResponse struct {
+	Action *protocol.Action `json:"action"`
+}
+
+// ActionMsg model
+//
+// Represents the notification of an action event, the action is continued or stopped by its PIID
+//
+type ActionMsg struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// Message is the received message the action is about
+	Message service.DIDCommMsgMap `json:"message"`
+}
+
+// StateMsg model
+//
+// Represents the notification of a state change of a present proof exchange
+//
+type StateMsg struct {
+	// PIID protocol state machine identifier
+	PIID string `json:"piid"`
+	// StateID is the state the exchange transitioned to
+	StateID string `json:"state_id"`
+	// Message is the message which triggered the transition
+	Message service.DIDCommMsgMap `json:"message"`
+}
@@ -50,6 +50,7 @@ const (
 	saveDIDCommandMethod         = "SaveDID"
 	getDIDsCommandMethod         = "GetDIDRecords"
 	getDIDCommandMethod          = "GetDID"
+	getSupportedMethodsCommand   = "GetSupportedMethods"
 
 	// error messages
 	errDIDMethodMandatory = "invalid method name"
@@ -93,6 +94,7 @@ func (o *Command) GetHandlers() []command.Handler {
 		cmdutil.NewCommandHandler(commandName, saveDIDCommandMethod, o.SaveDID),
 		cmdutil.NewCommandHandler(commandName, getDIDCommandMethod, o.GetDID),
 		cmdutil.NewCommandHandler(commandName, getDIDsCommandMethod, o.GetDIDRecords),
+		cmdutil.NewCommandHandler(commandName, getSupportedMethodsCommand, o.GetSupportedMethods),
 	}
 }
 
@@ -220,6 +222,18 @@ func (o *Command) GetDIDRecords(rw io.Writer, req io.Reader) command.Error {
 	return nil
 }
 
+// GetSupportedMethods retrieves the DID methods supported by the agent VDRIs with their capabilities
+// (create/update/deactivate support, supported key types).
+func (o *Command) GetSupportedMethods(rw io.Writer, req io.Reader) command.Error {
+	command.WriteNillableResponse(rw, &SupportedMethodsResult{
+		Result: o.ctx.VDRIRegistry().SupportedMethods(),
+	}, logger)
+
+	logutil.LogDebug(logger, commandName, getSupportedMethodsCommand, "success")
+
+	return nil
+}
+
 // prepareBasicRequestBuilder is basic request builder for public DID creation
 // request body format is : {"header": {raw header}, "payload": "payload"}
 func getBasicRequestBuilder(header string) func(payload []byte) (io.Reader, error) {
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol"
 	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
 	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
@@ -160,7 +161,7 @@ func TestNew(t *testing.T) {
 		require.NoError(t, err)
 
 		handlers := cmd.GetHandlers()
-		require.Equal(t, 4, len(handlers))
+		require.Equal(t, 5, len(handlers))
 	})
 
 	t.Run("test new command - did store error", func(t *testing.T) {
@@ -383,3 +384,24 @@ func TestGetDIDRecords(t *testing.T) {
 		require.Equal(t, 1, len(response.Result))
 	})
 }
+
+func TestGetSupportedMethods(t *testing.T) {
+	methods := []vdriapi.MethodCapabilities{
+		{Method: "peer", Create: true, KeyTypes: []string{"Ed25519VerificationKey2018"}},
+		{Method: "sidetree", Create: true, Update: true, Deactivate: true},
+	}
+
+	cmd, err := New(&mockprovider.Provider{
+		StorageProviderValue: mockstore.NewMockStoreProvider(),
+		VDRIRegistryValue:    &mockvdri.MockVDRIRegistry{Methods: methods},
+	})
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	cmdErr := cmd.GetSupportedMethods(&b, nil)
+	require.NoError(t, cmdErr)
+
+	var response SupportedMethodsResult
+	require.NoError(t, json.NewDecoder(&b).Decode(&response))
+	require.Equal(t, methods, response.Result)
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	storeDID "github.com/hyperledger/aries-framework-go/pkg/store/did"
 )
 
@@ -62,3 +63,9 @@ type NameArg struct {
 	// Name
 	Name string `json:"name"`
 }
+
+// SupportedMethodsResult holds the capabilities of the supported DID methods.
+type SupportedMethodsResult struct {
+	// Result
+	Result []vdriapi.MethodCapabilities `json:"result,omitempty"`
+}
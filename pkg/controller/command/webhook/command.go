@@ -0,0 +1,177 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/cmdutil"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/logutil"
+)
+
+var logger = log.New("aries-framework/command/webhook")
+
+// Error codes
+const (
+	// InvalidRequestErrorCode is typically a code for invalid requests
+	InvalidRequestErrorCode = command.Code(iota + command.Webhook)
+
+	// RegisterErrorCode for register subscription error
+	RegisterErrorCode
+
+	// UnregisterErrorCode for unregister subscription error
+	UnregisterErrorCode
+
+	// DeliveriesErrorCode for get deliveries error
+	DeliveriesErrorCode
+)
+
+const (
+	// command name
+	commandName = "webhook"
+
+	// command methods
+	registerCommandMethod      = "Register"
+	unregisterCommandMethod    = "Unregister"
+	subscriptionsCommandMethod = "Subscriptions"
+	deliveriesCommandMethod    = "Deliveries"
+
+	// error messages
+	errEmptyURL = "url is mandatory"
+	errEmptyID  = "id is mandatory"
+
+	// log constants
+	subscriptionID = "subscriptionID"
+	successString  = "success"
+)
+
+// Command contains the webhook subscription operations provided by controller.
+type Command struct {
+	registry *webnotifier.SubscriptionRegistry
+}
+
+// New returns new webhook controller command instance managing the subscriptions of the registry.
+func New(registry *webnotifier.SubscriptionRegistry) *Command {
+	return &Command{registry: registry}
+}
+
+// GetHandlers returns list of all commands supported by this controller command
+func (o *Command) GetHandlers() []command.Handler {
+	return []command.Handler{
+		cmdutil.NewCommandHandler(commandName, registerCommandMethod, o.Register),
+		cmdutil.NewCommandHandler(commandName, unregisterCommandMethod, o.Unregister),
+		cmdutil.NewCommandHandler(commandName, subscriptionsCommandMethod, o.Subscriptions),
+		cmdutil.NewCommandHandler(commandName, deliveriesCommandMethod, o.Deliveries),
+	}
+}
+
+// Register registers a webhook subscription, persisted across restarts.
+func (o *Command) Register(rw io.Writer, req io.Reader) command.Error {
+	var request RegisterArgs
+
+	err := json.NewDecoder(req).Decode(&request)
+	if err != nil {
+		logutil.LogInfo(logger, commandName, registerCommandMethod, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf("request decode : %w", err))
+	}
+
+	if request.URL == "" {
+		logutil.LogDebug(logger, commandName, registerCommandMethod, errEmptyURL)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyURL))
+	}
+
+	sub, err := o.registry.Register(&webnotifier.Subscription{
+		Topic:  request.Topic,
+		URL:    request.URL,
+		Secret: request.Secret,
+		Filter: request.Filter,
+	})
+	if err != nil {
+		logutil.LogError(logger, commandName, registerCommandMethod, err.Error())
+		return command.NewValidationError(RegisterErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &SubscriptionResult{Subscription: *sub}, logger)
+
+	logutil.LogDebug(logger, commandName, registerCommandMethod, successString,
+		logutil.CreateKeyValueString(subscriptionID, sub.ID))
+
+	return nil
+}
+
+// Unregister removes a webhook subscription and its delivery logs.
+func (o *Command) Unregister(rw io.Writer, req io.Reader) command.Error {
+	var request IDArg
+
+	if cmdErr := decodeID(req, &request, unregisterCommandMethod); cmdErr != nil {
+		return cmdErr
+	}
+
+	if err := o.registry.Unregister(request.ID); err != nil {
+		logutil.LogError(logger, commandName, unregisterCommandMethod, err.Error(),
+			logutil.CreateKeyValueString(subscriptionID, request.ID))
+		return command.NewExecuteError(UnregisterErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, nil, logger)
+
+	logutil.LogDebug(logger, commandName, unregisterCommandMethod, successString,
+		logutil.CreateKeyValueString(subscriptionID, request.ID))
+
+	return nil
+}
+
+// Subscriptions returns the registered webhook subscriptions, without their secret.
+func (o *Command) Subscriptions(rw io.Writer, req io.Reader) command.Error {
+	command.WriteNillableResponse(rw, &SubscriptionsResult{Subscriptions: o.registry.Subscriptions()}, logger)
+
+	logutil.LogDebug(logger, commandName, subscriptionsCommandMethod, successString)
+
+	return nil
+}
+
+// Deliveries returns the delivery logs of a webhook subscription.
+func (o *Command) Deliveries(rw io.Writer, req io.Reader) command.Error {
+	var request IDArg
+
+	if cmdErr := decodeID(req, &request, deliveriesCommandMethod); cmdErr != nil {
+		return cmdErr
+	}
+
+	deliveries, err := o.registry.Deliveries(request.ID)
+	if err != nil {
+		logutil.LogError(logger, commandName, deliveriesCommandMethod, err.Error(),
+			logutil.CreateKeyValueString(subscriptionID, request.ID))
+		return command.NewExecuteError(DeliveriesErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &DeliveriesResult{Deliveries: deliveries}, logger)
+
+	logutil.LogDebug(logger, commandName, deliveriesCommandMethod, successString,
+		logutil.CreateKeyValueString(subscriptionID, request.ID))
+
+	return nil
+}
+
+func decodeID(req io.Reader, request *IDArg, method string) command.Error {
+	if err := json.NewDecoder(req).Decode(request); err != nil {
+		logutil.LogInfo(logger, commandName, method, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf("request decode : %w", err))
+	}
+
+	if request.ID == "" {
+		logutil.LogDebug(logger, commandName, method, errEmptyID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errEmptyID))
+	}
+
+	return nil
+}
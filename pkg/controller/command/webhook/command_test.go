@@ -0,0 +1,166 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const webhookURL = "https://hooks.example.com/notify"
+
+// newLock returns a local secret lock with a random master key, the secrets are rejected by the noop lock.
+func newLock(t *testing.T) secretlock.Service {
+	t.Helper()
+
+	masterKey := make([]byte, 32)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+
+	lock, err := local.NewService(bytes.NewReader([]byte(base64.URLEncoding.EncodeToString(masterKey))), nil)
+	require.NoError(t, err)
+
+	return lock
+}
+
+func newCommand(t *testing.T) *Command {
+	t.Helper()
+
+	registry, err := webnotifier.NewSubscriptionRegistry(mem.NewProvider(), newLock(t), webnotifier.DefaultSecretKeyURI)
+	require.NoError(t, err)
+
+	return New(registry)
+}
+
+func TestNew(t *testing.T) {
+	cmd := newCommand(t)
+	require.NotNil(t, cmd)
+	require.Equal(t, 4, len(cmd.GetHandlers()))
+}
+
+func TestCommand_Register(t *testing.T) {
+	t.Run("test register - success", func(t *testing.T) {
+		cmd := newCommand(t)
+
+		var b bytes.Buffer
+		cmdErr := cmd.Register(&b, bytes.NewBufferString(
+			`{"topic":"didexchange_states","url":"`+webhookURL+`","secret":"secret","filter":"StateID == completed"}`))
+		require.NoError(t, cmdErr)
+
+		result := &SubscriptionResult{}
+		require.NoError(t, json.Unmarshal(b.Bytes(), result))
+		require.NotEmpty(t, result.ID)
+		require.Equal(t, "didexchange_states", result.Topic)
+		require.Equal(t, webhookURL, result.URL)
+		require.Equal(t, "StateID == completed", result.Filter)
+		require.Empty(t, result.Secret)
+
+		b.Reset()
+		require.NoError(t, cmd.Subscriptions(&b, nil))
+
+		subscriptions := &SubscriptionsResult{}
+		require.NoError(t, json.Unmarshal(b.Bytes(), subscriptions))
+		require.Equal(t, []webnotifier.Subscription{result.Subscription}, subscriptions.Subscriptions)
+	})
+
+	t.Run("test register - invalid request", func(t *testing.T) {
+		cmd := newCommand(t)
+
+		var b bytes.Buffer
+		cmdErr := cmd.Register(&b, bytes.NewBufferString("--"))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+
+		cmdErr = cmd.Register(&b, bytes.NewBufferString(`{"topic":"didexchange_states"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.EqualError(t, cmdErr, errEmptyURL)
+	})
+
+	t.Run("test register - registry error", func(t *testing.T) {
+		cmd := newCommand(t)
+
+		var b bytes.Buffer
+		cmdErr := cmd.Register(&b, bytes.NewBufferString(`{"url":"`+webhookURL+`","filter":"StateID"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, RegisterErrorCode, cmdErr.Code())
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+		require.Contains(t, cmdErr.Error(), "invalid filter condition")
+	})
+}
+
+func TestCommand_Unregister(t *testing.T) {
+	cmd := newCommand(t)
+
+	var b bytes.Buffer
+	require.NoError(t, cmd.Register(&b, bytes.NewBufferString(`{"url":"`+webhookURL+`"}`)))
+
+	result := &SubscriptionResult{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), result))
+
+	cmdErr := cmd.Unregister(&b, bytes.NewBufferString("--"))
+	require.Error(t, cmdErr)
+	require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+	cmdErr = cmd.Unregister(&b, bytes.NewBufferString(`{}`))
+	require.Error(t, cmdErr)
+	require.EqualError(t, cmdErr, errEmptyID)
+
+	b.Reset()
+	require.NoError(t, cmd.Unregister(&b, bytes.NewBufferString(`{"id":"`+result.ID+`"}`)))
+
+	cmdErr = cmd.Unregister(&b, bytes.NewBufferString(`{"id":"`+result.ID+`"}`))
+	require.Error(t, cmdErr)
+	require.Equal(t, UnregisterErrorCode, cmdErr.Code())
+	require.Equal(t, command.ExecuteError, cmdErr.Type())
+	require.Contains(t, cmdErr.Error(), webnotifier.ErrSubscriptionNotFound.Error())
+
+	b.Reset()
+	require.NoError(t, cmd.Subscriptions(&b, nil))
+
+	subscriptions := &SubscriptionsResult{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), subscriptions))
+	require.Empty(t, subscriptions.Subscriptions)
+}
+
+func TestCommand_Deliveries(t *testing.T) {
+	cmd := newCommand(t)
+
+	var b bytes.Buffer
+	require.NoError(t, cmd.Register(&b, bytes.NewBufferString(`{"url":"`+webhookURL+`"}`)))
+
+	result := &SubscriptionResult{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), result))
+
+	cmdErr := cmd.Deliveries(&b, bytes.NewBufferString(`{}`))
+	require.Error(t, cmdErr)
+	require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+	cmdErr = cmd.Deliveries(&b, bytes.NewBufferString(`{"id":"unknown"}`))
+	require.Error(t, cmdErr)
+	require.Equal(t, DeliveriesErrorCode, cmdErr.Code())
+	require.Equal(t, command.ExecuteError, cmdErr.Type())
+
+	b.Reset()
+	require.NoError(t, cmd.Deliveries(&b, bytes.NewBufferString(`{"id":"`+result.ID+`"}`)))
+
+	deliveries := &DeliveriesResult{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), deliveries))
+	require.Empty(t, deliveries.Deliveries)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import "github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+
+// RegisterArgs model
+//
+// This is used for registering a webhook subscription.
+//
+type RegisterArgs struct {
+	// Topic notified to the webhook, all the topics if empty
+	Topic string `json:"topic,omitempty"`
+
+	// URL of the webhook
+	URL string `json:"url"`
+
+	// Secret signing the notifications with HMAC-SHA256 (X-Aries-Signature header)
+	Secret string `json:"secret,omitempty"`
+
+	// Filter expression the notified messages must match, e.g "StateID == completed && Type != pre_state"
+	Filter string `json:"filter,omitempty"`
+}
+
+// IDArg model
+//
+// This is used for querying/removing a webhook subscription by ID.
+//
+type IDArg struct {
+	// ID of the subscription
+	ID string `json:"id"`
+}
+
+// SubscriptionResult model
+//
+// This is used for returning a registered webhook subscription.
+//
+type SubscriptionResult struct {
+	webnotifier.Subscription
+}
+
+// SubscriptionsResult model
+//
+// This is used for returning the registered webhook subscriptions.
+//
+type SubscriptionsResult struct {
+	// Subscriptions
+	Subscriptions []webnotifier.Subscription `json:"subscriptions"`
+}
+
+// DeliveriesResult model
+//
+// This is used for returning the delivery logs of a webhook subscription.
+//
+type DeliveriesResult struct {
+	// Deliveries, the latest last
+	Deliveries []webnotifier.Delivery `json:"deliveries"`
+}
@@ -10,20 +10,29 @@ import (
 	"fmt"
 
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	diagnosticscmd "github.com/hyperledger/aries-framework-go/pkg/controller/command/diagnostics"
 	didexchangecmd "github.com/hyperledger/aries-framework-go/pkg/controller/command/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/issuecredential"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command/kms"
 	messagingcmd "github.com/hyperledger/aries-framework-go/pkg/controller/command/messaging"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/presentproof"
 	routercmd "github.com/hyperledger/aries-framework-go/pkg/controller/command/route"
 	vdricmd "github.com/hyperledger/aries-framework-go/pkg/controller/command/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command/verifiable"
+	webhookcmd "github.com/hyperledger/aries-framework-go/pkg/controller/command/webhook"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+	diagnosticsrest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/diagnostics"
 	didexchangerest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/didexchange"
+	issuecredentialrest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/issuecredential"
 	kmsrest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/kms"
 	messagingrest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/messaging"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/rest/route"
 	vdrirest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/vdri"
 	verifiablerest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/verifiable"
+	webhookrest "github.com/hyperledger/aries-framework-go/pkg/controller/rest/webhook"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+	issuecredentialsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	presentproofsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/context"
 )
 
@@ -33,6 +42,12 @@ type allOpts struct {
 	autoAccept   bool
 	msgHandler   command.MessageHandler
 	notifier     command.Notifier
+	// subscriptions is the registry of the webhooks registered at runtime
+	subscriptions *webnotifier.SubscriptionRegistry
+	// webhookSecretKeyURI is the URI of the key encrypting the secrets of the webhooks registered at runtime
+	webhookSecretKeyURI string
+	// webhookAllowedHosts are the only hosts of the webhooks registered at runtime, if any
+	webhookAllowedHosts []string
 }
 
 const wsPath = "/ws"
@@ -68,6 +83,32 @@ func WithAutoAccept(autoAccept bool) Opt {
 	}
 }
 
+// WithSubscriptionRegistry is an option allowing the REST and the command handlers of an agent to share
+// the registry of the webhooks registered at runtime, otherwise each call creates its own registry.
+func WithSubscriptionRegistry(registry *webnotifier.SubscriptionRegistry) Opt {
+	return func(opts *allOpts) {
+		opts.subscriptions = registry
+	}
+}
+
+// WithWebhookSecretKeyURI is an option for setting the URI of the key encrypting the secrets of the webhooks
+// registered at runtime with the secret lock of the framework (webnotifier.DefaultSecretKeyURI by default).
+// The remote secret locks require a key URI with their own prefix, e.g "aws-kms://<key ID>".
+func WithWebhookSecretKeyURI(keyURI string) Opt {
+	return func(opts *allOpts) {
+		opts.webhookSecretKeyURI = keyURI
+	}
+}
+
+// WithWebhookAllowedHosts is an option restricting the webhooks registered at runtime to the given hosts, they
+// may be private addresses (e.g "localhost" for development). By default, any host may be registered except
+// the private, loopback and link-local addresses.
+func WithWebhookAllowedHosts(hosts ...string) Opt {
+	return func(opts *allOpts) {
+		opts.webhookAllowedHosts = hosts
+	}
+}
+
 // WithMessageHandler is an option allowing for the message handler to be set.
 func WithMessageHandler(handler command.MessageHandler) Opt {
 	return func(opts *allOpts) {
@@ -83,9 +124,13 @@ func GetRESTHandlers(ctx *context.Provider, opts ...Opt) ([]rest.Handler, error)
 		opt(restAPIOpts)
 	}
 
+	// the webhooks may be registered at runtime with the default notifier
+	var webNotifier *webnotifier.WebNotifier
+
 	notifier := restAPIOpts.notifier
 	if notifier == nil {
-		notifier = webnotifier.New(wsPath, restAPIOpts.webhookURLs)
+		webNotifier = webnotifier.New(wsPath, restAPIOpts.webhookURLs)
+		notifier = webNotifier
 	}
 
 	// DID Exchange REST operation
@@ -122,6 +167,12 @@ func GetRESTHandlers(ctx *context.Provider, opts ...Opt) ([]rest.Handler, error)
 	// kms command operation
 	kmscmd := kmsrest.New(ctx)
 
+	// diagnostics REST operation
+	diagnosticsOp, err := diagnosticsrest.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create diagnostics rest command : %w", err)
+	}
+
 	// creat handlers from all operations
 	var allHandlers []rest.Handler
 	allHandlers = append(allHandlers, exchangeOp.GetRESTHandlers()...)
@@ -130,6 +181,27 @@ func GetRESTHandlers(ctx *context.Provider, opts ...Opt) ([]rest.Handler, error)
 	allHandlers = append(allHandlers, routeOp.GetRESTHandlers()...)
 	allHandlers = append(allHandlers, verifiablecmd.GetRESTHandlers()...)
 	allHandlers = append(allHandlers, kmscmd.GetRESTHandlers()...)
+	allHandlers = append(allHandlers, diagnosticsOp.GetRESTHandlers()...)
+
+	// issue credential REST operation, if the protocol is supported by the framework
+	if _, err = ctx.Service(issuecredentialsvc.Name); err == nil {
+		issuecredentialOp, e := issuecredentialrest.New(ctx, notifier)
+		if e != nil {
+			return nil, e
+		}
+
+		allHandlers = append(allHandlers, issuecredentialOp.GetRESTHandlers()...)
+	}
+
+	if webNotifier != nil {
+		subscriptions, e := subscriptionRegistry(ctx, restAPIOpts)
+		if e != nil {
+			return nil, e
+		}
+
+		webNotifier.AddNotifier(subscriptions)
+		allHandlers = append(allHandlers, webhookrest.New(subscriptions).GetRESTHandlers()...)
+	}
 
 	nhp, ok := notifier.(handlerProvider)
 	if ok {
@@ -151,9 +223,13 @@ func GetCommandHandlers(ctx *context.Provider, opts ...Opt) ([]command.Handler,
 		opt(cmdOpts)
 	}
 
+	// the webhooks may be registered at runtime with the default notifier
+	var webNotifier *webnotifier.WebNotifier
+
 	notifier := cmdOpts.notifier
 	if notifier == nil {
-		notifier = webnotifier.New(wsPath, cmdOpts.webhookURLs)
+		webNotifier = webnotifier.New(wsPath, cmdOpts.webhookURLs)
+		notifier = webNotifier
 	}
 
 	// did exchange command operation
@@ -190,6 +266,12 @@ func GetCommandHandlers(ctx *context.Provider, opts ...Opt) ([]command.Handler,
 	// kms command operation
 	kmscmd := kms.New(ctx)
 
+	// diagnostics command operation
+	diagnosticsCmd, err := diagnosticscmd.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create diagnostics command : %w", err)
+	}
+
 	var allHandlers []command.Handler
 	allHandlers = append(allHandlers, didexcmd.GetHandlers()...)
 	allHandlers = append(allHandlers, vcmd.GetHandlers()...)
@@ -197,6 +279,71 @@ func GetCommandHandlers(ctx *context.Provider, opts ...Opt) ([]command.Handler,
 	allHandlers = append(allHandlers, routecmd.GetHandlers()...)
 	allHandlers = append(allHandlers, verifiablecmd.GetHandlers()...)
 	allHandlers = append(allHandlers, kmscmd.GetHandlers()...)
+	allHandlers = append(allHandlers, diagnosticsCmd.GetHandlers()...)
+
+	protocolHandlers, err := protocolCommandHandlers(ctx, notifier)
+	if err != nil {
+		return nil, err
+	}
+
+	allHandlers = append(allHandlers, protocolHandlers...)
+
+	if webNotifier != nil {
+		subscriptions, e := subscriptionRegistry(ctx, cmdOpts)
+		if e != nil {
+			return nil, e
+		}
+
+		webNotifier.AddNotifier(subscriptions)
+		allHandlers = append(allHandlers, webhookcmd.New(subscriptions).GetHandlers()...)
+	}
 
 	return allHandlers, nil
 }
+
+// protocolCommandHandlers returns the command handlers of the protocols supported by the framework
+// (present proof and issue credential), the protocol services depend on the build profile.
+func protocolCommandHandlers(ctx *context.Provider, notifier command.Notifier) ([]command.Handler, error) {
+	var handlers []command.Handler
+
+	if _, err := ctx.Service(presentproofsvc.Name); err == nil {
+		presentproofcmd, e := presentproof.New(ctx, notifier)
+		if e != nil {
+			return nil, fmt.Errorf("create present proof command : %w", e)
+		}
+
+		handlers = append(handlers, presentproofcmd.GetHandlers()...)
+	}
+
+	if _, err := ctx.Service(issuecredentialsvc.Name); err == nil {
+		issuecredentialcmd, e := issuecredential.New(ctx, issuecredential.WithNotifier(notifier))
+		if e != nil {
+			return nil, fmt.Errorf("create issue credential command : %w", e)
+		}
+
+		handlers = append(handlers, issuecredentialcmd.GetHandlers()...)
+	}
+
+	return handlers, nil
+}
+
+// subscriptionRegistry returns the webhook subscription registry of the options, or a new registry persisting
+// the subscriptions in the storage provider of the context.
+func subscriptionRegistry(ctx *context.Provider, opts *allOpts) (*webnotifier.SubscriptionRegistry, error) {
+	if opts.subscriptions != nil {
+		return opts.subscriptions, nil
+	}
+
+	keyURI := opts.webhookSecretKeyURI
+	if keyURI == "" {
+		keyURI = webnotifier.DefaultSecretKeyURI
+	}
+
+	registry, err := webnotifier.NewSubscriptionRegistry(ctx.StorageProvider(), ctx.SecretLock(), keyURI,
+		webnotifier.WithIDGenerator(ctx.IDGenerator()), webnotifier.WithAllowedHosts(opts.webhookAllowedHosts...))
+	if err != nil {
+		return nil, fmt.Errorf("create webhook subscription registry : %w", err)
+	}
+
+	return registry, nil
+}
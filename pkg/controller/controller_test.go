@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package controller
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -14,11 +15,19 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/mocks/webhook"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	routesvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/defaults"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/context"
 	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/msghandler"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 )
 
 func TestGetRESTHandlers(t *testing.T) {
@@ -97,6 +106,50 @@ func TestGetRESTHandlers_Success(t *testing.T) {
 	require.NotEmpty(t, handlers)
 }
 
+func TestSubscriptionRegistry(t *testing.T) {
+	ctx, err := context.New(context.WithStorageProvider(mem.NewProvider()), context.WithSecretLock(&noop.NoLock{}))
+	require.NoError(t, err)
+
+	registry, err := subscriptionRegistry(ctx, &allOpts{})
+	require.NoError(t, err)
+
+	// the REST and the command handlers share the registry given with the options
+	shared, err := subscriptionRegistry(ctx, &allOpts{subscriptions: registry})
+	require.NoError(t, err)
+	require.True(t, registry == shared)
+
+	other, err := subscriptionRegistry(ctx, &allOpts{})
+	require.NoError(t, err)
+	require.False(t, registry == other)
+
+	controllerOpts := &allOpts{}
+	WithSubscriptionRegistry(registry)(controllerOpts)
+	require.True(t, registry == controllerOpts.subscriptions)
+
+	controllerOpts = &allOpts{}
+	WithWebhookSecretKeyURI("aws-kms://webhook-key")(controllerOpts)
+	require.Equal(t, "aws-kms://webhook-key", controllerOpts.webhookSecretKeyURI)
+
+	_, err = subscriptionRegistry(ctx, controllerOpts)
+	require.NoError(t, err)
+
+	controllerOpts = &allOpts{}
+	WithWebhookAllowedHosts("localhost")(controllerOpts)
+	require.Equal(t, []string{"localhost"}, controllerOpts.webhookAllowedHosts)
+
+	restricted, err := subscriptionRegistry(ctx, controllerOpts)
+	require.NoError(t, err)
+
+	_, err = restricted.Register(&webnotifier.Subscription{URL: "http://localhost:8080"})
+	require.NoError(t, err)
+
+	_, err = restricted.Register(&webnotifier.Subscription{URL: "https://hooks.example.com"})
+	require.True(t, errors.Is(err, webnotifier.ErrHostNotAllowed))
+
+	_, err = registry.Register(&webnotifier.Subscription{URL: "http://localhost:8080"})
+	require.True(t, errors.Is(err, webnotifier.ErrHostNotAllowed))
+}
+
 func TestWithWebhookNotifierOption(t *testing.T) {
 	controllerOpts := &allOpts{}
 
@@ -152,3 +205,63 @@ func generateTempDir(t testing.TB) (string, func()) {
 		}
 	}
 }
+
+func TestGetHandlers_WithoutProtocols(t *testing.T) {
+	path, cleanup := generateTempDir(t)
+	defer cleanup()
+
+	framework, err := aries.New(defaults.WithStorePath(path), defaults.WithInboundHTTPAddr(":26508", ""))
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, framework.Close())
+	}()
+
+	fullCtx, err := framework.Context()
+	require.NoError(t, err)
+
+	names := map[string]bool{}
+
+	cmdHandlers, err := GetCommandHandlers(fullCtx)
+	require.NoError(t, err)
+
+	for _, handler := range cmdHandlers {
+		names[handler.Name()] = true
+	}
+
+	require.True(t, names["presentproof"])
+	require.True(t, names["issuecredential"])
+
+	// the framework context of a build profile without the present proof and issue credential protocols
+	var services []dispatcher.ProtocolService
+
+	for _, name := range []string{didexchange.DIDExchange, routesvc.Coordination} {
+		svc, e := fullCtx.Service(name)
+		require.NoError(t, e)
+
+		services = append(services, svc.(dispatcher.ProtocolService))
+	}
+
+	ctx, err := context.New(context.WithProtocolServices(services...),
+		context.WithLegacyKMS(fullCtx.LegacyKMS().(legacykms.KMS)), context.WithKMS(fullCtx.KMS()),
+		context.WithCrypto(fullCtx.Crypto()), context.WithStorageProvider(fullCtx.StorageProvider()),
+		context.WithTransientStorageProvider(fullCtx.TransientStorageProvider()),
+		context.WithVDRIRegistry(fullCtx.VDRIRegistry()),
+		context.WithMessengerHandler(fullCtx.Messenger().(service.MessengerHandler)))
+	require.NoError(t, err)
+
+	cmdHandlers, err = GetCommandHandlers(ctx)
+	require.NoError(t, err)
+
+	for _, handler := range cmdHandlers {
+		require.NotEqual(t, "presentproof", handler.Name())
+		require.NotEqual(t, "issuecredential", handler.Name())
+	}
+
+	restHandlers, err := GetRESTHandlers(ctx)
+	require.NoError(t, err)
+
+	for _, handler := range restHandlers {
+		require.NotContains(t, handler.Path(), "/issuecredential")
+	}
+}
@@ -0,0 +1,21 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diagnostics
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/client/diagnostics"
+)
+
+// snapshotRes model
+//
+// response of the diagnostics snapshot
+//
+// swagger:response snapshotResponse
+type snapshotRes struct { // nolint: unused,deadcode
+	// in: body
+	diagnostics.Snapshot
+}
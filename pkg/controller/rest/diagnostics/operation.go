@@ -0,0 +1,73 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diagnostics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/diagnostics"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/cmdutil"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	diagnosticsOperationID = "/diagnostics"
+	snapshotPath           = diagnosticsOperationID + "/snapshot"
+)
+
+// provider contains dependencies for the diagnostics and is typically created by using aries.Context().
+type provider interface {
+	Service(id string) (interface{}, error)
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+}
+
+// Operation contains basic common operations provided by controller REST API
+type Operation struct {
+	handlers []rest.Handler
+	command  *diagnostics.Command
+}
+
+// New returns new diagnostics rest client instance
+func New(ctx provider) (*Operation, error) {
+	diagnosticsCmd, err := diagnostics.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create diagnostics command : %w", err)
+	}
+
+	o := &Operation{command: diagnosticsCmd}
+
+	o.registerHandler()
+
+	return o, nil
+}
+
+// GetRESTHandlers get all controller API handler available for this service
+func (o *Operation) GetRESTHandlers() []rest.Handler {
+	return o.handlers
+}
+
+// registerHandler register handlers to be exposed from this service as REST API endpoints.
+func (o *Operation) registerHandler() {
+	o.handlers = []rest.Handler{
+		cmdutil.NewHTTPHandler(snapshotPath, http.MethodGet, o.Snapshot),
+	}
+}
+
+// Snapshot swagger:route GET /diagnostics/snapshot diagnostics diagnosticsSnapshot
+//
+// Retrieves the current state of the agent for the observability dashboards: the connections by state,
+// the protocol instances in progress, the queue depths, the mediation state and the store sizes.
+//
+// Responses:
+//    default: genericError
+//    200: snapshotResponse
+func (o *Operation) Snapshot(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.Snapshot, rw, req.Body)
+}
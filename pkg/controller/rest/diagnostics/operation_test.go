@@ -0,0 +1,80 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/client/diagnostics"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("test new command", func(t *testing.T) {
+		op, err := New(&mockprovider.Provider{
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		require.Equal(t, 1, len(op.GetRESTHandlers()))
+	})
+
+	t.Run("test new command - command creation fail", func(t *testing.T) {
+		op, err := New(&mockprovider.Provider{
+			StorageProviderValue: &mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create diagnostics command")
+		require.Nil(t, op)
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	op, err := New(&mockprovider.Provider{
+		StorageProviderValue:          mem.NewProvider(),
+		TransientStorageProviderValue: mem.NewProvider(),
+	})
+	require.NoError(t, err)
+
+	handler := op.GetRESTHandlers()[0]
+	require.Equal(t, snapshotPath, handler.Path())
+	require.Equal(t, http.MethodGet, handler.Method())
+
+	code, body := serve(t, handler)
+	require.Equal(t, http.StatusOK, code)
+
+	snapshot := &diagnostics.Snapshot{}
+	require.NoError(t, json.Unmarshal(body, snapshot))
+	require.False(t, snapshot.Time.IsZero())
+	require.Equal(t, map[string]int{"credentials": 0, "dids": 0}, snapshot.Stores)
+}
+
+func serve(t *testing.T, handler rest.Handler) (int, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(handler.Method(), handler.Path(), nil)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr.Code, rr.Body.Bytes()
+}
@@ -0,0 +1,157 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import "github.com/hyperledger/aries-framework-go/pkg/controller/command/issuecredential"
+
+// issueCredentialActionsRes model
+//
+// Represents the pending actions
+//
+// swagger:response issueCredentialActionsRes
+type issueCredentialActionsRes struct { // nolint: unused,deadcode
+	// in: body
+	issuecredential.ActionsResponse
+}
+
+// emptyRes model
+//
+// swagger:response emptyRes
+type emptyRes struct { // nolint: unused,deadcode
+}
+
+// pauseActionRes model
+//
+// Represents the token a paused action is resumed with
+//
+// swagger:response pauseActionRes
+type pauseActionRes struct { // nolint: unused,deadcode
+	// in: body
+	issuecredential.PauseActionResponse
+}
+
+// resumeActionRes model
+//
+// Represents the resumed action
+//
+// swagger:response resumeActionRes
+type resumeActionRes struct { // nolint: unused,deadcode
+	// in: body
+	issuecredential.ResumeActionResponse
+}
+
+// sendOfferReq model
+//
+// swagger:parameters sendOfferReq
+type sendOfferReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.SendOfferArgs
+}
+
+// sendProposalReq model
+//
+// swagger:parameters sendProposalReq
+type sendProposalReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.SendProposalArgs
+}
+
+// sendRequestReq model
+//
+// swagger:parameters sendRequestReq
+type sendRequestReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.SendRequestArgs
+}
+
+// acceptProposalReq model
+//
+// swagger:parameters acceptProposalReq
+type acceptProposalReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.AcceptProposalArgs
+}
+
+// declineProposalReq model
+//
+// swagger:parameters declineProposalReq
+type declineProposalReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.DeclineProposalArgs
+}
+
+// acceptOfferReq model
+//
+// swagger:parameters acceptOfferReq
+type acceptOfferReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.AcceptOfferArgs
+}
+
+// declineOfferReq model
+//
+// swagger:parameters declineOfferReq
+type declineOfferReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.DeclineOfferArgs
+}
+
+// negotiateProposalReq model
+//
+// swagger:parameters negotiateProposalReq
+type negotiateProposalReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.NegotiateProposalArgs
+}
+
+// acceptRequestReq model
+//
+// swagger:parameters acceptRequestReq
+type acceptRequestReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.AcceptRequestArgs
+}
+
+// declineRequestReq model
+//
+// swagger:parameters declineRequestReq
+type declineRequestReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.DeclineRequestArgs
+}
+
+// acceptCredentialReq model
+//
+// swagger:parameters acceptCredentialReq
+type acceptCredentialReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.AcceptCredentialArgs
+}
+
+// declineCredentialReq model
+//
+// swagger:parameters declineCredentialReq
+type declineCredentialReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.DeclineCredentialArgs
+}
+
+// pauseActionReq model
+//
+// swagger:parameters pauseActionReq
+type pauseActionReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.PauseActionArgs
+}
+
+// resumeActionReq model
+//
+// swagger:parameters resumeActionReq
+type resumeActionReq struct { // nolint: unused,deadcode
+	// in: body
+	Params issuecredential.ResumeActionArgs
+}
@@ -0,0 +1,248 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import (
+	"fmt"
+	"net/http"
+
+	client "github.com/hyperledger/aries-framework-go/pkg/client/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/cmdutil"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+)
+
+const (
+	issueCredentialOperationID = "/issuecredential"
+	actionsPath                = issueCredentialOperationID + "/actions"
+	sendOfferPath              = issueCredentialOperationID + "/send-offer"
+	sendProposalPath           = issueCredentialOperationID + "/send-proposal"
+	sendRequestPath            = issueCredentialOperationID + "/send-request"
+	acceptProposalPath         = issueCredentialOperationID + "/accept-proposal"
+	declineProposalPath        = issueCredentialOperationID + "/decline-proposal"
+	acceptOfferPath            = issueCredentialOperationID + "/accept-offer"
+	declineOfferPath           = issueCredentialOperationID + "/decline-offer"
+	negotiateProposalPath      = issueCredentialOperationID + "/negotiate-proposal"
+	acceptRequestPath          = issueCredentialOperationID + "/accept-request"
+	declineRequestPath         = issueCredentialOperationID + "/decline-request"
+	acceptCredentialPath       = issueCredentialOperationID + "/accept-credential"
+	declineCredentialPath      = issueCredentialOperationID + "/decline-credential"
+	pauseActionPath            = issueCredentialOperationID + "/pause-action"
+	resumeActionPath           = issueCredentialOperationID + "/resume-action"
+)
+
+// Operation contains the issue credential operations provided by controller REST API. The action events are
+// sent to the webhooks with a preview of the offered and issued credentials (issue-credential_actions topic).
+type Operation struct {
+	handlers []rest.Handler
+	command  *issuecredential.Command
+}
+
+// New returns new issue credential operations rest client instance
+func New(ctx client.Provider, notifier command.Notifier) (*Operation, error) {
+	cmd, err := issuecredential.New(ctx, issuecredential.WithNotifier(notifier))
+	if err != nil {
+		return nil, fmt.Errorf("create issue credential command : %w", err)
+	}
+
+	o := &Operation{command: cmd}
+	o.registerHandler()
+
+	return o, nil
+}
+
+// GetRESTHandlers get all controller API handler available for this service
+func (o *Operation) GetRESTHandlers() []rest.Handler {
+	return o.handlers
+}
+
+// registerHandler register handlers to be exposed from this protocol service as REST API endpoints
+func (o *Operation) registerHandler() {
+	o.handlers = []rest.Handler{
+		cmdutil.NewHTTPHandler(actionsPath, http.MethodGet, o.Actions),
+		cmdutil.NewHTTPHandler(sendOfferPath, http.MethodPost, o.SendOffer),
+		cmdutil.NewHTTPHandler(sendProposalPath, http.MethodPost, o.SendProposal),
+		cmdutil.NewHTTPHandler(sendRequestPath, http.MethodPost, o.SendRequest),
+		cmdutil.NewHTTPHandler(acceptProposalPath, http.MethodPost, o.AcceptProposal),
+		cmdutil.NewHTTPHandler(declineProposalPath, http.MethodPost, o.DeclineProposal),
+		cmdutil.NewHTTPHandler(acceptOfferPath, http.MethodPost, o.AcceptOffer),
+		cmdutil.NewHTTPHandler(declineOfferPath, http.MethodPost, o.DeclineOffer),
+		cmdutil.NewHTTPHandler(negotiateProposalPath, http.MethodPost, o.NegotiateProposal),
+		cmdutil.NewHTTPHandler(acceptRequestPath, http.MethodPost, o.AcceptRequest),
+		cmdutil.NewHTTPHandler(declineRequestPath, http.MethodPost, o.DeclineRequest),
+		cmdutil.NewHTTPHandler(acceptCredentialPath, http.MethodPost, o.AcceptCredential),
+		cmdutil.NewHTTPHandler(declineCredentialPath, http.MethodPost, o.DeclineCredential),
+		cmdutil.NewHTTPHandler(pauseActionPath, http.MethodPost, o.PauseAction),
+		cmdutil.NewHTTPHandler(resumeActionPath, http.MethodPost, o.ResumeAction),
+	}
+}
+
+// Actions swagger:route GET /issuecredential/actions issue-credential issueCredentialActions
+//
+// Returns the pending actions that have yet to be executed or cancelled.
+//
+// Responses:
+//    default: genericError
+//    200: issueCredentialActionsRes
+func (o *Operation) Actions(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.Actions, rw, req.Body)
+}
+
+// SendOffer swagger:route POST /issuecredential/send-offer issue-credential sendOfferReq
+//
+// Sends an offer to the other agent of the connection (Issuer).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) SendOffer(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.SendOffer, rw, req.Body)
+}
+
+// SendProposal swagger:route POST /issuecredential/send-proposal issue-credential sendProposalReq
+//
+// Sends a proposal to the other agent of the connection (Holder).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) SendProposal(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.SendProposal, rw, req.Body)
+}
+
+// SendRequest swagger:route POST /issuecredential/send-request issue-credential sendRequestReq
+//
+// Sends a request to the other agent of the connection (Holder).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) SendRequest(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.SendRequest, rw, req.Body)
+}
+
+// AcceptProposal swagger:route POST /issuecredential/accept-proposal issue-credential acceptProposalReq
+//
+// Accepts a proposal with an offer (Issuer).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) AcceptProposal(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.AcceptProposal, rw, req.Body)
+}
+
+// DeclineProposal swagger:route POST /issuecredential/decline-proposal issue-credential declineProposalReq
+//
+// Declines a proposal (Issuer).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) DeclineProposal(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.DeclineProposal, rw, req.Body)
+}
+
+// AcceptOffer swagger:route POST /issuecredential/accept-offer issue-credential acceptOfferReq
+//
+// Accepts an offer (Holder).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) AcceptOffer(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.AcceptOffer, rw, req.Body)
+}
+
+// DeclineOffer swagger:route POST /issuecredential/decline-offer issue-credential declineOfferReq
+//
+// Declines an offer (Holder).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) DeclineOffer(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.DeclineOffer, rw, req.Body)
+}
+
+// NegotiateProposal swagger:route POST /issuecredential/negotiate-proposal issue-credential negotiateProposalReq
+//
+// Answers an offer with a new proposal (Holder).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) NegotiateProposal(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.NegotiateProposal, rw, req.Body)
+}
+
+// AcceptRequest swagger:route POST /issuecredential/accept-request issue-credential acceptRequestReq
+//
+// Accepts a request with the issued credentials (Issuer).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) AcceptRequest(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.AcceptRequest, rw, req.Body)
+}
+
+// DeclineRequest swagger:route POST /issuecredential/decline-request issue-credential declineRequestReq
+//
+// Declines a request (Issuer).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) DeclineRequest(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.DeclineRequest, rw, req.Body)
+}
+
+// AcceptCredential swagger:route POST /issuecredential/accept-credential issue-credential acceptCredentialReq
+//
+// Accepts and stores the issued credentials (Holder).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) AcceptCredential(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.AcceptCredential, rw, req.Body)
+}
+
+// DeclineCredential swagger:route POST /issuecredential/decline-credential issue-credential declineCredentialReq
+//
+// Declines the issued credentials (Holder).
+//
+// Responses:
+//    default: genericError
+//    200: emptyRes
+func (o *Operation) DeclineCredential(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.DeclineCredential, rw, req.Body)
+}
+
+// PauseAction swagger:route POST /issuecredential/pause-action issue-credential pauseActionReq
+//
+// Pauses a pending action, it is resumed with the returned token.
+//
+// Responses:
+//    default: genericError
+//    200: pauseActionRes
+func (o *Operation) PauseAction(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.PauseAction, rw, req.Body)
+}
+
+// ResumeAction swagger:route POST /issuecredential/resume-action issue-credential resumeActionReq
+//
+// Resumes a paused action.
+//
+// Responses:
+//    default: genericError
+//    200: resumeActionRes
+func (o *Operation) ResumeAction(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.ResumeAction, rw, req.Body)
+}
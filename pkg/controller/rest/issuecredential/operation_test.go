@@ -0,0 +1,174 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/mocks/webhook"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+	protocol "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	mocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/client/issuecredential"
+)
+
+func newOperation(t *testing.T, ctrl *gomock.Controller) (*Operation, *mocks.MockProtocolService) {
+	t.Helper()
+
+	svc := mocks.NewMockProtocolService(ctrl)
+	svc.EXPECT().RegisterActionEvent(gomock.Any()).Return(nil)
+
+	provider := mocks.NewMockProvider(ctrl)
+	provider.EXPECT().Service(gomock.Any()).Return(svc, nil)
+
+	op, err := New(provider, webhook.NewMockWebhookNotifier())
+	require.NoError(t, err)
+
+	return op, svc
+}
+
+func TestNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("Success", func(t *testing.T) {
+		op, _ := newOperation(t, ctrl)
+		require.Equal(t, 15, len(op.GetRESTHandlers()))
+	})
+
+	t.Run("Create command (error)", func(t *testing.T) {
+		provider := mocks.NewMockProvider(ctrl)
+		provider.EXPECT().Service(gomock.Any()).Return(nil, errors.New("service error"))
+
+		_, err := New(provider, webhook.NewMockWebhookNotifier())
+		require.EqualError(t, err, "create issue credential command : cannot create a client: service error")
+	})
+}
+
+func TestOperation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	op, svc := newOperation(t, ctrl)
+
+	svc.EXPECT().HandleOutbound(gomock.Any(), "A", "B").Return(nil).Times(3)
+	svc.EXPECT().ActionContinue("id", gomock.Any()).Return(nil).Times(5)
+	svc.EXPECT().ActionStop("id", gomock.Any()).Return(nil).Times(4)
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{sendOfferPath, `{"my_did":"A","their_did":"B","offer_credential":{}}`},
+		{sendProposalPath, `{"my_did":"A","their_did":"B","propose_credential":{}}`},
+		{sendRequestPath, `{"my_did":"A","their_did":"B","request_credential":{}}`},
+		{acceptProposalPath, `{"piid":"id","offer_credential":{}}`},
+		{declineProposalPath, `{"piid":"id"}`},
+		{acceptOfferPath, `{"piid":"id"}`},
+		{declineOfferPath, `{"piid":"id"}`},
+		{negotiateProposalPath, `{"piid":"id","propose_credential":{}}`},
+		{acceptRequestPath, `{"piid":"id","issue_credential":{}}`},
+		{declineRequestPath, `{"piid":"id"}`},
+		{acceptCredentialPath, `{"piid":"id"}`},
+		{declineCredentialPath, `{"piid":"id"}`},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.path, func(t *testing.T) {
+			body, code := sendRequest(t, lookupHandler(t, op, tc.path, http.MethodPost), tc.body, tc.path)
+			require.Equal(t, http.StatusOK, code, body.String())
+		})
+	}
+
+	t.Run("validation error", func(t *testing.T) {
+		body, code := sendRequest(t, lookupHandler(t, op, acceptOfferPath, http.MethodPost), `{}`, acceptOfferPath)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		errResponse := struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}{}
+		require.NoError(t, json.Unmarshal(body.Bytes(), &errResponse))
+		require.EqualValues(t, issuecredential.InvalidRequestErrorCode, errResponse.Code)
+		require.Equal(t, "empty PIID", errResponse.Message)
+	})
+
+	t.Run("actions", func(t *testing.T) {
+		svc.EXPECT().Actions().Return([]protocol.Action{{PIID: "id"}}, nil)
+
+		body, code := sendRequest(t, lookupHandler(t, op, actionsPath, http.MethodGet), "", actionsPath)
+		require.Equal(t, http.StatusOK, code)
+
+		response := issueCredentialActionsRes{}
+		require.NoError(t, json.Unmarshal(body.Bytes(), &response))
+		require.Len(t, response.Actions, 1)
+		require.Equal(t, "id", response.Actions[0].PIID)
+	})
+
+	t.Run("pause and resume action", func(t *testing.T) {
+		svc.EXPECT().ActionPause("id").Return("token", nil)
+		svc.EXPECT().ActionResume("token").Return(&protocol.Action{PIID: "id"}, nil)
+
+		body, code := sendRequest(t, lookupHandler(t, op, pauseActionPath, http.MethodPost), `{"piid":"id"}`,
+			pauseActionPath)
+		require.Equal(t, http.StatusOK, code)
+
+		paused := pauseActionRes{}
+		require.NoError(t, json.Unmarshal(body.Bytes(), &paused))
+		require.Equal(t, "token", paused.ResumeToken)
+
+		body, code = sendRequest(t, lookupHandler(t, op, resumeActionPath, http.MethodPost),
+			`{"resume_token":"token"}`, resumeActionPath)
+		require.Equal(t, http.StatusOK, code)
+
+		resumed := resumeActionRes{}
+		require.NoError(t, json.Unmarshal(body.Bytes(), &resumed))
+		require.Equal(t, "id", resumed.Action.PIID)
+	})
+}
+
+func lookupHandler(t *testing.T, op *Operation, path, method string) rest.Handler {
+	t.Helper()
+
+	for _, h := range op.GetRESTHandlers() {
+		if h.Path() == path && h.Method() == method {
+			return h
+		}
+	}
+
+	require.Fail(t, "unable to find handler")
+
+	return nil
+}
+
+// sendRequest sends the request to the handler and returns the response and its status.
+func sendRequest(t *testing.T, handler rest.Handler, body, path string) (*bytes.Buffer, int) {
+	t.Helper()
+
+	req, err := http.NewRequest(handler.Method(), path, strings.NewReader(body))
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr.Body, rr.Code
+}
@@ -11,6 +11,7 @@ import (
 
 	vdricommand "github.com/hyperledger/aries-framework-go/pkg/controller/command/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	didstore "github.com/hyperledger/aries-framework-go/pkg/store/did"
 )
 
@@ -81,3 +82,13 @@ type didRecordResult struct {
 	// in: body
 	Result []*didstore.Record `json:"result,omitempty"`
 }
+
+// supportedMethodsResult model
+//
+// This is used to return the supported DID methods and their capabilities.
+//
+// swagger:response supportedMethodsResult
+type supportedMethodsResult struct { // nolint: unused,deadcode
+	// in: body
+	Result []vdriapi.MethodCapabilities `json:"result,omitempty"`
+}
@@ -30,6 +30,7 @@ const (
 	saveDIDPath         = vdriDIDPath
 	getDIDPath          = vdriDIDPath + "/{id}"
 	getDIDRecordsPath   = vdriDIDPath + "/records"
+	getMethodsPath      = vdriOperationID + "/methods"
 )
 
 // provider contains dependencies for the common controller operations
@@ -71,6 +72,7 @@ func (o *Operation) registerHandler() {
 		cmdutil.NewHTTPHandler(saveDIDPath, http.MethodPost, o.SaveDID),
 		cmdutil.NewHTTPHandler(getDIDPath, http.MethodGet, o.GetDID),
 		cmdutil.NewHTTPHandler(getDIDRecordsPath, http.MethodGet, o.GetDIDRecords),
+		cmdutil.NewHTTPHandler(getMethodsPath, http.MethodGet, o.GetSupportedMethods),
 	}
 }
 
@@ -133,6 +135,17 @@ func (o *Operation) GetDIDRecords(rw http.ResponseWriter, req *http.Request) {
 	rest.Execute(o.command.GetDIDRecords, rw, req.Body)
 }
 
+// GetSupportedMethods swagger:route GET /vdri/methods vdri getSupportedMethods
+//
+// Retrieves the supported DID methods and their capabilities
+//
+// Responses:
+//    default: genericError
+//        200: supportedMethodsResult
+func (o *Operation) GetSupportedMethods(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.GetSupportedMethods, rw, req.Body)
+}
+
 // queryValuesAsJSON converts query strings to `map[string]string`
 // and marshals them to JSON bytes
 func queryValuesAsJSON(vals url.Values) ([]byte, error) {
@@ -22,6 +22,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol"
 	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
 	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
@@ -57,7 +58,7 @@ func TestNew(t *testing.T) {
 		})
 		require.NoError(t, err)
 		require.NotNil(t, cmd)
-		require.Equal(t, 4, len(cmd.GetRESTHandlers()))
+		require.Equal(t, 5, len(cmd.GetRESTHandlers()))
 	})
 
 	t.Run("test new command - error", func(t *testing.T) {
@@ -255,6 +256,21 @@ func TestGetDIDRecords(t *testing.T) {
 	})
 }
 
+func TestGetSupportedMethods(t *testing.T) {
+	methods := []vdriapi.MethodCapabilities{{Method: "peer", Create: true}}
+
+	cmd, err := New(&protocol.MockProvider{CustomVDRI: &mockvdri.MockVDRIRegistry{Methods: methods}})
+	require.NoError(t, err)
+
+	handler := lookupHandler(t, cmd, getMethodsPath, http.MethodGet)
+	buf, err := getSuccessResponseFromHandler(handler, nil, getMethodsPath)
+	require.NoError(t, err)
+
+	var response supportedMethodsResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &response))
+	require.Equal(t, methods, response.Result)
+}
+
 func lookupHandler(t *testing.T, op *Operation, path, method string) rest.Handler {
 	handlers := op.GetRESTHandlers()
 	require.NotEmpty(t, handlers)
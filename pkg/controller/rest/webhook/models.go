@@ -0,0 +1,67 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/webhook"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+)
+
+// registerWebhookRequest model
+//
+// This is used for registering a webhook subscription.
+//
+// swagger:parameters registerWebhook
+type registerWebhookRequest struct { // nolint: unused,deadcode
+	// Params for registering the webhook subscription
+	//
+	// in: body
+	Params webhook.RegisterArgs
+}
+
+// webhookSubscriptionResponse model
+//
+// This is used for returning the registered webhook subscription.
+//
+// swagger:response webhookSubscriptionResponse
+type webhookSubscriptionResponse struct { // nolint: unused,deadcode
+	// in: body
+	webnotifier.Subscription
+}
+
+// webhookSubscriptionsResponse model
+//
+// This is used for returning the registered webhook subscriptions.
+//
+// swagger:response webhookSubscriptionsResponse
+type webhookSubscriptionsResponse struct { // nolint: unused,deadcode
+	// in: body
+	Subscriptions []webnotifier.Subscription `json:"subscriptions"`
+}
+
+// webhookIDRequest model
+//
+// This is used for unregistering a webhook subscription or retrieving its deliveries.
+//
+// swagger:parameters unregisterWebhook webhookDeliveries
+type webhookIDRequest struct { // nolint: unused,deadcode
+	// ID of the subscription
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// webhookDeliveriesResponse model
+//
+// This is used for returning the delivery logs of a webhook subscription.
+//
+// swagger:response webhookDeliveriesResponse
+type webhookDeliveriesResponse struct { // nolint: unused,deadcode
+	// in: body
+	Deliveries []webnotifier.Delivery `json:"deliveries"`
+}
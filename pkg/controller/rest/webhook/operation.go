@@ -0,0 +1,103 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/webhook"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/internal/cmdutil"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+)
+
+const (
+	operationID       = "/webhooks"
+	subscriptionsPath = operationID + "/subscriptions"
+	subscriptionPath  = subscriptionsPath + "/{id}"
+	deliveriesPath    = subscriptionPath + "/deliveries"
+)
+
+// Operation contains the webhook subscription operations provided by controller REST API
+type Operation struct {
+	handlers []rest.Handler
+	command  *webhook.Command
+}
+
+// New returns new webhook subscription rest client instance.
+func New(registry *webnotifier.SubscriptionRegistry) *Operation {
+	o := &Operation{command: webhook.New(registry)}
+	o.registerHandler()
+
+	return o
+}
+
+// GetRESTHandlers get all controller API handler available for this service
+func (o *Operation) GetRESTHandlers() []rest.Handler {
+	return o.handlers
+}
+
+// registerHandler register handlers to be exposed from this service as REST API endpoints
+func (o *Operation) registerHandler() {
+	o.handlers = []rest.Handler{
+		cmdutil.NewHTTPHandler(subscriptionsPath, http.MethodPost, o.Register),
+		cmdutil.NewHTTPHandler(subscriptionsPath, http.MethodGet, o.Subscriptions),
+		cmdutil.NewHTTPHandler(subscriptionPath, http.MethodDelete, o.Unregister),
+		cmdutil.NewHTTPHandler(deliveriesPath, http.MethodGet, o.Deliveries),
+	}
+}
+
+// Register swagger:route POST /webhooks/subscriptions webhook registerWebhook
+//
+// Registers a webhook subscription, persisted across restarts.
+//
+// Responses:
+//    default: genericError
+//        200: webhookSubscriptionResponse
+func (o *Operation) Register(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.Register, rw, req.Body)
+}
+
+// Subscriptions swagger:route GET /webhooks/subscriptions webhook webhookSubscriptions
+//
+// Retrieves the registered webhook subscriptions.
+//
+// Responses:
+//    default: genericError
+//        200: webhookSubscriptionsResponse
+func (o *Operation) Subscriptions(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.Subscriptions, rw, req.Body)
+}
+
+// Unregister swagger:route DELETE /webhooks/subscriptions/{id} webhook unregisterWebhook
+//
+// Removes a webhook subscription and its delivery logs.
+//
+// Responses:
+//    default: genericError
+func (o *Operation) Unregister(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.Unregister, rw, idRequest(req))
+}
+
+// Deliveries swagger:route GET /webhooks/subscriptions/{id}/deliveries webhook webhookDeliveries
+//
+// Retrieves the delivery logs of a webhook subscription.
+//
+// Responses:
+//    default: genericError
+//        200: webhookDeliveriesResponse
+func (o *Operation) Deliveries(rw http.ResponseWriter, req *http.Request) {
+	rest.Execute(o.command.Deliveries, rw, idRequest(req))
+}
+
+func idRequest(req *http.Request) *bytes.Buffer {
+	return bytes.NewBufferString(fmt.Sprintf(`{"id":%q}`, mux.Vars(req)["id"]))
+}
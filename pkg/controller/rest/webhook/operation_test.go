@@ -0,0 +1,115 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command/webhook"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/rest"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+// newLock returns a local secret lock with a random master key, the secrets are rejected by the noop lock.
+func newLock(t *testing.T) secretlock.Service {
+	t.Helper()
+
+	masterKey := make([]byte, 32)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+
+	lock, err := local.NewService(bytes.NewReader([]byte(base64.URLEncoding.EncodeToString(masterKey))), nil)
+	require.NoError(t, err)
+
+	return lock
+}
+
+func TestOperation_Subscriptions(t *testing.T) {
+	registry, err := webnotifier.NewSubscriptionRegistry(mem.NewProvider(), newLock(t), webnotifier.DefaultSecretKeyURI)
+	require.NoError(t, err)
+
+	op := New(registry)
+	require.NotNil(t, op)
+
+	handlers := map[string]rest.Handler{}
+	for _, handler := range op.GetRESTHandlers() {
+		handlers[handler.Method()+" "+handler.Path()] = handler
+	}
+
+	require.Len(t, handlers, 4)
+
+	register := handlers[http.MethodPost+" "+subscriptionsPath]
+	list := handlers[http.MethodGet+" "+subscriptionsPath]
+	unregister := handlers[http.MethodDelete+" "+subscriptionPath]
+	deliveries := handlers[http.MethodGet+" "+deliveriesPath]
+
+	code, body := serve(t, register, subscriptionsPath,
+		bytes.NewBufferString(`{"topic":"didexchange_states","url":"https://hooks.example.com/notify","secret":"secret"}`))
+	require.Equal(t, http.StatusOK, code)
+
+	sub := &webnotifier.Subscription{}
+	require.NoError(t, json.Unmarshal(body, sub))
+	require.NotEmpty(t, sub.ID)
+	require.Empty(t, sub.Secret)
+
+	code, body = serve(t, register, subscriptionsPath, bytes.NewBufferString(`{"url":"localhost"}`))
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Contains(t, string(body), "invalid webhook URL")
+
+	code, body = serve(t, list, subscriptionsPath, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	subscriptions := &webhook.SubscriptionsResult{}
+	require.NoError(t, json.Unmarshal(body, subscriptions))
+	require.Equal(t, []webnotifier.Subscription{*sub}, subscriptions.Subscriptions)
+
+	code, body = serve(t, deliveries, strings.Replace(deliveriesPath, "{id}", sub.ID, 1), nil)
+	require.Equal(t, http.StatusOK, code)
+
+	result := &webhook.DeliveriesResult{}
+	require.NoError(t, json.Unmarshal(body, result))
+	require.Empty(t, result.Deliveries)
+
+	code, _ = serve(t, unregister, strings.Replace(subscriptionPath, "{id}", sub.ID, 1), nil)
+	require.Equal(t, http.StatusOK, code)
+
+	code, body = serve(t, unregister, strings.Replace(subscriptionPath, "{id}", sub.ID, 1), nil)
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Contains(t, string(body), webnotifier.ErrSubscriptionNotFound.Error())
+
+	code, _ = serve(t, deliveries, strings.Replace(deliveriesPath, "{id}", sub.ID, 1), nil)
+	require.Equal(t, http.StatusInternalServerError, code)
+}
+
+func serve(t *testing.T, handler rest.Handler, path string, body io.Reader) (int, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(handler.Method(), path, body)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr.Code, rr.Body.Bytes()
+}
@@ -0,0 +1,250 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webnotifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+// notification is a topic message queued for the subscriptions.
+type notification struct {
+	id    string
+	topic string
+	body  []byte
+}
+
+// loggedDelivery is the persisted delivery, the log of a subscription is a ring of maxDeliveries entries indexed
+// by the sequence number of the deliveries: the oldest delivery is overwritten.
+type loggedDelivery struct {
+	Delivery
+	Sequence uint64 `json:"sequence"`
+}
+
+// privateNetworks are the private IPv4 (RFC 1918), shared IPv4 (RFC 6598) and unique local IPv6 (RFC 4193)
+// networks.
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}()
+
+func newEntry(sub *Subscription, f filter) *entry {
+	return &entry{
+		Subscription: *sub,
+		filter:       f,
+		queue:        make(chan *notification, deliveryQueueSize),
+		done:         make(chan struct{}),
+	}
+}
+
+// deliver is the worker delivering the queued notifications of the subscription until it is stopped.
+func (r *SubscriptionRegistry) deliver(e *entry) {
+	for {
+		select {
+		case <-e.done:
+			return
+		case n := <-e.queue:
+			delivery := Delivery{MessageID: n.id, Topic: n.topic, Time: time.Now()}
+
+			var err error
+
+			delivery.StatusCode, err = r.post(e.URL, e.Secret, n.body)
+			if err != nil {
+				delivery.Error = err.Error()
+				logger.Warnf("webhook subscription %s: %v", e.ID, err)
+			}
+
+			select {
+			case <-e.done:
+				// unregistered while delivering, its log is removed
+				return
+			default:
+			}
+
+			if err := r.log(e, &delivery); err != nil {
+				logger.Warnf("log delivery of webhook subscription %s: %v", e.ID, err)
+			}
+		}
+	}
+}
+
+// newHTTPClient returns the client posting the notifications, it doesn't follow the redirects and, unless the
+// hosts are restricted, it refuses to connect to the private, loopback and link-local addresses: the checks of
+// the registered URLs can't be bypassed by the webhooks.
+func (r *SubscriptionRegistry) newHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: notificationSendTimeout, Control: r.checkAddress}
+
+	return &http.Client{
+		Timeout: notificationSendTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: notificationSendTimeout,
+			MaxIdleConnsPerHost: 1,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// post sends the notification to the webhook, signed if the subscription has a secret.
+func (r *SubscriptionRegistry) post(destination, secret string, message []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, destination, bytes.NewBuffer(message))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create new http post request for %s: %w", destination, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		// nolint:errcheck // the hash never returns an error
+		mac.Write(message)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to post notification to %s: %w", destination, err)
+	}
+
+	defer closeResponse(resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated &&
+		resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		return resp.StatusCode, fmt.Errorf("notification was sent to %s, but %s was received", destination, resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// checkHost checks the host of a webhook being registered.
+func (r *SubscriptionRegistry) checkHost(host string) error {
+	host = strings.ToLower(host)
+
+	if len(r.allowedHosts) > 0 {
+		if !r.allowedHosts[host] {
+			return ErrHostNotAllowed
+		}
+
+		return nil
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrHostNotAllowed
+	}
+
+	if ip := net.ParseIP(host); ip != nil && deniedIP(ip) {
+		return ErrHostNotAllowed
+	}
+
+	return nil
+}
+
+// checkAddress checks the resolved address the client connects to, the hostnames of the webhooks may resolve
+// to private addresses. The allowed hosts are trusted.
+func (r *SubscriptionRegistry) checkAddress(_, address string, _ syscall.RawConn) error {
+	if len(r.allowedHosts) > 0 {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || deniedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+	}
+
+	return nil
+}
+
+// deniedIP checks whether the IP is a private, loopback, link-local or unspecified address.
+func deniedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// log saves the delivery in the next slot of the ring of the subscription, overwriting the oldest delivery.
+func (r *SubscriptionRegistry) log(e *entry, delivery *Delivery) error {
+	e.sequence++
+
+	raw, err := json.Marshal(&loggedDelivery{Delivery: *delivery, Sequence: e.sequence})
+	if err != nil {
+		return fmt.Errorf("marshal delivery: %w", err)
+	}
+
+	if err := r.store.Put(deliveryKey(e.ID, e.sequence), raw); err != nil {
+		return fmt.Errorf("save delivery: %w", err)
+	}
+
+	return nil
+}
+
+func deliveryKey(id string, sequence uint64) string {
+	return fmt.Sprintf("%s%s_%d", deliveryKeyPrefix, id, sequence%maxDeliveries)
+}
+
+// deliveries returns the logged deliveries of the subscription sorted by sequence number.
+func (r *SubscriptionRegistry) deliveries(id string) ([]loggedDelivery, error) {
+	prefix := deliveryKeyPrefix + id + "_"
+
+	itr := r.store.Iterator(prefix, prefix+storage.EndKeySuffix)
+	defer itr.Release()
+
+	var deliveries []loggedDelivery
+
+	for itr.Next() {
+		var delivery loggedDelivery
+		if err := json.Unmarshal(itr.Value(), &delivery); err != nil {
+			return nil, fmt.Errorf("unmarshal delivery: %w", err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := itr.Error(); err != nil {
+		return nil, fmt.Errorf("iterate deliveries: %w", err)
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].Sequence < deliveries[j].Sequence })
+
+	return deliveries, nil
+}
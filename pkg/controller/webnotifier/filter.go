@@ -0,0 +1,208 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webnotifier
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// operatorChars are the characters of the operators, they can't appear in unquoted paths or values.
+const operatorChars = `=!&`
+
+type tokenKind int
+
+const (
+	// wordToken is an unquoted path or value.
+	wordToken tokenKind = iota
+	// quotedToken is a value between double or single quotes.
+	quotedToken
+	equalToken
+	notEqualToken
+	andToken
+)
+
+var operators = map[string]tokenKind{
+	"==": equalToken,
+	"!=": notEqualToken,
+	"&&": andToken,
+}
+
+type token struct {
+	kind tokenKind
+	// text is the path or the value of the token, without its quotes.
+	text string
+	// raw is the token as written in the expression.
+	raw string
+}
+
+// filter is a parsed filter expression: the conditions all messages must match.
+type filter []condition
+
+type condition struct {
+	path  []string
+	value string
+	equal bool
+}
+
+// parseFilter parses the conditions of the expression, joined by &&: path == value or path != value.
+// The values containing spaces or operator characters (=, ! and &) must be quoted, e.g `label == "a && b"`,
+// with double or single quotes. Any other expression is rejected as ambiguous.
+func parseFilter(expression string) (filter, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	terms := [][]token{nil}
+
+	for _, t := range tokens {
+		if t.kind == andToken {
+			terms = append(terms, nil)
+			continue
+		}
+
+		terms[len(terms)-1] = append(terms[len(terms)-1], t)
+	}
+
+	f := make(filter, 0, len(terms))
+
+	for _, term := range terms {
+		if len(term) == 0 {
+			return nil, fmt.Errorf("invalid filter %q: empty condition", expression)
+		}
+
+		c, err := parseCondition(term)
+		if err != nil {
+			return nil, err
+		}
+
+		f = append(f, c)
+	}
+
+	return f, nil
+}
+
+func parseCondition(term []token) (condition, error) {
+	raw := make([]string, len(term))
+	for i, t := range term {
+		raw[i] = t.raw
+	}
+
+	text := strings.Join(raw, " ")
+
+	switch term[0].kind {
+	case wordToken:
+	case quotedToken:
+		return condition{}, fmt.Errorf("invalid filter condition %q: quoted path", text)
+	default:
+		return condition{}, fmt.Errorf("invalid filter condition %q: empty path", text)
+	}
+
+	if len(term) != 3 || (term[1].kind != equalToken && term[1].kind != notEqualToken) ||
+		(term[2].kind != wordToken && term[2].kind != quotedToken) {
+		return condition{}, fmt.Errorf("invalid filter condition %q: expecting path == value or path != value", text)
+	}
+
+	return condition{
+		path:  strings.Split(term[0].text, "."),
+		value: term[2].text,
+		equal: term[1].kind == equalToken,
+	}, nil
+}
+
+// tokenize splits the expression into paths, values and operators.
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(expression); {
+		c := rune(expression[i])
+
+		var (
+			op   tokenKind
+			isOp bool
+		)
+
+		if i+2 <= len(expression) {
+			op, isOp = operators[expression[i:i+2]]
+		}
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case isOp:
+			tokens = append(tokens, token{kind: op, raw: expression[i : i+2]})
+			i += 2
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid filter %q: unterminated quoted value", expression)
+			}
+
+			tokens = append(tokens, token{
+				kind: quotedToken,
+				text: expression[i+1 : i+1+end],
+				raw:  expression[i : i+end+2],
+			})
+			i += end + 2
+		case strings.ContainsRune(operatorChars, c):
+			return nil, fmt.Errorf("invalid filter %q: unexpected %q, the values containing %s must be quoted",
+				expression, c, operatorChars)
+		default:
+			end := strings.IndexFunc(expression[i:], func(r rune) bool {
+				return unicode.IsSpace(r) || strings.ContainsRune(operatorChars+`"'`, r)
+			})
+			if end < 0 {
+				end = len(expression) - i
+			}
+
+			tokens = append(tokens, token{kind: wordToken, text: expression[i : i+end], raw: expression[i : i+end]})
+			i += end
+		}
+	}
+
+	return tokens, nil
+}
+
+func (f filter) match(message interface{}) bool {
+	for _, c := range f {
+		value, ok := lookupPath(message, c.path)
+		if (ok && value == c.value) != c.equal {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lookupPath returns the scalar value at the path of the message, formatted as a string.
+func lookupPath(message interface{}, path []string) (string, bool) {
+	value := message
+
+	for _, field := range path {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+
+		if value, ok = obj[field]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
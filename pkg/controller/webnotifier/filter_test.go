@@ -0,0 +1,128 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webnotifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	message := map[string]interface{}{
+		"state": "completed",
+		"count": float64(2),
+		"properties": map[string]interface{}{
+			"connectionID": "conn",
+			"done":         true,
+		},
+	}
+
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{filter: "", match: true},
+		{filter: "state == completed", match: true},
+		{filter: "state == 'requested'", match: false},
+		{filter: "state != requested", match: true},
+		{filter: "count == 2 && properties.done == true", match: true},
+		{filter: `properties.connectionID == "conn" && state != completed`, match: false},
+		{filter: "properties == conn", match: false},
+		{filter: "missing != value", match: true},
+		{filter: "state.nested == value", match: false},
+	}
+
+	for _, test := range tests {
+		f, err := parseFilter(test.filter)
+		require.NoError(t, err)
+		require.Equal(t, test.match, f.match(message), test.filter)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	t.Run("quoted values", func(t *testing.T) {
+		message := map[string]interface{}{
+			"label":  "a && b",
+			"rule":   "x!=y",
+			"query":  "a=b",
+			"quoted": `say "hi"`,
+		}
+
+		tests := []struct {
+			filter string
+			match  bool
+		}{
+			{filter: `label == "a && b"`, match: true},
+			{filter: `label == 'a && b' && rule == "x!=y"`, match: true},
+			{filter: `rule != "x!=y"`, match: false},
+			{filter: `query == "a=b" && label != "a"`, match: true},
+			{filter: `query=="a=b"&&rule=='x!=y'`, match: true},
+			{filter: `quoted == 'say "hi"'`, match: true},
+			{filter: `label == ""`, match: false},
+		}
+
+		for _, test := range tests {
+			f, err := parseFilter(test.filter)
+			require.NoError(t, err, test.filter)
+			require.Equal(t, test.match, f.match(message), test.filter)
+		}
+	})
+
+	t.Run("ambiguous or invalid expressions", func(t *testing.T) {
+		tests := []struct {
+			filter string
+			err    string
+		}{
+			{
+				filter: "label == a && b",
+				err:    `invalid filter condition "b": expecting path == value or path != value`,
+			},
+			{
+				filter: "query == a=b",
+				err:    `invalid filter "query == a=b": unexpected '=', the values containing =!& must be quoted`,
+			},
+			{
+				filter: "rule == x!=y",
+				err:    `invalid filter condition "rule == x != y": expecting path == value or path != value`,
+			},
+			{
+				filter: "state = completed",
+				err:    `invalid filter "state = completed": unexpected '=', the values containing =!& must be quoted`,
+			},
+			{
+				filter: "state == completed &&",
+				err:    `invalid filter "state == completed &&": empty condition`,
+			},
+			{
+				filter: "&& state == completed",
+				err:    `invalid filter "&& state == completed": empty condition`,
+			},
+			{
+				filter: `label == "a && b`,
+				err:    `invalid filter "label == \"a && b": unterminated quoted value`,
+			},
+			{
+				filter: `"label" == value`,
+				err:    `invalid filter condition "\"label\" == value": quoted path`,
+			},
+			{
+				filter: "state == == completed",
+				err:    `invalid filter condition "state == == completed": expecting path == value or path != value`,
+			},
+			{
+				filter: "state completed",
+				err:    `invalid filter condition "state completed": expecting path == value or path != value`,
+			},
+		}
+
+		for _, test := range tests {
+			_, err := parseFilter(test.filter)
+			require.EqualError(t, err, test.err, test.filter)
+		}
+	})
+}
@@ -0,0 +1,457 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webnotifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// SubscriptionsNamespace is the namespace of the store persisting the webhook subscriptions.
+	SubscriptionsNamespace = "webhooksubscriptions"
+
+	// SignatureHeader is the header carrying the HMAC-SHA256 signature of the notifications sent to the
+	// subscriptions having a secret, e.g "sha256=<hex encoded signature of the body>".
+	SignatureHeader = "X-Aries-Signature"
+
+	// maxDeliveries is the number of deliveries logged by subscription, the oldest are overwritten.
+	maxDeliveries = 100
+
+	// deliveryQueueSize is the number of notifications queued by subscription, the notifications are dropped
+	// while the queue of the subscription is full.
+	deliveryQueueSize = 100
+
+	// DefaultSecretKeyURI is the URI of the key encrypting the secrets of the subscriptions with the local secret
+	// lock, the remote secret locks (e.g awskms, gcpkms) require a key URI with their own prefix.
+	DefaultSecretKeyURI = "local-lock://default/webhook/secret/"
+
+	subscriptionKeyPrefix = "subscription_"
+	deliveryKeyPrefix     = "delivery_"
+)
+
+// ErrSubscriptionNotFound is returned for unknown subscriptions.
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// ErrSecretNotSupported is returned when a subscription with a secret is registered without a secret lock
+// protecting it (none or the noop lock of the framework), the secret would be persisted in plaintext.
+var ErrSecretNotSupported = errors.New("webhook secrets require a secret lock (see aries.WithSecretLock)")
+
+// ErrHostNotAllowed is returned when a webhook is registered with a host not allowed (see WithAllowedHosts).
+var ErrHostNotAllowed = errors.New("webhook host not allowed")
+
+// Subscription is a webhook registered at runtime.
+type Subscription struct {
+	// ID is the ID of the subscription, generated when registered.
+	ID string `json:"id"`
+	// Topic is the topic notified to the webhook, e.g "didexchange_states", all the topics if empty.
+	Topic string `json:"topic,omitempty"`
+	// URL is the URL of the webhook.
+	URL string `json:"url"`
+	// Secret signs the notifications (see SignatureHeader), it is never returned once registered and it is
+	// persisted encrypted by the secret lock. The secrets are rejected without a secret lock (ErrSecretNotSupported).
+	Secret string `json:"secret,omitempty"`
+	// Filter is the expression the notified messages must match, e.g `StateID == completed && Type != pre_state`.
+	// The conditions compare the values at dot-separated paths of the messages, all of them must hold.
+	Filter string `json:"filter,omitempty"`
+}
+
+// Delivery is the log of a notification sent to a subscription.
+type Delivery struct {
+	// MessageID is the ID of the notification.
+	MessageID string `json:"messageID"`
+	// Topic is the topic of the notification.
+	Topic string `json:"topic"`
+	// Time is the time of the delivery.
+	Time time.Time `json:"time"`
+	// StatusCode is the HTTP status returned by the webhook, 0 if the request failed.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Error is the reason of a failed delivery.
+	Error string `json:"error,omitempty"`
+}
+
+// SubscriptionRegistry is a dispatcher notifying the webhooks registered at runtime, the subscriptions are
+// persisted so they survive restarts and the deliveries are logged by subscription.
+//
+// The notifications are queued and delivered asynchronously by a worker of each subscription, a slow or
+// unreachable webhook doesn't delay the others. By default the webhooks of private, loopback and link-local
+// addresses are rejected (see WithAllowedHosts).
+type SubscriptionRegistry struct {
+	store        storage.Store
+	lock         secretlock.Service
+	keyURI       string
+	idGenerator  idgen.Generator
+	allowedHosts map[string]bool
+	httpClient   *http.Client
+	mu           sync.RWMutex
+	entries      map[string]*entry
+}
+
+// RegistryOpt configures a SubscriptionRegistry.
+type RegistryOpt func(r *SubscriptionRegistry)
+
+// WithIDGenerator sets the generator of the subscription and notification IDs, the framework generator
+// (see context.Provider IDGenerator). Defaults to random UUIDs.
+func WithIDGenerator(g idgen.Generator) RegistryOpt {
+	return func(r *SubscriptionRegistry) {
+		r.idGenerator = g
+	}
+}
+
+// WithAllowedHosts restricts the webhooks to the given hosts (e.g "hooks.example.com", "localhost"), they may
+// be private addresses. By default, any host is allowed except the private, loopback and link-local addresses.
+func WithAllowedHosts(hosts ...string) RegistryOpt {
+	return func(r *SubscriptionRegistry) {
+		for _, host := range hosts {
+			r.allowedHosts[strings.ToLower(host)] = true
+		}
+	}
+}
+
+type entry struct {
+	Subscription
+	filter filter
+	// queue holds the notifications to deliver to the subscription.
+	queue chan *notification
+	// done stops the delivery worker of the subscription.
+	done chan struct{}
+	once sync.Once
+	// sequence is the sequence number of the last logged delivery, used by the delivery worker only.
+	sequence uint64
+}
+
+func (e *entry) stop() {
+	e.once.Do(func() { close(e.done) })
+}
+
+// storedSubscription is the persisted subscription, its secret is encrypted by the secret lock.
+type storedSubscription struct {
+	Subscription
+	EncryptedSecret string `json:"encryptedSecret,omitempty"`
+}
+
+// NewSubscriptionRegistry returns a new SubscriptionRegistry loading the persisted subscriptions, their secrets
+// are encrypted by the secret lock using the key found at keyURI. The lock may be nil or the noop lock, the
+// subscriptions have no secret then.
+func NewSubscriptionRegistry(p storage.Provider, lock secretlock.Service, keyURI string,
+	opts ...RegistryOpt) (*SubscriptionRegistry, error) {
+	store, err := p.OpenStore(SubscriptionsNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("open webhook subscriptions store: %w", err)
+	}
+
+	r := &SubscriptionRegistry{
+		store:        store,
+		lock:         lock,
+		keyURI:       keyURI,
+		idGenerator:  idgen.UUIDv4(),
+		allowedHosts: make(map[string]bool),
+		entries:      make(map[string]*entry),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.httpClient = r.newHTTPClient()
+
+	itr := store.Iterator(subscriptionKeyPrefix, subscriptionKeyPrefix+storage.EndKeySuffix)
+	defer itr.Release()
+
+	for itr.Next() {
+		sub, err := r.load(itr.Value())
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := parseFilter(sub.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("webhook subscription %s: %w", sub.ID, err)
+		}
+
+		deliveries, err := r.deliveries(sub.ID)
+		if err != nil {
+			return nil, fmt.Errorf("webhook subscription %s: %w", sub.ID, err)
+		}
+
+		e := newEntry(sub, f)
+
+		if len(deliveries) > 0 {
+			e.sequence = deliveries[len(deliveries)-1].Sequence
+		}
+
+		r.entries[sub.ID] = e
+	}
+
+	if err := itr.Error(); err != nil {
+		return nil, fmt.Errorf("load webhook subscriptions: %w", err)
+	}
+
+	for _, e := range r.entries {
+		go r.deliver(e)
+	}
+
+	return r, nil
+}
+
+// load unmarshals the persisted subscription and decrypts its secret.
+func (r *SubscriptionRegistry) load(raw []byte) (*Subscription, error) {
+	var stored storedSubscription
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, fmt.Errorf("unmarshal webhook subscription: %w", err)
+	}
+
+	sub := stored.Subscription
+
+	if stored.EncryptedSecret != "" {
+		if !r.secretsSupported() {
+			return nil, fmt.Errorf("decrypt secret of webhook subscription %s: %w", sub.ID, ErrSecretNotSupported)
+		}
+
+		resp, err := r.lock.Decrypt(r.keyURI, &secretlock.DecryptRequest{
+			Ciphertext:                  stored.EncryptedSecret,
+			AdditionalAuthenticatedData: sub.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("decrypt secret of webhook subscription %s: %w", sub.ID, err)
+		}
+
+		sub.Secret = resp.Plaintext
+	}
+
+	return &sub, nil
+}
+
+// marshal returns the subscription to persist, its secret is encrypted.
+func (r *SubscriptionRegistry) marshal(sub *Subscription) ([]byte, error) {
+	stored := storedSubscription{Subscription: *sub}
+	stored.Secret = ""
+
+	if sub.Secret != "" {
+		if !r.secretsSupported() {
+			return nil, ErrSecretNotSupported
+		}
+
+		resp, err := r.lock.Encrypt(r.keyURI, &secretlock.EncryptRequest{
+			Plaintext:                   sub.Secret,
+			AdditionalAuthenticatedData: sub.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("encrypt secret of webhook subscription: %w", err)
+		}
+
+		stored.EncryptedSecret = resp.Ciphertext
+	}
+
+	raw, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("marshal webhook subscription: %w", err)
+	}
+
+	return raw, nil
+}
+
+// secretsSupported checks whether the secrets are protected by a secret lock, the noop lock would persist them
+// in plaintext.
+func (r *SubscriptionRegistry) secretsSupported() bool {
+	_, isNoop := r.lock.(*noop.NoLock)
+
+	return r.lock != nil && !isNoop
+}
+
+// Register validates and persists the subscription, it returns the registered subscription.
+func (r *SubscriptionRegistry) Register(sub *Subscription) (*Subscription, error) {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", sub.URL)
+	}
+
+	if err := r.checkHost(u.Hostname()); err != nil {
+		return nil, fmt.Errorf("invalid webhook URL %q: %w", sub.URL, err)
+	}
+
+	f, err := parseFilter(sub.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	registered := *sub
+	registered.ID = r.idGenerator.NewID()
+
+	raw, err := r.marshal(&registered)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.store.Put(subscriptionKeyPrefix+registered.ID, raw); err != nil {
+		return nil, fmt.Errorf("save webhook subscription: %w", err)
+	}
+
+	e := newEntry(&registered, f)
+
+	r.mu.Lock()
+	r.entries[registered.ID] = e
+	r.mu.Unlock()
+
+	go r.deliver(e)
+
+	registered.Secret = ""
+
+	return &registered, nil
+}
+
+// Unregister removes the subscription and its delivery logs.
+func (r *SubscriptionRegistry) Unregister(id string) error {
+	r.mu.Lock()
+	e, ok := r.entries[id]
+	delete(r.entries, id)
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+
+	e.stop()
+
+	if err := r.store.Delete(subscriptionKeyPrefix + id); err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+
+	deliveries, err := r.deliveries(id)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deliveries {
+		if err := r.store.Delete(deliveryKey(id, d.Sequence)); err != nil {
+			return fmt.Errorf("delete webhook delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close stops the delivery workers of the subscriptions, the queued notifications are dropped.
+func (r *SubscriptionRegistry) Close() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		e.stop()
+	}
+}
+
+// Subscriptions returns the registered subscriptions sorted by ID, without their secret.
+func (r *SubscriptionRegistry) Subscriptions() []Subscription {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := make([]Subscription, 0, len(r.entries))
+
+	for _, e := range r.entries {
+		sub := e.Subscription
+		sub.Secret = ""
+		subs = append(subs, sub)
+	}
+
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+
+	return subs
+}
+
+// Deliveries returns the logged deliveries of the subscription, the latest last.
+func (r *SubscriptionRegistry) Deliveries(id string) ([]Delivery, error) {
+	r.mu.RLock()
+	_, ok := r.entries[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	logged, err := r.deliveries(id)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, len(logged))
+	for i := range logged {
+		deliveries[i] = logged[i].Delivery
+	}
+
+	return deliveries, nil
+}
+
+// Notify queues the message for the subscriptions of the topic whose filter it matches, the notifications are
+// delivered asynchronously and their deliveries are logged (see Deliveries). Notify doesn't block: the
+// notifications of the subscriptions whose queue is full are dropped and reported in the returned error.
+func (r *SubscriptionRegistry) Notify(topic string, message []byte) error {
+	if topic == "" {
+		return fmt.Errorf(emptyTopicErrMsg)
+	}
+
+	if len(message) == 0 {
+		return fmt.Errorf(emptyMessageErrMsg)
+	}
+
+	r.mu.RLock()
+	entries := make([]*entry, 0, len(r.entries))
+
+	for _, e := range r.entries {
+		if e.Topic == "" || e.Topic == topic {
+			entries = append(entries, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var content interface{}
+	if err := json.Unmarshal(message, &content); err != nil {
+		return fmt.Errorf("unmarshal message: %w", err)
+	}
+
+	msgID := r.idGenerator.NewID()
+
+	topicMsg, err := json.Marshal(&topicMessage{ID: msgID, Topic: topic, Message: message})
+	if err != nil {
+		return fmt.Errorf(failedToCreateErrMsg, err)
+	}
+
+	n := &notification{id: msgID, topic: topic, body: topicMsg}
+
+	var allErrs error
+
+	for _, e := range entries {
+		if !e.filter.match(content) {
+			continue
+		}
+
+		select {
+		case e.queue <- n:
+		default:
+			logger.Warnf("delivery queue of webhook subscription %s is full, notification %s dropped", e.ID, msgID)
+			allErrs = appendError(allErrs, fmt.Errorf("delivery queue of webhook subscription %s is full", e.ID))
+		}
+	}
+
+	return allErrs
+}
@@ -0,0 +1,513 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webnotifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const webhookURL = "https://hooks.example.com/notify"
+
+type received struct {
+	body      []byte
+	signature string
+}
+
+func newWebhook(t *testing.T, status int) (*httptest.Server, chan received) {
+	t.Helper()
+
+	notifications := make(chan received, maxDeliveries+10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		notifications <- received{body: body, signature: r.Header.Get(SignatureHeader)}
+
+		w.WriteHeader(status)
+	}))
+
+	return server, notifications
+}
+
+// newLock returns a local secret lock with a random master key.
+func newLock(t *testing.T) secretlock.Service {
+	t.Helper()
+
+	masterKey := make([]byte, 32)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+
+	lock, err := local.NewService(bytes.NewReader([]byte(base64.URLEncoding.EncodeToString(masterKey))), nil)
+	require.NoError(t, err)
+
+	return lock
+}
+
+type failingLock struct {
+	noop.NoLock
+}
+
+func (l *failingLock) Encrypt(string, *secretlock.EncryptRequest) (*secretlock.EncryptResponse, error) {
+	return nil, errors.New("encrypt error")
+}
+
+// prefixLock is a secret lock supporting only the key URIs with its prefix, as the remote secret locks do.
+type prefixLock struct {
+	secretlock.Service
+	prefix string
+}
+
+func (l *prefixLock) Encrypt(keyURI string, req *secretlock.EncryptRequest) (*secretlock.EncryptResponse, error) {
+	if !strings.HasPrefix(keyURI, l.prefix) {
+		return nil, fmt.Errorf("invalid key URI: %s", keyURI)
+	}
+
+	return l.Service.Encrypt(keyURI, req)
+}
+
+func (l *prefixLock) Decrypt(keyURI string, req *secretlock.DecryptRequest) (*secretlock.DecryptResponse, error) {
+	if !strings.HasPrefix(keyURI, l.prefix) {
+		return nil, fmt.Errorf("invalid key URI: %s", keyURI)
+	}
+
+	return l.Service.Decrypt(keyURI, req)
+}
+
+func TestNewSubscriptionRegistry(t *testing.T) {
+	t.Run("subscriptions persisted across restarts", func(t *testing.T) {
+		provider := mem.NewProvider()
+		lock := newLock(t)
+
+		registry, err := NewSubscriptionRegistry(provider, lock, DefaultSecretKeyURI)
+		require.NoError(t, err)
+
+		sub, err := registry.Register(&Subscription{
+			Topic: topic, URL: webhookURL, Secret: "secret", Filter: "state == completed",
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, sub.ID)
+		require.Empty(t, sub.Secret)
+
+		// the secret is not persisted in plaintext
+		store, err := provider.OpenStore(SubscriptionsNamespace)
+		require.NoError(t, err)
+
+		raw, err := store.Get(subscriptionKeyPrefix + sub.ID)
+		require.NoError(t, err)
+		require.NotContains(t, string(raw), "secret\"")
+
+		restarted, err := NewSubscriptionRegistry(provider, lock, DefaultSecretKeyURI)
+		require.NoError(t, err)
+		require.Equal(t, []Subscription{*sub}, restarted.Subscriptions())
+		require.Equal(t, "secret", restarted.entries[sub.ID].Secret)
+
+		_, err = NewSubscriptionRegistry(provider, newLock(t), DefaultSecretKeyURI)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decrypt secret of webhook subscription "+sub.ID)
+	})
+
+	t.Run("secrets encrypted with the key URI of the remote secret lock", func(t *testing.T) {
+		provider := mem.NewProvider()
+		lock := &prefixLock{Service: newLock(t), prefix: "aws-kms://"}
+
+		registry, err := NewSubscriptionRegistry(provider, lock, "aws-kms://webhook-key")
+		require.NoError(t, err)
+
+		sub, err := registry.Register(&Subscription{URL: webhookURL, Secret: "secret"})
+		require.NoError(t, err)
+
+		restarted, err := NewSubscriptionRegistry(provider, lock, "aws-kms://webhook-key")
+		require.NoError(t, err)
+		require.Equal(t, "secret", restarted.entries[sub.ID].Secret)
+
+		// the default key URI is not supported by the remote secret lock
+		registry, err = NewSubscriptionRegistry(mem.NewProvider(), lock, DefaultSecretKeyURI)
+		require.NoError(t, err)
+
+		_, err = registry.Register(&Subscription{URL: webhookURL, Secret: "secret"})
+		require.EqualError(t, err, "encrypt secret of webhook subscription: invalid key URI: "+DefaultSecretKeyURI)
+	})
+
+	t.Run("encrypt secret error", func(t *testing.T) {
+		registry, err := NewSubscriptionRegistry(mem.NewProvider(), &failingLock{}, DefaultSecretKeyURI)
+		require.NoError(t, err)
+
+		_, err = registry.Register(&Subscription{URL: webhookURL, Secret: "secret"})
+		require.EqualError(t, err, "encrypt secret of webhook subscription: encrypt error")
+	})
+
+	t.Run("secrets not supported without secret lock", func(t *testing.T) {
+		for _, lock := range []secretlock.Service{nil, &noop.NoLock{}} {
+			registry, err := NewSubscriptionRegistry(mem.NewProvider(), lock, DefaultSecretKeyURI)
+			require.NoError(t, err)
+
+			_, err = registry.Register(&Subscription{URL: webhookURL, Secret: "secret"})
+			require.True(t, errors.Is(err, ErrSecretNotSupported))
+
+			// the subscriptions without secret are supported
+			_, err = registry.Register(&Subscription{URL: webhookURL})
+			require.NoError(t, err)
+		}
+
+		// the secrets persisted with a secret lock can't be loaded without it
+		provider := mem.NewProvider()
+
+		registry, err := NewSubscriptionRegistry(provider, newLock(t), DefaultSecretKeyURI)
+		require.NoError(t, err)
+
+		_, err = registry.Register(&Subscription{URL: webhookURL, Secret: "secret"})
+		require.NoError(t, err)
+
+		_, err = NewSubscriptionRegistry(provider, &noop.NoLock{}, DefaultSecretKeyURI)
+		require.True(t, errors.Is(err, ErrSecretNotSupported))
+	})
+
+	t.Run("open store error", func(t *testing.T) {
+		_, err := NewSubscriptionRegistry(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+			&noop.NoLock{}, DefaultSecretKeyURI)
+		require.EqualError(t, err, "open webhook subscriptions store: open error")
+	})
+
+	t.Run("invalid persisted subscription", func(t *testing.T) {
+		provider := mem.NewProvider()
+		store, err := provider.OpenStore(SubscriptionsNamespace)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(subscriptionKeyPrefix+"id", []byte("{")))
+
+		_, err = NewSubscriptionRegistry(provider, &noop.NoLock{}, DefaultSecretKeyURI)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal webhook subscription")
+	})
+
+	t.Run("iterator error", func(t *testing.T) {
+		_, err := NewSubscriptionRegistry(mockstore.NewCustomMockStoreProvider(&mockstore.MockStore{
+			Store:  map[string][]byte{},
+			ErrItr: errors.New("iterator error"),
+		}), &noop.NoLock{}, DefaultSecretKeyURI)
+		require.EqualError(t, err, "load webhook subscriptions: iterator error")
+	})
+}
+
+func TestSubscriptionRegistry_Register(t *testing.T) {
+	registry, err := NewSubscriptionRegistry(mem.NewProvider(), &noop.NoLock{}, DefaultSecretKeyURI)
+	require.NoError(t, err)
+
+	_, err = registry.Register(&Subscription{URL: "localhost:8080"})
+	require.EqualError(t, err, `invalid webhook URL "localhost:8080"`)
+
+	_, err = registry.Register(&Subscription{URL: "ftp://localhost"})
+	require.EqualError(t, err, `invalid webhook URL "ftp://localhost"`)
+
+	_, err = registry.Register(&Subscription{URL: webhookURL, Filter: "state"})
+	require.EqualError(t, err, `invalid filter condition "state": expecting path == value or path != value`)
+
+	_, err = registry.Register(&Subscription{URL: webhookURL, Filter: " == completed"})
+	require.EqualError(t, err, `invalid filter condition "== completed": empty path`)
+
+	for _, u := range []string{
+		"http://localhost:8080", "http://api.localhost", "http://127.0.0.1", "http://[::1]:8080",
+		"http://169.254.169.254/latest/meta-data", "https://10.0.0.1", "http://172.16.5.4", "http://192.168.1.1",
+		"http://[fd00::1]", "http://0.0.0.0", "http://[::ffff:127.0.0.1]",
+	} {
+		_, err = registry.Register(&Subscription{URL: u})
+		require.True(t, errors.Is(err, ErrHostNotAllowed), u)
+	}
+
+	registry.store = &mockstore.MockStore{Store: map[string][]byte{}, ErrPut: errors.New("put error")}
+
+	_, err = registry.Register(&Subscription{URL: webhookURL})
+	require.EqualError(t, err, "save webhook subscription: put error")
+	require.Empty(t, registry.Subscriptions())
+}
+
+func TestSubscriptionRegistry_AllowedHosts(t *testing.T) {
+	registry, err := NewSubscriptionRegistry(mem.NewProvider(), &noop.NoLock{}, DefaultSecretKeyURI,
+		WithAllowedHosts("LocalHost", "hooks.example.com"))
+	require.NoError(t, err)
+
+	_, err = registry.Register(&Subscription{URL: "http://localhost:8080"})
+	require.NoError(t, err)
+
+	_, err = registry.Register(&Subscription{URL: webhookURL})
+	require.NoError(t, err)
+
+	_, err = registry.Register(&Subscription{URL: "https://other.example.com"})
+	require.EqualError(t, err, `invalid webhook URL "https://other.example.com": webhook host not allowed`)
+
+	_, err = registry.Register(&Subscription{URL: "http://127.0.0.1:8080"})
+	require.True(t, errors.Is(err, ErrHostNotAllowed))
+
+	// the allowed hosts are trusted, they may resolve to private addresses
+	require.NoError(t, registry.checkAddress("tcp", "127.0.0.1:8080", nil))
+}
+
+func TestSubscriptionRegistry_CheckAddress(t *testing.T) {
+	registry, err := NewSubscriptionRegistry(mem.NewProvider(), &noop.NoLock{}, DefaultSecretKeyURI)
+	require.NoError(t, err)
+
+	// the hostnames of the registered webhooks may resolve to private addresses
+	for _, address := range []string{"127.0.0.1:80", "[::1]:443", "169.254.169.254:80", "10.1.2.3:8080"} {
+		require.True(t, errors.Is(registry.checkAddress("tcp", address, nil), ErrHostNotAllowed), address)
+	}
+
+	require.NoError(t, registry.checkAddress("tcp", "93.184.216.34:443", nil))
+	require.Error(t, registry.checkAddress("tcp", "93.184.216.34", nil))
+
+	// the webhooks resolving to a private address are not notified
+	server, notifications := newWebhook(t, http.StatusOK)
+	defer server.Close()
+
+	sub, err := registry.Register(&Subscription{URL: webhookURL})
+	require.NoError(t, err)
+
+	// e.g a hostname resolving to a loopback address
+	registry.entries[sub.ID].URL = server.URL
+
+	defer registry.Close()
+
+	require.NoError(t, registry.Notify(topic, getTestBasicMessageJSON()))
+
+	deliveries := waitForDeliveries(t, registry, sub.ID, 1)
+	require.Contains(t, deliveries[0].Error, ErrHostNotAllowed.Error())
+	require.Empty(t, notifications)
+}
+
+func TestSubscriptionRegistry_IDGenerator(t *testing.T) {
+	registry, err := NewSubscriptionRegistry(mem.NewProvider(), &noop.NoLock{}, DefaultSecretKeyURI,
+		WithIDGenerator(idgen.WithPrefix("tenant:", idgen.UUIDv4())))
+	require.NoError(t, err)
+
+	sub, err := registry.Register(&Subscription{URL: webhookURL})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(sub.ID, "tenant:"))
+}
+
+// waitForDeliveries waits for the count deliveries of the subscription to be logged.
+func waitForDeliveries(t *testing.T, registry *SubscriptionRegistry, id string, count int) []Delivery {
+	t.Helper()
+
+	var deliveries []Delivery
+
+	require.Eventually(t, func() bool {
+		var err error
+
+		deliveries, err = registry.Deliveries(id)
+		require.NoError(t, err)
+
+		return len(deliveries) >= count
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return deliveries
+}
+
+func TestSubscriptionRegistry_Notify(t *testing.T) {
+	registry, err := NewSubscriptionRegistry(mem.NewProvider(), newLock(t), DefaultSecretKeyURI,
+		WithAllowedHosts("127.0.0.1"))
+	require.NoError(t, err)
+
+	defer registry.Close()
+
+	server, notifications := newWebhook(t, http.StatusOK)
+	defer server.Close()
+
+	failing, _ := newWebhook(t, http.StatusInternalServerError)
+	defer failing.Close()
+
+	signed, err := registry.Register(&Subscription{
+		Topic: topic, URL: server.URL, Secret: "secret", Filter: `state == "completed" && content != skip`,
+	})
+	require.NoError(t, err)
+
+	other, err := registry.Register(&Subscription{Topic: "other", URL: server.URL})
+	require.NoError(t, err)
+
+	broken, err := registry.Register(&Subscription{URL: failing.URL})
+	require.NoError(t, err)
+
+	require.EqualError(t, registry.Notify("", getTestBasicMessageJSON()), emptyTopicErrMsg)
+	require.EqualError(t, registry.Notify(topic, nil), emptyMessageErrMsg)
+
+	message := []byte(`{"state": "completed", "content": "hello"}`)
+
+	// the failed deliveries are logged, not returned
+	require.NoError(t, registry.Notify(topic, message))
+
+	notification := <-notifications
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, err = mac.Write(notification.body)
+	require.NoError(t, err)
+	require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), notification.signature)
+
+	topicMsg := &topicMessage{}
+	require.NoError(t, json.Unmarshal(notification.body, topicMsg))
+	require.Equal(t, topic, topicMsg.Topic)
+	require.JSONEq(t, string(message), string(topicMsg.Message))
+
+	deliveries := waitForDeliveries(t, registry, signed.ID, 1)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, topicMsg.ID, deliveries[0].MessageID)
+	require.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+	require.Empty(t, deliveries[0].Error)
+
+	deliveries = waitForDeliveries(t, registry, broken.ID, 1)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, http.StatusInternalServerError, deliveries[0].StatusCode)
+	require.Contains(t, deliveries[0].Error, "500 Internal Server Error")
+
+	deliveries, err = registry.Deliveries(other.ID)
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+
+	require.NoError(t, registry.Unregister(broken.ID))
+	require.NoError(t, registry.Unregister(other.ID))
+	require.NoError(t, registry.Notify("other", getTestBasicMessageJSON()))
+
+	// filtered out
+	require.NoError(t, registry.Notify(topic, []byte(`{"state": "requested"}`)))
+	require.NoError(t, registry.Notify(topic, []byte(`{"state": "completed", "content": "skip"}`)))
+	require.Error(t, registry.Notify(topic, []byte("{")))
+
+	deliveries, err = registry.Deliveries(signed.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Empty(t, notifications)
+
+	require.True(t, errors.Is(registry.Unregister(broken.ID), ErrSubscriptionNotFound))
+
+	_, err = registry.Deliveries(broken.ID)
+	require.True(t, errors.Is(err, ErrSubscriptionNotFound))
+
+	itr := registry.store.Iterator(deliveryKeyPrefix+broken.ID, deliveryKeyPrefix+broken.ID+"!!")
+	defer itr.Release()
+
+	require.False(t, itr.Next())
+}
+
+func TestSubscriptionRegistry_NotifyAsync(t *testing.T) {
+	registry, err := NewSubscriptionRegistry(mem.NewProvider(), &noop.NoLock{}, DefaultSecretKeyURI,
+		WithAllowedHosts("127.0.0.1"))
+	require.NoError(t, err)
+
+	defer registry.Close()
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	fast, notifications := newWebhook(t, http.StatusOK)
+	defer fast.Close()
+
+	blocked, err := registry.Register(&Subscription{URL: slow.URL})
+	require.NoError(t, err)
+
+	_, err = registry.Register(&Subscription{URL: fast.URL})
+	require.NoError(t, err)
+
+	// the slow webhook delays neither Notify nor the other webhooks
+	require.NoError(t, registry.Notify(topic, getTestBasicMessageJSON()))
+
+	select {
+	case <-notifications:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the notification was not delivered to the fast webhook")
+	}
+
+	// the worker of the slow webhook is delivering the first notification, the next ones fill its queue
+	<-started
+
+	for i := 0; i < deliveryQueueSize; i++ {
+		require.NoError(t, registry.Notify(topic, getTestBasicMessageJSON()))
+		<-notifications
+	}
+
+	err = registry.Notify(topic, getTestBasicMessageJSON())
+	require.EqualError(t, err, fmt.Sprintf("delivery queue of webhook subscription %s is full", blocked.ID))
+}
+
+func TestSubscriptionRegistry_DeliveriesRetention(t *testing.T) {
+	provider := mem.NewProvider()
+
+	registry, err := NewSubscriptionRegistry(provider, &noop.NoLock{}, DefaultSecretKeyURI,
+		WithAllowedHosts("127.0.0.1"))
+	require.NoError(t, err)
+
+	server, notifications := newWebhook(t, http.StatusNoContent)
+	defer server.Close()
+
+	sub, err := registry.Register(&Subscription{URL: server.URL})
+	require.NoError(t, err)
+
+	for i := 0; i < maxDeliveries+5; i++ {
+		require.NoError(t, registry.Notify(topic, getTestBasicMessageJSON()))
+		<-notifications
+	}
+
+	deliveries := waitForDeliveries(t, registry, sub.ID, maxDeliveries)
+	require.Len(t, deliveries, maxDeliveries)
+
+	for i := 1; i < len(deliveries); i++ {
+		require.False(t, deliveries[i].Time.Before(deliveries[i-1].Time))
+	}
+
+	registry.Close()
+
+	// the sequence of the deliveries continues after a restart
+	restarted, err := NewSubscriptionRegistry(provider, &noop.NoLock{}, DefaultSecretKeyURI,
+		WithAllowedHosts("127.0.0.1"))
+	require.NoError(t, err)
+
+	defer restarted.Close()
+
+	require.Equal(t, uint64(maxDeliveries+5), restarted.entries[sub.ID].sequence)
+
+	require.NoError(t, restarted.Notify(topic, getTestBasicMessageJSON()))
+	<-notifications
+
+	require.Eventually(t, func() bool {
+		logged, err := restarted.deliveries(sub.ID)
+		require.NoError(t, err)
+
+		return logged[len(logged)-1].Sequence == maxDeliveries+6
+	}, 5*time.Second, 10*time.Millisecond)
+
+	logged, err := restarted.deliveries(sub.ID)
+	require.NoError(t, err)
+	require.Len(t, logged, maxDeliveries)
+	require.Equal(t, uint64(7), logged[0].Sequence)
+}
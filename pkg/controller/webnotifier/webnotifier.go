@@ -9,6 +9,7 @@ package webnotifier
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,6 +30,7 @@ var logger = log.New("aries-framework/webnotifier")
 
 // WebNotifier is a dispatcher capable of notifying multiple subscribers via HTTP Webhooks and WebSockets.
 type WebNotifier struct {
+	mu        sync.RWMutex
 	notifiers []command.Notifier
 	handlers  []rest.Handler
 }
@@ -51,7 +53,11 @@ func New(wsPath string, webhookURLs []string) *WebNotifier {
 func (n *WebNotifier) Notify(topic string, message []byte) error {
 	var allErrs error
 
-	for _, notifier := range n.notifiers {
+	n.mu.RLock()
+	notifiers := append(n.notifiers[:0:0], n.notifiers...)
+	n.mu.RUnlock()
+
+	for _, notifier := range notifiers {
 		err := notifier.Notify(topic, message)
 		allErrs = appendError(allErrs, err)
 	}
@@ -59,6 +65,14 @@ func (n *WebNotifier) Notify(topic string, message []byte) error {
 	return allErrs
 }
 
+// AddNotifier adds a notifier notified along with the webhooks and the WebSockets, e.g the
+// SubscriptionRegistry of the webhooks registered at runtime.
+func (n *WebNotifier) AddNotifier(notifier command.Notifier) {
+	n.mu.Lock()
+	n.notifiers = append(n.notifiers, notifier)
+	n.mu.Unlock()
+}
+
 // GetRESTHandlers returns all REST handlers provided by notifier.
 func (n *WebNotifier) GetRESTHandlers() []rest.Handler {
 	return n.handlers
@@ -72,16 +86,17 @@ func appendError(errToAppendTo, err error) error {
 	return fmt.Errorf("%v;%v", errToAppendTo, err)
 }
 
+// topicMessage is the notification sent to the subscribers.
+type topicMessage struct {
+	ID      string          `json:"id"`
+	Topic   string          `json:"topic"`
+	Message json.RawMessage `json:"message"`
+}
+
 func prepareTopicMessage(topic string, message []byte) ([]byte, error) {
-	topicMsg := struct {
-		ID      string          `json:"id"`
-		Topic   string          `json:"topic"`
-		Message json.RawMessage `json:"message"`
-	}{
+	return json.Marshal(&topicMessage{
 		ID:      uuid.New().String(),
 		Topic:   topic,
 		Message: message,
-	}
-
-	return json.Marshal(topicMsg)
+	})
 }
@@ -0,0 +1,151 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package secp256k1 provides the ECDSA secp256k1 signature primitive for Tink: the key managers of the secp256k1
+// keys are registered when the package is imported, the keys are then created, used and stored by Tink
+// like the built-in ECDSA keys (e.g keyset.NewHandle(secp256k1.IEEEP1363KeyWithoutPrefixTemplate())).
+//
+// The keys are serialized with the ECDSA protos of Tink under their own type URLs, the curve of their
+// parameters is not set (Tink does not define secp256k1) and the hash function is always SHA-256.
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/tink/go/core/registry"
+	commonpb "github.com/google/tink/go/proto/common_go_proto"
+	ecdsapb "github.com/google/tink/go/proto/ecdsa_go_proto"
+	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
+)
+
+const (
+	// SignerTypeURL is the type URL of the secp256k1 private keys.
+	SignerTypeURL = "type.hyperledger.org/hyperledger.aries.crypto.tink.Secp256k1PrivateKey"
+	// VerifierTypeURL is the type URL of the secp256k1 public keys.
+	VerifierTypeURL = "type.hyperledger.org/hyperledger.aries.crypto.tink.Secp256k1PublicKey"
+
+	keyVersion = 0
+)
+
+// nolint:gochecknoinits
+func init() {
+	if err := registry.RegisterKeyManager(newSignerKeyManager()); err != nil {
+		panic(fmt.Sprintf("secp256k1.init() failed: %v", err))
+	}
+
+	if err := registry.RegisterKeyManager(newVerifierKeyManager()); err != nil {
+		panic(fmt.Sprintf("secp256k1.init() failed: %v", err))
+	}
+}
+
+// IEEEP1363KeyWithoutPrefixTemplate is a KeyTemplate that generates a new secp256k1 private key with the following
+// parameters:
+//   - Hash function: SHA256
+//   - Signature encoding: IEEE_P1363 (R || S, as used by the JSON Web Signatures and the linked data proofs)
+//   - Output prefix type: RAW
+func IEEEP1363KeyWithoutPrefixTemplate() *tinkpb.KeyTemplate {
+	return createKeyTemplate(ecdsapb.EcdsaSignatureEncoding_IEEE_P1363)
+}
+
+// DERKeyWithoutPrefixTemplate is a KeyTemplate that generates a new secp256k1 private key with the following
+// parameters:
+//   - Hash function: SHA256
+//   - Signature encoding: DER
+//   - Output prefix type: RAW
+func DERKeyWithoutPrefixTemplate() *tinkpb.KeyTemplate {
+	return createKeyTemplate(ecdsapb.EcdsaSignatureEncoding_DER)
+}
+
+func createKeyTemplate(encoding ecdsapb.EcdsaSignatureEncoding) *tinkpb.KeyTemplate {
+	format := &ecdsapb.EcdsaKeyFormat{Params: &ecdsapb.EcdsaParams{
+		HashType: commonpb.HashType_SHA256,
+		Encoding: encoding,
+	}}
+
+	// the format has no invalid value to marshal
+	serializedFormat, _ := proto.Marshal(format) // nolint:errcheck
+
+	return &tinkpb.KeyTemplate{
+		TypeUrl:          SignerTypeURL,
+		Value:            serializedFormat,
+		OutputPrefixType: tinkpb.OutputPrefixType_RAW,
+	}
+}
+
+// validateParams validates the parameters of the secp256k1 keys.
+func validateParams(params *ecdsapb.EcdsaParams) error {
+	if params == nil {
+		return fmt.Errorf("missing params")
+	}
+
+	if params.HashType != commonpb.HashType_SHA256 {
+		return fmt.Errorf("invalid hash type, expect SHA-256")
+	}
+
+	switch params.Encoding {
+	case ecdsapb.EcdsaSignatureEncoding_DER, ecdsapb.EcdsaSignatureEncoding_IEEE_P1363:
+		return nil
+	default:
+		return fmt.Errorf("unsupported encoding: %s", params.Encoding)
+	}
+}
+
+// keySize is the size of the secp256k1 coordinates, as well as of R and S in the IEEE_P1363 signatures
+const keySize = 32
+
+func encodeSignature(r, s *big.Int, encoding ecdsapb.EcdsaSignatureEncoding) ([]byte, error) {
+	if encoding == ecdsapb.EcdsaSignatureEncoding_DER {
+		return marshalDER(r, s)
+	}
+
+	signature := make([]byte, 2*keySize)
+	r.FillBytes(signature[:keySize])
+	s.FillBytes(signature[keySize:])
+
+	return signature, nil
+}
+
+func digest(data []byte) []byte {
+	hashed := sha256.Sum256(data)
+
+	return hashed[:]
+}
+
+type derSignature struct {
+	R, S *big.Int
+}
+
+func marshalDER(r, s *big.Int) ([]byte, error) {
+	signature, err := asn1.Marshal(derSignature{R: r, S: s})
+	if err != nil {
+		return nil, fmt.Errorf("secp256k1_signer: marshal DER signature: %w", err)
+	}
+
+	return signature, nil
+}
+
+func decodeSignature(signature []byte, encoding ecdsapb.EcdsaSignatureEncoding) (*big.Int, *big.Int, error) {
+	if encoding == ecdsapb.EcdsaSignatureEncoding_DER {
+		sig := derSignature{}
+
+		rest, err := asn1.Unmarshal(signature, &sig)
+		if err != nil || len(rest) > 0 || sig.R == nil || sig.S == nil {
+			return nil, nil, errInvalidSignature
+		}
+
+		return sig.R, sig.S, nil
+	}
+
+	if len(signature) != 2*keySize {
+		return nil, nil, errInvalidSignature
+	}
+
+	return new(big.Int).SetBytes(signature[:keySize]), new(big.Int).SetBytes(signature[keySize:]), nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secp256k1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/tink/go/keyset"
+	commonpb "github.com/google/tink/go/proto/common_go_proto"
+	ecdsapb "github.com/google/tink/go/proto/ecdsa_go_proto"
+	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
+	"github.com/google/tink/go/signature"
+	"github.com/stretchr/testify/require"
+
+	sigverifier "github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+)
+
+var msg = []byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit.")
+
+func TestSignVerify(t *testing.T) {
+	for _, template := range []*tinkpb.KeyTemplate{IEEEP1363KeyWithoutPrefixTemplate(), DERKeyWithoutPrefixTemplate()} {
+		kh, err := keyset.NewHandle(template)
+		require.NoError(t, err)
+
+		signer, err := signature.NewSigner(kh)
+		require.NoError(t, err)
+
+		sig, err := signer.Sign(msg)
+		require.NoError(t, err)
+
+		pubKH, err := kh.Public()
+		require.NoError(t, err)
+
+		verifier, err := signature.NewVerifier(pubKH)
+		require.NoError(t, err)
+
+		require.NoError(t, verifier.Verify(sig, msg))
+		require.Error(t, verifier.Verify(sig, []byte("other message")))
+		require.Error(t, verifier.Verify([]byte("signature"), msg))
+	}
+}
+
+func TestSignVerify_LinkedDataProofs(t *testing.T) {
+	kh, err := keyset.NewHandle(IEEEP1363KeyWithoutPrefixTemplate())
+	require.NoError(t, err)
+
+	signer, err := signature.NewSigner(kh)
+	require.NoError(t, err)
+
+	sig, err := signer.Sign(msg)
+	require.NoError(t, err)
+	require.Len(t, sig, 64)
+
+	pubKey := publicKey(t, kh)
+
+	// the signature is verified by the ECDSA secp256k1 verifier of the signature suites
+	require.NoError(t, sigverifier.NewECDSASecp256k1SignatureVerifier().Verify(
+		&sigverifier.PublicKey{Type: "EcdsaSecp256k1VerificationKey2019", Value: pubKey}, msg, sig))
+}
+
+func publicKey(t *testing.T, kh *keyset.Handle) []byte {
+	t.Helper()
+
+	pubKH, err := kh.Public()
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, pubKH.WriteWithNoSecrets(keyset.NewBinaryWriter(buf)))
+
+	ks := new(tinkpb.Keyset)
+	require.NoError(t, proto.Unmarshal(buf.Bytes(), ks))
+
+	key := new(ecdsapb.EcdsaPublicKey)
+	require.NoError(t, proto.Unmarshal(ks.Key[0].KeyData.Value, key))
+
+	pub, err := btcec.ParsePubKey(append(append([]byte{0x04}, pad(key.X)...), pad(key.Y)...), btcec.S256())
+	require.NoError(t, err)
+
+	return pub.SerializeUncompressed()
+}
+
+func pad(b []byte) []byte {
+	return append(make([]byte, keySize-len(b)), b...)
+}
+
+func TestSignerKeyManager(t *testing.T) {
+	km := newSignerKeyManager()
+	require.True(t, km.DoesSupport(SignerTypeURL))
+	require.False(t, km.DoesSupport(VerifierTypeURL))
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, err := km.Primitive(nil)
+		require.EqualError(t, err, errInvalidSignerKey.Error())
+
+		_, err = km.Primitive([]byte("key"))
+		require.EqualError(t, err, errInvalidSignerKey.Error())
+
+		key, err := proto.Marshal(&ecdsapb.EcdsaPrivateKey{Version: 1})
+		require.NoError(t, err)
+
+		_, err = km.Primitive(key)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid key")
+
+		key, err = proto.Marshal(&ecdsapb.EcdsaPrivateKey{KeyValue: []byte{1}})
+		require.NoError(t, err)
+
+		_, err = km.Primitive(key)
+		require.EqualError(t, err, errInvalidSignerKey.Error())
+
+		key, err = proto.Marshal(&ecdsapb.EcdsaPrivateKey{
+			PublicKey: &ecdsapb.EcdsaPublicKey{Params: &ecdsapb.EcdsaParams{HashType: commonpb.HashType_SHA512}},
+			KeyValue:  []byte{1},
+		})
+		require.NoError(t, err)
+
+		_, err = km.Primitive(key)
+		require.EqualError(t, err, "secp256k1_signer_key_manager: invalid hash type, expect SHA-256")
+	})
+
+	t.Run("invalid key formats", func(t *testing.T) {
+		_, err := km.NewKeyData(nil)
+		require.EqualError(t, err, errInvalidSignerKeyFormat.Error())
+
+		_, err = km.NewKey([]byte("format"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid proto")
+
+		format, err := proto.Marshal(&ecdsapb.EcdsaKeyFormat{Params: &ecdsapb.EcdsaParams{
+			HashType: commonpb.HashType_SHA256,
+		}})
+		require.NoError(t, err)
+
+		_, err = km.NewKey(format)
+		require.EqualError(t, err, "secp256k1_signer_key_manager: invalid key format: "+
+			"unsupported encoding: UNKNOWN_ENCODING")
+	})
+
+	t.Run("invalid public key data", func(t *testing.T) {
+		_, err := km.PublicKeyData([]byte("key"))
+		require.EqualError(t, err, errInvalidSignerKey.Error())
+	})
+}
+
+func TestVerifierKeyManager(t *testing.T) {
+	km := newVerifierKeyManager()
+	require.True(t, km.DoesSupport(VerifierTypeURL))
+	require.Equal(t, VerifierTypeURL, km.TypeURL())
+
+	_, err := km.NewKey(nil)
+	require.EqualError(t, err, errVerifierNotImplemented.Error())
+
+	_, err = km.NewKeyData(nil)
+	require.EqualError(t, err, errVerifierNotImplemented.Error())
+
+	_, err = km.Primitive(nil)
+	require.EqualError(t, err, errInvalidVerifierKey.Error())
+
+	_, err = km.Primitive([]byte("key"))
+	require.EqualError(t, err, errInvalidVerifierKey.Error())
+
+	key, err := proto.Marshal(&ecdsapb.EcdsaPublicKey{Version: 1})
+	require.NoError(t, err)
+
+	_, err = km.Primitive(key)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid key")
+
+	key, err = proto.Marshal(&ecdsapb.EcdsaPublicKey{X: []byte{1}})
+	require.NoError(t, err)
+
+	_, err = km.Primitive(key)
+	require.EqualError(t, err, "secp256k1_verifier_key_manager: missing params")
+
+	key, err = proto.Marshal(&ecdsapb.EcdsaPublicKey{
+		Params: &ecdsapb.EcdsaParams{
+			HashType: commonpb.HashType_SHA256,
+			Encoding: ecdsapb.EcdsaSignatureEncoding_DER,
+		},
+		X: []byte{1},
+		Y: []byte{2},
+	})
+	require.NoError(t, err)
+
+	_, err = km.Primitive(key)
+	require.EqualError(t, err, errInvalidVerifierKey.Error())
+}
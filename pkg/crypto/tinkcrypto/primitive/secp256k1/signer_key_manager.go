@@ -0,0 +1,162 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secp256k1
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/tink/go/keyset"
+	ecdsapb "github.com/google/tink/go/proto/ecdsa_go_proto"
+	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
+)
+
+var (
+	errInvalidSignerKey       = errors.New("secp256k1_signer_key_manager: invalid key")
+	errInvalidSignerKeyFormat = errors.New("secp256k1_signer_key_manager: invalid key format")
+)
+
+// signerKeyManager is an implementation of the KeyManager interface of Tink.
+// It generates new secp256k1 private keys and produces new instances of the secp256k1 signer.
+type signerKeyManager struct{}
+
+func newSignerKeyManager() *signerKeyManager {
+	return new(signerKeyManager)
+}
+
+// Primitive creates a signer for the given serialized private key.
+func (km *signerKeyManager) Primitive(serializedKey []byte) (interface{}, error) {
+	if len(serializedKey) == 0 {
+		return nil, errInvalidSignerKey
+	}
+
+	key := new(ecdsapb.EcdsaPrivateKey)
+	if err := proto.Unmarshal(serializedKey, key); err != nil {
+		return nil, errInvalidSignerKey
+	}
+
+	if err := keyset.ValidateKeyVersion(key.Version, keyVersion); err != nil {
+		return nil, fmt.Errorf("secp256k1_signer_key_manager: invalid key: %w", err)
+	}
+
+	if key.PublicKey == nil {
+		return nil, errInvalidSignerKey
+	}
+
+	if err := validateParams(key.PublicKey.Params); err != nil {
+		return nil, fmt.Errorf("secp256k1_signer_key_manager: %w", err)
+	}
+
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), key.KeyValue)
+
+	return newSigner(privKey.ToECDSA(), key.PublicKey.Params.Encoding), nil
+}
+
+// NewKey creates a new private key according to the given serialized key format.
+func (km *signerKeyManager) NewKey(serializedKeyFormat []byte) (proto.Message, error) {
+	if len(serializedKeyFormat) == 0 {
+		return nil, errInvalidSignerKeyFormat
+	}
+
+	keyFormat := new(ecdsapb.EcdsaKeyFormat)
+	if err := proto.Unmarshal(serializedKeyFormat, keyFormat); err != nil {
+		return nil, fmt.Errorf("secp256k1_signer_key_manager: invalid proto: %w", err)
+	}
+
+	if err := validateParams(keyFormat.Params); err != nil {
+		return nil, fmt.Errorf("secp256k1_signer_key_manager: invalid key format: %w", err)
+	}
+
+	privKey, err := ecdsa.GenerateKey(btcec.S256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("secp256k1_signer_key_manager: cannot generate key: %w", err)
+	}
+
+	return &ecdsapb.EcdsaPrivateKey{
+		Version: keyVersion,
+		PublicKey: &ecdsapb.EcdsaPublicKey{
+			Version: keyVersion,
+			Params:  keyFormat.Params,
+			X:       privKey.X.Bytes(),
+			Y:       privKey.Y.Bytes(),
+		},
+		KeyValue: privKey.D.Bytes(),
+	}, nil
+}
+
+// NewKeyData creates a new KeyData according to the given serialized key format.
+// It should be used solely by the key management API.
+func (km *signerKeyManager) NewKeyData(serializedKeyFormat []byte) (*tinkpb.KeyData, error) {
+	key, err := km.NewKey(serializedKeyFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedKey, err := proto.Marshal(key)
+	if err != nil {
+		return nil, errInvalidSignerKeyFormat
+	}
+
+	return &tinkpb.KeyData{
+		TypeUrl:         SignerTypeURL,
+		Value:           serializedKey,
+		KeyMaterialType: tinkpb.KeyData_ASYMMETRIC_PRIVATE,
+	}, nil
+}
+
+// PublicKeyData extracts the public key data from the private key.
+func (km *signerKeyManager) PublicKeyData(serializedPrivKey []byte) (*tinkpb.KeyData, error) {
+	privKey := new(ecdsapb.EcdsaPrivateKey)
+	if err := proto.Unmarshal(serializedPrivKey, privKey); err != nil {
+		return nil, errInvalidSignerKey
+	}
+
+	serializedPubKey, err := proto.Marshal(privKey.PublicKey)
+	if err != nil {
+		return nil, errInvalidSignerKey
+	}
+
+	return &tinkpb.KeyData{
+		TypeUrl:         VerifierTypeURL,
+		Value:           serializedPubKey,
+		KeyMaterialType: tinkpb.KeyData_ASYMMETRIC_PUBLIC,
+	}, nil
+}
+
+// DoesSupport indicates if this key manager supports the given key type.
+func (km *signerKeyManager) DoesSupport(typeURL string) bool {
+	return typeURL == SignerTypeURL
+}
+
+// TypeURL returns the key type of keys managed by this key manager.
+func (km *signerKeyManager) TypeURL() string {
+	return SignerTypeURL
+}
+
+// signer signs with a secp256k1 private key, it implements the tink.Signer interface.
+type signer struct {
+	privateKey *ecdsa.PrivateKey
+	encoding   ecdsapb.EcdsaSignatureEncoding
+}
+
+func newSigner(privateKey *ecdsa.PrivateKey, encoding ecdsapb.EcdsaSignatureEncoding) *signer {
+	return &signer{privateKey: privateKey, encoding: encoding}
+}
+
+// Sign computes the signature of the SHA-256 digest of the data.
+func (s *signer) Sign(data []byte) ([]byte, error) {
+	r, sig, err := ecdsa.Sign(rand.Reader, s.privateKey, digest(data))
+	if err != nil {
+		return nil, fmt.Errorf("secp256k1_signer: signing failed: %w", err)
+	}
+
+	return encodeSignature(r, sig, s.encoding)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secp256k1
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/tink/go/keyset"
+	ecdsapb "github.com/google/tink/go/proto/ecdsa_go_proto"
+	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
+)
+
+var (
+	errInvalidVerifierKey     = errors.New("secp256k1_verifier_key_manager: invalid key")
+	errVerifierNotImplemented = errors.New("secp256k1_verifier_key_manager: not implemented")
+	errInvalidSignature       = errors.New("secp256k1_verifier: invalid signature")
+)
+
+// verifierKeyManager is an implementation of the KeyManager interface of Tink.
+// It doesn't support key generation.
+type verifierKeyManager struct{}
+
+func newVerifierKeyManager() *verifierKeyManager {
+	return new(verifierKeyManager)
+}
+
+// Primitive creates a verifier for the given serialized public key.
+func (km *verifierKeyManager) Primitive(serializedKey []byte) (interface{}, error) {
+	if len(serializedKey) == 0 {
+		return nil, errInvalidVerifierKey
+	}
+
+	key := new(ecdsapb.EcdsaPublicKey)
+	if err := proto.Unmarshal(serializedKey, key); err != nil {
+		return nil, errInvalidVerifierKey
+	}
+
+	if err := keyset.ValidateKeyVersion(key.Version, keyVersion); err != nil {
+		return nil, fmt.Errorf("secp256k1_verifier_key_manager: invalid key: %w", err)
+	}
+
+	if err := validateParams(key.Params); err != nil {
+		return nil, fmt.Errorf("secp256k1_verifier_key_manager: %w", err)
+	}
+
+	publicKey := &ecdsa.PublicKey{
+		Curve: btcec.S256(),
+		X:     new(big.Int).SetBytes(key.X),
+		Y:     new(big.Int).SetBytes(key.Y),
+	}
+
+	if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errInvalidVerifierKey
+	}
+
+	return &verifier{publicKey: publicKey, encoding: key.Params.Encoding}, nil
+}
+
+// NewKey is not implemented.
+func (km *verifierKeyManager) NewKey(serializedKeyFormat []byte) (proto.Message, error) {
+	return nil, errVerifierNotImplemented
+}
+
+// NewKeyData is not implemented.
+func (km *verifierKeyManager) NewKeyData(serializedKeyFormat []byte) (*tinkpb.KeyData, error) {
+	return nil, errVerifierNotImplemented
+}
+
+// DoesSupport indicates if this key manager supports the given key type.
+func (km *verifierKeyManager) DoesSupport(typeURL string) bool {
+	return typeURL == VerifierTypeURL
+}
+
+// TypeURL returns the key type of keys managed by this key manager.
+func (km *verifierKeyManager) TypeURL() string {
+	return VerifierTypeURL
+}
+
+// verifier verifies the signatures of a secp256k1 public key, it implements the tink.Verifier interface.
+type verifier struct {
+	publicKey *ecdsa.PublicKey
+	encoding  ecdsapb.EcdsaSignatureEncoding
+}
+
+// Verify verifies the signature of the SHA-256 digest of the data.
+func (v *verifier) Verify(signature, data []byte) error {
+	r, s, err := decodeSignature(signature, v.encoding)
+	if err != nil {
+		return err
+	}
+
+	if !ecdsa.Verify(v.publicKey, digest(data), r, s) {
+		return errInvalidSignature
+	}
+
+	return nil
+}
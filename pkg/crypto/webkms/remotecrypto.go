@@ -0,0 +1,233 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package webkms provides a crypto.Crypto implementation delegating all operations to a remote key server (WebKMS).
+// Key handles are key URLs as returned by the remote kms found in pkg/kms/webkms, the private keys never leave the
+// remote key server.
+package webkms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
+)
+
+const (
+	encryptPath    = "/encrypt"
+	decryptPath    = "/decrypt"
+	signPath       = "/sign"
+	verifyPath     = "/verify"
+	computeMACPath = "/computemac"
+	verifyMACPath  = "/verifymac"
+)
+
+var errBadKeyHandleFormat = errors.New("bad key handle format: key URL expected")
+
+type encryptReq struct {
+	Message string `json:"message,omitempty"`
+	AAD     string `json:"aad,omitempty"`
+}
+
+type encryptResp struct {
+	CipherText string `json:"cipherText,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+}
+
+type decryptReq struct {
+	CipherText string `json:"cipherText,omitempty"`
+	AAD        string `json:"aad,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+}
+
+type decryptResp struct {
+	PlainText string `json:"plainText,omitempty"`
+}
+
+type signReq struct {
+	Message string `json:"message,omitempty"`
+}
+
+type signResp struct {
+	Signature string `json:"signature,omitempty"`
+}
+
+type verifyReq struct {
+	Signature string `json:"signature,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+type computeMACReq struct {
+	Data string `json:"data,omitempty"`
+}
+
+type computeMACResp struct {
+	MAC string `json:"mac,omitempty"`
+}
+
+type verifyMACReq struct {
+	MAC  string `json:"mac,omitempty"`
+	Data string `json:"data,omitempty"`
+}
+
+// RemoteCrypto implementation of crypto.Crypto api using a remote key server
+type RemoteCrypto struct {
+	httpClient webkms.HTTPClient
+}
+
+// New creates a new remote crypto instance
+func New(client webkms.HTTPClient) *RemoteCrypto {
+	return &RemoteCrypto{httpClient: client}
+}
+
+// Encrypt will remotely encrypt msg and aad using the key found at the key URL kh
+// returns:
+// 		cipherText in []byte
+//		nonce in []byte
+//		error in case of errors during encryption
+func (r *RemoteCrypto) Encrypt(msg, aad []byte, kh interface{}) ([]byte, []byte, error) {
+	resp := &encryptResp{}
+
+	err := r.post(kh, encryptPath, encryptReq{Message: encode(msg), AAD: encode(aad)}, resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypt: %w", err)
+	}
+
+	cipherText, err := decode(resp.CipherText)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypt: decode cipher text: %w", err)
+	}
+
+	nonce, err := decode(resp.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypt: decode nonce: %w", err)
+	}
+
+	return cipherText, nonce, nil
+}
+
+// Decrypt will remotely decrypt cipher with aad and given nonce using the key found at the key URL kh
+// returns:
+//		plainText in []byte
+//		error in case of errors
+func (r *RemoteCrypto) Decrypt(cipher, aad, nonce []byte, kh interface{}) ([]byte, error) {
+	resp := &decryptResp{}
+
+	err := r.post(kh, decryptPath, decryptReq{CipherText: encode(cipher), AAD: encode(aad), Nonce: encode(nonce)}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+
+	plainText, err := decode(resp.PlainText)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: decode plain text: %w", err)
+	}
+
+	return plainText, nil
+}
+
+// Sign will remotely sign msg using the key found at the key URL kh
+// returns:
+// 		signature in []byte
+//		error in case of errors
+func (r *RemoteCrypto) Sign(msg []byte, kh interface{}) ([]byte, error) {
+	resp := &signResp{}
+
+	err := r.post(kh, signPath, signReq{Message: encode(msg)}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+
+	signature, err := decode(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("sign: decode signature: %w", err)
+	}
+
+	return signature, nil
+}
+
+// Verify will remotely verify a signature for the given msg using the key found at the key URL kh
+// returns:
+// 		error in case of errors or nil if signature verification was successful
+func (r *RemoteCrypto) Verify(signature, msg []byte, kh interface{}) error {
+	err := r.post(kh, verifyPath, verifyReq{Signature: encode(signature), Message: encode(msg)}, nil)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	return nil
+}
+
+// ComputeMAC remotely computes message authentication code (MAC) for data using the key found at the key URL kh
+// returns:
+// 		mac in []byte
+//		error in case of errors
+func (r *RemoteCrypto) ComputeMAC(data []byte, kh interface{}) ([]byte, error) {
+	resp := &computeMACResp{}
+
+	err := r.post(kh, computeMACPath, computeMACReq{Data: encode(data)}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("compute mac: %w", err)
+	}
+
+	mac, err := decode(resp.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("compute mac: decode mac: %w", err)
+	}
+
+	return mac, nil
+}
+
+// VerifyMAC remotely determines if mac is a correct authentication code (MAC) for data using the key found at the
+// key URL kh and returns nil if so, otherwise it returns an error.
+func (r *RemoteCrypto) VerifyMAC(mac, data []byte, kh interface{}) error {
+	err := r.post(kh, verifyMACPath, verifyMACReq{MAC: encode(mac), Data: encode(data)}, nil)
+	if err != nil {
+		return fmt.Errorf("verify mac: %w", err)
+	}
+
+	return nil
+}
+
+// post sends req to the path of the key URL kh and unmarshals the response into resp (if not nil)
+func (r *RemoteCrypto) post(kh interface{}, path string, req, resp interface{}) error {
+	keyURL, ok := kh.(string)
+	if !ok {
+		return errBadKeyHandleFormat
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	_, respBody, err := webkms.Do(r.httpClient, http.MethodPost, keyURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	if resp == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(respBody, resp)
+	if err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+func encode(b []byte) string {
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.URLEncoding.DecodeString(s)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webkms
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const keyPath = "/kms/keystores/ks1/keys/key1"
+
+// newKeyServer creates a fake remote key server: the signing key and mac key never leave the server
+func newKeyServer(t *testing.T) *httptest.Server {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	macKey := []byte("mac key")
+
+	computeMAC := func(data []byte) []byte {
+		h := hmac.New(sha256.New, macKey)
+		_, e := h.Write(data)
+		require.NoError(t, e)
+
+		return h.Sum(nil)
+	}
+
+	respond := func(w http.ResponseWriter, resp interface{}) {
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(keyPath+encryptPath, func(w http.ResponseWriter, r *http.Request) {
+		req := &encryptReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		// reversible fake encryption, enough to validate the protocol
+		msg, e := decode(req.Message)
+		require.NoError(t, e)
+
+		respond(w, encryptResp{CipherText: encode(reverse(msg)), Nonce: encode([]byte("nonce"))})
+	})
+
+	mux.HandleFunc(keyPath+decryptPath, func(w http.ResponseWriter, r *http.Request) {
+		req := &decryptReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		cipherText, e := decode(req.CipherText)
+		require.NoError(t, e)
+
+		respond(w, decryptResp{PlainText: encode(reverse(cipherText))})
+	})
+
+	mux.HandleFunc(keyPath+signPath, func(w http.ResponseWriter, r *http.Request) {
+		req := &signReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		msg, e := decode(req.Message)
+		require.NoError(t, e)
+
+		respond(w, signResp{Signature: encode(ed25519.Sign(privKey, msg))})
+	})
+
+	mux.HandleFunc(keyPath+verifyPath, func(w http.ResponseWriter, r *http.Request) {
+		req := &verifyReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		msg, e := decode(req.Message)
+		require.NoError(t, e)
+
+		sig, e := decode(req.Signature)
+		require.NoError(t, e)
+
+		if !ed25519.Verify(pubKey, msg, sig) {
+			w.WriteHeader(http.StatusNotAcceptable)
+		}
+	})
+
+	mux.HandleFunc(keyPath+computeMACPath, func(w http.ResponseWriter, r *http.Request) {
+		req := &computeMACReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		data, e := decode(req.Data)
+		require.NoError(t, e)
+
+		respond(w, computeMACResp{MAC: encode(computeMAC(data))})
+	})
+
+	mux.HandleFunc(keyPath+verifyMACPath, func(w http.ResponseWriter, r *http.Request) {
+		req := &verifyMACReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		data, e := decode(req.Data)
+		require.NoError(t, e)
+
+		mac, e := decode(req.MAC)
+		require.NoError(t, e)
+
+		if !hmac.Equal(mac, computeMAC(data)) {
+			w.WriteHeader(http.StatusNotAcceptable)
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+
+	return r
+}
+
+func TestRemoteCrypto(t *testing.T) {
+	server := newKeyServer(t)
+	defer server.Close()
+
+	keyURL := server.URL + keyPath
+	msg := []byte("lorem ipsum")
+
+	rCrypto := New(server.Client())
+
+	t.Run("encrypt/decrypt", func(t *testing.T) {
+		cipherText, nonce, err := rCrypto.Encrypt(msg, []byte("aad"), keyURL)
+		require.NoError(t, err)
+		require.Equal(t, []byte("nonce"), nonce)
+		require.False(t, bytes.Equal(msg, cipherText))
+
+		plainText, err := rCrypto.Decrypt(cipherText, []byte("aad"), nonce, keyURL)
+		require.NoError(t, err)
+		require.Equal(t, msg, plainText)
+	})
+
+	t.Run("sign/verify", func(t *testing.T) {
+		signature, err := rCrypto.Sign(msg, keyURL)
+		require.NoError(t, err)
+
+		require.NoError(t, rCrypto.Verify(signature, msg, keyURL))
+
+		err = rCrypto.Verify(signature, []byte("other message"), keyURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "verify: remote kms returned status 406")
+	})
+
+	t.Run("compute/verify mac", func(t *testing.T) {
+		mac, err := rCrypto.ComputeMAC(msg, keyURL)
+		require.NoError(t, err)
+
+		require.NoError(t, rCrypto.VerifyMAC(mac, msg, keyURL))
+
+		err = rCrypto.VerifyMAC(mac, []byte("other message"), keyURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "verify mac: remote kms returned status 406")
+	})
+
+	t.Run("signer", func(t *testing.T) {
+		signature, err := NewSigner(rCrypto, keyURL).Sign(msg)
+		require.NoError(t, err)
+		require.NoError(t, rCrypto.Verify(signature, msg, keyURL))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := rCrypto.Sign(msg, server.URL+"/kms/keystores/ks1/keys/unknown")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sign: remote kms returned status 404")
+	})
+
+	t.Run("bad key handle", func(t *testing.T) {
+		_, err := rCrypto.Sign(msg, []byte(keyURL))
+		require.EqualError(t, err, "sign: "+errBadKeyHandleFormat.Error())
+
+		_, _, err = rCrypto.Encrypt(msg, nil, nil)
+		require.EqualError(t, err, "encrypt: "+errBadKeyHandleFormat.Error())
+
+		_, err = rCrypto.Decrypt(msg, nil, nil, nil)
+		require.EqualError(t, err, "decrypt: "+errBadKeyHandleFormat.Error())
+
+		_, err = rCrypto.ComputeMAC(msg, nil)
+		require.EqualError(t, err, "compute mac: "+errBadKeyHandleFormat.Error())
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		badServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte("{"))
+			require.NoError(t, err)
+		}))
+		defer badServer.Close()
+
+		_, err := New(badServer.Client()).Sign(msg, badServer.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sign: unmarshal response")
+	})
+}
@@ -0,0 +1,29 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webkms
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/crypto"
+)
+
+// Signer signs data with a single key managed by a crypto.Crypto implementation.
+// It can be used wherever a `Sign(data []byte) ([]byte, error)` signer is expected, eg to add proofs to
+// verifiable credentials and presentations with a remote (HSM-backed) key.
+type Signer struct {
+	crypto crypto.Crypto
+	kh     interface{}
+}
+
+// NewSigner creates a new Signer using the key handle kh (the key URL when used with RemoteCrypto)
+func NewSigner(c crypto.Crypto, kh interface{}) *Signer {
+	return &Signer{crypto: c, kh: kh}
+}
+
+// Sign will sign data with the Signer's key
+func (s *Signer) Sign(data []byte) ([]byte, error) {
+	return s.crypto.Sign(data, s.kh)
+}
@@ -0,0 +1,264 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package attachformat is the registry of the attachment formats of the protocols exchanging credentials and
+// presentations (e.g issue credential and present proof v2), with the helpers negotiating the formats with
+// the peer: the formats declared by the peer in its proposals, offers and requests are intersected with the
+// formats supported by the service.
+package attachformat
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+)
+
+// Attachment formats of the credentials and presentations, as registered by Aries RFC 0453 (issue credential v2)
+// and Aries RFC 0454 (present proof v2).
+const (
+	// HLIndyCredentialFilter is the Hyperledger Indy credential filter (proposals).
+	HLIndyCredentialFilter = "hlindy/cred-filter@v2.0"
+	// HLIndyCredentialAbstract is the Hyperledger Indy credential offer.
+	HLIndyCredentialAbstract = "hlindy/cred-abstract@v2.0"
+	// HLIndyCredentialRequest is the Hyperledger Indy credential request.
+	HLIndyCredentialRequest = "hlindy/cred-req@v2.0"
+	// HLIndyCredential is the Hyperledger Indy credential.
+	HLIndyCredential = "hlindy/cred@v2.0"
+	// HLIndyProofRequest is the Hyperledger Indy proof request.
+	HLIndyProofRequest = "hlindy/proof-req@v2.0"
+	// HLIndyProof is the Hyperledger Indy proof.
+	HLIndyProof = "hlindy/proof@v2.0"
+	// DIFPresentationDefinitions are the DIF Presentation Exchange presentation definitions (requests).
+	DIFPresentationDefinitions = "dif/presentation-exchange/definitions@v1.0"
+	// DIFPresentationSubmission is the DIF Presentation Exchange presentation submission.
+	DIFPresentationSubmission = "dif/presentation-exchange/submission@v1.0"
+	// LDProofCredentialDetail is the detail of a linked data proof credential (proposals, offers and requests).
+	LDProofCredentialDetail = "aries/ld-proof-vc-detail@v1.0"
+	// LDProofCredential is a linked data proof credential.
+	LDProofCredential = "aries/ld-proof-vc@v1.0"
+)
+
+// ErrNoCommonFormat is returned when none of the formats declared by the peer is supported.
+var ErrNoCommonFormat = errors.New("no common attachment format")
+
+// Descriptor describes an attachment format.
+type Descriptor struct {
+	// ID is the identifier of the format, declared in the formats of the messages.
+	ID string
+	// MimeType is the MIME type of the attachments of the format.
+	MimeType string
+	// Description is a human readable description of the format.
+	Description string
+}
+
+// nolint:gochecknoglobals
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Descriptor{
+		HLIndyCredentialFilter:     {HLIndyCredentialFilter, "application/json", "Indy credential filter"},
+		HLIndyCredentialAbstract:   {HLIndyCredentialAbstract, "application/json", "Indy credential abstract"},
+		HLIndyCredentialRequest:    {HLIndyCredentialRequest, "application/json", "Indy credential request"},
+		HLIndyCredential:           {HLIndyCredential, "application/json", "Indy credential"},
+		HLIndyProofRequest:         {HLIndyProofRequest, "application/json", "Indy proof request"},
+		HLIndyProof:                {HLIndyProof, "application/json", "Indy proof"},
+		DIFPresentationDefinitions: {DIFPresentationDefinitions, "application/json", "DIF presentation definitions"},
+		DIFPresentationSubmission:  {DIFPresentationSubmission, "application/json", "DIF presentation submission"},
+		LDProofCredentialDetail:    {LDProofCredentialDetail, "application/json", "Linked data proof credential detail"},
+		LDProofCredential:          {LDProofCredential, "application/ld+json", "Linked data proof credential"},
+	}
+)
+
+// Register adds a format to the registry, e.g a format specific to an ecosystem.
+func Register(descriptor Descriptor) error {
+	if descriptor.ID == "" {
+		return errors.New("register attachment format: ID is mandatory")
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[descriptor.ID]; ok {
+		return fmt.Errorf("register attachment format %s: already registered", descriptor.ID)
+	}
+
+	registry[descriptor.ID] = descriptor
+
+	return nil
+}
+
+// Get returns the descriptor of a registered format.
+func Get(id string) (Descriptor, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	descriptor, ok := registry[id]
+
+	return descriptor, ok
+}
+
+// Supported is the set of the formats supported by a service, in its order of preference.
+type Supported struct {
+	formats []string
+}
+
+// NewSupported declares the formats supported by a service, in its order of preference.
+// The formats must be registered.
+func NewSupported(formats ...string) (*Supported, error) {
+	for _, format := range formats {
+		if _, ok := Get(format); !ok {
+			return nil, fmt.Errorf("unknown attachment format: %s", format)
+		}
+	}
+
+	return &Supported{formats: formats}, nil
+}
+
+// MustNewSupported is NewSupported for the formats registered by this package, it panics if a format is unknown.
+func MustNewSupported(formats ...string) *Supported {
+	supported, err := NewSupported(formats...)
+	if err != nil {
+		panic(err)
+	}
+
+	return supported
+}
+
+// Formats returns the supported formats, in the order of preference.
+func (s *Supported) Formats() []string {
+	return append([]string(nil), s.formats...)
+}
+
+// Supports checks whether the format is supported.
+func (s *Supported) Supports(format string) bool {
+	for _, f := range s.formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Declare returns the formats and the attachments of a message, the attachments of the supported formats
+// by ID of their format. The attachments get the MIME type of their format if they have none.
+func (s *Supported) Declare(attachments map[string]decorator.Attachment) ([]decorator.AttachmentFormat,
+	[]decorator.Attachment, error) {
+	var (
+		formats  []decorator.AttachmentFormat
+		attached []decorator.Attachment
+	)
+
+	// iterates over the supported formats to declare them in the order of preference
+	for _, format := range s.formats {
+		attachment, ok := attachments[format]
+		if !ok {
+			continue
+		}
+
+		if attachment.ID == "" {
+			return nil, nil, fmt.Errorf("attachment of the format %s has no ID", format)
+		}
+
+		if attachment.MimeType == "" {
+			descriptor, _ := Get(format) // nolint: errcheck
+			attachment.MimeType = descriptor.MimeType
+		}
+
+		formats = append(formats, decorator.AttachmentFormat{AttachID: attachment.ID, Format: format})
+		attached = append(attached, attachment)
+	}
+
+	if len(attached) != len(attachments) {
+		return nil, nil, errors.New("attachments of unsupported formats")
+	}
+
+	return formats, attached, nil
+}
+
+// Negotiate intersects the formats declared by the peer with the supported ones: it returns the declared
+// formats which are supported, in the order of preference of the peer. ErrNoCommonFormat is returned if
+// none of them is supported.
+func (s *Supported) Negotiate(declared []decorator.AttachmentFormat) ([]decorator.AttachmentFormat, error) {
+	var common []decorator.AttachmentFormat
+
+	for _, format := range declared {
+		if s.Supports(format.Format) {
+			common = append(common, format)
+		}
+	}
+
+	if len(common) == 0 {
+		return nil, fmt.Errorf("%w: declared %v, supported %v", ErrNoCommonFormat, formatIDs(declared), s.formats)
+	}
+
+	return common, nil
+}
+
+// Select negotiates the format of the message (see Negotiate) and returns the attachment of the preferred
+// common format of the peer, with its format.
+func (s *Supported) Select(declared []decorator.AttachmentFormat,
+	attachments []decorator.Attachment) (*decorator.Attachment, string, error) {
+	common, err := s.Negotiate(declared)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, format := range common {
+		for i := range attachments {
+			if attachments[i].ID == format.AttachID {
+				return &attachments[i], format.Format, nil
+			}
+		}
+	}
+
+	return nil, "", fmt.Errorf("no attachment of the formats %v", formatIDs(common))
+}
+
+// Filter negotiates the formats of a message (see Negotiate) and returns its attachments of the common formats,
+// in the order of the attachments, with the common formats. The messages declaring no format (e.g the messages
+// of the first versions of the protocols) are not negotiated, all their attachments are returned.
+func (s *Supported) Filter(declared []decorator.AttachmentFormat,
+	attachments []decorator.Attachment) ([]decorator.Attachment, []decorator.AttachmentFormat, error) {
+	if len(declared) == 0 {
+		return attachments, nil, nil
+	}
+
+	common, err := s.Negotiate(declared)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make(map[string]struct{}, len(common))
+	for _, format := range common {
+		ids[format.AttachID] = struct{}{}
+	}
+
+	var filtered []decorator.Attachment
+
+	for i := range attachments {
+		if _, ok := ids[attachments[i].ID]; ok {
+			filtered = append(filtered, attachments[i])
+		}
+	}
+
+	if len(filtered) == 0 {
+		return nil, nil, fmt.Errorf("no attachment of the formats %v", formatIDs(common))
+	}
+
+	return filtered, common, nil
+}
+
+func formatIDs(formats []decorator.AttachmentFormat) []string {
+	ids := make([]string, len(formats))
+
+	for i, format := range formats {
+		ids[i] = format.Format
+	}
+
+	return ids
+}
@@ -0,0 +1,167 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attachformat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+)
+
+func TestRegister(t *testing.T) {
+	const custom = "example/custom@v1.0"
+
+	defer func() {
+		registryMutex.Lock()
+		delete(registry, custom)
+		registryMutex.Unlock()
+	}()
+
+	_, ok := Get(custom)
+	require.False(t, ok)
+
+	require.NoError(t, Register(Descriptor{ID: custom, MimeType: "application/cbor"}))
+
+	descriptor, ok := Get(custom)
+	require.True(t, ok)
+	require.Equal(t, "application/cbor", descriptor.MimeType)
+
+	err := Register(Descriptor{ID: custom})
+	require.EqualError(t, err, "register attachment format example/custom@v1.0: already registered")
+
+	err = Register(Descriptor{})
+	require.EqualError(t, err, "register attachment format: ID is mandatory")
+}
+
+func TestNewSupported(t *testing.T) {
+	supported, err := NewSupported(DIFPresentationDefinitions, HLIndyProofRequest)
+	require.NoError(t, err)
+	require.Equal(t, []string{DIFPresentationDefinitions, HLIndyProofRequest}, supported.Formats())
+	require.True(t, supported.Supports(HLIndyProofRequest))
+	require.False(t, supported.Supports(DIFPresentationSubmission))
+	require.False(t, supported.Supports("w3c/vp@v1.0"))
+
+	_, err = NewSupported(DIFPresentationDefinitions, "unknown@v1.0")
+	require.EqualError(t, err, "unknown attachment format: unknown@v1.0")
+
+	require.Equal(t, []string{LDProofCredential}, MustNewSupported(LDProofCredential).Formats())
+	require.Panics(t, func() { MustNewSupported("unknown@v1.0") })
+}
+
+func TestSupported_Declare(t *testing.T) {
+	supported, err := NewSupported(DIFPresentationDefinitions, HLIndyProofRequest)
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		formats, attachments, err := supported.Declare(map[string]decorator.Attachment{
+			HLIndyProofRequest:         {ID: "indy", MimeType: "text/plain"},
+			DIFPresentationDefinitions: {ID: "dif"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []decorator.AttachmentFormat{
+			{AttachID: "dif", Format: DIFPresentationDefinitions},
+			{AttachID: "indy", Format: HLIndyProofRequest},
+		}, formats)
+		require.Equal(t, []decorator.Attachment{
+			{ID: "dif", MimeType: "application/json"},
+			{ID: "indy", MimeType: "text/plain"},
+		}, attachments)
+	})
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		_, _, err := supported.Declare(map[string]decorator.Attachment{DIFPresentationSubmission: {ID: "vp"}})
+		require.EqualError(t, err, "attachments of unsupported formats")
+	})
+
+	t.Run("No attachment ID", func(t *testing.T) {
+		_, _, err := supported.Declare(map[string]decorator.Attachment{HLIndyProofRequest: {}})
+		require.EqualError(t, err, "attachment of the format hlindy/proof-req@v2.0 has no ID")
+	})
+}
+
+func TestSupported_Negotiate(t *testing.T) {
+	supported, err := NewSupported(DIFPresentationDefinitions, HLIndyProofRequest)
+	require.NoError(t, err)
+
+	declared := []decorator.AttachmentFormat{
+		{AttachID: "indy", Format: HLIndyProofRequest},
+		{AttachID: "ld", Format: LDProofCredentialDetail},
+		{AttachID: "dif", Format: DIFPresentationDefinitions},
+	}
+
+	t.Run("Common formats in the order of the peer", func(t *testing.T) {
+		common, err := supported.Negotiate(declared)
+		require.NoError(t, err)
+		require.Equal(t, []decorator.AttachmentFormat{declared[0], declared[2]}, common)
+	})
+
+	t.Run("No common format", func(t *testing.T) {
+		_, err := supported.Negotiate(declared[1:2])
+		require.True(t, errors.Is(err, ErrNoCommonFormat))
+		require.EqualError(t, err, "no common attachment format: declared [aries/ld-proof-vc-detail@v1.0], "+
+			"supported [dif/presentation-exchange/definitions@v1.0 hlindy/proof-req@v2.0]")
+	})
+
+	t.Run("Select the attachment", func(t *testing.T) {
+		attachments := []decorator.Attachment{{ID: "dif"}, {ID: "ld"}, {ID: "indy"}}
+
+		attachment, format, err := supported.Select(declared, attachments)
+		require.NoError(t, err)
+		require.Equal(t, HLIndyProofRequest, format)
+		require.Equal(t, &attachments[2], attachment)
+
+		_, _, err = supported.Select(declared, attachments[:2])
+		require.NoError(t, err)
+
+		_, _, err = supported.Select(declared, attachments[1:2])
+		require.EqualError(t, err,
+			"no attachment of the formats [hlindy/proof-req@v2.0 dif/presentation-exchange/definitions@v1.0]")
+
+		_, _, err = supported.Select(nil, attachments)
+		require.True(t, errors.Is(err, ErrNoCommonFormat))
+	})
+}
+
+func TestSupported_Filter(t *testing.T) {
+	supported := MustNewSupported(DIFPresentationDefinitions)
+	attachments := []decorator.Attachment{{ID: "indy"}, {ID: "dif"}}
+
+	t.Run("Attachments of the common formats", func(t *testing.T) {
+		declared := []decorator.AttachmentFormat{
+			{AttachID: "indy", Format: HLIndyProofRequest},
+			{AttachID: "dif", Format: DIFPresentationDefinitions},
+		}
+
+		filtered, common, err := supported.Filter(declared, attachments)
+		require.NoError(t, err)
+		require.Equal(t, []decorator.Attachment{{ID: "dif"}}, filtered)
+		require.Equal(t, declared[1:], common)
+	})
+
+	t.Run("No declared format", func(t *testing.T) {
+		filtered, common, err := supported.Filter(nil, attachments)
+		require.NoError(t, err)
+		require.Equal(t, attachments, filtered)
+		require.Empty(t, common)
+	})
+
+	t.Run("No common format", func(t *testing.T) {
+		_, _, err := supported.Filter([]decorator.AttachmentFormat{{AttachID: "indy", Format: HLIndyProofRequest}},
+			attachments)
+		require.True(t, errors.Is(err, ErrNoCommonFormat))
+	})
+
+	t.Run("No attachment of the common formats", func(t *testing.T) {
+		_, _, err := supported.Filter([]decorator.AttachmentFormat{
+			{AttachID: "unknown", Format: DIFPresentationDefinitions},
+		}, attachments)
+		require.EqualError(t, err, "no attachment of the formats [dif/presentation-exchange/definitions@v1.0]")
+	})
+}
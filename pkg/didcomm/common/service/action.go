@@ -10,10 +10,15 @@ import (
 	"sync"
 )
 
+// ActionCallback handles the action events, see Action.RegisterActionCallback.
+type ActionCallback func(DIDCommAction)
+
 // Action thread-safe action register structure
 type Action struct {
-	mu    sync.RWMutex
-	event chan<- DIDCommAction
+	mu       sync.RWMutex
+	event    chan<- DIDCommAction
+	callback ActionCallback
+	threads  map[string]ActionCallback
 }
 
 // ActionEvent returns event action channel
@@ -41,6 +46,10 @@ func (a *Action) RegisterActionEvent(ch chan<- DIDCommAction) error {
 		return ErrChannelRegistered
 	}
 
+	if a.callback != nil {
+		return ErrCallbackRegistered
+	}
+
 	a.event = ch
 
 	return nil
@@ -63,3 +72,115 @@ func (a *Action) UnregisterActionEvent(ch chan<- DIDCommAction) error {
 
 	return nil
 }
+
+// RegisterActionCallback registers a callback invoked with the action events instead of a channel.
+// Unlike a channel, a callback doesn't need a goroutine consuming the events, which is costly on the JS/WASM target.
+// The callback is invoked by the protocol service while handling the message, it must not block: the consumer
+// invokes the Continue or Stop function of the event later on.
+// Only one channel or callback can be registered for the action events.
+// NOTE: the callbacks are supported by the services triggering the events with TriggerActionEvent
+// (present proof and issue credential).
+func (a *Action) RegisterActionCallback(cb ActionCallback) error {
+	if cb == nil {
+		return ErrNilCallback
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.event != nil {
+		return ErrChannelRegistered
+	}
+
+	if a.callback != nil {
+		return ErrCallbackRegistered
+	}
+
+	a.callback = cb
+
+	return nil
+}
+
+// UnregisterActionCallback unregisters the action callback. Refer RegisterActionCallback().
+func (a *Action) UnregisterActionCallback() {
+	a.mu.Lock()
+	a.callback = nil
+	a.mu.Unlock()
+}
+
+// RegisterThreadActionCallback registers a callback invoked with the action events of a thread instead of the
+// registered channel or callback, it returns the function unregistering the callback. Unlike them, a callback
+// may be registered for each thread: a client driving its own protocol instances (eg the onboarding) handles
+// their actions while the events of the other threads still reach the registered channel or callback.
+// Only one callback can be registered for a thread.
+func (a *Action) RegisterThreadActionCallback(thID string, cb ActionCallback) (func(), error) {
+	if cb == nil {
+		return nil, ErrNilCallback
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.threads[thID]; ok {
+		return nil, ErrCallbackRegistered
+	}
+
+	if a.threads == nil {
+		a.threads = make(map[string]ActionCallback)
+	}
+
+	a.threads[thID] = cb
+
+	return func() {
+		a.mu.Lock()
+		delete(a.threads, thID)
+		a.mu.Unlock()
+	}, nil
+}
+
+// HasActionHandler returns true if a channel or a callback is registered for the action events, the callbacks
+// of the threads included.
+func (a *Action) HasActionHandler() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.event != nil || a.callback != nil || len(a.threads) > 0
+}
+
+// TriggerActionEvent invokes the callback registered for the thread of the message, else the registered
+// callback or sends the event to the registered channel.
+// It returns false if neither a channel nor a callback is registered for the message.
+func (a *Action) TriggerActionEvent(msg DIDCommAction) bool {
+	a.mu.RLock()
+	event, callback := a.event, a.callback
+	thread := a.threadCallback(msg)
+	a.mu.RUnlock()
+
+	if thread != nil {
+		event, callback = nil, thread
+	}
+
+	switch {
+	case callback != nil:
+		callback(msg)
+	case event != nil:
+		event <- msg
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (a *Action) threadCallback(msg DIDCommAction) ActionCallback {
+	if len(a.threads) == 0 || msg.Message == nil {
+		return nil
+	}
+
+	thID, err := msg.Message.ThreadID()
+	if err != nil {
+		return nil
+	}
+
+	return a.threads[thID]
+}
@@ -46,3 +46,87 @@ func TestAction_UnregisterActionEvent(t *testing.T) {
 	require.Nil(t, a.RegisterActionEvent(ch))
 	require.Nil(t, a.UnregisterActionEvent(ch))
 }
+
+func TestAction_RegisterActionCallback(t *testing.T) {
+	a := Action{}
+
+	// nil callback
+	require.EqualError(t, a.RegisterActionCallback(nil), ErrNilCallback.Error())
+	require.False(t, a.HasActionHandler())
+	require.False(t, a.TriggerActionEvent(DIDCommAction{}))
+
+	var received []DIDCommAction
+
+	require.NoError(t, a.RegisterActionCallback(func(msg DIDCommAction) {
+		received = append(received, msg)
+	}))
+	require.True(t, a.HasActionHandler())
+
+	// only one callback or channel
+	require.EqualError(t, a.RegisterActionCallback(func(DIDCommAction) {}), ErrCallbackRegistered.Error())
+	require.EqualError(t, a.RegisterActionEvent(make(chan DIDCommAction)), ErrCallbackRegistered.Error())
+
+	require.True(t, a.TriggerActionEvent(DIDCommAction{ProtocolName: "protocol"}))
+	require.Equal(t, []DIDCommAction{{ProtocolName: "protocol"}}, received)
+
+	a.UnregisterActionCallback()
+	require.False(t, a.HasActionHandler())
+
+	// the channel is used once the callback is unregistered
+	ch := make(chan DIDCommAction, 1)
+	require.NoError(t, a.RegisterActionEvent(ch))
+	require.EqualError(t, a.RegisterActionCallback(func(DIDCommAction) {}), ErrChannelRegistered.Error())
+
+	require.True(t, a.TriggerActionEvent(DIDCommAction{ProtocolName: "protocol"}))
+	require.Equal(t, "protocol", (<-ch).ProtocolName)
+	require.Len(t, received, 1)
+}
+
+func TestAction_RegisterThreadActionCallback(t *testing.T) {
+	a := Action{}
+
+	_, err := a.RegisterThreadActionCallback("thread", nil)
+	require.EqualError(t, err, ErrNilCallback.Error())
+
+	var received []string
+
+	unregister, err := a.RegisterThreadActionCallback("thread", func(msg DIDCommAction) {
+		received = append(received, msg.ProtocolName)
+	})
+	require.NoError(t, err)
+	require.True(t, a.HasActionHandler())
+
+	// only one callback for a thread
+	_, err = a.RegisterThreadActionCallback("thread", func(DIDCommAction) {})
+	require.EqualError(t, err, ErrCallbackRegistered.Error())
+
+	// a channel may be registered for the other threads
+	ch := make(chan DIDCommAction, 1)
+	require.NoError(t, a.RegisterActionEvent(ch))
+
+	thread := NewDIDCommMsgMap(struct {
+		ID string `json:"@id"`
+	}{ID: "thread"})
+
+	other := NewDIDCommMsgMap(struct {
+		ID string `json:"@id"`
+	}{ID: "other"})
+
+	require.True(t, a.TriggerActionEvent(DIDCommAction{ProtocolName: "thread", Message: thread}))
+	require.True(t, a.TriggerActionEvent(DIDCommAction{ProtocolName: "other", Message: other}))
+	require.Equal(t, []string{"thread"}, received)
+	require.Equal(t, "other", (<-ch).ProtocolName)
+
+	// the events of the thread reach the channel once the callback is unregistered
+	unregister()
+	require.True(t, a.TriggerActionEvent(DIDCommAction{ProtocolName: "thread", Message: thread}))
+	require.Equal(t, "thread", (<-ch).ProtocolName)
+
+	require.NoError(t, a.UnregisterActionEvent(ch))
+	require.False(t, a.HasActionHandler())
+
+	// a message of an unclaimed thread isn't handled
+	_, err = a.RegisterThreadActionCallback("thread", func(DIDCommAction) {})
+	require.NoError(t, err)
+	require.False(t, a.TriggerActionEvent(DIDCommAction{Message: other}))
+}
@@ -8,12 +8,17 @@ package service
 
 // Service errors
 const (
-	ErrChannelRegistered = serviceError("channel is already registered for the action event")
-	ErrNilChannel        = serviceError("cannot pass nil channel")
-	ErrInvalidChannel    = serviceError("invalid channel passed to unregister the action event")
-	ErrThreadIDNotFound  = serviceError("threadID not found")
-	ErrInvalidMessage    = serviceError("invalid message")
-	ErrNilMessage        = serviceError("message is nil")
+	ErrChannelRegistered  = serviceError("channel is already registered for the action event")
+	ErrNilChannel         = serviceError("cannot pass nil channel")
+	ErrNilCallback        = serviceError("cannot pass nil callback")
+	ErrCallbackRegistered = serviceError("callback is already registered for the action event")
+	ErrInvalidChannel     = serviceError("invalid channel passed to unregister the action event")
+	ErrThreadIDNotFound   = serviceError("threadID not found")
+	ErrInvalidMessage     = serviceError("invalid message")
+	ErrNilMessage         = serviceError("message is nil")
+	ErrWaitTimeout        = serviceError("timeout waiting for a terminal state")
+	ErrSubscriptionClosed = serviceError("subscription is closed")
+	ErrStrictMode         = serviceError("message rejected by the strict mode")
 )
 
 // serviceError defines service error
@@ -72,6 +72,24 @@ type DIDCommAction struct {
 // EventProperties type for event related data.
 type EventProperties interface{}
 
+// EventPayloadVersion is the version of the properties of the events triggered by the protocol services
+// (DID exchange, present proof and issue credential). The applications opt into a newer version, the
+// properties of the previous versions are unchanged so the existing consumers are not broken.
+type EventPayloadVersion int
+
+const (
+	// EventPayloadV1 is the original version of the event properties (default), e.g the ID of the connection.
+	EventPayloadV1 EventPayloadVersion = iota + 1
+	// EventPayloadV2 enriches the properties of the events with the documents resolved or parsed by the services,
+	// e.g the DID document of the other agent or the received credentials and presentations.
+	EventPayloadV2
+)
+
+// VersionedEventProperties is implemented by the versioned event properties.
+type VersionedEventProperties interface {
+	PayloadVersion() EventPayloadVersion
+}
+
 // Event event related apis.
 type Event interface {
 	// RegisterActionEvent on protocol messages. The events are triggered for incoming message types based on
@@ -91,6 +109,22 @@ type Event interface {
 	UnregisterMsgEvent(ch chan<- StateMsg) error
 }
 
+// CallbackEvent event related apis based on callbacks rather than channels, implemented by the services
+// supporting them (present proof and issue credential). It avoids a goroutine consuming the channel of
+// each subscription, which is costly on the JS/WASM target.
+type CallbackEvent interface {
+	// RegisterActionCallback registers the callback invoked with the action events. Only one channel or
+	// callback can be registered for the action events. Refer RegisterActionEvent().
+	RegisterActionCallback(cb ActionCallback) error
+
+	// UnregisterActionCallback unregisters the action callback.
+	UnregisterActionCallback()
+
+	// RegisterMsgCallback registers a callback invoked with the state-change events of the protocol,
+	// it returns the function unregistering the callback. Refer RegisterMsgEvent().
+	RegisterMsgCallback(cb StateMsgCallback) (func(), error)
+}
+
 // AutoExecuteActionEvent is a utility function to execute Action events automatically. The function requires
 // a channel to be passed-in to listen to dispatcher.DIDCommAction and triggers the Continue function on the
 // action event. This is a blocking function and use this function with a goroutine.
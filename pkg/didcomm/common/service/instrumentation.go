@@ -0,0 +1,111 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+// Instrumentation is the instrumentation hook of the DIDComm messages and of the protocol services, e.g to export
+// counters and histograms per protocol to a monitoring system, or to trace a thread across the inbound transport,
+// the dispatcher and the state machine of the protocol (OpenTelemetry spans keyed by the thread ID).
+// The methods are called synchronously by the framework and the services, they should not block.
+type Instrumentation interface {
+	// MessageReceived reports an inbound message dispatched to the service of the protocol, or received by
+	// the inbound transport (the protocol is then the transport, e.g "http" or "ws"), see InstrumentInbound.
+	MessageReceived(protocol string, msg DIDCommMsg)
+	// MessageSent reports an outbound message sent by the service of the protocol.
+	MessageSent(protocol string, msg DIDCommMsg)
+	// StateTransition reports the transition of the protocol instance of the thread to the state.
+	StateTransition(protocol, threadID, state string)
+	// StartSpan starts a step of the processing of the thread, e.g the dispatching of an inbound message or
+	// the verification of a presentation. The returned function ends the step, with its error if it failed.
+	StartSpan(protocol, threadID, name string) func(err error)
+}
+
+// NoopInstrumentation is the Instrumentation used when none is configured, it does nothing.
+type NoopInstrumentation struct{}
+
+// MessageReceived does nothing.
+func (NoopInstrumentation) MessageReceived(string, DIDCommMsg) {}
+
+// MessageSent does nothing.
+func (NoopInstrumentation) MessageSent(string, DIDCommMsg) {}
+
+// StateTransition does nothing.
+func (NoopInstrumentation) StateTransition(_, _, _ string) {}
+
+// StartSpan returns a function doing nothing.
+func (NoopInstrumentation) StartSpan(_, _, _ string) func(error) {
+	return func(error) {}
+}
+
+// InstrumentInbound reports the unpacked message received by the inbound transport (e.g "http") and starts
+// the step of the transport handling it, in the thread of the message. The returned function ends the step
+// with the error of the inbound message handler. A message which is not a DIDComm message is not reported,
+// the inbound message handler rejects it.
+func InstrumentInbound(instrumentation Instrumentation, transport string, message []byte) func(err error) {
+	if instrumentation == nil {
+		return func(error) {}
+	}
+
+	msg, err := ParseDIDCommMsgMap(message)
+	if err != nil {
+		return func(error) {}
+	}
+
+	thID, _ := msg.ThreadID() //nolint:errcheck
+
+	instrumentation.MessageReceived(transport, msg)
+
+	return instrumentation.StartSpan(transport, thID, "inbound transport")
+}
+
+// InstrumentMessenger returns a Messenger reporting the messages sent by the service of the protocol
+// to the instrumentation.
+func InstrumentMessenger(messenger Messenger, protocol string, instrumentation Instrumentation) Messenger {
+	if _, ok := instrumentation.(NoopInstrumentation); ok {
+		return messenger
+	}
+
+	return &instrumentedMessenger{
+		Messenger:       messenger,
+		protocol:        protocol,
+		instrumentation: instrumentation,
+	}
+}
+
+// instrumentedMessenger reports the messages once sent, the messenger then has set their thread.
+type instrumentedMessenger struct {
+	Messenger
+	protocol        string
+	instrumentation Instrumentation
+}
+
+func (m *instrumentedMessenger) sent(msg DIDCommMsgMap, err error) error {
+	if err == nil {
+		m.instrumentation.MessageSent(m.protocol, msg)
+	}
+
+	return err
+}
+
+// ReplyTo replies to the message by given msgID and reports the reply.
+func (m *instrumentedMessenger) ReplyTo(msgID string, msg DIDCommMsgMap) error {
+	return m.sent(msg, m.Messenger.ReplyTo(msgID, msg))
+}
+
+// Send sends the message by starting a new thread and reports it.
+func (m *instrumentedMessenger) Send(msg DIDCommMsgMap, myDID, theirDID string) error {
+	return m.sent(msg, m.Messenger.Send(msg, myDID, theirDID))
+}
+
+// SendToDestination sends the message to given destination by starting a new thread and reports it.
+func (m *instrumentedMessenger) SendToDestination(msg DIDCommMsgMap, sender string, destination *Destination) error {
+	return m.sent(msg, m.Messenger.SendToDestination(msg, sender, destination))
+}
+
+// ReplyToNested sends the message by starting a new thread, child of the given thread, and reports it.
+func (m *instrumentedMessenger) ReplyToNested(threadID string, msg DIDCommMsgMap, myDID, theirDID string) error {
+	return m.sent(msg, m.Messenger.ReplyToNested(threadID, msg, myDID, theirDID))
+}
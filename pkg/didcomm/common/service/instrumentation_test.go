@@ -0,0 +1,120 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sentRecorder struct {
+	NoopInstrumentation
+	sent []string
+}
+
+func (r *sentRecorder) MessageSent(protocol string, msg DIDCommMsg) {
+	r.sent = append(r.sent, protocol+" "+msg.Type())
+}
+
+type stubMessenger struct {
+	err error
+}
+
+func (m *stubMessenger) ReplyTo(string, DIDCommMsgMap) error { return m.err }
+
+func (m *stubMessenger) Send(DIDCommMsgMap, string, string) error { return m.err }
+
+func (m *stubMessenger) SendToDestination(DIDCommMsgMap, string, *Destination) error { return m.err }
+
+func (m *stubMessenger) ReplyToNested(string, DIDCommMsgMap, string, string) error { return m.err }
+
+func TestInstrumentMessenger(t *testing.T) {
+	t.Run("noop instrumentation", func(t *testing.T) {
+		messenger := &stubMessenger{}
+		require.Equal(t, messenger, InstrumentMessenger(messenger, "protocol", NoopInstrumentation{}))
+	})
+
+	t.Run("reports the sent messages", func(t *testing.T) {
+		recorder := &sentRecorder{}
+		messenger := InstrumentMessenger(&stubMessenger{}, "protocol", recorder)
+
+		msg := DIDCommMsgMap{jsonType: "type"}
+
+		require.NoError(t, messenger.ReplyTo("ID", msg))
+		require.NoError(t, messenger.Send(msg, "myDID", "theirDID"))
+		require.NoError(t, messenger.SendToDestination(msg, "sender", &Destination{}))
+		require.NoError(t, messenger.ReplyToNested("thID", msg, "myDID", "theirDID"))
+
+		require.Equal(t, []string{"protocol type", "protocol type", "protocol type", "protocol type"}, recorder.sent)
+	})
+
+	t.Run("does not report the messages not sent", func(t *testing.T) {
+		expected := errors.New("test")
+		recorder := &sentRecorder{}
+		messenger := InstrumentMessenger(&stubMessenger{err: expected}, "protocol", recorder)
+
+		require.True(t, errors.Is(messenger.Send(DIDCommMsgMap{jsonType: "type"}, "myDID", "theirDID"), expected))
+		require.Empty(t, recorder.sent)
+	})
+}
+
+type inboundRecorder struct {
+	NoopInstrumentation
+	received []string
+	spans    []string
+}
+
+func (r *inboundRecorder) MessageReceived(protocol string, msg DIDCommMsg) {
+	r.received = append(r.received, protocol+" "+msg.Type())
+}
+
+func (r *inboundRecorder) StartSpan(protocol, threadID, name string) func(err error) {
+	return func(err error) {
+		r.spans = append(r.spans, protocol+" "+threadID+" "+name)
+
+		if err != nil {
+			r.spans = append(r.spans, err.Error())
+		}
+	}
+}
+
+func TestInstrumentInbound(t *testing.T) {
+	t.Run("reports the message and the step of the transport", func(t *testing.T) {
+		recorder := &inboundRecorder{}
+
+		endSpan := InstrumentInbound(recorder, "http",
+			[]byte(`{"@id":"ID","@type":"type","~thread":{"thid":"thID"}}`))
+		endSpan(errors.New("handler error"))
+
+		require.Equal(t, []string{"http type"}, recorder.received)
+		require.Equal(t, []string{"http thID inbound transport", "handler error"}, recorder.spans)
+	})
+
+	t.Run("not a DIDComm message", func(t *testing.T) {
+		recorder := &inboundRecorder{}
+
+		InstrumentInbound(recorder, "ws", []byte("data"))(nil)
+
+		require.Empty(t, recorder.received)
+		require.Empty(t, recorder.spans)
+	})
+
+	t.Run("no instrumentation", func(t *testing.T) {
+		InstrumentInbound(nil, "ws", []byte(`{"@id":"ID","@type":"type"}`))(nil)
+	})
+}
+
+func TestNoopInstrumentation(t *testing.T) {
+	var instrumentation Instrumentation = NoopInstrumentation{}
+
+	instrumentation.MessageReceived("protocol", DIDCommMsgMap{})
+	instrumentation.MessageSent("protocol", DIDCommMsgMap{})
+	instrumentation.StateTransition("protocol", "thID", "state")
+	instrumentation.StartSpan("protocol", "thID", "span")(errors.New("test"))
+}
@@ -8,17 +8,32 @@ package service
 
 import "sync"
 
+// StateMsgCallback handles the state-change events, see Message.RegisterMsgCallback.
+type StateMsgCallback func(StateMsg)
+
 // Message thread-safe message register structure
 type Message struct {
-	mu     sync.RWMutex
-	events []chan<- StateMsg
+	mu        sync.RWMutex
+	events    []*msgEvent
+	callbacks []*StateMsgCallback
+}
+
+// msgEvent is a registered channel, done is closed when the channel is unregistered to release
+// the events being sent to the channel (see TriggerMsgEvents).
+type msgEvent struct {
+	ch   chan<- StateMsg
+	done chan struct{}
 }
 
 // MsgEvents returns event message channels
 func (m *Message) MsgEvents() []chan<- StateMsg {
 	m.mu.RLock()
-	events := append(m.events[:0:0], m.events...)
-	m.mu.RUnlock()
+	defer m.mu.RUnlock()
+
+	var events []chan<- StateMsg
+	for _, e := range m.events {
+		events = append(events, e.ch)
+	}
 
 	return events
 }
@@ -31,17 +46,19 @@ func (m *Message) RegisterMsgEvent(ch chan<- StateMsg) error {
 	}
 
 	m.mu.Lock()
-	m.events = append(m.events, ch)
+	m.events = append(m.events, &msgEvent{ch: ch, done: make(chan struct{})})
 	m.mu.Unlock()
 
 	return nil
 }
 
 // UnregisterMsgEvent on protocol messages. Refer RegisterMsgEvent().
+// The events being sent to the channel are dropped, the channel no longer receives any event once unregistered.
 func (m *Message) UnregisterMsgEvent(ch chan<- StateMsg) error {
 	m.mu.Lock()
 	for i := 0; i < len(m.events); i++ {
-		if m.events[i] == ch {
+		if m.events[i].ch == ch {
+			close(m.events[i].done)
 			m.events = append(m.events[:i], m.events[i+1:]...)
 			i--
 		}
@@ -50,3 +67,53 @@ func (m *Message) UnregisterMsgEvent(ch chan<- StateMsg) error {
 
 	return nil
 }
+
+// RegisterMsgCallback registers a callback invoked with the state-change events, as an alternative to the channels
+// for the JS/WASM target (see Action.RegisterActionCallback). The callback must not block.
+// It returns the function unregistering the callback.
+// NOTE: the callbacks are supported by the services triggering the events with TriggerMsgEvents.
+func (m *Message) RegisterMsgCallback(cb StateMsgCallback) (func(), error) {
+	if cb == nil {
+		return nil, ErrNilCallback
+	}
+
+	// the pointer identifies the callback, functions are not comparable
+	ref := &cb
+
+	m.mu.Lock()
+	m.callbacks = append(m.callbacks, ref)
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		for i := range m.callbacks {
+			if m.callbacks[i] == ref {
+				m.callbacks = append(m.callbacks[:i], m.callbacks[i+1:]...)
+
+				return
+			}
+		}
+	}, nil
+}
+
+// TriggerMsgEvents sends the state-change event to the registered channels and invokes the registered callbacks.
+// A send blocked on a channel is released when the channel is unregistered.
+func (m *Message) TriggerMsgEvents(msg StateMsg) {
+	m.mu.RLock()
+	events := append(m.events[:0:0], m.events...)
+	callbacks := append(m.callbacks[:0:0], m.callbacks...)
+	m.mu.RUnlock()
+
+	for _, e := range events {
+		select {
+		case e.ch <- msg:
+		case <-e.done:
+		}
+	}
+
+	for _, cb := range callbacks {
+		(*cb)(msg)
+	}
+}
@@ -8,6 +8,7 @@ package service
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -51,3 +52,57 @@ func TestAction_UnregisterMsgEvent(t *testing.T) {
 	// no error if nothing to unregister
 	require.Nil(t, m.UnregisterMsgEvent(ch))
 }
+
+func TestMessage_TriggerMsgEvents_Unregister(t *testing.T) {
+	m := Message{}
+
+	ch := make(chan StateMsg)
+	require.NoError(t, m.RegisterMsgEvent(ch))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		// nobody reads the channel, the send is released by the unregistration
+		m.TriggerMsgEvents(StateMsg{StateID: "state"})
+	}()
+
+	require.NoError(t, m.UnregisterMsgEvent(ch))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("trigger is blocked on an unregistered channel")
+	}
+}
+
+func TestMessage_RegisterMsgCallback(t *testing.T) {
+	m := Message{}
+
+	_, err := m.RegisterMsgCallback(nil)
+	require.EqualError(t, err, ErrNilCallback.Error())
+
+	var first, second []string
+
+	unregisterFirst, err := m.RegisterMsgCallback(func(msg StateMsg) { first = append(first, msg.StateID) })
+	require.NoError(t, err)
+
+	_, err = m.RegisterMsgCallback(func(msg StateMsg) { second = append(second, msg.StateID) })
+	require.NoError(t, err)
+
+	ch := make(chan StateMsg, 2)
+	require.NoError(t, m.RegisterMsgEvent(ch))
+
+	m.TriggerMsgEvents(StateMsg{StateID: "request-sent"})
+
+	unregisterFirst()
+	unregisterFirst()
+
+	m.TriggerMsgEvents(StateMsg{StateID: "done"})
+
+	require.Equal(t, []string{"request-sent"}, first)
+	require.Equal(t, []string{"request-sent", "done"}, second)
+	require.Equal(t, "request-sent", (<-ch).StateID)
+	require.Equal(t, "done", (<-ch).StateID)
+}
@@ -0,0 +1,176 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// decoratorPrefix starts the name of the decorators, e.g ~thread, a field decorator is the name of the field
+// followed by the decorator (e.g connection~sig).
+const decoratorPrefix = "~"
+
+// standardDecorators are the decorators of the Aries RFCs understood by the framework.
+// nolint:gochecknoglobals
+var standardDecorators = map[string]struct{}{
+	"~ack":        {},
+	"~attach":     {},
+	"~l":          {},
+	"~l10n":       {},
+	"~please_ack": {},
+	"~purpose":    {},
+	"~service":    {},
+	"~sig":        {},
+	"~thread":     {},
+	"~timing":     {},
+	"~trace":      {},
+	"~transport":  {},
+}
+
+// threadFields are the fields of the ~thread decorator.
+// nolint:gochecknoglobals
+var threadFields = map[string]struct{}{
+	jsonThreadID:       {},
+	jsonParentThreadID: {},
+	"sender_order":     {},
+	"received_orders":  {},
+}
+
+// StrictMode is the strict parsing of the inbound messages: the messages carrying unknown decorators (an
+// unknown decorator is critical, the agent can not tell whether the message may be processed without
+// understanding it) or malformed threading are rejected before reaching the protocol services.
+// The zero value disables the strict mode.
+type StrictMode struct {
+	// Enabled enables the strict mode for all the protocols, except the ones disabled by Protocols.
+	Enabled bool
+	// Protocols overrides Enabled by name of the protocol service (e.g presentproof.Name).
+	Protocols map[string]bool
+	// Decorators are the decorators accepted in addition to the standard ones (e.g ~payment_receipt).
+	Decorators []string
+}
+
+// Enforced tells whether the strict mode applies to the messages of the protocol service.
+func (s *StrictMode) Enforced(protocol string) bool {
+	if s == nil {
+		return false
+	}
+
+	if enabled, ok := s.Protocols[protocol]; ok {
+		return enabled
+	}
+
+	return s.Enabled
+}
+
+// Check checks the message handled by the protocol service if the strict mode applies to the protocol.
+// The returned errors wrap ErrStrictMode.
+func (s *StrictMode) Check(protocol string, msg DIDCommMsgMap) error {
+	if !s.Enforced(protocol) {
+		return nil
+	}
+
+	if err := s.checkDecorators(msg); err != nil {
+		return fmt.Errorf("%w: %s", ErrStrictMode, err)
+	}
+
+	if err := checkThreading(msg); err != nil {
+		return fmt.Errorf("%w: %s", ErrStrictMode, err)
+	}
+
+	return nil
+}
+
+func (s *StrictMode) checkDecorators(msg DIDCommMsgMap) error {
+	for field := range msg {
+		i := strings.Index(field, decoratorPrefix)
+		if i < 0 {
+			continue
+		}
+
+		if decorator := field[i:]; !s.known(decorator) {
+			return fmt.Errorf("unknown decorator %s", decorator)
+		}
+	}
+
+	return nil
+}
+
+func (s *StrictMode) known(decorator string) bool {
+	if _, ok := standardDecorators[decorator]; ok {
+		return true
+	}
+
+	for _, d := range s.Decorators {
+		if d == decorator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkThreading checks the ID of the message and its ~thread decorator.
+func checkThreading(msg DIDCommMsgMap) error {
+	if id, ok := msg[jsonID].(string); !ok || id == "" {
+		return fmt.Errorf("malformed threading: %s must be a non-empty string", jsonID)
+	}
+
+	value, ok := msg[jsonThread]
+	if !ok {
+		return nil
+	}
+
+	thread, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("malformed threading: %s must be an object", jsonThread)
+	}
+
+	for field, v := range thread {
+		if _, ok := threadFields[field]; !ok {
+			return fmt.Errorf("malformed threading: unknown field %s.%s", jsonThread, field)
+		}
+
+		if !validThreadField(field, v) {
+			return fmt.Errorf("malformed threading: invalid %s.%s", jsonThread, field)
+		}
+	}
+
+	return nil
+}
+
+func validThreadField(field string, value interface{}) bool {
+	switch field {
+	case "sender_order":
+		return isOrder(value)
+	case "received_orders":
+		orders, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		for _, order := range orders {
+			if !isOrder(order) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		id, ok := value.(string)
+
+		return ok && id != ""
+	}
+}
+
+// isOrder checks the value is a non-negative integer, JSON numbers being decoded as float64.
+func isOrder(value interface{}) bool {
+	order, ok := value.(float64)
+
+	return ok && order >= 0 && order == math.Trunc(order)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictMode_Enforced(t *testing.T) {
+	var disabled *StrictMode
+	require.False(t, disabled.Enforced("present-proof"))
+	require.False(t, (&StrictMode{}).Enforced("present-proof"))
+
+	mode := &StrictMode{
+		Enabled:   true,
+		Protocols: map[string]bool{"introduce": false},
+	}
+	require.True(t, mode.Enforced("present-proof"))
+	require.False(t, mode.Enforced("introduce"))
+
+	mode = &StrictMode{Protocols: map[string]bool{"present-proof": true}}
+	require.True(t, mode.Enforced("present-proof"))
+	require.False(t, mode.Enforced("introduce"))
+}
+
+func TestStrictMode_Check(t *testing.T) {
+	mode := &StrictMode{
+		Enabled:    true,
+		Protocols:  map[string]bool{"lenient": false},
+		Decorators: []string{"~payment_receipt"},
+	}
+
+	tests := []struct {
+		name string
+		msg  string
+		err  string
+	}{
+		{
+			name: "Valid message",
+			msg: `{"@id": "id", "@type": "type", "~thread": {"thid": "thid", "pthid": "pthid", "sender_order": 1,
+				"received_orders": {"did:example:alice": 2}}, "~timing": {}, "connection~sig": {}}`,
+		},
+		{
+			name: "Accepted decorator",
+			msg:  `{"@id": "id", "~payment_receipt": {}}`,
+		},
+		{
+			name: "Unknown decorator",
+			msg:  `{"@id": "id", "~unknown": {}}`,
+			err:  "message rejected by the strict mode: unknown decorator ~unknown",
+		},
+		{
+			name: "Unknown field decorator",
+			msg:  `{"@id": "id", "offer~unknown": {}}`,
+			err:  "message rejected by the strict mode: unknown decorator ~unknown",
+		},
+		{
+			name: "No ID",
+			msg:  `{"~thread": {"thid": "thid"}}`,
+			err:  "message rejected by the strict mode: malformed threading: @id must be a non-empty string",
+		},
+		{
+			name: "Invalid thread",
+			msg:  `{"@id": "id", "~thread": "thid"}`,
+			err:  "message rejected by the strict mode: malformed threading: ~thread must be an object",
+		},
+		{
+			name: "Unknown thread field",
+			msg:  `{"@id": "id", "~thread": {"thid": "thid", "order": 1}}`,
+			err:  "message rejected by the strict mode: malformed threading: unknown field ~thread.order",
+		},
+		{
+			name: "Empty thread ID",
+			msg:  `{"@id": "id", "~thread": {"thid": ""}}`,
+			err:  "message rejected by the strict mode: malformed threading: invalid ~thread.thid",
+		},
+		{
+			name: "Invalid parent thread ID",
+			msg:  `{"@id": "id", "~thread": {"pthid": 1}}`,
+			err:  "message rejected by the strict mode: malformed threading: invalid ~thread.pthid",
+		},
+		{
+			name: "Invalid sender order",
+			msg:  `{"@id": "id", "~thread": {"sender_order": 1.5}}`,
+			err:  "message rejected by the strict mode: malformed threading: invalid ~thread.sender_order",
+		},
+		{
+			name: "Invalid received orders",
+			msg:  `{"@id": "id", "~thread": {"received_orders": {"did:example:alice": -1}}}`,
+			err:  "message rejected by the strict mode: malformed threading: invalid ~thread.received_orders",
+		},
+		{
+			name: "Received orders not an object",
+			msg:  `{"@id": "id", "~thread": {"received_orders": [1]}}`,
+			err:  "message rejected by the strict mode: malformed threading: invalid ~thread.received_orders",
+		},
+	}
+
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := ParseDIDCommMsgMap([]byte(tc.msg))
+			require.NoError(t, err)
+
+			err = mode.Check("present-proof", msg)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, tc.err)
+			require.True(t, errors.Is(err, ErrStrictMode))
+
+			// the strict mode is disabled for the protocol
+			require.NoError(t, mode.Check("lenient", msg))
+		})
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultSubscriptionBufferSize = 10
+
+// ThreadSubscription delivers the state-change events (see Event.RegisterMsgEvent) of a single thread, e.g
+// one credential or presentation exchange, so that applications don't have to filter the events of all threads.
+//
+// The events are queued by the subscription as soon as they are triggered by the protocol, the protocol is
+// never blocked by a slow subscriber and no event is lost: events are delivered in their order of occurrence
+// until the subscription is closed.
+type ThreadSubscription struct {
+	source         Event
+	threadID       string
+	filters        []func(StateMsg) bool
+	terminalStates map[string]struct{}
+	input          chan StateMsg
+	events         chan StateMsg
+	stop           chan struct{}
+	closeOnce      sync.Once
+}
+
+// SubscriptionOpt configures a thread subscription.
+type SubscriptionOpt func(s *ThreadSubscription)
+
+// WithSubscriptionBufferSize sets the size of the events channel of the subscription.
+func WithSubscriptionBufferSize(size int) SubscriptionOpt {
+	return func(s *ThreadSubscription) {
+		s.events = make(chan StateMsg, size)
+	}
+}
+
+// WithStateFilter only delivers the events for which the filter returns true, e.g to keep the events of
+// a given role (see presentproof.RoleFilter).
+func WithStateFilter(filter func(StateMsg) bool) SubscriptionOpt {
+	return func(s *ThreadSubscription) {
+		s.filters = append(s.filters, filter)
+	}
+}
+
+// WithTerminalStates overrides the states ending an exchange, waited by WaitForTerminalState.
+// Defaults to "done" and "abandoning", as well as "completed" and "abandoned" for the DID exchange.
+func WithTerminalStates(states ...string) SubscriptionOpt {
+	return func(s *ThreadSubscription) {
+		s.terminalStates = toSet(states)
+	}
+}
+
+// SubscribeThread subscribes to the state-change events of the given thread triggered by source
+// (a protocol service or client). The subscription must be closed once no longer used.
+func SubscribeThread(source Event, threadID string, opts ...SubscriptionOpt) (*ThreadSubscription, error) {
+	if threadID == "" {
+		return nil, errors.New("thread ID is mandatory")
+	}
+
+	s := &ThreadSubscription{
+		source:         source,
+		threadID:       threadID,
+		terminalStates: toSet([]string{"done", "abandoning", "completed", "abandoned"}),
+		input:          make(chan StateMsg),
+		events:         make(chan StateMsg, defaultSubscriptionBufferSize),
+		stop:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := source.RegisterMsgEvent(s.input); err != nil {
+		return nil, err
+	}
+
+	go s.listen()
+
+	return s, nil
+}
+
+// Events returns the channel delivering the events of the thread, it is closed when the subscription is closed.
+func (s *ThreadSubscription) Events() <-chan StateMsg {
+	return s.events
+}
+
+// WaitForTerminalState waits for the exchange to reach a terminal state and returns the post-state event
+// of that state. The events received meanwhile are consumed, they are no longer delivered by Events.
+func (s *ThreadSubscription) WaitForTerminalState(timeout time.Duration) (StateMsg, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-s.events:
+			if !ok {
+				return StateMsg{}, ErrSubscriptionClosed
+			}
+
+			if _, terminal := s.terminalStates[msg.StateID]; terminal && msg.Type == PostState {
+				return msg, nil
+			}
+		case <-timer.C:
+			return StateMsg{}, ErrWaitTimeout
+		}
+	}
+}
+
+// Close stops the subscription and closes the events channel. The subscription is unregistered first, the
+// events still being triggered for the subscription are then released by the source (see Message.TriggerMsgEvents).
+func (s *ThreadSubscription) Close() error {
+	err := s.source.UnregisterMsgEvent(s.input)
+
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+
+	return err
+}
+
+// listen queues the events of the thread until they are consumed.
+func (s *ThreadSubscription) listen() {
+	defer close(s.events)
+
+	var queue []StateMsg
+
+	for {
+		var (
+			out  chan StateMsg
+			next StateMsg
+		)
+
+		// delivers the queued events only if any
+		if len(queue) > 0 {
+			out, next = s.events, queue[0]
+		}
+
+		select {
+		case msg := <-s.input:
+			if s.accept(msg) {
+				queue = append(queue, msg)
+			}
+		case out <- next:
+			queue = queue[1:]
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *ThreadSubscription) accept(msg StateMsg) bool {
+	if msg.Msg == nil {
+		return false
+	}
+
+	thID, err := msg.Msg.ThreadID()
+	if err != nil || thID != s.threadID {
+		return false
+	}
+
+	for _, filter := range s.filters {
+		if !filter(msg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+
+	return set
+}
@@ -0,0 +1,205 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type eventSource struct {
+	Action
+	Message
+}
+
+// trigger sends the event the same way the protocols do
+func (e *eventSource) trigger(msg StateMsg) {
+	e.TriggerMsgEvents(msg)
+}
+
+type failingSource struct {
+	eventSource
+}
+
+func (f *failingSource) RegisterMsgEvent(chan<- StateMsg) error {
+	return errors.New("register error")
+}
+
+func stateMsg(thID, state string, msgType StateMsgType) StateMsg {
+	return StateMsg{
+		Type:    msgType,
+		StateID: state,
+		Msg: DIDCommMsgMap{
+			"@id":     "id-" + state,
+			"~thread": map[string]interface{}{"thid": thID},
+		},
+	}
+}
+
+func TestSubscribeThread(t *testing.T) {
+	t.Run("thread ID is mandatory", func(t *testing.T) {
+		_, err := SubscribeThread(&eventSource{}, "")
+		require.EqualError(t, err, "thread ID is mandatory")
+	})
+
+	t.Run("register error", func(t *testing.T) {
+		_, err := SubscribeThread(&failingSource{}, "thid")
+		require.EqualError(t, err, "register error")
+	})
+
+	t.Run("events of the thread only", func(t *testing.T) {
+		source := &eventSource{}
+
+		sub, err := SubscribeThread(source, "thid", WithSubscriptionBufferSize(0))
+		require.NoError(t, err)
+
+		// the protocol is not blocked even if the events are not consumed
+		source.trigger(stateMsg("thid", "request-sent", PreState))
+		source.trigger(stateMsg("other", "request-sent", PreState))
+		source.trigger(StateMsg{StateID: "no message"})
+		source.trigger(stateMsg("thid", "request-sent", PostState))
+
+		require.Equal(t, stateMsg("thid", "request-sent", PreState), <-sub.Events())
+		require.Equal(t, stateMsg("thid", "request-sent", PostState), <-sub.Events())
+
+		require.NoError(t, sub.Close())
+		require.NoError(t, sub.Close())
+
+		_, ok := <-sub.Events()
+		require.False(t, ok)
+		require.Empty(t, source.MsgEvents())
+	})
+
+	t.Run("state filter", func(t *testing.T) {
+		source := &eventSource{}
+
+		sub, err := SubscribeThread(source, "thid", WithStateFilter(func(msg StateMsg) bool {
+			return msg.Type == PostState
+		}))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, sub.Close()) }()
+
+		source.trigger(stateMsg("thid", "request-sent", PreState))
+		source.trigger(stateMsg("thid", "request-sent", PostState))
+
+		require.Equal(t, stateMsg("thid", "request-sent", PostState), <-sub.Events())
+	})
+}
+
+func TestThreadSubscription_WaitForTerminalState(t *testing.T) {
+	t.Run("terminal state reached", func(t *testing.T) {
+		source := &eventSource{}
+
+		sub, err := SubscribeThread(source, "thid")
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, sub.Close()) }()
+
+		go func() {
+			source.trigger(stateMsg("thid", "request-sent", PostState))
+			source.trigger(stateMsg("thid", "done", PreState))
+			source.trigger(stateMsg("thid", "done", PostState))
+		}()
+
+		msg, err := sub.WaitForTerminalState(time.Second)
+		require.NoError(t, err)
+		require.Equal(t, stateMsg("thid", "done", PostState), msg)
+	})
+
+	t.Run("custom terminal states", func(t *testing.T) {
+		source := &eventSource{}
+
+		sub, err := SubscribeThread(source, "thid", WithTerminalStates("presentation-received"))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, sub.Close()) }()
+
+		source.trigger(stateMsg("thid", "presentation-received", PostState))
+
+		msg, err := sub.WaitForTerminalState(time.Second)
+		require.NoError(t, err)
+		require.Equal(t, "presentation-received", msg.StateID)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		source := &eventSource{}
+
+		sub, err := SubscribeThread(source, "thid")
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, sub.Close()) }()
+
+		source.trigger(stateMsg("thid", "request-sent", PostState))
+
+		_, err = sub.WaitForTerminalState(10 * time.Millisecond)
+		require.True(t, errors.Is(err, ErrWaitTimeout))
+	})
+
+	t.Run("subscription closed", func(t *testing.T) {
+		source := &eventSource{}
+
+		sub, err := SubscribeThread(source, "thid")
+		require.NoError(t, err)
+		require.NoError(t, sub.Close())
+
+		_, err = sub.WaitForTerminalState(time.Second)
+		require.True(t, errors.Is(err, ErrSubscriptionClosed))
+	})
+}
+
+func TestThreadSubscription_CloseWhileTriggering(t *testing.T) {
+	const triggers = 50
+
+	for i := 0; i < 20; i++ {
+		source := &eventSource{}
+
+		sub, err := SubscribeThread(source, "thid")
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+
+		wg.Add(triggers + 1)
+
+		for j := 0; j < triggers; j++ {
+			go func(j int) {
+				defer wg.Done()
+
+				source.trigger(stateMsg("thid", fmt.Sprintf("state-%d", j), PostState))
+			}(j)
+		}
+
+		go func() {
+			defer wg.Done()
+
+			require.NoError(t, sub.Close())
+		}()
+
+		done := make(chan struct{})
+
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the events triggered while closing the subscription are blocked")
+		}
+
+		// the events queued before the close may still be delivered, then the channel is closed
+		for range sub.Events() {
+			continue
+		}
+	}
+}
@@ -36,3 +36,19 @@ type Packager interface {
 	// error: error
 	UnpackMessage(encMessage []byte) (*Envelope, error)
 }
+
+// MediaTypeProfile is a DIDComm media type profile accepted by an inbound transport (eg the V1 or V2 envelope).
+// It restricts the envelope encodings (as returned by packer.EncodingType()) used to unpack the messages received
+// with one of its media types, no restriction applies if EncodingTypes is empty.
+type MediaTypeProfile struct {
+	Name          string
+	MediaTypes    []string
+	EncodingTypes []string
+}
+
+// ProfileUnpacker is implemented by the packagers able to unpack a message for the media type profile matched
+// by the inbound transport.
+type ProfileUnpacker interface {
+	// UnpackMessageForProfile unpacks a message received for the given media type profile.
+	UnpackMessageForProfile(encMessage []byte, profile *MediaTypeProfile) (*Envelope, error)
+}
@@ -10,19 +10,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/btcsuite/btcutil/base58"
-	"github.com/google/uuid"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packdebug"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
 )
 
+const (
+	// every message is logged by the dispatcher: only the first messages of each second are logged,
+	// then one message out of logSampleThereafter
+	logSampleFirst      = 10
+	logSampleThereafter = 100
+)
+
+var logger = log.New("aries-framework/dispatcher").
+	WithSampling(log.NewSampler(logSampleFirst, logSampleThereafter, time.Second))
+
 // provider interface for outbound ctx
 type provider interface {
 	Packager() commontransport.Packager
@@ -30,6 +44,9 @@ type provider interface {
 	TransportReturnRoute() string
 	VDRIRegistry() vdri.Registry
 	LegacyKMS() legacykms.KeyManager
+	// PackDebug tells whether the envelopes of the outbound messages are recorded, see package packdebug.
+	PackDebug() bool
+	StorageProvider() storage.Provider
 }
 
 // OutboundDispatcher dispatch msgs to destination
@@ -39,17 +56,32 @@ type OutboundDispatcher struct {
 	transportReturnRoute string
 	vdRegistry           vdri.Registry
 	kms                  legacykms.KeyManager
+	packDebug            *packdebug.Recorder
+	idGenerator          idgen.Generator
 }
 
 // NewOutbound return new dispatcher outbound instance
 func NewOutbound(prov provider) *OutboundDispatcher {
-	return &OutboundDispatcher{
+	o := &OutboundDispatcher{
 		outboundTransports:   prov.OutboundTransports(),
 		packager:             prov.Packager(),
 		transportReturnRoute: prov.TransportReturnRoute(),
 		vdRegistry:           prov.VDRIRegistry(),
 		kms:                  prov.LegacyKMS(),
+		idGenerator:          idgen.FromProvider(prov),
+	}
+
+	if prov.PackDebug() {
+		recorder, err := packdebug.New(prov)
+		if err != nil {
+			// the messages are sent without being recorded
+			logger.Errorf("pack debug: %s", err)
+		}
+
+		o.packDebug = recorder
 	}
+
+	return o
 }
 
 // SendToDID sends a message from myDID to the agent who owns theirDID
@@ -107,16 +139,22 @@ func (o *OutboundDispatcher) Send(msg interface{}, senderVerKey string, des *ser
 		// set the return route option
 		des.TransportReturnRoute = o.transportReturnRoute
 
+		envelope := packedMsg
+
 		packedMsg, err = o.createForwardMessage(packedMsg, des)
 		if err != nil {
 			return fmt.Errorf("create forward msg : %w", err)
 		}
 
+		o.recordEnvelope(req, envelope, packedMsg, des)
+
 		_, err = v.Send(packedMsg, des)
 		if err != nil {
 			return fmt.Errorf("failed to send msg using outbound transport: %w", err)
 		}
 
+		logger.Debugf("message sent to %s", des.ServiceEndpoint)
+
 		return nil
 	}
 
@@ -148,6 +186,51 @@ func (o *OutboundDispatcher) Forward(msg interface{}, des *service.Destination)
 	return fmt.Errorf("no outbound transport found for serviceEndpoint: %s", des.ServiceEndpoint)
 }
 
+// recordEnvelope records the structure of the envelope of the message (and of the forward message, if the message
+// is routed) in the pack debug mode. A recording failure is logged only, the message is sent anyway.
+func (o *OutboundDispatcher) recordEnvelope(req, envelope, packedMsg []byte, des *service.Destination) {
+	if o.packDebug == nil {
+		return
+	}
+
+	msg := &struct {
+		ID   string `json:"@id"`
+		Type string `json:"@type"`
+	}{}
+
+	if err := json.Unmarshal(req, msg); err != nil || msg.ID == "" {
+		logger.Warnf("pack debug: message without ID")
+		return
+	}
+
+	record := &packdebug.Record{
+		MessageID:       msg.ID,
+		MessageType:     msg.Type,
+		ServiceEndpoint: des.ServiceEndpoint,
+		Packed:          time.Now().UTC(),
+	}
+
+	var err error
+
+	record.Envelope, err = packdebug.Introspect(envelope)
+	if err != nil {
+		logger.Warnf("pack debug: message %s: %s", msg.ID, err)
+		return
+	}
+
+	if len(des.RoutingKeys) != 0 {
+		record.Forward, err = packdebug.Introspect(packedMsg)
+		if err != nil {
+			logger.Warnf("pack debug: forward of message %s: %s", msg.ID, err)
+			return
+		}
+	}
+
+	if err = o.packDebug.Save(record); err != nil {
+		logger.Warnf("pack debug: message %s: %s", msg.ID, err)
+	}
+}
+
 func (o *OutboundDispatcher) createForwardMessage(msg []byte, des *service.Destination) ([]byte, error) {
 	if len(des.RoutingKeys) == 0 {
 		return msg, nil
@@ -162,7 +245,7 @@ func (o *OutboundDispatcher) createForwardMessage(msg []byte, des *service.Desti
 	// create forward message
 	forward := &model.Forward{
 		Type: service.ForwardMsgType,
-		ID:   uuid.New().String(),
+		ID:   o.idGenerator.NewID(),
 		To:   des.RecipientKeys[0],
 		Msg:  env,
 	}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package dispatcher
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,6 +19,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packdebug"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
@@ -25,7 +27,10 @@ import (
 	mockpackager "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/packager"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
 	mockdiddoc "github.com/hyperledger/aries-framework-go/pkg/mock/diddoc"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 )
 
 func TestOutboundDispatcher_Send(t *testing.T) {
@@ -250,6 +255,83 @@ func TestOutboundDispatcherTransportReturnRoute(t *testing.T) {
 	})
 }
 
+func TestOutboundDispatcher_PackDebug(t *testing.T) {
+	protected := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWM/1.0","alg":"Anoncrypt"}`))
+	packed := []byte(fmt.Sprintf(`{"protected":%q,"recipients":[{"header":{"kid":"abc"}}],"ciphertext":"Y3Q"}`,
+		protected))
+
+	msg := &struct {
+		ID   string `json:"@id"`
+		Type string `json:"@type"`
+	}{ID: uuid.New().String(), Type: "https://didcomm.org/basicmessage/1.0/message"}
+
+	newOutbound := func(storeProvider storage.Provider) *OutboundDispatcher {
+		return NewOutbound(&mockProvider{
+			packagerValue:           &mockpackager.Packager{PackValue: packed},
+			outboundTransportsValue: []transport.OutboundTransport{&mockdidcomm.MockOutboundTransport{AcceptValue: true}},
+			packDebug:               true,
+			storageProvider:         storeProvider,
+		})
+	}
+
+	t.Run("envelope recorded", func(t *testing.T) {
+		storeProvider := mem.NewProvider()
+
+		o := newOutbound(storeProvider)
+		require.NoError(t, o.Send(msg, "", &service.Destination{ServiceEndpoint: "url", RecipientKeys: []string{"abc"}}))
+
+		recorder, err := packdebug.New(&mockProvider{storageProvider: storeProvider})
+		require.NoError(t, err)
+
+		record, err := recorder.Record(msg.ID)
+		require.NoError(t, err)
+		require.Equal(t, msg.Type, record.MessageType)
+		require.Equal(t, "url", record.ServiceEndpoint)
+		require.Equal(t, &packdebug.Envelope{
+			Typ:        "JWM/1.0",
+			Alg:        "Anoncrypt",
+			Recipients: []packdebug.Recipient{{KID: "abc"}},
+			Size:       len(packed),
+		}, record.Envelope)
+		require.Nil(t, record.Forward)
+	})
+
+	t.Run("forward envelope recorded", func(t *testing.T) {
+		storeProvider := mem.NewProvider()
+
+		o := newOutbound(storeProvider)
+		require.NoError(t, o.Send(msg, "", &service.Destination{
+			ServiceEndpoint: "url",
+			RecipientKeys:   []string{"abc"},
+			RoutingKeys:     []string{"xyz"},
+		}))
+
+		recorder, err := packdebug.New(&mockProvider{storageProvider: storeProvider})
+		require.NoError(t, err)
+
+		record, err := recorder.Record(msg.ID)
+		require.NoError(t, err)
+		require.NotNil(t, record.Envelope)
+		require.NotNil(t, record.Forward)
+	})
+
+	t.Run("message sent when the envelope is not recorded", func(t *testing.T) {
+		o := newOutbound(&mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open store error")})
+		require.Nil(t, o.packDebug)
+		require.NoError(t, o.Send(msg, "", &service.Destination{ServiceEndpoint: "url"}))
+
+		o = newOutbound(mockstorage.NewCustomMockStoreProvider(&mockstorage.MockStore{
+			Store:  map[string][]byte{},
+			ErrPut: errors.New("put error"),
+		}))
+		require.NoError(t, o.Send(msg, "", &service.Destination{ServiceEndpoint: "url"}))
+		require.NoError(t, o.Send("data", "", &service.Destination{ServiceEndpoint: "url"}))
+
+		o.packager = &mockpackager.Packager{PackValue: []byte("{}")}
+		require.NoError(t, o.Send(msg, "", &service.Destination{ServiceEndpoint: "url"}))
+	})
+}
+
 func TestOutboundDispatcher_Forward(t *testing.T) {
 	t.Run("test forward - success", func(t *testing.T) {
 		o := NewOutbound(&mockProvider{
@@ -293,6 +375,8 @@ type mockProvider struct {
 	transportReturnRoute    string
 	vdriRegistry            vdri.Registry
 	legacyKMS               legacykms.KMS
+	packDebug               bool
+	storageProvider         storage.Provider
 }
 
 func (p *mockProvider) Packager() commontransport.Packager {
@@ -319,6 +403,14 @@ func (p *mockProvider) LegacyKMS() legacykms.KeyManager {
 	return &mockKMS{}
 }
 
+func (p *mockProvider) PackDebug() bool {
+	return p.packDebug
+}
+
+func (p *mockProvider) StorageProvider() storage.Provider {
+	return p.storageProvider
+}
+
 // mockOutboundTransport mock outbound transport
 type mockOutboundTransport struct {
 	expectedRequest string
@@ -384,6 +476,10 @@ func (m *mockKMS) DeriveKEK(alg, apu, fromKey, toPubKey []byte) ([]byte, error)
 	return nil, nil
 }
 
+func (m *mockKMS) DeriveSharedSecret(fromPubKey, toPubKey []byte) ([]byte, error) {
+	return nil, nil
+}
+
 func (m *mockKMS) GetEncryptionKey(verKey []byte) ([]byte, error) {
 	return nil, nil
 }
@@ -391,3 +487,7 @@ func (m *mockKMS) GetEncryptionKey(verKey []byte) ([]byte, error) {
 func (m *mockKMS) ConvertToEncryptionKey(key []byte) ([]byte, error) {
 	return nil, nil
 }
+
+func (m *mockKMS) DeleteKeySet(verKey string) error {
+	return nil
+}
@@ -11,8 +11,7 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
@@ -22,13 +21,15 @@ const (
 	// MessengerStore is messenger store name
 	MessengerStore = "messenger_store"
 
-	metadataKey = "metadata_%s"
+	metadataKey  = "metadata_%s"
+	fromPriorKey = "fromprior_%s_%s"
 
 	jsonID             = "@id"
 	jsonThread         = "~thread"
 	jsonThreadID       = "thid"
 	jsonParentThreadID = "pthid"
 	jsonMetadata       = "_internal_metadata"
+	jsonFromPrior      = "from_prior"
 )
 
 // record is an internal structure and keeps payload about inbound message
@@ -48,8 +49,9 @@ type Provider interface {
 
 // Messenger describes the messenger structure
 type Messenger struct {
-	store      storage.Store
-	dispatcher dispatcher.Outbound
+	store       storage.Store
+	dispatcher  dispatcher.Outbound
+	idGenerator idgen.Generator
 }
 
 // NewMessenger returns a new instance of the Messenger
@@ -60,8 +62,9 @@ func NewMessenger(ctx Provider) (*Messenger, error) {
 	}
 
 	return &Messenger{
-		store:      store,
-		dispatcher: ctx.OutboundDispatcher(),
+		store:       store,
+		dispatcher:  ctx.OutboundDispatcher(),
+		idGenerator: idgen.FromProvider(ctx),
 	}, nil
 }
 
@@ -84,6 +87,10 @@ func (m *Messenger) HandleInbound(msg service.DIDCommMsgMap, myDID, theirDID str
 		return fmt.Errorf("with metadata: %w", err)
 	}
 
+	if err := m.completeRotation(myDID, theirDID); err != nil {
+		return fmt.Errorf("complete DID rotation: %w", err)
+	}
+
 	// saves message payload
 	return m.saveRecord(msg.ID(), record{
 		ParentThreadID: msg.ParentThreadID(),
@@ -133,7 +140,7 @@ func (m *Messenger) populateMetadata(thID string, msg service.DIDCommMsgMap) err
 // Use ReplyTo function instead. It will keep ~thread decorator automatically.
 func (m *Messenger) Send(msg service.DIDCommMsgMap, myDID, theirDID string) error {
 	// fills missing fields
-	fillIfMissing(msg)
+	m.fillIfMissing(msg)
 
 	if err := m.saveMetadata(msg); err != nil {
 		return fmt.Errorf("save metadata: %w", err)
@@ -143,6 +150,10 @@ func (m *Messenger) Send(msg service.DIDCommMsgMap, myDID, theirDID string) erro
 		jsonThreadID: msg.ID(),
 	}
 
+	if err := m.addFromPrior(msg, myDID, theirDID); err != nil {
+		return fmt.Errorf("add from_prior: %w", err)
+	}
+
 	return m.dispatcher.SendToDID(msg, myDID, theirDID)
 }
 
@@ -152,7 +163,7 @@ func (m *Messenger) Send(msg service.DIDCommMsgMap, myDID, theirDID string) erro
 func (m *Messenger) SendToDestination(msg service.DIDCommMsgMap, sender string,
 	destination *service.Destination) error {
 	// fills missing fields
-	fillIfMissing(msg)
+	m.fillIfMissing(msg)
 
 	if err := m.saveMetadata(msg); err != nil {
 		return fmt.Errorf("save metadata: %w", err)
@@ -168,7 +179,7 @@ func (m *Messenger) SendToDestination(msg service.DIDCommMsgMap, sender string,
 // Do not provide a message with ~thread decorator. It will be rewritten.
 func (m *Messenger) ReplyTo(msgID string, msg service.DIDCommMsgMap) error {
 	// fills missing fields
-	fillIfMissing(msg)
+	m.fillIfMissing(msg)
 
 	rec, err := m.getRecord(msgID)
 	if err != nil {
@@ -191,6 +202,10 @@ func (m *Messenger) ReplyTo(msgID string, msg service.DIDCommMsgMap) error {
 		return fmt.Errorf("save metadata: %w", err)
 	}
 
+	if err := m.addFromPrior(msg, rec.MyDID, rec.TheirDID); err != nil {
+		return fmt.Errorf("add from_prior: %w", err)
+	}
+
 	return m.dispatcher.SendToDID(msg, rec.MyDID, rec.TheirDID)
 }
 
@@ -200,7 +215,7 @@ func (m *Messenger) ReplyTo(msgID string, msg service.DIDCommMsgMap) error {
 // NOTE: Given threadID becomes parent threadID.
 func (m *Messenger) ReplyToNested(threadID string, msg service.DIDCommMsgMap, myDID, theirDID string) error {
 	// fills missing fields
-	fillIfMissing(msg)
+	m.fillIfMissing(msg)
 
 	if err := m.saveMetadata(msg); err != nil {
 		return fmt.Errorf("save metadata: %w", err)
@@ -209,14 +224,65 @@ func (m *Messenger) ReplyToNested(threadID string, msg service.DIDCommMsgMap, my
 	// sets parent threadID
 	msg[jsonThread] = map[string]interface{}{jsonParentThreadID: threadID}
 
+	if err := m.addFromPrior(msg, myDID, theirDID); err != nil {
+		return fmt.Errorf("add from_prior: %w", err)
+	}
+
 	return m.dispatcher.SendToDID(msg, myDID, theirDID)
 }
 
+// SetFromPrior sets the from_prior JWT by which the agent rotated its DID of a connection to myDID (see
+// decorator.FromPrior): the JWT is added to the messages sent from myDID to theirDID until a message of theirDID
+// is received by myDID, i.e until the other agent follows the rotation.
+func (m *Messenger) SetFromPrior(myDID, theirDID, fromPrior string) error {
+	if err := m.store.Put(fmt.Sprintf(fromPriorKey, myDID, theirDID), []byte(fromPrior)); err != nil {
+		return fmt.Errorf("save from_prior: %w", err)
+	}
+
+	return nil
+}
+
+// addFromPrior adds the from_prior JWT of the rotated DID to the message, if the rotation is not complete.
+func (m *Messenger) addFromPrior(msg service.DIDCommMsgMap, myDID, theirDID string) error {
+	if _, ok := msg[jsonFromPrior]; ok {
+		return nil
+	}
+
+	fromPrior, err := m.store.Get(fmt.Sprintf(fromPriorKey, myDID, theirDID))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	msg[jsonFromPrior] = string(fromPrior)
+
+	return nil
+}
+
+// completeRotation removes the from_prior JWT of the rotated DID once the other agent sent a message to it.
+func (m *Messenger) completeRotation(myDID, theirDID string) error {
+	key := fmt.Sprintf(fromPriorKey, myDID, theirDID)
+
+	_, err := m.store.Get(key)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return m.store.Delete(key)
+}
+
 // fillIfMissing populates message with common fields such as ID
-func fillIfMissing(msg service.DIDCommMsgMap) {
+func (m *Messenger) fillIfMissing(msg service.DIDCommMsgMap) {
 	// if ID is empty we will create a new one
 	if msg.ID() == "" {
-		msg[jsonID] = uuid.New().String()
+		msg[jsonID] = m.idGenerator.NewID()
 	}
 }
 
@@ -14,12 +14,14 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	dispatcherMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/dispatcher"
 	messengerMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/messenger"
 	storageMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/storage"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 )
 
 const (
@@ -69,6 +71,7 @@ func TestMessenger_HandleInbound(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		store := storageMocks.NewMockStore(ctrl)
 		store.EXPECT().Put(ID, gomock.Any()).Return(nil)
+		expectNoRotation(store, myDID, theirDID)
 		store.EXPECT().Get(gomock.Any()).Return([]byte(`{}`), nil)
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
@@ -88,6 +91,7 @@ func TestMessenger_HandleInbound(t *testing.T) {
 	t.Run("success without metadata", func(t *testing.T) {
 		store := storageMocks.NewMockStore(ctrl)
 		store.EXPECT().Put(ID, gomock.Any()).Return(nil)
+		expectNoRotation(store, myDID, theirDID)
 		store.EXPECT().Get(gomock.Any()).Return(nil, storage.ErrDataNotFound)
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
@@ -143,6 +147,7 @@ func TestMessenger_HandleInbound(t *testing.T) {
 		store := storageMocks.NewMockStore(ctrl)
 		payload := []byte(`{"my_did":"myDID","their_did":"theirDID","thread_id":"thID","parent_thread_id":"pthID"}`)
 		store.EXPECT().Put(ID, payload).Return(nil)
+		expectNoRotation(store, myDID, theirDID)
 		store.EXPECT().Get(gomock.Any()).Return([]byte(`{"metadata":{"key":"val"}}`), nil)
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
@@ -167,6 +172,7 @@ func TestMessenger_HandleInbound(t *testing.T) {
 	t.Run("success with metadata (thread is nil)", func(t *testing.T) {
 		store := storageMocks.NewMockStore(ctrl)
 		store.EXPECT().Put(ID, gomock.Any()).Return(nil)
+		expectNoRotation(store, myDID, theirDID)
 		store.EXPECT().Get(gomock.Any()).Return([]byte(`{"metadata":{"key":"val"}}`), nil)
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
@@ -186,6 +192,11 @@ func TestMessenger_HandleInbound(t *testing.T) {
 	})
 }
 
+// expectNoRotation expects the lookup of the from_prior JWT of the DIDs, none is set.
+func expectNoRotation(store *storageMocks.MockStore, myDID, theirDID string) {
+	store.EXPECT().Get(fmt.Sprintf(fromPriorKey, myDID, theirDID)).Return(nil, storage.ErrDataNotFound)
+}
+
 func sendToDIDCheck(t *testing.T, checks ...string) func(msg service.DIDCommMsgMap, myDID, theirDID string) error {
 	return func(msg service.DIDCommMsgMap, myDID, theirDID string) error {
 		v := struct {
@@ -220,7 +231,10 @@ func TestMessenger_Send(t *testing.T) {
 
 	t.Run("send success", func(t *testing.T) {
 		storageProvider := storageMocks.NewMockProvider(ctrl)
-		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil)
+		store := storageMocks.NewMockStore(ctrl)
+		expectNoRotation(store, myDID, theirDID)
+
+		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
 
 		outbound := dispatcherMocks.NewMockOutbound(ctrl)
 		outbound.EXPECT().SendToDID(gomock.Any(), myDID, theirDID).
@@ -258,7 +272,10 @@ func TestMessenger_Send(t *testing.T) {
 
 	t.Run("success msg without id", func(t *testing.T) {
 		storageProvider := storageMocks.NewMockProvider(ctrl)
-		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil)
+		store := storageMocks.NewMockStore(ctrl)
+		expectNoRotation(store, myDID, theirDID)
+
+		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
 
 		outbound := dispatcherMocks.NewMockOutbound(ctrl)
 		outbound.EXPECT().SendToDID(gomock.Any(), myDID, theirDID).
@@ -302,6 +319,45 @@ func TestMessenger_Send(t *testing.T) {
 	})
 }
 
+// idProvider adds the generator of the identifiers to the provider of the messenger
+type idProvider struct {
+	*messengerMocks.MockProvider
+	generator idgen.Generator
+}
+
+func (p *idProvider) IDGenerator() idgen.Generator {
+	return p.generator
+}
+
+func TestMessenger_IDGenerator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageProvider := storageMocks.NewMockProvider(ctrl)
+	store := storageMocks.NewMockStore(ctrl)
+	expectNoRotation(store, myDID, theirDID)
+
+	storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
+
+	outbound := dispatcherMocks.NewMockOutbound(ctrl)
+	outbound.EXPECT().SendToDID(gomock.Any(), myDID, theirDID).
+		Do(func(msg interface{}, _, _ string) {
+			require.Equal(t, "generated-id", msg.(service.DIDCommMsgMap).ID())
+		})
+
+	provider := messengerMocks.NewMockProvider(ctrl)
+	provider.EXPECT().StorageProvider().Return(storageProvider)
+	provider.EXPECT().OutboundDispatcher().Return(outbound)
+
+	msgr, err := NewMessenger(&idProvider{
+		MockProvider: provider,
+		generator:    idgen.GeneratorFunc(func() string { return "generated-id" }),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, msgr.Send(service.DIDCommMsgMap{}, myDID, theirDID))
+}
+
 func TestMessenger_ReplyTo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -309,6 +365,7 @@ func TestMessenger_ReplyTo(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		store := storageMocks.NewMockStore(ctrl)
 		store.EXPECT().Get(ID).Return([]byte(`{"thread_id":"thID","parent_thread_id":"pthID"}`), nil)
+		expectNoRotation(store, "", "")
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
 		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
@@ -349,6 +406,7 @@ func TestMessenger_ReplyTo(t *testing.T) {
 	t.Run("success msg without id", func(t *testing.T) {
 		store := storageMocks.NewMockStore(ctrl)
 		store.EXPECT().Get(ID).Return([]byte(`{"thread_id":"thID"}`), nil)
+		expectNoRotation(store, "", "")
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
 		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
@@ -399,7 +457,10 @@ func TestMessenger_ReplyToNested(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		storageProvider := storageMocks.NewMockProvider(ctrl)
-		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil)
+		store := storageMocks.NewMockStore(ctrl)
+		expectNoRotation(store, myDID, theirDID)
+
+		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
 
 		outbound := dispatcherMocks.NewMockOutbound(ctrl)
 		outbound.EXPECT().SendToDID(gomock.Any(), gomock.Any(), gomock.Any()).
@@ -417,7 +478,10 @@ func TestMessenger_ReplyToNested(t *testing.T) {
 
 	t.Run("success msg without id", func(t *testing.T) {
 		storageProvider := storageMocks.NewMockProvider(ctrl)
-		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil)
+		store := storageMocks.NewMockStore(ctrl)
+		expectNoRotation(store, myDID, theirDID)
+
+		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
 
 		outbound := dispatcherMocks.NewMockOutbound(ctrl)
 		outbound.EXPECT().SendToDID(gomock.Any(), gomock.Any(), gomock.Any()).
@@ -455,3 +519,69 @@ func TestMessenger_ReplyToNested(t *testing.T) {
 		require.Contains(t, fmt.Sprintf("%v", err), errMsg)
 	})
 }
+
+func TestMessenger_FromPrior(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var sent []service.DIDCommMsgMap
+
+	outbound := dispatcherMocks.NewMockOutbound(ctrl)
+	outbound.EXPECT().SendToDID(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(msg interface{}, _, _ string) {
+			sent = append(sent, msg.(service.DIDCommMsgMap))
+		}).AnyTimes()
+
+	provider := messengerMocks.NewMockProvider(ctrl)
+	provider.EXPECT().StorageProvider().Return(mem.NewProvider())
+	provider.EXPECT().OutboundDispatcher().Return(outbound)
+
+	msgr, err := NewMessenger(provider)
+	require.NoError(t, err)
+
+	require.NoError(t, msgr.SetFromPrior(myDID, theirDID, "from-prior-jwt"))
+
+	// the JWT is added to the messages sent from the rotated DID to the other agent
+	require.NoError(t, msgr.Send(service.DIDCommMsgMap{}, myDID, theirDID))
+	require.NoError(t, msgr.ReplyToNested("thID", service.DIDCommMsgMap{}, myDID, theirDID))
+	require.NoError(t, msgr.Send(service.DIDCommMsgMap{}, myDID, "did:example:other"))
+
+	require.Len(t, sent, 3)
+	require.Equal(t, "from-prior-jwt", sent[0][jsonFromPrior])
+	require.Equal(t, "from-prior-jwt", sent[1][jsonFromPrior])
+	require.NotContains(t, sent[2], jsonFromPrior)
+
+	// the JWT set on the message is kept
+	require.NoError(t, msgr.Send(service.DIDCommMsgMap{jsonFromPrior: "other-jwt"}, myDID, theirDID))
+	require.Equal(t, "other-jwt", sent[3][jsonFromPrior])
+
+	// the other agent sent a message to the rotated DID, the rotation is complete
+	require.NoError(t, msgr.HandleInbound(service.DIDCommMsgMap{jsonID: ID}, myDID, theirDID))
+	require.NoError(t, msgr.ReplyTo(ID, service.DIDCommMsgMap{}))
+	require.NotContains(t, sent[4], jsonFromPrior)
+
+	t.Run("store error", func(t *testing.T) {
+		store := storageMocks.NewMockStore(ctrl)
+		store.EXPECT().Get(fmt.Sprintf(fromPriorKey, myDID, theirDID)).Return(nil, errors.New(errMsg)).Times(2)
+		store.EXPECT().Put(fmt.Sprintf(fromPriorKey, myDID, theirDID), gomock.Any()).Return(errors.New(errMsg))
+
+		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
+
+		provider := messengerMocks.NewMockProvider(ctrl)
+		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().OutboundDispatcher().Return(nil)
+
+		msgr, err := NewMessenger(provider)
+		require.NoError(t, err)
+
+		err = msgr.SetFromPrior(myDID, theirDID, "from-prior-jwt")
+		require.EqualError(t, err, "save from_prior: "+errMsg)
+
+		err = msgr.Send(service.DIDCommMsgMap{}, myDID, theirDID)
+		require.EqualError(t, err, "add from_prior: "+errMsg)
+
+		err = msgr.ReplyToNested("thID", service.DIDCommMsgMap{}, myDID, theirDID)
+		require.EqualError(t, err, "add from_prior: "+errMsg)
+	})
+}
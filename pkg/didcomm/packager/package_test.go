@@ -8,6 +8,7 @@ package packager_test
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -18,6 +19,7 @@ import (
 	. "github.com/hyperledger/aries-framework-go/pkg/didcomm/packager"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
 	jwe "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/authcrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/ecdh1pu"
 	legacy "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/authcrypt"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm"
@@ -340,6 +342,169 @@ func TestBaseKMSInPackager_UnpackMessage(t *testing.T) {
 	})
 }
 
+func TestPackager_EnvelopeNegotiation(t *testing.T) {
+	w, err := legacykms.New(newMockKMSProvider(mockstorage.NewMockStoreProvider()))
+	require.NoError(t, err)
+
+	mockedProviders := &mockProvider{
+		storage: mockstorage.NewMockStoreProvider(),
+		kms:     w,
+	}
+
+	legacyPacker := legacy.New(mockedProviders)
+	v2Packer, err := ecdh1pu.New(mockedProviders, ecdh1pu.A256GCM)
+	require.NoError(t, err)
+
+	// alice uses legacy envelopes by default but supports DIDComm V2 envelopes
+	mockedProviders.primaryPacker = legacyPacker
+	mockedProviders.packers = []packer.Packer{v2Packer}
+	alice, err := New(mockedProviders)
+	require.NoError(t, err)
+
+	// bob uses DIDComm V2 envelopes
+	bob, err := New(&mockProvider{
+		storage:       mockstorage.NewMockStoreProvider(),
+		kms:           w,
+		primaryPacker: v2Packer,
+	})
+	require.NoError(t, err)
+
+	_, aliceVerKey, err := w.CreateKeySet()
+	require.NoError(t, err)
+
+	_, bobVerKey, err := w.CreateKeySet()
+	require.NoError(t, err)
+
+	t.Run("primary packer is used when no encoding was negotiated", func(t *testing.T) {
+		packMsg, e := alice.PackMessage(&transport.Envelope{Message: []byte("msg1"),
+			FromVerKey: base58.Decode(aliceVerKey),
+			ToVerKeys:  []string{bobVerKey}})
+		require.NoError(t, e)
+		require.Equal(t, legacyPacker.EncodingType(), encodingType(t, packMsg))
+	})
+
+	t.Run("reply uses the envelope encoding of the peer", func(t *testing.T) {
+		packMsg, e := bob.PackMessage(&transport.Envelope{Message: []byte("msg1"),
+			FromVerKey: base58.Decode(bobVerKey),
+			ToVerKeys:  []string{aliceVerKey}})
+		require.NoError(t, e)
+		require.Equal(t, ecdh1pu.EncodingType, encodingType(t, packMsg))
+
+		unpackedMsg, e := alice.UnpackMessage(packMsg)
+		require.NoError(t, e)
+		require.Equal(t, []byte("msg1"), unpackedMsg.Message)
+
+		packMsg, e = alice.PackMessage(&transport.Envelope{Message: []byte("msg2"),
+			FromVerKey: base58.Decode(aliceVerKey),
+			ToVerKeys:  []string{bobVerKey}})
+		require.NoError(t, e)
+		require.Equal(t, ecdh1pu.EncodingType, encodingType(t, packMsg))
+
+		unpackedMsg, e = bob.UnpackMessage(packMsg)
+		require.NoError(t, e)
+		require.Equal(t, []byte("msg2"), unpackedMsg.Message)
+	})
+
+	t.Run("envelope encoding saved only when it changes", func(t *testing.T) {
+		packMsg, e := bob.PackMessage(&transport.Envelope{Message: []byte("msg1"),
+			FromVerKey: base58.Decode(bobVerKey),
+			ToVerKeys:  []string{aliceVerKey}})
+		require.NoError(t, e)
+
+		// the encoding of bob was already saved
+		mockedProviders.storage.Store.ErrPut = fmt.Errorf("put error")
+		defer func() { mockedProviders.storage.Store.ErrPut = nil }()
+
+		unpackedMsg, e := alice.UnpackMessage(packMsg)
+		require.NoError(t, e)
+		require.Equal(t, []byte("msg1"), unpackedMsg.Message)
+
+		// bob switches to the legacy encoding
+		legacyMsg, e := legacyPacker.Pack([]byte("msg3"), base58.Decode(bobVerKey), [][]byte{base58.Decode(aliceVerKey)})
+		require.NoError(t, e)
+
+		_, e = alice.UnpackMessage(legacyMsg)
+		require.EqualError(t, e, "failed to save envelope encoding: put error")
+	})
+
+	t.Run("set envelope encoding", func(t *testing.T) {
+		_, carolVerKey, e := w.CreateKeySet()
+		require.NoError(t, e)
+
+		require.True(t, alice.SupportsEnvelopeEncoding(ecdh1pu.EncodingType))
+		require.False(t, alice.SupportsEnvelopeEncoding("unknown"))
+		require.NoError(t, alice.SetEnvelopeEncoding(carolVerKey, ecdh1pu.EncodingType))
+
+		packMsg, e := alice.PackMessage(&transport.Envelope{Message: []byte("msg1"),
+			FromVerKey: base58.Decode(aliceVerKey),
+			ToVerKeys:  []string{carolVerKey}})
+		require.NoError(t, e)
+		require.Equal(t, ecdh1pu.EncodingType, encodingType(t, packMsg))
+
+		e = alice.SetEnvelopeEncoding(carolVerKey, "unknown")
+		require.EqualError(t, e, "envelope encoding not supported: unknown")
+	})
+
+	t.Run("unpack for media type profile", func(t *testing.T) {
+		packMsg, e := bob.PackMessage(&transport.Envelope{Message: []byte("msg1"),
+			FromVerKey: base58.Decode(bobVerKey),
+			ToVerKeys:  []string{aliceVerKey}})
+		require.NoError(t, e)
+
+		v2Profile := &transport.MediaTypeProfile{Name: "didcomm/v2",
+			EncodingTypes: []string{ecdh1pu.EncodingType}}
+
+		unpackedMsg, e := alice.UnpackMessageForProfile(packMsg, v2Profile)
+		require.NoError(t, e)
+		require.Equal(t, []byte("msg1"), unpackedMsg.Message)
+
+		v1Profile := &transport.MediaTypeProfile{Name: "didcomm/v1",
+			EncodingTypes: []string{legacyPacker.EncodingType()}}
+
+		_, e = alice.UnpackMessageForProfile(packMsg, v1Profile)
+		require.EqualError(t, e, "envelope encoding "+ecdh1pu.EncodingType+
+			" not accepted by media type profile didcomm/v1")
+
+		_, e = alice.UnpackMessageForProfile(nil, v1Profile)
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "getEncodingType")
+	})
+
+	t.Run("open envelope store error", func(t *testing.T) {
+		_, e := New(&mockProvider{
+			storage: &mockstorage.MockStoreProvider{
+				Store:         &mockstorage.MockStore{Store: map[string][]byte{}},
+				FailNamespace: EnvelopeStoreName,
+			},
+			kms:           w,
+			primaryPacker: legacyPacker,
+		})
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "failed to open envelope store")
+	})
+}
+
+func encodingType(t *testing.T, packMsg []byte) string {
+	env := struct {
+		Protected string `json:"protected"`
+	}{}
+	require.NoError(t, json.Unmarshal(packMsg, &env))
+
+	protected, err := base64.URLEncoding.DecodeString(env.Protected)
+	if err != nil {
+		protected, err = base64.RawURLEncoding.DecodeString(env.Protected)
+	}
+
+	require.NoError(t, err)
+
+	header := struct {
+		Typ string `json:"typ"`
+	}{}
+	require.NoError(t, json.Unmarshal(protected, &header))
+
+	return header.Typ
+}
+
 func newMockKMSProvider(storagePvdr *mockstorage.MockStoreProvider) *mockProvider {
 	return &mockProvider{storagePvdr, nil, nil, nil, nil}
 }
@@ -32,11 +32,19 @@ type Provider interface {
 // Creator method to create new packager service
 type Creator func(prov Provider) (transport.Packager, error)
 
+const (
+	// EnvelopeStoreName is the name of the store keeping the envelope encoding negotiated with each peer key.
+	EnvelopeStoreName = "packagerenvelope"
+
+	envelopeKeyPrefix = "envelope_"
+)
+
 // Packager is the basic implementation of Packager
 type Packager struct {
 	primaryPacker   packer.Packer
 	packers         map[string]packer.Packer
 	connectionStore *did.ConnectionStore
+	envelopeStore   storage.Store
 }
 
 // PackerCreator holds a creator function for a Packer and the name of the Packer's encoding method.
@@ -52,10 +60,16 @@ func New(ctx Provider) (*Packager, error) {
 		return nil, fmt.Errorf("failed to create new packager: %w", err)
 	}
 
+	envelopeStore, err := ctx.StorageProvider().OpenStore(EnvelopeStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open envelope store: %w", err)
+	}
+
 	basePackager := Packager{
 		primaryPacker:   nil,
 		packers:         map[string]packer.Packer{},
 		connectionStore: didConnStore,
+		envelopeStore:   envelopeStore,
 	}
 
 	for _, packerType := range ctx.Packers() {
@@ -78,6 +92,43 @@ func (bp *Packager) addPacker(pack packer.Packer) {
 	}
 }
 
+// SetEnvelopeEncoding sets the envelope encoding (as returned by packer.EncodingType()) to be used
+// when packing messages for the given base58 encoded recipient key. It can be used to select the DIDComm
+// envelope version of a connection before any message was received from the peer.
+func (bp *Packager) SetEnvelopeEncoding(verKey, encodingType string) error {
+	if !bp.SupportsEnvelopeEncoding(encodingType) {
+		return fmt.Errorf("envelope encoding not supported: %s", encodingType)
+	}
+
+	return bp.envelopeStore.Put(envelopeKeyPrefix+verKey, []byte(encodingType))
+}
+
+// SupportsEnvelopeEncoding returns true if a packer of the packager packs the envelope encoding
+// (as returned by packer.EncodingType()).
+func (bp *Packager) SupportsEnvelopeEncoding(encodingType string) bool {
+	_, ok := bp.packers[encodingType]
+
+	return ok
+}
+
+// packerFor returns the packer negotiated with the recipients, an envelope encoding is negotiated with a peer
+// key once a message packed with that encoding was received from it. The primary packer is used otherwise.
+func (bp *Packager) packerFor(verKeys []string) packer.Packer {
+	for _, verKey := range verKeys {
+		// a lookup failure is not fatal, the primary packer is used instead
+		encType, err := bp.envelopeStore.Get(envelopeKeyPrefix + verKey)
+		if err != nil {
+			continue
+		}
+
+		if p, ok := bp.packers[string(encType)]; ok {
+			return p
+		}
+	}
+
+	return bp.primaryPacker
+}
+
 // PackMessage Pack a message for one or more recipients.
 func (bp *Packager) PackMessage(messageEnvelope *transport.Envelope) ([]byte, error) {
 	if messageEnvelope == nil {
@@ -98,7 +149,9 @@ func (bp *Packager) PackMessage(messageEnvelope *transport.Envelope) ([]byte, er
 		recipients = append(recipients, verKeyBytes)
 	}
 	// pack message
-	bytes, err := bp.primaryPacker.Pack(messageEnvelope.Message, messageEnvelope.FromVerKey, recipients)
+	p := bp.packerFor(messageEnvelope.ToVerKeys)
+
+	bytes, err := p.Pack(messageEnvelope.Message, messageEnvelope.FromVerKey, recipients)
 	if err != nil {
 		return nil, fmt.Errorf("pack: %w", err)
 	}
@@ -153,6 +206,36 @@ func (bp *Packager) UnpackMessage(encMessage []byte) (*transport.Envelope, error
 		return nil, fmt.Errorf("getEncodingType: %w", err)
 	}
 
+	return bp.unpack(encMessage, encType)
+}
+
+// UnpackMessageForProfile unpacks a message received for the media type profile matched by the inbound transport,
+// the message is rejected if its envelope encoding is not accepted by the profile.
+func (bp *Packager) UnpackMessageForProfile(encMessage []byte,
+	profile *transport.MediaTypeProfile) (*transport.Envelope, error) {
+	encType, err := getEncodingType(encMessage)
+	if err != nil {
+		return nil, fmt.Errorf("getEncodingType: %w", err)
+	}
+
+	if profile != nil && len(profile.EncodingTypes) > 0 && !contains(profile.EncodingTypes, encType) {
+		return nil, fmt.Errorf("envelope encoding %s not accepted by media type profile %s", encType, profile.Name)
+	}
+
+	return bp.unpack(encMessage, encType)
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (bp *Packager) unpack(encMessage []byte, encType string) (*transport.Envelope, error) {
 	p, ok := bp.packers[encType]
 	if !ok {
 		return nil, fmt.Errorf("message Type not recognized")
@@ -180,5 +263,24 @@ func (bp *Packager) UnpackMessage(encMessage []byte) (*transport.Envelope, error
 	envelope.ToDID = myDID
 	envelope.FromDID = theirDID
 
+	// reply to the peer using the same envelope encoding
+	if len(envelope.FromVerKey) != 0 {
+		err = bp.saveEnvelopeEncoding(base58.Encode(envelope.FromVerKey), encType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save envelope encoding: %w", err)
+		}
+	}
+
 	return envelope, nil
 }
+
+// saveEnvelopeEncoding saves the envelope encoding of the peer key, the store is only written when the encoding
+// changes (most messages are received with the encoding already negotiated).
+func (bp *Packager) saveEnvelopeEncoding(verKey, encType string) error {
+	saved, err := bp.envelopeStore.Get(envelopeKeyPrefix + verKey)
+	if err == nil && string(saved) == encType {
+		return nil
+	}
+
+	return bp.envelopeStore.Put(envelopeKeyPrefix+verKey, []byte(encType))
+}
@@ -0,0 +1,183 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package packdebug
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// StoreName is the name of the store of the outbound envelope records.
+	StoreName = "packdebug"
+
+	recordKey = "record_%s"
+)
+
+// ErrRecordNotFound is returned when no envelope was recorded for the message ID.
+var ErrRecordNotFound = errors.New("envelope record not found")
+
+// Envelope is the structure of a packed envelope. It holds the headers and the key IDs of the recipients only:
+// neither the content encryption key, the encrypted keys nor the plaintext are recorded.
+type Envelope struct {
+	// Typ is the envelope encoding, e.g "JWM/1.0".
+	Typ string `json:"typ,omitempty"`
+	// Alg is the key wrapping algorithm.
+	Alg string `json:"alg,omitempty"`
+	// Enc is the content encryption algorithm.
+	Enc string `json:"enc,omitempty"`
+	// SKID is the key ID of the sender, if it is not encrypted.
+	SKID string `json:"skid,omitempty"`
+	// Recipients are the recipients of the envelope.
+	Recipients []Recipient `json:"recipients,omitempty"`
+	// Size is the size of the envelope, in bytes.
+	Size int `json:"size"`
+}
+
+// Recipient is a recipient of a packed envelope.
+type Recipient struct {
+	// KID is the key ID of the recipient.
+	KID string `json:"kid,omitempty"`
+	// Alg is the key wrapping algorithm of the recipient, if it is set in the recipient header.
+	Alg string `json:"alg,omitempty"`
+	// Sender is true if the (encrypted) sender key is set for the recipient (legacy authcrypt).
+	Sender bool `json:"sender,omitempty"`
+}
+
+// Record is the record of the envelopes of an outbound message.
+type Record struct {
+	// MessageID is the ID of the outbound message.
+	MessageID string `json:"message_id"`
+	// MessageType is the type of the outbound message.
+	MessageType string `json:"message_type,omitempty"`
+	// ServiceEndpoint is the service endpoint the message was sent to.
+	ServiceEndpoint string `json:"service_endpoint,omitempty"`
+	// Envelope is the envelope of the message for its recipients.
+	Envelope *Envelope `json:"envelope"`
+	// Forward is the envelope of the forward message for the routing keys, if the message is routed.
+	Forward *Envelope `json:"forward,omitempty"`
+	// Packed is the time the message was packed.
+	Packed time.Time `json:"packed"`
+}
+
+// Provider contains dependencies for the pack debug records and is typically created by using aries.Context()
+type Provider interface {
+	StorageProvider() storage.Provider
+}
+
+// Recorder records the structure of the envelopes of the outbound messages by message ID, to troubleshoot
+// the messages a peer fails to decrypt (e.g an unexpected recipient key or algorithm).
+type Recorder struct {
+	store storage.Store
+}
+
+// New returns the recorder of the outbound envelopes.
+func New(prov Provider) (*Recorder, error) {
+	store, err := prov.StorageProvider().OpenStore(StoreName)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	return &Recorder{store: store}, nil
+}
+
+// Save saves the record of the envelopes of an outbound message.
+func (r *Recorder) Save(record *Record) error {
+	src, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	if err := r.store.Put(fmt.Sprintf(recordKey, record.MessageID), src); err != nil {
+		return fmt.Errorf("save record: %w", err)
+	}
+
+	return nil
+}
+
+// Record returns the record of the envelopes of the outbound message with the given ID.
+func (r *Recorder) Record(msgID string) (*Record, error) {
+	src, err := r.store.Get(fmt.Sprintf(recordKey, msgID))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, ErrRecordNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get record: %w", err)
+	}
+
+	var record *Record
+	if err := json.Unmarshal(src, &record); err != nil {
+		return nil, fmt.Errorf("unmarshal record: %w", err)
+	}
+
+	return record, nil
+}
+
+type envelopeStub struct {
+	Protected  string          `json:"protected,omitempty"`
+	Recipients []recipientStub `json:"recipients,omitempty"`
+}
+
+type headerStub struct {
+	Typ        string          `json:"typ,omitempty"`
+	Alg        string          `json:"alg,omitempty"`
+	Enc        string          `json:"enc,omitempty"`
+	SKID       string          `json:"skid,omitempty"`
+	Recipients []recipientStub `json:"recipients,omitempty"`
+}
+
+type recipientStub struct {
+	Header struct {
+		KID    string `json:"kid,omitempty"`
+		Alg    string `json:"alg,omitempty"`
+		Sender string `json:"sender,omitempty"`
+	} `json:"header,omitempty"`
+}
+
+// Introspect returns the structure of a packed envelope, the recipients are either in the protected header
+// (legacy envelope) or in the unprotected recipients (JWE).
+func Introspect(packed []byte) (*Envelope, error) {
+	env := &envelopeStub{}
+	if err := json.Unmarshal(packed, env); err != nil {
+		return nil, fmt.Errorf("parse envelope: %w", err)
+	}
+
+	protected, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(env.Protected, "="))
+	if err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+
+	header := &headerStub{}
+	if err := json.Unmarshal(protected, header); err != nil {
+		return nil, fmt.Errorf("parse header: %w", err)
+	}
+
+	envelope := &Envelope{
+		Typ:  header.Typ,
+		Alg:  header.Alg,
+		Enc:  header.Enc,
+		SKID: header.SKID,
+		Size: len(packed),
+	}
+
+	for _, recipient := range append(header.Recipients, env.Recipients...) {
+		envelope.Recipients = append(envelope.Recipients, Recipient{
+			KID:    recipient.Header.KID,
+			Alg:    recipient.Header.Alg,
+			Sender: recipient.Header.Sender != "",
+		})
+	}
+
+	return envelope, nil
+}
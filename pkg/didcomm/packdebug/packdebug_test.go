@@ -0,0 +1,131 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package packdebug
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+type provider struct {
+	storageProvider storage.Provider
+}
+
+func (p *provider) StorageProvider() storage.Provider { return p.storageProvider }
+
+func TestIntrospect(t *testing.T) {
+	t.Run("legacy envelope", func(t *testing.T) {
+		protected := base64.URLEncoding.EncodeToString([]byte(`{"enc":"xchacha20poly1305_ietf","typ":"JWM/1.0",` +
+			`"alg":"Authcrypt","recipients":[{"encrypted_key":"a2V5","header":{"kid":"F7mNtF","sender":"c2VuZGVy",` +
+			`"iv":"aXY="}},{"encrypted_key":"a2V5","header":{"kid":"3zZnGm","sender":"c2VuZGVy","iv":"aXY="}}]}`))
+		packed := []byte(fmt.Sprintf(`{"protected":%q,"iv":"aXY","ciphertext":"Y3Q","tag":"dGFn"}`, protected))
+
+		envelope, err := Introspect(packed)
+		require.NoError(t, err)
+		require.Equal(t, &Envelope{
+			Typ: "JWM/1.0",
+			Alg: "Authcrypt",
+			Enc: "xchacha20poly1305_ietf",
+			Recipients: []Recipient{
+				{KID: "F7mNtF", Sender: true},
+				{KID: "3zZnGm", Sender: true},
+			},
+			Size: len(packed),
+		}, envelope)
+	})
+
+	t.Run("JWE envelope", func(t *testing.T) {
+		protected := base64.RawURLEncoding.EncodeToString([]byte(
+			`{"typ":"application/didcomm-encrypted+json","alg":"ECDH-1PU+A256KW","enc":"A256GCM","skid":"sender"}`))
+		packed := []byte(fmt.Sprintf(`{"protected":%q,"recipients":[{"encrypted_key":"a2V5",`+
+			`"header":{"kid":"did:example:bob#key-1","epk":{"kty":"OKP"}}}],"ciphertext":"Y3Q"}`, protected))
+
+		envelope, err := Introspect(packed)
+		require.NoError(t, err)
+		require.Equal(t, &Envelope{
+			Typ:        "application/didcomm-encrypted+json",
+			Alg:        "ECDH-1PU+A256KW",
+			Enc:        "A256GCM",
+			SKID:       "sender",
+			Recipients: []Recipient{{KID: "did:example:bob#key-1"}},
+			Size:       len(packed),
+		}, envelope)
+	})
+
+	t.Run("invalid envelope", func(t *testing.T) {
+		_, err := Introspect([]byte("{"))
+		require.Contains(t, err.Error(), "parse envelope")
+
+		_, err = Introspect([]byte(`{"protected":"!"}`))
+		require.Contains(t, err.Error(), "decode header")
+
+		_, err = Introspect([]byte(fmt.Sprintf(`{"protected":%q}`, base64.RawURLEncoding.EncodeToString([]byte("{")))))
+		require.Contains(t, err.Error(), "parse header")
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("open store error", func(t *testing.T) {
+		_, err := New(&provider{storageProvider: &mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("error")}})
+		require.EqualError(t, err, "open store: error")
+	})
+
+	t.Run("save and get record", func(t *testing.T) {
+		recorder, err := New(&provider{storageProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		record := &Record{
+			MessageID:       "msg-1",
+			MessageType:     "https://didcomm.org/basicmessage/1.0/message",
+			ServiceEndpoint: "http://example.com",
+			Envelope:        &Envelope{Typ: "JWM/1.0", Recipients: []Recipient{{KID: "F7mNtF"}}, Size: 512},
+			Forward:         &Envelope{Typ: "JWM/1.0", Recipients: []Recipient{{KID: "3zZnGm"}}, Size: 1024},
+			Packed:          time.Now().UTC(),
+		}
+
+		require.NoError(t, recorder.Save(record))
+
+		saved, err := recorder.Record("msg-1")
+		require.NoError(t, err)
+		require.True(t, record.Packed.Equal(saved.Packed))
+
+		saved.Packed = record.Packed
+		require.Equal(t, record, saved)
+
+		_, err = recorder.Record("msg-2")
+		require.True(t, errors.Is(err, ErrRecordNotFound))
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: map[string][]byte{}, ErrPut: errors.New("put error")}
+
+		recorder, err := New(&provider{storageProvider: mockstorage.NewCustomMockStoreProvider(store)})
+		require.NoError(t, err)
+
+		require.EqualError(t, recorder.Save(&Record{MessageID: "msg-1"}), "save record: put error")
+
+		store.ErrGet = errors.New("get error")
+
+		_, err = recorder.Record("msg-1")
+		require.EqualError(t, err, "get record: get error")
+
+		store.ErrGet = nil
+		store.Store["record_msg-1"] = []byte("{")
+
+		_, err = recorder.Record("msg-1")
+		require.Contains(t, err.Error(), "unmarshal record")
+	})
+}
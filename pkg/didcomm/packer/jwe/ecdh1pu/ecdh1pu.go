@@ -0,0 +1,125 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ecdh1pu
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	josecipher "github.com/square/go-jose/v3/cipher"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+)
+
+// This package deals with DIDComm V2 authenticated encryption for Packing/Unpacking DID Comm exchange
+// Using ECDH-1PU key agreement (X25519) with AES key wrap and AES based content encryption
+
+// ContentEncryption represents a content encryption algorithm.
+type ContentEncryption string
+
+const (
+	// A256GCM AES-GCM algorithm using a 256 bits key
+	A256GCM = ContentEncryption("A256GCM")
+	// A256CBCHS512 AES-CBC + HMAC-SHA512 algorithm using a 512 bits key
+	A256CBCHS512 = ContentEncryption("A256CBC-HS512")
+	// EncodingType is the `typ` string identifier in a message that identifies the format as being a DIDComm V2 JWE
+	EncodingType = "application/didcomm-encrypted+json"
+	// KeyAgreementAlg is the JWE `alg` of the key agreement and key wrapping used by this packer
+	KeyAgreementAlg = "ECDH-1PU+A256KW"
+
+	kekSize = 32
+
+	cbcHS512KeySize = 64
+	gcmKeySize      = 32
+)
+
+// errUnsupportedAlg is used when a bad encryption algorithm is used
+var errUnsupportedAlg = errors.New("algorithm not supported")
+
+// Packer represents an ECDH-1PU Packer/Unpacker that outputs/reads DIDComm V2 JWE envelopes
+type Packer struct {
+	enc        ContentEncryption
+	cekSize    int
+	legacyKMS  legacykms.KeyManager
+	randReader io.Reader
+}
+
+// Envelope represents a JWE envelope as per the DIDComm V2 envelope specs
+type Envelope struct {
+	Protected  string           `json:"protected,omitempty"`
+	Recipients []jose.Recipient `json:"recipients,omitempty"`
+	IV         string           `json:"iv,omitempty"`
+	Tag        string           `json:"tag,omitempty"`
+	CipherText string           `json:"ciphertext,omitempty"`
+}
+
+// jweHeaders are the Protected JWE headers in a map format
+type jweHeaders struct {
+	Typ  string `json:"typ,omitempty"`
+	Alg  string `json:"alg,omitempty"`
+	Enc  string `json:"enc,omitempty"`
+	SKID string `json:"skid,omitempty"`
+}
+
+// jwk formatted ephemeral key
+type jwk struct {
+	Kty string `json:"kty,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// New will create a Packer instance to pack payloads with ECDH-1PU key agreement for the given
+// content encryption algorithm. Possible algorithms supported are:
+// A256GCM (AES-GCM with a 256 bits key)
+// A256CBC-HS512 (AES-CBC with HMAC-SHA512)
+// The returned Packer contains all the information required to pack and unpack payloads.
+func New(ctx packer.Provider, enc ContentEncryption) (*Packer, error) {
+	var cekSize int
+
+	switch enc {
+	case A256GCM:
+		cekSize = gcmKeySize
+	case A256CBCHS512:
+		cekSize = cbcHS512KeySize
+	default:
+		return nil, errUnsupportedAlg
+	}
+
+	return &Packer{
+		enc:        enc,
+		cekSize:    cekSize,
+		legacyKMS:  ctx.LegacyKMS(),
+		randReader: rand.Reader,
+	}, nil
+}
+
+// EncodingType returns the type of the encoding, as in the `Typ` field of the envelope header
+func (p *Packer) EncodingType() string {
+	return EncodingType
+}
+
+// createCipher will create and return a new AEAD cipher for the given content encryption algorithm and cek
+func createCipher(enc ContentEncryption, cek []byte) (cipher.AEAD, error) {
+	switch enc {
+	case A256GCM:
+		block, err := aes.NewCipher(cek)
+		if err != nil {
+			return nil, err
+		}
+
+		return cipher.NewGCM(block)
+	case A256CBCHS512:
+		return josecipher.NewCBCHMAC(cek, aes.NewCipher)
+	default:
+		return nil, errUnsupportedAlg
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ecdh1pu
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/legacykms"
+)
+
+func newMessagingKeys(t *testing.T) *cryptoutil.MessagingKeys {
+	sigPubKey, sigPrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	encPubKey, err := cryptoutil.PublicEd25519toCurve25519(sigPubKey)
+	require.NoError(t, err)
+
+	encPrivKey, err := cryptoutil.SecretEd25519toCurve25519(sigPrivKey)
+	require.NoError(t, err)
+
+	return &cryptoutil.MessagingKeys{
+		SigKeyPair: &cryptoutil.SigKeyPair{
+			KeyPair: cryptoutil.KeyPair{Pub: sigPubKey, Priv: sigPrivKey},
+			Alg:     cryptoutil.EdDSA,
+		},
+		EncKeyPair: &cryptoutil.EncKeyPair{
+			KeyPair: cryptoutil.KeyPair{Pub: encPubKey, Priv: encPrivKey},
+			Alg:     cryptoutil.Curve25519,
+		},
+	}
+}
+
+func newPacker(t *testing.T, enc ContentEncryption, kp ...*cryptoutil.MessagingKeys) *Packer {
+	kmsProvider, err := mockkms.NewMockProvider(kp...)
+	require.NoError(t, err)
+
+	p, err := New(kmsProvider, enc)
+	require.NoError(t, err)
+
+	return p
+}
+
+func TestNew(t *testing.T) {
+	p := newPacker(t, A256GCM)
+	require.Equal(t, EncodingType, p.EncodingType())
+
+	p = newPacker(t, A256CBCHS512)
+	require.Equal(t, EncodingType, p.EncodingType())
+
+	_, err := New(&mockprovider.Provider{}, "BAD")
+	require.EqualError(t, err, errUnsupportedAlg.Error())
+}
+
+func TestPackUnpack(t *testing.T) {
+	for _, enc := range []ContentEncryption{A256GCM, A256CBCHS512} {
+		enc := enc
+
+		t.Run("pack and unpack with "+string(enc), func(t *testing.T) {
+			sender := newMessagingKeys(t)
+			rec1 := newMessagingKeys(t)
+			rec2 := newMessagingKeys(t)
+
+			senderPacker := newPacker(t, enc, sender)
+			payload := []byte("Lorem Ipsum is simply dummy text of the printing and typesetting industry.")
+
+			envelope, err := senderPacker.Pack(payload, sender.SigKeyPair.Pub,
+				[][]byte{rec1.SigKeyPair.Pub, rec2.SigKeyPair.Pub})
+			require.NoError(t, err)
+
+			jwe := &Envelope{}
+			require.NoError(t, json.Unmarshal(envelope, jwe))
+			require.Len(t, jwe.Recipients, 2)
+
+			headers, err := decodeHeaders(jwe.Protected)
+			require.NoError(t, err)
+			require.Equal(t, EncodingType, headers.Typ)
+			require.Equal(t, KeyAgreementAlg, headers.Alg)
+			require.Equal(t, string(enc), headers.Enc)
+			require.Equal(t, base58.Encode(sender.SigKeyPair.Pub), headers.SKID)
+
+			for _, rec := range []*cryptoutil.MessagingKeys{rec1, rec2} {
+				// the recipient agent uses its own packer (with a different content encryption) to unpack
+				recPacker := newPacker(t, A256GCM, rec)
+
+				msg, e := recPacker.Unpack(envelope)
+				require.NoError(t, e)
+				require.Equal(t, payload, msg.Message)
+				require.Equal(t, sender.SigKeyPair.Pub, msg.FromVerKey)
+				require.Equal(t, rec.SigKeyPair.Pub, msg.ToVerKey)
+			}
+		})
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	sender := newMessagingKeys(t)
+	rec := newMessagingKeys(t)
+
+	p := newPacker(t, A256GCM, sender)
+
+	t.Run("empty sender key", func(t *testing.T) {
+		_, err := p.Pack([]byte("msg"), nil, [][]byte{rec.SigKeyPair.Pub})
+		require.EqualError(t, err, "failed to pack message: empty sender key")
+	})
+
+	t.Run("empty recipients", func(t *testing.T) {
+		_, err := p.Pack([]byte("msg"), sender.SigKeyPair.Pub, nil)
+		require.EqualError(t, err, "failed to pack message: empty recipients")
+	})
+
+	t.Run("sender key not found", func(t *testing.T) {
+		_, err := p.Pack([]byte("msg"), rec.SigKeyPair.Pub, [][]byte{sender.SigKeyPair.Pub})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to pack message: sender key")
+	})
+
+	t.Run("invalid recipient key", func(t *testing.T) {
+		_, err := p.Pack([]byte("msg"), sender.SigKeyPair.Pub, [][]byte{[]byte("invalid")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "for recipient 1")
+	})
+
+	t.Run("derive shared secret error", func(t *testing.T) {
+		mp := newPacker(t, A256GCM)
+		mp.legacyKMS = &mockkms.CloseableKMS{
+			EncryptionKeyValue:    sender.EncKeyPair.Pub,
+			DeriveSharedSecretErr: errors.New("derive error"),
+		}
+
+		_, err := mp.Pack([]byte("msg"), sender.SigKeyPair.Pub, [][]byte{rec.SigKeyPair.Pub})
+		require.EqualError(t, err, "failed to pack message: derive error")
+	})
+}
+
+func TestUnpackErrors(t *testing.T) {
+	sender := newMessagingKeys(t)
+	rec := newMessagingKeys(t)
+
+	envelope, err := newPacker(t, A256CBCHS512, sender).Pack([]byte("msg"), sender.SigKeyPair.Pub,
+		[][]byte{rec.SigKeyPair.Pub})
+	require.NoError(t, err)
+
+	recPacker := newPacker(t, A256CBCHS512, rec)
+
+	t.Run("invalid envelope", func(t *testing.T) {
+		_, err = recPacker.Unpack([]byte("{"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unpack json")
+	})
+
+	t.Run("recipient not found", func(t *testing.T) {
+		_, err = newPacker(t, A256CBCHS512, newMessagingKeys(t)).Unpack(envelope)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), cryptoutil.ErrKeyNotFound.Error())
+	})
+
+	t.Run("unsupported alg", func(t *testing.T) {
+		jwe := &Envelope{}
+		require.NoError(t, json.Unmarshal(envelope, jwe))
+
+		jwe.Protected = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ECDH-ES+A256KW"}`))
+		raw, e := json.Marshal(jwe)
+		require.NoError(t, e)
+
+		_, err = recPacker.Unpack(raw)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errUnsupportedAlg.Error())
+	})
+
+	t.Run("invalid sender key", func(t *testing.T) {
+		jwe := &Envelope{}
+		require.NoError(t, json.Unmarshal(envelope, jwe))
+
+		jwe.Protected = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + KeyAgreementAlg + `"}`))
+		raw, e := json.Marshal(jwe)
+		require.NoError(t, e)
+
+		_, err = recPacker.Unpack(raw)
+		require.EqualError(t, err, "unpack: invalid sender key in envelope")
+	})
+
+	t.Run("forged sender key", func(t *testing.T) {
+		jwe := &Envelope{}
+		require.NoError(t, json.Unmarshal(envelope, jwe))
+
+		h, e := decodeHeaders(jwe.Protected)
+		require.NoError(t, e)
+
+		// claim the message was sent by a different key: the KEK derivation must fail to unwrap the cek
+		h.SKID = base58.Encode(newMessagingKeys(t).SigKeyPair.Pub)
+		hBytes, e := json.Marshal(h)
+		require.NoError(t, e)
+
+		jwe.Protected = base64.RawURLEncoding.EncodeToString(hBytes)
+		raw, e := json.Marshal(jwe)
+		require.NoError(t, e)
+
+		_, err = recPacker.Unpack(raw)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unpack: decrypt shared key")
+	})
+
+	t.Run("tampered cipher text", func(t *testing.T) {
+		jwe := &Envelope{}
+		require.NoError(t, json.Unmarshal(envelope, jwe))
+
+		jwe.CipherText = base64.RawURLEncoding.EncodeToString([]byte("tampered cipher text"))
+		raw, e := json.Marshal(jwe)
+		require.NoError(t, e)
+
+		_, err = recPacker.Unpack(raw)
+		require.Error(t, err)
+	})
+
+	t.Run("tampered tag", func(t *testing.T) {
+		jwe := &Envelope{}
+		require.NoError(t, json.Unmarshal(envelope, jwe))
+
+		// the tag is an input of the KEK derivation: the cek is not unwrapped
+		jwe.Tag = base64.RawURLEncoding.EncodeToString([]byte("tampered tag"))
+		raw, e := json.Marshal(jwe)
+		require.NoError(t, e)
+
+		_, err = recPacker.Unpack(raw)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unpack: decrypt shared key")
+
+		jwe.Tag = "!"
+		raw, e = json.Marshal(jwe)
+		require.NoError(t, e)
+
+		_, err = recPacker.Unpack(raw)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unpack: decode tag")
+	})
+
+	t.Run("bad epk", func(t *testing.T) {
+		jwe := &Envelope{}
+		require.NoError(t, json.Unmarshal(envelope, jwe))
+
+		jwe.Recipients[0].Header.EPK = json.RawMessage(`"not a jwk"`)
+		raw, e := json.Marshal(jwe)
+		require.NoError(t, e)
+
+		_, err = recPacker.Unpack(raw)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal epk")
+	})
+}
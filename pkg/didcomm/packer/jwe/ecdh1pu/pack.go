@@ -0,0 +1,189 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ecdh1pu
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	josecipher "github.com/square/go-jose/v3/cipher"
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+)
+
+// Pack will JWE encode the payload argument for the sender and recipients
+// Using ECDH-1PU key agreement to wrap a freshly generated content encryption key for each recipient.
+// It will encrypt by fetching the sender's encryption key corresponding to senderVerKey and converting the list
+// of recipientsVerKeys into a list of encryption keys
+func (p *Packer) Pack(payload, senderVerKey []byte, recipientsVerKeys [][]byte) ([]byte, error) {
+	if len(senderVerKey) == 0 {
+		return nil, fmt.Errorf("failed to pack message: empty sender key")
+	}
+
+	if len(recipientsVerKeys) == 0 {
+		return nil, fmt.Errorf("failed to pack message: empty recipients")
+	}
+
+	senderPubKey, err := p.legacyKMS.GetEncryptionKey(senderVerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack message: sender key: %w", err)
+	}
+
+	h, err := json.Marshal(jweHeaders{
+		Typ:  EncodingType,
+		Alg:  KeyAgreementAlg,
+		Enc:  string(p.enc),
+		SKID: base58.Encode(senderVerKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	encHeaders := base64.RawURLEncoding.EncodeToString(h)
+
+	cek := make([]byte, p.cekSize)
+
+	_, err = p.randReader.Read(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := createCipher(p.enc, cek)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+
+	_, err = p.randReader.Read(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	// the output is a []byte containing the cipherText + tag, the protected headers are authenticated as AAD
+	symOutput := aead.Seal(nil, iv, payload, []byte(encHeaders))
+	tagStart := len(symOutput) - aead.Overhead()
+
+	// the cek is wrapped once the content is encrypted, the tag is an input of the key derivation
+	tag := symOutput[tagStart:]
+
+	recipients, err := p.encodeRecipients(cek, tag, senderVerKey, senderPubKey, recipientsVerKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack message: %w", err)
+	}
+
+	return json.Marshal(Envelope{
+		Protected:  encHeaders,
+		Recipients: recipients,
+		IV:         base64.RawURLEncoding.EncodeToString(iv),
+		Tag:        base64.RawURLEncoding.EncodeToString(tag),
+		CipherText: base64.RawURLEncoding.EncodeToString(symOutput[:tagStart]),
+	})
+}
+
+// encodeRecipients is a utility function that will wrap the cek (content encryption key) for each recipient
+// and return a list of encoded recipient keys in a JWE compliant format ([]Recipient)
+func (p *Packer) encodeRecipients(cek, tag, senderVerKey, senderPubKey []byte, recipients [][]byte) ([]jose.Recipient, error) { //nolint:lll
+	var encodedRecipients []jose.Recipient
+
+	for i, rVer := range recipients {
+		if !cryptoutil.IsChachaKeyValid(rVer) {
+			return nil, fmt.Errorf("%w - for recipient %d", cryptoutil.ErrInvalidKey, i+1)
+		}
+
+		rec, err := p.encodeRecipient(cek, tag, senderVerKey, senderPubKey, rVer)
+		if err != nil {
+			return nil, err
+		}
+
+		encodedRecipients = append(encodedRecipients, *rec)
+	}
+
+	return encodedRecipients, nil
+}
+
+// encodeRecipient will wrap the cek with a key derived from a newly generated ephemeral key (Ze) and the
+// static sender key (Zs) as per ECDH-1PU. It returns a JWE compliant Recipient
+func (p *Packer) encodeRecipient(cek, tag, senderVerKey, senderPubKey,
+	recipientVerKey []byte) (*jose.Recipient, error) {
+	recipientPubKey, err := cryptoutil.PublicEd25519toCurve25519(recipientVerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	epkPriv := make([]byte, curve25519.ScalarSize)
+
+	_, err = p.randReader.Read(epkPriv)
+	if err != nil {
+		return nil, err
+	}
+
+	epkPub, err := curve25519.X25519(epkPriv, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ze, err := curve25519.X25519(epkPriv, recipientPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	zs, err := p.legacyKMS.DeriveSharedSecret(senderPubKey, recipientPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := base58.Encode(recipientVerKey)
+
+	wrapped, err := wrapCEK(cek, tag, ze, zs, senderVerKey, []byte(kid))
+	if err != nil {
+		return nil, err
+	}
+
+	epk, err := json.Marshal(jwk{
+		Kty: "OKP",
+		Crv: "X25519",
+		X:   base64.RawURLEncoding.EncodeToString(epkPub),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &jose.Recipient{
+		EncryptedKey: base64.RawURLEncoding.EncodeToString(wrapped),
+		Header: jose.RecipientHeaders{
+			KID: kid,
+			EPK: epk,
+		},
+	}, nil
+}
+
+// deriveKEK derives the key encryption key shared between the sender and the recipient.
+// The sender key is used as the PartyUInfo and the recipient kid as the PartyVInfo of the KDF, the tag of the
+// content encryption is part of its SuppPubInfo.
+func deriveKEK(tag, ze, zs, apu, apv []byte) ([]byte, error) {
+	return cryptoutil.Derive1PUKEK([]byte(KeyAgreementAlg), apu, apv, tag, ze, zs, kekSize)
+}
+
+func wrapCEK(cek, tag, ze, zs, apu, apv []byte) ([]byte, error) {
+	kek, err := deriveKEK(tag, ze, zs, apu, apv)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return josecipher.KeyWrap(block, cek)
+}
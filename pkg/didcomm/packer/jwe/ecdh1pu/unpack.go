@@ -0,0 +1,186 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ecdh1pu
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	josecipher "github.com/square/go-jose/v3/cipher"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+)
+
+// Unpack will JWE decode the envelope argument for the recipient found in the LegacyKMS.
+// The sender is authenticated by the ECDH-1PU key agreement: the cek can only be unwrapped
+// if the envelope was built with the private key corresponding to the `skid` header.
+func (p *Packer) Unpack(envelope []byte) (*transport.Envelope, error) {
+	jwe := &Envelope{}
+
+	err := json.Unmarshal(envelope, jwe)
+	if err != nil {
+		return nil, fmt.Errorf("unpack json: %w", err)
+	}
+
+	headers, err := decodeHeaders(jwe.Protected)
+	if err != nil {
+		return nil, fmt.Errorf("unpack: %w", err)
+	}
+
+	if headers.Alg != KeyAgreementAlg {
+		return nil, fmt.Errorf("unpack: %w: %s", errUnsupportedAlg, headers.Alg)
+	}
+
+	senderVerKey := base58.Decode(headers.SKID)
+	if !cryptoutil.IsChachaKeyValid(senderVerKey) {
+		return nil, errors.New("unpack: invalid sender key in envelope")
+	}
+
+	recipientVerKey, recipient, err := p.findRecipient(jwe.Recipients)
+	if err != nil {
+		return nil, fmt.Errorf("unpack: %w", err)
+	}
+
+	tag, err := base64.RawURLEncoding.DecodeString(jwe.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("unpack: decode tag: %w", err)
+	}
+
+	cek, err := p.decryptCEK(tag, senderVerKey, recipientVerKey, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("unpack: decrypt shared key: %w", err)
+	}
+
+	payload, err := decryptPayload(ContentEncryption(headers.Enc), cek, jwe)
+	if err != nil {
+		return nil, fmt.Errorf("unpack: %w", err)
+	}
+
+	return &transport.Envelope{
+		Message:    payload,
+		FromVerKey: senderVerKey,
+		ToVerKey:   recipientVerKey,
+	}, nil
+}
+
+func decodeHeaders(protected string) (*jweHeaders, error) {
+	h, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return nil, fmt.Errorf("decode headers: %w", err)
+	}
+
+	headers := &jweHeaders{}
+
+	err = json.Unmarshal(h, headers)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal headers: %w", err)
+	}
+
+	return headers, nil
+}
+
+func decryptPayload(enc ContentEncryption, cek []byte, jwe *Envelope) ([]byte, error) {
+	aead, err := createCipher(enc, cek)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText, err := base64.RawURLEncoding.DecodeString(jwe.CipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := base64.RawURLEncoding.DecodeString(jwe.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, err := base64.RawURLEncoding.DecodeString(jwe.IV)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aead.NonceSize() {
+		return nil, errors.New("bad nonce size")
+	}
+
+	return aead.Open(nil, iv, append(cipherText, tag...), []byte(jwe.Protected))
+}
+
+// findRecipient will loop through jweRecipients and returns the first matching key from the legacyKMS
+func (p *Packer) findRecipient(jweRecipients []jose.Recipient) ([]byte, *jose.Recipient, error) {
+	var recipientsKeys []string
+	for _, recipient := range jweRecipients {
+		recipientsKeys = append(recipientsKeys, recipient.Header.KID)
+	}
+
+	i, err := p.legacyKMS.FindVerKey(recipientsKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return base58.Decode(recipientsKeys[i]), &jweRecipients[i], nil
+}
+
+// decryptCEK will unwrap the CEK found in recipient using the key derived from the recipient's private key,
+// the ephemeral key (Ze), the sender's static key (Zs) and the tag of the content encryption
+func (p *Packer) decryptCEK(tag, senderVerKey, recipientVerKey []byte, recipient *jose.Recipient) ([]byte, error) {
+	epk := &jwk{}
+
+	err := json.Unmarshal(recipient.Header.EPK, epk)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal epk: %w", err)
+	}
+
+	epkPub, err := base64.RawURLEncoding.DecodeString(epk.X)
+	if err != nil {
+		return nil, fmt.Errorf("decode epk: %w", err)
+	}
+
+	senderPubKey, err := cryptoutil.PublicEd25519toCurve25519(senderVerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	recipientPubKey, err := p.legacyKMS.GetEncryptionKey(recipientVerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ze, err := p.legacyKMS.DeriveSharedSecret(recipientPubKey, epkPub)
+	if err != nil {
+		return nil, err
+	}
+
+	zs, err := p.legacyKMS.DeriveSharedSecret(recipientPubKey, senderPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedCEK, err := base64.RawURLEncoding.DecodeString(recipient.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := deriveKEK(tag, ze, zs, senderVerKey, []byte(recipient.Header.KID))
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return josecipher.KeyUnwrap(block, encryptedCEK)
+}
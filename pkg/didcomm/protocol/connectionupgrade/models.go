@@ -0,0 +1,23 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package connectionupgrade
+
+// Request is sent over a connection to upgrade it to a media type profile (eg "didcomm/v2"), once the updated
+// document of the DID of the sender accepting the profile was sent (see the DID update protocol).
+type Request struct {
+	ID      string `json:"@id,omitempty"`
+	Type    string `json:"@type,omitempty"`
+	Profile string `json:"profile"`
+}
+
+// Response is the reply of the agent accepting the upgrade, it is sent once the updated document of its DID
+// accepting the profile was sent. The agents use the profile for the messages of the connection that follow.
+type Response struct {
+	ID      string `json:"@id,omitempty"`
+	Type    string `json:"@type,omitempty"`
+	Profile string `json:"profile"`
+}
@@ -0,0 +1,505 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package connectionupgrade
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/ecdh1pu"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didupdate"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/discoverfeatures"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	// Name defines the protocol name
+	Name = "connection-upgrade"
+	// Spec defines the protocol spec
+	Spec = "https://didcomm.org/connection-upgrade/1.0/"
+	// RequestMsgType defines the protocol request message type.
+	RequestMsgType = Spec + "request"
+	// ResponseMsgType defines the protocol response message type.
+	ResponseMsgType = Spec + "response"
+	// ProblemReportMsgType defines the protocol problem-report message type.
+	ProblemReportMsgType = Spec + "problem-report"
+
+	// ProfileDIDCommV2 is the media type profile of the DIDComm V2 envelope.
+	ProfileDIDCommV2 = "didcomm/v2"
+
+	// StateUpgraded is the state of the message event triggered once a connection was upgraded.
+	StateUpgraded = "upgraded"
+
+	// AcceptProperty is the property of the did-communication service listing the media type profiles
+	// accepted by the agent.
+	AcceptProperty = "accept"
+
+	codeNotSupported   = "profile-not-supported"
+	codeUpgradeFailed  = "upgrade-failed"
+	roleRequester      = "requester"
+	roleResponder      = "responder"
+	stateNameCompleted = "completed"
+	upgradeTimeout     = 10 * time.Second
+)
+
+var logger = log.New("aries-framework/connectionupgrade/service")
+
+var (
+	// ErrNotSupported is returned when the agent does not pack the DIDComm V2 envelope.
+	ErrNotSupported = errors.New("DIDComm V2 not supported by the agent")
+	// ErrPeerNotSupported is returned when the other agent did not disclose the protocol.
+	ErrPeerNotSupported = errors.New("DIDComm V2 not supported by the other agent")
+	// ErrRejected is returned when the other agent replied with a problem report.
+	ErrRejected = errors.New("upgrade rejected by the other agent")
+	// ErrTimeout is returned when the other agent did not reply in time.
+	ErrTimeout = errors.New("timeout waiting for the upgrade response")
+)
+
+// Provider contains dependencies for the protocol and is typically created by using aries.Context()
+type Provider interface {
+	Messenger() service.Messenger
+	Service(id string) (interface{}, error)
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+	VDRIRegistry() vdri.Registry
+	Packager() transport.Packager
+}
+
+type didUpdater interface {
+	UpdateDID(doc *did.Doc) error
+}
+
+type featureDiscoverer interface {
+	Disclose(protocols ...*discoverfeatures.Protocol)
+	Query(query, myDID, theirDID string) ([]*discoverfeatures.Protocol, error)
+}
+
+// envelopeSelector is implemented by the packagers selecting the envelope encoding used with a peer key.
+type envelopeSelector interface {
+	SupportsEnvelopeEncoding(encodingType string) bool
+	SetEnvelopeEncoding(verKey, encodingType string) error
+}
+
+// Service for the connection upgrade protocol: it upgrades a connection established with the DIDComm V1 envelope
+// to the DIDComm V2 media type profile when both agents support it.
+//
+// The protocol is disclosed (see the discover features protocol) by the agents packing the V2 envelope. The agent
+// upgrading a connection first queries the protocol, then updates the document of its DID in place to accept the
+// V2 profile (see the DID update protocol) and sends a request. The other agent updates the document of its DID
+// the same way and replies; both agents then pack the messages of the connection with the V2 envelope and record
+// the profile on the connection record (see connection.Record.MediaTypeProfile).
+//
+// The connection keeps the V1 envelope if the upgrade fails: the connection records and the envelope encodings
+// are only changed once the upgrade was accepted, the updated documents remain valid for the V1 envelope.
+// The agents may disagree on the profile of the connection if the response is lost or if an agent fails to record
+// the upgrade, both agents unpack either envelope meanwhile. The upgrade is idempotent: running it again on the
+// connection, upgraded or not, reconciles both agents.
+type Service struct {
+	service.Message
+	messenger    service.Messenger
+	updater      didUpdater
+	discoverer   featureDiscoverer
+	vdriRegistry vdri.Registry
+	envelopes    envelopeSelector
+	connections  *connection.Recorder
+	// v2 is true if the agent packs the DIDComm V2 envelope
+	v2      bool
+	timeout time.Duration
+
+	mu          sync.Mutex
+	requests    map[string]chan error
+	idGenerator idgen.Generator
+}
+
+// New returns the connection upgrade service, the DID update and discover features services are mandatory.
+func New(p Provider) (*Service, error) {
+	raw, err := p.Service(didupdate.Name)
+	if err != nil {
+		return nil, fmt.Errorf("didupdate service: %w", err)
+	}
+
+	updater, ok := raw.(didUpdater)
+	if !ok {
+		return nil, errors.New("cast service to didupdate service failed")
+	}
+
+	raw, err = p.Service(discoverfeatures.Name)
+	if err != nil {
+		return nil, fmt.Errorf("discover features service: %w", err)
+	}
+
+	discoverer, ok := raw.(featureDiscoverer)
+	if !ok {
+		return nil, errors.New("cast service to discover features service failed")
+	}
+
+	connections, err := connection.NewRecorder(p)
+	if err != nil {
+		return nil, fmt.Errorf("connection recorder: %w", err)
+	}
+
+	s := &Service{
+		idGenerator:  idgen.FromProvider(p),
+		messenger:    p.Messenger(),
+		updater:      updater,
+		discoverer:   discoverer,
+		vdriRegistry: p.VDRIRegistry(),
+		connections:  connections,
+		timeout:      upgradeTimeout,
+		requests:     map[string]chan error{},
+	}
+
+	s.envelopes, s.v2 = p.Packager().(envelopeSelector)
+	s.v2 = s.v2 && s.envelopes.SupportsEnvelopeEncoding(ecdh1pu.EncodingType)
+
+	if s.v2 {
+		discoverer.Disclose(&discoverfeatures.Protocol{
+			PID:   discoverfeatures.PID(Spec),
+			Roles: []string{roleRequester, roleResponder},
+		})
+	}
+
+	return s, nil
+}
+
+// Upgrade upgrades the connection to the DIDComm V2 media type profile, it blocks until the other agent accepted
+// the upgrade. The connection keeps the V1 envelope if an error is returned, the upgrade is then run again
+// (eg on ErrTimeout, the other agent may have upgraded the connection). The request is sent again for
+// an upgraded connection, so that the other agent upgrades it too if it failed to.
+func (s *Service) Upgrade(connectionID string) error {
+	if !s.v2 {
+		return ErrNotSupported
+	}
+
+	record, err := s.connections.GetConnectionRecord(connectionID)
+	if err != nil {
+		return fmt.Errorf("get connection record: %w", err)
+	}
+
+	if record.State != stateNameCompleted {
+		return fmt.Errorf("connection %s is not completed", connectionID)
+	}
+
+	protocols, err := s.discoverer.Query(discoverfeatures.PID(Spec), record.MyDID, record.TheirDID)
+	if err != nil {
+		return fmt.Errorf("discover features: %w", err)
+	}
+
+	if len(protocols) == 0 {
+		return ErrPeerNotSupported
+	}
+
+	if err = s.acceptV2(record.MyDID); err != nil {
+		return err
+	}
+
+	msgID := s.idGenerator.NewID()
+
+	responseCh := make(chan error, 1)
+	s.setRequestCh(msgID, responseCh)
+
+	defer s.setRequestCh(msgID, nil)
+
+	err = s.messenger.Send(service.NewDIDCommMsgMap(Request{
+		ID:      msgID,
+		Type:    RequestMsgType,
+		Profile: ProfileDIDCommV2,
+	}), record.MyDID, record.TheirDID)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	select {
+	case err = <-responseCh:
+		if err != nil {
+			return err
+		}
+	case <-time.After(s.timeout):
+		return ErrTimeout
+	}
+
+	return s.upgrade(connectionID)
+}
+
+func (s *Service) setRequestCh(msgID string, ch chan error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ch == nil {
+		delete(s.requests, msgID)
+	} else {
+		s.requests[msgID] = ch
+	}
+}
+
+func (s *Service) getRequestCh(msgID string) chan error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[msgID]
+}
+
+// acceptV2 updates the document of the DID of the agent to accept the V2 profile, the other agents
+// of the connections of the DID receive the updated document.
+func (s *Service) acceptV2(didID string) error {
+	doc, err := s.vdriRegistry.Resolve(didID)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", didID, err)
+	}
+
+	updated, err := acceptProfile(doc, ProfileDIDCommV2)
+	if err != nil {
+		return fmt.Errorf("document of %s: %w", didID, err)
+	}
+
+	if updated == nil {
+		return nil
+	}
+
+	if err = s.updater.UpdateDID(updated); err != nil {
+		return fmt.Errorf("update DID: %w", err)
+	}
+
+	return nil
+}
+
+// acceptProfile returns a copy of the document whose did-communication services accept the profile,
+// nil if they already accept it.
+func acceptProfile(doc *did.Doc, profile string) (*did.Doc, error) {
+	src, err := doc.JSONBytes()
+	if err != nil {
+		return nil, fmt.Errorf("marshal document: %w", err)
+	}
+
+	updated, err := did.ParseDocument(src)
+	if err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+
+	found, changed := false, false
+
+	for i := range updated.Service {
+		svc := &updated.Service[i]
+		if svc.Type != vdri.DIDCommServiceType {
+			continue
+		}
+
+		found = true
+
+		accept, _ := svc.Properties[AcceptProperty].([]interface{})
+		if contains(accept, profile) {
+			continue
+		}
+
+		if svc.Properties == nil {
+			svc.Properties = map[string]interface{}{}
+		}
+
+		svc.Properties[AcceptProperty] = append(accept, profile)
+		changed = true
+	}
+
+	if !found {
+		return nil, errors.New("no did-communication service")
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	return updated, nil
+}
+
+func contains(values []interface{}, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// upgrade selects the V2 envelope for the keys of the other agent and records the profile.
+func (s *Service) upgrade(connectionID string) error {
+	// the record is read again as the keys of the other agent are updated with its document
+	record, err := s.connections.GetConnectionRecord(connectionID)
+	if err != nil {
+		return fmt.Errorf("get connection record: %w", err)
+	}
+
+	for _, verKey := range record.RecipientKeys {
+		if err = s.envelopes.SetEnvelopeEncoding(verKey, ecdh1pu.EncodingType); err != nil {
+			return fmt.Errorf("select envelope encoding: %w", err)
+		}
+	}
+
+	record.MediaTypeProfile = ProfileDIDCommV2
+
+	if err = s.connections.SaveConnectionRecord(record); err != nil {
+		return fmt.Errorf("save connection record: %w", err)
+	}
+
+	logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldConnection: connectionID}).
+		Infof("connection upgraded to %s", ProfileDIDCommV2)
+
+	s.TriggerMsgEvents(service.StateMsg{
+		ProtocolName: Name,
+		Type:         service.PostState,
+		StateID:      StateUpgraded,
+		Properties:   &eventProps{connectionID: connectionID, profile: ProfileDIDCommV2},
+	})
+
+	return nil
+}
+
+// HandleInbound handles inbound message (connection upgrade protocol)
+func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
+	msgMap, ok := msg.(service.DIDCommMsgMap)
+	if !ok {
+		return "", errors.New("bad assertion message is not DIDCommMsgMap")
+	}
+
+	switch msg.Type() {
+	case RequestMsgType:
+		return "", s.handleRequest(msgMap, myDID, theirDID)
+	case ResponseMsgType, ProblemReportMsgType:
+		return "", s.handleReply(msgMap)
+	default:
+		return "", fmt.Errorf("unrecognized msgType: %s", msg.Type())
+	}
+}
+
+func (s *Service) handleRequest(msg service.DIDCommMsgMap, myDID, theirDID string) error {
+	request := &Request{}
+	if err := msg.Decode(request); err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	if !s.v2 || request.Profile != ProfileDIDCommV2 {
+		return s.reject(msg.ID(), codeNotSupported, fmt.Errorf("profile %s not supported", request.Profile))
+	}
+
+	connectionID, err := s.connections.GetConnectionIDByDIDs(myDID, theirDID)
+	if err != nil {
+		return s.reject(msg.ID(), codeUpgradeFailed, fmt.Errorf("connection of the request: %w", err))
+	}
+
+	if err = s.acceptV2(myDID); err != nil {
+		return s.reject(msg.ID(), codeUpgradeFailed, err)
+	}
+
+	// the response is packed with the V1 envelope, the connection is not upgraded if it is not sent.
+	// The request of an upgraded connection is accepted again, the requester failed to record the upgrade.
+	err = s.messenger.ReplyTo(msg.ID(), service.NewDIDCommMsgMap(Response{
+		ID:      s.idGenerator.NewID(),
+		Type:    ResponseMsgType,
+		Profile: ProfileDIDCommV2,
+	}))
+	if err != nil {
+		return fmt.Errorf("send response: %w", err)
+	}
+
+	return s.upgrade(connectionID)
+}
+
+// reject replies to the request with a problem report and returns the cause of the rejection.
+func (s *Service) reject(msgID, code string, cause error) error {
+	logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: msgID}).
+		Warnf("upgrade request rejected: %s", cause)
+
+	err := s.messenger.ReplyTo(msgID, service.NewDIDCommMsgMap(model.ProblemReport{
+		Type:        ProblemReportMsgType,
+		ID:          s.idGenerator.NewID(),
+		Description: model.Code{Code: code},
+	}))
+	if err != nil {
+		return fmt.Errorf("send problem report: %w (rejected: %s)", err, cause)
+	}
+
+	return cause
+}
+
+func (s *Service) handleReply(msg service.DIDCommMsgMap) error {
+	thID, err := msg.ThreadID()
+	if err != nil {
+		return fmt.Errorf("threadID: %w", err)
+	}
+
+	var result error
+
+	if msg.Type() == ProblemReportMsgType {
+		report := &model.ProblemReport{}
+		if err = msg.Decode(report); err != nil {
+			return fmt.Errorf("decode problem report: %w", err)
+		}
+
+		result = fmt.Errorf("%w: %s", ErrRejected, report.Description.Code)
+	}
+
+	responseCh := s.getRequestCh(thID)
+	if responseCh == nil {
+		logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: thID}).
+			Warnf("reply to an unknown upgrade request")
+
+		return nil
+	}
+
+	// the channel is buffered, a duplicated reply is dropped
+	select {
+	case responseCh <- result:
+	default:
+	}
+
+	return nil
+}
+
+// HandleOutbound handles outbound message (connection upgrade protocol)
+func (s *Service) HandleOutbound(_ service.DIDCommMsg, _, _ string) error {
+	return errors.New("not implemented")
+}
+
+// Name returns service name
+func (s *Service) Name() string {
+	return Name
+}
+
+// Accept msg checks the msg type
+func (s *Service) Accept(msgType string) bool {
+	switch msgType {
+	case RequestMsgType, ResponseMsgType, ProblemReportMsgType:
+		return true
+	}
+
+	return false
+}
+
+// eventProps are the properties of the message event of an upgraded connection.
+type eventProps struct {
+	connectionID string
+	profile      string
+}
+
+// ConnectionID returns the ID of the upgraded connection.
+func (e *eventProps) ConnectionID() string {
+	return e.connectionID
+}
+
+// Profile returns the media type profile of the upgraded connection.
+func (e *eventProps) Profile() string {
+	return e.profile
+}
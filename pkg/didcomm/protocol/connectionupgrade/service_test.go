@@ -0,0 +1,637 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package connectionupgrade
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/ecdh1pu"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didupdate"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/discoverfeatures"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	aliceDID = "did:peer:1zQmalicealicealicealicealicealicealicealicealice"
+	bobDID   = "did:peer:1zQmbobbobbobbobbobbobbobbobbobbobbobbobbobbobbob"
+
+	aliceKey = "alice-key"
+	bobKey   = "bob-key"
+)
+
+type provider struct {
+	messenger    service.Messenger
+	services     map[string]interface{}
+	storage      storage.Provider
+	transient    storage.Provider
+	vdriRegistry vdriapi.Registry
+	packager     transport.Packager
+}
+
+func (p *provider) Messenger() service.Messenger {
+	return p.messenger
+}
+
+func (p *provider) Service(id string) (interface{}, error) {
+	svc, ok := p.services[id]
+	if !ok {
+		return nil, errors.New("service not found")
+	}
+
+	return svc, nil
+}
+
+func (p *provider) StorageProvider() storage.Provider {
+	return p.storage
+}
+
+func (p *provider) TransientStorageProvider() storage.Provider {
+	return p.transient
+}
+
+func (p *provider) VDRIRegistry() vdriapi.Registry {
+	return p.vdriRegistry
+}
+
+func (p *provider) Packager() transport.Packager {
+	return p.packager
+}
+
+// packager records the envelope encodings selected for the peer keys.
+type packager struct {
+	transport.Packager
+	v2        bool
+	err       error
+	mu        sync.Mutex
+	encodings map[string]string
+}
+
+func (p *packager) SupportsEnvelopeEncoding(encodingType string) bool {
+	return p.v2 && encodingType == ecdh1pu.EncodingType
+}
+
+func (p *packager) SetEnvelopeEncoding(verKey, encodingType string) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.encodings[verKey] = encodingType
+
+	return nil
+}
+
+func (p *packager) encoding(verKey string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.encodings[verKey]
+}
+
+// updater stores the updated documents of the DID of the agent, for the agent and its peer,
+// as the DID update service does.
+type updater struct {
+	agent *agent
+	err   error
+}
+
+func (u *updater) UpdateDID(doc *did.Doc) error {
+	if u.err != nil {
+		return u.err
+	}
+
+	u.agent.store(doc)
+	u.agent.peer.store(doc)
+
+	return nil
+}
+
+// messenger delivers the messages to the peer agent.
+type messenger struct {
+	agent *agent
+}
+
+func (m *messenger) ReplyTo(msgID string, msg service.DIDCommMsgMap) error {
+	msg["~thread"] = map[string]interface{}{"thid": msgID}
+
+	return m.agent.deliver(msg)
+}
+
+func (m *messenger) Send(msg service.DIDCommMsgMap, _, _ string) error {
+	return m.agent.deliver(msg)
+}
+
+func (m *messenger) SendToDestination(service.DIDCommMsgMap, string, *service.Destination) error {
+	return errors.New("not implemented")
+}
+
+func (m *messenger) ReplyToNested(string, service.DIDCommMsgMap, string, string) error {
+	return errors.New("not implemented")
+}
+
+type agent struct {
+	did, key string
+	peer     *agent
+
+	provider *provider
+	registry *mockvdri.MockVDRIRegistry
+	regMu    sync.Mutex
+	packager *packager
+	updater  *updater
+	features *discoverfeatures.Service
+	svc      *Service
+
+	// drop drops the messages sent by the agent, dropType the ones of a type, sendErr fails sending them
+	drop     bool
+	dropType string
+	sendErr  error
+	// errs are the errors of the messages handled by the agent
+	errs chan error
+}
+
+func newAgent(t *testing.T, didID, key string) *agent {
+	t.Helper()
+
+	a := &agent{
+		did:      didID,
+		key:      key,
+		registry: &mockvdri.MockVDRIRegistry{MemStore: map[string]*did.Doc{}},
+		packager: &packager{v2: true, encodings: map[string]string{}},
+		errs:     make(chan error, 10),
+	}
+
+	a.registry.ResolveFunc = func(didID string, _ ...vdriapi.ResolveOpts) (*did.Doc, error) {
+		a.regMu.Lock()
+		defer a.regMu.Unlock()
+
+		doc, ok := a.registry.MemStore[didID]
+		if !ok {
+			return nil, vdriapi.ErrNotFound
+		}
+
+		return doc, nil
+	}
+
+	a.updater = &updater{agent: a}
+
+	a.provider = &provider{
+		messenger:    &messenger{agent: a},
+		storage:      mem.NewProvider(),
+		transient:    mem.NewProvider(),
+		vdriRegistry: a.registry,
+		packager:     a.packager,
+	}
+
+	var err error
+
+	a.features, err = discoverfeatures.New(a.provider)
+	require.NoError(t, err)
+
+	a.provider.services = map[string]interface{}{didupdate.Name: a.updater, discoverfeatures.Name: a.features}
+
+	return a
+}
+
+func (a *agent) store(doc *did.Doc) {
+	a.regMu.Lock()
+	defer a.regMu.Unlock()
+
+	a.registry.MemStore[doc.ID] = doc
+}
+
+func (a *agent) resolve(t *testing.T, didID string) *did.Doc {
+	t.Helper()
+
+	doc, err := a.registry.Resolve(didID)
+	require.NoError(t, err)
+
+	return doc
+}
+
+func (a *agent) start(t *testing.T) {
+	t.Helper()
+
+	var err error
+
+	a.svc, err = New(a.provider)
+	require.NoError(t, err)
+
+	a.svc.timeout = time.Second
+}
+
+func (a *agent) deliver(msg service.DIDCommMsgMap) error {
+	if a.sendErr != nil {
+		return a.sendErr
+	}
+
+	if a.drop || msg.Type() == a.dropType {
+		return nil
+	}
+
+	peer := a.peer
+
+	go func() {
+		var err error
+
+		switch {
+		case peer.features.Accept(msg.Type()):
+			_, err = peer.features.HandleInbound(msg, peer.did, a.did)
+		case peer.svc != nil && peer.svc.Accept(msg.Type()):
+			_, err = peer.svc.HandleInbound(msg, peer.did, a.did)
+		default:
+			err = errors.New("no handler")
+		}
+
+		peer.errs <- err
+	}()
+
+	return nil
+}
+
+func (a *agent) record(t *testing.T) *connection.Record {
+	t.Helper()
+
+	lookup, err := connection.NewLookup(a.provider)
+	require.NoError(t, err)
+
+	record, err := lookup.GetConnectionRecord("conn-" + a.key)
+	require.NoError(t, err)
+
+	return record
+}
+
+func newDoc(didID, key string) *did.Doc {
+	return &did.Doc{
+		Context: []string{did.Context},
+		ID:      didID,
+		PublicKey: []did.PublicKey{{
+			ID:         didID + "#key-1",
+			Type:       "Ed25519VerificationKey2018",
+			Controller: didID,
+			Value:      []byte(key),
+		}},
+		Service: []did.Service{{
+			ID:              "#agent",
+			Type:            vdriapi.DIDCommServiceType,
+			ServiceEndpoint: "http://agent.example.com",
+			RecipientKeys:   []string{key},
+		}},
+	}
+}
+
+// newAgents returns two agents connected with the V1 envelope, the services of the agents are not started.
+func newAgents(t *testing.T) (*agent, *agent) {
+	t.Helper()
+
+	alice, bob := newAgent(t, aliceDID, aliceKey), newAgent(t, bobDID, bobKey)
+	alice.peer, bob.peer = bob, alice
+
+	for _, a := range []*agent{alice, bob} {
+		for _, doc := range []*did.Doc{newDoc(aliceDID, aliceKey), newDoc(bobDID, bobKey)} {
+			a.store(doc)
+		}
+
+		recorder, err := connection.NewRecorder(a.provider)
+		require.NoError(t, err)
+
+		require.NoError(t, recorder.SaveConnectionRecord(&connection.Record{
+			ConnectionID:  "conn-" + a.key,
+			State:         stateNameCompleted,
+			MyDID:         a.did,
+			TheirDID:      a.peer.did,
+			RecipientKeys: []string{a.peer.key},
+		}))
+	}
+
+	return alice, bob
+}
+
+func requireAcceptsV2(t *testing.T, doc *did.Doc, accepts bool) {
+	t.Helper()
+
+	accept, _ := doc.Service[0].Properties[AcceptProperty].([]interface{})
+	require.Equal(t, accepts, contains(accept, ProfileDIDCommV2))
+}
+
+func TestNew(t *testing.T) {
+	t.Run("disclose the protocol", func(t *testing.T) {
+		a := newAgent(t, aliceDID, aliceKey)
+		a.start(t)
+
+		require.True(t, a.svc.v2)
+		require.Equal(t, Name, a.svc.Name())
+		require.Len(t, a.features.Protocols(discoverfeatures.PID(Spec)), 1)
+	})
+
+	t.Run("V2 envelope not supported", func(t *testing.T) {
+		a := newAgent(t, aliceDID, aliceKey)
+		a.packager.v2 = false
+		a.start(t)
+
+		require.False(t, a.svc.v2)
+		require.Empty(t, a.features.Protocols(discoverfeatures.PID(Spec)))
+		require.True(t, errors.Is(a.svc.Upgrade("conn-"+aliceKey), ErrNotSupported))
+
+		a.provider.packager = nil
+		a.start(t)
+		require.False(t, a.svc.v2)
+	})
+
+	t.Run("services error", func(t *testing.T) {
+		a := newAgent(t, aliceDID, aliceKey)
+
+		services := a.provider.services
+		a.provider.services = map[string]interface{}{}
+
+		_, err := New(a.provider)
+		require.EqualError(t, err, "didupdate service: service not found")
+
+		a.provider.services = map[string]interface{}{didupdate.Name: "invalid"}
+		_, err = New(a.provider)
+		require.EqualError(t, err, "cast service to didupdate service failed")
+
+		a.provider.services = map[string]interface{}{didupdate.Name: services[didupdate.Name]}
+		_, err = New(a.provider)
+		require.EqualError(t, err, "discover features service: service not found")
+
+		a.provider.services[discoverfeatures.Name] = "invalid"
+		_, err = New(a.provider)
+		require.EqualError(t, err, "cast service to discover features service failed")
+	})
+}
+
+func TestService_Upgrade(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		alice.start(t)
+		bob.start(t)
+
+		events := make(chan service.StateMsg, 1)
+		require.NoError(t, bob.svc.RegisterMsgEvent(events))
+
+		require.NoError(t, alice.svc.Upgrade("conn-"+aliceKey))
+
+		select {
+		case e := <-events:
+			require.Equal(t, StateUpgraded, e.StateID)
+
+			props, ok := e.Properties.(*eventProps)
+			require.True(t, ok)
+			require.Equal(t, "conn-"+bobKey, props.ConnectionID())
+			require.Equal(t, ProfileDIDCommV2, props.Profile())
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		for _, a := range []*agent{alice, bob} {
+			require.Equal(t, ProfileDIDCommV2, a.record(t).MediaTypeProfile)
+			require.Equal(t, ecdh1pu.EncodingType, a.packager.encoding(a.peer.key))
+			requireAcceptsV2(t, a.resolve(t, aliceDID), true)
+			requireAcceptsV2(t, a.resolve(t, bobDID), true)
+		}
+
+		// the DIDs are kept
+		require.Equal(t, bobDID, alice.record(t).TheirDID)
+
+		// the upgrade is run again on the upgraded connection
+		require.NoError(t, alice.svc.Upgrade("conn-"+aliceKey))
+		require.Equal(t, ProfileDIDCommV2, alice.record(t).MediaTypeProfile)
+	})
+
+	t.Run("response lost", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		alice.start(t)
+		bob.start(t)
+
+		alice.svc.timeout = 100 * time.Millisecond
+
+		// bob upgrades the connection but its response is lost
+		upgraded := make(chan service.StateMsg, 2)
+		require.NoError(t, bob.svc.RegisterMsgEvent(upgraded))
+
+		bob.dropType = ResponseMsgType
+
+		err := alice.svc.Upgrade("conn-" + aliceKey)
+		require.True(t, errors.Is(err, ErrTimeout))
+		require.Empty(t, alice.record(t).MediaTypeProfile)
+
+		<-upgraded
+		require.Equal(t, ProfileDIDCommV2, bob.record(t).MediaTypeProfile)
+
+		// the upgrade run again reconciles the agents
+		bob.dropType = ""
+
+		require.NoError(t, alice.svc.Upgrade("conn-"+aliceKey))
+
+		for _, a := range []*agent{alice, bob} {
+			require.Equal(t, ProfileDIDCommV2, a.record(t).MediaTypeProfile)
+			require.Equal(t, ecdh1pu.EncodingType, a.packager.encoding(a.peer.key))
+		}
+	})
+
+	t.Run("peer without V2 support", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		bob.packager.v2 = false
+		alice.start(t)
+		bob.start(t)
+
+		err := alice.svc.Upgrade("conn-" + aliceKey)
+		require.True(t, errors.Is(err, ErrPeerNotSupported))
+
+		require.Empty(t, alice.record(t).MediaTypeProfile)
+		require.Empty(t, alice.packager.encoding(bobKey))
+		requireAcceptsV2(t, alice.resolve(t, aliceDID), false)
+	})
+
+	t.Run("upgrade rejected", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		bob.updater.err = errors.New("store error")
+		alice.start(t)
+		bob.start(t)
+
+		err := alice.svc.Upgrade("conn-" + aliceKey)
+		require.True(t, errors.Is(err, ErrRejected))
+		require.Contains(t, err.Error(), codeUpgradeFailed)
+
+		// the connection keeps the V1 envelope
+		for _, a := range []*agent{alice, bob} {
+			require.Empty(t, a.record(t).MediaTypeProfile)
+			require.Empty(t, a.packager.encoding(a.peer.key))
+		}
+
+		requireAcceptsV2(t, alice.resolve(t, bobDID), false)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		alice.start(t)
+		bob.start(t)
+
+		// the peer discloses the protocol but the request is lost
+		bob.svc = nil
+		alice.svc.timeout = 10 * time.Millisecond
+
+		err := alice.svc.Upgrade("conn-" + aliceKey)
+		require.True(t, errors.Is(err, ErrTimeout))
+		require.Empty(t, alice.record(t).MediaTypeProfile)
+		require.Empty(t, alice.packager.encoding(bobKey))
+		require.Empty(t, alice.svc.requests)
+	})
+
+	t.Run("discover features error", func(t *testing.T) {
+		alice, _ := newAgents(t)
+		alice.sendErr = errors.New("send error")
+		alice.start(t)
+
+		err := alice.svc.Upgrade("conn-" + aliceKey)
+		require.EqualError(t, err, "discover features: send query: send error")
+	})
+
+	t.Run("select envelope error", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		alice.packager.err = errors.New("store error")
+		alice.start(t)
+		bob.start(t)
+
+		err := alice.svc.Upgrade("conn-" + aliceKey)
+		require.EqualError(t, err, "select envelope encoding: store error")
+		require.Empty(t, alice.record(t).MediaTypeProfile)
+	})
+
+	t.Run("update error", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		alice.updater.err = errors.New("store error")
+		alice.start(t)
+		bob.start(t)
+
+		err := alice.svc.Upgrade("conn-" + aliceKey)
+		require.EqualError(t, err, "update DID: store error")
+	})
+
+	t.Run("invalid connection", func(t *testing.T) {
+		alice, _ := newAgents(t)
+		alice.start(t)
+
+		err := alice.svc.Upgrade("unknown")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get connection record")
+
+		require.NoError(t, alice.svc.connections.SaveConnectionRecord(&connection.Record{
+			ConnectionID: "requested",
+			State:        "requested",
+		}))
+
+		err = alice.svc.Upgrade("requested")
+		require.EqualError(t, err, "connection requested is not completed")
+	})
+}
+
+func TestService_HandleInbound(t *testing.T) {
+	t.Run("profile not supported", func(t *testing.T) {
+		alice, bob := newAgents(t)
+		bob.start(t)
+
+		_, err := bob.svc.HandleInbound(service.NewDIDCommMsgMap(Request{
+			ID:      "request",
+			Type:    RequestMsgType,
+			Profile: "didcomm/v3",
+		}), bobDID, aliceDID)
+		require.EqualError(t, err, "profile didcomm/v3 not supported")
+		require.Error(t, <-alice.errs)
+	})
+
+	t.Run("unknown connection", func(t *testing.T) {
+		_, bob := newAgents(t)
+		bob.start(t)
+
+		_, err := bob.svc.HandleInbound(service.NewDIDCommMsgMap(Request{
+			ID:      "request",
+			Type:    RequestMsgType,
+			Profile: ProfileDIDCommV2,
+		}), bobDID, "did:example:carl")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "connection of the request")
+		requireAcceptsV2(t, bob.resolve(t, bobDID), false)
+	})
+
+	t.Run("reply to an unknown request", func(t *testing.T) {
+		alice, _ := newAgents(t)
+		alice.start(t)
+
+		_, err := alice.svc.HandleInbound(service.NewDIDCommMsgMap(Response{
+			ID:   "response",
+			Type: ResponseMsgType,
+		}), aliceDID, bobDID)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid messages", func(t *testing.T) {
+		alice, _ := newAgents(t)
+		alice.start(t)
+
+		require.True(t, alice.svc.Accept(RequestMsgType))
+		require.True(t, alice.svc.Accept(ProblemReportMsgType))
+		require.False(t, alice.svc.Accept(discoverfeatures.QueryMsgType))
+
+		_, err := alice.svc.HandleInbound(service.DIDCommMsgMap{
+			"@type":   RequestMsgType,
+			"profile": map[string]interface{}{},
+		}, aliceDID, bobDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode request")
+
+		_, err = alice.svc.HandleInbound(service.DIDCommMsgMap{"@type": ResponseMsgType}, aliceDID, bobDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "threadID")
+
+		_, err = alice.svc.HandleInbound(service.DIDCommMsgMap{
+			"@id":         "report",
+			"@type":       ProblemReportMsgType,
+			"description": "code",
+		}, aliceDID, bobDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode problem report")
+
+		_, err = alice.svc.HandleInbound(service.NewDIDCommMsgMap(Request{Type: "unknown"}), aliceDID, bobDID)
+		require.EqualError(t, err, "unrecognized msgType: unknown")
+
+		require.EqualError(t, alice.svc.HandleOutbound(nil, aliceDID, bobDID), "not implemented")
+	})
+}
+
+func TestAcceptProfile(t *testing.T) {
+	doc := newDoc(aliceDID, aliceKey)
+
+	updated, err := acceptProfile(doc, ProfileDIDCommV2)
+	require.NoError(t, err)
+	requireAcceptsV2(t, updated, true)
+	requireAcceptsV2(t, doc, false)
+	require.Equal(t, doc.Service[0].RecipientKeys, updated.Service[0].RecipientKeys)
+
+	updated, err = acceptProfile(updated, ProfileDIDCommV2)
+	require.NoError(t, err)
+	require.Nil(t, updated)
+
+	doc.Service = nil
+
+	_, err = acceptProfile(doc, ProfileDIDCommV2)
+	require.EqualError(t, err, "no did-communication service")
+}
@@ -32,6 +32,15 @@ type Timing struct {
 	ExpiresTime time.Time `json:"expires_time,omitempty"`
 }
 
+// FromPrior contains the claims of the from_prior JWT by which the sender of a message rotates its DID:
+// the JWT is signed with a key of the prior DID (iss) and declares the new DID (sub) of the sender.
+// https://identity.foundation/didcomm-messaging/spec/#did-rotation
+type FromPrior struct {
+	ISS string `json:"iss"`
+	SUB string `json:"sub"`
+	IAT int64  `json:"iat,omitempty"`
+}
+
 // Transport transport decorator
 // https://github.com/hyperledger/aries-rfcs/tree/master/features/0092-transport-return-route
 type Transport struct {
@@ -87,3 +96,12 @@ type AttachmentData struct {
 	// and when the content is natively conveyable as JSON. Optional.
 	JSON interface{} `json:"json,omitempty"`
 }
+
+// AttachmentFormat declares the format of an attachment of a message (the formats field of the issue
+// credential and present proof v2 messages), the attachments are decoded according to their format.
+type AttachmentFormat struct {
+	// AttachID is the ID of the attachment.
+	AttachID string `json:"attach_id"`
+	// Format is the identifier of the format of the attachment, e.g "dif/presentation-exchange/definitions@v1.0".
+	Format string `json:"format"`
+}
@@ -6,6 +6,16 @@ SPDX-License-Identifier: Apache-2.0
 
 package didexchange
 
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
 // didExchangeEvent implements didexchange.Event interface.
 type didExchangeEvent struct {
 	connectionID string
@@ -22,6 +32,11 @@ func (ex *didExchangeEvent) InvitationID() string {
 	return ex.invitationID
 }
 
+// PayloadVersion returns the version of the event properties.
+func (ex *didExchangeEvent) PayloadVersion() service.EventPayloadVersion {
+	return service.EventPayloadV1
+}
+
 // didExchangeEvent for sending events with processing error.
 type didExchangeEventError struct {
 	*didExchangeEvent
@@ -36,3 +51,47 @@ func (ex *didExchangeEventError) Error() string {
 
 	return ""
 }
+
+// didExchangeEventV2 is the version 2 of the event properties (service.EventPayloadV2), it adds the connection
+// record and the DID document of the other agent.
+type didExchangeEventV2 struct {
+	*didExchangeEvent
+	record       *connection.Record
+	vdriRegistry vdriapi.Registry
+}
+
+// PayloadVersion returns the version of the event properties.
+func (ex *didExchangeEventV2) PayloadVersion() service.EventPayloadVersion {
+	return service.EventPayloadV2
+}
+
+// ConnectionRecord returns the connection record at the state of the event.
+func (ex *didExchangeEventV2) ConnectionRecord() *connection.Record {
+	return ex.record
+}
+
+// TheirDIDDoc resolves the DID document of the other agent, the DID is not known before the request
+// (inviter) or the response (invitee) is received.
+func (ex *didExchangeEventV2) TheirDIDDoc() (*did.Doc, error) {
+	if ex.record.TheirDID == "" {
+		return nil, errors.New("the DID of the other agent is not known at this state")
+	}
+
+	doc, err := ex.vdriRegistry.Resolve(ex.record.TheirDID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve DID %s: %w", ex.record.TheirDID, err)
+	}
+
+	return doc, nil
+}
+
+// didExchangeEventErrorV2 is the version 2 of the properties of the events with processing error.
+type didExchangeEventErrorV2 struct {
+	*didExchangeEventV2
+	err error
+}
+
+// Error implements error interface.
+func (ex *didExchangeEventErrorV2) Error() string {
+	return ex.err.Error()
+}
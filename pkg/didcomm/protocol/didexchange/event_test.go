@@ -11,12 +11,19 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 )
 
 func TestDIDExchangeEvent(t *testing.T) {
 	ev := didExchangeEvent{connectionID: "abc", invitationID: "xyz"}
 	require.Equal(t, ev.ConnectionID(), "abc")
 	require.Equal(t, ev.InvitationID(), "xyz")
+	require.Equal(t, service.EventPayloadV1, ev.PayloadVersion())
 
 	err := errors.New("processing error")
 	evErr := didExchangeEventError{err: err}
@@ -25,3 +32,68 @@ func TestDIDExchangeEvent(t *testing.T) {
 	evErr = didExchangeEventError{}
 	require.Equal(t, "", evErr.Error())
 }
+
+func TestService_EventProperties(t *testing.T) {
+	record := &connection.Record{ConnectionID: "abc", InvitationID: "xyz", TheirDID: "did:example:bob"}
+	processErr := errors.New("processing error")
+
+	t.Run("version 1", func(t *testing.T) {
+		svc := &Service{ctx: &context{idGenerator: idgen.UUIDv4()}}
+
+		require.Equal(t, &didExchangeEvent{connectionID: "abc", invitationID: "xyz"}, svc.eventProperties(record))
+
+		props, ok := svc.errorEventProperties(record, processErr).(*didExchangeEventError)
+		require.True(t, ok)
+		require.Equal(t, "abc", props.ConnectionID())
+		require.Empty(t, props.InvitationID())
+		require.EqualError(t, props, processErr.Error())
+	})
+
+	t.Run("version 2", func(t *testing.T) {
+		theirDoc := &did.Doc{ID: "did:example:bob"}
+
+		svc := &Service{
+			ctx:                 &context{vdriRegistry: &mockvdri.MockVDRIRegistry{ResolveValue: theirDoc}},
+			eventPayloadVersion: service.EventPayloadV2,
+		}
+
+		props, ok := svc.eventProperties(record).(*didExchangeEventV2)
+		require.True(t, ok)
+		require.Equal(t, service.EventPayloadV2, props.PayloadVersion())
+		require.Equal(t, "abc", props.ConnectionID())
+		require.Equal(t, "xyz", props.InvitationID())
+		require.Equal(t, record, props.ConnectionRecord())
+
+		// the properties are not updated by the next states
+		record.State = "completed"
+		require.Empty(t, props.ConnectionRecord().State)
+
+		doc, err := props.TheirDIDDoc()
+		require.NoError(t, err)
+		require.Equal(t, theirDoc, doc)
+
+		errProps, ok := svc.errorEventProperties(record, processErr).(*didExchangeEventErrorV2)
+		require.True(t, ok)
+		require.Equal(t, "xyz", errProps.InvitationID())
+		require.EqualError(t, errProps, processErr.Error())
+	})
+
+	t.Run("version 2 - their DID document", func(t *testing.T) {
+		svc := &Service{
+			ctx:                 &context{vdriRegistry: &mockvdri.MockVDRIRegistry{ResolveErr: errors.New("resolve error")}},
+			eventPayloadVersion: service.EventPayloadV2,
+		}
+
+		props, ok := svc.eventProperties(&connection.Record{ConnectionID: "abc"}).(*didExchangeEventV2)
+		require.True(t, ok)
+
+		_, err := props.TheirDIDDoc()
+		require.EqualError(t, err, "the DID of the other agent is not known at this state")
+
+		props, ok = svc.eventProperties(record).(*didExchangeEventV2)
+		require.True(t, ok)
+
+		_, err = props.TheirDIDDoc()
+		require.EqualError(t, err, "resolve DID did:example:bob: resolve error")
+	})
+}
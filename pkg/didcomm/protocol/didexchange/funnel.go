@@ -0,0 +1,199 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didexchange
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+// FunnelStage is a stage of the establishment of the connections created from the invitations of the agent.
+type FunnelStage string
+
+// Stages of the connection establishment funnel, as seen by the inviter.
+const (
+	// FunnelInvitationCreated is reached when the agent creates an invitation.
+	FunnelInvitationCreated FunnelStage = "invitation-created"
+	// FunnelRequestReceived is reached when the agent receives an exchange request answering an invitation.
+	FunnelRequestReceived FunnelStage = "request-received"
+	// FunnelResponseSent is reached when the agent sends the exchange response.
+	FunnelResponseSent FunnelStage = "response-sent"
+	// FunnelCompleted is reached when the invitee acknowledges the response: the connection is established.
+	FunnelCompleted FunnelStage = "completed"
+	// FunnelAbandoned is reached when the exchange fails after the request was received.
+	FunnelAbandoned FunnelStage = "abandoned"
+)
+
+// funnelRetention is the time the funnel keeps the creation time of the invitations and the last stage
+// of the exchanges, the stages reached later are reported without their timing.
+const funnelRetention = 24 * time.Hour
+
+// FunnelEvent reports that a connection, or an invitation, reached a stage of the funnel.
+type FunnelEvent struct {
+	Stage FunnelStage
+	// InvitationID is the ID of the invitation the connection is created from.
+	InvitationID string
+	// ConnectionID is the ID of the connection, empty for FunnelInvitationCreated.
+	ConnectionID string
+	// Time is the time the stage was reached.
+	Time time.Time
+	// SinceInvitation is the time elapsed since the invitation was created, 0 if it was not created by the
+	// agent within the retention time (e.g before a restart, or a public invitation).
+	SinceInvitation time.Duration
+	// SincePrevious is the time elapsed since the previous stage of the connection, 0 if it is unknown.
+	SincePrevious time.Duration
+}
+
+// FunnelMetrics is the instrumentation hook of the connection establishment funnel, e.g to export the drop-off
+// rate and the latency of each stage of the onboarding to a monitoring system. It is called synchronously by
+// the service, it should not block.
+type FunnelMetrics interface {
+	FunnelEvent(event *FunnelEvent)
+}
+
+// funnel records the stages reached by the connections created from the invitations of the agent.
+type funnel struct {
+	mu          sync.Mutex
+	metrics     FunnelMetrics
+	counters    map[FunnelStage]uint64
+	invitations map[string]time.Time
+	// stages are the times of the last stage of the exchanges in progress, by connection ID
+	stages map[string]time.Time
+	now    func() time.Time
+}
+
+func newFunnel() *funnel {
+	return &funnel{
+		counters:    map[FunnelStage]uint64{},
+		invitations: map[string]time.Time{},
+		stages:      map[string]time.Time{},
+		now:         time.Now,
+	}
+}
+
+// SetFunnelMetrics sets the instrumentation hook of the connection establishment funnel.
+func (s *Service) SetFunnelMetrics(metrics FunnelMetrics) {
+	s.funnel.mu.Lock()
+	defer s.funnel.mu.Unlock()
+
+	s.funnel.metrics = metrics
+}
+
+// FunnelCounters returns the number of invitations and connections which reached each stage of the funnel
+// since the service started, e.g the drop-off rate of the onboarding is 1 - completed / request-received.
+func (s *Service) FunnelCounters() map[FunnelStage]uint64 {
+	s.funnel.mu.Lock()
+	defer s.funnel.mu.Unlock()
+
+	counters := make(map[FunnelStage]uint64, len(s.funnel.counters))
+
+	for stage, count := range s.funnel.counters {
+		counters[stage] = count
+	}
+
+	return counters
+}
+
+// InvitationCreated records the creation of an invitation, the first stage of the funnel. The invitations
+// created by the didexchange and out-of-band clients are recorded.
+func (s *Service) InvitationCreated(invitationID string) {
+	s.funnel.invitationCreated(invitationID)
+}
+
+func (f *funnel) invitationCreated(invitationID string) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	f.prune(now)
+	f.invitations[invitationID] = now
+
+	f.report(&FunnelEvent{Stage: FunnelInvitationCreated, InvitationID: invitationID, Time: now})
+}
+
+// stateReached records the stage of the funnel matching the state of the connection record, if any.
+// Only the exchanges of the inviter are recorded.
+func (f *funnel) stateReached(record *connection.Record) {
+	if f == nil || record.Namespace != theirNSPrefix {
+		return
+	}
+
+	var stage FunnelStage
+
+	switch record.State {
+	case stateNameRequested:
+		stage = FunnelRequestReceived
+	case stateNameResponded:
+		stage = FunnelResponseSent
+	case stateNameCompleted:
+		stage = FunnelCompleted
+	case stateNameAbandoned:
+		stage = FunnelAbandoned
+	default:
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	f.prune(now)
+
+	event := &FunnelEvent{
+		Stage:        stage,
+		InvitationID: record.InvitationID,
+		ConnectionID: record.ConnectionID,
+		Time:         now,
+	}
+
+	if created, ok := f.invitations[record.InvitationID]; ok {
+		event.SinceInvitation = now.Sub(created)
+	}
+
+	if previous, ok := f.stages[record.ConnectionID]; ok {
+		event.SincePrevious = now.Sub(previous)
+	} else if stage == FunnelRequestReceived {
+		event.SincePrevious = event.SinceInvitation
+	}
+
+	if stage == FunnelCompleted || stage == FunnelAbandoned {
+		delete(f.stages, record.ConnectionID)
+	} else {
+		f.stages[record.ConnectionID] = now
+	}
+
+	f.report(event)
+}
+
+func (f *funnel) report(event *FunnelEvent) {
+	f.counters[event.Stage]++
+
+	if f.metrics != nil {
+		f.metrics.FunnelEvent(event)
+	}
+}
+
+// prune forgets the invitations and the exchanges older than the retention time.
+func (f *funnel) prune(now time.Time) {
+	for id, created := range f.invitations {
+		if now.Sub(created) > funnelRetention {
+			delete(f.invitations, id)
+		}
+	}
+
+	for id, reached := range f.stages {
+		if now.Sub(reached) > funnelRetention {
+			delete(f.stages, id)
+		}
+	}
+}
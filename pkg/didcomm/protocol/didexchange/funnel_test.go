@@ -0,0 +1,188 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didexchange
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+// funnelRecorder records the funnel events
+type funnelRecorder struct {
+	mu     sync.Mutex
+	events []*FunnelEvent
+}
+
+func (r *funnelRecorder) FunnelEvent(event *FunnelEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *funnelRecorder) stages() []FunnelStage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stages := make([]FunnelStage, len(r.events))
+
+	for i, event := range r.events {
+		stages[i] = event.Stage
+	}
+
+	return stages
+}
+
+// clock is the fake clock of the funnel
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestFunnel() (*Service, *clock, *funnelRecorder) {
+	c := &clock{now: time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)}
+	recorder := &funnelRecorder{}
+
+	s := &Service{funnel: newFunnel()}
+	s.funnel.now = func() time.Time { return c.now }
+	s.SetFunnelMetrics(recorder)
+
+	return s, c, recorder
+}
+
+func TestFunnel(t *testing.T) {
+	t.Run("Timing of the stages", func(t *testing.T) {
+		s, c, recorder := newTestFunnel()
+
+		s.InvitationCreated("invitation")
+
+		record := &connection.Record{ConnectionID: "conn", InvitationID: "invitation", Namespace: theirNSPrefix}
+
+		for _, state := range []string{stateNameRequested, stateNameResponded, stateNameCompleted} {
+			c.advance(time.Minute)
+
+			record.State = state
+			s.funnel.stateReached(record)
+		}
+
+		require.Len(t, recorder.events, 4)
+		require.Equal(t, &FunnelEvent{
+			Stage:        FunnelInvitationCreated,
+			InvitationID: "invitation",
+			Time:         c.now.Add(-3 * time.Minute),
+		}, recorder.events[0])
+		require.Equal(t, &FunnelEvent{
+			Stage:           FunnelRequestReceived,
+			InvitationID:    "invitation",
+			ConnectionID:    "conn",
+			Time:            c.now.Add(-2 * time.Minute),
+			SinceInvitation: time.Minute,
+			SincePrevious:   time.Minute,
+		}, recorder.events[1])
+		require.Equal(t, FunnelResponseSent, recorder.events[2].Stage)
+		require.Equal(t, 2*time.Minute, recorder.events[2].SinceInvitation)
+		require.Equal(t, time.Minute, recorder.events[2].SincePrevious)
+		require.Equal(t, FunnelCompleted, recorder.events[3].Stage)
+		require.Equal(t, 3*time.Minute, recorder.events[3].SinceInvitation)
+		require.Equal(t, time.Minute, recorder.events[3].SincePrevious)
+
+		// the completed exchanges are forgotten
+		require.Empty(t, s.funnel.stages)
+	})
+
+	t.Run("Drop-off", func(t *testing.T) {
+		s, _, _ := newTestFunnel()
+
+		for _, id := range []string{"a", "b", "c"} {
+			s.InvitationCreated(id)
+		}
+
+		s.funnel.stateReached(&connection.Record{ConnectionID: "a", InvitationID: "a", State: stateNameRequested,
+			Namespace: theirNSPrefix})
+		s.funnel.stateReached(&connection.Record{ConnectionID: "a", InvitationID: "a", State: stateNameAbandoned,
+			Namespace: theirNSPrefix})
+		s.funnel.stateReached(&connection.Record{ConnectionID: "b", InvitationID: "b", State: stateNameRequested,
+			Namespace: theirNSPrefix})
+
+		require.Equal(t, map[FunnelStage]uint64{
+			FunnelInvitationCreated: 3,
+			FunnelRequestReceived:   2,
+			FunnelAbandoned:         1,
+		}, s.FunnelCounters())
+	})
+
+	t.Run("Exchanges of the invitee and other states are not recorded", func(t *testing.T) {
+		s, _, recorder := newTestFunnel()
+
+		s.funnel.stateReached(&connection.Record{ConnectionID: "conn", State: stateNameRequested, Namespace: myNSPrefix})
+		s.funnel.stateReached(&connection.Record{ConnectionID: "conn", State: stateNameInvited, Namespace: theirNSPrefix})
+
+		require.Empty(t, recorder.events)
+		require.Empty(t, s.FunnelCounters())
+	})
+
+	t.Run("Unknown invitation", func(t *testing.T) {
+		s, _, recorder := newTestFunnel()
+
+		s.funnel.stateReached(&connection.Record{ConnectionID: "conn", InvitationID: "public", State: stateNameRequested,
+			Namespace: theirNSPrefix})
+
+		require.Len(t, recorder.events, 1)
+		require.Zero(t, recorder.events[0].SinceInvitation)
+		require.Zero(t, recorder.events[0].SincePrevious)
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		s, c, recorder := newTestFunnel()
+
+		s.InvitationCreated("old")
+		s.funnel.stateReached(&connection.Record{ConnectionID: "stalled", InvitationID: "old", State: stateNameRequested,
+			Namespace: theirNSPrefix})
+
+		c.advance(funnelRetention + time.Second)
+		s.InvitationCreated("new")
+
+		require.Len(t, s.funnel.invitations, 1)
+		require.Empty(t, s.funnel.stages)
+
+		s.funnel.stateReached(&connection.Record{ConnectionID: "stalled", InvitationID: "old", State: stateNameAbandoned,
+			Namespace: theirNSPrefix})
+		require.Zero(t, recorder.events[3].SinceInvitation)
+		require.Zero(t, recorder.events[3].SincePrevious)
+	})
+
+	t.Run("Services without funnel", func(t *testing.T) {
+		s := &Service{}
+
+		require.NotPanics(t, func() {
+			s.InvitationCreated("invitation")
+			s.funnel.stateReached(&connection.Record{State: stateNameRequested, Namespace: theirNSPrefix})
+		})
+	})
+}
+
+func TestSaveInvitation_Funnel(t *testing.T) {
+	s, _, recorder := newTestFunnel()
+
+	connectionStore, err := newConnectionStore(&protocol.MockProvider{})
+	require.NoError(t, err)
+
+	s.connectionStore = connectionStore
+
+	require.NoError(t, s.SaveInvitation(&OOBInvitation{ID: "id", ThreadID: "request"}))
+	require.Equal(t, []FunnelStage{FunnelInvitationCreated}, recorder.stages())
+	require.Equal(t, "request", recorder.events[0].InvitationID)
+}
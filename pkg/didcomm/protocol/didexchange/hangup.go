@@ -0,0 +1,78 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didexchange
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+// Hangup terminates the completed connection identified by connectionID.
+// A hangup message is sent to the other party before the connection record is removed, so both
+// agents can clean up the connection. The reason is optional and is passed along to the other party.
+func (s *Service) Hangup(connectionID, reason string) error {
+	connRecord, err := s.connectionStore.GetConnectionRecord(connectionID)
+	if err != nil {
+		return fmt.Errorf("hangup - fetch connection record: %w", err)
+	}
+
+	if connRecord.State != stateNameCompleted {
+		return fmt.Errorf("hangup - connection %s is not completed: state=%s", connectionID, connRecord.State)
+	}
+
+	msg := &Hangup{
+		Type:   HangupMsgType,
+		ID:     s.ctx.idGenerator.NewID(),
+		Reason: reason,
+	}
+
+	err = s.ctx.outboundDispatcher.SendToDID(msg, connRecord.MyDID, connRecord.TheirDID)
+	if err != nil {
+		return fmt.Errorf("hangup - send message: %w", err)
+	}
+
+	return s.terminate(connRecord, service.NewDIDCommMsgMap(msg))
+}
+
+// handleHangup terminates the connection between myDID and theirDID upon receipt of a hangup message.
+func (s *Service) handleHangup(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
+	connectionID, err := s.connectionStore.GetConnectionIDByDIDs(myDID, theirDID)
+	if err != nil {
+		return "", fmt.Errorf("handle hangup - find connection: %w", err)
+	}
+
+	connRecord, err := s.connectionStore.GetConnectionRecord(connectionID)
+	if err != nil {
+		return "", fmt.Errorf("handle hangup - fetch connection record: %w", err)
+	}
+
+	if err = s.terminate(connRecord, msg); err != nil {
+		return "", fmt.Errorf("handle hangup: %w", err)
+	}
+
+	return connectionID, nil
+}
+
+// terminate removes the connection record and triggers the terminated state event.
+func (s *Service) terminate(connRecord *connection.Record, msg service.DIDCommMsg) error {
+	err := s.connectionStore.RemoveConnection(connRecord.ConnectionID)
+	if err != nil {
+		return fmt.Errorf("remove connection: %w", err)
+	}
+
+	s.sendMsgEvents(&service.StateMsg{
+		ProtocolName: DIDExchange,
+		Type:         service.PostState,
+		Msg:          msg,
+		StateID:      stateNameTerminated,
+		Properties:   s.eventProperties(connRecord),
+	})
+
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didexchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	mockdispatcher "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/dispatcher"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol"
+	mockroute "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+func newHangupService(t *testing.T, outbound *mockdispatcher.MockOutbound) *Service {
+	svc, err := New(&protocol.MockProvider{
+		ServiceMap: map[string]interface{}{
+			route.Coordination: &mockroute.MockRouteSvc{},
+		},
+		CustomOutbound: outbound,
+	})
+	require.NoError(t, err)
+
+	return svc
+}
+
+func saveCompletedConnection(t *testing.T, svc *Service, myDID, theirDID string) *connection.Record {
+	connRec := &connection.Record{
+		ConnectionID: randomString(),
+		ThreadID:     randomString(),
+		InvitationID: randomString(),
+		Namespace:    myNSPrefix,
+		State:        stateNameCompleted,
+		MyDID:        myDID,
+		TheirDID:     theirDID,
+	}
+
+	require.NoError(t, svc.connectionStore.saveConnectionRecordWithMapping(connRec))
+
+	return connRec
+}
+
+func TestService_Hangup(t *testing.T) {
+	t.Run("sends hangup and removes the connection", func(t *testing.T) {
+		var sent *Hangup
+
+		svc := newHangupService(t, &mockdispatcher.MockOutbound{
+			ValidateSendToDID: func(msg interface{}, myDID, theirDID string) error {
+				require.Equal(t, "mydid", myDID)
+				require.Equal(t, "theirdid", theirDID)
+
+				var ok bool
+				sent, ok = msg.(*Hangup)
+				require.True(t, ok)
+
+				return nil
+			},
+		})
+
+		statusCh := make(chan service.StateMsg, 10)
+		require.NoError(t, svc.RegisterMsgEvent(statusCh))
+
+		connRec := saveCompletedConnection(t, svc, "mydid", "theirdid")
+
+		require.NoError(t, svc.Hangup(connRec.ConnectionID, "bye"))
+		require.NotNil(t, sent)
+		require.Equal(t, HangupMsgType, sent.Type)
+		require.NotEmpty(t, sent.ID)
+		require.Equal(t, "bye", sent.Reason)
+
+		select {
+		case e := <-statusCh:
+			require.Equal(t, service.PostState, e.Type)
+			require.Equal(t, stateNameTerminated, e.StateID)
+
+			prop, ok := e.Properties.(event)
+			require.True(t, ok)
+			require.Equal(t, connRec.ConnectionID, prop.ConnectionID())
+			require.Equal(t, connRec.InvitationID, prop.InvitationID())
+		case <-time.After(time.Second):
+			require.Fail(t, "terminated event not received")
+		}
+
+		_, err := svc.connectionStore.GetConnectionRecord(connRec.ConnectionID)
+		require.Error(t, err)
+	})
+
+	t.Run("connection not found", func(t *testing.T) {
+		svc := newHangupService(t, nil)
+
+		err := svc.Hangup("unknown", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "hangup - fetch connection record")
+	})
+
+	t.Run("connection not completed", func(t *testing.T) {
+		svc := newHangupService(t, nil)
+
+		connRec := &connection.Record{
+			ConnectionID: randomString(),
+			ThreadID:     randomString(),
+			Namespace:    myNSPrefix,
+			State:        stateNameRequested,
+		}
+		require.NoError(t, svc.connectionStore.saveConnectionRecord(connRec))
+
+		err := svc.Hangup(connRec.ConnectionID, "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not completed")
+	})
+
+	t.Run("send error keeps the connection", func(t *testing.T) {
+		svc := newHangupService(t, &mockdispatcher.MockOutbound{SendErr: errors.New("send error")})
+
+		connRec := saveCompletedConnection(t, svc, "mydid", "theirdid")
+
+		err := svc.Hangup(connRec.ConnectionID, "")
+		require.EqualError(t, err, "hangup - send message: send error")
+
+		_, err = svc.connectionStore.GetConnectionRecord(connRec.ConnectionID)
+		require.NoError(t, err)
+	})
+}
+
+func TestService_HandleInboundHangup(t *testing.T) {
+	t.Run("removes the connection", func(t *testing.T) {
+		svc := newHangupService(t, nil)
+
+		statusCh := make(chan service.StateMsg, 10)
+		require.NoError(t, svc.RegisterMsgEvent(statusCh))
+
+		connRec := saveCompletedConnection(t, svc, "mydid", "theirdid")
+
+		msg := service.NewDIDCommMsgMap(&Hangup{Type: HangupMsgType, ID: randomString(), Reason: "bye"})
+
+		connID, err := svc.HandleInbound(msg, "mydid", "theirdid")
+		require.NoError(t, err)
+		require.Equal(t, connRec.ConnectionID, connID)
+
+		select {
+		case e := <-statusCh:
+			require.Equal(t, stateNameTerminated, e.StateID)
+			require.Equal(t, "bye", e.Msg.(service.DIDCommMsgMap)["reason"])
+		case <-time.After(time.Second):
+			require.Fail(t, "terminated event not received")
+		}
+
+		_, err = svc.connectionStore.GetConnectionRecord(connRec.ConnectionID)
+		require.Error(t, err)
+	})
+
+	t.Run("unknown connection", func(t *testing.T) {
+		svc := newHangupService(t, nil)
+
+		msg := service.NewDIDCommMsgMap(&Hangup{Type: HangupMsgType, ID: randomString()})
+
+		_, err := svc.HandleInbound(msg, "mydid", "theirdid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "handle hangup - find connection")
+	})
+}
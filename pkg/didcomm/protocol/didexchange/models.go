@@ -28,6 +28,8 @@ type OOBInvitation struct {
 	// - a string with a valid DID
 	// - a valid `did.Service`
 	Target interface{}
+	// HandshakeProtocol negotiated by the out-of-band protocol, recorded on the connection.
+	HandshakeProtocol string
 }
 
 // Invitation model
@@ -96,3 +98,12 @@ type Connection struct {
 	DID    string   `json:"did,omitempty"`
 	DIDDoc *did.Doc `json:"did_doc,omitempty"`
 }
+
+// Hangup signals the intentional termination of an established connection to the other party.
+// Upon receipt, the other party cleans up the connection and does not expect any further message on it.
+type Hangup struct {
+	Type string `json:"@type,omitempty"`
+	ID   string `json:"@id,omitempty"`
+	// Reason is an optional human readable explanation of why the connection was terminated.
+	Reason string `json:"reason,omitempty"`
+}
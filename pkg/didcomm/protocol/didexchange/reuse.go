@@ -0,0 +1,96 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didexchange
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+// reuseConnection looks for a completed connection with the inviter of the invitation and triggers
+// the reuse-available event when one is found. The existing connection is returned when the connections
+// are reused, nil otherwise (a new connection is then created for the invitation).
+func (s *Service) reuseConnection(invitationDID string, msg service.DIDCommMsg) (*connection.Record, error) {
+	if invitationDID == "" {
+		// the inviter is only identified by the recipient keys of the invitation
+		return nil, nil
+	}
+
+	existing, err := s.findCompletedConnection(invitationDID)
+	if err != nil {
+		return nil, fmt.Errorf("reuse connection: %w", err)
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	logger.Debugf("invitation from %s matches the completed connection %s", invitationDID, existing.ConnectionID)
+
+	s.sendMsgEvents(&service.StateMsg{
+		ProtocolName: DIDExchange,
+		Type:         service.PostState,
+		Msg:          msg,
+		StateID:      stateNameReuseAvailable,
+		Properties:   s.eventProperties(existing),
+	})
+
+	if !s.connectionReuse {
+		return nil, nil
+	}
+
+	return existing, nil
+}
+
+// findCompletedConnection returns the completed connection with the agent identified by theirDID, the DID
+// of the invitation is matched as well since the inviter may have answered with a new (pairwise) DID.
+func (s *Service) findCompletedConnection(theirDID string) (*connection.Record, error) {
+	records, err := s.connectionStore.QueryConnectionRecords()
+	if err != nil {
+		return nil, fmt.Errorf("query connection records: %w", err)
+	}
+
+	for _, record := range records {
+		if record.State != stateNameCompleted {
+			continue
+		}
+
+		if record.TheirDID == theirDID || record.InvitationDID == theirDID {
+			return record, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// invitationDID returns the public DID of the inviter, empty if the invitation has none.
+func invitationDID(msg service.DIDCommMsg) (string, error) {
+	switch msg.Type() {
+	case InvitationMsgType:
+		invitation := &Invitation{}
+
+		if err := msg.Decode(invitation); err != nil {
+			return "", fmt.Errorf("decode invitation: %w", err)
+		}
+
+		return invitation.DID, nil
+	case oobMsgType:
+		var invitation OOBInvitation
+
+		if err := msg.Decode(&invitation); err != nil {
+			return "", fmt.Errorf("decode oob invitation: %w", err)
+		}
+
+		publicDID, _ := invitation.Target.(string)
+
+		return publicDID, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didexchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol"
+	mockroute "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol/route"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+func newReuseService(t *testing.T, reuse bool) (*Service, *did.Doc) {
+	pubKey, _ := generateKeyPair()
+	inviterDoc := createDIDDocWithKey(pubKey)
+
+	prov := &protocol.MockProvider{
+		StoreProvider: mockstorage.NewMockStoreProvider(),
+		ServiceMap: map[string]interface{}{
+			route.Coordination: &mockroute.MockRouteSvc{},
+		},
+		CustomVDRI:       &mockvdri.MockVDRIRegistry{ResolveValue: inviterDoc},
+		ReuseConnections: reuse,
+	}
+
+	svc, err := New(prov)
+	require.NoError(t, err)
+	require.Equal(t, reuse, svc.connectionReuse)
+
+	return svc, inviterDoc
+}
+
+func reuseAvailableEvent(t *testing.T, statusCh chan service.StateMsg) service.StateMsg {
+	t.Helper()
+
+	for {
+		select {
+		case e := <-statusCh:
+			if e.StateID == stateNameReuseAvailable {
+				return e
+			}
+		case <-time.After(time.Second):
+			require.Fail(t, "reuse-available event not received")
+
+			return service.StateMsg{}
+		}
+	}
+}
+
+func invitationWithDID(t *testing.T, publicDID string) service.DIDCommMsg {
+	msg, err := createDIDCommMsg(&Invitation{
+		ID:    uuid.New().String(),
+		Label: "inviter",
+		DID:   publicDID,
+		Type:  InvitationMsgType,
+	})
+	require.NoError(t, err)
+
+	return msg
+}
+
+func TestService_ReuseConnection(t *testing.T) {
+	t.Run("invitation from a connected agent triggers the reuse-available event", func(t *testing.T) {
+		svc, inviterDoc := newReuseService(t, false)
+
+		statusCh := make(chan service.StateMsg, 10)
+		require.NoError(t, svc.RegisterMsgEvent(statusCh))
+
+		connRec := saveCompletedConnection(t, svc, "mydid", inviterDoc.ID)
+
+		connID, err := svc.HandleInbound(invitationWithDID(t, inviterDoc.ID), "", "")
+		require.NoError(t, err)
+		require.NotEqual(t, connRec.ConnectionID, connID)
+
+		e := reuseAvailableEvent(t, statusCh)
+		require.Equal(t, service.PostState, e.Type)
+		require.Equal(t, InvitationMsgType, e.Msg.Type())
+
+		prop, ok := e.Properties.(event)
+		require.True(t, ok)
+		require.Equal(t, connRec.ConnectionID, prop.ConnectionID())
+	})
+
+	t.Run("existing connection is reused", func(t *testing.T) {
+		svc, inviterDoc := newReuseService(t, true)
+
+		statusCh := make(chan service.StateMsg, 10)
+		require.NoError(t, svc.RegisterMsgEvent(statusCh))
+
+		connRec := saveCompletedConnection(t, svc, "mydid", inviterDoc.ID)
+
+		connID, err := svc.HandleInbound(invitationWithDID(t, inviterDoc.ID), "", "")
+		require.NoError(t, err)
+		require.Equal(t, connRec.ConnectionID, connID)
+		reuseAvailableEvent(t, statusCh)
+
+		records, err := svc.connectionStore.QueryConnectionRecords()
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+	})
+
+	t.Run("inviter answered with a pairwise DID", func(t *testing.T) {
+		svc, inviterDoc := newReuseService(t, true)
+
+		connRec := &connection.Record{
+			ConnectionID:  randomString(),
+			ThreadID:      randomString(),
+			Namespace:     myNSPrefix,
+			State:         stateNameCompleted,
+			InvitationDID: inviterDoc.ID,
+			MyDID:         "mydid",
+			TheirDID:      inviterDoc.ID,
+		}
+		require.NoError(t, svc.connectionStore.saveConnectionRecordWithMapping(connRec))
+
+		// the DID of the other agent is not the public DID of the invitation
+		connRec.TheirDID = "did:peer:pairwise"
+		require.NoError(t, svc.connectionStore.SaveConnectionRecord(connRec))
+
+		connID, err := svc.HandleInbound(invitationWithDID(t, inviterDoc.ID), "", "")
+		require.NoError(t, err)
+		require.Equal(t, connRec.ConnectionID, connID)
+	})
+
+	t.Run("out-of-band invitation", func(t *testing.T) {
+		svc, inviterDoc := newReuseService(t, true)
+
+		connRec := saveCompletedConnection(t, svc, "mydid", inviterDoc.ID)
+
+		connID, err := svc.RespondTo(newInvitation(inviterDoc.ID))
+		require.NoError(t, err)
+		require.Equal(t, connRec.ConnectionID, connID)
+	})
+
+	t.Run("implicit invitation", func(t *testing.T) {
+		svc, inviterDoc := newReuseService(t, true)
+
+		connRec := saveCompletedConnection(t, svc, "mydid", inviterDoc.ID)
+
+		connID, err := svc.CreateImplicitInvitation("inviter", inviterDoc.ID, "invitee", "")
+		require.NoError(t, err)
+		require.Equal(t, connRec.ConnectionID, connID)
+	})
+
+	t.Run("connection with the inviter is not completed", func(t *testing.T) {
+		svc, inviterDoc := newReuseService(t, true)
+
+		connRec := &connection.Record{
+			ConnectionID: randomString(),
+			ThreadID:     randomString(),
+			Namespace:    myNSPrefix,
+			State:        stateNameRequested,
+			TheirDID:     inviterDoc.ID,
+		}
+		require.NoError(t, svc.connectionStore.saveConnectionRecord(connRec))
+
+		connID, err := svc.HandleInbound(invitationWithDID(t, inviterDoc.ID), "", "")
+		require.NoError(t, err)
+		require.NotEqual(t, connRec.ConnectionID, connID)
+	})
+
+	t.Run("query connection records error", func(t *testing.T) {
+		svc, inviterDoc := newReuseService(t, true)
+
+		// the stored record can't be unmarshalled
+		store := &mockstorage.MockStore{Store: map[string][]byte{"conn_invalid": []byte("invalid")}}
+		recorder, err := connection.NewRecorder(&protocol.MockProvider{
+			StoreProvider: mockstorage.NewCustomMockStoreProvider(store),
+		})
+		require.NoError(t, err)
+
+		svc.connectionStore.Recorder = recorder
+
+		_, err = svc.HandleInbound(invitationWithDID(t, inviterDoc.ID), "", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "reuse connection: query connection records")
+	})
+}
@@ -11,8 +11,7 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
@@ -25,7 +24,9 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 )
 
-var logger = log.New("aries-framework/did-exchange/service")
+const logModule = "aries-framework/did-exchange/service"
+
+var logger = log.New(logModule)
 
 const (
 	// DIDExchange did exchange protocol
@@ -40,6 +41,10 @@ const (
 	ResponseMsgType = DIDExchangeSpec + "response"
 	// AckMsgType defines the did-exchange ack message type.
 	AckMsgType = DIDExchangeSpec + "ack"
+	// HangupMsgType defines the did-exchange hangup message type (signals connection termination).
+	HangupMsgType = DIDExchangeSpec + "hangup"
+	// HandshakeProtocol identifies the did-exchange protocol in the handshake protocols of the out-of-band messages.
+	HandshakeProtocol = "https://didcomm.org/didexchange/1.0"
 
 	oobMsgType = "oob-invitation"
 )
@@ -65,6 +70,13 @@ type provider interface {
 	Signer() legacykms.Signer
 	VDRIRegistry() vdriapi.Registry
 	Service(id string) (interface{}, error)
+	// EventPayloadVersion returns the version of the event properties, see service.EventPayloadVersion.
+	EventPayloadVersion() service.EventPayloadVersion
+	// Instrumentation returns the instrumentation hook, service.NoopInstrumentation if none is configured.
+	Instrumentation() service.Instrumentation
+	// ConnectionReuse tells whether the invitations from connected agents reuse the existing connection,
+	// see reuseConnection.
+	ConnectionReuse() bool
 }
 
 // stateMachineMsg is an internal struct used to pass data to state machine.
@@ -81,6 +93,13 @@ type Service struct {
 	ctx             *context
 	callbackChannel chan *message
 	connectionStore *connectionStore
+	// eventPayloadVersion is the version of the event properties, see service.EventPayloadVersion
+	eventPayloadVersion service.EventPayloadVersion
+	instrumentation     service.Instrumentation
+	// connectionReuse tells whether the invitations from connected agents reuse the existing connection
+	connectionReuse bool
+	// funnel records the stages of the establishment of the connections created from the invitations
+	funnel *funnel
 }
 
 type context struct {
@@ -89,6 +108,7 @@ type context struct {
 	connectionStore    *connectionStore
 	vdriRegistry       vdriapi.Registry
 	routeSvc           route.ProtocolService
+	idGenerator        idgen.Generator
 }
 
 // opts are used to provide client properties to DID Exchange service
@@ -126,10 +146,15 @@ func New(prov provider) (*Service, error) {
 			vdriRegistry:       prov.VDRIRegistry(),
 			connectionStore:    connRecorder,
 			routeSvc:           routeSvc,
+			idGenerator:        idgen.FromProvider(prov),
 		},
 		// TODO channel size - https://github.com/hyperledger/aries-framework-go/issues/246
-		callbackChannel: make(chan *message, callbackChannelSize),
-		connectionStore: connRecorder,
+		callbackChannel:     make(chan *message, callbackChannelSize),
+		connectionStore:     connRecorder,
+		eventPayloadVersion: prov.EventPayloadVersion(),
+		instrumentation:     prov.Instrumentation(),
+		connectionReuse:     prov.ConnectionReuse(),
+		funnel:              newFunnel(),
 	}
 
 	// start the listener
@@ -142,8 +167,13 @@ func New(prov provider) (*Service, error) {
 func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
 	logger.Debugf("receive inbound message : %s", msg)
 
+	// hangup is not part of the exchange thread, it refers to the connection between the two DIDs
+	if msg.Type() == HangupMsgType {
+		return s.handleHangup(msg, myDID, theirDID)
+	}
+
 	// fetch the thread id
-	thID, err := threadID(msg)
+	thID, err := threadID(msg, s.ctx.idGenerator)
 	if err != nil {
 		return "", err
 	}
@@ -154,6 +184,20 @@ func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string)
 		return "", fmt.Errorf("handle inbound - next state : %w", err)
 	}
 
+	publicDID, err := invitationDID(msg)
+	if err != nil {
+		return "", fmt.Errorf("handle inbound : %w", err)
+	}
+
+	existing, err := s.reuseConnection(publicDID, msg)
+	if err != nil {
+		return "", fmt.Errorf("handle inbound : %w", err)
+	}
+
+	if existing != nil {
+		return existing.ConnectionID, nil
+	}
+
 	// connection record
 	connRecord, err := s.connectionRecord(msg)
 	if err != nil {
@@ -209,7 +253,8 @@ func (s *Service) Accept(msgType string) bool {
 	return msgType == InvitationMsgType ||
 		msgType == RequestMsgType ||
 		msgType == ResponseMsgType ||
-		msgType == AckMsgType
+		msgType == AckMsgType ||
+		msgType == HangupMsgType
 }
 
 // HandleOutbound handles outbound didexchange messages.
@@ -218,7 +263,9 @@ func (s *Service) HandleOutbound(msg service.DIDCommMsg, myDID, theirDID string)
 }
 
 func (s *Service) nextState(msgType, thID string) (state, error) {
-	logger.Debugf("msgType=%s thID=%s", msgType, thID)
+	stateLogger := logger.WithFields(log.Fields{log.FieldProtocol: DIDExchange, log.FieldThread: thID})
+
+	stateLogger.Debugf("msgType=%s", msgType)
 
 	nsThID, err := connection.CreateNamespaceKey(findNamespace(msgType), thID)
 	if err != nil {
@@ -230,14 +277,14 @@ func (s *Service) nextState(msgType, thID string) (state, error) {
 		return nil, err
 	}
 
-	logger.Debugf("retrieved current state [%s] using nsThID [%s]", current.Name(), nsThID)
+	stateLogger.Debugf("retrieved current state [%s] using nsThID [%s]", current.Name(), nsThID)
 
 	next, err := stateFromMsgType(msgType)
 	if err != nil {
 		return nil, err
 	}
 
-	logger.Debugf("check if current state [%s] can transition to [%s]", current.Name(), next.Name())
+	stateLogger.Debugf("check if current state [%s] can transition to [%s]", current.Name(), next.Name())
 
 	if !current.CanTransitionTo(next) {
 		return nil, fmt.Errorf("invalid state transition: %s -> %s", current.Name(), next.Name())
@@ -247,7 +294,12 @@ func (s *Service) nextState(msgType, thID string) (state, error) {
 }
 
 func (s *Service) handle(msg *message, aEvent chan<- service.DIDCommAction) error { //nolint funlen
-	logger.Debugf("handling msg: %+v", msg)
+	msgLogger := logger.WithFields(log.Fields{log.FieldProtocol: DIDExchange, log.FieldThread: msg.ThreadID})
+	if msg.ConnRecord != nil {
+		msgLogger = msgLogger.WithFields(log.Fields{log.FieldConnection: msg.ConnRecord.ConnectionID})
+	}
+
+	msgLogger.Debugf("handling msg: %+v", msg)
 
 	next, err := stateFromName(msg.NextStateName)
 	if err != nil {
@@ -260,9 +312,10 @@ func (s *Service) handle(msg *message, aEvent chan<- service.DIDCommAction) erro
 			Type:         service.PreState,
 			Msg:          msg.Msg,
 			StateID:      next.Name(),
-			Properties:   createEventProperties(msg.ConnRecord.ConnectionID, msg.ConnRecord.InvitationID),
+			Properties:   s.eventProperties(msg.ConnRecord),
 		})
-		logger.Debugf("sent pre event for state %s", next.Name())
+		stateLogger := msgLogger.WithFields(log.Fields{log.FieldState: next.Name()})
+		stateLogger.Debugf("sent pre event")
 
 		var (
 			action           stateAction
@@ -284,19 +337,21 @@ func (s *Service) handle(msg *message, aEvent chan<- service.DIDCommAction) erro
 		}
 
 		connectionRecord.State = next.Name()
-		logger.Debugf("finished execute state: %s", next.Name())
+		stateLogger.Debugf("finished execute state")
 
 		if err = s.update(msg.Msg.Type(), connectionRecord); err != nil {
 			return fmt.Errorf("failed to persist state %s %w", next.Name(), err)
 		}
 
-		logger.Debugf("updated connection record %+v", connectionRecord)
+		stateLogger.Debugf("updated connection record %+v", connectionRecord)
+		s.instrumentation.StateTransition(DIDExchange, msg.ThreadID, next.Name())
 
 		if err = action(); err != nil {
 			return fmt.Errorf("failed to execute state action %s %w", next.Name(), err)
 		}
 
-		logger.Debugf("finish execute state action: %s", next.Name())
+		stateLogger.Debugf("finish execute state action")
+		s.funnel.stateReached(connectionRecord)
 
 		prev := next
 		next = followup
@@ -317,12 +372,12 @@ func (s *Service) handle(msg *message, aEvent chan<- service.DIDCommAction) erro
 			Type:         service.PostState,
 			Msg:          msg.Msg,
 			StateID:      prev.Name(),
-			Properties:   createEventProperties(connectionRecord.ConnectionID, connectionRecord.InvitationID),
+			Properties:   s.eventProperties(connectionRecord),
 		})
-		logger.Debugf("sent post event for state %s", prev.Name())
+		stateLogger.Debugf("sent post event")
 
 		if haltExecution {
-			logger.Debugf("halted execution before state=%s", msg.NextStateName)
+			msgLogger.Debugf("halted execution before state=%s", msg.NextStateName)
 			break
 		}
 	}
@@ -348,6 +403,35 @@ func createErrorEventProperties(connectionID, invitationID string, err error) *d
 	}
 }
 
+// eventProperties returns the properties of the event in the configured version.
+func (s *Service) eventProperties(record *connection.Record) service.EventProperties {
+	if s.eventPayloadVersion < service.EventPayloadV2 {
+		return createEventProperties(record.ConnectionID, record.InvitationID)
+	}
+
+	return s.eventPropertiesV2(record)
+}
+
+// errorEventProperties returns the properties of the event with processing error in the configured version.
+func (s *Service) errorEventProperties(record *connection.Record, err error) service.EventProperties {
+	if s.eventPayloadVersion < service.EventPayloadV2 {
+		return createErrorEventProperties(record.ConnectionID, "", err)
+	}
+
+	return &didExchangeEventErrorV2{didExchangeEventV2: s.eventPropertiesV2(record), err: err}
+}
+
+func (s *Service) eventPropertiesV2(record *connection.Record) *didExchangeEventV2 {
+	// the record is updated by the next states
+	recordCopy := *record
+
+	return &didExchangeEventV2{
+		didExchangeEvent: createEventProperties(record.ConnectionID, record.InvitationID),
+		record:           &recordCopy,
+		vdriRegistry:     s.ctx.vdriRegistry,
+	}
+}
+
 // sendActionEvent triggers the action event. This function stores the state of current processing and passes a callback
 // function in the event message.
 func (s *Service) sendActionEvent(internalMsg *message, aEvent chan<- service.DIDCommAction) error {
@@ -377,7 +461,7 @@ func (s *Service) sendActionEvent(internalMsg *message, aEvent chan<- service.DI
 				internalMsg.err = err
 				s.processCallback(internalMsg)
 			},
-			Properties: createEventProperties(internalMsg.ConnRecord.ConnectionID, internalMsg.ConnRecord.InvitationID),
+			Properties: s.eventProperties(internalMsg.ConnRecord),
 		}
 	}
 
@@ -387,9 +471,7 @@ func (s *Service) sendActionEvent(internalMsg *message, aEvent chan<- service.DI
 // sendEvent triggers the message events.
 func (s *Service) sendMsgEvents(msg *service.StateMsg) {
 	// trigger the message events
-	for _, handler := range s.MsgEvents() {
-		handler <- *msg
-	}
+	s.TriggerMsgEvents(*msg)
 }
 
 // startInternalListener listens to messages in gochannel for callback messages from clients.
@@ -407,7 +489,8 @@ func (s *Service) startInternalListener() {
 		}
 
 		if err := s.abandon(msg.ThreadID, msg.Msg, msg.err); err != nil {
-			logger.Errorf("process callback : %s", err)
+			logger.WithFields(log.Fields{log.FieldProtocol: DIDExchange, log.FieldThread: msg.ThreadID}).
+				Errorf("process callback : %s", err)
 		}
 	}
 }
@@ -438,6 +521,8 @@ func (s *Service) SaveInvitation(i *OOBInvitation) error {
 		return fmt.Errorf("failed to save oob invitation : %w", err)
 	}
 
+	s.funnel.invitationCreated(i.ThreadID)
+
 	return nil
 }
 
@@ -507,13 +592,15 @@ func (s *Service) abandon(thID string, msg service.DIDCommMsg, processErr error)
 		return fmt.Errorf("unable to update the state to abandoned: %w", err)
 	}
 
+	s.funnel.stateReached(connRec)
+
 	// send the message event
 	s.sendMsgEvents(&service.StateMsg{
 		ProtocolName: DIDExchange,
 		Type:         service.PostState,
 		Msg:          msg,
 		StateID:      stateNameAbandoned,
-		Properties:   createErrorEventProperties(connRec.ConnectionID, "", processErr),
+		Properties:   s.errorEventProperties(connRec, processErr),
 	})
 
 	return nil
@@ -530,9 +617,9 @@ func isNoOp(s state) bool {
 	return ok
 }
 
-func threadID(didCommMsg service.DIDCommMsg) (string, error) {
+func threadID(didCommMsg service.DIDCommMsg, g idgen.Generator) (string, error) {
 	if didCommMsg.Type() == InvitationMsgType || didCommMsg.Type() == oobMsgType {
-		return generateRandomID(), nil
+		return g.NewID(), nil
 	}
 
 	return didCommMsg.ThreadID()
@@ -597,15 +684,16 @@ func (s *Service) oobInvitationMsgRecord(msg service.DIDCommMsg) (*connection.Re
 	}
 
 	connRecord := &connection.Record{
-		ConnectionID:    generateRandomID(),
-		ThreadID:        thID,
-		ParentThreadID:  oobInvitation.ThreadID,
-		State:           stateNameNull,
-		InvitationID:    oobInvitation.ID,
-		ServiceEndPoint: svc.ServiceEndpoint,
-		RecipientKeys:   svc.RecipientKeys,
-		TheirLabel:      oobInvitation.Label,
-		Namespace:       findNamespace(msg.Type()),
+		ConnectionID:      s.ctx.idGenerator.NewID(),
+		ThreadID:          thID,
+		ParentThreadID:    oobInvitation.ThreadID,
+		State:             stateNameNull,
+		InvitationID:      oobInvitation.ID,
+		ServiceEndPoint:   svc.ServiceEndpoint,
+		RecipientKeys:     svc.RecipientKeys,
+		TheirLabel:        oobInvitation.Label,
+		Namespace:         findNamespace(msg.Type()),
+		HandshakeProtocol: oobInvitation.HandshakeProtocol,
 	}
 
 	publicDID, ok := oobInvitation.Target.(string)
@@ -640,7 +728,7 @@ func (s *Service) invitationMsgRecord(msg service.DIDCommMsg) (*connection.Recor
 	}
 
 	connRecord := &connection.Record{
-		ConnectionID:    generateRandomID(),
+		ConnectionID:    s.ctx.idGenerator.NewID(),
 		ThreadID:        thID,
 		State:           stateNameNull,
 		InvitationID:    invitation.ID,
@@ -667,13 +755,24 @@ func (s *Service) requestMsgRecord(msg service.DIDCommMsg) (*connection.Record,
 	}
 
 	connRecord := &connection.Record{
-		ConnectionID: generateRandomID(),
+		ConnectionID: s.ctx.idGenerator.NewID(),
 		ThreadID:     request.ID,
 		State:        stateNameNull,
 		TheirDID:     request.Connection.DID,
 		Namespace:    theirNSPrefix,
 	}
 
+	if request.Thread != nil && s.hasInvitation(request.Thread.PID) {
+		// the invitee answered an invitation of this agent, e.g the protocols connecting the agents
+		// (introduce) find the connection by the invitation ID
+		connRecord.InvitationID = request.Thread.PID
+	}
+
+	if request.Thread != nil && s.isOOBInvitation(request.Thread.PID) {
+		// the invitee answered the out-of-band message with a did-exchange request
+		connRecord.HandshakeProtocol = HandshakeProtocol
+	}
+
 	if err := s.connectionStore.saveConnectionRecord(connRecord); err != nil {
 		return nil, err
 	}
@@ -681,6 +780,32 @@ func (s *Service) requestMsgRecord(msg service.DIDCommMsg) (*connection.Record,
 	return connRecord, nil
 }
 
+// hasInvitation tells whether the invitation with the given ID was saved by this agent.
+func (s *Service) hasInvitation(invitationID string) bool {
+	if invitationID == "" {
+		return false
+	}
+
+	var invitation map[string]interface{}
+
+	return s.connectionStore.GetInvitation(invitationID, &invitation) == nil
+}
+
+// isOOBInvitation tells whether the invitation with the given ID is an out-of-band invitation saved by this agent.
+func (s *Service) isOOBInvitation(invitationID string) bool {
+	if invitationID == "" {
+		return false
+	}
+
+	var invitation OOBInvitation
+
+	if err := s.connectionStore.GetInvitation(invitationID, &invitation); err != nil {
+		return false
+	}
+
+	return invitation.Type == oobMsgType
+}
+
 func (s *Service) responseMsgRecord(payload service.DIDCommMsg) (*connection.Record, error) {
 	return s.fetchConnectionRecord(myNSPrefix, payload)
 }
@@ -707,10 +832,6 @@ func (s *Service) fetchConnectionRecord(nsPrefix string, payload service.DIDComm
 	return s.connectionStore.GetConnectionRecordByNSThreadID(key)
 }
 
-func generateRandomID() string {
-	return uuid.New().String()
-}
-
 // canTriggerActionEvents true based on role and state.
 // 1. Role is invitee and state is invited
 // 2. Role is inviter and state is requested
@@ -725,6 +846,7 @@ type options struct {
 
 // CreateImplicitInvitation creates implicit invitation. Inviter DID is required, invitee DID is optional.
 // If invitee DID is not provided new peer DID will be created for implicit invitation exchange request.
+// The ID of the completed connection with the inviter DID is returned instead if the connections are reused.
 func (s *Service) CreateImplicitInvitation(inviterLabel, inviterDID, inviteeLabel, inviteeDID string) (string, error) {
 	logger.Debugf("implicit invitation requested inviterDID[%s] inviteeDID[%s]", inviterDID, inviteeDID)
 
@@ -738,9 +860,29 @@ func (s *Service) CreateImplicitInvitation(inviterLabel, inviterDID, inviteeLabe
 		return "", err
 	}
 
-	thID := generateRandomID()
+	invitation := &Invitation{
+		ID:    s.ctx.idGenerator.NewID(),
+		Label: inviterLabel,
+		DID:   inviterDID,
+		Type:  InvitationMsgType}
+
+	msg, err := createDIDCommMsg(invitation)
+	if err != nil {
+		return "", fmt.Errorf("failed to create DIDCommMsg for implicit invitation: %w", err)
+	}
+
+	existing, err := s.reuseConnection(inviterDID, msg)
+	if err != nil {
+		return "", fmt.Errorf("implicit invitation: %w", err)
+	}
+
+	if existing != nil {
+		return existing.ConnectionID, nil
+	}
+
+	thID := s.ctx.idGenerator.NewID()
 	connRecord := &connection.Record{
-		ConnectionID:    generateRandomID(),
+		ConnectionID:    s.ctx.idGenerator.NewID(),
 		ThreadID:        thID,
 		State:           stateNameNull,
 		InvitationDID:   inviterDID,
@@ -755,17 +897,6 @@ func (s *Service) CreateImplicitInvitation(inviterLabel, inviterDID, inviteeLabe
 		return "", fmt.Errorf("failed to save new connection record for implicit invitation: %w", e)
 	}
 
-	invitation := &Invitation{
-		ID:    uuid.New().String(),
-		Label: inviterLabel,
-		DID:   inviterDID,
-		Type:  InvitationMsgType}
-
-	msg, err := createDIDCommMsg(invitation)
-	if err != nil {
-		return "", fmt.Errorf("failed to create DIDCommMsg for implicit invitation: %w", err)
-	}
-
 	// TODO: get rid of this  msg.(service.DIDCommMsgMap)
 	next := &requested{}
 	internalMsg := &message{
@@ -17,6 +17,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
@@ -98,6 +99,7 @@ func TestService_Handle_Inviter(t *testing.T) {
 	require.NotNil(t, connectionStore)
 
 	ctx := &context{
+		idGenerator:        idgen.UUIDv4(),
 		outboundDispatcher: prov.OutboundDispatcher(),
 		vdriRegistry:       &mockvdri.MockVDRIRegistry{CreateValue: createDIDDocWithKey(pubKey)},
 		signer:             &mockSigner{privateKey: privKey},
@@ -136,6 +138,10 @@ func TestService_Handle_Inviter(t *testing.T) {
 	err = ctx.connectionStore.SaveInvitation(invitation.ID, invitation)
 	require.NoError(t, err)
 
+	funnelMetrics := &funnelRecorder{}
+	s.SetFunnelMetrics(funnelMetrics)
+	s.InvitationCreated(invitation.ID)
+
 	thid := randomString()
 
 	// Invitation was previously sent by Alice to Bob.
@@ -188,6 +194,21 @@ func TestService_Handle_Inviter(t *testing.T) {
 	}
 
 	validateState(t, s, thid, findNamespace(AckMsgType), (&completed{}).Name())
+
+	require.Equal(t, map[FunnelStage]uint64{
+		FunnelInvitationCreated: 1,
+		FunnelRequestReceived:   1,
+		FunnelResponseSent:      1,
+		FunnelCompleted:         1,
+	}, s.FunnelCounters())
+	require.Equal(t, []FunnelStage{FunnelInvitationCreated, FunnelRequestReceived, FunnelResponseSent, FunnelCompleted},
+		funnelMetrics.stages())
+
+	for _, event := range funnelMetrics.events[1:] {
+		require.Equal(t, invitation.ID, event.InvitationID)
+		require.NotEmpty(t, event.ConnectionID)
+		require.NotZero(t, event.SinceInvitation)
+	}
 }
 
 func msgEventListener(t *testing.T, statusCh chan service.StateMsg, respondedFlag, completedFlag chan struct{}) {
@@ -242,6 +263,7 @@ func TestService_Handle_Invitee(t *testing.T) {
 	require.NotNil(t, connectionStore)
 
 	ctx := context{
+		idGenerator:        idgen.UUIDv4(),
 		outboundDispatcher: prov.OutboundDispatcher(),
 		vdriRegistry:       &mockvdri.MockVDRIRegistry{CreateValue: createDIDDocWithKey(pubKey)},
 		signer:             &mockSigner{privateKey: privKey},
@@ -493,20 +515,21 @@ func TestService_Accept(t *testing.T) {
 	require.Equal(t, true, s.Accept("https://didcomm.org/didexchange/1.0/request"))
 	require.Equal(t, true, s.Accept("https://didcomm.org/didexchange/1.0/response"))
 	require.Equal(t, true, s.Accept("https://didcomm.org/didexchange/1.0/ack"))
+	require.Equal(t, true, s.Accept("https://didcomm.org/didexchange/1.0/hangup"))
 	require.Equal(t, false, s.Accept("unsupported msg type"))
 }
 
 func TestService_threadID(t *testing.T) {
 	t.Run("returns new thid for ", func(t *testing.T) {
 		didMsg := service.NewDIDCommMsgMap(Invitation{Type: InvitationMsgType})
-		thid, err := threadID(didMsg)
+		thid, err := threadID(didMsg, idgen.UUIDv4())
 		require.NoError(t, err)
 		require.NotNil(t, thid)
 	})
 
 	t.Run("returns unmarshall error", func(t *testing.T) {
 		didMsg := service.NewDIDCommMsgMap(Request{Type: RequestMsgType})
-		_, err := threadID(didMsg)
+		_, err := threadID(didMsg, idgen.UUIDv4())
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "threadID not found")
 	})
@@ -1000,6 +1023,17 @@ func TestInvitationRecord(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "threadID not found")
 
+	require.Empty(t, conn.HandshakeProtocol)
+
+	// request answering an out-of-band invitation
+	oobInvitation := newOOBInvite("did:example:public")
+	require.NoError(t, svc.SaveInvitation(oobInvitation))
+
+	conn, err = svc.requestMsgRecord(generateRequestMsgPayload(t, &protocol.MockProvider{},
+		randomString(), oobInvitation.ThreadID))
+	require.NoError(t, err)
+	require.Equal(t, HandshakeProtocol, conn.HandshakeProtocol)
+
 	// db error
 	svc, err = New(&protocol.MockProvider{
 		TransientStoreProvider: mockstorage.NewCustomMockStoreProvider(&mockstorage.MockStore{
@@ -1039,6 +1073,16 @@ func TestRequestRecord(t *testing.T) {
 		randomString(), ""))
 	require.NoError(t, err)
 	require.NotNil(t, conn)
+	require.Empty(t, conn.InvitationID)
+
+	// the request answers an invitation of this agent
+	invitationID := randomString()
+	require.NoError(t, svc.connectionStore.SaveInvitation(invitationID, &Invitation{ID: invitationID}))
+
+	conn, err = svc.requestMsgRecord(generateRequestMsgPayload(t, &protocol.MockProvider{},
+		randomString(), invitationID))
+	require.NoError(t, err)
+	require.Equal(t, invitationID, conn.InvitationID)
 
 	// db error
 	svc, err = New(&protocol.MockProvider{
@@ -1578,7 +1622,7 @@ func generateRequestMsgPayload(t *testing.T, prov provider, id, invitationID str
 	require.NoError(t, err)
 	require.NotNil(t, connStore)
 
-	ctx := context{outboundDispatcher: prov.OutboundDispatcher(),
+	ctx := context{idGenerator: idgen.UUIDv4(), outboundDispatcher: prov.OutboundDispatcher(),
 		vdriRegistry:    &mockvdri.MockVDRIRegistry{CreateValue: mockdiddoc.GetMockDIDDoc()},
 		connectionStore: connStore}
 	newDidDoc, err := ctx.vdriRegistry.Create(testMethod)
@@ -1619,6 +1663,7 @@ func TestService_CreateImplicitInvitation(t *testing.T) {
 		require.NotNil(t, connStore)
 
 		ctx := &context{
+			idGenerator:        idgen.UUIDv4(),
 			outboundDispatcher: prov.OutboundDispatcher(),
 			vdriRegistry:       &mockvdri.MockVDRIRegistry{ResolveValue: newDIDDoc},
 			connectionStore:    connStore,
@@ -1649,6 +1694,7 @@ func TestService_CreateImplicitInvitation(t *testing.T) {
 		require.NotNil(t, connStore)
 
 		ctx := &context{
+			idGenerator:        idgen.UUIDv4(),
 			outboundDispatcher: prov.OutboundDispatcher(),
 			vdriRegistry:       &mockvdri.MockVDRIRegistry{ResolveErr: errors.New("resolve error")},
 			connectionStore:    connStore,
@@ -1683,6 +1729,7 @@ func TestService_CreateImplicitInvitation(t *testing.T) {
 		require.NotNil(t, connStore)
 
 		ctx := &context{
+			idGenerator:        idgen.UUIDv4(),
 			outboundDispatcher: prov.OutboundDispatcher(),
 			vdriRegistry:       &mockvdri.MockVDRIRegistry{ResolveValue: newDIDDoc},
 			connectionStore:    connStore,
@@ -1819,3 +1866,7 @@ func testProvider() *protocol.MockProvider {
 		},
 	}
 }
+
+func generateRandomID() string {
+	return idgen.NewID()
+}
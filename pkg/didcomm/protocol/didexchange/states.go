@@ -16,7 +16,6 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcutil/base58"
-	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
@@ -41,10 +40,16 @@ const (
 	stateNameResponded = "responded"
 	stateNameCompleted = "completed"
 	stateNameAbandoned = "abandoned"
-	ackStatusOK        = "ok"
-	didCommServiceType = "did-communication"
-	didMethod          = "peer"
-	timestamplen       = 8
+	// terminated is not part of the exchange state machine, it is the terminal state of a connection
+	// that was intentionally ended by one of the parties (see Hangup)
+	stateNameTerminated = "terminated"
+	// reuse-available is not part of the exchange state machine either, it notifies that an invitation was
+	// received from an agent with which a connection is already completed (see aries.WithConnectionReuse)
+	stateNameReuseAvailable = "reuse-available"
+	ackStatusOK             = "ok"
+	didCommServiceType      = "did-communication"
+	didMethod               = "peer"
+	timestamplen            = 8
 )
 
 var errVerKeyNotFound = errors.New("verkey not found")
@@ -404,7 +409,7 @@ func (ctx *context) handleInboundRequest(request *Request, options *options,
 	// prepare the response
 	response := &Response{
 		Type: ResponseMsgType,
-		ID:   uuid.New().String(),
+		ID:   ctx.idGenerator.NewID(),
 		Thread: &decorator.Thread{
 			ID: request.ID,
 		},
@@ -573,7 +578,7 @@ func (ctx *context) prepareConnectionSignature(connection *Connection,
 func (ctx *context) handleInboundResponse(response *Response) (stateAction, *connectionstore.Record, error) {
 	ack := &model.Ack{
 		Type:   AckMsgType,
-		ID:     uuid.New().String(),
+		ID:     ctx.idGenerator.NewID(),
 		Status: ackStatusOK,
 		Thread: &decorator.Thread{
 			ID: response.Thread.ID,
@@ -21,6 +21,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
@@ -113,7 +114,7 @@ func TestAbandonedState(t *testing.T) {
 	require.False(t, abandoned.CanTransitionTo(&requested{}))
 	require.False(t, abandoned.CanTransitionTo(&responded{}))
 	require.False(t, abandoned.CanTransitionTo(&completed{}))
-	connRec, _, _, err := abandoned.ExecuteInbound(&stateMachineMsg{}, "", &context{})
+	connRec, _, _, err := abandoned.ExecuteInbound(&stateMachineMsg{}, "", &context{idGenerator: idgen.UUIDv4()})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "not implemented")
 	require.Nil(t, connRec)
@@ -205,7 +206,7 @@ func TestStateFromName(t *testing.T) {
 
 // noOp.ExecuteInbound() returns nil, error
 func TestNoOpState_Execute(t *testing.T) {
-	_, followup, _, err := (&noOp{}).ExecuteInbound(&stateMachineMsg{}, "", &context{})
+	_, followup, _, err := (&noOp{}).ExecuteInbound(&stateMachineMsg{}, "", &context{idGenerator: idgen.UUIDv4()})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "cannot execute no-op")
 	require.Nil(t, followup)
@@ -213,7 +214,7 @@ func TestNoOpState_Execute(t *testing.T) {
 
 // null.ExecuteInbound() is a no-op
 func TestNullState_Execute(t *testing.T) {
-	_, followup, _, err := (&null{}).ExecuteInbound(&stateMachineMsg{}, "", &context{})
+	_, followup, _, err := (&null{}).ExecuteInbound(&stateMachineMsg{}, "", &context{idGenerator: idgen.UUIDv4()})
 	require.NoError(t, err)
 	require.IsType(t, &noOp{}, followup)
 }
@@ -228,7 +229,7 @@ func TestInvitedState_Execute(t *testing.T) {
 		for _, msg := range others {
 			_, _, _, err := (&invited{}).ExecuteInbound(&stateMachineMsg{
 				DIDCommMsg: msg,
-			}, "", &context{})
+			}, "", &context{idGenerator: idgen.UUIDv4()})
 			require.Error(t, err)
 			require.Contains(t, err.Error(), "illegal msg type")
 		}
@@ -249,7 +250,7 @@ func TestInvitedState_Execute(t *testing.T) {
 				connRecord: &connection.Record{},
 			},
 			"",
-			&context{})
+			&context{idGenerator: idgen.UUIDv4()})
 		require.NoError(t, err)
 		require.Equal(t, &requested{}, followup)
 		require.NotNil(t, connRec)
@@ -261,7 +262,7 @@ func TestInvitedState_Execute(t *testing.T) {
 				connRecord: &connection.Record{},
 			},
 			"",
-			&context{},
+			&context{idGenerator: idgen.UUIDv4()},
 		)
 		require.NoError(t, err)
 		require.Equal(t, &requested{}, followup)
@@ -289,7 +290,7 @@ func TestRequestedState_Execute(t *testing.T) {
 		for _, msg := range others {
 			_, _, _, e := (&requested{}).ExecuteInbound(&stateMachineMsg{
 				DIDCommMsg: msg,
-			}, "", &context{})
+			}, "", &context{idGenerator: idgen.UUIDv4()})
 			require.Error(t, e)
 			require.Contains(t, e.Error(), "illegal msg type")
 		}
@@ -299,7 +300,7 @@ func TestRequestedState_Execute(t *testing.T) {
 		msg, err := service.ParseDIDCommMsgMap(invitationPayloadBytes)
 		require.NoError(t, err)
 		// nolint: govet
-		thid, err := threadID(msg)
+		thid, err := threadID(msg, idgen.UUIDv4())
 		require.NoError(t, err)
 		connRec, _, _, e := (&requested{}).ExecuteInbound(&stateMachineMsg{
 			DIDCommMsg: msg,
@@ -469,13 +470,13 @@ func TestRequestedState_Execute(t *testing.T) {
 				"@type": InvitationMsgType,
 				"@id":   map[int]int{},
 			},
-		}, "", &context{})
+		}, "", &context{idGenerator: idgen.UUIDv4()})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "JSON unmarshalling of invitation")
 		require.Nil(t, followup)
 	})
 	t.Run("create DID error", func(t *testing.T) {
-		ctx2 := &context{outboundDispatcher: prov.OutboundDispatcher(),
+		ctx2 := &context{idGenerator: idgen.UUIDv4(), outboundDispatcher: prov.OutboundDispatcher(),
 			vdriRegistry: &mockvdri.MockVDRIRegistry{CreateErr: fmt.Errorf("create DID error")}}
 		didDoc, err := ctx2.vdriRegistry.Create(testMethod)
 		require.Error(t, err)
@@ -503,7 +504,7 @@ func TestRespondedState_Execute(t *testing.T) {
 		for _, msg := range others {
 			_, _, _, e := (&responded{}).ExecuteInbound(&stateMachineMsg{
 				DIDCommMsg: msg,
-			}, "", &context{})
+			}, "", &context{idGenerator: idgen.UUIDv4()})
 			require.Error(t, e)
 			require.Contains(t, e.Error(), "illegal msg type")
 		}
@@ -540,7 +541,7 @@ func TestRespondedState_Execute(t *testing.T) {
 	t.Run("handle inbound request unmarshalling error", func(t *testing.T) {
 		_, followup, _, err := (&responded{}).ExecuteInbound(&stateMachineMsg{
 			DIDCommMsg: service.DIDCommMsgMap{"@id": map[int]int{}, "@type": RequestMsgType},
-		}, "", &context{})
+		}, "", &context{idGenerator: idgen.UUIDv4()})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "JSON unmarshalling of request")
 		require.Nil(t, followup)
@@ -569,7 +570,7 @@ func TestAbandonedState_Execute(t *testing.T) {
 	t.Run("execute abandon state", func(t *testing.T) {
 		connRec, _, _, err := (&abandoned{}).ExecuteInbound(&stateMachineMsg{
 			DIDCommMsg: service.NewDIDCommMsgMap(Response{Type: ResponseMsgType}),
-		}, "", &context{})
+		}, "", &context{idGenerator: idgen.UUIDv4()})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "not implemented")
 		require.Nil(t, connRec)
@@ -585,7 +586,7 @@ func TestCompletedState_Execute(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, connStore)
 
-	ctx := &context{signer: &mockSigner{privateKey: privKey},
+	ctx := &context{idGenerator: idgen.UUIDv4(), signer: &mockSigner{privateKey: privKey},
 		connectionStore: connStore}
 	newDIDDoc := createDIDDocWithKey(pubKey)
 	c := &Connection{
@@ -663,7 +664,7 @@ func TestCompletedState_Execute(t *testing.T) {
 
 		for _, msg := range others {
 			_, _, _, err = (&completed{}).ExecuteInbound(&stateMachineMsg{
-				DIDCommMsg: msg}, "", &context{})
+				DIDCommMsg: msg}, "", &context{idGenerator: idgen.UUIDv4()})
 			require.Error(t, err)
 			require.Contains(t, err.Error(), "illegal msg type")
 		}
@@ -671,7 +672,7 @@ func TestCompletedState_Execute(t *testing.T) {
 	t.Run("no followup for inbound responses unmarshalling error", func(t *testing.T) {
 		_, followup, _, err := (&completed{}).ExecuteInbound(&stateMachineMsg{
 			DIDCommMsg: service.DIDCommMsgMap{"@id": map[int]int{}, "@type": ResponseMsgType},
-		}, "", &context{})
+		}, "", &context{idGenerator: idgen.UUIDv4()})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "JSON unmarshalling of response")
 		require.Nil(t, followup)
@@ -697,7 +698,7 @@ func TestVerifySignature(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, connStore)
 
-	ctx := &context{signer: &mockSigner{privateKey: privKey},
+	ctx := &context{idGenerator: idgen.UUIDv4(), signer: &mockSigner{privateKey: privKey},
 		connectionStore: connStore}
 	newDIDDoc := createDIDDocWithKey(pubKey)
 	c := &Connection{
@@ -837,7 +838,7 @@ func TestPrepareConnectionSignature(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, connStore)
 
-		ctx2 := &context{outboundDispatcher: prov.OutboundDispatcher(),
+		ctx2 := &context{idGenerator: idgen.UUIDv4(), outboundDispatcher: prov.OutboundDispatcher(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{ResolveValue: newDidDoc},
 			signer:          &mockSigner{privateKey: privKey},
 			connectionStore: connStore,
@@ -877,7 +878,7 @@ func TestPrepareConnectionSignature(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, connStore)
 
-		ctx := &context{signer: &mockSigner{err: errors.New("sign error")},
+		ctx := &context{idGenerator: idgen.UUIDv4(), signer: &mockSigner{err: errors.New("sign error")},
 			connectionStore: connStore}
 		c := &Connection{
 			DIDDoc: mockdiddoc.GetMockDIDDoc(),
@@ -904,7 +905,7 @@ func TestNewRequestFromInvitation(t *testing.T) {
 		ctx := getContext(t, &prov)
 		invitationBytes, err := json.Marshal(invitation)
 		require.NoError(t, err)
-		thid, err := threadID(bytesToDIDCommMsg(t, invitationBytes))
+		thid, err := threadID(bytesToDIDCommMsg(t, invitationBytes), idgen.UUIDv4())
 		require.NoError(t, err)
 		_, connRec, err := ctx.handleInboundInvitation(invitation, thid, &options{}, &connection.Record{})
 		require.NoError(t, err)
@@ -915,12 +916,13 @@ func TestNewRequestFromInvitation(t *testing.T) {
 		connectionStore, err := newConnectionStore(&protocol.MockProvider{})
 		require.NoError(t, err)
 		ctx := context{
+			idGenerator:     idgen.UUIDv4(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{ResolveValue: doc},
 			connectionStore: connectionStore}
 
 		invitationBytes, err := json.Marshal(invitation)
 		require.NoError(t, err)
-		thid, err := threadID(bytesToDIDCommMsg(t, invitationBytes))
+		thid, err := threadID(bytesToDIDCommMsg(t, invitationBytes), idgen.UUIDv4())
 		require.NoError(t, err)
 		_, connRec, err := ctx.handleInboundInvitation(invitation, thid, &options{publicDID: doc.ID},
 			&connection.Record{})
@@ -934,7 +936,7 @@ func TestNewRequestFromInvitation(t *testing.T) {
 			vdriRegistry: &mockvdri.MockVDRIRegistry{CreateErr: fmt.Errorf("create DID error")}}
 		invitationBytes, err := json.Marshal(&Invitation{Type: InvitationMsgType})
 		require.NoError(t, err)
-		thid, err := threadID(bytesToDIDCommMsg(t, invitationBytes))
+		thid, err := threadID(bytesToDIDCommMsg(t, invitationBytes), idgen.UUIDv4())
 		require.NoError(t, err)
 		_, connRec, err := ctx.handleInboundInvitation(invitation, thid, &options{}, &connection.Record{})
 		require.Error(t, err)
@@ -958,6 +960,7 @@ func TestNewResponseFromRequest(t *testing.T) {
 	t.Run("unsuccessful new response from request due to create did error", func(t *testing.T) {
 		didDoc := mockdiddoc.GetMockDIDDoc()
 		ctx := &context{
+			idGenerator: idgen.UUIDv4(),
 			vdriRegistry: &mockvdri.MockVDRIRegistry{
 				CreateErr:    fmt.Errorf("create DID error"),
 				ResolveValue: mockdiddoc.GetMockDIDDoc(),
@@ -1049,7 +1052,7 @@ func TestGetInvitationRecipientKey(t *testing.T) {
 		doc := mockdiddoc.GetMockDIDDoc()
 		_, ok := diddoc.LookupService(doc, "did-communication")
 		require.True(t, ok)
-		ctx := context{vdriRegistry: &mockvdri.MockVDRIRegistry{ResolveValue: doc}}
+		ctx := context{idGenerator: idgen.UUIDv4(), vdriRegistry: &mockvdri.MockVDRIRegistry{ResolveValue: doc}}
 		invitation := &Invitation{
 			Type: InvitationMsgType,
 			ID:   randomString(),
@@ -1099,6 +1102,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 		connectionStore, err := newConnectionStore(&protocol.MockProvider{})
 		require.NoError(t, err)
 		ctx := context{
+			idGenerator:     idgen.UUIDv4(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{ResolveValue: doc},
 			connectionStore: connectionStore}
 		didDoc, conn, err := ctx.getDIDDocAndConnection(doc.ID)
@@ -1109,6 +1113,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 	})
 	t.Run("error getting public did doc from resolver", func(t *testing.T) {
 		ctx := context{
+			idGenerator:  idgen.UUIDv4(),
 			vdriRegistry: &mockvdri.MockVDRIRegistry{ResolveErr: errors.New("resolver error")}}
 		didDoc, conn, err := ctx.getDIDDocAndConnection("did-id")
 		require.Error(t, err)
@@ -1131,6 +1136,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 		require.NoError(t, err)
 
 		ctx := context{
+			idGenerator:     idgen.UUIDv4(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{ResolveValue: doc},
 			connectionStore: connectionStore}
 		didDoc, conn, err := ctx.getDIDDocAndConnection(doc.ID)
@@ -1141,6 +1147,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 	})
 	t.Run("error creating peer did", func(t *testing.T) {
 		ctx := context{
+			idGenerator:  idgen.UUIDv4(),
 			vdriRegistry: &mockvdri.MockVDRIRegistry{CreateErr: errors.New("creator error")},
 			routeSvc:     &mockroute.MockRouteSvc{},
 		}
@@ -1154,6 +1161,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 		connectionStore, err := newConnectionStore(&protocol.MockProvider{})
 		require.NoError(t, err)
 		ctx := context{
+			idGenerator:     idgen.UUIDv4(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{CreateValue: mockdiddoc.GetMockDIDDoc()},
 			connectionStore: connectionStore,
 			routeSvc:        &mockroute.MockRouteSvc{},
@@ -1177,6 +1185,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 		require.NoError(t, err)
 
 		ctx := context{
+			idGenerator:     idgen.UUIDv4(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{CreateValue: mockdiddoc.GetMockDIDDoc()},
 			connectionStore: connectionStore,
 			routeSvc:        &mockroute.MockRouteSvc{},
@@ -1192,6 +1201,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 		connectionStore, err := newConnectionStore(&protocol.MockProvider{})
 		require.NoError(t, err)
 		ctx := context{
+			idGenerator:     idgen.UUIDv4(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{CreateValue: mockdiddoc.GetMockDIDDoc()},
 			connectionStore: connectionStore,
 			routeSvc:        &mockroute.MockRouteSvc{ConfigErr: errors.New("router config error")},
@@ -1207,6 +1217,7 @@ func TestGetDIDDocAndConnection(t *testing.T) {
 		connectionStore, err := newConnectionStore(&protocol.MockProvider{})
 		require.NoError(t, err)
 		ctx := context{
+			idGenerator:     idgen.UUIDv4(),
 			vdriRegistry:    &mockvdri.MockVDRIRegistry{CreateValue: mockdiddoc.GetMockDIDDoc()},
 			connectionStore: connectionStore,
 			routeSvc:        &mockroute.MockRouteSvc{AddKeyErr: errors.New("router add key error")},
@@ -1224,6 +1235,7 @@ func TestGetVerKey(t *testing.T) {
 		expected := newServiceBlock()
 		invitation := newOOBInvite(expected)
 		ctx := &context{
+			idGenerator:     idgen.UUIDv4(),
 			connectionStore: connStore(t, testProvider()),
 		}
 		err := ctx.connectionStore.SaveInvitation(invitation.ThreadID, invitation)
@@ -1237,6 +1249,7 @@ func TestGetVerKey(t *testing.T) {
 		publicDID := createDIDDoc()
 		invitation := newOOBInvite(publicDID.ID)
 		ctx := &context{
+			idGenerator:     idgen.UUIDv4(),
 			connectionStore: connStore(t, testProvider()),
 			vdriRegistry: &mockvdri.MockVDRIRegistry{
 				ResolveValue: publicDID,
@@ -1253,6 +1266,7 @@ func TestGetVerKey(t *testing.T) {
 		expected := newServiceBlock()
 		invitation := newDidExchangeInvite("", expected)
 		ctx := &context{
+			idGenerator:     idgen.UUIDv4(),
 			connectionStore: connStore(t, testProvider()),
 		}
 		err := ctx.connectionStore.SaveInvitation(invitation.ID, invitation)
@@ -1265,6 +1279,7 @@ func TestGetVerKey(t *testing.T) {
 	t.Run("returns verkey from implicit didexchange invitation", func(t *testing.T) {
 		publicDID := createDIDDoc()
 		ctx := &context{
+			idGenerator:     idgen.UUIDv4(),
 			connectionStore: connStore(t, testProvider()),
 			vdriRegistry: &mockvdri.MockVDRIRegistry{
 				ResolveValue: publicDID,
@@ -1281,6 +1296,7 @@ func TestGetVerKey(t *testing.T) {
 	t.Run("fails for oob invitation with no target", func(t *testing.T) {
 		invalid := newOOBInvite(nil)
 		ctx := &context{
+			idGenerator:     idgen.UUIDv4(),
 			connectionStore: connStore(t, testProvider()),
 		}
 		err := ctx.connectionStore.SaveInvitation(invalid.ThreadID, invalid)
@@ -1299,6 +1315,7 @@ func TestGetVerKey(t *testing.T) {
 			},
 		}
 		ctx := &context{
+			idGenerator:     idgen.UUIDv4(),
 			connectionStore: connStore(t, provider),
 		}
 
@@ -1312,6 +1329,7 @@ func TestGetVerKey(t *testing.T) {
 	t.Run("wraps error from vdri resolution", func(t *testing.T) {
 		expected := errors.New("test")
 		ctx := &context{
+			idGenerator:     idgen.UUIDv4(),
 			connectionStore: connStore(t, testProvider()),
 			vdriRegistry: &mockvdri.MockVDRIRegistry{
 				ResolveErr: expected,
@@ -1393,7 +1411,7 @@ func getContext(t *testing.T, prov *protocol.MockProvider) *context {
 	connStore, err := newConnectionStore(prov)
 	require.NoError(t, err)
 
-	return &context{outboundDispatcher: prov.OutboundDispatcher(),
+	return &context{idGenerator: idgen.UUIDv4(), outboundDispatcher: prov.OutboundDispatcher(),
 		vdriRegistry:    &mockvdri.MockVDRIRegistry{CreateValue: createDIDDocWithKey(pubKey)},
 		signer:          &mockSigner{privateKey: privKey},
 		connectionStore: connStore,
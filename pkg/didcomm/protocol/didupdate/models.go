@@ -0,0 +1,30 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didupdate
+
+// Update is sent to the other agent of each connection of a peer DID when the document of the DID changes
+// (e.g the keys or the endpoints of the agent changed). The updated document is signed with a key of the
+// prior document.
+type Update struct {
+	ID   string `json:"@id,omitempty"`
+	Type string `json:"@type,omitempty"`
+	// DID is the DID whose document is updated.
+	DID string `json:"did"`
+	// Signature is the signature of the updated document, the signed data is the JSON of the document.
+	Signature *DocSignature `json:"did_doc~sig"`
+}
+
+// DocSignature is the signature of an updated DID document.
+type DocSignature struct {
+	Type string `json:"@type,omitempty"`
+	// Signature is the base64 URL encoded signature.
+	Signature string `json:"signature"`
+	// SignedData is the base64 URL encoded JSON of the updated document.
+	SignedData string `json:"sig_data"`
+	// SignVerKey is the base58 encoded key of the prior document verifying the signature.
+	SignVerKey string `json:"signer"`
+}
@@ -0,0 +1,501 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didupdate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	didstore "github.com/hyperledger/aries-framework-go/pkg/store/did"
+	"github.com/hyperledger/aries-framework-go/pkg/vdri/peer"
+)
+
+const (
+	// Name defines the protocol name
+	Name = "did-update"
+	// Spec defines the protocol spec
+	Spec = "https://didcomm.org/did-update/1.0/"
+	// UpdateMsgType defines the protocol update message type.
+	UpdateMsgType = Spec + "update"
+
+	// StateUpdated is the state of the message event triggered once the updated document of the other agent
+	// of a connection is stored.
+	StateUpdated = "updated"
+	// StatePublished is the state of the message event triggered once the updated document of a DID of the agent
+	// is stored (see UpdateDID).
+	StatePublished = "published"
+
+	signatureType      = "https://didcomm.org/signature/1.0/ed25519Sha512_single"
+	stateNameCompleted = "completed"
+)
+
+var logger = log.New("aries-framework/didupdate/service")
+
+// Provider contains dependencies for the protocol and is typically created by using aries.Context()
+type Provider interface {
+	Messenger() service.Messenger
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+	VDRIRegistry() vdri.Registry
+	Signer() legacykms.Signer
+}
+
+// Service for the DID update protocol: it propagates the updated document of a peer DID to the other agents
+// of the connections of the DID, e.g after the agent was re-keyed or its endpoint changed.
+//
+// The updated document is signed with a key of the prior document and sent over the connections with the prior
+// keys. The receiver verifies the signature against the document it holds, then replaces it with the updated
+// document (the peer DID documents are stored at once, with their history) and updates the connection records.
+// A message event is triggered once the updated document is stored (see StateUpdated), or once the updated
+// document of a DID of the agent is stored (see StatePublished).
+type Service struct {
+	service.Message
+	messenger    service.Messenger
+	vdriRegistry vdri.Registry
+	signer       legacykms.Signer
+	connections  *connection.Recorder
+	didConnStore *didstore.ConnectionStore
+	// mu serializes the updates of the documents
+	mu          sync.Mutex
+	idGenerator idgen.Generator
+}
+
+// New returns the DID update service
+func New(p Provider) (*Service, error) {
+	connections, err := connection.NewRecorder(p)
+	if err != nil {
+		return nil, fmt.Errorf("connection recorder: %w", err)
+	}
+
+	didConnStore, err := didstore.NewConnectionStore(p)
+	if err != nil {
+		return nil, fmt.Errorf("did connection store: %w", err)
+	}
+
+	return &Service{
+		idGenerator:  idgen.FromProvider(p),
+		messenger:    p.Messenger(),
+		vdriRegistry: p.VDRIRegistry(),
+		signer:       p.Signer(),
+		connections:  connections,
+		didConnStore: didConnStore,
+	}, nil
+}
+
+// HandleInbound handles inbound message (DID update protocol)
+func (s *Service) HandleInbound(msg service.DIDCommMsg, _, theirDID string) (string, error) {
+	msgMap, ok := msg.(service.DIDCommMsgMap)
+	if !ok {
+		return "", errors.New("bad assertion message is not DIDCommMsgMap")
+	}
+
+	if msg.Type() != UpdateMsgType {
+		return "", fmt.Errorf("unrecognized msgType: %s", msg.Type())
+	}
+
+	return "", s.handleUpdate(msgMap, theirDID)
+}
+
+// HandleOutbound handles outbound message (DID update protocol)
+func (s *Service) HandleOutbound(_ service.DIDCommMsg, _, _ string) error {
+	return errors.New("not implemented")
+}
+
+// Name returns service name
+func (s *Service) Name() string {
+	return Name
+}
+
+// Accept msg checks the msg type
+func (s *Service) Accept(msgType string) bool {
+	return msgType == UpdateMsgType
+}
+
+// SendError is returned when the update of a document could not be sent over some connections, the update
+// is sent over them again with SendUpdate.
+type SendError struct {
+	// Update is the signed update of the document.
+	Update *Update
+	// Failures maps the IDs of the connections the update was not sent over to the error.
+	Failures map[string]error
+}
+
+func (e *SendError) Error() string {
+	ids := e.ConnectionIDs()
+
+	failures := make([]string, len(ids))
+	for i, id := range ids {
+		failures[i] = fmt.Sprintf("connection %s: %s", id, e.Failures[id])
+	}
+
+	return "send update: " + strings.Join(failures, "; ")
+}
+
+// ConnectionIDs returns the IDs of the connections the update was not sent over.
+func (e *SendError) ConnectionIDs() []string {
+	ids := make([]string, 0, len(e.Failures))
+	for id := range e.Failures {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// UpdateDID stores the updated document of a peer DID of the agent and sends it to the other agent of each
+// connection of the DID. The document is signed with the first authentication (or capability invocation) key of
+// the prior document, the updates are sent before the document is stored so that they are packed with the prior keys.
+// The document is stored even if it could not be sent over some connections, a *SendError lists them.
+func (s *Service) UpdateDID(doc *did.Doc) error {
+	if doc == nil || !peer.IsUpdatable(doc.ID) {
+		return errors.New("the document of a peer DID (numalgo 1) is mandatory")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prior, err := s.vdriRegistry.Resolve(doc.ID)
+	if err != nil {
+		return fmt.Errorf("resolve prior document: %w", err)
+	}
+
+	keys := updateKeys(prior)
+	if len(keys) == 0 {
+		return errors.New("prior document without authentication or capability invocation key")
+	}
+
+	updated := time.Now().UTC()
+	if last := lastChange(prior); last != nil && !updated.After(*last) {
+		// the update time is strictly increasing, the receivers reject the older updates
+		updated = last.Add(time.Nanosecond)
+	}
+
+	doc.Updated = &updated
+
+	signature, err := s.sign(doc, base58.Encode(keys[0]))
+	if err != nil {
+		return err
+	}
+
+	records, err := s.connections.QueryConnectionRecords()
+	if err != nil {
+		return fmt.Errorf("query connection records: %w", err)
+	}
+
+	update := &Update{Type: UpdateMsgType, DID: doc.ID, Signature: signature}
+	failures := map[string]error{}
+
+	for _, record := range records {
+		if record.MyDID != doc.ID || record.State != stateNameCompleted {
+			continue
+		}
+
+		if err := s.send(update, record); err != nil {
+			failures[record.ConnectionID] = err
+		}
+	}
+
+	if err := s.saveDoc(doc); err != nil {
+		return err
+	}
+
+	s.TriggerMsgEvents(service.StateMsg{
+		ProtocolName: Name,
+		Type:         service.PostState,
+		StateID:      StatePublished,
+		Msg:          service.NewDIDCommMsgMap(*update),
+		Properties:   &eventProps{doc: doc},
+	})
+
+	if len(failures) > 0 {
+		return &SendError{Update: update, Failures: failures}
+	}
+
+	return nil
+}
+
+// SendUpdate sends the update of a document (see SendError) again over the connections, e.g once the other
+// agents are reachable. It returns a *SendError listing the connections the update was not sent over.
+func (s *Service) SendUpdate(update *Update, connectionIDs ...string) error {
+	if update == nil || update.Signature == nil {
+		return errors.New("signed update is mandatory")
+	}
+
+	failures := map[string]error{}
+
+	for _, connectionID := range connectionIDs {
+		record, err := s.connections.GetConnectionRecord(connectionID)
+		if err != nil {
+			failures[connectionID] = fmt.Errorf("get connection record: %w", err)
+
+			continue
+		}
+
+		if record.MyDID != update.DID {
+			failures[connectionID] = fmt.Errorf("connection of another DID: %s", record.MyDID)
+
+			continue
+		}
+
+		if err := s.send(update, record); err != nil {
+			failures[connectionID] = err
+		}
+	}
+
+	if len(failures) > 0 {
+		return &SendError{Update: update, Failures: failures}
+	}
+
+	return nil
+}
+
+// send sends the update over the connection, each message gets its own ID.
+func (s *Service) send(update *Update, record *connection.Record) error {
+	msg := *update
+	msg.ID = s.idGenerator.NewID()
+
+	return s.messenger.Send(service.NewDIDCommMsgMap(msg), record.MyDID, record.TheirDID)
+}
+
+func (s *Service) sign(doc *did.Doc, verKey string) (*DocSignature, error) {
+	src, err := doc.JSONBytes()
+	if err != nil {
+		return nil, fmt.Errorf("marshal document: %w", err)
+	}
+
+	signature, err := s.signer.SignMessage(src, verKey)
+	if err != nil {
+		return nil, fmt.Errorf("sign document: %w", err)
+	}
+
+	return &DocSignature{
+		Type:       signatureType,
+		Signature:  base64.URLEncoding.EncodeToString(signature),
+		SignedData: base64.URLEncoding.EncodeToString(src),
+		SignVerKey: verKey,
+	}, nil
+}
+
+func (s *Service) handleUpdate(msg service.DIDCommMsgMap, theirDID string) error {
+	update := &Update{}
+	if err := msg.Decode(update); err != nil {
+		return fmt.Errorf("decode update: %w", err)
+	}
+
+	// the update is received over a connection of the DID, i.e packed with a key of the prior document
+	if theirDID == "" || update.DID != theirDID {
+		return fmt.Errorf("update of DID %s not received from the DID", update.DID)
+	}
+
+	if update.Signature == nil {
+		return errors.New("update without signature")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prior, err := s.vdriRegistry.Resolve(theirDID)
+	if err != nil {
+		return fmt.Errorf("resolve prior document: %w", err)
+	}
+
+	doc, err := verify(update.Signature, prior)
+	if err != nil {
+		return fmt.Errorf("verify update: %w", err)
+	}
+
+	if doc.ID != theirDID {
+		return fmt.Errorf("updated document of DID %s instead of %s", doc.ID, theirDID)
+	}
+
+	// a replayed update would revert the document
+	if !newer(doc, prior) {
+		return fmt.Errorf("update of DID %s is not newer than the stored document", theirDID)
+	}
+
+	// the connections are updated before the document is stored: the stored document is the commit point,
+	// an update failing midway leaves the prior document and is applied again when it is received again
+	if err = s.updateConnections(doc); err != nil {
+		return err
+	}
+
+	if err = s.saveDoc(doc); err != nil {
+		return err
+	}
+
+	logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: msg.ID()}).
+		Debugf("document of DID %s updated", doc.ID)
+
+	s.TriggerMsgEvents(service.StateMsg{
+		ProtocolName: Name,
+		Type:         service.PostState,
+		StateID:      StateUpdated,
+		Msg:          msg.Clone(),
+		Properties:   &eventProps{doc: doc},
+	})
+
+	return nil
+}
+
+// verify verifies the signature of the updated document with a key of the prior document
+// and returns the updated document.
+func verify(signature *DocSignature, prior *did.Doc) (*did.Doc, error) {
+	signer := base58.Decode(signature.SignVerKey)
+
+	if !hasKey(updateKeys(prior), signer) {
+		return nil, errors.New("signer is not an authentication or capability invocation key of the prior document")
+	}
+
+	data, err := base64.URLEncoding.DecodeString(signature.SignedData)
+	if err != nil {
+		return nil, fmt.Errorf("decode signed data: %w", err)
+	}
+
+	sig, err := base64.URLEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+
+	if len(signer) != ed25519.PublicKeySize || !ed25519.Verify(signer, data, sig) {
+		return nil, errors.New("invalid signature")
+	}
+
+	doc, err := did.ParseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// updateKeys returns the keys of the document allowed to sign its updates: the authentication and capability
+// invocation keys, the other keys (e.g. the key agreement keys) can't rewrite the document.
+func updateKeys(doc *did.Doc) [][]byte {
+	var keys [][]byte
+
+	for _, methods := range [][]did.VerificationMethod{doc.Authentication, doc.CapabilityInvocation} {
+		for _, method := range methods {
+			keys = append(keys, method.PublicKey.Value)
+		}
+	}
+
+	return keys
+}
+
+func hasKey(keys [][]byte, key []byte) bool {
+	for _, k := range keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newer checks whether the updated document has an update time strictly after the last change of the prior
+// document (its update time, or its creation time if it was never updated).
+func newer(doc, prior *did.Doc) bool {
+	if doc.Updated == nil {
+		return false
+	}
+
+	last := lastChange(prior)
+
+	return last == nil || doc.Updated.After(*last)
+}
+
+func lastChange(doc *did.Doc) *time.Time {
+	if doc.Updated != nil {
+		return doc.Updated
+	}
+
+	return doc.Created
+}
+
+// saveDoc maps the keys of the document to the DID then replaces the stored document. The mappings are only
+// added, the document is stored last so that a failure leaves the prior document in place.
+func (s *Service) saveDoc(doc *did.Doc) error {
+	if err := s.didConnStore.SaveDIDFromDoc(doc); err != nil {
+		return fmt.Errorf("save DID keys: %w", err)
+	}
+
+	if err := s.vdriRegistry.Store(doc); err != nil {
+		return fmt.Errorf("store document: %w", err)
+	}
+
+	return nil
+}
+
+// updateConnections updates the endpoint and keys of the connection records of the DID.
+func (s *Service) updateConnections(doc *did.Doc) error {
+	dest, err := service.CreateDestination(doc)
+	if err != nil {
+		return fmt.Errorf("updated document: %w", err)
+	}
+
+	records, err := s.connections.QueryConnectionRecords()
+	if err != nil {
+		return fmt.Errorf("query connection records: %w", err)
+	}
+
+	for _, record := range records {
+		if record.TheirDID != doc.ID {
+			continue
+		}
+
+		record.ServiceEndPoint = dest.ServiceEndpoint
+		record.RecipientKeys = dest.RecipientKeys
+		record.RoutingKeys = dest.RoutingKeys
+
+		if err := s.connections.SaveConnectionRecord(record); err != nil {
+			return fmt.Errorf("save connection record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Event is implemented by the properties of the message events of the updated documents.
+type Event interface {
+	// DID returns the DID whose document was updated.
+	DID() string
+	// Doc returns the updated document.
+	Doc() *did.Doc
+}
+
+// eventProps are the properties of the message event of an updated document.
+type eventProps struct {
+	doc *did.Doc
+}
+
+// DID returns the DID whose document was updated.
+func (e *eventProps) DID() string {
+	return e.doc.ID
+}
+
+// Doc returns the updated document.
+func (e *eventProps) Doc() *did.Doc {
+	return e.doc
+}
@@ -0,0 +1,583 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didupdate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	didstore "github.com/hyperledger/aries-framework-go/pkg/store/did"
+	"github.com/hyperledger/aries-framework-go/pkg/vdri/peer"
+)
+
+const bobDID = "did:peer:1zQmbobbobbobbobbobbobbobbobbobbobbobbobbobbobbob"
+
+type provider struct {
+	messenger                service.Messenger
+	storageProvider          storage.Provider
+	transientStorageProvider storage.Provider
+	vdriRegistry             vdriapi.Registry
+	signer                   legacykms.Signer
+}
+
+func (p *provider) Messenger() service.Messenger {
+	return p.messenger
+}
+
+func (p *provider) StorageProvider() storage.Provider {
+	return p.storageProvider
+}
+
+func (p *provider) TransientStorageProvider() storage.Provider {
+	return p.transientStorageProvider
+}
+
+func (p *provider) VDRIRegistry() vdriapi.Registry {
+	return p.vdriRegistry
+}
+
+func (p *provider) Signer() legacykms.Signer {
+	return p.signer
+}
+
+func (p *provider) connections(t *testing.T) *connection.Recorder {
+	t.Helper()
+
+	recorder, err := connection.NewRecorder(p)
+	require.NoError(t, err)
+
+	return recorder
+}
+
+// signer signs with the ed25519 keys of the agent
+type signer map[string]ed25519.PrivateKey
+
+func (s signer) SignMessage(message []byte, fromVerKey string) ([]byte, error) {
+	key, ok := s[fromVerKey]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	return ed25519.Sign(key, message), nil
+}
+
+func newProvider(messenger service.Messenger, s signer) *provider {
+	registry := &mockvdri.MockVDRIRegistry{MemStore: map[string]*did.Doc{}}
+	registry.ResolveFunc = func(didID string, _ ...vdriapi.ResolveOpts) (*did.Doc, error) {
+		doc, ok := registry.MemStore[didID]
+		if !ok {
+			return nil, vdriapi.ErrNotFound
+		}
+
+		return doc, nil
+	}
+
+	return &provider{
+		messenger:                messenger,
+		storageProvider:          mem.NewProvider(),
+		transientStorageProvider: mem.NewProvider(),
+		vdriRegistry:             registry,
+		signer:                   s,
+	}
+}
+
+// newDoc returns the document of the DID with a key and the did-communication service of the agent.
+func newDoc(t *testing.T, didID, endpoint string, s signer) *did.Doc {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	verKey := base58.Encode(pub)
+	s[verKey] = priv
+
+	pk := did.PublicKey{ID: didID + "#key-1", Type: "Ed25519VerificationKey2018", Controller: didID, Value: pub}
+
+	return &did.Doc{
+		Context:        []string{did.Context},
+		ID:             didID,
+		PublicKey:      []did.PublicKey{pk},
+		Authentication: []did.VerificationMethod{{PublicKey: pk}},
+		Service: []did.Service{{
+			ID:              didID + "#didcomm",
+			Type:            "did-communication",
+			ServiceEndpoint: endpoint,
+			RecipientKeys:   []string{verKey},
+		}},
+	}
+}
+
+func newPeerDoc(t *testing.T, endpoint string, s signer) *did.Doc {
+	t.Helper()
+
+	doc := newDoc(t, "", endpoint, s)
+
+	genesis, err := peer.NewDoc(doc.PublicKey, []did.VerificationMethod{{PublicKey: doc.PublicKey[0]}},
+		did.WithService(doc.Service))
+	require.NoError(t, err)
+
+	return genesis
+}
+
+func saveConnection(t *testing.T, p *provider, connectionID, myDID, theirDID string) {
+	t.Helper()
+
+	require.NoError(t, p.connections(t).SaveConnectionRecord(&connection.Record{
+		ConnectionID: connectionID,
+		State:        stateNameCompleted,
+		MyDID:        myDID,
+		TheirDID:     theirDID,
+	}))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(&provider{
+		storageProvider: &mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open store error")},
+	})
+	require.Contains(t, err.Error(), "connection recorder")
+
+	svc, err := New(newProvider(nil, signer{}))
+	require.NoError(t, err)
+	require.Equal(t, Name, svc.Name())
+	require.True(t, svc.Accept(UpdateMsgType))
+	require.False(t, svc.Accept("unknown"))
+	require.EqualError(t, svc.HandleOutbound(nil, "", ""), "not implemented")
+}
+
+func TestService_UpdateDID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Alice updates her DID document, Bob receives the update
+	aliceSigner := signer{}
+	aliceMessenger := serviceMocks.NewMockMessenger(ctrl)
+	alice := newProvider(aliceMessenger, aliceSigner)
+	bob := newProvider(nil, signer{})
+
+	aliceSvc, err := New(alice)
+	require.NoError(t, err)
+
+	bobSvc, err := New(bob)
+	require.NoError(t, err)
+
+	genesis := newPeerDoc(t, "https://alice.example.com", aliceSigner)
+	aliceDID := genesis.ID
+
+	require.NoError(t, alice.vdriRegistry.Store(genesis))
+	require.NoError(t, bob.vdriRegistry.Store(genesis))
+
+	saveConnection(t, alice, "alice-bob", aliceDID, bobDID)
+	saveConnection(t, alice, "alice-carol", "did:peer:1zQmother", "did:peer:1zQmcarol")
+	saveConnection(t, bob, "bob-alice", bobDID, aliceDID)
+
+	events := make(chan service.StateMsg, 1)
+	require.NoError(t, bobSvc.RegisterMsgEvent(events))
+
+	published := make(chan service.StateMsg, 2)
+	require.NoError(t, aliceSvc.RegisterMsgEvent(published))
+
+	var sent []service.DIDCommMsgMap
+
+	prior := genesis
+
+	aliceMessenger.EXPECT().Send(gomock.Any(), aliceDID, bobDID).
+		DoAndReturn(func(msg service.DIDCommMsgMap, _, _ string) error {
+			// the update is sent with the prior keys
+			doc, e := alice.vdriRegistry.Resolve(aliceDID)
+			require.NoError(t, e)
+			require.Equal(t, prior, doc)
+
+			sent = append(sent, msg)
+
+			return nil
+		}).Times(2)
+
+	// the agent was re-keyed and moved
+	rekeyed := newDoc(t, aliceDID, "https://alice.example.org", aliceSigner)
+	require.NoError(t, aliceSvc.UpdateDID(rekeyed))
+	require.NotNil(t, rekeyed.Updated)
+
+	doc, err := alice.vdriRegistry.Resolve(aliceDID)
+	require.NoError(t, err)
+	require.Equal(t, rekeyed, doc)
+
+	require.Len(t, sent, 1)
+	require.Equal(t, UpdateMsgType, sent[0].Type())
+
+	e := <-published
+	require.Equal(t, StatePublished, e.StateID)
+	require.Equal(t, UpdateMsgType, e.Msg.Type())
+	require.Equal(t, aliceDID, e.Properties.(Event).DID())
+	require.Equal(t, rekeyed, e.Properties.(Event).Doc())
+
+	t.Run("update received", func(t *testing.T) {
+		_, err = bobSvc.HandleInbound(sent[0], bobDID, aliceDID)
+		require.NoError(t, err)
+
+		doc, err := bob.vdriRegistry.Resolve(aliceDID)
+		require.NoError(t, err)
+		require.Equal(t, rekeyed.Service[0].ServiceEndpoint, doc.Service[0].ServiceEndpoint)
+		require.Equal(t, rekeyed.PublicKey[0].Value, doc.PublicKey[0].Value)
+
+		record, err := bob.connections(t).GetConnectionRecord("bob-alice")
+		require.NoError(t, err)
+		require.Equal(t, "https://alice.example.org", record.ServiceEndPoint)
+		require.Equal(t, rekeyed.Service[0].RecipientKeys, record.RecipientKeys)
+
+		select {
+		case e := <-events:
+			require.Equal(t, StateUpdated, e.StateID)
+
+			props, ok := e.Properties.(*eventProps)
+			require.True(t, ok)
+			require.Equal(t, aliceDID, props.DID())
+			require.Equal(t, doc, props.Doc())
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+		}
+	})
+
+	t.Run("update replayed", func(t *testing.T) {
+		_, err = bobSvc.HandleInbound(sent[0], bobDID, aliceDID)
+		require.Contains(t, err.Error(),
+			"verify update: signer is not an authentication or capability invocation key of the prior document")
+	})
+
+	t.Run("next update signed with the new key", func(t *testing.T) {
+		prior = rekeyed
+
+		moved := newDoc(t, aliceDID, "https://alice.example.net", aliceSigner)
+		moved.PublicKey = rekeyed.PublicKey
+		moved.Authentication = rekeyed.Authentication
+		moved.Service[0].RecipientKeys = rekeyed.Service[0].RecipientKeys
+
+		require.NoError(t, aliceSvc.UpdateDID(moved))
+		require.Len(t, sent, 2)
+
+		_, err = bobSvc.HandleInbound(sent[1], bobDID, aliceDID)
+		require.NoError(t, err)
+
+		<-events
+
+		// an older document signed with the current key would revert the document
+		olderDoc := *moved
+		older := rekeyed.Updated.Add(-time.Hour)
+		olderDoc.Updated = &older
+
+		signature, err := aliceSvc.sign(&olderDoc, rekeyed.Service[0].RecipientKeys[0])
+		require.NoError(t, err)
+
+		_, err = bobSvc.HandleInbound(service.NewDIDCommMsgMap(Update{
+			Type:      UpdateMsgType,
+			DID:       aliceDID,
+			Signature: signature,
+		}), bobDID, aliceDID)
+		require.Contains(t, err.Error(), "is not newer than the stored document")
+	})
+}
+
+func TestService_UpdateDID_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := signer{}
+	messenger := serviceMocks.NewMockMessenger(ctrl)
+	p := newProvider(messenger, s)
+
+	svc, err := New(p)
+	require.NoError(t, err)
+
+	t.Run("not a peer DID", func(t *testing.T) {
+		require.EqualError(t, svc.UpdateDID(nil), "the document of a peer DID (numalgo 1) is mandatory")
+		require.EqualError(t, svc.UpdateDID(&did.Doc{ID: "did:example:123"}),
+			"the document of a peer DID (numalgo 1) is mandatory")
+		require.EqualError(t, svc.UpdateDID(&did.Doc{ID: "did:peer:0z6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH"}),
+			"the document of a peer DID (numalgo 1) is mandatory")
+	})
+
+	genesis := newPeerDoc(t, "https://alice.example.com", s)
+	require.NoError(t, p.vdriRegistry.Store(genesis))
+
+	t.Run("prior document not found", func(t *testing.T) {
+		err := svc.UpdateDID(&did.Doc{ID: "did:peer:1zQmunknown"})
+		require.Contains(t, err.Error(), "resolve prior document")
+	})
+
+	t.Run("prior document without authentication key", func(t *testing.T) {
+		require.NoError(t, p.vdriRegistry.Store(&did.Doc{ID: "did:peer:1zQmnoauthkey"}))
+
+		err := svc.UpdateDID(&did.Doc{ID: "did:peer:1zQmnoauthkey"})
+		require.EqualError(t, err, "prior document without authentication or capability invocation key")
+	})
+
+	t.Run("sign error", func(t *testing.T) {
+		p.signer = signer{}
+
+		svc, err := New(p)
+		require.NoError(t, err)
+
+		err = svc.UpdateDID(newDoc(t, genesis.ID, "https://alice.example.org", signer{}))
+		require.EqualError(t, err, "sign document: key not found")
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		saveConnection(t, p, "alice-bob", genesis.ID, bobDID)
+
+		messenger.EXPECT().Send(gomock.Any(), genesis.ID, bobDID).Return(errors.New("send error"))
+
+		p.signer = s
+		p.vdriRegistry.(*mockvdri.MockVDRIRegistry).PutErr = nil
+
+		svc, err := New(p)
+		require.NoError(t, err)
+
+		rekeyed := newDoc(t, genesis.ID, "https://alice.example.org", s)
+		err = svc.UpdateDID(rekeyed)
+		require.EqualError(t, err, "send update: connection alice-bob: send error")
+
+		// the document is stored anyway
+		doc, err := p.vdriRegistry.Resolve(genesis.ID)
+		require.NoError(t, err)
+		require.Equal(t, rekeyed, doc)
+	})
+
+	t.Run("send the update again", func(t *testing.T) {
+		saveConnection(t, p, "alice-bob", genesis.ID, bobDID)
+
+		var sent service.DIDCommMsgMap
+
+		gomock.InOrder(
+			messenger.EXPECT().Send(gomock.Any(), genesis.ID, bobDID).Return(errors.New("send error")),
+			messenger.EXPECT().Send(gomock.Any(), genesis.ID, bobDID).
+				Do(func(msg service.DIDCommMsgMap, _, _ string) error {
+					sent = msg
+
+					return nil
+				}),
+		)
+
+		svc, err := New(p)
+		require.NoError(t, err)
+
+		err = svc.UpdateDID(newDoc(t, genesis.ID, "https://alice.example.net", s))
+
+		sendErr := &SendError{}
+		require.True(t, errors.As(err, &sendErr))
+		require.Equal(t, []string{"alice-bob"}, sendErr.ConnectionIDs())
+		require.Equal(t, genesis.ID, sendErr.Update.DID)
+
+		require.NoError(t, svc.SendUpdate(sendErr.Update, sendErr.ConnectionIDs()...))
+
+		update := &Update{}
+		require.NoError(t, sent.Decode(update))
+		require.NotEmpty(t, update.ID)
+		require.Equal(t, sendErr.Update.Signature, update.Signature)
+
+		err = svc.SendUpdate(sendErr.Update, "unknown")
+		require.True(t, errors.As(err, &sendErr))
+		require.Contains(t, err.Error(), "connection unknown: get connection record")
+
+		saveConnection(t, p, "alice-carol", "did:peer:1zQmother", bobDID)
+
+		err = svc.SendUpdate(sendErr.Update, "alice-carol")
+		require.Contains(t, err.Error(), "connection of another DID")
+
+		require.EqualError(t, svc.SendUpdate(nil, "alice-bob"), "signed update is mandatory")
+	})
+}
+
+func TestService_HandleInbound_Errors(t *testing.T) {
+	s := signer{}
+	p := newProvider(nil, s)
+
+	svc, err := New(p)
+	require.NoError(t, err)
+
+	genesis := newPeerDoc(t, "https://alice.example.com", s)
+	require.NoError(t, p.vdriRegistry.Store(genesis))
+
+	updated := newDoc(t, genesis.ID, "https://alice.example.org", s)
+	updateTime := time.Now().UTC()
+	updated.Updated = &updateTime
+
+	signature, err := svc.sign(updated, genesis.Service[0].RecipientKeys[0])
+	require.NoError(t, err)
+
+	newUpdate := func(didID string, sig *DocSignature) service.DIDCommMsgMap {
+		msg := service.DIDCommMsgMap{"@type": UpdateMsgType, "did": didID}
+		if sig != nil {
+			msg["did_doc~sig"] = sig
+		}
+
+		return msg
+	}
+
+	t.Run("unrecognized message type", func(t *testing.T) {
+		_, err := svc.HandleInbound(service.DIDCommMsgMap{"@type": "unknown"}, "", genesis.ID)
+		require.EqualError(t, err, "unrecognized msgType: unknown")
+	})
+
+	t.Run("not received from the DID", func(t *testing.T) {
+		_, err := svc.HandleInbound(newUpdate(genesis.ID, signature), "", "")
+		require.Contains(t, err.Error(), "not received from the DID")
+
+		_, err = svc.HandleInbound(newUpdate(genesis.ID, signature), "", bobDID)
+		require.Contains(t, err.Error(), "not received from the DID")
+	})
+
+	t.Run("without signature", func(t *testing.T) {
+		_, err := svc.HandleInbound(newUpdate(genesis.ID, nil), "", genesis.ID)
+		require.EqualError(t, err, "update without signature")
+	})
+
+	t.Run("prior document not found", func(t *testing.T) {
+		_, err := svc.HandleInbound(newUpdate(bobDID, signature), "", bobDID)
+		require.Contains(t, err.Error(), "resolve prior document")
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		invalid := *signature
+		invalid.Signature = base64.URLEncoding.EncodeToString([]byte("invalid"))
+
+		_, err := svc.HandleInbound(newUpdate(genesis.ID, &invalid), "", genesis.ID)
+		require.EqualError(t, err, "verify update: invalid signature")
+
+		invalid.Signature = "!"
+		_, err = svc.HandleInbound(newUpdate(genesis.ID, &invalid), "", genesis.ID)
+		require.Contains(t, err.Error(), "verify update: decode signature")
+
+		invalid = *signature
+		invalid.SignedData = "!"
+		_, err = svc.HandleInbound(newUpdate(genesis.ID, &invalid), "", genesis.ID)
+		require.Contains(t, err.Error(), "verify update: decode signed data")
+	})
+
+	t.Run("signed with a key agreement key", func(t *testing.T) {
+		agreement := newDoc(t, genesis.ID, "", s).PublicKey[0]
+		agreement.ID = genesis.ID + "#key-agreement"
+
+		prior := *genesis
+		prior.ID = "did:peer:1zQmkeyagreement"
+		prior.PublicKey = append(append([]did.PublicKey{}, genesis.PublicKey...), agreement)
+		prior.KeyAgreement = []did.VerificationMethod{{PublicKey: agreement}}
+		require.NoError(t, p.vdriRegistry.Store(&prior))
+
+		rewritten := newDoc(t, prior.ID, "https://mallory.example.org", s)
+		rewritten.Updated = &updateTime
+
+		sig, err := svc.sign(rewritten, base58.Encode(agreement.Value))
+		require.NoError(t, err)
+
+		_, err = svc.HandleInbound(newUpdate(prior.ID, sig), "", prior.ID)
+		require.EqualError(t, err,
+			"verify update: signer is not an authentication or capability invocation key of the prior document")
+
+		// the capability invocation keys sign the updates as well
+		prior.CapabilityInvocation = prior.KeyAgreement
+		require.NoError(t, p.vdriRegistry.Store(&prior))
+
+		_, err = svc.HandleInbound(newUpdate(prior.ID, sig), "", prior.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		sig, err := s.SignMessage([]byte("{"), genesis.Service[0].RecipientKeys[0])
+		require.NoError(t, err)
+
+		_, err = svc.HandleInbound(newUpdate(genesis.ID, &DocSignature{
+			Signature:  base64.URLEncoding.EncodeToString(sig),
+			SignedData: base64.URLEncoding.EncodeToString([]byte("{")),
+			SignVerKey: genesis.Service[0].RecipientKeys[0],
+		}), "", genesis.ID)
+		require.Contains(t, err.Error(), "verify update: parse document")
+	})
+
+	t.Run("document of another DID", func(t *testing.T) {
+		other := newDoc(t, bobDID, "https://bob.example.org", s)
+
+		sig, err := svc.sign(other, genesis.Service[0].RecipientKeys[0])
+		require.NoError(t, err)
+
+		_, err = svc.HandleInbound(newUpdate(genesis.ID, sig), "", genesis.ID)
+		require.EqualError(t, err, "updated document of DID "+bobDID+" instead of "+genesis.ID)
+	})
+
+	t.Run("update without update time", func(t *testing.T) {
+		// the prior document was never updated, the update could be replayed
+		require.Nil(t, genesis.Updated)
+
+		noTime := *updated
+		noTime.Updated = nil
+
+		sig, err := svc.sign(&noTime, genesis.Service[0].RecipientKeys[0])
+		require.NoError(t, err)
+
+		_, err = svc.HandleInbound(newUpdate(genesis.ID, sig), "", genesis.ID)
+		require.Contains(t, err.Error(), "is not newer than the stored document")
+	})
+
+	t.Run("update older than the creation of the document", func(t *testing.T) {
+		created := updateTime.Add(time.Hour)
+
+		prior := *genesis
+		prior.Created = &created
+
+		require.NoError(t, p.vdriRegistry.Store(&prior))
+		defer func() { require.NoError(t, p.vdriRegistry.Store(genesis)) }()
+
+		_, err := svc.HandleInbound(newUpdate(genesis.ID, signature), "", genesis.ID)
+		require.Contains(t, err.Error(), "is not newer than the stored document")
+	})
+
+	t.Run("save DID keys error", func(t *testing.T) {
+		didConnStore := svc.didConnStore
+		defer func() { svc.didConnStore = didConnStore }()
+
+		svc.didConnStore, err = didstore.NewConnectionStore(&provider{
+			storageProvider: mockstorage.NewCustomMockStoreProvider(&mockstorage.MockStore{
+				Store:  map[string][]byte{},
+				ErrPut: errors.New("put error"),
+			}),
+			vdriRegistry: p.vdriRegistry,
+		})
+		require.NoError(t, err)
+
+		_, err := svc.HandleInbound(newUpdate(genesis.ID, signature), "", genesis.ID)
+		require.Contains(t, err.Error(), "save DID keys")
+
+		// the prior document is kept, the update is applied when received again
+		doc, err := p.vdriRegistry.Resolve(genesis.ID)
+		require.NoError(t, err)
+		require.Equal(t, genesis, doc)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		p.vdriRegistry.(*mockvdri.MockVDRIRegistry).PutErr = errors.New("put error")
+		defer func() { p.vdriRegistry.(*mockvdri.MockVDRIRegistry).PutErr = nil }()
+
+		_, err := svc.HandleInbound(newUpdate(genesis.ID, signature), "", genesis.ID)
+		require.EqualError(t, err, "store document: put error")
+	})
+}
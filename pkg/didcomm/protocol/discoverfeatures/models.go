@@ -0,0 +1,32 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package discoverfeatures
+
+// Query is sent to discover the protocols supported by the other agent of a connection.
+type Query struct {
+	ID   string `json:"@id,omitempty"`
+	Type string `json:"@type,omitempty"`
+	// Query is the identifier of the queried protocols, the "*" wildcard matches any suffix
+	// (eg "https://didcomm.org/tictactoe/1.*").
+	Query   string `json:"query"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// Disclose is the reply to a query, it lists the supported protocols matching the query.
+type Disclose struct {
+	ID        string      `json:"@id,omitempty"`
+	Type      string      `json:"@type,omitempty"`
+	Protocols []*Protocol `json:"protocols"`
+}
+
+// Protocol is a protocol disclosed by an agent.
+type Protocol struct {
+	// PID is the identifier of the protocol (eg "https://didcomm.org/tictactoe/1.0").
+	PID string `json:"pid"`
+	// Roles are the roles of the protocol played by the agent, all roles are supported if it is empty.
+	Roles []string `json:"roles,omitempty"`
+}
@@ -0,0 +1,269 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package discoverfeatures
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+)
+
+const (
+	// Name defines the protocol name
+	Name = "discover-features"
+	// Spec defines the protocol spec
+	Spec = "https://didcomm.org/discover-features/1.0/"
+	// QueryMsgType defines the protocol query message type.
+	QueryMsgType = Spec + "query"
+	// DiscloseMsgType defines the protocol disclose message type.
+	DiscloseMsgType = Spec + "disclose"
+
+	// RoleRequester is the role of the agent sending queries.
+	RoleRequester = "requester"
+	// RoleResponder is the role of the agent disclosing its protocols.
+	RoleResponder = "responder"
+
+	wildcard     = "*"
+	queryTimeout = 5 * time.Second
+)
+
+var logger = log.New("aries-framework/discoverfeatures/service")
+
+// ErrQueryTimeout is returned when the other agent did not disclose its protocols in time.
+var ErrQueryTimeout = errors.New("timeout waiting for the disclose message")
+
+// Provider contains dependencies for the protocol and is typically created by using aries.Context()
+type Provider interface {
+	Messenger() service.Messenger
+}
+
+// Service for the discover features protocol: it discloses the protocols of the agent matching the queries
+// of the other agents and queries the protocols supported by the other agent of a connection.
+//
+// The disclosed protocols are added by the protocol services with optional capabilities (see Disclose),
+// the protocol itself is always disclosed. The framework discloses the protocols of its services, the protocols
+// registered by the applications are disclosed by their services.
+type Service struct {
+	messenger service.Messenger
+	timeout   time.Duration
+
+	mu        sync.RWMutex
+	protocols []*Protocol
+
+	queriesMu   sync.Mutex
+	queries     map[string]chan *Disclose
+	idGenerator idgen.Generator
+}
+
+// New returns the discover features service
+func New(p Provider) (*Service, error) {
+	s := &Service{
+		idGenerator: idgen.FromProvider(p),
+		messenger:   p.Messenger(),
+		timeout:     queryTimeout,
+		queries:     map[string]chan *Disclose{},
+	}
+
+	s.Disclose(&Protocol{PID: PID(Spec), Roles: []string{RoleRequester, RoleResponder}})
+
+	return s, nil
+}
+
+// PID returns the protocol identifier disclosed for the spec of a protocol (ie without the trailing slash).
+func PID(spec string) string {
+	return strings.TrimSuffix(spec, "/")
+}
+
+// Disclose adds protocols to the protocols disclosed by the agent, a protocol added again replaces
+// the previous one.
+func (s *Service) Disclose(protocols ...*Protocol) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, protocol := range protocols {
+		s.remove(protocol.PID)
+		s.protocols = append(s.protocols, protocol)
+	}
+}
+
+func (s *Service) remove(pid string) {
+	for i, protocol := range s.protocols {
+		if protocol.PID == pid {
+			s.protocols = append(s.protocols[:i], s.protocols[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// Protocols returns the protocols disclosed by the agent matching the query.
+func (s *Service) Protocols(query string) []*Protocol {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var protocols []*Protocol
+
+	for _, protocol := range s.protocols {
+		if match(query, protocol.PID) {
+			protocols = append(protocols, protocol)
+		}
+	}
+
+	return protocols
+}
+
+func match(query, pid string) bool {
+	if strings.HasSuffix(query, wildcard) {
+		return strings.HasPrefix(pid, strings.TrimSuffix(query, wildcard))
+	}
+
+	return query == pid
+}
+
+// Query sends a query to the other agent of a connection and returns the disclosed protocols matching it.
+// It blocks until the disclose message is received.
+func (s *Service) Query(query, myDID, theirDID string) ([]*Protocol, error) {
+	msgID := s.idGenerator.NewID()
+
+	discloseCh := make(chan *Disclose, 1)
+	s.setQueryCh(msgID, discloseCh)
+
+	defer s.setQueryCh(msgID, nil)
+
+	err := s.messenger.Send(service.NewDIDCommMsgMap(Query{
+		ID:    msgID,
+		Type:  QueryMsgType,
+		Query: query,
+	}), myDID, theirDID)
+	if err != nil {
+		return nil, fmt.Errorf("send query: %w", err)
+	}
+
+	select {
+	case disclose := <-discloseCh:
+		var protocols []*Protocol
+
+		// the other agent is not trusted to filter its protocols
+		for _, protocol := range disclose.Protocols {
+			if protocol != nil && match(query, protocol.PID) {
+				protocols = append(protocols, protocol)
+			}
+		}
+
+		return protocols, nil
+	case <-time.After(s.timeout):
+		return nil, ErrQueryTimeout
+	}
+}
+
+func (s *Service) setQueryCh(msgID string, ch chan *Disclose) {
+	s.queriesMu.Lock()
+	defer s.queriesMu.Unlock()
+
+	if ch == nil {
+		delete(s.queries, msgID)
+	} else {
+		s.queries[msgID] = ch
+	}
+}
+
+func (s *Service) getQueryCh(msgID string) chan *Disclose {
+	s.queriesMu.Lock()
+	defer s.queriesMu.Unlock()
+
+	return s.queries[msgID]
+}
+
+// HandleInbound handles inbound message (discover features protocol)
+func (s *Service) HandleInbound(msg service.DIDCommMsg, _, _ string) (string, error) {
+	msgMap, ok := msg.(service.DIDCommMsgMap)
+	if !ok {
+		return "", errors.New("bad assertion message is not DIDCommMsgMap")
+	}
+
+	switch msg.Type() {
+	case QueryMsgType:
+		return "", s.handleQuery(msgMap)
+	case DiscloseMsgType:
+		return "", s.handleDisclose(msgMap)
+	default:
+		return "", fmt.Errorf("unrecognized msgType: %s", msg.Type())
+	}
+}
+
+func (s *Service) handleQuery(msg service.DIDCommMsgMap) error {
+	query := &Query{}
+	if err := msg.Decode(query); err != nil {
+		return fmt.Errorf("decode query: %w", err)
+	}
+
+	protocols := s.Protocols(query.Query)
+	if protocols == nil {
+		// the disclose message lists no protocols rather than null
+		protocols = []*Protocol{}
+	}
+
+	err := s.messenger.ReplyTo(msg.ID(), service.NewDIDCommMsgMap(Disclose{
+		ID:        s.idGenerator.NewID(),
+		Type:      DiscloseMsgType,
+		Protocols: protocols,
+	}))
+	if err != nil {
+		return fmt.Errorf("send disclose: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) handleDisclose(msg service.DIDCommMsgMap) error {
+	disclose := &Disclose{}
+	if err := msg.Decode(disclose); err != nil {
+		return fmt.Errorf("decode disclose: %w", err)
+	}
+
+	thID, err := msg.ThreadID()
+	if err != nil {
+		return fmt.Errorf("threadID: %w", err)
+	}
+
+	discloseCh := s.getQueryCh(thID)
+	if discloseCh == nil {
+		logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: thID}).
+			Warnf("disclose message of an unknown query")
+
+		return nil
+	}
+
+	// the channel is buffered, a duplicated disclose message is dropped
+	select {
+	case discloseCh <- disclose:
+	default:
+	}
+
+	return nil
+}
+
+// HandleOutbound handles outbound message (discover features protocol)
+func (s *Service) HandleOutbound(_ service.DIDCommMsg, _, _ string) error {
+	return errors.New("not implemented")
+}
+
+// Name returns service name
+func (s *Service) Name() string {
+	return Name
+}
+
+// Accept msg checks the msg type
+func (s *Service) Accept(msgType string) bool {
+	return msgType == QueryMsgType || msgType == DiscloseMsgType
+}
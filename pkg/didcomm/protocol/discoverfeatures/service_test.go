@@ -0,0 +1,213 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package discoverfeatures
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+)
+
+const (
+	myDID    = "did:example:alice"
+	theirDID = "did:example:bob"
+
+	tictactoe = "https://didcomm.org/tictactoe/1.0"
+)
+
+type provider struct {
+	messenger service.Messenger
+}
+
+func (p *provider) Messenger() service.Messenger {
+	return p.messenger
+}
+
+func newService(t *testing.T, messenger service.Messenger) *Service {
+	t.Helper()
+
+	svc, err := New(&provider{messenger: messenger})
+	require.NoError(t, err)
+
+	return svc
+}
+
+func TestService_Protocols(t *testing.T) {
+	svc := newService(t, nil)
+
+	require.Equal(t, Name, svc.Name())
+	require.True(t, svc.Accept(QueryMsgType))
+	require.True(t, svc.Accept(DiscloseMsgType))
+	require.False(t, svc.Accept("unknown"))
+
+	svc.Disclose(&Protocol{PID: tictactoe}, &Protocol{PID: "https://didcomm.org/tictactoe/2.0"})
+	svc.Disclose(&Protocol{PID: tictactoe, Roles: []string{"player"}})
+
+	require.Equal(t, []*Protocol{{PID: "https://didcomm.org/discover-features/1.0",
+		Roles: []string{RoleRequester, RoleResponder}}}, svc.Protocols(PID(Spec)))
+	require.Equal(t, []*Protocol{
+		{PID: "https://didcomm.org/tictactoe/2.0"},
+		{PID: tictactoe, Roles: []string{"player"}},
+	}, svc.Protocols("https://didcomm.org/tictactoe/*"))
+	require.Len(t, svc.Protocols("*"), 3)
+	require.Empty(t, svc.Protocols("https://didcomm.org/tictactoe/1.1"))
+}
+
+func TestService_HandleInbound(t *testing.T) {
+	t.Run("disclose protocols", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().ReplyTo("query", gomock.Any()).Do(func(_ string, msg service.DIDCommMsgMap) error {
+			disclose := &Disclose{}
+			require.NoError(t, msg.Decode(disclose))
+			require.Equal(t, DiscloseMsgType, disclose.Type)
+			require.Equal(t, []*Protocol{{PID: tictactoe}}, disclose.Protocols)
+
+			return nil
+		})
+		messenger.EXPECT().ReplyTo("query-2", gomock.Any()).Do(func(_ string, msg service.DIDCommMsgMap) error {
+			require.Equal(t, []interface{}{}, msg["protocols"])
+
+			return nil
+		})
+
+		svc := newService(t, messenger)
+		svc.Disclose(&Protocol{PID: tictactoe})
+
+		_, err := svc.HandleInbound(service.NewDIDCommMsgMap(Query{
+			ID:    "query",
+			Type:  QueryMsgType,
+			Query: "https://didcomm.org/tictactoe/1.*",
+		}), myDID, theirDID)
+		require.NoError(t, err)
+
+		_, err = svc.HandleInbound(service.NewDIDCommMsgMap(Query{
+			ID:    "query-2",
+			Type:  QueryMsgType,
+			Query: "https://didcomm.org/unknown/1.0",
+		}), myDID, theirDID)
+		require.NoError(t, err)
+	})
+
+	t.Run("reply error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any()).Return(errors.New("reply error"))
+
+		_, err := newService(t, messenger).HandleInbound(service.NewDIDCommMsgMap(Query{
+			ID:   "query",
+			Type: QueryMsgType,
+		}), myDID, theirDID)
+		require.EqualError(t, err, "send disclose: reply error")
+	})
+
+	t.Run("disclose of an unknown query", func(t *testing.T) {
+		_, err := newService(t, nil).HandleInbound(service.NewDIDCommMsgMap(Disclose{
+			ID:   "disclose",
+			Type: DiscloseMsgType,
+		}), myDID, theirDID)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid messages", func(t *testing.T) {
+		svc := newService(t, nil)
+
+		_, err := svc.HandleInbound(service.DIDCommMsgMap{
+			"@type": QueryMsgType,
+			"query": map[string]interface{}{},
+		}, myDID, theirDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode query")
+
+		_, err = svc.HandleInbound(service.DIDCommMsgMap{"@type": DiscloseMsgType, "protocols": "pid"}, myDID, theirDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode disclose")
+
+		_, err = svc.HandleInbound(service.NewDIDCommMsgMap(Disclose{Type: DiscloseMsgType}), myDID, theirDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "threadID")
+
+		_, err = svc.HandleInbound(service.NewDIDCommMsgMap(Query{Type: "unknown"}), myDID, theirDID)
+		require.EqualError(t, err, "unrecognized msgType: unknown")
+
+		require.EqualError(t, svc.HandleOutbound(nil, myDID, theirDID), "not implemented")
+	})
+}
+
+func TestService_Query(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var svc *Service
+
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().Send(gomock.Any(), myDID, theirDID).
+			Do(func(msg service.DIDCommMsgMap, _, _ string) error {
+				require.Equal(t, QueryMsgType, msg.Type())
+				require.Equal(t, "https://didcomm.org/tictactoe/*", msg["query"])
+
+				go func() {
+					disclose := service.NewDIDCommMsgMap(Disclose{
+						ID:   "disclose",
+						Type: DiscloseMsgType,
+						// the protocols not matching the query are ignored
+						Protocols: []*Protocol{{PID: tictactoe}, {PID: PID(Spec)}},
+					})
+					disclose["~thread"] = map[string]interface{}{"thid": msg.ID()}
+
+					_, err := svc.HandleInbound(disclose, myDID, theirDID)
+					require.NoError(t, err)
+				}()
+
+				return nil
+			})
+
+		svc = newService(t, messenger)
+
+		protocols, err := svc.Query("https://didcomm.org/tictactoe/*", myDID, theirDID)
+		require.NoError(t, err)
+		require.Equal(t, []*Protocol{{PID: tictactoe}}, protocols)
+		require.Empty(t, svc.queries)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().Send(gomock.Any(), myDID, theirDID).Return(nil)
+
+		svc := newService(t, messenger)
+		svc.timeout = time.Millisecond
+
+		_, err := svc.Query(tictactoe, myDID, theirDID)
+		require.True(t, errors.Is(err, ErrQueryTimeout))
+		require.Empty(t, svc.queries)
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().Send(gomock.Any(), myDID, theirDID).Return(errors.New("send error"))
+
+		_, err := newService(t, messenger).Query(tictactoe, myDID, theirDID)
+		require.EqualError(t, err, "send query: send error")
+	})
+}
@@ -0,0 +1,52 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filetransfer
+
+// File describes the transferred file. The file is split into chunks of ChunkSize bytes, the last chunk
+// may be smaller.
+type File struct {
+	// Name is a hint about the name of the file.
+	Name string `json:"name"`
+	// MimeType describes the MIME type of the file.
+	MimeType string `json:"mime-type,omitempty"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded SHA-256 digest of the file, verified by the receiver once all chunks are received.
+	SHA256 string `json:"sha256"`
+	// ChunkSize is the size of the chunks in bytes.
+	ChunkSize int `json:"chunk_size"`
+	// Chunks is the number of chunks.
+	Chunks int `json:"chunks"`
+}
+
+// Offer is sent by the sender to offer a file to the receiver, it starts the thread of the transfer.
+type Offer struct {
+	ID   string `json:"@id,omitempty"`
+	Type string `json:"@type,omitempty"`
+	// Comment is a field that provides some human readable information about the file.
+	Comment string `json:"comment,omitempty"`
+	File    File   `json:"file"`
+}
+
+// Request is sent by the receiver to accept the offered file or to resume the transfer,
+// it lists the indexes of the chunks the receiver misses.
+type Request struct {
+	ID     string `json:"@id,omitempty"`
+	Type   string `json:"@type,omitempty"`
+	Chunks []int  `json:"chunks"`
+}
+
+// Chunk is a chunk of the file sent in response to a Request message.
+type Chunk struct {
+	ID    string `json:"@id,omitempty"`
+	Type  string `json:"@type,omitempty"`
+	Index int    `json:"index"`
+	// Data is the base64 encoded content of the chunk.
+	Data string `json:"data"`
+	// SHA256 is the hex encoded SHA-256 digest of the chunk.
+	SHA256 string `json:"sha256"`
+}
@@ -0,0 +1,657 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filetransfer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	// Name defines the protocol name
+	Name = "file-transfer"
+	// Spec defines the protocol spec
+	Spec = "https://didcomm.org/file-transfer/1.0/"
+	// OfferMsgType defines the protocol offer message type.
+	OfferMsgType = Spec + "offer"
+	// RequestMsgType defines the protocol request message type.
+	RequestMsgType = Spec + "request"
+	// ChunkMsgType defines the protocol chunk message type.
+	ChunkMsgType = Spec + "chunk"
+	// AckMsgType defines the protocol ack message type.
+	AckMsgType = Spec + "ack"
+	// ProblemReportMsgType defines the protocol problem-report message type.
+	ProblemReportMsgType = Spec + "problem-report"
+)
+
+const (
+	// ChunkSize is the size of the chunks the sent files are split into
+	ChunkSize = 64 * 1024
+	// MaxChunkSize is the maximum size of the chunks of the received files
+	MaxChunkSize = 1024 * 1024
+	// MaxFileSize is the maximum size of the transferred files
+	MaxFileSize = 100 * 1024 * 1024
+)
+
+const (
+	// error codes
+	codeRejectedError  = "rejected"
+	codeIntegrityError = "integrity"
+	codeInvalidError   = "invalid"
+)
+
+var logger = log.New("aries-framework/filetransfer/service")
+
+// Provider contains dependencies for the protocol and is typically created by using aries.Context()
+type Provider interface {
+	Messenger() service.Messenger
+	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+}
+
+// Service for the file transfer protocol: it transfers files over a connection, the DIDComm messages (and so
+// the chunks of the files) being encrypted for the other agent of the connection.
+//
+// The sender offers a file to the receiver, which accepts it by requesting its chunks (an action event is
+// triggered, the file may also be accepted later by using AcceptFile). The receiver stores the received chunks
+// and, once all of them are received, verifies the integrity of the file and acknowledges it.
+// An interrupted transfer is resumed by the receiver requesting the missing chunks (see Resume).
+// The progress of the transfers is reported by the message events, their properties give the updated Transfer.
+type Service struct {
+	service.Action
+	service.Message
+	store       storage.Store
+	messenger   service.Messenger
+	connections *connection.Lookup
+	// mu serializes the updates of the transfers
+	mu sync.Mutex
+	// events are the pending progress events, triggered once mu is released
+	events      []service.StateMsg
+	idGenerator idgen.Generator
+}
+
+// New returns the file transfer service
+func New(p Provider) (*Service, error) {
+	store, err := p.StorageProvider().OpenStore(Name)
+	if err != nil {
+		return nil, err
+	}
+
+	connections, err := connection.NewLookup(p)
+	if err != nil {
+		return nil, fmt.Errorf("connection lookup: %w", err)
+	}
+
+	return &Service{
+		idGenerator: idgen.FromProvider(p),
+		store:       store,
+		messenger:   p.Messenger(),
+		connections: connections,
+	}, nil
+}
+
+// HandleInbound handles inbound message (file transfer protocol)
+func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
+	msgMap, ok := msg.(service.DIDCommMsgMap)
+	if !ok {
+		return "", errors.New("bad assertion message is not DIDCommMsgMap")
+	}
+
+	transferID, err := msg.ThreadID()
+	if err != nil {
+		return "", fmt.Errorf("threadID: %w", err)
+	}
+
+	if msg.Type() == OfferMsgType {
+		return "", s.handleOffer(transferID, msgMap, myDID, theirDID)
+	}
+
+	if !s.Accept(msg.Type()) {
+		return "", fmt.Errorf("unrecognized msgType: %s", msg.Type())
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+
+	t, err := s.getTransfer(transferID)
+	if err != nil {
+		return "", err
+	}
+
+	// the ID of a transfer is not a secret, only the agents of its connection take part in it
+	if myDID != t.MyDID || theirDID != t.TheirDID {
+		return "", fmt.Errorf("%s message of transfer %s not received over its connection", msg.Type(), t.ID)
+	}
+
+	switch msg.Type() {
+	case RequestMsgType:
+		return "", s.handleRequest(t, msgMap)
+	case ChunkMsgType:
+		return "", s.handleChunk(t, msgMap)
+	case AckMsgType:
+		return "", s.handleAck(t, msgMap)
+	default:
+		return "", s.handleProblemReport(t, msgMap)
+	}
+}
+
+// HandleOutbound handles outbound message (file transfer protocol)
+func (s *Service) HandleOutbound(_ service.DIDCommMsg, _, _ string) error {
+	return errors.New("not implemented")
+}
+
+// Name returns service name
+func (s *Service) Name() string {
+	return Name
+}
+
+// Accept msg checks the msg type
+func (s *Service) Accept(msgType string) bool {
+	switch msgType {
+	case OfferMsgType, RequestMsgType, ChunkMsgType, AckMsgType, ProblemReportMsgType:
+		return true
+	}
+
+	return false
+}
+
+// SendFile offers the file to the other agent of the connection and returns the ID of the transfer.
+// The chunks are sent once the other agent accepts the file.
+func (s *Service) SendFile(connectionID, name, mimeType string, content []byte) (string, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	record, err := s.connections.GetConnectionRecord(connectionID)
+	if err != nil {
+		return "", fmt.Errorf("get connection record: %w", err)
+	}
+
+	file, err := newFile(name, mimeType, content)
+	if err != nil {
+		return "", err
+	}
+
+	offer := service.NewDIDCommMsgMap(Offer{ID: s.idGenerator.NewID(), Type: OfferMsgType, File: *file})
+
+	t := &Transfer{
+		ID:           offer.ID(),
+		Role:         RoleSender,
+		State:        StateOfferSent,
+		ConnectionID: connectionID,
+		MyDID:        record.MyDID,
+		TheirDID:     record.TheirDID,
+		File:         *file,
+	}
+
+	for i := 0; i < file.Chunks; i++ {
+		if err := s.store.Put(chunkKey(t.ID, i), chunkData(file, i, content)); err != nil {
+			return "", fmt.Errorf("save chunk: %w", err)
+		}
+	}
+
+	if err := s.saveTransfer(t); err != nil {
+		return "", err
+	}
+
+	if err := s.messenger.Send(offer, t.MyDID, t.TheirDID); err != nil {
+		t.State = StateAbandoned
+
+		if e := s.deleteChunks(t); e != nil {
+			transferLogger(t).Errorf("send file: %s", e)
+		}
+
+		if e := s.saveTransfer(t); e != nil {
+			transferLogger(t).Errorf("send file: %s", e)
+		}
+
+		return "", fmt.Errorf("send offer: %w", err)
+	}
+
+	s.sendProgress(t, offer)
+
+	return t.ID, nil
+}
+
+// AcceptFile accepts the file offered by the transfer, the chunks of the file are requested.
+func (s *Service) AcceptFile(transferID string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	t, err := s.getTransfer(transferID)
+	if err != nil {
+		return err
+	}
+
+	if t.Role != RoleReceiver || t.State != StateOfferReceived {
+		return fmt.Errorf("transfer %s cannot be accepted (%s %s)", t.ID, t.Role, t.State)
+	}
+
+	t.State = StateTransferring
+
+	return s.requestMissingChunks(t)
+}
+
+// DeclineFile declines the file offered by the transfer.
+func (s *Service) DeclineFile(transferID string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	t, err := s.getTransfer(transferID)
+	if err != nil {
+		return err
+	}
+
+	if t.Role != RoleReceiver || t.State != StateOfferReceived {
+		return fmt.Errorf("transfer %s cannot be declined (%s %s)", t.ID, t.Role, t.State)
+	}
+
+	return s.abandon(t, codeRejectedError, t.ID)
+}
+
+// Resume resumes an interrupted transfer of a received file by requesting the chunks which were not received.
+func (s *Service) Resume(transferID string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	t, err := s.getTransfer(transferID)
+	if err != nil {
+		return err
+	}
+
+	if t.Role != RoleReceiver || t.State != StateTransferring {
+		return fmt.Errorf("transfer %s cannot be resumed (%s %s)", t.ID, t.Role, t.State)
+	}
+
+	return s.requestMissingChunks(t)
+}
+
+// Content returns the content of the completed transfer of a received file.
+func (s *Service) Content(transferID string) ([]byte, error) {
+	t, err := s.getTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Role != RoleReceiver || t.State != StateCompleted {
+		return nil, fmt.Errorf("transfer %s is not a completed transfer of a received file", t.ID)
+	}
+
+	return s.content(t)
+}
+
+func (s *Service) handleOffer(transferID string, msg service.DIDCommMsgMap, myDID, theirDID string) error {
+	t, err := s.receiveOffer(transferID, msg, myDID, theirDID)
+	if err != nil || t == nil {
+		return err
+	}
+
+	// the file may be accepted later, e.g once a client is registered
+	s.TriggerActionEvent(s.newDIDCommActionMsg(t, msg))
+
+	return nil
+}
+
+// receiveOffer saves the transfer of the offered file, it returns nil if the offer was already received.
+func (s *Service) receiveOffer(transferID string, msg service.DIDCommMsgMap,
+	myDID, theirDID string) (*Transfer, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if _, err := s.getTransfer(transferID); !errors.Is(err, storage.ErrDataNotFound) {
+		return nil, err
+	}
+
+	offer := Offer{}
+	if err := msg.Decode(&offer); err != nil {
+		return nil, fmt.Errorf("decode offer: %w", err)
+	}
+
+	if err := validateFile(&offer.File); err != nil {
+		return nil, s.replyProblemReport(msg.ID(), codeInvalidError, fmt.Errorf("invalid offer: %w", err))
+	}
+
+	connectionID, err := s.connections.GetConnectionIDByDIDs(myDID, theirDID)
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("get connection ID: %w", err)
+	}
+
+	t := &Transfer{
+		ID:           transferID,
+		Role:         RoleReceiver,
+		State:        StateOfferReceived,
+		ConnectionID: connectionID,
+		MyDID:        myDID,
+		TheirDID:     theirDID,
+		File:         offer.File,
+		Received:     make([]bool, offer.File.Chunks),
+	}
+
+	if err := s.saveTransfer(t); err != nil {
+		return nil, err
+	}
+
+	s.sendProgress(t, msg)
+
+	return t, nil
+}
+
+func (s *Service) handleRequest(t *Transfer, msg service.DIDCommMsgMap) error {
+	if t.Role != RoleSender || (t.State != StateOfferSent && t.State != StateTransferring) {
+		return fmt.Errorf("unexpected request for transfer %s (%s %s)", t.ID, t.Role, t.State)
+	}
+
+	request := Request{}
+	if err := msg.Decode(&request); err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	t.State = StateTransferring
+
+	for _, i := range request.Chunks {
+		if i < 0 || i >= t.File.Chunks {
+			return fmt.Errorf("chunk index %d is out of range", i)
+		}
+
+		data, err := s.store.Get(chunkKey(t.ID, i))
+		if err != nil {
+			return fmt.Errorf("get chunk: %w", err)
+		}
+
+		chunk := service.NewDIDCommMsgMap(Chunk{
+			Type:   ChunkMsgType,
+			Index:  i,
+			Data:   base64.StdEncoding.EncodeToString(data),
+			SHA256: digest(data),
+		})
+
+		if err := s.messenger.ReplyTo(msg.ID(), chunk); err != nil {
+			return fmt.Errorf("send chunk: %w", err)
+		}
+
+		t.Transferred++
+
+		if err := s.saveTransfer(t); err != nil {
+			return err
+		}
+
+		s.sendProgress(t, chunk)
+	}
+
+	return nil
+}
+
+func (s *Service) handleChunk(t *Transfer, msg service.DIDCommMsgMap) error {
+	if t.Role != RoleReceiver || t.State != StateTransferring {
+		return fmt.Errorf("unexpected chunk for transfer %s (%s %s)", t.ID, t.Role, t.State)
+	}
+
+	chunk := Chunk{}
+	if err := msg.Decode(&chunk); err != nil {
+		return fmt.Errorf("decode chunk: %w", err)
+	}
+
+	data, err := validateChunk(&t.File, &chunk)
+	if err != nil {
+		// the chunk is requested again when the transfer is resumed
+		return fmt.Errorf("invalid chunk: %w", err)
+	}
+
+	if t.Received[chunk.Index] {
+		return nil
+	}
+
+	if err := s.store.Put(chunkKey(t.ID, chunk.Index), data); err != nil {
+		return fmt.Errorf("save chunk: %w", err)
+	}
+
+	t.Received[chunk.Index] = true
+	t.Transferred++
+
+	if t.Transferred < t.File.Chunks {
+		if err := s.saveTransfer(t); err != nil {
+			return err
+		}
+
+		s.sendProgress(t, msg)
+
+		return nil
+	}
+
+	return s.complete(t, msg)
+}
+
+// complete verifies the integrity of the received file and acknowledges it.
+func (s *Service) complete(t *Transfer, msg service.DIDCommMsgMap) error {
+	content, err := s.content(t)
+	if err != nil {
+		return err
+	}
+
+	if digest(content) != t.File.SHA256 {
+		return s.abandon(t, codeIntegrityError, msg.ID())
+	}
+
+	t.State = StateCompleted
+
+	if err := s.saveTransfer(t); err != nil {
+		return err
+	}
+
+	ack := service.NewDIDCommMsgMap(model.Ack{Type: AckMsgType, Status: "OK"})
+
+	if err := s.messenger.ReplyTo(msg.ID(), ack); err != nil {
+		return fmt.Errorf("send ack: %w", err)
+	}
+
+	s.sendProgress(t, msg)
+
+	return nil
+}
+
+func (s *Service) handleAck(t *Transfer, msg service.DIDCommMsgMap) error {
+	if t.Role != RoleSender || t.State != StateTransferring {
+		return fmt.Errorf("unexpected ack for transfer %s (%s %s)", t.ID, t.Role, t.State)
+	}
+
+	t.State = StateCompleted
+
+	if err := s.deleteChunks(t); err != nil {
+		return err
+	}
+
+	if err := s.saveTransfer(t); err != nil {
+		return err
+	}
+
+	s.sendProgress(t, msg)
+
+	return nil
+}
+
+func (s *Service) handleProblemReport(t *Transfer, msg service.DIDCommMsgMap) error {
+	if t.State == StateCompleted || t.State == StateAbandoned {
+		return nil
+	}
+
+	report := model.ProblemReport{}
+	if err := msg.Decode(&report); err != nil {
+		return fmt.Errorf("decode problem-report: %w", err)
+	}
+
+	t.State = StateAbandoned
+	t.Error = report.Description.Code
+
+	if err := s.deleteChunks(t); err != nil {
+		return err
+	}
+
+	if err := s.saveTransfer(t); err != nil {
+		return err
+	}
+
+	s.sendProgress(t, msg)
+
+	return nil
+}
+
+// requestMissingChunks requests the chunks of the file which were not received.
+func (s *Service) requestMissingChunks(t *Transfer) error {
+	var missing []int
+
+	for i, received := range t.Received {
+		if !received {
+			missing = append(missing, i)
+		}
+	}
+
+	if err := s.saveTransfer(t); err != nil {
+		return err
+	}
+
+	request := service.NewDIDCommMsgMap(Request{Type: RequestMsgType, Chunks: missing})
+
+	if err := s.messenger.ReplyTo(t.ID, request); err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	s.sendProgress(t, request)
+
+	return nil
+}
+
+// abandon abandons the transfer and notifies the other agent with a problem-report replying to the given message.
+func (s *Service) abandon(t *Transfer, code, msgID string) error {
+	t.State = StateAbandoned
+	t.Error = code
+
+	if err := s.deleteChunks(t); err != nil {
+		return err
+	}
+
+	if err := s.saveTransfer(t); err != nil {
+		return err
+	}
+
+	report := newProblemReport(code)
+
+	if err := s.messenger.ReplyTo(msgID, report); err != nil {
+		return fmt.Errorf("send problem-report: %w", err)
+	}
+
+	s.sendProgress(t, report)
+
+	return nil
+}
+
+// replyProblemReport notifies the other agent of the error with a problem-report replying to the given message.
+func (s *Service) replyProblemReport(msgID, code string, cause error) error {
+	report := newProblemReport(code)
+
+	if err := s.messenger.ReplyTo(msgID, report); err != nil {
+		return fmt.Errorf("send problem-report: %w (%s)", err, cause)
+	}
+
+	return cause
+}
+
+// newDIDCommActionMsg creates the action event of an offered file
+func (s *Service) newDIDCommActionMsg(t *Transfer, msg service.DIDCommMsgMap) service.DIDCommAction {
+	return service.DIDCommAction{
+		ProtocolName: Name,
+		Message:      msg.Clone(),
+		Properties:   &eventProps{transfer: *t},
+		Continue: func(interface{}) {
+			if err := s.AcceptFile(t.ID); err != nil {
+				transferLogger(t).Errorf("accept file: %s", err)
+			}
+		},
+		Stop: func(error) {
+			if err := s.DeclineFile(t.ID); err != nil {
+				transferLogger(t).Errorf("decline file: %s", err)
+			}
+		},
+	}
+}
+
+// transferLogger returns the logger of the transfer, with its contextual fields.
+func transferLogger(t *Transfer) *log.Log {
+	return logger.WithFields(log.Fields{
+		log.FieldProtocol:   Name,
+		log.FieldThread:     t.ID,
+		log.FieldConnection: t.ConnectionID,
+	})
+}
+
+// sendProgress queues the message event reporting the progress of the transfer, it is triggered by unlock.
+func (s *Service) sendProgress(t *Transfer, msg service.DIDCommMsgMap) {
+	s.events = append(s.events, service.StateMsg{
+		ProtocolName: Name,
+		Type:         service.PostState,
+		StateID:      t.State,
+		Msg:          msg.Clone(),
+		Properties:   &eventProps{transfer: *t},
+	})
+}
+
+// unlock releases the lock of the transfers then triggers the pending message events,
+// so the consumers of the events may use the service.
+func (s *Service) unlock() {
+	events := s.events
+	s.events = nil
+	s.mu.Unlock()
+
+	for _, e := range events {
+		s.TriggerMsgEvents(e)
+	}
+}
+
+func (s *Service) content(t *Transfer) ([]byte, error) {
+	content := make([]byte, 0, t.File.Size)
+
+	for i := 0; i < t.File.Chunks; i++ {
+		data, err := s.store.Get(chunkKey(t.ID, i))
+		if err != nil {
+			return nil, fmt.Errorf("get chunk: %w", err)
+		}
+
+		content = append(content, data...)
+	}
+
+	return content, nil
+}
+
+func (s *Service) deleteChunks(t *Transfer) error {
+	for i := 0; i < t.File.Chunks; i++ {
+		err := s.store.Delete(chunkKey(t.ID, i))
+		if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+			return fmt.Errorf("delete chunk: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func newProblemReport(code string) service.DIDCommMsgMap {
+	return service.NewDIDCommMsgMap(model.ProblemReport{Type: ProblemReportMsgType, Description: model.Code{Code: code}})
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,433 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filetransfer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	Alice = "Alice"
+	Bob   = "Bob"
+
+	connID = "connection-id"
+)
+
+type provider struct {
+	messenger        service.Messenger
+	storage          storage.Provider
+	transientStorage storage.Provider
+}
+
+func (p *provider) Messenger() service.Messenger               { return p.messenger }
+func (p *provider) StorageProvider() storage.Provider          { return p.storage }
+func (p *provider) TransientStorageProvider() storage.Provider { return p.transientStorage }
+
+// pipe is the messenger of an agent delivering the messages to the other agent in order, the messages are
+// threaded like the messenger does.
+type pipe struct {
+	myDID    string
+	theirDID string
+	threads  *sync.Map
+	queue    chan service.DIDCommMsgMap
+	done     chan struct{}
+	// tamper may modify the sent messages, or drop them by returning nil
+	tamper func(service.DIDCommMsgMap) service.DIDCommMsgMap
+	// errors reports the errors of the agent handling the inbound messages
+	errors chan error
+}
+
+// deliver delivers the messages to the peer, the errors of the peer handling them are reported to its pipe
+func (p *pipe) deliver(peer *agent) {
+	for {
+		select {
+		case msg := <-p.queue:
+			if _, err := peer.HandleInbound(msg, p.theirDID, p.myDID); err != nil {
+				peer.pipe.errors <- err
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *pipe) send(thID string, msg service.DIDCommMsgMap) {
+	if msg.ID() == "" {
+		msg["@id"] = uuid.New().String()
+	}
+
+	msg["~thread"] = map[string]interface{}{"thid": thID}
+	p.threads.Store(msg.ID(), thID)
+
+	// the messages are marshaled like by the transport
+	src, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	if msg, err = service.ParseDIDCommMsgMap(src); err != nil {
+		panic(err)
+	}
+
+	if p.tamper != nil {
+		if msg = p.tamper(msg); msg == nil {
+			return
+		}
+	}
+
+	select {
+	case p.queue <- msg:
+	case <-p.done:
+	}
+}
+
+func (p *pipe) ReplyTo(msgID string, msg service.DIDCommMsgMap) error {
+	thID, ok := p.threads.Load(msgID)
+	if !ok {
+		return errors.New("unknown message")
+	}
+
+	p.send(thID.(string), msg)
+
+	return nil
+}
+
+func (p *pipe) Send(msg service.DIDCommMsgMap, _, _ string) error {
+	p.send(msg.ID(), msg)
+
+	return nil
+}
+
+func (p *pipe) SendToDestination(service.DIDCommMsgMap, string, *service.Destination) error {
+	return errors.New("not implemented")
+}
+
+func (p *pipe) ReplyToNested(string, service.DIDCommMsgMap, string, string) error {
+	return errors.New("not implemented")
+}
+
+type agent struct {
+	*Service
+	pipe   *pipe
+	events chan service.StateMsg
+}
+
+// waitFor waits for the progress event of the transfer in the given state
+func (a *agent) waitFor(t *testing.T, state string) Transfer {
+	t.Helper()
+
+	for {
+		select {
+		case e := <-a.events:
+			transfer := e.Properties.(*eventProps).Transfer()
+			if transfer.State == state {
+				return transfer
+			}
+		case err := <-a.pipe.errors:
+			t.Fatal(err)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %s", state)
+		}
+	}
+}
+
+func newAgent(t *testing.T, myDID, theirDID string, threads *sync.Map) *agent {
+	t.Helper()
+
+	p := &provider{
+		messenger: &pipe{
+			myDID:    myDID,
+			theirDID: theirDID,
+			threads:  threads,
+			queue:    make(chan service.DIDCommMsgMap, 100),
+			done:     make(chan struct{}),
+			errors:   make(chan error, 100),
+		},
+		storage:          mem.NewProvider(),
+		transientStorage: mem.NewProvider(),
+	}
+
+	recorder, err := connection.NewRecorder(p)
+	require.NoError(t, err)
+	require.NoError(t, recorder.SaveConnectionRecord(&connection.Record{
+		ConnectionID: connID, State: "completed", MyDID: myDID, TheirDID: theirDID,
+	}))
+
+	svc, err := New(p)
+	require.NoError(t, err)
+
+	events := make(chan service.StateMsg, 100)
+	require.NoError(t, svc.RegisterMsgEvent(events))
+
+	return &agent{Service: svc, pipe: p.messenger.(*pipe), events: events}
+}
+
+// newAgents returns the agents of a connection, the messages of one agent being delivered to the other
+func newAgents(t *testing.T) (*agent, *agent) {
+	t.Helper()
+
+	threads := &sync.Map{}
+
+	alice, bob := newAgent(t, Alice, Bob, threads), newAgent(t, Bob, Alice, threads)
+
+	go alice.pipe.deliver(bob)
+	go bob.pipe.deliver(alice)
+
+	t.Cleanup(func() {
+		close(alice.pipe.done)
+		close(bob.pipe.done)
+	})
+
+	return alice, bob
+}
+
+func testContent() []byte {
+	return bytes.Repeat([]byte("0123456789"), ChunkSize/4)
+}
+
+func TestService_Transfer(t *testing.T) {
+	content := testContent()
+
+	t.Run("file transferred", func(t *testing.T) {
+		alice, bob := newAgents(t)
+
+		actions := make(chan service.DIDCommAction, 1)
+		require.NoError(t, bob.RegisterActionEvent(actions))
+
+		transferID, err := alice.SendFile(connID, "file.txt", "text/plain", content)
+		require.NoError(t, err)
+
+		select {
+		case action := <-actions:
+			offered := action.Properties.(*eventProps).Transfer()
+			require.Equal(t, transferID, offered.ID)
+			require.Equal(t, connID, offered.ConnectionID)
+			require.Equal(t, "file.txt", offered.File.Name)
+			require.Equal(t, 3, offered.File.Chunks)
+
+			action.Continue(nil)
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		received := bob.waitFor(t, StateCompleted)
+		require.Equal(t, RoleReceiver, received.Role)
+		require.Equal(t, 3, received.Transferred)
+
+		sent := alice.waitFor(t, StateCompleted)
+		require.Equal(t, RoleSender, sent.Role)
+		require.Equal(t, 3, sent.Transferred)
+
+		file, err := bob.Content(transferID)
+		require.NoError(t, err)
+		require.Equal(t, content, file)
+
+		// the sent chunks are deleted
+		_, err = alice.store.Get(chunkKey(transferID, 0))
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		transfers, err := bob.Transfers()
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+	})
+
+	t.Run("transfer resumed", func(t *testing.T) {
+		alice, bob := newAgents(t)
+
+		var dropped bool
+
+		alice.pipe.tamper = func(msg service.DIDCommMsgMap) service.DIDCommMsgMap {
+			// the second chunk is lost the first time
+			if msg.Type() == ChunkMsgType && msg["index"] == 1.0 && !dropped {
+				dropped = true
+				return nil
+			}
+
+			return msg
+		}
+
+		transferID, err := alice.SendFile(connID, "file.txt", "text/plain", content)
+		require.NoError(t, err)
+
+		bob.waitFor(t, StateOfferReceived)
+		require.NoError(t, bob.AcceptFile(transferID))
+
+		for i := 0; i < 2; i++ {
+			bob.waitFor(t, StateTransferring)
+		}
+
+		// wait for the last chunk
+		transfer := bob.waitFor(t, StateTransferring)
+		require.Equal(t, 2, transfer.Transferred)
+		require.Equal(t, []bool{true, false, true}, transfer.Received)
+
+		require.NoError(t, bob.Resume(transferID))
+		bob.waitFor(t, StateCompleted)
+		alice.waitFor(t, StateCompleted)
+
+		file, err := bob.Content(transferID)
+		require.NoError(t, err)
+		require.Equal(t, content, file)
+	})
+
+	t.Run("file declined", func(t *testing.T) {
+		alice, bob := newAgents(t)
+
+		transferID, err := alice.SendFile(connID, "file.txt", "text/plain", content)
+		require.NoError(t, err)
+
+		bob.waitFor(t, StateOfferReceived)
+		require.NoError(t, bob.DeclineFile(transferID))
+
+		require.Equal(t, codeRejectedError, alice.waitFor(t, StateAbandoned).Error)
+
+		_, err = bob.Content(transferID)
+		require.EqualError(t, err, "transfer "+transferID+" is not a completed transfer of a received file")
+	})
+
+	t.Run("integrity check failed", func(t *testing.T) {
+		alice, bob := newAgents(t)
+
+		alice.pipe.tamper = func(msg service.DIDCommMsgMap) service.DIDCommMsgMap {
+			if msg.Type() == OfferMsgType {
+				msg["file"].(map[string]interface{})["sha256"] = digest([]byte("other"))
+			}
+
+			return msg
+		}
+
+		transferID, err := alice.SendFile(connID, "file.txt", "text/plain", content)
+		require.NoError(t, err)
+
+		bob.waitFor(t, StateOfferReceived)
+		require.NoError(t, bob.AcceptFile(transferID))
+
+		require.Equal(t, codeIntegrityError, bob.waitFor(t, StateAbandoned).Error)
+		require.Equal(t, codeIntegrityError, alice.waitFor(t, StateAbandoned).Error)
+	})
+
+	t.Run("invalid offer", func(t *testing.T) {
+		alice, bob := newAgents(t)
+
+		alice.pipe.tamper = func(msg service.DIDCommMsgMap) service.DIDCommMsgMap {
+			if msg.Type() == OfferMsgType {
+				msg["file"].(map[string]interface{})["chunks"] = 1
+			}
+
+			return msg
+		}
+
+		_, err := alice.SendFile(connID, "file.txt", "text/plain", content)
+		require.NoError(t, err)
+
+		select {
+		case err := <-bob.pipe.errors:
+			require.EqualError(t, err, "invalid offer: number of chunks does not match the file size")
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		require.Equal(t, codeInvalidError, alice.waitFor(t, StateAbandoned).Error)
+	})
+}
+
+func TestService_Errors(t *testing.T) {
+	alice, _ := newAgents(t)
+
+	_, err := alice.SendFile("unknown", "file.txt", "text/plain", testContent())
+	require.EqualError(t, err, "get connection record: data not found")
+
+	_, err = alice.SendFile(connID, "file.txt", "text/plain", nil)
+	require.EqualError(t, err, "file is empty")
+
+	transferID, err := alice.SendFile(connID, "file.txt", "text/plain", testContent())
+	require.NoError(t, err)
+
+	require.EqualError(t, alice.AcceptFile(transferID),
+		"transfer "+transferID+" cannot be accepted (sender offer-sent)")
+	require.EqualError(t, alice.DeclineFile(transferID),
+		"transfer "+transferID+" cannot be declined (sender offer-sent)")
+	require.EqualError(t, alice.Resume(transferID),
+		"transfer "+transferID+" cannot be resumed (sender offer-sent)")
+	require.EqualError(t, alice.Resume("unknown"), "get transfer: data not found")
+
+	_, err = alice.HandleInbound(service.NewDIDCommMsgMap(Chunk{ID: "ID", Type: ChunkMsgType}), Alice, Bob)
+	require.EqualError(t, err, "get transfer: data not found")
+
+	_, err = alice.HandleInbound(service.DIDCommMsgMap{"@id": "ID", "@type": "unknown"}, Alice, Bob)
+	require.EqualError(t, err, "unrecognized msgType: unknown")
+
+	require.EqualError(t, alice.HandleOutbound(nil, Alice, Bob), "not implemented")
+	require.Equal(t, Name, alice.Name())
+	require.True(t, alice.Accept(ChunkMsgType))
+	require.False(t, alice.Accept("unknown"))
+}
+
+func TestService_ThirdParty(t *testing.T) {
+	alice, _ := newAgents(t)
+
+	transferID, err := alice.SendFile(connID, "file.txt", "text/plain", testContent())
+	require.NoError(t, err)
+
+	request := service.NewDIDCommMsgMap(Request{ID: "request", Type: RequestMsgType, Chunks: []int{0, 1, 2}})
+	request["~thread"] = map[string]interface{}{"thid": transferID}
+
+	// another connection of Alice or another DID of Alice
+	for _, dids := range [][2]string{{Alice, "Mallory"}, {"Alice-2", Bob}} {
+		_, err = alice.HandleInbound(request, dids[0], dids[1])
+		require.EqualError(t, err, RequestMsgType+" message of transfer "+transferID+
+			" not received over its connection")
+	}
+
+	transfer, err := alice.Transfer(transferID)
+	require.NoError(t, err)
+	require.Equal(t, StateOfferSent, transfer.State)
+	require.Zero(t, transfer.Transferred)
+}
+
+func TestValidateChunk(t *testing.T) {
+	content := testContent()
+
+	file, err := newFile("file.txt", "", content)
+	require.NoError(t, err)
+
+	last := chunkData(file, 2, content)
+	require.Len(t, last, len(content)-2*ChunkSize)
+
+	encoded := base64.StdEncoding.EncodeToString(last)
+
+	data, err := validateChunk(file, &Chunk{Index: 2, Data: encoded, SHA256: digest(last)})
+	require.NoError(t, err)
+	require.Equal(t, last, data)
+
+	_, err = validateChunk(file, &Chunk{Index: 3})
+	require.EqualError(t, err, "chunk index 3 is out of range")
+
+	_, err = validateChunk(file, &Chunk{Index: 2, Data: "%"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "decode chunk 2")
+
+	_, err = validateChunk(file, &Chunk{Index: 2, Data: base64.StdEncoding.EncodeToString(content)})
+	require.EqualError(t, err, "unexpected size of chunk 2")
+
+	_, err = validateChunk(file, &Chunk{Index: 2, Data: encoded, SHA256: digest(content)})
+	require.EqualError(t, err, "SHA-256 digest of chunk 2 does not match")
+}
@@ -0,0 +1,213 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filetransfer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// RoleSender is the role of the agent sending the file.
+	RoleSender = "sender"
+	// RoleReceiver is the role of the agent receiving the file.
+	RoleReceiver = "receiver"
+)
+
+const (
+	// StateOfferSent is the state of the sender until the receiver accepts the file.
+	StateOfferSent = "offer-sent"
+	// StateOfferReceived is the state of the receiver until it accepts or declines the file.
+	StateOfferReceived = "offer-received"
+	// StateTransferring is the state of both agents while the chunks are transferred.
+	StateTransferring = "transferring"
+	// StateCompleted is the state of both agents once the receiver verified and acknowledged the file.
+	StateCompleted = "completed"
+	// StateAbandoned is the state of both agents if the file was declined or the transfer failed.
+	StateAbandoned = "abandoned"
+)
+
+const (
+	transferKey = "transfer_%s"
+	chunkKeyFmt = "chunk_%s_%d"
+)
+
+// Transfer is the record of a file transfer.
+type Transfer struct {
+	// ID of the transfer, the thread ID of its messages
+	ID    string
+	Role  string
+	State string
+	// ConnectionID is the ID of the connection the file is transferred over (if any)
+	ConnectionID string `json:",omitempty"`
+	MyDID        string
+	TheirDID     string
+	File         File
+	// Transferred is the number of chunks sent by the sender or received by the receiver
+	Transferred int
+	// Received reports, for the receiver, which chunks were received
+	Received []bool `json:",omitempty"`
+	// Error is the code of the problem-report which abandoned the transfer
+	Error string `json:",omitempty"`
+}
+
+// eventProps contains the properties of the events, i.e the transfer as updated by the event.
+type eventProps struct {
+	transfer Transfer
+}
+
+// Transfer returns the transfer: its state and the number of chunks transferred report its progress.
+func (e *eventProps) Transfer() Transfer {
+	return e.transfer
+}
+
+// Transfers returns the transfers.
+func (s *Service) Transfers() ([]*Transfer, error) {
+	records := s.store.Iterator(fmt.Sprintf(transferKey, ""), fmt.Sprintf(transferKey, storage.EndKeySuffix))
+	defer records.Release()
+
+	var transfers []*Transfer
+
+	for records.Next() {
+		var t *Transfer
+		if err := json.Unmarshal(records.Value(), &t); err != nil {
+			return nil, fmt.Errorf("unmarshal transfer: %w", err)
+		}
+
+		transfers = append(transfers, t)
+	}
+
+	if records.Error() != nil {
+		return nil, records.Error()
+	}
+
+	return transfers, nil
+}
+
+// Transfer returns the transfer with the given ID.
+func (s *Service) Transfer(transferID string) (*Transfer, error) {
+	return s.getTransfer(transferID)
+}
+
+func (s *Service) getTransfer(transferID string) (*Transfer, error) {
+	src, err := s.store.Get(fmt.Sprintf(transferKey, transferID))
+	if err != nil {
+		return nil, fmt.Errorf("get transfer: %w", err)
+	}
+
+	var t *Transfer
+	if err := json.Unmarshal(src, &t); err != nil {
+		return nil, fmt.Errorf("unmarshal transfer: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *Service) saveTransfer(t *Transfer) error {
+	src, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("marshal transfer: %w", err)
+	}
+
+	if err := s.store.Put(fmt.Sprintf(transferKey, t.ID), src); err != nil {
+		return fmt.Errorf("save transfer: %w", err)
+	}
+
+	return nil
+}
+
+func chunkKey(transferID string, index int) string {
+	return fmt.Sprintf(chunkKeyFmt, transferID, index)
+}
+
+// newFile describes the file to be sent.
+func newFile(name, mimeType string, content []byte) (*File, error) {
+	if len(content) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	if len(content) > MaxFileSize {
+		return nil, fmt.Errorf("file size exceeds %d bytes", MaxFileSize)
+	}
+
+	return &File{
+		Name:      name,
+		MimeType:  mimeType,
+		Size:      int64(len(content)),
+		SHA256:    digest(content),
+		ChunkSize: ChunkSize,
+		Chunks:    (len(content) + ChunkSize - 1) / ChunkSize,
+	}, nil
+}
+
+// chunkData returns the content of the chunk with the given index.
+func chunkData(file *File, index int, content []byte) []byte {
+	end := (index + 1) * file.ChunkSize
+	if end > len(content) {
+		end = len(content)
+	}
+
+	return content[index*file.ChunkSize : end]
+}
+
+// chunkLen returns the expected size of the chunk with the given index.
+func chunkLen(file *File, index int) int {
+	if index == file.Chunks-1 {
+		return int(file.Size - int64(index)*int64(file.ChunkSize))
+	}
+
+	return file.ChunkSize
+}
+
+// validateFile validates the description of an offered file.
+func validateFile(file *File) error {
+	if file.Size <= 0 || file.Size > MaxFileSize {
+		return fmt.Errorf("file size must be between 1 and %d bytes", MaxFileSize)
+	}
+
+	if file.ChunkSize <= 0 || file.ChunkSize > MaxChunkSize {
+		return fmt.Errorf("chunk size must be between 1 and %d bytes", MaxChunkSize)
+	}
+
+	if int64(file.Chunks) != (file.Size+int64(file.ChunkSize)-1)/int64(file.ChunkSize) {
+		return errors.New("number of chunks does not match the file size")
+	}
+
+	if sum, err := hex.DecodeString(file.SHA256); err != nil || len(sum) != sha256.Size {
+		return errors.New("invalid SHA-256 digest")
+	}
+
+	return nil
+}
+
+// validateChunk validates a received chunk against the description of the file and returns its content.
+func validateChunk(file *File, chunk *Chunk) ([]byte, error) {
+	if chunk.Index < 0 || chunk.Index >= file.Chunks {
+		return nil, fmt.Errorf("chunk index %d is out of range", chunk.Index)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(chunk.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decode chunk %d: %w", chunk.Index, err)
+	}
+
+	if len(data) != chunkLen(file, chunk.Index) {
+		return nil, fmt.Errorf("unexpected size of chunk %d", chunk.Index)
+	}
+
+	if digest(data) != chunk.SHA256 {
+		return nil, fmt.Errorf("SHA-256 digest of chunk %d does not match", chunk.Index)
+	}
+
+	return data, nil
+}
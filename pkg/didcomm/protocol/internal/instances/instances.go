@@ -0,0 +1,34 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package instances counts the instances of the protocol services (introduce, issue credential and present proof)
+// by state, over the state names the services keep in their stores.
+package instances
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+// ByState returns the number of protocol instances in progress by state, the state names of the instances are kept
+// in the store under the key prefix. The instances which reached the done state are not counted.
+func ByState(store storage.Store, keyPrefix, doneState string) (map[string]int, error) {
+	records := store.Iterator(keyPrefix, keyPrefix+storage.EndKeySuffix)
+	defer records.Release()
+
+	instances := map[string]int{}
+
+	for records.Next() {
+		if state := string(records.Value()); state != doneState {
+			instances[state]++
+		}
+	}
+
+	if records.Error() != nil {
+		return nil, records.Error()
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package instances
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const (
+	stateNameKey = "state_name_"
+	done         = "done"
+)
+
+func TestByState(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		store, err := mem.NewProvider().OpenStore("test")
+		require.NoError(t, err)
+
+		instances, err := ByState(store, stateNameKey, done)
+		require.NoError(t, err)
+		require.Empty(t, instances)
+
+		require.NoError(t, store.Put(stateNameKey+"piID-1", []byte("request-sent")))
+		require.NoError(t, store.Put(stateNameKey+"piID-2", []byte("request-sent")))
+		require.NoError(t, store.Put(stateNameKey+"piID-3", []byte("presentation-received")))
+		require.NoError(t, store.Put(stateNameKey+"piID-4", []byte(done)))
+		// not a state name
+		require.NoError(t, store.Put("transitionalPayload_piID-1", []byte("{}")))
+
+		instances, err = ByState(store, stateNameKey, done)
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"request-sent": 2, "presentation-received": 1}, instances)
+	})
+
+	t.Run("iterator error", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: map[string][]byte{}, ErrItr: errors.New("iterator error")}
+
+		_, err := ByState(store, stateNameKey, done)
+		require.EqualError(t, err, "iterator error")
+	})
+}
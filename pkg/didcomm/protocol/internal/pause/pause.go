@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package pause parks the actions of the protocol services (issue credential and present proof) until they
+// are resumed with a resume token, the tokens are persisted with the exchanges and survive a restart of the agent.
+package pause
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const resumeTokenKey = "resumeToken_%s"
+
+// ErrActionPaused is returned when a paused action is continued or stopped by its piID,
+// the action must be resumed with its resume token first.
+var ErrActionPaused = errors.New("action is paused")
+
+// ErrUnknownResumeToken is returned when no action is paused with the resume token.
+var ErrUnknownResumeToken = errors.New("unknown resume token")
+
+// Actions keeps the resume tokens of the paused actions of a protocol service.
+type Actions struct {
+	store storage.Store
+	// paused keeps the piIDs of the actions paused since the agent started
+	paused sync.Map
+}
+
+// New returns the paused actions of a protocol service, the resume tokens are kept in its store.
+func New(store storage.Store) *Actions {
+	return &Actions{store: store}
+}
+
+// Pause parks the action by the piID. The token is the resume token of the action if it is already paused,
+// save persists the action with its new resume token otherwise. It returns the resume token of the action.
+func (a *Actions) Pause(piID, token string, save func(token string) error) (string, error) {
+	a.paused.Store(piID, struct{}{})
+
+	if token != "" {
+		return token, nil
+	}
+
+	// the resume token must be unpredictable, it is a random UUID whatever the ID generator of the framework
+	token = idgen.NewID()
+
+	if err := a.store.Put(fmt.Sprintf(resumeTokenKey, token), []byte(piID)); err != nil {
+		return "", fmt.Errorf("save resume token: %w", err)
+	}
+
+	if err := save(token); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Resume resumes the action paused with the token: resume persists the action by its piID without its resume
+// token, it returns an error wrapping storage.ErrDataNotFound if the exchange was abandoned while the action
+// was paused. The resume token can only be used once.
+func (a *Actions) Resume(token string, resume func(piID string) error) error {
+	piID, err := a.store.Get(fmt.Sprintf(resumeTokenKey, token))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return ErrUnknownResumeToken
+	}
+
+	if err != nil {
+		return fmt.Errorf("get resume token: %w", err)
+	}
+
+	err = resume(string(piID))
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+		return err
+	}
+
+	a.paused.Delete(string(piID))
+
+	if e := a.store.Delete(fmt.Sprintf(resumeTokenKey, token)); e != nil {
+		return fmt.Errorf("delete resume token: %w", e)
+	}
+
+	if err != nil {
+		// the exchange was abandoned while the action was paused
+		return ErrUnknownResumeToken
+	}
+
+	return nil
+}
+
+// Paused checks whether the action by the piID was paused since the agent started, the DIDCommAction
+// of a paused action can not be continued or stopped (the action events are not triggered again after a restart).
+func (a *Actions) Paused(piID string) bool {
+	_, ok := a.paused.Load(piID)
+
+	return ok
+}
@@ -0,0 +1,115 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pause
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func newActions(t *testing.T) (*Actions, storage.Store) {
+	t.Helper()
+
+	store, err := mem.NewProvider().OpenStore("test")
+	require.NoError(t, err)
+
+	return New(store), store
+}
+
+func TestActions(t *testing.T) {
+	t.Run("pause and resume", func(t *testing.T) {
+		actions, store := newActions(t)
+
+		var saved string
+
+		token, err := actions.Pause("piID", "", func(token string) error {
+			saved = token
+			return nil
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		require.Equal(t, token, saved)
+		require.True(t, actions.Paused("piID"))
+
+		// the action is already paused
+		again, err := actions.Pause("piID", token, func(string) error {
+			return errors.New("not saved again")
+		})
+		require.NoError(t, err)
+		require.Equal(t, token, again)
+
+		var resumed string
+
+		require.NoError(t, actions.Resume(token, func(piID string) error {
+			resumed = piID
+			return nil
+		}))
+		require.Equal(t, "piID", resumed)
+		require.False(t, actions.Paused("piID"))
+
+		_, err = store.Get(fmt.Sprintf(resumeTokenKey, token))
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		// the token can only be used once
+		require.True(t, errors.Is(actions.Resume(token, nil), ErrUnknownResumeToken))
+	})
+
+	t.Run("exchange abandoned while the action is paused", func(t *testing.T) {
+		actions, store := newActions(t)
+
+		token, err := actions.Pause("piID", "", func(string) error { return nil })
+		require.NoError(t, err)
+
+		err = actions.Resume(token, func(string) error {
+			return fmt.Errorf("get transitional payload: %w", storage.ErrDataNotFound)
+		})
+		require.True(t, errors.Is(err, ErrUnknownResumeToken))
+		require.False(t, actions.Paused("piID"))
+
+		_, err = store.Get(fmt.Sprintf(resumeTokenKey, token))
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+	})
+
+	t.Run("resume error", func(t *testing.T) {
+		actions, store := newActions(t)
+
+		token, err := actions.Pause("piID", "", func(string) error { return nil })
+		require.NoError(t, err)
+
+		require.EqualError(t, actions.Resume(token, func(string) error {
+			return errors.New("save error")
+		}), "save error")
+		require.True(t, actions.Paused("piID"))
+
+		// the action can be resumed again
+		_, err = store.Get(fmt.Sprintf(resumeTokenKey, token))
+		require.NoError(t, err)
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		actions := New(&mockstorage.MockStore{Store: map[string][]byte{}, ErrPut: errors.New("put error")})
+
+		_, err := actions.Pause("piID", "", func(string) error { return nil })
+		require.EqualError(t, err, "save resume token: put error")
+
+		actions, _ = newActions(t)
+
+		_, err = actions.Pause("piID", "", func(string) error { return errors.New("save error") })
+		require.EqualError(t, err, "save error")
+
+		actions = New(&mockstorage.MockStore{Store: map[string][]byte{}, ErrGet: errors.New("get error")})
+
+		require.EqualError(t, actions.Resume("token", nil), "get resume token: get error")
+	})
+}
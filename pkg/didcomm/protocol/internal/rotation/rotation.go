@@ -0,0 +1,205 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package rotation associates the exchanges of the protocol services (issue credential and present proof) with
+// the connections of their parties and follows the rotation of the DID of the other agent (from_prior JWT).
+package rotation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	threadKey = "thread_%s"
+
+	jsonFromPrior = "from_prior"
+)
+
+// Thread binds an exchange to the current DIDs of its parties and to the connection between them (if any).
+type Thread struct {
+	ConnectionID string
+	MyDID        string
+	TheirDID     string
+}
+
+// Threads keeps the threads of the exchanges of a protocol service.
+type Threads struct {
+	store       storage.Store
+	connections *connection.Recorder
+	keyResolver jwt.KeyResolver
+}
+
+// New returns the threads of a protocol service, they are kept in its store. The from_prior JWTs are verified
+// with the keys resolved by the key resolver.
+func New(store storage.Store, connections *connection.Recorder, keyResolver jwt.KeyResolver) *Threads {
+	return &Threads{store: store, connections: connections, keyResolver: keyResolver}
+}
+
+// Associate associates the exchange with the connection of the DIDs of the message and binds the thread
+// to the DID of the other agent: a message of the thread from another DID is rejected unless it carries
+// a from_prior JWT, signed by the DID bound to the thread, declaring the new DID of the other agent.
+// The connection record is updated with the new DID, so the next exchanges are associated with the connection too.
+// It returns the ID of the connection the exchange is associated with, empty if there is none.
+func (t *Threads) Associate(piID, myDID, theirDID string, msg service.DIDCommMsgMap) (string, error) {
+	fromPrior, err := t.FromPrior(msg)
+	if err != nil {
+		return "", fmt.Errorf("from_prior: %w", err)
+	}
+
+	th, err := t.Get(piID)
+	if err != nil {
+		return "", fmt.Errorf("get thread: %w", err)
+	}
+
+	if th == nil {
+		th = &Thread{TheirDID: theirDID}
+
+		// the first message of the exchange is sent from the rotated DID
+		if fromPrior != nil {
+			th.TheirDID = fromPrior.ISS
+		}
+
+		th.ConnectionID, err = t.connectionID(myDID, th.TheirDID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if fromPrior != nil && fromPrior.ISS == th.TheirDID && fromPrior.SUB == theirDID {
+		if err := t.rotateTheirDID(th, fromPrior.SUB); err != nil {
+			return "", fmt.Errorf("rotate DID: %w", err)
+		}
+	}
+
+	if th.TheirDID != theirDID {
+		return "", fmt.Errorf("DID %s is not a party of the thread %s", theirDID, piID)
+	}
+
+	// our own DID is rotated by us
+	th.MyDID = myDID
+
+	if err := t.save(piID, th); err != nil {
+		return "", fmt.Errorf("save thread: %w", err)
+	}
+
+	return th.ConnectionID, nil
+}
+
+// Release deletes the thread of the exchange once it is over.
+func (t *Threads) Release(piID string) error {
+	if err := t.store.Delete(fmt.Sprintf(threadKey, piID)); err != nil {
+		return fmt.Errorf("delete thread: %w", err)
+	}
+
+	return nil
+}
+
+// FromPrior returns the verified claims of the from_prior JWT of the message, if any.
+func (t *Threads) FromPrior(msg service.DIDCommMsgMap) (*decorator.FromPrior, error) {
+	raw, ok := msg[jsonFromPrior].(string)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	token, err := jwt.Parse(raw, jwt.WithSignatureVerifier(jwt.NewVerifier(t.keyResolver)))
+	if err != nil {
+		return nil, fmt.Errorf("parse JWT: %w", err)
+	}
+
+	claims := &decorator.FromPrior{}
+
+	if err := token.DecodeClaims(claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+
+	if claims.ISS == "" || claims.SUB == "" {
+		return nil, errors.New("iss and sub claims are mandatory")
+	}
+
+	return claims, nil
+}
+
+// connectionID returns the ID of the connection between the DIDs, empty if there is none.
+func (t *Threads) connectionID(myDID, theirDID string) (string, error) {
+	connID, err := t.connections.GetConnectionIDByDIDs(myDID, theirDID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("get connection ID: %w", err)
+	}
+
+	return connID, nil
+}
+
+// rotateTheirDID replaces the DID of the other agent in the thread and in its connection record,
+// the connection is no longer found by the prior DID.
+func (t *Threads) rotateTheirDID(th *Thread, newDID string) error {
+	th.TheirDID = newDID
+
+	if th.ConnectionID == "" {
+		return nil
+	}
+
+	record, err := t.connections.GetConnectionRecord(th.ConnectionID)
+	if err != nil {
+		return fmt.Errorf("get connection record: %w", err)
+	}
+
+	priorDID := record.TheirDID
+	record.TheirDID = newDID
+
+	if err := t.connections.SaveConnectionRecord(record); err != nil {
+		return fmt.Errorf("save connection record: %w", err)
+	}
+
+	if priorDID == newDID {
+		return nil
+	}
+
+	if err := t.connections.RemoveDIDMapping(record.MyDID, priorDID); err != nil {
+		return fmt.Errorf("remove prior DID mapping: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the thread of the exchange, nil if the exchange is not associated yet.
+func (t *Threads) Get(piID string) (*Thread, error) {
+	src, err := t.store.Get(fmt.Sprintf(threadKey, piID))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	th := &Thread{}
+	if err := json.Unmarshal(src, th); err != nil {
+		return nil, fmt.Errorf("unmarshal thread: %w", err)
+	}
+
+	return th, nil
+}
+
+func (t *Threads) save(piID string, th *Thread) error {
+	src, err := json.Marshal(th)
+	if err != nil {
+		return fmt.Errorf("marshal thread: %w", err)
+	}
+
+	return t.store.Put(fmt.Sprintf(threadKey, piID), src)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rotation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	alice  = "did:example:alice"
+	bob    = "did:example:bob"
+	newBob = "did:example:bob-rotated"
+	connID = "connection-id"
+)
+
+type provider struct {
+	storageProvider          storage.Provider
+	transientStorageProvider storage.Provider
+}
+
+func (p *provider) StorageProvider() storage.Provider {
+	return p.storageProvider
+}
+
+func (p *provider) TransientStorageProvider() storage.Provider {
+	return p.transientStorageProvider
+}
+
+type ed25519Signer struct {
+	privKey ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privKey, data), nil
+}
+
+func (s *ed25519Signer) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: "EdDSA", jose.HeaderKeyID: "#key-1"}
+}
+
+func newThreads(t *testing.T, pubKey ed25519.PublicKey) (*Threads, *connection.Recorder) {
+	t.Helper()
+
+	p := &provider{storageProvider: mem.NewProvider(), transientStorageProvider: mem.NewProvider()}
+
+	recorder, err := connection.NewRecorder(p)
+	require.NoError(t, err)
+
+	require.NoError(t, recorder.SaveConnectionRecord(&connection.Record{
+		ConnectionID: connID, State: "completed", MyDID: alice, TheirDID: bob,
+	}))
+
+	store, err := p.StorageProvider().OpenStore("test")
+	require.NoError(t, err)
+
+	// the keys of bob
+	keyResolver := jwt.KeyResolverFunc(func(did, _ string) (*verifier.PublicKey, error) {
+		if did != bob {
+			return nil, errors.New("unknown DID")
+		}
+
+		return &verifier.PublicKey{Type: "Ed25519VerificationKey2018", Value: pubKey}, nil
+	})
+
+	return New(store, recorder, keyResolver), recorder
+}
+
+func messageFrom(t *testing.T, privKey ed25519.PrivateKey, claims *decorator.FromPrior) service.DIDCommMsgMap {
+	t.Helper()
+
+	if claims == nil {
+		return service.DIDCommMsgMap{}
+	}
+
+	token, err := jwt.NewSigned(claims, nil, &ed25519Signer{privKey: privKey})
+	require.NoError(t, err)
+
+	raw, err := token.Serialize(false)
+	require.NoError(t, err)
+
+	return service.DIDCommMsgMap{jsonFromPrior: raw}
+}
+
+func TestThreads_Associate(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("exchange associated with the connection", func(t *testing.T) {
+		threads, _ := newThreads(t, pubKey)
+
+		id, err := threads.Associate("piID", alice, bob, nil)
+		require.NoError(t, err)
+		require.Equal(t, connID, id)
+
+		th, err := threads.Get("piID")
+		require.NoError(t, err)
+		require.Equal(t, &Thread{ConnectionID: connID, MyDID: alice, TheirDID: bob}, th)
+
+		// no connection between the DIDs
+		id, err = threads.Associate("piID-2", alice, "did:example:carol", nil)
+		require.NoError(t, err)
+		require.Empty(t, id)
+	})
+
+	t.Run("message from another DID", func(t *testing.T) {
+		threads, _ := newThreads(t, pubKey)
+
+		_, err := threads.Associate("piID", alice, bob, nil)
+		require.NoError(t, err)
+
+		_, err = threads.Associate("piID", alice, newBob, nil)
+		require.EqualError(t, err, "DID "+newBob+" is not a party of the thread piID")
+	})
+
+	t.Run("DID rotation", func(t *testing.T) {
+		threads, recorder := newThreads(t, pubKey)
+
+		_, err := threads.Associate("piID", alice, bob, nil)
+		require.NoError(t, err)
+
+		msg := messageFrom(t, privKey, &decorator.FromPrior{ISS: bob, SUB: newBob})
+
+		id, err := threads.Associate("piID", alice, newBob, msg)
+		require.NoError(t, err)
+		require.Equal(t, connID, id)
+
+		record, err := recorder.GetConnectionRecord(connID)
+		require.NoError(t, err)
+		require.Equal(t, newBob, record.TheirDID)
+
+		id, err = recorder.GetConnectionIDByDIDs(alice, newBob)
+		require.NoError(t, err)
+		require.Equal(t, connID, id)
+
+		// the stale mapping of the prior DID is removed
+		_, err = recorder.GetConnectionIDByDIDs(alice, bob)
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		th, err := threads.Get("piID")
+		require.NoError(t, err)
+		require.Equal(t, &Thread{ConnectionID: connID, MyDID: alice, TheirDID: newBob}, th)
+	})
+
+	t.Run("DID rotation of the first message", func(t *testing.T) {
+		threads, _ := newThreads(t, pubKey)
+
+		msg := messageFrom(t, privKey, &decorator.FromPrior{ISS: bob, SUB: newBob})
+
+		id, err := threads.Associate("piID", alice, newBob, msg)
+		require.NoError(t, err)
+		require.Equal(t, connID, id)
+	})
+
+	t.Run("from_prior not signed by the prior DID", func(t *testing.T) {
+		threads, _ := newThreads(t, pubKey)
+
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		msg := messageFrom(t, otherKey, &decorator.FromPrior{ISS: bob, SUB: newBob})
+
+		_, err = threads.Associate("piID", alice, newBob, msg)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "from_prior: parse JWT")
+	})
+
+	t.Run("from_prior without sub", func(t *testing.T) {
+		threads, _ := newThreads(t, pubKey)
+
+		_, err := threads.FromPrior(messageFrom(t, privKey, &decorator.FromPrior{ISS: bob}))
+		require.EqualError(t, err, "iss and sub claims are mandatory")
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		threads, _ := newThreads(t, pubKey)
+		threads.store = &mockstorage.MockStore{Store: map[string][]byte{}, ErrGet: errors.New("get error")}
+
+		_, err := threads.Associate("piID", alice, bob, nil)
+		require.EqualError(t, err, "get thread: get error")
+
+		threads.store = &mockstorage.MockStore{Store: map[string][]byte{}, ErrPut: errors.New("put error")}
+
+		_, err = threads.Associate("piID", alice, bob, nil)
+		require.EqualError(t, err, "save thread: put error")
+
+		threads.store = &mockstorage.MockStore{Store: map[string][]byte{"thread_piID": []byte("{")}}
+
+		_, err = threads.Associate("piID", alice, bob, nil)
+		require.Contains(t, err.Error(), "get thread: unmarshal thread")
+	})
+}
+
+func TestThreads_Release(t *testing.T) {
+	threads, _ := newThreads(t, nil)
+
+	_, err := threads.Associate("piID", alice, bob, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, threads.Release("piID"))
+
+	th, err := threads.Get("piID")
+	require.NoError(t, err)
+	require.Nil(t, th)
+
+	threads.store = &mockstorage.MockStore{Store: map[string][]byte{}, ErrDelete: errors.New("delete error")}
+	require.EqualError(t, threads.Release("piID"), "delete thread: delete error")
+}
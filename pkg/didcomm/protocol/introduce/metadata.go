@@ -7,8 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package introduce
 
 import (
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
 )
@@ -62,7 +61,7 @@ func WithRecipients(to *To, recipient *Recipient) Opt {
 // The function is used by the introduce client to define that a few messages are related to each other.
 // e.g When two proposals are sent simultaneously piID helps the protocol to determine that messages are related.
 func WrapWithMetadataPIID(msgMap ...service.DIDCommMsg) {
-	var piID = uuid.New().String()
+	var piID = idgen.NewID()
 
 	for _, msg := range msgMap {
 		msg.Metadata()[metaPIID] = piID
@@ -19,6 +19,9 @@ type Proposal struct {
 	NWise  bool              `json:"nwise,omitempty"`
 	Thread *decorator.Thread `json:"~thread,omitempty"`
 	Timing *decorator.Timing `json:"~timing,omitempty"`
+	// Attachments may contain the request-presentation the verifier sends to the other introducee once they are
+	// connected (see RequestPresentationAttachID).
+	Attachments []decorator.Attachment `json:"~attach,omitempty"`
 }
 
 // To introducee descriptor keeps information about the introduction
@@ -0,0 +1,243 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package introduce
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// RequestPresentationAttachID is the ID of the request-presentation attached to the proposal sent to the verifier.
+	RequestPresentationAttachID = "request-presentation"
+	// stateCompleted the didexchange protocol state name of the established connections
+	stateCompleted = "completed"
+
+	presentationRequestKey           = "presentation_request_%s"
+	presentationRequestInvitationKey = "presentation_request_invitation_%s"
+)
+
+// connectionEvent is implemented by the properties of the didexchange events.
+type connectionEvent interface {
+	ConnectionID() string
+	InvitationID() string
+}
+
+// CreateRecipientProposal creates a DIDCommMsgMap proposal for the recipient, the request-presentation
+// of the recipient (the verifier) is attached to the proposal.
+func CreateRecipientProposal(recipient *Recipient) service.DIDCommMsgMap {
+	proposal := &Proposal{
+		Type: ProposalMsgType,
+		To:   recipient.To,
+	}
+
+	if recipient.RequestPresentation != nil {
+		proposal.Attachments = []decorator.Attachment{{
+			ID:       RequestPresentationAttachID,
+			MimeType: "application/json",
+			Data:     decorator.AttachmentData{JSON: recipient.RequestPresentation},
+		}}
+	}
+
+	return service.NewDIDCommMsgMap(proposal)
+}
+
+// requestPresentation returns the request-presentation attached to the proposal, nil if there is none.
+func requestPresentation(msg service.DIDCommMsg) (*presentproof.RequestPresentation, error) {
+	proposal := &Proposal{}
+	if err := msg.Decode(proposal); err != nil {
+		return nil, fmt.Errorf("decode proposal: %w", err)
+	}
+
+	for _, a := range proposal.Attachments {
+		if a.ID != RequestPresentationAttachID {
+			continue
+		}
+
+		src, err := json.Marshal(a.Data.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request-presentation: %w", err)
+		}
+
+		request := &presentproof.RequestPresentation{}
+		if err := json.Unmarshal(src, request); err != nil {
+			return nil, fmt.Errorf("unmarshal request-presentation: %w", err)
+		}
+
+		return request, nil
+	}
+
+	return nil, nil
+}
+
+// presentationRequest is the request-presentation saved for an introduction, bound to the invitation
+// of the connection it is sent to.
+type presentationRequest struct {
+	InvitationID string                            `json:"invitation_id,omitempty"`
+	Request      *presentproof.RequestPresentation `json:"request"`
+}
+
+// savePresentationRequest saves the request-presentation attached to the proposal approved by the verifier.
+// The request is sent once the verifier is connected to the prover, the connection is identified by the invitation
+// of the verifier or, if the verifier has not shared one, by the invitation delivered in the thread of the proposal.
+func (s *Service) savePresentationRequest(md *metaData) error {
+	if !md.inbound || md.rejected || md.err != nil || md.Msg.Type() != ProposalMsgType {
+		return nil
+	}
+
+	request, err := requestPresentation(md.Msg)
+	if err != nil || request == nil {
+		return err
+	}
+
+	saved := &presentationRequest{Request: request}
+
+	inv, err := contextInvitation(md.Msg)
+	if err != nil {
+		return fmt.Errorf("context invitation: %w", err)
+	}
+
+	if inv != nil {
+		saved.InvitationID = inv.ID
+	}
+
+	return s.putPresentationRequest(md.PIID, saved)
+}
+
+// bindPresentationRequest binds the request-presentation saved for the introduction to the invitation delivered
+// in its thread.
+func (s *Service) bindPresentationRequest(piID, invitationID string) error {
+	saved, err := s.getPresentationRequest(piID)
+	if err != nil || saved == nil {
+		return err
+	}
+
+	saved.InvitationID = invitationID
+
+	return s.putPresentationRequest(piID, saved)
+}
+
+// deletePresentationRequest deletes the request-presentation saved for the introduction, once it is sent
+// or when the introduction is abandoned.
+func (s *Service) deletePresentationRequest(piID string) error {
+	saved, err := s.getPresentationRequest(piID)
+	if err != nil || saved == nil {
+		return err
+	}
+
+	if saved.InvitationID != "" {
+		if err := s.store.Delete(fmt.Sprintf(presentationRequestInvitationKey, saved.InvitationID)); err != nil {
+			return fmt.Errorf("delete request-presentation invitation: %w", err)
+		}
+	}
+
+	if err := s.store.Delete(fmt.Sprintf(presentationRequestKey, piID)); err != nil {
+		return fmt.Errorf("delete request-presentation: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) putPresentationRequest(piID string, saved *presentationRequest) error {
+	src, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("marshal request-presentation: %w", err)
+	}
+
+	if err := s.store.Put(fmt.Sprintf(presentationRequestKey, piID), src); err != nil {
+		return err
+	}
+
+	if saved.InvitationID == "" {
+		return nil
+	}
+
+	// the introduction of the request-presentation is found by the invitation of the completed connection
+	err = s.store.Put(fmt.Sprintf(presentationRequestInvitationKey, saved.InvitationID), []byte(piID))
+	if err != nil {
+		return fmt.Errorf("invitation: %w", err)
+	}
+
+	return nil
+}
+
+// getPresentationRequest returns the request-presentation saved for the introduction, nil if there is none.
+func (s *Service) getPresentationRequest(piID string) (*presentationRequest, error) {
+	src, err := s.store.Get(fmt.Sprintf(presentationRequestKey, piID))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get request-presentation: %w", err)
+	}
+
+	saved := &presentationRequest{}
+	if err := json.Unmarshal(src, saved); err != nil {
+		return nil, fmt.Errorf("unmarshal request-presentation: %w", err)
+	}
+
+	return saved, nil
+}
+
+// ConnectionCompleted starts the proof exchange attached to the introduction
+// the function should be called by didexchange after the connection is completed.
+func (s *Service) ConnectionCompleted(msg service.StateMsg) error {
+	if msg.StateID != stateCompleted || msg.Type != service.PostState {
+		return nil
+	}
+
+	props, ok := msg.Properties.(connectionEvent)
+	if !ok || props.InvitationID() == "" {
+		return nil
+	}
+
+	piID, err := s.store.Get(fmt.Sprintf(presentationRequestInvitationKey, props.InvitationID()))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("get request-presentation invitation: %w", err)
+	}
+
+	saved, err := s.getPresentationRequest(string(piID))
+	if err != nil || saved == nil {
+		return err
+	}
+
+	record, err := s.connections.GetConnectionRecord(props.ConnectionID())
+	if err != nil {
+		return fmt.Errorf("get connection record: %w", err)
+	}
+
+	svc, err := s.getService(presentproof.Name)
+	if err != nil {
+		return fmt.Errorf("load the present proof service: %w", err)
+	}
+
+	presentProofSvc, ok := svc.(service.InboundHandler)
+	if !ok {
+		return errors.New("cast service to present proof service failed")
+	}
+
+	request := service.NewDIDCommMsgMap(saved.Request)
+	request["@type"] = presentproof.RequestPresentationMsgType
+
+	// the request-presentation is sent by the present proof service of the verifier in a new thread
+	if _, err = presentProofSvc.HandleInbound(request, record.MyDID, record.TheirDID); err != nil {
+		return fmt.Errorf("send request-presentation: %w", err)
+	}
+
+	return s.deletePresentationRequest(string(piID))
+}
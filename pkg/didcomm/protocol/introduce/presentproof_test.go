@@ -0,0 +1,375 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package introduce_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/messenger"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/introduce"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	dispatcherMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/dispatcher"
+	messengerMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/messenger"
+	introduceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/protocol/introduce"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+const (
+	verifierDID   = "did:example:verifier"
+	introducerDID = "did:example:introducer"
+)
+
+// storageProvider is used to save the connection records.
+type storageProvider struct {
+	store     storage.Provider
+	transient storage.Provider
+}
+
+func (p *storageProvider) StorageProvider() storage.Provider {
+	return p.store
+}
+
+func (p *storageProvider) TransientStorageProvider() storage.Provider {
+	return p.transient
+}
+
+type connectionProps struct {
+	connectionID string
+	invitationID string
+}
+
+func (p *connectionProps) ConnectionID() string {
+	return p.connectionID
+}
+
+func (p *connectionProps) InvitationID() string {
+	return p.invitationID
+}
+
+// presentProofHandler records the messages handled by the present proof service.
+type presentProofHandler struct {
+	msgs     chan service.DIDCommMsg
+	theirDID string
+	err      error
+}
+
+func (h *presentProofHandler) HandleInbound(msg service.DIDCommMsg, _, theirDID string) (string, error) {
+	h.theirDID = theirDID
+	h.msgs <- msg
+
+	return "", h.err
+}
+
+// verifier is the introducee attaching a request-presentation to the introduction.
+type verifier struct {
+	*introduce.Service
+	providers *storageProvider
+	messenger *messenger.Messenger
+	// responses receives the responses sent to the introducer
+	responses chan interface{}
+}
+
+func verifierSetup(t *testing.T, ctrl *gomock.Controller, handler interface{}) *verifier {
+	t.Helper()
+
+	providers := &storageProvider{store: mem.NewProvider(), transient: mem.NewProvider()}
+	responses := make(chan interface{}, 1)
+
+	didSvc := serviceMocks.NewMockEvent(ctrl)
+	didSvc.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil)
+
+	outbound := dispatcherMocks.NewMockOutbound(ctrl)
+	outbound.EXPECT().SendToDID(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(msg interface{}, _, _ string) error {
+			responses <- msg
+
+			return nil
+		}).AnyTimes()
+
+	mProvider := messengerMocks.NewMockProvider(ctrl)
+	mProvider.EXPECT().StorageProvider().Return(providers.store)
+	mProvider.EXPECT().OutboundDispatcher().Return(outbound)
+
+	msgSvc, err := messenger.NewMessenger(mProvider)
+	require.NoError(t, err)
+
+	provider := introduceMocks.NewMockProvider(ctrl)
+	provider.EXPECT().StorageProvider().Return(providers.store).AnyTimes()
+	provider.EXPECT().TransientStorageProvider().Return(providers.transient).AnyTimes()
+	provider.EXPECT().Service(didexchange.DIDExchange).Return(didSvc, nil)
+	provider.EXPECT().Service(presentproof.Name).Return(handler, nil).AnyTimes()
+	provider.EXPECT().Messenger().Return(msgSvc)
+
+	svc, err := introduce.New(provider)
+	require.NoError(t, err)
+
+	return &verifier{Service: svc, providers: providers, messenger: msgSvc, responses: responses}
+}
+
+// receiveProposal handles the proposal with the request-presentation and approves it with the given option.
+func receiveProposal(t *testing.T, svc *verifier, opt interface{}) string {
+	t.Helper()
+
+	actions := make(chan service.DIDCommAction, 1)
+	require.NoError(t, svc.RegisterActionEvent(actions))
+
+	proposal := introduce.CreateRecipientProposal(&introduce.Recipient{
+		To:                  &introduce.To{Name: Carol},
+		RequestPresentation: &presentproof.RequestPresentation{Comment: "proof of age"},
+	})
+	proposal["@id"] = uuid.New().String()
+
+	require.NoError(t, svc.messenger.HandleInbound(proposal, verifierDID, introducerDID))
+	_, err := svc.HandleInbound(proposal, verifierDID, introducerDID)
+	require.NoError(t, err)
+
+	select {
+	case action := <-actions:
+		action.Continue(opt)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for DIDCommAction")
+	}
+
+	// the response is sent once the introduction is waiting for the invitation
+	select {
+	case <-svc.responses:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for response")
+	}
+
+	return proposal.ID()
+}
+
+func saveConnection(t *testing.T, providers *storageProvider) *connection.Record {
+	t.Helper()
+
+	recorder, err := connection.NewRecorder(providers)
+	require.NoError(t, err)
+
+	record := &connection.Record{
+		ConnectionID: uuid.New().String(),
+		ThreadID:     uuid.New().String(),
+		Namespace:    "my",
+		State:        "completed",
+		MyDID:        verifierDID,
+		TheirDID:     "did:example:prover",
+	}
+	require.NoError(t, recorder.SaveConnectionRecord(record))
+
+	return record
+}
+
+func completed(connID, invitationID string) service.StateMsg {
+	return service.StateMsg{
+		Type:       service.PostState,
+		StateID:    "completed",
+		Properties: &connectionProps{connectionID: connID, invitationID: invitationID},
+	}
+}
+
+func TestCreateRecipientProposal(t *testing.T) {
+	t.Run("without request-presentation", func(t *testing.T) {
+		proposal := &introduce.Proposal{}
+		require.NoError(t, introduce.CreateRecipientProposal(&introduce.Recipient{
+			To: &introduce.To{Name: Bob},
+		}).Decode(proposal))
+
+		require.Equal(t, introduce.ProposalMsgType, proposal.Type)
+		require.Equal(t, Bob, proposal.To.Name)
+		require.Empty(t, proposal.Attachments)
+	})
+
+	t.Run("with request-presentation", func(t *testing.T) {
+		proposal := &introduce.Proposal{}
+		require.NoError(t, introduce.CreateRecipientProposal(&introduce.Recipient{
+			To:                  &introduce.To{Name: Bob},
+			RequestPresentation: &presentproof.RequestPresentation{Comment: "proof of age"},
+		}).Decode(proposal))
+
+		require.Len(t, proposal.Attachments, 1)
+		require.Equal(t, introduce.RequestPresentationAttachID, proposal.Attachments[0].ID)
+		require.Equal(t, &presentproof.RequestPresentation{Comment: "proof of age"}, proposal.Attachments[0].Data.JSON)
+	})
+}
+
+func TestService_ConnectionCompleted(t *testing.T) {
+	t.Run("request-presentation is sent to the prover (verifier invitation)", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := &presentProofHandler{msgs: make(chan service.DIDCommMsg, 1)}
+		svc := verifierSetup(t, ctrl, handler)
+
+		invitationID := uuid.New().String()
+		receiveProposal(t, svc, introduce.WithInvitation(&didexchange.Invitation{
+			ID:   invitationID,
+			Type: didexchange.InvitationMsgType,
+		}))
+
+		record := saveConnection(t, svc.providers)
+
+		require.NoError(t, svc.ConnectionCompleted(completed(record.ConnectionID, invitationID)))
+
+		request := &presentproof.RequestPresentation{}
+		require.NoError(t, (<-handler.msgs).Decode(request))
+		require.Equal(t, presentproof.RequestPresentationMsgType, request.Type)
+		require.Equal(t, "proof of age", request.Comment)
+		require.Equal(t, record.TheirDID, handler.theirDID)
+
+		// the request-presentation is only sent once
+		require.NoError(t, svc.ConnectionCompleted(completed(record.ConnectionID, invitationID)))
+		require.Empty(t, handler.msgs)
+	})
+
+	t.Run("request-presentation is sent to the prover (prover invitation)", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := &presentProofHandler{msgs: make(chan service.DIDCommMsg, 1)}
+		svc := verifierSetup(t, ctrl, handler)
+
+		piID := receiveProposal(t, svc, nil)
+
+		invitationID := uuid.New().String()
+		require.NoError(t, svc.InvitationReceived(service.StateMsg{
+			Type:    service.PostState,
+			StateID: "invited",
+			Msg: service.NewDIDCommMsgMap(&didexchange.Invitation{
+				ID:     invitationID,
+				Type:   didexchange.InvitationMsgType,
+				Thread: &decorator.Thread{PID: piID},
+			}),
+		}))
+
+		record := saveConnection(t, svc.providers)
+
+		require.NoError(t, svc.ConnectionCompleted(completed(record.ConnectionID, invitationID)))
+
+		request := &presentproof.RequestPresentation{}
+		require.NoError(t, (<-handler.msgs).Decode(request))
+		require.Equal(t, "proof of age", request.Comment)
+	})
+
+	t.Run("request-presentation of an abandoned introduction is deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := &presentProofHandler{msgs: make(chan service.DIDCommMsg, 1)}
+		svc := verifierSetup(t, ctrl, handler)
+
+		invitationID := uuid.New().String()
+		piID := receiveProposal(t, svc, introduce.WithInvitation(&didexchange.Invitation{
+			ID:   invitationID,
+			Type: didexchange.InvitationMsgType,
+		}))
+
+		// the introducer abandons the introduction
+		problem := service.NewDIDCommMsgMap(&model.ProblemReport{
+			Type: introduce.ProblemReportMsgType,
+			ID:   uuid.New().String(),
+		})
+		problem["~thread"] = map[string]interface{}{"thid": piID}
+
+		_, err := svc.HandleInbound(problem, verifierDID, introducerDID)
+		require.NoError(t, err)
+
+		store, err := svc.providers.store.OpenStore(introduce.Introduce)
+		require.NoError(t, err)
+
+		for _, key := range []string{"presentation_request_" + piID, "presentation_request_invitation_" + invitationID} {
+			_, err = store.Get(key)
+			require.True(t, errors.Is(err, storage.ErrDataNotFound), key)
+		}
+
+		record := saveConnection(t, svc.providers)
+
+		require.NoError(t, svc.ConnectionCompleted(completed(record.ConnectionID, invitationID)))
+		require.Empty(t, handler.msgs)
+	})
+
+	t.Run("no request-presentation for the connection", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := &presentProofHandler{msgs: make(chan service.DIDCommMsg, 1)}
+		svc := verifierSetup(t, ctrl, handler)
+
+		record := saveConnection(t, svc.providers)
+
+		require.NoError(t, svc.ConnectionCompleted(completed(record.ConnectionID, uuid.New().String())))
+		require.NoError(t, svc.ConnectionCompleted(completed(record.ConnectionID, "")))
+		require.NoError(t, svc.ConnectionCompleted(service.StateMsg{Type: service.PostState, StateID: "responded"}))
+		require.Empty(t, handler.msgs)
+	})
+
+	t.Run("connection record not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := verifierSetup(t, ctrl, &presentProofHandler{msgs: make(chan service.DIDCommMsg, 1)})
+
+		invitationID := uuid.New().String()
+		receiveProposal(t, svc, introduce.WithInvitation(&didexchange.Invitation{
+			ID:   invitationID,
+			Type: didexchange.InvitationMsgType,
+		}))
+
+		err := svc.ConnectionCompleted(completed(uuid.New().String(), invitationID))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get connection record")
+	})
+
+	t.Run("present proof service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		handler := &presentProofHandler{msgs: make(chan service.DIDCommMsg, 1), err: errors.New("test err")}
+		svc := verifierSetup(t, ctrl, handler)
+
+		invitationID := uuid.New().String()
+		receiveProposal(t, svc, introduce.WithInvitation(&didexchange.Invitation{
+			ID:   invitationID,
+			Type: didexchange.InvitationMsgType,
+		}))
+
+		record := saveConnection(t, svc.providers)
+
+		err := svc.ConnectionCompleted(completed(record.ConnectionID, invitationID))
+		require.EqualError(t, err, "send request-presentation: test err")
+	})
+
+	t.Run("cast present proof service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := verifierSetup(t, ctrl, struct{}{})
+
+		invitationID := uuid.New().String()
+		receiveProposal(t, svc, introduce.WithInvitation(&didexchange.Invitation{
+			ID:   invitationID,
+			Type: didexchange.InvitationMsgType,
+		}))
+
+		record := saveConnection(t, svc.providers)
+
+		err := svc.ConnectionCompleted(completed(record.ConnectionID, invitationID))
+		require.EqualError(t, err, "cast service to present proof service failed")
+	})
+}
@@ -13,14 +13,16 @@ import (
 	"sort"
 	"time"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/instances"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 )
 
 const (
@@ -57,10 +59,13 @@ type customError struct{ error }
 // Recipient keeps information needed for the service
 // 'To' field is needed for the proposal message
 // 'MyDID' and 'TheirDID' fields are needed for sending messages e.g report-problem, proposal, ack etc.
+// 'RequestPresentation' field is optional, the recipient (the verifier) sends it to the other introducee (the prover)
+// once they are connected.
 type Recipient struct {
-	To       *To
-	MyDID    string `json:"my_did,omitempty"`
-	TheirDID string `json:"their_did,omitempty"`
+	To                  *To
+	MyDID               string                            `json:"my_did,omitempty"`
+	TheirDID            string                            `json:"their_did,omitempty"`
+	RequestPresentation *presentproof.RequestPresentation `json:"request_presentation,omitempty"`
 }
 
 // Action contains helpful information about action
@@ -103,12 +108,18 @@ type Service struct {
 	didEvent        chan service.StateMsg
 	didEventService service.Event
 	messenger       service.Messenger
+	connections     *connection.Lookup
+	getService      func(id string) (interface{}, error)
+	idGenerator     idgen.Generator
 }
 
 // Provider contains dependencies for the DID exchange protocol and is typically created by using aries.Context()
 type Provider interface {
 	Messenger() service.Messenger
 	StorageProvider() storage.Provider
+	// TransientStorageProvider with StorageProvider supplies the connection records, the request-presentations
+	// attached to the introductions are sent once the introducees are connected.
+	TransientStorageProvider() storage.Provider
 	Service(id string) (interface{}, error)
 }
 
@@ -129,10 +140,18 @@ func New(p Provider) (*Service, error) {
 		return nil, fmt.Errorf("cast service to service.Event")
 	}
 
+	connections, err := connection.NewLookup(p)
+	if err != nil {
+		return nil, fmt.Errorf("connection lookup: %w", err)
+	}
+
 	svc := &Service{
+		idGenerator:     idgen.FromProvider(p),
 		messenger:       p.Messenger(),
 		store:           store,
 		didEventService: didService,
+		connections:     connections,
+		getService:      p.Service,
 		callbacks:       make(chan *metaData),
 		didEvent:        make(chan service.StateMsg),
 	}
@@ -164,42 +183,55 @@ func (s *Service) startInternalListener() {
 
 			msg.state = &abandoning{Code: codeInternalError}
 
-			logInternalError(msg.err)
+			logInternalError(msg)
 
 			if err := s.handle(msg); err != nil {
-				logger.Errorf("listener handle: %s", err)
+				errorLogger(msg).Errorf("listener handle: %s", err)
 			}
 		case event := <-s.didEvent:
 			if err := s.InvitationReceived(event); err != nil {
 				logger.Errorf("listener invitation received: %s", err)
 			}
+
+			if err := s.ConnectionCompleted(event); err != nil {
+				logger.Errorf("listener connection completed: %s", err)
+			}
 		}
 	}
 }
 
-func logInternalError(err error) {
-	if _, ok := err.(customError); !ok {
-		logger.Errorf("go to abandoning: %v", err)
+func logInternalError(md *metaData) {
+	if _, ok := md.err.(customError); !ok {
+		errorLogger(md).Errorf("go to abandoning: %v", md.err)
 	}
 }
 
-func getPIID(msg service.DIDCommMsg) (string, error) {
+// errorLogger returns the logger of the errors of the introduction, with its contextual fields.
+func errorLogger(md *metaData) *log.Log {
+	return logger.WithFields(log.Fields{
+		log.FieldProtocol: Introduce,
+		log.FieldThread:   md.PIID,
+		log.FieldState:    md.StateName,
+	})
+}
+
+func getPIID(msg service.DIDCommMsg, g idgen.Generator) (string, error) {
 	piID := msg.Metadata()[metaPIID]
 	if piID, ok := piID.(string); ok && piID != "" {
 		return piID, nil
 	}
 
-	return threadID(msg)
+	return threadID(msg, g)
 }
 
-func threadID(msg service.DIDCommMsg) (string, error) {
+func threadID(msg service.DIDCommMsg, g idgen.Generator) (string, error) {
 	if pthID := msg.ParentThreadID(); pthID != "" {
 		return pthID, nil
 	}
 
 	thID, err := msg.ThreadID()
 	if errors.Is(err, service.ErrThreadIDNotFound) {
-		msg.(service.DIDCommMsgMap)["@id"] = uuid.New().String()
+		msg.(service.DIDCommMsgMap)["@id"] = g.NewID()
 		return msg.(service.DIDCommMsgMap)["@id"].(string), nil
 	}
 
@@ -207,7 +239,7 @@ func threadID(msg service.DIDCommMsg) (string, error) {
 }
 
 func (s *Service) doHandle(msg service.DIDCommMsg, outbound bool) (*metaData, error) {
-	piID, err := getPIID(msg)
+	piID, err := getPIID(msg, s.idGenerator)
 	if err != nil {
 		return nil, fmt.Errorf("piID: %w", err)
 	}
@@ -246,11 +278,15 @@ func (s *Service) InvitationReceived(msg service.StateMsg) error {
 		return nil
 	}
 
+	if err := s.bindPresentationRequest(msg.Msg.ParentThreadID(), msg.Msg.ID()); err != nil {
+		return fmt.Errorf("bind request-presentation: %w", err)
+	}
+
 	// NOTE: the message is being used internally.
 	// Do not modify the payload such as ID and Thread.
 	_, err := s.HandleInbound(service.NewDIDCommMsgMap(&model.Ack{
 		Type:   AckMsgType,
-		ID:     uuid.New().String(),
+		ID:     s.idGenerator.NewID(),
 		Thread: &decorator.Thread{ID: msg.Msg.ParentThreadID()},
 	}), "internal", "internal")
 
@@ -309,9 +345,7 @@ func (s *Service) HandleOutbound(msg service.DIDCommMsg, myDID, theirDID string)
 // sendMsgEvents triggers the message events.
 func (s *Service) sendMsgEvents(msg *service.StateMsg) {
 	// trigger the message events
-	for _, handler := range s.MsgEvents() {
-		handler <- *msg
-	}
+	s.TriggerMsgEvents(*msg)
 }
 
 // newDIDCommActionMsg creates new DIDCommAction message
@@ -343,7 +377,7 @@ func (s *Service) newDIDCommActionMsg(md *metaData) service.DIDCommAction {
 			}
 
 			if err := s.deleteTransitionalPayload(md.PIID); err != nil {
-				logger.Errorf("delete transitional payload", err)
+				errorLogger(md).Errorf("delete transitional payload: %s", err)
 			}
 
 			s.processCallback(md)
@@ -377,7 +411,7 @@ func (s *Service) Continue(piID string, opt Opt) error {
 	}
 
 	if err := s.deleteTransitionalPayload(md.PIID); err != nil {
-		logger.Errorf("delete transitional payload", err)
+		errorLogger(md).Errorf("delete transitional payload: %s", err)
 	}
 
 	s.processCallback(md)
@@ -425,6 +459,12 @@ func (s *Service) currentStateName(piID string) (string, error) {
 	return string(src), err
 }
 
+// InstancesByState returns the number of protocol instances in progress by state, the instances which
+// reached the done state are not counted.
+func (s *Service) InstancesByState() (map[string]int, error) {
+	return instances.ByState(s.store, stateNameKey, stateNameDone)
+}
+
 // Actions returns actions for the async usage
 func (s *Service) Actions() ([]Action, error) {
 	records := s.store.Iterator(
@@ -542,14 +582,20 @@ func (s *Service) handle(md *metaData) error {
 		return err
 	}
 
+	if err := s.savePresentationRequest(md); err != nil {
+		return fmt.Errorf("save request-presentation: %w", err)
+	}
+
 	var (
 		current   = md.state
 		actions   []stateAction
 		stateName string
+		abandoned bool
 	)
 
 	for !isNoOp(current) {
 		stateName = current.Name()
+		abandoned = abandoned || stateName == stateNameAbandoning
 
 		next, action, err := s.execute(current, md)
 		if err != nil {
@@ -569,6 +615,13 @@ func (s *Service) handle(md *metaData) error {
 		return fmt.Errorf("failed to persist state %s: %w", stateName, err)
 	}
 
+	// the request-presentation of an abandoned introduction is never sent
+	if abandoned {
+		if err := s.deletePresentationRequest(md.PIID); err != nil {
+			return fmt.Errorf("delete request-presentation: %w", err)
+		}
+	}
+
 	for _, action := range actions {
 		if err := action(); err != nil {
 			return err
@@ -652,7 +705,7 @@ func (s *Service) saveParticipant(piID string, p *participant) error {
 		return fmt.Errorf("marshal: %w", err)
 	}
 
-	return s.store.Put(fmt.Sprintf(participantsKey, piID, uuid.New().String()), src)
+	return s.store.Put(fmt.Sprintf(participantsKey, piID, s.idGenerator.NewID()), src)
 }
 
 func (s *Service) getParticipants(piID string) ([]*participant, error) {
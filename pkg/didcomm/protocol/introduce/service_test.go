@@ -28,6 +28,7 @@ import (
 	storageMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/storage"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
 	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 )
 
 const (
@@ -74,7 +75,8 @@ func agentSetup(agent string, t *testing.T, ctrl *gomock.Controller, tr map[stri
 	mProvider.EXPECT().OutboundDispatcher().Return(outbound)
 
 	provider := introduceMocks.NewMockProvider(ctrl)
-	provider.EXPECT().StorageProvider().Return(storageProvider)
+	provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+	provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 	provider.EXPECT().Service(gomock.Any()).Return(didSvc, nil)
 
 	msgSvc, err := messenger.NewMessenger(mProvider)
@@ -1285,10 +1287,12 @@ func TestService_New(t *testing.T) {
 
 	t.Run("OpenStore Error", func(t *testing.T) {
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(nil, errors.New(errMsg))
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 
 		svc, err := introduce.New(provider)
 		require.EqualError(t, err, "test err")
@@ -1297,10 +1301,12 @@ func TestService_New(t *testing.T) {
 
 	t.Run("Service Error", func(t *testing.T) {
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(nil, nil)
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 		provider.EXPECT().Service(didexchange.DIDExchange).Return(nil, errors.New(errMsg))
 
 		svc, err := introduce.New(provider)
@@ -1310,16 +1316,36 @@ func TestService_New(t *testing.T) {
 
 	t.Run("Cast Service Error", func(t *testing.T) {
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(nil, nil)
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 		provider.EXPECT().Service(didexchange.DIDExchange).Return(nil, nil)
 
 		svc, err := introduce.New(provider)
 		require.EqualError(t, err, "cast service to service.Event")
 		require.Nil(t, svc)
 	})
+
+	t.Run("Connection Lookup Error", func(t *testing.T) {
+		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).Return(nil, errors.New(errMsg))
+		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(nil, nil)
+
+		didService := serviceMocks.NewMockDIDComm(ctrl)
+		didService.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil).AnyTimes()
+
+		provider := introduceMocks.NewMockProvider(ctrl)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().Service(didexchange.DIDExchange).Return(didService, nil)
+
+		svc, err := introduce.New(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "connection lookup: ")
+		require.Nil(t, svc)
+	})
 }
 
 func TestService_HandleOutbound(t *testing.T) {
@@ -1332,13 +1358,15 @@ func TestService_HandleOutbound(t *testing.T) {
 		store.EXPECT().Get(gomock.Any()).Return([]byte(raw), nil)
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(store, nil)
 
 		didService := serviceMocks.NewMockDIDComm(ctrl)
 		didService.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil)
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 		provider.EXPECT().Messenger().Return(nil)
 		provider.EXPECT().Service(didexchange.DIDExchange).Return(didService, nil)
 
@@ -1364,13 +1392,15 @@ func TestService_HandleInbound(t *testing.T) {
 		defer ctrl.Finish()
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(nil, nil)
 
 		didService := serviceMocks.NewMockDIDComm(ctrl)
 		didService.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil)
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 		provider.EXPECT().Messenger().Return(nil)
 		provider.EXPECT().Service(didexchange.DIDExchange).Return(didService, nil)
 
@@ -1391,13 +1421,15 @@ func TestService_HandleInbound(t *testing.T) {
 		store.EXPECT().Get(gomock.Any()).Return(nil, errors.New(errMsg))
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(store, nil)
 
 		didService := serviceMocks.NewMockDIDComm(ctrl)
 		didService.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil)
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 		provider.EXPECT().Messenger().Return(nil)
 		provider.EXPECT().Service(didexchange.DIDExchange).Return(didService, nil)
 
@@ -1421,13 +1453,15 @@ func TestService_HandleInbound(t *testing.T) {
 		store.EXPECT().Get(gomock.Any()).Return([]byte(`{"state_name":"noop","wait_count":1}`), nil)
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(store, nil)
 
 		didService := serviceMocks.NewMockDIDComm(ctrl)
 		didService.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil)
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 		provider.EXPECT().Messenger().Return(nil)
 		provider.EXPECT().Service(didexchange.DIDExchange).Return(didService, nil)
 
@@ -1451,13 +1485,15 @@ func TestService_HandleInbound(t *testing.T) {
 		store.EXPECT().Get(gomock.Any()).Return(nil, storage.ErrDataNotFound)
 
 		storageProvider := storageMocks.NewMockProvider(ctrl)
+		storageProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 		storageProvider.EXPECT().OpenStore(introduce.Introduce).Return(store, nil)
 
 		didService := serviceMocks.NewMockDIDComm(ctrl)
 		didService.EXPECT().RegisterMsgEvent(gomock.Any()).Return(nil)
 
 		provider := introduceMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storageProvider)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
 		provider.EXPECT().Messenger().Return(nil)
 		provider.EXPECT().Service(didexchange.DIDExchange).Return(didService, nil)
 
@@ -189,7 +189,7 @@ func CreateProposal(to *To) service.DIDCommMsgMap {
 
 func sendProposals(messenger service.Messenger, md *metaData) error {
 	for _, recipient := range getMetaRecipients(md) {
-		proposal := CreateProposal(recipient.To)
+		proposal := CreateRecipientProposal(recipient)
 		proposal.Metadata()[metaPIID] = md.PIID
 		copyMetadata(md.Msg, proposal)
 
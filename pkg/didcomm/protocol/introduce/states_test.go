@@ -16,6 +16,7 @@ import (
 
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 )
 
 func notTransition(t *testing.T, st state) {
@@ -357,3 +358,23 @@ func Test_sendProposals(t *testing.T) {
 		transitionalPayload: transitionalPayload{Msg: msg},
 	})), errMsg)
 }
+
+func TestService_InstancesByState(t *testing.T) {
+	store, err := mem.NewProvider().OpenStore(Introduce)
+	require.NoError(t, err)
+
+	svc := &Service{store: store}
+
+	instances, err := svc.InstancesByState()
+	require.NoError(t, err)
+	require.Empty(t, instances)
+
+	require.NoError(t, svc.saveStateName("piID-1", stateNameArranging))
+	require.NoError(t, svc.saveStateName("piID-2", stateNameArranging))
+	require.NoError(t, svc.saveStateName("piID-3", stateNameWaiting))
+	require.NoError(t, svc.saveStateName("piID-4", stateNameDone))
+
+	instances, err = svc.InstancesByState()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{stateNameArranging: 2, stateNameWaiting: 1}, instances)
+}
@@ -37,6 +37,10 @@ type ProposeCredential struct {
 	CredDefID string `json:"cred_def_id,omitempty"`
 	// IssuerDid is an optional filter to request a credential issued by the owner of a particular DID.
 	IssuerDid string `json:"issuer_did,omitempty"`
+	// ValidityPeriod is an optional validity window the Holder requests for the credential.
+	ValidityPeriod *ValidityPeriod `json:"validity_period,omitempty"`
+	// FromPrior is the from_prior JWT of the Holder rotating its DID, see decorator.FromPrior.
+	FromPrior string `json:"from_prior,omitempty"`
 }
 
 // OfferCredential is a message sent by the Issuer to the potential Holder,
@@ -50,9 +54,18 @@ type OfferCredential struct {
 	Comment string `json:"comment,omitempty"`
 	// CredentialPreview is a JSON-LD object that represents the credential data that Issuer is willing to issue.
 	CredentialPreview PreviewCredential `json:"credential_preview,omitempty"`
+	// Formats declare the format of each attachment of OffersAttach (see package attachformat),
+	// the attachments of an offer declaring no format are linked data proof credential details.
+	Formats []decorator.AttachmentFormat `json:"formats,omitempty"`
 	// OffersAttach is a slice of attachments that further define the credential being offered.
 	// This might be used to clarify which formats or format versions will be issued.
 	OffersAttach []decorator.Attachment `json:"offers~attach,omitempty"`
+	// ValidityPeriod is the validity window of the credential the Issuer is willing to issue, it confirms
+	// or adjusts the window of the proposal. The issued credentials must be valid within it, an empty window
+	// withdraws the window of a previous offer of the exchange.
+	ValidityPeriod *ValidityPeriod `json:"validity_period,omitempty"`
+	// FromPrior is the from_prior JWT of the Issuer rotating its DID, see decorator.FromPrior.
+	FromPrior string `json:"from_prior,omitempty"`
 }
 
 // RequestCredential is a message sent by the potential Holder to the Issuer,
@@ -67,8 +80,12 @@ type RequestCredential struct {
 	// so the offer can be evaluated by human judgment.
 	// TODO: Should follow DIDComm conventions for l10n. [Issue #1300]
 	Comment string `json:"comment,omitempty"`
+	// Formats declare the format of each attachment of RequestsAttach (see package attachformat).
+	Formats []decorator.AttachmentFormat `json:"formats,omitempty"`
 	// RequestsAttach is a slice of attachments defining the requested formats for the credential
 	RequestsAttach []decorator.Attachment `json:"requests~attach,omitempty"`
+	// FromPrior is the from_prior JWT of the Holder rotating its DID, see decorator.FromPrior.
+	FromPrior string `json:"from_prior,omitempty"`
 }
 
 // IssueCredential contains as attached payload the credentials being issued and is
@@ -80,8 +97,13 @@ type IssueCredential struct {
 	// so the offer can be evaluated by human judgment.
 	// TODO: Should follow DIDComm conventions for l10n. [Issue #1300]
 	Comment string `json:"comment,omitempty"`
+	// Formats declare the format of each attachment of CredentialsAttach (see package attachformat),
+	// the attachments of a message declaring no format are linked data proof credentials.
+	Formats []decorator.AttachmentFormat `json:"formats,omitempty"`
 	// CredentialsAttach is a slice of attachments containing the issued credentials.
 	CredentialsAttach []decorator.Attachment `json:"credentials~attach,omitempty"`
+	// FromPrior is the from_prior JWT of the Issuer rotating its DID, see decorator.FromPrior.
+	FromPrior string `json:"from_prior,omitempty"`
 }
 
 // PreviewCredential is used to construct a preview of the data for the credential that is to be issued.
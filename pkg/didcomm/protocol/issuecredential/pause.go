@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/pause"
+)
+
+// ErrActionPaused is returned when an action paused by ActionPause is continued or stopped by its piID,
+// the action must be resumed with its resume token first.
+var ErrActionPaused = pause.ErrActionPaused
+
+// ErrUnknownResumeToken is returned by ActionResume when no action is paused with the resume token.
+var ErrUnknownResumeToken = pause.ErrUnknownResumeToken
+
+// ActionPause parks the action by the piID until it is resumed with the returned resume token (see ActionResume).
+// The action is persisted with the exchange, the token remains valid after a restart of the agent.
+// Pausing an action which is already paused returns its resume token.
+func (s *Service) ActionPause(piID string) (string, error) {
+	tPayload, err := s.getTransitionalPayload(piID)
+	if err != nil {
+		return "", fmt.Errorf("get transitional payload: %w", err)
+	}
+
+	return s.pausedActions.Pause(piID, tPayload.ResumeToken, func(token string) error {
+		tPayload.ResumeToken = token
+
+		if err := s.saveTransitionalPayload(piID, *tPayload); err != nil {
+			return fmt.Errorf("save transitional payload: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ActionResume resumes the action paused with the resume token, the action is returned and can then be
+// continued or stopped by its piID. The resume token can only be used once.
+func (s *Service) ActionResume(token string) (*Action, error) {
+	var tPayload *transitionalPayload
+
+	err := s.pausedActions.Resume(token, func(piID string) error {
+		var err error
+
+		tPayload, err = s.getTransitionalPayload(piID)
+		if err != nil {
+			return fmt.Errorf("get transitional payload: %w", err)
+		}
+
+		tPayload.ResumeToken = ""
+
+		if err = s.saveTransitionalPayload(piID, *tPayload); err != nil {
+			return fmt.Errorf("save transitional payload: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := json.Marshal(tPayload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal transitional payload: %w", err)
+	}
+
+	action := &Action{}
+	if err = json.Unmarshal(src, action); err != nil {
+		return nil, fmt.Errorf("unmarshal action: %w", err)
+	}
+
+	return action, nil
+}
@@ -11,11 +11,17 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/instances"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/pause"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/rotation"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 	storeverifiable "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
 )
 
@@ -45,7 +51,9 @@ const (
 	transitionalPayloadKey = "transitionalPayload_%s"
 )
 
-var logger = log.New("aries-framework/issuecredential/service")
+const logModule = "aries-framework/issuecredential/service"
+
+var logger = log.New(logModule)
 
 // customError is a wrapper to determine custom error against internal error
 type customError struct{ error }
@@ -58,6 +66,14 @@ type transitionalPayload struct {
 	Msg       service.DIDCommMsgMap
 	MyDID     string
 	TheirDID  string
+	// ConnectionID is the ID of the connection the exchange is associated with (if any)
+	ConnectionID string `json:",omitempty"`
+	// Duplicates keeps the stored credentials which are near-duplicates of the received ones
+	Duplicates []*storeverifiable.CredentialRecord `json:",omitempty"`
+	// ValidityPeriod is the validity window negotiated by the offer (if any)
+	ValidityPeriod *ValidityPeriod `json:",omitempty"`
+	// ResumeToken is the token the action is resumed with while it is paused (see ActionPause)
+	ResumeToken string `json:",omitempty"`
 }
 
 // metaData type to store data for internal usage
@@ -68,11 +84,17 @@ type metaData struct {
 	inbound         bool
 	verifiable      *storeverifiable.Store
 	credentialNames []string
+	// decision taken by the Holder when the received credentials have near-duplicates in the store
+	duplicateDecision DuplicateDecision
 	// keeps offer credential payload,
 	// allows filling the message by providing an option function
 	offerCredential   *OfferCredential
 	proposeCredential *ProposeCredential
 	issueCredential   *IssueCredential
+	// credentials are the parsed credentials of the received IssueCredential message
+	credentials []*verifiable.Credential
+	// validityPeriodOffered is true if an offer was sent, its validity window (if any) is the negotiated one
+	validityPeriodOffered bool
 	// err is used to determine whether callback was stopped
 	// e.g the user received an action event and executes Stop(err) function
 	// in that case `err` is equal to `err` which was passing to Stop function
@@ -84,6 +106,74 @@ type Action struct {
 	// protocol state machine identifier
 	PIID string
 	Msg  service.DIDCommMsgMap
+	// ConnectionID is the ID of the connection the exchange is associated with, empty if the DIDs
+	// of the exchange are not those of a connection.
+	ConnectionID string `json:",omitempty"`
+	// Duplicates are the stored credentials which are near-duplicates of the received credentials (if any).
+	// A decision may be provided by using the WithDuplicateDecision option, both are kept otherwise.
+	Duplicates []*storeverifiable.CredentialRecord `json:",omitempty"`
+	// ResumeToken is the token the action is resumed with, empty if the action is not paused (see ActionPause).
+	ResumeToken string `json:",omitempty"`
+}
+
+// DuplicateDecision describes how the Holder handles received credentials that have near-duplicates
+// (same issuer, types and subject ID) already stored.
+type DuplicateDecision string
+
+const (
+	// DuplicateReplace removes the stored near-duplicates before saving the received credential
+	DuplicateReplace DuplicateDecision = "replace"
+	// DuplicateKeepBoth saves the received credential and keeps the stored near-duplicates, the default decision
+	DuplicateKeepBoth DuplicateDecision = "keep-both"
+	// DuplicateReject rejects the received credential
+	DuplicateReject DuplicateDecision = "reject"
+)
+
+// eventProps contains the properties of the action event: the connection of the exchange, for an
+// IssueCredential message, the stored near-duplicates of the received credentials and, for the other
+// messages, the validity window of the credential.
+type eventProps struct {
+	connectionID   string
+	duplicates     []*storeverifiable.CredentialRecord
+	validityPeriod *ValidityPeriod
+}
+
+// ConnectionID returns the ID of the connection the exchange is associated with.
+func (e *eventProps) ConnectionID() string {
+	return e.connectionID
+}
+
+// Duplicates returns the stored credentials which are near-duplicates of the received credentials.
+func (e *eventProps) Duplicates() []*storeverifiable.CredentialRecord {
+	return e.duplicates
+}
+
+// ValidityPeriod returns the validity window requested by the received ProposeCredential message, offered by
+// the received OfferCredential message or negotiated for the received RequestCredential message.
+func (e *eventProps) ValidityPeriod() *ValidityPeriod {
+	return e.validityPeriod
+}
+
+// PayloadVersion returns the version of the event properties.
+func (e *eventProps) PayloadVersion() service.EventPayloadVersion {
+	return service.EventPayloadV1
+}
+
+// eventPropsV2 is the version 2 of the properties of the action event (service.EventPayloadV2),
+// it adds the parsed credentials of the received IssueCredential message.
+type eventPropsV2 struct {
+	*eventProps
+	credentials []*verifiable.Credential
+}
+
+// PayloadVersion returns the version of the event properties.
+func (e *eventPropsV2) PayloadVersion() service.EventPayloadVersion {
+	return service.EventPayloadV2
+}
+
+// Credentials returns the parsed credentials of the received IssueCredential message.
+func (e *eventPropsV2) Credentials() []*verifiable.Credential {
+	return e.credentials
 }
 
 // Opt describes option signature for the Continue function
@@ -121,10 +211,27 @@ func WithFriendlyNames(names ...string) Opt {
 	}
 }
 
+// WithDuplicateDecision allows providing a decision about the received credentials that have near-duplicates
+// along with the names for the credentials.
+// USAGE: This function should be used when the Holder receives IssueCredential message with duplicates.
+// If duplicates are found and no decision is provided, the credential is kept along with them (DuplicateKeepBoth).
+func WithDuplicateDecision(decision DuplicateDecision, names ...string) Opt {
+	return func(md *metaData) {
+		md.duplicateDecision = decision
+		md.credentialNames = names
+	}
+}
+
 // Provider contains dependencies for the protocol and is typically created by using aries.Context()
 type Provider interface {
 	Messenger() service.Messenger
 	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
+	VDRIRegistry() vdri.Registry
+	// EventPayloadVersion returns the version of the event properties, see service.EventPayloadVersion.
+	EventPayloadVersion() service.EventPayloadVersion
+	// Instrumentation returns the instrumentation hook, service.NoopInstrumentation if none is configured.
+	Instrumentation() service.Instrumentation
 }
 
 // Service for the issuecredential protocol
@@ -135,6 +242,14 @@ type Service struct {
 	callbacks  chan *metaData
 	messenger  service.Messenger
 	verifiable *storeverifiable.Store
+	// threads associates the exchanges with the connections
+	threads *rotation.Threads
+	// eventPayloadVersion is the version of the event properties, see service.EventPayloadVersion
+	eventPayloadVersion service.EventPayloadVersion
+	instrumentation     service.Instrumentation
+	// pausedActions keeps the resume tokens of the actions paused by ActionPause
+	pausedActions *pause.Actions
+	idGenerator   idgen.Generator
 }
 
 // New returns the issuecredential service
@@ -150,12 +265,26 @@ func New(p Provider) (*Service, error) {
 	}
 
 	svc := &Service{
-		messenger:  p.Messenger(),
-		store:      store,
-		verifiable: vStore,
-		callbacks:  make(chan *metaData),
+		idGenerator:         idgen.FromProvider(p),
+		messenger:           p.Messenger(),
+		store:               store,
+		pausedActions:       pause.New(store),
+		verifiable:          vStore,
+		callbacks:           make(chan *metaData),
+		eventPayloadVersion: p.EventPayloadVersion(),
+		instrumentation:     p.Instrumentation(),
 	}
 
+	svc.messenger = service.InstrumentMessenger(svc.messenger, Name, svc.instrumentation)
+
+	connections, err := connection.NewRecorder(p)
+	if err != nil {
+		return nil, fmt.Errorf("connection recorder: %w", err)
+	}
+
+	keyResolver := verifiable.NewCachingDIDKeyResolver(p.VDRIRegistry())
+	svc.threads = rotation.New(store, connections, jwt.KeyResolverFunc(keyResolver.PublicKeyFetcher()))
+
 	// start the listener
 	go svc.startInternalListener()
 
@@ -164,10 +293,8 @@ func New(p Provider) (*Service, error) {
 
 // HandleInbound handles inbound message (issuecredential protocol)
 func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
-	aEvent := s.ActionEvent()
-
 	// throw error if there is no action event registered for inbound messages
-	if aEvent == nil {
+	if !s.HasActionHandler() {
 		return "", errors.New("no clients are registered to handle the message")
 	}
 
@@ -181,14 +308,26 @@ func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string)
 	md.MyDID = myDID
 	md.TheirDID = theirDID
 
+	if err = s.associateConnection(md); err != nil {
+		return "", fmt.Errorf("associate connection: %w", err)
+	}
+
 	// trigger action event based on message type for inbound messages
 	if canTriggerActionEvents(msg) {
+		if msg.Type() == IssueCredentialMsgType {
+			md.credentials = s.receivedCredentials(msg)
+			md.Duplicates = s.findDuplicates(md.credentials)
+		}
+
 		err = s.saveTransitionalPayload(md.PIID, md.transitionalPayload)
 		if err != nil {
 			return "", fmt.Errorf("save transitional payload: %w", err)
 		}
 
-		aEvent <- s.newDIDCommActionMsg(md)
+		// the handler may have been unregistered meanwhile
+		if !s.TriggerActionEvent(s.newDIDCommActionMsg(md)) {
+			return "", errors.New("no clients are registered to handle the message")
+		}
 
 		return "", nil
 	}
@@ -208,13 +347,36 @@ func (s *Service) HandleOutbound(msg service.DIDCommMsg, myDID, theirDID string)
 	md.MyDID = myDID
 	md.TheirDID = theirDID
 
+	if err = s.associateConnection(md); err != nil {
+		return fmt.Errorf("associate connection: %w", err)
+	}
+
 	return s.handle(md)
 }
 
+// associateConnection associates the exchange with the connection of the DIDs of the message and follows
+// the rotation of the DID of the other agent, see rotation.Threads.
+func (s *Service) associateConnection(md *metaData) error {
+	msg := md.Msg
+	if !md.inbound {
+		// the from_prior JWT of an outbound message rotates our own DID
+		msg = nil
+	}
+
+	connID, err := s.threads.Associate(md.PIID, md.MyDID, md.TheirDID, msg)
+	if err != nil {
+		return err
+	}
+
+	md.ConnectionID = connID
+
+	return nil
+}
+
 func (s *Service) getCurrentStateNameAndPIID(msg service.DIDCommMsg) (string, string, error) {
 	piID, err := getPIID(msg)
 	if errors.Is(err, service.ErrThreadIDNotFound) {
-		piID = uuid.New().String()
+		piID = s.idGenerator.NewID()
 
 		return piID, stateNameStart, msg.SetID(piID)
 	}
@@ -248,11 +410,22 @@ func (s *Service) doHandle(msg service.DIDCommMsg, outbound bool) (*metaData, er
 		return nil, fmt.Errorf("invalid state transition: %s -> %s", current.Name(), next.Name())
 	}
 
+	var validityPeriod *ValidityPeriod
+
+	// the credentials are issued within the validity window negotiated by the offer
+	if current.Name() == stateNameOfferSent && next.Name() == stateNameRequestReceived {
+		validityPeriod, err = s.validityPeriod(piID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &metaData{
 		transitionalPayload: transitionalPayload{
-			StateName: next.Name(),
-			Msg:       msg.(service.DIDCommMsgMap),
-			PIID:      piID,
+			StateName:      next.Name(),
+			Msg:            msg.(service.DIDCommMsgMap),
+			PIID:           piID,
+			ValidityPeriod: validityPeriod,
 		},
 		state:      next,
 		verifiable: s.verifiable,
@@ -276,11 +449,16 @@ func (s *Service) startInternalListener() {
 		msg.state = &abandoning{Code: codeInternalError}
 
 		if err := s.handle(msg); err != nil {
-			logger.Errorf("listener handle: %s", err)
+			errorLogger(msg).Errorf("listener handle: %s", err)
 		}
 	}
 }
 
+// errorLogger returns the logger of the errors of the exchange, with its contextual fields.
+func errorLogger(md *metaData) *log.Log {
+	return logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: md.PIID, log.FieldState: md.StateName})
+}
+
 func isNoOp(s state) bool {
 	_, ok := s.(*noOp)
 	return ok
@@ -314,6 +492,16 @@ func (s *Service) handle(md *metaData) error {
 		return fmt.Errorf("failed to persist state %s: %w", stateName, err)
 	}
 
+	if stateName == stateNameDone {
+		if err := s.releaseExchange(md.PIID); err != nil {
+			return fmt.Errorf("release exchange: %w", err)
+		}
+	} else if md.ValidityPeriod != nil && md.validityPeriodOffered {
+		if err := s.saveValidityPeriod(md.PIID, md.ValidityPeriod); err != nil {
+			return fmt.Errorf("failed to persist validity period: %w", err)
+		}
+	}
+
 	for _, action := range actions {
 		if err := action(s.messenger); err != nil {
 			return fmt.Errorf("action %s: %w", stateName, err)
@@ -323,6 +511,15 @@ func (s *Service) handle(md *metaData) error {
 	return nil
 }
 
+// releaseExchange deletes the records kept for the exchange once it is done (completed or abandoned).
+func (s *Service) releaseExchange(piID string) error {
+	if err := s.deleteValidityPeriod(piID); err != nil {
+		return err
+	}
+
+	return s.threads.Release(piID)
+}
+
 func getPIID(msg service.DIDCommMsg) (string, error) {
 	if pthID := msg.ParentThreadID(); pthID != "" {
 		return pthID, nil
@@ -451,6 +648,10 @@ func (s *Service) ActionContinue(piID string, opt Opt) error {
 		return fmt.Errorf("get transitional payload: %w", err)
 	}
 
+	if tPayload.ResumeToken != "" {
+		return ErrActionPaused
+	}
+
 	md := &metaData{
 		transitionalPayload: *tPayload,
 		state:               stateFromName(tPayload.StateName),
@@ -479,6 +680,10 @@ func (s *Service) ActionStop(piID string, cErr error) error {
 		return fmt.Errorf("get transitional payload: %w", err)
 	}
 
+	if tPayload.ResumeToken != "" {
+		return ErrActionPaused
+	}
+
 	md := &metaData{
 		transitionalPayload: *tPayload,
 		state:               stateFromName(tPayload.StateName),
@@ -497,6 +702,12 @@ func (s *Service) ActionStop(piID string, cErr error) error {
 	return nil
 }
 
+// InstancesByState returns the number of protocol instances in progress by state, the instances which
+// reached the done state are not counted.
+func (s *Service) InstancesByState() (map[string]int, error) {
+	return instances.ByState(s.store, stateNameKey, stateNameDone)
+}
+
 // Actions returns actions for the async usage
 func (s *Service) Actions() ([]Action, error) {
 	records := s.store.Iterator(
@@ -523,6 +734,65 @@ func (s *Service) Actions() ([]Action, error) {
 	return actions, nil
 }
 
+// receivedCredentials returns the credentials of the received IssueCredential message.
+// Invalid credentials are reported when the Holder accepts them, so errors are only logged here.
+func (s *Service) receivedCredentials(msg service.DIDCommMsg) []*verifiable.Credential {
+	var credential = IssueCredential{}
+
+	if err := msg.Decode(&credential); err != nil {
+		logger.Warnf("received credentials: decode: %s", err)
+		return nil
+	}
+
+	credentials, err := issuedCredentials(&credential)
+	if err != nil {
+		logger.Warnf("received credentials: %s", err)
+		return nil
+	}
+
+	for _, vc := range credentials {
+		logger.Debugf("received credential %s: %s", vc.ID, verifiable.RedactedCredential(vc))
+	}
+
+	return credentials
+}
+
+// findDuplicates returns the stored credentials which are near-duplicates of the received ones.
+func (s *Service) findDuplicates(credentials []*verifiable.Credential) []*storeverifiable.CredentialRecord {
+	var duplicates []*storeverifiable.CredentialRecord
+
+	for _, vc := range credentials {
+		records, err := s.verifiable.FindDuplicates(vc)
+		if errors.Is(err, storeverifiable.ErrNoSubjectID) {
+			continue
+		}
+
+		if err != nil {
+			logger.Warnf("find duplicates: %s", err)
+			return nil
+		}
+
+		duplicates = append(duplicates, records...)
+	}
+
+	return duplicates
+}
+
+// eventProperties returns the properties of the action event in the configured version.
+func (s *Service) eventProperties(md *metaData) service.EventProperties {
+	props := &eventProps{
+		connectionID:   md.ConnectionID,
+		duplicates:     md.Duplicates,
+		validityPeriod: eventValidityPeriod(md),
+	}
+
+	if s.eventPayloadVersion < service.EventPayloadV2 {
+		return props
+	}
+
+	return &eventPropsV2{eventProps: props, credentials: md.credentials}
+}
+
 func (s *Service) processCallback(msg *metaData) {
 	// pass the callback data to internal channel. This is created to unblock consumer go routine and wrap the callback
 	// channel internally.
@@ -536,20 +806,33 @@ func (s *Service) newDIDCommActionMsg(md *metaData) service.DIDCommAction {
 	return service.DIDCommAction{
 		ProtocolName: Name,
 		Message:      md.msgClone,
+		Properties:   s.eventProperties(md),
 		Continue: func(opt interface{}) {
+			if s.pausedActions.Paused(md.PIID) {
+				errorLogger(md).Errorf("continue: %v", ErrActionPaused)
+
+				return
+			}
+
 			if fn, ok := opt.(Opt); ok {
 				fn(md)
 			}
 
 			if err := s.deleteTransitionalPayload(md.PIID); err != nil {
-				logger.Errorf("delete transitional payload", err)
+				errorLogger(md).Errorf("delete transitional payload: %v", err)
 			}
 
 			s.processCallback(md)
 		},
 		Stop: func(cErr error) {
+			if s.pausedActions.Paused(md.PIID) {
+				errorLogger(md).Errorf("stop: %v", ErrActionPaused)
+
+				return
+			}
+
 			if err := s.deleteTransitionalPayload(md.PIID); err != nil {
-				logger.Errorf("delete transitional payload", err)
+				errorLogger(md).Errorf("delete transitional payload: %v", err)
 			}
 
 			md.err = customError{error: cErr}
@@ -578,15 +861,18 @@ func (s *Service) execute(next state, md *metaData) (state, stateAction, error)
 		exec = next.ExecuteInbound
 	}
 
-	return exec(md)
+	followup, action, err := exec(md)
+	if err == nil {
+		s.instrumentation.StateTransition(Name, md.PIID, next.Name())
+	}
+
+	return followup, action, err
 }
 
 // sendMsgEvents triggers the message events.
 func (s *Service) sendMsgEvents(msg *service.StateMsg) {
 	// trigger the message events
-	for _, handler := range s.MsgEvents() {
-		handler <- *msg
-	}
+	s.TriggerMsgEvents(*msg)
 }
 
 // Name returns service name
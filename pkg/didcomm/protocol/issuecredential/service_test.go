@@ -7,8 +7,12 @@ SPDX-License-Identifier: Apache-2.0
 package issuecredential
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,15 +20,25 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/rotation"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
 	issuecredentialMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/protocol/issuecredential"
 	storageMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/storage"
+	mocklog "github.com/hyperledger/aries-framework-go/pkg/internal/mock/log"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
 	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	storeverifiable "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
 )
 
 const (
@@ -32,17 +46,57 @@ const (
 	Bob   = "Bob"
 )
 
+// logRecorder records the lines logged by the tests of the package
+var logRecorder = &mocklog.Recorder{} // nolint:gochecknoglobals
+
+func TestMain(m *testing.M) {
+	log.Initialize(logRecorder)
+
+	os.Exit(m.Run())
+}
+
+// newMockProvider returns a mock of the provider of the service, the connections are kept in memory.
+func newMockProvider(ctrl *gomock.Controller) *issuecredentialMocks.MockProvider {
+	provider := issuecredentialMocks.NewMockProvider(ctrl)
+	provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
+	provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
+	provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV1).AnyTimes()
+	provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{}).AnyTimes()
+
+	return provider
+}
+
+// threadKey matches the keys of the threads associating the exchanges with the connections.
+type threadKey struct{}
+
+func (threadKey) Matches(x interface{}) bool {
+	key, ok := x.(string)
+
+	return ok && strings.HasPrefix(key, "thread_")
+}
+
+func (threadKey) String() string {
+	return "is a thread key"
+}
+
+// expectThreads lets the service keep the threads of the exchanges in the mock store.
+func expectThreads(store *storageMocks.MockStore) {
+	store.EXPECT().Get(threadKey{}).Return(nil, storage.ErrDataNotFound).AnyTimes()
+	store.EXPECT().Put(threadKey{}, gomock.Any()).Return(nil).AnyTimes()
+	store.EXPECT().Delete(threadKey{}).Return(nil).AnyTimes()
+}
+
 func TestNew(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	t.Run("Success", func(t *testing.T) {
 		storeProvider := storageMocks.NewMockProvider(ctrl)
-		storeProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil).Times(2)
+		storeProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil).Times(3)
 
-		provider := issuecredentialMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(nil)
-		provider.EXPECT().StorageProvider().Return(storeProvider).Times(2)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
 		svc, err := New(provider)
 		require.NoError(t, err)
@@ -55,8 +109,8 @@ func TestNew(t *testing.T) {
 		storeProvider := storageMocks.NewMockProvider(ctrl)
 		storeProvider.EXPECT().OpenStore(Name).Return(nil, errors.New(errMsg))
 
-		provider := issuecredentialMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider := newMockProvider(ctrl)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
 		svc, err := New(provider)
 		require.Contains(t, fmt.Sprintf("%v", err), errMsg)
@@ -72,13 +126,15 @@ func TestService_HandleInbound(t *testing.T) {
 	const errMsg = "error"
 
 	store := storageMocks.NewMockStore(ctrl)
+	expectThreads(store)
 
 	storeProvider := storageMocks.NewMockProvider(ctrl)
+	storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 	storeProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil).AnyTimes()
 
 	messenger := serviceMocks.NewMockMessenger(ctrl)
 
-	provider := issuecredentialMocks.NewMockProvider(ctrl)
+	provider := newMockProvider(ctrl)
 	provider.EXPECT().Messenger().Return(messenger).AnyTimes()
 	provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
@@ -155,6 +211,7 @@ func TestService_HandleInbound(t *testing.T) {
 
 			return nil
 		})
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
 
 		svc, err := New(provider)
 		require.NoError(t, err)
@@ -232,7 +289,7 @@ func TestService_HandleInbound(t *testing.T) {
 	t.Run("Receive Propose Credential Continue (async)", func(t *testing.T) {
 		var done = make(chan struct{})
 
-		newProvider := issuecredentialMocks.NewMockProvider(ctrl)
+		newProvider := newMockProvider(ctrl)
 		newProvider.EXPECT().Messenger().Return(messenger).AnyTimes()
 		newProvider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
 
@@ -279,7 +336,7 @@ func TestService_HandleInbound(t *testing.T) {
 	t.Run("Receive Propose Credential Stop (async)", func(t *testing.T) {
 		var done = make(chan struct{})
 
-		newProvider := issuecredentialMocks.NewMockProvider(ctrl)
+		newProvider := newMockProvider(ctrl)
 		newProvider.EXPECT().Messenger().Return(messenger).AnyTimes()
 		newProvider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
 
@@ -349,6 +406,7 @@ func TestService_HandleInbound(t *testing.T) {
 
 			return nil
 		})
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
 
 		svc, err := New(provider)
 		require.NoError(t, err)
@@ -498,6 +556,7 @@ func TestService_HandleInbound(t *testing.T) {
 
 			return nil
 		})
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
 
 		svc, err := New(provider)
 		require.NoError(t, err)
@@ -601,6 +660,7 @@ func TestService_HandleInbound(t *testing.T) {
 
 			return nil
 		})
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
 
 		svc, err := New(provider)
 		require.NoError(t, err)
@@ -673,6 +733,7 @@ func TestService_HandleInbound(t *testing.T) {
 
 			return nil
 		})
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
 
 		svc, err := New(provider)
 		require.NoError(t, err)
@@ -710,6 +771,7 @@ func TestService_HandleInbound(t *testing.T) {
 
 			return nil
 		})
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
 
 		svc, err := New(provider)
 		require.NoError(t, err)
@@ -750,6 +812,301 @@ func TestService_HandleInbound(t *testing.T) {
 	})
 }
 
+func TestService_EventPayloadV2(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provider := issuecredentialMocks.NewMockProvider(ctrl)
+	provider.EXPECT().Messenger().Return(nil).AnyTimes()
+	provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+	provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider())
+	provider.EXPECT().VDRIRegistry().Return(nil)
+	provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV2)
+	provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{})
+
+	svc, err := New(provider)
+	require.NoError(t, err)
+
+	ch := make(chan service.DIDCommAction, 1)
+	require.NoError(t, svc.RegisterActionEvent(ch))
+
+	var issued = time.Date(2010, time.January, 1, 19, 23, 24, 0, time.UTC)
+
+	msg := service.NewDIDCommMsgMap(IssueCredential{
+		Type: IssueCredentialMsgType,
+		CredentialsAttach: []decorator.Attachment{{Data: decorator.AttachmentData{JSON: &verifiable.Credential{
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			ID:      "http://example.edu/credentials/1",
+			Types:   []string{"VerifiableCredential"},
+			Subject: "did:example:holder",
+			Issuer:  verifiable.Issuer{ID: "did:example:issuer"},
+			Issued:  &issued,
+		}}}},
+	})
+
+	piID := uuid.New().String()
+	require.NoError(t, msg.SetID(piID))
+	require.NoError(t, svc.saveStateName(piID, stateNameRequestSent))
+
+	_, err = svc.HandleInbound(msg, Alice, Bob)
+	require.NoError(t, err)
+
+	action := <-ch
+
+	props, ok := action.Properties.(*eventPropsV2)
+	require.True(t, ok)
+	require.Equal(t, service.EventPayloadV2, props.PayloadVersion())
+	require.Empty(t, props.Duplicates())
+	require.Len(t, props.Credentials(), 1)
+	require.Equal(t, "http://example.edu/credentials/1", props.Credentials()[0].ID)
+
+	// the version 1 of the properties is unchanged
+	propsV1, ok := (&Service{}).eventProperties(&metaData{}).(*eventProps)
+	require.True(t, ok)
+	require.Equal(t, service.EventPayloadV1, propsV1.PayloadVersion())
+}
+
+func TestService_DuplicateCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newCredential := func(id string) *verifiable.Credential {
+		var issued = time.Date(2010, time.January, 1, 19, 23, 24, 0, time.UTC)
+
+		return &verifiable.Credential{
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			ID:      id,
+			Types:   []string{"VerifiableCredential"},
+			Subject: "did:example:holder",
+			Issuer:  verifiable.Issuer{ID: "did:example:issuer"},
+			Issued:  &issued,
+		}
+	}
+
+	var done = make(chan struct{})
+
+	messenger := serviceMocks.NewMockMessenger(ctrl)
+	messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any()).
+		Do(func(_ string, msg service.DIDCommMsgMap) error {
+			defer close(done)
+
+			require.Equal(t, AckMsgType, msg.Type())
+
+			return nil
+		})
+
+	storeProvider := mem.NewProvider()
+
+	provider := newMockProvider(ctrl)
+	provider.EXPECT().Messenger().Return(messenger).AnyTimes()
+	provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
+
+	vStore, err := storeverifiable.New(provider)
+	require.NoError(t, err)
+	require.NoError(t, vStore.SaveCredential("stored", newCredential("http://example.edu/credentials/1")))
+
+	svc, err := New(provider)
+	require.NoError(t, err)
+
+	ch := make(chan service.DIDCommAction, 1)
+	require.NoError(t, svc.RegisterActionEvent(ch))
+
+	msg := service.NewDIDCommMsgMap(IssueCredential{
+		Type: IssueCredentialMsgType,
+		CredentialsAttach: []decorator.Attachment{
+			{Data: decorator.AttachmentData{JSON: newCredential("http://example.edu/credentials/2")}},
+		},
+	})
+
+	piID := uuid.New().String()
+	require.NoError(t, msg.SetID(piID))
+	require.NoError(t, svc.saveStateName(piID, stateNameRequestSent))
+
+	_, err = svc.HandleInbound(msg, Alice, Bob)
+	require.NoError(t, err)
+
+	action := <-ch
+
+	props, ok := action.Properties.(*eventProps)
+	require.True(t, ok)
+	require.Len(t, props.Duplicates(), 1)
+	require.Equal(t, "stored", props.Duplicates()[0].Name)
+
+	actions, err := svc.Actions()
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	require.Equal(t, props.Duplicates(), actions[0].Duplicates)
+
+	require.NoError(t, svc.ActionContinue(piID, WithDuplicateDecision(DuplicateReplace, "received")))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("timeout")
+	}
+
+	records := vStore.GetCredentials()
+	require.Len(t, records, 1)
+	require.Equal(t, "received", records[0].Name)
+}
+
+type ed25519Signer struct {
+	privKey ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privKey, data), nil
+}
+
+func (s *ed25519Signer) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: "EdDSA", jose.HeaderKeyID: "#key-1"}
+}
+
+// nolint: funlen
+func TestService_Connection(t *testing.T) {
+	const (
+		connID = "connection-id"
+		newBob = "Bob-rotated"
+	)
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	registry := &mockvdri.MockVDRIRegistry{
+		ResolveFunc: func(didID string, _ ...vdriapi.ResolveOpts) (*did.Doc, error) {
+			if didID != Bob {
+				return nil, vdriapi.ErrNotFound
+			}
+
+			return &did.Doc{ID: Bob, PublicKey: []did.PublicKey{{
+				ID: Bob + "#key-1", Type: "Ed25519VerificationKey2018", Value: pubKey,
+			}}}, nil
+		},
+	}
+
+	newService := func(t *testing.T) (*Service, *connection.Recorder) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		provider := issuecredentialMocks.NewMockProvider(ctrl)
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().Send(gomock.Any(), Alice, Bob).AnyTimes()
+
+		provider.EXPECT().Messenger().Return(messenger).AnyTimes()
+		provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
+		provider.EXPECT().VDRIRegistry().Return(registry).AnyTimes()
+		provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV1)
+		provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{})
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		recorder, err := connection.NewRecorder(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, recorder.SaveConnectionRecord(&connection.Record{
+			ConnectionID: connID, State: "completed", MyDID: Alice, TheirDID: Bob,
+		}))
+
+		return svc, recorder
+	}
+
+	receive := func(t *testing.T, svc *Service, msg service.DIDCommMsgMap, theirDID string) service.DIDCommAction {
+		t.Helper()
+
+		ch := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(ch))
+
+		defer func() { require.NoError(t, svc.UnregisterActionEvent(ch)) }()
+
+		_, err := svc.HandleInbound(msg, Alice, theirDID)
+		require.NoError(t, err)
+
+		select {
+		case action := <-ch:
+			return action
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		return service.DIDCommAction{}
+	}
+
+	proposal := func(fromPrior string) service.DIDCommMsgMap {
+		msg := service.NewDIDCommMsgMap(ProposeCredential{Type: ProposeCredentialMsgType, FromPrior: fromPrior})
+		require.NoError(t, msg.SetID(uuid.New().String()))
+
+		return msg
+	}
+
+	t.Run("exchange associated with the connection", func(t *testing.T) {
+		svc, _ := newService(t)
+
+		action := receive(t, svc, proposal(""), Bob)
+		require.Equal(t, connID, action.Properties.(*eventProps).ConnectionID())
+
+		actions, err := svc.Actions()
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		require.Equal(t, connID, actions[0].ConnectionID)
+
+		// no connection between the DIDs
+		action = receive(t, svc, proposal(""), "Carol")
+		require.Empty(t, action.Properties.(*eventProps).ConnectionID())
+	})
+
+	t.Run("message from another DID", func(t *testing.T) {
+		svc, _ := newService(t)
+
+		offer := service.NewDIDCommMsgMap(OfferCredential{Type: OfferCredentialMsgType})
+		require.NoError(t, svc.HandleOutbound(offer, Alice, Bob))
+
+		th, err := svc.threads.Get(offer.ID())
+		require.NoError(t, err)
+		require.Equal(t, &rotation.Thread{ConnectionID: connID, MyDID: Alice, TheirDID: Bob}, th)
+
+		report := service.NewDIDCommMsgMap(model.ProblemReport{ID: uuid.New().String(), Type: ProblemReportMsgType})
+		report["~thread"] = map[string]interface{}{"thid": offer.ID()}
+
+		require.NoError(t, svc.RegisterActionEvent(make(chan service.DIDCommAction)))
+
+		_, err = svc.HandleInbound(report, Alice, newBob)
+		require.EqualError(t, err, "associate connection: DID "+newBob+" is not a party of the thread "+offer.ID())
+
+		// the exchange is abandoned by the other agent, the thread is released once done
+		_, err = svc.HandleInbound(report, Alice, Bob)
+		require.NoError(t, err)
+
+		th, err = svc.threads.Get(offer.ID())
+		require.NoError(t, err)
+		require.Nil(t, th)
+	})
+
+	t.Run("DID rotation", func(t *testing.T) {
+		svc, recorder := newService(t)
+
+		token, err := jwt.NewSigned(&decorator.FromPrior{ISS: Bob, SUB: newBob}, nil, &ed25519Signer{privKey: privKey})
+		require.NoError(t, err)
+
+		fromPrior, err := token.Serialize(false)
+		require.NoError(t, err)
+
+		action := receive(t, svc, proposal(fromPrior), newBob)
+		require.Equal(t, connID, action.Properties.(*eventProps).ConnectionID())
+
+		record, err := recorder.GetConnectionRecord(connID)
+		require.NoError(t, err)
+		require.Equal(t, newBob, record.TheirDID)
+
+		// the connection is no longer found by the prior DID
+		_, err = recorder.GetConnectionIDByDIDs(Alice, Bob)
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+	})
+}
+
 func TestService_HandleOutbound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -757,13 +1114,15 @@ func TestService_HandleOutbound(t *testing.T) {
 	const errMsg = "error"
 
 	store := storageMocks.NewMockStore(ctrl)
+	expectThreads(store)
 
 	storeProvider := storageMocks.NewMockProvider(ctrl)
+	storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 	storeProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil).AnyTimes()
 
 	messenger := serviceMocks.NewMockMessenger(ctrl)
 
-	provider := issuecredentialMocks.NewMockProvider(ctrl)
+	provider := newMockProvider(ctrl)
 	provider.EXPECT().Messenger().Return(messenger).AnyTimes()
 	provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
@@ -956,7 +1315,7 @@ func TestService_ActionContinue(t *testing.T) {
 
 		messenger := serviceMocks.NewMockMessenger(ctrl)
 
-		provider := issuecredentialMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(messenger)
 		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
@@ -982,7 +1341,7 @@ func TestService_ActionContinue(t *testing.T) {
 
 		messenger := serviceMocks.NewMockMessenger(ctrl)
 
-		provider := issuecredentialMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(messenger)
 		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
@@ -1009,7 +1368,7 @@ func TestService_ActionStop(t *testing.T) {
 
 		messenger := serviceMocks.NewMockMessenger(ctrl)
 
-		provider := issuecredentialMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(messenger)
 		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
@@ -1035,7 +1394,7 @@ func TestService_ActionStop(t *testing.T) {
 
 		messenger := serviceMocks.NewMockMessenger(ctrl)
 
-		provider := issuecredentialMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(messenger)
 		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
@@ -1122,6 +1481,26 @@ func Test_nextState(t *testing.T) {
 	require.Nil(t, next)
 }
 
+func TestService_InstancesByState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := newPauseService(t, ctrl, mem.NewProvider())
+
+	instances, err := svc.InstancesByState()
+	require.NoError(t, err)
+	require.Empty(t, instances)
+
+	require.NoError(t, svc.saveStateName("piID-1", stateNameOfferSent))
+	require.NoError(t, svc.saveStateName("piID-2", stateNameOfferSent))
+	require.NoError(t, svc.saveStateName("piID-3", stateNameCredentialReceived))
+	require.NoError(t, svc.saveStateName("piID-4", stateNameDone))
+
+	instances, err = svc.InstancesByState()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{stateNameOfferSent: 2, stateNameCredentialReceived: 1}, instances)
+}
+
 func TestService_Name(t *testing.T) {
 	require.Equal(t, (*Service).Name(nil), Name)
 }
@@ -11,10 +11,12 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	storeverifiable "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
 )
 
 const (
@@ -42,6 +44,13 @@ const (
 	codeInternalError = "internal"
 )
 
+// the attachment formats supported by the Holder, the formats declared by the Issuer are intersected with them.
+// nolint:gochecknoglobals
+var (
+	detailFormats     = attachformat.MustNewSupported(attachformat.LDProofCredentialDetail)
+	credentialFormats = attachformat.MustNewSupported(attachformat.LDProofCredential)
+)
+
 // state action for network call
 type stateAction func(messenger service.Messenger) error
 
@@ -206,6 +215,9 @@ func (s *offerSent) ExecuteInbound(md *metaData) (state, stateAction, error) {
 		return nil, nil, errors.New("offer credential was not provided")
 	}
 
+	md.ValidityPeriod = md.offerCredential.ValidityPeriod
+	md.validityPeriodOffered = true
+
 	// creates the state's action
 	action := func(messenger service.Messenger) error {
 		// sets message type
@@ -217,6 +229,14 @@ func (s *offerSent) ExecuteInbound(md *metaData) (state, stateAction, error) {
 }
 
 func (s *offerSent) ExecuteOutbound(md *metaData) (state, stateAction, error) {
+	validityPeriod, err := offeredValidityPeriod(md)
+	if err != nil {
+		return nil, nil, fmt.Errorf("validity period: %w", err)
+	}
+
+	md.ValidityPeriod = validityPeriod
+	md.validityPeriodOffered = true
+
 	// creates the state's action
 	action := func(messenger service.Messenger) error {
 		return messenger.Send(md.Msg, md.MyDID, md.TheirDID)
@@ -241,10 +261,17 @@ func (s *requestReceived) ExecuteInbound(md *metaData) (state, stateAction, erro
 		return nil, nil, errors.New("issue credential was not provided")
 	}
 
+	if err := checkValidityPeriod(md); err != nil {
+		return nil, nil, fmt.Errorf("validity period: %w", err)
+	}
+
 	// creates the state's action
 	action := func(messenger service.Messenger) error {
 		// sets message type
 		md.issueCredential.Type = IssueCredentialMsgType
+
+		logger.Debugf("issue credentials of %s: %s", md.PIID, verifiable.RedactedMessage(md.issueCredential))
+
 		return messenger.ReplyTo(md.Msg.ID(), service.NewDIDCommMsgMap(md.issueCredential))
 	}
 
@@ -333,11 +360,18 @@ func (s *offerReceived) ExecuteInbound(md *metaData) (state, stateAction, error)
 		return nil, nil, fmt.Errorf("decode: %w", err)
 	}
 
+	// requests the offered details of the supported formats
+	attachments, formats, err := detailFormats.Filter(offer.Formats, offer.OffersAttach)
+	if err != nil {
+		return nil, nil, fmt.Errorf("offer formats: %w", err)
+	}
+
 	// creates the state's action
 	action := func(messenger service.Messenger) error {
 		return messenger.ReplyTo(md.Msg.ID(), service.NewDIDCommMsgMap(RequestCredential{
 			Type:           RequestCredentialMsgType,
-			RequestsAttach: offer.OffersAttach,
+			Formats:        formats,
+			RequestsAttach: attachments,
 		}))
 	}
 
@@ -383,6 +417,16 @@ func (s *credentialReceived) CanTransitionTo(st state) bool {
 	return st.Name() == stateNameDone || st.Name() == stateNameAbandoning
 }
 
+// issuedCredentials returns the credentials of the attachments of the supported formats of the message.
+func issuedCredentials(msg *IssueCredential) ([]*verifiable.Credential, error) {
+	attachments, _, err := credentialFormats.Filter(msg.Formats, msg.CredentialsAttach)
+	if err != nil {
+		return nil, fmt.Errorf("credential formats: %w", err)
+	}
+
+	return toVerifiableCredentials(attachments)
+}
+
 func toVerifiableCredentials(attachments []decorator.Attachment) ([]*verifiable.Credential, error) {
 	var credentials []*verifiable.Credential
 
@@ -412,9 +456,9 @@ func (s *credentialReceived) ExecuteInbound(md *metaData) (state, stateAction, e
 		return nil, nil, fmt.Errorf("decode: %w", err)
 	}
 
-	credentials, err := toVerifiableCredentials(credential.CredentialsAttach)
+	credentials, err := issuedCredentials(&credential)
 	if err != nil {
-		return nil, nil, fmt.Errorf("to verifiable credentials: %w", err)
+		return nil, nil, fmt.Errorf("issued credentials: %w", err)
 	}
 
 	if len(credentials) == 0 {
@@ -427,6 +471,10 @@ func (s *credentialReceived) ExecuteInbound(md *metaData) (state, stateAction, e
 			name = md.credentialNames[i]
 		}
 
+		if err := resolveDuplicates(md, credential); err != nil {
+			return nil, nil, fmt.Errorf("resolve duplicates: %w", err)
+		}
+
 		if err := md.verifiable.SaveCredential(name, credential); err != nil {
 			return nil, nil, fmt.Errorf("save credential: %w", err)
 		}
@@ -442,6 +490,42 @@ func (s *credentialReceived) ExecuteInbound(md *metaData) (state, stateAction, e
 	return &done{}, action, nil
 }
 
+// resolveDuplicates applies the Holder's decision when the credential has near-duplicates in the store.
+// Without a decision both are kept, the near-duplicates are reported by the action event (see Action.Duplicates)
+// so that the Holder decides. The credentials without a single subject ID have no near-duplicates.
+func resolveDuplicates(md *metaData, credential *verifiable.Credential) error {
+	duplicates, err := md.verifiable.FindDuplicates(credential)
+	if errors.Is(err, storeverifiable.ErrNoSubjectID) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("find duplicates: %w", err)
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	switch md.duplicateDecision {
+	case "", DuplicateKeepBoth:
+		return nil
+	case DuplicateReplace:
+		for _, record := range duplicates {
+			if err := md.verifiable.RemoveCredentialByName(record.Name); err != nil {
+				return fmt.Errorf("remove credential: %w", err)
+			}
+		}
+
+		return nil
+	case DuplicateReject:
+		return customError{error: fmt.Errorf("credential %s rejected: duplicate of %s", credential.ID,
+			duplicates[0].Name)}
+	default:
+		return customError{error: fmt.Errorf("unknown duplicate decision %q", md.duplicateDecision)}
+	}
+}
+
 func (s *credentialReceived) ExecuteOutbound(_ *metaData) (state, stateAction, error) {
 	return nil, nil, fmt.Errorf("%s: ExecuteOutbound is not implemented yet", s.Name())
 }
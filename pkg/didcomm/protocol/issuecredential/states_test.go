@@ -9,6 +9,7 @@ package issuecredential
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,13 +17,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
-	issuecredentialMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/protocol/issuecredential"
 	storageMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 	storeVerifiable "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
 )
 
@@ -364,6 +367,51 @@ func TestRequestReceived_ExecuteInbound(t *testing.T) {
 	})
 }
 
+func TestCredentials_RedactedLogs(t *testing.T) {
+	log.SetLevel(logModule, log.DEBUG)
+	defer log.SetLevel(logModule, log.INFO)
+
+	logRecorder.Reset()
+
+	vc := newCredential()
+	vc.Subject = map[string]interface{}{"id": "did:example:holder", "name": "Alice Smith"}
+	issued := time.Now()
+	vc.Issued = &issued
+
+	issue := &IssueCredential{
+		CredentialsAttach: []decorator.Attachment{{Data: decorator.AttachmentData{JSON: vc}}},
+	}
+
+	// the Issuer issues the credential
+	_, action, err := (&requestReceived{}).ExecuteInbound(&metaData{
+		transitionalPayload: transitionalPayload{PIID: "piid"},
+		issueCredential:     issue,
+	})
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messenger := serviceMocks.NewMockMessenger(ctrl)
+	messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any())
+
+	require.NoError(t, action(messenger))
+
+	// the Holder receives it
+	require.Len(t, (&Service{}).receivedCredentials(service.NewDIDCommMsgMap(issue)), 1)
+
+	lines := logRecorder.Lines(logModule)
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "DEBUG issue credentials of piid: "))
+	require.True(t, strings.HasPrefix(lines[1], "DEBUG received credential "+vc.ID+": "))
+
+	for _, line := range lines {
+		require.NotContains(t, line, "Alice Smith")
+		require.NotContains(t, line, "did:example:holder")
+		require.Contains(t, line, `"credentialSubject":{"id":"***","name":"***"}`)
+	}
+}
+
 func TestRequestReceived_ExecuteOutbound(t *testing.T) {
 	followup, action, err := (&requestReceived{}).ExecuteOutbound(&metaData{})
 	require.Contains(t, fmt.Sprintf("%v", err), "is not implemented yet")
@@ -523,6 +571,57 @@ func TestOfferReceived_ExecuteInbound(t *testing.T) {
 		require.Nil(t, followup)
 		require.Nil(t, action)
 	})
+
+	t.Run("Requests the details of the supported formats", func(t *testing.T) {
+		followup, action, err := (&offerReceived{}).ExecuteInbound(&metaData{
+			transitionalPayload: transitionalPayload{
+				Msg: service.NewDIDCommMsgMap(OfferCredential{
+					Type: OfferCredentialMsgType,
+					Formats: []decorator.AttachmentFormat{
+						{AttachID: "indy", Format: attachformat.HLIndyCredentialAbstract},
+						{AttachID: "ld", Format: attachformat.LDProofCredentialDetail},
+					},
+					OffersAttach: []decorator.Attachment{{ID: "indy"}, {ID: "ld"}},
+				}),
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &requestSent{}, followup)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any()).Do(func(_ string, msg service.DIDCommMsgMap) error {
+			request := RequestCredential{}
+			require.NoError(t, msg.Decode(&request))
+			require.Equal(t, []decorator.AttachmentFormat{
+				{AttachID: "ld", Format: attachformat.LDProofCredentialDetail},
+			}, request.Formats)
+			require.Len(t, request.RequestsAttach, 1)
+			require.Equal(t, "ld", request.RequestsAttach[0].ID)
+
+			return nil
+		})
+
+		require.NoError(t, action(messenger))
+	})
+
+	t.Run("No supported format", func(t *testing.T) {
+		followup, action, err := (&offerReceived{}).ExecuteInbound(&metaData{
+			transitionalPayload: transitionalPayload{
+				Msg: service.NewDIDCommMsgMap(OfferCredential{
+					Type:         OfferCredentialMsgType,
+					Formats:      []decorator.AttachmentFormat{{AttachID: "indy", Format: attachformat.HLIndyCredentialAbstract}},
+					OffersAttach: []decorator.Attachment{{ID: "indy"}},
+				}),
+			},
+		})
+
+		require.True(t, errors.Is(err, attachformat.ErrNoCommonFormat))
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
 }
 
 func TestOfferReceived_ExecuteOutbound(t *testing.T) {
@@ -645,7 +744,23 @@ func TestCredentialReceived_ExecuteInbound(t *testing.T) {
 			},
 		})
 
-		require.Contains(t, fmt.Sprintf("%v", err), "to verifiable credentials")
+		require.Contains(t, fmt.Sprintf("%v", err), "issued credentials")
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+
+	t.Run("Credentials of unsupported formats", func(t *testing.T) {
+		followup, action, err := (&credentialReceived{}).ExecuteInbound(&metaData{
+			transitionalPayload: transitionalPayload{
+				Msg: service.NewDIDCommMsgMap(IssueCredential{
+					Type:              IssueCredentialMsgType,
+					Formats:           []decorator.AttachmentFormat{{AttachID: "indy", Format: attachformat.HLIndyCredential}},
+					CredentialsAttach: []decorator.Attachment{{ID: "indy"}},
+				}),
+			},
+		})
+
+		require.True(t, errors.Is(err, attachformat.ErrNoCommonFormat))
 		require.Nil(t, followup)
 		require.Nil(t, action)
 	})
@@ -661,8 +776,8 @@ func TestCredentialReceived_ExecuteInbound(t *testing.T) {
 		storeProvider := storageMocks.NewMockProvider(ctrl)
 		storeProvider.EXPECT().OpenStore(gomock.Any()).Return(store, nil)
 
-		provider := issuecredentialMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider := newMockProvider(ctrl)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
 		var issued = time.Date(2010, time.January, 1, 19, 23, 24, 0, time.UTC)
 		vStore, err := storeVerifiable.New(provider)
@@ -711,6 +826,98 @@ func TestCredentialReceived_ExecuteInbound(t *testing.T) {
 	})
 }
 
+func TestCredentialReceived_Duplicates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newCredential := func(id string) *verifiable.Credential {
+		var issued = time.Date(2010, time.January, 1, 19, 23, 24, 0, time.UTC)
+
+		return &verifiable.Credential{
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			ID:      id,
+			Types:   []string{"VerifiableCredential"},
+			Subject: "did:example:holder",
+			Issuer:  verifiable.Issuer{ID: "did:example:issuer"},
+			Issued:  &issued,
+		}
+	}
+
+	newMetaData := func(t *testing.T, decision DuplicateDecision) *metaData {
+		provider := newMockProvider(ctrl)
+		provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+
+		vStore, err := storeVerifiable.New(provider)
+		require.NoError(t, err)
+		require.NoError(t, vStore.SaveCredential("stored", newCredential("http://example.edu/credentials/1")))
+
+		return &metaData{
+			verifiable:        vStore,
+			credentialNames:   []string{"received"},
+			duplicateDecision: decision,
+			transitionalPayload: transitionalPayload{
+				Msg: service.NewDIDCommMsgMap(IssueCredential{
+					Type: IssueCredentialMsgType,
+					CredentialsAttach: []decorator.Attachment{
+						{Data: decorator.AttachmentData{JSON: newCredential("http://example.edu/credentials/2")}},
+					},
+				}),
+			},
+		}
+	}
+
+	t.Run("Both are kept by default", func(t *testing.T) {
+		md := newMetaData(t, "")
+
+		followup, _, err := (&credentialReceived{}).ExecuteInbound(md)
+		require.NoError(t, err)
+		require.Equal(t, &done{}, followup)
+		require.Len(t, md.verifiable.GetCredentials(), 2)
+	})
+
+	t.Run("Unknown decision", func(t *testing.T) {
+		md := newMetaData(t, "unknown")
+
+		followup, action, err := (&credentialReceived{}).ExecuteInbound(md)
+		require.Contains(t, fmt.Sprintf("%v", err), "unknown duplicate decision")
+		require.True(t, errors.As(err, &customError{}))
+		require.Nil(t, followup)
+		require.Nil(t, action)
+		require.Len(t, md.verifiable.GetCredentials(), 1)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		md := newMetaData(t, DuplicateReject)
+
+		_, _, err := (&credentialReceived{}).ExecuteInbound(md)
+		require.Contains(t, fmt.Sprintf("%v", err), "rejected: duplicate of stored")
+		require.True(t, errors.As(err, &customError{}))
+		require.Len(t, md.verifiable.GetCredentials(), 1)
+	})
+
+	t.Run("Keep both", func(t *testing.T) {
+		md := newMetaData(t, DuplicateKeepBoth)
+
+		followup, _, err := (&credentialReceived{}).ExecuteInbound(md)
+		require.NoError(t, err)
+		require.Equal(t, &done{}, followup)
+		require.Len(t, md.verifiable.GetCredentials(), 2)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		md := newMetaData(t, DuplicateReplace)
+
+		followup, _, err := (&credentialReceived{}).ExecuteInbound(md)
+		require.NoError(t, err)
+		require.Equal(t, &done{}, followup)
+
+		records := md.verifiable.GetCredentials()
+		require.Len(t, records, 1)
+		require.Equal(t, "received", records[0].Name)
+		require.Equal(t, "http://example.edu/credentials/2", records[0].ID)
+	})
+}
+
 func TestCredentialReceived_ExecuteOutbound(t *testing.T) {
 	followup, action, err := (&credentialReceived{}).ExecuteOutbound(&metaData{})
 	require.Contains(t, fmt.Sprintf("%v", err), "is not implemented yet")
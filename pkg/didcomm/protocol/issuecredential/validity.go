@@ -0,0 +1,152 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const validityPeriodKey = "validity_period_%s"
+
+// ValidityPeriod is the validity window of the credential: the Holder requests it in the proposal, the Issuer
+// confirms or adjusts it in the offer. The window of the offer is the negotiated one, the credentials issued
+// in the exchange must be valid within it.
+type ValidityPeriod struct {
+	// ValidFrom is the earliest issuance date of the credential
+	ValidFrom *time.Time `json:"valid_from,omitempty"`
+	// ValidUntil is the latest expiration date of the credential, the credential must expire if set
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+// Apply sets the issuance and expiration dates of the credential to the validity window.
+// USAGE: The Issuer builds the credentials of the exchange with it, when the window was negotiated.
+func (p *ValidityPeriod) Apply(vc *verifiable.Credential) {
+	if p.ValidFrom != nil {
+		issued := *p.ValidFrom
+		vc.Issued = &issued
+	}
+
+	if p.ValidUntil != nil {
+		expired := *p.ValidUntil
+		vc.Expired = &expired
+	}
+}
+
+// Check returns an error if the credential is not valid within the window.
+func (p *ValidityPeriod) Check(vc *verifiable.Credential) error {
+	if p.ValidFrom != nil && p.ValidUntil != nil && p.ValidUntil.Before(*p.ValidFrom) {
+		return errors.New("validity period ends before it starts")
+	}
+
+	if p.ValidFrom != nil && (vc.Issued == nil || vc.Issued.Before(*p.ValidFrom)) {
+		return fmt.Errorf("credential %s: issued before the validity period", vc.ID)
+	}
+
+	if p.ValidUntil != nil && (vc.Expired == nil || vc.Expired.After(*p.ValidUntil)) {
+		return fmt.Errorf("credential %s: expires after the validity period", vc.ID)
+	}
+
+	return nil
+}
+
+// offeredValidityPeriod returns the validity window of the offer sent by the Issuer, nil if there is none.
+func offeredValidityPeriod(md *metaData) (*ValidityPeriod, error) {
+	if md.offerCredential != nil {
+		return md.offerCredential.ValidityPeriod, nil
+	}
+
+	var offer OfferCredential
+	if err := md.Msg.Decode(&offer); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return offer.ValidityPeriod, nil
+}
+
+// eventValidityPeriod returns the validity window of the action event: the window requested by the proposal,
+// offered by the offer or negotiated for the request.
+func eventValidityPeriod(md *metaData) *ValidityPeriod {
+	var msg struct {
+		ValidityPeriod *ValidityPeriod `json:"validity_period,omitempty"`
+	}
+
+	switch md.Msg.Type() {
+	case ProposeCredentialMsgType, OfferCredentialMsgType:
+		if err := md.Msg.Decode(&msg); err != nil {
+			logger.Warnf("validity period: decode: %s", err)
+			return nil
+		}
+
+		return msg.ValidityPeriod
+	case RequestCredentialMsgType:
+		return md.ValidityPeriod
+	default:
+		return nil
+	}
+}
+
+// checkValidityPeriod checks the credentials issued by the Issuer against the negotiated validity window.
+func checkValidityPeriod(md *metaData) error {
+	if md.ValidityPeriod == nil {
+		return nil
+	}
+
+	credentials, err := issuedCredentials(md.issueCredential)
+	if err != nil {
+		return fmt.Errorf("issued credentials: %w", err)
+	}
+
+	for _, vc := range credentials {
+		if err := md.ValidityPeriod.Check(vc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) saveValidityPeriod(piID string, period *ValidityPeriod) error {
+	src, err := json.Marshal(period)
+	if err != nil {
+		return fmt.Errorf("marshal validity period: %w", err)
+	}
+
+	return s.store.Put(fmt.Sprintf(validityPeriodKey, piID), src)
+}
+
+func (s *Service) deleteValidityPeriod(piID string) error {
+	if err := s.store.Delete(fmt.Sprintf(validityPeriodKey, piID)); err != nil {
+		return fmt.Errorf("delete validity period: %w", err)
+	}
+
+	return nil
+}
+
+// validityPeriod returns the validity window negotiated in the exchange, nil if there is none.
+func (s *Service) validityPeriod(piID string) (*ValidityPeriod, error) {
+	src, err := s.store.Get(fmt.Sprintf(validityPeriodKey, piID))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get validity period: %w", err)
+	}
+
+	var period ValidityPeriod
+	if err := json.Unmarshal(src, &period); err != nil {
+		return nil, fmt.Errorf("unmarshal validity period: %w", err)
+	}
+
+	return &period, nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuecredential
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	storageMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func newValidityPeriod() *ValidityPeriod {
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(1, 0, 0)
+
+	return &ValidityPeriod{ValidFrom: &from, ValidUntil: &until}
+}
+
+func credentialAttachment(t *testing.T, vc *verifiable.Credential) decorator.Attachment {
+	raw, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	var credential map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &credential))
+
+	return decorator.Attachment{Data: decorator.AttachmentData{JSON: credential}}
+}
+
+func newCredential() *verifiable.Credential {
+	return &verifiable.Credential{
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		ID:      "http://example.edu/credentials/1872",
+		Types:   []string{"VerifiableCredential"},
+		Subject: "did:example:ebfeb1f712ebc6f1c276e12ec21",
+		Issuer:  verifiable.Issuer{ID: "did:example:76e12ec712ebc6f1c221ebfeb1f"},
+	}
+}
+
+func TestValidityPeriod(t *testing.T) {
+	t.Run("apply", func(t *testing.T) {
+		period := newValidityPeriod()
+
+		vc := newCredential()
+		period.Apply(vc)
+
+		require.Equal(t, period.ValidFrom, vc.Issued)
+		require.Equal(t, period.ValidUntil, vc.Expired)
+		require.NoError(t, period.Check(vc))
+
+		// the window without bounds leaves the dates
+		(&ValidityPeriod{}).Apply(vc)
+		require.Equal(t, period.ValidFrom, vc.Issued)
+	})
+
+	t.Run("check", func(t *testing.T) {
+		period := newValidityPeriod()
+
+		vc := newCredential()
+		require.EqualError(t, period.Check(vc), "credential http://example.edu/credentials/1872: "+
+			"issued before the validity period")
+
+		issued := period.ValidFrom.AddDate(0, 1, 0)
+		vc.Issued = &issued
+		require.EqualError(t, period.Check(vc), "credential http://example.edu/credentials/1872: "+
+			"expires after the validity period")
+
+		expired := period.ValidUntil.AddDate(0, 1, 0)
+		vc.Expired = &expired
+		require.EqualError(t, period.Check(vc), "credential http://example.edu/credentials/1872: "+
+			"expires after the validity period")
+
+		expired = period.ValidUntil.AddDate(0, -1, 0)
+		require.NoError(t, period.Check(vc))
+
+		require.NoError(t, (&ValidityPeriod{}).Check(newCredential()))
+
+		period.ValidUntil, period.ValidFrom = period.ValidFrom, period.ValidUntil
+		require.EqualError(t, period.Check(vc), "validity period ends before it starts")
+	})
+}
+
+func TestRequestReceived_ValidityPeriod(t *testing.T) {
+	period := newValidityPeriod()
+
+	t.Run("credential within the window", func(t *testing.T) {
+		vc := newCredential()
+		period.Apply(vc)
+
+		followup, _, err := (&requestReceived{}).ExecuteInbound(&metaData{
+			transitionalPayload: transitionalPayload{ValidityPeriod: period},
+			issueCredential:     &IssueCredential{CredentialsAttach: []decorator.Attachment{credentialAttachment(t, vc)}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &credentialIssued{}, followup)
+	})
+
+	t.Run("credential outside of the window", func(t *testing.T) {
+		vc := newCredential()
+		period.Apply(vc)
+
+		expired := period.ValidUntil.AddDate(1, 0, 0)
+		vc.Expired = &expired
+
+		_, _, err := (&requestReceived{}).ExecuteInbound(&metaData{
+			transitionalPayload: transitionalPayload{ValidityPeriod: period},
+			issueCredential:     &IssueCredential{CredentialsAttach: []decorator.Attachment{credentialAttachment(t, vc)}},
+		})
+		require.EqualError(t, err, "validity period: credential http://example.edu/credentials/1872: "+
+			"expires after the validity period")
+	})
+
+	t.Run("invalid credential", func(t *testing.T) {
+		_, _, err := (&requestReceived{}).ExecuteInbound(&metaData{
+			transitionalPayload: transitionalPayload{ValidityPeriod: period},
+			issueCredential: &IssueCredential{CredentialsAttach: []decorator.Attachment{{
+				Data: decorator.AttachmentData{JSON: "credential"},
+			}}},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "validity period: issued credentials")
+	})
+}
+
+func TestService_ValidityPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	period := newValidityPeriod()
+
+	t.Run("negotiated by the offer", func(t *testing.T) {
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().Send(gomock.Any(), Alice, Bob).Return(nil)
+
+		provider := newMockProvider(ctrl)
+		provider.EXPECT().Messenger().Return(messenger)
+		provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		ch := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(ch))
+
+		offer := service.NewDIDCommMsgMap(OfferCredential{Type: OfferCredentialMsgType, ValidityPeriod: period})
+		require.NoError(t, offer.SetID(uuid.New().String()))
+		require.NoError(t, svc.HandleOutbound(offer, Alice, Bob))
+
+		request := service.NewDIDCommMsgMap(RequestCredential{Type: RequestCredentialMsgType})
+		require.NoError(t, request.SetID(uuid.New().String()))
+		request["~thread"] = map[string]interface{}{"thid": offer.ID()}
+
+		_, err = svc.HandleInbound(request, Alice, Bob)
+		require.NoError(t, err)
+
+		action := <-ch
+
+		props, ok := action.Properties.(*eventProps)
+		require.True(t, ok)
+		require.Equal(t, period.ValidFrom.Unix(), props.ValidityPeriod().ValidFrom.Unix())
+		require.Equal(t, period.ValidUntil.Unix(), props.ValidityPeriod().ValidUntil.Unix())
+
+		stored, err := svc.validityPeriod(offer.ID())
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+
+		// the validity period is deleted once the exchange is abandoned
+		reported := make(chan struct{})
+
+		messenger.EXPECT().ReplyToNested(offer.ID(), gomock.Any(), Alice, Bob).
+			Do(func(string, service.DIDCommMsgMap, string, string) error {
+				close(reported)
+
+				return nil
+			})
+
+		action.Stop(nil)
+
+		select {
+		case <-reported:
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		stored, err = svc.validityPeriod(offer.ID())
+		require.NoError(t, err)
+		require.Nil(t, stored)
+	})
+
+	t.Run("requested by the proposal", func(t *testing.T) {
+		md := &metaData{transitionalPayload: transitionalPayload{
+			Msg: service.NewDIDCommMsgMap(ProposeCredential{Type: ProposeCredentialMsgType, ValidityPeriod: period}),
+		}}
+
+		require.Equal(t, period.ValidUntil.Unix(), eventValidityPeriod(md).ValidUntil.Unix())
+
+		md.Msg = service.NewDIDCommMsgMap(IssueCredential{Type: IssueCredentialMsgType})
+		require.Nil(t, eventValidityPeriod(md))
+
+		md.Msg = service.DIDCommMsgMap{
+			"@type":           ProposeCredentialMsgType,
+			"validity_period": "one year",
+		}
+		require.Nil(t, eventValidityPeriod(md))
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		store := storageMocks.NewMockStore(ctrl)
+		store.EXPECT().Get(gomock.Any()).Return(nil, errors.New("test"))
+
+		_, err := (&Service{store: store}).validityPeriod("piid")
+		require.EqualError(t, err, "get validity period: test")
+	})
+}
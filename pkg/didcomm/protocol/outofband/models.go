@@ -17,4 +17,6 @@ type Request struct {
 	GoalCode string                  `json:"goal-code,omitempty"`
 	Requests []*decorator.Attachment `json:"request~attach"`
 	Service  []interface{}           `json:"service"` // Service is an array of either DIDs or 'service' block entries.
+	// HandshakeProtocols are the protocols the sender can use to establish the connection, in preference order.
+	HandshakeProtocols []string `json:"handshake_protocols,omitempty"`
 }
@@ -11,8 +11,7 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
@@ -30,6 +29,13 @@ const (
 	// RequestMsgType is the '@type' for the request message.
 	RequestMsgType = "https://didcomm.org/oob-request/1.0/request"
 
+	// HandshakeDIDExchange10 identifies the DID exchange 1.0 handshake protocol.
+	HandshakeDIDExchange10 = didexchange.HandshakeProtocol
+	// HandshakeDIDExchange11 identifies the DID exchange 1.1 handshake protocol.
+	HandshakeDIDExchange11 = "https://didcomm.org/didexchange/1.1"
+	// HandshakeConnections10 identifies the connections 1.0 handshake protocol.
+	HandshakeConnections10 = "https://didcomm.org/connections/1.0"
+
 	// TODO channel size - https://github.com/hyperledger/aries-framework-go/issues/246
 	callbackChannelSize = 10
 )
@@ -56,6 +62,7 @@ type Service struct {
 	getNextRequestFunc         func(*myState) (*decorator.Attachment, bool)
 	extractDIDCommMsgBytesFunc func(*decorator.Attachment) ([]byte, error)
 	listenerFunc               func()
+	idGenerator                idgen.Generator
 }
 
 type callback struct {
@@ -102,6 +109,7 @@ func New(p Provider) (*Service, error) {
 	}
 
 	s := &Service{
+		idGenerator:                idgen.FromProvider(p),
 		callbackChannel:            make(chan *callback, callbackChannelSize),
 		didSvc:                     didSvc,
 		didEvents:                  make(chan service.StateMsg, callbackChannelSize),
@@ -141,7 +149,8 @@ func (s *Service) Accept(msgType string) bool {
 
 // HandleInbound handles inbound messages
 func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string) (string, error) {
-	logger.Debugf("receive inbound message : %s", msg)
+	logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: msg.ID()}).
+		Debugf("receive inbound message : %s", msg)
 
 	if !s.Accept(msg.Type()) {
 		return "", fmt.Errorf("unsupported message type %s", msg.Type())
@@ -197,7 +206,7 @@ func (s *Service) SaveRequest(r *Request) error {
 	}
 
 	err = s.didSvc.SaveInvitation(&didexchange.OOBInvitation{
-		ID:       uuid.New().String(),
+		ID:       s.idGenerator.NewID(),
 		ThreadID: r.ID,
 		Label:    r.Label,
 		Target:   target,
@@ -256,11 +265,16 @@ func listener(
 func (s *Service) handleRequestCallback(c *callback) (string, error) {
 	// TODO refactor didexchange.Service to accept an object other than didexchange.Invitation
 	//  https://github.com/hyperledger/aries-framework-go/issues/1501
-	invitation, req, err := decodeInvitationAndRequest(c.msg)
+	invitation, req, err := decodeInvitationAndRequest(c.msg, s.idGenerator)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode didexchange invitation and out-of-band request : %w", err)
 	}
 
+	invitation.HandshakeProtocol, err = selectHandshakeProtocol(req.HandshakeProtocols)
+	if err != nil {
+		return "", fmt.Errorf("failed to negotiate the handshake protocol : %w", err)
+	}
+
 	connID, err := s.didSvc.RespondTo(invitation)
 	if err != nil {
 		return "", fmt.Errorf("didexchange service failed to handle inbound request : %w", err)
@@ -368,7 +382,8 @@ func (s *Service) fetchConnectionRecord(id string) (*connection.Record, error) {
 }
 
 // TODO a request message contains an array of attachments (each a request in of itself).
-//  Should we process in parallel? Would need a spec update.
+//
+//	Should we process in parallel? Would need a spec update.
 func getNextRequest(state *myState) (*decorator.Attachment, bool) {
 	if !state.Done {
 		return state.Request.Requests[0], true
@@ -382,7 +397,7 @@ func extractDIDCommMsgBytes(_ *decorator.Attachment) ([]byte, error) {
 	return nil, nil
 }
 
-func decodeInvitationAndRequest(msg service.DIDCommMsg) (*didexchange.OOBInvitation, *Request, error) {
+func decodeInvitationAndRequest(msg service.DIDCommMsg, g idgen.Generator) (*didexchange.OOBInvitation, *Request, error) {
 	req := &Request{}
 
 	err := msg.Decode(req)
@@ -391,7 +406,7 @@ func decodeInvitationAndRequest(msg service.DIDCommMsg) (*didexchange.OOBInvitat
 	}
 
 	invitation := &didexchange.OOBInvitation{
-		ID:       uuid.New().String(),
+		ID:       g.NewID(),
 		ThreadID: req.ID,
 		Label:    req.Label,
 	}
@@ -407,6 +422,32 @@ func decodeInvitationAndRequest(msg service.DIDCommMsg) (*didexchange.OOBInvitat
 	return invitation, req, nil
 }
 
+// HandshakeProtocols returns the handshake protocols supported by this agent, in preference order. They are
+// offered by the requests which do not specify their handshake protocols.
+func HandshakeProtocols() []string {
+	return []string{HandshakeDIDExchange10}
+}
+
+// selectHandshakeProtocol returns the first of the offered handshake protocols, in the sender's preference order,
+// supported by this agent. A request without handshake protocols predates them and implies DID exchange 1.0.
+func selectHandshakeProtocol(offered []string) (string, error) {
+	if len(offered) == 0 {
+		return HandshakeDIDExchange10, nil
+	}
+
+	supported := HandshakeProtocols()
+
+	for _, protocol := range offered {
+		for _, s := range supported {
+			if protocol == s {
+				return protocol, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("none of the handshake protocols %v is supported", offered)
+}
+
 func chooseTarget(svcs []interface{}) (interface{}, error) {
 	for i := range svcs {
 		switch svc := svcs[i].(type) {
@@ -16,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
@@ -200,6 +201,32 @@ func TestHandleRequestCallback(t *testing.T) {
 		_, err := s.handleRequestCallback(newReqCallback())
 		require.NoError(t, err)
 	})
+	t.Run("negotiates the handshake protocol", func(t *testing.T) {
+		var negotiated string
+		provider := testProvider()
+		provider.ServiceMap = map[string]interface{}{
+			didexchange.DIDExchange: &mockdidexchange.MockDIDExchangeSvc{
+				RespondToFunc: func(i *didexchange.OOBInvitation) (string, error) {
+					negotiated = i.HandshakeProtocol
+					return "", nil
+				},
+			},
+		}
+		s := newAutoService(t, provider)
+		req := newRequest()
+		req.HandshakeProtocols = []string{HandshakeDIDExchange11, HandshakeDIDExchange10, HandshakeConnections10}
+		_, err := s.handleRequestCallback(&callback{msg: service.NewDIDCommMsgMap(req)})
+		require.NoError(t, err)
+		require.Equal(t, HandshakeDIDExchange10, negotiated)
+	})
+	t.Run("fails if none of the handshake protocols is supported", func(t *testing.T) {
+		s := newAutoService(t, testProvider())
+		req := newRequest()
+		req.HandshakeProtocols = []string{HandshakeDIDExchange11, HandshakeConnections10}
+		_, err := s.handleRequestCallback(&callback{msg: service.NewDIDCommMsgMap(req)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to negotiate the handshake protocol")
+	})
 	t.Run("wraps error thrown when decoding the message", func(t *testing.T) {
 		expected := errors.New("test")
 		s := newAutoService(t, testProvider())
@@ -530,7 +557,7 @@ func TestDecodeInvitationAndRequest(t *testing.T) {
 		id := "did:example:myPublicDID123"
 		expected := newRequest()
 		expected.Service = []interface{}{id}
-		inv, req, err := decodeInvitationAndRequest(service.NewDIDCommMsgMap(expected))
+		inv, req, err := decodeInvitationAndRequest(service.NewDIDCommMsgMap(expected), idgen.UUIDv4())
 		require.NoError(t, err)
 		require.NotNil(t, req)
 		require.Equal(t, expected, req)
@@ -552,7 +579,7 @@ func TestDecodeInvitationAndRequest(t *testing.T) {
 		}
 		req := newRequest()
 		req.Service = []interface{}{expected}
-		inv, req, err := decodeInvitationAndRequest(service.NewDIDCommMsgMap(req))
+		inv, req, err := decodeInvitationAndRequest(service.NewDIDCommMsgMap(req), idgen.UUIDv4())
 		require.NoError(t, err)
 		require.NotNil(t, inv)
 		require.Equal(t, expected, inv.Target)
@@ -563,18 +590,36 @@ func TestDecodeInvitationAndRequest(t *testing.T) {
 	t.Run("fails if request has no service targets", func(t *testing.T) {
 		req := newRequest()
 		req.Service = nil
-		_, _, err := decodeInvitationAndRequest(service.NewDIDCommMsgMap(req))
+		_, _, err := decodeInvitationAndRequest(service.NewDIDCommMsgMap(req), idgen.UUIDv4())
 		require.Error(t, err)
 	})
 	t.Run("wraps error thrown when decoding the message", func(t *testing.T) {
 		expected := errors.New("test")
 		msg := &testDIDCommMsg{errDecode: expected}
-		_, _, err := decodeInvitationAndRequest(msg)
+		_, _, err := decodeInvitationAndRequest(msg, idgen.UUIDv4())
 		require.Error(t, err)
 		require.True(t, errors.Is(err, expected))
 	})
 }
 
+func TestSelectHandshakeProtocol(t *testing.T) {
+	t.Run("defaults to didexchange 1.0", func(t *testing.T) {
+		protocol, err := selectHandshakeProtocol(nil)
+		require.NoError(t, err)
+		require.Equal(t, HandshakeDIDExchange10, protocol)
+	})
+	t.Run("selects the first supported protocol", func(t *testing.T) {
+		protocol, err := selectHandshakeProtocol([]string{HandshakeConnections10, HandshakeDIDExchange10})
+		require.NoError(t, err)
+		require.Equal(t, HandshakeDIDExchange10, protocol)
+	})
+	t.Run("fails if no protocol is supported", func(t *testing.T) {
+		_, err := selectHandshakeProtocol([]string{HandshakeConnections10})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "none of the handshake protocols")
+	})
+}
+
 func TestAcceptRequest(t *testing.T) {
 	t.Run("returns connectionID", func(t *testing.T) {
 		expected := "123456"
@@ -0,0 +1,207 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/audit"
+)
+
+const auditKey = "audit_%s"
+
+// AuditLog records the exchanges reaching the done state, e.g an audit.Store.
+type AuditLog interface {
+	Add(record *audit.Record) error
+}
+
+// auditEvidence is what is recorded of an in-flight exchange, until the exchange is done.
+type auditEvidence struct {
+	StartedAt          time.Time
+	RequestHashes      []string
+	PresentationHashes []string
+	// Received is true if the presentations were received, the agent is then the Verifier of the exchange
+	Received  bool
+	Results   []VerificationResult
+	Abandoned bool
+	Errors    []string
+}
+
+// SetAuditLog sets the audit log recording the exchanges reaching the done state: the hashes of the requested
+// presentations and of the presentations, the DIDs, the timestamps and the verification results of the exchange.
+// A nil log disables the audit (the default).
+func (s *Service) SetAuditLog(log AuditLog) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	s.audit = log
+}
+
+func (s *Service) auditLog() AuditLog {
+	s.auditMu.RLock()
+	defer s.auditMu.RUnlock()
+
+	return s.audit
+}
+
+// recordAudit collects the evidence of the exchange once the state is persisted, and records it once done.
+func (s *Service) recordAudit(md *metaData, current state) error {
+	log := s.auditLog()
+	if log == nil {
+		return nil
+	}
+
+	evidence, err := s.auditEvidence(md.PIID)
+	if err != nil {
+		return err
+	}
+
+	switch current.Name() {
+	case stateNameDone:
+		if err := log.Add(auditRecord(md, evidence)); err != nil {
+			return fmt.Errorf("add record: %w", err)
+		}
+
+		return s.store.Delete(fmt.Sprintf(auditKey, md.PIID))
+	case stateNameAbandoning:
+		evidence.Abandoned = true
+
+		// the reasons given to stop an exchange may quote its presentations
+		if md.err != nil {
+			evidence.Errors = append(evidence.Errors, verifiable.RedactText(md.err.Error()))
+		}
+	default:
+		if err := evidence.collect(md); err != nil {
+			return err
+		}
+	}
+
+	src, err := json.Marshal(evidence)
+	if err != nil {
+		return fmt.Errorf("marshal evidence: %w", err)
+	}
+
+	return s.store.Put(fmt.Sprintf(auditKey, md.PIID), src)
+}
+
+func (s *Service) auditEvidence(piID string) (*auditEvidence, error) {
+	src, err := s.store.Get(fmt.Sprintf(auditKey, piID))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return &auditEvidence{StartedAt: time.Now().UTC()}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get evidence: %w", err)
+	}
+
+	var evidence auditEvidence
+	if err := json.Unmarshal(src, &evidence); err != nil {
+		return nil, fmt.Errorf("unmarshal evidence: %w", err)
+	}
+
+	return &evidence, nil
+}
+
+// collect the requested presentations and the presentations, sent or received, of the exchange.
+func (e *auditEvidence) collect(md *metaData) error {
+	switch md.Msg.Type() {
+	case RequestPresentationMsgType:
+		var request RequestPresentation
+		if err := md.Msg.Decode(&request); err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
+
+		e.RequestHashes = appendHashes(e.RequestHashes, request.RequestPresentations)
+	case PresentationMsgType:
+		var presentation Presentation
+		if err := md.Msg.Decode(&presentation); err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
+
+		e.PresentationHashes = appendHashes(e.PresentationHashes, presentation.Presentations)
+		e.Received = true
+		e.Results = md.VerificationResults
+	}
+
+	if md.request != nil {
+		e.RequestHashes = appendHashes(e.RequestHashes, md.request.RequestPresentations)
+	}
+
+	if md.presentation != nil {
+		e.PresentationHashes = appendHashes(e.PresentationHashes, md.presentation.Presentations)
+	}
+
+	return nil
+}
+
+// appendHashes appends the hashes of the attachments which are not already recorded.
+func appendHashes(hashes []string, attachments []decorator.Attachment) []string {
+	for i := range attachments {
+		src, err := json.Marshal(&attachments[i])
+		if err != nil {
+			// the attachments are decoded from JSON, marshaling them again does not fail
+			continue
+		}
+
+		hash := audit.HashAttachment(src)
+		if !contains(hashes, hash) {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return hashes
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func auditRecord(md *metaData, evidence *auditEvidence) *audit.Record {
+	record := &audit.Record{
+		Protocol:       Name,
+		ThreadID:       md.PIID,
+		MyDID:          md.MyDID,
+		TheirDID:       md.TheirDID,
+		RequestHashes:  evidence.RequestHashes,
+		ResponseHashes: evidence.PresentationHashes,
+		Errors:         evidence.Errors,
+		Abandoned:      evidence.Abandoned,
+		StartedAt:      evidence.StartedAt,
+		CompletedAt:    time.Now().UTC(),
+	}
+
+	// the presentations are only verified by the Verifier
+	if !evidence.Received {
+		return record
+	}
+
+	verified := !evidence.Abandoned
+
+	for _, result := range evidence.Results {
+		if !result.Verified {
+			verified = false
+			record.Errors = append(record.Errors,
+				fmt.Sprintf("presentation %s: %s", result.ID, verifiable.RedactText(result.Error)))
+		}
+	}
+
+	record.Verified = &verified
+
+	return record
+}
@@ -0,0 +1,224 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/audit"
+)
+
+type failingAuditLog struct{}
+
+func (failingAuditLog) Add(*audit.Record) error {
+	return errors.New("test")
+}
+
+func newAuditService(t *testing.T) (*Service, storage.Store) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	storeProvider := mem.NewProvider()
+
+	provider := newMockProvider(ctrl)
+	provider.EXPECT().Messenger().Return(nil)
+	provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
+	provider.EXPECT().VDRIRegistry().Return(nil)
+
+	svc, err := New(provider)
+	require.NoError(t, err)
+
+	store, err := storeProvider.OpenStore(Name)
+	require.NoError(t, err)
+
+	return svc, store
+}
+
+func auditMetaData(msg interface{}) *metaData {
+	return &metaData{transitionalPayload: transitionalPayload{
+		PIID:     "piid",
+		Msg:      service.NewDIDCommMsgMap(msg),
+		MyDID:    "did:example:verifier",
+		TheirDID: "did:example:prover",
+	}}
+}
+
+func TestService_RecordAudit(t *testing.T) {
+	request := &RequestPresentation{
+		Type:                 RequestPresentationMsgType,
+		RequestPresentations: []decorator.Attachment{{ID: "1", Data: decorator.AttachmentData{Base64: "cmVxdWVzdA=="}}},
+	}
+
+	presentation := &Presentation{
+		Type:          PresentationMsgType,
+		Presentations: []decorator.Attachment{{ID: "1", Data: decorator.AttachmentData{Base64: "cHJlc2VudGF0aW9u"}}},
+	}
+
+	t.Run("verified exchange", func(t *testing.T) {
+		svc, store := newAuditService(t)
+
+		log, err := audit.New(mem.NewProvider())
+		require.NoError(t, err)
+
+		svc.SetAuditLog(log)
+
+		require.NoError(t, svc.recordAudit(auditMetaData(request), &requestSent{}))
+		// the request is recorded once
+		require.NoError(t, svc.recordAudit(auditMetaData(request), &requestSent{}))
+
+		md := auditMetaData(presentation)
+		md.VerificationResults = []VerificationResult{{ID: "1", Verified: true}}
+
+		require.NoError(t, svc.recordAudit(md, &presentationReceived{}))
+		require.NoError(t, svc.recordAudit(md, &done{}))
+
+		// the evidence is deleted once recorded
+		_, err = store.Get(fmt.Sprintf(auditKey, "piid"))
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		var buf bytes.Buffer
+		require.NoError(t, log.Export(&buf))
+
+		var record audit.Record
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+		requestAttachment, err := json.Marshal(&request.RequestPresentations[0])
+		require.NoError(t, err)
+
+		presentationAttachment, err := json.Marshal(&presentation.Presentations[0])
+		require.NoError(t, err)
+
+		require.Equal(t, Name, record.Protocol)
+		require.Equal(t, "piid", record.ThreadID)
+		require.Equal(t, "did:example:verifier", record.MyDID)
+		require.Equal(t, "did:example:prover", record.TheirDID)
+		require.Equal(t, []string{audit.HashAttachment(requestAttachment)}, record.RequestHashes)
+		require.Equal(t, []string{audit.HashAttachment(presentationAttachment)}, record.ResponseHashes)
+		require.NotNil(t, record.Verified)
+		require.True(t, *record.Verified)
+		require.False(t, record.Abandoned)
+		require.False(t, record.CompletedAt.Before(record.StartedAt))
+
+		require.NoError(t, log.Verify())
+	})
+
+	t.Run("presentation not verified", func(t *testing.T) {
+		svc, _ := newAuditService(t)
+
+		log, err := audit.New(mem.NewProvider())
+		require.NoError(t, err)
+
+		svc.SetAuditLog(log)
+
+		md := auditMetaData(presentation)
+		md.VerificationResults = []VerificationResult{{ID: "1", Error: "invalid signature"}}
+
+		require.NoError(t, svc.recordAudit(md, &presentationReceived{}))
+
+		md.err = errors.New("rejected")
+		require.NoError(t, svc.recordAudit(md, &abandoning{}))
+		require.NoError(t, svc.recordAudit(md, &done{}))
+
+		var buf bytes.Buffer
+		require.NoError(t, log.Export(&buf))
+
+		var record audit.Record
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+		require.NotNil(t, record.Verified)
+		require.False(t, *record.Verified)
+		require.True(t, record.Abandoned)
+		require.Equal(t, []string{"rejected", "presentation 1: invalid signature"}, record.Errors)
+	})
+
+	t.Run("quoted presentations redacted", func(t *testing.T) {
+		svc, _ := newAuditService(t)
+
+		log, err := audit.New(mem.NewProvider())
+		require.NoError(t, err)
+
+		svc.SetAuditLog(log)
+
+		quoted := `{"verifiableCredential": [{"credentialSubject": {"name": "Alice Smith"}}]}`
+
+		md := auditMetaData(presentation)
+		md.VerificationResults = []VerificationResult{{ID: "1", Error: "credential not trusted: " + quoted}}
+
+		require.NoError(t, svc.recordAudit(md, &presentationReceived{}))
+
+		md.err = customError{error: fmt.Errorf("declined presentation %s", quoted)}
+		require.NoError(t, svc.recordAudit(md, &abandoning{}))
+		require.NoError(t, svc.recordAudit(md, &done{}))
+
+		var buf bytes.Buffer
+		require.NoError(t, log.Export(&buf))
+		require.NotContains(t, buf.String(), "Alice Smith")
+
+		var record audit.Record
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+		redacted := `{"verifiableCredential":[{"credentialSubject":{"name":"***"}}]}`
+		require.Equal(t, []string{
+			"declined presentation " + redacted,
+			"presentation 1: credential not trusted: " + redacted,
+		}, record.Errors)
+	})
+
+	t.Run("presentation sent", func(t *testing.T) {
+		svc, _ := newAuditService(t)
+
+		log, err := audit.New(mem.NewProvider())
+		require.NoError(t, err)
+
+		svc.SetAuditLog(log)
+
+		md := auditMetaData(request)
+		md.presentation = presentation
+
+		require.NoError(t, svc.recordAudit(md, &presentationSent{}))
+		require.NoError(t, svc.recordAudit(md, &done{}))
+
+		var buf bytes.Buffer
+		require.NoError(t, log.Export(&buf))
+
+		var record audit.Record
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+		// the Prover does not verify the presentations
+		require.Nil(t, record.Verified)
+		require.Len(t, record.ResponseHashes, 1)
+		require.NotContains(t, buf.String(), `"verified"`)
+	})
+
+	t.Run("no audit log", func(t *testing.T) {
+		svc, store := newAuditService(t)
+
+		require.NoError(t, svc.recordAudit(auditMetaData(request), &requestSent{}))
+
+		_, err := store.Get(fmt.Sprintf(auditKey, "piid"))
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+	})
+
+	t.Run("audit log error", func(t *testing.T) {
+		svc, _ := newAuditService(t)
+		svc.SetAuditLog(failingAuditLog{})
+
+		err := svc.recordAudit(auditMetaData(presentation), &done{})
+		require.EqualError(t, err, "add record: test")
+	})
+}
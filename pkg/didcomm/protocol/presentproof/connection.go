@@ -0,0 +1,29 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+// associateConnection associates the exchange with the connection of the DIDs of the message and follows
+// the rotation of the DID of the other agent, see rotation.Threads.
+func (s *Service) associateConnection(md *metaData) error {
+	connID, err := s.threads.Associate(md.PIID, md.MyDID, md.TheirDID, md.Msg)
+	if err != nil {
+		return err
+	}
+
+	md.ConnectionID = connID
+
+	return nil
+}
+
+// releaseThread deletes the thread of the exchange once it is done.
+func (s *Service) releaseThread(md *metaData, current state) error {
+	if current.Name() != stateNameDone {
+		return nil
+	}
+
+	return s.threads.Release(md.PIID)
+}
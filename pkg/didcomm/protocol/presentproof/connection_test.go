@@ -0,0 +1,251 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/rotation"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	presentproofMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/protocol/presentproof"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+type ed25519Signer struct {
+	privKey ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privKey, data), nil
+}
+
+func (s *ed25519Signer) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: "EdDSA", jose.HeaderKeyID: "#key-1"}
+}
+
+func fromPriorJWT(t *testing.T, privKey ed25519.PrivateKey, claims *decorator.FromPrior) string {
+	t.Helper()
+
+	token, err := jwt.NewSigned(claims, nil, &ed25519Signer{privKey: privKey})
+	require.NoError(t, err)
+
+	raw, err := token.Serialize(false)
+	require.NoError(t, err)
+
+	return raw
+}
+
+func messageFrom(msgType, piID, fromPrior string) service.DIDCommMsgMap {
+	return service.NewDIDCommMsgMap(struct {
+		ID        string           `json:"@id"`
+		Type      string           `json:"@type"`
+		Thread    decorator.Thread `json:"~thread"`
+		FromPrior string           `json:"from_prior,omitempty"`
+	}{ID: "message-id", Type: msgType, Thread: decorator.Thread{ID: piID}, FromPrior: fromPrior})
+}
+
+// nolint: funlen
+func TestService_Connection(t *testing.T) {
+	const (
+		connID = "connection-id"
+		newBob = "Bob-rotated"
+	)
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	registry := &mockvdri.MockVDRIRegistry{
+		ResolveFunc: func(didID string, _ ...vdriapi.ResolveOpts) (*did.Doc, error) {
+			if didID != Bob {
+				return nil, vdriapi.ErrNotFound
+			}
+
+			return &did.Doc{ID: Bob, PublicKey: []did.PublicKey{{
+				ID: Bob + "#key-1", Type: "Ed25519VerificationKey2018", Value: pubKey,
+			}}}, nil
+		},
+	}
+
+	newService := func(t *testing.T,
+		ctrl *gomock.Controller) (*Service, *serviceMocks.MockMessenger, *connection.Recorder) {
+		t.Helper()
+
+		storageProvider, transientStorage := mem.NewProvider(), mem.NewProvider()
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+
+		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider.EXPECT().Messenger().Return(messenger)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(transientStorage).AnyTimes()
+		provider.EXPECT().VDRIRegistry().Return(registry)
+		provider.EXPECT().JSONLDDocumentLoader().Return(nil)
+		provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV1)
+		provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{})
+		provider.EXPECT().Scheduler().Return(nil)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		recorder, err := connection.NewRecorder(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, recorder.SaveConnectionRecord(&connection.Record{
+			ConnectionID: connID, State: "completed", MyDID: Alice, TheirDID: Bob,
+		}))
+
+		return svc, messenger, recorder
+	}
+
+	receiveMessage := func(t *testing.T, svc *Service, msg service.DIDCommMsgMap, theirDID string) {
+		t.Helper()
+
+		actions := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(actions))
+
+		_, err := svc.HandleInbound(msg, Alice, theirDID)
+		require.NoError(t, err)
+
+		select {
+		case action := <-actions:
+			props, ok := action.Properties.(*eventProps)
+			require.True(t, ok)
+			require.Equal(t, connID, props.ConnectionID())
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		pending, err := svc.Actions()
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, connID, pending[0].ConnectionID)
+	}
+
+	t.Run("exchange associated with the connection", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger, _ := newService(t, ctrl)
+
+		piID := sendRequest(t, svc, messenger, nil)
+
+		receiveMessage(t, svc, messageFrom(PresentationMsgType, piID, ""), Bob)
+	})
+
+	t.Run("thread released once done", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger, _ := newService(t, ctrl)
+
+		piID := sendRequest(t, svc, messenger, nil)
+
+		th, err := svc.threads.Get(piID)
+		require.NoError(t, err)
+		require.Equal(t, connID, th.ConnectionID)
+
+		require.NoError(t, svc.RegisterActionEvent(make(chan service.DIDCommAction)))
+
+		_, err = svc.HandleInbound(messageFrom(ProblemReportMsgType, piID, ""), Alice, Bob)
+		require.NoError(t, err)
+
+		th, err = svc.threads.Get(piID)
+		require.NoError(t, err)
+		require.Nil(t, th)
+	})
+
+	t.Run("message from another DID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger, _ := newService(t, ctrl)
+
+		piID := sendRequest(t, svc, messenger, nil)
+
+		require.NoError(t, svc.RegisterActionEvent(make(chan service.DIDCommAction)))
+
+		_, err := svc.HandleInbound(messageFrom(PresentationMsgType, piID, ""), Alice, newBob)
+		require.EqualError(t, err, "associate connection: DID "+newBob+" is not a party of the thread "+piID)
+	})
+
+	t.Run("DID rotation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger, recorder := newService(t, ctrl)
+
+		piID := sendRequest(t, svc, messenger, nil)
+
+		fromPrior := fromPriorJWT(t, privKey, &decorator.FromPrior{ISS: Bob, SUB: newBob})
+
+		receiveMessage(t, svc, messageFrom(PresentationMsgType, piID, fromPrior), newBob)
+
+		// the connection refers to the new DID
+		record, err := recorder.GetConnectionRecord(connID)
+		require.NoError(t, err)
+		require.Equal(t, newBob, record.TheirDID)
+
+		id, err := recorder.GetConnectionIDByDIDs(Alice, newBob)
+		require.NoError(t, err)
+		require.Equal(t, connID, id)
+
+		// the connection is no longer found by the prior DID
+		_, err = recorder.GetConnectionIDByDIDs(Alice, Bob)
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		// the thread is bound to the new DID
+		th, err := svc.threads.Get(piID)
+		require.NoError(t, err)
+		require.Equal(t, &rotation.Thread{ConnectionID: connID, MyDID: Alice, TheirDID: newBob}, th)
+	})
+
+	t.Run("DID rotation of the first message", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, _, _ := newService(t, ctrl)
+
+		fromPrior := fromPriorJWT(t, privKey, &decorator.FromPrior{ISS: Bob, SUB: newBob})
+
+		receiveMessage(t, svc, messageFrom(RequestPresentationMsgType, "thread-id", fromPrior), newBob)
+	})
+
+	t.Run("from_prior not signed by the prior DID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger, _ := newService(t, ctrl)
+
+		piID := sendRequest(t, svc, messenger, nil)
+
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		fromPrior := fromPriorJWT(t, otherKey, &decorator.FromPrior{ISS: Bob, SUB: newBob})
+
+		require.NoError(t, svc.RegisterActionEvent(make(chan service.DIDCommAction)))
+
+		_, err = svc.HandleInbound(messageFrom(PresentationMsgType, piID, fromPrior), Alice, newBob)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "associate connection: from_prior: parse JWT")
+	})
+}
@@ -0,0 +1,295 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	expiryKey = "expiry_%s"
+
+	// how often the expired exchanges are abandoned
+	janitorInterval = 30 * time.Second
+
+	// name of the task of the framework scheduler abandoning the expired exchanges
+	expiryTaskName = "presentproof-expiry"
+)
+
+// expiry keeps the deadline of an in-flight exchange and what is needed to abandon it.
+type expiry struct {
+	PIID     string
+	Expires  time.Time
+	Msg      service.DIDCommMsgMap
+	MyDID    string
+	TheirDID string
+	// Request is true if the deadline is the expires_time of the request-presentation message
+	Request bool `json:",omitempty"`
+}
+
+// SetExchangeTimeout sets the duration after which an exchange without response is abandoned, i.e the exchange
+// transitions to abandoning and a problem-report with the timeout code is sent to the other agent.
+// The expires_time of the ~timing decorator of a request-presentation message takes precedence, the
+// expired-request code is then reported.
+// Zero (the default) disables the timeout, only the exchanges with an expires_time are then abandoned.
+func (s *Service) SetExchangeTimeout(timeout time.Duration) {
+	s.expiryMu.Lock()
+	defer s.expiryMu.Unlock()
+
+	s.timeout = timeout
+}
+
+func (s *Service) exchangeTimeout() time.Duration {
+	s.expiryMu.RLock()
+	defer s.expiryMu.RUnlock()
+
+	return s.timeout
+}
+
+// expiryEnabled returns true if the deadlines of the exchanges may be tracked
+func (s *Service) expiryEnabled() bool {
+	s.expiryMu.RLock()
+	defer s.expiryMu.RUnlock()
+
+	return s.timeout > 0 || s.tracking
+}
+
+// trackExpiry updates the deadline of the exchange after its state was persisted: the exchange expires
+// at the expires_time of the request-presentation message or when no response is received within the timeout.
+func (s *Service) trackExpiry(md *metaData, current state) error {
+	switch current.Name() {
+	case stateNameDone, stateNameAbandoning:
+		if !s.expiryEnabled() {
+			return nil
+		}
+
+		// the exchange is over
+		return s.store.Delete(fmt.Sprintf(expiryKey, md.PIID))
+	case stateNameRequestSent, stateNameRequestReceived:
+		if expires := requestExpiresTime(md, current); !expires.IsZero() {
+			return s.saveExpiry(md, expires, true)
+		}
+	}
+
+	// the other agent is expected to respond within the timeout
+	if timeout := s.exchangeTimeout(); timeout > 0 {
+		return s.saveExpiry(md, time.Now().Add(timeout), false)
+	}
+
+	return nil
+}
+
+// requestExpiresTime returns the expires_time of the ~timing decorator of the request-presentation message.
+func requestExpiresTime(md *metaData, current state) time.Time {
+	if current.Name() == stateNameRequestSent && canReplyTo(md.Msg) {
+		if md.request == nil || md.request.Timing == nil {
+			return time.Time{}
+		}
+
+		return md.request.Timing.ExpiresTime
+	}
+
+	var request = RequestPresentation{}
+	if err := md.Msg.Decode(&request); err != nil || request.Timing == nil {
+		return time.Time{}
+	}
+
+	return request.Timing.ExpiresTime
+}
+
+func (s *Service) saveExpiry(md *metaData, expires time.Time, request bool) error {
+	src, err := json.Marshal(expiry{
+		PIID:     md.PIID,
+		Expires:  expires,
+		Msg:      md.Msg,
+		MyDID:    md.MyDID,
+		TheirDID: md.TheirDID,
+		Request:  request,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal expiry: %w", err)
+	}
+
+	s.expiryMu.Lock()
+	s.tracking = true
+	s.expiryMu.Unlock()
+
+	return s.store.Put(fmt.Sprintf(expiryKey, md.PIID), src)
+}
+
+// restoreTracking tracks the deadlines persisted before the agent restarted, whether a timeout is set or not.
+func (s *Service) restoreTracking() {
+	records := s.store.Iterator(fmt.Sprintf(expiryKey, ""), fmt.Sprintf(expiryKey, storage.EndKeySuffix))
+	defer records.Release()
+
+	if records.Next() {
+		s.expiryMu.Lock()
+		s.tracking = true
+		s.expiryMu.Unlock()
+	}
+}
+
+// startJanitor periodically abandons the expired exchanges until the service is closed.
+func (s *Service) startJanitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := s.abandonExpiredTask(now); err != nil {
+				logger.Errorf("abandon expired exchanges: %s", err)
+			}
+		case <-s.janitorDone:
+			return
+		}
+	}
+}
+
+// Close stops the janitor of the expired exchanges, the deadlines remain persisted and the expired exchanges
+// are abandoned by the next instance of the service.
+func (s *Service) Close() error {
+	var err error
+
+	s.closeOnce.Do(func() {
+		if s.scheduler == nil {
+			close(s.janitorDone)
+
+			return
+		}
+
+		// the task is already stopped if the scheduler was stopped
+		err = s.scheduler.Unregister(expiryTaskName)
+		if errors.Is(err, scheduler.ErrTaskNotFound) {
+			err = nil
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("unschedule expiry task: %w", err)
+	}
+
+	return nil
+}
+
+// abandonExpiredTask is the periodic task abandoning the expired exchanges.
+func (s *Service) abandonExpiredTask(now time.Time) error {
+	s.restoreOnce.Do(s.restoreTracking)
+
+	if !s.expiryEnabled() {
+		return nil
+	}
+
+	return s.abandonExpired(now)
+}
+
+// abandonExpired transitions the exchanges expired at the given time to abandoning. The exchanges whose action
+// is paused (see ActionPause) are not abandoned, their deadline is tracked again once the action is continued.
+func (s *Service) abandonExpired(now time.Time) error {
+	expired, err := s.expired(now)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range expired {
+		paused, err := s.pausedAction(e.PIID)
+		if err != nil {
+			return err
+		}
+
+		if paused {
+			continue
+		}
+
+		if err := s.store.Delete(fmt.Sprintf(expiryKey, e.PIID)); err != nil {
+			return fmt.Errorf("delete expiry: %w", err)
+		}
+
+		stateName, err := s.currentStateName(e.PIID)
+		if err != nil {
+			return fmt.Errorf("current state name: %w", err)
+		}
+
+		if stateName == stateNameDone || stateName == stateNameAbandoning {
+			continue
+		}
+
+		// the pending action (if any) can no longer be continued
+		err = s.deleteTransitionalPayload(e.PIID)
+		if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+			return fmt.Errorf("delete transitional payload: %w", err)
+		}
+
+		code := codeTimeoutError
+		if e.Request {
+			code = CodeExpiredRequest
+		}
+
+		s.processCallback(&metaData{
+			transitionalPayload: transitionalPayload{
+				PIID:      e.PIID,
+				StateName: stateNameAbandoning,
+				Msg:       e.Msg,
+				MyDID:     e.MyDID,
+				TheirDID:  e.TheirDID,
+			},
+			state:            &abandoning{Code: code},
+			msgClone:         e.Msg.Clone(),
+			presentationOpts: s.presentationOpts(),
+			credentialOpts:   s.credentialOpts(),
+			verificationPool: s.verificationPool,
+		})
+	}
+
+	return nil
+}
+
+// pausedAction returns true if the action of the exchange is paused, the pause is persisted with the action.
+func (s *Service) pausedAction(piID string) (bool, error) {
+	tPayload, err := s.getTransitionalPayload(piID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("get transitional payload: %w", err)
+	}
+
+	return tPayload.ResumeToken != "", nil
+}
+
+// expired returns the exchanges expired at the given time.
+func (s *Service) expired(now time.Time) ([]*expiry, error) {
+	records := s.store.Iterator(fmt.Sprintf(expiryKey, ""), fmt.Sprintf(expiryKey, storage.EndKeySuffix))
+	defer records.Release()
+
+	var expired []*expiry
+
+	for records.Next() {
+		var e *expiry
+		if err := json.Unmarshal(records.Value(), &e); err != nil {
+			return nil, fmt.Errorf("unmarshal expiry: %w", err)
+		}
+
+		if !now.Before(e.Expires) {
+			expired = append(expired, e)
+		}
+	}
+
+	if records.Error() != nil {
+		return nil, records.Error()
+	}
+
+	return expired, nil
+}
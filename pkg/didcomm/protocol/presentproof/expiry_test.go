@@ -0,0 +1,307 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	presentproofMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/protocol/presentproof"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func newMemStoreService(t *testing.T, ctrl *gomock.Controller) (*Service, *serviceMocks.MockMessenger) {
+	t.Helper()
+
+	messenger := serviceMocks.NewMockMessenger(ctrl)
+
+	provider := newMockProvider(ctrl)
+	provider.EXPECT().Messenger().Return(messenger)
+	provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+	provider.EXPECT().VDRIRegistry().Return(nil)
+
+	svc, err := New(provider)
+	require.NoError(t, err)
+
+	return svc, messenger
+}
+
+// sendRequest sends a request-presentation message and returns the ID of the exchange
+func sendRequest(t *testing.T, svc *Service, messenger *serviceMocks.MockMessenger, timing *decorator.Timing) string {
+	t.Helper()
+
+	messenger.EXPECT().Send(gomock.Any(), Alice, Bob).Return(nil)
+
+	msg := service.NewDIDCommMsgMap(RequestPresentation{Type: RequestPresentationMsgType, Timing: timing})
+
+	_, err := svc.HandleInbound(msg, Alice, Bob)
+	require.NoError(t, err)
+
+	return msg.ID()
+}
+
+func TestService_Expiry(t *testing.T) {
+	t.Run("request-presentation expires_time", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger := newMemStoreService(t, ctrl)
+
+		events := make(chan service.StateMsg, 10)
+		require.NoError(t, svc.RegisterMsgEvent(events))
+
+		expires := time.Now().Add(time.Hour)
+
+		piID := sendRequest(t, svc, messenger, &decorator.Timing{ExpiresTime: expires})
+
+		// the exchange is not expired yet
+		require.NoError(t, svc.abandonExpired(expires.Add(-time.Second)))
+
+		reported := make(chan struct{})
+
+		messenger.EXPECT().ReplyToNested(piID, gomock.Any(), Alice, Bob).
+			Do(func(_ string, msg service.DIDCommMsgMap, _, _ string) error {
+				defer close(reported)
+
+				r := &model.ProblemReport{}
+				require.NoError(t, msg.Decode(r))
+				require.Equal(t, ProblemReportMsgType, r.Type)
+				require.Equal(t, CodeExpiredRequest, r.Description.Code)
+
+				return nil
+			})
+
+		require.NoError(t, svc.abandonExpired(expires))
+
+		select {
+		case <-reported:
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+
+		var states []string
+
+		for len(states) < 4 {
+			select {
+			case e := <-events:
+				if e.StateID != stateNameRequestSent {
+					states = append(states, fmt.Sprintf("%d %s", e.Type, e.StateID))
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timeout")
+			}
+		}
+
+		require.Equal(t, []string{
+			fmt.Sprintf("%d abandoning", service.PreState), fmt.Sprintf("%d abandoning", service.PostState),
+			fmt.Sprintf("%d done", service.PreState), fmt.Sprintf("%d done", service.PostState),
+		}, states)
+
+		// the exchange is no longer tracked
+		expired, err := svc.expired(expires.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, expired)
+	})
+
+	t.Run("exchange timeout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger := newMemStoreService(t, ctrl)
+		svc.SetExchangeTimeout(time.Minute)
+
+		piID := sendRequest(t, svc, messenger, nil)
+
+		expired, err := svc.expired(time.Now())
+		require.NoError(t, err)
+		require.Empty(t, expired)
+
+		expired, err = svc.expired(time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+		require.Equal(t, piID, expired[0].PIID)
+		require.Equal(t, Alice, expired[0].MyDID)
+		require.Equal(t, Bob, expired[0].TheirDID)
+	})
+
+	t.Run("completed exchange is not tracked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger := newMemStoreService(t, ctrl)
+		svc.SetExchangeTimeout(time.Minute)
+
+		piID := sendRequest(t, svc, messenger, nil)
+
+		require.NoError(t, svc.RegisterActionEvent(make(chan service.DIDCommAction)))
+
+		// the Prover declines the request
+		_, err := svc.HandleInbound(service.NewDIDCommMsgMap(struct {
+			ID     string           `json:"@id"`
+			Type   string           `json:"@type"`
+			Thread decorator.Thread `json:"~thread"`
+		}{ID: "problem-report", Type: ProblemReportMsgType, Thread: decorator.Thread{ID: piID}}), Alice, Bob)
+		require.NoError(t, err)
+
+		expired, err := svc.expired(time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, expired)
+	})
+
+	t.Run("completed exchange is skipped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger := newMemStoreService(t, ctrl)
+
+		piID := sendRequest(t, svc, messenger, &decorator.Timing{ExpiresTime: time.Now()})
+
+		// the state was persisted, e.g by another instance, without the expiry being deleted
+		require.NoError(t, svc.saveStateName(piID, stateNameDone))
+
+		require.NoError(t, svc.abandonExpired(time.Now()))
+
+		expired, err := svc.expired(time.Now())
+		require.NoError(t, err)
+		require.Empty(t, expired)
+	})
+
+	t.Run("paused exchange is not abandoned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, messenger := newMemStoreService(t, ctrl)
+
+		piID := sendRequest(t, svc, messenger, &decorator.Timing{ExpiresTime: time.Now()})
+
+		require.NoError(t, svc.saveTransitionalPayload(piID, transitionalPayload{PIID: piID, ResumeToken: "token"}))
+		require.NoError(t, svc.abandonExpired(time.Now()))
+
+		// the deadline is kept
+		expired, err := svc.expired(time.Now())
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+	})
+
+	t.Run("deadlines persisted before a restart", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		storageProvider := mem.NewProvider()
+		store, err := storageProvider.OpenStore(Name)
+		require.NoError(t, err)
+		record := []byte(`{"PIID":"piID","Expires":"2100-01-01T00:00:00Z"}`)
+		require.NoError(t, store.Put(fmt.Sprintf(expiryKey, "piID"), record))
+
+		provider := newMockProvider(ctrl)
+		provider.EXPECT().Messenger().Return(nil)
+		provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+		provider.EXPECT().VDRIRegistry().Return(nil)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+		require.False(t, svc.expiryEnabled())
+
+		require.NoError(t, svc.abandonExpiredTask(time.Now()))
+		require.True(t, svc.expiryEnabled())
+		require.NoError(t, svc.Close())
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		svc := &Service{store: &mockstorage.MockStore{Store: map[string][]byte{
+			fmt.Sprintf(expiryKey, "piID"): []byte("{"),
+		}}}
+
+		require.EqualError(t, svc.abandonExpired(time.Now()), "unmarshal expiry: unexpected end of JSON input")
+
+		svc = &Service{store: &mockstorage.MockStore{ErrItr: errors.New("iterator error")}}
+
+		require.EqualError(t, svc.abandonExpired(time.Now()), "iterator error")
+
+		svc = &Service{store: &mockstorage.MockStore{
+			Store: map[string][]byte{
+				fmt.Sprintf(expiryKey, "piID"): []byte(`{"PIID":"piID"}`),
+			},
+			ErrGet: errors.New("get error"),
+		}}
+
+		require.EqualError(t, svc.abandonExpired(time.Now()), "get transitional payload: store get: get error")
+	})
+}
+
+func TestService_Close(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, _ := newMemStoreService(t, ctrl)
+
+	require.NoError(t, svc.Close())
+	require.NoError(t, svc.Close())
+
+	select {
+	case <-svc.janitorDone:
+	default:
+		t.Fatal("janitor not stopped")
+	}
+}
+
+func TestService_ExpiryTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := scheduler.New()
+	defer s.Stop()
+
+	// the scheduler of the framework is shared
+	newProvider := func() *presentproofMocks.MockProvider {
+		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider.EXPECT().Messenger().Return(nil)
+		provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
+		provider.EXPECT().VDRIRegistry().Return(nil)
+		provider.EXPECT().JSONLDDocumentLoader().Return(nil).AnyTimes()
+		provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV1).AnyTimes()
+		provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{}).AnyTimes()
+		provider.EXPECT().Scheduler().Return(s).AnyTimes()
+
+		return provider
+	}
+
+	svc, err := New(newProvider())
+	require.NoError(t, err)
+	require.Equal(t, []string{expiryTaskName}, s.Tasks())
+
+	// the expiry is not tracked
+	require.NoError(t, s.Run(expiryTaskName))
+
+	svc.SetExchangeTimeout(time.Hour)
+	require.NoError(t, s.Run(expiryTaskName))
+
+	_, err = New(newProvider())
+	require.Contains(t, err.Error(), "schedule expiry task")
+
+	require.NoError(t, svc.Close())
+	require.Empty(t, s.Tasks())
+
+	svc, err = New(newProvider())
+	require.NoError(t, err)
+
+	// the task was stopped with the scheduler
+	s.Stop()
+	require.NoError(t, svc.Close())
+}
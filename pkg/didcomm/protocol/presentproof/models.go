@@ -18,6 +18,11 @@ type ProposePresentation struct {
 	Comment string `json:"comment,omitempty"`
 	// PresentationProposal is a JSON-LD object that represents the presentation example that Prover wants to provide.
 	PresentationProposal PresentationPreview `json:"presentation_proposal,omitempty"`
+	// MultipleAvailable is the number of credentials matching the proposal the Prover holds, when it holds
+	// several of them: the Verifier may then request one, several or all of them (see RequestedCount).
+	MultipleAvailable int `json:"multiple_available,omitempty"`
+	// FromPrior is the from_prior JWT of the Prover rotating its DID, see decorator.FromPrior.
+	FromPrior string `json:"from_prior,omitempty"`
 }
 
 // RequestPresentation describes values that need to be revealed and predicates that need to be fulfilled.
@@ -26,18 +31,44 @@ type RequestPresentation struct {
 	// Comment is a field that provides some human readable information about the proposed presentation.
 	// TODO: Should follow DIDComm conventions for l10n. [Issue #1300]
 	Comment string `json:"comment,omitempty"`
+	// Formats declare the format of each attachment of RequestPresentations (see package attachformat),
+	// the attachments of a request declaring no format are not negotiated.
+	Formats []decorator.AttachmentFormat `json:"formats,omitempty"`
 	// RequestPresentations is a slice of attachments defining the acceptable formats for the presentation.
+	// Several presentations may be requested in a single exchange, the attachments must then have unique IDs.
 	RequestPresentations []decorator.Attachment `json:"request_presentations~attach,omitempty"`
+	// Timing allows the Verifier to set the time after which the exchange is abandoned if no presentation
+	// was received (expires_time).
+	Timing *decorator.Timing `json:"~timing,omitempty"`
+	// FromPrior is the from_prior JWT of the Verifier rotating its DID, see decorator.FromPrior.
+	FromPrior string `json:"from_prior,omitempty"`
+	// ReasonAttach is set when the Verifier requests the presentations again in the same thread after a failed
+	// verification, the attachment (ReasonAttachmentID) contains the RerequestReason.
+	ReasonAttach []decorator.Attachment `json:"reason~attach,omitempty"`
+	// RequestedCount is the number of matching credentials the Prover presents when it holds several of them
+	// (see ProposePresentation.MultipleAvailable), or RequestAllAvailable. A single credential is presented
+	// if it is not set.
+	RequestedCount int `json:"requested_count,omitempty"`
 }
 
+// RequestAllAvailable is the RequestedCount of the requests asking the Prover to present all the matching
+// credentials it holds.
+const RequestAllAvailable = -1
+
 // Presentation is a response to a RequestPresentation message and contains signed presentations.
 type Presentation struct {
 	Type string `json:"@type,omitempty"`
 	// Comment is a field that provides some human readable information about the proposed presentation.
 	// TODO: Should follow DIDComm conventions for l10n. [Issue #1300]
 	Comment string `json:"comment,omitempty"`
+	// Formats declare the format of each attachment of Presentations (see package attachformat),
+	// the attachments of a message declaring no format are verifiable presentations.
+	Formats []decorator.AttachmentFormat `json:"formats,omitempty"`
 	// Presentations is a slice of attachments containing the presentation in the requested format(s).
+	// The ID of each attachment matches the ID of the requested presentation it answers.
 	Presentations []decorator.Attachment `json:"presentations~attach,omitempty"`
+	// FromPrior is the from_prior JWT of the Prover rotating its DID, see decorator.FromPrior.
+	FromPrior string `json:"from_prior,omitempty"`
 }
 
 // PresentationPreview is used to construct a preview of the data for the presentation.
@@ -63,3 +94,29 @@ type Predicate struct {
 	Predicate string `json:"predicate"`
 	Threshold string `json:"threshold"`
 }
+
+// VerificationResult is the result of the verification of one of the presentations of a Presentation message.
+type VerificationResult struct {
+	// ID is the ID of the presentation attachment, matching the ID of the requested presentation.
+	ID string `json:"id,omitempty"`
+	// Verified is true if the presentation was provided and is valid.
+	Verified bool `json:"verified"`
+	// Error describes why the presentation is invalid or missing.
+	Error string `json:"error,omitempty"`
+	// Transient is true if the presentation could not be verified because of a transient DID resolution
+	// error (e.g a network timeout) which persisted after the retries, rather than because it is invalid.
+	Transient bool `json:"transient,omitempty"`
+}
+
+// ReasonAttachmentID is the ID of the attachment of the RequestPresentation message requesting
+// the presentations again, after a failed verification.
+const ReasonAttachmentID = "reason"
+
+// RerequestReason is the content of the reason attachment of the RequestPresentation message requesting
+// the presentations again: why the Verifier rejected the received presentations.
+type RerequestReason struct {
+	// Reason is the reason given by the Verifier.
+	Reason string `json:"reason,omitempty"`
+	// VerificationResults are the verification results of the rejected presentations.
+	VerificationResults []VerificationResult `json:"verification_results,omitempty"`
+}
@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func newPauseService(t *testing.T, ctrl *gomock.Controller, storageProvider storage.Provider) *Service {
+	t.Helper()
+
+	provider := newMockProvider(ctrl)
+	provider.EXPECT().Messenger().Return(nil)
+	provider.EXPECT().StorageProvider().Return(storageProvider).AnyTimes()
+	provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
+
+	svc, err := New(provider)
+	require.NoError(t, err)
+
+	return svc
+}
+
+func saveRequestAction(t *testing.T, svc *Service, piID string) {
+	t.Helper()
+
+	require.NoError(t, svc.saveTransitionalPayload(piID, transitionalPayload{
+		PIID:      piID,
+		StateName: stateNameRequestReceived,
+		Msg:       service.NewDIDCommMsgMap(RequestPresentation{Type: RequestPresentationMsgType}),
+		MyDID:     Alice,
+		TheirDID:  Bob,
+	}))
+}
+
+func TestService_ActionPause(t *testing.T) {
+	t.Run("action is resumed with its token after a restart", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		storageProvider := mem.NewProvider()
+
+		svc := newPauseService(t, ctrl, storageProvider)
+		saveRequestAction(t, svc, "piID")
+
+		token, err := svc.ActionPause("piID")
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+
+		// pausing the action again returns the same token
+		again, err := svc.ActionPause("piID")
+		require.NoError(t, err)
+		require.Equal(t, token, again)
+
+		actions, err := svc.Actions()
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		require.Equal(t, token, actions[0].ResumeToken)
+
+		require.True(t, errors.Is(svc.ActionContinue("piID", nil), ErrActionPaused))
+		require.True(t, errors.Is(svc.ActionStop("piID", nil), ErrActionPaused))
+
+		// the agent is restarted
+		svc = newPauseService(t, ctrl, storageProvider)
+
+		action, err := svc.ActionResume(token)
+		require.NoError(t, err)
+		require.Equal(t, "piID", action.PIID)
+		require.Equal(t, RequestPresentationMsgType, action.Msg.Type())
+		require.Empty(t, action.ResumeToken)
+
+		actions, err = svc.Actions()
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		require.Empty(t, actions[0].ResumeToken)
+
+		// the token can only be used once
+		_, err = svc.ActionResume(token)
+		require.True(t, errors.Is(err, ErrUnknownResumeToken))
+	})
+
+	t.Run("DIDCommAction of a paused action is ignored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := newPauseService(t, ctrl, mem.NewProvider())
+		saveRequestAction(t, svc, "piID")
+
+		tPayload, err := svc.getTransitionalPayload("piID")
+		require.NoError(t, err)
+
+		action := svc.newDIDCommActionMsg(&metaData{
+			transitionalPayload: *tPayload,
+			state:               stateFromName(tPayload.StateName),
+			msgClone:            tPayload.Msg.Clone(),
+		})
+
+		_, err = svc.ActionPause("piID")
+		require.NoError(t, err)
+
+		action.Continue(nil)
+		action.Stop(nil)
+
+		actions, err := svc.Actions()
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		_, err := newPauseService(t, ctrl, mem.NewProvider()).ActionPause("piID")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get transitional payload")
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		_, err := newPauseService(t, ctrl, mem.NewProvider()).ActionResume("token")
+		require.True(t, errors.Is(err, ErrUnknownResumeToken))
+	})
+
+	t.Run("exchange abandoned while the action is paused", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := newPauseService(t, ctrl, mem.NewProvider())
+		saveRequestAction(t, svc, "piID")
+
+		token, err := svc.ActionPause("piID")
+		require.NoError(t, err)
+
+		require.NoError(t, svc.deleteTransitionalPayload("piID"))
+
+		_, err = svc.ActionResume(token)
+		require.True(t, errors.Is(err, ErrUnknownResumeToken))
+
+		// the resume token is deleted
+		iter := svc.store.Iterator("resumeToken_", "resumeToken_"+storage.EndKeySuffix)
+		defer iter.Release()
+
+		require.False(t, iter.Next())
+	})
+}
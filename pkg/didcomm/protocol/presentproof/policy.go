@@ -0,0 +1,129 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+)
+
+// ErrUnknownDID is the reason given when an action from an unknown DID is declined by AutoDeclineUnknownDIDs.
+var ErrUnknownDID = errors.New("unknown DID")
+
+// Decision describes how an action is executed by an ActionPolicy.
+type Decision struct {
+	opt  Opt
+	err  error
+	stop bool
+}
+
+// ContinueWith returns a decision proceeding with the action, the same way ActionContinue does.
+func ContinueWith(opt Opt) *Decision {
+	return &Decision{opt: opt}
+}
+
+// StopWith returns a decision stopping the action with the given reason, the same way ActionStop does.
+func StopWith(reason error) *Decision {
+	return &Decision{err: reason, stop: true}
+}
+
+// ActionPolicy executes an inbound action (propose-presentation, request-presentation or presentation)
+// without an action event being consumed by the application.
+// A policy returns nil when it does not apply to the action; when no registered policy applies,
+// the action event is triggered as usual.
+type ActionPolicy func(msg service.DIDCommMsgMap, myDID, theirDID string) *Decision
+
+// PresentationSupplier supplies the presentation answering a request-presentation message.
+type PresentationSupplier func(req *RequestPresentation, myDID, theirDID string) (*Presentation, error)
+
+// AutoAcceptRequestPresentation answers the request-presentation messages with the presentation
+// provided by the supplier. The request is declined if the supplier returns an error.
+func AutoAcceptRequestPresentation(supplier PresentationSupplier) ActionPolicy {
+	return func(msg service.DIDCommMsgMap, myDID, theirDID string) *Decision {
+		if msg.Type() != RequestPresentationMsgType {
+			return nil
+		}
+
+		req := &RequestPresentation{}
+		if err := msg.Decode(req); err != nil {
+			return StopWith(fmt.Errorf("decode request presentation: %w", err))
+		}
+
+		presentation, err := supplier(req, myDID, theirDID)
+		if err != nil {
+			return StopWith(err)
+		}
+
+		return ContinueWith(WithPresentation(presentation))
+	}
+}
+
+// AutoAcceptPresentation accepts the presentation messages received by the verifier.
+func AutoAcceptPresentation() ActionPolicy {
+	return func(msg service.DIDCommMsgMap, _, _ string) *Decision {
+		if msg.Type() != PresentationMsgType {
+			return nil
+		}
+
+		return ContinueWith(nil)
+	}
+}
+
+// AutoDeclineUnknownDIDs declines the actions received from the DIDs for which isKnown returns false.
+// It is meant to be registered before the accepting policies.
+func AutoDeclineUnknownDIDs(isKnown func(theirDID string) bool) ActionPolicy {
+	return func(_ service.DIDCommMsgMap, _, theirDID string) *Decision {
+		if isKnown(theirDID) {
+			return nil
+		}
+
+		return StopWith(ErrUnknownDID)
+	}
+}
+
+// AddActionPolicies registers policies executing the inbound actions automatically.
+// Policies are evaluated in the registration order and the first one deciding on an action wins.
+func (s *Service) AddActionPolicies(policies ...ActionPolicy) {
+	s.policiesMu.Lock()
+	defer s.policiesMu.Unlock()
+
+	s.policies = append(s.policies, policies...)
+}
+
+func (s *Service) hasActionPolicies() bool {
+	s.policiesMu.RLock()
+	defer s.policiesMu.RUnlock()
+
+	return len(s.policies) > 0
+}
+
+// decide returns the decision of the first policy applying to the action, nil if none does.
+func (s *Service) decide(md *metaData) *Decision {
+	s.policiesMu.RLock()
+	defer s.policiesMu.RUnlock()
+
+	for _, policy := range s.policies {
+		if decision := policy(md.Msg, md.MyDID, md.TheirDID); decision != nil {
+			return decision
+		}
+	}
+
+	return nil
+}
+
+// applyDecision executes the action the same way the Continue and Stop functions of an action event do.
+func (s *Service) applyDecision(md *metaData, decision *Decision) {
+	if decision.stop {
+		md.err = customError{error: causeError{error: decision.err, cause: ErrPolicyRejected}}
+	} else if decision.opt != nil {
+		decision.opt(md)
+	}
+
+	s.processCallback(md)
+}
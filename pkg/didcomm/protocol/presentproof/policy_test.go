@@ -0,0 +1,217 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
+	storageMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+)
+
+func TestActionPolicies(t *testing.T) {
+	request := randomInboundMessage(RequestPresentationMsgType)
+	presentation := randomInboundMessage(PresentationMsgType)
+
+	t.Run("auto accept request presentation", func(t *testing.T) {
+		supplier := func(req *RequestPresentation, myDID, theirDID string) (*Presentation, error) {
+			require.Equal(t, RequestPresentationMsgType, req.Type)
+			require.Equal(t, Alice, myDID)
+			require.Equal(t, Bob, theirDID)
+
+			return &Presentation{Comment: "auto"}, nil
+		}
+
+		policy := AutoAcceptRequestPresentation(supplier)
+
+		require.Nil(t, policy(presentation, Alice, Bob))
+
+		decision := policy(request, Alice, Bob)
+		require.NotNil(t, decision)
+		require.False(t, decision.stop)
+
+		md := &metaData{}
+		decision.opt(md)
+		require.Equal(t, "auto", md.presentation.Comment)
+	})
+
+	t.Run("auto accept request presentation (supplier error)", func(t *testing.T) {
+		policy := AutoAcceptRequestPresentation(func(*RequestPresentation, string, string) (*Presentation, error) {
+			return nil, errors.New("no credentials")
+		})
+
+		decision := policy(request, Alice, Bob)
+		require.True(t, decision.stop)
+		require.EqualError(t, decision.err, "no credentials")
+	})
+
+	t.Run("auto accept request presentation (decode error)", func(t *testing.T) {
+		policy := AutoAcceptRequestPresentation(nil)
+
+		decision := policy(service.DIDCommMsgMap{
+			"@type":   RequestPresentationMsgType,
+			"comment": []int{1},
+		}, Alice, Bob)
+		require.True(t, decision.stop)
+		require.Contains(t, decision.err.Error(), "decode request presentation")
+	})
+
+	t.Run("auto accept presentation", func(t *testing.T) {
+		policy := AutoAcceptPresentation()
+
+		require.Nil(t, policy(request, Alice, Bob))
+		require.Equal(t, ContinueWith(nil), policy(presentation, Alice, Bob))
+	})
+
+	t.Run("auto decline unknown DIDs", func(t *testing.T) {
+		policy := AutoDeclineUnknownDIDs(func(theirDID string) bool {
+			return theirDID == Bob
+		})
+
+		require.Nil(t, policy(request, Alice, Bob))
+		require.Equal(t, StopWith(ErrUnknownDID), policy(request, Alice, "Eve"))
+	})
+}
+
+func TestService_HandleInboundWithPolicies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storageMocks.NewMockStore(ctrl)
+	expectThreads(store)
+
+	storeProvider := storageMocks.NewMockProvider(ctrl)
+	storeProvider.EXPECT().OpenStore(Name).Return(store, nil).AnyTimes()
+	storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
+
+	messenger := serviceMocks.NewMockMessenger(ctrl)
+
+	provider := newMockProvider(ctrl)
+	provider.EXPECT().Messenger().Return(messenger).AnyTimes()
+	provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
+	provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
+
+	t.Run("Receive Request Presentation (auto accept)", func(t *testing.T) {
+		var done = make(chan struct{})
+
+		messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any()).
+			Do(func(_ string, msg service.DIDCommMsgMap) error {
+				r := &Presentation{}
+				require.NoError(t, msg.Decode(r))
+				require.Equal(t, PresentationMsgType, r.Type)
+
+				return nil
+			})
+
+		store.EXPECT().Get(gomock.Any()).Return(nil, storage.ErrDataNotFound)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			require.Equal(t, "request-received", string(name))
+
+			return nil
+		})
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			defer close(done)
+
+			require.Equal(t, "presentation-sent", string(name))
+
+			return nil
+		})
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		svc.AddActionPolicies(AutoAcceptRequestPresentation(
+			func(*RequestPresentation, string, string) (*Presentation, error) {
+				return &Presentation{}, nil
+			},
+		))
+
+		_, err = svc.HandleInbound(randomInboundMessage(RequestPresentationMsgType), Alice, Bob)
+		require.NoError(t, err)
+
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Second):
+			t.Error("timeout")
+		}
+	})
+
+	t.Run("Receive Request Presentation (auto decline)", func(t *testing.T) {
+		var done = make(chan struct{})
+
+		messenger.EXPECT().
+			ReplyToNested(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Do(func(_ string, msg service.DIDCommMsgMap, myDID, theirDID string) error {
+				r := &model.ProblemReport{}
+				require.NoError(t, msg.Decode(r))
+				require.Equal(t, CodePolicyRejected, r.Description.Code)
+
+				return nil
+			})
+
+		store.EXPECT().Get(gomock.Any()).Return(nil, storage.ErrDataNotFound)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			require.Equal(t, "abandoning", string(name))
+
+			return nil
+		})
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			defer close(done)
+
+			require.Equal(t, "done", string(name))
+
+			return nil
+		})
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		ch := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(ch))
+
+		svc.AddActionPolicies(
+			AutoDeclineUnknownDIDs(func(string) bool { return false }),
+			AutoAcceptRequestPresentation(func(*RequestPresentation, string, string) (*Presentation, error) {
+				return &Presentation{}, nil
+			}),
+		)
+
+		_, err = svc.HandleInbound(randomInboundMessage(RequestPresentationMsgType), Alice, Bob)
+		require.NoError(t, err)
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Error("timeout")
+		}
+
+		require.Empty(t, ch)
+	})
+
+	t.Run("No policy applies and no clients", func(t *testing.T) {
+		store.EXPECT().Get(gomock.Any()).Return(nil, storage.ErrDataNotFound)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		svc.AddActionPolicies(AutoAcceptPresentation())
+
+		_, err = svc.HandleInbound(randomInboundMessage(RequestPresentationMsgType), Alice, Bob)
+		require.True(t, errors.Is(err, errNoClients))
+	})
+}
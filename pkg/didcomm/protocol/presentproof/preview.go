@@ -0,0 +1,240 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+)
+
+const (
+	// PresentationDefinitionAttachmentID is the ID of the requested presentation built from a presentation preview.
+	PresentationDefinitionAttachmentID = "presentation-definition-0"
+
+	// credentialSubjectPath is the JSON path of the claims of the credentials in the presentation definitions
+	credentialSubjectPath = "$.credentialSubject."
+)
+
+// PresentationDefinitionRequest is the requested presentation built from the presentation preview of a proposal
+// (dif/presentation-exchange/definitions@v1.0), see RequestPresentationFromPreview. The challenge of the options
+// is set to the proof of the presentation by the Prover.
+type PresentationDefinitionRequest struct {
+	Options                *PresentationOptions    `json:"options,omitempty"`
+	PresentationDefinition *PresentationDefinition `json:"presentation_definition"`
+}
+
+// PresentationOptions are the proof options of the presentation.
+type PresentationOptions struct {
+	Challenge string `json:"challenge,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+}
+
+// PresentationDefinition describes the credentials the presentation must provide, one per input descriptor.
+type PresentationDefinition struct {
+	ID               string             `json:"id"`
+	Name             string             `json:"name,omitempty"`
+	InputDescriptors []*InputDescriptor `json:"input_descriptors"`
+}
+
+// InputDescriptor describes a credential of the presentation: its schemas (any of them) and the fields
+// of its claims. The attributes sharing a referent in the preview are requested by the same input descriptor,
+// they are provided by the same credential. The credential definition of a W3C credential is the ID of its
+// credential schema.
+type InputDescriptor struct {
+	ID          string       `json:"id"`
+	Schema      []*Schema    `json:"schema,omitempty"`
+	Constraints *Constraints `json:"constraints,omitempty"`
+}
+
+// Schema is a credential schema of an input descriptor.
+type Schema struct {
+	URI string `json:"uri"`
+}
+
+// Constraints are the fields the credential of an input descriptor must have.
+type Constraints struct {
+	Fields []*Field `json:"fields"`
+}
+
+// Field is a claim of the credential ($.credentialSubject.<name>), the filter restricts its value,
+// e.g the minimum of a predicate age >= 18.
+type Field struct {
+	Path   []string `json:"path"`
+	Filter *Filter  `json:"filter,omitempty"`
+}
+
+// Filter is the JSON schema of the value of a field, the bounds of the predicates are the only keywords
+// produced from a presentation preview.
+type Filter struct {
+	Minimum          *int `json:"minimum,omitempty"`
+	ExclusiveMinimum *int `json:"exclusiveMinimum,omitempty"`
+	Maximum          *int `json:"maximum,omitempty"`
+	ExclusiveMaximum *int `json:"exclusiveMaximum,omitempty"`
+}
+
+// RequestPresentationFromPreview builds the request presentation answering a proposal: it requests the
+// attributes and predicates of the presentation preview, restricted to the credential definitions of the preview,
+// as a presentation definition (attachformat.DIFPresentationDefinitions) with a fresh challenge.
+func RequestPresentationFromPreview(preview *PresentationPreview, name string) (*RequestPresentation, error) {
+	if preview == nil || len(preview.Attributes)+len(preview.Predicates) == 0 {
+		return nil, errors.New("presentation preview is empty")
+	}
+
+	definition := &PresentationDefinition{
+		ID:               idgen.NewID(),
+		Name:             name,
+		InputDescriptors: attributeDescriptors(preview.Attributes),
+	}
+
+	for i, predicate := range preview.Predicates {
+		filter, err := predicateFilter(&predicate)
+		if err != nil {
+			return nil, fmt.Errorf("predicate %s: %w", predicate.Name, err)
+		}
+
+		definition.InputDescriptors = append(definition.InputDescriptors, &InputDescriptor{
+			ID:     fmt.Sprintf("predicate_%d", i),
+			Schema: schemas(predicate.CredDefID),
+			Constraints: &Constraints{Fields: []*Field{{
+				Path:   []string{credentialSubjectPath + predicate.Name},
+				Filter: filter,
+			}}},
+		})
+	}
+
+	return &RequestPresentation{
+		Formats: []decorator.AttachmentFormat{{
+			AttachID: PresentationDefinitionAttachmentID,
+			Format:   attachformat.DIFPresentationDefinitions,
+		}},
+		RequestPresentations: []decorator.Attachment{{
+			ID:       PresentationDefinitionAttachmentID,
+			MimeType: "application/json",
+			Data: decorator.AttachmentData{JSON: &PresentationDefinitionRequest{
+				Options:                &PresentationOptions{Challenge: idgen.NewID()},
+				PresentationDefinition: definition,
+			}},
+		}},
+	}, nil
+}
+
+// attributeDescriptors groups the attributes sharing a referent, the other attributes are requested one by one.
+func attributeDescriptors(attributes []Attribute) []*InputDescriptor {
+	var (
+		descriptors []*InputDescriptor
+		byReferent  = map[string]*InputDescriptor{}
+	)
+
+	for i, attribute := range attributes {
+		field := &Field{Path: []string{credentialSubjectPath + attribute.Name}}
+
+		if attribute.Referent == "" {
+			descriptors = append(descriptors, &InputDescriptor{
+				ID:          fmt.Sprintf("attribute_%d", i),
+				Schema:      schemas(attribute.CredDefID),
+				Constraints: &Constraints{Fields: []*Field{field}},
+			})
+
+			continue
+		}
+
+		descriptor, ok := byReferent[attribute.Referent]
+		if !ok {
+			descriptor = &InputDescriptor{
+				ID:          attribute.Referent,
+				Schema:      schemas(attribute.CredDefID),
+				Constraints: &Constraints{},
+			}
+
+			byReferent[attribute.Referent] = descriptor
+			descriptors = append(descriptors, descriptor)
+		}
+
+		descriptor.Constraints.Fields = append(descriptor.Constraints.Fields, field)
+	}
+
+	return descriptors
+}
+
+func schemas(credDefID string) []*Schema {
+	if credDefID == "" {
+		return nil
+	}
+
+	return []*Schema{{URI: credDefID}}
+}
+
+// predicateFilter returns the filter of the field of the predicate, e.g a minimum of 18 for age >= 18.
+func predicateFilter(predicate *Predicate) (*Filter, error) {
+	threshold, err := strconv.Atoi(predicate.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("threshold: %w", err)
+	}
+
+	switch predicate.Predicate {
+	case "<":
+		return &Filter{ExclusiveMaximum: &threshold}, nil
+	case "<=":
+		return &Filter{Maximum: &threshold}, nil
+	case ">=":
+		return &Filter{Minimum: &threshold}, nil
+	case ">":
+		return &Filter{ExclusiveMinimum: &threshold}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", predicate.Predicate)
+	}
+}
+
+// proposalPreview returns the presentation preview of a received ProposePresentation message, nil otherwise.
+func proposalPreview(msg service.DIDCommMsg) *PresentationPreview {
+	if msg == nil || msg.Type() != ProposePresentationMsgType {
+		return nil
+	}
+
+	var proposal ProposePresentation
+	if err := msg.Decode(&proposal); err != nil {
+		logger.Warnf("presentation preview: decode: %s", err)
+		return nil
+	}
+
+	return &proposal.PresentationProposal
+}
+
+// countHints returns the number of matching credentials stated by a received ProposePresentation message
+// and the number of credentials requested by a received RequestPresentation message.
+func countHints(msg service.DIDCommMsg) (multipleAvailable, requestedCount int) {
+	if msg == nil {
+		return 0, 0
+	}
+
+	switch msg.Type() {
+	case ProposePresentationMsgType:
+		var proposal ProposePresentation
+		if err := msg.Decode(&proposal); err != nil {
+			logger.Warnf("multiple available: decode: %s", err)
+			return 0, 0
+		}
+
+		return proposal.MultipleAvailable, 0
+	case RequestPresentationMsgType:
+		var request RequestPresentation
+		if err := msg.Decode(&request); err != nil {
+			logger.Warnf("requested count: decode: %s", err)
+			return 0, 0
+		}
+
+		return 0, request.RequestedCount
+	default:
+		return 0, 0
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+)
+
+func TestRequestPresentationFromPreview(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		request, err := RequestPresentationFromPreview(&PresentationPreview{
+			Type: PresentationPreviewMsgType,
+			Attributes: []Attribute{
+				{Name: "first_name", CredDefID: "cred-def-1", Referent: "identity"},
+				{Name: "last_name", CredDefID: "cred-def-1", Referent: "identity"},
+				{Name: "degree", CredDefID: "cred-def-2"},
+				{Name: "email"},
+			},
+			Predicates: []Predicate{{Name: "age", CredDefID: "cred-def-1", Predicate: ">=", Threshold: "18"}},
+		}, "proof of education")
+		require.NoError(t, err)
+		require.Len(t, request.RequestPresentations, 1)
+
+		attachment := request.RequestPresentations[0]
+		require.Equal(t, PresentationDefinitionAttachmentID, attachment.ID)
+		require.Equal(t, "application/json", attachment.MimeType)
+
+		// the format is declared, the Prover answers with a presentation submission
+		requested, formats, err := RequestedPresentations(request)
+		require.NoError(t, err)
+		require.Len(t, requested, 1)
+		require.Equal(t, []decorator.AttachmentFormat{{
+			AttachID: PresentationDefinitionAttachmentID,
+			Format:   attachformat.DIFPresentationDefinitions,
+		}}, formats)
+
+		definitionRequest, ok := attachment.Data.JSON.(*PresentationDefinitionRequest)
+		require.True(t, ok)
+		require.NotEmpty(t, definitionRequest.Options.Challenge)
+
+		definition := definitionRequest.PresentationDefinition
+		require.NotEmpty(t, definition.ID)
+		require.Equal(t, "proof of education", definition.Name)
+
+		minimum := 18
+
+		require.Equal(t, []*InputDescriptor{{
+			ID:     "identity",
+			Schema: []*Schema{{URI: "cred-def-1"}},
+			Constraints: &Constraints{Fields: []*Field{
+				{Path: []string{"$.credentialSubject.first_name"}},
+				{Path: []string{"$.credentialSubject.last_name"}},
+			}},
+		}, {
+			ID:          "attribute_2",
+			Schema:      []*Schema{{URI: "cred-def-2"}},
+			Constraints: &Constraints{Fields: []*Field{{Path: []string{"$.credentialSubject.degree"}}}},
+		}, {
+			ID:          "attribute_3",
+			Constraints: &Constraints{Fields: []*Field{{Path: []string{"$.credentialSubject.email"}}}},
+		}, {
+			ID:     "predicate_0",
+			Schema: []*Schema{{URI: "cred-def-1"}},
+			Constraints: &Constraints{Fields: []*Field{{
+				Path:   []string{"$.credentialSubject.age"},
+				Filter: &Filter{Minimum: &minimum},
+			}}},
+		}}, definition.InputDescriptors)
+
+		// the request is sent as a DIDComm message
+		src, err := json.Marshal(request)
+		require.NoError(t, err)
+		require.Contains(t, string(src), `"presentation_definition"`)
+		require.Contains(t, string(src), `"minimum":18`)
+	})
+
+	t.Run("unique challenge", func(t *testing.T) {
+		preview := &PresentationPreview{Attributes: []Attribute{{Name: "name"}}}
+
+		first, err := RequestPresentationFromPreview(preview, "")
+		require.NoError(t, err)
+
+		second, err := RequestPresentationFromPreview(preview, "")
+		require.NoError(t, err)
+
+		require.NotEqual(t,
+			first.RequestPresentations[0].Data.JSON.(*PresentationDefinitionRequest).Options.Challenge,
+			second.RequestPresentations[0].Data.JSON.(*PresentationDefinitionRequest).Options.Challenge,
+		)
+	})
+
+	t.Run("empty preview", func(t *testing.T) {
+		_, err := RequestPresentationFromPreview(nil, "")
+		require.EqualError(t, err, "presentation preview is empty")
+
+		_, err = RequestPresentationFromPreview(&PresentationPreview{}, "")
+		require.EqualError(t, err, "presentation preview is empty")
+	})
+
+	t.Run("invalid predicate", func(t *testing.T) {
+		_, err := RequestPresentationFromPreview(&PresentationPreview{
+			Predicates: []Predicate{{Name: "age", Predicate: "!=", Threshold: "18"}},
+		}, "")
+		require.EqualError(t, err, `predicate age: unsupported type "!="`)
+
+		_, err = RequestPresentationFromPreview(&PresentationPreview{
+			Predicates: []Predicate{{Name: "age", Predicate: ">=", Threshold: "adult"}},
+		}, "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "predicate age: threshold")
+	})
+}
+
+func TestProposalPreview(t *testing.T) {
+	require.Nil(t, proposalPreview(nil))
+	require.Nil(t, proposalPreview(service.NewDIDCommMsgMap(RequestPresentation{Type: RequestPresentationMsgType})))
+
+	msg := service.NewDIDCommMsgMap(ProposePresentation{
+		Type:                 ProposePresentationMsgType,
+		PresentationProposal: PresentationPreview{Attributes: []Attribute{{Name: "name"}}},
+	})
+
+	preview := proposalPreview(msg)
+	require.NotNil(t, preview)
+	require.Equal(t, "name", preview.Attributes[0].Name)
+
+	// not decodable
+	msg["presentation_proposal"] = "preview"
+	require.Nil(t, proposalPreview(msg))
+}
+
+func TestCountHints(t *testing.T) {
+	multipleAvailable, requestedCount := countHints(nil)
+	require.Zero(t, multipleAvailable)
+	require.Zero(t, requestedCount)
+
+	proposal := service.NewDIDCommMsgMap(ProposePresentation{
+		Type:              ProposePresentationMsgType,
+		MultipleAvailable: 3,
+	})
+
+	multipleAvailable, requestedCount = countHints(proposal)
+	require.Equal(t, 3, multipleAvailable)
+	require.Zero(t, requestedCount)
+
+	request := service.NewDIDCommMsgMap(RequestPresentation{
+		Type:           RequestPresentationMsgType,
+		RequestedCount: RequestAllAvailable,
+	})
+
+	multipleAvailable, requestedCount = countHints(request)
+	require.Zero(t, multipleAvailable)
+	require.Equal(t, RequestAllAvailable, requestedCount)
+
+	multipleAvailable, requestedCount = countHints(service.NewDIDCommMsgMap(Presentation{Type: PresentationMsgType}))
+	require.Zero(t, multipleAvailable)
+	require.Zero(t, requestedCount)
+
+	// not decodable
+	proposal["multiple_available"] = "three"
+	multipleAvailable, _ = countHints(proposal)
+	require.Zero(t, multipleAvailable)
+
+	request["requested_count"] = "all"
+	_, requestedCount = countHints(request)
+	require.Zero(t, requestedCount)
+}
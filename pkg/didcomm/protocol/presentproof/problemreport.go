@@ -0,0 +1,131 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+)
+
+// Codes of the problem-report message sent to the other agent when an exchange is abandoned, depending on the
+// cause of abandoning it. The generic codes (internal, rejected and timeout) are sent for the other causes.
+const (
+	// CodeNoMatchingCredential is sent by the Prover holding no credential matching the request.
+	CodeNoMatchingCredential = "no-matching-credential"
+	// CodeVerificationFailed is sent by the Verifier when a received presentation is not verified.
+	CodeVerificationFailed = "verification-failed"
+	// CodePolicyRejected is sent when the action is declined by an ActionPolicy.
+	CodePolicyRejected = "policy-rejected"
+	// CodeExpiredRequest is sent when the expires_time of the request-presentation message is reached.
+	CodeExpiredRequest = "expired-request"
+)
+
+var (
+	// ErrNoMatchingCredential is the cause of abandoning an exchange when the Prover holds no credential
+	// matching the request. The no-matching-credential code is reported if the action is stopped
+	// (or the PresentationSupplier fails) with an error wrapping it.
+	ErrNoMatchingCredential = errors.New("no matching credential")
+	// ErrVerificationFailed is the cause of abandoning an exchange when a received presentation is not verified.
+	ErrVerificationFailed = errors.New("presentation verification failed")
+	// ErrPolicyRejected is the cause of abandoning an exchange when the action is declined by an ActionPolicy.
+	ErrPolicyRejected = errors.New("rejected by policy")
+	// ErrExpiredRequest is the cause of abandoning an exchange when the request-presentation message expired.
+	ErrExpiredRequest = errors.New("request expired")
+)
+
+// ProblemReportError is the error of an exchange abandoned by the other agent, it wraps the error of the cause
+// of abandoning the exchange (e.g ErrVerificationFailed) if the problem code is one of the protocol's codes.
+type ProblemReportError struct {
+	Code string
+}
+
+// ErrorFromProblemCode returns the error of an exchange abandoned by the other agent with the given problem code.
+func ErrorFromProblemCode(code string) error {
+	return &ProblemReportError{Code: code}
+}
+
+func (e *ProblemReportError) Error() string {
+	return fmt.Sprintf("problem report: %s", e.Code)
+}
+
+// Unwrap returns the error of the cause of abandoning the exchange, nil for the generic codes.
+func (e *ProblemReportError) Unwrap() error {
+	switch e.Code {
+	case CodeNoMatchingCredential:
+		return ErrNoMatchingCredential
+	case CodeVerificationFailed:
+		return ErrVerificationFailed
+	case CodePolicyRejected:
+		return ErrPolicyRejected
+	case CodeExpiredRequest:
+		return ErrExpiredRequest
+	default:
+		return nil
+	}
+}
+
+// problemReportProps are the properties of the state-change events of the abandoning state.
+type problemReportProps struct {
+	code string
+}
+
+// ProblemCode returns the code of the problem-report sent or received when the exchange was abandoned,
+// empty if there is none.
+func (p *problemReportProps) ProblemCode() string {
+	return p.code
+}
+
+// causeError keeps the message of an error while telling the cause of abandoning the exchange.
+type causeError struct {
+	error
+	cause error
+}
+
+func (e causeError) Is(target error) bool {
+	return target == e.cause
+}
+
+func (e causeError) Unwrap() error {
+	return e.error
+}
+
+// problemCode returns the code of the problem-report telling the cause of abandoning the exchange: one of the
+// protocol's codes if the error wraps its cause, rejected if the action was stopped, the given code otherwise.
+func problemCode(code string, err error) string {
+	switch {
+	case errors.Is(err, ErrNoMatchingCredential):
+		return CodeNoMatchingCredential
+	case errors.Is(err, ErrVerificationFailed):
+		return CodeVerificationFailed
+	case errors.Is(err, ErrPolicyRejected):
+		return CodePolicyRejected
+	case errors.Is(err, ErrExpiredRequest):
+		return CodeExpiredRequest
+	case errors.As(err, &customError{}):
+		// the protocol was stopped by the user
+		return codeRejectedError
+	default:
+		return code
+	}
+}
+
+// receivedProblemCode returns the code of the received problem-report message, empty for the other messages.
+func receivedProblemCode(msg service.DIDCommMsgMap) string {
+	if msg.Type() != ProblemReportMsgType {
+		return ""
+	}
+
+	report := model.ProblemReport{}
+	if err := msg.Decode(&report); err != nil {
+		return ""
+	}
+
+	return report.Description.Code
+}
@@ -0,0 +1,135 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+)
+
+func TestErrorFromProblemCode(t *testing.T) {
+	tests := []struct {
+		code  string
+		cause error
+	}{
+		{code: CodeNoMatchingCredential, cause: ErrNoMatchingCredential},
+		{code: CodeVerificationFailed, cause: ErrVerificationFailed},
+		{code: CodePolicyRejected, cause: ErrPolicyRejected},
+		{code: CodeExpiredRequest, cause: ErrExpiredRequest},
+		{code: codeRejectedError},
+		{code: "unknown"},
+	}
+
+	for _, test := range tests {
+		err := ErrorFromProblemCode(test.code)
+		require.EqualError(t, err, "problem report: "+test.code)
+		require.Equal(t, test.cause, errors.Unwrap(err))
+
+		var reportErr *ProblemReportError
+
+		require.True(t, errors.As(err, &reportErr))
+		require.Equal(t, test.code, reportErr.Code)
+	}
+}
+
+func TestProblemCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{err: nil, code: codeInternalError},
+		{err: errors.New("error"), code: codeInternalError},
+		{err: customError{error: errors.New("declined")}, code: codeRejectedError},
+		{err: customError{error: fmt.Errorf("supplier: %w", ErrNoMatchingCredential)}, code: CodeNoMatchingCredential},
+		{
+			err:  fmt.Errorf("execute: %w", causeError{error: errors.New("verify"), cause: ErrVerificationFailed}),
+			code: CodeVerificationFailed,
+		},
+		{
+			err:  customError{error: causeError{error: ErrUnknownDID, cause: ErrPolicyRejected}},
+			code: CodePolicyRejected,
+		},
+		{err: ErrExpiredRequest, code: CodeExpiredRequest},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.code, problemCode(codeInternalError, test.err))
+	}
+
+	// the message of the cause error is kept
+	err := causeError{error: ErrUnknownDID, cause: ErrPolicyRejected}
+	require.EqualError(t, err, ErrUnknownDID.Error())
+	require.True(t, errors.Is(err, ErrUnknownDID))
+}
+
+func TestReceivedProblemCode(t *testing.T) {
+	require.Equal(t, CodeVerificationFailed, receivedProblemCode(service.NewDIDCommMsgMap(&model.ProblemReport{
+		Type:        ProblemReportMsgType,
+		Description: model.Code{Code: CodeVerificationFailed},
+	})))
+
+	require.Empty(t, receivedProblemCode(service.NewDIDCommMsgMap(&model.Ack{Type: AckMsgType})))
+	require.Empty(t, receivedProblemCode(service.DIDCommMsgMap{"@type": ProblemReportMsgType, "description": "code"}))
+}
+
+func TestService_ProblemReportEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, messenger := newMemStoreService(t, ctrl)
+
+	piID := sendRequest(t, svc, messenger, &decorator.Timing{ExpiresTime: time.Now().Add(time.Hour)})
+
+	require.NoError(t, svc.RegisterActionEvent(make(chan service.DIDCommAction)))
+
+	events := make(chan service.StateMsg, 10)
+	require.NoError(t, svc.RegisterMsgEvent(events))
+
+	_, err := svc.HandleInbound(service.NewDIDCommMsgMap(struct {
+		ID          string           `json:"@id"`
+		Type        string           `json:"@type"`
+		Description model.Code       `json:"description"`
+		Thread      decorator.Thread `json:"~thread"`
+	}{
+		ID:          "problem-report",
+		Type:        ProblemReportMsgType,
+		Description: model.Code{Code: CodeNoMatchingCredential},
+		Thread:      decorator.Thread{ID: piID},
+	}), Alice, Bob)
+	require.NoError(t, err)
+
+	for {
+		select {
+		case e := <-events:
+			if e.StateID != stateNameAbandoning {
+				require.Nil(t, e.Properties)
+
+				continue
+			}
+
+			props, ok := e.Properties.(*problemReportProps)
+			require.True(t, ok)
+			require.Equal(t, CodeNoMatchingCredential, props.ProblemCode())
+			require.True(t, errors.Is(ErrorFromProblemCode(props.ProblemCode()), ErrNoMatchingCredential))
+
+			if e.Type == service.PostState {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+}
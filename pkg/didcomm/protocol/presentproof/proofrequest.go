@@ -0,0 +1,467 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+)
+
+const (
+	// metadata key of the proof requests of the requested presentations
+	metaProofRequests = "proof_requests"
+
+	proofRequestVersion = "1.0"
+)
+
+// ErrSubjectMismatch is the verification error of a presentation answering a proof request with attributes
+// sourced from several credentials, when the credentials are not issued to the same subject.
+var ErrSubjectMismatch = errors.New("the requested attributes are sourced from credentials of different subjects")
+
+// ProofRequest is a requested presentation asking for attributes and predicates of the credentials (Indy style),
+// the presentations answering it are checked by the Verifier. The presentation definitions built from a presentation
+// preview are checked as proof requests too, see RequestPresentationFromPreview.
+type ProofRequest struct {
+	Name                string                        `json:"name,omitempty"`
+	Version             string                        `json:"version"`
+	Nonce               string                        `json:"nonce"`
+	RequestedAttributes map[string]RequestedAttribute `json:"requested_attributes"`
+	RequestedPredicates map[string]RequestedPredicate `json:"requested_predicates"`
+}
+
+// RequestedAttribute is an attribute to reveal. The attributes requested together (Names) are revealed from
+// the same credential.
+type RequestedAttribute struct {
+	Name         string        `json:"name,omitempty"`
+	Names        []string      `json:"names,omitempty"`
+	Restrictions []Restriction `json:"restrictions,omitempty"`
+}
+
+// RequestedPredicate is a predicate the presentation must satisfy, e.g age >= 18.
+type RequestedPredicate struct {
+	Name         string        `json:"name"`
+	PType        string        `json:"p_type"`
+	PValue       int           `json:"p_value"`
+	Restrictions []Restriction `json:"restrictions,omitempty"`
+}
+
+// Restriction restricts the credentials of a requested attribute or predicate, a credential satisfies
+// the restriction when it matches all its fields. The credential definition of a W3C credential is
+// the ID of its credential schema.
+type Restriction struct {
+	CredDefID string `json:"cred_def_id,omitempty"`
+	IssuerDID string `json:"issuer_did,omitempty"`
+}
+
+// subject holds the claims of a credential subject of a presentation, the issuer and the schemas of
+// the credential are matched against the restrictions of the proof request.
+type subject struct {
+	id      string
+	claims  map[string]interface{}
+	issuer  string
+	schemas []string
+}
+
+// proofRequests returns the proof requests of the requested presentations by the ID of their attachment.
+// The presentation definitions requesting claims of the credential subjects, as built from a presentation preview,
+// are checked as proof requests, the other requested presentations are skipped.
+func proofRequests(request *RequestPresentation) map[string]*ProofRequest {
+	requests := map[string]*ProofRequest{}
+
+	for _, attachment := range request.RequestPresentations {
+		if attachment.Data.JSON == nil {
+			continue
+		}
+
+		src, err := json.Marshal(attachment.Data.JSON)
+		if err != nil {
+			continue
+		}
+
+		proofRequest := &ProofRequest{}
+		if err := json.Unmarshal(src, proofRequest); err != nil {
+			continue
+		}
+
+		if len(proofRequest.RequestedAttributes)+len(proofRequest.RequestedPredicates) == 0 {
+			definition := &PresentationDefinitionRequest{}
+			if err := json.Unmarshal(src, definition); err != nil {
+				continue
+			}
+
+			if proofRequest = definitionProofRequest(definition.PresentationDefinition); proofRequest == nil {
+				continue
+			}
+		}
+
+		requests[attachment.ID] = proofRequest
+	}
+
+	return requests
+}
+
+// definitionProofRequest returns the proof request checking the presentations of the presentation definition,
+// nil if the definition has a constraint which is not a claim of the credential subject with an optional bound.
+// The fields of an input descriptor are requested together, its bounded fields are predicates.
+func definitionProofRequest(definition *PresentationDefinition) *ProofRequest {
+	if definition == nil || len(definition.InputDescriptors) == 0 {
+		return nil
+	}
+
+	request := &ProofRequest{
+		Name:                definition.Name,
+		Version:             proofRequestVersion,
+		RequestedAttributes: map[string]RequestedAttribute{},
+		RequestedPredicates: map[string]RequestedPredicate{},
+	}
+
+	for _, descriptor := range definition.InputDescriptors {
+		if descriptor == nil || descriptor.Constraints == nil || len(descriptor.Constraints.Fields) == 0 {
+			return nil
+		}
+
+		var restrictions []Restriction
+
+		for _, schema := range descriptor.Schema {
+			if schema != nil {
+				restrictions = append(restrictions, Restriction{CredDefID: schema.URI})
+			}
+		}
+
+		attribute := RequestedAttribute{Restrictions: restrictions}
+
+		for i, field := range descriptor.Constraints.Fields {
+			if field == nil || len(field.Path) != 1 || !strings.HasPrefix(field.Path[0], credentialSubjectPath) {
+				return nil
+			}
+
+			name := strings.TrimPrefix(field.Path[0], credentialSubjectPath)
+
+			if field.Filter == nil {
+				attribute.Names = append(attribute.Names, name)
+
+				continue
+			}
+
+			predicate, ok := filterPredicate(field.Filter)
+			if !ok {
+				return nil
+			}
+
+			predicate.Name = name
+			predicate.Restrictions = restrictions
+
+			referent := descriptor.ID
+			if len(descriptor.Constraints.Fields) > 1 {
+				referent = fmt.Sprintf("%s_%d", descriptor.ID, i)
+			}
+
+			request.RequestedPredicates[referent] = predicate
+		}
+
+		if len(attribute.Names) > 0 {
+			request.RequestedAttributes[descriptor.ID] = attribute
+		}
+	}
+
+	return request
+}
+
+// filterPredicate returns the predicate of a filter with a single bound, e.g age >= 18 for a minimum of 18.
+func filterPredicate(filter *Filter) (RequestedPredicate, bool) {
+	var predicates []RequestedPredicate
+
+	for pType, bound := range map[string]*int{
+		"<":  filter.ExclusiveMaximum,
+		"<=": filter.Maximum,
+		">=": filter.Minimum,
+		">":  filter.ExclusiveMinimum,
+	} {
+		if bound != nil {
+			predicates = append(predicates, RequestedPredicate{PType: pType, PValue: *bound})
+		}
+	}
+
+	if len(predicates) != 1 {
+		return RequestedPredicate{}, false
+	}
+
+	return predicates[0], true
+}
+
+// setProofRequests keeps the proof requests in the metadata of the request, the messenger adds them
+// to the presentation received in the same thread (see setRequestedIDs).
+func setProofRequests(msg service.DIDCommMsgMap, request *RequestPresentation) {
+	if metadata := msg.Metadata(); metadata != nil {
+		if requests := proofRequests(request); len(requests) > 0 {
+			metadata[metaProofRequests] = requests
+		}
+	}
+}
+
+// getProofRequests returns the proof requests from the metadata of the received presentation.
+func getProofRequests(msg service.DIDCommMsgMap) map[string]*ProofRequest {
+	switch requests := msg.Metadata()[metaProofRequests].(type) {
+	case nil:
+		return nil
+	case map[string]*ProofRequest:
+		return requests
+	default:
+		// the metadata were restored from the store
+		src, err := json.Marshal(requests)
+		if err != nil {
+			logger.Warnf("proof requests: marshal: %s", err)
+			return nil
+		}
+
+		var result map[string]*ProofRequest
+		if err := json.Unmarshal(src, &result); err != nil {
+			logger.Warnf("proof requests: unmarshal: %s", err)
+			return nil
+		}
+
+		return result
+	}
+}
+
+// checkProofRequest checks that the presentation satisfies the proof request. The requested attributes and
+// predicates may be sourced from several credentials of the presentation, the credentials must then be issued
+// to the same subject: the combined credentials are those sharing a subject ID.
+// The credentials are verified with the options and must be signed, the requested attributes and predicates
+// are provided by the credentials satisfying their restrictions (see Restriction).
+func checkProofRequest(request *ProofRequest, vp *verifiable.Presentation, opts []verifiable.CredentialOpt) error {
+	subjects, err := presentationSubjects(vp, opts)
+	if err != nil {
+		return err
+	}
+
+	// a credential without subject ID can only satisfy the request on its own
+	var groups [][]subject
+
+	byID := map[string]int{}
+
+	for _, s := range subjects {
+		if s.id == "" {
+			groups = append(groups, []subject{s})
+
+			continue
+		}
+
+		i, ok := byID[s.id]
+		if !ok {
+			i = len(groups)
+			byID[s.id] = i
+
+			groups = append(groups, nil)
+		}
+
+		groups[i] = append(groups[i], s)
+	}
+
+	for _, group := range groups {
+		if satisfies(request, group) == nil {
+			return nil
+		}
+	}
+
+	// the request is satisfied by the credentials of several subjects only
+	if err := satisfies(request, subjects); err != nil {
+		return err
+	}
+
+	return ErrSubjectMismatch
+}
+
+// satisfies checks that the requested attributes and predicates are provided by the subjects. The attributes
+// requested together are provided by the same subject.
+func satisfies(request *ProofRequest, subjects []subject) error {
+	attributes := make([]string, 0, len(request.RequestedAttributes))
+	for referent := range request.RequestedAttributes {
+		attributes = append(attributes, referent)
+	}
+
+	sort.Strings(attributes)
+
+	for _, referent := range attributes {
+		attribute := request.RequestedAttributes[referent]
+
+		names := attribute.Names
+		if attribute.Name != "" {
+			names = append([]string{attribute.Name}, names...)
+		}
+
+		if !provided(subjects, attribute.Restrictions, func(claims map[string]interface{}) bool {
+			return hasClaims(claims, names)
+		}) {
+			return fmt.Errorf("requested attribute %s is not provided", referent)
+		}
+	}
+
+	predicates := make([]string, 0, len(request.RequestedPredicates))
+	for referent := range request.RequestedPredicates {
+		predicates = append(predicates, referent)
+	}
+
+	sort.Strings(predicates)
+
+	for _, referent := range predicates {
+		predicate := request.RequestedPredicates[referent]
+
+		if !provided(subjects, predicate.Restrictions, func(claims map[string]interface{}) bool {
+			return holds(claims, &predicate)
+		}) {
+			return fmt.Errorf("requested predicate %s is not satisfied", referent)
+		}
+	}
+
+	return nil
+}
+
+func provided(subjects []subject, restrictions []Restriction, match func(claims map[string]interface{}) bool) bool {
+	for _, s := range subjects {
+		if restricts(restrictions, &s) && match(s.claims) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restricts checks that the subject satisfies one of the restrictions, any subject satisfies no restriction.
+func restricts(restrictions []Restriction, s *subject) bool {
+	if len(restrictions) == 0 {
+		return true
+	}
+
+	for _, restriction := range restrictions {
+		if restriction.IssuerDID != "" && restriction.IssuerDID != s.issuer {
+			continue
+		}
+
+		if restriction.CredDefID != "" && !contains(s.schemas, restriction.CredDefID) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func hasClaims(claims map[string]interface{}, names []string) bool {
+	for _, name := range names {
+		if _, ok := claims[name]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// holds checks that the claim of the predicate is a number satisfying the predicate, e.g age >= 18.
+func holds(claims map[string]interface{}, predicate *RequestedPredicate) bool {
+	var value float64
+
+	switch v := claims[predicate.Name].(type) {
+	case float64:
+		value = v
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return false
+		}
+
+		value = f
+	default:
+		return false
+	}
+
+	threshold := float64(predicate.PValue)
+
+	switch predicate.PType {
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case ">=":
+		return value >= threshold
+	case ">":
+		return value > threshold
+	default:
+		return false
+	}
+}
+
+// presentationSubjects returns the subjects of the credentials of the presentation. The proof of the presentation
+// does not vouch for the claims of its credentials: each credential is verified with the options and must be
+// signed, by an embedded proof or as a JWS.
+func presentationSubjects(vp *verifiable.Presentation, opts []verifiable.CredentialOpt) ([]subject, error) {
+	credentials, err := vp.MarshalledCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []subject
+
+	for _, raw := range credentials {
+		vc, _, err := verifiable.NewCredential(raw, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("credential of presentation: %w", err)
+		}
+
+		if len(vc.Proofs) == 0 && !jwt.IsJWS(strings.TrimSpace(string(raw))) {
+			return nil, fmt.Errorf("credential %s of presentation is not signed", vc.ID)
+		}
+
+		subjects = append(subjects, credentialSubjects(vc)...)
+	}
+
+	return subjects, nil
+}
+
+// credentialSubjects returns the subjects of the credential.
+func credentialSubjects(vc *verifiable.Credential) []subject {
+	var schemas []string
+	for _, schema := range vc.Schemas {
+		schemas = append(schemas, schema.ID)
+	}
+
+	newSubject := func(claims map[string]interface{}) subject {
+		id, _ := claims["id"].(string) // nolint: errcheck
+
+		return subject{id: id, claims: claims, issuer: vc.Issuer.ID, schemas: schemas}
+	}
+
+	var subjects []subject
+
+	switch s := vc.Subject.(type) {
+	case string:
+		subjects = append(subjects, newSubject(map[string]interface{}{"id": s}))
+	case map[string]interface{}:
+		subjects = append(subjects, newSubject(s))
+	case []interface{}:
+		for _, item := range s {
+			if claims, ok := item.(map[string]interface{}); ok {
+				subjects = append(subjects, newSubject(claims))
+			}
+		}
+	case []map[string]interface{}:
+		for _, claims := range s {
+			subjects = append(subjects, newSubject(claims))
+		}
+	}
+
+	return subjects
+}
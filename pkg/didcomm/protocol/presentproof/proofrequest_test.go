@@ -0,0 +1,366 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+)
+
+const issuer = "did:example:issuer"
+
+// credentialSigner signs the credentials of the tests as JWS, see credentialOpts.
+var credentialSigner = func() *ed25519Signer {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	return &ed25519Signer{privKey: privKey}
+}()
+
+func credentialOpts() []verifiable.CredentialOpt {
+	pubKey, _ := credentialSigner.privKey.Public().(ed25519.PublicKey) // nolint: errcheck
+
+	return []verifiable.CredentialOpt{
+		verifiable.WithPublicKeyFetcher(verifiable.SingleKey(pubKey, kms.ED25519)),
+		verifiable.WithNoCustomSchemaCheck(),
+	}
+}
+
+func unsignedCredential(id string, subject interface{}) *verifiable.Credential {
+	issued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return &verifiable.Credential{
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		ID:      id,
+		Types:   []string{"VerifiableCredential"},
+		Issuer:  verifiable.Issuer{ID: issuer},
+		Issued:  &issued,
+		Subject: subject,
+	}
+}
+
+// signCredential signs the credential as JWS with the signer, the claims are built from the JSON of
+// the credential as the JWT claims of a credential require a single subject ID (see Credential.JWTClaims).
+func signCredential(t *testing.T, vc *verifiable.Credential, signer verifiable.Signer) string {
+	t.Helper()
+
+	src, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	claims := &verifiable.JWTCredClaims{Claims: &jwt.Claims{Issuer: vc.Issuer.ID, ID: vc.ID}}
+	require.NoError(t, json.Unmarshal(src, &claims.VC))
+
+	jws, err := claims.MarshalJWS(verifiable.EdDSA, signer, vc.Issuer.ID+"#key-1")
+	require.NoError(t, err)
+
+	return jws
+}
+
+func newCredential(t *testing.T, id string, subject interface{}) string {
+	t.Helper()
+
+	return signCredential(t, unsignedCredential(id, subject), credentialSigner)
+}
+
+func newPresentation(t *testing.T, credentials ...interface{}) *verifiable.Presentation {
+	t.Helper()
+
+	vp := &verifiable.Presentation{}
+	require.NoError(t, vp.SetCredentials(credentials...))
+
+	return vp
+}
+
+func combinedProofRequest() *ProofRequest {
+	return &ProofRequest{
+		Version: proofRequestVersion,
+		RequestedAttributes: map[string]RequestedAttribute{
+			"name":   {Names: []string{"first_name", "last_name"}},
+			"degree": {Name: "degree"},
+		},
+		RequestedPredicates: map[string]RequestedPredicate{
+			"age": {Name: "age", PType: ">=", PValue: 18},
+		},
+	}
+}
+
+func Test_checkProofRequest(t *testing.T) {
+	const holder = "did:example:holder"
+
+	identity := newCredential(t, "http://example.edu/credentials/1", map[string]interface{}{
+		"id":         holder,
+		"first_name": "Alice",
+		"last_name":  "Smith",
+		"age":        25,
+	})
+
+	diploma := newCredential(t, "http://example.edu/credentials/2", map[string]interface{}{
+		"id":     holder,
+		"degree": "Bachelor of Science",
+	})
+
+	t.Run("Attributes sourced from several credentials of the subject", func(t *testing.T) {
+		require.NoError(t, checkProofRequest(combinedProofRequest(), newPresentation(t, identity, diploma), credentialOpts()))
+	})
+
+	t.Run("Attributes sourced from a single credential without subject ID", func(t *testing.T) {
+		credential := newCredential(t, "http://example.edu/credentials/3", []interface{}{
+			map[string]interface{}{"first_name": "Alice", "last_name": "Smith", "age": "25", "degree": "BSc"},
+		})
+
+		require.NoError(t, checkProofRequest(combinedProofRequest(), newPresentation(t, credential), credentialOpts()))
+	})
+
+	t.Run("Credentials of different subjects", func(t *testing.T) {
+		other := newCredential(t, "http://example.edu/credentials/4", map[string]interface{}{
+			"id":     "did:example:other",
+			"degree": "Master of Science",
+		})
+
+		err := checkProofRequest(combinedProofRequest(), newPresentation(t, identity, other), credentialOpts())
+		require.True(t, errors.Is(err, ErrSubjectMismatch))
+	})
+
+	t.Run("Credentials without subject ID are not combined", func(t *testing.T) {
+		anonymous := newCredential(t, "http://example.edu/credentials/5", map[string]interface{}{
+			"degree": "Master of Science",
+		})
+
+		err := checkProofRequest(combinedProofRequest(), newPresentation(t, identity, anonymous), credentialOpts())
+		require.True(t, errors.Is(err, ErrSubjectMismatch))
+	})
+
+	t.Run("Attributes requested together are sourced from the same credential", func(t *testing.T) {
+		lastName := newCredential(t, "http://example.edu/credentials/6", map[string]interface{}{
+			"id":        holder,
+			"last_name": "Smith",
+		})
+		firstName := newCredential(t, "http://example.edu/credentials/7", map[string]interface{}{
+			"id":         holder,
+			"first_name": "Alice",
+			"age":        25,
+		})
+
+		err := checkProofRequest(combinedProofRequest(), newPresentation(t, firstName, lastName, diploma), credentialOpts())
+		require.EqualError(t, err, "requested attribute name is not provided")
+	})
+
+	t.Run("Predicate not satisfied", func(t *testing.T) {
+		minor := newCredential(t, "http://example.edu/credentials/8", map[string]interface{}{
+			"id":         holder,
+			"first_name": "Bob",
+			"last_name":  "Smith",
+			"age":        "16",
+		})
+
+		err := checkProofRequest(combinedProofRequest(), newPresentation(t, minor, diploma), credentialOpts())
+		require.EqualError(t, err, "requested predicate age is not satisfied")
+	})
+
+	t.Run("Unsigned credential", func(t *testing.T) {
+		unsigned := unsignedCredential("http://example.edu/credentials/9", map[string]interface{}{
+			"id":     holder,
+			"degree": "Bachelor of Science",
+		})
+
+		err := checkProofRequest(combinedProofRequest(), newPresentation(t, identity, unsigned), credentialOpts())
+		require.EqualError(t, err, "credential http://example.edu/credentials/9 of presentation is not signed")
+	})
+
+	t.Run("Forged credential", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		forged := signCredential(t, unsignedCredential("http://example.edu/credentials/10", map[string]interface{}{
+			"id":     holder,
+			"degree": "Bachelor of Science",
+		}), &ed25519Signer{privKey: otherKey})
+
+		err = checkProofRequest(combinedProofRequest(), newPresentation(t, identity, forged), credentialOpts())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "credential of presentation")
+	})
+
+	t.Run("Restrictions", func(t *testing.T) {
+		schema := "did:example:issuer:3:CL:18:tag"
+
+		licence := unsignedCredential("http://example.edu/credentials/11", map[string]interface{}{
+			"id":     holder,
+			"degree": "Bachelor of Science",
+		})
+		licence.Schemas = []verifiable.TypedID{{ID: schema, Type: "JsonSchemaValidator2018"}}
+
+		request := combinedProofRequest()
+		request.RequestedAttributes["degree"] = RequestedAttribute{
+			Name:         "degree",
+			Restrictions: []Restriction{{CredDefID: schema, IssuerDID: issuer}},
+		}
+
+		vp := newPresentation(t, identity, signCredential(t, licence, credentialSigner))
+		require.NoError(t, checkProofRequest(request, vp, credentialOpts()))
+
+		err := checkProofRequest(request, newPresentation(t, identity, diploma), credentialOpts())
+		require.EqualError(t, err, "requested attribute degree is not provided")
+
+		request.RequestedAttributes["degree"] = RequestedAttribute{
+			Name:         "degree",
+			Restrictions: []Restriction{{IssuerDID: "did:example:other"}, {CredDefID: schema}},
+		}
+		require.NoError(t, checkProofRequest(request, vp, credentialOpts()))
+
+		request.RequestedAttributes["degree"] = RequestedAttribute{
+			Name:         "degree",
+			Restrictions: []Restriction{{CredDefID: schema, IssuerDID: "did:example:other"}},
+		}
+		require.EqualError(t, checkProofRequest(request, vp, credentialOpts()),
+			"requested attribute degree is not provided")
+	})
+}
+
+func Test_holds(t *testing.T) {
+	claims := map[string]interface{}{"age": 18.0, "score": "4.5", "name": "Alice"}
+
+	for _, tc := range []struct {
+		predicate RequestedPredicate
+		expected  bool
+	}{
+		{RequestedPredicate{Name: "age", PType: ">=", PValue: 18}, true},
+		{RequestedPredicate{Name: "age", PType: ">", PValue: 18}, false},
+		{RequestedPredicate{Name: "age", PType: "<=", PValue: 18}, true},
+		{RequestedPredicate{Name: "score", PType: "<", PValue: 5}, true},
+		{RequestedPredicate{Name: "name", PType: ">", PValue: 0}, false},
+		{RequestedPredicate{Name: "missing", PType: ">", PValue: 0}, false},
+		{RequestedPredicate{Name: "age", PType: "==", PValue: 18}, false},
+	} {
+		tc := tc
+		require.Equal(t, tc.expected, holds(claims, &tc.predicate), tc.predicate)
+	}
+}
+
+func Test_proofRequests(t *testing.T) {
+	request := &RequestPresentation{RequestPresentations: []decorator.Attachment{{
+		ID:   "proof",
+		Data: decorator.AttachmentData{JSON: combinedProofRequest()},
+	}, {
+		ID:   "definition",
+		Data: decorator.AttachmentData{JSON: map[string]interface{}{"input_descriptors": []interface{}{}}},
+	}, {
+		ID:   "base64",
+		Data: decorator.AttachmentData{Base64: "e30="},
+	}}}
+
+	msg := service.NewDIDCommMsgMap(request)
+	setProofRequests(msg, request)
+
+	requests := getProofRequests(msg)
+	require.Len(t, requests, 1)
+	require.Equal(t, combinedProofRequest(), requests["proof"])
+
+	// the metadata are restored from the store
+	src, err := json.Marshal(msg.Metadata())
+	require.NoError(t, err)
+
+	restored := service.NewDIDCommMsgMap(RequestPresentation{})
+
+	metadata := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(src, &metadata))
+
+	for k, v := range metadata {
+		restored.Metadata()[k] = v
+	}
+
+	require.Equal(t, requests, getProofRequests(restored))
+
+	require.Nil(t, getProofRequests(service.NewDIDCommMsgMap(RequestPresentation{})))
+}
+
+func Test_definitionProofRequest(t *testing.T) {
+	t.Run("presentation definition built from a preview", func(t *testing.T) {
+		request, err := RequestPresentationFromPreview(&PresentationPreview{
+			Attributes: []Attribute{
+				{Name: "first_name", CredDefID: "cred-def-1", Referent: "name"},
+				{Name: "last_name", CredDefID: "cred-def-1", Referent: "name"},
+				{Name: "degree"},
+			},
+			Predicates: []Predicate{{Name: "age", Predicate: ">=", Threshold: "18"}},
+		}, "proof")
+		require.NoError(t, err)
+
+		restrictions := []Restriction{{CredDefID: "cred-def-1"}}
+
+		require.Equal(t, map[string]*ProofRequest{PresentationDefinitionAttachmentID: {
+			Name:    "proof",
+			Version: proofRequestVersion,
+			RequestedAttributes: map[string]RequestedAttribute{
+				"name":        {Names: []string{"first_name", "last_name"}, Restrictions: restrictions},
+				"attribute_2": {Names: []string{"degree"}},
+			},
+			RequestedPredicates: map[string]RequestedPredicate{
+				"predicate_0": {Name: "age", PType: ">=", PValue: 18},
+			},
+		}}, proofRequests(request))
+	})
+
+	t.Run("presentation definition with other constraints", func(t *testing.T) {
+		minimum, maximum := 18, 65
+
+		for _, definition := range []*PresentationDefinition{
+			nil,
+			{ID: "empty"},
+			{ID: "no constraints", InputDescriptors: []*InputDescriptor{{ID: "vc"}}},
+			{ID: "other path", InputDescriptors: []*InputDescriptor{{
+				ID:          "vc",
+				Constraints: &Constraints{Fields: []*Field{{Path: []string{"$.issuer"}}}},
+			}}},
+			{ID: "range", InputDescriptors: []*InputDescriptor{{
+				ID: "vc",
+				Constraints: &Constraints{Fields: []*Field{{
+					Path:   []string{"$.credentialSubject.age"},
+					Filter: &Filter{Minimum: &minimum, Maximum: &maximum},
+				}}},
+			}}},
+		} {
+			require.Nil(t, definitionProofRequest(definition))
+		}
+	})
+
+	t.Run("several fields with a bound", func(t *testing.T) {
+		minimum, maximum := 18, 65
+
+		request := definitionProofRequest(&PresentationDefinition{InputDescriptors: []*InputDescriptor{{
+			ID:     "vc",
+			Schema: []*Schema{{URI: "schema-1"}, {URI: "schema-2"}},
+			Constraints: &Constraints{Fields: []*Field{
+				{Path: []string{"$.credentialSubject.age"}, Filter: &Filter{ExclusiveMinimum: &minimum}},
+				{Path: []string{"$.credentialSubject.age"}, Filter: &Filter{ExclusiveMaximum: &maximum}},
+			}},
+		}}})
+		require.NotNil(t, request)
+		require.Empty(t, request.RequestedAttributes)
+
+		restrictions := []Restriction{{CredDefID: "schema-1"}, {CredDefID: "schema-2"}}
+
+		require.Equal(t, map[string]RequestedPredicate{
+			"vc_0": {Name: "age", PType: ">", PValue: 18, Restrictions: restrictions},
+			"vc_1": {Name: "age", PType: "<", PValue: 65, Restrictions: restrictions},
+		}, request.RequestedPredicates)
+	})
+}
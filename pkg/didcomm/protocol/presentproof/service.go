@@ -10,13 +10,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/piprate/json-gold/ld"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/instances"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/pause"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/internal/rotation"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ecdsasecp256k1signature2019"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/jsonwebsignature2020"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 )
 
 const (
@@ -43,11 +59,22 @@ const (
 	transitionalPayloadKey = "transitionalPayload_%s"
 )
 
-var logger = log.New("aries-framework/presentproof/service")
+const logModule = "aries-framework/presentproof/service"
+
+var logger = log.New(logModule)
+
+var (
+	errNoClients        = errors.New("no clients are registered to handle the message")
+	errRerequestMissing = errors.New("rerequest presentation: the request presentation is nil")
+)
 
 // customError is a wrapper to determine custom error against internal error
 type customError struct{ error }
 
+func (e customError) Unwrap() error {
+	return e.error
+}
+
 // transitionalPayload keeps payload needed for Continue function to proceed with the action
 type transitionalPayload struct {
 	// protocol state machine identifier
@@ -56,6 +83,12 @@ type transitionalPayload struct {
 	Msg       service.DIDCommMsgMap
 	MyDID     string
 	TheirDID  string
+	// ConnectionID is the ID of the connection the exchange is associated with (if any)
+	ConnectionID string `json:",omitempty"`
+	// VerificationResults keeps the verification results of the presentations of a received Presentation message
+	VerificationResults []VerificationResult `json:",omitempty"`
+	// ResumeToken is the token the action is resumed with while it is paused (see ActionPause)
+	ResumeToken string `json:",omitempty"`
 }
 
 // metaData type to store data for internal usage
@@ -66,7 +99,16 @@ type metaData struct {
 	presentation        *Presentation
 	proposePresentation *ProposePresentation
 	request             *RequestPresentation
-	registryVDRI        vdri.Registry
+	// rerequestReason is the reason of the Verifier requesting the presentations again after a failed verification
+	rerequestReason string
+	// presentationOpts are the options of the verification of the received presentations
+	presentationOpts []verifiable.PresentationOpt
+	// credentialOpts are the options of the verification of the credentials answering a proof request
+	credentialOpts []verifiable.CredentialOpt
+	// verificationPool verifies the received presentations
+	verificationPool *verificationPool
+	// presentations are the verified presentations of the received Presentation message
+	presentations []*verifiable.Presentation
 	// err is used to determine whether callback was stopped
 	// e.g the user received an action event and executes Stop(err) function
 	// in that case `err` is equal to `err` which was passing to Stop function
@@ -78,6 +120,76 @@ type Action struct {
 	// protocol state machine identifier
 	PIID string
 	Msg  service.DIDCommMsgMap
+	// ConnectionID is the ID of the connection the exchange is associated with, empty if the DIDs
+	// of the exchange are not those of a connection.
+	ConnectionID string `json:",omitempty"`
+	// VerificationResults are the verification results of the presentations of a Presentation message,
+	// one result per presentation attachment and per requested presentation which was not provided.
+	VerificationResults []VerificationResult `json:",omitempty"`
+	// ResumeToken is the token the action is resumed with, empty if the action is not paused (see ActionPause).
+	ResumeToken string `json:",omitempty"`
+}
+
+// eventProps contains the properties of the action event: the connection of the exchange, for a Presentation
+// message, the verification result of each presentation, for a ProposePresentation message, the proposed
+// presentation preview and the number of matching credentials and, for a RequestPresentation message,
+// the number of requested credentials.
+type eventProps struct {
+	connectionID        string
+	verificationResults []VerificationResult
+	preview             *PresentationPreview
+	multipleAvailable   int
+	requestedCount      int
+}
+
+// ConnectionID returns the ID of the connection the exchange is associated with.
+func (e *eventProps) ConnectionID() string {
+	return e.connectionID
+}
+
+// VerificationResults returns the verification results of the presentations of the received Presentation message.
+func (e *eventProps) VerificationResults() []VerificationResult {
+	return e.verificationResults
+}
+
+// PresentationPreview returns the presentation preview of the received ProposePresentation message,
+// nil for the other messages.
+func (e *eventProps) PresentationPreview() *PresentationPreview {
+	return e.preview
+}
+
+// MultipleAvailable returns the number of matching credentials the Prover holds, as stated by the received
+// ProposePresentation message, 0 for the other messages or if the Prover did not state it.
+func (e *eventProps) MultipleAvailable() int {
+	return e.multipleAvailable
+}
+
+// RequestedCount returns the number of credentials requested by the received RequestPresentation message
+// (or RequestAllAvailable), 0 for the other messages or if the Verifier did not state it.
+func (e *eventProps) RequestedCount() int {
+	return e.requestedCount
+}
+
+// PayloadVersion returns the version of the event properties.
+func (e *eventProps) PayloadVersion() service.EventPayloadVersion {
+	return service.EventPayloadV1
+}
+
+// eventPropsV2 is the version 2 of the properties of the action event (service.EventPayloadV2),
+// it adds the verified presentations of the received Presentation message.
+type eventPropsV2 struct {
+	*eventProps
+	presentations []*verifiable.Presentation
+}
+
+// PayloadVersion returns the version of the event properties.
+func (e *eventPropsV2) PayloadVersion() service.EventPayloadVersion {
+	return service.EventPayloadV2
+}
+
+// Presentations returns the verified presentations of the received Presentation message.
+func (e *eventPropsV2) Presentations() []*verifiable.Presentation {
+	return e.presentations
 }
 
 // Opt describes option signature for the Continue function
@@ -107,40 +219,149 @@ func WithRequestPresentation(msg *RequestPresentation) Opt {
 	}
 }
 
+// WithRerequestPresentation allows providing the RequestPresentation message requesting the presentations
+// again in the same thread, along with the reason (e.g why the verification failed).
+// USAGE: This message can be provided after receiving a Presentation message, the exchange is abandoned
+// if the message is nil.
+func WithRerequestPresentation(msg *RequestPresentation, reason string) Opt {
+	return func(md *metaData) {
+		if msg == nil {
+			md.err = errRerequestMissing
+
+			return
+		}
+
+		md.request = msg
+		md.rerequestReason = reason
+	}
+}
+
 // Provider contains dependencies for the protocol and is typically created by using aries.Context()
 type Provider interface {
 	Messenger() service.Messenger
 	StorageProvider() storage.Provider
+	TransientStorageProvider() storage.Provider
 	VDRIRegistry() vdri.Registry
+	// JSONLDDocumentLoader returns the JSON-LD document loader shared by the framework, if nil the service
+	// creates its own loader caching the contexts in its storage provider.
+	JSONLDDocumentLoader() ld.DocumentLoader
+	// EventPayloadVersion returns the version of the event properties, see service.EventPayloadVersion.
+	EventPayloadVersion() service.EventPayloadVersion
+	// Instrumentation returns the instrumentation hook, service.NoopInstrumentation if none is configured.
+	Instrumentation() service.Instrumentation
+	// Scheduler returns the scheduler shared by the framework, if nil the service runs its own janitor
+	// of the expired exchanges.
+	Scheduler() *scheduler.Scheduler
+}
+
+// lazyDocumentLoader creates the JSON-LD document loader once a context is loaded.
+type lazyDocumentLoader struct {
+	once     sync.Once
+	provider storage.Provider
+	loader   *jsonld.DocumentLoader
+	err      error
+}
+
+func (l *lazyDocumentLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	l.once.Do(func() {
+		l.loader, l.err = jsonld.NewDocumentLoader(l.provider)
+	})
+
+	if l.err != nil {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, l.err)
+	}
+
+	return l.loader.LoadDocument(u)
 }
 
 // Service for the presentproof protocol
 type Service struct {
 	service.Action
 	service.Message
-	store        storage.Store
-	callbacks    chan *metaData
-	messenger    service.Messenger
-	registryVDRI vdri.Registry
+	store       storage.Store
+	callbacks   chan *metaData
+	messenger   service.Messenger
+	keyResolver *verifiable.CachingDIDKeyResolver
+	// threads associates the exchanges with the connections
+	threads *rotation.Threads
+	// documentLoader loads the JSON-LD contexts of the presentations
+	documentLoader ld.DocumentLoader
+	// verificationPool verifies the received presentations, see SetVerificationWorkers
+	verificationPool *verificationPool
+	// eventPayloadVersion is the version of the event properties, see service.EventPayloadVersion
+	eventPayloadVersion service.EventPayloadVersion
+	instrumentation     service.Instrumentation
+	policies            []ActionPolicy
+	policiesMu          sync.RWMutex
+	// timeout of the exchanges, see SetExchangeTimeout
+	timeout time.Duration
+	// tracking is set once the deadline of an exchange is tracked
+	tracking bool
+	expiryMu sync.RWMutex
+	// scheduler runs the janitor of the expired exchanges, the service runs its own janitor if it is nil
+	scheduler   *scheduler.Scheduler
+	janitorDone chan struct{}
+	closeOnce   sync.Once
+	restoreOnce sync.Once
+	// audit records the completed exchanges, see SetAuditLog
+	audit   AuditLog
+	auditMu sync.RWMutex
+	// pausedActions keeps the resume tokens of the actions paused by ActionPause
+	pausedActions *pause.Actions
+	idGenerator   idgen.Generator
 }
 
 // New returns the presentproof service
 func New(p Provider) (*Service, error) {
-	store, err := p.StorageProvider().OpenStore(Name)
+	storageProvider := p.StorageProvider()
+
+	store, err := storageProvider.OpenStore(Name)
 	if err != nil {
 		return nil, err
 	}
 
 	svc := &Service{
-		messenger:    p.Messenger(),
-		registryVDRI: p.VDRIRegistry(),
-		store:        store,
-		callbacks:    make(chan *metaData),
+		idGenerator: idgen.FromProvider(p),
+		messenger:   p.Messenger(),
+		keyResolver: verifiable.NewCachingDIDKeyResolver(p.VDRIRegistry()),
+		store:       store,
+		callbacks:   make(chan *metaData),
+		// the DID documents resolved by the key resolver are shared by the verifications of the pool
+		verificationPool:    newVerificationPool(defaultVerificationWorkers),
+		eventPayloadVersion: p.EventPayloadVersion(),
+		instrumentation:     p.Instrumentation(),
+		janitorDone:         make(chan struct{}),
+		pausedActions:       pause.New(store),
 	}
 
+	svc.messenger = service.InstrumentMessenger(svc.messenger, Name, svc.instrumentation)
+
+	svc.documentLoader = p.JSONLDDocumentLoader()
+	if svc.documentLoader == nil {
+		svc.documentLoader = &lazyDocumentLoader{provider: storageProvider}
+	}
+
+	connections, err := connection.NewRecorder(p)
+	if err != nil {
+		return nil, fmt.Errorf("connection recorder: %w", err)
+	}
+
+	svc.threads = rotation.New(store, connections, jwt.KeyResolverFunc(svc.keyResolver.PublicKeyFetcher()))
+
 	// start the listener
 	go svc.startInternalListener()
 
+	// start the janitor of the expired exchanges
+	if sched := p.Scheduler(); sched != nil {
+		if err := sched.Register(expiryTaskName, janitorInterval, svc.abandonExpiredTask); err != nil {
+			return nil, fmt.Errorf("schedule expiry task: %w", err)
+		}
+
+		svc.scheduler = sched
+	} else {
+		go svc.startJanitor()
+	}
+
 	return svc, nil
 }
 
@@ -151,13 +372,11 @@ func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string)
 		return "", errors.New("bad assertion message is not DIDCommMsgMap")
 	}
 
-	aEvent := s.ActionEvent()
-
 	canReply := canReplyTo(msgMap)
 
-	if canReply && aEvent == nil {
+	if canReply && !s.HasActionHandler() && !s.hasActionPolicies() {
 		// throw error if there is no action event registered for inbound messages
-		return "", errors.New("no clients are registered to handle the message")
+		return "", errNoClients
 	}
 
 	md, err := s.doHandle(msgMap)
@@ -168,21 +387,69 @@ func (s *Service) HandleInbound(msg service.DIDCommMsg, myDID, theirDID string)
 	md.MyDID = myDID
 	md.TheirDID = theirDID
 
+	if err := s.associateConnection(md); err != nil {
+		return "", fmt.Errorf("associate connection: %w", err)
+	}
+
 	// trigger action event based on message type for inbound messages
 	if canReply && canTriggerActionEvents(msg) {
-		err = s.saveTransitionalPayload(md.PIID, md.transitionalPayload)
-		if err != nil {
-			return "", fmt.Errorf("save transitional payload: %w", err)
+		if msg.Type() == PresentationMsgType {
+			// the verification may take a while (e.g. the retries of the DID resolutions), it is done
+			// in the background so the inbound transport is not blocked
+			go s.verifyAndTriggerAction(md)
+
+			return "", nil
 		}
-		aEvent <- s.newDIDCommActionMsg(md)
 
-		return "", nil
+		return "", s.triggerAction(md)
 	}
 
 	// if no action event is triggered, continue the execution
 	return "", s.handle(md)
 }
 
+// verifyAndTriggerAction verifies the received presentations and triggers the action, the exchange is abandoned
+// if the action can't be triggered.
+func (s *Service) verifyAndTriggerAction(md *metaData) {
+	endSpan := s.instrumentation.StartSpan(Name, md.PIID, "verify presentations")
+	md.VerificationResults, md.presentations = s.verifyPresentations(md.Msg)
+
+	endSpan(verificationError(md.VerificationResults))
+
+	if err := s.triggerAction(md); err != nil {
+		errorLogger(md).Errorf("trigger action: %s", err)
+
+		md.err = err
+		s.processCallback(md)
+	}
+}
+
+// triggerAction executes the action if a policy applies to it, otherwise triggers the action event.
+func (s *Service) triggerAction(md *metaData) error {
+	// the action is executed automatically if a policy applies to it
+	if decision := s.decide(md); decision != nil {
+		s.applyDecision(md, decision)
+
+		return nil
+	}
+
+	if !s.HasActionHandler() {
+		return errNoClients
+	}
+
+	err := s.saveTransitionalPayload(md.PIID, md.transitionalPayload)
+	if err != nil {
+		return fmt.Errorf("save transitional payload: %w", err)
+	}
+
+	// the handler may have been unregistered meanwhile
+	if !s.TriggerActionEvent(s.newDIDCommActionMsg(md)) {
+		return errNoClients
+	}
+
+	return nil
+}
+
 // HandleOutbound handles outbound message (presentproof protocol)
 func (s *Service) HandleOutbound(_ service.DIDCommMsg, _, _ string) error {
 	return nil
@@ -191,7 +458,7 @@ func (s *Service) HandleOutbound(_ service.DIDCommMsg, _, _ string) error {
 func (s *Service) getCurrentStateNameAndPIID(msg service.DIDCommMsg) (string, string, error) {
 	piID, err := getPIID(msg)
 	if errors.Is(err, service.ErrThreadIDNotFound) {
-		piID = uuid.New().String()
+		piID = s.idGenerator.NewID()
 
 		return piID, stateNameStart, msg.SetID(piID)
 	}
@@ -231,9 +498,11 @@ func (s *Service) doHandle(msg service.DIDCommMsgMap) (*metaData, error) {
 			Msg:       msg,
 			PIID:      piID,
 		},
-		state:        next,
-		msgClone:     msg.Clone(),
-		registryVDRI: s.registryVDRI,
+		state:            next,
+		msgClone:         msg.Clone(),
+		presentationOpts: s.presentationOpts(),
+		credentialOpts:   s.credentialOpts(),
+		verificationPool: s.verificationPool,
 	}, nil
 }
 
@@ -253,11 +522,16 @@ func (s *Service) startInternalListener() {
 		msg.state = &abandoning{Code: codeInternalError}
 
 		if err := s.handle(msg); err != nil {
-			logger.Errorf("listener handle: %s", err)
+			errorLogger(msg).Errorf("listener handle: %s", err)
 		}
 	}
 }
 
+// errorLogger returns the logger of the errors of the exchange, with its contextual fields.
+func errorLogger(md *metaData) *log.Log {
+	return logger.WithFields(log.Fields{log.FieldProtocol: Name, log.FieldThread: md.PIID, log.FieldState: md.StateName})
+}
+
 func isNoOp(s state) bool {
 	_, ok := s.(*noOp)
 	return ok
@@ -280,6 +554,18 @@ func (s *Service) handle(md *metaData) error {
 			return fmt.Errorf("failed to persist state %s: %w", current.Name(), err)
 		}
 
+		if err := s.trackExpiry(md, current); err != nil {
+			return fmt.Errorf("track expiry: %w", err)
+		}
+
+		if err := s.recordAudit(md, current); err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+
+		if err := s.releaseThread(md, current); err != nil {
+			return fmt.Errorf("release thread: %w", err)
+		}
+
 		if err := action(s.messenger); err != nil {
 			return fmt.Errorf("action %s: %w", md.state.Name(), err)
 		}
@@ -402,6 +688,12 @@ func (s *Service) deleteTransitionalPayload(id string) error {
 	return s.store.Delete(fmt.Sprintf(transitionalPayloadKey, id))
 }
 
+// InstancesByState returns the number of protocol instances in progress by state, the instances which
+// reached the done state are not counted.
+func (s *Service) InstancesByState() (map[string]int, error) {
+	return instances.ByState(s.store, stateNameKey, stateNameDone)
+}
+
 // Actions returns actions for the async usage
 func (s *Service) Actions() ([]Action, error) {
 	records := s.store.Iterator(
@@ -435,11 +727,17 @@ func (s *Service) ActionContinue(piID string, opt Opt) error {
 		return fmt.Errorf("get transitional payload: %w", err)
 	}
 
+	if tPayload.ResumeToken != "" {
+		return ErrActionPaused
+	}
+
 	md := &metaData{
 		transitionalPayload: *tPayload,
 		state:               stateFromName(tPayload.StateName),
 		msgClone:            tPayload.Msg.Clone(),
-		registryVDRI:        s.registryVDRI,
+		presentationOpts:    s.presentationOpts(),
+		credentialOpts:      s.credentialOpts(),
+		verificationPool:    s.verificationPool,
 	}
 
 	if opt != nil {
@@ -462,11 +760,17 @@ func (s *Service) ActionStop(piID string, cErr error) error {
 		return fmt.Errorf("get transitional payload: %w", err)
 	}
 
+	if tPayload.ResumeToken != "" {
+		return ErrActionPaused
+	}
+
 	md := &metaData{
 		transitionalPayload: *tPayload,
 		state:               stateFromName(tPayload.StateName),
 		msgClone:            tPayload.Msg.Clone(),
-		registryVDRI:        s.registryVDRI,
+		presentationOpts:    s.presentationOpts(),
+		credentialOpts:      s.credentialOpts(),
+		verificationPool:    s.verificationPool,
 	}
 
 	if err := s.deleteTransitionalPayload(md.PIID); err != nil {
@@ -479,6 +783,79 @@ func (s *Service) ActionStop(piID string, cErr error) error {
 	return nil
 }
 
+// verifyPresentations verifies the presentations of the received Presentation message, it returns the
+// verification results and the verified presentations.
+// A message which cannot be decoded is reported when the Verifier accepts it, so the error is only logged here.
+func (s *Service) verifyPresentations(msg service.DIDCommMsgMap) ([]VerificationResult, []*verifiable.Presentation) {
+	var presentation = Presentation{}
+
+	if err := msg.Decode(&presentation); err != nil {
+		logger.Warnf("verify presentations: decode: %s", err)
+		return nil, nil
+	}
+
+	presentations, err := supportedPresentations(&presentation)
+	if err != nil {
+		logger.Warnf("verify presentations: %s", err)
+		return nil, nil
+	}
+
+	return verifyPresentations(s.verificationPool, s.presentationOpts(), s.credentialOpts(), getRequestedIDs(msg),
+		getProofRequests(msg), presentations)
+}
+
+// presentationOpts returns the options of the verification of the presentations: the public keys are resolved
+// by the VDRI registry and the JSON-LD contexts of the linked data proofs are loaded by the document loader.
+// The linked data proofs are Ed25519Signature2018, JsonWebSignature2020 or EcdsaSecp256k1Signature2019 proofs.
+func (s *Service) presentationOpts() []verifiable.PresentationOpt {
+	return []verifiable.PresentationOpt{
+		verifiable.WithPresPublicKeyFetcher(s.keyResolver.PublicKeyFetcher()),
+		verifiable.WithPresEmbeddedSignatureSuites(s.signatureSuites()...),
+	}
+}
+
+// credentialOpts returns the options of the verification of the credentials of the presentations answering
+// a proof request, the proofs are verified as the proofs of the presentations. The credential schemas identify
+// the credential definitions of the restrictions (see Restriction), they are not downloaded.
+func (s *Service) credentialOpts() []verifiable.CredentialOpt {
+	return []verifiable.CredentialOpt{
+		verifiable.WithPublicKeyFetcher(s.keyResolver.PublicKeyFetcher()),
+		verifiable.WithEmbeddedSignatureSuites(s.signatureSuites()...),
+		verifiable.WithJSONLDDocumentLoader(s.documentLoader),
+		verifiable.WithNoCustomSchemaCheck(),
+	}
+}
+
+func (s *Service) signatureSuites() []verifier.SignatureSuite {
+	return []verifier.SignatureSuite{
+		ed25519signature2018.New(
+			suite.WithVerifier(ed25519signature2018.NewPublicKeyVerifier()),
+			suite.WithDocumentLoader(s.documentLoader)),
+		jsonwebsignature2020.New(
+			suite.WithVerifier(jsonwebsignature2020.NewPublicKeyVerifier()),
+			suite.WithDocumentLoader(s.documentLoader)),
+		ecdsasecp256k1signature2019.New(
+			suite.WithVerifier(ecdsasecp256k1signature2019.NewPublicKeyVerifier()),
+			suite.WithDocumentLoader(s.documentLoader)),
+	}
+}
+
+// eventProperties returns the properties of the action event in the configured version.
+func (s *Service) eventProperties(md *metaData) service.EventProperties {
+	props := &eventProps{
+		connectionID:        md.ConnectionID,
+		verificationResults: md.VerificationResults,
+		preview:             proposalPreview(md.Msg),
+	}
+	props.multipleAvailable, props.requestedCount = countHints(md.Msg)
+
+	if s.eventPayloadVersion < service.EventPayloadV2 {
+		return props
+	}
+
+	return &eventPropsV2{eventProps: props, presentations: md.presentations}
+}
+
 func (s *Service) processCallback(msg *metaData) {
 	// pass the callback data to internal channel. This is created to unblock consumer go routine and wrap the callback
 	// channel internally.
@@ -492,20 +869,33 @@ func (s *Service) newDIDCommActionMsg(md *metaData) service.DIDCommAction {
 	return service.DIDCommAction{
 		ProtocolName: Name,
 		Message:      md.msgClone,
+		Properties:   s.eventProperties(md),
 		Continue: func(opt interface{}) {
+			if s.pausedActions.Paused(md.PIID) {
+				errorLogger(md).Errorf("continue: %v", ErrActionPaused)
+
+				return
+			}
+
 			if fn, ok := opt.(Opt); ok {
 				fn(md)
 			}
 
 			if err := s.deleteTransitionalPayload(md.PIID); err != nil {
-				logger.Errorf("continue: delete transitional payload: %v", err)
+				errorLogger(md).Errorf("continue: delete transitional payload: %v", err)
 			}
 
 			s.processCallback(md)
 		},
 		Stop: func(cErr error) {
+			if s.pausedActions.Paused(md.PIID) {
+				errorLogger(md).Errorf("stop: %v", ErrActionPaused)
+
+				return
+			}
+
 			if err := s.deleteTransitionalPayload(md.PIID); err != nil {
-				logger.Errorf("stop: delete transitional payload: %v", err)
+				errorLogger(md).Errorf("stop: delete transitional payload: %v", err)
 			}
 
 			md.err = customError{error: cErr}
@@ -515,11 +905,19 @@ func (s *Service) newDIDCommActionMsg(md *metaData) service.DIDCommAction {
 }
 
 func (s *Service) execute(next state, md *metaData) (state, stateAction, error) {
+	var props service.EventProperties
+
+	// the abandoning events tell the cause of abandoning the exchange
+	if a, ok := next.(*abandoning); ok {
+		props = &problemReportProps{code: a.problemCode(md)}
+	}
+
 	s.sendMsgEvents(&service.StateMsg{
 		ProtocolName: Name,
 		Type:         service.PreState,
 		Msg:          md.msgClone,
 		StateID:      next.Name(),
+		Properties:   props,
 	})
 
 	defer s.sendMsgEvents(&service.StateMsg{
@@ -527,17 +925,21 @@ func (s *Service) execute(next state, md *metaData) (state, stateAction, error)
 		Type:         service.PostState,
 		Msg:          md.msgClone,
 		StateID:      next.Name(),
+		Properties:   props,
 	})
 
-	return next.Execute(md)
+	followup, action, err := next.Execute(md)
+	if err == nil {
+		s.instrumentation.StateTransition(Name, md.PIID, next.Name())
+	}
+
+	return followup, action, err
 }
 
 // sendMsgEvents triggers the message events.
 func (s *Service) sendMsgEvents(msg *service.StateMsg) {
 	// trigger the message events
-	for _, handler := range s.MsgEvents() {
-		handler <- *msg
-	}
+	s.TriggerMsgEvents(*msg)
 }
 
 // Name returns service name
@@ -9,6 +9,8 @@ package presentproof
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,14 +18,19 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
 	presentproofMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/protocol/presentproof"
 	storageMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/storage"
+	mocklog "github.com/hyperledger/aries-framework-go/pkg/internal/mock/log"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
 	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 )
 
 const (
@@ -31,17 +38,58 @@ const (
 	Bob   = "Bob"
 )
 
+// logRecorder records the lines logged by the tests of the package
+var logRecorder = &mocklog.Recorder{} // nolint:gochecknoglobals
+
+func TestMain(m *testing.M) {
+	log.Initialize(logRecorder)
+
+	os.Exit(m.Run())
+}
+
+// newMockProvider returns a mock of the provider of the service, the connections are kept in memory.
+func newMockProvider(ctrl *gomock.Controller) *presentproofMocks.MockProvider {
+	provider := presentproofMocks.NewMockProvider(ctrl)
+	provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider()).AnyTimes()
+	provider.EXPECT().JSONLDDocumentLoader().Return(nil).AnyTimes()
+	provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV1).AnyTimes()
+	provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{}).AnyTimes()
+	provider.EXPECT().Scheduler().Return(nil).AnyTimes()
+
+	return provider
+}
+
+// threadKey matches the keys of the threads associating the exchanges with the connections.
+type threadKey struct{}
+
+func (threadKey) Matches(x interface{}) bool {
+	key, ok := x.(string)
+
+	return ok && strings.HasPrefix(key, "thread_")
+}
+
+func (threadKey) String() string {
+	return "is a thread key"
+}
+
+// expectThreads lets the service keep the threads of the exchanges in the mock store.
+func expectThreads(store *storageMocks.MockStore) {
+	store.EXPECT().Get(threadKey{}).Return(nil, storage.ErrDataNotFound).AnyTimes()
+	store.EXPECT().Put(threadKey{}, gomock.Any()).Return(nil).AnyTimes()
+	store.EXPECT().Delete(threadKey{}).Return(nil).AnyTimes()
+}
+
 func TestNew(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	t.Run("Success", func(t *testing.T) {
 		storeProvider := storageMocks.NewMockProvider(ctrl)
-		storeProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil)
+		storeProvider.EXPECT().OpenStore(gomock.Any()).Return(nil, nil).AnyTimes()
 
-		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(nil)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 		provider.EXPECT().VDRIRegistry().Return(nil)
 
 		svc, err := New(provider)
@@ -55,13 +103,168 @@ func TestNew(t *testing.T) {
 		storeProvider := storageMocks.NewMockProvider(ctrl)
 		storeProvider.EXPECT().OpenStore(Name).Return(nil, errors.New(errMsg))
 
-		provider := presentproofMocks.NewMockProvider(ctrl)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider := newMockProvider(ctrl)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 
 		svc, err := New(provider)
 		require.Contains(t, fmt.Sprintf("%v", err), errMsg)
 		require.Nil(t, svc)
 	})
+
+	t.Run("JSON-LD document loader of the provider", func(t *testing.T) {
+		loader, err := jsonld.NewDocumentLoader(mem.NewProvider())
+		require.NoError(t, err)
+
+		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider.EXPECT().Messenger().Return(nil)
+		provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider())
+		provider.EXPECT().VDRIRegistry().Return(nil)
+		provider.EXPECT().JSONLDDocumentLoader().Return(loader)
+		provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV1)
+		provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{})
+		provider.EXPECT().Scheduler().Return(nil)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+		require.Equal(t, loader, svc.documentLoader)
+	})
+
+	t.Run("JSON-LD document loader created once a context is loaded", func(t *testing.T) {
+		storeProvider := storageMocks.NewMockProvider(ctrl)
+		storeProvider.EXPECT().OpenStore(Name).Return(nil, nil)
+		storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
+		storeProvider.EXPECT().OpenStore(jsonld.ContextsStoreName).Return(nil, errors.New("test error"))
+
+		provider := newMockProvider(ctrl)
+		provider.EXPECT().Messenger().Return(nil)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
+		provider.EXPECT().VDRIRegistry().Return(nil)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = svc.documentLoader.LoadDocument(jsonld.CredentialsContextURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "test error")
+	})
+
+	t.Run("event payload version", func(t *testing.T) {
+		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider.EXPECT().Messenger().Return(nil)
+		provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+		provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider())
+		provider.EXPECT().VDRIRegistry().Return(nil)
+		provider.EXPECT().JSONLDDocumentLoader().Return(nil)
+		provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV2)
+		provider.EXPECT().Instrumentation().Return(service.NoopInstrumentation{})
+		provider.EXPECT().Scheduler().Return(nil)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+		require.Equal(t, service.EventPayloadV2, svc.eventPayloadVersion)
+	})
+}
+
+type transitionRecorder struct {
+	service.NoopInstrumentation
+	transitions []string
+}
+
+func (r *transitionRecorder) StateTransition(protocol, threadID, state string) {
+	r.transitions = append(r.transitions, protocol+" "+threadID+" "+state)
+}
+
+func TestService_Instrumentation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorder := &transitionRecorder{}
+
+	provider := presentproofMocks.NewMockProvider(ctrl)
+	provider.EXPECT().Messenger().Return(serviceMocks.NewMockMessenger(ctrl))
+	provider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
+	provider.EXPECT().TransientStorageProvider().Return(mem.NewProvider())
+	provider.EXPECT().VDRIRegistry().Return(nil)
+	provider.EXPECT().JSONLDDocumentLoader().Return(nil)
+	provider.EXPECT().EventPayloadVersion().Return(service.EventPayloadV1)
+	provider.EXPECT().Instrumentation().Return(recorder)
+	provider.EXPECT().Scheduler().Return(nil)
+
+	svc, err := New(provider)
+	require.NoError(t, err)
+	require.Equal(t, recorder, svc.instrumentation)
+
+	md := &metaData{transitionalPayload: transitionalPayload{PIID: "piid"}}
+
+	_, _, err = svc.execute(&done{}, md)
+	require.NoError(t, err)
+
+	_, _, err = svc.execute(&noOp{}, md)
+	require.Error(t, err)
+
+	require.Equal(t, []string{Name + " piid " + stateNameDone}, recorder.transitions)
+}
+
+func TestService_EventProperties(t *testing.T) {
+	md := &metaData{
+		transitionalPayload: transitionalPayload{
+			ConnectionID:        "connection-id",
+			VerificationResults: []VerificationResult{{ID: "degree", Verified: true}},
+		},
+		presentations: []*verifiable.Presentation{{ID: "presentation-id"}},
+	}
+
+	props, ok := (&Service{eventPayloadVersion: service.EventPayloadV1}).eventProperties(md).(*eventProps)
+	require.True(t, ok)
+	require.Equal(t, service.EventPayloadV1, props.PayloadVersion())
+	require.Equal(t, md.ConnectionID, props.ConnectionID())
+	require.Equal(t, md.VerificationResults, props.VerificationResults())
+	require.Nil(t, props.PresentationPreview())
+
+	propsV2, ok := (&Service{eventPayloadVersion: service.EventPayloadV2}).eventProperties(md).(*eventPropsV2)
+	require.True(t, ok)
+	require.Equal(t, service.EventPayloadV2, propsV2.PayloadVersion())
+	require.Equal(t, md.ConnectionID, propsV2.ConnectionID())
+	require.Equal(t, md.VerificationResults, propsV2.VerificationResults())
+	require.Equal(t, md.presentations, propsV2.Presentations())
+
+	preview := PresentationPreview{
+		Type:       PresentationPreviewMsgType,
+		Attributes: []Attribute{{Name: "name", Value: "Alice"}},
+	}
+
+	md.Msg = service.NewDIDCommMsgMap(ProposePresentation{
+		Type:                 ProposePresentationMsgType,
+		PresentationProposal: preview,
+	})
+
+	props, ok = (&Service{eventPayloadVersion: service.EventPayloadV1}).eventProperties(md).(*eventProps)
+	require.True(t, ok)
+	require.Equal(t, &preview, props.PresentationPreview())
+	require.Zero(t, props.MultipleAvailable())
+
+	md.Msg = service.NewDIDCommMsgMap(ProposePresentation{
+		Type:                 ProposePresentationMsgType,
+		PresentationProposal: preview,
+		MultipleAvailable:    2,
+	})
+
+	props, ok = (&Service{eventPayloadVersion: service.EventPayloadV1}).eventProperties(md).(*eventProps)
+	require.True(t, ok)
+	require.Equal(t, 2, props.MultipleAvailable())
+	require.Zero(t, props.RequestedCount())
+
+	md.Msg = service.NewDIDCommMsgMap(RequestPresentation{
+		Type:           RequestPresentationMsgType,
+		RequestedCount: RequestAllAvailable,
+	})
+
+	props, ok = (&Service{eventPayloadVersion: service.EventPayloadV1}).eventProperties(md).(*eventProps)
+	require.True(t, ok)
+	require.Nil(t, props.PresentationPreview())
+	require.Zero(t, props.MultipleAvailable())
+	require.Equal(t, RequestAllAvailable, props.RequestedCount())
 }
 
 func TestService_ActionContinue(t *testing.T) {
@@ -76,10 +279,11 @@ func TestService_ActionContinue(t *testing.T) {
 
 		storeProvider := storageMocks.NewMockProvider(ctrl)
 		storeProvider.EXPECT().OpenStore(Name).Return(store, nil).AnyTimes()
+		storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 
-		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(nil)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 		provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
 
 		svc, err := New(provider)
@@ -101,10 +305,11 @@ func TestService_ActionContinue(t *testing.T) {
 
 		storeProvider := storageMocks.NewMockProvider(ctrl)
 		storeProvider.EXPECT().OpenStore(Name).Return(store, nil).AnyTimes()
+		storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 
-		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(nil)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 		provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
 
 		svc, err := New(provider)
@@ -127,10 +332,11 @@ func TestService_ActionStop(t *testing.T) {
 
 		storeProvider := storageMocks.NewMockProvider(ctrl)
 		storeProvider.EXPECT().OpenStore(Name).Return(store, nil).AnyTimes()
+		storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 
-		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(nil)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 		provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
 
 		svc, err := New(provider)
@@ -152,10 +358,11 @@ func TestService_ActionStop(t *testing.T) {
 
 		storeProvider := storageMocks.NewMockProvider(ctrl)
 		storeProvider.EXPECT().OpenStore(Name).Return(store, nil).AnyTimes()
+		storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 
-		provider := presentproofMocks.NewMockProvider(ctrl)
+		provider := newMockProvider(ctrl)
 		provider.EXPECT().Messenger().Return(nil)
-		provider.EXPECT().StorageProvider().Return(storeProvider)
+		provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 		provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
 
 		svc, err := New(provider)
@@ -174,13 +381,15 @@ func TestService_HandleInbound(t *testing.T) {
 	const errMsg = "error"
 
 	store := storageMocks.NewMockStore(ctrl)
+	expectThreads(store)
 
 	storeProvider := storageMocks.NewMockProvider(ctrl)
 	storeProvider.EXPECT().OpenStore(Name).Return(store, nil).AnyTimes()
+	storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 
 	messenger := serviceMocks.NewMockMessenger(ctrl)
 
-	provider := presentproofMocks.NewMockProvider(ctrl)
+	provider := newMockProvider(ctrl)
 	provider.EXPECT().Messenger().Return(messenger).AnyTimes()
 	provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 	provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
@@ -354,9 +563,9 @@ func TestService_HandleInbound(t *testing.T) {
 	t.Run("Receive Request Presentation (continue with presentation) async", func(t *testing.T) {
 		var done = make(chan struct{})
 
-		newProvider := presentproofMocks.NewMockProvider(ctrl)
+		newProvider := newMockProvider(ctrl)
 		newProvider.EXPECT().Messenger().Return(messenger)
-		newProvider.EXPECT().StorageProvider().Return(mem.NewProvider())
+		newProvider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
 		newProvider.EXPECT().VDRIRegistry().Return(nil)
 
 		messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any()).
@@ -396,9 +605,9 @@ func TestService_HandleInbound(t *testing.T) {
 	t.Run("Receive Request Presentation (Stop) async", func(t *testing.T) {
 		var done = make(chan struct{})
 
-		newProvider := presentproofMocks.NewMockProvider(ctrl)
+		newProvider := newMockProvider(ctrl)
 		newProvider.EXPECT().Messenger().Return(messenger)
-		newProvider.EXPECT().StorageProvider().Return(mem.NewProvider())
+		newProvider.EXPECT().StorageProvider().Return(mem.NewProvider()).AnyTimes()
 		newProvider.EXPECT().VDRIRegistry().Return(nil)
 
 		messenger.EXPECT().
@@ -636,6 +845,194 @@ func TestService_HandleInbound(t *testing.T) {
 		}
 	})
 
+	t.Run("Receive Presentation (re-request)", func(t *testing.T) {
+		var done = make(chan struct{})
+
+		messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any()).
+			Do(func(_ string, msg service.DIDCommMsgMap) error {
+				r := &RequestPresentation{}
+				require.NoError(t, msg.Decode(r))
+				require.Equal(t, RequestPresentationMsgType, r.Type)
+				require.Len(t, r.ReasonAttach, 1)
+				require.Equal(t, ReasonAttachmentID, r.ReasonAttach[0].ID)
+
+				reason, ok := r.ReasonAttach[0].Data.JSON.(*RerequestReason)
+				require.True(t, ok)
+				require.Equal(t, "expired credential", reason.Reason)
+
+				return nil
+			})
+
+		store.EXPECT().Get(gomock.Any()).Return([]byte("request-sent"), nil)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil)
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			require.Equal(t, "presentation-received", string(name))
+
+			return nil
+		})
+
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			defer close(done)
+
+			require.Equal(t, "request-sent", string(name))
+
+			return nil
+		})
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		ch := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(ch))
+
+		_, err = svc.HandleInbound(randomInboundMessage(PresentationMsgType), Alice, Bob)
+		require.NoError(t, err)
+
+		(<-ch).Continue(WithRerequestPresentation(&RequestPresentation{}, "expired credential"))
+
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Second):
+			t.Error("timeout")
+		}
+	})
+
+	t.Run("Receive Presentation (re-request without request)", func(t *testing.T) {
+		var done = make(chan struct{})
+
+		messenger.EXPECT().
+			ReplyToNested(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Do(func(_ string, msg service.DIDCommMsgMap, myDID, theirDID string) error {
+				r := &model.ProblemReport{}
+				require.NoError(t, msg.Decode(r))
+				require.Equal(t, codeInternalError, r.Description.Code)
+
+				return nil
+			})
+
+		store.EXPECT().Get(gomock.Any()).Return([]byte("request-sent"), nil)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil)
+		store.EXPECT().Delete(gomock.Any()).Return(nil)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			require.Equal(t, "abandoning", string(name))
+
+			return nil
+		})
+
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, name []byte) error {
+			defer close(done)
+
+			require.Equal(t, "done", string(name))
+
+			return nil
+		})
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		ch := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(ch))
+
+		_, err = svc.HandleInbound(randomInboundMessage(PresentationMsgType), Alice, Bob)
+		require.NoError(t, err)
+
+		(<-ch).Continue(WithRerequestPresentation(nil, "expired credential"))
+
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Second):
+			t.Error("timeout")
+		}
+	})
+
+	t.Run("Receive Presentation (verification results)", func(t *testing.T) {
+		store.EXPECT().Get(gomock.Any()).Return([]byte("request-sent"), nil)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		ch := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(ch))
+
+		msg := service.NewDIDCommMsgMap(struct {
+			ID            string                 `json:"@id"`
+			Thread        decorator.Thread       `json:"~thread"`
+			Type          string                 `json:"@type"`
+			Presentations []decorator.Attachment `json:"presentations~attach"`
+		}{
+			ID:            uuid.New().String(),
+			Thread:        decorator.Thread{ID: uuid.New().String()},
+			Type:          PresentationMsgType,
+			Presentations: []decorator.Attachment{{ID: "degree", Data: decorator.AttachmentData{Base64: "invalid"}}},
+		})
+		msg.Metadata()[metaRequestedPresentations] = []interface{}{"degree", "address"}
+
+		_, err = svc.HandleInbound(msg, Alice, Bob)
+		require.NoError(t, err)
+
+		props, ok := (<-ch).Properties.(*eventProps)
+		require.True(t, ok)
+
+		results := props.VerificationResults()
+		require.Len(t, results, 2)
+		require.Equal(t, "degree", results[0].ID)
+		require.False(t, results[0].Verified)
+		require.Contains(t, results[0].Error, "decode string")
+		require.Equal(t, VerificationResult{ID: "address", Error: "presentation was not provided"}, results[1])
+	})
+
+	t.Run("Receive Presentation (verified off the inbound path)", func(t *testing.T) {
+		store.EXPECT().Get(gomock.Any()).Return([]byte("request-sent"), nil)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil)
+
+		svc, err := New(provider)
+		require.NoError(t, err)
+
+		svc.SetVerificationWorkers(1)
+
+		ch := make(chan service.DIDCommAction, 1)
+		require.NoError(t, svc.RegisterActionEvent(ch))
+
+		msg := service.NewDIDCommMsgMap(struct {
+			ID            string                 `json:"@id"`
+			Thread        decorator.Thread       `json:"~thread"`
+			Type          string                 `json:"@type"`
+			Presentations []decorator.Attachment `json:"presentations~attach"`
+		}{
+			ID:            uuid.New().String(),
+			Thread:        decorator.Thread{ID: uuid.New().String()},
+			Type:          PresentationMsgType,
+			Presentations: []decorator.Attachment{{ID: "degree", Data: decorator.AttachmentData{Base64: "invalid"}}},
+		})
+
+		// the only worker is busy, the presentation can't be verified yet
+		svc.verificationPool.workers <- struct{}{}
+
+		_, err = svc.HandleInbound(msg, Alice, Bob)
+		require.NoError(t, err)
+
+		select {
+		case <-ch:
+			t.Fatal("action triggered before the verification")
+		default:
+		}
+
+		<-svc.verificationPool.workers
+
+		select {
+		case action := <-ch:
+			props, ok := action.Properties.(*eventProps)
+			require.True(t, ok)
+			require.Len(t, props.VerificationResults(), 1)
+		case <-time.After(time.Second):
+			t.Error("timeout")
+		}
+	})
+
 	t.Run("Receive Ack", func(t *testing.T) {
 		var done = make(chan struct{})
 
@@ -779,6 +1176,26 @@ func Test_stateFromName(t *testing.T) {
 	require.Equal(t, stateFromName("unknown"), &noOp{})
 }
 
+func TestService_InstancesByState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := newPauseService(t, ctrl, mem.NewProvider())
+
+	instances, err := svc.InstancesByState()
+	require.NoError(t, err)
+	require.Empty(t, instances)
+
+	require.NoError(t, svc.saveStateName("piID-1", stateNameRequestSent))
+	require.NoError(t, svc.saveStateName("piID-2", stateNameRequestSent))
+	require.NoError(t, svc.saveStateName("piID-3", stateNamePresentationReceived))
+	require.NoError(t, svc.saveStateName("piID-4", stateNameDone))
+
+	instances, err = svc.InstancesByState()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{stateNameRequestSent: 2, stateNamePresentationReceived: 1}, instances)
+}
+
 func TestService_Name(t *testing.T) {
 	require.Equal(t, (*Service).Name(nil), Name)
 }
@@ -816,8 +1233,9 @@ func Test_getTransitionalPayload(t *testing.T) {
 
 	storeProvider := storageMocks.NewMockProvider(ctrl)
 	storeProvider.EXPECT().OpenStore(Name).Return(store, nil).AnyTimes()
+	storeProvider.EXPECT().OpenStore(connection.Namespace).DoAndReturn(mem.NewProvider().OpenStore).AnyTimes()
 
-	provider := presentproofMocks.NewMockProvider(ctrl)
+	provider := newMockProvider(ctrl)
 	provider.EXPECT().Messenger().Return(nil).AnyTimes()
 	provider.EXPECT().StorageProvider().Return(storeProvider).AnyTimes()
 	provider.EXPECT().VDRIRegistry().Return(nil).AnyTimes()
@@ -869,3 +1287,48 @@ func Test_nextState(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, next)
 }
+
+func TestService_Callbacks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, messenger := newMemStoreService(t, ctrl)
+
+	var actions []service.DIDCommAction
+
+	require.NoError(t, svc.RegisterActionCallback(func(action service.DIDCommAction) {
+		actions = append(actions, action)
+	}))
+
+	done := make(chan struct{})
+
+	unregister, err := svc.RegisterMsgCallback(func(msg service.StateMsg) {
+		if msg.StateID == stateNameDone && msg.Type == service.PostState {
+			close(done)
+		}
+	})
+	require.NoError(t, err)
+
+	defer unregister()
+
+	// the action callback is invoked before HandleInbound returns
+	_, err = svc.HandleInbound(randomInboundMessage(RequestPresentationMsgType), Alice, Bob)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	require.Equal(t, RequestPresentationMsgType, actions[0].Message.Type())
+
+	messenger.EXPECT().ReplyToNested(gomock.Any(), gomock.Any(), Alice, Bob).Return(nil)
+
+	actions[0].Stop(errors.New("declined"))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	svc.UnregisterActionCallback()
+
+	_, err = svc.HandleInbound(randomInboundMessage(RequestPresentationMsgType), Alice, Bob)
+	require.Equal(t, errNoClients, err)
+}
@@ -11,11 +11,11 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
-	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 )
 
 const (
@@ -36,14 +36,73 @@ const (
 	stateNameProposalSent     = "proposal-sent"
 )
 
+const (
+	// RoleProver is the role of the agent presenting a proof.
+	RoleProver = "prover"
+	// RoleVerifier is the role of the agent requesting and verifying a proof.
+	RoleVerifier = "verifier"
+)
+
 const (
 	// error codes
 	codeInternalError = "internal"
 	codeRejectedError = "rejected"
+	codeTimeoutError  = "timeout"
 
 	jsonThread = "~thread"
+
+	// metadata key of the IDs of the presentations requested by the Verifier
+	metaRequestedPresentations = "requested_presentations"
 )
 
+// the attachment formats supported by the service, the formats declared by the other agent are intersected
+// with them: the Prover presents the requested presentations of the supported formats and the Verifier
+// verifies the presentations of the supported formats.
+// nolint:gochecknoglobals
+var (
+	requestFormats      = attachformat.MustNewSupported(attachformat.DIFPresentationDefinitions)
+	presentationFormats = attachformat.MustNewSupported(attachformat.DIFPresentationSubmission)
+)
+
+// RequestedPresentations returns the requested presentations of the formats supported by the Prover, with
+// their formats. All the requested presentations of a request declaring no format are returned, without format.
+func RequestedPresentations(request *RequestPresentation) ([]decorator.Attachment,
+	[]decorator.AttachmentFormat, error) {
+	return requestFormats.Filter(request.Formats, request.RequestPresentations)
+}
+
+// supportedPresentations returns the presentations of the formats supported by the Verifier.
+func supportedPresentations(presentation *Presentation) ([]decorator.Attachment, error) {
+	attachments, _, err := presentationFormats.Filter(presentation.Formats, presentation.Presentations)
+	if err != nil {
+		return nil, fmt.Errorf("presentation formats: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// RoleFilter returns a filter of the state-change events keeping the events of the given role,
+// to be used with service.WithStateFilter. The common states (start, done, abandoning...) are kept for both roles.
+func RoleFilter(role string) func(service.StateMsg) bool {
+	return func(msg service.StateMsg) bool {
+		r := roleOf(msg.StateID)
+
+		return r == "" || r == role
+	}
+}
+
+// roleOf returns the role of the agent in the given state, an empty string for the common states.
+func roleOf(stateName string) string {
+	switch stateName {
+	case stateNameRequestSent, stateNamePresentationReceived, stateNameProposalReceived:
+		return RoleVerifier
+	case stateNameRequestReceived, stateNamePresentationSent, stateNameProposalSent:
+		return RoleProver
+	default:
+		return ""
+	}
+}
+
 // state action for network call
 type stateAction func(messenger service.Messenger) error
 
@@ -104,12 +163,7 @@ func (s *abandoning) Execute(md *metaData) (state, stateAction, error) {
 		return &done{}, zeroAction, nil
 	}
 
-	var code = model.Code{Code: s.Code}
-
-	// if the protocol was stopped by the user we will set the rejected error code
-	if errors.As(md.err, &customError{}) {
-		code = model.Code{Code: codeRejectedError}
-	}
+	var code = model.Code{Code: s.problemCode(md)}
 
 	thID, err := md.Msg.ThreadID()
 	if err != nil {
@@ -124,6 +178,16 @@ func (s *abandoning) Execute(md *metaData) (state, stateAction, error) {
 	}, nil
 }
 
+// problemCode returns the code of the problem-report sent to the other agent or, if the exchange was
+// abandoned by the other agent, the code of the received problem-report.
+func (s *abandoning) problemCode(md *metaData) string {
+	if s.Code == "" {
+		return receivedProblemCode(md.Msg)
+	}
+
+	return problemCode(s.Code, md.err)
+}
+
 // done state
 type done struct{}
 
@@ -196,6 +260,19 @@ func forwardInitial(md *metaData) stateAction {
 
 func (s *requestSent) Execute(md *metaData) (state, stateAction, error) {
 	if !canReplyTo(md.Msg) {
+		var request = RequestPresentation{}
+		if err := md.Msg.Decode(&request); err != nil {
+			return nil, nil, fmt.Errorf("decode: %w", err)
+		}
+
+		requested, err := requestedIDs(&request)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		setRequestedIDs(md.Msg, requested)
+		setProofRequests(md.Msg, &request)
+
 		return &noOp{}, forwardInitial(md), nil
 	}
 
@@ -203,12 +280,84 @@ func (s *requestSent) Execute(md *metaData) (state, stateAction, error) {
 		return nil, nil, errors.New("request was not provided")
 	}
 
+	requested, err := requestedIDs(md.request)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	return &noOp{}, func(messenger service.Messenger) error {
 		md.request.Type = RequestPresentationMsgType
-		return messenger.ReplyTo(md.Msg.ID(), service.NewDIDCommMsgMap(md.request))
+
+		msg := service.NewDIDCommMsgMap(md.request)
+		setRequestedIDs(msg, requested)
+		setProofRequests(msg, md.request)
+
+		return messenger.ReplyTo(md.Msg.ID(), msg)
 	}, nil
 }
 
+// requestedIDs returns the IDs of the requested presentations.
+// When several presentations are requested, the attachments must have unique IDs.
+func requestedIDs(request *RequestPresentation) ([]string, error) {
+	if request.RequestedCount < RequestAllAvailable {
+		return nil, fmt.Errorf("invalid requested count: %d", request.RequestedCount)
+	}
+
+	var (
+		ids  []string
+		seen = map[string]struct{}{}
+	)
+
+	for _, attachment := range request.RequestPresentations {
+		if attachment.ID == "" {
+			if len(request.RequestPresentations) > 1 {
+				return nil, errors.New("several presentations are requested: attachment ID is mandatory")
+			}
+
+			continue
+		}
+
+		if _, ok := seen[attachment.ID]; ok {
+			return nil, fmt.Errorf("duplicate requested presentation ID: %s", attachment.ID)
+		}
+
+		seen[attachment.ID] = struct{}{}
+
+		ids = append(ids, attachment.ID)
+	}
+
+	return ids, nil
+}
+
+// setRequestedIDs keeps the IDs of the requested presentations in the metadata of the request,
+// the messenger adds them to the presentation received in the same thread.
+func setRequestedIDs(msg service.DIDCommMsgMap, ids []string) {
+	if metadata := msg.Metadata(); metadata != nil && len(ids) > 0 {
+		metadata[metaRequestedPresentations] = ids
+	}
+}
+
+// getRequestedIDs returns the IDs of the requested presentations from the metadata of the received presentation.
+func getRequestedIDs(msg service.DIDCommMsgMap) []string {
+	switch ids := msg.Metadata()[metaRequestedPresentations].(type) {
+	case []string:
+		return ids
+	case []interface{}:
+		// the metadata were restored from the store
+		var result []string
+
+		for _, id := range ids {
+			if v, ok := id.(string); ok {
+				result = append(result, v)
+			}
+		}
+
+		return result
+	default:
+		return nil
+	}
+}
+
 // presentationSent the Prover's state
 type presentationSent struct{}
 
@@ -217,7 +366,9 @@ func (s *presentationSent) Name() string {
 }
 
 func (s *presentationSent) CanTransitionTo(st state) bool {
-	return st.Name() == stateNameAbandoning ||
+	// the Verifier may request the presentations again after a failed verification
+	return st.Name() == stateNameRequestReceived ||
+		st.Name() == stateNameAbandoning ||
 		st.Name() == stateNameDone
 }
 
@@ -226,16 +377,77 @@ func (s *presentationSent) Execute(md *metaData) (state, stateAction, error) {
 		return nil, nil, errors.New("presentation was not provided")
 	}
 
+	var request = RequestPresentation{}
+	if err := md.Msg.Decode(&request); err != nil {
+		return nil, nil, fmt.Errorf("decode: %w", err)
+	}
+
+	if err := matchRequested(&request, md.presentation); err != nil {
+		return nil, nil, fmt.Errorf("match requested presentations: %w", err)
+	}
+
 	// creates the state's action
 	action := func(messenger service.Messenger) error {
 		// sets message type
 		md.presentation.Type = PresentationMsgType
+
+		logger.Debugf("send presentations of %s: %s", md.PIID, verifiable.RedactedMessage(md.presentation))
+
 		return messenger.ReplyTo(md.Msg.ID(), service.NewDIDCommMsgMap(md.presentation))
 	}
 
 	return &noOp{}, action, nil
 }
 
+// matchRequested checks that the presentations answer the requested ones when these have IDs.
+// A single presentation without ID answering a single requested presentation gets the requested ID.
+// Some requested presentations may not be provided, the Verifier reports them as missing.
+func matchRequested(request *RequestPresentation, presentation *Presentation) error {
+	requested, err := requestedIDs(request)
+	if err != nil {
+		return err
+	}
+
+	if len(requested) == 0 {
+		return nil
+	}
+
+	if len(requested) == 1 && len(presentation.Presentations) == 1 && presentation.Presentations[0].ID == "" {
+		presentation.Presentations[0].ID = requested[0]
+
+		return nil
+	}
+
+	var (
+		requestedSet = toSet(requested)
+		provided     = map[string]struct{}{}
+	)
+
+	for _, attachment := range presentation.Presentations {
+		if _, ok := requestedSet[attachment.ID]; !ok {
+			return fmt.Errorf("presentation %q was not requested", attachment.ID)
+		}
+
+		if _, ok := provided[attachment.ID]; ok {
+			return fmt.Errorf("presentation %q is provided more than once", attachment.ID)
+		}
+
+		provided[attachment.ID] = struct{}{}
+	}
+
+	return nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+
+	return set
+}
+
 // presentationReceived the Verifier's state
 type presentationReceived struct{}
 
@@ -244,37 +456,146 @@ func (s *presentationReceived) Name() string {
 }
 
 func (s *presentationReceived) CanTransitionTo(st state) bool {
-	return st.Name() == stateNameAbandoning ||
+	return st.Name() == stateNameRequestSent ||
+		st.Name() == stateNameAbandoning ||
 		st.Name() == stateNameDone
 }
 
-func verifyPresentation(registryVDRI vdri.Registry, attachments []decorator.Attachment) error {
+func verifyPresentation(opts []verifiable.PresentationOpt,
+	attachment *decorator.Attachment) (*verifiable.Presentation, error) {
 	// TODO: Currently, it supports only base64 payload. We need to add support for links and JSON as well. [Issue 1455]
+	raw, err := base64.StdEncoding.DecodeString(attachment.Data.Base64)
+	if err != nil {
+		return nil, fmt.Errorf("decode string: %w", err)
+	}
+
+	vp, err := verifiable.NewPresentation(raw, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("new presentation: %w", err)
+	}
+
+	logger.Debugf("verified presentation %s: %s", attachment.ID, verifiable.RedactedPresentation(vp))
+
+	return vp, nil
+}
+
+// verifyPresentations verifies each presentation, the requested presentations which were not provided
+// and the provided presentations which were not requested are reported as not verified.
+// A presentation answering a proof request must satisfy it, its credentials are verified with the credential
+// options, see checkProofRequest.
+// The presentations are verified concurrently by the workers of the pool, the verified presentations are returned
+// in the order of the attachments. The verifications failing because of a transient DID resolution error are
+// retried, see SetVerificationRetries.
+func verifyPresentations(pool *verificationPool, opts []verifiable.PresentationOpt,
+	credentialOpts []verifiable.CredentialOpt, requested []string, requests map[string]*ProofRequest,
+	attachments []decorator.Attachment) ([]VerificationResult, []*verifiable.Presentation) {
+	var (
+		results       = make([]VerificationResult, len(attachments))
+		presentations = make([]*verifiable.Presentation, len(attachments))
+		requestedSet  = toSet(requested)
+		provided      = map[string]struct{}{}
+		verified      []int
+	)
+
 	for i := range attachments {
-		raw, err := base64.StdEncoding.DecodeString(attachments[i].Data.Base64)
-		if err != nil {
-			return fmt.Errorf("decode string: %w", err)
+		results[i].ID = attachments[i].ID
+		provided[results[i].ID] = struct{}{}
+
+		if _, ok := requestedSet[results[i].ID]; !ok && len(requested) > 0 {
+			results[i].Error = "presentation was not requested"
+
+			continue
 		}
 
-		_, err = verifiable.NewPresentation(raw, verifiable.WithPresPublicKeyFetcher(
-			verifiable.NewDIDKeyResolver(registryVDRI).PublicKeyFetcher(),
-		))
+		verified = append(verified, i)
+	}
+
+	pool.verify(len(verified), func(j int) error {
+		i := verified[j]
+
+		vp, err := verifyPresentation(opts, &attachments[i])
 		if err != nil {
-			return fmt.Errorf("new presentation: %w", err)
+			results[i].Error = err.Error()
+			results[i].Transient = isTransient(err)
+
+			return err
+		}
+
+		if request, ok := requests[attachments[i].ID]; ok {
+			if err := checkProofRequest(request, vp, credentialOpts); err != nil {
+				results[i].Error = fmt.Sprintf("proof request: %s", err)
+				results[i].Transient = false
+
+				return err
+			}
+		}
+
+		results[i].Verified = true
+		results[i].Error = ""
+		results[i].Transient = false
+		presentations[i] = vp
+
+		return nil
+	})
+
+	var verifiedPresentations []*verifiable.Presentation
+
+	for _, vp := range presentations {
+		if vp != nil {
+			verifiedPresentations = append(verifiedPresentations, vp)
 		}
 	}
 
-	return nil
+	for _, id := range requested {
+		if _, ok := provided[id]; !ok {
+			results = append(results, VerificationResult{ID: id, Error: "presentation was not provided"})
+		}
+	}
+
+	return results, verifiedPresentations
 }
 
 func (s *presentationReceived) Execute(md *metaData) (state, stateAction, error) {
+	// the Verifier requests the presentations again in the same thread
+	if md.request != nil {
+		attachReason(md)
+
+		return &requestSent{}, zeroAction, nil
+	}
+
 	var presentation = Presentation{}
 	if err := md.Msg.Decode(&presentation); err != nil {
 		return nil, nil, fmt.Errorf("decode: %w", err)
 	}
 
-	if err := verifyPresentation(md.registryVDRI, presentation.Presentations); err != nil {
-		return nil, nil, fmt.Errorf("verify presentation: %w", err)
+	// the presentations were already verified if an action event was triggered
+	results := md.VerificationResults
+	if results == nil {
+		presentations, err := supportedPresentations(&presentation)
+		if err != nil {
+			return nil, nil, causeError{error: err, cause: ErrVerificationFailed}
+		}
+
+		results, _ = verifyPresentations(md.verificationPool, md.presentationOpts, md.credentialOpts,
+			getRequestedIDs(md.Msg), getProofRequests(md.Msg), presentations)
+	}
+
+	for _, result := range results {
+		if result.Verified {
+			continue
+		}
+
+		if result.ID == "" {
+			return nil, nil, causeError{
+				error: fmt.Errorf("verify presentation: %s", result.Error),
+				cause: ErrVerificationFailed,
+			}
+		}
+
+		return nil, nil, causeError{
+			error: fmt.Errorf("verify presentation %s: %s", result.ID, result.Error),
+			cause: ErrVerificationFailed,
+		}
 	}
 
 	// creates the state's action
@@ -287,6 +608,18 @@ func (s *presentationReceived) Execute(md *metaData) (state, stateAction, error)
 	return &done{}, action, nil
 }
 
+// attachReason attaches the reason of the Verifier requesting the presentations again to the request.
+func attachReason(md *metaData) {
+	md.request.ReasonAttach = []decorator.Attachment{{
+		ID:       ReasonAttachmentID,
+		MimeType: "application/json",
+		Data: decorator.AttachmentData{JSON: &RerequestReason{
+			Reason:              md.rerequestReason,
+			VerificationResults: md.VerificationResults,
+		}},
+	}}
+}
+
 // proposalSent the Prover's state
 type proposalSent struct{}
 
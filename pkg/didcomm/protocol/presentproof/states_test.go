@@ -10,18 +10,26 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/attachformat"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
 	vdriMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
 )
 
 func TestStart_CanTransitionTo(t *testing.T) {
@@ -286,6 +294,92 @@ func TestRequestSent_Execute(t *testing.T) {
 	})
 }
 
+func TestRequestSent_Execute_RequestedPresentations(t *testing.T) {
+	t.Run("Requested IDs kept in the metadata (outbound)", func(t *testing.T) {
+		msg := service.NewDIDCommMsgMap(RequestPresentation{
+			Type:                 RequestPresentationMsgType,
+			RequestPresentations: []decorator.Attachment{{ID: "degree"}, {ID: "address"}},
+		})
+
+		followup, action, err := (&requestSent{}).Execute(&metaData{transitionalPayload: transitionalPayload{Msg: msg}})
+		require.NoError(t, err)
+		require.Equal(t, &noOp{}, followup)
+		require.NotNil(t, action)
+		require.Equal(t, []string{"degree", "address"}, getRequestedIDs(msg))
+	})
+
+	t.Run("Requested IDs kept in the metadata", func(t *testing.T) {
+		followup, action, err := (&requestSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: randomInboundMessage("")},
+			request:             &RequestPresentation{RequestPresentations: []decorator.Attachment{{ID: "degree"}}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &noOp{}, followup)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		messenger := serviceMocks.NewMockMessenger(ctrl)
+		messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any()).
+			Do(func(_ string, msg service.DIDCommMsgMap) error {
+				require.Equal(t, []string{"degree"}, getRequestedIDs(msg))
+
+				return nil
+			})
+
+		require.NoError(t, action(messenger))
+	})
+
+	t.Run("Attachment ID is mandatory (outbound)", func(t *testing.T) {
+		followup, action, err := (&requestSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: service.NewDIDCommMsgMap(RequestPresentation{
+				RequestPresentations: []decorator.Attachment{{ID: "degree"}, {}},
+			})},
+		})
+		require.EqualError(t, err, "several presentations are requested: attachment ID is mandatory")
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+
+	t.Run("Duplicate attachment ID", func(t *testing.T) {
+		followup, action, err := (&requestSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: randomInboundMessage("")},
+			request: &RequestPresentation{
+				RequestPresentations: []decorator.Attachment{{ID: "degree"}, {ID: "degree"}},
+			},
+		})
+		require.EqualError(t, err, "duplicate requested presentation ID: degree")
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+
+	t.Run("Invalid requested count", func(t *testing.T) {
+		followup, action, err := (&requestSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: randomInboundMessage("")},
+			request:             &RequestPresentation{RequestedCount: -2},
+		})
+		require.EqualError(t, err, "invalid requested count: -2")
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+
+	t.Run("Decode error (outbound)", func(t *testing.T) {
+		followup, action, err := (&requestSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: service.DIDCommMsgMap{"@type": map[int]int{}}},
+		})
+		require.Contains(t, fmt.Sprintf("%v", err), "got unconvertible type")
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+}
+
+func Test_getRequestedIDs(t *testing.T) {
+	require.Nil(t, getRequestedIDs(service.DIDCommMsgMap{}))
+	require.Equal(t, []string{"degree"}, getRequestedIDs(service.DIDCommMsgMap{
+		"_internal_metadata": map[string]interface{}{metaRequestedPresentations: []interface{}{"degree", 1}},
+	}))
+}
+
 func TestPresentationSent_CanTransitionTo(t *testing.T) {
 	st := &presentationSent{}
 	require.Equal(t, stateNamePresentationSent, st.Name())
@@ -299,7 +393,7 @@ func TestPresentationSent_CanTransitionTo(t *testing.T) {
 	require.False(t, st.CanTransitionTo(&presentationReceived{}))
 	require.False(t, st.CanTransitionTo(&proposalReceived{}))
 	// states for Prover
-	require.False(t, st.CanTransitionTo(&requestReceived{}))
+	require.True(t, st.CanTransitionTo(&requestReceived{}))
 	require.False(t, st.CanTransitionTo(&presentationSent{}))
 	require.False(t, st.CanTransitionTo(&proposalSent{}))
 }
@@ -328,6 +422,133 @@ func TestPresentationSent_Execute(t *testing.T) {
 	})
 }
 
+func TestPresentations_RedactedLogs(t *testing.T) {
+	log.SetLevel(logModule, log.DEBUG)
+	defer log.SetLevel(logModule, log.INFO)
+
+	logRecorder.Reset()
+
+	vp := newPresentation(t, unsignedCredential("http://example.edu/credentials/1", map[string]interface{}{
+		"id":   "did:example:holder",
+		"name": "Alice Smith",
+	}))
+	vp.Context = []string{"https://www.w3.org/2018/credentials/v1"}
+	vp.Type = []string{"VerifiablePresentation"}
+	vp.Holder = "did:example:holder"
+
+	claims, err := vp.JWTClaims(nil, false)
+	require.NoError(t, err)
+
+	jws, err := claims.MarshalJWS(verifiable.EdDSA, credentialSigner, vp.Holder+"#key-1")
+	require.NoError(t, err)
+
+	attachment := decorator.Attachment{ID: "1", Data: decorator.AttachmentData{
+		Base64: base64.StdEncoding.EncodeToString([]byte(jws)),
+	}}
+
+	// the Prover sends the presentation
+	_, action, err := (&presentationSent{}).Execute(&metaData{
+		transitionalPayload: transitionalPayload{PIID: "piid"},
+		presentation:        &Presentation{Presentations: []decorator.Attachment{attachment}},
+	})
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messenger := serviceMocks.NewMockMessenger(ctrl)
+	messenger.EXPECT().ReplyTo(gomock.Any(), gomock.Any())
+
+	require.NoError(t, action(messenger))
+
+	// the Verifier verifies it
+	pubKey, _ := credentialSigner.privKey.Public().(ed25519.PublicKey) // nolint: errcheck
+
+	_, err = verifyPresentation([]verifiable.PresentationOpt{
+		verifiable.WithPresPublicKeyFetcher(verifiable.SingleKey(pubKey, kms.ED25519)),
+	}, &attachment)
+	require.NoError(t, err)
+
+	lines := logRecorder.Lines(logModule)
+	require.Len(t, lines, 2)
+
+	// the presentation defined as JWT is redacted as a whole
+	require.True(t, strings.HasPrefix(lines[0], "DEBUG send presentations of piid: "))
+	require.Contains(t, lines[0], `"data":{"json":"***"}`)
+	require.True(t, strings.HasPrefix(lines[1], "DEBUG verified presentation 1: "))
+	require.Contains(t, lines[1], `"credentialSubject":{"id":"***","name":"***"}`)
+
+	for _, line := range lines {
+		require.NotContains(t, line, "Alice Smith")
+		require.NotContains(t, line, jws)
+	}
+}
+
+func TestPresentationSent_Execute_RequestedPresentations(t *testing.T) {
+	request := service.NewDIDCommMsgMap(RequestPresentation{
+		RequestPresentations: []decorator.Attachment{{ID: "degree"}, {ID: "address"}},
+	})
+
+	t.Run("Presentations match the requested ones", func(t *testing.T) {
+		followup, _, err := (&presentationSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: request},
+			presentation:        &Presentation{Presentations: []decorator.Attachment{{ID: "address"}}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &noOp{}, followup)
+	})
+
+	t.Run("Single presentation gets the requested ID", func(t *testing.T) {
+		presentation := &Presentation{Presentations: []decorator.Attachment{{}}}
+
+		_, _, err := (&presentationSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: service.NewDIDCommMsgMap(RequestPresentation{
+				RequestPresentations: []decorator.Attachment{{ID: "degree"}},
+			})},
+			presentation: presentation,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "degree", presentation.Presentations[0].ID)
+	})
+
+	t.Run("Presentation was not requested", func(t *testing.T) {
+		followup, action, err := (&presentationSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: request},
+			presentation:        &Presentation{Presentations: []decorator.Attachment{{ID: "degree"}, {ID: "name"}}},
+		})
+		require.EqualError(t, err, `match requested presentations: presentation "name" was not requested`)
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+
+	t.Run("Presentation provided more than once", func(t *testing.T) {
+		_, _, err := (&presentationSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: request},
+			presentation:        &Presentation{Presentations: []decorator.Attachment{{ID: "degree"}, {ID: "degree"}}},
+		})
+		require.EqualError(t, err, `match requested presentations: presentation "degree" is provided more than once`)
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		_, _, err := (&presentationSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: service.NewDIDCommMsgMap(RequestPresentation{
+				RequestPresentations: []decorator.Attachment{{}, {}},
+			})},
+			presentation: &Presentation{},
+		})
+		require.EqualError(t, err,
+			"match requested presentations: several presentations are requested: attachment ID is mandatory")
+	})
+
+	t.Run("Decode error", func(t *testing.T) {
+		_, _, err := (&presentationSent{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: service.DIDCommMsgMap{"@type": map[int]int{}}},
+			presentation:        &Presentation{},
+		})
+		require.Contains(t, fmt.Sprintf("%v", err), "got unconvertible type")
+	})
+}
+
 func TestPresentationReceived_CanTransitionTo(t *testing.T) {
 	st := &presentationReceived{}
 	require.Equal(t, stateNamePresentationReceived, st.Name())
@@ -337,7 +558,7 @@ func TestPresentationReceived_CanTransitionTo(t *testing.T) {
 	require.True(t, st.CanTransitionTo(&done{}))
 	require.False(t, st.CanTransitionTo(&noOp{}))
 	// states for Verifier
-	require.False(t, st.CanTransitionTo(&requestSent{}))
+	require.True(t, st.CanTransitionTo(&requestSent{}))
 	require.False(t, st.CanTransitionTo(&presentationReceived{}))
 	require.False(t, st.CanTransitionTo(&proposalReceived{}))
 	// states for Prover
@@ -346,6 +567,18 @@ func TestPresentationReceived_CanTransitionTo(t *testing.T) {
 	require.False(t, st.CanTransitionTo(&proposalSent{}))
 }
 
+// vpJWS is a presentation signed by the key-1 of did:example:ebfeb1f712ebc6f1c276e12ec21 (see vpSignerDoc).
+const vpJWS = "eyJhbGciOiJFZERTQSIsImtpZCI6ImtleS0xIiwidHlwIjoiSldUIn0.eyJpc3MiOiJkaWQ6ZXhhbXBsZTplYmZlYjFmNzEyZWJjNmYxYzI3NmUxMmVjMjEiLCJqdGkiOiJ1cm46dXVpZDozOTc4MzQ0Zi04NTk2LTRjM2EtYTk3OC04ZmNhYmEzOTAzYzUiLCJ2cCI6eyJAY29udGV4dCI6WyJodHRwczovL3d3dy53My5vcmcvMjAxOC9jcmVkZW50aWFscy92MSIsImh0dHBzOi8vd3d3LnczLm9yZy8yMDE4L2NyZWRlbnRpYWxzL2V4YW1wbGVzL3YxIl0sInR5cGUiOlsiVmVyaWZpYWJsZVByZXNlbnRhdGlvbiIsIlVuaXZlcnNpdHlEZWdyZWVDcmVkZW50aWFsIl0sInZlcmlmaWFibGVDcmVkZW50aWFsIjpbeyJAY29udGV4dCI6WyJodHRwczovL3d3dy53My5vcmcvMjAxOC9jcmVkZW50aWFscy92MSIsImh0dHBzOi8vd3d3LnczLm9yZy8yMDE4L2NyZWRlbnRpYWxzL2V4YW1wbGVzL3YxIl0sImNyZWRlbnRpYWxTY2hlbWEiOltdLCJjcmVkZW50aWFsU3ViamVjdCI6eyJkZWdyZWUiOnsidHlwZSI6IkJhY2hlbG9yRGVncmVlIiwidW5pdmVyc2l0eSI6Ik1JVCJ9LCJpZCI6ImRpZDpleGFtcGxlOmViZmViMWY3MTJlYmM2ZjFjMjc2ZTEyZWMyMSIsIm5hbWUiOiJKYXlkZW4gRG9lIiwic3BvdXNlIjoiZGlkOmV4YW1wbGU6YzI3NmUxMmVjMjFlYmZlYjFmNzEyZWJjNmYxIn0sImV4cGlyYXRpb25EYXRlIjoiMjAyMC0wMS0wMVQxOToyMzoyNFoiLCJpZCI6Imh0dHA6Ly9leGFtcGxlLmVkdS9jcmVkZW50aWFscy8xODcyIiwiaXNzdWFuY2VEYXRlIjoiMjAxMC0wMS0wMVQxOToyMzoyNFoiLCJpc3N1ZXIiOnsiaWQiOiJkaWQ6ZXhhbXBsZTo3NmUxMmVjNzEyZWJjNmYxYzIyMWViZmViMWYiLCJuYW1lIjoiRXhhbXBsZSBVbml2ZXJzaXR5In0sInJlZmVyZW5jZU51bWJlciI6OC4zMjk0ODQ3ZSswNywidHlwZSI6WyJWZXJpZmlhYmxlQ3JlZGVudGlhbCIsIlVuaXZlcnNpdHlEZWdyZWVDcmVkZW50aWFsIl19XX19.RlO_1B-7qhQNwo2mmOFUWSa8A6hwaJrtq3q7yJDkKq4k6B-EJ-oyLNM6H_g2_nko2Yg9Im1CiROFm6nK12U_AQ" //nolint:lll
+
+func vpSignerDoc() *did.Doc {
+	return &did.Doc{
+		PublicKey: []did.PublicKey{{
+			ID:    "key-1",
+			Value: []byte{61, 133, 23, 17, 77, 132, 169, 196, 47, 203, 19, 71, 145, 144, 92, 145, 131, 101, 36, 251, 89, 216, 117, 140, 132, 226, 78, 187, 59, 58, 200, 255}, //nolint:lll
+		}},
+	}
+}
+
 func TestPresentationReceived_Execute(t *testing.T) {
 	t.Run("Decode error", func(t *testing.T) {
 		followup, action, err := (&presentationReceived{}).Execute(&metaData{
@@ -359,19 +592,29 @@ func TestPresentationReceived_Execute(t *testing.T) {
 		require.Nil(t, action)
 	})
 
+	t.Run("Presentations of unsupported formats", func(t *testing.T) {
+		followup, action, err := (&presentationReceived{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{
+				Msg: service.NewDIDCommMsgMap(Presentation{
+					Formats:       []decorator.AttachmentFormat{{AttachID: "indy", Format: attachformat.HLIndyProof}},
+					Presentations: []decorator.Attachment{{ID: "indy"}},
+				}),
+			},
+		})
+
+		require.True(t, errors.Is(err, ErrVerificationFailed))
+		require.True(t, errors.Is(err, attachformat.ErrNoCommonFormat))
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+
 	t.Run("Success", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		registry := vdriMocks.NewMockRegistry(ctrl)
-		registry.EXPECT().Resolve("did:example:ebfeb1f712ebc6f1c276e12ec21").Return(&did.Doc{
-			PublicKey: []did.PublicKey{{
-				ID:    "key-1",
-				Value: []byte{61, 133, 23, 17, 77, 132, 169, 196, 47, 203, 19, 71, 145, 144, 92, 145, 131, 101, 36, 251, 89, 216, 117, 140, 132, 226, 78, 187, 59, 58, 200, 255}, //nolint:lll
-			}},
-		}, nil)
-
-		vpJWS := "eyJhbGciOiJFZERTQSIsImtpZCI6ImtleS0xIiwidHlwIjoiSldUIn0.eyJpc3MiOiJkaWQ6ZXhhbXBsZTplYmZlYjFmNzEyZWJjNmYxYzI3NmUxMmVjMjEiLCJqdGkiOiJ1cm46dXVpZDozOTc4MzQ0Zi04NTk2LTRjM2EtYTk3OC04ZmNhYmEzOTAzYzUiLCJ2cCI6eyJAY29udGV4dCI6WyJodHRwczovL3d3dy53My5vcmcvMjAxOC9jcmVkZW50aWFscy92MSIsImh0dHBzOi8vd3d3LnczLm9yZy8yMDE4L2NyZWRlbnRpYWxzL2V4YW1wbGVzL3YxIl0sInR5cGUiOlsiVmVyaWZpYWJsZVByZXNlbnRhdGlvbiIsIlVuaXZlcnNpdHlEZWdyZWVDcmVkZW50aWFsIl0sInZlcmlmaWFibGVDcmVkZW50aWFsIjpbeyJAY29udGV4dCI6WyJodHRwczovL3d3dy53My5vcmcvMjAxOC9jcmVkZW50aWFscy92MSIsImh0dHBzOi8vd3d3LnczLm9yZy8yMDE4L2NyZWRlbnRpYWxzL2V4YW1wbGVzL3YxIl0sImNyZWRlbnRpYWxTY2hlbWEiOltdLCJjcmVkZW50aWFsU3ViamVjdCI6eyJkZWdyZWUiOnsidHlwZSI6IkJhY2hlbG9yRGVncmVlIiwidW5pdmVyc2l0eSI6Ik1JVCJ9LCJpZCI6ImRpZDpleGFtcGxlOmViZmViMWY3MTJlYmM2ZjFjMjc2ZTEyZWMyMSIsIm5hbWUiOiJKYXlkZW4gRG9lIiwic3BvdXNlIjoiZGlkOmV4YW1wbGU6YzI3NmUxMmVjMjFlYmZlYjFmNzEyZWJjNmYxIn0sImV4cGlyYXRpb25EYXRlIjoiMjAyMC0wMS0wMVQxOToyMzoyNFoiLCJpZCI6Imh0dHA6Ly9leGFtcGxlLmVkdS9jcmVkZW50aWFscy8xODcyIiwiaXNzdWFuY2VEYXRlIjoiMjAxMC0wMS0wMVQxOToyMzoyNFoiLCJpc3N1ZXIiOnsiaWQiOiJkaWQ6ZXhhbXBsZTo3NmUxMmVjNzEyZWJjNmYxYzIyMWViZmViMWYiLCJuYW1lIjoiRXhhbXBsZSBVbml2ZXJzaXR5In0sInJlZmVyZW5jZU51bWJlciI6OC4zMjk0ODQ3ZSswNywidHlwZSI6WyJWZXJpZmlhYmxlQ3JlZGVudGlhbCIsIlVuaXZlcnNpdHlEZWdyZWVDcmVkZW50aWFsIl19XX19.RlO_1B-7qhQNwo2mmOFUWSa8A6hwaJrtq3q7yJDkKq4k6B-EJ-oyLNM6H_g2_nko2Yg9Im1CiROFm6nK12U_AQ" //nolint:lll
+		registry.EXPECT().Resolve("did:example:ebfeb1f712ebc6f1c276e12ec21").Return(vpSignerDoc(), nil)
+
 		followup, action, err := (&presentationReceived{}).Execute(&metaData{
 			transitionalPayload: transitionalPayload{
 				Msg: service.NewDIDCommMsgMap(Presentation{
@@ -382,7 +625,9 @@ func TestPresentationReceived_Execute(t *testing.T) {
 					}},
 				}),
 			},
-			registryVDRI: registry,
+			presentationOpts: []verifiable.PresentationOpt{
+				verifiable.WithPresPublicKeyFetcher(verifiable.NewDIDKeyResolver(registry).PublicKeyFetcher()),
+			},
 		})
 		require.NoError(t, err)
 		require.Equal(t, &done{}, followup)
@@ -430,11 +675,130 @@ func TestPresentationReceived_Execute(t *testing.T) {
 			},
 		})
 		require.Contains(t, fmt.Sprintf("%v", err), "verify presentation: decode string")
+		require.True(t, errors.Is(err, ErrVerificationFailed))
+		require.Nil(t, followup)
+		require.Nil(t, action)
+	})
+}
+
+func Test_verifyPresentations(t *testing.T) {
+	results, _ := verifyPresentations(nil, nil, nil, []string{"degree", "address"}, nil, []decorator.Attachment{
+		{ID: "degree", Data: decorator.AttachmentData{Base64: "invalid"}},
+		{ID: "name"},
+	})
+
+	require.Len(t, results, 3)
+	require.Equal(t, "degree", results[0].ID)
+	require.False(t, results[0].Verified)
+	require.Contains(t, results[0].Error, "decode string")
+	require.Equal(t, VerificationResult{ID: "name", Error: "presentation was not requested"}, results[1])
+	require.Equal(t, VerificationResult{ID: "address", Error: "presentation was not provided"}, results[2])
+}
+
+func Test_verifyPresentations_TransientFailure(t *testing.T) {
+	attachments := []decorator.Attachment{{
+		ID:   "degree",
+		Data: decorator.AttachmentData{Base64: base64.StdEncoding.EncodeToString([]byte(vpJWS))},
+	}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pool := newVerificationPool(1)
+	pool.backoff = time.Millisecond
+
+	t.Run("Verified after the retries", func(t *testing.T) {
+		registry := vdriMocks.NewMockRegistry(ctrl)
+		gomock.InOrder(
+			registry.EXPECT().Resolve(gomock.Any()).Return(nil, vdriapi.ErrTransient).Times(2),
+			registry.EXPECT().Resolve(gomock.Any()).Return(vpSignerDoc(), nil),
+		)
+
+		results, verified := verifyPresentations(pool, []verifiable.PresentationOpt{
+			verifiable.WithPresPublicKeyFetcher(verifiable.NewDIDKeyResolver(registry).PublicKeyFetcher()),
+		}, nil, nil, nil, attachments)
+		require.Equal(t, []VerificationResult{{ID: "degree", Verified: true}}, results)
+		require.Len(t, verified, 1)
+	})
+
+	t.Run("Transient failure after the retries", func(t *testing.T) {
+		registry := vdriMocks.NewMockRegistry(ctrl)
+		registry.EXPECT().Resolve(gomock.Any()).Return(nil, vdriapi.ErrTransient).Times(3)
+
+		results, verified := verifyPresentations(pool, []verifiable.PresentationOpt{
+			verifiable.WithPresPublicKeyFetcher(verifiable.NewDIDKeyResolver(registry).PublicKeyFetcher()),
+		}, nil, nil, nil, attachments)
+		require.Empty(t, verified)
+		require.Len(t, results, 1)
+		require.False(t, results[0].Verified)
+		require.True(t, results[0].Transient)
+		require.Contains(t, results[0].Error, vdriapi.ErrTransient.Error())
+	})
+
+	t.Run("Resolution failure is not retried", func(t *testing.T) {
+		registry := vdriMocks.NewMockRegistry(ctrl)
+		registry.EXPECT().Resolve(gomock.Any()).Return(nil, vdriapi.ErrNotFound).Times(1)
+
+		results, _ := verifyPresentations(pool, []verifiable.PresentationOpt{
+			verifiable.WithPresPublicKeyFetcher(verifiable.NewDIDKeyResolver(registry).PublicKeyFetcher()),
+		}, nil, nil, nil, attachments)
+		require.False(t, results[0].Verified)
+		require.False(t, results[0].Transient)
+	})
+}
+
+func TestPresentationReceived_Execute_VerificationResults(t *testing.T) {
+	t.Run("Verified", func(t *testing.T) {
+		followup, action, err := (&presentationReceived{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{
+				Msg:                 service.NewDIDCommMsgMap(Presentation{}),
+				VerificationResults: []VerificationResult{{ID: "degree", Verified: true}},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &done{}, followup)
+		require.NotNil(t, action)
+	})
+
+	t.Run("Requested presentation was not provided", func(t *testing.T) {
+		msg := service.NewDIDCommMsgMap(Presentation{})
+		msg.Metadata()[metaRequestedPresentations] = []string{"degree"}
+
+		followup, action, err := (&presentationReceived{}).Execute(&metaData{
+			transitionalPayload: transitionalPayload{Msg: msg},
+		})
+		require.EqualError(t, err, "verify presentation degree: presentation was not provided")
+		require.True(t, errors.Is(err, ErrVerificationFailed))
 		require.Nil(t, followup)
 		require.Nil(t, action)
 	})
 }
 
+func TestPresentationReceived_Execute_Rerequest(t *testing.T) {
+	request := &RequestPresentation{}
+	results := []VerificationResult{{ID: "degree", Error: "credential has expired"}}
+
+	followup, action, err := (&presentationReceived{}).Execute(&metaData{
+		transitionalPayload: transitionalPayload{
+			Msg:                 service.NewDIDCommMsgMap(Presentation{}),
+			VerificationResults: results,
+		},
+		request:         request,
+		rerequestReason: "expired credential",
+	})
+	require.NoError(t, err)
+	require.Equal(t, &requestSent{}, followup)
+	require.NotNil(t, action)
+	require.Equal(t, []decorator.Attachment{{
+		ID:       ReasonAttachmentID,
+		MimeType: "application/json",
+		Data: decorator.AttachmentData{JSON: &RerequestReason{
+			Reason:              "expired credential",
+			VerificationResults: results,
+		}},
+	}}, request.ReasonAttach)
+}
+
 func TestProposePresentationSent_CanTransitionTo(t *testing.T) {
 	st := &proposalSent{}
 	require.Equal(t, stateNameProposalSent, st.Name())
@@ -538,3 +902,22 @@ func notTransition(t *testing.T, st state) {
 		require.False(t, st.CanTransitionTo(s))
 	}
 }
+
+func TestRoleFilter(t *testing.T) {
+	prover, verifier := RoleFilter(RoleProver), RoleFilter(RoleVerifier)
+
+	for _, name := range []string{stateNameRequestReceived, stateNamePresentationSent, stateNameProposalSent} {
+		require.True(t, prover(service.StateMsg{StateID: name}))
+		require.False(t, verifier(service.StateMsg{StateID: name}))
+	}
+
+	for _, name := range []string{stateNameRequestSent, stateNamePresentationReceived, stateNameProposalReceived} {
+		require.False(t, prover(service.StateMsg{StateID: name}))
+		require.True(t, verifier(service.StateMsg{StateID: name}))
+	}
+
+	for _, name := range []string{stateNameStart, stateNameDone, stateNameAbandoning, stateNameNoop} {
+		require.True(t, prover(service.StateMsg{StateID: name}))
+		require.True(t, verifier(service.StateMsg{StateID: name}))
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+)
+
+const (
+	// defaultVerificationWorkers is the default number of presentations verified concurrently.
+	defaultVerificationWorkers = 8
+	// defaultVerificationRetries is the default number of retries of a verification failing because of
+	// a transient DID resolution error.
+	defaultVerificationRetries = 2
+	// defaultVerificationBackoff is the default delay before the first retry of a verification.
+	defaultVerificationBackoff = 500 * time.Millisecond
+	// maxVerificationRetries bounds the number of retries of a verification.
+	maxVerificationRetries = 5
+	// maxVerificationRetryDelay bounds the time spent waiting for the retries of a verification, the exchange
+	// is not kept waiting on an unavailable resolver.
+	maxVerificationRetryDelay = 30 * time.Second
+)
+
+// VerificationMetrics is the instrumentation hook of the verification of the received presentations,
+// e.g to export the verification latency and the depth of the verification queue to a monitoring system.
+// The methods are called concurrently by the verification workers, they should not block.
+type VerificationMetrics interface {
+	// PresentationVerified reports the verification of a presentation: the duration of the verification,
+	// the time spent waiting for a worker excluded, and whether the presentation is verified.
+	PresentationVerified(latency time.Duration, verified bool)
+	// QueueDepth reports the number of presentations waiting for a verification worker.
+	QueueDepth(depth int)
+}
+
+// verificationPool is the bounded pool of workers verifying the presentations of all the exchanges,
+// a verifier handling many provers then verifies a bounded number of presentations concurrently.
+// A nil pool verifies the presentations sequentially.
+type verificationPool struct {
+	mu      sync.RWMutex
+	workers chan struct{}
+	metrics VerificationMetrics
+	// queued is the number of presentations waiting for a worker
+	queued int32
+	// retries is the number of retries of a verification failing because of a transient DID resolution error,
+	// the first retry is delayed by backoff, which doubles at each retry. The worker is released while waiting.
+	retries int
+	backoff time.Duration
+}
+
+func newVerificationPool(workers int) *verificationPool {
+	return &verificationPool{
+		workers: make(chan struct{}, workers),
+		retries: defaultVerificationRetries,
+		backoff: defaultVerificationBackoff,
+	}
+}
+
+// InvalidateDIDDoc removes the DID document cached for the verification of the presentations, e.g once it was
+// updated (see the DID update protocol). The keys removed from the document are then no longer accepted.
+func (s *Service) InvalidateDIDDoc(didID string) {
+	s.keyResolver.Invalidate(didID)
+}
+
+// SetVerificationWorkers sets the maximum number of presentations verified concurrently, 8 by default.
+// The presentations of all the exchanges are verified by the same workers, a value lower than 1 is treated as 1.
+func (s *Service) SetVerificationWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	s.verificationPool.mu.Lock()
+	defer s.verificationPool.mu.Unlock()
+
+	// the verifications in progress release the workers of the previous channel
+	s.verificationPool.workers = make(chan struct{}, workers)
+}
+
+// SetVerificationMetrics sets the instrumentation hook of the verification of the presentations.
+func (s *Service) SetVerificationMetrics(metrics VerificationMetrics) {
+	s.verificationPool.mu.Lock()
+	defer s.verificationPool.mu.Unlock()
+
+	s.verificationPool.metrics = metrics
+}
+
+// SetVerificationRetries sets how many times the verification of a presentation is retried when it fails because
+// of a transient DID resolution error (e.g a network timeout), 2 by default and 5 at most, and the delay before
+// the first retry, doubled at each retry (500ms by default). The retries of a presentation stop once the delays
+// would exceed 30s. The exchange is abandoned if the verification still fails after the retries, the invalid
+// presentations (e.g a wrong signature) are not retried.
+func (s *Service) SetVerificationRetries(retries int, backoff time.Duration) {
+	if retries < 0 {
+		retries = 0
+	}
+
+	if retries > maxVerificationRetries {
+		retries = maxVerificationRetries
+	}
+
+	s.verificationPool.mu.Lock()
+	defer s.verificationPool.mu.Unlock()
+
+	s.verificationPool.retries = retries
+	s.verificationPool.backoff = backoff
+}
+
+// QueueDepths returns the number of items waiting in the queues of the service: the presentations waiting
+// for a verification worker (verification).
+func (s *Service) QueueDepths() map[string]int {
+	return map[string]int{"verification": int(atomic.LoadInt32(&s.verificationPool.queued))}
+}
+
+// verify calls the verify function for each of the n presentations concurrently, by the workers of the pool,
+// and waits for the verifications. The verify function returns nil if the presentation is verified, it is called
+// again if it fails because of a transient DID resolution error (see isTransient), within the retry budget
+// of the pool. The worker is released while waiting for a retry. A nil pool verifies the presentations
+// sequentially and does not retry.
+func (p *verificationPool) verify(n int, verify func(i int) error) {
+	if p == nil {
+		for i := 0; i < n; i++ {
+			verify(i) // nolint: errcheck
+		}
+
+		return
+	}
+
+	p.mu.RLock()
+	metrics, retries, backoff := p.metrics, p.retries, p.backoff
+	p.mu.RUnlock()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			var (
+				latency time.Duration
+				waited  time.Duration
+				delay   = backoff
+				err     error
+			)
+
+			for attempt := 0; ; attempt++ {
+				var elapsed time.Duration
+
+				elapsed, err = p.attempt(metrics, func() error { return verify(i) })
+				latency += elapsed
+
+				if err == nil || attempt >= retries || !isTransient(err) || waited+delay > maxVerificationRetryDelay {
+					break
+				}
+
+				logger.Warnf("verify presentation: transient failure, retry %d/%d in %s: %s",
+					attempt+1, retries, delay, err)
+
+				time.Sleep(delay)
+
+				waited += delay
+				delay *= 2
+			}
+
+			if metrics != nil {
+				metrics.PresentationVerified(latency, err == nil)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// attempt calls the verify function once a worker is available and returns the duration of the verification,
+// the time spent waiting for the worker excluded.
+func (p *verificationPool) attempt(metrics VerificationMetrics, verify func() error) (time.Duration, error) {
+	p.mu.RLock()
+	workers := p.workers
+	p.mu.RUnlock()
+
+	p.updateQueue(metrics, 1)
+
+	workers <- struct{}{}
+	defer func() { <-workers }()
+
+	p.updateQueue(metrics, -1)
+
+	start := time.Now()
+	err := verify()
+
+	return time.Since(start), err
+}
+
+func (p *verificationPool) updateQueue(metrics VerificationMetrics, delta int32) {
+	depth := atomic.AddInt32(&p.queued, delta)
+
+	if metrics != nil {
+		metrics.QueueDepth(int(depth))
+	}
+}
+
+// isTransient tells whether the verification failed because of a transient DID resolution error (the resolver
+// is unavailable or timed out) rather than because the presentation is invalid.
+func isTransient(err error) bool {
+	if errors.Is(err, vdriapi.ErrTransient) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// verificationError returns the error of the first presentation which is not verified, nil if all are verified.
+func verificationError(results []VerificationResult) error {
+	for _, result := range results {
+		if !result.Verified {
+			return fmt.Errorf("presentation %s not verified: %s", result.ID, result.Error)
+		}
+	}
+
+	return nil
+}
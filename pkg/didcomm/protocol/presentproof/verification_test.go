@@ -0,0 +1,334 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentproof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+)
+
+type metricsRecorder struct {
+	mu       sync.Mutex
+	verified []bool
+	depths   []int
+}
+
+func (m *metricsRecorder) PresentationVerified(_ time.Duration, verified bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.verified = append(m.verified, verified)
+}
+
+func (m *metricsRecorder) QueueDepth(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.depths = append(m.depths, depth)
+}
+
+func TestVerificationPool_Verify(t *testing.T) {
+	t.Run("bounded workers", func(t *testing.T) {
+		const (
+			workers       = 3
+			presentations = 20
+		)
+
+		var running, maxRunning int32
+
+		pool := newVerificationPool(workers)
+		verified := make([]bool, presentations)
+
+		pool.verify(presentations, func(i int) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+
+			verified[i] = true
+
+			return nil
+		})
+
+		require.LessOrEqual(t, maxRunning, int32(workers))
+		require.NotContains(t, verified, false)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		metrics := &metricsRecorder{}
+
+		pool := newVerificationPool(1)
+		pool.metrics = metrics
+
+		pool.verify(3, func(i int) error {
+			if i == 1 {
+				return errors.New("invalid signature")
+			}
+
+			return nil
+		})
+
+		require.ElementsMatch(t, []bool{true, false, true}, metrics.verified)
+		// each presentation is queued, then dequeued
+		require.Len(t, metrics.depths, 6)
+		require.Zero(t, pool.queued)
+	})
+
+	t.Run("nil pool", func(t *testing.T) {
+		var (
+			pool  *verificationPool
+			order []int
+		)
+
+		pool.verify(3, func(i int) error {
+			order = append(order, i)
+
+			return fmt.Errorf("resolve DID: %w", vdriapi.ErrTransient)
+		})
+
+		// the transient failures are not retried
+
+		require.Equal(t, []int{0, 1, 2}, order)
+	})
+}
+
+func TestService_SetVerificationWorkers(t *testing.T) {
+	svc := &Service{verificationPool: newVerificationPool(defaultVerificationWorkers)}
+	require.Equal(t, defaultVerificationWorkers, cap(svc.verificationPool.workers))
+
+	svc.SetVerificationWorkers(2)
+	require.Equal(t, 2, cap(svc.verificationPool.workers))
+
+	svc.SetVerificationWorkers(0)
+	require.Equal(t, 1, cap(svc.verificationPool.workers))
+
+	metrics := &metricsRecorder{}
+	svc.SetVerificationMetrics(metrics)
+	require.Equal(t, metrics, svc.verificationPool.metrics)
+}
+
+func TestService_InvalidateDIDDoc(t *testing.T) {
+	var resolved int32
+
+	doc := &did.Doc{ID: "did:example:prover", PublicKey: []did.PublicKey{{
+		ID:    "did:example:prover#key-1",
+		Type:  "Ed25519VerificationKey2018",
+		Value: []byte("key"),
+	}}}
+
+	svc := &Service{keyResolver: verifiable.NewCachingDIDKeyResolver(&mockvdri.MockVDRIRegistry{
+		ResolveFunc: func(string, ...vdriapi.ResolveOpts) (*did.Doc, error) {
+			atomic.AddInt32(&resolved, 1)
+
+			return doc, nil
+		},
+	})}
+
+	fetch := svc.keyResolver.PublicKeyFetcher()
+
+	for i := 0; i < 2; i++ {
+		_, err := fetch(doc.ID, "#key-1")
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&resolved))
+
+	svc.InvalidateDIDDoc(doc.ID)
+
+	_, err := fetch(doc.ID, "#key-1")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&resolved))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestVerificationPool_Retry(t *testing.T) {
+	pool := newVerificationPool(1)
+	pool.backoff = time.Millisecond
+
+	t.Run("transient failures are retried", func(t *testing.T) {
+		metrics := &metricsRecorder{}
+		pool.metrics = metrics
+
+		defer func() { pool.metrics = nil }()
+
+		attempts := 0
+
+		pool.verify(1, func(int) error {
+			attempts++
+
+			if attempts < 3 {
+				return fmt.Errorf("resolve DID: %w", vdriapi.ErrTransient)
+			}
+
+			return nil
+		})
+		require.Equal(t, 3, attempts)
+		// the presentation is reported once
+		require.Equal(t, []bool{true}, metrics.verified)
+	})
+
+	t.Run("retries are exhausted", func(t *testing.T) {
+		attempts := 0
+
+		pool.verify(1, func(int) error {
+			attempts++
+
+			return &url.Error{Op: "Get", URL: "https://resolver.example.com", Err: timeoutError{}}
+		})
+		require.Equal(t, 1+defaultVerificationRetries, attempts)
+	})
+
+	t.Run("retry delay budget is exhausted", func(t *testing.T) {
+		budget := newVerificationPool(1)
+		budget.retries = maxVerificationRetries
+		budget.backoff = maxVerificationRetryDelay + time.Millisecond
+
+		attempts := 0
+
+		budget.verify(1, func(int) error {
+			attempts++
+
+			return context.DeadlineExceeded
+		})
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("worker is released while waiting for a retry", func(t *testing.T) {
+		slow := newVerificationPool(1)
+		slow.backoff = 50 * time.Millisecond
+
+		var verifiedWhileWaiting int32
+
+		first := true
+
+		slow.verify(2, func(i int) error {
+			if i == 0 {
+				if first {
+					first = false
+
+					return vdriapi.ErrTransient
+				}
+
+				return nil
+			}
+
+			atomic.StoreInt32(&verifiedWhileWaiting, 1)
+
+			return nil
+		})
+		require.Equal(t, int32(1), atomic.LoadInt32(&verifiedWhileWaiting))
+		require.False(t, first)
+	})
+
+	t.Run("invalid presentations are not retried", func(t *testing.T) {
+		attempts := 0
+
+		pool.verify(1, func(int) error {
+			attempts++
+
+			return errors.New("invalid signature")
+		})
+		require.Equal(t, 1, attempts)
+	})
+}
+
+func TestService_SetVerificationRetries(t *testing.T) {
+	svc := &Service{verificationPool: newVerificationPool(defaultVerificationWorkers)}
+	require.Equal(t, defaultVerificationRetries, svc.verificationPool.retries)
+	require.Equal(t, defaultVerificationBackoff, svc.verificationPool.backoff)
+
+	svc.SetVerificationRetries(5, time.Second)
+	require.Equal(t, 5, svc.verificationPool.retries)
+	require.Equal(t, time.Second, svc.verificationPool.backoff)
+
+	svc.SetVerificationRetries(-1, 0)
+	require.Zero(t, svc.verificationPool.retries)
+
+	svc.SetVerificationRetries(100, time.Second)
+	require.Equal(t, maxVerificationRetries, svc.verificationPool.retries)
+}
+
+func Test_isTransient(t *testing.T) {
+	require.True(t, isTransient(fmt.Errorf("resolve DID: %w", vdriapi.ErrTransient)))
+	require.True(t, isTransient(fmt.Errorf("resolve DID: %w", context.DeadlineExceeded)))
+	require.True(t, isTransient(fmt.Errorf("resolve DID: %w", timeoutError{})))
+	require.False(t, isTransient(fmt.Errorf("resolve DID: %w", vdriapi.ErrNotFound)))
+	require.False(t, isTransient(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+}
+
+func TestService_QueueDepths(t *testing.T) {
+	svc := &Service{verificationPool: newVerificationPool(1)}
+	require.Equal(t, map[string]int{"verification": 0}, svc.QueueDepths())
+
+	atomic.AddInt32(&svc.verificationPool.queued, 3)
+	require.Equal(t, map[string]int{"verification": 3}, svc.QueueDepths())
+}
+
+func Test_verifyPresentations_Concurrently(t *testing.T) {
+	const presentations = 10
+
+	attachments := make([]decorator.Attachment, presentations)
+	for i := range attachments {
+		attachments[i] = decorator.Attachment{
+			ID:   fmt.Sprintf("presentation-%d", i),
+			Data: decorator.AttachmentData{Base64: "invalid"},
+		}
+	}
+
+	metrics := &metricsRecorder{}
+
+	pool := newVerificationPool(3)
+	pool.metrics = metrics
+
+	results, verified := verifyPresentations(pool, nil, nil, nil, nil, attachments)
+	require.Empty(t, verified)
+
+	// the results are in the order of the attachments
+	require.Len(t, results, presentations)
+
+	for i, result := range results {
+		require.Equal(t, attachments[i].ID, result.ID)
+		require.False(t, result.Verified)
+		require.Contains(t, result.Error, "decode string")
+	}
+
+	require.Len(t, metrics.verified, presentations)
+}
+
+func Test_verificationError(t *testing.T) {
+	require.NoError(t, verificationError([]VerificationResult{{ID: "1", Verified: true}}))
+
+	err := verificationError([]VerificationResult{{ID: "1", Verified: true}, {ID: "2", Error: "invalid"}})
+	require.EqualError(t, err, "presentation 2 not verified: invalid")
+}
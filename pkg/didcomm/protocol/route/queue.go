@@ -0,0 +1,168 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package route
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// the queued forward messages are not route data, see dataKey
+	forwardQueueKeyPrefix = "forwardqueue_"
+
+	// how often the delivery of the queued forward messages is retried
+	forwardQueueInterval = time.Minute
+
+	// how long a forward message is queued before it is dropped
+	defaultForwardQueueTTL = 72 * time.Hour
+
+	// name of the task of the framework scheduler retrying the queued forward messages
+	forwardQueueTaskName = "route-forward-queue"
+)
+
+// queuedForward is a forward message the router could not deliver to the agent of the recipient key.
+type queuedForward struct {
+	To     string
+	Msg    *model.Envelope
+	Queued time.Time
+}
+
+// SetForwardQueueTTL sets how long the forward messages which could not be delivered are queued, they are
+// dropped after that duration or when their recipient key is removed. The default is 72 hours.
+func (s *Service) SetForwardQueueTTL(ttl time.Duration) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	s.queueTTL = ttl
+}
+
+func (s *Service) forwardQueueTTL() time.Duration {
+	s.queueMu.RLock()
+	defer s.queueMu.RUnlock()
+
+	return s.queueTTL
+}
+
+// queueForward persists the forward message, its delivery is retried by the forward queue task.
+func (s *Service) queueForward(forward *model.Forward, now time.Time) error {
+	bytes, err := json.Marshal(&queuedForward{To: forward.To, Msg: forward.Msg, Queued: now})
+	if err != nil {
+		return fmt.Errorf("marshal queued forward message : %w", err)
+	}
+
+	// the keys sort the messages by arrival
+	key := fmt.Sprintf("%s%020d_%s", forwardQueueKeyPrefix, now.UnixNano(), s.idGenerator.NewID())
+
+	if err := s.routeStore.Put(key, bytes); err != nil {
+		return fmt.Errorf("queue forward message : %w", err)
+	}
+
+	return nil
+}
+
+// Close unschedules the forward queue task, the queued messages remain persisted and are retried by the next
+// instance of the service.
+func (s *Service) Close() error {
+	if s.scheduler == nil {
+		return nil
+	}
+
+	// the task is already stopped if the scheduler was stopped
+	err := s.scheduler.Unregister(forwardQueueTaskName)
+	if err != nil && !errors.Is(err, scheduler.ErrTaskNotFound) {
+		return fmt.Errorf("unschedule forward queue task : %w", err)
+	}
+
+	return nil
+}
+
+// flushForwardQueue is the periodic task retrying the delivery of the queued forward messages. The expired
+// messages and the messages whose recipient key was removed are dropped.
+func (s *Service) flushForwardQueue(now time.Time) error {
+	queued, err := s.queuedForwards()
+	if err != nil {
+		return err
+	}
+
+	ttl := s.forwardQueueTTL()
+
+	for key, msg := range queued {
+		if now.Sub(msg.Queued) > ttl {
+			logger.Debugf("dropping the expired forward message to %s", msg.To)
+
+			if err := s.routeStore.Delete(key); err != nil {
+				return fmt.Errorf("delete queued forward message : %w", err)
+			}
+
+			continue
+		}
+
+		theirDID, err := s.routeStore.Get(dataKey(msg.To))
+		if errors.Is(err, storage.ErrDataNotFound) {
+			logger.Debugf("dropping the forward message to the removed route key %s", msg.To)
+
+			if err := s.routeStore.Delete(key); err != nil {
+				return fmt.Errorf("delete queued forward message : %w", err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("route key fetch : %w", err)
+		}
+
+		dest, err := service.GetDestination(string(theirDID), s.vdRegistry)
+		if err == nil {
+			err = s.outbound.Forward(msg.Msg, dest)
+		}
+
+		if err != nil {
+			// the agent is still unreachable, the message is retried at the next run
+			logger.Debugf("retry forward message to %s : %s", msg.To, err)
+
+			continue
+		}
+
+		if err := s.routeStore.Delete(key); err != nil {
+			return fmt.Errorf("delete queued forward message : %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) queuedForwards() (map[string]*queuedForward, error) {
+	records := s.routeStore.Iterator(forwardQueueKeyPrefix, forwardQueueKeyPrefix+storage.EndKeySuffix)
+	defer records.Release()
+
+	queued := make(map[string]*queuedForward)
+
+	for records.Next() {
+		msg := &queuedForward{}
+
+		if err := json.Unmarshal(records.Value(), msg); err != nil {
+			return nil, fmt.Errorf("unmarshal queued forward message : %w", err)
+		}
+
+		queued[string(records.Key())] = msg
+	}
+
+	if err := records.Error(); err != nil {
+		return nil, fmt.Errorf("iterate queued forward messages : %w", err)
+	}
+
+	return queued, nil
+}
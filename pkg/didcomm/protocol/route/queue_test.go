@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package route
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	mockdispatcher "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/dispatcher"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	mockdiddoc "github.com/hyperledger/aries-framework-go/pkg/mock/diddoc"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func TestServiceForwardQueue(t *testing.T) {
+	sched := scheduler.New()
+	defer sched.Stop()
+
+	var (
+		forwardErr = errors.New("agent unreachable")
+		forwarded  []interface{}
+	)
+
+	outbound := &mockdispatcher.MockOutbound{
+		ValidateForward: func(msg interface{}, _ *service.Destination) error {
+			if forwardErr != nil {
+				return forwardErr
+			}
+
+			forwarded = append(forwarded, msg)
+
+			return nil
+		},
+	}
+
+	newService := func() (*Service, error) {
+		return New(&mockprovider.Provider{
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+			OutboundDispatcherValue:       outbound,
+			VDRIRegistryValue: &mockvdri.MockVDRIRegistry{
+				ResolveFunc: func(string, ...vdri.ResolveOpts) (*did.Doc, error) {
+					return mockdiddoc.GetMockDIDDoc(), nil
+				},
+			},
+			SchedulerValue: sched,
+		})
+	}
+
+	svc, err := newService()
+	require.NoError(t, err)
+	require.Equal(t, []string{forwardQueueTaskName}, sched.Tasks())
+
+	_, err = newService()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "schedule forward queue task")
+
+	to := randomID()
+	content := &model.Envelope{CipherText: "qQyzvajdvCDJbwxM"}
+
+	require.NoError(t, svc.routeStore.Put(dataKey(to), []byte("did:example:123")))
+
+	// the undelivered message is queued
+	require.NoError(t, svc.handleForward(generateForwardMsgPayload(t, randomID(), to, content)))
+
+	queued, err := svc.queuedForwards()
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+
+	// the agent is still unreachable
+	require.NoError(t, sched.Run(forwardQueueTaskName))
+
+	queued, err = svc.queuedForwards()
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+
+	// the message is delivered and removed from the queue
+	forwardErr = nil
+
+	require.NoError(t, sched.Run(forwardQueueTaskName))
+	require.Equal(t, []interface{}{content}, forwarded)
+
+	queued, err = svc.queuedForwards()
+	require.NoError(t, err)
+	require.Empty(t, queued)
+
+	t.Run("expired and removed route keys are dropped", func(t *testing.T) {
+		forwardErr = errors.New("agent unreachable")
+		forwarded = nil
+
+		removed := randomID()
+		require.NoError(t, svc.routeStore.Put(dataKey(removed), []byte("did:example:456")))
+
+		require.NoError(t, svc.handleForward(generateForwardMsgPayload(t, randomID(), to, content)))
+		require.NoError(t, svc.handleForward(generateForwardMsgPayload(t, randomID(), removed, content)))
+
+		require.NoError(t, svc.routeStore.Delete(dataKey(removed)))
+
+		require.NoError(t, svc.flushForwardQueue(time.Now()))
+
+		queued, err := svc.queuedForwards()
+		require.NoError(t, err)
+		require.Len(t, queued, 1)
+
+		svc.SetForwardQueueTTL(time.Minute)
+
+		require.NoError(t, svc.flushForwardQueue(time.Now().Add(2*time.Minute)))
+
+		queued, err = svc.queuedForwards()
+		require.NoError(t, err)
+		require.Empty(t, queued)
+		require.Empty(t, forwarded)
+	})
+
+	require.NoError(t, svc.Close())
+	require.Empty(t, sched.Tasks())
+	require.NoError(t, svc.Close())
+
+	t.Run("the messages are not queued without scheduler", func(t *testing.T) {
+		svc, err := New(&mockprovider.Provider{
+			StorageProviderValue:          mem.NewProvider(),
+			TransientStorageProviderValue: mem.NewProvider(),
+			OutboundDispatcherValue:       outbound,
+			VDRIRegistryValue: &mockvdri.MockVDRIRegistry{
+				ResolveFunc: func(string, ...vdri.ResolveOpts) (*did.Doc, error) {
+					return mockdiddoc.GetMockDIDDoc(), nil
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		forwardErr = errors.New("agent unreachable")
+
+		require.NoError(t, svc.routeStore.Put(dataKey(to), []byte("did:example:123")))
+
+		err = svc.handleForward(generateForwardMsgPayload(t, randomID(), to, content))
+		require.True(t, errors.Is(err, forwardErr))
+
+		queued, err := svc.queuedForwards()
+		require.NoError(t, err)
+		require.Empty(t, queued)
+
+		require.NoError(t, svc.Close())
+	})
+}
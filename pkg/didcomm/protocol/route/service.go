@@ -13,9 +13,9 @@ import (
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
-
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/model"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
@@ -61,6 +61,12 @@ const (
 	// server error while storing the key
 	serverError = "server_error"
 
+	// key registered by another agent
+	clientError = "client_error"
+
+	// key not registered
+	noChange = "no_change"
+
 	// key save success
 	success = "success"
 )
@@ -91,6 +97,9 @@ type provider interface {
 	RouterEndpoint() string
 	LegacyKMS() legacykms.KeyManager
 	VDRIRegistry() vdri.Registry
+	// Scheduler returns the scheduler shared by the framework, if nil the forward messages which can't be
+	// delivered are not queued
+	Scheduler() *scheduler.Scheduler
 }
 
 // Service for Route Coordination protocol.
@@ -108,6 +117,11 @@ type Service struct {
 	routeRegistrationMapLock sync.RWMutex
 	keylistUpdateMap         map[string]chan *KeylistUpdateResponse
 	keylistUpdateMapLock     sync.RWMutex
+	idGenerator              idgen.Generator
+	// scheduler retries the queued forward messages, they are not queued if it is nil
+	scheduler *scheduler.Scheduler
+	queueTTL  time.Duration
+	queueMu   sync.RWMutex
 }
 
 // New return route coordination service.
@@ -122,7 +136,8 @@ func New(prov provider) (*Service, error) {
 		return nil, err
 	}
 
-	return &Service{
+	svc := &Service{
+		idGenerator:          idgen.FromProvider(prov),
 		routeStore:           store,
 		outbound:             prov.OutboundDispatcher(),
 		endpoint:             prov.RouterEndpoint(),
@@ -131,7 +146,19 @@ func New(prov provider) (*Service, error) {
 		connectionLookup:     connectionLookup,
 		routeRegistrationMap: make(map[string]chan Grant),
 		keylistUpdateMap:     make(map[string]chan *KeylistUpdateResponse),
-	}, nil
+		queueTTL:             defaultForwardQueueTTL,
+	}
+
+	// retry the forward messages the router could not deliver
+	if sched := prov.Scheduler(); sched != nil {
+		if err := sched.Register(forwardQueueTaskName, forwardQueueInterval, svc.flushForwardQueue); err != nil {
+			return nil, fmt.Errorf("schedule forward queue task : %w", err)
+		}
+
+		svc.scheduler = sched
+	}
+
+	return svc, nil
 }
 
 // HandleInbound handles inbound route coordination messages.
@@ -274,13 +301,11 @@ func (s *Service) handleKeylistUpdate(msg service.DIDCommMsg, myDID, theirDID st
 				Result:       result,
 			})
 		} else if v.Action == remove {
-			// TODO remove from the store
-
 			// construct the response doc
 			updates = append(updates, UpdateResponse{
 				RecipientKey: v.RecipientKey,
 				Action:       v.Action,
-				Result:       serverError,
+				Result:       s.removeRouteKey(v.RecipientKey, theirDID),
 			})
 		}
 	}
@@ -295,6 +320,33 @@ func (s *Service) handleKeylistUpdate(msg service.DIDCommMsg, myDID, theirDID st
 	return s.outbound.SendToDID(updateResponse, myDID, theirDID)
 }
 
+// removeRouteKey removes the route of the key registered by the agent and returns the result of the update,
+// the keys registered by the other agents are kept.
+func (s *Service) removeRouteKey(recKey, theirDID string) string {
+	val, err := s.routeStore.Get(dataKey(recKey))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return noChange
+	}
+
+	if err != nil {
+		logger.Errorf("failed to get the route key from store : %s", err)
+
+		return serverError
+	}
+
+	if string(val) != theirDID {
+		return clientError
+	}
+
+	if err := s.routeStore.Delete(dataKey(recKey)); err != nil {
+		logger.Errorf("failed to remove the route key from store : %s", err)
+
+		return serverError
+	}
+
+	return success
+}
+
 func (s *Service) handleKeylistUpdateResponse(msg service.DIDCommMsg) error {
 	// unmarshal the payload
 	respMsg := &KeylistUpdateResponse{}
@@ -336,7 +388,14 @@ func (s *Service) handleForward(msg service.DIDCommMsg) error {
 		return fmt.Errorf("get destination : %w", err)
 	}
 
-	return s.outbound.Forward(forward.Msg, dest)
+	err = s.outbound.Forward(forward.Msg, dest)
+	if err != nil && s.scheduler != nil {
+		logger.Debugf("queue forward message to %s : %s", forward.To, err)
+
+		return s.queueForward(forward, time.Now().UTC())
+	}
+
+	return err
 }
 
 // Register registers the agent with the router on the other end of the connection identified by
@@ -344,7 +403,8 @@ func (s *Service) handleForward(msg service.DIDCommMsg) error {
 // The agent is registered with the router and retrieves the router endpoint and routing keys.
 // This function throws an error if the agent is already registered against a router.
 // TODO https://github.com/hyperledger/aries-framework-go/issues/1076 Register agent with
-//  multiple routers
+//
+//	multiple routers
 func (s *Service) Register(connectionID string) error {
 	// check if router is already registered
 	routerConnID, err := s.getRouterConnectionID()
@@ -363,7 +423,7 @@ func (s *Service) Register(connectionID string) error {
 	}
 
 	// generate message ID
-	msgID := uuid.New().String()
+	msgID := s.idGenerator.NewID()
 
 	// register chan for callback processing
 	grantCh := make(chan Grant)
@@ -436,8 +496,22 @@ func (s *Service) GetConnection() (string, error) {
 // received from the router or it times out.
 // TODO https://github.com/hyperledger/aries-framework-go/issues/1076 Support for multiple routers
 // TODO https://github.com/hyperledger/aries-framework-go/issues/1105 Support to Add multiple
-//  recKeys to the Router
+//
+//	recKeys to the Router
 func (s *Service) AddKey(recKey string) error {
+	return s.updateKey(recKey, add)
+}
+
+// RemoveKey removes a recKey of the agent from the registered router, e.g once the key was rotated: the messages
+// forwarded to the key are not delivered afterwards. This method blocks until a response is received from the
+// router or it times out.
+func (s *Service) RemoveKey(recKey string) error {
+	return s.updateKey(recKey, remove)
+}
+
+// updateKey sends the keylist update of the recKey with the action to the registered router and waits
+// for its response.
+func (s *Service) updateKey(recKey, action string) error {
 	// check if router is already registered
 	routerConnID, err := s.getRouterConnectionID()
 	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
@@ -455,7 +529,7 @@ func (s *Service) AddKey(recKey string) error {
 	}
 
 	// generate message ID
-	msgID := uuid.New().String()
+	msgID := s.idGenerator.NewID()
 
 	// register chan for callback processing
 	keyUpdateCh := make(chan *KeylistUpdateResponse)
@@ -467,7 +541,7 @@ func (s *Service) AddKey(recKey string) error {
 		Updates: []Update{
 			{
 				RecipientKey: recKey,
-				Action:       add,
+				Action:       action,
 			},
 		},
 	}
@@ -478,7 +552,7 @@ func (s *Service) AddKey(recKey string) error {
 
 	select {
 	case keyUpdateResp := <-keyUpdateCh:
-		if err := processKeylistUpdateResp(recKey, keyUpdateResp); err != nil {
+		if err := processKeylistUpdateResp(recKey, action, keyUpdateResp); err != nil {
 			return err
 		}
 	// TODO https://github.com/hyperledger/aries-framework-go/issues/1134 configure this timeout at decorator level
@@ -505,9 +579,11 @@ func (s *Service) Config() (*Config, error) {
 	return s.getRouterConfig()
 }
 
-func processKeylistUpdateResp(recKey string, keyUpdateResp *KeylistUpdateResponse) error {
+func processKeylistUpdateResp(recKey, action string, keyUpdateResp *KeylistUpdateResponse) error {
 	for _, result := range keyUpdateResp.Updated {
-		if result.RecipientKey == recKey && result.Action == add && result.Result != success {
+		// a removed key may already be unknown to the router
+		if result.RecipientKey == recKey && result.Action == action && result.Result != success &&
+			(action != remove || result.Result != noChange) {
 			return errors.New("failed to update the recipient key with the router")
 		}
 	}
@@ -515,6 +591,27 @@ func processKeylistUpdateResp(recKey string, keyUpdateResp *KeylistUpdateRespons
 	return nil
 }
 
+// RoutedKeys returns the number of recipient keys the agent routes as a router (mediator).
+func (s *Service) RoutedKeys() (int, error) {
+	records := s.routeStore.Iterator(dataKey(""), dataKey(storage.EndKeySuffix))
+	defer records.Release()
+
+	count := 0
+
+	for records.Next() {
+		key := string(records.Key())
+		if key != routeConnIDDataKey && key != routeConfigDataKey {
+			count++
+		}
+	}
+
+	if records.Error() != nil {
+		return 0, records.Error()
+	}
+
+	return count, nil
+}
+
 func (s *Service) getRouteRegistrationCh(msgID string) chan Grant {
 	s.routeRegistrationMapLock.RLock()
 	defer s.routeRegistrationMapLock.RUnlock()
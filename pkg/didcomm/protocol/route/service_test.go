@@ -195,6 +195,49 @@ func TestServiceGrantMsg(t *testing.T) {
 	})
 }
 
+func TestServiceRoutedKeys(t *testing.T) {
+	t.Run("test service routed keys - success", func(t *testing.T) {
+		svc, err := New(&mockprovider.Provider{
+			StorageProviderValue:          mockstore.NewMockStoreProvider(),
+			TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+			KMSValue:                      &mockkms.CloseableKMS{},
+			OutboundDispatcherValue:       &mockdispatcher.MockOutbound{}})
+		require.NoError(t, err)
+
+		count, err := svc.RoutedKeys()
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		err = svc.handleKeylistUpdate(generateKeyUpdateListMsgPayload(t, randomID(), []Update{
+			{RecipientKey: "ABC", Action: add},
+			{RecipientKey: "XYZ", Action: add},
+		}), MYDID, THEIRDID)
+		require.NoError(t, err)
+
+		// the router connection and configuration of the agent are not routed keys
+		require.NoError(t, svc.saveRouterConnectionID("conn-abc-xyz"))
+
+		count, err = svc.RoutedKeys()
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("test service routed keys - iterator error", func(t *testing.T) {
+		svc, err := New(&mockprovider.Provider{
+			StorageProviderValue: mockstore.NewCustomMockStoreProvider(&mockstore.MockStore{
+				Store:  make(map[string][]byte),
+				ErrItr: errors.New("iterator error"),
+			}),
+			TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+			KMSValue:                      &mockkms.CloseableKMS{},
+			OutboundDispatcherValue:       &mockdispatcher.MockOutbound{}})
+		require.NoError(t, err)
+
+		_, err = svc.RoutedKeys()
+		require.EqualError(t, err, "iterator error")
+	})
+}
+
 func TestServiceUpdateKeyListMsg(t *testing.T) {
 	t.Run("test service handle inbound key list update msg - success", func(t *testing.T) {
 		svc, err := New(&mockprovider.Provider{
@@ -232,7 +275,7 @@ func TestServiceUpdateKeyListMsg(t *testing.T) {
 	t.Run("test service handle request msg - verify outbound message", func(t *testing.T) {
 		update := make(map[string]updateResult)
 		update["ABC"] = updateResult{action: add, result: success}
-		update["XYZ"] = updateResult{action: remove, result: serverError}
+		update["XYZ"] = updateResult{action: remove, result: noChange}
 		update[""] = updateResult{action: add, result: success}
 
 		svc, err := New(&mockprovider.Provider{
@@ -276,6 +319,40 @@ func TestServiceUpdateKeyListMsg(t *testing.T) {
 	})
 }
 
+func TestServiceRemoveRouteKey(t *testing.T) {
+	store := &mockstore.MockStore{Store: make(map[string][]byte)}
+
+	svc, err := New(&mockprovider.Provider{
+		StorageProviderValue:          &mockstore.MockStoreProvider{Store: store},
+		TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+		KMSValue:                      &mockkms.CloseableKMS{},
+		OutboundDispatcherValue:       &mockdispatcher.MockOutbound{}})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.handleKeylistUpdate(generateKeyUpdateListMsgPayload(t, randomID(), []Update{
+		{RecipientKey: "ABC", Action: add},
+	}), MYDID, THEIRDID))
+
+	// the key registered by an agent is not removed by the others
+	require.Equal(t, clientError, svc.removeRouteKey("ABC", "did:example:other"))
+	require.Equal(t, success, svc.removeRouteKey("ABC", THEIRDID))
+	require.Equal(t, noChange, svc.removeRouteKey("ABC", THEIRDID))
+
+	count, err := svc.RoutedKeys()
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	require.NoError(t, svc.handleKeylistUpdate(generateKeyUpdateListMsgPayload(t, randomID(), []Update{
+		{RecipientKey: "ABC", Action: add},
+	}), MYDID, THEIRDID))
+
+	store.ErrDelete = errors.New("delete error")
+	require.Equal(t, serverError, svc.removeRouteKey("ABC", THEIRDID))
+
+	store.ErrGet = errors.New("get error")
+	require.Equal(t, serverError, svc.removeRouteKey("ABC", THEIRDID))
+}
+
 func TestServiceKeylistUpdateResponseMsg(t *testing.T) {
 	t.Run("test service handle inbound key list update response msg - success", func(t *testing.T) {
 		svc, err := New(&mockprovider.Provider{
@@ -649,6 +726,55 @@ func TestKeylistUpdate(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("test remove key - success", func(t *testing.T) {
+		keyUpdateMsg := make(chan KeylistUpdate)
+
+		s := make(map[string][]byte)
+		svc, err := New(&mockprovider.Provider{
+			StorageProviderValue:          &mockstore.MockStoreProvider{Store: &mockstore.MockStore{Store: s}},
+			TransientStorageProviderValue: mockstore.NewMockStoreProvider(),
+			KMSValue:                      &mockkms.CloseableKMS{},
+			OutboundDispatcherValue: &mockdispatcher.MockOutbound{
+				ValidateSendToDID: func(msg interface{}, myDID, theirDID string) error {
+					request, ok := msg.(*KeylistUpdate)
+					require.True(t, ok)
+
+					keyUpdateMsg <- *request
+					return nil
+				}}})
+		require.NoError(t, err)
+
+		require.NoError(t, svc.saveRouterConnectionID("conn1"))
+
+		connBytes, err := json.Marshal(&connection.Record{
+			ConnectionID: "conn1", MyDID: MYDID, TheirDID: THEIRDID, State: "complete"})
+		require.NoError(t, err)
+		s["conn_conn1"] = connBytes
+
+		for _, result := range []string{success, noChange, clientError} {
+			go func(result string) {
+				updateMsg := <-keyUpdateMsg
+				require.Equal(t, remove, updateMsg.Updates[0].Action)
+
+				require.NoError(t, svc.handleKeylistUpdateResponse(generateKeylistUpdateResponseMsgPayload(
+					t, updateMsg.ID, []UpdateResponse{{
+						RecipientKey: updateMsg.Updates[0].RecipientKey,
+						Action:       updateMsg.Updates[0].Action,
+						Result:       result,
+					}})))
+			}(result)
+
+			err = svc.RemoveKey("recKey")
+
+			// the key may already be unknown to the router
+			if result == clientError {
+				require.EqualError(t, err, "failed to update the recipient key with the router")
+			} else {
+				require.NoError(t, err)
+			}
+		}
+	})
+
 	t.Run("test keylist update - failure", func(t *testing.T) {
 		keyUpdateMsg := make(chan KeylistUpdate)
 		recKey := "ojaosdjoajs123jkas"
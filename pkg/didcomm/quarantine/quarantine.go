@@ -0,0 +1,349 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+var logger = log.New("aries-framework/quarantine")
+
+const (
+	// StoreName is the name of the store of the quarantined envelopes.
+	StoreName = "quarantine"
+
+	// DefaultCapacity is the default number of quarantined envelopes, see WithCapacity.
+	DefaultCapacity = 1000
+	// DefaultTTL is the default time an envelope is quarantined, see WithTTL.
+	DefaultTTL = 7 * 24 * time.Hour
+	// DefaultMaxEnvelopeSize is the default size of the largest quarantined envelope, see WithMaxEnvelopeSize.
+	DefaultMaxEnvelopeSize = 1 << 20
+
+	envelopeKey = "envelope_%s"
+)
+
+var (
+	// ErrEnvelopeNotFound is returned when no envelope is quarantined with the ID.
+	ErrEnvelopeNotFound = errors.New("envelope not found")
+	// ErrEnvelopeTooLarge is returned when the envelope is larger than the max envelope size of the quarantine.
+	ErrEnvelopeTooLarge = errors.New("envelope too large")
+)
+
+// Envelope is an inbound envelope which could not be unpacked, e.g it is encrypted for an unknown recipient key
+// or the JWE is corrupt.
+type Envelope struct {
+	// ID identifies the quarantined envelope.
+	ID string `json:"id"`
+	// Raw is the envelope as received by the transport.
+	Raw []byte `json:"raw"`
+	// Reason is the error of the last attempt to unpack the envelope.
+	Reason string `json:"reason"`
+	// Received is the time the envelope was received.
+	Received time.Time `json:"received"`
+	// Retries is the number of failed attempts to unpack the envelope after it was quarantined.
+	Retries int `json:"retries,omitempty"`
+}
+
+// Provider contains dependencies for the quarantine and is typically created by using aries.Context()
+type Provider interface {
+	StorageProvider() storage.Provider
+	Packager() commontransport.Packager
+	InboundMessageHandler() transport.InboundMessageHandler
+}
+
+// Opt configures the quarantine.
+type Opt func(*Quarantine)
+
+// WithCapacity sets the number of quarantined envelopes, the oldest envelope is dropped to quarantine another one
+// when the quarantine is full.
+func WithCapacity(capacity int) Opt {
+	return func(q *Quarantine) {
+		q.capacity = capacity
+	}
+}
+
+// WithTTL sets the time an envelope is quarantined, the expired envelopes are dropped.
+func WithTTL(ttl time.Duration) Opt {
+	return func(q *Quarantine) {
+		q.ttl = ttl
+	}
+}
+
+// WithMaxEnvelopeSize sets the size (in bytes) of the largest quarantined envelope, the larger envelopes are dropped.
+func WithMaxEnvelopeSize(size int) Opt {
+	return func(q *Quarantine) {
+		q.maxEnvelopeSize = size
+	}
+}
+
+// Quarantine keeps the inbound envelopes the transports failed to unpack, rather than dropping them.
+// A quarantined envelope is unpacked and handled again by Retry (e.g after the recovery of the keys),
+// or exported for an offline analysis.
+//
+// The quarantine is bounded: it is a ring of the last envelopes (see WithCapacity), an envelope expires after
+// its TTL (see WithTTL) and the large envelopes are not quarantined (see WithMaxEnvelopeSize). The quarantine
+// keeps the ring in memory, a single instance must be used for a store (see context.Provider.Quarantine).
+type Quarantine struct {
+	store           storage.Store
+	packager        commontransport.Packager
+	handler         transport.InboundMessageHandler
+	capacity        int
+	ttl             time.Duration
+	maxEnvelopeSize int
+	idGenerator     idgen.Generator
+
+	mu sync.Mutex
+	// ring holds the quarantined envelopes (without their raw envelope), the oldest first
+	ring []*Envelope
+}
+
+// New returns the quarantine of the inbound envelopes, it loads the envelopes quarantined in the store.
+func New(prov Provider, opts ...Opt) (*Quarantine, error) {
+	store, err := prov.StorageProvider().OpenStore(StoreName)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	q := &Quarantine{
+		store:           store,
+		packager:        prov.Packager(),
+		handler:         prov.InboundMessageHandler(),
+		capacity:        DefaultCapacity,
+		ttl:             DefaultTTL,
+		maxEnvelopeSize: DefaultMaxEnvelopeSize,
+		idGenerator:     idgen.FromProvider(prov),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	envelopes, err := q.load()
+	if err != nil {
+		return nil, fmt.Errorf("load envelopes: %w", err)
+	}
+
+	for _, envelope := range envelopes {
+		q.ring = append(q.ring, &Envelope{ID: envelope.ID, Received: envelope.Received})
+	}
+
+	return q, nil
+}
+
+// Add quarantines the envelope the transport failed to unpack with the given reason. The oldest envelope
+// is dropped if the quarantine is full, ErrEnvelopeTooLarge is returned if the envelope is too large.
+func (q *Quarantine) Add(raw []byte, reason error) (*Envelope, error) {
+	if len(raw) > q.maxEnvelopeSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrEnvelopeTooLarge, len(raw))
+	}
+
+	envelope := &Envelope{
+		ID:       q.idGenerator.NewID(),
+		Raw:      raw,
+		Reason:   reason.Error(),
+		Received: time.Now().UTC(),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire()
+
+	for len(q.ring) > 0 && len(q.ring) >= q.capacity {
+		logger.Warnf("quarantine is full: envelope %s dropped", q.ring[0].ID)
+
+		if err := q.remove(q.ring[0].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := q.save(envelope); err != nil {
+		return nil, err
+	}
+
+	q.ring = append(q.ring, &Envelope{ID: envelope.ID, Received: envelope.Received})
+
+	logger.Warnf("envelope %s quarantined: %s", envelope.ID, envelope.Reason)
+
+	return envelope, nil
+}
+
+// Envelopes returns the quarantined envelopes, the oldest first.
+func (q *Quarantine) Envelopes() ([]*Envelope, error) {
+	q.mu.Lock()
+	q.expire()
+	q.mu.Unlock()
+
+	return q.load()
+}
+
+// load returns the envelopes of the store, the oldest first.
+func (q *Quarantine) load() ([]*Envelope, error) {
+	records := q.store.Iterator(fmt.Sprintf(envelopeKey, ""), fmt.Sprintf(envelopeKey, storage.EndKeySuffix))
+	defer records.Release()
+
+	var envelopes []*Envelope
+
+	for records.Next() {
+		var envelope *Envelope
+		if err := json.Unmarshal(records.Value(), &envelope); err != nil {
+			return nil, fmt.Errorf("unmarshal envelope: %w", err)
+		}
+
+		envelopes = append(envelopes, envelope)
+	}
+
+	if records.Error() != nil {
+		return nil, records.Error()
+	}
+
+	sort.SliceStable(envelopes, func(i, j int) bool {
+		return envelopes[i].Received.Before(envelopes[j].Received)
+	})
+
+	return envelopes, nil
+}
+
+// expire drops the expired envelopes, the lock must be held.
+func (q *Quarantine) expire() {
+	deadline := time.Now().Add(-q.ttl)
+
+	for len(q.ring) > 0 && q.ring[0].Received.Before(deadline) {
+		logger.Debugf("quarantined envelope %s expired", q.ring[0].ID)
+
+		if err := q.remove(q.ring[0].ID); err != nil {
+			logger.Warnf("quarantine: %s", err)
+
+			return
+		}
+	}
+}
+
+// Envelope returns the quarantined envelope with the given ID.
+func (q *Quarantine) Envelope(id string) (*Envelope, error) {
+	q.mu.Lock()
+	q.expire()
+	q.mu.Unlock()
+
+	src, err := q.store.Get(fmt.Sprintf(envelopeKey, id))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, ErrEnvelopeNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get envelope: %w", err)
+	}
+
+	var envelope *Envelope
+	if err := json.Unmarshal(src, &envelope); err != nil {
+		return nil, fmt.Errorf("unmarshal envelope: %w", err)
+	}
+
+	return envelope, nil
+}
+
+// Export returns the JSON of the quarantined envelope (the raw envelope and why it was quarantined),
+// for an offline analysis.
+func (q *Quarantine) Export(id string) ([]byte, error) {
+	envelope, err := q.Envelope(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope)
+}
+
+// Retry unpacks the quarantined envelope again and hands the message to the inbound message handler,
+// the envelope is then removed from the quarantine. The envelope remains quarantined if it still cannot
+// be unpacked, with the reason updated, or if the message cannot be handled.
+func (q *Quarantine) Retry(id string) error {
+	envelope, err := q.Envelope(id)
+	if err != nil {
+		return err
+	}
+
+	unpacked, err := q.packager.UnpackMessage(envelope.Raw)
+	if err != nil {
+		envelope.Reason = err.Error()
+		envelope.Retries++
+
+		if e := q.update(envelope); e != nil {
+			return fmt.Errorf("unpack envelope: %s: %w", err, e)
+		}
+
+		return fmt.Errorf("unpack envelope: %w", err)
+	}
+
+	if err = q.handler(unpacked.Message, unpacked.ToDID, unpacked.FromDID); err != nil {
+		return fmt.Errorf("handle message: %w", err)
+	}
+
+	return q.Remove(id)
+}
+
+// Remove removes the envelope from the quarantine.
+func (q *Quarantine) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.remove(id)
+}
+
+// remove removes the envelope from the store and the ring, the lock must be held.
+func (q *Quarantine) remove(id string) error {
+	if err := q.store.Delete(fmt.Sprintf(envelopeKey, id)); err != nil {
+		return fmt.Errorf("delete envelope: %w", err)
+	}
+
+	for i, envelope := range q.ring {
+		if envelope.ID == id {
+			q.ring = append(q.ring[:i], q.ring[i+1:]...)
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// update saves the envelope if it is still quarantined.
+func (q *Quarantine) update(envelope *Envelope) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range q.ring {
+		if e.ID == envelope.ID {
+			return q.save(envelope)
+		}
+	}
+
+	return ErrEnvelopeNotFound
+}
+
+func (q *Quarantine) save(envelope *Envelope) error {
+	src, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("marshal envelope: %w", err)
+	}
+
+	if err := q.store.Put(fmt.Sprintf(envelopeKey, envelope.ID), src); err != nil {
+		return fmt.Errorf("save envelope: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/packager"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+type provider struct {
+	storageProvider storage.Provider
+	packager        commontransport.Packager
+	handler         transport.InboundMessageHandler
+}
+
+func (p *provider) StorageProvider() storage.Provider                      { return p.storageProvider }
+func (p *provider) Packager() commontransport.Packager                     { return p.packager }
+func (p *provider) InboundMessageHandler() transport.InboundMessageHandler { return p.handler }
+
+func TestNew(t *testing.T) {
+	q, err := New(&provider{storageProvider: mem.NewProvider()})
+	require.NoError(t, err)
+	require.NotNil(t, q)
+
+	q, err = New(&provider{storageProvider: &mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("error")}})
+	require.EqualError(t, err, "open store: error")
+	require.Nil(t, q)
+}
+
+func TestQuarantine(t *testing.T) {
+	mockPackager := &packager.Packager{UnpackErr: errors.New("no key found")}
+
+	var handled []string
+
+	q, err := New(&provider{
+		storageProvider: mem.NewProvider(),
+		packager:        mockPackager,
+		handler: func(message []byte, myDID, theirDID string) error {
+			handled = append(handled, string(message))
+
+			if string(message) == "invalid" {
+				return errors.New("no message handlers found")
+			}
+
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	envelope, err := q.Add([]byte("jwe"), errors.New("unknown recipient key"))
+	require.NoError(t, err)
+	require.NotEmpty(t, envelope.ID)
+	require.False(t, envelope.Received.IsZero())
+
+	t.Run("Envelopes", func(t *testing.T) {
+		envelopes, err := q.Envelopes()
+		require.NoError(t, err)
+		require.Len(t, envelopes, 1)
+		require.Equal(t, envelope.ID, envelopes[0].ID)
+		require.Equal(t, []byte("jwe"), envelopes[0].Raw)
+		require.Equal(t, "unknown recipient key", envelopes[0].Reason)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		src, err := q.Export(envelope.ID)
+		require.NoError(t, err)
+
+		exported := &Envelope{}
+		require.NoError(t, json.Unmarshal(src, exported))
+		require.Equal(t, envelope.Raw, exported.Raw)
+		require.Equal(t, envelope.Reason, exported.Reason)
+
+		_, err = q.Export("unknown")
+		require.True(t, errors.Is(err, ErrEnvelopeNotFound))
+	})
+
+	t.Run("Retry (still not unpacked)", func(t *testing.T) {
+		require.EqualError(t, q.Retry(envelope.ID), "unpack envelope: no key found")
+
+		updated, err := q.Envelope(envelope.ID)
+		require.NoError(t, err)
+		require.Equal(t, "no key found", updated.Reason)
+		require.Equal(t, 1, updated.Retries)
+		require.Empty(t, handled)
+	})
+
+	t.Run("Retry (not handled)", func(t *testing.T) {
+		mockPackager.UnpackErr = nil
+		mockPackager.UnpackValue = &commontransport.Envelope{Message: []byte("invalid")}
+
+		require.EqualError(t, q.Retry(envelope.ID), "handle message: no message handlers found")
+
+		_, err := q.Envelope(envelope.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Retry (after key recovery)", func(t *testing.T) {
+		mockPackager.UnpackValue = &commontransport.Envelope{Message: []byte("message")}
+
+		require.NoError(t, q.Retry(envelope.ID))
+		require.Equal(t, []string{"invalid", "message"}, handled)
+
+		_, err := q.Envelope(envelope.ID)
+		require.True(t, errors.Is(err, ErrEnvelopeNotFound))
+
+		envelopes, err := q.Envelopes()
+		require.NoError(t, err)
+		require.Empty(t, envelopes)
+
+		require.True(t, errors.Is(q.Retry(envelope.ID), ErrEnvelopeNotFound))
+	})
+}
+
+func TestQuarantine_StoreErrors(t *testing.T) {
+	store := &mockstorage.MockStore{Store: map[string][]byte{}, ErrPut: errors.New("put error")}
+
+	q, err := New(&provider{storageProvider: mockstorage.NewCustomMockStoreProvider(store)})
+	require.NoError(t, err)
+
+	_, err = q.Add([]byte("jwe"), errors.New("corrupt JWE"))
+	require.EqualError(t, err, "save envelope: put error")
+
+	store.ErrPut = nil
+	store.ErrGet = errors.New("get error")
+
+	_, err = q.Envelope("id")
+	require.EqualError(t, err, "get envelope: get error")
+
+	store.ErrGet = nil
+	store.Store["envelope_id"] = []byte("{")
+
+	_, err = q.Envelope("id")
+	require.Contains(t, err.Error(), "unmarshal envelope")
+}
+
+func TestQuarantine_Bounds(t *testing.T) {
+	prov := &provider{storageProvider: mem.NewProvider()}
+
+	t.Run("Capacity", func(t *testing.T) {
+		q, err := New(prov, WithCapacity(2))
+		require.NoError(t, err)
+
+		var ids []string
+
+		for _, raw := range []string{"jwe-1", "jwe-2", "jwe-3"} {
+			envelope, e := q.Add([]byte(raw), errors.New("no key found"))
+			require.NoError(t, e)
+
+			ids = append(ids, envelope.ID)
+		}
+
+		envelopes, err := q.Envelopes()
+		require.NoError(t, err)
+		require.Len(t, envelopes, 2)
+		require.Equal(t, ids[1], envelopes[0].ID)
+		require.Equal(t, ids[2], envelopes[1].ID)
+
+		_, err = q.Envelope(ids[0])
+		require.True(t, errors.Is(err, ErrEnvelopeNotFound))
+
+		// the quarantined envelopes are loaded from the store
+		q, err = New(prov, WithCapacity(2))
+		require.NoError(t, err)
+
+		_, err = q.Add([]byte("jwe-4"), errors.New("no key found"))
+		require.NoError(t, err)
+
+		_, err = q.Envelope(ids[1])
+		require.True(t, errors.Is(err, ErrEnvelopeNotFound))
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		q, err := New(&provider{storageProvider: mem.NewProvider()}, WithTTL(time.Millisecond))
+		require.NoError(t, err)
+
+		envelope, err := q.Add([]byte("jwe"), errors.New("no key found"))
+		require.NoError(t, err)
+
+		time.Sleep(5 * time.Millisecond)
+
+		_, err = q.Envelope(envelope.ID)
+		require.True(t, errors.Is(err, ErrEnvelopeNotFound))
+
+		envelopes, err := q.Envelopes()
+		require.NoError(t, err)
+		require.Empty(t, envelopes)
+	})
+
+	t.Run("Max envelope size", func(t *testing.T) {
+		q, err := New(&provider{storageProvider: mem.NewProvider()}, WithMaxEnvelopeSize(3))
+		require.NoError(t, err)
+
+		_, err = q.Add([]byte("jwe!"), errors.New("no key found"))
+		require.True(t, errors.Is(err, ErrEnvelopeTooLarge))
+
+		_, err = q.Add([]byte("jwe"), errors.New("no key found"))
+		require.NoError(t, err)
+	})
+
+	t.Run("Corrupt store", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: map[string][]byte{"envelope_id": []byte("{")}}
+
+		_, err := New(&provider{storageProvider: mockstorage.NewCustomMockStoreProvider(store)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "load envelopes")
+	})
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/rs/cors"
 
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
 )
 
@@ -23,27 +25,60 @@ var logger = log.New("aries-framework/http")
 
 // TODO https://github.com/hyperledger/aries-framework-go/issues/891 Support for Transport Return Route (Duplex)
 
+// InboundPath is an HTTP path served by the inbound transport with the media type profile accepted on it,
+// eg "/didcomm" for the V1 envelope and "/didcomm/v2" for the V2 envelope.
+type InboundPath struct {
+	Path    string
+	Profile *commontransport.MediaTypeProfile
+}
+
 // NewInboundHandler will create a new handler to enforce Did-Comm HTTP transport specs
 // then routes processing to the mandatory 'msgHandler' argument.
 //
 // Arguments:
 // * 'msgHandler' is the handler function that will be executed with the inbound request payload.
 //    Users of this library must manage the handling of all inbound payloads in this function.
-func NewInboundHandler(prov transport.Provider) (http.Handler, error) {
+// * 'paths' are the paths served by the handler, each accepting the media types of its profile. If no path is
+//    given, all paths are served and accept the default DIDComm envelope content type.
+func NewInboundHandler(prov transport.Provider, paths ...InboundPath) (http.Handler, error) {
 	if prov == nil || prov.InboundMessageHandler() == nil {
 		logger.Errorf("Error creating a new inbound handler: message handler function is nil")
 		return nil, errors.New("creation of inbound handler failed")
 	}
 
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		processPOSTRequest(w, r, prov)
-	})
+	if len(paths) == 0 {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			processPOSTRequest(w, r, prov, nil)
+		})
+
+		return cors.Default().Handler(handler), nil
+	}
+
+	mux := http.NewServeMux()
 
-	return cors.Default().Handler(handler), nil
+	for _, p := range paths {
+		if p.Path == "" || p.Profile == nil || len(p.Profile.MediaTypes) == 0 {
+			return nil, fmt.Errorf("invalid inbound path [%s]: path and profile media types are mandatory", p.Path)
+		}
+
+		profile := p.Profile
+
+		mux.HandleFunc(p.Path, func(w http.ResponseWriter, r *http.Request) {
+			processPOSTRequest(w, r, prov, profile)
+		})
+	}
+
+	return cors.Default().Handler(mux), nil
 }
 
-func processPOSTRequest(w http.ResponseWriter, r *http.Request, prov transport.Provider) {
-	if valid := validateHTTPMethod(w, r); !valid {
+func processPOSTRequest(w http.ResponseWriter, r *http.Request, prov transport.Provider,
+	profile *commontransport.MediaTypeProfile) {
+	mediaTypes := []string{commContentType}
+	if profile != nil {
+		mediaTypes = profile.MediaTypes
+	}
+
+	if valid := validateHTTPMethod(w, r, mediaTypes); !valid {
 		return
 	}
 
@@ -59,17 +94,25 @@ func processPOSTRequest(w http.ResponseWriter, r *http.Request, prov transport.P
 		return
 	}
 
-	unpackMsg, err := prov.Packager().UnpackMessage(body)
+	unpackMsg, err := unpackMessage(prov.Packager(), body, profile)
 	if err != nil {
 		logger.Errorf("failed to unpack msg: %s - returning Code: %d", err, http.StatusInternalServerError)
 		http.Error(w, "failed to unpack msg", http.StatusInternalServerError)
 
+		if handler := prov.UnpackFailureHandler(); handler != nil {
+			handler(body, err)
+		}
+
 		return
 	}
 
 	messageHandler := prov.InboundMessageHandler()
 
+	endSpan := service.InstrumentInbound(prov.Instrumentation(), "http", unpackMsg.Message)
+
 	err = messageHandler(unpackMsg.Message, unpackMsg.ToDID, unpackMsg.FromDID)
+	endSpan(err)
+
 	if err != nil {
 		// TODO https://github.com/hyperledger/aries-framework-go/issues/271 HTTP Response Codes based on errors
 		//  from service
@@ -80,6 +123,18 @@ func processPOSTRequest(w http.ResponseWriter, r *http.Request, prov transport.P
 	}
 }
 
+// unpackMessage passes the matched media type profile to the packager if it supports it
+func unpackMessage(packager commontransport.Packager, body []byte,
+	profile *commontransport.MediaTypeProfile) (*commontransport.Envelope, error) {
+	if profile != nil {
+		if p, ok := packager.(commontransport.ProfileUnpacker); ok {
+			return p.UnpackMessageForProfile(body, profile)
+		}
+	}
+
+	return packager.UnpackMessage(body)
+}
+
 // validatePayload validate and get the payload from the request
 func validatePayload(r *http.Request, w http.ResponseWriter) bool {
 	if r.ContentLength == 0 { // empty payload should not be accepted
@@ -91,14 +146,14 @@ func validatePayload(r *http.Request, w http.ResponseWriter) bool {
 }
 
 // validateHTTPMethod validate HTTP method and content-type
-func validateHTTPMethod(w http.ResponseWriter, r *http.Request) bool {
+func validateHTTPMethod(w http.ResponseWriter, r *http.Request, mediaTypes []string) bool {
 	if r.Method != "POST" {
 		http.Error(w, "HTTP Method not allowed", http.StatusMethodNotAllowed)
 		return false
 	}
 
 	ct := r.Header.Get("Content-type")
-	if ct != commContentType {
+	if !acceptMediaType(mediaTypes, ct) {
 		http.Error(w, fmt.Sprintf("Unsupported Content-type \"%s\"", ct), http.StatusUnsupportedMediaType)
 		return false
 	}
@@ -106,28 +161,57 @@ func validateHTTPMethod(w http.ResponseWriter, r *http.Request) bool {
 	return true
 }
 
+func acceptMediaType(mediaTypes []string, ct string) bool {
+	for _, mt := range mediaTypes {
+		if mt == ct {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Inbound http type.
 type Inbound struct {
 	externalAddr string
 	server       *http.Server
+	paths        []InboundPath
+}
+
+// InboundOpt is an option of the HTTP inbound transport
+type InboundOpt func(*Inbound)
+
+// WithInboundPaths option registers the paths served by the inbound transport, each with its own media type
+// profile (eg "/didcomm" for the V1 envelope and "/didcomm/v2" for the V2 envelope). By default all paths
+// are served and accept the default DIDComm envelope content type.
+func WithInboundPaths(paths ...InboundPath) InboundOpt {
+	return func(i *Inbound) {
+		i.paths = append(i.paths, paths...)
+	}
 }
 
 // NewInbound creates a new HTTP inbound transport instance.
-func NewInbound(internalAddr, externalAddr string) (*Inbound, error) {
+func NewInbound(internalAddr, externalAddr string, opts ...InboundOpt) (*Inbound, error) {
 	if internalAddr == "" {
 		return nil, errors.New("http address is mandatory")
 	}
 
 	if externalAddr == "" {
-		return &Inbound{externalAddr: internalAddr, server: &http.Server{Addr: internalAddr}}, nil
+		externalAddr = internalAddr
+	}
+
+	inbound := &Inbound{externalAddr: externalAddr, server: &http.Server{Addr: internalAddr}}
+
+	for _, opt := range opts {
+		opt(inbound)
 	}
 
-	return &Inbound{externalAddr: externalAddr, server: &http.Server{Addr: internalAddr}}, nil
+	return inbound, nil
 }
 
 // Start the http server.
 func (i *Inbound) Start(prov transport.Provider) error {
-	handler, err := NewInboundHandler(prov)
+	handler, err := NewInboundHandler(prov, i.paths...)
 	if err != nil {
 		return fmt.Errorf("HTTP server start failed: %w", err)
 	}
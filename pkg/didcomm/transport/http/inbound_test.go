@@ -15,18 +15,22 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
 	mockpackager "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/packager"
 )
 
 type mockProvider struct {
-	packagerValue commontransport.Packager
+	packagerValue   commontransport.Packager
+	unpackFailure   transport.UnpackFailureHandler
+	instrumentation service.Instrumentation
 }
 
 func (p *mockProvider) InboundMessageHandler() transport.InboundMessageHandler {
@@ -36,6 +40,14 @@ func (p *mockProvider) InboundMessageHandler() transport.InboundMessageHandler {
 	}
 }
 
+func (p *mockProvider) UnpackFailureHandler() transport.UnpackFailureHandler {
+	return p.unpackFailure
+}
+
+func (p *mockProvider) Instrumentation() service.Instrumentation {
+	return p.instrumentation
+}
+
 func (p *mockProvider) Packager() commontransport.Packager {
 	return p.packagerValue
 }
@@ -131,6 +143,141 @@ func TestInboundHandler(t *testing.T) {
 	require.NoError(t, resp.Body.Close())
 }
 
+type mockProfilePackager struct {
+	mockpackager.Packager
+	profile *commontransport.MediaTypeProfile
+}
+
+func (p *mockProfilePackager) UnpackMessageForProfile(encMessage []byte,
+	profile *commontransport.MediaTypeProfile) (*commontransport.Envelope, error) {
+	p.profile = profile
+
+	return p.UnpackMessage(encMessage)
+}
+
+func TestInboundHandler_Paths(t *testing.T) {
+	const v2ContentType = "application/didcomm-encrypted+json"
+
+	v1Profile := &commontransport.MediaTypeProfile{Name: "v1", MediaTypes: []string{commContentType}}
+	v2Profile := &commontransport.MediaTypeProfile{Name: "v2", MediaTypes: []string{v2ContentType}}
+
+	packager := &mockProfilePackager{
+		Packager: mockpackager.Packager{UnpackValue: &commontransport.Envelope{Message: []byte("data")}},
+	}
+
+	inHandler, err := NewInboundHandler(&mockProvider{packagerValue: packager},
+		InboundPath{Path: "/didcomm", Profile: v1Profile},
+		InboundPath{Path: "/didcomm/v2", Profile: v2Profile})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(inHandler)
+	defer server.Close()
+
+	post := func(path, contentType string) int {
+		resp, e := http.Post(server.URL+path, contentType, bytes.NewBuffer([]byte("payload")))
+		require.NoError(t, e)
+		require.NoError(t, resp.Body.Close())
+
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusAccepted, post("/didcomm", commContentType))
+	require.Equal(t, v1Profile, packager.profile)
+
+	require.Equal(t, http.StatusAccepted, post("/didcomm/v2", v2ContentType))
+	require.Equal(t, v2Profile, packager.profile)
+
+	require.Equal(t, http.StatusUnsupportedMediaType, post("/didcomm", v2ContentType))
+	require.Equal(t, http.StatusUnsupportedMediaType, post("/didcomm/v2", commContentType))
+	require.Equal(t, http.StatusNotFound, post("/unknown", commContentType))
+
+	t.Run("packager without profile support", func(t *testing.T) {
+		h, e := NewInboundHandler(&mockProvider{packagerValue: &mockpackager.Packager{
+			UnpackValue: &commontransport.Envelope{Message: []byte("data")},
+		}}, InboundPath{Path: "/didcomm/v2", Profile: v2Profile})
+		require.NoError(t, e)
+
+		s := httptest.NewServer(h)
+		defer s.Close()
+
+		resp, e := http.Post(s.URL+"/didcomm/v2", v2ContentType, bytes.NewBuffer([]byte("payload")))
+		require.NoError(t, e)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		_, e := NewInboundHandler(&mockProvider{packagerValue: packager}, InboundPath{Path: "/didcomm"})
+		require.EqualError(t, e, "invalid inbound path [/didcomm]: path and profile media types are mandatory")
+	})
+}
+
+func TestInboundHandler_UnpackFailure(t *testing.T) {
+	var (
+		envelope []byte
+		reason   error
+	)
+
+	inHandler, err := NewInboundHandler(&mockProvider{
+		packagerValue: &mockpackager.Packager{UnpackErr: errors.New("no key found")},
+		unpackFailure: func(e []byte, err error) {
+			envelope, reason = e, err
+		},
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(inHandler)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, commContentType, bytes.NewBuffer([]byte("jwe")))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	require.Equal(t, []byte("jwe"), envelope)
+	require.EqualError(t, reason, "no key found")
+}
+
+// spanRecorder records the messages and the steps reported by the inbound transport.
+type spanRecorder struct {
+	service.NoopInstrumentation
+	received []string
+	spans    []string
+}
+
+func (r *spanRecorder) MessageReceived(protocol string, msg service.DIDCommMsg) {
+	r.received = append(r.received, protocol+" "+msg.Type())
+}
+
+func (r *spanRecorder) StartSpan(protocol, threadID, name string) func(err error) {
+	return func(err error) {
+		r.spans = append(r.spans, fmt.Sprintf("%s %s %s: %v", protocol, threadID, name, err))
+	}
+}
+
+func TestInboundHandler_Instrumentation(t *testing.T) {
+	recorder := &spanRecorder{}
+
+	inHandler, err := NewInboundHandler(&mockProvider{
+		packagerValue: &mockpackager.Packager{UnpackValue: &commontransport.Envelope{
+			Message: []byte(`{"@id":"msg-1","@type":"https://didcomm.org/trust_ping/1.0/ping"}`),
+		}},
+		instrumentation: recorder,
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(inHandler)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, commContentType, bytes.NewBuffer([]byte("jwe")))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	require.Equal(t, []string{"http https://didcomm.org/trust_ping/1.0/ping"}, recorder.received)
+	require.Equal(t, []string{"http msg-1 inbound transport: <nil>"}, recorder.spans)
+}
+
 func TestInboundTransport(t *testing.T) {
 	t.Run("test inbound transport - with host/port", func(t *testing.T) {
 		port := "26601"
@@ -159,6 +306,21 @@ func TestInboundTransport(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("test inbound transport - with paths", func(t *testing.T) {
+		path := InboundPath{
+			Path:    "/didcomm",
+			Profile: &commontransport.MediaTypeProfile{MediaTypes: []string{commContentType}},
+		}
+
+		inbound, err := NewInbound(":26606", "", WithInboundPaths(path))
+		require.NoError(t, err)
+		require.Equal(t, []InboundPath{path}, inbound.paths)
+
+		err = inbound.Start(&mockProvider{packagerValue: &mockpackager.Packager{}})
+		require.NoError(t, err)
+		require.NoError(t, inbound.Stop())
+	})
+
 	t.Run("test inbound transport - nil context", func(t *testing.T) {
 		inbound, err := NewInbound(":26604", "")
 		require.NoError(t, err)
@@ -0,0 +1,72 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mem
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
+)
+
+// Inbound is the in-memory inbound transport of an agent.
+type Inbound struct {
+	network  *Network
+	endpoint string
+	prov     transport.Provider
+	// handling serializes the handling of the messages, as a single connection would
+	handling sync.Mutex
+}
+
+// Start starts receiving the messages sent to the endpoint.
+func (i *Inbound) Start(prov transport.Provider) error {
+	if prov == nil || prov.InboundMessageHandler() == nil {
+		return errors.New("start in-memory inbound transport: message handler is mandatory")
+	}
+
+	i.prov = prov
+
+	return i.network.register(i.endpoint, i)
+}
+
+// Stop stops receiving the messages, the messages in flight to the endpoint are dropped.
+func (i *Inbound) Stop() error {
+	i.network.unregister(i.endpoint)
+
+	return nil
+}
+
+// Endpoint returns the endpoint of the transport, e.g "mem://alice".
+func (i *Inbound) Endpoint() string {
+	return i.endpoint
+}
+
+func (i *Inbound) receive(msg []byte) {
+	i.handling.Lock()
+	defer i.handling.Unlock()
+
+	envelope, err := i.prov.Packager().UnpackMessage(msg)
+	if err != nil {
+		logger.Errorf("failed to unpack msg received at %s: %s", i.endpoint, err)
+
+		if handler := i.prov.UnpackFailureHandler(); handler != nil {
+			handler(msg, err)
+		}
+
+		return
+	}
+
+	endSpan := service.InstrumentInbound(i.prov.Instrumentation(), "mem", envelope.Message)
+
+	err = i.prov.InboundMessageHandler()(envelope.Message, envelope.ToDID, envelope.FromDID)
+	endSpan(err)
+
+	if err != nil {
+		logger.Errorf("incoming msg processing failed: %s", err)
+	}
+}
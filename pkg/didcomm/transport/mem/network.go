@@ -0,0 +1,293 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mem
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+)
+
+// Scheme is the scheme of the endpoints of the in-memory transports, e.g "mem://alice".
+const Scheme = "mem"
+
+var logger = log.New("aries-framework/transport/mem")
+
+// Stats counts the messages sent over the network.
+type Stats struct {
+	// Sent is the number of messages sent by the outbound transports.
+	Sent int
+	// Delivered is the number of messages, including the duplicates, handed to the inbound transports.
+	Delivered int
+	// Dropped is the number of messages silently lost, including the messages to endpoints stopped
+	// before the messages were delivered.
+	Dropped int
+	// Failed is the number of sends which returned an error.
+	Failed int
+	// Duplicated is the number of extra copies of the messages delivered.
+	Duplicated int
+	// Reordered is the number of messages held back so that the following messages overtake them.
+	Reordered int
+}
+
+// Network is an in-memory network connecting the inbound and outbound transports created from it, it simulates
+// the adverse conditions of a real network (latency, reordering, duplicates and losses) so that the behavior of
+// the protocols (retries, deduplication, ordering) can be validated without sockets, e.g in tests.
+//
+// The messages are delivered asynchronously, in the order they were sent unless latency jitter or reordering
+// is configured. The faults are drawn from a pseudo-random source, seeded with WithSeed to replay a scenario.
+type Network struct {
+	minLatency    time.Duration
+	maxLatency    time.Duration
+	dropRate      float64
+	failureRate   float64
+	duplicateRate float64
+	reorderRate   float64
+	reorderDelay  time.Duration
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	inbounds map[string]*Inbound
+	stats    Stats
+	last     chan struct{}
+	inflight sync.WaitGroup
+}
+
+// NetworkOpt is an option of the in-memory network.
+type NetworkOpt func(*Network)
+
+// WithLatency option delays the delivery of each message by a random duration between min and max.
+// Messages overtake each other when their latencies differ by more than the time between their sends.
+func WithLatency(min, max time.Duration) NetworkOpt {
+	return func(n *Network) {
+		n.minLatency = min
+		n.maxLatency = max
+	}
+}
+
+// WithDropRate option silently loses the given ratio (from 0 to 1) of the messages: the send succeeds
+// but the message is never delivered, e.g a lost packet of a fire and forget transport.
+func WithDropRate(rate float64) NetworkOpt {
+	return func(n *Network) {
+		n.dropRate = rate
+	}
+}
+
+// WithFailureRate option fails the given ratio (from 0 to 1) of the sends: the message is not delivered
+// and the outbound transport returns an error, e.g an unreachable agent.
+func WithFailureRate(rate float64) NetworkOpt {
+	return func(n *Network) {
+		n.failureRate = rate
+	}
+}
+
+// WithDuplicateRate option delivers the given ratio (from 0 to 1) of the messages twice, each copy with
+// its own latency.
+func WithDuplicateRate(rate float64) NetworkOpt {
+	return func(n *Network) {
+		n.duplicateRate = rate
+	}
+}
+
+// WithReorderRate option holds back the given ratio (from 0 to 1) of the messages for the given extra delay,
+// so the messages sent meanwhile are delivered first.
+func WithReorderRate(rate float64, delay time.Duration) NetworkOpt {
+	return func(n *Network) {
+		n.reorderRate = rate
+		n.reorderDelay = delay
+	}
+}
+
+// WithSeed option seeds the source of the faults so that a scenario can be replayed, by default the source
+// is seeded with the current time.
+func WithSeed(seed int64) NetworkOpt {
+	return func(n *Network) {
+		n.rand = rand.New(rand.NewSource(seed)) // nolint: gosec
+	}
+}
+
+// NewNetwork returns a new in-memory network.
+func NewNetwork(opts ...NetworkOpt) (*Network, error) {
+	n := &Network{inbounds: map[string]*Inbound{}}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	if n.rand == nil {
+		n.rand = rand.New(rand.NewSource(time.Now().UnixNano())) // nolint: gosec
+	}
+
+	if n.minLatency < 0 || n.maxLatency < n.minLatency {
+		return nil, fmt.Errorf("invalid latency range [%s, %s]", n.minLatency, n.maxLatency)
+	}
+
+	for _, rate := range []float64{n.dropRate, n.failureRate, n.duplicateRate, n.reorderRate} {
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid rate %v: the rates range from 0 to 1", rate)
+		}
+	}
+
+	return n, nil
+}
+
+// NewInbound returns an inbound transport receiving the messages sent to the endpoint "mem://<name>".
+func (n *Network) NewInbound(name string) *Inbound {
+	return &Inbound{network: n, endpoint: Scheme + "://" + name}
+}
+
+// NewOutbound returns an outbound transport sending the messages to the inbound transports of the network.
+func (n *Network) NewOutbound() *Outbound {
+	return &Outbound{network: n}
+}
+
+// Stats returns the counts of the messages sent over the network so far.
+func (n *Network) Stats() Stats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.stats
+}
+
+// Flush waits until the messages in flight are delivered or dropped.
+func (n *Network) Flush() {
+	n.inflight.Wait()
+}
+
+func (n *Network) register(endpoint string, inbound *Inbound) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.inbounds[endpoint]; ok {
+		return fmt.Errorf("endpoint %s is already served", endpoint)
+	}
+
+	n.inbounds[endpoint] = inbound
+
+	return nil
+}
+
+func (n *Network) unregister(endpoint string) {
+	n.mu.Lock()
+	delete(n.inbounds, endpoint)
+	n.mu.Unlock()
+}
+
+// send applies the faults to the message and schedules the delivery of its copies.
+func (n *Network) send(data []byte, endpoint string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.stats.Sent++
+
+	if _, ok := n.inbounds[endpoint]; !ok {
+		n.stats.Failed++
+
+		return fmt.Errorf("no inbound transport at endpoint %s", endpoint)
+	}
+
+	if n.draw(n.failureRate) {
+		n.stats.Failed++
+
+		return errors.New("simulated network failure")
+	}
+
+	if n.draw(n.dropRate) {
+		n.stats.Dropped++
+
+		logger.Debugf("message to %s dropped", endpoint)
+
+		return nil
+	}
+
+	copies := 1
+
+	if n.draw(n.duplicateRate) {
+		n.stats.Duplicated++
+		copies++
+	}
+
+	// the message is copied since the sender may reuse its buffer
+	msg := append([]byte(nil), data...)
+
+	for i := 0; i < copies; i++ {
+		delay := n.latency()
+
+		if n.draw(n.reorderRate) {
+			n.stats.Reordered++
+			delay += n.reorderDelay
+		}
+
+		n.schedule(msg, endpoint, delay)
+	}
+
+	return nil
+}
+
+func (n *Network) schedule(msg []byte, endpoint string, delay time.Duration) {
+	n.inflight.Add(1)
+
+	// without jitter nor reordering the messages are delivered in the order they were sent: each delivery
+	// waits for the delivery of the message sent previously
+	var prior, done chan struct{}
+
+	if n.minLatency == n.maxLatency && n.reorderRate == 0 {
+		prior, done = n.last, make(chan struct{})
+		n.last = done
+	}
+
+	time.AfterFunc(delay, func() {
+		defer n.inflight.Done()
+
+		if prior != nil {
+			<-prior
+		}
+
+		n.deliver(msg, endpoint)
+
+		if done != nil {
+			close(done)
+		}
+	})
+}
+
+func (n *Network) deliver(msg []byte, endpoint string) {
+	n.mu.Lock()
+	inbound, ok := n.inbounds[endpoint]
+
+	if ok {
+		n.stats.Delivered++
+	} else {
+		n.stats.Dropped++
+	}
+	n.mu.Unlock()
+
+	if !ok {
+		logger.Debugf("message to %s dropped: the endpoint is no longer served", endpoint)
+
+		return
+	}
+
+	inbound.receive(msg)
+}
+
+// draw returns true with the given probability.
+func (n *Network) draw(rate float64) bool {
+	return rate > 0 && n.rand.Float64() < rate
+}
+
+func (n *Network) latency() time.Duration {
+	if n.maxLatency == n.minLatency {
+		return n.minLatency
+	}
+
+	return n.minLatency + time.Duration(n.rand.Int63n(int64(n.maxLatency-n.minLatency)+1))
+}
@@ -0,0 +1,298 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mem
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
+)
+
+// packager "unpacks" the envelopes as is, except the corrupt ones
+type packager struct{}
+
+func (p *packager) PackMessage(e *commontransport.Envelope) ([]byte, error) {
+	return e.Message, nil
+}
+
+func (p *packager) UnpackMessage(encMessage []byte) (*commontransport.Envelope, error) {
+	if string(encMessage) == "corrupt" {
+		return nil, errors.New("corrupt envelope")
+	}
+
+	return &commontransport.Envelope{Message: encMessage, ToDID: "did:example:to", FromDID: "did:example:from"}, nil
+}
+
+// agent records the messages it receives
+type agent struct {
+	mu       sync.Mutex
+	messages []string
+	failures []string
+}
+
+func (a *agent) InboundMessageHandler() transport.InboundMessageHandler {
+	return func(message []byte, myDID, theirDID string) error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		if myDID != "did:example:to" || theirDID != "did:example:from" {
+			return fmt.Errorf("unexpected DIDs %s %s", myDID, theirDID)
+		}
+
+		a.messages = append(a.messages, string(message))
+
+		return errors.New("handler errors are only logged")
+	}
+}
+
+func (a *agent) UnpackFailureHandler() transport.UnpackFailureHandler {
+	return func(envelope []byte, err error) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		a.failures = append(a.failures, fmt.Sprintf("%s: %s", envelope, err))
+	}
+}
+
+func (a *agent) Instrumentation() service.Instrumentation {
+	return service.NoopInstrumentation{}
+}
+
+func (a *agent) Packager() commontransport.Packager {
+	return &packager{}
+}
+
+func (a *agent) AriesFrameworkID() string {
+	return "agent"
+}
+
+func (a *agent) received() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]string(nil), a.messages...)
+}
+
+func start(t *testing.T, opts ...NetworkOpt) (*Network, *agent, *Outbound, *service.Destination) {
+	t.Helper()
+
+	network, err := NewNetwork(opts...)
+	require.NoError(t, err)
+
+	bob := &agent{}
+
+	inbound := network.NewInbound("bob")
+	require.NoError(t, inbound.Start(bob))
+	require.Equal(t, "mem://bob", inbound.Endpoint())
+
+	outbound := network.NewOutbound()
+	require.NoError(t, outbound.Start(nil))
+
+	return network, bob, outbound, &service.Destination{ServiceEndpoint: inbound.Endpoint()}
+}
+
+func send(t *testing.T, outbound *Outbound, dest *service.Destination, count int) []string {
+	t.Helper()
+
+	sent := make([]string, count)
+
+	for i := range sent {
+		sent[i] = fmt.Sprintf("message %d", i)
+
+		_, err := outbound.Send([]byte(sent[i]), dest)
+		require.NoError(t, err)
+	}
+
+	return sent
+}
+
+func TestNewNetwork(t *testing.T) {
+	t.Run("Invalid latency", func(t *testing.T) {
+		_, err := NewNetwork(WithLatency(time.Second, time.Millisecond))
+		require.EqualError(t, err, "invalid latency range [1s, 1ms]")
+
+		_, err = NewNetwork(WithLatency(-time.Second, time.Millisecond))
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid rates", func(t *testing.T) {
+		for _, opt := range []NetworkOpt{
+			WithDropRate(-0.1), WithFailureRate(1.1), WithDuplicateRate(2), WithReorderRate(-1, time.Second),
+		} {
+			_, err := NewNetwork(opt)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "the rates range from 0 to 1")
+		}
+	})
+}
+
+func TestNetwork_Delivery(t *testing.T) {
+	t.Run("In order", func(t *testing.T) {
+		network, bob, outbound, dest := start(t, WithLatency(time.Millisecond, time.Millisecond))
+
+		sent := send(t, outbound, dest, 50)
+
+		network.Flush()
+		require.Equal(t, sent, bob.received())
+		require.Equal(t, Stats{Sent: 50, Delivered: 50}, network.Stats())
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		network, bob, outbound, dest := start(t, WithLatency(50*time.Millisecond, 50*time.Millisecond))
+
+		send(t, outbound, dest, 1)
+		require.Empty(t, bob.received())
+
+		network.Flush()
+		require.Len(t, bob.received(), 1)
+	})
+
+	t.Run("Jitter", func(t *testing.T) {
+		network, bob, outbound, dest := start(t, WithLatency(0, 10*time.Millisecond), WithSeed(1))
+
+		sent := send(t, outbound, dest, 50)
+
+		network.Flush()
+		require.ElementsMatch(t, sent, bob.received())
+	})
+
+	t.Run("Dropped", func(t *testing.T) {
+		network, bob, outbound, dest := start(t, WithDropRate(1))
+
+		send(t, outbound, dest, 10)
+
+		network.Flush()
+		require.Empty(t, bob.received())
+		require.Equal(t, Stats{Sent: 10, Dropped: 10}, network.Stats())
+	})
+
+	t.Run("Failures", func(t *testing.T) {
+		network, bob, outbound, dest := start(t, WithFailureRate(1))
+
+		_, err := outbound.Send([]byte("message"), dest)
+		require.EqualError(t, err, "in-memory send: simulated network failure")
+
+		network.Flush()
+		require.Empty(t, bob.received())
+		require.Equal(t, Stats{Sent: 1, Failed: 1}, network.Stats())
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+		network, bob, outbound, dest := start(t, WithDuplicateRate(1))
+
+		send(t, outbound, dest, 2)
+
+		network.Flush()
+		require.Equal(t, []string{"message 0", "message 0", "message 1", "message 1"}, bob.received())
+		require.Equal(t, Stats{Sent: 2, Delivered: 4, Duplicated: 2}, network.Stats())
+	})
+
+	t.Run("Reordered", func(t *testing.T) {
+		network, bob, outbound, dest := start(t, WithReorderRate(0.5, 100*time.Millisecond), WithSeed(1))
+
+		sent := send(t, outbound, dest, 20)
+
+		network.Flush()
+
+		stats := network.Stats()
+		require.Equal(t, 20, stats.Delivered)
+		require.True(t, stats.Reordered > 0 && stats.Reordered < 20)
+		require.ElementsMatch(t, sent, bob.received())
+		require.NotEqual(t, sent, bob.received())
+	})
+
+	t.Run("Faults are replayed with the same seed", func(t *testing.T) {
+		var stats []Stats
+
+		for i := 0; i < 2; i++ {
+			network, _, outbound, dest := start(t, WithDropRate(0.3), WithDuplicateRate(0.3), WithSeed(42))
+
+			send(t, outbound, dest, 100)
+
+			network.Flush()
+
+			stats = append(stats, network.Stats())
+		}
+
+		require.Equal(t, stats[0], stats[1])
+		require.NotZero(t, stats[0].Dropped)
+		require.NotZero(t, stats[0].Duplicated)
+	})
+
+	t.Run("Unpack failure", func(t *testing.T) {
+		network, bob, outbound, dest := start(t)
+
+		_, err := outbound.Send([]byte("corrupt"), dest)
+		require.NoError(t, err)
+
+		network.Flush()
+		require.Empty(t, bob.received())
+		require.Equal(t, []string{"corrupt: corrupt envelope"}, bob.failures)
+	})
+}
+
+func TestInbound(t *testing.T) {
+	t.Run("Unknown endpoint", func(t *testing.T) {
+		network, _, outbound, _ := start(t)
+
+		_, err := outbound.Send([]byte("message"), &service.Destination{ServiceEndpoint: "mem://alice"})
+		require.EqualError(t, err, "in-memory send: no inbound transport at endpoint mem://alice")
+		require.Equal(t, Stats{Sent: 1, Failed: 1}, network.Stats())
+	})
+
+	t.Run("Endpoint already served", func(t *testing.T) {
+		network, _, _, _ := start(t)
+
+		err := network.NewInbound("bob").Start(&agent{})
+		require.EqualError(t, err, "endpoint mem://bob is already served")
+	})
+
+	t.Run("No message handler", func(t *testing.T) {
+		network, err := NewNetwork()
+		require.NoError(t, err)
+
+		require.Error(t, network.NewInbound("bob").Start(nil))
+	})
+
+	t.Run("Messages in flight to a stopped endpoint are dropped", func(t *testing.T) {
+		network, err := NewNetwork(WithLatency(50*time.Millisecond, 50*time.Millisecond))
+		require.NoError(t, err)
+
+		bob := &agent{}
+
+		inbound := network.NewInbound("bob")
+		require.NoError(t, inbound.Start(bob))
+
+		send(t, network.NewOutbound(), &service.Destination{ServiceEndpoint: inbound.Endpoint()}, 1)
+		require.NoError(t, inbound.Stop())
+
+		network.Flush()
+		require.Empty(t, bob.received())
+		require.Equal(t, Stats{Sent: 1, Dropped: 1}, network.Stats())
+
+		// the endpoint can be served again
+		require.NoError(t, inbound.Start(bob))
+	})
+}
+
+func TestOutbound_Accept(t *testing.T) {
+	network, err := NewNetwork()
+	require.NoError(t, err)
+
+	outbound := network.NewOutbound()
+	require.True(t, outbound.Accept("mem://bob"))
+	require.False(t, outbound.Accept("http://bob"))
+	require.False(t, outbound.AcceptRecipient([]string{"key"}))
+}
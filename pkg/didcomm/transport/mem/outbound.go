@@ -0,0 +1,45 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mem
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
+)
+
+// Outbound is the in-memory outbound transport of an agent.
+type Outbound struct {
+	network *Network
+}
+
+// Start starts the outbound transport.
+func (o *Outbound) Start(_ transport.Provider) error {
+	return nil
+}
+
+// Send sends the data to the inbound transport of the destination endpoint. The send fails if no inbound
+// transport serves the endpoint or on a simulated failure, it succeeds if the message is dropped.
+func (o *Outbound) Send(data []byte, destination *service.Destination) (string, error) {
+	if err := o.network.send(data, destination.ServiceEndpoint); err != nil {
+		return "", fmt.Errorf("in-memory send: %w", err)
+	}
+
+	return "", nil
+}
+
+// AcceptRecipient checks if there is a connection for the list of recipient keys.
+func (o *Outbound) AcceptRecipient([]string) bool {
+	return false
+}
+
+// Accept checks for the url scheme.
+func (o *Outbound) Accept(url string) bool {
+	return strings.HasPrefix(url, Scheme+"://")
+}
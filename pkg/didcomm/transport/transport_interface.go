@@ -32,10 +32,20 @@ type OutboundTransport interface {
 // message handle invocation.
 type InboundMessageHandler func(message []byte, myDID, theirDID string) error
 
+// UnpackFailureHandler handles the inbound envelopes the transport fails to unpack (e.g unknown recipient key,
+// corrupt JWE), rather than only dropping them.
+type UnpackFailureHandler func(envelope []byte, err error)
+
 // Provider contains dependencies for starting the inbound/outbound transports.
 // It is typically created by using aries.Context().
 type Provider interface {
 	InboundMessageHandler() InboundMessageHandler
+	// UnpackFailureHandler returns the handler of the envelopes the inbound transports fail to unpack,
+	// nil if they are dropped.
+	UnpackFailureHandler() UnpackFailureHandler
+	// Instrumentation returns the instrumentation hook the inbound transports report the received messages to,
+	// see service.InstrumentInbound.
+	Instrumentation() service.Instrumentation
 	Packager() transport.Packager
 	AriesFrameworkID() string
 }
@@ -9,12 +9,16 @@ package ws
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"nhooyr.io/websocket"
 
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
 	mockpackager "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/packager"
 	"github.com/hyperledger/aries-framework-go/pkg/internal/test/transportutil"
@@ -99,8 +103,18 @@ func TestInboundDataProcessing(t *testing.T) {
 		require.NotEmpty(t, inbound)
 
 		// start server
-		mockPackager := &mockpackager.Packager{UnpackErr: errors.New("error unpacking")}
-		err = inbound.Start(&mockProvider{packagerValue: mockPackager})
+		failures := make(chan []byte, 1)
+		err = inbound.Start(&mockTransportProvider{
+			packagerValue: &mockpackager.Packager{UnpackErr: errors.New("error unpacking")},
+			frameworkID:   uuid.New().String(),
+			executeInbound: func(message []byte, myDID, theirDID string) error {
+				return nil
+			},
+			unpackFailure: func(envelope []byte, err error) {
+				require.EqualError(t, err, "error unpacking")
+				failures <- envelope
+			},
+		})
 		require.NoError(t, err)
 
 		// create ws client
@@ -109,8 +123,15 @@ func TestInboundDataProcessing(t *testing.T) {
 
 		ctx := context.Background()
 
-		err = client.Write(ctx, websocket.MessageText, []byte(""))
+		err = client.Write(ctx, websocket.MessageText, []byte("jwe"))
 		require.NoError(t, err)
+
+		select {
+		case envelope := <-failures:
+			require.Equal(t, []byte("jwe"), envelope)
+		case <-time.After(time.Second):
+			require.Fail(t, "the envelope which cannot be unpacked was not handled")
+		}
 	})
 
 	t.Run("test inbound transport - message handler error", func(t *testing.T) {
@@ -156,3 +177,52 @@ func TestInboundDataProcessing(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+// spanRecorder sends the steps reported by the inbound transport.
+type spanRecorder struct {
+	service.NoopInstrumentation
+	spans chan string
+}
+
+func (r *spanRecorder) StartSpan(protocol, threadID, name string) func(err error) {
+	return func(err error) {
+		r.spans <- fmt.Sprintf("%s %s %s: %v", protocol, threadID, name, err)
+	}
+}
+
+func TestInboundDataProcessing_Instrumentation(t *testing.T) {
+	port := ":" + strconv.Itoa(transportutil.GetRandomPort(5))
+
+	inbound, err := NewInbound(port, "")
+	require.NoError(t, err)
+
+	recorder := &spanRecorder{spans: make(chan string, 1)}
+
+	err = inbound.Start(&mockTransportProvider{
+		packagerValue:   &mockPackager{},
+		frameworkID:     uuid.New().String(),
+		instrumentation: recorder,
+		executeInbound: func(message []byte, myDID, theirDID string) error {
+			return errors.New("handler error")
+		},
+	})
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, inbound.Stop())
+	}()
+
+	client, cleanup := websocketClient(t, port)
+	defer cleanup()
+
+	err = client.Write(context.Background(), websocket.MessageText,
+		[]byte(`{"@id":"msg-1","@type":"https://didcomm.org/trust_ping/1.0/ping"}`))
+	require.NoError(t, err)
+
+	select {
+	case span := <-recorder.spans:
+		require.Equal(t, "ws msg-1 inbound transport: handler error", span)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the inbound transport span")
+	}
+}
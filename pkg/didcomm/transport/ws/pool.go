@@ -14,6 +14,7 @@ import (
 	"github.com/btcsuite/btcutil/base58"
 	"nhooyr.io/websocket"
 
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	commtransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
@@ -27,8 +28,10 @@ const (
 type connPool struct {
 	connMap map[string]*websocket.Conn
 	sync.RWMutex
-	packager   commtransport.Packager
-	msgHandler transport.InboundMessageHandler
+	packager        commtransport.Packager
+	msgHandler      transport.InboundMessageHandler
+	unpackFailure   transport.UnpackFailureHandler
+	instrumentation service.Instrumentation
 }
 
 // nolint gochecknoglobals
@@ -39,9 +42,11 @@ func getConnPool(prov transport.Provider) *connPool {
 
 	if _, ok := pool[id]; !ok {
 		pool[id] = &connPool{
-			connMap:    make(map[string]*websocket.Conn),
-			packager:   prov.Packager(),
-			msgHandler: prov.InboundMessageHandler(),
+			connMap:         make(map[string]*websocket.Conn),
+			packager:        prov.Packager(),
+			msgHandler:      prov.InboundMessageHandler(),
+			unpackFailure:   prov.UnpackFailureHandler(),
+			instrumentation: prov.Instrumentation(),
 		}
 	}
 
@@ -91,6 +96,10 @@ func (d *connPool) listener(conn *websocket.Conn, outbound bool) {
 		if err != nil {
 			logger.Errorf("failed to unpack msg: %v", err)
 
+			if d.unpackFailure != nil {
+				d.unpackFailure(message, err)
+			}
+
 			continue
 		}
 
@@ -107,7 +116,11 @@ func (d *connPool) listener(conn *websocket.Conn, outbound bool) {
 
 		messageHandler := d.msgHandler
 
+		endSpan := service.InstrumentInbound(d.instrumentation, "ws", unpackMsg.Message)
+
 		err = messageHandler(unpackMsg.Message, unpackMsg.ToDID, unpackMsg.FromDID)
+		endSpan(err)
+
 		if err != nil {
 			logger.Errorf("incoming msg processing failed: %v", err)
 		}
@@ -44,6 +44,14 @@ func (p *mockProvider) InboundMessageHandler() transport.InboundMessageHandler {
 	}
 }
 
+func (p *mockProvider) UnpackFailureHandler() transport.UnpackFailureHandler {
+	return nil
+}
+
+func (p *mockProvider) Instrumentation() service.Instrumentation {
+	return nil
+}
+
 func (p *mockProvider) Packager() commontransport.Packager {
 	return p.packagerValue
 }
@@ -153,15 +161,25 @@ func (m *mockPackager) UnpackMessage(encMessage []byte) (*commontransport.Envelo
 }
 
 type mockTransportProvider struct {
-	packagerValue  commontransport.Packager
-	executeInbound func(message []byte, myDID, theirDID string) error
-	frameworkID    string
+	packagerValue   commontransport.Packager
+	executeInbound  func(message []byte, myDID, theirDID string) error
+	unpackFailure   transport.UnpackFailureHandler
+	instrumentation service.Instrumentation
+	frameworkID     string
 }
 
 func (p *mockTransportProvider) InboundMessageHandler() transport.InboundMessageHandler {
 	return p.executeInbound
 }
 
+func (p *mockTransportProvider) UnpackFailureHandler() transport.UnpackFailureHandler {
+	return p.unpackFailure
+}
+
+func (p *mockTransportProvider) Instrumentation() service.Instrumentation {
+	return p.instrumentation
+}
+
 func (p *mockTransportProvider) Packager() commontransport.Packager {
 	return p.packagerValue
 }
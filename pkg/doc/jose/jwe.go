@@ -31,11 +31,12 @@ type Recipient struct {
 
 // RecipientHeaders are the recipient headers
 type RecipientHeaders struct {
-	APU string `json:"apu,omitempty"`
-	IV  string `json:"iv,omitempty"`
-	Tag string `json:"tag,omitempty"`
-	KID string `json:"kid,omitempty"`
-	SPK string `json:"spk,omitempty"`
+	APU string          `json:"apu,omitempty"`
+	IV  string          `json:"iv,omitempty"`
+	Tag string          `json:"tag,omitempty"`
+	KID string          `json:"kid,omitempty"`
+	SPK string          `json:"spk,omitempty"`
+	EPK json.RawMessage `json:"epk,omitempty"`
 }
 
 // rawJSONWebEncryption represents a RAW JWE that is used for serialization/deserialization.
@@ -0,0 +1,49 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import "sync"
+
+// CredentialsContextURL is the URL of the context of the W3C Verifiable Credentials Data Model.
+const CredentialsContextURL = "https://www.w3.org/2018/credentials/v1"
+
+// SecurityContextURL is the URL of the context of the security vocabulary (v2), which extends its v1 context
+// (SecurityV1ContextURL).
+const SecurityContextURL = "https://w3id.org/security/v2"
+
+// SecurityV1ContextURL is the URL of the v1 context of the security vocabulary.
+const SecurityV1ContextURL = "https://w3id.org/security/v1"
+
+//nolint:gochecknoglobals
+var (
+	contextsMutex    sync.RWMutex
+	standardContexts = embeddedContexts()
+)
+
+// StandardContexts returns the standard JSON-LD contexts by URL, they are preloaded by the document loaders
+// so the documents using them are processed without fetching them.
+func StandardContexts() map[string]string {
+	contextsMutex.RLock()
+	defer contextsMutex.RUnlock()
+
+	contexts := make(map[string]string, len(standardContexts))
+
+	for url, context := range standardContexts {
+		contexts[url] = context
+	}
+
+	return contexts
+}
+
+// RegisterStandardContext adds a standard context preloaded by the document loaders created afterwards,
+// e.g the contexts which are not embedded in the minimal build profile (ariesminimal build tag).
+func RegisterStandardContext(url, context string) {
+	contextsMutex.Lock()
+	defer contextsMutex.Unlock()
+
+	standardContexts[url] = context
+}
@@ -0,0 +1,15 @@
+// +build ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+// embeddedContexts returns no context: the minimal build profile does not embed the contexts, they are
+// registered with RegisterStandardContext or fetched.
+func embeddedContexts() map[string]string {
+	return map[string]string{}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+// ContextsStoreName is the name of the store caching the JSON-LD contexts.
+const ContextsStoreName = "jsonld-contexts"
+
+// DocumentLoader is a JSON-LD document loader: the standard contexts are preloaded (see StandardContexts) and
+// custom contexts can be added (see AddContext). Other contexts are loaded by the remote document loader, then
+// cached by the storage provider, so the JSON-LD documents are processed offline and deterministically once
+// the contexts they use are cached.
+type DocumentLoader struct {
+	mu       sync.RWMutex
+	contexts map[string]*ld.RemoteDocument
+	store    storage.Store
+	remote   ld.DocumentLoader
+}
+
+// DocumentLoaderOpt is the DocumentLoader option.
+type DocumentLoaderOpt func(loader *DocumentLoader)
+
+// WithRemoteDocumentLoader defines the loader of the contexts which are neither preloaded, added nor cached,
+// by default they are fetched using HTTP. A nil loader disables the loading of remote contexts.
+func WithRemoteDocumentLoader(remote ld.DocumentLoader) DocumentLoaderOpt {
+	return func(loader *DocumentLoader) {
+		loader.remote = remote
+	}
+}
+
+// NewDocumentLoader returns a new JSON-LD document loader caching the contexts in the store of the provider.
+func NewDocumentLoader(p storage.Provider, opts ...DocumentLoaderOpt) (*DocumentLoader, error) {
+	store, err := p.OpenStore(ContextsStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	loader := &DocumentLoader{
+		contexts: map[string]*ld.RemoteDocument{},
+		store:    store,
+		remote:   ld.NewDefaultDocumentLoader(&http.Client{}),
+	}
+
+	for _, opt := range opts {
+		opt(loader)
+	}
+
+	for url, context := range StandardContexts() {
+		doc, err := ld.DocumentFromReader(strings.NewReader(context))
+		if err != nil {
+			return nil, fmt.Errorf("parse context %s: %w", url, err)
+		}
+
+		loader.contexts[url] = &ld.RemoteDocument{DocumentURL: url, Document: doc}
+	}
+
+	return loader, nil
+}
+
+// AddContext adds a custom context, it overrides the context loaded from the given URL. The context is saved
+// in the store so it is still available once the framework is restarted.
+func (l *DocumentLoader) AddContext(url string, context []byte) error {
+	var doc interface{}
+
+	if err := json.Unmarshal(context, &doc); err != nil {
+		return fmt.Errorf("parse context: %w", err)
+	}
+
+	if err := l.store.Put(url, context); err != nil {
+		return fmt.Errorf("save context: %w", err)
+	}
+
+	l.mu.Lock()
+	l.contexts[url] = &ld.RemoteDocument{DocumentURL: url, Document: doc}
+	l.mu.Unlock()
+
+	return nil
+}
+
+// LoadDocument returns the JSON-LD document with the given URL.
+func (l *DocumentLoader) LoadDocument(url string) (*ld.RemoteDocument, error) {
+	l.mu.RLock()
+	doc, ok := l.contexts[url]
+	l.mu.RUnlock()
+
+	if ok {
+		return doc, nil
+	}
+
+	doc, err := l.loadDocument(url)
+	if err != nil {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+	}
+
+	l.mu.Lock()
+	l.contexts[url] = doc
+	l.mu.Unlock()
+
+	return doc, nil
+}
+
+// loadDocument loads the document from the store or, if it is not cached, by the remote loader.
+func (l *DocumentLoader) loadDocument(url string) (*ld.RemoteDocument, error) {
+	src, err := l.store.Get(url)
+	if err == nil {
+		var doc interface{}
+		if err = json.Unmarshal(src, &doc); err != nil {
+			return nil, fmt.Errorf("parse cached document: %w", err)
+		}
+
+		return &ld.RemoteDocument{DocumentURL: url, Document: doc}, nil
+	}
+
+	if !errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("get cached document: %w", err)
+	}
+
+	if l.remote == nil {
+		return nil, fmt.Errorf("document %s is not cached and remote documents are disabled", url)
+	}
+
+	doc, err := l.remote.LoadDocument(url)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = json.Marshal(doc.Document)
+	if err != nil {
+		return nil, fmt.Errorf("marshal document: %w", err)
+	}
+
+	if err = l.store.Put(url, src); err != nil {
+		return nil, fmt.Errorf("cache document: %w", err)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const (
+	customContextURL = "https://example.com/context/v1"
+	customContext    = `{"@context": {"name": "https://example.com/vocab#name"}}`
+)
+
+type mockRemoteLoader struct {
+	calls int
+	err   error
+}
+
+func (m *mockRemoteLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	m.calls++
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	doc, err := ld.DocumentFromReader(strings.NewReader(customContext))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ld.RemoteDocument{DocumentURL: u, Document: doc}, nil
+}
+
+func TestDocumentLoader(t *testing.T) {
+	t.Run("standard context is preloaded", func(t *testing.T) {
+		remote := &mockRemoteLoader{}
+
+		loader, err := NewDocumentLoader(mem.NewProvider(), WithRemoteDocumentLoader(remote))
+		require.NoError(t, err)
+
+		for _, url := range []string{CredentialsContextURL, SecurityContextURL, SecurityV1ContextURL} {
+			doc, err := loader.LoadDocument(url)
+			require.NoError(t, err)
+			require.Equal(t, url, doc.DocumentURL)
+			require.NotNil(t, doc.Document)
+		}
+
+		require.Zero(t, remote.calls)
+	})
+
+	t.Run("registered standard context is preloaded", func(t *testing.T) {
+		RegisterStandardContext(customContextURL, customContext)
+
+		defer func() {
+			contextsMutex.Lock()
+			delete(standardContexts, customContextURL)
+			contextsMutex.Unlock()
+		}()
+
+		require.Equal(t, customContext, StandardContexts()[customContextURL])
+
+		remote := &mockRemoteLoader{}
+
+		loader, err := NewDocumentLoader(mem.NewProvider(), WithRemoteDocumentLoader(remote))
+		require.NoError(t, err)
+
+		doc, err := loader.LoadDocument(customContextURL)
+		require.NoError(t, err)
+		require.Equal(t, customContextURL, doc.DocumentURL)
+		require.Zero(t, remote.calls)
+	})
+
+	t.Run("remote context is cached", func(t *testing.T) {
+		provider := mem.NewProvider()
+		remote := &mockRemoteLoader{}
+
+		loader, err := NewDocumentLoader(provider, WithRemoteDocumentLoader(remote))
+		require.NoError(t, err)
+
+		doc, err := loader.LoadDocument(customContextURL)
+		require.NoError(t, err)
+		require.NotNil(t, doc.Document)
+
+		_, err = loader.LoadDocument(customContextURL)
+		require.NoError(t, err)
+		require.Equal(t, 1, remote.calls)
+
+		// the context is loaded from the store once the framework is restarted, even offline
+		offline, err := NewDocumentLoader(provider, WithRemoteDocumentLoader(nil))
+		require.NoError(t, err)
+
+		cached, err := offline.LoadDocument(customContextURL)
+		require.NoError(t, err)
+		require.Equal(t, doc.Document, cached.Document)
+	})
+
+	t.Run("custom context", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		loader, err := NewDocumentLoader(provider, WithRemoteDocumentLoader(nil))
+		require.NoError(t, err)
+
+		require.NoError(t, loader.AddContext(customContextURL, []byte(customContext)))
+
+		doc, err := loader.LoadDocument(customContextURL)
+		require.NoError(t, err)
+		require.Equal(t, customContextURL, doc.DocumentURL)
+
+		restarted, err := NewDocumentLoader(provider, WithRemoteDocumentLoader(nil))
+		require.NoError(t, err)
+
+		_, err = restarted.LoadDocument(customContextURL)
+		require.NoError(t, err)
+
+		require.Error(t, loader.AddContext(customContextURL, []byte("not JSON")))
+	})
+
+	t.Run("remote contexts disabled", func(t *testing.T) {
+		loader, err := NewDocumentLoader(mem.NewProvider(), WithRemoteDocumentLoader(nil))
+		require.NoError(t, err)
+
+		_, err = loader.LoadDocument(customContextURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "remote documents are disabled")
+	})
+
+	t.Run("proof canonicalized offline with the security contexts", func(t *testing.T) {
+		loader, err := NewDocumentLoader(mem.NewProvider(), WithRemoteDocumentLoader(nil))
+		require.NoError(t, err)
+
+		canonical, err := Default().GetCanonicalDocument(map[string]interface{}{
+			"@context":           SecurityContextURL,
+			"type":               "Ed25519Signature2018",
+			"created":            "2020-01-01T00:00:00Z",
+			"proofPurpose":       "assertionMethod",
+			"verificationMethod": "did:example:123#key-1",
+		}, WithDocumentLoader(loader))
+		require.NoError(t, err)
+		require.Contains(t, string(canonical), "<https://w3id.org/security#Ed25519Signature2018>")
+		require.Contains(t, string(canonical), "<http://purl.org/dc/terms/created>")
+	})
+
+	t.Run("remote loader error", func(t *testing.T) {
+		loader, err := NewDocumentLoader(mem.NewProvider(),
+			WithRemoteDocumentLoader(&mockRemoteLoader{err: errors.New("test error")}))
+		require.NoError(t, err)
+
+		_, err = loader.LoadDocument(customContextURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "test error")
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		_, err := NewDocumentLoader(&mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("test error")})
+		require.EqualError(t, err, "open store: test error")
+
+		provider := mockstorage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		loader, err := NewDocumentLoader(provider, WithRemoteDocumentLoader(&mockRemoteLoader{}))
+		require.NoError(t, err)
+
+		require.EqualError(t, loader.AddContext(customContextURL, []byte(customContext)), "save context: put error")
+
+		_, err = loader.LoadDocument(customContextURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cache document: put error")
+	})
+}
+
+func TestGetCanonicalDocument_DocumentLoader(t *testing.T) {
+	loader, err := NewDocumentLoader(mem.NewProvider(), WithRemoteDocumentLoader(nil))
+	require.NoError(t, err)
+
+	require.NoError(t, loader.AddContext(customContextURL, []byte(customContext)))
+
+	doc := map[string]interface{}{
+		"@context": []interface{}{CredentialsContextURL, customContextURL},
+		"type":     "VerifiableCredential",
+		"name":     "Alice",
+	}
+
+	// the contexts are loaded offline
+	result, err := Default().GetCanonicalDocument(doc, WithDocumentLoader(loader))
+	require.NoError(t, err)
+	require.Contains(t, string(result), `<https://example.com/vocab#name> "Alice"`)
+
+	doc["@context"] = []interface{}{CredentialsContextURL, "https://example.com/unknown"}
+
+	_, err = Default().GetCanonicalDocument(doc, WithDocumentLoader(loader))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "https://example.com/unknown")
+}
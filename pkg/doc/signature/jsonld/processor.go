@@ -26,45 +26,156 @@ const (
 var logger = log.New("aries-framework/json-ld-processor")
 
 // nolint:gochecknoglobals
-var (
-	invalidRDFLinePattern = regexp.MustCompile("[0-9]*$")
-)
+var invalidRDFLinePattern = regexp.MustCompile("[0-9]*$")
+
+// Options are the options of the processing of a document by a Backend. The documents are processed
+// in the JSON-LD 1.1 processing mode and the RDF datasets are in the N-Quads format.
+type Options struct {
+	// Algorithm is the RDF dataset canonicalization algorithm (e.g URDNA2015).
+	Algorithm string
+	// DocumentLoader loads the JSON-LD contexts, nil if the backend fetches them itself.
+	DocumentLoader ld.DocumentLoader
+	// ProduceGeneralizedRDF keeps the RDF triples with a blank node predicate.
+	ProduceGeneralizedRDF bool
+}
+
+// Backend is the JSON-LD processing library used by a processor (e.g a faster or a WASM-friendly
+// implementation), by default piprate/json-gold:
+//  - Normalize returns the canonized document in the N-Quads format.
+//  - FromRDF converts a dataset in the N-Quads format to a JSON-LD document.
+type Backend interface {
+	Normalize(input interface{}, opts *Options) (string, error)
+	Compact(input, context interface{}, opts *Options) (map[string]interface{}, error)
+	Frame(input, frame interface{}, opts *Options) (map[string]interface{}, error)
+	FromRDF(dataset string, opts *Options) (interface{}, error)
+}
+
+// goldBackend processes the documents with piprate/json-gold.
+type goldBackend struct {
+	proc *ld.JsonLdProcessor
+}
+
+func newGoldBackend() *goldBackend {
+	return &goldBackend{proc: ld.NewJsonLdProcessor()}
+}
+
+func (b *goldBackend) Normalize(input interface{}, opts *Options) (string, error) {
+	view, err := b.proc.Normalize(input, goldOptions(opts))
+	if err != nil {
+		return "", err
+	}
+
+	nquads, ok := view.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected normalized view type %T", view)
+	}
+
+	return nquads, nil
+}
+
+func (b *goldBackend) Compact(input, context interface{}, opts *Options) (map[string]interface{}, error) {
+	return b.proc.Compact(input, context, goldOptions(opts))
+}
+
+func (b *goldBackend) Frame(input, frame interface{}, opts *Options) (map[string]interface{}, error) {
+	return b.proc.Frame(input, frame, goldOptions(opts))
+}
+
+func (b *goldBackend) FromRDF(dataset string, opts *Options) (interface{}, error) {
+	return b.proc.FromRDF(dataset, goldOptions(opts))
+}
+
+func goldOptions(opts *Options) *ld.JsonLdOptions {
+	options := ld.NewJsonLdOptions("")
+	options.ProcessingMode = ld.JsonLd_1_1
+	options.Format = format
+	options.ProduceGeneralizedRdf = opts.ProduceGeneralizedRDF
+
+	if opts.Algorithm != "" {
+		options.Algorithm = opts.Algorithm
+	}
+
+	if opts.DocumentLoader != nil {
+		options.DocumentLoader = opts.DocumentLoader
+	}
+
+	return options
+}
 
 // Processor is JSON-LD processor for aries.
 // processing mode JSON-LD 1.0 {RFC: https://www.w3.org/TR/2014/REC-json-ld-20140116}
 type Processor struct {
 	algorithm string
+	backend   Backend
+}
+
+// Opt is the option of a processor.
+type Opt func(p *Processor)
+
+// WithBackend sets the JSON-LD processing library of the processor, by default json-gold.
+// A nil backend is ignored.
+func WithBackend(backend Backend) Opt {
+	return func(p *Processor) {
+		if backend != nil {
+			p.backend = backend
+		}
+	}
 }
 
 // NewProcessor returns new JSON-LD processor for aries
-func NewProcessor(algorithm string) *Processor {
+func NewProcessor(algorithm string, opts ...Opt) *Processor {
 	if algorithm == "" {
-		return Default()
+		algorithm = defaultAlgorithm
+	}
+
+	p := &Processor{algorithm: algorithm, backend: newGoldBackend()}
+
+	for _, opt := range opts {
+		opt(p)
 	}
 
-	return &Processor{algorithm}
+	return p
 }
 
 // Default returns new JSON-LD processor with default RDF dataset algorithm
-func Default() *Processor {
-	return &Processor{defaultAlgorithm}
+func Default(opts ...Opt) *Processor {
+	return NewProcessor(defaultAlgorithm, opts...)
+}
+
+// processorOpts holds the options of the processing of a document.
+type processorOpts struct {
+	documentLoader ld.DocumentLoader
+}
+
+// ProcessorOpts is the option of the processing of a document.
+type ProcessorOpts func(opts *processorOpts)
+
+// WithDocumentLoader defines the loader of the JSON-LD contexts (e.g a DocumentLoader), by default they are
+// fetched using HTTP. A nil loader is ignored.
+func WithDocumentLoader(loader ld.DocumentLoader) ProcessorOpts {
+	return func(opts *processorOpts) {
+		opts.documentLoader = loader
+	}
 }
 
 // GetCanonicalDocument returns canonized document of given json ld
-func (p *Processor) GetCanonicalDocument(doc map[string]interface{}) ([]byte, error) {
-	proc := ld.NewJsonLdProcessor()
-	options := ld.NewJsonLdOptions("")
-	options.ProcessingMode = ld.JsonLd_1_1
-	options.Algorithm = p.algorithm
-	options.Format = format
-	options.ProduceGeneralizedRdf = true
+func (p *Processor) GetCanonicalDocument(doc map[string]interface{}, opts ...ProcessorOpts) ([]byte, error) {
+	procOpts := &processorOpts{}
+
+	for _, opt := range opts {
+		opt(procOpts)
+	}
 
-	view, err := proc.Normalize(doc, options)
+	nquads, err := p.backend.Normalize(doc, &Options{
+		Algorithm:             p.algorithm,
+		DocumentLoader:        procOpts.documentLoader,
+		ProduceGeneralizedRDF: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to normalize JSON-LD document: %w", err)
 	}
 
-	result, err := p.validateView(view.(string))
+	result, err := p.validateView(nquads)
 	if err != nil {
 		return nil, fmt.Errorf("failed to normalize due to invalid RDF dataset: %w", err)
 	}
@@ -74,17 +185,12 @@ func (p *Processor) GetCanonicalDocument(doc map[string]interface{}) ([]byte, er
 
 // Compact compacts given json ld object
 func (p *Processor) Compact(input, context interface{}, loader ld.DocumentLoader) (map[string]interface{}, error) {
-	proc := ld.NewJsonLdProcessor()
-	options := ld.NewJsonLdOptions("")
-	options.ProcessingMode = ld.JsonLd_1_1
-	options.Format = format
-	options.ProduceGeneralizedRdf = true
-
-	if loader != nil {
-		options.DocumentLoader = loader
-	}
+	return p.backend.Compact(input, context, &Options{DocumentLoader: loader, ProduceGeneralizedRDF: true})
+}
 
-	return proc.Compact(input, context, options)
+// Frame frames given json ld object
+func (p *Processor) Frame(input, frame interface{}, loader ld.DocumentLoader) (map[string]interface{}, error) {
+	return p.backend.Frame(input, frame, &Options{DocumentLoader: loader})
 }
 
 // validateView validates normalized view to find any invalid RDF. If found then it discards that data
@@ -115,23 +221,14 @@ func (p *Processor) validateView(view string) (string, error) {
 // normalizeFilteredDataset recreates json-ld from RDF view and
 // returns normalized RDF dataset from recreated json-ld
 func (p *Processor) normalizeFilteredDataset(view string) (string, error) {
-	proc := ld.NewJsonLdProcessor()
-	options := ld.NewJsonLdOptions("")
-	options.ProcessingMode = ld.JsonLd_1_1
-	options.Algorithm = p.algorithm
-	options.Format = format
-
-	filteredJSONLd, err := proc.FromRDF(view, options)
-	if err != nil {
-		return "", err
-	}
+	options := &Options{Algorithm: p.algorithm}
 
-	result, err := proc.Normalize(filteredJSONLd, options)
+	filteredJSONLd, err := p.backend.FromRDF(view, options)
 	if err != nil {
 		return "", err
 	}
 
-	return result.(string), nil
+	return p.backend.Normalize(filteredJSONLd, options)
 }
 
 // removeQuad removes quad from given index of view
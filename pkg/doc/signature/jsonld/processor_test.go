@@ -8,12 +8,15 @@ package jsonld
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"testing"
 
 	"github.com/piprate/json-gold/ld"
 	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 )
 
 func TestGetCanonicalDocument(t *testing.T) {
@@ -111,6 +114,153 @@ func TestCompact(t *testing.T) {
 	})
 }
 
+func TestFrame(t *testing.T) {
+	doc := map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://example.org/vocab#"},
+		"@graph": []interface{}{
+			map[string]interface{}{"@id": "http://example.org/test#book", "@type": "Book", "title": "Title"},
+			map[string]interface{}{"@id": "http://example.org/test#chapter", "@type": "Chapter", "title": "Chapter"},
+		},
+	}
+
+	frame := map[string]interface{}{
+		"@context": map[string]interface{}{"@vocab": "http://example.org/vocab#"},
+		"@type":    "Chapter",
+	}
+
+	framedDoc, err := Default().Frame(doc, frame, ld.NewDefaultDocumentLoader(nil))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"@id":   "http://example.org/test#chapter",
+		"@type": "Chapter",
+		"title": "Chapter",
+	}}, framedDoc["@graph"])
+}
+
+// countingBackend counts the calls to json-gold.
+type countingBackend struct {
+	*goldBackend
+	calls map[string]int
+}
+
+func (b *countingBackend) Normalize(input interface{}, opts *Options) (string, error) {
+	b.calls["Normalize"]++
+
+	return b.goldBackend.Normalize(input, opts)
+}
+
+func (b *countingBackend) Compact(input, context interface{}, opts *Options) (map[string]interface{}, error) {
+	b.calls["Compact"]++
+
+	return b.goldBackend.Compact(input, context, opts)
+}
+
+func (b *countingBackend) Frame(input, frame interface{}, opts *Options) (map[string]interface{}, error) {
+	b.calls["Frame"]++
+
+	return b.goldBackend.Frame(input, frame, opts)
+}
+
+func (b *countingBackend) FromRDF(dataset string, opts *Options) (interface{}, error) {
+	b.calls["FromRDF"]++
+
+	return b.goldBackend.FromRDF(dataset, opts)
+}
+
+// failingBackend fails to normalize the documents.
+type failingBackend struct {
+	*goldBackend
+}
+
+func (b *failingBackend) Normalize(interface{}, *Options) (string, error) {
+	return "", errors.New("normalize error")
+}
+
+func TestWithBackend(t *testing.T) {
+	t.Run("Custom backend", func(t *testing.T) {
+		backend := &countingBackend{goldBackend: newGoldBackend(), calls: map[string]int{}}
+		processor := Default(WithBackend(backend))
+
+		loader := newTestDocumentLoader(t)
+		doc := credentialDocument(t)
+
+		_, err := processor.GetCanonicalDocument(doc, WithDocumentLoader(loader))
+		require.NoError(t, err)
+
+		_, err = processor.Compact(doc, map[string]interface{}{"@context": CredentialsContextURL}, loader)
+		require.NoError(t, err)
+
+		_, err = processor.Frame(doc, map[string]interface{}{"@context": CredentialsContextURL}, loader)
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]int{"Normalize": 2, "FromRDF": 1, "Compact": 1, "Frame": 1}, backend.calls)
+
+		// the backend is set per processor
+		require.IsType(t, &goldBackend{}, Default().backend)
+	})
+
+	t.Run("Failing backend", func(t *testing.T) {
+		processor := NewProcessor(defaultAlgorithm, WithBackend(&failingBackend{goldBackend: newGoldBackend()}))
+
+		_, err := processor.GetCanonicalDocument(map[string]interface{}{})
+		require.EqualError(t, err, "failed to normalize JSON-LD document: normalize error")
+
+		_, err = processor.normalizeFilteredDataset(canonizedJsonLDProof)
+		require.EqualError(t, err, "normalize error")
+	})
+
+	t.Run("Nil backend", func(t *testing.T) {
+		require.IsType(t, &goldBackend{}, NewProcessor("", WithBackend(nil)).backend)
+	})
+}
+
+func newTestDocumentLoader(b testing.TB) *DocumentLoader {
+	b.Helper()
+
+	loader, err := NewDocumentLoader(mem.NewProvider(), WithRemoteDocumentLoader(nil))
+	require.NoError(b, err)
+
+	return loader
+}
+
+func credentialDocument(b testing.TB) map[string]interface{} {
+	b.Helper()
+
+	var doc map[string]interface{}
+	require.NoError(b, json.Unmarshal([]byte(credentialSample), &doc))
+
+	return doc
+}
+
+func BenchmarkGetCanonicalDocument(b *testing.B) {
+	loader := newTestDocumentLoader(b)
+	doc := credentialDocument(b)
+	processor := Default()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := processor.GetCanonicalDocument(doc, WithDocumentLoader(loader))
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkCompact(b *testing.B) {
+	loader := newTestDocumentLoader(b)
+	doc := credentialDocument(b)
+	context := map[string]interface{}{"@context": CredentialsContextURL}
+	processor := Default()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := processor.Compact(doc, context, loader)
+		require.NoError(b, err)
+	}
+}
+
 func TestUtilFunctions(t *testing.T) {
 	t.Run("Test find line number", func(t *testing.T) {
 		l, e := findLineNumber(fmt.Errorf("sample error"))
@@ -125,6 +275,23 @@ func TestUtilFunctions(t *testing.T) {
 }
 
 const (
+	// credentialSample only uses the credentials context, which is embedded in the document loader
+	credentialSample = `{
+  "@context": "https://www.w3.org/2018/credentials/v1",
+  "id": "http://example.edu/credentials/1872",
+  "type": "VerifiableCredential",
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2010-01-01T19:23:24Z",
+  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"},
+  "proof": {
+    "type": "Ed25519Signature2018",
+    "created": "2020-04-08T04:00:22Z",
+    "proofPurpose": "assertionMethod",
+    "verificationMethod": "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1",
+    "jws": "eyJhbGciOiJFZERTQSIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..signature"
+  }
+}`
+
 	jsonLdWithIncorrectRDF = `{
   "@context": [
     "https://www.w3.org/2018/credentials/v1",
@@ -42,7 +42,7 @@ func New(opts ...suite.Opt) *Suite {
 // GetCanonicalDocument will return normalized/canonical version of the document
 // Ed25519Signature2018 signature SignatureSuite uses RDF Dataset Normalization as canonicalization algorithm
 func (s *Suite) GetCanonicalDocument(doc map[string]interface{}) ([]byte, error) {
-	return s.jsonldProcessor.GetCanonicalDocument(doc)
+	return s.jsonldProcessor.GetCanonicalDocument(doc, jsonld.WithDocumentLoader(s.DocumentLoader))
 }
 
 // GetDigest returns document digest
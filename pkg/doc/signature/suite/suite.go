@@ -8,6 +8,8 @@ package suite
 import (
 	"errors"
 
+	"github.com/piprate/json-gold/ld"
+
 	sigverifier "github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
 )
 
@@ -16,6 +18,8 @@ type SignatureSuite struct {
 	Signer         signer
 	Verifier       verifier
 	CompactedProof bool
+	// DocumentLoader loads the JSON-LD contexts of the canonicalized documents (fetched using HTTP if nil)
+	DocumentLoader ld.DocumentLoader
 }
 
 type signer interface {
@@ -52,6 +56,14 @@ func WithCompactProof() Opt {
 	}
 }
 
+// WithDocumentLoader defines the loader of the JSON-LD contexts used to canonicalize the documents,
+// e.g jsonld.DocumentLoader to process them offline.
+func WithDocumentLoader(loader ld.DocumentLoader) Opt {
+	return func(opts *SignatureSuite) {
+		opts.DocumentLoader = loader
+	}
+}
+
 // InitSuiteOptions initializes signature suite with options.
 func InitSuiteOptions(suite *SignatureSuite, opts ...Opt) *SignatureSuite {
 	for _, opt := range opts {
@@ -21,6 +21,7 @@ import (
 
 	"github.com/xeipuuv/gojsonschema"
 
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 )
@@ -83,6 +84,11 @@ func (r *DIDKeyResolver) resolvePublicKey(issuerDID, keyID string) (*verifier.Pu
 		return nil, fmt.Errorf("resolve DID %s: %w", issuerDID, err)
 	}
 
+	return findPublicKey(doc, issuerDID, keyID)
+}
+
+// findPublicKey finds the public key with the given ID in the DID document of the issuer.
+func findPublicKey(doc *did.Doc, issuerDID, keyID string) (*verifier.PublicKey, error) {
 	for _, key := range doc.PublicKey {
 		// TODO remove string contains after sidetree create public key with this format DID#KEYID
 		// sidetree now return #KEYID
@@ -1094,10 +1094,10 @@ func (vc *Credential) JWTClaims(minimizeVC bool) (*JWTCredClaims, error) {
 	return newJWTCredClaims(vc, minimizeVC)
 }
 
-// subjectID gets ID of single subject if present or
+// SubjectID gets ID of single subject if present or
 // returns error if there are several subjects or one without ID defined.
 // It can also try to get ID from subject of struct type.
-func subjectID(subject interface{}) (string, error) {
+func SubjectID(subject interface{}) (string, error) {
 	subjectIDFn := func(subject map[string]interface{}) (string, error) {
 		subjectWithID, defined := subject["id"]
 		if !defined {
@@ -1137,7 +1137,7 @@ func subjectID(subject interface{}) (string, error) {
 			return "", errors.New("subject of unknown structure")
 		}
 
-		return subjectID(sMap)
+		return SubjectID(sMap)
 	}
 }
 
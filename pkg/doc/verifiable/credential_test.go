@@ -1016,7 +1016,7 @@ func TestDownloadCustomSchema(t *testing.T) {
 func TestCredentialSubjectId(t *testing.T) {
 	t.Run("With string subject", func(t *testing.T) {
 		vcWithStringSubject := &Credential{Subject: "did:example:ebfeb1f712ebc6f1c276e12ecaa"}
-		subjectID, err := subjectID(vcWithStringSubject.Subject)
+		subjectID, err := SubjectID(vcWithStringSubject.Subject)
 		require.NoError(t, err)
 		require.Equal(t, "did:example:ebfeb1f712ebc6f1c276e12ecaa", subjectID)
 	})
@@ -1029,7 +1029,7 @@ func TestCredentialSubjectId(t *testing.T) {
 				"name": "Bachelor of Science and Arts",
 			},
 		}}
-		subjectID, err := subjectID(vcWithSingleSubject.Subject)
+		subjectID, err := SubjectID(vcWithSingleSubject.Subject)
 		require.NoError(t, err)
 		require.Equal(t, "did:example:ebfeb1f712ebc6f1c276e12ecaa", subjectID)
 	})
@@ -1042,7 +1042,7 @@ func TestCredentialSubjectId(t *testing.T) {
 				"name": "Bachelor of Science and Arts",
 			},
 		}}}
-		subjectID, err := subjectID(vcWithSingleSubject.Subject)
+		subjectID, err := SubjectID(vcWithSingleSubject.Subject)
 		require.NoError(t, err)
 		require.Equal(t, "did:example:ebfeb1f712ebc6f1c276e12ecaa", subjectID)
 	})
@@ -1061,7 +1061,7 @@ func TestCredentialSubjectId(t *testing.T) {
 					"spouse": "did:example:ebfeb1f712ebc6f1c276e12ec21",
 				},
 			}}
-		subjectID, err := subjectID(vcWithMultipleSubjects.Subject)
+		subjectID, err := SubjectID(vcWithMultipleSubjects.Subject)
 		require.Error(t, err)
 		require.EqualError(t, err, "more than one subject is defined")
 		require.Empty(t, subjectID)
@@ -1071,7 +1071,7 @@ func TestCredentialSubjectId(t *testing.T) {
 		vcWithNoSubject := &Credential{
 			Subject: nil,
 		}
-		subjectID, err := subjectID(vcWithNoSubject.Subject)
+		subjectID, err := SubjectID(vcWithNoSubject.Subject)
 		require.Error(t, err)
 		require.EqualError(t, err, "subject id is not defined")
 		require.Empty(t, subjectID)
@@ -1081,7 +1081,7 @@ func TestCredentialSubjectId(t *testing.T) {
 		vcWithNoSubject := &Credential{
 			Subject: []map[string]interface{}{},
 		}
-		subjectID, err := subjectID(vcWithNoSubject.Subject)
+		subjectID, err := SubjectID(vcWithNoSubject.Subject)
 		require.Error(t, err)
 		require.EqualError(t, err, "no subject is defined")
 		require.Empty(t, subjectID)
@@ -1095,7 +1095,7 @@ func TestCredentialSubjectId(t *testing.T) {
 				"name": "Bachelor of Science and Arts",
 			},
 		}}
-		subjectID, err := subjectID(vcWithNotStringID.Subject)
+		subjectID, err := SubjectID(vcWithNotStringID.Subject)
 		require.Error(t, err)
 		require.EqualError(t, err, "subject id is not string")
 		require.Empty(t, subjectID)
@@ -1113,7 +1113,7 @@ func TestCredentialSubjectId(t *testing.T) {
 				},
 			},
 		}
-		subjectID, err := subjectID(vcWithSubjectWithoutID.Subject)
+		subjectID, err := SubjectID(vcWithSubjectWithoutID.Subject)
 		require.Error(t, err)
 		require.EqualError(t, err, "subject id is not defined")
 		require.Empty(t, subjectID)
@@ -1143,13 +1143,13 @@ func TestCredentialSubjectId(t *testing.T) {
 				},
 			},
 		}
-		subjectID, err := subjectID(vcWithSubjectWithoutID.Subject)
+		subjectID, err := SubjectID(vcWithSubjectWithoutID.Subject)
 		require.NoError(t, err)
 		require.Equal(t, "did:example:ebfeb1f712ebc6f1c276e12ec21", subjectID)
 	})
 
 	t.Run("Get subject id from unmarshalable structure", func(t *testing.T) {
-		subjectID, err := subjectID(make(chan int))
+		subjectID, err := SubjectID(make(chan int))
 		require.Error(t, err)
 		require.EqualError(t, err, "subject of unknown structure")
 		require.Empty(t, subjectID)
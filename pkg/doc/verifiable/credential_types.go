@@ -0,0 +1,170 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrUnregisteredType is returned when no subject type is registered for the types of a credential.
+var ErrUnregisteredType = errors.New("no subject type registered")
+
+// CredentialTypes maps the credential types to the Go structs of their subject, e.g the application registers
+// the UniversityDegreeSubject struct for the UniversityDegreeCredential type to access the subject of the
+// degree credentials as a *UniversityDegreeSubject instead of a map[string]interface{}.
+type CredentialTypes struct {
+	mu       sync.RWMutex
+	subjects map[string]reflect.Type
+}
+
+// NewCredentialTypes returns a new empty registry of credential types.
+func NewCredentialTypes() *CredentialTypes {
+	return &CredentialTypes{subjects: map[string]reflect.Type{}}
+}
+
+// Register maps the credential type to the struct of its subject, given as a value or a pointer
+// (e.g UniversityDegreeSubject{}). The subjects are decoded with encoding/json, the struct fields should
+// have JSON tags matching the compacted subject.
+func (t *CredentialTypes) Register(credentialType string, subject interface{}) error {
+	if credentialType == "" {
+		return errors.New("register credential type: type is mandatory")
+	}
+
+	subjectType := reflect.TypeOf(subject)
+	if subjectType != nil && subjectType.Kind() == reflect.Ptr {
+		subjectType = subjectType.Elem()
+	}
+
+	if subjectType == nil || subjectType.Kind() != reflect.Struct {
+		return fmt.Errorf("register credential type %s: subject must be a struct, got %T", credentialType, subject)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.subjects[credentialType]; ok {
+		return fmt.Errorf("register credential type %s: already registered", credentialType)
+	}
+
+	t.subjects[credentialType] = subjectType
+
+	return nil
+}
+
+// Subject decodes the subject of the credential into a new instance of the struct registered for its type,
+// the most specific one if several of its types are registered (the last one of the credential types).
+// It returns a pointer to the struct, or a slice of pointers if the credential has several subjects.
+func (t *CredentialTypes) Subject(vc *Credential) (interface{}, error) {
+	subjectType, ok := t.subjectType(vc.Types)
+	if !ok {
+		return nil, fmt.Errorf("%w for the credential types %v", ErrUnregisteredType, vc.Types)
+	}
+
+	target := reflect.New(subjectType)
+
+	if len(subjects(vc.Subject)) > 1 {
+		target = reflect.New(reflect.SliceOf(reflect.PtrTo(subjectType)))
+	}
+
+	if err := vc.DecodeSubject(target.Interface()); err != nil {
+		return nil, err
+	}
+
+	if target.Elem().Kind() == reflect.Slice {
+		return target.Elem().Interface(), nil
+	}
+
+	return target.Interface(), nil
+}
+
+// ParseCredential parses the credential (see NewCredential) and decodes its subject (see Subject).
+func (t *CredentialTypes) ParseCredential(vcData []byte, opts ...CredentialOpt) (*Credential, interface{}, error) {
+	vc, _, err := NewCredential(vcData, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subject, err := t.Subject(vc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return vc, subject, nil
+}
+
+func (t *CredentialTypes) subjectType(types []string) (reflect.Type, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for i := len(types) - 1; i >= 0; i-- {
+		if subjectType, ok := t.subjects[types[i]]; ok {
+			return subjectType, true
+		}
+	}
+
+	return nil, false
+}
+
+// DecodeSubject decodes the subject of the credential into the given value, like json.Unmarshal: a pointer to
+// a struct for a single subject or a pointer to a slice for several subjects.
+func (vc *Credential) DecodeSubject(subject interface{}) error {
+	target := reflect.ValueOf(subject)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("decode subject: non-nil pointer expected, got %T", subject)
+	}
+
+	all := subjects(vc.Subject)
+
+	var source interface{} = all
+
+	if target.Elem().Kind() != reflect.Slice {
+		if len(all) != 1 {
+			return fmt.Errorf("decode subject: %d subjects can not be decoded into %T", len(all), subject)
+		}
+
+		source = all[0]
+	}
+
+	subjectJSON, err := json.Marshal(source)
+	if err != nil {
+		return fmt.Errorf("decode subject: %w", err)
+	}
+
+	if err := json.Unmarshal(subjectJSON, subject); err != nil {
+		return fmt.Errorf("decode subject: %w", err)
+	}
+
+	return nil
+}
+
+// subjects returns the subjects of the credential, which has either a subject or an array of subjects.
+// A subject given by its ID is returned as an object with the ID.
+func subjects(subject Subject) []interface{} {
+	switch s := subject.(type) {
+	case nil:
+		return nil
+	case string:
+		return []interface{}{map[string]interface{}{"id": s}}
+	}
+
+	value := reflect.ValueOf(subject)
+	if value.Kind() != reflect.Slice {
+		return []interface{}{subject}
+	}
+
+	all := make([]interface{}, value.Len())
+
+	for i := range all {
+		all[i] = value.Index(i).Interface()
+	}
+
+	return all
+}
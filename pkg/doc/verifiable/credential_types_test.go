@@ -0,0 +1,196 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const (
+	degreeContextURL = "https://example.com/context/v1"
+	// degreeContext defines the terms of the test credentials, the tests do not load remote contexts
+	degreeContext = `{"@context": {"@vocab": "https://example.com/vocab#"}}`
+
+	typedDegreeCredential = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1", "https://example.com/context/v1"],
+  "id": "http://example.edu/credentials/1872",
+  "type": ["VerifiableCredential", "UniversityDegreeCredential"],
+  "issuer": "did:example:university",
+  "issuanceDate": "2010-01-01T19:23:24Z",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+    "name": "Jayden Doe",
+    "degree": {"type": "BachelorDegree", "university": "MIT"}
+  }
+}`
+)
+
+func degreeLoader(t *testing.T) CredentialOpt {
+	t.Helper()
+
+	loader, err := jsonld.NewDocumentLoader(mem.NewProvider(), jsonld.WithRemoteDocumentLoader(nil))
+	require.NoError(t, err)
+	require.NoError(t, loader.AddContext(degreeContextURL, []byte(degreeContext)))
+
+	return WithJSONLDDocumentLoader(loader)
+}
+
+func TestCredentialTypes_Register(t *testing.T) {
+	types := NewCredentialTypes()
+	require.NoError(t, types.Register("UniversityDegreeCredential", &UniversityDegreeSubject{}))
+
+	err := types.Register("UniversityDegreeCredential", UniversityDegreeSubject{})
+	require.EqualError(t, err, "register credential type UniversityDegreeCredential: already registered")
+
+	err = types.Register("", UniversityDegreeSubject{})
+	require.EqualError(t, err, "register credential type: type is mandatory")
+
+	err = types.Register("NameCredential", "name")
+	require.EqualError(t, err, "register credential type NameCredential: subject must be a struct, got string")
+
+	err = types.Register("MapCredential", map[string]interface{}{})
+	require.EqualError(t, err,
+		"register credential type MapCredential: subject must be a struct, got map[string]interface {}")
+
+	err = types.Register("NilCredential", nil)
+	require.EqualError(t, err, "register credential type NilCredential: subject must be a struct, got <nil>")
+}
+
+func TestCredentialTypes_ParseCredential(t *testing.T) {
+	types := NewCredentialTypes()
+	require.NoError(t, types.Register("UniversityDegreeCredential", UniversityDegreeSubject{}))
+
+	t.Run("Single subject", func(t *testing.T) {
+		vc, subject, err := types.ParseCredential([]byte(typedDegreeCredential), WithNoCustomSchemaCheck(),
+			degreeLoader(t))
+		require.NoError(t, err)
+		require.Equal(t, "http://example.edu/credentials/1872", vc.ID)
+		require.Equal(t, &UniversityDegreeSubject{
+			ID:     "did:example:ebfeb1f712ebc6f1c276e12ec21",
+			Name:   "Jayden Doe",
+			Degree: UniversityDegree{Type: "BachelorDegree", University: "MIT"},
+		}, subject)
+	})
+
+	t.Run("Several subjects", func(t *testing.T) {
+		vc := &Credential{
+			Types: []string{"VerifiableCredential", "UniversityDegreeCredential"},
+			Subject: []map[string]interface{}{
+				{"id": "did:example:alice", "name": "Alice"},
+				{"id": "did:example:bob", "name": "Bob"},
+			},
+		}
+
+		subject, err := types.Subject(vc)
+		require.NoError(t, err)
+		require.Equal(t, []*UniversityDegreeSubject{
+			{ID: "did:example:alice", Name: "Alice"},
+			{ID: "did:example:bob", Name: "Bob"},
+		}, subject)
+	})
+
+	t.Run("Single subject in an array", func(t *testing.T) {
+		vc := &Credential{
+			Types:   []string{"UniversityDegreeCredential"},
+			Subject: []interface{}{map[string]interface{}{"id": "did:example:alice"}},
+		}
+
+		subject, err := types.Subject(vc)
+		require.NoError(t, err)
+		require.Equal(t, &UniversityDegreeSubject{ID: "did:example:alice"}, subject)
+	})
+
+	t.Run("Most specific type", func(t *testing.T) {
+		type person struct {
+			Name string `json:"name"`
+		}
+
+		specific := NewCredentialTypes()
+		require.NoError(t, specific.Register("VerifiableCredential", person{}))
+		require.NoError(t, specific.Register("UniversityDegreeCredential", UniversityDegreeSubject{}))
+
+		subject, err := specific.Subject(&Credential{
+			Types:   []string{"VerifiableCredential", "UniversityDegreeCredential"},
+			Subject: map[string]interface{}{"name": "Alice"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, &UniversityDegreeSubject{}, subject)
+
+		subject, err = specific.Subject(&Credential{
+			Types:   []string{"VerifiableCredential", "PassportCredential"},
+			Subject: map[string]interface{}{"name": "Alice"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &person{Name: "Alice"}, subject)
+	})
+
+	t.Run("Unregistered type", func(t *testing.T) {
+		_, err := types.Subject(&Credential{Types: []string{"VerifiableCredential"}})
+		require.True(t, errors.Is(err, ErrUnregisteredType))
+		require.EqualError(t, err, "no subject type registered for the credential types [VerifiableCredential]")
+
+		_, _, err = NewCredentialTypes().ParseCredential([]byte(typedDegreeCredential), WithNoCustomSchemaCheck(),
+			degreeLoader(t))
+		require.EqualError(t, err,
+			"no subject type registered for the credential types [VerifiableCredential UniversityDegreeCredential]")
+	})
+
+	t.Run("Invalid credential", func(t *testing.T) {
+		_, _, err := types.ParseCredential([]byte("{"))
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid subject", func(t *testing.T) {
+		_, err := types.Subject(&Credential{
+			Types:   []string{"UniversityDegreeCredential"},
+			Subject: map[string]interface{}{"name": 42},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode subject")
+	})
+}
+
+func TestCredential_DecodeSubject(t *testing.T) {
+	vc := &Credential{Subject: map[string]interface{}{"id": "did:example:alice"}}
+
+	t.Run("Into a struct", func(t *testing.T) {
+		subject := &UniversityDegreeSubject{}
+		require.NoError(t, vc.DecodeSubject(subject))
+		require.Equal(t, "did:example:alice", subject.ID)
+	})
+
+	t.Run("Into a slice", func(t *testing.T) {
+		var subjects []UniversityDegreeSubject
+		require.NoError(t, vc.DecodeSubject(&subjects))
+		require.Equal(t, []UniversityDegreeSubject{{ID: "did:example:alice"}}, subjects)
+	})
+
+	t.Run("Subject ID", func(t *testing.T) {
+		subject := &UniversityDegreeSubject{}
+		require.NoError(t, (&Credential{Subject: "did:example:bob"}).DecodeSubject(subject))
+		require.Equal(t, &UniversityDegreeSubject{ID: "did:example:bob"}, subject)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		err := vc.DecodeSubject(UniversityDegreeSubject{})
+		require.EqualError(t, err, "decode subject: non-nil pointer expected, got verifiable.UniversityDegreeSubject")
+
+		err = (&Credential{}).DecodeSubject(&UniversityDegreeSubject{})
+		require.EqualError(t, err, "decode subject: 0 subjects can not be decoded into *verifiable.UniversityDegreeSubject")
+
+		err = (&Credential{Subject: map[string]interface{}{"invalid": make(chan int)}}).DecodeSubject(
+			&UniversityDegreeSubject{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode subject: json: unsupported type")
+	})
+}
@@ -0,0 +1,212 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+)
+
+const (
+	defaultKeyCacheExpiration = 5 * time.Minute
+	defaultKeyCacheSize       = 1000
+)
+
+// CachingDIDKeyResolver resolves DID in order to find public keys for VC and VP verification, like DIDKeyResolver,
+// and caches the resolved DID documents so that the DID of a popular issuer is not resolved for every
+// credential or presentation.
+//
+// A cached DID document is resolved again when it expires or when a key is not found in it (e.g the keys were
+// rotated), its keys are then replaced by the keys of the resolved document unless the resolved version
+// (its "updated" time) is older than the cached one, e.g when it was returned by a lagging resolver.
+type CachingDIDKeyResolver struct {
+	vdriRegistry vdri.Registry
+	expiration   time.Duration
+	size         int
+	now          func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]*cachedDIDDoc
+	// pending are the resolutions in progress: the concurrent verifications of the documents of the same DID
+	// wait for a single resolution of the DID.
+	pending map[string]*pendingResolution
+}
+
+type pendingResolution struct {
+	done chan struct{}
+	doc  *did.Doc
+	err  error
+}
+
+type cachedDIDDoc struct {
+	doc     *did.Doc
+	expires time.Time
+}
+
+// KeyCacheOpt configures a CachingDIDKeyResolver.
+type KeyCacheOpt func(r *CachingDIDKeyResolver)
+
+// WithKeyCacheExpiration sets the duration after which a cached DID document is resolved again (5 minutes by default).
+func WithKeyCacheExpiration(expiration time.Duration) KeyCacheOpt {
+	return func(r *CachingDIDKeyResolver) {
+		r.expiration = expiration
+	}
+}
+
+// WithKeyCacheSize sets the maximum number of cached DID documents (1000 by default).
+func WithKeyCacheSize(size int) KeyCacheOpt {
+	return func(r *CachingDIDKeyResolver) {
+		r.size = size
+	}
+}
+
+// NewCachingDIDKeyResolver creates CachingDIDKeyResolver.
+func NewCachingDIDKeyResolver(vdriRegistry vdri.Registry, opts ...KeyCacheOpt) *CachingDIDKeyResolver {
+	r := &CachingDIDKeyResolver{
+		vdriRegistry: vdriRegistry,
+		expiration:   defaultKeyCacheExpiration,
+		size:         defaultKeyCacheSize,
+		now:          time.Now,
+		entries:      map[string]*cachedDIDDoc{},
+		pending:      map[string]*pendingResolution{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// PublicKeyFetcher returns Public Key Fetcher via DID resolution mechanism, the DID documents are cached.
+func (r *CachingDIDKeyResolver) PublicKeyFetcher() PublicKeyFetcher {
+	return r.resolvePublicKey
+}
+
+// Invalidate removes the cached DID document of the given DID, e.g when its keys are known to be rotated.
+func (r *CachingDIDKeyResolver) Invalidate(didID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, didID)
+}
+
+func (r *CachingDIDKeyResolver) resolvePublicKey(issuerDID, keyID string) (*verifier.PublicKey, error) {
+	if doc, ok := r.cached(issuerDID); ok {
+		if key, err := findPublicKey(doc, issuerDID, keyID); err == nil {
+			return key, nil
+		}
+
+		// the key is not found in the cached document, the keys may have been rotated since it was cached
+	}
+
+	doc, err := r.resolve(issuerDID)
+	if err != nil {
+		return nil, err
+	}
+
+	return findPublicKey(doc, issuerDID, keyID)
+}
+
+// cached returns the cached DID document if it is not expired.
+func (r *CachingDIDKeyResolver) cached(didID string) (*did.Doc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.entries[didID]
+	if !ok || !r.now().Before(entry.expires) {
+		return nil, false
+	}
+
+	return entry.doc, true
+}
+
+// resolve resolves the DID document and caches it, the concurrent resolutions of the DID are done once.
+func (r *CachingDIDKeyResolver) resolve(didID string) (*did.Doc, error) {
+	r.mu.Lock()
+
+	if pending, ok := r.pending[didID]; ok {
+		r.mu.Unlock()
+		<-pending.done
+
+		return pending.doc, pending.err
+	}
+
+	pending := &pendingResolution{done: make(chan struct{})}
+	r.pending[didID] = pending
+	r.mu.Unlock()
+
+	pending.doc, pending.err = r.resolveAndCache(didID)
+
+	r.mu.Lock()
+	delete(r.pending, didID)
+	r.mu.Unlock()
+
+	close(pending.done)
+
+	return pending.doc, pending.err
+}
+
+func (r *CachingDIDKeyResolver) resolveAndCache(didID string) (*did.Doc, error) {
+	doc, err := r.vdriRegistry.Resolve(didID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve DID %s: %w", didID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[didID]
+	if ok && isOlder(doc, entry.doc) {
+		return entry.doc, nil
+	}
+
+	if !ok && len(r.entries) >= r.size {
+		r.evict()
+	}
+
+	r.entries[didID] = &cachedDIDDoc{doc: doc, expires: r.now().Add(r.expiration)}
+
+	return doc, nil
+}
+
+// evict removes the cached document expiring first.
+func (r *CachingDIDKeyResolver) evict() {
+	var (
+		oldest  string
+		expires time.Time
+	)
+
+	for didID, entry := range r.entries {
+		if oldest == "" || entry.expires.Before(expires) {
+			oldest, expires = didID, entry.expires
+		}
+	}
+
+	delete(r.entries, oldest)
+}
+
+// isOlder returns true if the version of the DID document is older than the version of the other document.
+func isOlder(doc, other *did.Doc) bool {
+	version, otherVersion := docVersion(doc), docVersion(other)
+
+	return version != nil && otherVersion != nil && version.Before(*otherVersion)
+}
+
+// docVersion returns the version of the DID document, i.e the time of its last update.
+func docVersion(doc *did.Doc) *time.Time {
+	if doc.Updated != nil {
+		return doc.Updated
+	}
+
+	return doc.Created
+}
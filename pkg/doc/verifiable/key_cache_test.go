@@ -0,0 +1,212 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+)
+
+// countingRegistry returns the current document of each DID and counts the resolutions
+type countingRegistry struct {
+	mockvdri.MockVDRIRegistry
+	docs        map[string]*did.Doc
+	resolutions int
+}
+
+func newCountingRegistry(docs ...*did.Doc) *countingRegistry {
+	r := &countingRegistry{docs: map[string]*did.Doc{}}
+
+	for _, doc := range docs {
+		r.docs[doc.ID] = doc
+	}
+
+	r.ResolveFunc = func(didID string, _ ...vdriapi.ResolveOpts) (*did.Doc, error) {
+		r.resolutions++
+
+		doc, ok := r.docs[didID]
+		if !ok {
+			return nil, vdriapi.ErrNotFound
+		}
+
+		return doc, nil
+	}
+
+	return r
+}
+
+func versionedDoc(didID string, updated time.Time, keyIDs ...string) *did.Doc {
+	doc := &did.Doc{ID: didID, Updated: &updated}
+
+	for _, keyID := range keyIDs {
+		doc.PublicKey = append(doc.PublicKey, did.PublicKey{
+			ID:    didID + keyID,
+			Type:  "Ed25519VerificationKey2018",
+			Value: []byte(keyID),
+		})
+	}
+
+	return doc
+}
+
+func TestCachingDIDKeyResolver(t *testing.T) {
+	const issuer = "did:example:issuer"
+
+	now := time.Now()
+
+	t.Run("keys are cached until the document expires", func(t *testing.T) {
+		registry := newCountingRegistry(versionedDoc(issuer, now, "#key-1"))
+
+		resolver := NewCachingDIDKeyResolver(registry, WithKeyCacheExpiration(time.Minute))
+		resolver.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			key, err := resolver.PublicKeyFetcher()(issuer, "#key-1")
+			require.NoError(t, err)
+			require.Equal(t, []byte("#key-1"), key.Value)
+		}
+
+		require.Equal(t, 1, registry.resolutions)
+
+		resolver.now = func() time.Time { return now.Add(time.Minute) }
+
+		_, err := resolver.PublicKeyFetcher()(issuer, "#key-1")
+		require.NoError(t, err)
+		require.Equal(t, 2, registry.resolutions)
+	})
+
+	t.Run("rotated keys", func(t *testing.T) {
+		registry := newCountingRegistry(versionedDoc(issuer, now, "#key-1"))
+		resolver := NewCachingDIDKeyResolver(registry)
+
+		_, err := resolver.PublicKeyFetcher()(issuer, "#key-1")
+		require.NoError(t, err)
+
+		// the issuer rotates its key
+		registry.docs[issuer] = versionedDoc(issuer, now.Add(time.Second), "#key-2")
+
+		key, err := resolver.PublicKeyFetcher()(issuer, "#key-2")
+		require.NoError(t, err)
+		require.Equal(t, []byte("#key-2"), key.Value)
+		require.Equal(t, 2, registry.resolutions)
+
+		// the cached keys of the previous version are invalidated
+		_, err = resolver.PublicKeyFetcher()(issuer, "#key-1")
+		require.EqualError(t, err, fmt.Sprintf("public key with KID #key-1 is not found for DID %s", issuer))
+		require.Equal(t, 3, registry.resolutions)
+	})
+
+	t.Run("older version is ignored", func(t *testing.T) {
+		registry := newCountingRegistry(versionedDoc(issuer, now, "#key-2"))
+		resolver := NewCachingDIDKeyResolver(registry)
+
+		_, err := resolver.PublicKeyFetcher()(issuer, "#key-2")
+		require.NoError(t, err)
+
+		// a lagging resolver returns the previous version of the document
+		registry.docs[issuer] = versionedDoc(issuer, now.Add(-time.Second), "#key-1")
+
+		_, err = resolver.PublicKeyFetcher()(issuer, "#key-1")
+		require.Error(t, err)
+
+		key, err := resolver.PublicKeyFetcher()(issuer, "#key-2")
+		require.NoError(t, err)
+		require.Equal(t, []byte("#key-2"), key.Value)
+		require.Equal(t, 2, registry.resolutions)
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		registry := newCountingRegistry(versionedDoc(issuer, now, "#key-1"))
+		resolver := NewCachingDIDKeyResolver(registry)
+
+		_, err := resolver.PublicKeyFetcher()(issuer, "#key-1")
+		require.NoError(t, err)
+
+		resolver.Invalidate(issuer)
+
+		_, err = resolver.PublicKeyFetcher()(issuer, "#key-1")
+		require.NoError(t, err)
+		require.Equal(t, 2, registry.resolutions)
+	})
+
+	t.Run("cache size", func(t *testing.T) {
+		registry := newCountingRegistry(
+			&did.Doc{ID: "did:example:1", PublicKey: []did.PublicKey{{ID: "did:example:1#key-1"}}},
+			&did.Doc{ID: "did:example:2", PublicKey: []did.PublicKey{{ID: "did:example:2#key-1"}}},
+		)
+
+		resolver := NewCachingDIDKeyResolver(registry, WithKeyCacheSize(1))
+
+		for _, didID := range []string{"did:example:1", "did:example:2", "did:example:2", "did:example:1"} {
+			_, err := resolver.PublicKeyFetcher()(didID, "#key-1")
+			require.NoError(t, err)
+		}
+
+		require.Len(t, resolver.entries, 1)
+		require.Equal(t, 3, registry.resolutions)
+	})
+
+	t.Run("concurrent resolutions", func(t *testing.T) {
+		const verifications = 5
+
+		var (
+			resolving = make(chan struct{})
+			resolve   = make(chan struct{})
+			results   = make(chan error, verifications)
+		)
+
+		registry := newCountingRegistry(versionedDoc(issuer, now, "#key-1"))
+		resolveDoc := registry.ResolveFunc
+		registry.ResolveFunc = func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+			close(resolving)
+			<-resolve
+
+			return resolveDoc(didID, opts...)
+		}
+
+		resolver := NewCachingDIDKeyResolver(registry)
+
+		go func() {
+			_, err := resolver.PublicKeyFetcher()(issuer, "#key-1")
+			results <- err
+		}()
+
+		<-resolving
+
+		for i := 1; i < verifications; i++ {
+			go func() {
+				_, err := resolver.PublicKeyFetcher()(issuer, "#key-1")
+				results <- err
+			}()
+		}
+
+		// the other verifications wait for the resolution in progress
+		close(resolve)
+
+		for i := 0; i < verifications; i++ {
+			require.NoError(t, <-results)
+		}
+
+		require.Equal(t, 1, registry.resolutions)
+		require.Empty(t, resolver.pending)
+	})
+
+	t.Run("resolve error", func(t *testing.T) {
+		resolver := NewCachingDIDKeyResolver(&mockvdri.MockVDRIRegistry{ResolveErr: errors.New("resolver error")})
+
+		_, err := resolver.PublicKeyFetcher()(issuer, "#key-1")
+		require.EqualError(t, err, fmt.Sprintf("resolve DID %s: resolver error", issuer))
+	})
+}
@@ -0,0 +1,353 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+)
+
+const (
+	redactedValue = "***"
+	// redactedDigestLen is the number of bytes of the digest of a redacted value which are kept
+	redactedDigestLen = 8
+)
+
+// nolint:gochecknoglobals
+var (
+	// redactionKey is the HMAC key of the hashed values, generated once per process: equal values can be
+	// correlated in the logs of the process while the values can't be recovered by hashing candidate values.
+	redactionKey     []byte
+	redactionKeyOnce sync.Once
+)
+
+// redactionOpts holds the options of the redaction of credentials and presentations.
+type redactionOpts struct {
+	hashed bool
+}
+
+// RedactionOpt is the option of the redaction of credentials and presentations.
+type RedactionOpt func(opts *redactionOpts)
+
+// WithHashedValues replaces the redacted values by a keyed digest of them instead of masking them,
+// so equal values can be correlated across the log lines of the process.
+func WithHashedValues() RedactionOpt {
+	return func(opts *redactionOpts) {
+		opts.hashed = true
+	}
+}
+
+// RedactedCredential returns a representation of the credential which is safe for logging: the values of the
+// credential subjects are masked (or hashed, see WithHashedValues) while the other claims are kept.
+// The credential is redacted only when the representation is formatted, e.g if the log level is enabled.
+//  Usage:
+//  logger.Debugf("credential received: %s", verifiable.RedactedCredential(vc))
+func RedactedCredential(vc *Credential, opts ...RedactionOpt) fmt.Stringer {
+	return &redacted{
+		marshal: func() ([]byte, error) {
+			if vc == nil {
+				return []byte("null"), nil
+			}
+
+			return vc.MarshalJSON()
+		},
+		opts: opts,
+	}
+}
+
+// RedactedPresentation returns a representation of the presentation which is safe for logging: the values of the
+// credential subjects of its credentials are masked (or hashed, see WithHashedValues) and its credentials defined
+// as JWT are redacted as a whole.
+func RedactedPresentation(vp *Presentation, opts ...RedactionOpt) fmt.Stringer {
+	return &redacted{
+		marshal: func() ([]byte, error) {
+			if vp == nil {
+				return []byte("null"), nil
+			}
+
+			return vp.MarshalJSON()
+		},
+		opts: opts,
+	}
+}
+
+// RedactedMessage returns a representation of a message embedding credentials or presentations which is safe for
+// logging, e.g a DIDComm message with credential attachments or a notification: the credentials and presentations
+// are redacted wherever they are in the message, including the base64 attachments (see RedactJSON).
+// A json.RawMessage is redacted as is.
+func RedactedMessage(msg interface{}, opts ...RedactionOpt) fmt.Stringer {
+	return &redacted{
+		marshal: func() ([]byte, error) {
+			return json.Marshal(msg)
+		},
+		opts: opts,
+	}
+}
+
+// RedactJSON redacts the credential subjects of a JSON document, wherever they are in the document: the document
+// is a credential, a presentation or any document embedding them (e.g a message). The JSON documents of the base64
+// attachments are redacted too, they are replaced by the redacted JSON documents. The values of the attributes of
+// the credential and presentation previews are redacted as well.
+func RedactJSON(doc []byte, opts ...RedactionOpt) ([]byte, error) {
+	rOpts := newRedactionOpts(opts)
+
+	var raw interface{}
+
+	if err := json.Unmarshal(doc, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshal document: %w", err)
+	}
+
+	redactDocument(raw, rOpts)
+
+	return json.Marshal(raw)
+}
+
+// RedactText redacts the credentials and presentations quoted as JSON in a text, e.g the error given as reason
+// to decline a presentation, the rest of the text is kept.
+func RedactText(text string, opts ...RedactionOpt) string {
+	rOpts := newRedactionOpts(opts)
+
+	var b strings.Builder
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' {
+			b.WriteByte(text[i])
+
+			continue
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(text[i:]))
+
+		var doc interface{}
+
+		if err := decoder.Decode(&doc); err != nil || !redactDocument(doc, rOpts) {
+			b.WriteByte(text[i])
+
+			continue
+		}
+
+		redacted, err := json.Marshal(doc)
+		if err != nil {
+			b.WriteByte(text[i])
+
+			continue
+		}
+
+		b.Write(redacted)
+
+		i += int(decoder.InputOffset()) - 1
+	}
+
+	return b.String()
+}
+
+func newRedactionOpts(opts []RedactionOpt) *redactionOpts {
+	rOpts := &redactionOpts{}
+
+	for _, opt := range opts {
+		opt(rOpts)
+	}
+
+	return rOpts
+}
+
+type redacted struct {
+	marshal func() ([]byte, error)
+	opts    []RedactionOpt
+}
+
+func (r *redacted) String() string {
+	doc, err := r.marshal()
+	if err != nil {
+		return fmt.Sprintf("<redaction failed: %s>", err)
+	}
+
+	doc, err = RedactJSON(doc, r.opts...)
+	if err != nil {
+		return fmt.Sprintf("<redaction failed: %s>", err)
+	}
+
+	return string(doc)
+}
+
+// redactDocument redacts the credential subjects found in the document: those of a credential, of the credentials
+// of a presentation, or of the credentials and presentations embedded in the document. It returns true if the
+// document has redacted values.
+func redactDocument(doc interface{}, opts *redactionOpts) bool {
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		return redactObject(d, opts)
+	case []interface{}:
+		redacted := false
+
+		for i := range d {
+			redacted = redactDocument(d[i], opts) || redacted
+		}
+
+		return redacted
+	default:
+		return false
+	}
+}
+
+func redactObject(doc map[string]interface{}, opts *redactionOpts) bool {
+	redacted := false
+
+	for k, v := range doc {
+		switch k {
+		case "credentialSubject":
+			doc[k] = redactSubject(v, opts)
+			redacted = true
+		case "verifiableCredential":
+			doc[k] = redactCredentials(v, opts)
+			redacted = true
+		case "attributes":
+			redacted = redactPreviewAttributes(v, opts) || redactDocument(v, opts) || redacted
+		case "base64":
+			// the data of an attachment, e.g a presentation
+			if attached, ok := redactAttachment(v, opts); ok {
+				delete(doc, k)
+				doc["json"] = attached
+				redacted = true
+			}
+		default:
+			redacted = redactDocument(v, opts) || redacted
+		}
+	}
+
+	return redacted
+}
+
+// redactPreviewAttributes redacts the values of the attributes of a credential or presentation preview, i.e the
+// objects having a name and a value.
+func redactPreviewAttributes(attributes interface{}, opts *redactionOpts) bool {
+	list, ok := attributes.([]interface{})
+	if !ok {
+		return false
+	}
+
+	redacted := false
+
+	for _, attribute := range list {
+		a, ok := attribute.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		_, hasName := a["name"]
+		if value, hasValue := a["value"]; hasName && hasValue {
+			a["value"] = redactValue(value, opts)
+			redacted = true
+		}
+	}
+
+	return redacted
+}
+
+// redactCredentials redacts the credentials of a presentation, the credentials defined as JWT as a whole.
+func redactCredentials(credentials interface{}, opts *redactionOpts) interface{} {
+	redactCredential := func(credential interface{}) interface{} {
+		if vc, ok := credential.(map[string]interface{}); ok {
+			redactObject(vc, opts)
+
+			return vc
+		}
+
+		return redactValue(credential, opts)
+	}
+
+	if vcs, ok := credentials.([]interface{}); ok {
+		for i := range vcs {
+			vcs[i] = redactCredential(vcs[i])
+		}
+
+		return vcs
+	}
+
+	return redactCredential(credentials)
+}
+
+// redactAttachment returns the redacted JSON document of base64 attachment data, if it is a JSON document
+// having redacted values. The JWTs (e.g a presentation defined as JWT) are redacted as a whole.
+func redactAttachment(data interface{}, opts *redactionOpts) (interface{}, bool) {
+	encoded, ok := data.(string)
+	if !ok {
+		return nil, false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	if jwt.IsJWS(string(decoded)) || jwt.IsJWTUnsecured(string(decoded)) {
+		return redactValue(string(decoded), opts), true
+	}
+
+	var doc interface{}
+
+	if err := json.Unmarshal(decoded, &doc); err != nil || !redactDocument(doc, opts) {
+		return nil, false
+	}
+
+	return doc, true
+}
+
+// redactSubject redacts the values of the subject, its structure and types are kept.
+func redactSubject(subject interface{}, opts *redactionOpts) interface{} {
+	switch s := subject.(type) {
+	case map[string]interface{}:
+		for k, v := range s {
+			if k != "type" {
+				s[k] = redactSubject(v, opts)
+			}
+		}
+
+		return s
+	case []interface{}:
+		for i := range s {
+			s[i] = redactSubject(s[i], opts)
+		}
+
+		return s
+	default:
+		return redactValue(s, opts)
+	}
+}
+
+func redactValue(value interface{}, opts *redactionOpts) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if !opts.hashed {
+		return redactedValue
+	}
+
+	redactionKeyOnce.Do(func() {
+		redactionKey = make([]byte, sha256.Size)
+
+		if _, err := rand.Read(redactionKey); err != nil {
+			panic(err)
+		}
+	})
+
+	mac := hmac.New(sha256.New, redactionKey)
+	// the type is part of the digest, e.g the number 1 and the string "1" are different values
+	_, _ = fmt.Fprintf(mac, "%T:%v", value, value) // nolint:errcheck
+
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:redactedDigestLen])
+}
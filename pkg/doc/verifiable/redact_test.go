@@ -0,0 +1,238 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRedactionTestCredential() *Credential {
+	return &Credential{
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		ID:      "http://example.edu/credentials/1872",
+		Types:   []string{"VerifiableCredential"},
+		Subject: map[string]interface{}{
+			"id":   "did:example:ebfeb1f712ebc6f1c276e12ec21",
+			"name": "Jayden Doe",
+			"degree": map[string]interface{}{
+				"type": "BachelorDegree",
+				"name": "Bachelor of Science and Arts",
+			},
+			"age": 27,
+		},
+		Issuer: Issuer{ID: "did:example:76e12ec712ebc6f1c221ebfeb1f"},
+	}
+}
+
+func TestRedactedCredential(t *testing.T) {
+	vc := newRedactionTestCredential()
+
+	t.Run("masked values", func(t *testing.T) {
+		redacted := RedactedCredential(vc).String()
+
+		require.NotContains(t, redacted, "Jayden Doe")
+		require.NotContains(t, redacted, "Bachelor of Science and Arts")
+		require.NotContains(t, redacted, "did:example:ebfeb1f712ebc6f1c276e12ec21")
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(redacted), &doc))
+
+		// the other claims and the structure of the subject are kept
+		require.Equal(t, vc.ID, doc["id"])
+		require.Equal(t, vc.Issuer.ID, doc["issuer"])
+		require.Equal(t, map[string]interface{}{
+			"id":     redactedValue,
+			"name":   redactedValue,
+			"degree": map[string]interface{}{"type": "BachelorDegree", "name": redactedValue},
+			"age":    redactedValue,
+		}, doc["credentialSubject"])
+
+		// the credential is not modified
+		require.Equal(t, "Jayden Doe", vc.Subject.(map[string]interface{})["name"])
+	})
+
+	t.Run("hashed values", func(t *testing.T) {
+		first := RedactedCredential(vc, WithHashedValues()).String()
+		second := RedactedCredential(vc, WithHashedValues()).String()
+
+		require.NotContains(t, first, "Jayden Doe")
+		require.Contains(t, first, `"name":"hmac:`)
+		// the hashed values can be correlated
+		require.Equal(t, first, second)
+
+		other := newRedactionTestCredential()
+		other.Subject.(map[string]interface{})["name"] = "Alice"
+		require.NotEqual(t, first, RedactedCredential(other, WithHashedValues()).String())
+	})
+
+	t.Run("subject as string", func(t *testing.T) {
+		subjectVC := newRedactionTestCredential()
+		subjectVC.Subject = "did:example:ebfeb1f712ebc6f1c276e12ec21"
+
+		redacted := RedactedCredential(subjectVC).String()
+		require.Contains(t, redacted, `"credentialSubject":"***"`)
+	})
+
+	t.Run("nil credential", func(t *testing.T) {
+		require.Equal(t, "null", RedactedCredential(nil).String())
+	})
+
+	t.Run("marshal error", func(t *testing.T) {
+		invalidVC := newRedactionTestCredential()
+		invalidVC.Subject = make(chan int)
+
+		require.Contains(t, RedactedCredential(invalidVC).String(), "<redaction failed: ")
+	})
+
+	t.Run("formatted lazily", func(t *testing.T) {
+		require.Contains(t, fmt.Sprintf("%s", RedactedCredential(vc)), `"name":"***"`)
+	})
+}
+
+func TestRedactedPresentation(t *testing.T) {
+	vp := &Presentation{
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		Type:    []string{"VerifiablePresentation"},
+		Holder:  "did:example:ebfeb1f712ebc6f1c276e12ec21",
+	}
+	require.NoError(t, vp.SetCredentials(newRedactionTestCredential()))
+
+	redacted := RedactedPresentation(vp).String()
+	require.NotContains(t, redacted, "Jayden Doe")
+	require.Contains(t, redacted, `"holder":"did:example:ebfeb1f712ebc6f1c276e12ec21"`)
+	require.Contains(t, redacted, `"degree":{"name":"***","type":"BachelorDegree"}`)
+
+	require.Equal(t, "null", RedactedPresentation(nil).String())
+}
+
+func TestRedactJSON(t *testing.T) {
+	t.Run("credentials of presentation", func(t *testing.T) {
+		vp := `{
+			"type": "VerifiablePresentation",
+			"verifiableCredential": [
+				{"id": "http://example.edu/credentials/1872", "credentialSubject": [{"name": "Jayden Doe"}]},
+				"eyJhbGciOiJub25lIn0.eyJ2YyI6eyJjcmVkZW50aWFsU3ViamVjdCI6eyJuYW1lIjoiSmF5ZGVuIERvZSJ9fX0."
+			]
+		}`
+
+		redacted, err := RedactJSON([]byte(vp))
+		require.NoError(t, err)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(redacted, &doc))
+		require.Equal(t, []interface{}{
+			map[string]interface{}{
+				"id":                "http://example.edu/credentials/1872",
+				"credentialSubject": []interface{}{map[string]interface{}{"name": redactedValue}},
+			},
+			redactedValue,
+		}, doc["verifiableCredential"])
+	})
+
+	t.Run("single credential of presentation", func(t *testing.T) {
+		redacted, err := RedactJSON([]byte(`{"verifiableCredential": {"credentialSubject": {"name": "Jayden Doe"}}}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"verifiableCredential": {"credentialSubject": {"name": "***"}}}`, string(redacted))
+	})
+
+	t.Run("null values are kept", func(t *testing.T) {
+		redacted, err := RedactJSON([]byte(`{"credentialSubject": {"name": null}}`), WithHashedValues())
+		require.NoError(t, err)
+		require.JSONEq(t, `{"credentialSubject": {"name": null}}`, string(redacted))
+	})
+
+	t.Run("credentials embedded in a document", func(t *testing.T) {
+		redacted, err := RedactJSON([]byte(`{
+			"@type": "https://didcomm.org/issue-credential/2.0/issue-credential",
+			"credentials~attach": [{"data": {"json": {"credentialSubject": {"name": "Jayden Doe"}}}}]
+		}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"@type": "https://didcomm.org/issue-credential/2.0/issue-credential",
+			"credentials~attach": [{"data": {"json": {"credentialSubject": {"name": "***"}}}}]
+		}`, string(redacted))
+	})
+
+	t.Run("base64 attachments", func(t *testing.T) {
+		vp := base64.StdEncoding.EncodeToString([]byte(
+			`{"verifiableCredential": [{"credentialSubject": {"name": "Jayden Doe"}}]}`))
+		jwtVP := base64.StdEncoding.EncodeToString([]byte(
+			"eyJhbGciOiJub25lIn0.eyJ2cCI6eyJ2ZXJpZmlhYmxlQ3JlZGVudGlhbCI6W119fQ."))
+		other := base64.StdEncoding.EncodeToString([]byte(`{"comment": "Jayden Doe"}`))
+
+		redacted, err := RedactJSON([]byte(fmt.Sprintf(`{"presentations~attach": [
+			{"@id": "1", "data": {"base64": %q}},
+			{"@id": "2", "data": {"base64": %q}},
+			{"@id": "3", "data": {"base64": %q}},
+			{"@id": "4", "data": {"base64": "not base64"}}
+		]}`, vp, jwtVP, other)))
+		require.NoError(t, err)
+		require.JSONEq(t, fmt.Sprintf(`{"presentations~attach": [
+			{"@id": "1", "data": {"json": {"verifiableCredential": [{"credentialSubject": {"name": "***"}}]}}},
+			{"@id": "2", "data": {"json": "***"}},
+			{"@id": "3", "data": {"base64": %q}},
+			{"@id": "4", "data": {"base64": "not base64"}}
+		]}`, other), string(redacted))
+	})
+
+	t.Run("preview attributes", func(t *testing.T) {
+		redacted, err := RedactJSON([]byte(`{"credential_preview": {"attributes": [
+			{"name": "name", "mime-type": "text/plain", "value": "Jayden Doe"},
+			{"name": "degree"}
+		]}}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"credential_preview": {"attributes": [
+			{"name": "name", "mime-type": "text/plain", "value": "***"},
+			{"name": "degree"}
+		]}}`, string(redacted))
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := RedactJSON([]byte("not JSON"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal document")
+	})
+}
+
+func TestRedactedMessage(t *testing.T) {
+	msg := map[string]interface{}{
+		"@id":     "message-1",
+		"message": json.RawMessage(`{"credentials~attach": [{"data": {"json": {"credentialSubject": "Jayden Doe"}}}]}`),
+	}
+
+	redacted := RedactedMessage(msg).String()
+	require.NotContains(t, redacted, "Jayden Doe")
+	require.Contains(t, redacted, `"@id":"message-1"`)
+	require.Contains(t, redacted, `"credentialSubject":"***"`)
+
+	redacted = RedactedMessage(json.RawMessage(`{"credentialSubject": {"name": "Jayden Doe"}}`)).String()
+	require.JSONEq(t, `{"credentialSubject": {"name": "***"}}`, redacted)
+
+	require.Contains(t, RedactedMessage(make(chan int)).String(), "<redaction failed: ")
+}
+
+func TestRedactText(t *testing.T) {
+	vc, err := newRedactionTestCredential().MarshalJSON()
+	require.NoError(t, err)
+
+	text := RedactText(fmt.Sprintf("credential %s {not JSON} of {\"id\": \"1\"} is declined", vc))
+	require.NotContains(t, text, "Jayden Doe")
+	require.NotContains(t, text, "Bachelor of Science and Arts")
+	require.Contains(t, text, `"name":"***"`)
+	require.True(t, strings.HasPrefix(text, `credential {"@context":`))
+	// the JSON documents without credentials are kept as is
+	require.True(t, strings.HasSuffix(text, `} {not JSON} of {"id": "1"} is declined`))
+
+	require.Equal(t, "no credential", RedactText("no credential"))
+	require.Equal(t, "unterminated {", RedactText("unterminated {"))
+}
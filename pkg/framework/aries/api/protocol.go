@@ -9,6 +9,9 @@ package api
 import (
 	"errors"
 
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
@@ -41,6 +44,11 @@ type Provider interface {
 	Signer() legacykms.Signer
 	TransientStorageProvider() storage.Provider
 	InboundMessageHandler() didcommtransport.InboundMessageHandler
+	JSONLDDocumentLoader() ld.DocumentLoader
+	EventPayloadVersion() service.EventPayloadVersion
+	Instrumentation() service.Instrumentation
+	ConnectionReuse() bool
+	Scheduler() *scheduler.Scheduler
 }
 
 // ProtocolSvcCreator method to create new protocol service
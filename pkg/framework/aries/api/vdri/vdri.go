@@ -17,6 +17,10 @@ import (
 // ErrNotFound is returned when a DID resolver does not find the DID.
 var ErrNotFound = errors.New("DID not found")
 
+// ErrTransient is returned (wrapped) when a DID resolver fails for a transient reason, e.g the remote resolver
+// is unavailable: the resolution may succeed later.
+var ErrTransient = errors.New("transient DID resolution failure")
+
 // DIDCommServiceType default DID Communication service endpoint type
 const DIDCommServiceType = "did-communication"
 
@@ -25,6 +29,7 @@ type Registry interface {
 	Resolve(did string, opts ...ResolveOpts) (*did.Doc, error)
 	Store(doc *did.Doc) error
 	Create(method string, opts ...DocOpts) (*did.Doc, error)
+	SupportedMethods() []MethodCapabilities
 	Close() error
 }
 
@@ -37,6 +42,21 @@ type VDRI interface {
 	Close() error
 }
 
+// MethodCapabilities describes the operations and key types supported by a VDRI for a DID method
+type MethodCapabilities struct {
+	Method     string   `json:"method"`
+	Create     bool     `json:"create"`
+	Update     bool     `json:"update"`
+	Deactivate bool     `json:"deactivate"`
+	KeyTypes   []string `json:"keyTypes,omitempty"`
+}
+
+// CapabilitiesProvider is implemented by the VDRIs able to describe the DID methods they support.
+// VDRIs not implementing it are not listed by Registry.SupportedMethods().
+type CapabilitiesProvider interface {
+	Capabilities() []MethodCapabilities
+}
+
 // ResultType input option can be used to request a certain type of result.
 type ResultType int
 
@@ -93,6 +113,7 @@ type CreateDIDOpts struct {
 	ServiceEndpoint string
 	RoutingKeys     []string
 	RequestBuilder  func([]byte) (io.Reader, error)
+	NumAlgo         string
 }
 
 // DocOpts is a create DID option
@@ -134,6 +155,14 @@ func WithRequestBuilder(builder func(payload []byte) (io.Reader, error)) DocOpts
 	}
 }
 
+// WithNumAlgo allows for setting the numeric algorithm of the did:peer DIDs ("0", "1" or "2"),
+// the numalgo 0 and 2 DIDs are resolved without storage, e.g for ephemeral DIDs.
+func WithNumAlgo(numAlgo string) DocOpts {
+	return func(opts *CreateDIDOpts) {
+		opts.NumAlgo = numAlgo
+	}
+}
+
 // PubKey contains public key type and value
 type PubKey struct {
 	Value string // base58 encoded
@@ -15,13 +15,9 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packager"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
-	jwe "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/authcrypt"
 	legacy "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/authcrypt"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
-	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/introduce"
-	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofband"
-	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
 	arieshttp "github.com/hyperledger/aries-framework-go/pkg/didcomm/transport/http"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
@@ -29,6 +25,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/encrypted"
 )
 
 // defFrameworkOpts provides default framework options
@@ -52,11 +49,8 @@ func defFrameworkOpts(frameworkOpts *Aries) error {
 		frameworkOpts.storeProvider = storeProv
 	}
 
-	// order is important as DIDExchange service depends on Route service and Introduce depends on DIDExchange
-	frameworkOpts.protocolSvcCreators = append(frameworkOpts.protocolSvcCreators,
-		newRouteSvc(), newExchangeSvc(), newIntroduceSvc(),
-		newIssueCredentialSvc(), newOutOfBandSvc(), newPresentProofSvc(),
-	)
+	// the services of the build profile, then the registered ones
+	frameworkOpts.protocolSvcCreators = append(frameworkOpts.protocolSvcCreators, defaultProtocolSvcCreators()...)
 
 	if frameworkOpts.secretLock == nil && frameworkOpts.kmsCreator == nil {
 		err := createDefSecretLock(frameworkOpts)
@@ -80,24 +74,6 @@ func newExchangeSvc() api.ProtocolSvcCreator {
 	}
 }
 
-func newIntroduceSvc() api.ProtocolSvcCreator {
-	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
-		return introduce.New(prv)
-	}
-}
-
-func newIssueCredentialSvc() api.ProtocolSvcCreator {
-	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
-		return issuecredential.New(prv)
-	}
-}
-
-func newPresentProofSvc() api.ProtocolSvcCreator {
-	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
-		return presentproof.New(prv)
-	}
-}
-
 func newRouteSvc() api.ProtocolSvcCreator {
 	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
 		return route.New(prv)
@@ -132,14 +108,7 @@ func setAdditionalDefaultOpts(frameworkOpts *Aries) error {
 			return legacy.New(provider), nil
 		}
 
-		frameworkOpts.packerCreators = []packer.Creator{
-			func(provider packer.Provider) (packer.Packer, error) {
-				return legacy.New(provider), nil
-			},
-			func(provider packer.Provider) (packer.Packer, error) {
-				return jwe.New(provider, jwe.XC20P)
-			},
-		}
+		frameworkOpts.packerCreators = defaultPackerCreators()
 	}
 
 	if frameworkOpts.packagerCreator == nil {
@@ -161,6 +130,24 @@ func setAdditionalDefaultOpts(frameworkOpts *Aries) error {
 		frameworkOpts.msgSvcProvider = &noOpMessageServiceProvider{}
 	}
 
+	return setEncryptedStorage(frameworkOpts)
+}
+
+func setEncryptedStorage(frameworkOpts *Aries) error {
+	if frameworkOpts.storageKeyURI == "" {
+		return nil
+	}
+
+	// the default noop lock would store the data with an unprotected key
+	if _, ok := frameworkOpts.secretLock.(*noop.NoLock); ok || frameworkOpts.secretLock == nil {
+		return fmt.Errorf("encrypted storage requires a secret lock (see WithSecretLock)")
+	}
+
+	frameworkOpts.storeProvider = encrypted.NewProvider(frameworkOpts.storeProvider,
+		frameworkOpts.secretLock, frameworkOpts.storageKeyURI)
+	frameworkOpts.transientStoreProvider = encrypted.NewProvider(frameworkOpts.transientStoreProvider,
+		frameworkOpts.secretLock, frameworkOpts.storageKeyURI)
+
 	return nil
 }
 
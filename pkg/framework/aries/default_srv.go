@@ -10,7 +10,6 @@ package aries
 
 import (
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
-	"github.com/hyperledger/aries-framework-go/pkg/storage/leveldb"
 	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 )
 
@@ -20,10 +19,6 @@ var (
 	dbPath = "/tmp/peerstore/"
 )
 
-func storeProvider() (storage.Provider, error) {
-	return leveldb.NewProvider(dbPath), nil
-}
-
 func transientStoreProvider() (storage.Provider, error) {
 	return mem.NewProvider(), nil
 }
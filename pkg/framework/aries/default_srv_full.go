@@ -0,0 +1,18 @@
+// +build !js,!wasm,!ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/leveldb"
+)
+
+func storeProvider() (storage.Provider, error) {
+	return leveldb.NewProvider(dbPath), nil
+}
@@ -0,0 +1,21 @@
+// +build !js,!wasm,ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"errors"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+// storeProvider fails as the minimal profile does not link the LevelDB store, the application
+// passes its store provider (see WithStoreProvider).
+func storeProvider() (storage.Provider, error) {
+	return nil, errors.New("no default store provider in the minimal profile (see WithStoreProvider)")
+}
@@ -15,9 +15,10 @@ import (
 )
 
 // WithInboundHTTPAddr return new default http inbound transport.
-func WithInboundHTTPAddr(internalAddr, externalAddr string) aries.Option {
+// Options such as http.WithInboundPaths can be used to serve several paths with distinct media types.
+func WithInboundHTTPAddr(internalAddr, externalAddr string, httpOpts ...http.InboundOpt) aries.Option {
 	return func(opts *aries.Aries) error {
-		inbound, err := http.NewInbound(internalAddr, externalAddr)
+		inbound, err := http.NewInbound(internalAddr, externalAddr, httpOpts...)
 		if err != nil {
 			return fmt.Errorf("http inbound transport initialization failed : %w", err)
 		}
@@ -18,5 +18,22 @@ Usage:
 
 	// initialize the aries clients
 	didexchangeClient, err := didexchange.New(ctx)
+
+Build profiles:
+
+By default the framework creates all its protocol services and packers. The ariesminimal build tag selects the
+minimal profile for the applications bringing their own store (e.g the gomobile ones): the framework only creates
+the route, DID exchange and out-of-band services and the legacy packer, the JSON-LD contexts are not embedded, and
+the LevelDB store and the remote KMS (WithWebKMS) are not linked, the store provider is then mandatory (see
+WithStoreProvider). The default KMS and crypto are kept, the Tink library remains linked.
+The excluded pieces are plugged at runtime, either registered for all the frameworks or with the framework options:
+	// typically in the init function of the application
+	aries.RegisterProtocol(func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		return presentproof.New(prv)
+	})
+	aries.RegisterPacker(func(prv packer.Provider) (packer.Packer, error) {
+		return authcrypt.New(prv, authcrypt.XC20P)
+	})
+	jsonld.RegisterStandardContext(jsonld.CredentialsContextURL, credentialsContext)
 */
 package aries
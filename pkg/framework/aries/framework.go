@@ -8,12 +8,17 @@ package aries
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/messenger"
 
-	"github.com/google/uuid"
+	"github.com/piprate/json-gold/ld"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
@@ -21,13 +26,18 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packager"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/quarantine"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/context"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/keyid"
+	storeverifiable "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/vdri/peer"
 )
@@ -37,6 +47,15 @@ const (
 	//  should be of routing agent
 	defaultEndpoint     = "routing:endpoint"
 	defaultMasterKeyURI = "local-lock://default/master/key/"
+
+	// the names of the maintenance tasks of the framework (see Aries.Scheduler)
+	storeCompactionTask          = "store-compaction"
+	transientStoreCompactionTask = "transient-store-compaction"
+	statusListPollingTask        = "status-list-polling"
+
+	storeCompactionInterval   = 24 * time.Hour
+	statusListPollingInterval = time.Hour
+	statusListTimeout         = 30 * time.Second
 )
 
 // Aries provides access to the context being managed by the framework. The context can be used to create aries clients.
@@ -56,6 +75,7 @@ type Aries struct {
 	kms                    kms.KeyManager
 	kmsCreator             kms.Creator
 	secretLock             secretlock.Service
+	storageKeyURI          string
 	crypto                 crypto.Crypto
 	packagerCreator        packager.Creator
 	packager               commontransport.Packager
@@ -65,13 +85,32 @@ type Aries struct {
 	packers                []packer.Packer
 	vdriRegistry           vdriapi.Registry
 	vdri                   []vdriapi.VDRI
+	documentLoader         ld.DocumentLoader
+	eventPayloadVersion    service.EventPayloadVersion
+	instrumentation        service.Instrumentation
+	connectionReuse        bool
+	inboundQuarantine      bool
+	quarantineOpts         []quarantine.Opt
+	quarantine             *quarantine.Quarantine
+	strictMode             *service.StrictMode
+	packDebug              bool
+	scheduler              *scheduler.Scheduler
+	scheduledTasks         []scheduledTask
 	transportReturnRoute   string
 	id                     string
+	idGenerator            idgen.Generator
 }
 
 // Option configures the framework.
 type Option func(opts *Aries) error
 
+// scheduledTask is a periodic task of the application registered once the framework is initialized.
+type scheduledTask struct {
+	name     string
+	interval time.Duration
+	task     scheduler.Task
+}
+
 // New initializes the Aries framework based on the set of options provided. This function returns a framework
 // which can be used to manage Aries clients by getting the framework context.
 func New(opts ...Option) (*Aries, error) {
@@ -86,8 +125,15 @@ func New(opts ...Option) (*Aries, error) {
 		}
 	}
 
-	// generate a random framework ID
-	frameworkOpts.id = uuid.New().String()
+	if frameworkOpts.idGenerator == nil {
+		frameworkOpts.idGenerator = idgen.UUIDv4()
+	}
+
+	// generate the framework ID with the configured ID generator
+	frameworkOpts.id = frameworkOpts.idGenerator.NewID()
+
+	// the periodic maintenance tasks of the framework and of the application
+	frameworkOpts.scheduler = scheduler.New()
 
 	// get the default framework options
 	err := defFrameworkOpts(frameworkOpts)
@@ -135,6 +181,11 @@ func initializeServices(frameworkOpts *Aries) (*Aries, error) {
 		return nil, err
 	}
 
+	// Create JSON-LD document loader
+	if err := createJSONLDDocumentLoader(frameworkOpts); err != nil {
+		return nil, err
+	}
+
 	// Load services
 	if err := loadServices(frameworkOpts); err != nil {
 		return nil, err
@@ -145,9 +196,65 @@ func initializeServices(frameworkOpts *Aries) (*Aries, error) {
 		return nil, err
 	}
 
+	// Schedule the maintenance tasks of the framework
+	if err := scheduleMaintenanceTasks(frameworkOpts); err != nil {
+		return nil, err
+	}
+
+	// Schedule the periodic tasks of the application
+	for _, t := range frameworkOpts.scheduledTasks {
+		if err := frameworkOpts.scheduler.Register(t.name, t.interval, t.task); err != nil {
+			return nil, fmt.Errorf("schedule task failed: %w", err)
+		}
+	}
+
 	return frameworkOpts, nil
 }
 
+// scheduleMaintenanceTasks schedules the compaction of the stores of the storage providers reclaiming
+// the space of the deleted records (see storage.Compactor) and the polling of the revocation lists of the
+// stored credentials (see storeverifiable.StatusPoller).
+func scheduleMaintenanceTasks(frameworkOpts *Aries) error {
+	providers := map[string]storage.Provider{
+		storeCompactionTask:          frameworkOpts.storeProvider,
+		transientStoreCompactionTask: frameworkOpts.transientStoreProvider,
+	}
+
+	for name, provider := range providers {
+		compactor, ok := provider.(storage.Compactor)
+		if !ok {
+			continue
+		}
+
+		err := frameworkOpts.scheduler.Register(name, storeCompactionInterval, func(time.Time) error {
+			return compactor.Compact()
+		})
+		if err != nil {
+			return fmt.Errorf("schedule store compaction failed: %w", err)
+		}
+	}
+
+	ctx, err := frameworkOpts.Context()
+	if err != nil {
+		return fmt.Errorf("schedule status list polling failed: %w", err)
+	}
+
+	vcStore, err := storeverifiable.New(ctx)
+	if err != nil {
+		return fmt.Errorf("schedule status list polling failed: %w", err)
+	}
+
+	poller := storeverifiable.NewStatusPoller(vcStore, &http.Client{Timeout: statusListTimeout},
+		verifiable.WithPublicKeyFetcher(verifiable.NewDIDKeyResolver(ctx.VDRIRegistry()).PublicKeyFetcher()),
+		verifiable.WithJSONLDDocumentLoader(ctx.JSONLDDocumentLoader()))
+
+	if err := frameworkOpts.scheduler.Register(statusListPollingTask, statusListPollingInterval, poller.Poll); err != nil {
+		return fmt.Errorf("schedule status list polling failed: %w", err)
+	}
+
+	return nil
+}
+
 // WithMessengerHandler injects a messenger handler to the Aries framework
 func WithMessengerHandler(mh service.MessengerHandler) Option {
 	return func(opts *Aries) error {
@@ -205,6 +312,95 @@ func WithTransientStoreProvider(prov storage.Provider) Option {
 	}
 }
 
+// WithJSONLDDocumentLoader injects the loader of the JSON-LD contexts to the Aries framework. By default the
+// contexts are loaded by a jsonld.DocumentLoader caching them in the store provider.
+func WithJSONLDDocumentLoader(loader ld.DocumentLoader) Option {
+	return func(opts *Aries) error {
+		opts.documentLoader = loader
+		return nil
+	}
+}
+
+// WithEventPayloadVersion sets the version of the properties of the events of the DID exchange, present proof and
+// issue credential services. The default version (service.EventPayloadV1) is kept for the existing consumers,
+// service.EventPayloadV2 adds the resolved DID documents and the parsed credentials and presentations.
+func WithEventPayloadVersion(version service.EventPayloadVersion) Option {
+	return func(opts *Aries) error {
+		if version < service.EventPayloadV1 || version > service.EventPayloadV2 {
+			return fmt.Errorf("unsupported event payload version %d", version)
+		}
+
+		opts.eventPayloadVersion = version
+
+		return nil
+	}
+}
+
+// WithInstrumentation sets the instrumentation hook of the DIDComm messages and of the protocol services. It is
+// called with the dispatched inbound messages, the state transitions of the DID exchange, present proof and issue
+// credential services, the messages sent by the present proof and issue credential services and the verification
+// of the presentations.
+func WithInstrumentation(instrumentation service.Instrumentation) Option {
+	return func(opts *Aries) error {
+		opts.instrumentation = instrumentation
+		return nil
+	}
+}
+
+// WithConnectionReuse reuses the completed DID exchange connection with an inviter when one of its invitations
+// (identified by its public DID) is accepted, instead of creating another connection with the same agent.
+// The reuse-available event of the DID exchange service is triggered in both cases.
+func WithConnectionReuse() Option {
+	return func(opts *Aries) error {
+		opts.connectionReuse = true
+		return nil
+	}
+}
+
+// WithInboundQuarantine keeps the inbound envelopes the transports fail to unpack (e.g unknown recipient key,
+// corrupt JWE) in a quarantine store rather than dropping them, see quarantine.Quarantine: a quarantined envelope
+// can be unpacked again after the recovery of the keys, or exported for an offline analysis.
+// The quarantine is bounded, see the options of the quarantine (capacity, TTL and size of the envelopes), it is
+// returned by the Quarantine() of the framework context.
+func WithInboundQuarantine(quarantineOpts ...quarantine.Opt) Option {
+	return func(opts *Aries) error {
+		opts.inboundQuarantine = true
+		opts.quarantineOpts = quarantineOpts
+
+		return nil
+	}
+}
+
+// WithStrictMode rejects the inbound messages carrying unknown decorators or malformed threading before they
+// reach the protocol services, see service.StrictMode: the strict mode is enabled for all the protocols or
+// for some of them, and the decorators specific to an ecosystem can be accepted.
+func WithStrictMode(mode service.StrictMode) Option {
+	return func(opts *Aries) error {
+		opts.strictMode = &mode
+		return nil
+	}
+}
+
+// WithPackDebug records the structure of the envelope (recipient key IDs, algorithms, size) of each outbound
+// message by message ID, to troubleshoot the messages a peer fails to decrypt, see packdebug.Recorder.
+// Neither the keys nor the plaintext are recorded.
+func WithPackDebug() Option {
+	return func(opts *Aries) error {
+		opts.packDebug = true
+		return nil
+	}
+}
+
+// WithScheduledTask registers a periodic task of the application (e.g a cleanup of its records) with the scheduler
+// of the framework: the task runs at every interval until the framework is closed. Tasks can also be registered
+// once the framework is initialized, see Aries.Scheduler().
+func WithScheduledTask(name string, interval time.Duration, task scheduler.Task) Option {
+	return func(opts *Aries) error {
+		opts.scheduledTasks = append(opts.scheduledTasks, scheduledTask{name: name, interval: interval, task: task})
+		return nil
+	}
+}
+
 // WithProtocols injects a protocol service to the Aries framework.
 func WithProtocols(protocolSvcCreator ...api.ProtocolSvcCreator) Option {
 	return func(opts *Aries) error {
@@ -229,6 +425,28 @@ func WithSecretLock(s secretlock.Service) Option {
 	}
 }
 
+// WithEncryptedStorage encrypts all data stored by the Aries framework at rest (keys, connection records,
+// protocol states...) using the framework's secret lock and its master key found at keyURI.
+// The default secret lock doesn't protect the data, a secure lock must be provided with WithSecretLock (eg a local
+// lock protected by a passphrase or an AWS KMS lock): the framework fails to start with the noop lock.
+func WithEncryptedStorage(keyURI string) Option {
+	return func(opts *Aries) error {
+		opts.storageKeyURI = keyURI
+		return nil
+	}
+}
+
+// WithIDGenerator sets the generator of the identifiers created by the Aries framework (message IDs, connection IDs,
+// protocol instance IDs...), e.g idgen.UUIDv7() for time-sortable IDs or idgen.WithPrefix() for tenant-prefixed IDs.
+// The generator is provided to the services and clients by the context (see context.Provider IDGenerator),
+// the framework instances of a process can use different generators.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(opts *Aries) error {
+		opts.idGenerator = g
+		return nil
+	}
+}
+
 // WithKMS injects a KMS service to the Aries framework.
 func WithKMS(k kms.Creator) Option {
 	return func(opts *Aries) error {
@@ -292,8 +510,17 @@ func (a *Aries) Context() (*context.Provider, error) {
 		context.WithPacker(a.primaryPacker, a.packers...),
 		context.WithPackager(a.packager),
 		context.WithVDRIRegistry(a.vdriRegistry),
+		context.WithJSONLDDocumentLoader(a.documentLoader),
+		context.WithEventPayloadVersion(a.eventPayloadVersion),
+		context.WithInstrumentation(a.instrumentation),
+		context.WithConnectionReuse(a.connectionReuse),
+		context.WithQuarantine(a.quarantine),
+		context.WithStrictMode(a.strictMode),
+		context.WithPackDebug(a.packDebug),
+		context.WithScheduler(a.scheduler),
 		context.WithTransportReturnRoute(a.transportReturnRoute),
 		context.WithAriesFrameworkID(a.id),
+		context.WithIDGenerator(a.idGenerator),
 		context.WithMessageServiceProvider(a.msgSvcProvider),
 	)
 }
@@ -304,8 +531,28 @@ func (a *Aries) Messenger() service.Messenger {
 	return a.messenger
 }
 
+// Scheduler returns the scheduler of the periodic maintenance tasks of the framework, the application can
+// register its own tasks.
+func (a *Aries) Scheduler() *scheduler.Scheduler {
+	return a.scheduler
+}
+
 // Close frees resources being maintained by the framework.
 func (a *Aries) Close() error {
+	// stop the background work of the protocol services (e.g their janitors) before closing the stores they use
+	for _, svc := range a.services {
+		if closer, ok := svc.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				return fmt.Errorf("failed to close the %s service: %w", svc.Name(), err)
+			}
+		}
+	}
+
+	// stop the periodic tasks before closing the stores they use
+	if a.scheduler != nil {
+		a.scheduler.Stop()
+	}
+
 	if a.legacyKMS != nil {
 		err := a.legacyKMS.Close()
 		if err != nil {
@@ -400,8 +647,14 @@ func createVDRI(frameworkOpts *Aries) error {
 		return fmt.Errorf("create new vdri peer failed: %w", err)
 	}
 
+	keys, err := keyid.New(ctx)
+	if err != nil {
+		return fmt.Errorf("create key ID store failed: %w", err)
+	}
+
 	opts = append(opts,
 		vdri.WithVDRI(p),
+		vdri.WithKeyIDStore(keys),
 		vdri.WithDefaultServiceType(vdriapi.DIDCommServiceType),
 		vdri.WithDefaultServiceEndpoint(ctx.ServiceEndpoint()),
 	)
@@ -417,6 +670,7 @@ func createMessengerHandler(frameworkOpts *Aries) error {
 	}
 
 	ctx, err := context.New(
+		context.WithIDGenerator(frameworkOpts.idGenerator),
 		context.WithOutboundDispatcher(frameworkOpts.outboundDispatcher),
 		context.WithStorageProvider(frameworkOpts.storeProvider),
 	)
@@ -431,12 +685,15 @@ func createMessengerHandler(frameworkOpts *Aries) error {
 
 func createOutboundDispatcher(frameworkOpts *Aries) error {
 	ctx, err := context.New(
+		context.WithIDGenerator(frameworkOpts.idGenerator),
 		context.WithLegacyKMS(frameworkOpts.legacyKMS),
 		context.WithCrypto(frameworkOpts.crypto),
 		context.WithOutboundTransports(frameworkOpts.outboundTransports...),
 		context.WithPackager(frameworkOpts.packager),
 		context.WithTransportReturnRoute(frameworkOpts.transportReturnRoute),
 		context.WithVDRIRegistry(frameworkOpts.vdriRegistry),
+		context.WithStorageProvider(frameworkOpts.storeProvider),
+		context.WithPackDebug(frameworkOpts.packDebug),
 	)
 	if err != nil {
 		return fmt.Errorf("context creation failed: %w", err)
@@ -448,19 +705,37 @@ func createOutboundDispatcher(frameworkOpts *Aries) error {
 }
 
 func startTransports(frameworkOpts *Aries) error {
-	ctx, err := context.New(
+	ctxOpts := []context.ProviderOption{
 		context.WithLegacyKMS(frameworkOpts.legacyKMS),
 		context.WithCrypto(frameworkOpts.crypto),
 		context.WithPackager(frameworkOpts.packager),
 		context.WithProtocolServices(frameworkOpts.services...),
 		context.WithAriesFrameworkID(frameworkOpts.id),
+		context.WithIDGenerator(frameworkOpts.idGenerator),
 		context.WithMessageServiceProvider(frameworkOpts.msgSvcProvider),
 		context.WithMessengerHandler(frameworkOpts.messenger),
-	)
+		context.WithStorageProvider(frameworkOpts.storeProvider),
+		context.WithStrictMode(frameworkOpts.strictMode),
+	}
+
+	ctx, err := context.New(ctxOpts...)
 	if err != nil {
 		return fmt.Errorf("context creation failed: %w", err)
 	}
 
+	// the quarantine is shared by the transports and the framework context
+	if frameworkOpts.inboundQuarantine {
+		frameworkOpts.quarantine, err = quarantine.New(ctx, frameworkOpts.quarantineOpts...)
+		if err != nil {
+			return fmt.Errorf("create quarantine: %w", err)
+		}
+
+		ctx, err = context.New(append(ctxOpts, context.WithQuarantine(frameworkOpts.quarantine))...)
+		if err != nil {
+			return fmt.Errorf("context creation failed: %w", err)
+		}
+	}
+
 	for _, inbound := range frameworkOpts.inboundTransports {
 		// Start the inbound transport
 		if err = inbound.Start(ctx); err != nil {
@@ -478,6 +753,21 @@ func startTransports(frameworkOpts *Aries) error {
 	return nil
 }
 
+func createJSONLDDocumentLoader(frameworkOpts *Aries) error {
+	if frameworkOpts.documentLoader != nil {
+		return nil
+	}
+
+	loader, err := jsonld.NewDocumentLoader(frameworkOpts.storeProvider)
+	if err != nil {
+		return fmt.Errorf("create JSON-LD document loader failed: %w", err)
+	}
+
+	frameworkOpts.documentLoader = loader
+
+	return nil
+}
+
 func loadServices(frameworkOpts *Aries) error {
 	ctx, err := context.New(
 		context.WithOutboundDispatcher(frameworkOpts.outboundDispatcher),
@@ -490,6 +780,12 @@ func loadServices(frameworkOpts *Aries) error {
 		context.WithServiceEndpoint(serviceEndpoint(frameworkOpts)),
 		context.WithRouterEndpoint(routingEndpoint(frameworkOpts)),
 		context.WithVDRIRegistry(frameworkOpts.vdriRegistry),
+		context.WithJSONLDDocumentLoader(frameworkOpts.documentLoader),
+		context.WithEventPayloadVersion(frameworkOpts.eventPayloadVersion),
+		context.WithInstrumentation(frameworkOpts.instrumentation),
+		context.WithConnectionReuse(frameworkOpts.connectionReuse),
+		context.WithScheduler(frameworkOpts.scheduler),
+		context.WithIDGenerator(frameworkOpts.idGenerator),
 	)
 
 	if err != nil {
@@ -9,6 +9,7 @@ SPDX-License-Identifier: Apache-2.0
 package aries
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -20,15 +21,19 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/tink/go/subtle/random"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/quarantine"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
@@ -41,6 +46,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
 	mockcrypto "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	mockkeymanager "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
 	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/legacykms"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
@@ -48,7 +54,9 @@ import (
 	locallock "github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local/masterlock/hkdf"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/encrypted"
 	"github.com/hyperledger/aries-framework-go/pkg/storage/leveldb"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 	"github.com/hyperledger/aries-framework-go/pkg/vdri/peer"
 )
 
@@ -235,6 +243,26 @@ func TestFramework(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("test protocol svc - close error", func(t *testing.T) {
+		svc := &closingSvc{
+			MockDIDExchangeSvc: &mockdidexchange.MockDIDExchangeSvc{ProtocolName: "mockProtocolSvc"},
+			err:                errors.New("close error"),
+		}
+
+		newMockSvc := func(prv api.Provider) (dispatcher.ProtocolService, error) {
+			return svc, nil
+		}
+
+		aries, err := New(WithProtocols(newMockSvc), WithInboundTransport(&mockInboundTransport{}))
+		require.NoError(t, err)
+
+		err = aries.Close()
+		require.EqualError(t, err, "failed to close the mockProtocolSvc service: close error")
+
+		svc.err = nil
+		require.NoError(t, aries.Close())
+	})
+
 	t.Run("test new with protocol service", func(t *testing.T) {
 		mockSvcCreator := func(prv api.Provider) (dispatcher.ProtocolService, error) {
 			return &mockdidexchange.MockDIDExchangeSvc{
@@ -543,6 +571,76 @@ func TestFramework(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("test new with encrypted storage", func(t *testing.T) {
+		masterKey := base64.URLEncoding.EncodeToString(random.GetRandomBytes(32))
+
+		s, err := locallock.NewService(bytes.NewReader([]byte(masterKey)), nil)
+		require.NoError(t, err)
+
+		store := storage.NewMockStoreProvider()
+
+		a, err := New(WithInboundTransport(&mockInboundTransport{}), WithStoreProvider(store),
+			WithSecretLock(s), WithEncryptedStorage("local-lock://storage/master/key/"))
+		require.NoError(t, err)
+		require.IsType(t, &encrypted.Provider{}, a.storeProvider)
+		require.IsType(t, &encrypted.Provider{}, a.transientStoreProvider)
+
+		ctx, err := a.Context()
+		require.NoError(t, err)
+
+		_, err = ctx.StorageProvider().OpenStore("test")
+		require.NoError(t, err)
+
+		require.NoError(t, a.Close())
+	})
+
+	t.Run("test new with encrypted storage - missing secret lock", func(t *testing.T) {
+		_, err := New(WithInboundTransport(&mockInboundTransport{}), WithStoreProvider(storage.NewMockStoreProvider()),
+			WithKMS(func(ctx kms.Provider) (kms.KeyManager, error) {
+				return &mockkeymanager.KeyManager{}, nil
+			}),
+			WithEncryptedStorage("local-lock://storage/master/key/"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "encrypted storage requires a secret lock")
+
+		_, err = New(WithInboundTransport(&mockInboundTransport{}), WithStoreProvider(storage.NewMockStoreProvider()),
+			WithEncryptedStorage("local-lock://storage/master/key/"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "encrypted storage requires a secret lock")
+
+		_, err = New(WithInboundTransport(&mockInboundTransport{}), WithStoreProvider(storage.NewMockStoreProvider()),
+			WithSecretLock(&noop.NoLock{}), WithEncryptedStorage("local-lock://storage/master/key/"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "encrypted storage requires a secret lock")
+	})
+
+	t.Run("test new with id generator", func(t *testing.T) {
+		path, cleanup := generateTempDir(t)
+		defer cleanup()
+		dbPath = path
+
+		a, err := New(WithInboundTransport(&mockInboundTransport{}),
+			WithIDGenerator(idgen.WithPrefix("tenant-a:", idgen.UUIDv7())))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(a.id, "tenant-a:"))
+
+		b, err := New(WithInboundTransport(&mockInboundTransport{}), WithStoreProvider(storage.NewMockStoreProvider()),
+			WithTransientStoreProvider(storage.NewMockStoreProvider()))
+		require.NoError(t, err)
+
+		// the generator is not shared by the framework instances
+		ctx, err := a.Context()
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(ctx.IDGenerator().NewID(), "tenant-a:"))
+
+		ctx, err = b.Context()
+		require.NoError(t, err)
+		require.False(t, strings.HasPrefix(ctx.IDGenerator().NewID(), "tenant-a:"))
+
+		require.NoError(t, a.Close())
+		require.NoError(t, b.Close())
+	})
+
 	t.Run("test transient store - with user provided transient store", func(t *testing.T) {
 		path, cleanup := generateTempDir(t)
 		defer cleanup()
@@ -555,6 +653,155 @@ func TestFramework(t *testing.T) {
 		require.Equal(t, s, aries.transientStoreProvider)
 	})
 
+	t.Run("test event payload version", func(t *testing.T) {
+		path, cleanup := generateTempDir(t)
+		defer cleanup()
+		dbPath = path
+
+		aries, err := New(WithInboundTransport(&mockInboundTransport{}),
+			WithEventPayloadVersion(service.EventPayloadV2))
+		require.NoError(t, err)
+
+		ctx, err := aries.Context()
+		require.NoError(t, err)
+		require.Equal(t, service.EventPayloadV2, ctx.EventPayloadVersion())
+		require.NoError(t, aries.Close())
+
+		_, err = New(WithEventPayloadVersion(service.EventPayloadVersion(3)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported event payload version 3")
+	})
+
+	t.Run("test instrumentation", func(t *testing.T) {
+		path, cleanup := generateTempDir(t)
+		defer cleanup()
+		dbPath = path
+
+		instrumentation := &struct{ service.NoopInstrumentation }{}
+
+		aries, err := New(WithInboundTransport(&mockInboundTransport{}), WithInstrumentation(instrumentation))
+		require.NoError(t, err)
+
+		ctx, err := aries.Context()
+		require.NoError(t, err)
+		require.Equal(t, instrumentation, ctx.Instrumentation())
+		require.NoError(t, aries.Close())
+	})
+
+	t.Run("test connection reuse", func(t *testing.T) {
+		path, cleanup := generateTempDir(t)
+		defer cleanup()
+		dbPath = path
+
+		aries, err := New(WithInboundTransport(&mockInboundTransport{}), WithConnectionReuse())
+		require.NoError(t, err)
+
+		ctx, err := aries.Context()
+		require.NoError(t, err)
+		require.True(t, ctx.ConnectionReuse())
+		require.NoError(t, aries.Close())
+	})
+
+	t.Run("test new with inbound quarantine", func(t *testing.T) {
+		path, cleanup := generateTempDir(t)
+		defer cleanup()
+		dbPath = path
+
+		aries, err := New(WithInboundTransport(&mockInboundTransport{}))
+		require.NoError(t, err)
+
+		ctx, err := aries.Context()
+		require.NoError(t, err)
+		require.Nil(t, ctx.Quarantine())
+		require.NoError(t, aries.Close())
+
+		aries, err = New(WithInboundTransport(&mockInboundTransport{}),
+			WithInboundQuarantine(quarantine.WithCapacity(1)))
+		require.NoError(t, err)
+
+		ctx, err = aries.Context()
+		require.NoError(t, err)
+		require.NotNil(t, ctx.Quarantine())
+		require.NotNil(t, ctx.UnpackFailureHandler())
+
+		ctx.UnpackFailureHandler()([]byte("jwe-1"), errors.New("no key found"))
+		ctx.UnpackFailureHandler()([]byte("jwe-2"), errors.New("no key found"))
+
+		envelopes, err := ctx.Quarantine().Envelopes()
+		require.NoError(t, err)
+		require.Len(t, envelopes, 1)
+		require.Equal(t, []byte("jwe-2"), envelopes[0].Raw)
+		require.NoError(t, aries.Close())
+	})
+
+	t.Run("test new with strict mode", func(t *testing.T) {
+		path, cleanup := generateTempDir(t)
+		defer cleanup()
+		dbPath = path
+
+		mode := service.StrictMode{Enabled: true, Decorators: []string{"~payment_receipt"}}
+
+		aries, err := New(WithInboundTransport(&mockInboundTransport{}), WithStrictMode(mode))
+		require.NoError(t, err)
+
+		ctx, err := aries.Context()
+		require.NoError(t, err)
+		require.Equal(t, &mode, ctx.StrictMode())
+		require.NoError(t, aries.Close())
+	})
+
+	t.Run("test new with scheduled task", func(t *testing.T) {
+		path, cleanup := generateTempDir(t)
+		defer cleanup()
+		dbPath = path
+
+		runs := make(chan time.Time, 10)
+
+		aries, err := New(WithInboundTransport(&mockInboundTransport{}),
+			WithScheduledTask("cleanup", 10*time.Millisecond, func(now time.Time) error {
+				runs <- now
+				return nil
+			}))
+		require.NoError(t, err)
+
+		require.Contains(t, aries.Scheduler().Tasks(), "cleanup")
+		// the default leveldb store is compacted
+		require.Contains(t, aries.Scheduler().Tasks(), storeCompactionTask)
+		require.NoError(t, aries.Scheduler().Run(storeCompactionTask))
+
+		ctx, err := aries.Context()
+		require.NoError(t, err)
+		require.Equal(t, aries.Scheduler(), ctx.Scheduler())
+
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("task not run")
+		}
+
+		require.NoError(t, aries.Close())
+		require.Empty(t, aries.Scheduler().Tasks())
+
+		_, err = New(WithInboundTransport(&mockInboundTransport{}),
+			WithScheduledTask("cleanup", 0, func(time.Time) error { return nil }))
+		require.Contains(t, err.Error(), "schedule task failed")
+
+		// the mem stores are not compacted
+		aries, err = New(WithInboundTransport(&mockInboundTransport{}), WithStoreProvider(mem.NewProvider()))
+		require.NoError(t, err)
+		require.NotContains(t, aries.Scheduler().Tasks(), storeCompactionTask)
+		require.NotContains(t, aries.Scheduler().Tasks(), transientStoreCompactionTask)
+		// the revocation lists of the stored credentials are polled, there is none
+		require.Contains(t, aries.Scheduler().Tasks(), statusListPollingTask)
+		require.NoError(t, aries.Scheduler().Run(statusListPollingTask))
+		require.NoError(t, aries.Close())
+
+		_, err = New(WithInboundTransport(&mockInboundTransport{}),
+			WithStoreProvider(leveldb.NewProvider(path+"/compaction")),
+			WithScheduledTask(storeCompactionTask, time.Hour, func(time.Time) error { return nil }))
+		require.Contains(t, err.Error(), "schedule task failed")
+	})
+
 	t.Run("test new with outbound transport service", func(t *testing.T) {
 		path, cleanup := generateTempDir(t)
 		defer cleanup()
@@ -744,3 +991,13 @@ func (m *mockInboundTransport) Stop() error {
 func (m *mockInboundTransport) Endpoint() string {
 	return ""
 }
+
+// closingSvc is a protocol service with background work stopped by Close
+type closingSvc struct {
+	*mockdidexchange.MockDIDExchangeSvc
+	err error
+}
+
+func (s *closingSvc) Close() error {
+	return s.err
+}
@@ -0,0 +1,56 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
+)
+
+//nolint:gochecknoglobals
+var (
+	registryMutex       sync.RWMutex
+	registeredProtocols []api.ProtocolSvcCreator
+	registeredPackers   []packer.Creator
+)
+
+// RegisterProtocol registers protocol services created by default by the frameworks, after the services of
+// the build profile (e.g the services excluded from the minimal profile). It is typically called by the
+// init function of the application, before the frameworks are created.
+func RegisterProtocol(protocolSvcCreator ...api.ProtocolSvcCreator) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registeredProtocols = append(registeredProtocols, protocolSvcCreator...)
+}
+
+// RegisterPacker registers additional packers created by default by the frameworks, after the packers of
+// the build profile. They are ignored if the packers are set with the WithPacker option.
+func RegisterPacker(packerCreator ...packer.Creator) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registeredPackers = append(registeredPackers, packerCreator...)
+}
+
+// defaultProtocolSvcCreators returns the protocol services of the build profile and the registered ones.
+func defaultProtocolSvcCreators() []api.ProtocolSvcCreator {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	return append(profileProtocolSvcCreators(), registeredProtocols...)
+}
+
+// defaultPackerCreators returns the packers of the build profile and the registered ones.
+func defaultPackerCreators() []packer.Creator {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	return append(profilePackerCreators(), registeredPackers...)
+}
@@ -0,0 +1,143 @@
+// +build !ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	jwe "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/authcrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/ecdh1pu"
+	legacy "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/authcrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/connectionupgrade"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didupdate"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/discoverfeatures"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/filetransfer"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/introduce"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
+)
+
+// profileProtocolSvcCreators returns all the protocol services of the framework.
+func profileProtocolSvcCreators() []api.ProtocolSvcCreator {
+	// order is important as DIDExchange service depends on Route service, Introduce depends on DIDExchange
+	// and ConnectionUpgrade depends on DIDUpdate and DiscoverFeatures
+	return []api.ProtocolSvcCreator{
+		newRouteSvc(), newExchangeSvc(), newIntroduceSvc(),
+		newIssueCredentialSvc(), newOutOfBandSvc(), newPresentProofSvc(), newFileTransferSvc(),
+		newDIDUpdateSvc(), newDiscoverFeaturesSvc(), newConnectionUpgradeSvc(),
+	}
+}
+
+// profilePackerCreators returns all the packers of the framework.
+func profilePackerCreators() []packer.Creator {
+	return []packer.Creator{
+		func(provider packer.Provider) (packer.Packer, error) {
+			return legacy.New(provider), nil
+		},
+		func(provider packer.Provider) (packer.Packer, error) {
+			return jwe.New(provider, jwe.XC20P)
+		},
+		func(provider packer.Provider) (packer.Packer, error) {
+			return ecdh1pu.New(provider, ecdh1pu.A256GCM)
+		},
+	}
+}
+
+func newIntroduceSvc() api.ProtocolSvcCreator {
+	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		return introduce.New(prv)
+	}
+}
+
+func newIssueCredentialSvc() api.ProtocolSvcCreator {
+	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		return issuecredential.New(prv)
+	}
+}
+
+func newPresentProofSvc() api.ProtocolSvcCreator {
+	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		return presentproof.New(prv)
+	}
+}
+
+func newFileTransferSvc() api.ProtocolSvcCreator {
+	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		return filetransfer.New(prv)
+	}
+}
+
+// newDIDUpdateSvc returns the DID update service, the DID documents cached by the present proof service created
+// before it are invalidated once they are updated.
+func newDIDUpdateSvc() api.ProtocolSvcCreator {
+	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		svc, err := didupdate.New(prv)
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := prv.Service(presentproof.Name)
+		if err != nil {
+			return svc, nil
+		}
+
+		presentProof, ok := raw.(*presentproof.Service)
+		if !ok {
+			return svc, nil
+		}
+
+		_, err = svc.RegisterMsgCallback(func(msg service.StateMsg) {
+			if e, ok := msg.Properties.(didupdate.Event); ok {
+				presentProof.InvalidateDIDDoc(e.DID())
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return svc, nil
+	}
+}
+
+// newDiscoverFeaturesSvc returns the discover features service disclosing the protocols of the services
+// created before it, the services created afterwards (eg connection upgrade) disclose their protocol themselves.
+func newDiscoverFeaturesSvc() api.ProtocolSvcCreator {
+	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		svc, err := discoverfeatures.New(prv)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, protocol := range []struct{ name, spec string }{
+			{route.Coordination, route.CoordinationSpec},
+			{didexchange.DIDExchange, didexchange.DIDExchangeSpec},
+			{introduce.Introduce, introduce.IntroduceSpec},
+			{issuecredential.Name, issuecredential.Spec},
+			{presentproof.Name, presentproof.Spec},
+			{filetransfer.Name, filetransfer.Spec},
+			{didupdate.Name, didupdate.Spec},
+		} {
+			if _, err = prv.Service(protocol.name); err == nil {
+				svc.Disclose(&discoverfeatures.Protocol{PID: discoverfeatures.PID(protocol.spec)})
+			}
+		}
+
+		return svc, nil
+	}
+}
+
+func newConnectionUpgradeSvc() api.ProtocolSvcCreator {
+	return func(prv api.Provider) (dispatcher.ProtocolService, error) {
+		return connectionupgrade.New(prv)
+	}
+}
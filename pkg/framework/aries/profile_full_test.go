@@ -0,0 +1,42 @@
+// +build !ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/discoverfeatures"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/issuecredential"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func TestDiscoverFeatures(t *testing.T) {
+	a, err := New(WithStoreProvider(mem.NewProvider()), WithTransientStoreProvider(mem.NewProvider()))
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, a.Close())
+	}()
+
+	ctx, err := a.Context()
+	require.NoError(t, err)
+
+	raw, err := ctx.Service(discoverfeatures.Name)
+	require.NoError(t, err)
+
+	svc, ok := raw.(*discoverfeatures.Service)
+	require.True(t, ok)
+
+	// the protocols of the framework are disclosed
+	require.Equal(t, []*discoverfeatures.Protocol{{PID: discoverfeatures.PID(issuecredential.Spec)}},
+		svc.Protocols(discoverfeatures.PID(issuecredential.Spec)))
+	require.Len(t, svc.Protocols("https://didcomm.org/*"), 9)
+}
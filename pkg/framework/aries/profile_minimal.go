@@ -0,0 +1,30 @@
+// +build ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	legacy "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/authcrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
+)
+
+// profileProtocolSvcCreators returns the protocol services establishing the connections.
+func profileProtocolSvcCreators() []api.ProtocolSvcCreator {
+	// order is important as DIDExchange service depends on Route service and OutOfBand depends on DIDExchange
+	return []api.ProtocolSvcCreator{newRouteSvc(), newExchangeSvc(), newOutOfBandSvc()}
+}
+
+// profilePackerCreators returns the legacy packer.
+func profilePackerCreators() []packer.Creator {
+	return []packer.Creator{
+		func(provider packer.Provider) (packer.Packer, error) {
+			return legacy.New(provider), nil
+		},
+	}
+}
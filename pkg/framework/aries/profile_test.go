@@ -0,0 +1,61 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+func resetRegistry() {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registeredProtocols = nil
+	registeredPackers = nil
+}
+
+func TestRegisterProtocol(t *testing.T) {
+	defer resetRegistry()
+
+	profile := len(profileProtocolSvcCreators())
+
+	RegisterProtocol(func(api.Provider) (dispatcher.ProtocolService, error) {
+		return nil, errors.New("registered service error")
+	})
+
+	require.Len(t, defaultProtocolSvcCreators(), profile+1)
+	// the registry is not modified by the frameworks
+	require.Len(t, defaultProtocolSvcCreators(), profile+1)
+
+	_, err := New(WithStoreProvider(mem.NewProvider()), WithTransientStoreProvider(mem.NewProvider()))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "registered service error")
+}
+
+func TestRegisterPacker(t *testing.T) {
+	defer resetRegistry()
+
+	profile := len(profilePackerCreators())
+
+	RegisterPacker(func(packer.Provider) (packer.Packer, error) {
+		return nil, errors.New("registered packer error")
+	})
+
+	require.Len(t, defaultPackerCreators(), profile+1)
+
+	_, err := New(WithStoreProvider(mem.NewProvider()), WithTransientStoreProvider(mem.NewProvider()))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "registered packer error")
+}
@@ -0,0 +1,39 @@
+// +build !ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	webcrypto "github.com/hyperledger/aries-framework-go/pkg/crypto/webkms"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
+)
+
+// WithWebKMS injects a remote KMS and crypto service to the Aries framework. The keys of the KMS and crypto of
+// the context (see context.Provider KMS and Crypto) are created and used in the remote keystore found at
+// keystoreURL (eg an HSM-backed key server), they never leave the key server: the credentials and presentations
+// signed with these keys (see suite.NewCryptoSigner) use HSM-backed keys.
+//
+// The legacy KMS is replaced by the remote legacy KMS of the same keystore: the keys of the DIDComm packers, of
+// the DID documents created with the VDRI registry and of the DID exchange signatures are also remote keys.
+//
+// The option is not available in the minimal profile, the remote KMS is injected with WithKMS and WithCrypto.
+func WithWebKMS(keystoreURL string, httpClient webkms.HTTPClient) Option {
+	return func(opts *Aries) error {
+		opts.legacyKMSCreator = func(ctx api.Provider) (api.CloseableKMS, error) {
+			return webkms.NewLegacyKMS(keystoreURL, httpClient, ctx.StorageProvider())
+		}
+		opts.kmsCreator = func(kms.Provider) (kms.KeyManager, error) {
+			return webkms.New(keystoreURL, httpClient), nil
+		}
+		opts.crypto = webcrypto.New(httpClient)
+
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+// +build !ariesminimal
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package aries
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	webcrypto "github.com/hyperledger/aries-framework-go/pkg/crypto/webkms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
+	mockwebkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/webkms"
+)
+
+func TestWithWebKMS(t *testing.T) {
+	path, cleanup := generateTempDir(t)
+	defer cleanup()
+	dbPath = path
+
+	a, err := New(WithWebKMS("https://keyserver/kms/keystores/123", &http.Client{}))
+	require.NoError(t, err)
+	require.NotEmpty(t, a)
+	require.IsType(t, &webkms.RemoteKMS{}, a.kms)
+	require.IsType(t, &webcrypto.RemoteCrypto{}, a.crypto)
+
+	require.NoError(t, a.Close())
+}
+
+func TestWithWebKMS_RemoteKeys(t *testing.T) {
+	path, cleanup := generateTempDir(t)
+	defer cleanup()
+	dbPath = path
+
+	server := mockwebkms.NewKeyServer()
+	defer server.Close()
+
+	a, err := New(WithWebKMS(server.KeystoreURL(), server.Client()))
+	require.NoError(t, err)
+	require.IsType(t, &webkms.LegacyKMS{}, a.legacyKMS)
+
+	defer func() {
+		require.NoError(t, a.Close())
+	}()
+
+	ctx, err := a.Context()
+	require.NoError(t, err)
+
+	t.Run("DID creation", func(t *testing.T) {
+		count := server.KeyCount()
+
+		doc, err := ctx.VDRIRegistry().Create("peer")
+		require.NoError(t, err)
+		require.Equal(t, count+1, server.KeyCount())
+
+		_, err = ctx.LegacyKMS().FindVerKey([]string{base58.Encode(doc.PublicKey[0].Value)})
+		require.NoError(t, err)
+	})
+
+	t.Run("pack and unpack", func(t *testing.T) {
+		_, sender, err := ctx.LegacyKMS().CreateKeySet()
+		require.NoError(t, err)
+
+		_, recipient, err := ctx.LegacyKMS().CreateKeySet()
+		require.NoError(t, err)
+
+		for _, p := range append(ctx.Packers(), ctx.PrimaryPacker()) {
+			envelope, err := p.Pack([]byte("message"), base58.Decode(sender), [][]byte{base58.Decode(recipient)})
+			require.NoError(t, err, p.EncodingType())
+
+			env, err := p.Unpack(envelope)
+			require.NoError(t, err, p.EncodingType())
+			require.Equal(t, []byte("message"), env.Message)
+		}
+	})
+}
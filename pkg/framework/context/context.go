@@ -8,12 +8,19 @@ package context
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	commontransport "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/quarantine"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
@@ -23,6 +30,17 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
 )
 
+const (
+	logModule = "aries-framework/context"
+
+	// every inbound message is logged: only the first messages of each second are logged,
+	// then one message out of logSampleThereafter
+	logSampleFirst      = 10
+	logSampleThereafter = 100
+)
+
+var logger = log.New(logModule).WithSampling(log.NewSampler(logSampleFirst, logSampleThereafter, time.Second))
+
 // Provider supplies the framework configuration to client objects.
 type Provider struct {
 	services               []dispatcher.ProtocolService
@@ -42,8 +60,17 @@ type Provider struct {
 	messenger              service.MessengerHandler
 	outboundTransports     []transport.OutboundTransport
 	vdriRegistry           vdriapi.Registry
+	documentLoader         ld.DocumentLoader
+	eventPayloadVersion    service.EventPayloadVersion
+	instrumentation        service.Instrumentation
+	connectionReuse        bool
+	quarantine             *quarantine.Quarantine
+	strictMode             *service.StrictMode
+	packDebug              bool
+	scheduler              *scheduler.Scheduler
 	transportReturnRoute   string
 	frameworkID            string
+	idGenerator            idgen.Generator
 }
 
 // New instantiates a new context provider.
@@ -140,12 +167,27 @@ func (p *Provider) RouterEndpoint() string {
 	return p.routerEndpoint
 }
 
-func (p *Provider) tryToHandle(svc service.InboundHandler, msg service.DIDCommMsgMap, myDID, theirDID string) error {
-	if err := p.messenger.HandleInbound(msg, myDID, theirDID); err != nil {
+func (p *Provider) tryToHandle(svc service.InboundHandler, name string, msg service.DIDCommMsgMap,
+	myDID, theirDID string) (err error) {
+	if err = p.strictMode.Check(name, msg); err != nil {
+		return err
+	}
+
+	thID, _ := msg.ThreadID() //nolint:errcheck
+
+	logger.WithFields(log.Fields{log.FieldThread: thID}).Debugf("dispatching inbound message %s", msg.Type())
+
+	instrumentation := p.Instrumentation()
+	instrumentation.MessageReceived(name, msg)
+
+	endSpan := instrumentation.StartSpan(name, thID, "dispatch")
+	defer func() { endSpan(err) }()
+
+	if err = p.messenger.HandleInbound(msg, myDID, theirDID); err != nil {
 		return fmt.Errorf("messenger HandleInbound: %w", err)
 	}
 
-	_, err := svc.HandleInbound(msg, myDID, theirDID)
+	_, err = svc.HandleInbound(msg, myDID, theirDID)
 
 	return err
 }
@@ -161,7 +203,7 @@ func (p *Provider) InboundMessageHandler() transport.InboundMessageHandler {
 		// find the service which accepts the message type
 		for _, svc := range p.services {
 			if svc.Accept(msg.Type()) {
-				return p.tryToHandle(svc, msg, myDID, theirDID)
+				return p.tryToHandle(svc, svc.Name(), msg, myDID, theirDID)
 			}
 		}
 
@@ -178,7 +220,7 @@ func (p *Provider) InboundMessageHandler() transport.InboundMessageHandler {
 			}
 
 			if svc.Accept(msg.Type(), h.Purpose) {
-				return p.tryToHandle(svc, msg, myDID, theirDID)
+				return p.tryToHandle(svc, svc.Name(), msg, myDID, theirDID)
 			}
 		}
 
@@ -201,6 +243,71 @@ func (p *Provider) VDRIRegistry() vdriapi.Registry {
 	return p.vdriRegistry
 }
 
+// JSONLDDocumentLoader returns the loader of the JSON-LD contexts.
+func (p *Provider) JSONLDDocumentLoader() ld.DocumentLoader {
+	return p.documentLoader
+}
+
+// EventPayloadVersion returns the version of the properties of the events of the protocol services,
+// service.EventPayloadV1 by default.
+func (p *Provider) EventPayloadVersion() service.EventPayloadVersion {
+	if p.eventPayloadVersion == 0 {
+		return service.EventPayloadV1
+	}
+
+	return p.eventPayloadVersion
+}
+
+// Instrumentation returns the instrumentation hook of the DIDComm messages and of the protocol services,
+// service.NoopInstrumentation by default.
+func (p *Provider) Instrumentation() service.Instrumentation {
+	if p.instrumentation == nil {
+		return service.NoopInstrumentation{}
+	}
+
+	return p.instrumentation
+}
+
+// ConnectionReuse tells whether the DID exchange invitations from connected agents reuse the existing connection.
+func (p *Provider) ConnectionReuse() bool {
+	return p.connectionReuse
+}
+
+// Scheduler returns the scheduler of the periodic maintenance tasks of the framework.
+func (p *Provider) Scheduler() *scheduler.Scheduler {
+	return p.scheduler
+}
+
+// PackDebug returns whether the structure of the envelopes of the outbound messages is recorded.
+func (p *Provider) PackDebug() bool {
+	return p.packDebug
+}
+
+// Quarantine returns the quarantine of the inbound envelopes the transports fail to unpack,
+// nil if the inbound quarantine is not enabled.
+func (p *Provider) Quarantine() *quarantine.Quarantine {
+	return p.quarantine
+}
+
+// UnpackFailureHandler returns the handler of the inbound envelopes the transports fail to unpack,
+// it quarantines them (see Quarantine). Nil if the inbound quarantine is not enabled.
+func (p *Provider) UnpackFailureHandler() transport.UnpackFailureHandler {
+	if p.quarantine == nil {
+		return nil
+	}
+
+	return func(envelope []byte, err error) {
+		if _, e := p.quarantine.Add(envelope, err); e != nil {
+			logger.Errorf("quarantine envelope: %s", e)
+		}
+	}
+}
+
+// StrictMode returns the strict parsing mode of the inbound messages, nil if the strict mode is disabled.
+func (p *Provider) StrictMode() *service.StrictMode {
+	return p.strictMode
+}
+
 // TransportReturnRoute returns transport return route
 func (p *Provider) TransportReturnRoute() string {
 	return p.transportReturnRoute
@@ -211,6 +318,16 @@ func (p *Provider) AriesFrameworkID() string {
 	return p.frameworkID
 }
 
+// IDGenerator returns the generator of the identifiers created by the framework (message IDs, connection IDs,
+// protocol instance IDs...), the random (version 4) UUID generator by default.
+func (p *Provider) IDGenerator() idgen.Generator {
+	if p.idGenerator == nil {
+		return idgen.UUIDv4()
+	}
+
+	return p.idGenerator
+}
+
 // ProviderOption configures the framework.
 type ProviderOption func(opts *Provider) error
 
@@ -294,6 +411,71 @@ func WithVDRIRegistry(vdri vdriapi.Registry) ProviderOption {
 	}
 }
 
+// WithJSONLDDocumentLoader injects the loader of the JSON-LD contexts into the context.
+func WithJSONLDDocumentLoader(loader ld.DocumentLoader) ProviderOption {
+	return func(opts *Provider) error {
+		opts.documentLoader = loader
+		return nil
+	}
+}
+
+// WithEventPayloadVersion injects the version of the properties of the events of the protocol services.
+func WithEventPayloadVersion(version service.EventPayloadVersion) ProviderOption {
+	return func(opts *Provider) error {
+		opts.eventPayloadVersion = version
+		return nil
+	}
+}
+
+// WithInstrumentation injects the instrumentation hook of the DIDComm messages and of the protocol services.
+func WithInstrumentation(instrumentation service.Instrumentation) ProviderOption {
+	return func(opts *Provider) error {
+		opts.instrumentation = instrumentation
+		return nil
+	}
+}
+
+// WithConnectionReuse injects whether the DID exchange invitations from connected agents reuse
+// the existing connection.
+func WithConnectionReuse(reuse bool) ProviderOption {
+	return func(opts *Provider) error {
+		opts.connectionReuse = reuse
+		return nil
+	}
+}
+
+// WithScheduler injects the scheduler of the periodic maintenance tasks into the context.
+func WithScheduler(s *scheduler.Scheduler) ProviderOption {
+	return func(opts *Provider) error {
+		opts.scheduler = s
+		return nil
+	}
+}
+
+// WithPackDebug injects whether the structure of the envelopes of the outbound messages is recorded.
+func WithPackDebug(enabled bool) ProviderOption {
+	return func(opts *Provider) error {
+		opts.packDebug = enabled
+		return nil
+	}
+}
+
+// WithQuarantine injects the quarantine of the inbound envelopes the transports fail to unpack.
+func WithQuarantine(q *quarantine.Quarantine) ProviderOption {
+	return func(opts *Provider) error {
+		opts.quarantine = q
+		return nil
+	}
+}
+
+// WithStrictMode injects the strict parsing mode of the inbound messages.
+func WithStrictMode(mode *service.StrictMode) ProviderOption {
+	return func(opts *Provider) error {
+		opts.strictMode = mode
+		return nil
+	}
+}
+
 // WithServiceEndpoint injects an service transport endpoint into the context.
 func WithServiceEndpoint(endpoint string) ProviderOption {
 	return func(opts *Provider) error {
@@ -356,6 +538,14 @@ func WithAriesFrameworkID(id string) ProviderOption {
 	}
 }
 
+// WithIDGenerator injects the generator of the identifiers created by the framework into the context.
+func WithIDGenerator(g idgen.Generator) ProviderOption {
+	return func(opts *Provider) error {
+		opts.idGenerator = g
+		return nil
+	}
+}
+
 // WithMessageServiceProvider injects a message service provider into the context
 func WithMessageServiceProvider(msv api.MessageServiceProvider) ProviderOption {
 	return func(opts *Provider) error {
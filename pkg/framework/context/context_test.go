@@ -14,10 +14,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/piprate/json-gold/ld"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/idgen"
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/quarantine"
 	serviceMocks "github.com/hyperledger/aries-framework-go/pkg/internal/gomocks/didcomm/common/service"
 	mockdidcomm "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm"
 	mockdispatcher "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/dispatcher"
@@ -31,6 +35,7 @@ import (
 	mocklegacykms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/legacykms"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 )
 
 func TestNewProvider(t *testing.T) {
@@ -172,6 +177,30 @@ func TestNewProvider(t *testing.T) {
 		require.EqualError(t, errors.Unwrap(err), errTest.Error())
 	})
 
+	t.Run("Strict mode", func(t *testing.T) {
+		messengerHandler := serviceMocks.NewMockMessengerHandler(ctrl)
+		messengerHandler.EXPECT().HandleInbound(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		mode := &service.StrictMode{Protocols: map[string]bool{"didexchange": true}}
+
+		ctx, err := New(
+			WithProtocolServices(&mockdidexchange.MockDIDExchangeSvc{}),
+			WithMessageServiceProvider(msghandler.NewMockMsgServiceProvider()),
+			WithMessengerHandler(messengerHandler),
+			WithStrictMode(mode),
+		)
+		require.NoError(t, err)
+		require.Equal(t, mode, ctx.StrictMode())
+
+		inboundHandler := ctx.InboundMessageHandler()
+
+		err = inboundHandler([]byte(`{"@id": "id", "@type": "type", "~unknown": {}}`), "", "")
+		require.True(t, errors.Is(err, service.ErrStrictMode))
+
+		err = inboundHandler([]byte(`{"@id": "id", "@type": "type", "~thread": {"thid": "thid"}}`), "", "")
+		require.NoError(t, err)
+	})
+
 	t.Run("test new with message service", func(t *testing.T) {
 		const sampleMsgType = "generic-msg-type-2.0"
 
@@ -308,6 +337,112 @@ func TestNewProvider(t *testing.T) {
 		require.Equal(t, r, prov.VDRIRegistry())
 	})
 
+	t.Run("test new with JSON-LD document loader", func(t *testing.T) {
+		loader := ld.NewDefaultDocumentLoader(nil)
+		prov, err := New(WithJSONLDDocumentLoader(loader))
+		require.NoError(t, err)
+		require.Equal(t, loader, prov.JSONLDDocumentLoader())
+	})
+
+	t.Run("test new with event payload version", func(t *testing.T) {
+		prov, err := New()
+		require.NoError(t, err)
+		require.Equal(t, service.EventPayloadV1, prov.EventPayloadVersion())
+
+		prov, err = New(WithEventPayloadVersion(service.EventPayloadV2))
+		require.NoError(t, err)
+		require.Equal(t, service.EventPayloadV2, prov.EventPayloadVersion())
+	})
+
+	t.Run("test new with connection reuse", func(t *testing.T) {
+		prov, err := New()
+		require.NoError(t, err)
+		require.False(t, prov.ConnectionReuse())
+
+		prov, err = New(WithConnectionReuse(true))
+		require.NoError(t, err)
+		require.True(t, prov.ConnectionReuse())
+	})
+
+	t.Run("test new with scheduler", func(t *testing.T) {
+		s := scheduler.New()
+
+		prov, err := New(WithScheduler(s))
+		require.NoError(t, err)
+		require.Equal(t, s, prov.Scheduler())
+	})
+
+	t.Run("test new with pack debug", func(t *testing.T) {
+		prov, err := New()
+		require.NoError(t, err)
+		require.False(t, prov.PackDebug())
+
+		prov, err = New(WithPackDebug(true))
+		require.NoError(t, err)
+		require.True(t, prov.PackDebug())
+	})
+
+	t.Run("test new with inbound quarantine", func(t *testing.T) {
+		prov, err := New()
+		require.NoError(t, err)
+		require.Nil(t, prov.UnpackFailureHandler())
+
+		prov, err = New(WithStorageProvider(mem.NewProvider()))
+		require.NoError(t, err)
+
+		q, err := quarantine.New(prov, quarantine.WithMaxEnvelopeSize(3))
+		require.NoError(t, err)
+
+		prov, err = New(WithQuarantine(q))
+		require.NoError(t, err)
+		require.Equal(t, q, prov.Quarantine())
+		require.NotNil(t, prov.UnpackFailureHandler())
+
+		prov.UnpackFailureHandler()([]byte("jwe"), errors.New("no key found"))
+
+		envelopes, err := q.Envelopes()
+		require.NoError(t, err)
+		require.Len(t, envelopes, 1)
+		require.Equal(t, []byte("jwe"), envelopes[0].Raw)
+		require.Equal(t, "no key found", envelopes[0].Reason)
+
+		// the failure to quarantine the envelope is logged
+		prov.UnpackFailureHandler()([]byte("large jwe"), errors.New("no key found"))
+	})
+
+	t.Run("test new with instrumentation", func(t *testing.T) {
+		prov, err := New()
+		require.NoError(t, err)
+		require.Equal(t, service.NoopInstrumentation{}, prov.Instrumentation())
+
+		messengerHandler := serviceMocks.NewMockMessengerHandler(ctrl)
+		messengerHandler.EXPECT().HandleInbound(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+		errTest := errors.New("test")
+		recorder := &instrumentationRecorder{}
+
+		prov, err = New(WithProtocolServices(&mockdidexchange.MockDIDExchangeSvc{
+			ProtocolName: "mockProtocolSvc",
+			AcceptFunc: func(msgType string) bool {
+				return msgType == "valid-message-type"
+			},
+			HandleFunc: func(service.DIDCommMsg) (string, error) {
+				return "", errTest
+			},
+		}), WithMessengerHandler(messengerHandler), WithInstrumentation(recorder))
+		require.NoError(t, err)
+		require.Equal(t, recorder, prov.Instrumentation())
+
+		msg := `{"@id": "ID", "@type": "valid-message-type", "~thread": {"thid": "thID"}}`
+
+		err = prov.InboundMessageHandler()([]byte(msg), "", "")
+		require.True(t, errors.Is(err, errTest))
+
+		require.Equal(t, []string{"mockProtocolSvc valid-message-type"}, recorder.received)
+		require.Equal(t, []string{"mockProtocolSvc thID dispatch"}, recorder.spans)
+		require.Equal(t, []error{errTest}, recorder.spanErrors)
+	})
+
 	t.Run("test new with outbound transport service", func(t *testing.T) {
 		prov, err := New(WithOutboundTransports(&mockdidcomm.MockOutboundTransport{ExpectedResponse: "data"},
 			&mockdidcomm.MockOutboundTransport{ExpectedResponse: "data1"}))
@@ -335,6 +470,16 @@ func TestNewProvider(t *testing.T) {
 		require.Equal(t, frameworkID, prov.AriesFrameworkID())
 	})
 
+	t.Run("test new with id generator", func(t *testing.T) {
+		prov, err := New()
+		require.NoError(t, err)
+		require.NotEmpty(t, prov.IDGenerator().NewID())
+
+		prov, err = New(WithIDGenerator(idgen.GeneratorFunc(func() string { return "id" })))
+		require.NoError(t, err)
+		require.Equal(t, "id", prov.IDGenerator().NewID())
+	})
+
 	t.Run("test new with bad (fake) option", func(t *testing.T) {
 		prov, err := New(func(opts *Provider) error {
 			return fmt.Errorf("bad option")
@@ -343,3 +488,22 @@ func TestNewProvider(t *testing.T) {
 		require.Empty(t, prov)
 	})
 }
+
+type instrumentationRecorder struct {
+	service.NoopInstrumentation
+	received   []string
+	spans      []string
+	spanErrors []error
+}
+
+func (r *instrumentationRecorder) MessageReceived(protocol string, msg service.DIDCommMsg) {
+	r.received = append(r.received, protocol+" "+msg.Type())
+}
+
+func (r *instrumentationRecorder) StartSpan(protocol, threadID, name string) func(error) {
+	r.spans = append(r.spans, protocol+" "+threadID+" "+name)
+
+	return func(err error) {
+		r.spanErrors = append(r.spanErrors, err)
+	}
+}
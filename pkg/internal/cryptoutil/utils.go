@@ -148,11 +148,6 @@ func Derive25519KEK(alg, apu []byte, fromPrivKey, toPubKey *[chacha.KeySize]byte
 		return nil, ErrInvalidKey
 	}
 
-	const (
-		numBitsPerByte = 8
-		supPubInfoLen  = 4
-	)
-
 	// do ScalarMult of the sender's private key with the recipient key to get a derived Z point
 	// ( equivalent to derive an EC key )
 	z, err := curve25519.X25519(fromPrivKey[:], toPubKey[:])
@@ -160,6 +155,21 @@ func Derive25519KEK(alg, apu []byte, fromPrivKey, toPubKey *[chacha.KeySize]byte
 		return nil, err
 	}
 
+	return DeriveKEKFromSharedSecret(alg, apu, z)
+}
+
+// DeriveKEKFromSharedSecret is a utility function that will derive an ephemeral symmetric key (kek) from the
+// X25519 shared secret z, eg computed by a remote key server holding the private key
+func DeriveKEKFromSharedSecret(alg, apu, z []byte) ([]byte, error) {
+	if len(z) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	const (
+		numBitsPerByte = 8
+		supPubInfoLen  = 4
+	)
+
 	// inspired by: github.com/square/go-jose/v3@v3.0.0-20190722231519-723929d55157/cipher/ecdh_es.go
 	// -> DeriveECDHES() call
 	// suppPubInfo is the encoded length of the recipient shared key output size in bits
@@ -185,7 +195,48 @@ func Derive25519KEK(alg, apu []byte, fromPrivKey, toPubKey *[chacha.KeySize]byte
 	kek := make([]byte, chacha.KeySize)
 
 	// Read on the KDF will never fail
-	_, err = reader.Read(kek)
+	_, err := reader.Read(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return kek, nil
+}
+
+// Derive1PUKEK is a utility function that will derive a key encryption key (kek) of keySize bytes from the
+// ephemeral-static (ze) and static-static (zs) X25519 shared secrets as defined by ECDH-1PU. In the key wrapping
+// modes, the tag of the content encryption is appended to the SuppPubInfo of the KDF (length prefixed), it is
+// empty in the direct key agreement mode:
+// https://tools.ietf.org/html/draft-madden-jose-ecdh-1pu-04#section-2.3
+func Derive1PUKEK(alg, apu, apv, tag, ze, zs []byte, keySize int) ([]byte, error) {
+	if len(ze) == 0 || len(zs) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	const (
+		numBitsPerByte = 8
+		supPubInfoLen  = 4
+	)
+
+	// Z is the concatenation of the ephemeral and static shared secrets: Z = Ze || Zs
+	z := make([]byte, 0, len(ze)+len(zs))
+	z = append(z, ze...)
+	z = append(z, zs...)
+
+	supPubInfo := make([]byte, supPubInfoLen)
+	binary.BigEndian.PutUint32(supPubInfo, uint32(keySize)*numBitsPerByte)
+
+	if len(tag) > 0 {
+		supPubInfo = append(supPubInfo, lengthPrefix(tag)...)
+	}
+
+	reader := josecipher.NewConcatKDF(crypto.SHA256, z, lengthPrefix(alg), lengthPrefix(apu), lengthPrefix(apv),
+		supPubInfo, []byte{})
+
+	kek := make([]byte, keySize)
+
+	// Read on the KDF will never fail
+	_, err := reader.Read(kek)
 	if err != nil {
 		return nil, err
 	}
@@ -7,12 +7,15 @@ SPDX-License-Identifier: Apache-2.0
 package cryptoutil
 
 import (
+	"crypto"
 	"encoding/base64"
 	"testing"
 
 	"github.com/btcsuite/btcutil/base58"
+	josecipher "github.com/square/go-jose/v3/cipher"
 	"github.com/stretchr/testify/require"
 	chacha "golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
 )
 
 func TestIsKeyPairValid(t *testing.T) {
@@ -106,6 +109,72 @@ func TestDeriveKEK_Util(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestDeriveKEKFromSharedSecret_Util(t *testing.T) {
+	kek, err := DeriveKEKFromSharedSecret(nil, nil, nil)
+	require.EqualError(t, err, ErrInvalidKey.Error())
+	require.Empty(t, kek)
+
+	privKey, err := base64.RawURLEncoding.DecodeString("c8CSJr_27PN9xWCpzXNmepRndD6neQcnO9DS0YWjhNs")
+	require.NoError(t, err)
+
+	pubKey, err := base64.RawURLEncoding.DecodeString("AAjrHjiFLw6kf6CZ5zqH1ooG3y2aQhuqxmUvqJnIvDI")
+	require.NoError(t, err)
+
+	z, err := curve25519.X25519(privKey, pubKey)
+	require.NoError(t, err)
+
+	kek, err = DeriveKEKFromSharedSecret([]byte("alg"), []byte("apu"), z)
+	require.NoError(t, err)
+
+	from, to := new([chacha.KeySize]byte), new([chacha.KeySize]byte)
+	copy(from[:], privKey)
+	copy(to[:], pubKey)
+
+	expected, err := Derive25519KEK([]byte("alg"), []byte("apu"), from, to)
+	require.NoError(t, err)
+	require.Equal(t, expected, kek)
+}
+
+func TestDerive1PUKEK_Util(t *testing.T) {
+	kek, err := Derive1PUKEK(nil, nil, nil, nil, nil, nil, chacha.KeySize)
+	require.EqualError(t, err, ErrInvalidKey.Error())
+	require.Empty(t, kek)
+
+	ze := []byte("ephemeral-static-shared-secret-z")
+	zs := []byte("static-static-shared-secret-zzzz")
+
+	kek, err = Derive1PUKEK([]byte("ECDH-1PU+A256KW"), []byte("apu"), []byte("apv"), nil, ze, zs, chacha.KeySize)
+	require.NoError(t, err)
+	require.Len(t, kek, chacha.KeySize)
+
+	// deriving with the same inputs must be deterministic
+	kek2, err := Derive1PUKEK([]byte("ECDH-1PU+A256KW"), []byte("apu"), []byte("apv"), nil, ze, zs, chacha.KeySize)
+	require.NoError(t, err)
+	require.Equal(t, kek, kek2)
+
+	// swapping the shared secrets must produce a different key
+	kek2, err = Derive1PUKEK([]byte("ECDH-1PU+A256KW"), []byte("apu"), []byte("apv"), nil, zs, ze, chacha.KeySize)
+	require.NoError(t, err)
+	require.NotEqual(t, kek, kek2)
+
+	// in the key wrapping modes, the tag is appended to the SuppPubInfo: keydatalen || len(tag) || tag
+	tag := []byte("content-encryption-tag")
+
+	kek2, err = Derive1PUKEK([]byte("ECDH-1PU+A256KW"), []byte("apu"), []byte("apv"), tag, ze, zs, chacha.KeySize)
+	require.NoError(t, err)
+	require.NotEqual(t, kek, kek2)
+
+	suppPubInfo := []byte{0, 0, 1, 0, 0, 0, 0, byte(len(tag))}
+	reader := josecipher.NewConcatKDF(crypto.SHA256, append(append([]byte{}, ze...), zs...),
+		lengthPrefix([]byte("ECDH-1PU+A256KW")), lengthPrefix([]byte("apu")), lengthPrefix([]byte("apv")),
+		append(suppPubInfo, tag...), []byte{})
+
+	expected := make([]byte, chacha.KeySize)
+	_, err = reader.Read(expected)
+	require.NoError(t, err)
+	require.Equal(t, expected, kek2)
+}
+
 func TestNonceGeneration(t *testing.T) {
 	t.Run("Verify nonce against libsodium generated data", func(t *testing.T) {
 		data := [][]string{
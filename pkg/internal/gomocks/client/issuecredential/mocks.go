@@ -86,6 +86,36 @@ func (mr *MockProtocolServiceMockRecorder) ActionContinue(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionContinue", reflect.TypeOf((*MockProtocolService)(nil).ActionContinue), arg0, arg1)
 }
 
+// ActionPause mocks base method
+func (m *MockProtocolService) ActionPause(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActionPause", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActionPause indicates an expected call of ActionPause
+func (mr *MockProtocolServiceMockRecorder) ActionPause(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionPause", reflect.TypeOf((*MockProtocolService)(nil).ActionPause), arg0)
+}
+
+// ActionResume mocks base method
+func (m *MockProtocolService) ActionResume(arg0 string) (*issuecredential.Action, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActionResume", arg0)
+	ret0, _ := ret[0].(*issuecredential.Action)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActionResume indicates an expected call of ActionResume
+func (mr *MockProtocolServiceMockRecorder) ActionResume(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionResume", reflect.TypeOf((*MockProtocolService)(nil).ActionResume), arg0)
+}
+
 // ActionStop mocks base method
 func (m *MockProtocolService) ActionStop(arg0 string, arg1 error) error {
 	m.ctrl.T.Helper()
@@ -144,6 +174,20 @@ func (mr *MockProtocolServiceMockRecorder) HandleOutbound(arg0, arg1, arg2 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleOutbound", reflect.TypeOf((*MockProtocolService)(nil).HandleOutbound), arg0, arg1, arg2)
 }
 
+// RegisterActionCallback mocks base method
+func (m *MockProtocolService) RegisterActionCallback(arg0 service.ActionCallback) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterActionCallback", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterActionCallback indicates an expected call of RegisterActionCallback
+func (mr *MockProtocolServiceMockRecorder) RegisterActionCallback(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterActionCallback", reflect.TypeOf((*MockProtocolService)(nil).RegisterActionCallback), arg0)
+}
+
 // RegisterActionEvent mocks base method
 func (m *MockProtocolService) RegisterActionEvent(arg0 chan<- service.DIDCommAction) error {
 	m.ctrl.T.Helper()
@@ -158,6 +202,21 @@ func (mr *MockProtocolServiceMockRecorder) RegisterActionEvent(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterActionEvent", reflect.TypeOf((*MockProtocolService)(nil).RegisterActionEvent), arg0)
 }
 
+// RegisterMsgCallback mocks base method
+func (m *MockProtocolService) RegisterMsgCallback(arg0 service.StateMsgCallback) (func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterMsgCallback", arg0)
+	ret0, _ := ret[0].(func())
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterMsgCallback indicates an expected call of RegisterMsgCallback
+func (mr *MockProtocolServiceMockRecorder) RegisterMsgCallback(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterMsgCallback", reflect.TypeOf((*MockProtocolService)(nil).RegisterMsgCallback), arg0)
+}
+
 // RegisterMsgEvent mocks base method
 func (m *MockProtocolService) RegisterMsgEvent(arg0 chan<- service.StateMsg) error {
 	m.ctrl.T.Helper()
@@ -172,6 +231,18 @@ func (mr *MockProtocolServiceMockRecorder) RegisterMsgEvent(arg0 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterMsgEvent", reflect.TypeOf((*MockProtocolService)(nil).RegisterMsgEvent), arg0)
 }
 
+// UnregisterActionCallback mocks base method
+func (m *MockProtocolService) UnregisterActionCallback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UnregisterActionCallback")
+}
+
+// UnregisterActionCallback indicates an expected call of UnregisterActionCallback
+func (mr *MockProtocolServiceMockRecorder) UnregisterActionCallback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnregisterActionCallback", reflect.TypeOf((*MockProtocolService)(nil).UnregisterActionCallback))
+}
+
 // UnregisterActionEvent mocks base method
 func (m *MockProtocolService) UnregisterActionEvent(arg0 chan<- service.DIDCommAction) error {
 	m.ctrl.T.Helper()
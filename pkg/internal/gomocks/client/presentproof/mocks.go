@@ -9,6 +9,7 @@ import (
 	service "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	presentproof "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/presentproof"
 	reflect "reflect"
+	time "time"
 )
 
 // MockProvider is a mock of Provider interface
@@ -86,6 +87,36 @@ func (mr *MockProtocolServiceMockRecorder) ActionContinue(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionContinue", reflect.TypeOf((*MockProtocolService)(nil).ActionContinue), arg0, arg1)
 }
 
+// ActionPause mocks base method
+func (m *MockProtocolService) ActionPause(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActionPause", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActionPause indicates an expected call of ActionPause
+func (mr *MockProtocolServiceMockRecorder) ActionPause(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionPause", reflect.TypeOf((*MockProtocolService)(nil).ActionPause), arg0)
+}
+
+// ActionResume mocks base method
+func (m *MockProtocolService) ActionResume(arg0 string) (*presentproof.Action, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActionResume", arg0)
+	ret0, _ := ret[0].(*presentproof.Action)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActionResume indicates an expected call of ActionResume
+func (mr *MockProtocolServiceMockRecorder) ActionResume(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionResume", reflect.TypeOf((*MockProtocolService)(nil).ActionResume), arg0)
+}
+
 // ActionStop mocks base method
 func (m *MockProtocolService) ActionStop(arg0 string, arg1 error) error {
 	m.ctrl.T.Helper()
@@ -115,6 +146,22 @@ func (mr *MockProtocolServiceMockRecorder) Actions() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Actions", reflect.TypeOf((*MockProtocolService)(nil).Actions))
 }
 
+// AddActionPolicies mocks base method
+func (m *MockProtocolService) AddActionPolicies(arg0 ...presentproof.ActionPolicy) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "AddActionPolicies", varargs...)
+}
+
+// AddActionPolicies indicates an expected call of AddActionPolicies
+func (mr *MockProtocolServiceMockRecorder) AddActionPolicies(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddActionPolicies", reflect.TypeOf((*MockProtocolService)(nil).AddActionPolicies), arg0...)
+}
+
 // HandleInbound mocks base method
 func (m *MockProtocolService) HandleInbound(arg0 service.DIDCommMsg, arg1, arg2 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -144,6 +191,20 @@ func (mr *MockProtocolServiceMockRecorder) HandleOutbound(arg0, arg1, arg2 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleOutbound", reflect.TypeOf((*MockProtocolService)(nil).HandleOutbound), arg0, arg1, arg2)
 }
 
+// RegisterActionCallback mocks base method
+func (m *MockProtocolService) RegisterActionCallback(arg0 service.ActionCallback) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterActionCallback", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterActionCallback indicates an expected call of RegisterActionCallback
+func (mr *MockProtocolServiceMockRecorder) RegisterActionCallback(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterActionCallback", reflect.TypeOf((*MockProtocolService)(nil).RegisterActionCallback), arg0)
+}
+
 // RegisterActionEvent mocks base method
 func (m *MockProtocolService) RegisterActionEvent(arg0 chan<- service.DIDCommAction) error {
 	m.ctrl.T.Helper()
@@ -158,6 +219,21 @@ func (mr *MockProtocolServiceMockRecorder) RegisterActionEvent(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterActionEvent", reflect.TypeOf((*MockProtocolService)(nil).RegisterActionEvent), arg0)
 }
 
+// RegisterMsgCallback mocks base method
+func (m *MockProtocolService) RegisterMsgCallback(arg0 service.StateMsgCallback) (func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterMsgCallback", arg0)
+	ret0, _ := ret[0].(func())
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterMsgCallback indicates an expected call of RegisterMsgCallback
+func (mr *MockProtocolServiceMockRecorder) RegisterMsgCallback(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterMsgCallback", reflect.TypeOf((*MockProtocolService)(nil).RegisterMsgCallback), arg0)
+}
+
 // RegisterMsgEvent mocks base method
 func (m *MockProtocolService) RegisterMsgEvent(arg0 chan<- service.StateMsg) error {
 	m.ctrl.T.Helper()
@@ -172,6 +248,78 @@ func (mr *MockProtocolServiceMockRecorder) RegisterMsgEvent(arg0 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterMsgEvent", reflect.TypeOf((*MockProtocolService)(nil).RegisterMsgEvent), arg0)
 }
 
+// SetAuditLog mocks base method
+func (m *MockProtocolService) SetAuditLog(arg0 presentproof.AuditLog) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetAuditLog", arg0)
+}
+
+// SetAuditLog indicates an expected call of SetAuditLog
+func (mr *MockProtocolServiceMockRecorder) SetAuditLog(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAuditLog", reflect.TypeOf((*MockProtocolService)(nil).SetAuditLog), arg0)
+}
+
+// SetExchangeTimeout mocks base method
+func (m *MockProtocolService) SetExchangeTimeout(arg0 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetExchangeTimeout", arg0)
+}
+
+// SetExchangeTimeout indicates an expected call of SetExchangeTimeout
+func (mr *MockProtocolServiceMockRecorder) SetExchangeTimeout(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeTimeout", reflect.TypeOf((*MockProtocolService)(nil).SetExchangeTimeout), arg0)
+}
+
+// SetVerificationMetrics mocks base method
+func (m *MockProtocolService) SetVerificationMetrics(arg0 presentproof.VerificationMetrics) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetVerificationMetrics", arg0)
+}
+
+// SetVerificationMetrics indicates an expected call of SetVerificationMetrics
+func (mr *MockProtocolServiceMockRecorder) SetVerificationMetrics(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVerificationMetrics", reflect.TypeOf((*MockProtocolService)(nil).SetVerificationMetrics), arg0)
+}
+
+// SetVerificationRetries mocks base method
+func (m *MockProtocolService) SetVerificationRetries(arg0 int, arg1 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetVerificationRetries", arg0, arg1)
+}
+
+// SetVerificationRetries indicates an expected call of SetVerificationRetries
+func (mr *MockProtocolServiceMockRecorder) SetVerificationRetries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVerificationRetries", reflect.TypeOf((*MockProtocolService)(nil).SetVerificationRetries), arg0, arg1)
+}
+
+// SetVerificationWorkers mocks base method
+func (m *MockProtocolService) SetVerificationWorkers(arg0 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetVerificationWorkers", arg0)
+}
+
+// SetVerificationWorkers indicates an expected call of SetVerificationWorkers
+func (mr *MockProtocolServiceMockRecorder) SetVerificationWorkers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVerificationWorkers", reflect.TypeOf((*MockProtocolService)(nil).SetVerificationWorkers), arg0)
+}
+
+// UnregisterActionCallback mocks base method
+func (m *MockProtocolService) UnregisterActionCallback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UnregisterActionCallback")
+}
+
+// UnregisterActionCallback indicates an expected call of UnregisterActionCallback
+func (mr *MockProtocolServiceMockRecorder) UnregisterActionCallback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnregisterActionCallback", reflect.TypeOf((*MockProtocolService)(nil).UnregisterActionCallback))
+}
+
 // UnregisterActionEvent mocks base method
 func (m *MockProtocolService) UnregisterActionEvent(arg0 chan<- service.DIDCommAction) error {
 	m.ctrl.T.Helper()
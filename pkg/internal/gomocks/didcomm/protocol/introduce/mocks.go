@@ -76,3 +76,17 @@ func (mr *MockProviderMockRecorder) StorageProvider() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageProvider", reflect.TypeOf((*MockProvider)(nil).StorageProvider))
 }
+
+// TransientStorageProvider mocks base method
+func (m *MockProvider) TransientStorageProvider() storage.Provider {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransientStorageProvider")
+	ret0, _ := ret[0].(storage.Provider)
+	return ret0
+}
+
+// TransientStorageProvider indicates an expected call of TransientStorageProvider
+func (mr *MockProviderMockRecorder) TransientStorageProvider() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransientStorageProvider", reflect.TypeOf((*MockProvider)(nil).TransientStorageProvider))
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	gomock "github.com/golang/mock/gomock"
 	service "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	vdri "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	storage "github.com/hyperledger/aries-framework-go/pkg/storage"
 	reflect "reflect"
 )
@@ -34,6 +35,34 @@ func (m *MockProvider) EXPECT() *MockProviderMockRecorder {
 	return m.recorder
 }
 
+// EventPayloadVersion mocks base method
+func (m *MockProvider) EventPayloadVersion() service.EventPayloadVersion {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EventPayloadVersion")
+	ret0, _ := ret[0].(service.EventPayloadVersion)
+	return ret0
+}
+
+// EventPayloadVersion indicates an expected call of EventPayloadVersion
+func (mr *MockProviderMockRecorder) EventPayloadVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventPayloadVersion", reflect.TypeOf((*MockProvider)(nil).EventPayloadVersion))
+}
+
+// Instrumentation mocks base method
+func (m *MockProvider) Instrumentation() service.Instrumentation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Instrumentation")
+	ret0, _ := ret[0].(service.Instrumentation)
+	return ret0
+}
+
+// Instrumentation indicates an expected call of Instrumentation
+func (mr *MockProviderMockRecorder) Instrumentation() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Instrumentation", reflect.TypeOf((*MockProvider)(nil).Instrumentation))
+}
+
 // Messenger mocks base method
 func (m *MockProvider) Messenger() service.Messenger {
 	m.ctrl.T.Helper()
@@ -61,3 +90,31 @@ func (mr *MockProviderMockRecorder) StorageProvider() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageProvider", reflect.TypeOf((*MockProvider)(nil).StorageProvider))
 }
+
+// TransientStorageProvider mocks base method
+func (m *MockProvider) TransientStorageProvider() storage.Provider {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransientStorageProvider")
+	ret0, _ := ret[0].(storage.Provider)
+	return ret0
+}
+
+// TransientStorageProvider indicates an expected call of TransientStorageProvider
+func (mr *MockProviderMockRecorder) TransientStorageProvider() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransientStorageProvider", reflect.TypeOf((*MockProvider)(nil).TransientStorageProvider))
+}
+
+// VDRIRegistry mocks base method
+func (m *MockProvider) VDRIRegistry() vdri.Registry {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VDRIRegistry")
+	ret0, _ := ret[0].(vdri.Registry)
+	return ret0
+}
+
+// VDRIRegistry indicates an expected call of VDRIRegistry
+func (mr *MockProviderMockRecorder) VDRIRegistry() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VDRIRegistry", reflect.TypeOf((*MockProvider)(nil).VDRIRegistry))
+}
@@ -6,9 +6,11 @@ package mocks
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	scheduler "github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
 	service "github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	vdri "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	storage "github.com/hyperledger/aries-framework-go/pkg/storage"
+	ld "github.com/piprate/json-gold/ld"
 	reflect "reflect"
 )
 
@@ -35,6 +37,48 @@ func (m *MockProvider) EXPECT() *MockProviderMockRecorder {
 	return m.recorder
 }
 
+// EventPayloadVersion mocks base method
+func (m *MockProvider) EventPayloadVersion() service.EventPayloadVersion {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EventPayloadVersion")
+	ret0, _ := ret[0].(service.EventPayloadVersion)
+	return ret0
+}
+
+// EventPayloadVersion indicates an expected call of EventPayloadVersion
+func (mr *MockProviderMockRecorder) EventPayloadVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventPayloadVersion", reflect.TypeOf((*MockProvider)(nil).EventPayloadVersion))
+}
+
+// Instrumentation mocks base method
+func (m *MockProvider) Instrumentation() service.Instrumentation {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Instrumentation")
+	ret0, _ := ret[0].(service.Instrumentation)
+	return ret0
+}
+
+// Instrumentation indicates an expected call of Instrumentation
+func (mr *MockProviderMockRecorder) Instrumentation() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Instrumentation", reflect.TypeOf((*MockProvider)(nil).Instrumentation))
+}
+
+// JSONLDDocumentLoader mocks base method
+func (m *MockProvider) JSONLDDocumentLoader() ld.DocumentLoader {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JSONLDDocumentLoader")
+	ret0, _ := ret[0].(ld.DocumentLoader)
+	return ret0
+}
+
+// JSONLDDocumentLoader indicates an expected call of JSONLDDocumentLoader
+func (mr *MockProviderMockRecorder) JSONLDDocumentLoader() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JSONLDDocumentLoader", reflect.TypeOf((*MockProvider)(nil).JSONLDDocumentLoader))
+}
+
 // Messenger mocks base method
 func (m *MockProvider) Messenger() service.Messenger {
 	m.ctrl.T.Helper()
@@ -49,6 +93,20 @@ func (mr *MockProviderMockRecorder) Messenger() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Messenger", reflect.TypeOf((*MockProvider)(nil).Messenger))
 }
 
+// Scheduler mocks base method
+func (m *MockProvider) Scheduler() *scheduler.Scheduler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scheduler")
+	ret0, _ := ret[0].(*scheduler.Scheduler)
+	return ret0
+}
+
+// Scheduler indicates an expected call of Scheduler
+func (mr *MockProviderMockRecorder) Scheduler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scheduler", reflect.TypeOf((*MockProvider)(nil).Scheduler))
+}
+
 // StorageProvider mocks base method
 func (m *MockProvider) StorageProvider() storage.Provider {
 	m.ctrl.T.Helper()
@@ -63,6 +121,20 @@ func (mr *MockProviderMockRecorder) StorageProvider() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageProvider", reflect.TypeOf((*MockProvider)(nil).StorageProvider))
 }
 
+// TransientStorageProvider mocks base method
+func (m *MockProvider) TransientStorageProvider() storage.Provider {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransientStorageProvider")
+	ret0, _ := ret[0].(storage.Provider)
+	return ret0
+}
+
+// TransientStorageProvider indicates an expected call of TransientStorageProvider
+func (mr *MockProviderMockRecorder) TransientStorageProvider() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransientStorageProvider", reflect.TypeOf((*MockProvider)(nil).TransientStorageProvider))
+}
+
 // VDRIRegistry mocks base method
 func (m *MockProvider) VDRIRegistry() vdri.Registry {
 	m.ctrl.T.Helper()
@@ -101,3 +101,17 @@ func (mr *MockRegistryMockRecorder) Store(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockRegistry)(nil).Store), arg0)
 }
+
+// SupportedMethods mocks base method
+func (m *MockRegistry) SupportedMethods() []vdri.MethodCapabilities {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SupportedMethods")
+	ret0, _ := ret[0].([]vdri.MethodCapabilities)
+	return ret0
+}
+
+// SupportedMethods indicates an expected call of SupportedMethods
+func (mr *MockRegistryMockRecorder) SupportedMethods() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SupportedMethods", reflect.TypeOf((*MockRegistry)(nil).SupportedMethods))
+}
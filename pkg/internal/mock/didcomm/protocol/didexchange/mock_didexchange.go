@@ -38,6 +38,7 @@ type MockDIDExchangeSvc struct {
 	ImplicitInvitationErr    error
 	RespondToFunc            func(*didexchange.OOBInvitation) (string, error)
 	SaveFunc                 func(invitation *didexchange.OOBInvitation) error
+	HangupErr                error
 }
 
 // HandleInbound msg
@@ -139,6 +140,15 @@ func (m *MockDIDExchangeSvc) CreateImplicitInvitation(inviterLabel, inviterDID,
 	return "connection-id", nil
 }
 
+// Hangup terminates the connection.
+func (m *MockDIDExchangeSvc) Hangup(connectionID, reason string) error {
+	if m.HangupErr != nil {
+		return m.HangupErr
+	}
+
+	return nil
+}
+
 // RespondTo this invitation.
 func (m *MockDIDExchangeSvc) RespondTo(i *didexchange.OOBInvitation) (string, error) {
 	if m.RespondToFunc != nil {
@@ -31,6 +31,12 @@ type MockProvider struct {
 	ServiceErr             error
 	ServiceMap             map[string]interface{}
 	InboundMsgHandler      transport.InboundMessageHandler
+	// CustomEventPayloadVersion is the version of the event properties, service.EventPayloadV1 by default
+	CustomEventPayloadVersion service.EventPayloadVersion
+	// CustomInstrumentation is the instrumentation hook, service.NoopInstrumentation by default
+	CustomInstrumentation service.Instrumentation
+	// ReuseConnections enables the reuse of the existing connections
+	ReuseConnections bool
 }
 
 // OutboundDispatcher is mock outbound dispatcher for DID exchange service
@@ -105,3 +111,26 @@ func (p *MockProvider) Messenger() service.Messenger {
 func (p *MockProvider) InboundMessageHandler() transport.InboundMessageHandler {
 	return p.InboundMsgHandler
 }
+
+// EventPayloadVersion returns the version of the event properties.
+func (p *MockProvider) EventPayloadVersion() service.EventPayloadVersion {
+	if p.CustomEventPayloadVersion != 0 {
+		return p.CustomEventPayloadVersion
+	}
+
+	return service.EventPayloadV1
+}
+
+// Instrumentation returns the instrumentation hook.
+func (p *MockProvider) Instrumentation() service.Instrumentation {
+	if p.CustomInstrumentation != nil {
+		return p.CustomInstrumentation
+	}
+
+	return service.NoopInstrumentation{}
+}
+
+// ConnectionReuse tells whether the existing connections are reused.
+func (p *MockProvider) ConnectionReuse() bool {
+	return p.ReuseConnections
+}
@@ -28,6 +28,7 @@ type MockRouteSvc struct {
 	ConnectionID       string
 	GetConnectionIDErr error
 	AddKeyFunc         func(string) error
+	RemoveKeyErr       error
 }
 
 // HandleInbound msg
@@ -93,6 +94,11 @@ func (m *MockRouteSvc) AddKey(recKey string) error {
 	return nil
 }
 
+// RemoveKey removes agents recKey from the router
+func (m *MockRouteSvc) RemoveKey(recKey string) error {
+	return m.RemoveKeyErr
+}
+
 // Config gives back the router configuration
 func (m *MockRouteSvc) Config() (*route.Config, error) {
 	if m.ConfigErr != nil {
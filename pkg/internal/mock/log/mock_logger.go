@@ -0,0 +1,89 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+)
+
+// Recorder is a logger provider recording the formatted lines of its loggers, e.g to check what a service logs.
+// The provider is set once by process (see log.Initialize): the tests set it in TestMain, before any line is logged.
+type Recorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// GetLogger returns the logger of the module recording its lines.
+func (r *Recorder) GetLogger(module string) log.Logger {
+	return &recordingLogger{recorder: r, module: module}
+}
+
+// Lines returns the recorded lines of the module, formatted as "<LEVEL> <message>".
+func (r *Recorder) Lines(module string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lines []string
+
+	for _, line := range r.lines {
+		if strings.HasPrefix(line, module+" ") {
+			lines = append(lines, strings.TrimPrefix(line, module+" "))
+		}
+	}
+
+	return lines
+}
+
+// Reset removes the recorded lines.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines = nil
+}
+
+func (r *Recorder) record(module, level, msg string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines = append(r.lines, module+" "+level+" "+fmt.Sprintf(msg, args...))
+}
+
+type recordingLogger struct {
+	recorder *Recorder
+	module   string
+}
+
+func (l *recordingLogger) Fatalf(msg string, args ...interface{}) {
+	l.recorder.record(l.module, "FATAL", msg, args...)
+	panic(fmt.Sprintf(msg, args...))
+}
+
+func (l *recordingLogger) Panicf(msg string, args ...interface{}) {
+	l.recorder.record(l.module, "PANIC", msg, args...)
+	panic(fmt.Sprintf(msg, args...))
+}
+
+func (l *recordingLogger) Debugf(msg string, args ...interface{}) {
+	l.recorder.record(l.module, "DEBUG", msg, args...)
+}
+
+func (l *recordingLogger) Infof(msg string, args ...interface{}) {
+	l.recorder.record(l.module, "INFO", msg, args...)
+}
+
+func (l *recordingLogger) Warnf(msg string, args ...interface{}) {
+	l.recorder.record(l.module, "WARN", msg, args...)
+}
+
+func (l *recordingLogger) Errorf(msg string, args ...interface{}) {
+	l.recorder.record(l.module, "ERROR", msg, args...)
+}
@@ -6,9 +6,12 @@ SPDX-License-Identifier: Apache-2.0
 package provider
 
 import (
+	"github.com/hyperledger/aries-framework-go/pkg/common/scheduler"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/dispatcher"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
 )
@@ -19,6 +22,7 @@ type Provider struct {
 	ServiceErr                    error
 	ServiceMap                    map[string]interface{}
 	KMSValue                      legacykms.KeyManager
+	LocalKMSValue                 kms.KeyManager
 	ServiceEndpointValue          string
 	StorageProviderValue          storage.Provider
 	TransientStorageProviderValue storage.Provider
@@ -26,6 +30,8 @@ type Provider struct {
 	PackerValue                   packer.Packer
 	OutboundDispatcherValue       dispatcher.Outbound
 	VDRIRegistryValue             vdriapi.Registry
+	SchedulerValue                *scheduler.Scheduler
+	MessengerValue                service.Messenger
 }
 
 // Service return service
@@ -46,6 +52,11 @@ func (p *Provider) LegacyKMS() legacykms.KeyManager {
 	return p.KMSValue
 }
 
+// KMS returns a KMS instance
+func (p *Provider) KMS() kms.KeyManager {
+	return p.LocalKMSValue
+}
+
 // ServiceEndpoint returns the service endpoint
 func (p *Provider) ServiceEndpoint() string {
 	return p.ServiceEndpointValue
@@ -85,3 +96,13 @@ func (p *Provider) OutboundDispatcher() dispatcher.Outbound {
 func (p *Provider) VDRIRegistry() vdriapi.Registry {
 	return p.VDRIRegistryValue
 }
+
+// Scheduler returns the scheduler of the periodic tasks
+func (p *Provider) Scheduler() *scheduler.Scheduler {
+	return p.SchedulerValue
+}
+
+// Messenger returns the messenger
+func (p *Provider) Messenger() service.Messenger {
+	return p.MessengerValue
+}
@@ -48,6 +48,8 @@ const (
 	ECDSAP384 = "ECDSAP384"
 	// ECDSAP521 key type value
 	ECDSAP521 = "ECDSAP521"
+	// ECDSASecp256k1 key type value (signatures in the IEEE P1363 format, R || S)
+	ECDSASecp256k1 = "ECDSASecp256k1"
 	// ED25519 key type value
 	ED25519 = "ED25519"
 	// RSA key type value
@@ -74,6 +76,8 @@ const (
 	ECDSAP384Type = KeyType(ECDSAP384)
 	// ECDSAP521Type key type value
 	ECDSAP521Type = KeyType(ECDSAP521)
+	// ECDSASecp256k1Type key type value
+	ECDSASecp256k1Type = KeyType(ECDSASecp256k1)
 	// ED25519Type key type value
 	ED25519Type = KeyType(ED25519)
 	// RSAType key type value
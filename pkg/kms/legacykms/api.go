@@ -37,6 +37,16 @@ type KeyManager interface {
 	//		error in case of errors
 	DeriveKEK(alg, apu, fromPubKey, toPubKey []byte) ([]byte, error)
 
+	// DeriveSharedSecret will compute the raw X25519 shared secret (Z) using the private key fetched from
+	// the LegacyKMS corresponding to fromPubKey and the public toPubKey.
+	//
+	// This function assumes both fromPubKey and toPubKey to be on curve25519.
+	//
+	// returns:
+	// 		z []byte the shared secret, to be used as input of a key derivation function (eg ECDH-1PU)
+	//		error in case of errors
+	DeriveSharedSecret(fromPubKey, toPubKey []byte) ([]byte, error)
+
 	// FindVerKey will search the LegacyKMS to find stored keys that match any of candidateKeys and
 	// 		return the index of the first match
 	// returns:
@@ -48,6 +58,10 @@ type KeyManager interface {
 
 	// GetEncryptionKey will return the public encryption key corresponding to the public verKey argument
 	GetEncryptionKey(verKey []byte) ([]byte, error)
+
+	// DeleteKeySet deletes the key pairs set of the signature key verKey (base58 encoded) from the LegacyKMS,
+	// e.g once the key was rotated. It returns cryptoutil.ErrKeyNotFound if the key is not found.
+	DeleteKeySet(verKey string) error
 }
 
 // Signer interface provides signing capabilities
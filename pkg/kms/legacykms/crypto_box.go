@@ -11,14 +11,13 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/btcsuite/btcutil/base58"
 	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/salsa20/salsa"
 
 	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
 )
 
-// TODO: move CryptoBox out of the LegacyKMS package.
-//   this currently only sits inside LegacyKMS so it can access private keys. See issue #511
+// TODO: move CryptoBox out of the LegacyKMS package. See issue #511
 
 // CryptoBox provides an elliptic-curve-based authenticated encryption scheme
 //
@@ -26,45 +25,52 @@ import (
 // using a shared key derived from a shared secret created by
 //   Curve25519 Elliptic Curve Diffie-Hellman key exchange.
 //
-// CryptoBox is created by a LegacyKMS, and reads secret keys from the LegacyKMS
-//   for encryption/decryption, so clients do not need to see
-//   the secrets themselves.
+// CryptoBox is created by a LegacyKMS, and derives the shared secrets with the LegacyKMS
+//   for encryption/decryption, so the secret keys never leave the LegacyKMS
+//   (which can be a remote key server).
 type CryptoBox struct {
-	km *BaseKMS
+	km KeyManager
 }
 
 // NewCryptoBox creates a CryptoBox which provides crypto box encryption using the given LegacyKMS's keypairs
 func NewCryptoBox(w KeyManager) (*CryptoBox, error) {
-	wa, ok := w.(*BaseKMS)
-	if !ok {
+	if w == nil {
 		return nil, fmt.Errorf("cannot use parameter as LegacyKMS")
 	}
 
-	return &CryptoBox{km: wa}, nil
+	return &CryptoBox{km: w}, nil
+}
+
+// sharedKey computes the crypto box shared key (as box.Precompute) from the X25519 shared secret derived by
+// the LegacyKMS with the private key of myPub and theirPub
+func (b *CryptoBox) sharedKey(myPub, theirPub []byte) (*[cryptoutil.Curve25519KeySize]byte, error) {
+	z, err := b.km.DeriveSharedSecret(myPub, theirPub)
+	if err != nil {
+		return nil, err
+	}
+
+	key := new([cryptoutil.Curve25519KeySize]byte)
+	copy(key[:], z)
+
+	salsa.HSalsa20(key, new([16]byte), key, &salsa.Sigma)
+
+	return key, nil
 }
 
 // Easy seals a message with a provided nonce
 // theirPub is used as a public key, while myPub is used to identify the private key that should be used
 func (b *CryptoBox) Easy(payload, nonce, theirPub, myPub []byte) ([]byte, error) {
-	var recPubBytes [cryptoutil.Curve25519KeySize]byte
-
-	copy(recPubBytes[:], theirPub)
-
 	//	 myPub is used to get the sender private key for encryption
-	kp, err := b.km.getKeyPairSet(base58.Encode(myPub))
+	key, err := b.sharedKey(myPub, theirPub)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		priv       [cryptoutil.Curve25519KeySize]byte
-		nonceBytes [cryptoutil.NonceSize]byte
-	)
+	var nonceBytes [cryptoutil.NonceSize]byte
 
-	copy(priv[:], kp.EncKeyPair.Priv)
 	copy(nonceBytes[:], nonce)
 
-	ret := box.Seal(nil, payload, &nonceBytes, &recPubBytes, &priv)
+	ret := box.SealAfterPrecomputation(nil, payload, &nonceBytes, key)
 
 	return ret, nil
 }
@@ -73,24 +79,16 @@ func (b *CryptoBox) Easy(payload, nonce, theirPub, myPub []byte) ([]byte, error)
 // theirPub is the public key used to decrypt directly, while myPub is used to identify the private key to be used
 func (b *CryptoBox) EasyOpen(cipherText, nonce, theirPub, myPub []byte) ([]byte, error) {
 	//	 myPub is used to get the recipient private key for decryption
-	var sendPubBytes [cryptoutil.Curve25519KeySize]byte
-
-	copy(sendPubBytes[:], theirPub)
-
-	kp, err := b.km.getKeyPairSet(base58.Encode(myPub))
+	key, err := b.sharedKey(myPub, theirPub)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		priv       [cryptoutil.Curve25519KeySize]byte
-		nonceBytes [cryptoutil.NonceSize]byte
-	)
+	var nonceBytes [cryptoutil.NonceSize]byte
 
-	copy(priv[:], kp.EncKeyPair.Priv)
 	copy(nonceBytes[:], nonce)
 
-	out, success := box.Open(nil, cipherText, &nonceBytes, &sendPubBytes, &priv)
+	out, success := box.OpenAfterPrecomputation(nil, cipherText, &nonceBytes, key)
 	if !success {
 		return nil, errors.New("failed to unpack")
 	}
@@ -133,25 +131,19 @@ func (b *CryptoBox) SealOpen(cipherText, myPub []byte) ([]byte, error) {
 		return nil, errors.New("message too short")
 	}
 
-	kp, err := b.km.getKeyPairSet(base58.Encode(myPub))
+	epk := cipherText[:cryptoutil.Curve25519KeySize]
+
+	key, err := b.sharedKey(myPub, epk)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		epk  [cryptoutil.Curve25519KeySize]byte
-		priv [cryptoutil.Curve25519KeySize]byte
-	)
-
-	copy(epk[:], cipherText[:cryptoutil.Curve25519KeySize])
-	copy(priv[:], kp.EncKeyPair.Priv)
-
-	nonce, err := cryptoutil.Nonce(epk[:], myPub)
+	nonce, err := cryptoutil.Nonce(epk, myPub)
 	if err != nil {
 		return nil, err
 	}
 
-	out, success := box.Open(nil, cipherText[cryptoutil.Curve25519KeySize:], nonce, &epk, &priv)
+	out, success := box.OpenAfterPrecomputation(nil, cipherText[cryptoutil.Curve25519KeySize:], nonce, key)
 	if !success {
 		return nil, errors.New("failed to unpack")
 	}
@@ -79,7 +79,7 @@ func TestBoxSeal(t *testing.T) {
 
 		_, err := b.SealOpen(msg, base58.Decode("BADKEY23452345234523452345"))
 		require.NotNil(t, err)
-		require.EqualError(t, err, "key not found")
+		require.EqualError(t, err, "failed from getKeyPairSet: key not found")
 	})
 
 	t.Run("Failed decrypt, short message", func(t *testing.T) {
@@ -148,7 +148,7 @@ func TestBoxEasy(t *testing.T) {
 
 		_, err := b.Easy(msg, nonce, base58.Decode("BADKEY1"), base58.Decode("BADKEY2"))
 		require.NotNil(t, err)
-		require.EqualError(t, err, "key not found")
+		require.EqualError(t, err, "failed from getKeyPairSet: key not found")
 	})
 
 	t.Run("Failed decrypt, key missing from LegacyKMS", func(t *testing.T) {
@@ -156,7 +156,7 @@ func TestBoxEasy(t *testing.T) {
 
 		_, err := b.EasyOpen(msg, nonce, base58.Decode("BADKEY1"), base58.Decode("BADKEY2"))
 		require.NotNil(t, err)
-		require.EqualError(t, err, "key not found")
+		require.EqualError(t, err, "failed from getKeyPairSet: key not found")
 	})
 
 	t.Run("Failed decrypt, garbled message", func(t *testing.T) {
@@ -15,6 +15,7 @@ import (
 
 	"github.com/btcsuite/btcutil/base58"
 	chacha "golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
@@ -224,6 +225,44 @@ func (w *BaseKMS) DeriveKEK(alg, apu, fromPubKey, toPubKey []byte) ([]byte, erro
 	return cryptoutil.Derive25519KEK(alg, apu, fromPrivKey, toKey)
 }
 
+// DeriveSharedSecret will compute the raw X25519 shared secret between the private key fetched from
+// the LegacyKMS corresponding to fromPubKey and toPubKey
+// This implementation is for curve 25519 only
+func (w *BaseKMS) DeriveSharedSecret(fromPubKey, toPubKey []byte) ([]byte, error) {
+	if fromPubKey == nil || toPubKey == nil {
+		return nil, cryptoutil.ErrInvalidKey
+	}
+
+	// get key pairs combo from LegacyKMS store
+	kpc, err := w.getKeyPairSet(base58.Encode(fromPubKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed from getKeyPairSet: %w", err)
+	}
+
+	return curve25519.X25519(kpc.EncKeyPair.Priv, toPubKey)
+}
+
+// DeleteKeySet deletes the key pairs set of the signature key verKey, stored for the signature key
+// and for its encryption key.
+func (w *BaseKMS) DeleteKeySet(verKey string) error {
+	kpc, err := w.getKeyPairSet(verKey)
+	if err != nil {
+		return err
+	}
+
+	if kpc.EncKeyPair != nil {
+		if err := w.keystore.Delete(base58.Encode(kpc.EncKeyPair.Pub)); err != nil {
+			return fmt.Errorf("delete encryption key: %w", err)
+		}
+	}
+
+	if err := w.keystore.Delete(verKey); err != nil {
+		return fmt.Errorf("delete signature key: %w", err)
+	}
+
+	return nil
+}
+
 // FindVerKey selects a signing key which is present in candidateKeys that is present in the LegacyKMS
 func (w *BaseKMS) FindVerKey(candidateKeys []string) (int, error) {
 	for i, key := range candidateKeys {
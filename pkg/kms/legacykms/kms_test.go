@@ -14,6 +14,7 @@ import (
 
 	"github.com/btcsuite/btcutil/base58"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
 	"golang.org/x/crypto/nacl/box"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
@@ -202,6 +203,46 @@ func TestBaseKMS_ConvertToEncryptionKey(t *testing.T) {
 	})
 }
 
+func TestBaseKMS_DeleteKeySet(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: map[string][]byte{}}
+
+		k, err := New(newMockKMSProvider(&mockstorage.MockStoreProvider{Store: store}))
+		require.NoError(t, err)
+
+		encKey, verKey, err := k.CreateKeySet()
+		require.NoError(t, err)
+
+		other, _, err := k.CreateKeySet()
+		require.NoError(t, err)
+
+		require.NoError(t, k.DeleteKeySet(verKey))
+		require.NotContains(t, store.Store, encKey)
+		require.NotContains(t, store.Store, verKey)
+		require.Contains(t, store.Store, other)
+
+		_, err = k.SignMessage([]byte("message"), verKey)
+		require.Error(t, err)
+
+		require.Equal(t, cryptoutil.ErrKeyNotFound, k.DeleteKeySet(verKey))
+	})
+
+	t.Run("delete error", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: map[string][]byte{}}
+
+		k, err := New(newMockKMSProvider(&mockstorage.MockStoreProvider{Store: store}))
+		require.NoError(t, err)
+
+		_, verKey, err := k.CreateKeySet()
+		require.NoError(t, err)
+
+		store.ErrDelete = fmt.Errorf("delete error")
+
+		err = k.DeleteKeySet(verKey)
+		require.EqualError(t, err, "delete encryption key: delete error")
+	})
+}
+
 func TestBaseKMS_DeriveKEK(t *testing.T) {
 	pk32, sk32, err := box.GenerateKey(rand.Reader)
 	require.NoError(t, err)
@@ -270,6 +311,57 @@ func TestBaseKMS_DeriveKEK(t *testing.T) {
 	})
 }
 
+func TestBaseKMS_DeriveSharedSecret(t *testing.T) {
+	pk32, sk32, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kpCombo := &cryptoutil.MessagingKeys{
+		EncKeyPair: &cryptoutil.EncKeyPair{
+			KeyPair: cryptoutil.KeyPair{Pub: pk32[:], Priv: sk32[:]},
+			Alg:     cryptoutil.Curve25519,
+		},
+	}
+	kpm, err := json.Marshal(kpCombo)
+	require.NoError(t, err)
+
+	pk32a, sk32a, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	k, err := New(newMockKMSProvider(&mockstorage.MockStoreProvider{Store: &mockstorage.MockStore{
+		Store: map[string][]byte{
+			base58.Encode(pk32[:]): kpm,
+		},
+	}}))
+	require.NoError(t, err)
+
+	t.Run("test success", func(t *testing.T) {
+		z, e := k.DeriveSharedSecret(pk32[:], pk32a[:])
+		require.NoError(t, e)
+		require.NotEmpty(t, z)
+
+		// the other party must compute the same shared secret
+		z2, e := curve25519.X25519(sk32a[:], pk32[:])
+		require.NoError(t, e)
+		require.Equal(t, z, z2)
+	})
+
+	t.Run("test failure empty keys", func(t *testing.T) {
+		z, e := k.DeriveSharedSecret(nil, pk32a[:])
+		require.EqualError(t, e, cryptoutil.ErrInvalidKey.Error())
+		require.Empty(t, z)
+
+		z, e = k.DeriveSharedSecret(pk32[:], nil)
+		require.EqualError(t, e, cryptoutil.ErrInvalidKey.Error())
+		require.Empty(t, z)
+	})
+
+	t.Run("test failure fromPubKey not found in LegacyKMS", func(t *testing.T) {
+		z, e := k.DeriveSharedSecret(pk32a[:], pk32[:])
+		require.EqualError(t, e, "failed from getKeyPairSet: "+cryptoutil.ErrKeyNotFound.Error())
+		require.Empty(t, z)
+	})
+}
+
 func TestBaseKMS_FindVerKey(t *testing.T) {
 	pk1, sk1, err := box.GenerateKey(rand.Reader)
 	require.NoError(t, err)
@@ -16,6 +16,7 @@ import (
 	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
 	"github.com/google/tink/go/signature"
 
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/secp256k1"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms/internal/keywrapper"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
@@ -129,6 +130,15 @@ func (l *LocalKMS) Rotate(kt kms.KeyType, keyID string) (string, interface{}, er
 	return newID, updatedKH, nil
 }
 
+// Delete deletes the keyset of the given keyID, e.g once the key was replaced and is no longer used.
+func (l *LocalKMS) Delete(keyID string) error {
+	if err := l.store.Delete(keyID); err != nil {
+		return fmt.Errorf("failed to delete keyset: %w", err)
+	}
+
+	return nil
+}
+
 // nolint:gocyclo
 func getKeyTemplate(keyType kms.KeyType) (*tinkpb.KeyTemplate, error) {
 	switch keyType {
@@ -149,6 +159,8 @@ func getKeyTemplate(keyType kms.KeyType) (*tinkpb.KeyTemplate, error) {
 		return signature.ECDSAP384KeyWithoutPrefixTemplate(), nil
 	case kms.ECDSAP521Type:
 		return signature.ECDSAP521KeyWithoutPrefixTemplate(), nil
+	case kms.ECDSASecp256k1Type:
+		return secp256k1.IEEEP1363KeyWithoutPrefixTemplate(), nil
 	case kms.ED25519Type:
 		return signature.ED25519KeyWithoutPrefixTemplate(), nil
 	case kms.HMACSHA256Tag256Type:
@@ -202,6 +202,7 @@ func TestLocalKMS_Success(t *testing.T) {
 		kms.ECDSAP256Type,
 		kms.ECDSAP384Type,
 		kms.ECDSAP521Type,
+		kms.ECDSASecp256k1Type,
 		kms.ED25519Type,
 	}
 
@@ -263,9 +264,29 @@ func TestLocalKMS_Success(t *testing.T) {
 			require.NoError(t, e)
 			require.NotEmpty(t, kh)
 		}
+
+		// test Delete()
+		require.NoError(t, kmsService.Delete(newKeyID))
+		require.NotContains(t, storeDB, newKeyID)
+
+		_, e = kmsService.Get(newKeyID)
+		require.Error(t, e)
 	}
 }
 
+func TestLocalKMS_Delete_Failure(t *testing.T) {
+	kmsService, err := New(testMasterKeyURI, &mockProvider{
+		storage: mockstorage.NewCustomMockStoreProvider(&mockstorage.MockStore{
+			Store:     map[string][]byte{},
+			ErrDelete: fmt.Errorf("delete error"),
+		}),
+		secretLock: &mocksecretlock.MockSecretLock{},
+	})
+	require.NoError(t, err)
+
+	require.EqualError(t, kmsService.Delete("keyID"), "failed to delete keyset: delete error")
+}
+
 func TestLocalKMS_getKeyTemplate(t *testing.T) {
 	keyTemplate, err := getKeyTemplate(kms.HMACSHA256Tag256Type)
 	require.NoError(t, err)
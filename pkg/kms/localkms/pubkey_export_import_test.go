@@ -17,6 +17,7 @@ import (
 	"github.com/google/tink/go/signature"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/secp256k1"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 )
 
@@ -45,6 +46,12 @@ func TestPubKeyExportAndRead(t *testing.T) {
 			keyTemplate: signature.ECDSAP521KeyWithoutPrefixTemplate(),
 			doSign:      true,
 		},
+		{
+			tcName:      "export then read ECDSASecp256k1 public key",
+			keyType:     kms.ECDSASecp256k1Type,
+			keyTemplate: secp256k1.IEEEP1363KeyWithoutPrefixTemplate(),
+			doSign:      true,
+		},
 		{
 			tcName:      "export then read ED25519 public key",
 			keyType:     kms.ED25519Type,
@@ -143,6 +150,13 @@ func TestNegativeCases(t *testing.T) {
 		require.Empty(t, kh)
 	})
 
+	t.Run("test publicKeyBytesToHandle with bad pubKey and ECDSASecp256k1Type", func(t *testing.T) {
+		kh, err := publicKeyBytesToHandle([]byte{1}, kms.ECDSASecp256k1Type)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error getting marshalled proto key: invalid key")
+		require.Empty(t, kh)
+	})
+
 	t.Run("test getMarshalledECDSAKey with empty curveName", func(t *testing.T) {
 		kh, err := getMarshalledECDSAKey([]byte{},
 			"",
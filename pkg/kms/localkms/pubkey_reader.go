@@ -10,6 +10,7 @@ import (
 	"crypto/elliptic"
 	"fmt"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/keyset"
@@ -19,6 +20,7 @@ import (
 	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
 	"github.com/google/tink/go/subtle"
 
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/secp256k1"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 )
 
@@ -101,6 +103,13 @@ func getMarshalledProtoKeyAndKeyURL(pubKey []byte, kt kms.KeyType) ([]byte, stri
 		if err != nil {
 			return nil, "", err
 		}
+	case kms.ECDSASecp256k1Type:
+		tURL = secp256k1.VerifierTypeURL
+
+		keyValue, err = getMarshalledSecp256k1Key(pubKey)
+		if err != nil {
+			return nil, "", err
+		}
 	case kms.ED25519Type:
 		tURL = ed25519VerifierTypeURL
 		pubKeyProto := new(ed25519pb.Ed25519PublicKey)
@@ -144,3 +153,22 @@ func getMarshalledECDSAKey(pubKey []byte, curveName string, c commonpb.EllipticC
 
 	return proto.Marshal(pubKeyProto)
 }
+
+// getMarshalledSecp256k1Key returns the secp256k1 public key proto of the (compressed or uncompressed) key bytes,
+// verifying signatures in the IEEE P1363 format.
+func getMarshalledSecp256k1Key(pubKey []byte) ([]byte, error) {
+	key, err := btcec.ParsePubKey(pubKey, btcec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	return proto.Marshal(&ecdsapb.EcdsaPublicKey{
+		Version: 0,
+		Params: &ecdsapb.EcdsaParams{
+			Encoding: ecdsapb.EcdsaSignatureEncoding_IEEE_P1363,
+			HashType: commonpb.HashType_SHA256,
+		},
+		X: key.X.Bytes(),
+		Y: key.Y.Bytes(),
+	})
+}
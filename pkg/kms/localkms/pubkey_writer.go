@@ -13,12 +13,15 @@ import (
 	"io"
 	"math/big"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/golang/protobuf/proto"
 	commonpb "github.com/google/tink/go/proto/common_go_proto"
 	ecdsapb "github.com/google/tink/go/proto/ecdsa_go_proto"
 	ed25519pb "github.com/google/tink/go/proto/ed25519_go_proto"
 	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
 	"github.com/google/tink/go/subtle"
+
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/secp256k1"
 )
 
 const (
@@ -59,7 +62,7 @@ func write(w io.Writer, msg *tinkpb.Keyset) error {
 	for _, key := range ks {
 		if key.KeyId == primaryKID && key.Status == tinkpb.KeyStatusType_ENABLED {
 			switch key.KeyData.TypeUrl {
-			case ecdsaVerifierTypeURL, ed25519VerifierTypeURL:
+			case ecdsaVerifierTypeURL, ed25519VerifierTypeURL, secp256k1.VerifierTypeURL:
 				created, err = writePubKey(w, key)
 				if err != nil {
 					return err
@@ -108,6 +111,21 @@ func writePubKey(w io.Writer, key *tinkpb.Keyset_Key) (bool, error) {
 		pubKey.Y.SetBytes(pubKeyProto.Y)
 
 		marshaledPubKey = elliptic.Marshal(curve, pubKey.X, pubKey.Y)
+	case secp256k1.VerifierTypeURL:
+		pubKeyProto := new(ecdsapb.EcdsaPublicKey)
+
+		err := proto.Unmarshal(key.KeyData.Value, pubKeyProto)
+		if err != nil {
+			return false, err
+		}
+
+		pubKey := btcec.PublicKey{
+			Curve: btcec.S256(),
+			X:     new(big.Int).SetBytes(pubKeyProto.X),
+			Y:     new(big.Int).SetBytes(pubKeyProto.Y),
+		}
+
+		marshaledPubKey = pubKey.SerializeUncompressed()
 	case ed25519VerifierTypeURL:
 		pubKeyProto := new(ed25519pb.Ed25519PublicKey)
 
@@ -0,0 +1,274 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webkms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// LegacyKeyStoreNamespace is the store name of the index of the remote keys of the legacy kms
+	LegacyKeyStoreNamespace = "webkms_legacy"
+
+	// SharedSecretPath is the path of a key used to compute the X25519 shared secret with a public key
+	SharedSecretPath = "/sharedsecret"
+	// SignPath is the path of a key used to sign a message
+	SignPath = "/sign"
+)
+
+type sharedSecretReq struct {
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+type sharedSecretResp struct {
+	SharedSecret string `json:"sharedSecret,omitempty"`
+}
+
+type signReq struct {
+	Message string `json:"message,omitempty"`
+}
+
+type signResp struct {
+	Signature string `json:"signature,omitempty"`
+}
+
+// remoteKey is the index entry of a remote key, stored for its signature and for its encryption public keys
+type remoteKey struct {
+	KeyID  string `json:"keyID"`
+	SigPub []byte `json:"sigPub"`
+	EncPub []byte `json:"encPub"`
+}
+
+// LegacyKMS implementation of the legacykms.KMS api (used by the DIDComm packers, the DID creation and the
+// DID exchange signatures) using a remote key server.
+//
+// The key sets are ED25519 keys created in the remote keystore, their X25519 encryption keys are converted by the
+// key server: the shared secrets are computed and the messages are signed remotely, the private keys never leave
+// the key server. The public keys are indexed locally to find the key URL of a verification or encryption key.
+type LegacyKMS struct {
+	remote *RemoteKMS
+	keys   storage.Store
+}
+
+// NewLegacyKMS creates a new remote legacy kms for the keystore found at keystoreURL, the remote keys are indexed
+// in a store of the storage provider p.
+func NewLegacyKMS(keystoreURL string, client HTTPClient, p storage.Provider) (*LegacyKMS, error) {
+	keys, err := p.OpenStore(LegacyKeyStoreNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to OpenStore for '%s', cause: %w", LegacyKeyStoreNamespace, err)
+	}
+
+	return &LegacyKMS{remote: New(keystoreURL, client), keys: keys}, nil
+}
+
+// CreateKeySet creates a new ED25519 key in the remote keystore.
+// returns:
+// 		string: encryption public key base58 encoded (the X25519 key converted from the ED25519 key)
+// 		string: signature public key base58 encoded
+//		error: in case of errors
+func (l *LegacyKMS) CreateKeySet() (string, string, error) {
+	keyID, _, err := l.remote.Create(kms.ED25519Type)
+	if err != nil {
+		return "", "", err
+	}
+
+	sigPub, err := l.remote.ExportPubKeyBytes(keyID)
+	if err != nil {
+		return "", "", err
+	}
+
+	encPub, err := cryptoutil.PublicEd25519toCurve25519(sigPub)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create encPub: %w", err)
+	}
+
+	key := &remoteKey{KeyID: keyID, SigPub: sigPub, EncPub: encPub}
+
+	if err := l.index(key); err != nil {
+		return "", "", err
+	}
+
+	return base58.Encode(encPub), base58.Encode(sigPub), nil
+}
+
+// ConvertToEncryptionKey returns the X25519 public key of the remote ED25519 key verKey.
+func (l *LegacyKMS) ConvertToEncryptionKey(verKey []byte) ([]byte, error) {
+	return l.GetEncryptionKey(verKey)
+}
+
+// GetEncryptionKey will return the public encryption key corresponding to the public verKey argument
+func (l *LegacyKMS) GetEncryptionKey(verKey []byte) ([]byte, error) {
+	key, err := l.get(base58.Encode(verKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return key.EncPub, nil
+}
+
+// FindVerKey selects a signing key which is present in candidateKeys that is present in the remote keystore
+func (l *LegacyKMS) FindVerKey(candidateKeys []string) (int, error) {
+	for i, candidate := range candidateKeys {
+		_, err := l.get(candidate)
+		if err != nil {
+			if errors.Is(err, cryptoutil.ErrKeyNotFound) {
+				continue
+			}
+
+			return -1, fmt.Errorf("failed to get remote key: %w", err)
+		}
+
+		return i, nil
+	}
+
+	return -1, cryptoutil.ErrKeyNotFound
+}
+
+// DeriveKEK will derive an ephemeral symmetric key (kek) from the shared secret computed by the remote key server
+// with the private key corresponding to fromPubKey and toPubKey.
+func (l *LegacyKMS) DeriveKEK(alg, apu, fromPubKey, toPubKey []byte) ([]byte, error) {
+	z, err := l.DeriveSharedSecret(fromPubKey, toPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cryptoutil.DeriveKEKFromSharedSecret(alg, apu, z)
+}
+
+// DeriveSharedSecret will remotely compute the raw X25519 shared secret between the private key corresponding to
+// fromPubKey and toPubKey.
+func (l *LegacyKMS) DeriveSharedSecret(fromPubKey, toPubKey []byte) ([]byte, error) {
+	if fromPubKey == nil || toPubKey == nil {
+		return nil, cryptoutil.ErrInvalidKey
+	}
+
+	resp := &sharedSecretResp{}
+
+	err := l.post(base58.Encode(fromPubKey), SharedSecretPath,
+		sharedSecretReq{PublicKey: base64.URLEncoding.EncodeToString(toPubKey)}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("derive shared secret: %w", err)
+	}
+
+	z, err := base64.URLEncoding.DecodeString(resp.SharedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("derive shared secret: decode shared secret: %w", err)
+	}
+
+	return z, nil
+}
+
+// SignMessage remotely signs a message using the private key associated with a given verification key.
+func (l *LegacyKMS) SignMessage(message []byte, fromVerKey string) ([]byte, error) {
+	resp := &signResp{}
+
+	err := l.post(fromVerKey, SignPath, signReq{Message: base64.URLEncoding.EncodeToString(message)}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("sign message: %w", err)
+	}
+
+	signature, err := base64.URLEncoding.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("sign message: decode signature: %w", err)
+	}
+
+	return signature, nil
+}
+
+// DeleteKeySet removes the signature key verKey and its encryption key from the index, the key itself is managed
+// by the remote key server.
+func (l *LegacyKMS) DeleteKeySet(verKey string) error {
+	key, err := l.get(verKey)
+	if err != nil {
+		return err
+	}
+
+	if err := l.keys.Delete(base58.Encode(key.EncPub)); err != nil {
+		return fmt.Errorf("delete encryption key: %w", err)
+	}
+
+	if err := l.keys.Delete(base58.Encode(key.SigPub)); err != nil {
+		return fmt.Errorf("delete signature key: %w", err)
+	}
+
+	return nil
+}
+
+// Close the legacy kms
+func (l *LegacyKMS) Close() error {
+	return nil
+}
+
+// post sends req to the path of the remote key of pubKey (base58 encoded) and unmarshals the response into resp
+func (l *LegacyKMS) post(pubKey, path string, req, resp interface{}) error {
+	key, err := l.get(pubKey)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	_, respBody, err := Do(l.remote.httpClient, http.MethodPost, l.remote.keyURL(key.KeyID)+path, body)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(respBody, resp)
+	if err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+func (l *LegacyKMS) index(key *remoteKey) error {
+	bytes, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal remote key: %w", err)
+	}
+
+	for _, pub := range [][]byte{key.EncPub, key.SigPub} {
+		if err := l.keys.Put(base58.Encode(pub), bytes); err != nil {
+			return fmt.Errorf("failed to index remote key: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (l *LegacyKMS) get(pubKey string) (*remoteKey, error) {
+	bytes, err := l.keys.Get(pubKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, cryptoutil.ErrKeyNotFound
+		}
+
+		return nil, err
+	}
+
+	key := &remoteKey{}
+
+	err = json.Unmarshal(bytes, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal remote key: %w", err)
+	}
+
+	return key, nil
+}
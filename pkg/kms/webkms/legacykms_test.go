@@ -0,0 +1,198 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webkms_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	jwe "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/authcrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/jwe/ecdh1pu"
+	legacy "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/authcrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
+	mockwebkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/webkms"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+type packerProvider struct {
+	kms legacykms.KeyManager
+}
+
+func (p *packerProvider) LegacyKMS() legacykms.KeyManager {
+	return p.kms
+}
+
+func TestNewLegacyKMS(t *testing.T) {
+	_, err := webkms.NewLegacyKMS("https://keyserver", nil, &mockstorage.MockStoreProvider{
+		ErrOpenStoreHandle: errors.New("open error"),
+	})
+	require.EqualError(t, err, "failed to OpenStore for 'webkms_legacy', cause: open error")
+}
+
+func TestLegacyKMS(t *testing.T) {
+	server := mockwebkms.NewKeyServer()
+	defer server.Close()
+
+	k, err := webkms.NewLegacyKMS(server.KeystoreURL(), server.Client(), mem.NewProvider())
+	require.NoError(t, err)
+
+	encKey, sigKey, err := k.CreateKeySet()
+	require.NoError(t, err)
+	require.Equal(t, 1, server.KeyCount())
+
+	t.Run("encryption key", func(t *testing.T) {
+		encPub, err := k.GetEncryptionKey(base58.Decode(sigKey))
+		require.NoError(t, err)
+		require.Equal(t, encKey, base58.Encode(encPub))
+
+		encPub, err = k.ConvertToEncryptionKey(base58.Decode(sigKey))
+		require.NoError(t, err)
+		require.Equal(t, encKey, base58.Encode(encPub))
+
+		_, err = k.GetEncryptionKey([]byte("unknown"))
+		require.True(t, errors.Is(err, cryptoutil.ErrKeyNotFound))
+	})
+
+	t.Run("find verification key", func(t *testing.T) {
+		i, err := k.FindVerKey([]string{"unknown", sigKey})
+		require.NoError(t, err)
+		require.Equal(t, 1, i)
+
+		i, err = k.FindVerKey([]string{"unknown"})
+		require.True(t, errors.Is(err, cryptoutil.ErrKeyNotFound))
+		require.Equal(t, -1, i)
+	})
+
+	t.Run("sign message", func(t *testing.T) {
+		signature, err := k.SignMessage([]byte("message"), sigKey)
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(base58.Decode(sigKey), []byte("message"), signature))
+
+		_, err = k.SignMessage([]byte("message"), "unknown")
+		require.EqualError(t, err, "sign message: key not found")
+	})
+
+	t.Run("derive shared secret", func(t *testing.T) {
+		otherEnc, _, err := k.CreateKeySet()
+		require.NoError(t, err)
+
+		z1, err := k.DeriveSharedSecret(base58.Decode(encKey), base58.Decode(otherEnc))
+		require.NoError(t, err)
+
+		z2, err := k.DeriveSharedSecret(base58.Decode(otherEnc), base58.Decode(encKey))
+		require.NoError(t, err)
+		require.Equal(t, z1, z2)
+
+		kek, err := k.DeriveKEK([]byte("alg"), nil, base58.Decode(encKey), base58.Decode(otherEnc))
+		require.NoError(t, err)
+		require.Len(t, kek, 32)
+
+		_, err = k.DeriveSharedSecret(nil, base58.Decode(otherEnc))
+		require.True(t, errors.Is(err, cryptoutil.ErrInvalidKey))
+
+		_, err = k.DeriveKEK([]byte("alg"), nil, []byte("unknown"), base58.Decode(otherEnc))
+		require.EqualError(t, err, "derive shared secret: key not found")
+
+		_, err = k.DeriveSharedSecret(base58.Decode(encKey), []byte("bad key"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "remote kms returned status 400")
+	})
+
+	t.Run("delete key set", func(t *testing.T) {
+		enc, sig, err := k.CreateKeySet()
+		require.NoError(t, err)
+
+		require.NoError(t, k.DeleteKeySet(sig))
+
+		_, err = k.FindVerKey([]string{sig, enc})
+		require.True(t, errors.Is(err, cryptoutil.ErrKeyNotFound))
+
+		require.True(t, errors.Is(k.DeleteKeySet(sig), cryptoutil.ErrKeyNotFound))
+	})
+
+	require.NoError(t, k.Close())
+}
+
+func TestLegacyKMS_CreateKeySetErrors(t *testing.T) {
+	server := mockwebkms.NewKeyServer()
+	defer server.Close()
+
+	k, err := webkms.NewLegacyKMS(server.URL+"/unknown", server.Client(), mem.NewProvider())
+	require.NoError(t, err)
+
+	_, _, err = k.CreateKeySet()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "create key")
+
+	k, err = webkms.NewLegacyKMS(server.KeystoreURL(), server.Client(), &mockstorage.MockStoreProvider{
+		Store: &mockstorage.MockStore{Store: map[string][]byte{}, ErrPut: errors.New("put error")},
+	})
+	require.NoError(t, err)
+
+	_, _, err = k.CreateKeySet()
+	require.EqualError(t, err, "failed to index remote key: put error")
+}
+
+func TestLegacyKMS_PackUnpack(t *testing.T) {
+	server := mockwebkms.NewKeyServer()
+	defer server.Close()
+
+	senderKMS, err := webkms.NewLegacyKMS(server.KeystoreURL(), server.Client(), mem.NewProvider())
+	require.NoError(t, err)
+
+	recipientKMS, err := webkms.NewLegacyKMS(server.KeystoreURL(), server.Client(), mem.NewProvider())
+	require.NoError(t, err)
+
+	_, senderKey, err := senderKMS.CreateKeySet()
+	require.NoError(t, err)
+
+	_, recipientKey, err := recipientKMS.CreateKeySet()
+	require.NoError(t, err)
+
+	creators := map[string]packer.Creator{
+		"legacy authcrypt": func(p packer.Provider) (packer.Packer, error) {
+			return legacy.New(p), nil
+		},
+		"jwe authcrypt": func(p packer.Provider) (packer.Packer, error) {
+			return jwe.New(p, jwe.XC20P)
+		},
+		"ecdh-1pu": func(p packer.Provider) (packer.Packer, error) {
+			return ecdh1pu.New(p, ecdh1pu.A256GCM)
+		},
+	}
+
+	for name, create := range creators {
+		create := create
+
+		t.Run(name, func(t *testing.T) {
+			sender, err := create(&packerProvider{kms: senderKMS})
+			require.NoError(t, err)
+
+			recipient, err := create(&packerProvider{kms: recipientKMS})
+			require.NoError(t, err)
+
+			envelope, err := sender.Pack([]byte("secret message"), base58.Decode(senderKey),
+				[][]byte{base58.Decode(recipientKey)})
+			require.NoError(t, err)
+
+			env, err := recipient.Unpack(envelope)
+			require.NoError(t, err)
+			require.Equal(t, []byte("secret message"), env.Message)
+
+			_, err = sender.Unpack(envelope)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package webkms provides a kms.KeyManager implementation backed by a remote key server (WebKMS).
+// Private keys never leave the remote key server (which can be HSM-backed), key handles returned by this
+// KMS are key URLs which must be used with the remote crypto implementation found in pkg/crypto/webkms.
+//
+// The KMS manages the keys used through the crypto.Crypto interface (e.g to sign credentials and presentations),
+// the LegacyKMS manages the remote keys used by the DIDComm packers and the DID creation (see pkg/kms/legacykms).
+package webkms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+)
+
+var logger = log.New("aries-framework/kms/webkms")
+
+const (
+	// ContentType is the content type of the requests sent to the remote key server
+	ContentType = "application/json"
+	// KeystoreEndpoint is the key server endpoint used to create keystores
+	KeystoreEndpoint = "/kms/keystores"
+	// KeysPath is the path of a keystore used to create keys
+	KeysPath = "/keys"
+	// ExportPath is the path of a key used to export its public key
+	ExportPath = "/export"
+
+	// LocationHeader is the response header containing the URL of a created keystore or key
+	LocationHeader = "Location"
+)
+
+// errRotateNotSupported is returned by Rotate, key rotation is managed by the remote key server
+var errRotateNotSupported = errors.New("key rotation is not supported by the remote kms")
+
+// HTTPClient interface for the http client
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type createKeystoreReq struct {
+	Controller string `json:"controller,omitempty"`
+}
+
+type createKeyReq struct {
+	KeyType kms.KeyType `json:"keyType,omitempty"`
+}
+
+type exportKeyResp struct {
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// RemoteKMS implementation of kms.KeyManager api using a remote key server
+type RemoteKMS struct {
+	httpClient  HTTPClient
+	keystoreURL string
+}
+
+// CreateKeyStore calls the key server's create keystore REST function and returns the resulting keystoreURL value.
+func CreateKeyStore(httpClient HTTPClient, keyServerURL, controller string) (string, error) {
+	body, err := json.Marshal(createKeystoreReq{Controller: controller})
+	if err != nil {
+		return "", fmt.Errorf("create keystore: marshal request: %w", err)
+	}
+
+	header, _, err := Do(httpClient, http.MethodPost, keyServerURL+KeystoreEndpoint, body)
+	if err != nil {
+		return "", fmt.Errorf("create keystore: %w", err)
+	}
+
+	keystoreURL := header.Get(LocationHeader)
+	if keystoreURL == "" {
+		return "", errors.New("create keystore: location header is missing")
+	}
+
+	return keystoreURL, nil
+}
+
+// New creates a new remote kms for the keystore found at keystoreURL
+func New(keystoreURL string, client HTTPClient) *RemoteKMS {
+	return &RemoteKMS{
+		httpClient:  client,
+		keystoreURL: keystoreURL,
+	}
+}
+
+// Create a new key of type kt in the remote keystore.
+// Returns:
+//  - keyID of the new key
+//  - handle of the new key (the key URL, to be used with pkg/crypto/webkms)
+//  - error if failure
+func (r *RemoteKMS) Create(kt kms.KeyType) (string, interface{}, error) {
+	body, err := json.Marshal(createKeyReq{KeyType: kt})
+	if err != nil {
+		return "", nil, fmt.Errorf("create key: marshal request: %w", err)
+	}
+
+	header, _, err := Do(r.httpClient, http.MethodPost, r.keystoreURL+KeysPath, body)
+	if err != nil {
+		return "", nil, fmt.Errorf("create key: %w", err)
+	}
+
+	keyURL := header.Get(LocationHeader)
+	if keyURL == "" {
+		return "", nil, errors.New("create key: location header is missing")
+	}
+
+	return keyURL[strings.LastIndex(keyURL, "/")+1:], keyURL, nil
+}
+
+// Get the handle (key URL) of the key identified by keyID.
+// The key is not fetched, remote operations on a missing key will fail.
+func (r *RemoteKMS) Get(keyID string) (interface{}, error) {
+	return r.keyURL(keyID), nil
+}
+
+// Rotate is not supported by the remote kms, keys are managed by the remote key server.
+func (r *RemoteKMS) Rotate(kt kms.KeyType, keyID string) (string, interface{}, error) {
+	return "", nil, errRotateNotSupported
+}
+
+// ExportPubKeyBytes will fetch the public key bytes of the key identified by keyID from the remote key server.
+func (r *RemoteKMS) ExportPubKeyBytes(keyID string) ([]byte, error) {
+	_, respBody, err := Do(r.httpClient, http.MethodGet, r.keyURL(keyID)+ExportPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("export public key: %w", err)
+	}
+
+	resp := &exportKeyResp{}
+
+	err = json.Unmarshal(respBody, resp)
+	if err != nil {
+		return nil, fmt.Errorf("export public key: unmarshal response: %w", err)
+	}
+
+	pubKey, err := base64.URLEncoding.DecodeString(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("export public key: decode public key: %w", err)
+	}
+
+	return pubKey, nil
+}
+
+func (r *RemoteKMS) keyURL(keyID string) string {
+	return r.keystoreURL + KeysPath + "/" + keyID
+}
+
+// Do sends an http request with a json body to the remote key server and returns the response headers and body.
+// Any response status other than 200 OK or 201 Created is returned as an error.
+func Do(httpClient HTTPClient, method, url string, body []byte) (http.Header, []byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("build request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", ContentType)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("send request: %w", err)
+	}
+
+	defer func() {
+		if e := resp.Body.Close(); e != nil {
+			logger.Errorf("failed to close response body: %s", e)
+		}
+	}()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, nil, fmt.Errorf("remote kms returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	return resp.Header, respBody, nil
+}
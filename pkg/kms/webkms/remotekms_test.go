@@ -0,0 +1,155 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webkms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+)
+
+type mockHTTPClient struct {
+	err error
+}
+
+func (m *mockHTTPClient) Do(*http.Request) (*http.Response, error) {
+	return nil, m.err
+}
+
+func newKeyServer(t *testing.T, pubKey []byte) *httptest.Server {
+	mux := http.NewServeMux()
+
+	var server *httptest.Server
+
+	mux.HandleFunc(KeystoreEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, ContentType, r.Header.Get("Content-Type"))
+
+		req := &createKeystoreReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		require.Equal(t, "did:example:controller", req.Controller)
+
+		w.Header().Set(LocationHeader, server.URL+KeystoreEndpoint+"/ks1")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	mux.HandleFunc(KeystoreEndpoint+"/ks1"+KeysPath, func(w http.ResponseWriter, r *http.Request) {
+		req := &createKeyReq{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+
+		if req.KeyType != kms.ED25519Type {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set(LocationHeader, server.URL+KeystoreEndpoint+"/ks1"+KeysPath+"/key1")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	mux.HandleFunc(KeystoreEndpoint+"/ks1"+KeysPath+"/key1"+ExportPath, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+
+		resp, err := json.Marshal(exportKeyResp{PublicKey: base64.URLEncoding.EncodeToString(pubKey)})
+		require.NoError(t, err)
+
+		_, err = w.Write(resp)
+		require.NoError(t, err)
+	})
+
+	server = httptest.NewServer(mux)
+
+	return server
+}
+
+func TestRemoteKMS(t *testing.T) {
+	pubKey := []byte("public key")
+
+	server := newKeyServer(t, pubKey)
+	defer server.Close()
+
+	keystoreURL, err := CreateKeyStore(server.Client(), server.URL, "did:example:controller")
+	require.NoError(t, err)
+	require.Equal(t, server.URL+KeystoreEndpoint+"/ks1", keystoreURL)
+
+	remoteKMS := New(keystoreURL, server.Client())
+
+	t.Run("create key", func(t *testing.T) {
+		keyID, kh, err := remoteKMS.Create(kms.ED25519Type)
+		require.NoError(t, err)
+		require.Equal(t, "key1", keyID)
+		require.Equal(t, keystoreURL+KeysPath+"/key1", kh)
+
+		handle, err := remoteKMS.Get(keyID)
+		require.NoError(t, err)
+		require.Equal(t, kh, handle)
+	})
+
+	t.Run("create key error", func(t *testing.T) {
+		_, _, err := remoteKMS.Create(kms.AES256GCMType)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create key: remote kms returned status 400")
+	})
+
+	t.Run("export public key", func(t *testing.T) {
+		key, err := remoteKMS.ExportPubKeyBytes("key1")
+		require.NoError(t, err)
+		require.Equal(t, pubKey, key)
+
+		_, err = remoteKMS.ExportPubKeyBytes("unknown")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "export public key: remote kms returned status 404")
+	})
+
+	t.Run("rotate is not supported", func(t *testing.T) {
+		_, _, err := remoteKMS.Rotate(kms.ED25519Type, "key1")
+		require.EqualError(t, err, errRotateNotSupported.Error())
+	})
+}
+
+func TestRemoteKMSErrors(t *testing.T) {
+	t.Run("http client error", func(t *testing.T) {
+		client := &mockHTTPClient{err: errors.New("client error")}
+
+		_, err := CreateKeyStore(client, "https://keyserver", "")
+		require.EqualError(t, err, "create keystore: send request: client error")
+
+		_, _, err = New("https://keyserver/kms/keystores/ks1", client).Create(kms.ED25519Type)
+		require.EqualError(t, err, "create key: send request: client error")
+	})
+
+	t.Run("missing location header", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		_, err := CreateKeyStore(server.Client(), server.URL, "")
+		require.EqualError(t, err, "create keystore: location header is missing")
+
+		_, _, err = New(server.URL, server.Client()).Create(kms.ED25519Type)
+		require.EqualError(t, err, "create key: location header is missing")
+	})
+
+	t.Run("invalid export response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte(`{"publicKey":"!"}`))
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+
+		_, err := New(server.URL, server.Client()).ExportPubKeyBytes("key1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "export public key: decode public key")
+	})
+}
@@ -29,6 +29,9 @@ type CloseableKMS struct {
 	MockDID                  *did.Doc
 	EncryptionKeyValue       []byte
 	EncryptionKeyErr         error
+	DeriveSharedSecretValue  []byte
+	DeriveSharedSecretErr    error
+	DeleteKeySetErr          error
 }
 
 // Close previously-opened LegacyKMS, removing it if so configured.
@@ -57,6 +60,12 @@ func (m *CloseableKMS) DeriveKEK(alg, apu, fromKey, toPubKey []byte) ([]byte, er
 	return []byte(""), nil
 }
 
+// DeriveSharedSecret derives a shared secret from two keys
+// mocked to return DeriveSharedSecretValue
+func (m *CloseableKMS) DeriveSharedSecret(fromPubKey, toPubKey []byte) ([]byte, error) {
+	return m.DeriveSharedSecretValue, m.DeriveSharedSecretErr
+}
+
 // GetEncryptionKey will return the public encryption key corresponding to the public verKey argument
 func (m *CloseableKMS) GetEncryptionKey(verKey []byte) ([]byte, error) {
 	return m.EncryptionKeyValue, m.EncryptionKeyErr
@@ -66,3 +75,8 @@ func (m *CloseableKMS) GetEncryptionKey(verKey []byte) ([]byte, error) {
 func (m *CloseableKMS) ConvertToEncryptionKey(key []byte) ([]byte, error) {
 	return m.EncryptionKeyValue, m.EncryptionKeyErr
 }
+
+// DeleteKeySet deletes the key pairs set of the signature key
+func (m *CloseableKMS) DeleteKeySet(verKey string) error {
+	return m.DeleteKeySetErr
+}
@@ -0,0 +1,184 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webkms
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/hyperledger/aries-framework-go/pkg/internal/cryptoutil"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
+)
+
+const keystorePath = webkms.KeystoreEndpoint + "/ks1"
+
+// KeyServer is a mock remote key server holding ED25519 keys in a single keystore, it supports the key creation,
+// the public key export, the X25519 shared secret derivation and the signature of the keys.
+type KeyServer struct {
+	*httptest.Server
+
+	mu   sync.RWMutex
+	keys map[string]ed25519.PrivateKey
+}
+
+// NewKeyServer starts a new mock remote key server, it must be closed by the caller.
+func NewKeyServer() *KeyServer {
+	s := &KeyServer{keys: map[string]ed25519.PrivateKey{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// KeystoreURL returns the URL of the keystore of the key server
+func (s *KeyServer) KeystoreURL() string {
+	return s.URL + keystorePath
+}
+
+// KeyCount returns the number of keys created in the keystore
+func (s *KeyServer) KeyCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.keys)
+}
+
+func (s *KeyServer) handle(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, keystorePath+webkms.KeysPath)
+	if path == r.URL.Path {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if path == "" {
+		s.create(w, r)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.RLock()
+	priv, ok := s.keys[parts[0]]
+	s.mu.RUnlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var (
+		resp interface{}
+		err  error
+	)
+
+	switch "/" + parts[1] {
+	case webkms.ExportPath:
+		resp = map[string]string{"publicKey": encode(priv.Public().(ed25519.PublicKey))}
+	case webkms.SharedSecretPath:
+		resp, err = sharedSecret(r, priv)
+	case webkms.SignPath:
+		resp, err = sign(r, priv)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *KeyServer) create(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		KeyType kms.KeyType `json:"keyType"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.KeyType != kms.ED25519Type {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.Lock()
+	keyID := fmt.Sprintf("key%d", len(s.keys)+1)
+	s.keys[keyID] = priv
+	s.mu.Unlock()
+
+	w.Header().Set(webkms.LocationHeader, s.KeystoreURL()+webkms.KeysPath+"/"+keyID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func sharedSecret(r *http.Request, priv ed25519.PrivateKey) (interface{}, error) {
+	req := struct {
+		PublicKey string `json:"publicKey"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	pub, err := base64.URLEncoding.DecodeString(req.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	encPriv, err := cryptoutil.SecretEd25519toCurve25519(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	z, err := curve25519.X25519(encPriv, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"sharedSecret": encode(z)}, nil
+}
+
+func sign(r *http.Request, priv ed25519.PrivateKey) (interface{}, error) {
+	req := struct {
+		Message string `json:"message"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	msg, err := base64.URLEncoding.DecodeString(req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"signature": encode(ed25519.Sign(priv, msg))}, nil
+}
+
+func encode(b []byte) string {
+	return base64.URLEncoding.EncodeToString(b)
+}
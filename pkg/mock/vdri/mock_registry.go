@@ -26,6 +26,7 @@ type MockVDRIRegistry struct {
 	ResolveErr   error
 	ResolveValue *did.Doc
 	ResolveFunc  func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error)
+	Methods      []vdriapi.MethodCapabilities
 }
 
 // Store stores the key and the record
@@ -76,6 +77,11 @@ func (m *MockVDRIRegistry) Resolve(didID string, opts ...vdriapi.ResolveOpts) (*
 	return m.ResolveValue, nil
 }
 
+// SupportedMethods returns the mock DID methods capabilities
+func (m *MockVDRIRegistry) SupportedMethods() []vdriapi.MethodCapabilities {
+	return m.Methods
+}
+
 // Close frees resources being maintained by vdri.
 func (m *MockVDRIRegistry) Close() error {
 	return nil
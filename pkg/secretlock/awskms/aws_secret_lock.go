@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package awskms provides a secret lock service delegating the encryption of keys to a remote AWS KMS-style key
+// management service. The master key never leaves the remote service, it is referenced by the keyURI passed to
+// Encrypt/Decrypt, eg: `aws-kms://arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab`
+//
+// The framework does not depend on a cloud SDK: users must provide a Client, typically a thin adapter of the AWS SDK
+// KMS client's Encrypt and Decrypt functions.
+package awskms
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+)
+
+// URIPrefix is the prefix of the key URIs supported by this lock
+const URIPrefix = "aws-kms://"
+
+// aadContextKey is the encryption context key of the additional authenticated data
+const aadContextKey = "additionalData"
+
+var errInvalidKeyURI = errors.New("invalid key URI")
+
+// Client is the interface of an AWS KMS-style remote key management client.
+// The encryptionContext is additional authenticated data: Decrypt must fail if the context given to Decrypt differs
+// from the one given to Encrypt.
+type Client interface {
+	// Encrypt plaintext using the remote master key keyID
+	Encrypt(keyID string, plaintext []byte, encryptionContext map[string]string) ([]byte, error)
+	// Decrypt ciphertext using the remote master key keyID
+	Decrypt(keyID string, ciphertext []byte, encryptionContext map[string]string) ([]byte, error)
+}
+
+// Lock is a secret lock service using a remote AWS KMS-style master key to encrypt keys
+type Lock struct {
+	client Client
+}
+
+// New creates a new instance of an AWS KMS-style secret lock service using client
+func New(client Client) *Lock {
+	return &Lock{client: client}
+}
+
+// Encrypt a key in req using the remote master key referenced by keyURI
+func (s *Lock) Encrypt(keyURI string, req *secretlock.EncryptRequest) (*secretlock.EncryptResponse, error) {
+	keyID, err := keyIDFromURI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+
+	ct, err := s.client.Encrypt(keyID, []byte(req.Plaintext), encryptionContext(req.AdditionalAuthenticatedData))
+	if err != nil {
+		return nil, fmt.Errorf("remote encrypt: %w", err)
+	}
+
+	return &secretlock.EncryptResponse{
+		Ciphertext: base64.URLEncoding.EncodeToString(ct),
+	}, nil
+}
+
+// Decrypt a key in req using the remote master key referenced by keyURI
+func (s *Lock) Decrypt(keyURI string, req *secretlock.DecryptRequest) (*secretlock.DecryptResponse, error) {
+	keyID, err := keyIDFromURI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+
+	ct, err := base64.URLEncoding.DecodeString(req.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	pt, err := s.client.Decrypt(keyID, ct, encryptionContext(req.AdditionalAuthenticatedData))
+	if err != nil {
+		return nil, fmt.Errorf("remote decrypt: %w", err)
+	}
+
+	return &secretlock.DecryptResponse{Plaintext: string(pt)}, nil
+}
+
+func keyIDFromURI(keyURI string) (string, error) {
+	if !strings.HasPrefix(keyURI, URIPrefix) || len(keyURI) == len(URIPrefix) {
+		return "", fmt.Errorf("%w: %s", errInvalidKeyURI, keyURI)
+	}
+
+	return strings.TrimPrefix(keyURI, URIPrefix), nil
+}
+
+func encryptionContext(aad string) map[string]string {
+	if aad == "" {
+		return nil
+	}
+
+	return map[string]string{aadContextKey: aad}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package awskms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/tink/go/aead"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/tink"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+)
+
+const (
+	keyID  = "arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	keyURI = URIPrefix + keyID
+)
+
+// mockClient simulates a remote kms holding a single master key
+type mockClient struct {
+	aead tink.AEAD
+	err  error
+}
+
+func newMockClient(t *testing.T) *mockClient {
+	kh, err := keyset.NewHandle(aead.AES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	a, err := aead.New(kh)
+	require.NoError(t, err)
+
+	return &mockClient{aead: a}
+}
+
+func (m *mockClient) Encrypt(id string, plaintext []byte, ctx map[string]string) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if id != keyID {
+		return nil, errors.New("key not found")
+	}
+
+	return m.aead.Encrypt(plaintext, []byte(ctx[aadContextKey]))
+}
+
+func (m *mockClient) Decrypt(id string, ciphertext []byte, ctx map[string]string) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if id != keyID {
+		return nil, errors.New("key not found")
+	}
+
+	return m.aead.Decrypt(ciphertext, []byte(ctx[aadContextKey]))
+}
+
+func TestLock(t *testing.T) {
+	client := newMockClient(t)
+	lock := New(client)
+
+	for _, aad := range []string{"", "additional data"} {
+		enc, err := lock.Encrypt(keyURI, &secretlock.EncryptRequest{
+			Plaintext:                   "secret key",
+			AdditionalAuthenticatedData: aad,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, enc.Ciphertext)
+
+		dec, err := lock.Decrypt(keyURI, &secretlock.DecryptRequest{
+			Ciphertext:                  enc.Ciphertext,
+			AdditionalAuthenticatedData: aad,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "secret key", dec.Plaintext)
+
+		// decrypt with a different aad
+		_, err = lock.Decrypt(keyURI, &secretlock.DecryptRequest{
+			Ciphertext:                  enc.Ciphertext,
+			AdditionalAuthenticatedData: aad + "x",
+		})
+		require.Error(t, err)
+	}
+
+	t.Run("invalid key URI", func(t *testing.T) {
+		for _, uri := range []string{"", URIPrefix, "local-lock://" + keyID} {
+			_, err := lock.Encrypt(uri, &secretlock.EncryptRequest{Plaintext: "secret key"})
+			require.True(t, errors.Is(err, errInvalidKeyURI))
+
+			_, err = lock.Decrypt(uri, &secretlock.DecryptRequest{Ciphertext: "c2VjcmV0"})
+			require.True(t, errors.Is(err, errInvalidKeyURI))
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := lock.Encrypt(URIPrefix+"unknown", &secretlock.EncryptRequest{Plaintext: "secret key"})
+		require.EqualError(t, err, "remote encrypt: key not found")
+	})
+
+	t.Run("invalid cipher text", func(t *testing.T) {
+		_, err := lock.Decrypt(keyURI, &secretlock.DecryptRequest{Ciphertext: "bad{}base64URLstring[]"})
+		require.Error(t, err)
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		failing := New(&mockClient{err: errors.New("client error")})
+
+		_, err := failing.Encrypt(keyURI, &secretlock.EncryptRequest{Plaintext: "secret key"})
+		require.EqualError(t, err, "remote encrypt: client error")
+
+		_, err = failing.Decrypt(keyURI, &secretlock.DecryptRequest{Ciphertext: "c2VjcmV0"})
+		require.EqualError(t, err, "remote decrypt: client error")
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package gcpkms provides a secret lock service delegating the encryption of keys to the GCP Cloud KMS. The master
+// key never leaves the remote service, it is referenced by the keyURI passed to Encrypt/Decrypt, eg:
+// `gcp-kms://projects/my-project/locations/global/keyRings/my-key-ring/cryptoKeys/my-key`
+//
+// The framework does not depend on a cloud SDK: users must provide a Client, typically a thin adapter of the
+// Encrypt and Decrypt functions of the Cloud KMS client of the GCP SDK.
+package gcpkms
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+)
+
+// URIPrefix is the prefix of the key URIs supported by this lock
+const URIPrefix = "gcp-kms://"
+
+var errInvalidKeyURI = errors.New("invalid key URI")
+
+// Client is the interface of a GCP Cloud KMS client.
+// Decrypt must fail if the additional authenticated data given to Decrypt differs from the one given to Encrypt.
+type Client interface {
+	// Encrypt plaintext using the remote crypto key keyName
+	Encrypt(keyName string, plaintext, additionalAuthenticatedData []byte) ([]byte, error)
+	// Decrypt ciphertext using the remote crypto key keyName
+	Decrypt(keyName string, ciphertext, additionalAuthenticatedData []byte) ([]byte, error)
+}
+
+// Lock is a secret lock service using a remote GCP Cloud KMS crypto key to encrypt keys
+type Lock struct {
+	client Client
+}
+
+// New creates a new instance of a GCP Cloud KMS secret lock service using client
+func New(client Client) *Lock {
+	return &Lock{client: client}
+}
+
+// Encrypt a key in req using the remote crypto key referenced by keyURI
+func (s *Lock) Encrypt(keyURI string, req *secretlock.EncryptRequest) (*secretlock.EncryptResponse, error) {
+	keyName, err := keyNameFromURI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+
+	ct, err := s.client.Encrypt(keyName, []byte(req.Plaintext), []byte(req.AdditionalAuthenticatedData))
+	if err != nil {
+		return nil, fmt.Errorf("remote encrypt: %w", err)
+	}
+
+	return &secretlock.EncryptResponse{
+		Ciphertext: base64.URLEncoding.EncodeToString(ct),
+	}, nil
+}
+
+// Decrypt a key in req using the remote crypto key referenced by keyURI
+func (s *Lock) Decrypt(keyURI string, req *secretlock.DecryptRequest) (*secretlock.DecryptResponse, error) {
+	keyName, err := keyNameFromURI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+
+	ct, err := base64.URLEncoding.DecodeString(req.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	pt, err := s.client.Decrypt(keyName, ct, []byte(req.AdditionalAuthenticatedData))
+	if err != nil {
+		return nil, fmt.Errorf("remote decrypt: %w", err)
+	}
+
+	return &secretlock.DecryptResponse{Plaintext: string(pt)}, nil
+}
+
+func keyNameFromURI(keyURI string) (string, error) {
+	if !strings.HasPrefix(keyURI, URIPrefix) || len(keyURI) == len(URIPrefix) {
+		return "", fmt.Errorf("%w: %s", errInvalidKeyURI, keyURI)
+	}
+
+	return strings.TrimPrefix(keyURI, URIPrefix), nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gcpkms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/tink/go/aead"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/tink"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+)
+
+const (
+	keyName = "projects/my-project/locations/global/keyRings/my-key-ring/cryptoKeys/my-key"
+	keyURI  = URIPrefix + keyName
+)
+
+// mockClient simulates a remote kms holding a single crypto key
+type mockClient struct {
+	aead tink.AEAD
+	err  error
+}
+
+func newMockClient(t *testing.T) *mockClient {
+	kh, err := keyset.NewHandle(aead.AES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	a, err := aead.New(kh)
+	require.NoError(t, err)
+
+	return &mockClient{aead: a}
+}
+
+func (m *mockClient) Encrypt(name string, plaintext, aad []byte) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if name != keyName {
+		return nil, errors.New("key not found")
+	}
+
+	return m.aead.Encrypt(plaintext, aad)
+}
+
+func (m *mockClient) Decrypt(name string, ciphertext, aad []byte) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if name != keyName {
+		return nil, errors.New("key not found")
+	}
+
+	return m.aead.Decrypt(ciphertext, aad)
+}
+
+func TestLock(t *testing.T) {
+	client := newMockClient(t)
+	lock := New(client)
+
+	for _, aad := range []string{"", "additional data"} {
+		enc, err := lock.Encrypt(keyURI, &secretlock.EncryptRequest{
+			Plaintext:                   "secret key",
+			AdditionalAuthenticatedData: aad,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, enc.Ciphertext)
+
+		dec, err := lock.Decrypt(keyURI, &secretlock.DecryptRequest{
+			Ciphertext:                  enc.Ciphertext,
+			AdditionalAuthenticatedData: aad,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "secret key", dec.Plaintext)
+
+		// decrypt with a different aad
+		_, err = lock.Decrypt(keyURI, &secretlock.DecryptRequest{
+			Ciphertext:                  enc.Ciphertext,
+			AdditionalAuthenticatedData: aad + "x",
+		})
+		require.Error(t, err)
+	}
+
+	t.Run("invalid key URI", func(t *testing.T) {
+		for _, uri := range []string{"", URIPrefix, "aws-kms://" + keyName} {
+			_, err := lock.Encrypt(uri, &secretlock.EncryptRequest{Plaintext: "secret key"})
+			require.True(t, errors.Is(err, errInvalidKeyURI))
+
+			_, err = lock.Decrypt(uri, &secretlock.DecryptRequest{Ciphertext: "c2VjcmV0"})
+			require.True(t, errors.Is(err, errInvalidKeyURI))
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := lock.Encrypt(URIPrefix+"unknown", &secretlock.EncryptRequest{Plaintext: "secret key"})
+		require.EqualError(t, err, "remote encrypt: key not found")
+	})
+
+	t.Run("invalid cipher text", func(t *testing.T) {
+		_, err := lock.Decrypt(keyURI, &secretlock.DecryptRequest{Ciphertext: "bad{}base64URLstring[]"})
+		require.Error(t, err)
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		failing := New(&mockClient{err: errors.New("client error")})
+
+		_, err := failing.Encrypt(keyURI, &secretlock.EncryptRequest{Plaintext: "secret key"})
+		require.EqualError(t, err, "remote encrypt: client error")
+
+		_, err = failing.Decrypt(keyURI, &secretlock.DecryptRequest{Ciphertext: "c2VjcmV0"})
+		require.EqualError(t, err, "remote decrypt: client error")
+	})
+}
@@ -22,7 +22,8 @@ import (
 // in a local file or an environment variable prior to using this service.
 //
 // The user has the option to encrypt the master key using hkdf.NewMasterLock(passphrase, hash func(), salt)
-// found in the sub package masterlock/hkdf.
+// found in the sub package masterlock/hkdf, or to wrap it with the master key of a remote key management service
+// (AWS KMS or GCP Cloud KMS) using remote.NewMasterLock(remoteLock, keyURI) found in the sub package masterlock/remote.
 //
 // The master key must be stored (encrypted with a MasterLock or not encrypted) either in a file or in
 // an environment variable.
@@ -0,0 +1,96 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pbkdf2
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/google/tink/go/subtle/random"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+)
+
+const iterations = 1000
+
+func TestMasterLock(t *testing.T) {
+	keySize := sha256.New().Size()
+	testKey := random.GetRandomBytes(uint32(keySize))
+	goodPassphrase := "somepassphrase"
+
+	salt := make([]byte, keySize)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+
+	mkLock, err := NewMasterLock(goodPassphrase, sha256.New, iterations, salt)
+	require.NoError(t, err)
+
+	// try to create a bad master key lock (unsupported hash)
+	mkLockBad, err := NewMasterLock(goodPassphrase, sha512.New, iterations, salt)
+	require.Error(t, err)
+	require.Empty(t, mkLockBad)
+
+	encryptedMk, err := mkLock.Encrypt("", &secretlock.EncryptRequest{Plaintext: string(testKey)})
+	require.NoError(t, err)
+	require.NotEmpty(t, encryptedMk)
+
+	decryptedMk, err := mkLock.Decrypt("", &secretlock.DecryptRequest{Ciphertext: encryptedMk.Ciphertext})
+	require.NoError(t, err)
+	require.Equal(t, testKey, []byte(decryptedMk.Plaintext))
+
+	// try encrypting a key with a size different than keySize
+	badEncryptedMk, err := mkLock.Encrypt("", &secretlock.EncryptRequest{Plaintext: "BadKey"})
+	require.EqualError(t, err, "invalid key size")
+	require.Empty(t, badEncryptedMk)
+
+	// try decrypting a non valid base64URL string
+	decryptedMk, err = mkLock.Decrypt("", &secretlock.DecryptRequest{Ciphertext: "bad{}base64URLstring[]"})
+	require.Error(t, err)
+	require.Empty(t, decryptedMk)
+
+	// try decrypting a too short cipher text
+	decryptedMk, err = mkLock.Decrypt("", &secretlock.DecryptRequest{Ciphertext: "c2hvcnQ="})
+	require.EqualError(t, err, "invalid request")
+	require.Empty(t, decryptedMk)
+
+	// create a new lock instance with the same passphrase, hash, iterations and salt
+	mkLock2, err := NewMasterLock(goodPassphrase, sha256.New, iterations, salt)
+	require.NoError(t, err)
+
+	// ensure Decrypt() is successful and returns the same result as the original lock
+	decryptedMk2, err := mkLock2.Decrypt("", &secretlock.DecryptRequest{Ciphertext: encryptedMk.Ciphertext})
+	require.NoError(t, err)
+	require.Equal(t, testKey, []byte(decryptedMk2.Plaintext))
+
+	// recreate new lock with a different number of iterations
+	mkLock2, err = NewMasterLock(goodPassphrase, sha256.New, iterations+1, salt)
+	require.NoError(t, err)
+
+	decryptedMk2, err = mkLock2.Decrypt("", &secretlock.DecryptRequest{Ciphertext: encryptedMk.Ciphertext})
+	require.Error(t, err)
+	require.Empty(t, decryptedMk2)
+
+	// recreate new lock with empty salt
+	mkLock2, err = NewMasterLock(goodPassphrase, sha256.New, iterations, nil)
+	require.NoError(t, err)
+
+	decryptedMk2, err = mkLock2.Decrypt("", &secretlock.DecryptRequest{Ciphertext: encryptedMk.Ciphertext})
+	require.Error(t, err)
+	require.Empty(t, decryptedMk2)
+
+	// try creating a lock with an empty passphrase, a nil hash or invalid iterations
+	_, err = NewMasterLock("", sha256.New, iterations, salt)
+	require.EqualError(t, err, "passphrase is empty")
+
+	_, err = NewMasterLock(goodPassphrase, nil, iterations, salt)
+	require.EqualError(t, err, "hash is nil")
+
+	_, err = NewMasterLock(goodPassphrase, sha256.New, 0, salt)
+	require.EqualError(t, err, "invalid number of iterations")
+}
@@ -0,0 +1,71 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"fmt"
+
+	"github.com/google/tink/go/subtle/random"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+)
+
+// masterKeySize is the size of the master keys (AES-256)
+const masterKeySize = 32
+
+type masterLockRemote struct {
+	remote secretlock.Service
+	keyURI string
+}
+
+// NewMasterLock is responsible for encrypting/decrypting a master key using the master key referenced by `keyURI` of
+// a remote key management service, `remote` being a remote secret lock such as awskms.New(client) or
+// gcpkms.New(client). This is envelope encryption: the remote service is only called to decrypt the master key
+// when the local secret lock service is created, the keys are then encrypted locally by the master key.
+// The size of a master key passed to Encrypt() must be 32 bytes, NewEncryptedMasterKey() creates one.
+// This implementation must not be used directly in Aries framework. It should be passed in
+// as the second argument to local secret lock service constructor:
+// `local.NewService(masterKeyReader io.Reader, secLock secretlock.Service)`
+func NewMasterLock(remote secretlock.Service, keyURI string) (secretlock.Service, error) {
+	if remote == nil {
+		return nil, fmt.Errorf("remote lock is nil")
+	}
+
+	if keyURI == "" {
+		return nil, fmt.Errorf("keyURI is empty")
+	}
+
+	return &masterLockRemote{remote: remote, keyURI: keyURI}, nil
+}
+
+// NewEncryptedMasterKey creates a random master key encrypted by masterLock, to be stored in a file or
+// an environment variable read by local.MasterKeyFromPath() or local.MasterKeyFromEnv().
+func NewEncryptedMasterKey(masterLock secretlock.Service) (string, error) {
+	resp, err := masterLock.Encrypt("", &secretlock.EncryptRequest{
+		Plaintext: string(random.GetRandomBytes(masterKeySize)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("encrypt master key: %w", err)
+	}
+
+	return resp.Ciphertext, nil
+}
+
+// Encrypt a master key in req using the remote master key
+// (keyURI is set by NewMasterLock, the keyURI argument is ignored by this implementation)
+func (m *masterLockRemote) Encrypt(keyURI string, req *secretlock.EncryptRequest) (*secretlock.EncryptResponse, error) {
+	if len(req.Plaintext) != masterKeySize {
+		return nil, fmt.Errorf("invalid key size")
+	}
+
+	return m.remote.Encrypt(m.keyURI, req)
+}
+
+// Decrypt a master key in req using the remote master key
+// (keyURI is set by NewMasterLock, the keyURI argument is ignored by this implementation)
+func (m *masterLockRemote) Decrypt(keyURI string, req *secretlock.DecryptRequest) (*secretlock.DecryptResponse, error) {
+	return m.remote.Decrypt(m.keyURI, req)
+}
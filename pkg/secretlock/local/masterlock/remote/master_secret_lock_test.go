@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/tink/go/aead"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/tink"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/awskms"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/gcpkms"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
+)
+
+// mockKMS simulates a remote kms holding a single master key, it counts the calls
+type mockKMS struct {
+	aead  tink.AEAD
+	calls int
+	err   error
+}
+
+func newMockKMS(t *testing.T) *mockKMS {
+	kh, err := keyset.NewHandle(aead.AES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	a, err := aead.New(kh)
+	require.NoError(t, err)
+
+	return &mockKMS{aead: a}
+}
+
+func (m *mockKMS) encrypt(plaintext, aad []byte) ([]byte, error) {
+	m.calls++
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.aead.Encrypt(plaintext, aad)
+}
+
+func (m *mockKMS) decrypt(ciphertext, aad []byte) ([]byte, error) {
+	m.calls++
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.aead.Decrypt(ciphertext, aad)
+}
+
+// awsClient is the AWS KMS client of the mock kms
+type awsClient struct{ *mockKMS }
+
+func (c *awsClient) Encrypt(_ string, plaintext []byte, ctx map[string]string) ([]byte, error) {
+	return c.encrypt(plaintext, []byte(ctx["additionalData"]))
+}
+
+func (c *awsClient) Decrypt(_ string, ciphertext []byte, ctx map[string]string) ([]byte, error) {
+	return c.decrypt(ciphertext, []byte(ctx["additionalData"]))
+}
+
+// gcpClient is the GCP Cloud KMS client of the mock kms
+type gcpClient struct{ *mockKMS }
+
+func (c *gcpClient) Encrypt(_ string, plaintext, aad []byte) ([]byte, error) {
+	return c.encrypt(plaintext, aad)
+}
+
+func (c *gcpClient) Decrypt(_ string, ciphertext, aad []byte) ([]byte, error) {
+	return c.decrypt(ciphertext, aad)
+}
+
+func TestMasterLock(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote func(*mockKMS) secretlock.Service
+		keyURI string
+	}{
+		{
+			name:   "AWS KMS",
+			remote: func(m *mockKMS) secretlock.Service { return awskms.New(&awsClient{m}) },
+			keyURI: awskms.URIPrefix + "arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		},
+		{
+			name:   "GCP KMS",
+			remote: func(m *mockKMS) secretlock.Service { return gcpkms.New(&gcpClient{m}) },
+			keyURI: gcpkms.URIPrefix + "projects/p/locations/global/keyRings/r/cryptoKeys/k",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			kms := newMockKMS(t)
+
+			mkLock, err := NewMasterLock(tc.remote(kms), tc.keyURI)
+			require.NoError(t, err)
+
+			encryptedMk, err := NewEncryptedMasterKey(mkLock)
+			require.NoError(t, err)
+			require.Equal(t, 1, kms.calls)
+
+			lock, err := local.NewService(bytes.NewReader([]byte(encryptedMk)), mkLock)
+			require.NoError(t, err)
+			require.Equal(t, 2, kms.calls)
+
+			enc, err := lock.Encrypt("", &secretlock.EncryptRequest{Plaintext: "secret key"})
+			require.NoError(t, err)
+
+			// the keys are encrypted locally
+			require.Equal(t, 2, kms.calls)
+
+			// a new lock service unwraps the same master key
+			lock, err = local.NewService(bytes.NewReader([]byte(encryptedMk)), mkLock)
+			require.NoError(t, err)
+
+			dec, err := lock.Decrypt("", &secretlock.DecryptRequest{Ciphertext: enc.Ciphertext})
+			require.NoError(t, err)
+			require.Equal(t, "secret key", dec.Plaintext)
+		})
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := NewMasterLock(nil, "aws-kms://key")
+		require.EqualError(t, err, "remote lock is nil")
+
+		_, err = NewMasterLock(awskms.New(&awsClient{newMockKMS(t)}), "")
+		require.EqualError(t, err, "keyURI is empty")
+	})
+
+	t.Run("invalid key size", func(t *testing.T) {
+		mkLock, err := NewMasterLock(awskms.New(&awsClient{newMockKMS(t)}), "aws-kms://key")
+		require.NoError(t, err)
+
+		_, err = mkLock.Encrypt("", &secretlock.EncryptRequest{Plaintext: "BadKey"})
+		require.EqualError(t, err, "invalid key size")
+	})
+
+	t.Run("remote error", func(t *testing.T) {
+		kms := newMockKMS(t)
+		kms.err = errors.New("remote error")
+
+		mkLock, err := NewMasterLock(gcpkms.New(&gcpClient{kms}), gcpkms.URIPrefix+"key")
+		require.NoError(t, err)
+
+		_, err = NewEncryptedMasterKey(mkLock)
+		require.True(t, errors.Is(err, kms.err))
+
+		_, err = local.NewService(bytes.NewReader([]byte("c2VjcmV0")), mkLock)
+		require.True(t, errors.Is(err, kms.err))
+	})
+}
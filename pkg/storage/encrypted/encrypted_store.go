@@ -0,0 +1,156 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package encrypted provides a storage.Provider wrapper encrypting the stored values at rest using a secret lock
+// service (eg: a local secret lock protected by a passphrase or a remote AWS KMS-style lock).
+//
+// Keys are stored in plain text to keep iterators working, values are encrypted with the master key referenced by
+// keyURI and the store name and key are used as additional authenticated data, so values can't be swapped between
+// keys, nor copied from one store to another.
+package encrypted
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+// Provider encrypted implementation of storage.Provider interface wrapping another storage.Provider
+type Provider struct {
+	provider storage.Provider
+	lock     secretlock.Service
+	keyURI   string
+}
+
+// NewProvider instantiates Provider wrapping p. Values are encrypted by lock using the master key found at keyURI.
+func NewProvider(p storage.Provider, lock secretlock.Service, keyURI string) *Provider {
+	return &Provider{
+		provider: p,
+		lock:     lock,
+		keyURI:   keyURI,
+	}
+}
+
+// OpenStore opens and returns an encrypted store for given name space.
+func (p *Provider) OpenStore(name string) (storage.Store, error) {
+	store, err := p.provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedStore{store: store, name: name, lock: p.lock, keyURI: p.keyURI}, nil
+}
+
+// CloseStore closes store of given name space
+func (p *Provider) CloseStore(name string) error {
+	return p.provider.CloseStore(name)
+}
+
+// Close closes all stores created under this store provider
+func (p *Provider) Close() error {
+	return p.provider.Close()
+}
+
+// Compact compacts the stores of the wrapped provider if it reclaims the space of the deleted records
+// (see storage.Compactor), it does nothing otherwise.
+func (p *Provider) Compact() error {
+	if compactor, ok := p.provider.(storage.Compactor); ok {
+		return compactor.Compact()
+	}
+
+	return nil
+}
+
+type encryptedStore struct {
+	store  storage.Store
+	name   string
+	lock   secretlock.Service
+	keyURI string
+}
+
+// Put encrypts the record and stores it with the key
+func (s *encryptedStore) Put(k string, v []byte) error {
+	enc, err := s.lock.Encrypt(s.keyURI, &secretlock.EncryptRequest{
+		Plaintext:                   string(v),
+		AdditionalAuthenticatedData: s.aad(k),
+	})
+	if err != nil {
+		return fmt.Errorf("encrypt record: %w", err)
+	}
+
+	return s.store.Put(k, []byte(enc.Ciphertext))
+}
+
+// Get fetches the record based on key and decrypts it
+func (s *encryptedStore) Get(k string) ([]byte, error) {
+	v, err := s.store.Get(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decrypt(k, v)
+}
+
+// Iterator returns an iterator decrypting the records of the underlying store
+func (s *encryptedStore) Iterator(startKey, endKey string) storage.StoreIterator {
+	return &encryptedIterator{StoreIterator: s.store.Iterator(startKey, endKey), store: s}
+}
+
+// Delete will delete a record with k key
+func (s *encryptedStore) Delete(k string) error {
+	return s.store.Delete(k)
+}
+
+func (s *encryptedStore) decrypt(k string, v []byte) ([]byte, error) {
+	dec, err := s.lock.Decrypt(s.keyURI, &secretlock.DecryptRequest{
+		Ciphertext:                  string(v),
+		AdditionalAuthenticatedData: s.aad(k),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decrypt record: %w", err)
+	}
+
+	return []byte(dec.Plaintext), nil
+}
+
+// aad returns the additional authenticated data of the record with the key k: the store name and the key,
+// separated by a NUL byte so that distinct (name, key) pairs never share the same data.
+func (s *encryptedStore) aad(k string) string {
+	return s.name + "\x00" + k
+}
+
+type encryptedIterator struct {
+	storage.StoreIterator
+	store *encryptedStore
+	err   error
+}
+
+// Value returns the decrypted value of the current key/value pair, or nil if done or if the decryption failed.
+// Decryption errors are returned by Error().
+func (i *encryptedIterator) Value() []byte {
+	v := i.StoreIterator.Value()
+	if v == nil {
+		return nil
+	}
+
+	dec, err := i.store.decrypt(string(i.StoreIterator.Key()), v)
+	if err != nil {
+		i.err = err
+		return nil
+	}
+
+	return dec
+}
+
+// Error returns any accumulated error, including decryption errors.
+func (i *encryptedIterator) Error() error {
+	if i.err != nil {
+		return i.err
+	}
+
+	return i.StoreIterator.Error()
+}
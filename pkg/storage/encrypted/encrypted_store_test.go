@@ -0,0 +1,174 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encrypted
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/tink/go/subtle/random"
+	"github.com/stretchr/testify/require"
+
+	mocksecretlock "github.com/hyperledger/aries-framework-go/pkg/mock/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
+	spi "github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const keyURI = "local-lock://test/master/key/"
+
+func newLock(t *testing.T) secretlock.Service {
+	masterKey := base64.URLEncoding.EncodeToString(random.GetRandomBytes(32))
+
+	lock, err := local.NewService(bytes.NewReader([]byte(masterKey)), nil)
+	require.NoError(t, err)
+
+	return lock
+}
+
+func TestEncryptedStore(t *testing.T) {
+	memProvider := mem.NewProvider()
+	provider := NewProvider(memProvider, newLock(t), keyURI)
+
+	store, err := provider.OpenStore("test")
+	require.NoError(t, err)
+
+	rawStore, err := memProvider.OpenStore("test")
+	require.NoError(t, err)
+
+	t.Run("put/get", func(t *testing.T) {
+		require.NoError(t, store.Put("key1", []byte("value1")))
+
+		raw, err := rawStore.Get("key1")
+		require.NoError(t, err)
+		require.NotContains(t, string(raw), "value1")
+
+		v, err := store.Get("key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), v)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := store.Get("unknown")
+		require.True(t, errors.Is(err, spi.ErrDataNotFound))
+	})
+
+	t.Run("swapped values are rejected", func(t *testing.T) {
+		require.NoError(t, store.Put("key2", []byte("value2")))
+
+		raw, err := rawStore.Get("key2")
+		require.NoError(t, err)
+		require.NoError(t, rawStore.Put("key3", raw))
+
+		_, err = store.Get("key3")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decrypt record")
+	})
+
+	t.Run("values copied from another store are rejected", func(t *testing.T) {
+		other, err := provider.OpenStore("other")
+		require.NoError(t, err)
+
+		rawOther, err := memProvider.OpenStore("other")
+		require.NoError(t, err)
+
+		require.NoError(t, other.Put("key4", []byte("other value")))
+
+		raw, err := rawOther.Get("key4")
+		require.NoError(t, err)
+		require.NoError(t, rawStore.Put("key4", raw))
+
+		_, err = store.Get("key4")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decrypt record")
+
+		// the copied value is still decrypted by its own store
+		v, err := other.Get("key4")
+		require.NoError(t, err)
+		require.Equal(t, []byte("other value"), v)
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		require.NoError(t, store.Put("it_1", []byte("a")))
+		require.NoError(t, store.Put("it_2", []byte("b")))
+
+		itr := store.Iterator("it_", "it_~")
+		defer itr.Release()
+
+		var values []string
+		for itr.Next() {
+			values = append(values, string(itr.Value()))
+		}
+
+		require.NoError(t, itr.Error())
+		require.Equal(t, []string{"a", "b"}, values)
+	})
+
+	t.Run("iterator decryption error", func(t *testing.T) {
+		require.NoError(t, rawStore.Put("bad_1", []byte("not encrypted")))
+
+		itr := store.Iterator("bad_", "bad_~")
+		defer itr.Release()
+
+		require.True(t, itr.Next())
+		require.Nil(t, itr.Value())
+		require.Error(t, itr.Error())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete("key1"))
+
+		_, err := store.Get("key1")
+		require.True(t, errors.Is(err, spi.ErrDataNotFound))
+	})
+
+	require.NoError(t, provider.CloseStore("test"))
+	require.NoError(t, provider.Close())
+}
+
+func TestEncryptedStoreErrors(t *testing.T) {
+	t.Run("open store error", func(t *testing.T) {
+		provider := NewProvider(&storage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+			newLock(t), keyURI)
+
+		_, err := provider.OpenStore("test")
+		require.EqualError(t, err, "open error")
+	})
+
+	t.Run("encryption error", func(t *testing.T) {
+		lock := &mocksecretlock.MockSecretLock{ErrEncrypt: errors.New("encrypt error")}
+
+		store, err := NewProvider(mem.NewProvider(), lock, keyURI).OpenStore("test")
+		require.NoError(t, err)
+
+		err = store.Put("key", []byte("value"))
+		require.EqualError(t, err, "encrypt record: encrypt error")
+	})
+}
+
+type compactingProvider struct {
+	spi.Provider
+	compacted int
+}
+
+func (p *compactingProvider) Compact() error {
+	p.compacted++
+	return nil
+}
+
+func TestEncryptedStoreCompact(t *testing.T) {
+	// the stores of a provider not reclaiming the space of the deleted records are not compacted
+	require.NoError(t, NewProvider(mem.NewProvider(), newLock(t), keyURI).Compact())
+
+	compacting := &compactingProvider{Provider: mem.NewProvider()}
+	require.NoError(t, NewProvider(compacting, newLock(t), keyURI).Compact())
+	require.Equal(t, 1, compacting.compacted)
+}
@@ -94,6 +94,20 @@ func (p *Provider) Close() error {
 	return nil
 }
 
+// Compact compacts the level db stores opened by the provider, reclaiming the space of the deleted records
+func (p *Provider) Compact() error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	for name, store := range p.dbs {
+		if err := store.db.CompactRange(util.Range{}); err != nil {
+			return fmt.Errorf("compact store %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
 // CloseStore closes level db store of given name
 func (p *Provider) CloseStore(name string) error {
 	p.lock.Lock()
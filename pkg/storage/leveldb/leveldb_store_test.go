@@ -9,6 +9,7 @@ SPDX-License-Identifier: Apache-2.0
 package leveldb
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -294,3 +295,32 @@ func TestLevelDBStoreDelete(t *testing.T) {
 	require.EqualError(t, err, storage.ErrDataNotFound.Error())
 	require.Empty(t, doc)
 }
+
+func TestLevelDBStoreCompact(t *testing.T) {
+	path, cleanup := setupLevelDB(t)
+	defer cleanup()
+
+	prov := NewProvider(path)
+	require.Implements(t, (*storage.Compactor)(nil), prov)
+
+	store, err := prov.OpenStore("store1")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put("k1", []byte("v1")))
+	require.NoError(t, store.Put("k2", []byte("v2")))
+	require.NoError(t, store.Delete("k1"))
+
+	require.NoError(t, prov.Compact())
+
+	_, err = store.Get("k1")
+	require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+	v, err := store.Get("k2")
+	require.NoError(t, err)
+	require.Equal(t, []byte("v2"), v)
+
+	require.NoError(t, prov.Close())
+
+	// closed stores are not compacted
+	require.NoError(t, prov.Compact())
+}
@@ -0,0 +1,508 @@
+// +build !js,!wasm
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const fakeDriverName = "fakesql"
+
+// fakeDriver is an in-memory database/sql driver understanding the statements of this package only,
+// for these unit tests to run without a PostgreSQL or MySQL instance.
+type fakeDriver struct {
+	sync.Mutex
+	dbs map[string]*fakeDB
+}
+
+type fakeDB struct {
+	sync.Mutex
+	tables map[string]map[string][]byte
+	// statements containing failOn fail
+	failOn string
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	if name == "unreachable" {
+		return nil, errors.New("connection refused")
+	}
+
+	db, ok := d.dbs[name]
+	if !ok {
+		db = &fakeDB{tables: map[string]map[string][]byte{}}
+		d.dbs[name] = db
+	}
+
+	return &fakeConn{db: db}, nil
+}
+
+//nolint:gochecknoglobals
+var testDriver = &fakeDriver{dbs: map[string]*fakeDB{}}
+
+//nolint:gochecknoinits
+func init() {
+	sql.Register(fakeDriverName, testDriver)
+}
+
+type fakeConn struct {
+	db      *fakeDB
+	pending []func()
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	c.pending = nil
+
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.Lock()
+	defer c.db.Unlock()
+
+	for _, op := range c.pending {
+		op()
+	}
+
+	c.inTx, c.pending = false, nil
+
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.inTx, c.pending = false, nil
+
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+// table returns the table name, following the FROM, INTO or EXISTS keyword
+func (s *fakeStmt) table() string {
+	fields := strings.Fields(s.query)
+
+	for i, f := range fields {
+		if (f == "FROM" || f == "INTO" || f == "EXISTS") && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+
+	return ""
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+
+	db.Lock()
+	defer db.Unlock()
+
+	if db.failOn != "" && strings.Contains(s.query, db.failOn) {
+		return nil, errors.New("exec error")
+	}
+
+	table := s.table()
+
+	var op func()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+		if _, ok := db.tables[table]; !ok {
+			db.tables[table] = map[string][]byte{}
+		}
+
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "INSERT INTO"):
+		k, v := args[0].(string), args[1].([]byte)
+		op = func() { db.tables[table][k] = v }
+	case strings.HasPrefix(s.query, "DELETE FROM"):
+		k := args[0].(string)
+		op = func() { delete(db.tables[table], k) }
+	default:
+		return nil, fmt.Errorf("unexpected statement: %s", s.query)
+	}
+
+	if s.conn.inTx {
+		s.conn.pending = append(s.conn.pending, op)
+	} else {
+		op()
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.conn.db
+
+	db.Lock()
+	defer db.Unlock()
+
+	if db.failOn != "" && strings.Contains(s.query, db.failOn) {
+		return nil, errors.New("query error")
+	}
+
+	table := db.tables[s.table()]
+
+	if strings.HasPrefix(s.query, "SELECT store_value") {
+		rows := &fakeRows{columns: []string{"store_value"}}
+
+		if v, ok := table[args[0].(string)]; ok {
+			rows.values = [][]driver.Value{{v}}
+		}
+
+		return rows, nil
+	}
+
+	start, end := args[0].(string), args[1].(string)
+	rows := &fakeRows{columns: []string{"store_key", "store_value"}}
+
+	for k, v := range table {
+		if k >= start && k < end {
+			rows.values = append(rows.values, []driver.Value{[]byte(k), v})
+		}
+	}
+
+	sort.Slice(rows.values, func(i, j int) bool {
+		return string(rows.values[i][0].([]byte)) < string(rows.values[j][0].([]byte))
+	})
+
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
+
+func newProvider(t *testing.T, dsn string, opts ...Option) *Provider {
+	prov, err := NewProvider(fakeDriverName, dsn, append([]Option{WithDialect(Postgres)}, opts...)...)
+	require.NoError(t, err)
+
+	return prov
+}
+
+func TestSQLStore(t *testing.T) {
+	prov := newProvider(t, t.Name(), WithDBPrefix("aries"), WithMaxOpenConns(5), WithMaxIdleConns(2))
+
+	store, err := prov.OpenStore("test-store")
+	require.NoError(t, err)
+
+	t.Run("put and get", func(t *testing.T) {
+		require.NoError(t, store.Put("key1", []byte("value1")))
+		require.NoError(t, store.Put("key1", []byte("value2")))
+
+		v, err := store.Get("key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value2"), v)
+
+		require.EqualError(t, store.Put("", []byte("value")), "key and value are mandatory")
+		require.EqualError(t, store.Put("key", nil), "key and value are mandatory")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := store.Get("unknown")
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Put("key2", []byte("value")))
+		require.NoError(t, store.Delete("key2"))
+
+		_, err := store.Get("key2")
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		require.EqualError(t, store.Delete(""), "key is mandatory")
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		require.NoError(t, store.Put("abc_2", []byte("v2")))
+		require.NoError(t, store.Put("abc_1", []byte("v1")))
+		require.NoError(t, store.Put("abd_1", []byte("other")))
+
+		itr := store.Iterator("abc_", "abc_"+storage.EndKeySuffix)
+		defer itr.Release()
+
+		var keys, values []string
+
+		for itr.Next() {
+			keys = append(keys, string(itr.Key()))
+			values = append(values, string(itr.Value()))
+		}
+
+		require.NoError(t, itr.Error())
+		require.Equal(t, []string{"abc_1", "abc_2"}, keys)
+		require.Equal(t, []string{"v1", "v2"}, values)
+		require.Nil(t, itr.Key())
+		require.Nil(t, itr.Value())
+
+		// release can be called multiple times
+		itr.Release()
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		sqlStore, ok := store.(*SQLStore)
+		require.True(t, ok)
+
+		require.NoError(t, store.Put("batch_3", []byte("v3")))
+
+		err := sqlStore.Batch([]Operation{
+			{Key: "batch_1", Value: []byte("v1")},
+			{Key: "batch_2", Value: []byte("v2")},
+			{Key: "batch_3"},
+		})
+		require.NoError(t, err)
+
+		v, err := store.Get("batch_2")
+		require.NoError(t, err)
+		require.Equal(t, []byte("v2"), v)
+
+		_, err = store.Get("batch_3")
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		// nothing is applied if an operation fails
+		err = sqlStore.Batch([]Operation{{Key: "batch_4", Value: []byte("v4")}, {Value: []byte("v")}})
+		require.EqualError(t, err, "key is mandatory")
+
+		_, err = store.Get("batch_4")
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+	})
+
+	t.Run("data is shared across providers", func(t *testing.T) {
+		other := newProvider(t, t.Name()[:strings.Index(t.Name(), "/")], WithDBPrefix("aries"))
+
+		otherStore, err := other.OpenStore("test-store")
+		require.NoError(t, err)
+
+		v, err := otherStore.Get("key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value2"), v)
+
+		require.NoError(t, other.Close())
+	})
+
+	t.Run("open cached store", func(t *testing.T) {
+		cached, err := prov.OpenStore("test-store")
+		require.NoError(t, err)
+		require.Equal(t, store, cached)
+
+		require.NoError(t, prov.CloseStore("test-store"))
+		require.NotContains(t, prov.dbs, `"aries_test-store"`)
+	})
+
+	t.Run("distinct names don't share a table", func(t *testing.T) {
+		stores := make([]storage.Store, 0, 4)
+
+		for _, name := range []string{"a-b", "a.b", "a_b", "A-B"} {
+			s, err := prov.OpenStore(name)
+			require.NoError(t, err)
+			require.NoError(t, s.Put("key", []byte(name)))
+
+			stores = append(stores, s)
+		}
+
+		for i, name := range []string{"a-b", "a.b", "a_b", "A-B"} {
+			v, err := stores[i].Get("key")
+			require.NoError(t, err)
+			require.Equal(t, []byte(name), v)
+		}
+	})
+
+	t.Run("names quoted as identifiers", func(t *testing.T) {
+		s, err := prov.OpenStore(`1st"store`)
+		require.NoError(t, err)
+		require.Contains(t, s.(*SQLStore).get, `FROM "aries_1st""store" WHERE`)
+
+		mysql := &Provider{dialect: MySQL}
+
+		table, err := mysql.tableName("1st`store")
+		require.NoError(t, err)
+		require.Equal(t, "`1st``store`", table)
+	})
+
+	t.Run("invalid names rejected", func(t *testing.T) {
+		_, err := prov.OpenStore(strings.Repeat("a", maxTableNameLength))
+		require.EqualError(t, err, "table name aries_"+strings.Repeat("a", maxTableNameLength)+" exceeds 63 bytes")
+
+		_, err = prov.OpenStore("nul\x00")
+		require.EqualError(t, err, `table name "aries_nul\x00" is not a valid identifier`)
+
+		_, err = prov.OpenStore("trailing ")
+		require.EqualError(t, err, `table name "aries_trailing " is not a valid identifier`)
+
+		_, err = (&Provider{}).OpenStore("")
+		require.EqualError(t, err, "store name is mandatory")
+
+		require.EqualError(t, prov.CloseStore("nul\x00"), `table name "aries_nul\x00" is not a valid identifier`)
+	})
+
+	require.NoError(t, prov.Close())
+}
+
+func TestSQLStoreErrors(t *testing.T) {
+	prov := newProvider(t, t.Name())
+
+	store, err := prov.OpenStore("test")
+	require.NoError(t, err)
+
+	fail := func(statement string) {
+		testDriver.dbs[t.Name()].failOn = statement
+	}
+
+	t.Run("put error", func(t *testing.T) {
+		fail("INSERT")
+		defer fail("")
+
+		err := store.Put("key", []byte("value"))
+		require.EqualError(t, err, "failed to put value: exec error")
+
+		err = store.(*SQLStore).Batch([]Operation{{Key: "key", Value: []byte("value")}})
+		require.EqualError(t, err, "failed to apply operation on key key: exec error")
+	})
+
+	t.Run("get error", func(t *testing.T) {
+		fail("SELECT store_value")
+		defer fail("")
+
+		_, err := store.Get("key")
+		require.EqualError(t, err, "failed to get value: query error")
+	})
+
+	t.Run("delete error", func(t *testing.T) {
+		fail("DELETE")
+		defer fail("")
+
+		err := store.Delete("key")
+		require.EqualError(t, err, "failed to delete value: exec error")
+	})
+
+	t.Run("iterator error", func(t *testing.T) {
+		fail("SELECT store_key")
+		defer fail("")
+
+		itr := store.Iterator("a", "b")
+		require.False(t, itr.Next())
+		require.EqualError(t, itr.Error(), "failed to query keys: query error")
+		itr.Release()
+	})
+
+	t.Run("create table error", func(t *testing.T) {
+		fail("CREATE TABLE")
+		defer fail("")
+
+		_, err := prov.OpenStore("other")
+		require.EqualError(t, err, `failed to create table "other": exec error`)
+	})
+
+	require.NoError(t, prov.Close())
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Run("blank driver name", func(t *testing.T) {
+		_, err := NewProvider("", "")
+		require.EqualError(t, err, blankDriverErrMsg)
+	})
+
+	t.Run("dialect derived from driver name", func(t *testing.T) {
+		d, err := dialectFor("postgres")
+		require.NoError(t, err)
+		require.Equal(t, Postgres, d)
+
+		d, err = dialectFor("mysql")
+		require.NoError(t, err)
+		require.Equal(t, MySQL, d)
+
+		_, err = NewProvider(fakeDriverName, "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unable to derive the SQL dialect of driver fakesql")
+	})
+
+	t.Run("unsupported dialect", func(t *testing.T) {
+		_, err := NewProvider(fakeDriverName, "", WithDialect(Dialect(10)))
+		require.EqualError(t, err, "unsupported SQL dialect: 10")
+	})
+
+	t.Run("unknown driver", func(t *testing.T) {
+		_, err := NewProvider("unknown", "", WithDialect(MySQL))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open database")
+	})
+
+	t.Run("unreachable database", func(t *testing.T) {
+		_, err := NewProvider(fakeDriverName, "unreachable", WithDialect(MySQL))
+		require.EqualError(t, err, "failed to ping database: connection refused")
+	})
+
+	t.Run("mysql statements", func(t *testing.T) {
+		prov := newProvider(t, t.Name(), WithDialect(MySQL))
+
+		store, err := prov.OpenStore("test")
+		require.NoError(t, err)
+		require.Contains(t, store.(*SQLStore).put, "ON DUPLICATE KEY UPDATE")
+
+		require.NoError(t, store.Put("key", []byte("value")))
+
+		v, err := store.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), v)
+
+		require.NoError(t, prov.Close())
+	})
+}
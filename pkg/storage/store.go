@@ -26,6 +26,13 @@ type Provider interface {
 	Close() error
 }
 
+// Compactor is implemented by the storage providers reclaiming the space of the deleted records on demand
+// (e.g leveldb), the framework compacts them periodically.
+type Compactor interface {
+	// Compact compacts all the stores opened by the provider
+	Compact() error
+}
+
 // Store is the storage interface
 type Store interface {
 	// Put stores the key and the record
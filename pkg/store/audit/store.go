@@ -0,0 +1,303 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// NameSpace for the audit store
+	NameSpace = "audit"
+
+	headKey          = "head"
+	recordKeyPattern = "record_%020d"
+)
+
+// ErrTampered signals that a record of the audit log was modified, removed or inserted.
+var ErrTampered = errors.New("audit log tampered")
+
+// Record is the tamper-evident record of a completed exchange. The records are chained: the hash of a record
+// covers the hash of the previous one, a modified, removed or inserted record breaks the chain.
+type Record struct {
+	// Sequence of the record in the log, starting at 1
+	Sequence uint64 `json:"sequence"`
+	// Protocol of the exchange, e.g presentproof.Name
+	Protocol string `json:"protocol"`
+	// ThreadID of the exchange
+	ThreadID string `json:"thread_id"`
+	MyDID    string `json:"my_did,omitempty"`
+	TheirDID string `json:"their_did,omitempty"`
+	// RequestHashes are the SHA-256 hashes (hex) of the requested attachments, e.g the requested presentations
+	RequestHashes []string `json:"request_hashes,omitempty"`
+	// ResponseHashes are the SHA-256 hashes (hex) of the provided attachments, e.g the presentations
+	ResponseHashes []string `json:"response_hashes,omitempty"`
+	// Verified tells if the received attachments were verified, nil if the agent provided the attachments
+	// (e.g the Prover of a presentation)
+	Verified *bool `json:"verified,omitempty"`
+	// Errors describes why the attachments were not verified, or why the exchange was abandoned
+	Errors []string `json:"errors,omitempty"`
+	// Abandoned is true if the exchange did not complete successfully
+	Abandoned   bool      `json:"abandoned,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	// PreviousHash is the hash of the previous record, empty for the first record
+	PreviousHash string `json:"previous_hash,omitempty"`
+	// Hash of the record (hex), the hashed content is the JSON of the record without Hash and Signature
+	Hash string `json:"hash"`
+	// Signature of the hash by the agent key SignerKey (base64 URL), if the log is signed
+	Signature string `json:"signature,omitempty"`
+	// SignerKey is the verification key (base58) of the agent key signing the record
+	SignerKey string `json:"signer_key,omitempty"`
+}
+
+// HashAttachment returns the hash of an attachment recorded in the audit log.
+func HashAttachment(data []byte) string {
+	h := sha256.Sum256(data)
+
+	return hex.EncodeToString(h[:])
+}
+
+// Opt configures the audit store.
+type Opt func(*Store)
+
+// WithSigner signs the records with the agent key of the verification key verKey (base58, ed25519).
+func WithSigner(signer legacykms.Signer, verKey string) Opt {
+	return func(s *Store) {
+		s.signer = signer
+		s.verKey = verKey
+	}
+}
+
+// Store is the append-only audit log of the completed exchanges.
+type Store struct {
+	store  storage.Store
+	signer legacykms.Signer
+	verKey string
+	mu     sync.Mutex
+}
+
+type head struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// New returns a new audit store.
+func New(p storage.Provider, opts ...Opt) (*Store, error) {
+	store, err := p.OpenStore(NameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit store: %w", err)
+	}
+
+	s := &Store{store: store}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Add appends the record to the log, it sets the sequence, the chained hash and the signature of the record.
+func (s *Store) Add(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, err := s.head()
+	if err != nil {
+		return err
+	}
+
+	record.Sequence = h.Sequence + 1
+	record.PreviousHash = h.Hash
+	record.Signature = ""
+	record.SignerKey = ""
+
+	if s.signer != nil {
+		record.SignerKey = s.verKey
+	}
+
+	record.Hash, err = hashRecord(record)
+	if err != nil {
+		return err
+	}
+
+	if s.signer != nil {
+		sig, e := s.signer.SignMessage([]byte(record.Hash), s.verKey)
+		if e != nil {
+			return fmt.Errorf("sign record: %w", e)
+		}
+
+		record.Signature = base64.URLEncoding.EncodeToString(sig)
+	}
+
+	if err = s.put(fmt.Sprintf(recordKeyPattern, record.Sequence), record); err != nil {
+		return err
+	}
+
+	return s.put(headKey, &head{Sequence: record.Sequence, Hash: record.Hash})
+}
+
+// Export writes the records to w in the order of the log, as JSON lines.
+func (s *Store) Export(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	return s.iterate(func(record *Record) error {
+		return encoder.Encode(record)
+	})
+}
+
+// Verify checks the chain of the records and their signatures, it returns ErrTampered if they do not match.
+// The signatures are verified with the key of the signer of the store (see WithSigner) rather than the key
+// of the records, and a record which is not signed is tampered. Without signer, only the chain is checked.
+func (s *Store) Verify() error {
+	var previous string
+
+	return s.iterate(func(record *Record) error {
+		if record.PreviousHash != previous {
+			return fmt.Errorf("%w: record %d is not chained to the previous record", ErrTampered, record.Sequence)
+		}
+
+		hash, err := hashRecord(record)
+		if err != nil {
+			return err
+		}
+
+		if hash != record.Hash {
+			return fmt.Errorf("%w: hash of record %d does not match", ErrTampered, record.Sequence)
+		}
+
+		if err := s.verifySignature(record); err != nil {
+			return fmt.Errorf("%w: record %d: %s", ErrTampered, record.Sequence, err)
+		}
+
+		previous = record.Hash
+
+		return nil
+	})
+}
+
+func (s *Store) iterate(fn func(*Record) error) error {
+	s.mu.Lock()
+	h, err := s.head()
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for seq := uint64(1); seq <= h.Sequence; seq++ {
+		src, err := s.store.Get(fmt.Sprintf(recordKeyPattern, seq))
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return fmt.Errorf("%w: record %d is missing", ErrTampered, seq)
+		}
+
+		if err != nil {
+			return fmt.Errorf("get record %d: %w", seq, err)
+		}
+
+		var record Record
+		if err := json.Unmarshal(src, &record); err != nil {
+			return fmt.Errorf("unmarshal record %d: %w", seq, err)
+		}
+
+		if err := fn(&record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) head() (*head, error) {
+	src, err := s.store.Get(headKey)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return &head{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get head: %w", err)
+	}
+
+	var h head
+	if err := json.Unmarshal(src, &h); err != nil {
+		return nil, fmt.Errorf("unmarshal head: %w", err)
+	}
+
+	return &h, nil
+}
+
+func (s *Store) put(k string, v interface{}) error {
+	src, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", k, err)
+	}
+
+	if err := s.store.Put(k, src); err != nil {
+		return fmt.Errorf("put %s: %w", k, err)
+	}
+
+	return nil
+}
+
+func hashRecord(record *Record) (string, error) {
+	hashed := *record
+	hashed.Hash = ""
+	hashed.Signature = ""
+
+	src, err := json.Marshal(&hashed)
+	if err != nil {
+		return "", fmt.Errorf("marshal record: %w", err)
+	}
+
+	return HashAttachment(src), nil
+}
+
+func (s *Store) verifySignature(record *Record) error {
+	if s.signer == nil {
+		return nil
+	}
+
+	if record.Signature == "" {
+		return errors.New("record is not signed")
+	}
+
+	// a record signed by another key is not trusted, whatever its signer key
+	if record.SignerKey != s.verKey {
+		return errors.New("record is not signed by the key of the log")
+	}
+
+	sig, err := base64.URLEncoding.DecodeString(record.Signature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	pubKey := base58.Decode(s.verKey)
+	if len(pubKey) != ed25519.PublicKeySize {
+		return errors.New("invalid signer key")
+	}
+
+	if !ed25519.Verify(pubKey, []byte(record.Hash), sig) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+type signer struct {
+	privKey ed25519.PrivateKey
+	err     error
+}
+
+func (s *signer) SignMessage(message []byte, _ string) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return ed25519.Sign(s.privKey, message), nil
+}
+
+func newSigner(t *testing.T) (*signer, string) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return &signer{privKey: privKey}, base58.Encode(pubKey)
+}
+
+func newRecord(thID string) *Record {
+	now := time.Now().UTC()
+	verified := true
+
+	return &Record{
+		Protocol:       "present-proof",
+		ThreadID:       thID,
+		MyDID:          "did:example:verifier",
+		TheirDID:       "did:example:prover",
+		RequestHashes:  []string{HashAttachment([]byte("request"))},
+		ResponseHashes: []string{HashAttachment([]byte("presentation"))},
+		Verified:       &verified,
+		StartedAt:      now.Add(-time.Second),
+		CompletedAt:    now,
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Run("chained records", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, s.Add(newRecord(fmt.Sprintf("thread-%d", i))))
+		}
+
+		require.NoError(t, s.Verify())
+
+		var buf bytes.Buffer
+		require.NoError(t, s.Export(&buf))
+
+		var (
+			records []Record
+			scanner = bufio.NewScanner(&buf)
+		)
+
+		for scanner.Scan() {
+			var record Record
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+
+			records = append(records, record)
+		}
+
+		require.Len(t, records, 3)
+
+		for i, record := range records {
+			require.Equal(t, uint64(i+1), record.Sequence)
+			require.Equal(t, fmt.Sprintf("thread-%d", i), record.ThreadID)
+			require.NotEmpty(t, record.Hash)
+			require.Empty(t, record.Signature)
+
+			if i > 0 {
+				require.Equal(t, records[i-1].Hash, record.PreviousHash)
+			}
+		}
+	})
+
+	t.Run("signed records", func(t *testing.T) {
+		sig, verKey := newSigner(t)
+
+		s, err := New(mem.NewProvider(), WithSigner(sig, verKey))
+		require.NoError(t, err)
+
+		record := newRecord("thread")
+		require.NoError(t, s.Add(record))
+		require.Equal(t, verKey, record.SignerKey)
+		require.NotEmpty(t, record.Signature)
+
+		require.NoError(t, s.Verify())
+
+		// signed by another key
+		other, _ := newSigner(t)
+		s.signer = other
+		require.NoError(t, s.Add(newRecord("thread")))
+
+		require.True(t, errors.Is(s.Verify(), ErrTampered))
+	})
+
+	t.Run("signed records rewritten", func(t *testing.T) {
+		sig, verKey := newSigner(t)
+		provider := mem.NewProvider()
+
+		s, err := New(provider, WithSigner(sig, verKey))
+		require.NoError(t, err)
+
+		require.NoError(t, s.Add(newRecord("thread")))
+
+		// the log is rewritten and signed by the key of the attacker
+		other, otherKey := newSigner(t)
+		forged, err := New(provider, WithSigner(other, otherKey))
+		require.NoError(t, err)
+
+		require.NoError(t, forged.store.Delete(headKey))
+		require.NoError(t, forged.Add(newRecord("thread")))
+		require.NoError(t, forged.Verify())
+
+		err = s.Verify()
+		require.True(t, errors.Is(err, ErrTampered))
+		require.Contains(t, err.Error(), "record 1: record is not signed by the key of the log")
+
+		// the log is rewritten without signatures
+		unsigned, err := New(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, unsigned.store.Delete(headKey))
+		require.NoError(t, unsigned.Add(newRecord("thread")))
+		require.NoError(t, unsigned.Verify())
+
+		err = s.Verify()
+		require.True(t, errors.Is(err, ErrTampered))
+		require.Contains(t, err.Error(), "record 1: record is not signed")
+	})
+
+	t.Run("signer error", func(t *testing.T) {
+		s, err := New(mem.NewProvider(), WithSigner(&signer{err: errors.New("test")}, "key"))
+		require.NoError(t, err)
+
+		err = s.Add(newRecord("thread"))
+		require.EqualError(t, err, "sign record: test")
+	})
+
+	t.Run("tampered records", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, s.Add(newRecord(fmt.Sprintf("thread-%d", i))))
+		}
+
+		store, err := provider.OpenStore(NameSpace)
+		require.NoError(t, err)
+
+		key := fmt.Sprintf(recordKeyPattern, 2)
+
+		src, err := store.Get(key)
+		require.NoError(t, err)
+
+		// modified record
+		var record Record
+		require.NoError(t, json.Unmarshal(src, &record))
+
+		verified := false
+		record.Verified = &verified
+		require.NoError(t, s.put(key, &record))
+
+		err = s.Verify()
+		require.True(t, errors.Is(err, ErrTampered))
+		require.Contains(t, err.Error(), "hash of record 2 does not match")
+
+		// record replaced by a consistent record which is not chained
+		replaced := newRecord("thread-1")
+		replaced.Sequence = 2
+		replaced.Hash, err = hashRecord(replaced)
+		require.NoError(t, err)
+		require.NoError(t, s.put(key, replaced))
+
+		err = s.Verify()
+		require.True(t, errors.Is(err, ErrTampered))
+		require.Contains(t, err.Error(), "record 2 is not chained")
+
+		// removed record
+		require.NoError(t, store.Delete(key))
+
+		err = s.Verify()
+		require.True(t, errors.Is(err, ErrTampered))
+		require.Contains(t, err.Error(), "record 2 is missing")
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		_, err := New(&failingProvider{})
+		require.EqualError(t, err, "failed to open audit store: test")
+	})
+}
+
+type failingProvider struct {
+	storage.Provider
+}
+
+func (p *failingProvider) OpenStore(string) (storage.Store, error) {
+	return nil, errors.New("test")
+}
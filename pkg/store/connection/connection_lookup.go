@@ -57,6 +57,11 @@ type Record struct {
 	InvitationDID   string
 	Implicit        bool
 	Namespace       string
+	// HandshakeProtocol is the protocol establishing the connection, negotiated by the out-of-band protocol
+	HandshakeProtocol string
+	// MediaTypeProfile is the DIDComm media type profile the connection was upgraded to (eg "didcomm/v2"),
+	// it is empty for the connections using the V1 envelope they were established with
+	MediaTypeProfile string
 }
 
 // NewLookup returns new connection lookup instance.
@@ -169,6 +169,11 @@ func (c *Recorder) RemoveConnection(connectionID string) error {
 	return nil
 }
 
+// RemoveDIDMapping removes the mapping of the DIDs to their connection, e.g once the other agent rotated its DID.
+func (c *Recorder) RemoveDIDMapping(myDID, theirDID string) error {
+	return c.store.Delete(getDIDConnMapKeyPrefix()(myDID, theirDID))
+}
+
 func marshalAndSave(k string, v interface{}, store storage.Store) error {
 	bytes, err := json.Marshal(v)
 	if err != nil {
@@ -10,6 +10,7 @@
 package connection
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -460,6 +461,31 @@ func TestConnectionRecorder_SaveConnectionRecord(t *testing.T) {
 	})
 }
 
+func TestConnectionRecorder_RemoveDIDMapping(t *testing.T) {
+	recorder, err := NewRecorder(&protocol.MockProvider{})
+	require.NoError(t, err)
+
+	record := &Record{
+		ConnectionID: uuid.New().String(),
+		State:        stateNameCompleted,
+		MyDID:        "did:mydid:123",
+		TheirDID:     "did:theirdid:123",
+	}
+	require.NoError(t, recorder.SaveConnectionRecord(record))
+
+	// the other agent rotated its DID
+	record.TheirDID = "did:theirdid:456"
+	require.NoError(t, recorder.SaveConnectionRecord(record))
+	require.NoError(t, recorder.RemoveDIDMapping(record.MyDID, "did:theirdid:123"))
+
+	_, err = recorder.GetConnectionIDByDIDs(record.MyDID, "did:theirdid:123")
+	require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+	connID, err := recorder.GetConnectionIDByDIDs(record.MyDID, record.TheirDID)
+	require.NoError(t, err)
+	require.Equal(t, record.ConnectionID, connID)
+}
+
 func TestConnectionRecorder_RemoveConnection(t *testing.T) {
 	t.Run("save and remove connection record with invited state - completed", func(t *testing.T) {
 		recorder, err := NewRecorder(&protocol.MockProvider{})
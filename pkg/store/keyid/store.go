@@ -0,0 +1,212 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package keyid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// NameSpace for the key ID store
+	NameSpace = "keyid"
+
+	// the KMS key IDs are base64 URL encoded, they do not contain the separator
+	didURLKeyPattern = "keyid_%s!%s"
+	keyIDKeyPattern  = "didurl_%s"
+)
+
+// ErrNotFound signals that no KMS key is indexed for the DID URL.
+var ErrNotFound = errors.New("key ID not found")
+
+type provider interface {
+	StorageProvider() storage.Provider
+}
+
+// Store indexes the KMS key IDs with the DID URLs (verification method IDs) of the DID documents where
+// the keys are published. A key can be published in several DID documents, a DID URL refers to one key.
+// Signing code gets the `kid` or `verificationMethod` of a key from its DID URLs, key rotation gets the DID
+// documents to update.
+type Store struct {
+	store storage.Store
+}
+
+// New returns a new key ID store.
+func New(ctx provider) (*Store, error) {
+	store, err := ctx.StorageProvider().OpenStore(NameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key ID store: %w", err)
+	}
+
+	return &Store{store: store}, nil
+}
+
+// Save indexes the KMS key ID with the DID URL where the key is published. The DID URL is indexed with
+// one key only: the key ID previously indexed with the DID URL is replaced.
+func (s *Store) Save(keyID, didURL string) error {
+	if keyID == "" || didURL == "" {
+		return errors.New("key ID and DID URL are mandatory")
+	}
+
+	if err := s.Delete(didURL); err != nil {
+		return err
+	}
+
+	if err := s.store.Put(fmt.Sprintf(keyIDKeyPattern, didURL), []byte(keyID)); err != nil {
+		return fmt.Errorf("save key ID: %w", err)
+	}
+
+	if err := s.store.Put(fmt.Sprintf(didURLKeyPattern, keyID, didURL), []byte(didURL)); err != nil {
+		return fmt.Errorf("save DID URL: %w", err)
+	}
+
+	return nil
+}
+
+// SaveDIDDoc indexes the KMS key IDs with the verification methods of the DID document, keyIDs maps the ID
+// of a public key of the document (absolute or relative, e.g "#key-1") to the KMS key ID.
+func (s *Store) SaveDIDDoc(doc *did.Doc, keyIDs map[string]string) error {
+	for _, pk := range doc.PublicKey {
+		keyID, ok := keyIDs[pk.ID]
+		if !ok {
+			continue
+		}
+
+		if err := s.Save(keyID, DIDURL(doc.ID, pk.ID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveKey indexes the KMS key ID with the public keys of the DID document having the given value, e.g once
+// the key was published in a new or rotated DID document.
+func (s *Store) SaveKey(doc *did.Doc, keyID string, value []byte) error {
+	keyIDs := map[string]string{}
+
+	for _, pk := range doc.PublicKey {
+		if bytes.Equal(pk.Value, value) {
+			keyIDs[pk.ID] = keyID
+		}
+	}
+
+	return s.SaveDIDDoc(doc, keyIDs)
+}
+
+// KeyID returns the KMS key ID of the key published with the DID URL.
+func (s *Store) KeyID(didURL string) (string, error) {
+	keyID, err := s.store.Get(fmt.Sprintf(keyIDKeyPattern, didURL))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return "", ErrNotFound
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("get key ID: %w", err)
+	}
+
+	return string(keyID), nil
+}
+
+// DIDURLs returns the DID URLs where the key is published.
+func (s *Store) DIDURLs(keyID string) ([]string, error) {
+	prefix := fmt.Sprintf(didURLKeyPattern, keyID, "")
+
+	itr := s.store.Iterator(prefix, prefix+storage.EndKeySuffix)
+	defer itr.Release()
+
+	var didURLs []string
+
+	for itr.Next() {
+		didURLs = append(didURLs, string(itr.Value()))
+	}
+
+	if itr.Error() != nil {
+		return nil, fmt.Errorf("iterate DID URLs: %w", itr.Error())
+	}
+
+	return didURLs, nil
+}
+
+// DIDs returns the DIDs of the documents where the key is published.
+func (s *Store) DIDs(keyID string) ([]string, error) {
+	didURLs, err := s.DIDURLs(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var dids []string
+
+	seen := map[string]bool{}
+
+	for _, didURL := range didURLs {
+		id := strings.SplitN(didURL, "#", 2)[0]
+		if !seen[id] {
+			seen[id] = true
+			dids = append(dids, id)
+		}
+	}
+
+	return dids, nil
+}
+
+// KeyIDs returns the KMS key IDs indexed by DID URL, e.g to rotate all the keys published by the DID documents.
+func (s *Store) KeyIDs() (map[string]string, error) {
+	prefix := fmt.Sprintf(keyIDKeyPattern, "")
+
+	itr := s.store.Iterator(prefix, prefix+storage.EndKeySuffix)
+	defer itr.Release()
+
+	keyIDs := map[string]string{}
+
+	for itr.Next() {
+		keyIDs[strings.TrimPrefix(string(itr.Key()), prefix)] = string(itr.Value())
+	}
+
+	if itr.Error() != nil {
+		return nil, fmt.Errorf("iterate key IDs: %w", itr.Error())
+	}
+
+	return keyIDs, nil
+}
+
+// Delete removes the DID URL from the index, e.g after the key was rotated.
+func (s *Store) Delete(didURL string) error {
+	keyID, err := s.KeyID(didURL)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Delete(fmt.Sprintf(didURLKeyPattern, keyID, didURL)); err != nil {
+		return fmt.Errorf("delete DID URL: %w", err)
+	}
+
+	if err := s.store.Delete(fmt.Sprintf(keyIDKeyPattern, didURL)); err != nil {
+		return fmt.Errorf("delete key ID: %w", err)
+	}
+
+	return nil
+}
+
+// DIDURL returns the absolute DID URL of a verification method of the DID document, the ID of the
+// verification method may be relative to the document (e.g "#key-1").
+func DIDURL(didID, verificationMethodID string) string {
+	if strings.HasPrefix(verificationMethodID, "#") {
+		return didID + verificationMethodID
+	}
+
+	return verificationMethodID
+}
@@ -0,0 +1,216 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package keyid
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const (
+	aliceDID = "did:example:alice"
+	bobDID   = "did:example:bob"
+)
+
+type mockProvider struct {
+	storageProvider storage.Provider
+}
+
+func (p *mockProvider) StorageProvider() storage.Provider {
+	return p.storageProvider
+}
+
+func TestNew(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		s, err := New(&mockProvider{storageProvider: mem.NewProvider()})
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+
+	t.Run("test error from open store", func(t *testing.T) {
+		s, err := New(&mockProvider{
+			storageProvider: &mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open store error")},
+		})
+		require.EqualError(t, err, "failed to open key ID store: open store error")
+		require.Nil(t, s)
+	})
+}
+
+func TestStore(t *testing.T) {
+	s, err := New(&mockProvider{storageProvider: mem.NewProvider()})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Save("key-1", aliceDID+"#key-1"))
+	require.NoError(t, s.Save("key-1", bobDID+"#signing"))
+	require.NoError(t, s.Save("key-1", bobDID+"#authentication"))
+	require.NoError(t, s.Save("key-10", aliceDID+"#key-10"))
+
+	t.Run("test key ID", func(t *testing.T) {
+		keyID, err := s.KeyID(bobDID + "#signing")
+		require.NoError(t, err)
+		require.Equal(t, "key-1", keyID)
+
+		_, err = s.KeyID(bobDID + "#unknown")
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("test DID URLs and DIDs", func(t *testing.T) {
+		didURLs, err := s.DIDURLs("key-1")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{aliceDID + "#key-1", bobDID + "#signing", bobDID + "#authentication"}, didURLs)
+
+		dids, err := s.DIDs("key-1")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{aliceDID, bobDID}, dids)
+
+		didURLs, err = s.DIDURLs("key-10")
+		require.NoError(t, err)
+		require.Equal(t, []string{aliceDID + "#key-10"}, didURLs)
+
+		didURLs, err = s.DIDURLs("unknown")
+		require.NoError(t, err)
+		require.Empty(t, didURLs)
+	})
+
+	t.Run("test DID URL indexed with a new key", func(t *testing.T) {
+		require.NoError(t, s.Save("key-2", bobDID+"#signing"))
+
+		keyID, err := s.KeyID(bobDID + "#signing")
+		require.NoError(t, err)
+		require.Equal(t, "key-2", keyID)
+
+		didURLs, err := s.DIDURLs("key-1")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{aliceDID + "#key-1", bobDID + "#authentication"}, didURLs)
+	})
+
+	t.Run("test key IDs", func(t *testing.T) {
+		keyIDs, err := s.KeyIDs()
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			aliceDID + "#key-1":        "key-1",
+			aliceDID + "#key-10":       "key-10",
+			bobDID + "#signing":        "key-2",
+			bobDID + "#authentication": "key-1",
+		}, keyIDs)
+	})
+
+	t.Run("test delete", func(t *testing.T) {
+		require.NoError(t, s.Delete(bobDID+"#authentication"))
+		require.NoError(t, s.Delete(bobDID+"#authentication"))
+
+		dids, err := s.DIDs("key-1")
+		require.NoError(t, err)
+		require.Equal(t, []string{aliceDID}, dids)
+	})
+
+	t.Run("test missing arguments", func(t *testing.T) {
+		require.EqualError(t, s.Save("", aliceDID+"#key-1"), "key ID and DID URL are mandatory")
+		require.EqualError(t, s.Save("key-1", ""), "key ID and DID URL are mandatory")
+	})
+}
+
+func TestStore_SaveDIDDoc(t *testing.T) {
+	s, err := New(&mockProvider{storageProvider: mem.NewProvider()})
+	require.NoError(t, err)
+
+	doc := &did.Doc{
+		ID: aliceDID,
+		PublicKey: []did.PublicKey{
+			{ID: "#key-1", Type: "Ed25519VerificationKey2018", Controller: aliceDID},
+			{ID: aliceDID + "#key-2", Type: "Ed25519VerificationKey2018", Controller: aliceDID},
+			{ID: "#key-3", Type: "Ed25519VerificationKey2018", Controller: aliceDID},
+		},
+	}
+
+	require.NoError(t, s.SaveDIDDoc(doc, map[string]string{"#key-1": "kms-1", aliceDID + "#key-2": "kms-2"}))
+
+	keyID, err := s.KeyID(aliceDID + "#key-1")
+	require.NoError(t, err)
+	require.Equal(t, "kms-1", keyID)
+
+	keyID, err = s.KeyID(aliceDID + "#key-2")
+	require.NoError(t, err)
+	require.Equal(t, "kms-2", keyID)
+
+	_, err = s.KeyID(aliceDID + "#key-3")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	require.EqualError(t, s.SaveDIDDoc(doc, map[string]string{"#key-1": ""}), "key ID and DID URL are mandatory")
+}
+
+func TestStore_SaveKey(t *testing.T) {
+	s, err := New(&mockProvider{storageProvider: mem.NewProvider()})
+	require.NoError(t, err)
+
+	doc := &did.Doc{
+		ID: aliceDID,
+		PublicKey: []did.PublicKey{
+			{ID: "#key-1", Type: "Ed25519VerificationKey2018", Controller: aliceDID, Value: []byte("key")},
+			{ID: "#key-2", Type: "Ed25519VerificationKey2018", Controller: aliceDID, Value: []byte("other")},
+		},
+	}
+
+	require.NoError(t, s.SaveKey(doc, "kms-1", []byte("key")))
+
+	didURLs, err := s.DIDURLs("kms-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{aliceDID + "#key-1"}, didURLs)
+}
+
+func TestStore_Errors(t *testing.T) {
+	store := &mockstorage.MockStore{Store: map[string][]byte{}}
+
+	s, err := New(&mockProvider{storageProvider: mockstorage.NewCustomMockStoreProvider(store)})
+	require.NoError(t, err)
+
+	t.Run("test put error", func(t *testing.T) {
+		store.ErrPut = errors.New("put error")
+		defer func() { store.ErrPut = nil }()
+
+		require.EqualError(t, s.Save("key-1", aliceDID+"#key-1"), "save key ID: put error")
+	})
+
+	t.Run("test get error", func(t *testing.T) {
+		store.ErrGet = errors.New("get error")
+		defer func() { store.ErrGet = nil }()
+
+		_, err := s.KeyID(aliceDID + "#key-1")
+		require.EqualError(t, err, "get key ID: get error")
+
+		require.EqualError(t, s.Save("key-1", aliceDID+"#key-1"), "get key ID: get error")
+	})
+
+	t.Run("test iterator error", func(t *testing.T) {
+		store.ErrItr = errors.New("iterator error")
+		defer func() { store.ErrItr = nil }()
+
+		_, err := s.DIDURLs("key-1")
+		require.EqualError(t, err, "iterate DID URLs: iterator error")
+
+		_, err = s.DIDs("key-1")
+		require.EqualError(t, err, "iterate DID URLs: iterator error")
+
+		_, err = s.KeyIDs()
+		require.EqualError(t, err, "iterate key IDs: iterator error")
+	})
+
+	t.Run("test delete error", func(t *testing.T) {
+		store.Store["didurl_"+aliceDID+"#key-1"] = []byte("key-1")
+		store.ErrDelete = errors.New("delete error")
+		defer func() { store.ErrDelete = nil }()
+
+		require.EqualError(t, s.Delete(aliceDID+"#key-1"), "delete DID URL: delete error")
+	})
+}
@@ -0,0 +1,301 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	// RevocationList2020Status is the type of the credential status checked against a revocation list
+	// credential, see https://w3c-ccg.github.io/vc-status-rl-2020.
+	RevocationList2020Status = "RevocationList2020Status"
+
+	revocationListIndexField      = "revocationListIndex"
+	revocationListCredentialField = "revocationListCredential"
+
+	credentialStatusKeyPattern = "vcstatus_%s"
+
+	// the maximum sizes of a revocation list credential and of its bitstring, i.e 128M credentials
+	maxRevocationListSize = 1 << 20
+	maxBitstringSize      = 1 << 24
+)
+
+// CredentialStatus is the status of a stored credential, polled from its revocation list.
+type CredentialStatus struct {
+	// Revoked is true if the credential is revoked by its issuer.
+	Revoked bool `json:"revoked"`
+	// Checked is the time of the poll the status was checked.
+	Checked time.Time `json:"checked"`
+}
+
+// HTTPClient fetches the revocation list credentials.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// GetCredentialStatus returns the status of the credential polled from its revocation list, ErrNotFound if the
+// credential has no status or if it was not polled yet.
+func (s *Store) GetCredentialStatus(id string) (*CredentialStatus, error) {
+	src, err := s.store.Get(fmt.Sprintf(credentialStatusKeyPattern, id))
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get credential status: %w", err)
+	}
+
+	status := &CredentialStatus{}
+
+	if err := json.Unmarshal(src, status); err != nil {
+		return nil, fmt.Errorf("unmarshal credential status: %w", err)
+	}
+
+	return status, nil
+}
+
+func (s *Store) saveCredentialStatus(id string, status *CredentialStatus) error {
+	src, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("marshal credential status: %w", err)
+	}
+
+	if err := s.store.Put(fmt.Sprintf(credentialStatusKeyPattern, id), src); err != nil {
+		return fmt.Errorf("save credential status: %w", err)
+	}
+
+	return nil
+}
+
+// StatusPoller polls the revocation lists of the stored credentials having a RevocationList2020Status and records
+// their status, see Store.GetCredentialStatus. The revocation lists are credentials of the issuers of the stored
+// credentials, their proof is checked.
+type StatusPoller struct {
+	store  *Store
+	client HTTPClient
+	opts   []verifiable.CredentialOpt
+}
+
+// NewStatusPoller returns a new poller of the status of the credentials of the store, the revocation list
+// credentials are fetched by the client and decoded with the options, e.g verifiable.WithPublicKeyFetcher.
+func NewStatusPoller(store *Store, client HTTPClient, opts ...verifiable.CredentialOpt) *StatusPoller {
+	return &StatusPoller{store: store, client: client, opts: opts}
+}
+
+// Poll checks the status of the stored credentials, the revocation lists are fetched once by poll.
+// It is the task run by the framework scheduler. The credentials whose status can't be checked are skipped,
+// the first error is returned.
+func (p *StatusPoller) Poll(now time.Time) error {
+	lists := make(map[string]*revocationList)
+
+	var (
+		firstErr error
+		failed   int
+	)
+
+	for _, record := range p.store.GetCredentials() {
+		vc, err := p.store.GetCredential(record.ID)
+		if err != nil || vc.Status == nil || vc.Status.Type != RevocationList2020Status {
+			continue
+		}
+
+		revoked, err := p.revoked(vc, lists)
+		if err == nil {
+			err = p.store.saveCredentialStatus(vc.ID, &CredentialStatus{Revoked: revoked, Checked: now})
+		}
+
+		if err != nil {
+			failed++
+
+			if firstErr == nil {
+				firstErr = fmt.Errorf("credential %s: %w", vc.ID, err)
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("poll status of %d credentials failed, first error: %w", failed, firstErr)
+	}
+
+	return nil
+}
+
+type revocationList struct {
+	issuer string
+	bits   []byte
+	err    error
+}
+
+func (p *StatusPoller) revoked(vc *verifiable.Credential, lists map[string]*revocationList) (bool, error) {
+	listURL, ok := vc.Status.CustomFields[revocationListCredentialField].(string)
+	if !ok || listURL == "" {
+		return false, fmt.Errorf("missing %s", revocationListCredentialField)
+	}
+
+	index, err := statusIndex(vc.Status.CustomFields[revocationListIndexField])
+	if err != nil {
+		return false, err
+	}
+
+	list, ok := lists[listURL]
+	if !ok {
+		list = p.fetch(listURL)
+		lists[listURL] = list
+	}
+
+	if list.err != nil {
+		return false, list.err
+	}
+
+	// the list is signed by the issuer of the credential
+	if list.issuer != vc.Issuer.ID {
+		return false, fmt.Errorf("revocation list %s issued by %s, not by the issuer of the credential", listURL,
+			list.issuer)
+	}
+
+	if index >= len(list.bits)*8 {
+		return false, fmt.Errorf("%s %d out of the revocation list %s", revocationListIndexField, index, listURL)
+	}
+
+	// the first index is the left-most bit of the bitstring
+	return list.bits[index/8]&(1<<(7-uint(index%8))) != 0, nil
+}
+
+// fetch downloads and decodes the revocation list credential.
+func (p *StatusPoller) fetch(listURL string) *revocationList {
+	req, err := http.NewRequest(http.MethodGet, listURL, nil)
+	if err != nil {
+		return &revocationList{err: fmt.Errorf("new revocation list request: %w", err)}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return &revocationList{err: fmt.Errorf("fetch revocation list %s: %w", listURL, err)}
+	}
+
+	defer func() {
+		if e := resp.Body.Close(); e != nil {
+			logger.Warnf("failed to close revocation list response body: %s", e)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return &revocationList{err: fmt.Errorf("fetch revocation list %s: HTTP status %d", listURL, resp.StatusCode)}
+	}
+
+	src, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRevocationListSize))
+	if err != nil {
+		return &revocationList{err: fmt.Errorf("read revocation list %s: %w", listURL, err)}
+	}
+
+	vc, _, err := verifiable.NewCredential(src, p.opts...)
+	if err != nil {
+		return &revocationList{err: fmt.Errorf("decode revocation list %s: %w", listURL, err)}
+	}
+
+	if !signed(src, vc) {
+		return &revocationList{err: fmt.Errorf("revocation list %s is not signed", listURL)}
+	}
+
+	bits, err := decodeEncodedList(vc.Subject)
+	if err != nil {
+		return &revocationList{err: fmt.Errorf("revocation list %s: %w", listURL, err)}
+	}
+
+	return &revocationList{issuer: vc.Issuer.ID, bits: bits}
+}
+
+// signed checks whether the decoded credential has a proof or is a JWS, the proofs are checked by the decoding.
+func signed(src []byte, vc *verifiable.Credential) bool {
+	src = bytes.TrimSpace(src)
+
+	if bytes.HasPrefix(src, []byte("{")) {
+		return len(vc.Proofs) > 0
+	}
+
+	// the unsecured JWTs have an empty signature
+	return !bytes.HasSuffix(src, []byte("."))
+}
+
+// decodeEncodedList decodes the GZIP-compressed, base64 encoded bitstring of the revocation list.
+func decodeEncodedList(subject interface{}) ([]byte, error) {
+	src, err := json.Marshal(subject)
+	if err != nil {
+		return nil, fmt.Errorf("marshal subject: %w", err)
+	}
+
+	type listSubject struct {
+		EncodedList string `json:"encodedList"`
+	}
+
+	var s listSubject
+
+	if err := json.Unmarshal(src, &s); err != nil {
+		var subjects []listSubject
+		if err := json.Unmarshal(src, &subjects); err != nil || len(subjects) != 1 {
+			return nil, errors.New("expecting a single credential subject")
+		}
+
+		s = subjects[0]
+	}
+
+	if s.EncodedList == "" {
+		return nil, errors.New("missing encodedList")
+	}
+
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s.EncodedList, "="))
+	if err != nil {
+		compressed, err = base64.StdEncoding.DecodeString(s.EncodedList)
+		if err != nil {
+			return nil, fmt.Errorf("decode encodedList: %w", err)
+		}
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("decompress encodedList: %w", err)
+	}
+
+	bits, err := ioutil.ReadAll(io.LimitReader(r, maxBitstringSize))
+	if err != nil {
+		return nil, fmt.Errorf("decompress encodedList: %w", err)
+	}
+
+	return bits, nil
+}
+
+// statusIndex returns the index of the credential in the revocation list, a string or a number.
+func statusIndex(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case string:
+		index, err := strconv.Atoi(v)
+		if err == nil && index >= 0 {
+			return index, nil
+		}
+	case float64:
+		if v >= 0 && v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid %s: %v", revocationListIndexField, value)
+}
@@ -0,0 +1,278 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+)
+
+const statusIssuer = "did:example:issuer"
+
+type ed25519Signer struct {
+	privKey ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privKey, data), nil
+}
+
+// encodedList returns the GZIP-compressed, base64url encoded bitstring of the revoked indexes.
+func encodedList(t *testing.T, size int, revoked ...int) string {
+	t.Helper()
+
+	bits := make([]byte, size/8)
+	for _, i := range revoked {
+		bits[i/8] |= 1 << (7 - uint(i%8))
+	}
+
+	var b bytes.Buffer
+
+	w := gzip.NewWriter(&b)
+	_, err := w.Write(bits)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return base64.RawURLEncoding.EncodeToString(b.Bytes())
+}
+
+// revocationListJWS returns the revocation list credential of the issuer signed as JWS.
+func revocationListJWS(t *testing.T, signer verifiable.Signer, issuer, list string) string {
+	t.Helper()
+
+	issued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	vc := &verifiable.Credential{
+		Context: []string{"https://www.w3.org/2018/credentials/v1", "https://w3id.org/vc-revocation-list-2020/v1"},
+		ID:      "https://issuer.example.com/status/1",
+		Types:   []string{"VerifiableCredential", "RevocationList2020Credential"},
+		Issuer:  verifiable.Issuer{ID: issuer},
+		Issued:  &issued,
+		Subject: map[string]interface{}{
+			"id":          "https://issuer.example.com/status/1#list",
+			"type":        "RevocationList2020",
+			"encodedList": list,
+		},
+	}
+
+	src, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	claims := &verifiable.JWTCredClaims{Claims: &jwt.Claims{Issuer: issuer, ID: vc.ID}}
+	require.NoError(t, json.Unmarshal(src, &claims.VC))
+
+	jws, err := claims.MarshalJWS(verifiable.EdDSA, signer, issuer+"#key-1")
+	require.NoError(t, err)
+
+	return jws
+}
+
+// statusListOpts returns the options decoding the revocation lists signed by the key, the context of the
+// revocation lists is loaded locally.
+func statusListOpts(t *testing.T, pubKey ed25519.PublicKey) []verifiable.CredentialOpt {
+	t.Helper()
+
+	loader := verifiable.CachingJSONLDLoader()
+
+	context, err := ld.DocumentFromReader(strings.NewReader(`{
+  "@context": {
+    "RevocationList2020Credential": "https://w3id.org/vc-revocation-list-2020#RevocationList2020Credential",
+    "RevocationList2020": "https://w3id.org/vc-revocation-list-2020#RevocationList2020",
+    "encodedList": "https://w3id.org/vc-revocation-list-2020#encodedList"
+  }
+}`))
+	require.NoError(t, err)
+
+	loader.AddDocument("https://w3id.org/vc-revocation-list-2020/v1", context)
+
+	return []verifiable.CredentialOpt{
+		verifiable.WithPublicKeyFetcher(verifiable.SingleKey(pubKey, kms.ED25519)),
+		verifiable.WithNoCustomSchemaCheck(),
+		verifiable.WithJSONLDDocumentLoader(loader),
+	}
+}
+
+func statusCredential(id, listURL string, index interface{}) *verifiable.Credential {
+	issued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return &verifiable.Credential{
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		ID:      id,
+		Types:   []string{"VerifiableCredential"},
+		Issuer:  verifiable.Issuer{ID: statusIssuer},
+		Issued:  &issued,
+		Subject: map[string]interface{}{"id": "did:example:holder"},
+		Status: &verifiable.TypedID{
+			ID:   listURL + "#" + id,
+			Type: RevocationList2020Status,
+			CustomFields: verifiable.CustomFields{
+				revocationListIndexField:      index,
+				revocationListCredentialField: listURL,
+			},
+		},
+	}
+}
+
+func TestStatusPoller_Poll(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	signer := &ed25519Signer{privKey: privKey}
+	var fetches int32
+
+	list := revocationListJWS(t, signer, statusIssuer, encodedList(t, 16*1024, 3, 94567%(16*1024)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+
+		switch r.URL.Path {
+		case "/status/1":
+			_, err := w.Write([]byte(list))
+			require.NoError(t, err)
+		case "/status/other":
+			_, err := w.Write([]byte(revocationListJWS(t, signer, "did:example:other", encodedList(t, 8))))
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s, err := New(&mockprovider.Provider{StorageProviderValue: mem.NewProvider()})
+	require.NoError(t, err)
+
+	listURL := server.URL + "/status/1"
+
+	require.NoError(t, s.SaveCredential("revoked", statusCredential("http://example.edu/vc/1", listURL, "3")))
+	require.NoError(t, s.SaveCredential("valid", statusCredential("http://example.edu/vc/2", listURL, float64(4))))
+	require.NoError(t, s.SaveCredential("no status", &verifiable.Credential{ID: "http://example.edu/vc/3",
+		Context: []string{"https://www.w3.org/2018/credentials/v1"}, Types: []string{"VerifiableCredential"}}))
+
+	poller := NewStatusPoller(s, server.Client(), statusListOpts(t, pubKey)...)
+
+	now := time.Now().UTC()
+	require.NoError(t, poller.Poll(now))
+
+	// the list is fetched once by poll
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	status, err := s.GetCredentialStatus("http://example.edu/vc/1")
+	require.NoError(t, err)
+	require.True(t, status.Revoked)
+	require.True(t, now.Equal(status.Checked))
+
+	status, err = s.GetCredentialStatus("http://example.edu/vc/2")
+	require.NoError(t, err)
+	require.False(t, status.Revoked)
+
+	_, err = s.GetCredentialStatus("http://example.edu/vc/3")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	// the status is removed with the credential
+	require.NoError(t, s.RemoveCredentialByName("revoked"))
+
+	_, err = s.GetCredentialStatus("http://example.edu/vc/1")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	t.Run("invalid status lists", func(t *testing.T) {
+		s, err := New(&mockprovider.Provider{StorageProviderValue: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.NoError(t, s.SaveCredential("other issuer",
+			statusCredential("http://example.edu/vc/1", server.URL+"/status/other", "1")))
+		require.NoError(t, s.SaveCredential("out of list",
+			statusCredential("http://example.edu/vc/2", listURL, "200000")))
+		require.NoError(t, s.SaveCredential("not found",
+			statusCredential("http://example.edu/vc/3", server.URL+"/status/unknown", "1")))
+		require.NoError(t, s.SaveCredential("invalid index",
+			statusCredential("http://example.edu/vc/4", listURL, "-1")))
+
+		poller := NewStatusPoller(s, server.Client(), statusListOpts(t, pubKey)...)
+
+		err = poller.Poll(time.Now())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "poll status of 4 credentials failed")
+
+		for _, id := range []string{"http://example.edu/vc/1", "http://example.edu/vc/2", "http://example.edu/vc/3"} {
+			_, err = s.GetCredentialStatus(id)
+			require.True(t, errors.Is(err, ErrNotFound))
+		}
+
+		// the list is not signed by a trusted key
+		otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		s, err = New(&mockprovider.Provider{StorageProviderValue: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.NoError(t, s.SaveCredential("valid", statusCredential("http://example.edu/vc/1", listURL, "4")))
+
+		poller = NewStatusPoller(s, server.Client(), statusListOpts(t, otherKey)...)
+
+		err = poller.Poll(time.Now())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode revocation list")
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		store := &mockstore.MockStore{Store: map[string][]byte{}}
+
+		s, err := New(&mockprovider.Provider{StorageProviderValue: mockstore.NewCustomMockStoreProvider(store)})
+		require.NoError(t, err)
+
+		require.NoError(t, s.SaveCredential("valid", statusCredential("http://example.edu/vc/2", listURL, "4")))
+
+		store.ErrPut = errors.New("put error")
+
+		poller := NewStatusPoller(s, server.Client(), statusListOpts(t, pubKey)...)
+
+		err = poller.Poll(time.Now())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "save credential status: put error")
+	})
+}
+
+func TestDecodeEncodedList(t *testing.T) {
+	bits, err := decodeEncodedList([]interface{}{map[string]interface{}{"encodedList": encodedList(t, 8, 0)}})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x80}, bits)
+
+	_, err = decodeEncodedList(map[string]interface{}{"id": "list"})
+	require.EqualError(t, err, "missing encodedList")
+
+	_, err = decodeEncodedList("list")
+	require.EqualError(t, err, "expecting a single credential subject")
+
+	_, err = decodeEncodedList(map[string]interface{}{"encodedList": "!"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "decode encodedList")
+
+	_, err = decodeEncodedList(map[string]interface{}{"encodedList": base64.StdEncoding.EncodeToString([]byte("list"))})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "decompress encodedList")
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
 )
@@ -21,13 +22,22 @@ const (
 	credentialNameKey            = "vcname_"
 	credentialNameDataKeyPattern = credentialNameKey + "%s"
 
+	// the credentials are indexed by subject ID to find their near-duplicates
+	credentialSubjectKeyPattern     = "vcsubject_%s!"
+	credentialSubjectDataKeyPattern = credentialSubjectKeyPattern + "%s"
+
 	// limitPattern for the iterator
 	limitPattern = "%s" + storage.EndKeySuffix
 )
 
+var logger = log.New("aries-framework/store/verifiable")
+
 // ErrNotFound signals that the entry for the given DID and key is not present in the store.
 var ErrNotFound = errors.New("did not found under given key")
 
+// ErrNoSubjectID is returned when finding the near-duplicates of a credential without a single subject ID.
+var ErrNoSubjectID = errors.New("credential has no single subject ID")
+
 // Store stores vc
 type Store struct {
 	store storage.Store
@@ -75,6 +85,14 @@ func (s *Store) SaveCredential(name string, vc *verifiable.Credential) error {
 		return fmt.Errorf("store vc name to id map : %w", err)
 	}
 
+	if subjectID, err := verifiable.SubjectID(vc.Subject); err == nil {
+		if err := s.store.Put(credentialSubjectDataKey(subjectID, name), []byte(vc.ID)); err != nil {
+			return fmt.Errorf("store vc subject index : %w", err)
+		}
+	}
+
+	logger.Debugf("saved credential %s: %s", name, verifiable.RedactedCredential(vc))
+
 	return nil
 }
 
@@ -124,10 +142,143 @@ func (s *Store) GetCredentials() []*CredentialRecord {
 	return records
 }
 
+// RemoveCredentialByName removes the verifiable credential and its name mapping based on name.
+func (s *Store) RemoveCredentialByName(name string) error {
+	id, err := s.GetCredentialIDByName(name)
+	if err != nil {
+		return fmt.Errorf("get credential id using name : %w", err)
+	}
+
+	// the index entry of an unreadable credential is left, it is skipped by FindDuplicates once the vc is deleted
+	if vc, err := s.GetCredential(id); err == nil {
+		if subjectID, err := verifiable.SubjectID(vc.Subject); err == nil {
+			if err := s.store.Delete(credentialSubjectDataKey(subjectID, name)); err != nil {
+				return fmt.Errorf("delete vc subject index : %w", err)
+			}
+		}
+	}
+
+	if err := s.store.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete vc: %w", err)
+	}
+
+	if err := s.store.Delete(credentialNameDataKey(name)); err != nil {
+		return fmt.Errorf("delete vc name to id map : %w", err)
+	}
+
+	if err := s.store.Delete(fmt.Sprintf(credentialStatusKeyPattern, id)); err != nil {
+		return fmt.Errorf("delete vc status : %w", err)
+	}
+
+	return nil
+}
+
+// FindDuplicates returns the records of the stored credentials which are near-duplicates of the given one:
+// same issuer, same types and same subject ID. Credentials without a single subject ID have no near-duplicates,
+// ErrNoSubjectID is returned for them.
+func (s *Store) FindDuplicates(vc *verifiable.Credential) ([]*CredentialRecord, error) {
+	subjectID, err := verifiable.SubjectID(vc.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoSubjectID, err)
+	}
+
+	searchKey := fmt.Sprintf(credentialSubjectKeyPattern, subjectID)
+
+	itr := s.store.Iterator(searchKey, fmt.Sprintf(limitPattern, searchKey))
+	defer itr.Release()
+
+	var duplicates []*CredentialRecord
+
+	for itr.Next() {
+		record := &CredentialRecord{
+			Name: string(itr.Key())[len(searchKey):],
+			ID:   string(itr.Value()),
+		}
+
+		stored, err := s.GetCredential(record.ID)
+		if errors.Is(err, storage.ErrDataNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("get credential %s : %w", record.Name, err)
+		}
+
+		if isDuplicate(vc, stored, subjectID) {
+			duplicates = append(duplicates, record)
+		}
+	}
+
+	return duplicates, nil
+}
+
+// QueryCredentials returns the stored credentials whose subject matches, the subject being decoded into the
+// struct registered for the credential type (see verifiable.CredentialTypes): a pointer to the struct, or a slice
+// of pointers if the credential has several subjects. The credentials of the unregistered types are skipped.
+func (s *Store) QueryCredentials(types *verifiable.CredentialTypes,
+	match func(subject interface{}) bool) ([]*verifiable.Credential, error) {
+	var credentials []*verifiable.Credential
+
+	for _, record := range s.GetCredentials() {
+		vc, err := s.GetCredential(record.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get credential %s : %w", record.Name, err)
+		}
+
+		subject, err := types.Subject(vc)
+		if errors.Is(err, verifiable.ErrUnregisteredType) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("credential %s : %w", record.Name, err)
+		}
+
+		if match(subject) {
+			credentials = append(credentials, vc)
+		}
+	}
+
+	return credentials, nil
+}
+
+func isDuplicate(vc, stored *verifiable.Credential, subjectID string) bool {
+	if vc.Issuer.ID != stored.Issuer.ID || !sameTypes(vc.Types, stored.Types) {
+		return false
+	}
+
+	storedSubjectID, err := verifiable.SubjectID(stored.Subject)
+
+	return err == nil && storedSubjectID == subjectID
+}
+
+func sameTypes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	types := make(map[string]struct{}, len(a))
+	for _, t := range a {
+		types[t] = struct{}{}
+	}
+
+	for _, t := range b {
+		if _, ok := types[t]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
 func credentialNameDataKey(name string) string {
 	return fmt.Sprintf(credentialNameDataKeyPattern, name)
 }
 
+func credentialSubjectDataKey(subjectID, name string) string {
+	return fmt.Sprintf(credentialSubjectDataKeyPattern, subjectID, name)
+}
+
 func getCredentialName(dataKey string) string {
 	return dataKey[len(credentialNameKey):]
 }
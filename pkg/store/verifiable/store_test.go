@@ -7,9 +7,11 @@ SPDX-License-Identifier: Apache-2.0
 package verifiable
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 
@@ -289,3 +291,175 @@ func TestGetCredentials(t *testing.T) {
 		require.Equal(t, 1+n, len(records))
 	})
 }
+
+func newTestCredential(id, issuer, subject string, types ...string) *verifiable.Credential {
+	issued := time.Now()
+
+	return &verifiable.Credential{
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		ID:      id,
+		Types:   append([]string{"VerifiableCredential"}, types...),
+		Subject: subject,
+		Issuer:  verifiable.Issuer{ID: issuer},
+		Issued:  &issued,
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	t.Run("test find duplicates", func(t *testing.T) {
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: mockstore.NewMockStoreProvider(),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, s.SaveCredential("degree", newTestCredential("http://example.edu/credentials/1",
+			"did:example:issuer", "did:example:holder", "UniversityDegreeCredential")))
+		require.NoError(t, s.SaveCredential("other subject", newTestCredential("http://example.edu/credentials/2",
+			"did:example:issuer", "did:example:other", "UniversityDegreeCredential")))
+		require.NoError(t, s.SaveCredential("other issuer", newTestCredential("http://example.edu/credentials/3",
+			"did:example:other", "did:example:holder", "UniversityDegreeCredential")))
+		require.NoError(t, s.SaveCredential("other type", newTestCredential("http://example.edu/credentials/4",
+			"did:example:issuer", "did:example:holder", "DriversLicense")))
+
+		duplicates, err := s.FindDuplicates(newTestCredential("http://example.edu/credentials/5",
+			"did:example:issuer", "did:example:holder", "UniversityDegreeCredential"))
+		require.NoError(t, err)
+		require.Len(t, duplicates, 1)
+		require.Equal(t, "degree", duplicates[0].Name)
+		require.Equal(t, "http://example.edu/credentials/1", duplicates[0].ID)
+
+		duplicates, err = s.FindDuplicates(newTestCredential("http://example.edu/credentials/6",
+			"did:example:issuer", "did:example:new", "UniversityDegreeCredential"))
+		require.NoError(t, err)
+		require.Empty(t, duplicates)
+
+		require.NoError(t, s.RemoveCredentialByName("degree"))
+
+		duplicates, err = s.FindDuplicates(newTestCredential("http://example.edu/credentials/5",
+			"did:example:issuer", "did:example:holder", "UniversityDegreeCredential"))
+		require.NoError(t, err)
+		require.Empty(t, duplicates)
+	})
+
+	t.Run("test credential without subject id", func(t *testing.T) {
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: mockstore.NewMockStoreProvider(),
+		})
+		require.NoError(t, err)
+
+		vc := newTestCredential("http://example.edu/credentials/1", "did:example:issuer", "")
+		vc.Subject = []map[string]interface{}{{"id": "did:example:1"}, {"id": "did:example:2"}}
+
+		duplicates, err := s.FindDuplicates(vc)
+		require.True(t, errors.Is(err, ErrNoSubjectID))
+		require.Empty(t, duplicates)
+	})
+
+	t.Run("test error from get credential", func(t *testing.T) {
+		store := make(map[string][]byte)
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: &mockstore.MockStoreProvider{Store: &mockstore.MockStore{Store: store}},
+		})
+		require.NoError(t, err)
+
+		store[credentialSubjectDataKey("did:example:holder", "broken")] = []byte("vc1")
+		store["vc1"] = []byte("{")
+
+		_, err = s.FindDuplicates(newTestCredential("vc2", "did:example:issuer", "did:example:holder"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get credential broken")
+	})
+}
+
+type degreeSubject struct {
+	ID string `json:"id"`
+}
+
+func TestQueryCredentials(t *testing.T) {
+	types := verifiable.NewCredentialTypes()
+	require.NoError(t, types.Register("UniversityDegreeCredential", degreeSubject{}))
+
+	holder := func(subject interface{}) bool {
+		return subject.(*degreeSubject).ID == "did:example:holder"
+	}
+
+	t.Run("test query credentials", func(t *testing.T) {
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: mockstore.NewMockStoreProvider(),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, s.SaveCredential("degree", newTestCredential("http://example.edu/credentials/1",
+			"did:example:issuer", "did:example:holder", "UniversityDegreeCredential")))
+		require.NoError(t, s.SaveCredential("other subject", newTestCredential("http://example.edu/credentials/2",
+			"did:example:issuer", "did:example:other", "UniversityDegreeCredential")))
+		require.NoError(t, s.SaveCredential("other type", newTestCredential("http://example.edu/credentials/3",
+			"did:example:issuer", "did:example:holder", "DriversLicense")))
+
+		credentials, err := s.QueryCredentials(types, holder)
+		require.NoError(t, err)
+		require.Len(t, credentials, 1)
+		require.Equal(t, "http://example.edu/credentials/1", credentials[0].ID)
+	})
+
+	t.Run("test invalid subject", func(t *testing.T) {
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: mockstore.NewMockStoreProvider(),
+		})
+		require.NoError(t, err)
+
+		vc := newTestCredential("http://example.edu/credentials/1", "did:example:issuer", "",
+			"UniversityDegreeCredential")
+		vc.Subject = map[string]interface{}{"id": 42}
+		require.NoError(t, s.SaveCredential("invalid", vc))
+
+		_, err = s.QueryCredentials(types, holder)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "credential invalid : decode subject")
+	})
+
+	t.Run("test error from get credential", func(t *testing.T) {
+		store := make(map[string][]byte)
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: &mockstore.MockStoreProvider{Store: &mockstore.MockStore{Store: store}},
+		})
+		require.NoError(t, err)
+
+		store[credentialNameDataKey("broken")] = []byte("vc1")
+		store["vc1"] = []byte("{")
+
+		_, err = s.QueryCredentials(types, holder)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get credential broken")
+	})
+}
+
+func TestRemoveCredentialByName(t *testing.T) {
+	t.Run("test remove credential", func(t *testing.T) {
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: mockstore.NewMockStoreProvider(),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, s.SaveCredential(sampleCredentialName, &verifiable.Credential{ID: sampleCredentialID}))
+		require.NoError(t, s.RemoveCredentialByName(sampleCredentialName))
+
+		_, err = s.GetCredentialIDByName(sampleCredentialName)
+		require.Error(t, err)
+
+		_, err = s.GetCredential(sampleCredentialID)
+		require.Error(t, err)
+		require.Empty(t, s.GetCredentials())
+	})
+
+	t.Run("test remove unknown credential", func(t *testing.T) {
+		s, err := New(&mockprovider.Provider{
+			StorageProviderValue: mockstore.NewMockStoreProvider(),
+		})
+		require.NoError(t, err)
+
+		err = s.RemoveCredentialByName("unknown")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get credential id using name")
+	})
+}
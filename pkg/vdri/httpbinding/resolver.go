@@ -29,7 +29,8 @@ type didResolution struct {
 	MethodMetadata   map[string]interface{} `json:"methodMetadata"`
 }
 
-// resolveDID makes DID resolution via HTTP
+// resolveDID makes DID resolution via HTTP. The connection errors and the server errors (5xx) wrap
+// vdriapi.ErrTransient, the resolution may succeed later.
 func (v *VDRI) resolveDID(uri string) ([]byte, error) {
 	req, err := http.NewRequest(http.MethodGet, uri, nil)
 	if err != nil {
@@ -40,7 +41,7 @@ func (v *VDRI) resolveDID(uri string) ([]byte, error) {
 
 	resp, err := v.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP Get request failed: %w", err)
+		return nil, fmt.Errorf("%w: HTTP Get request failed: %s", vdriapi.ErrTransient, err)
 	}
 
 	defer closeResponseBody(resp.Body)
@@ -49,13 +50,16 @@ func (v *VDRI) resolveDID(uri string) ([]byte, error) {
 
 	gotBody, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response body failed: %w", err)
+		return nil, fmt.Errorf("%w: reading response body failed: %s", vdriapi.ErrTransient, err)
 	}
 
 	if resp.StatusCode == http.StatusOK && strings.Contains(resp.Header.Get("Content-type"), didLDJson) {
 		return gotBody, nil
 	} else if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("DID does not exist for request: %s", uri)
+		return nil, fmt.Errorf("%w: DID does not exist for request: %s", vdriapi.ErrNotFound, uri)
+	} else if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: DID resolver error [%v] body [%s]", vdriapi.ErrTransient, resp.StatusCode,
+			gotBody)
 	}
 
 	return nil, fmt.Errorf("unsupported response from DID resolver [%v] header [%s] body [%s]",
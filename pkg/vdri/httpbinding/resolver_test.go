@@ -242,6 +242,34 @@ func TestRead_DIDDocNotFound(t *testing.T) {
 	_, err = resolver.Read("did:example:334455")
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "DID does not exist")
+	require.True(t, errors.Is(err, vdriapi.ErrNotFound))
+}
+
+func TestRead_TransientFailure(t *testing.T) {
+	t.Run("server error", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusServiceUnavailable)
+		}))
+
+		defer func() { testServer.Close() }()
+
+		resolver, err := New(testServer.URL)
+		require.NoError(t, err)
+		_, err = resolver.Read("did:example:334455")
+		require.True(t, errors.Is(err, vdriapi.ErrTransient))
+		require.Contains(t, err.Error(), "DID resolver error [503]")
+	})
+
+	t.Run("connection error", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+		testServer.Close()
+
+		resolver, err := New(testServer.URL)
+		require.NoError(t, err)
+		_, err = resolver.Read("did:example:334455")
+		require.True(t, errors.Is(err, vdriapi.ErrTransient))
+		require.Contains(t, err.Error(), "HTTP Get request failed")
+	})
 }
 
 func TestRead_UnsupportedStatus(t *testing.T) {
@@ -256,6 +284,7 @@ func TestRead_UnsupportedStatus(t *testing.T) {
 	_, err = resolver.Read("did:example:334455")
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "unsupported response from DID resolver")
+	require.False(t, errors.Is(err, vdriapi.ErrTransient))
 }
 
 func TestRead_HTTPGetFailed(t *testing.T) {
@@ -35,6 +35,9 @@ type VDRI struct {
 	endpointURL string
 	client      *http.Client
 	accept      Accept
+	// methods are the DID methods set with WithMethods
+	methods      []string
+	capabilities []vdriapi.MethodCapabilities
 }
 
 // Accept is method to accept did method
@@ -64,6 +67,21 @@ func (v *VDRI) Accept(method string) bool {
 	return v.accept(method)
 }
 
+// Capabilities returns the capabilities of the DID methods set with WithCapabilities, or else the capabilities
+// of the DID methods set with WithMethods: the DIDs are created by the remote endpoint (see Build).
+func (v *VDRI) Capabilities() []vdriapi.MethodCapabilities {
+	if len(v.capabilities) > 0 {
+		return v.capabilities
+	}
+
+	capabilities := make([]vdriapi.MethodCapabilities, len(v.methods))
+	for i, method := range v.methods {
+		capabilities[i] = vdriapi.MethodCapabilities{Method: method, Create: true}
+	}
+
+	return capabilities
+}
+
 // Store did doc
 func (v *VDRI) Store(doc *did.Doc, by *[]vdriapi.ModifiedBy) error {
 	logger.Warnf(" store not supported in http binding vdri")
@@ -199,6 +217,31 @@ func WithAccept(accept Accept) Option {
 	}
 }
 
+// WithMethods option is for accept the given did methods only, their capabilities are derived from the binding
+// (see Capabilities)
+func WithMethods(methods ...string) Option {
+	return func(opts *VDRI) {
+		opts.methods = append(opts.methods, methods...)
+		opts.accept = func(method string) bool {
+			for _, m := range opts.methods {
+				if m == method {
+					return true
+				}
+			}
+
+			return false
+		}
+	}
+}
+
+// WithCapabilities option describes the DID methods supported by the remote endpoint
+// (the accepted methods can't be listed from the Accept function)
+func WithCapabilities(methods ...vdriapi.MethodCapabilities) Option {
+	return func(opts *VDRI) {
+		opts.capabilities = append(opts.capabilities, methods...)
+	}
+}
+
 func closeResponseBody(respBody io.Closer) {
 	e := respBody.Close()
 	if e != nil {
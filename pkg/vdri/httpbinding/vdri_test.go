@@ -30,6 +30,24 @@ func TestVDRI_Close(t *testing.T) {
 	})
 }
 
+func TestVDRI_Capabilities(t *testing.T) {
+	v, err := New("/did:example:334455")
+	require.NoError(t, err)
+	require.Empty(t, v.Capabilities())
+
+	sidetree := vdriapi.MethodCapabilities{Method: "sidetree", Create: true}
+
+	v, err = New("/did:example:334455", WithCapabilities(sidetree))
+	require.NoError(t, err)
+	require.Equal(t, []vdriapi.MethodCapabilities{sidetree}, v.Capabilities())
+
+	v, err = New("/did:example:334455", WithMethods("sidetree", "example"))
+	require.NoError(t, err)
+	require.Equal(t, []vdriapi.MethodCapabilities{sidetree, {Method: "example", Create: true}}, v.Capabilities())
+	require.True(t, v.Accept("example"))
+	require.False(t, v.Accept("peer"))
+}
+
 func TestVDRI_Build(t *testing.T) {
 	pubKey := &vdriapi.PubKey{Type: "sample-type", Value: "sample-value"}
 
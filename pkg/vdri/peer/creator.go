@@ -16,7 +16,8 @@ import (
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 )
 
-// Build builds new DID Document
+// Build builds new DID Document, of the numalgo 1 unless another numalgo is set (see vdriapi.WithNumAlgo).
+// The numalgo 0 DIDs have no service.
 func (v *VDRI) Build(pubKey *vdriapi.PubKey, opts ...vdriapi.DocOpts) (*did.Doc, error) {
 	docOpts := &vdriapi.CreateDIDOpts{}
 	// Apply options
@@ -24,7 +25,22 @@ func (v *VDRI) Build(pubKey *vdriapi.PubKey, opts ...vdriapi.DocOpts) (*did.Doc,
 		opt(docOpts)
 	}
 
-	didDoc, err := build(pubKey, docOpts)
+	var (
+		didDoc *did.Doc
+		err    error
+	)
+
+	switch docOpts.NumAlgo {
+	case "", NumAlgo1:
+		didDoc, err = build(pubKey, docOpts)
+	case NumAlgo0:
+		didDoc, err = buildNumAlgo0(pubKey)
+	case NumAlgo2:
+		didDoc, err = buildNumAlgo2(pubKey, docOpts)
+	default:
+		err = fmt.Errorf("numalgo %s is not supported", docOpts.NumAlgo)
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("create peer DID : %w", err)
 	}
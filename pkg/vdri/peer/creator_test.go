@@ -96,3 +96,16 @@ func getSigningKey() *api.PubKey {
 
 	return &api.PubKey{Value: base58.Encode(pub[:]), Type: keyType}
 }
+
+func TestCapabilities(t *testing.T) {
+	v, err := New(&storage.MockStoreProvider{})
+	require.NoError(t, err)
+
+	caps := v.Capabilities()
+	require.Len(t, caps, 1)
+	require.Equal(t, didMethod, caps[0].Method)
+	require.True(t, caps[0].Create)
+	require.False(t, caps[0].Update)
+	require.False(t, caps[0].Deactivate)
+	require.Equal(t, []string{ed25519KeyType}, caps[0].KeyTypes)
+}
@@ -0,0 +1,263 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package peer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	multibase "github.com/multiformats/go-multibase"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+)
+
+// The numeric algorithms of the peer DIDs.
+// Reference: https://identity.foundation/peer-did-method-spec/#generation-method
+const (
+	// NumAlgo0 is the inception key without doc: the DID is the multibase encoded key, it has no service.
+	NumAlgo0 = "0"
+	// NumAlgo1 is the genesis doc: the DID is the hash of the stored genesis version of the doc (the default).
+	NumAlgo1 = "1"
+	// NumAlgo2 is the multiple inception keys and service: the DID encodes the keys and the service of the doc.
+	NumAlgo2 = "2"
+
+	x25519KeyType = "X25519KeyAgreementKey2019"
+
+	// purposes of the elements of the numalgo 2 DIDs
+	purposeAuthentication = 'V'
+	purposeKeyAgreement   = 'E'
+	purposeService        = 'S'
+
+	// abbreviation of the DIDCommMessaging service type in the numalgo 2 DIDs
+	didCommMessagingType        = "DIDCommMessaging"
+	didCommMessagingAbbreviated = "dm"
+)
+
+// multicodec prefixes (varint) of the public keys
+// nolint:gochecknoglobals
+var (
+	ed25519Codec = []byte{0xed, 0x01}
+	x25519Codec  = []byte{0xec, 0x01}
+)
+
+// numAlgo2Service is the abbreviated service encoded in the numalgo 2 DIDs.
+type numAlgo2Service struct {
+	Type            string   `json:"t"`
+	ServiceEndpoint string   `json:"s"`
+	RoutingKeys     []string `json:"r,omitempty"`
+}
+
+// isStatic returns true if the doc of the DID is derived from the DID (numalgo 0 and 2), it needs no storage.
+func isStatic(didID string) bool {
+	return isNumAlgo0(didID) || isNumAlgo2(didID)
+}
+
+// IsUpdatable returns true if the document of the peer DID can be updated: the doc of a numalgo 0 or 2 DID
+// is derived from the DID, it cannot change.
+func IsUpdatable(didID string) bool {
+	return strings.HasPrefix(didID, peerPrefix) && !isStatic(didID)
+}
+
+// isNumAlgo0 returns true for the numalgo 0 DIDs, the key following the numalgo is multibase encoded.
+// The numalgo alone is not enough: the legacy peer DIDs don't start with a numalgo.
+func isNumAlgo0(didID string) bool {
+	return strings.HasPrefix(didID, peerPrefix+NumAlgo0+string(rune(transform)))
+}
+
+// isNumAlgo2 returns true for the numalgo 2 DIDs, the elements following the numalgo are separated by dots.
+func isNumAlgo2(didID string) bool {
+	return strings.HasPrefix(didID, peerPrefix+NumAlgo2+".")
+}
+
+// buildNumAlgo0 builds the doc of the numalgo 0 DID of the inception key.
+func buildNumAlgo0(pubKey *vdriapi.PubKey) (*did.Doc, error) {
+	key, err := multibaseKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveNumAlgo0(peerPrefix + NumAlgo0 + key)
+}
+
+// resolveNumAlgo0 resolves the numalgo 0 DID: the doc has the inception key for authentication.
+func resolveNumAlgo0(didID string) (*did.Doc, error) {
+	key := strings.TrimPrefix(didID, peerPrefix+NumAlgo0)
+	if len(key) < 2 {
+		return nil, fmt.Errorf("invalid numalgo 0 DID: %s", didID)
+	}
+
+	publicKey, err := decodeKey(didID, "#"+key[1:], key, ed25519Codec, ed25519KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := did.BuildDoc(did.WithPublicKey([]did.PublicKey{*publicKey}))
+	doc.ID = didID
+	doc.Authentication = []did.VerificationMethod{{PublicKey: *publicKey}}
+
+	return doc, nil
+}
+
+// buildNumAlgo2 builds the doc of the numalgo 2 DID of the key (authentication) and service.
+func buildNumAlgo2(pubKey *vdriapi.PubKey, docOpts *vdriapi.CreateDIDOpts) (*did.Doc, error) {
+	key, err := multibaseKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	didID := peerPrefix + NumAlgo2 + "." + string(purposeAuthentication) + key
+
+	if docOpts.ServiceType != "" {
+		serviceType := docOpts.ServiceType
+		if serviceType == didCommMessagingType {
+			serviceType = didCommMessagingAbbreviated
+		}
+
+		service, err := json.Marshal(&numAlgo2Service{
+			Type:            serviceType,
+			ServiceEndpoint: docOpts.ServiceEndpoint,
+			RoutingKeys:     docOpts.RoutingKeys,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal service: %w", err)
+		}
+
+		didID += "." + string(purposeService) + base64.RawURLEncoding.EncodeToString(service)
+	}
+
+	return resolveNumAlgo2(didID)
+}
+
+// resolveNumAlgo2 resolves the numalgo 2 DID: the keys and the service of the doc are decoded from the DID.
+func resolveNumAlgo2(didID string) (*did.Doc, error) {
+	elements := strings.Split(strings.TrimPrefix(didID, peerPrefix+NumAlgo2), ".")
+	if len(elements) < 2 || elements[0] != "" {
+		return nil, fmt.Errorf("invalid numalgo 2 DID: %s", didID)
+	}
+
+	doc := did.BuildDoc()
+	doc.ID = didID
+
+	var services []string
+
+	for i, element := range elements[1:] {
+		if element == "" {
+			return nil, fmt.Errorf("invalid numalgo 2 DID: %s", didID)
+		}
+
+		id := fmt.Sprintf("#key-%d", i+1)
+
+		switch element[0] {
+		case purposeAuthentication:
+			publicKey, err := decodeKey(didID, id, element[1:], ed25519Codec, ed25519KeyType)
+			if err != nil {
+				return nil, err
+			}
+
+			doc.PublicKey = append(doc.PublicKey, *publicKey)
+			doc.Authentication = append(doc.Authentication, did.VerificationMethod{PublicKey: *publicKey})
+		case purposeKeyAgreement:
+			publicKey, err := decodeKey(didID, id, element[1:], x25519Codec, x25519KeyType)
+			if err != nil {
+				return nil, err
+			}
+
+			doc.PublicKey = append(doc.PublicKey, *publicKey)
+			doc.KeyAgreement = append(doc.KeyAgreement, did.VerificationMethod{PublicKey: *publicKey})
+		case purposeService:
+			services = append(services, element[1:])
+		default:
+			return nil, fmt.Errorf("invalid numalgo 2 DID: unsupported purpose %c", element[0])
+		}
+	}
+
+	for i, encoded := range services {
+		service, err := decodeService(encoded, doc)
+		if err != nil {
+			return nil, err
+		}
+
+		service.ID = "#agent"
+		if i > 0 {
+			service.ID = fmt.Sprintf("#agent-%d", i)
+		}
+
+		doc.Service = append(doc.Service, *service)
+	}
+
+	return doc, nil
+}
+
+func decodeService(encoded string, doc *did.Doc) (*did.Service, error) {
+	src, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode service: %w", err)
+	}
+
+	var abbreviated numAlgo2Service
+	if err := json.Unmarshal(src, &abbreviated); err != nil {
+		return nil, fmt.Errorf("unmarshal service: %w", err)
+	}
+
+	service := &did.Service{
+		Type:            abbreviated.Type,
+		ServiceEndpoint: abbreviated.ServiceEndpoint,
+		RoutingKeys:     abbreviated.RoutingKeys,
+	}
+
+	if service.Type == didCommMessagingAbbreviated {
+		service.Type = didCommMessagingType
+	}
+
+	// the messages are packed for the authentication keys, as for the numalgo 1 DIDs
+	if service.Type == vdriapi.DIDCommServiceType {
+		for _, vm := range doc.Authentication {
+			service.RecipientKeys = append(service.RecipientKeys, base58.Encode(vm.PublicKey.Value))
+		}
+	}
+
+	return service, nil
+}
+
+// multibaseKey encodes the ed25519 key as a multibase (base58) multicodec key, e.g z6Mk...
+func multibaseKey(pubKey *vdriapi.PubKey) (string, error) {
+	if pubKey.Type != ed25519KeyType {
+		return "", fmt.Errorf("key type %s is not supported", pubKey.Type)
+	}
+
+	// TODO fix hardcode base58 https://github.com/hyperledger/aries-framework-go/issues/1207
+	key := base58.Decode(pubKey.Value)
+	if len(key) == 0 {
+		return "", errors.New("invalid public key")
+	}
+
+	return multibase.Encode(transform, append(append([]byte{}, ed25519Codec...), key...))
+}
+
+func decodeKey(didID, id, encoded string, codec []byte, keyType string) (*did.PublicKey, error) {
+	encoding, key, err := multibase.Decode(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode key %s: %w", id, err)
+	}
+
+	if encoding != transform || !bytes.HasPrefix(key, codec) || len(key) == len(codec) {
+		return nil, fmt.Errorf("decode key %s: not a multibase %s key", id, keyType)
+	}
+
+	return &did.PublicKey{
+		ID:         id,
+		Type:       keyType,
+		Controller: didID,
+		Value:      key[len(codec):],
+	}, nil
+}
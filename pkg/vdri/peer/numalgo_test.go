@@ -0,0 +1,199 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package peer
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+)
+
+func newEd25519Key(t *testing.T) (*api.PubKey, ed25519.PublicKey) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return &api.PubKey{Value: base58.Encode(pub), Type: ed25519KeyType}, pub
+}
+
+func TestNumAlgo0(t *testing.T) {
+	v, err := New(storage.NewMockStoreProvider())
+	require.NoError(t, err)
+
+	t.Run("create and resolve", func(t *testing.T) {
+		pubKey, pub := newEd25519Key(t)
+
+		doc, err := v.Build(pubKey, api.WithNumAlgo(NumAlgo0), api.WithServiceType(api.DIDCommServiceType))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(doc.ID, "did:peer:0z6Mk"))
+		require.Len(t, doc.PublicKey, 1)
+		require.Equal(t, []byte(pub), doc.PublicKey[0].Value)
+		require.Equal(t, ed25519KeyType, doc.PublicKey[0].Type)
+		require.Len(t, doc.Authentication, 1)
+		require.Empty(t, doc.Service)
+
+		// not stored, the doc is resolved from the DID
+		require.NoError(t, v.Store(doc, nil))
+
+		resolved, err := v.Read(doc.ID)
+		require.NoError(t, err)
+		require.Equal(t, doc, resolved)
+	})
+
+	t.Run("spec example", func(t *testing.T) {
+		doc, err := v.Read("did:peer:0z6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH")
+		require.NoError(t, err)
+		require.Equal(t, "#6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH", doc.PublicKey[0].ID)
+		require.Len(t, doc.PublicKey[0].Value, ed25519.PublicKeySize)
+	})
+
+	t.Run("invalid DIDs", func(t *testing.T) {
+		_, err := v.Read("did:peer:0z")
+		require.EqualError(t, err, "invalid numalgo 0 DID: did:peer:0z")
+
+		_, err = v.Read("did:peer:0zinvalid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode key")
+
+		// a key agreement key
+		_, err = v.Read("did:peer:0z6LSbysY2xFMRpGMhb7tFTLMpeuPRaqaWM1yECx2AtzE3KCc")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not a multibase Ed25519VerificationKey2018 key")
+	})
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		_, err := v.Build(getSigningKey(), api.WithNumAlgo(NumAlgo0))
+		require.EqualError(t, err, "create peer DID : key type key-type is not supported")
+	})
+}
+
+func TestNumAlgo2(t *testing.T) {
+	v, err := New(storage.NewMockStoreProvider())
+	require.NoError(t, err)
+
+	t.Run("create and resolve", func(t *testing.T) {
+		pubKey, pub := newEd25519Key(t)
+
+		doc, err := v.Build(pubKey, api.WithNumAlgo(NumAlgo2),
+			api.WithServiceType(api.DIDCommServiceType),
+			api.WithServiceEndpoint("https://agent.example.com"),
+			api.WithRoutingKeys([]string{"routing-key"}),
+		)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(doc.ID, "did:peer:2.Vz6Mk"))
+		require.Len(t, doc.Authentication, 1)
+		require.Equal(t, []byte(pub), doc.Authentication[0].PublicKey.Value)
+		require.Len(t, doc.Service, 1)
+		require.Equal(t, "#agent", doc.Service[0].ID)
+		require.Equal(t, api.DIDCommServiceType, doc.Service[0].Type)
+		require.Equal(t, "https://agent.example.com", doc.Service[0].ServiceEndpoint)
+		require.Equal(t, []string{"routing-key"}, doc.Service[0].RoutingKeys)
+		require.Equal(t, []string{pubKey.Value}, doc.Service[0].RecipientKeys)
+
+		require.NoError(t, v.Store(doc, nil))
+
+		resolved, err := v.Read(doc.ID)
+		require.NoError(t, err)
+		require.Equal(t, doc, resolved)
+	})
+
+	t.Run("without service", func(t *testing.T) {
+		pubKey, _ := newEd25519Key(t)
+
+		doc, err := v.Build(pubKey, api.WithNumAlgo(NumAlgo2))
+		require.NoError(t, err)
+		require.Empty(t, doc.Service)
+		require.Len(t, strings.Split(doc.ID, "."), 2)
+	})
+
+	t.Run("DIDCommMessaging service", func(t *testing.T) {
+		pubKey, _ := newEd25519Key(t)
+
+		doc, err := v.Build(pubKey, api.WithNumAlgo(NumAlgo2), api.WithServiceType(didCommMessagingType))
+		require.NoError(t, err)
+		require.Contains(t, doc.ID, ".S"+base64.RawURLEncoding.EncodeToString([]byte(`{"t":"dm","s":""}`)))
+		require.Equal(t, didCommMessagingType, doc.Service[0].Type)
+		require.Empty(t, doc.Service[0].RecipientKeys)
+	})
+
+	t.Run("spec example", func(t *testing.T) {
+		doc, err := v.Read("did:peer:2" +
+			".Ez6LSbysY2xFMRpGMhb7tFTLMpeuPRaqaWM1yECx2AtzE3KCc" +
+			".Vz6MkqRYqQiSgvZQdnBytw86Qbs2ZWUkGv22od935YF4s8M7V" +
+			".Vz6MkgoLTnTypo3tDRwCkZXSccTPHRLhF4ZnjhueYAFpEX6vg" +
+			".SeyJ0IjoiZG0iLCJzIjoiaHR0cHM6Ly9leGFtcGxlLmNvbS9lbmRwb2ludCIsInIiOlsiZGlkOmV4YW1wbGU6c29tZW1lZGlh" +
+			"dG9yI3NvbWVrZXkiXX0" +
+			".SeyJ0IjoiZG0iLCJzIjoiaHR0cHM6Ly9leGFtcGxlLmNvbS9lbmRwb2ludDIiLCJyIjpbImRpZDpleGFtcGxlOnNvbWVtZWRp" +
+			"YXRvciNzb21la2V5MiJdfQ")
+		require.NoError(t, err)
+		require.Len(t, doc.PublicKey, 3)
+		require.Len(t, doc.KeyAgreement, 1)
+		require.Equal(t, "#key-1", doc.KeyAgreement[0].PublicKey.ID)
+		require.Equal(t, x25519KeyType, doc.KeyAgreement[0].PublicKey.Type)
+		require.Len(t, doc.Authentication, 2)
+		require.Equal(t, "#key-3", doc.Authentication[1].PublicKey.ID)
+		require.Len(t, doc.Service, 2)
+		require.Equal(t, didCommMessagingType, doc.Service[0].Type)
+		require.Equal(t, "https://example.com/endpoint", doc.Service[0].ServiceEndpoint)
+		require.Equal(t, []string{"did:example:somemediator#somekey"}, doc.Service[0].RoutingKeys)
+		require.Equal(t, "#agent-1", doc.Service[1].ID)
+		require.Equal(t, "https://example.com/endpoint2", doc.Service[1].ServiceEndpoint)
+	})
+
+	t.Run("invalid DIDs", func(t *testing.T) {
+		for _, didID := range []string{"did:peer:2.", "did:peer:2..Vz6Mk"} {
+			_, err := v.Read(didID)
+			require.EqualError(t, err, "invalid numalgo 2 DID: "+didID)
+		}
+
+		_, err := v.Read("did:peer:2.Xz6Mk")
+		require.EqualError(t, err, "invalid numalgo 2 DID: unsupported purpose X")
+
+		_, err = v.Read("did:peer:2.Vz6LSbysY2xFMRpGMhb7tFTLMpeuPRaqaWM1yECx2AtzE3KCc")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode key #key-1")
+
+		_, err = v.Read("did:peer:2.Ez6MkqRYqQiSgvZQdnBytw86Qbs2ZWUkGv22od935YF4s8M7V")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not a multibase X25519KeyAgreementKey2019 key")
+
+		_, err = v.Read("did:peer:2.S!")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode service")
+
+		_, err = v.Read("did:peer:2.S" + base64.RawURLEncoding.EncodeToString([]byte("service")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal service")
+	})
+}
+
+func TestLegacyPeerDIDs(t *testing.T) {
+	v, err := New(storage.NewMockStoreProvider())
+	require.NoError(t, err)
+
+	// the DIDs starting with a numalgo digit are not mistaken for the numalgo 0 and 2 DIDs, they are read from the store
+	for _, didID := range []string{"did:peer:0", "did:peer:2", "did:peer:2Vz6Mk", "did:peer:21tDAKCERh95uGgKbJNHYp"} {
+		_, err = v.Read(didID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "fetching data from store failed", didID)
+	}
+}
+
+func TestUnsupportedNumAlgo(t *testing.T) {
+	v, err := New(storage.NewMockStoreProvider())
+	require.NoError(t, err)
+
+	_, err = v.Build(getSigningKey(), api.WithNumAlgo("3"))
+	require.EqualError(t, err, "create peer DID : numalgo 3 is not supported")
+}
@@ -14,7 +14,15 @@ import (
 )
 
 // Read implements didresolver.DidMethod.Read interface (https://w3c-ccg.github.io/did-resolution/#resolving-input)
+// The numalgo 0 and 2 DIDs are resolved from the DID itself.
 func (v *VDRI) Read(didID string, _ ...vdriapi.ResolveOpts) (*did.Doc, error) {
+	switch {
+	case isNumAlgo0(didID):
+		return resolveNumAlgo0(didID)
+	case isNumAlgo2(didID):
+		return resolveNumAlgo2(didID)
+	}
+
 	// get the document from the store
 	doc, err := v.Get(didID)
 	if err != nil {
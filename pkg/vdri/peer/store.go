@@ -15,6 +15,7 @@ import (
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
 )
 
 type docDelta struct {
@@ -23,33 +24,47 @@ type docDelta struct {
 	ModifiedAt time.Time             `json:"when,omitempty"`
 }
 
-// Store saves Peer DID Document along with user key/signature.
+// Store saves Peer DID Document along with user key/signature. The document of a DID already stored is an update
+// of the document: it is appended to the deltas of the DID, Get returns the latest document.
 func (v *VDRI) Store(doc *did.Doc, by *[]vdriapi.ModifiedBy) error {
 	if doc == nil || doc.ID == "" {
 		return errors.New("DID and document are mandatory")
 	}
 
-	var deltas []docDelta
+	// the numalgo 0 and 2 DIDs are resolved from the DID itself
+	if isStatic(doc.ID) {
+		return nil
+	}
+
+	deltas, err := v.getDeltas(doc.ID)
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+		return fmt.Errorf("delta data fetch from store failed: %w", err)
+	}
 
-	// For now, assume the doc is a genesis document
 	jsonDoc, err := doc.JSONBytes()
 	if err != nil {
 		return fmt.Errorf("JSON marshalling of document failed: %w", err)
 	}
 
-	docDelta := &docDelta{
-		Change:     base64.URLEncoding.EncodeToString(jsonDoc),
-		ModifiedBy: by,
-		ModifiedAt: time.Now(),
+	change := base64.URLEncoding.EncodeToString(jsonDoc)
+
+	// the document is unchanged
+	if len(deltas) > 0 && deltas[len(deltas)-1].Change == change {
+		return nil
 	}
 
-	deltas = append(deltas, *docDelta)
+	deltas = append(deltas, docDelta{
+		Change:     change,
+		ModifiedBy: by,
+		ModifiedAt: time.Now(),
+	})
 
 	val, err := json.Marshal(deltas)
 	if err != nil {
 		return fmt.Errorf("JSON marshalling of document deltas failed: %w", err)
 	}
 
+	// the deltas are saved at once, the update of the document is atomic
 	return v.store.Put(doc.ID, val)
 }
 
@@ -64,8 +79,12 @@ func (v *VDRI) Get(id string) (*did.Doc, error) {
 		return nil, fmt.Errorf("delta data fetch from store failed: %w", err)
 	}
 
-	// For now, assume storage contains only one delta(genesis document)
-	delta := deltas[0]
+	if len(deltas) == 0 {
+		return nil, errors.New("no document delta found")
+	}
+
+	// the latest document
+	delta := deltas[len(deltas)-1]
 
 	doc, err := base64.URLEncoding.DecodeString(delta.Change)
 	if err != nil {
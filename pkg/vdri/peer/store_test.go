@@ -70,6 +70,57 @@ func TestPeerDIDStore(t *testing.T) {
 	require.Contains(t, err.Error(), "delta data fetch from store failed")
 }
 
+func TestPeerDIDStore_Update(t *testing.T) {
+	prov := storage.NewMockStoreProvider()
+
+	store, err := New(prov)
+	require.NoError(t, err)
+
+	const didID = "did:peer:1234"
+
+	genesis := &did.Doc{Context: []string{"https://w3id.org/did/v1"}, ID: didID}
+	require.NoError(t, store.Store(genesis, nil))
+
+	updated := &did.Doc{Context: genesis.Context, ID: didID, Service: []did.Service{{
+		ID:              "#agent",
+		Type:            "did-communication",
+		ServiceEndpoint: "https://example.com/agent",
+	}}}
+	require.NoError(t, store.Store(updated, nil))
+
+	// unchanged document
+	require.NoError(t, store.Store(updated, nil))
+
+	deltas, err := store.getDeltas(didID)
+	require.NoError(t, err)
+	require.Len(t, deltas, 2)
+
+	doc, err := store.Get(didID)
+	require.NoError(t, err)
+	require.Len(t, doc.Service, 1)
+	require.Equal(t, "https://example.com/agent", doc.Service[0].ServiceEndpoint)
+
+	t.Run("store errors", func(t *testing.T) {
+		dbstore, err := prov.OpenStore(StoreNamespace)
+		require.NoError(t, err)
+
+		require.NoError(t, dbstore.Put(didID, []byte("not json")))
+		require.Contains(t, store.Store(updated, nil).Error(), "delta data fetch from store failed")
+
+		require.NoError(t, dbstore.Put(didID, []byte("[]")))
+		_, err = store.Get(didID)
+		require.EqualError(t, err, "no document delta found")
+	})
+}
+
+func TestIsUpdatable(t *testing.T) {
+	require.True(t, IsUpdatable("did:peer:1zQmZMygzYqNwU6Uhmewx5Xepf2VLp5S4HLSwwgf2aiKZuwa"))
+	require.True(t, IsUpdatable("did:peer:21tDAKCERh95uGgKbJNHYp"))
+	require.False(t, IsUpdatable("did:peer:0z6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH"))
+	require.False(t, IsUpdatable("did:peer:2.Ez6LSbysY2xFMRpGMhb7tFTLMpeuPRaqaWM1yECx2AtzE3KCc"))
+	require.False(t, IsUpdatable("did:example:123"))
+}
+
 func TestVDRI_Close(t *testing.T) {
 	t.Run("test success", func(t *testing.T) {
 		v, err := New(&storage.MockStoreProvider{})
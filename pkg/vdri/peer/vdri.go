@@ -9,12 +9,15 @@ package peer
 import (
 	"fmt"
 
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
 )
 
 const (
 	// StoreNamespace store name space for DID Store
 	StoreNamespace = "peer"
+
+	ed25519KeyType = "Ed25519VerificationKey2018"
 )
 
 // VDRI implements building new peer dids
@@ -36,3 +39,12 @@ func New(s storage.Provider) (*VDRI, error) {
 func (v *VDRI) Accept(method string) bool {
 	return method == didMethod
 }
+
+// Capabilities of the peer vdri: peer DIDs can be created, update and deactivate are not supported yet
+func (v *VDRI) Capabilities() []vdriapi.MethodCapabilities {
+	return []vdriapi.MethodCapabilities{{
+		Method:   didMethod,
+		Create:   true,
+		KeyTypes: []string{ed25519KeyType},
+	}}
+}
@@ -11,9 +11,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/btcsuite/btcutil/base58"
+
 	diddoc "github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/store/keyid"
 )
 
 const (
@@ -34,6 +37,8 @@ type Registry struct {
 	crypto             legacykms.KeyManager
 	defServiceEndpoint string
 	defServiceType     string
+	// keys indexes the keys of the created DIDs, nil if not set
+	keys *keyid.Store
 }
 
 // New return new instance of vdri
@@ -113,6 +118,13 @@ func (r *Registry) Create(didMethod string, opts ...vdriapi.DocOpts) (*diddoc.Do
 		return nil, err
 	}
 
+	if r.keys != nil {
+		// the legacy KMS keys are identified by their base58 encoded value
+		if err := r.keys.SaveKey(doc, base58PubKey, base58.Decode(base58PubKey)); err != nil {
+			return nil, fmt.Errorf("index key ID: %w", err)
+		}
+	}
+
 	return doc, nil
 }
 
@@ -155,6 +167,33 @@ func (r *Registry) Close() error {
 	return nil
 }
 
+// SupportedMethods returns the capabilities of the DID methods supported by the registered VDRIs.
+// When several VDRIs support the same method, the first one is used (the one resolving this method).
+func (r *Registry) SupportedMethods() []vdriapi.MethodCapabilities {
+	var methods []vdriapi.MethodCapabilities
+
+	seen := make(map[string]bool)
+
+	for _, v := range r.vdri {
+		cp, ok := v.(vdriapi.CapabilitiesProvider)
+		if !ok {
+			continue
+		}
+
+		for _, c := range cp.Capabilities() {
+			if seen[c.Method] {
+				continue
+			}
+
+			seen[c.Method] = true
+
+			methods = append(methods, c)
+		}
+	}
+
+	return methods
+}
+
 func (r *Registry) resolveVDRI(method string) (vdriapi.VDRI, error) {
 	for _, v := range r.vdri {
 		if v.Accept(method) {
@@ -186,6 +225,14 @@ func WithDefaultServiceEndpoint(serviceEndpoint string) Option {
 	}
 }
 
+// WithKeyIDStore indexes the keys of the created DIDs with the DID URLs publishing them (see keyid.Store),
+// so that the signing code finds the key of a verification method.
+func WithKeyIDStore(keys *keyid.Store) Option {
+	return func(opts *Registry) {
+		opts.keys = keys
+	}
+}
+
 func getDidMethod(didID string) (string, error) {
 	// TODO https://github.com/hyperledger/aries-framework-go/issues/20 Validate that the input DID conforms to
 	//  the did rule of the Generic DID Syntax. Reference: https://w3c-ccg.github.io/did-spec/#generic-did-syntax
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/stretchr/testify/require"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
@@ -17,6 +18,8 @@ import (
 	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
 	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/legacykms"
 	mockvdri "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/store/keyid"
 )
 
 func TestRegistry_New(t *testing.T) {
@@ -203,4 +206,54 @@ func TestRegistry_Create(t *testing.T) {
 		_, err := registry.Create("id")
 		require.NoError(t, err)
 	})
+	t.Run("test key is indexed", func(t *testing.T) {
+		keys, err := keyid.New(&mockprovider.Provider{StorageProviderValue: mem.NewProvider()})
+		require.NoError(t, err)
+
+		verKey := base58.Encode([]byte("key"))
+
+		registry := New(&mockprovider.Provider{KMSValue: &mockkms.CloseableKMS{CreateSigningKeyValue: verKey}},
+			WithKeyIDStore(keys),
+			WithVDRI(&mockvdri.MockVDRI{AcceptValue: true,
+				BuildFunc: func(pubKey *vdriapi.PubKey, opts ...vdriapi.DocOpts) (doc *did.Doc, e error) {
+					return &did.Doc{ID: "did:id:123", PublicKey: []did.PublicKey{
+						{ID: "#key-1", Type: pubKey.Type, Value: base58.Decode(pubKey.Value)},
+					}}, nil
+				}}))
+		_, err = registry.Create("id")
+		require.NoError(t, err)
+
+		keyID, err := keys.KeyID("did:id:123#key-1")
+		require.NoError(t, err)
+		require.Equal(t, verKey, keyID)
+	})
+}
+
+type capabilitiesVDRI struct {
+	mockvdri.MockVDRI
+	methods []vdriapi.MethodCapabilities
+}
+
+func (v *capabilitiesVDRI) Capabilities() []vdriapi.MethodCapabilities {
+	return v.methods
+}
+
+func TestRegistry_SupportedMethods(t *testing.T) {
+	t.Run("test no vdri", func(t *testing.T) {
+		require.Empty(t, New(&mockprovider.Provider{}).SupportedMethods())
+	})
+
+	t.Run("test supported methods", func(t *testing.T) {
+		peer := vdriapi.MethodCapabilities{Method: "peer", Create: true, KeyTypes: []string{defaultKeyType}}
+		sidetree := vdriapi.MethodCapabilities{Method: "sidetree", Create: true, Update: true, Deactivate: true}
+
+		registry := New(&mockprovider.Provider{},
+			WithVDRI(&mockvdri.MockVDRI{AcceptValue: true}),
+			WithVDRI(&capabilitiesVDRI{methods: []vdriapi.MethodCapabilities{peer}}),
+			WithVDRI(&capabilitiesVDRI{methods: []vdriapi.MethodCapabilities{
+				{Method: "peer"}, sidetree,
+			}}))
+
+		require.Equal(t, []vdriapi.MethodCapabilities{peer, sidetree}, registry.SupportedMethods())
+	})
 }
@@ -0,0 +1,242 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/legacykms"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+var logger = log.New("aries-framework/vdri/web")
+
+const (
+	// WellKnownPath is the path the DID document of a did:web DID without path is published at,
+	// the Publisher is mounted at it (e.g http.Handle(web.WellKnownPath, publisher)).
+	WellKnownPath = "/.well-known/did.json"
+
+	// StoreName is the name of the store keeping the keys of the published DID documents.
+	StoreName = "webdid"
+
+	didWebPrefix        = "did:web:"
+	ed25519KeyType      = "Ed25519VerificationKey2018"
+	keyIndex1           = "#key-1"
+	didCommServiceIndex = "#did-communication"
+	keyDataKey          = "key_%s"
+)
+
+// Provider contains dependencies of the Publisher and is typically created by using aries.Context().
+type Provider interface {
+	LegacyKMS() legacykms.KeyManager
+	ServiceEndpoint() string
+	Service(id string) (interface{}, error)
+	StorageProvider() storage.Provider
+}
+
+// ErrNotPublished is returned when the DID document was not published yet, see Publisher.Publish.
+var ErrNotPublished = errors.New("DID document not published")
+
+// Publisher generates the DID document of the did:web DID of the agent and publishes it over HTTP.
+// The key of the document is created by the KMS once and kept in the store, the DIDComm service endpoint
+// is the endpoint of the inbound transports or, once the agent is registered with a mediator,
+// the endpoint and the routing keys of the mediator.
+//
+// The document is built by Publish and served as is: Publish must be called again once the agent registered
+// with a mediator so that the served document is up to date.
+type Publisher struct {
+	didID           string
+	legacyKMS       legacykms.KeyManager
+	routeSvc        route.ProtocolService
+	serviceEndpoint string
+	store           storage.Store
+	lock            sync.RWMutex
+	// routerEndpoint is the endpoint of the mediator the key was added to
+	routerEndpoint string
+	// doc is the published document and src its JSON
+	doc *did.Doc
+	src []byte
+}
+
+// New returns a new Publisher of the DID document of the did:web DID of the domain (e.g example.com,
+// the port is allowed).
+func New(domain string, ctx Provider) (*Publisher, error) {
+	if domain == "" || strings.ContainsAny(domain, "/") {
+		return nil, fmt.Errorf("invalid domain: %q", domain)
+	}
+
+	s, err := ctx.Service(route.Coordination)
+	if err != nil {
+		return nil, err
+	}
+
+	routeSvc, ok := s.(route.ProtocolService)
+	if !ok {
+		return nil, errors.New("cast service to Route Service failed")
+	}
+
+	store, err := ctx.StorageProvider().OpenStore(StoreName)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+
+	return &Publisher{
+		didID:           didWebPrefix + strings.ReplaceAll(domain, ":", "%3A"),
+		legacyKMS:       ctx.LegacyKMS(),
+		routeSvc:        routeSvc,
+		serviceEndpoint: ctx.ServiceEndpoint(),
+		store:           store,
+	}, nil
+}
+
+// DID returns the did:web DID of the published document.
+func (p *Publisher) DID() string {
+	return p.didID
+}
+
+// Document returns the published DID document, ErrNotPublished if it was not published yet.
+func (p *Publisher) Document() (*did.Doc, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.doc == nil {
+		return nil, ErrNotPublished
+	}
+
+	return p.doc, nil
+}
+
+// Publish builds the DID document and publishes it: the key is created by the KMS on the first call,
+// it is added to the mediator the agent is registered with (if any).
+func (p *Publisher) Publish() (*did.Doc, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	verKey, err := p.verificationKey()
+	if err != nil {
+		return nil, err
+	}
+
+	serviceEndpoint, routingKeys, err := route.GetRouterConfig(p.routeSvc, p.serviceEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("fetch router config: %w", err)
+	}
+
+	// the messages of the mediator are forwarded for the key once added to it
+	if len(routingKeys) > 0 && serviceEndpoint != p.routerEndpoint {
+		if err = route.AddKeyToRouter(p.routeSvc, verKey); err != nil {
+			return nil, fmt.Errorf("add key to the router: %w", err)
+		}
+
+		p.routerEndpoint = serviceEndpoint
+	}
+
+	publicKey := did.PublicKey{
+		ID:         p.didID + keyIndex1,
+		Type:       ed25519KeyType,
+		Controller: p.didID,
+		// TODO fix hardcode base58 https://github.com/hyperledger/aries-framework-go/issues/1207
+		Value: base58.Decode(verKey),
+	}
+
+	doc := did.BuildDoc(
+		did.WithPublicKey([]did.PublicKey{publicKey}),
+		did.WithAuthentication([]did.VerificationMethod{{PublicKey: publicKey}}),
+		did.WithService([]did.Service{{
+			ID:              p.didID + didCommServiceIndex,
+			Type:            vdriapi.DIDCommServiceType,
+			RecipientKeys:   []string{verKey},
+			RoutingKeys:     routingKeys,
+			ServiceEndpoint: serviceEndpoint,
+		}}),
+	)
+	doc.ID = p.didID
+
+	src, err := doc.JSONBytes()
+	if err != nil {
+		return nil, fmt.Errorf("marshal DID document: %w", err)
+	}
+
+	p.doc, p.src = doc, src
+
+	return doc, nil
+}
+
+// ServeHTTP serves the published DID document, the document is not built again.
+func (p *Publisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	p.lock.RLock()
+	src := p.src
+	p.lock.RUnlock()
+
+	if src == nil {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(src); err != nil {
+		logger.Errorf("write DID document %s: %s", p.didID, err)
+	}
+}
+
+type keyData struct {
+	VerificationKey string
+}
+
+// verificationKey returns the base58 verification key of the document, created by the KMS on the first call.
+func (p *Publisher) verificationKey() (string, error) {
+	key := fmt.Sprintf(keyDataKey, p.didID)
+
+	src, err := p.store.Get(key)
+	if err == nil {
+		data := &keyData{}
+		if err = json.Unmarshal(src, data); err != nil {
+			return "", fmt.Errorf("unmarshal key data: %w", err)
+		}
+
+		return data.VerificationKey, nil
+	}
+
+	if !errors.Is(err, storage.ErrDataNotFound) {
+		return "", fmt.Errorf("get key data: %w", err)
+	}
+
+	_, sigPubKey, err := p.legacyKMS.CreateKeySet()
+	if err != nil {
+		return "", fmt.Errorf("create key set: %w", err)
+	}
+
+	src, err = json.Marshal(&keyData{VerificationKey: sigPubKey})
+	if err != nil {
+		return "", fmt.Errorf("marshal key data: %w", err)
+	}
+
+	if err = p.store.Put(key, src); err != nil {
+		return "", fmt.Errorf("save key data: %w", err)
+	}
+
+	return sigPubKey, nil
+}
@@ -0,0 +1,249 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/route"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	mockroute "github.com/hyperledger/aries-framework-go/pkg/internal/mock/didcomm/protocol/route"
+	mockprovider "github.com/hyperledger/aries-framework-go/pkg/internal/mock/provider"
+	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms/legacykms"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+)
+
+const (
+	verKey   = "F7BmcAi2imd1CVyfn4A5A4YFZFQ7FjUH7nEVoQKtMTjk"
+	endpoint = "https://agent.example.com"
+)
+
+func newProvider(routeSvc *mockroute.MockRouteSvc) *mockprovider.Provider {
+	return &mockprovider.Provider{
+		ServiceMap:           map[string]interface{}{route.Coordination: routeSvc},
+		KMSValue:             &mockkms.CloseableKMS{CreateSigningKeyValue: verKey},
+		ServiceEndpointValue: endpoint,
+		StorageProviderValue: mockstorage.NewMockStoreProvider(),
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		p, err := New("example.com:8443", newProvider(&mockroute.MockRouteSvc{}))
+		require.NoError(t, err)
+		require.Equal(t, "did:web:example.com%3A8443", p.DID())
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+		_, err := New("", newProvider(&mockroute.MockRouteSvc{}))
+		require.EqualError(t, err, `invalid domain: ""`)
+
+		_, err = New("example.com/alice", newProvider(&mockroute.MockRouteSvc{}))
+		require.EqualError(t, err, `invalid domain: "example.com/alice"`)
+	})
+
+	t.Run("route service error", func(t *testing.T) {
+		_, err := New("example.com", &mockprovider.Provider{ServiceErr: errors.New("test")})
+		require.EqualError(t, err, "test")
+
+		_, err = New("example.com", &mockprovider.Provider{ServiceValue: "route"})
+		require.EqualError(t, err, "cast service to Route Service failed")
+	})
+
+	t.Run("open store error", func(t *testing.T) {
+		provider := newProvider(&mockroute.MockRouteSvc{})
+		provider.StorageProviderValue = &mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("test")}
+
+		_, err := New("example.com", provider)
+		require.EqualError(t, err, "open store: test")
+	})
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	t.Run("without mediator", func(t *testing.T) {
+		p, err := New("example.com", newProvider(&mockroute.MockRouteSvc{}))
+		require.NoError(t, err)
+
+		_, err = p.Document()
+		require.True(t, errors.Is(err, ErrNotPublished))
+
+		doc, err := p.Publish()
+		require.NoError(t, err)
+
+		published, err := p.Document()
+		require.NoError(t, err)
+		require.Equal(t, doc, published)
+		require.Equal(t, "did:web:example.com", doc.ID)
+		require.Len(t, doc.PublicKey, 1)
+		require.Equal(t, "did:web:example.com#key-1", doc.PublicKey[0].ID)
+		require.Equal(t, base58.Decode(verKey), doc.PublicKey[0].Value)
+		require.Len(t, doc.Authentication, 1)
+		require.Len(t, doc.Service, 1)
+		require.Equal(t, vdriapi.DIDCommServiceType, doc.Service[0].Type)
+		require.Equal(t, endpoint, doc.Service[0].ServiceEndpoint)
+		require.Equal(t, []string{verKey}, doc.Service[0].RecipientKeys)
+		require.Empty(t, doc.Service[0].RoutingKeys)
+	})
+
+	t.Run("key is created once", func(t *testing.T) {
+		provider := newProvider(&mockroute.MockRouteSvc{})
+
+		p, err := New("example.com", provider)
+		require.NoError(t, err)
+
+		_, err = p.Publish()
+		require.NoError(t, err)
+
+		provider.KMSValue = &mockkms.CloseableKMS{CreateSigningKeyValue: "other"}
+
+		p, err = New("example.com", provider)
+		require.NoError(t, err)
+
+		doc, err := p.Publish()
+		require.NoError(t, err)
+		require.Equal(t, []string{verKey}, doc.Service[0].RecipientKeys)
+	})
+
+	t.Run("with mediator", func(t *testing.T) {
+		var added []string
+
+		routeSvc := &mockroute.MockRouteSvc{
+			RouterEndpoint: "https://mediator.example.com",
+			RoutingKeys:    []string{"routing-key"},
+			AddKeyFunc: func(recKey string) error {
+				added = append(added, recKey)
+
+				return nil
+			},
+		}
+
+		p, err := New("example.com", newProvider(routeSvc))
+		require.NoError(t, err)
+
+		doc, err := p.Publish()
+		require.NoError(t, err)
+		require.Equal(t, "https://mediator.example.com", doc.Service[0].ServiceEndpoint)
+		require.Equal(t, []string{"routing-key"}, doc.Service[0].RoutingKeys)
+
+		// the key is added to the mediator once
+		_, err = p.Publish()
+		require.NoError(t, err)
+		require.Equal(t, []string{verKey}, added)
+
+		// the agent registered with another mediator
+		routeSvc.RouterEndpoint = "https://mediator2.example.com"
+
+		doc, err = p.Publish()
+		require.NoError(t, err)
+		require.Equal(t, "https://mediator2.example.com", doc.Service[0].ServiceEndpoint)
+		require.Equal(t, []string{verKey, verKey}, added)
+	})
+
+	t.Run("router errors", func(t *testing.T) {
+		p, err := New("example.com", newProvider(&mockroute.MockRouteSvc{ConfigErr: errors.New("test")}))
+		require.NoError(t, err)
+
+		_, err = p.Publish()
+		require.EqualError(t, err, "fetch router config: fetch router config : test")
+
+		p, err = New("example.com", newProvider(&mockroute.MockRouteSvc{
+			RouterEndpoint: "https://mediator.example.com",
+			RoutingKeys:    []string{"routing-key"},
+			AddKeyErr:      errors.New("test"),
+		}))
+		require.NoError(t, err)
+
+		_, err = p.Publish()
+		require.EqualError(t, err, "add key to the router: add key to the router : test")
+	})
+
+	t.Run("key errors", func(t *testing.T) {
+		provider := newProvider(&mockroute.MockRouteSvc{})
+		provider.KMSValue = &mockkms.CloseableKMS{CreateKeyErr: errors.New("test")}
+
+		p, err := New("example.com", provider)
+		require.NoError(t, err)
+
+		_, err = p.Publish()
+		require.EqualError(t, err, "create key set: test")
+
+		store := &mockstorage.MockStore{Store: map[string][]byte{}, ErrGet: errors.New("test")}
+		provider.StorageProviderValue = &mockstorage.MockStoreProvider{Store: store}
+
+		p, err = New("example.com", provider)
+		require.NoError(t, err)
+
+		_, err = p.Publish()
+		require.EqualError(t, err, "get key data: test")
+
+		store.ErrGet = nil
+		store.Store["key_did:web:example.com"] = []byte("key")
+
+		_, err = p.Publish()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal key data")
+
+		delete(store.Store, "key_did:web:example.com")
+		store.ErrPut = errors.New("test")
+		provider.KMSValue = &mockkms.CloseableKMS{CreateSigningKeyValue: verKey}
+
+		p, err = New("example.com", provider)
+		require.NoError(t, err)
+
+		_, err = p.Publish()
+		require.EqualError(t, err, "save key data: test")
+	})
+}
+
+func TestPublisher_ServeHTTP(t *testing.T) {
+	routeSvc := &mockroute.MockRouteSvc{}
+	provider := newProvider(routeSvc)
+
+	p, err := New("example.com", provider)
+	require.NoError(t, err)
+
+	t.Run("not published", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, WellKnownPath, nil))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	_, err = p.Publish()
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		// the published document is served, neither the KMS nor the mediator is used
+		provider.KMSValue = &mockkms.CloseableKMS{CreateKeyErr: errors.New("test")}
+		routeSvc.ConfigErr = errors.New("test")
+
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, WellKnownPath, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		doc, err := did.ParseDocument(rr.Body.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, p.DID(), doc.ID)
+		require.Equal(t, endpoint, doc.Service[0].ServiceEndpoint)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, WellKnownPath, nil))
+
+		require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+}
@@ -0,0 +1,62 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package wallet
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+)
+
+// QueryParams is a query of a verifiable presentation request (W3C VC API, CHAPI), e.g a query by example
+// {"type": "QueryByExample", "credentialQuery": {"example": {"type": "UniversityDegreeCredential"}}}.
+type QueryParams struct {
+	// Type is the query type: QueryByExample, QueryByFrame or DIDAuth.
+	Type string `json:"type"`
+	// CredentialQuery holds the credential queries, a single query or an array of queries.
+	// DIDAuth queries have no credential query.
+	CredentialQuery json.RawMessage `json:"credentialQuery,omitempty"`
+}
+
+// CredentialQuery is a credential query of a QueryByExample or QueryByFrame query.
+type CredentialQuery struct {
+	// Reason explains why the credentials are requested, it is meant to be displayed to the holder.
+	Reason string `json:"reason,omitempty"`
+	// Example is the example of the requested credentials (QueryByExample).
+	Example *Example `json:"example,omitempty"`
+	// Frame is the JSON-LD frame the requested credentials match (QueryByFrame).
+	Frame map[string]interface{} `json:"frame,omitempty"`
+	// TrustedIssuer lists the issuers trusted by the verifier: if some of them are required the credentials
+	// must be issued by one of the required issuers, otherwise the list is informative.
+	TrustedIssuer []TrustedIssuer `json:"trustedIssuer,omitempty"`
+	// Required queries fail if no credential matches them.
+	Required bool `json:"required,omitempty"`
+}
+
+// Example is the example of the credentials requested by a QueryByExample query, the credentials match
+// the example if they have all its contexts and types and if their subject and schemas contain the example ones.
+type Example struct {
+	Context           interface{} `json:"@context,omitempty"`
+	Type              interface{} `json:"type,omitempty"`
+	CredentialSubject interface{} `json:"credentialSubject,omitempty"`
+	CredentialSchema  interface{} `json:"credentialSchema,omitempty"`
+}
+
+// TrustedIssuer is an issuer trusted by the verifier.
+type TrustedIssuer struct {
+	Issuer   string `json:"issuer"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// QueryResult is the result of the queries of a presentation request.
+type QueryResult struct {
+	// Credentials are the stored credentials matching the queries, without duplicates.
+	Credentials []*verifiable.Credential
+	// DIDAuth is true if the request has a DIDAuth query: the presentation answering it must be signed
+	// to prove the control of the holder DID (see presentproof.Client.CreatePresentationForRequest).
+	DIDAuth bool
+}
@@ -0,0 +1,337 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	verifiablestore "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
+)
+
+// Query types of the verifiable presentation requests.
+const (
+	// QueryByExample requests the credentials matching an example.
+	QueryByExample = "QueryByExample"
+	// QueryByFrame requests the credentials matching a JSON-LD frame.
+	QueryByFrame = "QueryByFrame"
+	// DIDAuth requests the holder to prove the control of its DID.
+	DIDAuth = "DIDAuth"
+)
+
+// ErrNoResult is returned when no stored credential matches a required credential query.
+var ErrNoResult = errors.New("no credential matches the query")
+
+// Provider contains dependencies for the wallet and is typically created by using aries.Context().
+type Provider interface {
+	StorageProvider() storage.Provider
+	// JSONLDDocumentLoader returns the JSON-LD document loader shared by the framework, if nil the wallet
+	// creates its own loader caching the contexts in its storage provider.
+	JSONLDDocumentLoader() ld.DocumentLoader
+}
+
+// Wallet executes the queries of the verifiable presentation requests (W3C VC API, CHAPI) over the credentials
+// of the credential store (see verifiable.Store).
+type Wallet struct {
+	store          *verifiablestore.Store
+	documentLoader ld.DocumentLoader
+}
+
+// New returns a new wallet.
+func New(ctx Provider) (*Wallet, error) {
+	store, err := verifiablestore.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("new wallet: %w", err)
+	}
+
+	loader := ctx.JSONLDDocumentLoader()
+	if loader == nil {
+		loader, err = jsonld.NewDocumentLoader(ctx.StorageProvider())
+		if err != nil {
+			return nil, fmt.Errorf("new wallet: %w", err)
+		}
+	}
+
+	return &Wallet{store: store, documentLoader: loader}, nil
+}
+
+// Query returns the stored credentials matching the queries of a presentation request, each credential once.
+// The credentials are matched as stored: the queries by frame return the full credentials, not a selective
+// disclosure of them. ErrNoResult is returned if no credential matches a required credential query.
+func (w *Wallet) Query(queries ...*QueryParams) (*QueryResult, error) {
+	credentials, err := w.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QueryResult{}
+	matched := make([]bool, len(credentials))
+
+	for _, query := range queries {
+		if query.Type == DIDAuth {
+			result.DIDAuth = true
+
+			continue
+		}
+
+		credentialQueries, err := parseCredentialQueries(query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, credentialQuery := range credentialQueries {
+			found := false
+
+			for i, credential := range credentials {
+				ok, err := w.match(query.Type, credentialQuery, credential)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", query.Type, err)
+				}
+
+				if ok {
+					found = true
+					matched[i] = true
+				}
+			}
+
+			if !found && credentialQuery.Required {
+				return nil, fmt.Errorf("%s %q: %w", query.Type, credentialQuery.Reason, ErrNoResult)
+			}
+		}
+	}
+
+	for i, credential := range credentials {
+		if matched[i] {
+			result.Credentials = append(result.Credentials, credential.vc)
+		}
+	}
+
+	return result, nil
+}
+
+func parseCredentialQueries(query *QueryParams) ([]*CredentialQuery, error) {
+	if query.Type != QueryByExample && query.Type != QueryByFrame {
+		return nil, fmt.Errorf("unsupported query type: %s", query.Type)
+	}
+
+	if len(query.CredentialQuery) == 0 {
+		return nil, fmt.Errorf("%s: credential query is mandatory", query.Type)
+	}
+
+	var credentialQueries []*CredentialQuery
+
+	if err := json.Unmarshal(query.CredentialQuery, &credentialQueries); err != nil {
+		single := &CredentialQuery{}
+
+		if err := json.Unmarshal(query.CredentialQuery, single); err != nil {
+			return nil, fmt.Errorf("%s: parse credential query: %w", query.Type, err)
+		}
+
+		credentialQueries = []*CredentialQuery{single}
+	}
+
+	for _, credentialQuery := range credentialQueries {
+		if query.Type == QueryByExample && credentialQuery.Example == nil {
+			return nil, fmt.Errorf("%s: example is mandatory", query.Type)
+		}
+
+		if query.Type == QueryByFrame && credentialQuery.Frame == nil {
+			return nil, fmt.Errorf("%s: frame is mandatory", query.Type)
+		}
+	}
+
+	return credentialQueries, nil
+}
+
+// storedCredential is a stored credential with its JSON representation, which the queries match.
+type storedCredential struct {
+	vc  *verifiable.Credential
+	raw map[string]interface{}
+}
+
+func (w *Wallet) credentials() ([]*storedCredential, error) {
+	records := w.store.GetCredentials()
+	credentials := make([]*storedCredential, 0, len(records))
+
+	for _, record := range records {
+		vc, err := w.store.GetCredential(record.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get credential %s: %w", record.Name, err)
+		}
+
+		src, err := vc.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshal credential %s: %w", record.Name, err)
+		}
+
+		raw := map[string]interface{}{}
+		if err := json.Unmarshal(src, &raw); err != nil {
+			return nil, fmt.Errorf("unmarshal credential %s: %w", record.Name, err)
+		}
+
+		credentials = append(credentials, &storedCredential{vc: vc, raw: raw})
+	}
+
+	return credentials, nil
+}
+
+func (w *Wallet) match(queryType string, query *CredentialQuery, credential *storedCredential) (bool, error) {
+	if !trustedIssuer(query.TrustedIssuer, credential.vc.Issuer.ID) {
+		return false, nil
+	}
+
+	if queryType == QueryByExample {
+		return matchExample(query.Example, credential.raw), nil
+	}
+
+	return w.matchFrame(query.Frame, credential.raw)
+}
+
+func trustedIssuer(issuers []TrustedIssuer, issuer string) bool {
+	restricted := false
+
+	for _, trusted := range issuers {
+		if !trusted.Required {
+			continue
+		}
+
+		if trusted.Issuer == issuer {
+			return true
+		}
+
+		restricted = true
+	}
+
+	return !restricted
+}
+
+// matchExample checks that the credential has the contexts and types of the example and that its subject
+// and schemas contain the example ones.
+func matchExample(example *Example, credential map[string]interface{}) bool {
+	if !containsAll(values(credential["@context"]), values(example.Context)) ||
+		!containsAll(values(credential["type"]), values(example.Type)) {
+		return false
+	}
+
+	if example.CredentialSubject != nil && !contains(credential["credentialSubject"], example.CredentialSubject) {
+		return false
+	}
+
+	if example.CredentialSchema != nil && !contains(credential["credentialSchema"], example.CredentialSchema) {
+		return false
+	}
+
+	return true
+}
+
+// matchFrame checks that framing the credential with the frame yields a node.
+func (w *Wallet) matchFrame(frame, credential map[string]interface{}) (bool, error) {
+	framed, err := jsonld.Default().Frame(credential, frame, w.documentLoader)
+	if err != nil {
+		return false, fmt.Errorf("frame credential: %w", err)
+	}
+
+	if graph, ok := framed["@graph"]; ok {
+		nodes, _ := graph.([]interface{}) // nolint: errcheck
+
+		return len(nodes) > 0, nil
+	}
+
+	for key := range framed {
+		if key != "@context" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// contains checks that the value contains the example: the objects have the fields of the example objects,
+// the arrays have an element containing each example element and the other values are equal. An object or
+// an array contains an example object if one of its elements does.
+func contains(value, example interface{}) bool {
+	switch e := example.(type) {
+	case map[string]interface{}:
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, exampleValue := range e {
+				if !contains(v[key], exampleValue) {
+					return false
+				}
+			}
+
+			return true
+		case []interface{}:
+			for _, element := range v {
+				if contains(element, e) {
+					return true
+				}
+			}
+		}
+
+		return false
+	case []interface{}:
+		for _, exampleElement := range e {
+			if !contains(value, exampleElement) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if elements, ok := value.([]interface{}); ok {
+		for _, element := range elements {
+			if contains(element, example) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return value == example
+}
+
+func containsAll(values, expected []interface{}) bool {
+	for _, e := range expected {
+		found := false
+
+		for _, v := range values {
+			if reflect.DeepEqual(v, e) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// values returns the values of a JSON-LD field which is either a value or an array of values.
+func values(v interface{}) []interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return value
+	default:
+		return []interface{}{value}
+	}
+}
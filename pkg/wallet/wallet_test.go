@@ -0,0 +1,283 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	verifiablestore "github.com/hyperledger/aries-framework-go/pkg/store/verifiable"
+)
+
+const (
+	exampleContextURL = "https://example.com/context/v1"
+	// exampleContext defines the terms of the test credentials, the tests do not load remote contexts
+	exampleContext = `{"@context": {"@vocab": "https://example.com/vocab#"}}`
+
+	universityDegree = `{
+		"@context": ["https://www.w3.org/2018/credentials/v1", "https://example.com/context/v1"],
+		"id": "http://example.edu/credentials/1872",
+		"type": ["VerifiableCredential", "UniversityDegreeCredential"],
+		"issuer": "did:example:university",
+		"issuanceDate": "2010-01-01T19:23:24Z",
+		"credentialSubject": {
+			"id": "did:example:holder",
+			"degree": {"type": "BachelorDegree", "name": "Bachelor of Science and Arts"}
+		},
+		"credentialSchema": {"id": "https://example.com/schemas/degree.json", "type": "JsonSchemaValidator2018"}
+	}`
+
+	driverLicense = `{
+		"@context": ["https://www.w3.org/2018/credentials/v1", "https://example.com/context/v1"],
+		"id": "http://example.gov/credentials/3732",
+		"type": ["VerifiableCredential", "DriverLicenseCredential"],
+		"issuer": {"id": "did:example:dmv", "name": "Department of Motor Vehicles"},
+		"issuanceDate": "2015-05-12T10:00:00Z",
+		"credentialSubject": [{"id": "did:example:holder", "licenseClass": ["B", "C"]}]
+	}`
+)
+
+type provider struct {
+	storageProvider storage.Provider
+	loader          ld.DocumentLoader
+}
+
+func (p *provider) StorageProvider() storage.Provider {
+	return p.storageProvider
+}
+
+func (p *provider) JSONLDDocumentLoader() ld.DocumentLoader {
+	return p.loader
+}
+
+func newWallet(t *testing.T) *Wallet {
+	t.Helper()
+
+	loader, err := jsonld.NewDocumentLoader(mem.NewProvider(), jsonld.WithRemoteDocumentLoader(nil))
+	require.NoError(t, err)
+	require.NoError(t, loader.AddContext(exampleContextURL, []byte(exampleContext)))
+
+	p := &provider{storageProvider: mem.NewProvider(), loader: loader}
+
+	store, err := verifiablestore.New(p)
+	require.NoError(t, err)
+
+	for name, src := range map[string]string{"degree": universityDegree, "license": driverLicense} {
+		vc, _, err := verifiable.NewCredential([]byte(src), verifiable.WithNoCustomSchemaCheck(),
+			verifiable.WithJSONLDDocumentLoader(loader))
+		require.NoError(t, err)
+		require.NoError(t, store.SaveCredential(name, vc))
+	}
+
+	wallet, err := New(p)
+	require.NoError(t, err)
+
+	return wallet
+}
+
+func query(t *testing.T, queryType, credentialQuery string) *QueryParams {
+	t.Helper()
+
+	params := &QueryParams{}
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "`+queryType+`", "credentialQuery": `+credentialQuery+`}`),
+		params))
+
+	return params
+}
+
+func ids(result *QueryResult) []string {
+	var credentialIDs []string
+
+	for _, vc := range result.Credentials {
+		credentialIDs = append(credentialIDs, vc.ID)
+	}
+
+	return credentialIDs
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Document loader of the wallet", func(t *testing.T) {
+		wallet, err := New(&provider{storageProvider: mem.NewProvider()})
+		require.NoError(t, err)
+		require.NotNil(t, wallet.documentLoader)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		_, err := New(&provider{storageProvider: &mockstorage.MockStoreProvider{
+			ErrOpenStoreHandle: errors.New("open store error"),
+		}})
+		require.EqualError(t, err, "new wallet: failed to open vc store: open store error")
+	})
+}
+
+func TestWallet_QueryByExample(t *testing.T) {
+	wallet := newWallet(t)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{{
+		name:     "By type",
+		query:    `{"example": {"type": "UniversityDegreeCredential"}}`,
+		expected: []string{"http://example.edu/credentials/1872"},
+	}, {
+		name:     "By contexts and types",
+		query:    `{"example": {"@context": ["https://www.w3.org/2018/credentials/v1"], "type": ["VerifiableCredential"]}}`,
+		expected: []string{"http://example.gov/credentials/3732", "http://example.edu/credentials/1872"},
+	}, {
+		name:     "Unknown context",
+		query:    `{"example": {"@context": "https://www.w3.org/2018/credentials/examples/v1"}}`,
+		expected: nil,
+	}, {
+		name:     "By subject",
+		query:    `{"example": {"credentialSubject": {"degree": {"type": "BachelorDegree"}}}}`,
+		expected: []string{"http://example.edu/credentials/1872"},
+	}, {
+		name:     "By subject of an array of subjects",
+		query:    `{"example": {"credentialSubject": {"id": "did:example:holder", "licenseClass": "C"}}}`,
+		expected: []string{"http://example.gov/credentials/3732"},
+	}, {
+		name:     "By subject values",
+		query:    `{"example": {"credentialSubject": {"licenseClass": ["C", "A"]}}}`,
+		expected: nil,
+	}, {
+		name:     "By schema",
+		query:    `{"example": {"credentialSchema": {"id": "https://example.com/schemas/degree.json"}}}`,
+		expected: []string{"http://example.edu/credentials/1872"},
+	}, {
+		name: "By required trusted issuer",
+		query: `{"example": {"type": "VerifiableCredential"},
+			"trustedIssuer": [{"issuer": "did:example:dmv", "required": true}, {"issuer": "did:example:university"}]}`,
+		expected: []string{"http://example.gov/credentials/3732"},
+	}, {
+		name:     "Informative trusted issuer",
+		query:    `{"example": {"type": "UniversityDegreeCredential"}, "trustedIssuer": [{"issuer": "did:example:dmv"}]}`,
+		expected: []string{"http://example.edu/credentials/1872"},
+	}, {
+		name: "Several credential queries",
+		query: `[{"example": {"type": "UniversityDegreeCredential"}},
+			{"example": {"type": "DriverLicenseCredential"}}, {"example": {"type": "VerifiableCredential"}}]`,
+		expected: []string{"http://example.gov/credentials/3732", "http://example.edu/credentials/1872"},
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := wallet.Query(query(t, QueryByExample, tc.query))
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, ids(result))
+			require.False(t, result.DIDAuth)
+		})
+	}
+
+	t.Run("Required query without result", func(t *testing.T) {
+		_, err := wallet.Query(query(t, QueryByExample,
+			`{"reason": "proof of age", "example": {"type": "PassportCredential"}, "required": true}`))
+		require.True(t, errors.Is(err, ErrNoResult))
+		require.EqualError(t, err, `QueryByExample "proof of age": no credential matches the query`)
+	})
+
+	t.Run("Invalid queries", func(t *testing.T) {
+		_, err := wallet.Query(query(t, QueryByExample, `{"reason": "no example"}`))
+		require.EqualError(t, err, "QueryByExample: example is mandatory")
+
+		_, err = wallet.Query(query(t, QueryByExample, `"example"`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "QueryByExample: parse credential query")
+
+		_, err = wallet.Query(&QueryParams{Type: QueryByExample})
+		require.EqualError(t, err, "QueryByExample: credential query is mandatory")
+
+		_, err = wallet.Query(&QueryParams{Type: "PresentationDefinition"})
+		require.EqualError(t, err, "unsupported query type: PresentationDefinition")
+	})
+}
+
+func TestWallet_QueryByFrame(t *testing.T) {
+	wallet := newWallet(t)
+
+	t.Run("By type", func(t *testing.T) {
+		result, err := wallet.Query(query(t, QueryByFrame, `{"reason": "degree", "frame": {
+			"@context": ["https://www.w3.org/2018/credentials/v1", "https://example.com/context/v1"],
+			"type": "UniversityDegreeCredential",
+			"credentialSubject": {"@explicit": true, "degree": {}}
+		}}`))
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://example.edu/credentials/1872"}, ids(result))
+	})
+
+	t.Run("By ID", func(t *testing.T) {
+		result, err := wallet.Query(query(t, QueryByFrame, `{"frame": {
+			"@context": ["https://www.w3.org/2018/credentials/v1", "https://example.com/context/v1"],
+			"id": "http://example.gov/credentials/3732"
+		}}`))
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://example.gov/credentials/3732"}, ids(result))
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		result, err := wallet.Query(query(t, QueryByFrame, `{"frame": {
+			"@context": ["https://www.w3.org/2018/credentials/v1", "https://example.com/context/v1"],
+			"type": "PassportCredential"
+		}}`))
+		require.NoError(t, err)
+		require.Empty(t, result.Credentials)
+	})
+
+	t.Run("Frame error", func(t *testing.T) {
+		_, err := wallet.Query(query(t, QueryByFrame, `{"frame": {"@context": "https://example.com/unknown/v1"}}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "QueryByFrame: frame credential")
+	})
+
+	t.Run("No frame", func(t *testing.T) {
+		_, err := wallet.Query(query(t, QueryByFrame, `{"reason": "no frame"}`))
+		require.EqualError(t, err, "QueryByFrame: frame is mandatory")
+	})
+}
+
+func TestWallet_DIDAuth(t *testing.T) {
+	wallet := newWallet(t)
+
+	result, err := wallet.Query(&QueryParams{Type: DIDAuth})
+	require.NoError(t, err)
+	require.True(t, result.DIDAuth)
+	require.Empty(t, result.Credentials)
+
+	result, err = wallet.Query(&QueryParams{Type: DIDAuth},
+		query(t, QueryByExample, `{"example": {"type": "DriverLicenseCredential"}}`))
+	require.NoError(t, err)
+	require.True(t, result.DIDAuth)
+	require.Equal(t, []string{"http://example.gov/credentials/3732"}, ids(result))
+}
+
+func TestWallet_QueryStoreError(t *testing.T) {
+	wallet := newWallet(t)
+
+	store := mockstorage.NewMockStoreProvider()
+	s, err := store.OpenStore(verifiablestore.NameSpace)
+	require.NoError(t, err)
+	require.NoError(t, s.Put("vcname_degree", []byte("http://example.edu/credentials/1872")))
+
+	wallet.store, err = verifiablestore.New(&provider{storageProvider: store})
+	require.NoError(t, err)
+
+	_, err = wallet.Query(&QueryParams{Type: DIDAuth})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "get credential degree")
+}